@@ -87,7 +87,8 @@ func (c *AlicloudAccessConfig) Client() (*ClientWrapper, error) {
 
 func (c *AlicloudAccessConfig) Prepare(ctx *interpolate.Context) []error {
 	var errs []error
-	if err := c.Config(); err != nil {
+	// The keys may be missing when the configuration is only validated
+	if err := c.Config(); err != nil && (ctx == nil || !ctx.Validate) {
 		errs = append(errs, err)
 	}
 
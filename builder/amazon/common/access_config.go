@@ -386,10 +386,13 @@ func (c *AccessConfig) Prepare(ctx *interpolate.Context) []error {
 					" the access_key or secret_key."))
 		}
 		// Go ahead and grab those credentials from Vault now, so we can set
-		// the keys and token now.
-		err := c.GetCredsFromVault()
-		if err != nil {
-			errs = append(errs, err)
+		// the keys and token now. Vault isn't reached when the configuration
+		// is only validated.
+		if ctx == nil || !ctx.Validate {
+			err := c.GetCredsFromVault()
+			if err != nil {
+				errs = append(errs, err)
+			}
 		}
 	}
 
@@ -0,0 +1,57 @@
+//go:generate struct-markdown
+//go:generate mapstructure-to-hcl2 -type AMIRetentionConfig
+
+package common
+
+import (
+	"fmt"
+)
+
+// AMIRetentionConfig deregisters the older AMIs of a series of builds once a
+// new one is built, in each region the AMI is copied to. For example, to keep
+// the last 3 AMIs named like `ubuntu-20.04-*` and delete their snapshots:
+//
+// In JSON:
+// ```json
+// "retention": {
+//     "name_pattern": "ubuntu-20.04-*",
+//     "keep": 3,
+//     "delete_snapshots": true
+// }
+// ```
+// In HCL2:
+// ```hcl
+// retention {
+//     name_pattern = "ubuntu-20.04-*"
+//     keep = 3
+//     delete_snapshots = true
+// }
+// ```
+type AMIRetentionConfig struct {
+	// The pattern of the names of the AMIs of the series, owned by the
+	// account, where `*` matches any characters, like `ubuntu-20.04-*`. The
+	// AMI built must match it.
+	NamePattern string `mapstructure:"name_pattern" required:"true"`
+	// The number of AMIs of the series to keep, including the AMI built. The
+	// most recently created are kept. Defaults to `1`, only keeping the AMI
+	// built.
+	Keep int `mapstructure:"keep" required:"false"`
+	// Delete the snapshots of the deregistered AMIs. Defaults to `false`.
+	DeleteSnapshots bool `mapstructure:"delete_snapshots" required:"false"`
+}
+
+func (c *AMIRetentionConfig) Prepare() []error {
+	var errs []error
+
+	if c.NamePattern == "" {
+		errs = append(errs, fmt.Errorf("retention.name_pattern must be set"))
+	}
+	if c.Keep == 0 {
+		c.Keep = 1
+	}
+	if c.Keep < 0 {
+		errs = append(errs, fmt.Errorf("retention.keep must be positive"))
+	}
+
+	return errs
+}
@@ -0,0 +1,90 @@
+package common
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/hashicorp/packer/packer"
+	"github.com/hashicorp/packer/packer-plugin-sdk/multistep"
+)
+
+// The types of the resources recorded by the steps, see packer.Resource.
+const (
+	resourceInstance      = "instance"
+	resourceSecurityGroup = "security-group"
+	resourceKeyPair       = "key-pair"
+	resourceTemplate      = "launch-template"
+)
+
+// recordResource records a resource created in the region of the session of
+// the build, for `packer destroy` to destroy it if the build crashes. It's
+// not recorded when the state has no session.
+func recordResource(state multistep.StateBag, typ, id string) packer.Resource {
+	r := packer.Resource{
+		Provider: "amazon",
+		Type:     typ,
+		ID:       id,
+	}
+	if sess, ok := state.GetOk("awsSession"); ok {
+		r.Attributes = map[string]string{
+			"region": aws.StringValue(sess.(*session.Session).Config.Region),
+		}
+		packer.RecordResource(r)
+	}
+	return r
+}
+
+// DestroyResource destroys a resource recorded by the steps of the Amazon
+// builders, with the credentials of the environment. Resources that don't
+// exist anymore are ignored.
+func DestroyResource(ctx context.Context, r packer.Resource) error {
+	sess, err := session.NewSessionWithOptions(session.Options{
+		Config: aws.Config{
+			Region: aws.String(r.Attributes["region"]),
+		},
+		SharedConfigState: session.SharedConfigEnable,
+	})
+	if err != nil {
+		return err
+	}
+	ec2conn := ec2.New(sess)
+
+	switch r.Type {
+	case resourceInstance:
+		input := &ec2.TerminateInstancesInput{InstanceIds: []*string{aws.String(r.ID)}}
+		if _, err := ec2conn.TerminateInstancesWithContext(ctx, input); err != nil {
+			return ignoreNotFound(err, "InvalidInstanceID.NotFound")
+		}
+		return ec2conn.WaitUntilInstanceTerminatedWithContext(ctx, &ec2.DescribeInstancesInput{
+			InstanceIds: []*string{aws.String(r.ID)},
+		})
+	case resourceSecurityGroup:
+		_, err := ec2conn.DeleteSecurityGroupWithContext(ctx, &ec2.DeleteSecurityGroupInput{
+			GroupId: aws.String(r.ID),
+		})
+		return ignoreNotFound(err, "InvalidGroup.NotFound")
+	case resourceKeyPair:
+		_, err := ec2conn.DeleteKeyPairWithContext(ctx, &ec2.DeleteKeyPairInput{
+			KeyName: aws.String(r.ID),
+		})
+		return err
+	case resourceTemplate:
+		_, err := ec2conn.DeleteLaunchTemplateWithContext(ctx, &ec2.DeleteLaunchTemplateInput{
+			LaunchTemplateName: aws.String(r.ID),
+		})
+		return ignoreNotFound(err, "InvalidLaunchTemplateName.NotFoundException")
+	default:
+		return fmt.Errorf("unknown type of resource %q", r.Type)
+	}
+}
+
+func ignoreNotFound(err error, code string) error {
+	if awsErr, ok := err.(awserr.Error); ok && awsErr.Code() == code {
+		return nil
+	}
+	return err
+}
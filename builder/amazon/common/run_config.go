@@ -1,5 +1,5 @@
 //go:generate struct-markdown
-//go:generate mapstructure-to-hcl2 -type AmiFilterOptions,SecurityGroupFilterOptions,SubnetFilterOptions,VpcFilterOptions,PolicyDocument,Statement
+//go:generate mapstructure-to-hcl2 -type AmiFilterOptions,SecurityGroupFilterOptions,SubnetFilterOptions,VpcFilterOptions,PolicyDocument,Statement,MetadataOptions
 
 package common
 
@@ -11,6 +11,8 @@ import (
 	"strings"
 	"time"
 
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
 	"github.com/hashicorp/packer/helper/communicator"
 	"github.com/hashicorp/packer/packer-plugin-sdk/template/config"
 	"github.com/hashicorp/packer/packer-plugin-sdk/template/interpolate"
@@ -67,6 +69,91 @@ type SecurityGroupFilterOptions struct {
 	config.NameValueFilter `mapstructure:",squash"`
 }
 
+// Configures the metadata service of the instance Packer launches. For
+// example, to only allow the instance metadata service version 2 (IMDSv2):
+//
+// In JSON:
+// ```json
+// "metadata_options": {
+//   "http_tokens": "required"
+// }
+// ```
+// In HCL2:
+// ```hcl
+// metadata_options {
+//   http_tokens = "required"
+// }
+// ```
+type MetadataOptions struct {
+	// Whether the metadata service is available, `enabled` or `disabled`.
+	// Defaults to `enabled`.
+	HttpEndpoint string `mapstructure:"http_endpoint" required:"false"`
+	// Whether requests to the metadata service must hold a session token,
+	// `optional` or `required`. Set to `required` to only allow the version 2
+	// of the metadata service (IMDSv2). Defaults to `optional`.
+	HttpTokens string `mapstructure:"http_tokens" required:"false"`
+	// The number of network hops the responses of the metadata service can
+	// travel, from 1 to 64. Containers of the instance need 2 with IMDSv2.
+	// Defaults to `1`.
+	HttpPutResponseHopLimit int64 `mapstructure:"http_put_response_hop_limit" required:"false"`
+}
+
+func (o *MetadataOptions) Prepare() []error {
+	var errs []error
+	switch o.HttpEndpoint {
+	case "", "enabled", "disabled":
+	default:
+		errs = append(errs, fmt.Errorf("metadata_options.http_endpoint must be 'enabled' or 'disabled'"))
+	}
+	switch o.HttpTokens {
+	case "", "optional", "required":
+	default:
+		errs = append(errs, fmt.Errorf("metadata_options.http_tokens must be 'optional' or 'required'"))
+	}
+	if o.HttpPutResponseHopLimit < 0 || o.HttpPutResponseHopLimit > 64 {
+		errs = append(errs, fmt.Errorf("metadata_options.http_put_response_hop_limit must be between 1 and 64"))
+	}
+	return errs
+}
+
+// Empty returns whether the metadata service keeps the defaults of EC2.
+func (o *MetadataOptions) Empty() bool {
+	return o.HttpEndpoint == "" && o.HttpTokens == "" && o.HttpPutResponseHopLimit == 0
+}
+
+// InstanceMetadataOptionsRequest returns the options for RunInstances, or
+// nil when the defaults are kept.
+func (o *MetadataOptions) InstanceMetadataOptionsRequest() *ec2.InstanceMetadataOptionsRequest {
+	if o.Empty() {
+		return nil
+	}
+	req := &ec2.InstanceMetadataOptionsRequest{}
+	if o.HttpEndpoint != "" {
+		req.HttpEndpoint = aws.String(o.HttpEndpoint)
+	}
+	if o.HttpTokens != "" {
+		req.HttpTokens = aws.String(o.HttpTokens)
+	}
+	if o.HttpPutResponseHopLimit != 0 {
+		req.HttpPutResponseHopLimit = aws.Int64(o.HttpPutResponseHopLimit)
+	}
+	return req
+}
+
+// LaunchTemplateMetadataOptionsRequest returns the options for launch
+// templates, or nil when the defaults are kept.
+func (o *MetadataOptions) LaunchTemplateMetadataOptionsRequest() *ec2.LaunchTemplateInstanceMetadataOptionsRequest {
+	req := o.InstanceMetadataOptionsRequest()
+	if req == nil {
+		return nil
+	}
+	return &ec2.LaunchTemplateInstanceMetadataOptionsRequest{
+		HttpEndpoint:            req.HttpEndpoint,
+		HttpPutResponseHopLimit: req.HttpPutResponseHopLimit,
+		HttpTokens:              req.HttpTokens,
+	}
+}
+
 // RunConfig contains configuration for running an instance from a source
 // AMI and details on how to access that launched image.
 type RunConfig struct {
@@ -163,6 +250,15 @@ type RunConfig struct {
 	// shutdown in case Packer exits ungracefully. Possible values are stop and
 	// terminate. Defaults to stop.
 	InstanceInitiatedShutdownBehavior string `mapstructure:"shutdown_behavior" required:"false"`
+	// Configures the metadata service of the instance, for example to
+	// require IMDSv2. See the [Metadata Options](#metadata-options) documentation
+	// for fields.
+	Metadata MetadataOptions `mapstructure:"metadata_options" required:"false"`
+	// Tag the instance, its volumes and its network interfaces when launching
+	// it in the China and GovCloud regions as well, where Packer tags them once
+	// launched otherwise. This is needed when policies deny launching untagged
+	// resources. Defaults to `false`.
+	ForceTagOnCreate bool `mapstructure:"force_tag_on_create" required:"false"`
 	// The EC2 instance type to use while building the
 	// AMI, such as t2.small.
 	InstanceType string `mapstructure:"instance_type" required:"true"`
@@ -611,6 +707,8 @@ func (c *RunConfig) Prepare(ctx *interpolate.Context) []error {
 		}
 	}
 
+	errs = append(errs, c.Metadata.Prepare()...)
+
 	if c.InstanceInitiatedShutdownBehavior == "" {
 		c.InstanceInitiatedShutdownBehavior = "stop"
 	} else if !reShutdownBehavior.MatchString(c.InstanceInitiatedShutdownBehavior) {
@@ -252,3 +252,17 @@ func TestRunConfigPrepare_TenancyGood(t *testing.T) {
 		}
 	}
 }
+
+func TestRunConfigPrepare_MetadataOptions(t *testing.T) {
+	c := testConfig()
+	c.Metadata = MetadataOptions{HttpTokens: "required", HttpPutResponseHopLimit: 2}
+	if err := c.Prepare(nil); len(err) != 0 {
+		t.Fatalf("Should not error with valid metadata options: %v", err)
+	}
+
+	c = testConfig()
+	c.Metadata = MetadataOptions{HttpEndpoint: "off", HttpTokens: "v2", HttpPutResponseHopLimit: 65}
+	if err := c.Prepare(nil); len(err) != 3 {
+		t.Fatalf("Should error with invalid metadata options: %v", err)
+	}
+}
@@ -101,7 +101,7 @@ func (w *AWSPollingConfig) WaitUntilAMIAvailable(ctx aws.Context, conn ec2iface.
 	return err
 }
 
-func (w *AWSPollingConfig) WaitUntilInstanceRunning(ctx aws.Context, conn *ec2.EC2, instanceId string) error {
+func (w *AWSPollingConfig) WaitUntilInstanceRunning(ctx aws.Context, conn ec2iface.EC2API, instanceId string) error {
 
 	instanceInput := ec2.DescribeInstancesInput{
 		InstanceIds: []*string{&instanceId},
@@ -114,7 +114,7 @@ func (w *AWSPollingConfig) WaitUntilInstanceRunning(ctx aws.Context, conn *ec2.E
 	return err
 }
 
-func (w *AWSPollingConfig) WaitUntilInstanceTerminated(ctx aws.Context, conn *ec2.EC2, instanceId string) error {
+func (w *AWSPollingConfig) WaitUntilInstanceTerminated(ctx aws.Context, conn ec2iface.EC2API, instanceId string) error {
 	instanceInput := ec2.DescribeInstancesInput{
 		InstanceIds: []*string{&instanceId},
 	}
@@ -189,7 +189,10 @@ func (w *AWSPollingConfig) WaitUntilVolumeDetached(ctx aws.Context, conn *ec2.EC
 	return err
 }
 
-func (w *AWSPollingConfig) WaitUntilImageImported(ctx aws.Context, conn *ec2.EC2, taskID string) error {
+// WaitUntilImageImported waits for the import task. The options are applied
+// after the ones of the polling configuration, like to watch the progress of
+// the task.
+func (w *AWSPollingConfig) WaitUntilImageImported(ctx aws.Context, conn *ec2.EC2, taskID string, opts ...request.WaiterOption) error {
 	importInput := ec2.DescribeImportImageTasksInput{
 		ImportTaskIds: []*string{&taskID},
 	}
@@ -197,7 +200,7 @@ func (w *AWSPollingConfig) WaitUntilImageImported(ctx aws.Context, conn *ec2.EC2
 	err := WaitForImageToBeImported(conn,
 		ctx,
 		&importInput,
-		w.getWaiterOptions()...)
+		append(w.getWaiterOptions(), opts...)...)
 	return err
 }
 
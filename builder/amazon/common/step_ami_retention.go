@@ -0,0 +1,121 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/hashicorp/packer/packer"
+	"github.com/hashicorp/packer/packer-plugin-sdk/multistep"
+)
+
+// StepAMIRetention deregisters the AMIs of the series of the AMIs built that
+// are beyond the number to keep, in each region. Failing to deregister them
+// doesn't fail the build, as the AMIs are built already.
+type StepAMIRetention struct {
+	Retention *AMIRetentionConfig
+}
+
+func (s *StepAMIRetention) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	if s.Retention == nil {
+		return multistep.ActionContinue
+	}
+
+	session := state.Get("awsSession").(*session.Session)
+	ui := state.Get("ui").(packer.Ui)
+	amis := state.Get("amis").(map[string]string)
+
+	for region, ami := range amis {
+		regionConn := ec2.New(session.Copy(&aws.Config{
+			Region: aws.String(region),
+		}))
+		resp, err := regionConn.DescribeImages(&ec2.DescribeImagesInput{
+			Owners: aws.StringSlice([]string{"self"}),
+			Filters: []*ec2.Filter{{
+				Name:   aws.String("name"),
+				Values: aws.StringSlice([]string{s.Retention.NamePattern}),
+			}},
+		})
+		if err != nil {
+			ui.Error(fmt.Sprintf("Error describing the AMIs named %s in %s, not deregistering them: %s",
+				s.Retention.NamePattern, region, err))
+			continue
+		}
+
+		expired, ok := expiredImages(resp.Images, ami, s.Retention.Keep)
+		if !ok {
+			ui.Error(fmt.Sprintf("AMI %s isn't named like %s, not deregistering the AMIs named %s in %s",
+				ami, s.Retention.NamePattern, s.Retention.NamePattern, region))
+			continue
+		}
+
+		for _, image := range expired {
+			s.deregister(ui, regionConn, image)
+		}
+	}
+
+	return multistep.ActionContinue
+}
+
+func (s *StepAMIRetention) deregister(ui packer.Ui, conn *ec2.EC2, image *ec2.Image) {
+	_, err := conn.DeregisterImage(&ec2.DeregisterImageInput{
+		ImageId: image.ImageId,
+	})
+	if err != nil {
+		ui.Error(fmt.Sprintf("Error deregistering AMI %s: %s", aws.StringValue(image.ImageId), err))
+		return
+	}
+	ui.Say(fmt.Sprintf("Deregistered AMI %s, id: %s", aws.StringValue(image.Name), aws.StringValue(image.ImageId)))
+
+	if !s.Retention.DeleteSnapshots {
+		return
+	}
+	for _, b := range image.BlockDeviceMappings {
+		if b.Ebs == nil || aws.StringValue(b.Ebs.SnapshotId) == "" {
+			continue
+		}
+		_, err := conn.DeleteSnapshot(&ec2.DeleteSnapshotInput{
+			SnapshotId: b.Ebs.SnapshotId,
+		})
+		if err != nil {
+			ui.Error(fmt.Sprintf("Error deleting snapshot %s: %s", aws.StringValue(b.Ebs.SnapshotId), err))
+			continue
+		}
+		ui.Say(fmt.Sprintf("Deleted snapshot: %s", aws.StringValue(b.Ebs.SnapshotId)))
+	}
+}
+
+func (s *StepAMIRetention) Cleanup(state multistep.StateBag) {
+	// No cleanup...
+}
+
+// expiredImages returns the images beyond the most recent ones to keep, the
+// image built being kept whatever its creation date. It returns false when
+// the image built isn't among the images.
+func expiredImages(images []*ec2.Image, built string, keep int) ([]*ec2.Image, bool) {
+	var others []*ec2.Image
+	found := false
+	for _, image := range images {
+		if aws.StringValue(image.ImageId) == built {
+			found = true
+			continue
+		}
+		others = append(others, image)
+	}
+	if !found {
+		return nil, false
+	}
+
+	// The creation dates are ISO 8601 timestamps in UTC, which sort as
+	// strings
+	sort.SliceStable(others, func(i, j int) bool {
+		return aws.StringValue(others[i].CreationDate) > aws.StringValue(others[j].CreationDate)
+	})
+	if keep-1 >= len(others) {
+		return nil, true
+	}
+	return others[keep-1:], true
+}
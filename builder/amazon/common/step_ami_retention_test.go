@@ -0,0 +1,66 @@
+package common
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+func testRetentionImage(id string, created string) *ec2.Image {
+	return &ec2.Image{ImageId: aws.String(id), CreationDate: aws.String(created)}
+}
+
+func TestExpiredImages(t *testing.T) {
+	images := []*ec2.Image{
+		testRetentionImage("ami-2", "2020-12-02T10:00:00.000Z"),
+		testRetentionImage("ami-4", "2020-12-04T10:00:00.000Z"),
+		testRetentionImage("ami-1", "2020-12-01T10:00:00.000Z"),
+		testRetentionImage("ami-3", "2020-12-03T10:00:00.000Z"),
+		// The AMI built might not be the most recently created one
+		testRetentionImage("ami-built", "2020-11-01T10:00:00.000Z"),
+	}
+
+	cases := []struct {
+		keep     int
+		expected []string
+	}{
+		{1, []string{"ami-4", "ami-3", "ami-2", "ami-1"}},
+		{3, []string{"ami-2", "ami-1"}},
+		{5, nil},
+		{10, nil},
+	}
+	for _, tc := range cases {
+		expired, ok := expiredImages(images, "ami-built", tc.keep)
+		if !ok {
+			t.Fatalf("keep %d: the AMI built should be found", tc.keep)
+		}
+		var ids []string
+		for _, image := range expired {
+			ids = append(ids, aws.StringValue(image.ImageId))
+		}
+		if !reflect.DeepEqual(ids, tc.expected) {
+			t.Fatalf("keep %d: bad: %#v", tc.keep, ids)
+		}
+	}
+
+	if _, ok := expiredImages(images, "ami-other", 1); ok {
+		t.Fatal("an AMI not matching the pattern should not be found")
+	}
+}
+
+func TestAMIRetentionConfigPrepare(t *testing.T) {
+	c := &AMIRetentionConfig{NamePattern: "foo-*"}
+	if errs := c.Prepare(); len(errs) > 0 {
+		t.Fatalf("should not have error: %s", errs)
+	}
+	if c.Keep != 1 {
+		t.Fatalf("bad keep: %d", c.Keep)
+	}
+
+	c = &AMIRetentionConfig{NamePattern: "foo-*", Keep: -1}
+	if errs := c.Prepare(); len(errs) != 1 {
+		t.Fatalf("bad: %s", errs)
+	}
+}
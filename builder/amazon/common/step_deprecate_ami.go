@@ -0,0 +1,102 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/hashicorp/packer/packer"
+	"github.com/hashicorp/packer/packer-plugin-sdk/multistep"
+)
+
+// ParseDeprecateAt parses the deprecation time of an AMI, either an RFC 3339
+// timestamp or a duration from now.
+func ParseDeprecateAt(deprecateAt string, now time.Time) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, deprecateAt); err == nil {
+		return t, nil
+	}
+	d, err := time.ParseDuration(deprecateAt)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("deprecate_at must be an RFC 3339 timestamp, "+
+			"like 2021-12-31T23:59:00Z, or a duration, like 4380h: %q", deprecateAt)
+	}
+	if d <= 0 {
+		return time.Time{}, fmt.Errorf("deprecate_at must be a positive duration: %q", deprecateAt)
+	}
+	return now.Add(d), nil
+}
+
+// The EnableImageDeprecation action is more recent than the AWS SDK, these are
+// its parameters in the same form as the ones of the SDK.
+type enableImageDeprecationInput struct {
+	_ struct{} `type:"structure"`
+
+	DeprecateAt *time.Time `type:"timestamp" required:"true"`
+	ImageId     *string    `type:"string" required:"true"`
+}
+
+type enableImageDeprecationOutput struct {
+	_ struct{} `type:"structure"`
+
+	Return *bool `locationName:"return" type:"boolean"`
+}
+
+// enableImageDeprecation sets the time the image is deprecated at.
+func enableImageDeprecation(conn *ec2.EC2, imageId string, deprecateAt time.Time) error {
+	op := &request.Operation{
+		Name:       "EnableImageDeprecation",
+		HTTPMethod: "POST",
+		HTTPPath:   "/",
+	}
+	input := &enableImageDeprecationInput{
+		DeprecateAt: aws.Time(deprecateAt.UTC()),
+		ImageId:     aws.String(imageId),
+	}
+	return conn.NewRequest(op, input, &enableImageDeprecationOutput{}).Send()
+}
+
+// StepDeprecateAMI sets the deprecation time of the AMIs built, in each
+// region.
+type StepDeprecateAMI struct {
+	DeprecateAt string
+}
+
+func (s *StepDeprecateAMI) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	if s.DeprecateAt == "" {
+		return multistep.ActionContinue
+	}
+
+	session := state.Get("awsSession").(*session.Session)
+	ui := state.Get("ui").(packer.Ui)
+	amis := state.Get("amis").(map[string]string)
+
+	deprecateAt, err := ParseDeprecateAt(s.DeprecateAt, time.Now())
+	if err != nil {
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+
+	for region, ami := range amis {
+		ui.Say(fmt.Sprintf("Deprecating AMI %s at %s", ami, deprecateAt.UTC().Format(time.RFC3339)))
+		regionConn := ec2.New(session.Copy(&aws.Config{
+			Region: aws.String(region),
+		}))
+		if err := enableImageDeprecation(regionConn, ami, deprecateAt); err != nil {
+			err := fmt.Errorf("Error deprecating AMI %s: %s", ami, err)
+			state.Put("error", err)
+			ui.Error(err.Error())
+			return multistep.ActionHalt
+		}
+	}
+
+	return multistep.ActionContinue
+}
+
+func (s *StepDeprecateAMI) Cleanup(state multistep.StateBag) {
+	// No cleanup...
+}
@@ -0,0 +1,73 @@
+package common
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+func TestParseDeprecateAt(t *testing.T) {
+	now := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	at, err := ParseDeprecateAt("2021-12-31T23:59:00Z", now)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if !at.Equal(time.Date(2021, 12, 31, 23, 59, 0, 0, time.UTC)) {
+		t.Fatalf("bad: %s", at)
+	}
+
+	at, err = ParseDeprecateAt("48h", now)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if !at.Equal(time.Date(2021, 1, 3, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("bad: %s", at)
+	}
+
+	for _, bad := range []string{"tomorrow", "2021-12-31", "-48h", "0s"} {
+		if _, err := ParseDeprecateAt(bad, now); err == nil {
+			t.Fatalf("%s: should have error", bad)
+		}
+	}
+}
+
+func TestEnableImageDeprecation(t *testing.T) {
+	var form map[string][]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		form = r.PostForm
+		w.Write([]byte(`<EnableImageDeprecationResponse xmlns="http://ec2.amazonaws.com/doc/2016-11-15/">
+    <requestId>59dbff89-35bd-4eac-99ed-be587EXAMPLE</requestId>
+    <return>true</return>
+</EnableImageDeprecationResponse>`))
+	}))
+	defer server.Close()
+
+	sess := session.Must(session.NewSession(&aws.Config{
+		Credentials: credentials.NewStaticCredentials("id", "secret", ""),
+		Endpoint:    aws.String(server.URL),
+		Region:      aws.String("us-east-1"),
+	}))
+	at := time.Date(2021, 12, 31, 23, 59, 0, 0, time.UTC)
+	if err := enableImageDeprecation(ec2.New(sess), "ami-123", at); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	expected := map[string]string{
+		"Action":      "EnableImageDeprecation",
+		"ImageId":     "ami-123",
+		"DeprecateAt": "2021-12-31T23:59:00Z",
+	}
+	for k, v := range expected {
+		if len(form[k]) != 1 || form[k][0] != v {
+			t.Fatalf("bad %s: %#v", k, form[k])
+		}
+	}
+}
@@ -20,6 +20,7 @@ type StepKeyPair struct {
 	DebugKeyPath string
 
 	doCleanup bool
+	resource  packer.Resource
 }
 
 func (s *StepKeyPair) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
@@ -74,6 +75,7 @@ func (s *StepKeyPair) Run(ctx context.Context, state multistep.StateBag) multist
 	}
 
 	s.doCleanup = true
+	s.resource = recordResource(state, resourceKeyPair, s.Comm.SSHTemporaryKeyPairName)
 
 	// Set some data for use in future steps
 	s.Comm.SSHKeyPairName = s.Comm.SSHTemporaryKeyPairName
@@ -122,6 +124,9 @@ func (s *StepKeyPair) Cleanup(state multistep.StateBag) {
 	if err != nil {
 		ui.Error(fmt.Sprintf(
 			"Error cleaning up keypair. Please delete the key manually: %s", s.Comm.SSHTemporaryKeyPairName))
+		multistep.AddOrphanedResource(state, fmt.Sprintf("key pair %s", s.Comm.SSHTemporaryKeyPairName))
+	} else {
+		packer.ForgetResource(s.resource)
 	}
 
 	// Also remove the physical key if we're debugging.
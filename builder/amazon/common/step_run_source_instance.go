@@ -10,6 +10,7 @@ import (
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
 
 	"github.com/hashicorp/packer/builder/amazon/common/awserrors"
 	"github.com/hashicorp/packer/helper/communicator"
@@ -32,6 +33,8 @@ type StepRunSourceInstance struct {
 	InstanceInitiatedShutdownBehavior string
 	InstanceType                      string
 	IsRestricted                      bool
+	MetadataOptions                   MetadataOptions
+	Region                            string
 	SourceAMI                         string
 	Tags                              map[string]string
 	Tenancy                           string
@@ -41,10 +44,11 @@ type StepRunSourceInstance struct {
 	NoEphemeral                       bool
 
 	instanceId string
+	resource   packer.Resource
 }
 
 func (s *StepRunSourceInstance) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
-	ec2conn := state.Get("ec2").(*ec2.EC2)
+	ec2conn := state.Get("ec2").(ec2iface.EC2API)
 
 	securityGroupIds := aws.StringSlice(state.Get("securityGroupIds").([]string))
 	iamInstanceProfile := aws.String(state.Get("iamInstanceProfile").(string))
@@ -91,7 +95,7 @@ func (s *StepRunSourceInstance) Run(ctx context.Context, state multistep.StateBa
 		s.Tags["Name"] = "Packer Builder"
 	}
 
-	ec2Tags, err := TagMap(s.Tags).EC2Tags(s.Ctx, *ec2conn.Config.Region, state)
+	ec2Tags, err := TagMap(s.Tags).EC2Tags(s.Ctx, s.Region, state)
 	if err != nil {
 		err := fmt.Errorf("Error tagging source instance: %s", err)
 		state.Put("error", err)
@@ -99,7 +103,7 @@ func (s *StepRunSourceInstance) Run(ctx context.Context, state multistep.StateBa
 		return multistep.ActionHalt
 	}
 
-	volTags, err := TagMap(s.VolumeTags).EC2Tags(s.Ctx, *ec2conn.Config.Region, state)
+	volTags, err := TagMap(s.VolumeTags).EC2Tags(s.Ctx, s.Region, state)
 	if err != nil {
 		err := fmt.Errorf("Error tagging volumes: %s", err)
 		state.Put("error", err)
@@ -139,6 +143,8 @@ func (s *StepRunSourceInstance) Run(ctx context.Context, state multistep.StateBa
 		}
 	}
 
+	runOpts.MetadataOptions = s.MetadataOptions.InstanceMetadataOptionsRequest()
+
 	if s.EnableT2Unlimited {
 		creditOption := "unlimited"
 		runOpts.CreditSpecification = &ec2.CreditSpecificationRequest{CpuCredits: &creditOption}
@@ -154,6 +160,15 @@ func (s *StepRunSourceInstance) Run(ctx context.Context, state multistep.StateBa
 		}
 
 		tagSpecs = append(tagSpecs, runTags)
+
+		// The network interfaces of the instance are tagged like it, as
+		// policies requiring tags usually apply to them as well
+		eniTags := &ec2.TagSpecification{
+			ResourceType: aws.String("network-interface"),
+			Tags:         ec2Tags,
+		}
+
+		tagSpecs = append(tagSpecs, eniTags)
 	}
 
 	if len(volTags) > 0 {
@@ -233,6 +248,7 @@ func (s *StepRunSourceInstance) Run(ctx context.Context, state multistep.StateBa
 
 	// Set the instance ID so that the cleanup works properly
 	s.instanceId = instanceId
+	s.resource = recordResource(state, resourceInstance, instanceId)
 
 	ui.Message(fmt.Sprintf("Instance ID: %s", instanceId))
 	ui.Say(fmt.Sprintf("Waiting for instance (%v) to become ready...", instanceId))
@@ -342,7 +358,7 @@ func (s *StepRunSourceInstance) Run(ctx context.Context, state multistep.StateBa
 		if len(volumeIds) > 0 && len(s.VolumeTags) > 0 {
 			ui.Say("Adding tags to source EBS Volumes")
 
-			volumeTags, err := TagMap(s.VolumeTags).EC2Tags(s.Ctx, *ec2conn.Config.Region, state)
+			volumeTags, err := TagMap(s.VolumeTags).EC2Tags(s.Ctx, s.Region, state)
 			if err != nil {
 				err := fmt.Errorf("Error tagging source EBS Volumes on %s: %s", *instance.InstanceId, err)
 				state.Put("error", err)
@@ -369,8 +385,12 @@ func (s *StepRunSourceInstance) Run(ctx context.Context, state multistep.StateBa
 }
 
 func (s *StepRunSourceInstance) Cleanup(state multistep.StateBag) {
+	s.CleanupContext(context.Background(), state)
+}
 
-	ec2conn := state.Get("ec2").(*ec2.EC2)
+func (s *StepRunSourceInstance) CleanupContext(ctx context.Context, state multistep.StateBag) {
+
+	ec2conn := state.Get("ec2").(ec2iface.EC2API)
 	ui := state.Get("ui").(packer.Ui)
 
 	// Terminate the source instance if it exists
@@ -378,11 +398,15 @@ func (s *StepRunSourceInstance) Cleanup(state multistep.StateBag) {
 		ui.Say("Terminating the source AWS instance...")
 		if _, err := ec2conn.TerminateInstances(&ec2.TerminateInstancesInput{InstanceIds: []*string{&s.instanceId}}); err != nil {
 			ui.Error(fmt.Sprintf("Error terminating instance, may still be around: %s", err))
+			multistep.AddOrphanedResource(state, fmt.Sprintf("instance %s", s.instanceId))
 			return
 		}
 
-		if err := s.PollingConfig.WaitUntilInstanceTerminated(aws.BackgroundContext(), ec2conn, s.instanceId); err != nil {
+		if err := s.PollingConfig.WaitUntilInstanceTerminated(ctx, ec2conn, s.instanceId); err != nil {
 			ui.Error(err.Error())
+			multistep.AddOrphanedResource(state, fmt.Sprintf("instance %s", s.instanceId))
+			return
 		}
+		packer.ForgetResource(s.resource)
 	}
 }
@@ -0,0 +1,238 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+	"github.com/hashicorp/packer/helper/communicator"
+	"github.com/hashicorp/packer/packer"
+	"github.com/hashicorp/packer/packer-plugin-sdk/multistep"
+)
+
+// runSourceEC2ConnMock records the calls of StepRunSourceInstance. The Fn
+// functions inject the responses and faults of the EC2 API.
+type runSourceEC2ConnMock struct {
+	ec2iface.EC2API
+
+	RunInstancesParams []*ec2.RunInstancesInput
+	RunInstancesFn     func(*ec2.RunInstancesInput) (*ec2.Reservation, error)
+
+	WaitUntilInstanceRunningFn func(*ec2.DescribeInstancesInput) error
+
+	DescribeInstancesParams []*ec2.DescribeInstancesInput
+	DescribeInstancesFn     func(*ec2.DescribeInstancesInput) (*ec2.DescribeInstancesOutput, error)
+
+	CreateTagsParams []*ec2.CreateTagsInput
+
+	TerminateInstancesParams []*ec2.TerminateInstancesInput
+}
+
+func (m *runSourceEC2ConnMock) RunInstances(req *ec2.RunInstancesInput) (*ec2.Reservation, error) {
+	m.RunInstancesParams = append(m.RunInstancesParams, req)
+	return m.RunInstancesFn(req)
+}
+
+func (m *runSourceEC2ConnMock) WaitUntilInstanceRunningWithContext(_ aws.Context, req *ec2.DescribeInstancesInput, _ ...request.WaiterOption) error {
+	if m.WaitUntilInstanceRunningFn != nil {
+		return m.WaitUntilInstanceRunningFn(req)
+	}
+	return nil
+}
+
+func (m *runSourceEC2ConnMock) DescribeInstances(req *ec2.DescribeInstancesInput) (*ec2.DescribeInstancesOutput, error) {
+	m.DescribeInstancesParams = append(m.DescribeInstancesParams, req)
+	return m.DescribeInstancesFn(req)
+}
+
+func (m *runSourceEC2ConnMock) CreateTags(req *ec2.CreateTagsInput) (*ec2.CreateTagsOutput, error) {
+	m.CreateTagsParams = append(m.CreateTagsParams, req)
+	return &ec2.CreateTagsOutput{}, nil
+}
+
+func (m *runSourceEC2ConnMock) TerminateInstances(req *ec2.TerminateInstancesInput) (*ec2.TerminateInstancesOutput, error) {
+	m.TerminateInstancesParams = append(m.TerminateInstancesParams, req)
+	return &ec2.TerminateInstancesOutput{}, nil
+}
+
+func (m *runSourceEC2ConnMock) WaitUntilInstanceTerminatedWithContext(aws.Context, *ec2.DescribeInstancesInput, ...request.WaiterOption) error {
+	return nil
+}
+
+func defaultRunSourceEC2Mock() *runSourceEC2ConnMock {
+	instance := &ec2.Instance{
+		InstanceId: aws.String("i-123"),
+		BlockDeviceMappings: []*ec2.InstanceBlockDeviceMapping{
+			{Ebs: &ec2.EbsInstanceBlockDevice{VolumeId: aws.String("vol-123")}},
+		},
+		StateTransitionReason: aws.String(""),
+		StateReason:           &ec2.StateReason{Message: aws.String("Server.InternalError")},
+	}
+	return &runSourceEC2ConnMock{
+		RunInstancesFn: func(*ec2.RunInstancesInput) (*ec2.Reservation, error) {
+			return &ec2.Reservation{Instances: []*ec2.Instance{instance}}, nil
+		},
+		DescribeInstancesFn: func(*ec2.DescribeInstancesInput) (*ec2.DescribeInstancesOutput, error) {
+			return &ec2.DescribeInstancesOutput{
+				Reservations: []*ec2.Reservation{{Instances: []*ec2.Instance{instance}}},
+			}, nil
+		},
+	}
+}
+
+func tStateRunSource(t *testing.T, conn ec2iface.EC2API) multistep.StateBag {
+	state := tStateSpot()
+	state.Put("ui", packer.TestUi(t))
+	state.Put("ec2", conn)
+	state.Put("source_image", testImage())
+	return state
+}
+
+func getBasicRunSourceStep() *StepRunSourceInstance {
+	return &StepRunSourceInstance{
+		PollingConfig:      new(AWSPollingConfig),
+		Comm:               &communicator.Config{},
+		ExpectedRootDevice: "ebs",
+		InstanceType:       "t2.micro",
+		LaunchMappings:     BlockDevices{},
+		Region:             "us-east-1",
+		Tags:               map[string]string{},
+		VolumeTags:         map[string]string{"volume-tag": "volume-tag-value"},
+	}
+}
+
+func TestStepRunSourceInstance_MetadataAndTagOnCreate(t *testing.T) {
+	conn := defaultRunSourceEC2Mock()
+	state := tStateRunSource(t, conn)
+
+	step := getBasicRunSourceStep()
+	step.MetadataOptions = MetadataOptions{HttpTokens: "required", HttpPutResponseHopLimit: 2}
+	if action := step.Run(context.TODO(), state); action != multistep.ActionContinue {
+		t.Fatalf("bad action: %#v: %s", action, state.Get("error"))
+	}
+
+	if len(conn.RunInstancesParams) != 1 {
+		t.Fatalf("bad RunInstances calls: %d", len(conn.RunInstancesParams))
+	}
+	input := conn.RunInstancesParams[0]
+
+	metadata := input.MetadataOptions
+	if metadata == nil || aws.StringValue(metadata.HttpTokens) != "required" ||
+		aws.Int64Value(metadata.HttpPutResponseHopLimit) != 2 || metadata.HttpEndpoint != nil {
+		t.Fatalf("bad metadata options: %#v", metadata)
+	}
+
+	tagged := map[string]bool{}
+	for _, spec := range input.TagSpecifications {
+		tagged[aws.StringValue(spec.ResourceType)] = true
+	}
+	for _, resource := range []string{"instance", "volume", "network-interface"} {
+		if !tagged[resource] {
+			t.Fatalf("%s should be tagged on creation: %#v", resource, input.TagSpecifications)
+		}
+	}
+	if len(conn.CreateTagsParams) != 0 {
+		t.Fatalf("should not tag once launched: %#v", conn.CreateTagsParams)
+	}
+}
+
+func TestStepRunSourceInstance_DefaultMetadata(t *testing.T) {
+	conn := defaultRunSourceEC2Mock()
+	state := tStateRunSource(t, conn)
+
+	step := getBasicRunSourceStep()
+	if action := step.Run(context.TODO(), state); action != multistep.ActionContinue {
+		t.Fatalf("bad action: %#v: %s", action, state.Get("error"))
+	}
+	if conn.RunInstancesParams[0].MetadataOptions != nil {
+		t.Fatalf("should keep the metadata options of EC2: %#v", conn.RunInstancesParams[0].MetadataOptions)
+	}
+}
+
+func TestStepRunSourceInstance_Restricted(t *testing.T) {
+	conn := defaultRunSourceEC2Mock()
+	state := tStateRunSource(t, conn)
+
+	step := getBasicRunSourceStep()
+	step.IsRestricted = true
+	if action := step.Run(context.TODO(), state); action != multistep.ActionContinue {
+		t.Fatalf("bad action: %#v: %s", action, state.Get("error"))
+	}
+	if len(conn.RunInstancesParams[0].TagSpecifications) != 0 {
+		t.Fatalf("should not tag on creation: %#v", conn.RunInstancesParams[0].TagSpecifications)
+	}
+	// The instance, then its volumes
+	if len(conn.CreateTagsParams) != 2 {
+		t.Fatalf("should tag once launched: %#v", conn.CreateTagsParams)
+	}
+}
+
+func TestStepRunSourceInstance_RetryInstanceProfile(t *testing.T) {
+	conn := defaultRunSourceEC2Mock()
+	run := conn.RunInstancesFn
+	conn.RunInstancesFn = func(input *ec2.RunInstancesInput) (*ec2.Reservation, error) {
+		// The instance profile created by Packer isn't visible to EC2 yet
+		if len(conn.RunInstancesParams) == 1 {
+			return nil, awserr.New("InvalidParameterValue", "Value (packer-123) for parameter iamInstanceProfile.name is invalid", nil)
+		}
+		return run(input)
+	}
+	state := tStateRunSource(t, conn)
+
+	step := getBasicRunSourceStep()
+	if action := step.Run(context.TODO(), state); action != multistep.ActionContinue {
+		t.Fatalf("bad action: %#v: %s", action, state.Get("error"))
+	}
+	if len(conn.RunInstancesParams) != 2 {
+		t.Fatalf("should retry RunInstances: %d", len(conn.RunInstancesParams))
+	}
+}
+
+func TestStepRunSourceInstance_Faults(t *testing.T) {
+	cases := map[string]func(*runSourceEC2ConnMock){
+		"RunInstances fails": func(conn *runSourceEC2ConnMock) {
+			conn.RunInstancesFn = func(*ec2.RunInstancesInput) (*ec2.Reservation, error) {
+				return nil, awserr.New("InsufficientInstanceCapacity", "no capacity", nil)
+			}
+		},
+		"instance never runs": func(conn *runSourceEC2ConnMock) {
+			conn.WaitUntilInstanceRunningFn = func(*ec2.DescribeInstancesInput) error {
+				return awserr.New(request.WaiterResourceNotReadyErrorCode, "exceeded wait attempts", nil)
+			}
+		},
+		"instance disappears": func(conn *runSourceEC2ConnMock) {
+			conn.DescribeInstancesFn = func(*ec2.DescribeInstancesInput) (*ec2.DescribeInstancesOutput, error) {
+				return nil, fmt.Errorf("connection reset by peer")
+			}
+		},
+	}
+
+	for name, inject := range cases {
+		conn := defaultRunSourceEC2Mock()
+		inject(conn)
+		state := tStateRunSource(t, conn)
+
+		step := getBasicRunSourceStep()
+		if action := step.Run(context.TODO(), state); action != multistep.ActionHalt {
+			t.Fatalf("%s: bad action: %#v", name, action)
+		}
+		if _, ok := state.GetOk("error"); !ok {
+			t.Fatalf("%s: should have error", name)
+		}
+
+		// The instance launched is terminated even though the step failed
+		step.Cleanup(state)
+		launched := len(conn.TerminateInstancesParams) == 1
+		if name == "RunInstances fails" && launched {
+			t.Fatalf("%s: should not terminate an instance", name)
+		}
+		if name != "RunInstances fails" && !launched {
+			t.Fatalf("%s: should terminate the instance", name)
+		}
+	}
+}
@@ -36,6 +36,7 @@ type StepRunSpotInstance struct {
 	ExpectedRootDevice                string
 	InstanceInitiatedShutdownBehavior string
 	InstanceType                      string
+	MetadataOptions                   MetadataOptions
 	Region                            string
 	SourceAMI                         string
 	SpotPrice                         string
@@ -49,6 +50,8 @@ type StepRunSpotInstance struct {
 	NoEphemeral                       bool
 
 	instanceId string
+	instance   packer.Resource
+	template   packer.Resource
 }
 
 func (s *StepRunSpotInstance) CreateTemplateData(userData *string, az string,
@@ -101,6 +104,7 @@ func (s *StepRunSpotInstance) CreateTemplateData(userData *string, az string,
 		IamInstanceProfile:    &ec2.LaunchTemplateIamInstanceProfileSpecificationRequest{Name: iamInstanceProfile},
 		ImageId:               &s.SourceAMI,
 		InstanceMarketOptions: marketOptions,
+		MetadataOptions:       s.MetadataOptions.LaunchTemplateMetadataOptionsRequest(),
 		Placement: &ec2.LaunchTemplatePlacementRequest{
 			AvailabilityZone: &az,
 		},
@@ -270,6 +274,7 @@ func (s *StepRunSpotInstance) Run(ctx context.Context, state multistep.StateBag)
 		ui.Error(err.Error())
 		return multistep.ActionHalt
 	}
+	s.template = recordResource(state, resourceTemplate, launchTemplateName)
 
 	// Add overrides for each user-provided instance type
 	var overrides []*ec2.FleetLaunchTemplateOverridesRequest
@@ -350,6 +355,7 @@ func (s *StepRunSpotInstance) Run(ctx context.Context, state multistep.StateBag)
 	instanceId = *createOutput.Instances[0].InstanceIds[0]
 	// Set the instance ID so that the cleanup works properly
 	s.instanceId = instanceId
+	s.instance = recordResource(state, resourceInstance, instanceId)
 
 	ui.Message(fmt.Sprintf("Instance ID: %s", instanceId))
 
@@ -484,6 +490,10 @@ func (s *StepRunSpotInstance) Run(ctx context.Context, state multistep.StateBag)
 }
 
 func (s *StepRunSpotInstance) Cleanup(state multistep.StateBag) {
+	s.CleanupContext(context.Background(), state)
+}
+
+func (s *StepRunSpotInstance) CleanupContext(ctx context.Context, state multistep.StateBag) {
 	ec2conn := state.Get("ec2").(*ec2.EC2)
 	ui := state.Get("ui").(packer.Ui)
 	launchTemplateName := state.Get("launchTemplateName").(string)
@@ -493,11 +503,15 @@ func (s *StepRunSpotInstance) Cleanup(state multistep.StateBag) {
 		ui.Say("Terminating the source AWS instance...")
 		if _, err := ec2conn.TerminateInstances(&ec2.TerminateInstancesInput{InstanceIds: []*string{&s.instanceId}}); err != nil {
 			ui.Error(fmt.Sprintf("Error terminating instance, may still be around: %s", err))
+			multistep.AddOrphanedResource(state, fmt.Sprintf("instance %s", s.instanceId))
 			return
 		}
 
-		if err := s.PollingConfig.WaitUntilInstanceTerminated(aws.BackgroundContext(), ec2conn, s.instanceId); err != nil {
+		if err := s.PollingConfig.WaitUntilInstanceTerminated(ctx, ec2conn, s.instanceId); err != nil {
 			ui.Error(err.Error())
+			multistep.AddOrphanedResource(state, fmt.Sprintf("instance %s", s.instanceId))
+		} else {
+			packer.ForgetResource(s.instance)
 		}
 	}
 
@@ -507,5 +521,8 @@ func (s *StepRunSpotInstance) Cleanup(state multistep.StateBag) {
 	}
 	if _, err := ec2conn.DeleteLaunchTemplate(deleteInput); err != nil {
 		ui.Error(err.Error())
+		multistep.AddOrphanedResource(state, fmt.Sprintf("launch template %s", launchTemplateName))
+		return
 	}
+	packer.ForgetResource(s.template)
 }
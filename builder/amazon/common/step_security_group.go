@@ -24,6 +24,7 @@ type StepSecurityGroup struct {
 	SkipSSHRuleCreation    bool
 
 	createdGroupId string
+	resource       packer.Resource
 }
 
 func (s *StepSecurityGroup) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
@@ -96,6 +97,7 @@ func (s *StepSecurityGroup) Run(ctx context.Context, state multistep.StateBag) m
 
 	// Set the group ID so we can delete it later
 	s.createdGroupId = *groupResp.GroupId
+	s.resource = recordResource(state, resourceSecurityGroup, s.createdGroupId)
 
 	// Wait for the security group become available for authorizing
 	log.Printf("[DEBUG] Waiting for temporary security group: %s", s.createdGroupId)
@@ -160,6 +162,10 @@ func (s *StepSecurityGroup) Run(ctx context.Context, state multistep.StateBag) m
 }
 
 func (s *StepSecurityGroup) Cleanup(state multistep.StateBag) {
+	s.CleanupContext(context.Background(), state)
+}
+
+func (s *StepSecurityGroup) CleanupContext(ctx context.Context, state multistep.StateBag) {
 	if s.createdGroupId == "" {
 		return
 	}
@@ -171,20 +177,26 @@ func (s *StepSecurityGroup) Cleanup(state multistep.StateBag) {
 
 	var err error
 	for i := 0; i < 5; i++ {
-		_, err = ec2conn.DeleteSecurityGroup(&ec2.DeleteSecurityGroupInput{GroupId: &s.createdGroupId})
+		_, err = ec2conn.DeleteSecurityGroupWithContext(ctx, &ec2.DeleteSecurityGroupInput{GroupId: &s.createdGroupId})
 		if err == nil {
 			break
 		}
 
 		log.Printf("Error deleting security group: %s", err)
-		time.Sleep(5 * time.Second)
+		select {
+		case <-time.After(5 * time.Second):
+		case <-ctx.Done():
+		}
 	}
 
 	if err != nil {
 		ui.Error(fmt.Sprintf(
 			"Error cleaning up security group. Please delete the group manually:"+
 				" err: %s; security group ID: %s", err, s.createdGroupId))
+		multistep.AddOrphanedResource(state, fmt.Sprintf("security group %s", s.createdGroupId))
+		return
 	}
+	packer.ForgetResource(s.resource)
 }
 
 func waitUntilSecurityGroupExists(c *ec2.EC2, input *ec2.DescribeSecurityGroupsInput) error {
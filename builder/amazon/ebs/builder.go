@@ -11,6 +11,7 @@ package ebs
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/aws/aws-sdk-go/service/ec2"
 	"github.com/aws/aws-sdk-go/service/iam"
@@ -71,6 +72,17 @@ type Config struct {
 	// make sure you don't set this for *nix guests; behavior may be
 	// unpredictable.
 	NoEphemeral bool `mapstructure:"no_ephemeral" required:"false"`
+	// The date and time to deprecate the AMI at, in each region, as an
+	// [RFC 3339](https://tools.ietf.org/html/rfc3339) timestamp like
+	// `2021-12-31T23:59:00Z`, or as a duration from the end of the build like
+	// `4380h`. Deprecated AMIs are hidden from the AMIs listed by the other
+	// accounts, but can still be used to launch instances. Not deprecated by
+	// default.
+	DeprecateAt string `mapstructure:"deprecate_at" required:"false"`
+	// Deregister the older AMIs of the series of builds once the AMI is
+	// built. See the [Retention](#retention-configuration) documentation for
+	// fields.
+	Retention *awscommon.AMIRetentionConfig `mapstructure:"retention" required:"false"`
 
 	ctx interpolate.Context
 }
@@ -125,6 +137,15 @@ func (b *Builder) Prepare(raws ...interface{}) ([]string, []string, error) {
 	errs = packer.MultiErrorAppend(errs, b.config.LaunchMappings.Prepare(&b.config.ctx)...)
 	errs = packer.MultiErrorAppend(errs, b.config.RunConfig.Prepare(&b.config.ctx)...)
 
+	if b.config.DeprecateAt != "" {
+		if _, err := awscommon.ParseDeprecateAt(b.config.DeprecateAt, time.Now()); err != nil {
+			errs = packer.MultiErrorAppend(errs, err)
+		}
+	}
+	if b.config.Retention != nil {
+		errs = packer.MultiErrorAppend(errs, b.config.Retention.Prepare()...)
+	}
+
 	if b.config.IsSpotInstance() && (b.config.AMIENASupport.True() || b.config.AMISriovNetSupport) {
 		errs = packer.MultiErrorAppend(errs,
 			fmt.Errorf("Spot instances do not support modification, which is required "+
@@ -186,6 +207,7 @@ func (b *Builder) Run(ctx context.Context, ui packer.Ui, hook packer.Hook) (pack
 			ExpectedRootDevice:                "ebs",
 			InstanceInitiatedShutdownBehavior: b.config.InstanceInitiatedShutdownBehavior,
 			InstanceType:                      b.config.InstanceType,
+			MetadataOptions:                   b.config.Metadata,
 			Region:                            *ec2conn.Config.Region,
 			SourceAMI:                         b.config.SourceAmi,
 			SpotPrice:                         b.config.SpotPrice,
@@ -210,7 +232,9 @@ func (b *Builder) Run(ctx context.Context, ui packer.Ui, hook packer.Hook) (pack
 			ExpectedRootDevice:                "ebs",
 			InstanceInitiatedShutdownBehavior: b.config.InstanceInitiatedShutdownBehavior,
 			InstanceType:                      b.config.InstanceType,
-			IsRestricted:                      b.config.IsChinaCloud() || b.config.IsGovCloud(),
+			IsRestricted:                      (b.config.IsChinaCloud() || b.config.IsGovCloud()) && !b.config.ForceTagOnCreate,
+			MetadataOptions:                   b.config.Metadata,
+			Region:                            *ec2conn.Config.Region,
 			SourceAMI:                         b.config.SourceAmi,
 			Tags:                              b.config.RunTags,
 			Tenancy:                           b.config.Tenancy,
@@ -347,6 +371,12 @@ func (b *Builder) Run(ctx context.Context, ui packer.Ui, hook packer.Hook) (pack
 			SnapshotTags: b.config.SnapshotTags,
 			Ctx:          b.config.ctx,
 		},
+		&awscommon.StepDeprecateAMI{
+			DeprecateAt: b.config.DeprecateAt,
+		},
+		&awscommon.StepAMIRetention{
+			Retention: b.config.Retention,
+		},
 	}
 
 	// Run!
@@ -164,3 +164,45 @@ func TestBuilderPrepare_ReturnGeneratedData(t *testing.T) {
 		t.Fatalf("Generated data should contain SourceAMIOwnerName")
 	}
 }
+
+func TestBuilderPrepare_DeprecateAt(t *testing.T) {
+	for _, deprecateAt := range []string{"2021-12-31T23:59:00Z", "4380h"} {
+		var b Builder
+		config := testConfig()
+		config["deprecate_at"] = deprecateAt
+		if _, _, err := b.Prepare(config); err != nil {
+			t.Fatalf("%s: should not have error: %s", deprecateAt, err)
+		}
+	}
+
+	for _, deprecateAt := range []string{"2021-12-31", "-1h"} {
+		var b Builder
+		config := testConfig()
+		config["deprecate_at"] = deprecateAt
+		if _, _, err := b.Prepare(config); err == nil {
+			t.Fatalf("%s: should have error", deprecateAt)
+		}
+	}
+}
+
+func TestBuilderPrepare_Retention(t *testing.T) {
+	var b Builder
+	config := testConfig()
+	config["retention"] = map[string]interface{}{
+		"name_pattern": "foo-*",
+	}
+	if _, _, err := b.Prepare(config); err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	if b.config.Retention.Keep != 1 {
+		t.Fatalf("bad keep: %d", b.config.Retention.Keep)
+	}
+
+	b = Builder{}
+	config["retention"] = map[string]interface{}{
+		"keep": 3,
+	}
+	if _, _, err := b.Prepare(config); err == nil {
+		t.Fatal("should have error")
+	}
+}
@@ -209,6 +209,7 @@ func (b *Builder) Run(ctx context.Context, ui packer.Ui, hook packer.Hook) (pack
 			ExpectedRootDevice:                "ebs",
 			InstanceInitiatedShutdownBehavior: b.config.InstanceInitiatedShutdownBehavior,
 			InstanceType:                      b.config.InstanceType,
+			MetadataOptions:                   b.config.Metadata,
 			Region:                            *ec2conn.Config.Region,
 			SourceAMI:                         b.config.SourceAmi,
 			SpotPrice:                         b.config.SpotPrice,
@@ -232,7 +233,9 @@ func (b *Builder) Run(ctx context.Context, ui packer.Ui, hook packer.Hook) (pack
 			ExpectedRootDevice:                "ebs",
 			InstanceInitiatedShutdownBehavior: b.config.InstanceInitiatedShutdownBehavior,
 			InstanceType:                      b.config.InstanceType,
-			IsRestricted:                      b.config.IsChinaCloud() || b.config.IsGovCloud(),
+			IsRestricted:                      (b.config.IsChinaCloud() || b.config.IsGovCloud()) && !b.config.ForceTagOnCreate,
+			MetadataOptions:                   b.config.Metadata,
+			Region:                            *ec2conn.Config.Region,
 			SourceAMI:                         b.config.SourceAmi,
 			Tags:                              b.config.RunTags,
 			Tenancy:                           b.config.Tenancy,
@@ -197,6 +197,7 @@ func (b *Builder) Run(ctx context.Context, ui packer.Ui, hook packer.Hook) (pack
 			ExpectedRootDevice:                "ebs",
 			InstanceInitiatedShutdownBehavior: b.config.InstanceInitiatedShutdownBehavior,
 			InstanceType:                      b.config.InstanceType,
+			MetadataOptions:                   b.config.Metadata,
 			Region:                            *ec2conn.Config.Region,
 			SourceAMI:                         b.config.SourceAmi,
 			SpotInstanceTypes:                 b.config.SpotInstanceTypes,
@@ -220,7 +221,9 @@ func (b *Builder) Run(ctx context.Context, ui packer.Ui, hook packer.Hook) (pack
 			ExpectedRootDevice:                "ebs",
 			InstanceInitiatedShutdownBehavior: b.config.InstanceInitiatedShutdownBehavior,
 			InstanceType:                      b.config.InstanceType,
-			IsRestricted:                      b.config.IsChinaCloud() || b.config.IsGovCloud(),
+			IsRestricted:                      (b.config.IsChinaCloud() || b.config.IsGovCloud()) && !b.config.ForceTagOnCreate,
+			MetadataOptions:                   b.config.Metadata,
+			Region:                            *ec2conn.Config.Region,
 			SourceAMI:                         b.config.SourceAmi,
 			Tags:                              b.config.RunTags,
 			Tenancy:                           b.config.Tenancy,
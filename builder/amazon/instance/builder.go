@@ -266,6 +266,7 @@ func (b *Builder) Run(ctx context.Context, ui packer.Ui, hook packer.Hook) (pack
 			Debug:                    b.config.PackerDebug,
 			EbsOptimized:             b.config.EbsOptimized,
 			InstanceType:             b.config.InstanceType,
+			MetadataOptions:          b.config.Metadata,
 			Region:                   *ec2conn.Config.Region,
 			SourceAMI:                b.config.SourceAmi,
 			SpotPrice:                b.config.SpotPrice,
@@ -286,7 +287,9 @@ func (b *Builder) Run(ctx context.Context, ui packer.Ui, hook packer.Hook) (pack
 			EbsOptimized:             b.config.EbsOptimized,
 			EnableT2Unlimited:        b.config.EnableT2Unlimited,
 			InstanceType:             b.config.InstanceType,
-			IsRestricted:             b.config.IsChinaCloud() || b.config.IsGovCloud(),
+			IsRestricted:             (b.config.IsChinaCloud() || b.config.IsGovCloud()) && !b.config.ForceTagOnCreate,
+			MetadataOptions:          b.config.Metadata,
+			Region:                   *ec2conn.Config.Region,
 			SourceAMI:                b.config.SourceAmi,
 			Tags:                     b.config.RunTags,
 			Tenancy:                  b.config.Tenancy,
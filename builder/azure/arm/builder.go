@@ -10,6 +10,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2019-03-01/compute"
 	armstorage "github.com/Azure/azure-sdk-for-go/services/storage/mgmt/2017-10-01/storage"
 	"github.com/Azure/azure-sdk-for-go/storage"
 	"github.com/Azure/go-autorest/autorest/adal"
@@ -174,6 +175,23 @@ func (b *Builder) Run(ctx context.Context, ui packer.Ui, hook packer.Hook) (pack
 
 	deploymentName := b.stateBag.Get(constants.ArmDeploymentName).(string)
 
+	// The Shared Gallery Image Version to build from must be replicated to the build location
+	if b.config.SharedGallery.GalleryName != "" && b.config.SharedGallery.ImageVersion != "" {
+		sourceClient := azureClient.GalleryImageVersionsClient
+		sourceClient.SubscriptionID = b.config.SharedGallery.Subscription
+		version, err := sourceClient.Get(ctx, b.config.SharedGallery.ResourceGroup, b.config.SharedGallery.GalleryName,
+			b.config.SharedGallery.ImageName, b.config.SharedGallery.ImageVersion, "")
+		if err != nil {
+			return nil, fmt.Errorf("the Shared Gallery Image Version %s to build from does not exist in the gallery %s: %s",
+				b.config.SharedGallery.ImageVersion, b.config.SharedGallery.GalleryName, err)
+		}
+		buildLocation := normalizeAzureRegion(b.stateBag.Get(constants.ArmLocation).(string))
+		if regions := galleryImageVersionRegions(version); !containsRegion(regions, buildLocation) {
+			return nil, fmt.Errorf("the Shared Gallery Image Version %s to build from is replicated to %v, but the build will take place in %s",
+				b.config.SharedGallery.ImageVersion, regions, buildLocation)
+		}
+	}
+
 	// For Managed Images, validate that Shared Gallery Image exists before publishing to SIG
 	if b.config.isManagedImage() && b.config.SharedGalleryDestination.SigDestinationGalleryName != "" {
 		_, err = azureClient.GalleryImagesClient.Get(ctx, b.config.SharedGalleryDestination.SigDestinationResourceGroup, b.config.SharedGalleryDestination.SigDestinationGalleryName, b.config.SharedGalleryDestination.SigDestinationImageName)
@@ -193,9 +211,20 @@ func (b *Builder) Run(ctx context.Context, ui packer.Ui, hook packer.Hook) (pack
 				continue
 			}
 		}
+		// Regions with their own replication settings are replicated to as well
+		for _, target := range b.config.SharedGalleryDestination.SigDestinationTargetRegions {
+			normalizedRegion := normalizeAzureRegion(target.Name)
+			if !containsRegion(normalizedReplicationRegions, normalizedRegion) {
+				normalizedReplicationRegions = append(normalizedReplicationRegions, normalizedRegion)
+				if strings.EqualFold(normalizedRegion, managedImageLocation) {
+					foundMandatoryReplicationRegion = true
+				}
+			}
+		}
 		if foundMandatoryReplicationRegion == false {
-			b.config.SharedGalleryDestination.SigDestinationReplicationRegions = append(normalizedReplicationRegions, managedImageLocation)
+			normalizedReplicationRegions = append(normalizedReplicationRegions, managedImageLocation)
 		}
+		b.config.SharedGalleryDestination.SigDestinationReplicationRegions = normalizedReplicationRegions
 		b.stateBag.Put(constants.ArmManagedImageSharedGalleryReplicationRegions, b.config.SharedGalleryDestination.SigDestinationReplicationRegions)
 	}
 
@@ -435,6 +464,8 @@ func (b *Builder) configureStateBag(stateBag multistep.StateBag) {
 		stateBag.Put(constants.ArmManagedImageSharedGalleryImageVersionEndOfLifeDate, b.config.SharedGalleryImageVersionEndOfLifeDate)
 		stateBag.Put(constants.ArmManagedImageSharedGalleryImageVersionReplicaCount, b.config.SharedGalleryImageVersionReplicaCount)
 		stateBag.Put(constants.ArmManagedImageSharedGalleryImageVersionExcludeFromLatest, b.config.SharedGalleryImageVersionExcludeFromLatest)
+		stateBag.Put(constants.ArmManagedImageSharedGalleryStorageAccountType, b.config.SharedGalleryDestination.SigDestinationStorageAccountType)
+		stateBag.Put(constants.ArmManagedImageSharedGalleryTargetRegions, b.config.SharedGalleryDestination.SigDestinationTargetRegions)
 	}
 }
 
@@ -475,3 +506,29 @@ func getObjectIdFromToken(ui packer.Ui, token *adal.ServicePrincipalToken) strin
 func normalizeAzureRegion(name string) string {
 	return strings.ToLower(strings.Replace(name, " ", "", -1))
 }
+
+func containsRegion(regions []string, region string) bool {
+	for _, r := range regions {
+		if strings.EqualFold(r, region) {
+			return true
+		}
+	}
+	return false
+}
+
+// galleryImageVersionRegions returns the regions a Shared Gallery Image
+// Version is replicated to.
+func galleryImageVersionRegions(version compute.GalleryImageVersion) []string {
+	var regions []string
+	if version.GalleryImageVersionProperties == nil ||
+		version.GalleryImageVersionProperties.PublishingProfile == nil ||
+		version.GalleryImageVersionProperties.PublishingProfile.TargetRegions == nil {
+		return regions
+	}
+	for _, target := range *version.GalleryImageVersionProperties.PublishingProfile.TargetRegions {
+		if target.Name != nil {
+			regions = append(regions, normalizeAzureRegion(*target.Name))
+		}
+	}
+	return regions
+}
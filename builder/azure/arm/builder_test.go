@@ -3,6 +3,7 @@ package arm
 import (
 	"testing"
 
+	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2019-03-01/compute"
 	"github.com/hashicorp/packer/builder/azure/common/constants"
 )
 
@@ -65,3 +66,25 @@ func TestStateBagShouldPoluateExpectedTags(t *testing.T) {
 	}
 
 }
+
+func TestGalleryImageVersionRegions(t *testing.T) {
+	westUS, eastUS := "West US", "eastus"
+	version := compute.GalleryImageVersion{
+		GalleryImageVersionProperties: &compute.GalleryImageVersionProperties{
+			PublishingProfile: &compute.GalleryImageVersionPublishingProfile{
+				TargetRegions: &[]compute.TargetRegion{{Name: &westUS}, {Name: &eastUS}},
+			},
+		},
+	}
+
+	regions := galleryImageVersionRegions(version)
+	if !containsRegion(regions, "westus") || !containsRegion(regions, "eastus") {
+		t.Fatalf("expected the regions of the image version, but got %v", regions)
+	}
+	if containsRegion(regions, "northeurope") {
+		t.Fatalf("expected northeurope not to be a region of the image version")
+	}
+	if regions := galleryImageVersionRegions(compute.GalleryImageVersion{}); len(regions) != 0 {
+		t.Fatalf("expected no regions, but got %v", regions)
+	}
+}
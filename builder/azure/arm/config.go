@@ -1,5 +1,5 @@
 //go:generate struct-markdown
-//go:generate mapstructure-to-hcl2 -type Config,SharedImageGallery,SharedImageGalleryDestination,PlanInformation
+//go:generate mapstructure-to-hcl2 -type Config,SharedImageGallery,SharedImageGalleryDestination,SharedImageGalleryTargetRegion,PlanInformation
 
 package arm
 
@@ -95,6 +95,25 @@ type SharedImageGalleryDestination struct {
 	SigDestinationImageName          string   `mapstructure:"image_name"`
 	SigDestinationImageVersion       string   `mapstructure:"image_version"`
 	SigDestinationReplicationRegions []string `mapstructure:"replication_regions"`
+	// The storage account type of the replicas of the image version,
+	// `Standard_LRS` or `Standard_ZRS`. Defaults to `Standard_LRS`.
+	SigDestinationStorageAccountType string `mapstructure:"storage_account_type"`
+	// Replication settings of some regions, overriding the replica count
+	// and storage account type of the image version. The regions are
+	// replicated to as well when missing from replication_regions.
+	SigDestinationTargetRegions []SharedImageGalleryTargetRegion `mapstructure:"target_regions"`
+}
+
+type SharedImageGalleryTargetRegion struct {
+	// The name of the region.
+	Name string `mapstructure:"name" required:"true"`
+	// The number of replicas of the image version in the region, between 1
+	// and 10. Defaults to shared_image_gallery_replica_count.
+	ReplicaCount int32 `mapstructure:"replicas"`
+	// The storage account type of the replicas in the region, `Standard_LRS`
+	// or `Standard_ZRS`. Defaults to the storage_account_type of the
+	// destination.
+	StorageAccountType string `mapstructure:"storage_account_type"`
 }
 
 type Config struct {
@@ -158,7 +177,11 @@ type Config struct {
 	//     "gallery_name": "GalleryName",
 	//     "image_name": "ImageName",
 	//     "image_version": "1.0.0",
-	//     "replication_regions": ["regionA", "regionB", "regionC"]
+	//     "replication_regions": ["regionA", "regionB", "regionC"],
+	//     "storage_account_type": "Standard_ZRS",
+	//     "target_regions": [
+	//         {"name": "regionA", "replicas": 3}
+	//     ]
 	// }
 	// "managed_image_name": "TargetImageName",
 	// "managed_image_resource_group_name": "TargetResourceGroup"
@@ -172,6 +195,11 @@ type Config struct {
 	//     image_name = "ImageName"
 	//     image_version = "1.0.0"
 	//     replication_regions = ["regionA", "regionB", "regionC"]
+	//     storage_account_type = "Standard_ZRS"
+	//     target_regions {
+	//         name = "regionA"
+	//         replicas = 3
+	//     }
 	// }
 	// managed_image_name = "TargetImageName"
 	// managed_image_resource_group_name = "TargetResourceGroup"
@@ -186,7 +214,7 @@ type Config struct {
 	// minutes, and `h` for hours.)
 	SharedGalleryTimeout time.Duration `mapstructure:"shared_image_gallery_timeout"`
 	// The end of life date (2006-01-02T15:04:05.99Z) of the gallery Image Version. This property
-	// can be used for decommissioning purposes.
+	// can be used for decommissioning purposes. The date must be in the future.
 	SharedGalleryImageVersionEndOfLifeDate string `mapstructure:"shared_gallery_image_version_end_of_life_date" required:"false"`
 	// The number of replicas of the Image Version to be created per region. This
 	// property would take effect for a region when regionalReplicaCount is not specified.
@@ -1006,9 +1034,31 @@ func assertRequiredParametersSet(c *Config, errs *packer.MultiError) {
 		if c.SharedGalleryDestination.SigDestinationImageVersion == "" {
 			errs = packer.MultiErrorAppend(errs, fmt.Errorf("An image_version must be specified for shared_image_gallery_destination"))
 		}
-		if len(c.SharedGalleryDestination.SigDestinationReplicationRegions) == 0 {
+		if len(c.SharedGalleryDestination.SigDestinationReplicationRegions) == 0 && len(c.SharedGalleryDestination.SigDestinationTargetRegions) == 0 {
 			errs = packer.MultiErrorAppend(errs, fmt.Errorf("A list of replication_regions must be specified for shared_image_gallery_destination"))
 		}
+		if !isValidGalleryStorageAccountType(c.SharedGalleryDestination.SigDestinationStorageAccountType) {
+			errs = packer.MultiErrorAppend(errs, fmt.Errorf("The storage_account_type %q of shared_image_gallery_destination is invalid", c.SharedGalleryDestination.SigDestinationStorageAccountType))
+		}
+		for _, region := range c.SharedGalleryDestination.SigDestinationTargetRegions {
+			if region.Name == "" {
+				errs = packer.MultiErrorAppend(errs, fmt.Errorf("A name must be specified for the target_regions of shared_image_gallery_destination"))
+			}
+			if region.ReplicaCount < 0 || region.ReplicaCount > constants.SharedImageGalleryImageVersionDefaultMaxReplicaCount {
+				errs = packer.MultiErrorAppend(errs, fmt.Errorf("The replicas of target region %q must be between 1 and 10", region.Name))
+			}
+			if !isValidGalleryStorageAccountType(region.StorageAccountType) {
+				errs = packer.MultiErrorAppend(errs, fmt.Errorf("The storage_account_type %q of target region %q is invalid", region.StorageAccountType, region.Name))
+			}
+		}
+		if c.SharedGalleryImageVersionEndOfLifeDate != "" {
+			endOfLife, err := time.Parse("2006-01-02T15:04:05.99Z", c.SharedGalleryImageVersionEndOfLifeDate)
+			if err != nil {
+				errs = packer.MultiErrorAppend(errs, fmt.Errorf("The shared_gallery_image_version_end_of_life_date %q is not a date like 2006-01-02T15:04:05.99Z", c.SharedGalleryImageVersionEndOfLifeDate))
+			} else if endOfLife.Before(time.Now()) {
+				errs = packer.MultiErrorAppend(errs, fmt.Errorf("The shared_gallery_image_version_end_of_life_date %q is in the past", c.SharedGalleryImageVersionEndOfLifeDate))
+			}
+		}
 		if c.SharedGalleryDestination.SigDestinationSubscription == "" {
 			c.SharedGalleryDestination.SigDestinationSubscription = c.ClientConfig.SubscriptionID
 		}
@@ -1162,6 +1212,14 @@ func isValidAzureName(re *regexp.Regexp, rgn string) bool {
 		!strings.HasSuffix(rgn, "-")
 }
 
+func isValidGalleryStorageAccountType(storageAccountType string) bool {
+	switch storageAccountType {
+	case "", "Standard_LRS", "Standard_ZRS":
+		return true
+	}
+	return false
+}
+
 // The supplied password must be between 8-123 characters long and must satisfy at least 3 of password complexity requirements from the following:
 // 1) Contains an uppercase character
 // 2) Contains a lowercase character
@@ -2163,3 +2163,68 @@ func TestConfigShouldRejectMalformedUserAssignedManagedIdentities(t *testing.T)
 		}
 	}
 }
+
+func TestConfigSharedImageGalleryDestinationReplication(t *testing.T) {
+	newConfig := func() map[string]interface{} {
+		return map[string]interface{}{
+			"custom_managed_image_resource_group_name": "ignore",
+			"custom_managed_image_name":                "ignore",
+			"location":                                 "ignore",
+			"subscription_id":                          "ignore",
+			"communicator":                             "none",
+			"managed_image_resource_group_name":        "ignore",
+			"managed_image_name":                       "ignore",
+			"os_type":                                  constants.Target_Linux,
+			"shared_image_gallery_destination": map[string]interface{}{
+				"resource_group":       "ignore",
+				"gallery_name":         "ignore",
+				"image_name":           "ignore",
+				"image_version":        "1.0.0",
+				"storage_account_type": "Standard_ZRS",
+				"target_regions": []map[string]interface{}{
+					{"name": "West US", "replicas": 3},
+					{"name": "eastus", "storage_account_type": "Standard_LRS"},
+				},
+			},
+		}
+	}
+
+	var c Config
+	if _, err := c.Prepare(newConfig(), getPackerConfiguration()); err != nil {
+		t.Fatalf("expected config to accept target regions without replication_regions: %s", err)
+	}
+	if len(c.SharedGalleryDestination.SigDestinationTargetRegions) != 2 ||
+		c.SharedGalleryDestination.SigDestinationTargetRegions[0].ReplicaCount != 3 {
+		t.Fatalf("bad target regions: %#v", c.SharedGalleryDestination.SigDestinationTargetRegions)
+	}
+
+	invalid := map[string]func(map[string]interface{}){
+		"storage account type": func(config map[string]interface{}) {
+			config["shared_image_gallery_destination"].(map[string]interface{})["storage_account_type"] = "Premium_LRS"
+		},
+		"replicas": func(config map[string]interface{}) {
+			config["shared_image_gallery_destination"].(map[string]interface{})["target_regions"] = []map[string]interface{}{
+				{"name": "westus", "replicas": 11},
+			}
+		},
+		"unnamed target region": func(config map[string]interface{}) {
+			config["shared_image_gallery_destination"].(map[string]interface{})["target_regions"] = []map[string]interface{}{
+				{"replicas": 2},
+			}
+		},
+		"end of life date": func(config map[string]interface{}) {
+			config["shared_gallery_image_version_end_of_life_date"] = "2020-02-30"
+		},
+		"past end of life date": func(config map[string]interface{}) {
+			config["shared_gallery_image_version_end_of_life_date"] = "2020-01-02T15:04:05.99Z"
+		},
+	}
+	for name, invalidate := range invalid {
+		config := newConfig()
+		invalidate(config)
+		var c Config
+		if _, err := c.Prepare(config, getPackerConfiguration()); err == nil {
+			t.Errorf("expected config to reject an invalid %s", name)
+		}
+	}
+}
@@ -3,6 +3,8 @@ package arm
 import (
 	"context"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2019-03-01/compute"
 	"github.com/Azure/go-autorest/autorest/date"
@@ -11,9 +13,13 @@ import (
 	"github.com/hashicorp/packer/packer-plugin-sdk/multistep"
 )
 
+// replicationProgressInterval is how often the replication progress of the
+// image version is reported while publishing.
+const replicationProgressInterval = time.Minute
+
 type StepPublishToSharedImageGallery struct {
 	client  *AzureClient
-	publish func(ctx context.Context, mdiID, miSigPubRg, miSIGalleryName, miSGImageName, miSGImageVersion string, miSigReplicationRegions []string, miSGImageVersionEndOfLifeDate string, miSGImageVersionExcludeFromLatest bool, miSigReplicaCount int32, location string, tags map[string]*string) (string, error)
+	publish func(ctx context.Context, mdiID, miSigPubRg, miSIGalleryName, miSGImageName, miSGImageVersion string, miSigTargetRegions []compute.TargetRegion, miSGImageVersionEndOfLifeDate string, miSGImageVersionExcludeFromLatest bool, miSigReplicaCount int32, miSGStorageAccountType string, location string, tags map[string]*string) (string, error)
 	say     func(message string)
 	error   func(e error)
 	toSIG   func() bool
@@ -37,13 +43,7 @@ func NewStepPublishToSharedImageGallery(client *AzureClient, ui packer.Ui, confi
 	return step
 }
 
-func (s *StepPublishToSharedImageGallery) publishToSig(ctx context.Context, mdiID string, miSigPubRg string, miSIGalleryName string, miSGImageName string, miSGImageVersion string, miSigReplicationRegions []string, miSGImageVersionEndOfLifeDate string, miSGImageVersionExcludeFromLatest bool, miSigReplicaCount int32, location string, tags map[string]*string) (string, error) {
-
-	replicationRegions := make([]compute.TargetRegion, len(miSigReplicationRegions))
-	for i, v := range miSigReplicationRegions {
-		regionName := v
-		replicationRegions[i] = compute.TargetRegion{Name: &regionName}
-	}
+func (s *StepPublishToSharedImageGallery) publishToSig(ctx context.Context, mdiID string, miSigPubRg string, miSIGalleryName string, miSGImageName string, miSGImageVersion string, miSigTargetRegions []compute.TargetRegion, miSGImageVersionEndOfLifeDate string, miSGImageVersionExcludeFromLatest bool, miSigReplicaCount int32, miSGStorageAccountType string, location string, tags map[string]*string) (string, error) {
 
 	var endOfLifeDate *date.Time
 	if miSGImageVersionEndOfLifeDate != "" {
@@ -66,10 +66,11 @@ func (s *StepPublishToSharedImageGallery) publishToSig(ctx context.Context, mdiI
 						ID: &mdiID,
 					},
 				},
-				TargetRegions:     &replicationRegions,
-				EndOfLifeDate:     endOfLifeDate,
-				ExcludeFromLatest: &miSGImageVersionExcludeFromLatest,
-				ReplicaCount:      &miSigReplicaCount,
+				TargetRegions:      &miSigTargetRegions,
+				EndOfLifeDate:      endOfLifeDate,
+				ExcludeFromLatest:  &miSGImageVersionExcludeFromLatest,
+				ReplicaCount:       &miSigReplicaCount,
+				StorageAccountType: compute.StorageAccountType(miSGStorageAccountType),
 			},
 		},
 	}
@@ -81,7 +82,12 @@ func (s *StepPublishToSharedImageGallery) publishToSig(ctx context.Context, mdiI
 		return "", err
 	}
 
+	// Report the replication to the regions while waiting, it can take
+	// long for large images and many regions
+	progressCtx, stopProgress := context.WithCancel(ctx)
+	go s.reportReplicationProgress(progressCtx, miSigPubRg, miSIGalleryName, miSGImageName, miSGImageVersion)
 	err = f.WaitForCompletionRef(ctx, s.client.GalleryImageVersionsClient.Client)
+	stopProgress()
 
 	if err != nil {
 		s.say(s.client.LastError.Error())
@@ -99,6 +105,70 @@ func (s *StepPublishToSharedImageGallery) publishToSig(ctx context.Context, mdiI
 	return *(createdSGImageVersion.ID), nil
 }
 
+func (s *StepPublishToSharedImageGallery) reportReplicationProgress(ctx context.Context, miSigPubRg, miSIGalleryName, miSGImageName, miSGImageVersion string) {
+	ticker := time.NewTicker(replicationProgressInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		version, err := s.client.GalleryImageVersionsClient.Get(ctx, miSigPubRg, miSIGalleryName, miSGImageName, miSGImageVersion, compute.ReplicationStatusTypesReplicationStatus)
+		if err != nil || version.GalleryImageVersionProperties == nil {
+			// The image version isn't visible until its creation is accepted
+			continue
+		}
+		for _, line := range replicationProgress(version.GalleryImageVersionProperties.ReplicationStatus) {
+			s.say(line)
+		}
+	}
+}
+
+// replicationProgress describes the replication of an image version to
+// each of its regions.
+func replicationProgress(status *compute.ReplicationStatus) []string {
+	if status == nil || status.Summary == nil {
+		return nil
+	}
+	var lines []string
+	for _, region := range *status.Summary {
+		if region.Region == nil {
+			continue
+		}
+		line := fmt.Sprintf(" -> SIG replication to %s: %s", *region.Region, region.State)
+		if region.Progress != nil {
+			line += fmt.Sprintf(" (%d%%)", *region.Progress)
+		}
+		if region.State == compute.ReplicationStateFailed && region.Details != nil {
+			line += fmt.Sprintf(": %s", *region.Details)
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// sigTargetRegions returns the regions to replicate the image version to,
+// with the settings of the target regions configured.
+func sigTargetRegions(replicationRegions []string, targetRegions []SharedImageGalleryTargetRegion) []compute.TargetRegion {
+	regions := make([]compute.TargetRegion, len(replicationRegions))
+	for i, v := range replicationRegions {
+		regionName := v
+		regions[i] = compute.TargetRegion{Name: &regionName}
+		for _, target := range targetRegions {
+			if !strings.EqualFold(normalizeAzureRegion(target.Name), normalizeAzureRegion(regionName)) {
+				continue
+			}
+			if target.ReplicaCount > 0 {
+				replicaCount := target.ReplicaCount
+				regions[i].RegionalReplicaCount = &replicaCount
+			}
+			regions[i].StorageAccountType = compute.StorageAccountType(target.StorageAccountType)
+		}
+	}
+	return regions
+}
+
 func (s *StepPublishToSharedImageGallery) Run(ctx context.Context, stateBag multistep.StateBag) multistep.StepAction {
 	if !s.toSIG() {
 		return multistep.ActionContinue
@@ -113,6 +183,8 @@ func (s *StepPublishToSharedImageGallery) Run(ctx context.Context, stateBag mult
 	miSGImageName := stateBag.Get(constants.ArmManagedImageSharedGalleryImageName).(string)
 	miSGImageVersion := stateBag.Get(constants.ArmManagedImageSharedGalleryImageVersion).(string)
 	miSigReplicationRegions := stateBag.Get(constants.ArmManagedImageSharedGalleryReplicationRegions).([]string)
+	miSigTargetRegions, _ := stateBag.Get(constants.ArmManagedImageSharedGalleryTargetRegions).([]SharedImageGalleryTargetRegion)
+	miSGStorageAccountType, _ := stateBag.Get(constants.ArmManagedImageSharedGalleryStorageAccountType).(string)
 
 	tags := stateBag.Get(constants.ArmTags).(map[string]*string)
 	targetManagedImageResourceGroupName := stateBag.Get(constants.ArmManagedImageResourceGroupName).(string)
@@ -140,8 +212,13 @@ func (s *StepPublishToSharedImageGallery) Run(ctx context.Context, stateBag mult
 	s.say(fmt.Sprintf(" -> SIG image version endoflife date      : '%s'", miSGImageVersionEndOfLifeDate))
 	s.say(fmt.Sprintf(" -> SIG image version exclude from latest : '%t'", miSGImageVersionExcludeFromLatest))
 	s.say(fmt.Sprintf(" -> SIG replica count [1, 10]             : '%d'", miSigReplicaCount))
+	s.say(fmt.Sprintf(" -> SIG storage account type              : '%s'", miSGStorageAccountType))
+	for _, target := range miSigTargetRegions {
+		s.say(fmt.Sprintf(" -> SIG target region %s : replicas '%d', storage account type '%s'", target.Name, target.ReplicaCount, target.StorageAccountType))
+	}
 
-	createdGalleryImageVersionID, err := s.publish(ctx, mdiID, miSigPubRg, miSIGalleryName, miSGImageName, miSGImageVersion, miSigReplicationRegions, miSGImageVersionEndOfLifeDate, miSGImageVersionExcludeFromLatest, miSigReplicaCount, location, tags)
+	targetRegions := sigTargetRegions(miSigReplicationRegions, miSigTargetRegions)
+	createdGalleryImageVersionID, err := s.publish(ctx, mdiID, miSigPubRg, miSIGalleryName, miSGImageName, miSGImageVersion, targetRegions, miSGImageVersionEndOfLifeDate, miSGImageVersionExcludeFromLatest, miSigReplicaCount, miSGStorageAccountType, location, tags)
 
 	if err != nil {
 		stateBag.Put(constants.Error, err)
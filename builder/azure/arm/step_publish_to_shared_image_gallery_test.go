@@ -2,15 +2,17 @@ package arm
 
 import (
 	"context"
+	"strings"
 	"testing"
 
+	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2019-03-01/compute"
 	"github.com/hashicorp/packer/builder/azure/common/constants"
 	"github.com/hashicorp/packer/packer-plugin-sdk/multistep"
 )
 
 func TestStepPublishToSharedImageGalleryShouldNotPublishForVhd(t *testing.T) {
 	var testSubject = &StepPublishToSharedImageGallery{
-		publish: func(context.Context, string, string, string, string, string, []string, string, bool, int32, string, map[string]*string) (string, error) {
+		publish: func(context.Context, string, string, string, string, string, []compute.TargetRegion, string, bool, int32, string, string, map[string]*string) (string, error) {
 			return "test", nil
 		},
 		say:   func(message string) {},
@@ -31,7 +33,7 @@ func TestStepPublishToSharedImageGalleryShouldNotPublishForVhd(t *testing.T) {
 
 func TestStepPublishToSharedImageGalleryShouldPublishForManagedImageWithSig(t *testing.T) {
 	var testSubject = &StepPublishToSharedImageGallery{
-		publish: func(context.Context, string, string, string, string, string, []string, string, bool, int32, string, map[string]*string) (string, error) {
+		publish: func(context.Context, string, string, string, string, string, []compute.TargetRegion, string, bool, int32, string, string, map[string]*string) (string, error) {
 			return "", nil
 		},
 		say:   func(message string) {},
@@ -83,3 +85,67 @@ func createTestStateBagStepPublishToSharedImageGalleryForVhd() multistep.StateBa
 
 	return stateBag
 }
+
+func TestStepPublishToSharedImageGalleryShouldApplyTargetRegions(t *testing.T) {
+	var actualRegions []compute.TargetRegion
+	var actualStorageAccountType string
+	var testSubject = &StepPublishToSharedImageGallery{
+		publish: func(_ context.Context, _, _, _, _, _ string, targetRegions []compute.TargetRegion, _ string, _ bool, _ int32, storageAccountType string, _ string, _ map[string]*string) (string, error) {
+			actualRegions = targetRegions
+			actualStorageAccountType = storageAccountType
+			return "id", nil
+		},
+		say:   func(message string) {},
+		error: func(e error) {},
+		toSIG: func() bool { return true },
+	}
+
+	stateBag := createTestStateBagStepPublishToSharedImageGallery()
+	stateBag.Put(constants.ArmManagedImageSharedGalleryStorageAccountType, "Standard_ZRS")
+	stateBag.Put(constants.ArmManagedImageSharedGalleryTargetRegions, []SharedImageGalleryTargetRegion{
+		{Name: "ManagedImageSharedGalleryReplicationRegionB", ReplicaCount: 3, StorageAccountType: "Standard_LRS"},
+	})
+	if result := testSubject.Run(context.Background(), stateBag); result != multistep.ActionContinue {
+		t.Fatalf("Expected the step to return 'ActionContinue', but got '%d'.", result)
+	}
+
+	if actualStorageAccountType != "Standard_ZRS" {
+		t.Fatalf("Expected the storage account type 'Standard_ZRS', but got '%s'.", actualStorageAccountType)
+	}
+	if len(actualRegions) != 2 {
+		t.Fatalf("Expected 2 target regions, but got %d.", len(actualRegions))
+	}
+	if actualRegions[0].RegionalReplicaCount != nil || actualRegions[0].StorageAccountType != "" {
+		t.Fatalf("Expected region A to use the settings of the image version, but got %#v.", actualRegions[0])
+	}
+	if *actualRegions[1].RegionalReplicaCount != 3 || actualRegions[1].StorageAccountType != compute.StorageAccountTypeStandardLRS {
+		t.Fatalf("Expected region B to use its own settings, but got %#v.", actualRegions[1])
+	}
+}
+
+func TestReplicationProgress(t *testing.T) {
+	region := func(name string, state compute.ReplicationState, progress int32, details string) compute.RegionalReplicationStatus {
+		return compute.RegionalReplicationStatus{Region: &name, State: state, Progress: &progress, Details: &details}
+	}
+	status := &compute.ReplicationStatus{
+		Summary: &[]compute.RegionalReplicationStatus{
+			region("westus", compute.ReplicationStateCompleted, 100, ""),
+			region("eastus", compute.ReplicationStateReplicating, 42, ""),
+			region("northeurope", compute.ReplicationStateFailed, 10, "quota exceeded"),
+		},
+	}
+
+	expected := []string{
+		" -> SIG replication to westus: Completed (100%)",
+		" -> SIG replication to eastus: Replicating (42%)",
+		" -> SIG replication to northeurope: Failed (10%): quota exceeded",
+	}
+	actual := replicationProgress(status)
+	if strings.Join(actual, "\n") != strings.Join(expected, "\n") {
+		t.Fatalf("Expected %q, but got %q.", expected, actual)
+	}
+
+	if lines := replicationProgress(nil); len(lines) != 0 {
+		t.Fatalf("Expected no progress without status, but got %q.", lines)
+	}
+}
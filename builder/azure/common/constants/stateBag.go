@@ -50,6 +50,8 @@ const (
 	ArmManagedImageSharedGalleryImageVersionEndOfLifeDate     string = "arm.ArmManagedImageSharedGalleryImageVersionEndOfLifeDate"
 	ArmManagedImageSharedGalleryImageVersionReplicaCount      string = "arm.ArmManagedImageSharedGalleryImageVersionReplicaCount"
 	ArmManagedImageSharedGalleryImageVersionExcludeFromLatest string = "arm.ArmManagedImageSharedGalleryImageVersionExcludeFromLatest"
+	ArmManagedImageSharedGalleryStorageAccountType            string = "arm.ArmManagedImageSharedGalleryStorageAccountType"
+	ArmManagedImageSharedGalleryTargetRegions                 string = "arm.ArmManagedImageSharedGalleryTargetRegions"
 	ArmManagedImageSubscription                               string = "arm.ArmManagedImageSubscription"
 	ArmAsyncResourceGroupDelete                               string = "arm.AsyncResourceGroupDelete"
 	ArmManagedImageOSDiskSnapshotName                         string = "arm.ManagedImageOSDiskSnapshotName"
@@ -61,10 +61,16 @@ func (b *Builder) Run(ctx context.Context, ui packer.Ui, hook packer.Hook) (pack
 	steps := []multistep.Step{
 		&stepPrepareConfig{},
 		&commonsteps.StepHTTPServer{
-			HTTPDir:     b.config.HTTPDir,
-			HTTPPortMin: b.config.HTTPPortMin,
-			HTTPPortMax: b.config.HTTPPortMax,
-			HTTPAddress: b.config.HTTPAddress,
+			HTTPDir:      b.config.HTTPDir,
+			HTTPPortMin:  b.config.HTTPPortMin,
+			HTTPPortMax:  b.config.HTTPPortMax,
+			HTTPAddress:  b.config.HTTPAddress,
+			UseTLS:       b.config.HTTPUseTLS,
+			TLSCertFile:  b.config.HTTPTLSCertFile,
+			TLSKeyFile:   b.config.HTTPTLSKeyFile,
+			AuthToken:    b.config.HTTPAuthToken,
+			Templates:    b.config.HTTPTemplates,
+			TemplateVars: b.config.HTTPTemplateVars,
 		},
 		&stepKeypair{
 			Debug:        b.config.PackerDebug,
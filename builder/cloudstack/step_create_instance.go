@@ -16,8 +16,9 @@ import (
 
 // userDataTemplateData represents variables for user_data interpolation
 type userDataTemplateData struct {
-	HTTPIP   string
-	HTTPPort int
+	HTTPIP    string
+	HTTPPort  int
+	HTTPToken string
 }
 
 // stepCreateInstance represents a Packer build step that creates CloudStack instances.
@@ -95,9 +96,11 @@ func (s *stepCreateInstance) Run(ctx context.Context, state multistep.StateBag)
 		}
 		state.Put("http_ip", httpIP)
 
+		httpToken, _ := state.Get("http_token").(string)
 		s.Ctx.Data = &userDataTemplateData{
 			httpIP,
 			httpPort,
+			httpToken,
 		}
 
 		ud, err := s.generateUserData(config.UserData, config.HTTPGetOnly)
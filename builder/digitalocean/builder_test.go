@@ -1,6 +1,7 @@
 package digitalocean
 
 import (
+	"os"
 	"strconv"
 	"testing"
 	"time"
@@ -56,6 +57,28 @@ func TestBuilderPrepare_InvalidKey(t *testing.T) {
 	}
 }
 
+func TestBuilderPrepare_APIToken(t *testing.T) {
+	var b Builder
+	config := testConfig()
+	delete(config, "api_token")
+	if os.Getenv("DIGITALOCEAN_API_TOKEN") != "" {
+		t.Skip("DIGITALOCEAN_API_TOKEN is set")
+	}
+
+	_, _, err := b.Prepare(config)
+	if err == nil {
+		t.Fatal("should error without api_token")
+	}
+
+	// The token is not required to validate the configuration
+	b = Builder{}
+	config[packer.ValidateConfigKey] = true
+	_, _, err = b.Prepare(config)
+	if err != nil {
+		t.Fatalf("should not error when validating: %s", err)
+	}
+}
+
 func TestBuilderPrepare_Region(t *testing.T) {
 	var b Builder
 	config := testConfig()
@@ -153,8 +153,9 @@ func (c *Config) Prepare(raws ...interface{}) ([]string, error) {
 	if es := c.Comm.Prepare(&c.ctx); len(es) > 0 {
 		errs = packer.MultiErrorAppend(errs, es...)
 	}
-	if c.APIToken == "" {
-		// Required configurations that will display errors if not set
+	if c.APIToken == "" && !c.ctx.Validate {
+		// Required configurations that will display errors if not set. The
+		// token may be missing when the configuration is only validated.
 		errs = packer.MultiErrorAppend(
 			errs, errors.New("api_token for auth must be specified"))
 	}
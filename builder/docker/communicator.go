@@ -27,9 +27,14 @@ type Communicator struct {
 	ContainerUser string
 	lock          sync.Mutex
 	EntryPoint    []string
+
+	// layers saves the intermediate images of layer_cache, it is nil when
+	// layer_cache isn't set.
+	layers *layerStore
 }
 
 var _ packer.Communicator = new(Communicator)
+var _ packer.Layerer = new(Communicator)
 
 func (c *Communicator) Start(ctx context.Context, remote *packer.RemoteCmd) error {
 	dockerArgs := []string{
@@ -353,3 +358,26 @@ func (c *Communicator) fixDestinationOwner(destination string) error {
 
 	return nil
 }
+
+func (c *Communicator) LayersEnabled() (bool, error) {
+	return c.layers != nil, nil
+}
+
+func (c *Communicator) ResumeFromLayer(key string) (bool, error) {
+	if c.layers == nil {
+		return false, nil
+	}
+	id, err := c.layers.resume(c.ContainerID, key)
+	if err != nil || id == "" {
+		return false, err
+	}
+	c.ContainerID = id
+	return true, nil
+}
+
+func (c *Communicator) CommitLayer(key string) error {
+	if c.layers == nil {
+		return nil
+	}
+	return c.layers.commit(c.ContainerID, key)
+}
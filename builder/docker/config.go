@@ -99,6 +99,20 @@ type Config struct {
 	// running on a windows host. This is necessary for building Windows
 	// containers, because our normal docker bindings do not work for them.
 	WindowsContainer bool `mapstructure:"windows_container" required:"false"`
+	// If true, an intermediate image is committed after each provisioner,
+	// labelled with a hash of the definitions of the provisioners run so far
+	// and of the base image. The next builds start from the intermediate
+	// image of the last provisioners that didn't change instead of running
+	// them again, like the layer cache of `docker build`. Only the definition
+	// of the provisioners is hashed, not the content of the files or scripts
+	// they upload, so change the provisioner when those change. Not supported
+	// with `windows_container`. Defaults to false.
+	LayerCache bool `mapstructure:"layer_cache" required:"false"`
+	// The repository to tag the intermediate images of `layer_cache` with,
+	// using the first 12 characters of their hash as tag, so they can be
+	// found with `docker images`. The intermediate images are only labelled
+	// by default.
+	LayerRepository string `mapstructure:"layer_repository" required:"false"`
 
 	// This is used to login to dockerhub to pull a private base container. For
 	// pushing to dockerhub, see the docker post-processors
@@ -199,6 +213,14 @@ func (c *Config) Prepare(raws ...interface{}) ([]string, error) {
 		}
 	}
 
+	if c.LayerCache && c.WindowsContainer {
+		errs = packer.MultiErrorAppend(errs, fmt.Errorf("layer_cache is not supported with windows_container."))
+	}
+
+	if c.LayerRepository != "" && !c.LayerCache {
+		errs = packer.MultiErrorAppend(errs, fmt.Errorf("layer_repository requires layer_cache to be true."))
+	}
+
 	if c.EcrLogin && c.LoginServer == "" {
 		errs = packer.MultiErrorAppend(errs, fmt.Errorf("ECR login requires login server to be provided."))
 	}
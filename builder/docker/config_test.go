@@ -145,3 +145,28 @@ func TestConfigPrepare_pull(t *testing.T) {
 		t.Fatal("should not pull")
 	}
 }
+
+func TestConfigPrepare_layerCache(t *testing.T) {
+	raw := testConfig()
+	raw["layer_cache"] = true
+	raw["layer_repository"] = "packer-layers"
+	var c Config
+	warns, errs := c.Prepare(raw)
+	testConfigOk(t, warns, errs)
+	if !c.LayerCache || c.LayerRepository != "packer-layers" {
+		t.Fatalf("bad: %t, %s", c.LayerCache, c.LayerRepository)
+	}
+
+	// Windows containers
+	raw["windows_container"] = true
+	c = Config{}
+	warns, errs = c.Prepare(raw)
+	testConfigErr(t, warns, errs)
+
+	// Repository without the cache
+	raw = testConfig()
+	raw["layer_repository"] = "packer-layers"
+	c = Config{}
+	warns, errs = c.Prepare(raw)
+	testConfigErr(t, warns, errs)
+}
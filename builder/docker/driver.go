@@ -22,6 +22,10 @@ type Driver interface {
 	// Import imports a container from a tar file
 	Import(path string, changes []string, repo string) (string, error)
 
+	// ImageByLabels returns the ID of an image with all the given labels, or
+	// an empty string if there is none.
+	ImageByLabels(labels map[string]string) (string, error)
+
 	// IPAddress returns the address of the container that can be used
 	// for external access.
 	IPAddress(id string) (string, error)
@@ -142,6 +142,28 @@ func (d *DockerDriver) Import(path string, changes []string, repo string) (strin
 	return strings.TrimSpace(stdout.String()), nil
 }
 
+func (d *DockerDriver) ImageByLabels(labels map[string]string) (string, error) {
+	args := []string{"images", "--quiet", "--no-trunc"}
+	for k, v := range labels {
+		args = append(args, "--filter", fmt.Sprintf("label=%s=%s", k, v))
+	}
+
+	var stderr, stdout bytes.Buffer
+	cmd := exec.Command("docker", args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("Error: %s\n\nStderr: %s", err, stderr.String())
+	}
+
+	// Images are listed from the most recent one
+	ids := strings.Fields(stdout.String())
+	if len(ids) == 0 {
+		return "", nil
+	}
+	return ids[0], nil
+}
+
 func (d *DockerDriver) IPAddress(id string) (string, error) {
 	var stderr, stdout bytes.Buffer
 	cmd := exec.Command(
@@ -11,6 +11,7 @@ type MockDriver struct {
 	CommitCalled      bool
 	CommitContainerId string
 	CommitImageId     string
+	CommitChanges     []string
 	CommitErr         error
 
 	DeleteImageCalled bool
@@ -23,6 +24,11 @@ type MockDriver struct {
 	ImportId     string
 	ImportErr    error
 
+	ImageByLabelsCalled bool
+	ImageByLabelsLabels map[string]string
+	ImageByLabelsId     string
+	ImageByLabelsErr    error
+
 	IPAddressCalled bool
 	IPAddressID     string
 	IPAddressResult string
@@ -87,6 +93,7 @@ type MockDriver struct {
 func (d *MockDriver) Commit(id string, author string, changes []string, message string) (string, error) {
 	d.CommitCalled = true
 	d.CommitContainerId = id
+	d.CommitChanges = changes
 	return d.CommitImageId, d.CommitErr
 }
 
@@ -117,6 +124,12 @@ func (d *MockDriver) Import(path string, changes []string, repo string) (string,
 	return d.ImportId, d.ImportErr
 }
 
+func (d *MockDriver) ImageByLabels(labels map[string]string) (string, error) {
+	d.ImageByLabelsCalled = true
+	d.ImageByLabelsLabels = labels
+	return d.ImageByLabelsId, d.ImageByLabelsErr
+}
+
 func (d *MockDriver) IPAddress(id string) (string, error) {
 	d.IPAddressCalled = true
 	d.IPAddressID = id
@@ -0,0 +1,119 @@
+package docker
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/hashicorp/packer/packer-plugin-sdk/multistep"
+)
+
+const (
+	// layerLabel labels the intermediate images of layer_cache with the key
+	// of their layer.
+	layerLabel = "packer.layer"
+	// layerBaseLabel labels the intermediate images of layer_cache with the
+	// ID of the image the build started from.
+	layerBaseLabel = "packer.layer.base"
+)
+
+// layerStore saves the container as an intermediate image after each
+// provisioner and replaces the container with a saved intermediate image, for
+// layer_cache.
+type layerStore struct {
+	driver Driver
+	// runConfig is the configuration the container was started with.
+	runConfig ContainerConfig
+	// baseImage is the ID of the image the build started from, so that the
+	// intermediate images of another base image aren't used.
+	baseImage  string
+	repository string
+	// containerStarted is called with the ID of the container started from an
+	// intermediate image, after the previous container is killed.
+	containerStarted func(id string)
+}
+
+// newLayerStore returns the layerStore of the container of the build.
+func newLayerStore(state multistep.StateBag) (*layerStore, error) {
+	config := state.Get("config").(*Config)
+	driver := state.Get("driver").(Driver)
+	tempDir := state.Get("temp_dir").(string)
+
+	baseImage, err := driver.Sha256(config.Image)
+	if err != nil {
+		return nil, fmt.Errorf("Error reading the ID of image %s: %s", config.Image, err)
+	}
+
+	return &layerStore{
+		driver:     driver,
+		runConfig:  *containerConfig(config, tempDir),
+		baseImage:  baseImage,
+		repository: config.LayerRepository,
+		containerStarted: func(id string) {
+			state.Put("container_id", id)
+			state.Put("instance_id", id)
+		},
+	}, nil
+}
+
+func (l *layerStore) labels(key string) map[string]string {
+	return map[string]string{
+		layerLabel:     key,
+		layerBaseLabel: l.baseImage,
+	}
+}
+
+// resume starts a container from the intermediate image of key in place of
+// container id. It returns the ID of the new container, or an empty string
+// when there is no intermediate image of key.
+func (l *layerStore) resume(id string, key string) (string, error) {
+	image, err := l.driver.ImageByLabels(l.labels(key))
+	if err != nil {
+		return "", fmt.Errorf("Error looking for the image of layer %s: %s", key, err)
+	}
+	if image == "" {
+		return "", nil
+	}
+	log.Printf("Resuming from image %s of layer %s", image, key)
+
+	runConfig := l.runConfig
+	runConfig.Image = image
+	newId, err := l.driver.StartContainer(&runConfig)
+	if err != nil {
+		return "", fmt.Errorf("Error running container from image %s: %s", image, err)
+	}
+
+	// Errors usually just mean that the container doesn't exist anymore, like
+	// when StepRun kills it.
+	if err := l.driver.KillContainer(id); err != nil {
+		log.Printf("Error killing container %s: %s", id, err)
+	}
+	if l.containerStarted != nil {
+		l.containerStarted(newId)
+	}
+	return newId, nil
+}
+
+// commit saves container id as the intermediate image of key.
+func (l *layerStore) commit(id string, key string) error {
+	changes := []string{
+		fmt.Sprintf("LABEL %s=%s", layerLabel, key),
+		fmt.Sprintf("LABEL %s=%s", layerBaseLabel, l.baseImage),
+	}
+	image, err := l.driver.Commit(id, "", changes, "")
+	if err != nil {
+		return fmt.Errorf("Error committing the image of layer %s: %s", key, err)
+	}
+	log.Printf("Committed image %s of layer %s", image, key)
+
+	if l.repository != "" {
+		tag := key
+		if len(tag) > 12 {
+			tag = tag[:12]
+		}
+		repo := fmt.Sprintf("%s:%s", l.repository, tag)
+		if err := l.driver.TagImage(image, repo, true); err != nil {
+			return fmt.Errorf("Error tagging the image of layer %s: %s", key, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+package docker
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/packer/packer"
+)
+
+func testLayerCommunicator(t *testing.T) (*Communicator, *MockDriver, map[string]interface{}) {
+	state := testState(t)
+	state.Put("temp_dir", "/tmp/packer")
+	state.Put("container_id", "foo")
+	config := state.Get("config").(*Config)
+	config.LayerCache = true
+	config.LayerRepository = "packer-layers"
+	driver := state.Get("driver").(*MockDriver)
+	driver.Sha256Result = "sha256:base"
+
+	layers, err := newLayerStore(state)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	started := make(map[string]interface{})
+	containerStarted := layers.containerStarted
+	layers.containerStarted = func(id string) {
+		containerStarted(id)
+		started["container_id"] = state.Get("container_id")
+		started["instance_id"] = state.Get("instance_id")
+	}
+	return &Communicator{ContainerID: "foo", Config: config, layers: layers}, driver, started
+}
+
+func TestCommunicator_ImplementsLayerer(t *testing.T) {
+	var _ packer.Layerer = new(Communicator)
+}
+
+func TestCommunicator_layersDisabled(t *testing.T) {
+	comm := &Communicator{ContainerID: "foo"}
+	if enabled, err := packer.LayersEnabled(comm); err != nil || enabled {
+		t.Fatalf("bad: %t, %v", enabled, err)
+	}
+	if resumed, err := comm.ResumeFromLayer("key"); err != nil || resumed {
+		t.Fatalf("bad: %t, %v", resumed, err)
+	}
+	if err := comm.CommitLayer("key"); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+}
+
+func TestCommunicator_resumeFromLayer(t *testing.T) {
+	comm, driver, started := testLayerCommunicator(t)
+	driver.ImageByLabelsId = "sha256:layer"
+	driver.StartID = "bar"
+
+	if enabled, err := packer.LayersEnabled(comm); err != nil || !enabled {
+		t.Fatalf("bad: %t, %v", enabled, err)
+	}
+	resumed, err := comm.ResumeFromLayer("0123456789abcdef")
+	if err != nil || !resumed {
+		t.Fatalf("bad: %t, %v", resumed, err)
+	}
+
+	expected := map[string]string{
+		"packer.layer":      "0123456789abcdef",
+		"packer.layer.base": "sha256:base",
+	}
+	if !reflect.DeepEqual(driver.ImageByLabelsLabels, expected) {
+		t.Fatalf("bad labels: %#v", driver.ImageByLabelsLabels)
+	}
+	if driver.StartConfig.Image != "sha256:layer" {
+		t.Fatalf("bad image: %s", driver.StartConfig.Image)
+	}
+	if driver.StartConfig.Volumes["/tmp/packer"] != comm.Config.ContainerDir {
+		t.Fatalf("bad volumes: %#v", driver.StartConfig.Volumes)
+	}
+	if driver.KillID != "foo" {
+		t.Fatalf("should kill the previous container: %s", driver.KillID)
+	}
+	if comm.ContainerID != "bar" {
+		t.Fatalf("bad container: %s", comm.ContainerID)
+	}
+	if started["container_id"] != "bar" || started["instance_id"] != "bar" {
+		t.Fatalf("should save the new container: %#v", started)
+	}
+}
+
+func TestCommunicator_resumeFromLayerMissing(t *testing.T) {
+	comm, driver, _ := testLayerCommunicator(t)
+
+	resumed, err := comm.ResumeFromLayer("key")
+	if err != nil || resumed {
+		t.Fatalf("bad: %t, %v", resumed, err)
+	}
+	if driver.StartCalled || driver.KillCalled {
+		t.Fatal("should not replace the container")
+	}
+	if comm.ContainerID != "foo" {
+		t.Fatalf("bad container: %s", comm.ContainerID)
+	}
+
+	driver.ImageByLabelsErr = errors.New("foo")
+	if _, err := comm.ResumeFromLayer("key"); err == nil {
+		t.Fatal("should error")
+	}
+}
+
+func TestCommunicator_commitLayer(t *testing.T) {
+	comm, driver, _ := testLayerCommunicator(t)
+	driver.CommitImageId = "sha256:layer"
+
+	if err := comm.CommitLayer("0123456789abcdef"); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if driver.CommitContainerId != "foo" {
+		t.Fatalf("bad container: %s", driver.CommitContainerId)
+	}
+	expected := []string{
+		"LABEL packer.layer=0123456789abcdef",
+		"LABEL packer.layer.base=sha256:base",
+	}
+	if !reflect.DeepEqual(driver.CommitChanges, expected) {
+		t.Fatalf("bad changes: %#v", driver.CommitChanges)
+	}
+	if driver.TagImageImageId != "sha256:layer" ||
+		!reflect.DeepEqual(driver.TagImageRepo, []string{"packer-layers:0123456789ab"}) {
+		t.Fatalf("bad tag: %s, %#v", driver.TagImageImageId, driver.TagImageRepo)
+	}
+
+	driver.CommitErr = errors.New("foo")
+	if err := comm.CommitLayer("key"); err == nil {
+		t.Fatal("should error")
+	}
+}
@@ -35,8 +35,17 @@ func (s *StepCommit) Run(ctx context.Context, state multistep.StateBag) multiste
 			return multistep.ActionHalt
 		}
 	}
+	changes := config.Changes
+	if config.LayerCache {
+		// Don't inherit the labels of the intermediate image the container
+		// was started from, the image isn't one of the layers
+		changes = append([]string{
+			fmt.Sprintf("LABEL %s= %s=", layerLabel, layerBaseLabel),
+		}, changes...)
+	}
+
 	ui.Say("Committing the container")
-	imageId, err := driver.Commit(containerId, config.Author, config.Changes, config.Message)
+	imageId, err := driver.Commit(containerId, config.Author, changes, config.Message)
 	if err != nil {
 		state.Put("error", err)
 		ui.Error(err.Error())
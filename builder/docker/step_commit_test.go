@@ -3,6 +3,7 @@ package docker
 import (
 	"context"
 	"errors"
+	"reflect"
 	"testing"
 
 	"github.com/hashicorp/packer/packer-plugin-sdk/multistep"
@@ -66,3 +67,24 @@ func TestStepCommit_error(t *testing.T) {
 		t.Fatal("shouldn't save image ID")
 	}
 }
+
+func TestStepCommit_layerCache(t *testing.T) {
+	state := testStepCommitState(t)
+	step := new(StepCommit)
+	defer step.Cleanup(state)
+
+	config := state.Get("config").(*Config)
+	config.LayerCache = true
+	config.Changes = []string{"EXPOSE 8080"}
+	driver := state.Get("driver").(*MockDriver)
+
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("bad action: %#v", action)
+	}
+
+	// The image must not be found as one of the layers
+	expected := []string{"LABEL packer.layer= packer.layer.base=", "EXPOSE 8080"}
+	if !reflect.DeepEqual(driver.CommitChanges, expected) {
+		t.Fatalf("bad changes: %#v", driver.CommitChanges)
+	}
+}
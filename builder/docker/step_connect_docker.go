@@ -61,6 +61,13 @@ func (s *StepConnectDocker) Run(ctx context.Context, state multistep.StateBag) m
 			ContainerUser: containerUser,
 			EntryPoint:    []string{"/bin/sh", "-c"},
 		}
+		if config.LayerCache {
+			comm.layers, err = newLayerStore(state)
+			if err != nil {
+				state.Put("error", err)
+				return multistep.ActionHalt
+			}
+		}
 		state.Put("communicator", comm)
 	}
 	return multistep.ActionContinue
@@ -22,27 +22,12 @@ func (s *StepRun) Run(ctx context.Context, state multistep.StateBag) multistep.S
 		return multistep.ActionHalt
 	}
 
-	runConfig := ContainerConfig{
-		Image:      config.Image,
-		RunCommand: config.RunCommand,
-		Device:     config.Device,
-		TmpFs:      config.TmpFs,
-		Volumes:    make(map[string]string),
-		CapAdd:     config.CapAdd,
-		CapDrop:    config.CapDrop,
-		Privileged: config.Privileged,
-	}
-
-	for host, container := range config.Volumes {
-		runConfig.Volumes[host] = container
-	}
-
 	tempDir := state.Get("temp_dir").(string)
-	runConfig.Volumes[tempDir] = config.ContainerDir
+	runConfig := containerConfig(config, tempDir)
 
 	driver := state.Get("driver").(Driver)
 	ui.Say("Starting docker container...")
-	containerId, err := driver.StartContainer(&runConfig)
+	containerId, err := driver.StartContainer(runConfig)
 	if err != nil {
 		err := fmt.Errorf("Error running container: %s", err)
 		state.Put("error", err)
@@ -65,6 +50,12 @@ func (s *StepRun) Cleanup(state multistep.StateBag) {
 		return
 	}
 
+	// layer_cache replaces the container with one started from an
+	// intermediate image
+	if containerId, ok := state.GetOk("container_id"); ok {
+		s.containerId = containerId.(string)
+	}
+
 	driver := state.Get("driver").(Driver)
 	ui := state.Get("ui").(packer.Ui)
 
@@ -77,3 +68,25 @@ func (s *StepRun) Cleanup(state multistep.StateBag) {
 	// Reset the container ID so that we're idempotent
 	s.containerId = ""
 }
+
+// containerConfig returns the configuration to start the container of the
+// build with, mounting tempDir into it.
+func containerConfig(config *Config, tempDir string) *ContainerConfig {
+	runConfig := &ContainerConfig{
+		Image:      config.Image,
+		RunCommand: config.RunCommand,
+		Device:     config.Device,
+		TmpFs:      config.TmpFs,
+		Volumes:    make(map[string]string),
+		CapAdd:     config.CapAdd,
+		CapDrop:    config.CapDrop,
+		Privileged: config.Privileged,
+	}
+
+	for host, container := range config.Volumes {
+		runConfig.Volumes[host] = container
+	}
+	runConfig.Volumes[tempDir] = config.ContainerDir
+
+	return runConfig
+}
@@ -103,7 +103,7 @@ func (b *Builder) Run(ctx context.Context, ui packer.Ui, hook packer.Hook) (pack
 	if _, exists := b.config.Metadata[StartupScriptKey]; exists || b.config.StartupScriptFile != "" {
 		steps = append(steps, new(StepWaitStartupScript))
 	}
-	steps = append(steps, new(StepTeardownInstance), new(StepCreateImage))
+	steps = append(steps, new(StepTeardownInstance), new(StepCreateImage), new(StepDeprecatePreviousImages))
 
 	// Run the steps.
 	b.runner = commonsteps.NewRunner(steps, b.config.PackerConfig, ui)
@@ -106,6 +106,20 @@ type Config struct {
 	// image name. The image family always returns its latest image that is not
 	// deprecated.
 	ImageFamily string `mapstructure:"image_family" required:"false"`
+	// Once the image is created, mark the images of `image_family` built
+	// before as `DEPRECATED`, replaced by the image created. Only the images
+	// of the project that aren't deprecated yet are marked. Requires
+	// `image_family`. Defaults to `false`.
+	DeprecatePreviousImages bool `mapstructure:"deprecate_previous_images" required:"false"`
+	// The time after which the images deprecated by
+	// `deprecate_previous_images` are marked `OBSOLETE`, like `720h` for 30
+	// days. Obsolete images can't be used to create disks anymore.
+	PreviousImagesObsoleteAfter time.Duration `mapstructure:"previous_images_obsolete_after" required:"false"`
+	// The time after which the images deprecated by
+	// `deprecate_previous_images` are scheduled to be marked `DELETED`, like
+	// `2160h` for 90 days. Google Compute Engine only records the date, the
+	// images have to be deleted by a cleanup job.
+	PreviousImagesDeleteAfter time.Duration `mapstructure:"previous_images_delete_after" required:"false"`
 	// Key/value pair labels to apply to the created image.
 	ImageLabels map[string]string `mapstructure:"image_labels" required:"false"`
 	// Licenses to apply to the created image.
@@ -396,6 +410,27 @@ func (c *Config) Prepare(raws ...interface{}) ([]string, error) {
 		}
 	}
 
+	if c.DeprecatePreviousImages && c.ImageFamily == "" {
+		errs = packer.MultiErrorAppend(errs,
+			errors.New("deprecate_previous_images requires image_family"))
+	}
+
+	if !c.DeprecatePreviousImages && (c.PreviousImagesObsoleteAfter != 0 || c.PreviousImagesDeleteAfter != 0) {
+		errs = packer.MultiErrorAppend(errs,
+			errors.New("previous_images_obsolete_after and previous_images_delete_after require deprecate_previous_images"))
+	}
+
+	if c.PreviousImagesObsoleteAfter < 0 || c.PreviousImagesDeleteAfter < 0 {
+		errs = packer.MultiErrorAppend(errs,
+			errors.New("previous_images_obsolete_after and previous_images_delete_after must be positive"))
+	}
+
+	if c.PreviousImagesObsoleteAfter != 0 && c.PreviousImagesDeleteAfter != 0 &&
+		c.PreviousImagesDeleteAfter < c.PreviousImagesObsoleteAfter {
+		errs = packer.MultiErrorAppend(errs,
+			errors.New("previous_images_delete_after must not be before previous_images_obsolete_after"))
+	}
+
 	if len(c.ImageStorageLocations) > 1 {
 		errs = packer.MultiErrorAppend(errs,
 			errors.New("Invalid image storage locations: Must not have more than 1 region"))
@@ -256,6 +256,71 @@ func TestConfigPrepare(t *testing.T) {
 	}
 }
 
+func TestConfigPrepareDeprecatePreviousImages(t *testing.T) {
+	cases := []struct {
+		Keys map[string]interface{}
+		Err  bool
+	}{
+		{
+			map[string]interface{}{"deprecate_previous_images": true},
+			false,
+		},
+		{
+			map[string]interface{}{
+				"deprecate_previous_images":      true,
+				"previous_images_obsolete_after": "720h",
+				"previous_images_delete_after":   "2160h",
+			},
+			false,
+		},
+		{
+			map[string]interface{}{"deprecate_previous_images": true, "image_family": nil},
+			true,
+		},
+		{
+			map[string]interface{}{"previous_images_delete_after": "2160h"},
+			true,
+		},
+		{
+			map[string]interface{}{
+				"deprecate_previous_images":      true,
+				"previous_images_obsolete_after": "2160h",
+				"previous_images_delete_after":   "720h",
+			},
+			true,
+		},
+		{
+			map[string]interface{}{
+				"deprecate_previous_images":    true,
+				"previous_images_delete_after": "-1h",
+			},
+			true,
+		},
+	}
+
+	for _, tc := range cases {
+		raw, tempfile := testConfig(t)
+		defer os.Remove(tempfile)
+
+		for k, v := range tc.Keys {
+			if v == nil {
+				delete(raw, k)
+			} else {
+				raw[k] = v
+			}
+		}
+
+		var c Config
+		warns, errs := c.Prepare(raw)
+
+		if tc.Err {
+			testConfigErr(t, warns, errs, fmt.Sprintf("%v", tc.Keys))
+		} else {
+			testConfigOk(t, warns, errs)
+		}
+	}
+}
+
 func TestConfigPrepareAccelerator(t *testing.T) {
 	cases := []struct {
 		Keys   []string
@@ -19,6 +19,10 @@ type Driver interface {
 	// DeleteImage deletes the image with the given name.
 	DeleteImage(name string) <-chan error
 
+	// DeprecateImage sets the deprecation status of the image with the given
+	// name.
+	DeprecateImage(name string, deprecation *compute.DeprecationStatus) <-chan error
+
 	// DeleteInstance deletes the given instance, keeping the boot disk.
 	DeleteInstance(zone, name string) (<-chan error, error)
 
@@ -39,6 +43,10 @@ type Driver interface {
 	// is true, name designates an image family instead of a particular image.
 	GetImageFromProject(project, name string, fromFamily bool) (*Image, error)
 
+	// GetActiveFamilyImages gets the images of the family in the default
+	// project that aren't deprecated.
+	GetActiveFamilyImages(family string) ([]*Image, error)
+
 	// GetInstanceMetadata gets a metadata variable for the instance, name.
 	GetInstanceMetadata(zone, name, key string) (string, error)
 
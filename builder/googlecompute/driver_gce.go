@@ -210,6 +210,18 @@ func (d *driverGCE) DeleteImage(name string) <-chan error {
 	return errCh
 }
 
+func (d *driverGCE) DeprecateImage(name string, deprecation *compute.DeprecationStatus) <-chan error {
+	errCh := make(chan error, 1)
+	op, err := d.service.Images.Deprecate(d.projectId, name, deprecation).Do()
+	if err != nil {
+		errCh <- err
+	} else {
+		go waitForState(errCh, "DONE", d.refreshGlobalOp(op))
+	}
+
+	return errCh
+}
+
 func (d *driverGCE) DeleteInstance(zone, name string) (<-chan error, error) {
 	op, err := d.service.Instances.Delete(d.projectId, zone, name).Do()
 	if err != nil {
@@ -302,6 +314,32 @@ func (d *driverGCE) GetImageFromProject(project, name string, fromFamily bool) (
 	}
 }
 
+func (d *driverGCE) GetActiveFamilyImages(family string) ([]*Image, error) {
+	var images []*Image
+	filter := fmt.Sprintf("family = %q", family)
+	err := d.service.Images.List(d.projectId).Filter(filter).Pages(context.TODO(), func(list *compute.ImageList) error {
+		for _, image := range list.Items {
+			if image.Deprecated != nil && image.Deprecated.State != "" && image.Deprecated.State != "ACTIVE" {
+				continue
+			}
+			images = append(images, &Image{
+				GuestOsFeatures: image.GuestOsFeatures,
+				Labels:          image.Labels,
+				Licenses:        image.Licenses,
+				Name:            image.Name,
+				ProjectId:       d.projectId,
+				SelfLink:        image.SelfLink,
+				SizeGb:          image.DiskSizeGb,
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return images, nil
+}
+
 func (d *driverGCE) GetInstanceMetadata(zone, name, key string) (string, error) {
 	instance, err := d.service.Instances.Get(d.projectId, zone, name).Do()
 	if err != nil {
@@ -28,6 +28,10 @@ type DriverMock struct {
 	DeleteImageName  string
 	DeleteImageErrCh <-chan error
 
+	DeprecateImageNames        []string
+	DeprecateImageDeprecations []*compute.DeprecationStatus
+	DeprecateImageErrCh        <-chan error
+
 	DeleteInstanceZone  string
 	DeleteInstanceName  string
 	DeleteInstanceErrCh <-chan error
@@ -50,6 +54,10 @@ type DriverMock struct {
 	GetImageFromProjectResult     *Image
 	GetImageFromProjectErr        error
 
+	GetActiveFamilyImagesFamily string
+	GetActiveFamilyImagesResult []*Image
+	GetActiveFamilyImagesErr    error
+
 	GetInstanceMetadataZone   string
 	GetInstanceMetadataName   string
 	GetInstanceMetadataKey    string
@@ -150,6 +158,20 @@ func (d *DriverMock) DeleteImage(name string) <-chan error {
 	return resultCh
 }
 
+func (d *DriverMock) DeprecateImage(name string, deprecation *compute.DeprecationStatus) <-chan error {
+	d.DeprecateImageNames = append(d.DeprecateImageNames, name)
+	d.DeprecateImageDeprecations = append(d.DeprecateImageDeprecations, deprecation)
+
+	resultCh := d.DeprecateImageErrCh
+	if resultCh == nil {
+		ch := make(chan error)
+		close(ch)
+		resultCh = ch
+	}
+
+	return resultCh
+}
+
 func (d *DriverMock) DeleteInstance(zone, name string) (<-chan error, error) {
 	d.DeleteInstanceZone = zone
 	d.DeleteInstanceName = name
@@ -222,6 +244,11 @@ func (d *DriverMock) GetSerialPortOutput(zone, name string) (string, error) {
 	return d.GetSerialPortOutputResult, d.GetSerialPortOutputErr
 }
 
+func (d *DriverMock) GetActiveFamilyImages(family string) ([]*Image, error) {
+	d.GetActiveFamilyImagesFamily = family
+	return d.GetActiveFamilyImagesResult, d.GetActiveFamilyImagesErr
+}
+
 func (d *DriverMock) ImageExists(name string) bool {
 	d.ImageExistsName = name
 	return d.ImageExistsResult
@@ -0,0 +1,83 @@
+package googlecompute
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/packer/packer"
+	"github.com/hashicorp/packer/packer-plugin-sdk/multistep"
+	compute "google.golang.org/api/compute/v1"
+)
+
+// StepDeprecatePreviousImages represents a Packer build step that deprecates
+// the images of the image family built before the image created.
+type StepDeprecatePreviousImages int
+
+// Run executes the Packer build step that deprecates the previous images of
+// the image family.
+//
+// The image family already resolves to the image created, so failing to
+// deprecate the previous images doesn't fail the build.
+func (s *StepDeprecatePreviousImages) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	config := state.Get("config").(*Config)
+	driver := state.Get("driver").(Driver)
+	ui := state.Get("ui").(packer.Ui)
+
+	if !config.DeprecatePreviousImages || config.SkipCreateImage {
+		return multistep.ActionContinue
+	}
+
+	image, ok := state.Get("image").(*Image)
+	if !ok || image == nil {
+		return multistep.ActionContinue
+	}
+
+	ui.Say(fmt.Sprintf("Deprecating previous images of family %s...", config.ImageFamily))
+	images, err := driver.GetActiveFamilyImages(config.ImageFamily)
+	if err != nil {
+		ui.Error(fmt.Sprintf("Error listing the images of family %s: %s", config.ImageFamily, err))
+		return multistep.ActionContinue
+	}
+
+	deprecation := previousImageDeprecation(image, config.PreviousImagesObsoleteAfter, config.PreviousImagesDeleteAfter, time.Now())
+	for _, previous := range images {
+		if previous.Name == image.Name {
+			continue
+		}
+
+		ui.Message(fmt.Sprintf("Deprecating image %s, replaced by %s", previous.Name, image.Name))
+		errCh := driver.DeprecateImage(previous.Name, deprecation)
+		select {
+		case err = <-errCh:
+		case <-time.After(config.StateTimeout):
+			err = errors.New("time out while waiting for image to be deprecated")
+		}
+		if err != nil {
+			ui.Error(fmt.Sprintf("Error deprecating image %s: %s", previous.Name, err))
+		}
+	}
+
+	return multistep.ActionContinue
+}
+
+// Cleanup.
+func (s *StepDeprecatePreviousImages) Cleanup(state multistep.StateBag) {}
+
+// previousImageDeprecation returns the deprecation status of the images
+// replaced by image, scheduled to become obsolete and deleted after the given
+// durations from now when they aren't zero.
+func previousImageDeprecation(image *Image, obsoleteAfter, deleteAfter time.Duration, now time.Time) *compute.DeprecationStatus {
+	deprecation := &compute.DeprecationStatus{
+		State:       "DEPRECATED",
+		Replacement: image.SelfLink,
+	}
+	if obsoleteAfter != 0 {
+		deprecation.Obsolete = now.Add(obsoleteAfter).UTC().Format(time.RFC3339)
+	}
+	if deleteAfter != 0 {
+		deprecation.Deleted = now.Add(deleteAfter).UTC().Format(time.RFC3339)
+	}
+	return deprecation
+}
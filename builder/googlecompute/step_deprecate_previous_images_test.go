@@ -0,0 +1,99 @@
+package googlecompute
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/packer/packer-plugin-sdk/multistep"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStepDeprecatePreviousImages_impl(t *testing.T) {
+	var _ multistep.Step = new(StepDeprecatePreviousImages)
+}
+
+func TestStepDeprecatePreviousImages(t *testing.T) {
+	state := testState(t)
+	step := new(StepDeprecatePreviousImages)
+	defer step.Cleanup(state)
+
+	c := state.Get("config").(*Config)
+	c.ImageFamily = "family"
+	c.DeprecatePreviousImages = true
+	c.PreviousImagesDeleteAfter = 24 * time.Hour
+
+	d := state.Get("driver").(*DriverMock)
+	d.GetActiveFamilyImagesResult = []*Image{
+		{Name: "image-1"},
+		{Name: "image-2"},
+		{Name: "image-3"},
+	}
+	state.Put("image", &Image{Name: "image-3", SelfLink: "https://compute/image-3"})
+
+	action := step.Run(context.Background(), state)
+	assert.Equal(t, multistep.ActionContinue, action, "Step did not pass.")
+
+	assert.Equal(t, "family", d.GetActiveFamilyImagesFamily, "Incorrect family passed to driver.")
+	assert.Equal(t, []string{"image-1", "image-2"}, d.DeprecateImageNames, "Should deprecate the previous images only.")
+	for _, deprecation := range d.DeprecateImageDeprecations {
+		assert.Equal(t, "DEPRECATED", deprecation.State)
+		assert.Equal(t, "https://compute/image-3", deprecation.Replacement)
+		assert.Empty(t, deprecation.Obsolete)
+		assert.NotEmpty(t, deprecation.Deleted)
+	}
+}
+
+func TestStepDeprecatePreviousImages_disabled(t *testing.T) {
+	state := testState(t)
+	step := new(StepDeprecatePreviousImages)
+	defer step.Cleanup(state)
+
+	d := state.Get("driver").(*DriverMock)
+	d.GetActiveFamilyImagesResult = []*Image{{Name: "image-1"}}
+	state.Put("image", &Image{Name: "image-2"})
+
+	action := step.Run(context.Background(), state)
+	assert.Equal(t, multistep.ActionContinue, action, "Step did not pass.")
+	assert.Empty(t, d.DeprecateImageNames, "Should not deprecate images.")
+}
+
+func TestStepDeprecatePreviousImages_errors(t *testing.T) {
+	state := testState(t)
+	step := new(StepDeprecatePreviousImages)
+	defer step.Cleanup(state)
+
+	c := state.Get("config").(*Config)
+	c.ImageFamily = "family"
+	c.DeprecatePreviousImages = true
+
+	errCh := make(chan error, 1)
+	errCh <- errors.New("error")
+	d := state.Get("driver").(*DriverMock)
+	d.GetActiveFamilyImagesResult = []*Image{{Name: "image-1"}}
+	d.DeprecateImageErrCh = errCh
+	state.Put("image", &Image{Name: "image-2"})
+
+	// The image is already built, so failures don't fail the build
+	action := step.Run(context.Background(), state)
+	assert.Equal(t, multistep.ActionContinue, action, "Step should not halt.")
+	_, ok := state.GetOk("error")
+	assert.False(t, ok, "State should not have an error.")
+	assert.Equal(t, []string{"image-1"}, d.DeprecateImageNames)
+}
+
+func TestPreviousImageDeprecation(t *testing.T) {
+	now := time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC)
+	image := &Image{SelfLink: "https://compute/image"}
+
+	deprecation := previousImageDeprecation(image, 30*24*time.Hour, 90*24*time.Hour, now)
+	assert.Equal(t, "DEPRECATED", deprecation.State)
+	assert.Equal(t, "https://compute/image", deprecation.Replacement)
+	assert.Equal(t, "2020-10-31T12:00:00Z", deprecation.Obsolete)
+	assert.Equal(t, "2020-12-30T12:00:00Z", deprecation.Deleted)
+
+	deprecation = previousImageDeprecation(image, 0, 0, now)
+	assert.Empty(t, deprecation.Obsolete)
+	assert.Empty(t, deprecation.Deleted)
+}
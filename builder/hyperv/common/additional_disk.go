@@ -0,0 +1,124 @@
+//go:generate struct-markdown
+//go:generate mapstructure-to-hcl2 -type AdditionalDisk
+
+package common
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	ControllerTypeSCSI = "SCSI"
+	ControllerTypeIDE  = "IDE"
+)
+
+// An additional hard disk to create and attach to the VM, next to the
+// primary hard disk. Each `additional_disks` block describes one disk. In
+// JSON this looks like:
+//
+// ```json
+// "additional_disks": [
+//   {
+//     "size": 10240,
+//     "block_size": 1,
+//     "controller_type": "SCSI"
+//   },
+//   {
+//     "size": 2048,
+//     "fixed": true
+//   }
+// ]
+// ```
+//
+// In HCL2:
+//
+// ```hcl
+// additional_disks {
+//   size            = 10240
+//   block_size      = 1
+//   controller_type = "SCSI"
+// }
+//
+// additional_disks {
+//   size  = 2048
+//   fixed = true
+// }
+// ```
+//
+// Disks listed in `disk_additional_size` are created first, followed by the
+// disks in `additional_disks`.
+type AdditionalDisk struct {
+	// The size of the disk in megabytes.
+	Size uint `mapstructure:"size" required:"true"`
+	// The block size of the VHD to be created, in megabytes. Only used for
+	// dynamically expanding disks. Defaults to the value of `disk_block_size`.
+	BlockSize uint `mapstructure:"block_size" required:"false"`
+	// The controller the disk is attached to, either `SCSI` or `IDE`. `IDE`
+	// is only available to Generation 1 virtual machines, which have room for
+	// at most four IDE devices including the primary hard disk and any DVD
+	// drives. Defaults to `SCSI`.
+	ControllerType string `mapstructure:"controller_type" required:"false"`
+	// If true, the disk is created as a fixed-size virtual hard disk, which
+	// allocates its full size on the host up front. By default the disk is
+	// dynamically expanding.
+	Fixed bool `mapstructure:"fixed" required:"false"`
+}
+
+func (d *AdditionalDisk) Prepare(generation uint, defaultBlockSize uint) []error {
+	var errs []error
+
+	if d.Size == 0 {
+		errs = append(errs, fmt.Errorf("additional_disks: size must be specified for every disk"))
+	}
+
+	if d.BlockSize == 0 {
+		d.BlockSize = defaultBlockSize
+	} else if d.BlockSize < MinDiskBlockSize || d.BlockSize > MaxDiskBlockSize {
+		errs = append(errs, fmt.Errorf("additional_disks: block_size must be between %d and %d, but defined: %d",
+			MinDiskBlockSize, MaxDiskBlockSize, d.BlockSize))
+	}
+
+	switch strings.ToUpper(d.ControllerType) {
+	case "", ControllerTypeSCSI:
+		d.ControllerType = ControllerTypeSCSI
+	case ControllerTypeIDE:
+		d.ControllerType = ControllerTypeIDE
+		if generation > 1 {
+			errs = append(errs, fmt.Errorf("additional_disks: Generation 2 VMs don't support IDE controllers."))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("additional_disks: controller_type must be one of %s or %s, but defined: %s",
+			ControllerTypeSCSI, ControllerTypeIDE, d.ControllerType))
+	}
+
+	return errs
+}
+
+// addAdditionalDisks creates and attaches the disks from
+// `disk_additional_size`, followed by those from `additional_disks`.
+func addAdditionalDisks(driver Driver, vmName string, path string, sizes []uint, blockSize uint,
+	disks []AdditionalDisk) error {
+	all := make([]AdditionalDisk, 0, len(sizes)+len(disks))
+	for _, size := range sizes {
+		all = append(all, AdditionalDisk{
+			Size:           size,
+			BlockSize:      blockSize,
+			ControllerType: ControllerTypeSCSI,
+		})
+	}
+	all = append(all, disks...)
+
+	for index, disk := range all {
+		diskSize := int64(disk.Size) * 1024 * 1024
+		diskBlockSize := int64(disk.BlockSize) * 1024 * 1024
+		diskFile := fmt.Sprintf("%s-%d.vhdx", vmName, index)
+		err := driver.AddVirtualMachineHardDrive(vmName, path, diskFile, diskSize, diskBlockSize,
+			disk.ControllerType, disk.Fixed)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,65 @@
+//go:generate struct-markdown
+//go:generate mapstructure-to-hcl2 -type AdditionalNetworkAdapter
+
+package common
+
+import (
+	"fmt"
+)
+
+// A network adapter to add to the VM next to the default one, which is
+// connected to `switch_name`. Adapters are added in the order they are
+// listed, after the default adapter has been configured, so the communicator
+// keeps using the default adapter. In JSON this looks like:
+//
+// ```json
+// "additional_network_adapters": [
+//   {
+//     "switch_name": "Internal",
+//     "vlan_id": "20",
+//     "mac_address": "00155d380a01"
+//   },
+//   {
+//     "switch_name": "Storage",
+//     "enable_mac_spoofing": true
+//   }
+// ]
+// ```
+//
+// In HCL2:
+//
+// ```hcl
+// additional_network_adapters {
+//   switch_name = "Internal"
+//   vlan_id     = "20"
+//   mac_address = "00155d380a01"
+// }
+//
+// additional_network_adapters {
+//   switch_name         = "Storage"
+//   enable_mac_spoofing = true
+// }
+// ```
+type AdditionalNetworkAdapter struct {
+	// The name of an existing virtual switch to connect the adapter to.
+	SwitchName string `mapstructure:"switch_name" required:"true"`
+	// The VLAN of the adapter. By default no VLAN is set.
+	VlanId string `mapstructure:"vlan_id" required:"false"`
+	// A static MAC address for the adapter. The MAC address must be a string
+	// with no delimiters, for example "0000deadbeef". By default Hyper-V
+	// assigns a dynamic MAC address.
+	MacAddress string `mapstructure:"mac_address" required:"false"`
+	// If true enable MAC address spoofing on the adapter. This defaults to
+	// false.
+	EnableMacSpoofing bool `mapstructure:"enable_mac_spoofing" required:"false"`
+}
+
+func (a *AdditionalNetworkAdapter) Prepare() []error {
+	var errs []error
+
+	if a.SwitchName == "" {
+		errs = append(errs, fmt.Errorf("additional_network_adapters: switch_name must be specified for every adapter"))
+	}
+
+	return errs
+}
@@ -0,0 +1,127 @@
+package common
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/packer/packer-plugin-sdk/bootcommand"
+)
+
+// specialKeyRe matches everything in a boot command that looks like a
+// special key, <wait> or <xOn>.
+var specialKeyRe = regexp.MustCompile(`<[a-zA-Z][a-zA-Z0-9.]*>`)
+
+// CheckBootCommand returns warnings for the parts of the boot command that
+// are most likely mistakes: special keys the parser doesn't know, such as a
+// misspelled <enetr>, which are typed character by character instead, and
+// characters the virtual keyboard can't type.
+func CheckBootCommand(command string) []string {
+	var warnings []string
+
+	for _, key := range specialKeyRe.FindAllString(command, -1) {
+		// A known key parses into a single expression, anything else into a
+		// literal per character
+		seq, err := bootcommand.GenerateExpressionSequence(key)
+		if err == nil && len(seq) == 1 {
+			continue
+		}
+		warnings = append(warnings, fmt.Sprintf("boot_command: %s isn't a known special key "+
+			"and will be typed as is.", key))
+	}
+
+	seen := make(map[rune]bool)
+	for _, r := range command {
+		if isTypeable(r) || seen[r] {
+			continue
+		}
+		seen[r] = true
+		warnings = append(warnings, fmt.Sprintf("boot_command: %q can't be typed on the virtual "+
+			"keyboard, use special keys such as <enter> or <tab> instead.", r))
+	}
+
+	return warnings
+}
+
+// isTypeable reports whether the virtual keyboard can type r, which is only
+// true for printable ASCII characters.
+func isTypeable(r rune) bool {
+	return r >= ' ' && r <= '~'
+}
+
+// typeTextDriver is a bootcommand.BCDriver that types runs of pressed
+// characters with a single call to typeText instead of sending two scan
+// codes for every key. Special keys, keys that are held down or released and
+// characters pressed while a key is held down, such as <leftCtrlOn>c, are
+// sent by the wrapped driver.
+type typeTextDriver struct {
+	bootcommand.BCDriver
+
+	typeText func(string) error
+	// How long to wait after typing a run of characters.
+	interval time.Duration
+	text     strings.Builder
+	// The number of keys currently held down.
+	held int
+}
+
+func (d *typeTextDriver) SendKey(key rune, action bootcommand.KeyAction) error {
+	if action == bootcommand.KeyPress && d.held == 0 && isTypeable(key) {
+		// Send the scan codes queued so far first to keep the keys in order
+		if d.text.Len() == 0 {
+			if err := d.BCDriver.Flush(); err != nil {
+				return err
+			}
+		}
+		d.text.WriteRune(key)
+		return nil
+	}
+
+	if err := d.flushText(); err != nil {
+		return err
+	}
+	d.track(action)
+	return d.BCDriver.SendKey(key, action)
+}
+
+func (d *typeTextDriver) SendSpecial(special string, action bootcommand.KeyAction) error {
+	if err := d.flushText(); err != nil {
+		return err
+	}
+	d.track(action)
+	return d.BCDriver.SendSpecial(special, action)
+}
+
+// track counts the keys held down.
+func (d *typeTextDriver) track(action bootcommand.KeyAction) {
+	switch action {
+	case bootcommand.KeyOn:
+		d.held++
+	case bootcommand.KeyOff:
+		if d.held > 0 {
+			d.held--
+		}
+	}
+}
+
+func (d *typeTextDriver) Flush() error {
+	if err := d.flushText(); err != nil {
+		return err
+	}
+	return d.BCDriver.Flush()
+}
+
+func (d *typeTextDriver) flushText() error {
+	if d.text.Len() == 0 {
+		return nil
+	}
+
+	text := d.text.String()
+	d.text.Reset()
+	if err := d.typeText(text); err != nil {
+		return err
+	}
+	time.Sleep(d.interval)
+	return nil
+}
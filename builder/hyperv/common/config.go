@@ -5,13 +5,16 @@ package common
 import (
 	"fmt"
 	"log"
+	"net"
 	"os"
 	"strings"
+	"time"
 
 	powershell "github.com/hashicorp/packer/builder/hyperv/common/powershell"
 	"github.com/hashicorp/packer/builder/hyperv/common/powershell/hyperv"
 	"github.com/hashicorp/packer/packer-plugin-sdk/common"
 	"github.com/hashicorp/packer/packer-plugin-sdk/multistep/commonsteps"
+	"github.com/hashicorp/packer/packer-plugin-sdk/template/config"
 	"github.com/hashicorp/packer/packer-plugin-sdk/template/interpolate"
 )
 
@@ -29,11 +32,19 @@ const (
 	MinRamSize                     = 32        // 32MB
 	MaxRamSize                     = 32 * 1024 // 32GB
 	MinNestedVirtualizationRamSize = 4 * 1024  // 4GB
+	MinMemoryBufferPercent         = 5
+	MaxMemoryBufferPercent         = 2000
 
 	LowRam = 256 // 256MB
 
 	DefaultUsername = ""
 	DefaultPassword = ""
+
+	SecureBootTemplateMicrosoftWindows                  = "MicrosoftWindows"
+	SecureBootTemplateMicrosoftUEFICertificateAuthority = "MicrosoftUEFICertificateAuthority"
+	SecureBootTemplateOpenSourceShieldedVM              = "OpenSourceShieldedVM"
+
+	DefaultSwitchNatPrefix = "192.168.250.0/24"
 )
 
 type CommonConfig struct {
@@ -57,8 +68,12 @@ type CommonConfig struct {
 	// will be attached to the SCSI interface only. The builder uses
 	// expandable rather than fixed-size virtual hard disks, so the actual
 	// file representing the disk will not use the full size unless it is
-	// full.
+	// full. Use `additional_disks` to change any of this per disk.
 	AdditionalDiskSize []uint `mapstructure:"disk_additional_size" required:"false"`
+	// A list of additional hard disks for the VM, with control over the
+	// size, block size, controller type and disk type of each of them. See
+	// the [Additional Disks](#additional-disks) section for details.
+	AdditionalDisks []AdditionalDisk `mapstructure:"additional_disks" required:"false"`
 	// If set to attach then attach and
 	// mount the ISO image specified in guest_additions_path. If set to
 	// none then guest additions are not attached and mounted; This is the
@@ -81,6 +96,33 @@ type CommonConfig struct {
 	// set on the switch's network card. If this value is set it should match
 	// the VLAN specified in by vlan_id.
 	SwitchVlanId string `mapstructure:"switch_vlan_id" required:"false"`
+	// If true, Packer creates the switch named by `switch_name` when it
+	// doesn't exist yet, and deletes it again at the end of the build. Set
+	// this to false to fail the build instead when the switch is missing.
+	// This defaults to true.
+	CreateSwitch config.Trilean `mapstructure:"create_switch" required:"false"`
+	// The type of the switch Packer creates, one of `Internal`, `Private` or
+	// `External`. An internal switch connects the VM to the host only, unless
+	// `switch_nat` is set, a private switch connects it to other VMs only, and
+	// an external switch bridges it onto a physical network adapter of the
+	// host. This has no effect when the switch already exists. Defaults to
+	// `Internal`.
+	SwitchType string `mapstructure:"switch_type" required:"false"`
+	// The name of the physical network adapter of the host, as listed by
+	// `Get-NetAdapter`, to bind an external switch to. By default the first
+	// connected physical adapter is used.
+	SwitchNetAdapterName string `mapstructure:"switch_net_adapter_name" required:"false"`
+	// If true, Packer gives the host the first address of `switch_nat_prefix`
+	// on the internal switch it creates and sets up NAT for that network, so
+	// the VM can reach other networks through the host. There is no DHCP
+	// server on this network, so the guest needs a static address in
+	// `switch_nat_prefix` with the host's address as its gateway. The NAT is
+	// removed along with the switch. This defaults to false.
+	SwitchNat bool `mapstructure:"switch_nat" required:"false"`
+	// The IPv4 network used by `switch_nat`, in CIDR notation. Defaults to
+	// `192.168.250.0/24`, in which case the host's address is
+	// `192.168.250.1`.
+	SwitchNatPrefix string `mapstructure:"switch_nat_prefix" required:"false"`
 	// This allows a specific MAC address to be used on
 	// the default virtual network card. The MAC address must be a string with
 	// no delimiters, for example "0000deadbeef".
@@ -89,9 +131,28 @@ type CommonConfig struct {
 	// card for the new virtual machine. By default none is set. If none is set
 	// then VLANs are not set on the virtual machine's network card.
 	VlanId string `mapstructure:"vlan_id" required:"false"`
+	// A list of network adapters to add to the virtual machine in addition
+	// to the default one, each with its own switch, VLAN, MAC address and
+	// MAC spoofing setting. See the
+	// [Additional Network Adapters](#additional-network-adapters) section for
+	// details.
+	AdditionalNetworkAdapters []AdditionalNetworkAdapter `mapstructure:"additional_network_adapters" required:"false"`
 	// The number of CPUs the virtual machine should use. If
 	// this isn't specified, the default is 1 CPU.
 	Cpu uint `mapstructure:"cpus" required:"false"`
+	// The number of CPUs of each virtual processor socket, which must divide
+	// `cpus`. The guest sees `cpus` / `cpus_per_socket` sockets, which
+	// matters for software that is licensed per socket or per core, such as
+	// Windows Server and SQL Server. Hyper-V derives the sockets from the
+	// virtual NUMA topology, so this can't be combined with dynamic memory.
+	// By default Hyper-V decides, which usually results in a single socket.
+	CpusPerSocket uint `mapstructure:"cpus_per_socket" required:"false"`
+	// The number of virtual NUMA nodes of the virtual machine. This must be
+	// a multiple of the number of sockets and divide `cpus`; the CPUs and
+	// `memory` are split evenly between the nodes. Like `cpus_per_socket`
+	// this can't be combined with dynamic memory. By default there is one
+	// node per socket.
+	NumaNodes uint `mapstructure:"numa_nodes" required:"false"`
 	// The Hyper-V generation for the virtual machine. By
 	// default, this is 1. Generation 2 Hyper-V virtual machines do not support
 	// floppy drives. In this scenario use secondary_iso_images instead. Hard
@@ -103,21 +164,72 @@ type CommonConfig struct {
 	// If true enable dynamic memory for
 	// the virtual machine. This defaults to false.
 	EnableDynamicMemory bool `mapstructure:"enable_dynamic_memory" required:"false"`
+	// The minimum amount, in megabytes, of RAM the virtual machine can
+	// shrink to when dynamic memory is enabled. Must not be more than
+	// `memory`. Setting this enables dynamic memory. By default Hyper-V uses
+	// 512 MB.
+	DynamicMemoryMinimum uint `mapstructure:"dynamic_memory_minimum" required:"false"`
+	// The maximum amount, in megabytes, of RAM the virtual machine can grow
+	// to when dynamic memory is enabled. Must not be less than `memory`.
+	// Setting this enables dynamic memory. By default Hyper-V uses 1 TB.
+	DynamicMemoryMaximum uint `mapstructure:"dynamic_memory_maximum" required:"false"`
+	// The percentage of memory Hyper-V tries to reserve on top of what the
+	// virtual machine currently uses when dynamic memory is enabled, between
+	// 5 and 2000. Setting this enables dynamic memory. By default Hyper-V
+	// uses 20.
+	MemoryBufferPercent uint `mapstructure:"memory_buffer_percent" required:"false"`
 	// If true enable secure boot for the
 	// virtual machine. This defaults to false. See secure_boot_template
 	// below for additional settings.
 	EnableSecureBoot bool `mapstructure:"enable_secure_boot" required:"false"`
-	// The secure boot template to be
-	// configured. Valid values are "MicrosoftWindows" (Windows) or
-	// "MicrosoftUEFICertificateAuthority" (Linux). This only takes effect if
-	// enable_secure_boot is set to "true". This defaults to "MicrosoftWindows".
+	// The secure boot template to be configured. Valid values are:
+	//
+	//   - `MicrosoftWindows` - For Windows guests.
+	//   - `MicrosoftUEFICertificateAuthority` - For Linux guests whose boot
+	//     loader is signed by the Microsoft UEFI CA, such as Ubuntu or CentOS.
+	//   - `OpenSourceShieldedVM` - For Linux guests running as shielded
+	//     virtual machines. This requires Windows Server 2016 or newer.
+	//
+	// This only takes effect if enable_secure_boot is set to "true" on a
+	// Generation 2 virtual machine. This defaults to "MicrosoftWindows".
 	SecureBootTemplate string `mapstructure:"secure_boot_template" required:"false"`
+	// If true, add a virtual TPM to the virtual machine, for example to build
+	// Windows 11 or BitLocker enabled images. A new local key protector is
+	// created for the virtual machine, which means the exported virtual
+	// machine can only be started on hosts that trust the
+	// `UntrustedGuardian` of the build host. This only works for Generation 2
+	// virtual machines. This defaults to false.
+	EnableVirtualTPM bool `mapstructure:"enable_virtual_tpm" required:"false"`
 	// If true enable
 	// virtualization extensions for the virtual machine. This defaults to
 	// false. For nested virtualization you need to enable MAC spoofing,
 	// disable dynamic memory and have at least 4GB of RAM assigned to the
 	// virtual machine.
 	EnableVirtualizationExtensions bool `mapstructure:"enable_virtualization_extensions" required:"false"`
+	// If true, set up the virtual machine so that it can run Hyper-V
+	// itself. This turns on `enable_virtualization_extensions` and
+	// `enable_mac_spoofing`, and can't be combined with
+	// `enable_dynamic_memory`. You should still give the virtual machine at
+	// least 4GB of RAM. This defaults to false.
+	EnableNestedVirtualization bool `mapstructure:"enable_nested_virtualization" required:"false"`
+	// If true, turn off processor compatibility mode on the virtual machine
+	// so that the guest sees every feature of the host processor instead of
+	// a reduced set. The resulting VM can then only be moved to hosts with
+	// the same processor features. This defaults to false.
+	ExposeProcessorFeatures bool `mapstructure:"expose_processor_features" required:"false"`
+	// The path of a file to write the output of the first serial port (COM1)
+	// of the virtual machine to. Packer connects the port to a named pipe and
+	// copies everything the guest writes to it into this file while the
+	// virtual machine runs, which helps to debug unattended installs that
+	// fail before a communicator is available, such as a Linux kernel panic.
+	// The guest has to send its console to the serial port, for example with
+	// the `console=ttyS0` kernel argument. The port is disconnected again
+	// before the virtual machine is exported. By default the serial port
+	// isn't connected.
+	SerialLogFile string `mapstructure:"serial_log_file" required:"false"`
+	// If true and the build fails, the last lines of `serial_log_file` are
+	// shown in the UI. This defaults to false.
+	SerialLogOnError bool `mapstructure:"serial_log_on_error" required:"false"`
 	// The location under which Packer will create a directory to house all the
 	// VM files and folders during the build. By default `%TEMP%` is used
 	// which, for most systems, will evaluate to
@@ -135,7 +247,10 @@ type CommonConfig struct {
 	// The Hyper-V manager.
 	KeepRegistered bool `mapstructure:"keep_registered" required:"false"`
 	// If true skip compacting the hard disk for
-	// the virtual machine when exporting. This defaults to false.
+	// the virtual machine when exporting. By default, after the virtual
+	// machine has shut down, each dynamically expanding disk is mounted
+	// read-only and fully optimized with `Optimize-VHD`, which reclaims the
+	// space the guest no longer uses. This defaults to false.
 	SkipCompaction bool `mapstructure:"skip_compaction" required:"false"`
 	// If true Packer will skip the export of the VM.
 	// If you are interested only in the VHD/VHDX files, you can enable this
@@ -143,11 +258,42 @@ type CommonConfig struct {
 	// <output_directory>/Virtual Hard Disks. By default this option is false
 	// and Packer will export the VM to output_directory.
 	SkipExport bool `mapstructure:"skip_export" required:"false"`
+	// Controls how the virtual machine is exported. Valid values are:
+	//
+	//   - `flat` - Export the virtual machine as it is. This is the default.
+	//   - `checkpoints` - Take a production checkpoint of the virtual machine
+	//     before exporting it, so the export contains the checkpoint chain.
+	//   - `vhdset` - Convert the virtual hard disks to VHD Sets (`.vhds`)
+	//     before exporting, so the exported disks can be shared in guest
+	//     clusters. This requires Windows Server 2016 or newer.
+	//
+	// This option has no effect when `skip_export` is true.
+	ExportMode string `mapstructure:"export_mode" required:"false"`
+	// The format the virtual hard disks are converted to before the virtual
+	// machine is exported, either `vhdx` or `vhd`. Together with
+	// `output_disk_type` this produces, for example, the fixed size VHD
+	// images Azure expects. Generation 2 virtual machines can't use VHD
+	// disks, so `vhd` requires `skip_export` for them. By default the disks
+	// keep their format.
+	OutputDiskFormat string `mapstructure:"output_disk_format" required:"false"`
+	// The type the virtual hard disks are converted to before the virtual
+	// machine is exported, either `dynamic` or `fixed`. By default the disks
+	// keep their type.
+	OutputDiskType string `mapstructure:"output_disk_type" required:"false"`
 	// Packer defaults to building Hyper-V virtual
 	// machines by launching a GUI that shows the console of the machine being
 	// built. When this value is set to true, the machine will start without a
 	// console.
 	Headless bool `mapstructure:"headless" required:"false"`
+	// If true, the characters of `boot_command` are typed as text with the
+	// `TypeText` method of the Hyper-V virtual keyboard, one call for every
+	// run of characters between special keys and waits, instead of as a pair
+	// of scan codes for every key. This is a lot faster and less likely to
+	// drop characters of long boot commands on busy hosts. Special keys,
+	// `<wait>` and held keys such as `<leftShiftOn>` work as before, and
+	// `boot_keygroup_interval` is also waited after every run of text. This
+	// defaults to false.
+	BootTypeText bool `mapstructure:"boot_type_text" required:"false"`
 	// When configured, determines the device or device type that is given preferential
 	// treatment when choosing a boot device.
 	//
@@ -177,6 +323,69 @@ type CommonConfig struct {
 	//
 	// **NB** This only works for Generation 2 machines.
 	BootOrder []string `mapstructure:"boot_order" required:"false"`
+	// Where Packer looks up the IP address of the virtual machine to connect
+	// to. Valid values are `adapter`, which asks Hyper-V for the addresses
+	// of the network adapter and falls back to the addresses the guest
+	// reports through the KVP (Data Exchange) integration service, and
+	// `kvp`, which only uses the addresses reported by the guest, for
+	// example by `hv_kvp_daemon` on Linux. This defaults to `adapter`.
+	IpAddressSource string `mapstructure:"ip_address_source" required:"false"`
+	// The address family of the IP address to connect to, either `ipv4` or
+	// `ipv6`. By default an IPv4 address is preferred and an IPv6 address is
+	// only used if the virtual machine has no IPv4 address. Loopback and
+	// link-local addresses, such as the APIPA addresses a guest assigns
+	// itself before it got a DHCP lease, are always ignored.
+	IpAddressFamily string `mapstructure:"ip_address_family" required:"false"`
+	// The amount of time to wait for the virtual machine to get a usable IP
+	// address before connecting to it. The build fails if it doesn't get one
+	// in time. This has no effect if `ssh_host` or `winrm_host` is set or
+	// the communicator doesn't use the network. By default Packer doesn't
+	// wait separately and keeps looking up the address until the
+	// communicator times out.
+	IpAddressTimeout time.Duration `mapstructure:"ip_address_timeout" required:"false"`
+	// If true, Packer takes a standard checkpoint of the running virtual
+	// machine called `packer-debug-before-provisioning` before the
+	// provisioners run and one called `packer-debug-after-provisioning` once
+	// they succeeded. If a provisioner fails, a checkpoint called
+	// `packer-debug-provisioning-failed` is taken instead of the latter. This
+	// lets you apply a checkpoint in Hyper-V Manager and retry a failing
+	// provisioner by hand instead of rebuilding the virtual machine, which
+	// requires `keep_registered` or the `-on-error=abort` flag so the virtual
+	// machine is kept when the build fails. Provisioners run as a single
+	// step of the build, so the checkpoints are taken around all of them
+	// rather than after each one. The checkpoints are removed before the
+	// virtual machine is exported, and the checkpoint type of the virtual
+	// machine is left as `Standard`. This defaults to false.
+	DebugCheckpoints bool `mapstructure:"debug_checkpoints" required:"false"`
+	// Selects how Packer talks to Hyper-V. Valid values are `powershell`,
+	// which runs a PowerShell cmdlet for every operation, and `wmi`, which
+	// reads the state, uptime and heartbeat of the virtual machine, whether
+	// its disks are locked, and its MAC and IP addresses directly from the
+	// Hyper-V WMI v2 namespace. Operations that change the virtual machine or
+	// the host, and queries that fail over WMI, still run PowerShell. `wmi`
+	// makes builds faster and less flaky on busy hosts since Packer polls
+	// the virtual machine frequently. This defaults to `powershell`.
+	DriverMode string `mapstructure:"driver_mode" required:"false"`
+	// The name or address of a remote Hyper-V server to build the virtual
+	// machine on instead of this machine. Packer then runs its PowerShell
+	// commands on that server through PowerShell remoting, which has to be
+	// enabled there, uploads the ISO, floppy and CD files to the build
+	// directory on the server and copies the exported virtual machine back
+	// into `output_directory`. Paths to existing files such as
+	// `secondary_iso_images`, `guest_additions_path` and `temp_path` are
+	// paths on the server. The virtual machine has to be reachable from this
+	// machine, so it should be connected to an external switch. Commands run
+	// on the server can't authenticate to other machines, so files on
+	// network shares have to be copied to the server first. This can't be
+	// used with `driver_mode` `wmi`, `serial_log_file` or the
+	// `powershell-direct` communicator.
+	RemoteHost string `mapstructure:"remote_host" required:"false"`
+	// The user to connect to `remote_host` as. It has to be a member of the
+	// Administrators or Hyper-V Administrators group on the server. Required
+	// if `remote_host` is set.
+	RemoteUsername string `mapstructure:"remote_username" required:"false"`
+	// The password of `remote_username`. Required if `remote_host` is set.
+	RemotePassword string `mapstructure:"remote_password" required:"false"`
 }
 
 func (c *CommonConfig) Prepare(ctx *interpolate.Context, pc *common.PackerConfig) ([]error, []string) {
@@ -184,6 +393,9 @@ func (c *CommonConfig) Prepare(ctx *interpolate.Context, pc *common.PackerConfig
 	var errs []error
 	var warns []string
 
+	// Everything below that asks Hyper-V has to ask the remote host already
+	errs = append(errs, c.checkRemoteHost()...)
+
 	if c.VMName == "" {
 		c.VMName = fmt.Sprintf("packer-%s", pc.PackerBuildName)
 		log.Println(fmt.Sprintf("%s: %v", "VMName", c.VMName))
@@ -205,10 +417,6 @@ func (c *CommonConfig) Prepare(ctx *interpolate.Context, pc *common.PackerConfig
 		}
 	}
 
-	if len(c.AdditionalDiskSize) > 64 {
-		errs = append(errs, fmt.Errorf("VM's currently support a maximum of 64 additional SCSI attached disks."))
-	}
-
 	// Errors
 	errs = append(errs, c.FloppyConfig.Prepare(ctx)...)
 	errs = append(errs, c.CDConfig.Prepare(ctx)...)
@@ -218,13 +426,9 @@ func (c *CommonConfig) Prepare(ctx *interpolate.Context, pc *common.PackerConfig
 		} else {
 			c.GuestAdditionsPath = os.Getenv("WINDIR") + "\\system32\\vmguest.iso"
 
-			if _, err := os.Stat(c.GuestAdditionsPath); os.IsNotExist(err) {
-				if err != nil {
-					c.GuestAdditionsPath = ""
-					c.GuestAdditionsMode = "none"
-				} else {
-					c.GuestAdditionsMode = "attach"
-				}
+			if !c.PathExists(c.GuestAdditionsPath) {
+				c.GuestAdditionsPath = ""
+				c.GuestAdditionsMode = "none"
 			}
 		}
 	}
@@ -232,30 +436,24 @@ func (c *CommonConfig) Prepare(ctx *interpolate.Context, pc *common.PackerConfig
 	if c.GuestAdditionsPath == "" && c.GuestAdditionsMode == "attach" {
 		c.GuestAdditionsPath = os.Getenv("WINDIR") + "\\system32\\vmguest.iso"
 
-		if _, err := os.Stat(c.GuestAdditionsPath); os.IsNotExist(err) {
-			if err != nil {
-				c.GuestAdditionsPath = ""
-			}
+		if !c.PathExists(c.GuestAdditionsPath) {
+			c.GuestAdditionsPath = ""
 		}
 	}
 
 	for _, isoPath := range c.SecondaryDvdImages {
-		if _, err := os.Stat(isoPath); os.IsNotExist(err) {
-			if err != nil {
-				errs = append(
-					errs, fmt.Errorf("Secondary Dvd image does not exist: %s", err))
-			}
+		if !c.PathExists(isoPath) {
+			errs = append(
+				errs, fmt.Errorf("Secondary Dvd image does not exist: %s", isoPath))
 		}
 	}
 
 	numberOfIsos := len(c.SecondaryDvdImages)
 
 	if c.GuestAdditionsMode == "attach" {
-		if _, err := os.Stat(c.GuestAdditionsPath); os.IsNotExist(err) {
-			if err != nil {
-				errs = append(
-					errs, fmt.Errorf("Guest additions iso does not exist: %s", err))
-			}
+		if !c.PathExists(c.GuestAdditionsPath) {
+			errs = append(
+				errs, fmt.Errorf("Guest additions iso does not exist: %s", c.GuestAdditionsPath))
 		}
 
 		numberOfIsos = numberOfIsos + 1
@@ -283,8 +481,16 @@ func (c *CommonConfig) Prepare(ctx *interpolate.Context, pc *common.PackerConfig
 		}
 	}
 
+	if c.DynamicMemoryMinimum != 0 || c.DynamicMemoryMaximum != 0 || c.MemoryBufferPercent != 0 {
+		c.EnableDynamicMemory = true
+	}
+
+	if err := c.checkNestedVirtualization(); err != nil {
+		errs = append(errs, err)
+	}
+
 	if c.EnableVirtualizationExtensions {
-		hasVirtualMachineVirtualizationExtensions, err := powershell.HasVirtualMachineVirtualizationExtensions()
+		hasVirtualMachineVirtualizationExtensions, err := powershell.HasVirtualMachineVirtualizationExtensions(c.Remote())
 		if err != nil {
 			errs = append(errs, fmt.Errorf("Failed detecting virtual machine virtualization "+
 				"extensions support: %s", err))
@@ -297,6 +503,49 @@ func (c *CommonConfig) Prepare(ctx *interpolate.Context, pc *common.PackerConfig
 		}
 	}
 
+	errs = append(errs, c.checkIpAddress()...)
+
+	switch c.DriverMode {
+	case "":
+		c.DriverMode = DriverModePowerShell
+	case DriverModePowerShell, DriverModeWMI:
+	default:
+		errs = append(errs, fmt.Errorf("driver_mode: must be one of %q or %q, but defined: %q",
+			DriverModePowerShell, DriverModeWMI, c.DriverMode))
+	}
+
+	switch c.SecureBootTemplate {
+	case "":
+		c.SecureBootTemplate = SecureBootTemplateMicrosoftWindows
+	case SecureBootTemplateMicrosoftWindows, SecureBootTemplateMicrosoftUEFICertificateAuthority,
+		SecureBootTemplateOpenSourceShieldedVM:
+	default:
+		errs = append(errs, fmt.Errorf("secure_boot_template: must be one of %q, %q or %q, but defined: %q",
+			SecureBootTemplateMicrosoftWindows, SecureBootTemplateMicrosoftUEFICertificateAuthority,
+			SecureBootTemplateOpenSourceShieldedVM, c.SecureBootTemplate))
+	}
+
+	switch c.ExportMode {
+	case "":
+		c.ExportMode = ExportModeFlat
+	case ExportModeFlat, ExportModeCheckpoints, ExportModeVHDSet:
+	default:
+		errs = append(errs, fmt.Errorf("export_mode: must be one of %q, %q or %q, but defined: %q",
+			ExportModeFlat, ExportModeCheckpoints, ExportModeVHDSet, c.ExportMode))
+	}
+
+	errs = append(errs, c.checkSwitch()...)
+
+	if c.SerialLogOnError && c.SerialLogFile == "" {
+		errs = append(errs, fmt.Errorf("serial_log_on_error can only be used with serial_log_file."))
+	}
+
+	errs = append(errs, c.checkOutputDisk()...)
+
+	if c.SkipExport && c.ExportMode != ExportModeFlat {
+		warns = Appendwarns(warns, "export_mode has no effect when skip_export is true.")
+	}
+
 	if c.FirstBootDevice != "" {
 		_, _, _, err := ParseBootDeviceIdentifier(c.FirstBootDevice, c.Generation)
 		if err != nil {
@@ -337,10 +586,16 @@ func (c *CommonConfig) Prepare(ctx *interpolate.Context, pc *common.PackerConfig
 	if err != nil {
 		errs = append(errs, err)
 	}
+	errs = append(errs, c.checkAdditionalDisks()...)
+	for i := range c.AdditionalNetworkAdapters {
+		errs = append(errs, c.AdditionalNetworkAdapters[i].Prepare()...)
+	}
 	err = c.checkRamSize()
 	if err != nil {
 		errs = append(errs, err)
 	}
+	errs = append(errs, c.checkDynamicMemory()...)
+	errs = append(errs, c.checkCpuTopology()...)
 
 	// warns
 	warning := c.checkHostAvailableMemory()
@@ -355,6 +610,217 @@ func (c *CommonConfig) Prepare(ctx *interpolate.Context, pc *common.PackerConfig
 	return nil, warns
 }
 
+func (c *CommonConfig) checkAdditionalDisks() []error {
+	var errs []error
+
+	scsiDisks := len(c.AdditionalDiskSize)
+	ideDisks := 0
+	for i := range c.AdditionalDisks {
+		errs = append(errs, c.AdditionalDisks[i].Prepare(c.Generation, c.DiskBlockSize)...)
+		switch c.AdditionalDisks[i].ControllerType {
+		case ControllerTypeSCSI:
+			scsiDisks++
+		case ControllerTypeIDE:
+			ideDisks++
+		}
+	}
+
+	if scsiDisks > 64 {
+		errs = append(errs, fmt.Errorf("VM's currently support a maximum of 64 additional SCSI attached disks."))
+	}
+
+	// The primary hard disk and the DVD drive take two of the four locations
+	// of the 2 ide controllers, the secondary dvds and guest additions share
+	// the other two with the IDE additional disks.
+	numberOfIsos := len(c.SecondaryDvdImages)
+	if c.GuestAdditionsMode == "attach" {
+		numberOfIsos++
+	}
+	if c.Generation < 2 && ideDisks > 0 && ideDisks+numberOfIsos > 2 {
+		errs = append(errs, fmt.Errorf("There are only 2 ide controllers with 2 locations each, so "+
+			"we can't support %d IDE additional_disks next to %d secondary dvds and guest additions.",
+			ideDisks, numberOfIsos))
+	}
+
+	return errs
+}
+
+func (c *CommonConfig) checkNestedVirtualization() error {
+	if !c.EnableNestedVirtualization {
+		return nil
+	}
+
+	c.EnableVirtualizationExtensions = true
+	c.EnableMacSpoofing = true
+
+	if c.EnableDynamicMemory {
+		return fmt.Errorf("enable_nested_virtualization can't be used together with enable_dynamic_memory.")
+	}
+
+	return nil
+}
+
+func (c *CommonConfig) checkOutputDisk() []error {
+	var errs []error
+
+	c.OutputDiskFormat = strings.ToLower(c.OutputDiskFormat)
+	switch c.OutputDiskFormat {
+	case "", OutputDiskFormatVHDX:
+	case OutputDiskFormatVHD:
+		if c.Generation > 1 && !c.SkipExport {
+			errs = append(errs, fmt.Errorf("Generation 2 VMs don't support VHD disks, "+
+				"so output_disk_format %q requires skip_export.", OutputDiskFormatVHD))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("output_disk_format: must be one of %q or %q, but defined: %q",
+			OutputDiskFormatVHDX, OutputDiskFormatVHD, c.OutputDiskFormat))
+	}
+
+	c.OutputDiskType = strings.ToLower(c.OutputDiskType)
+	switch c.OutputDiskType {
+	case "", OutputDiskTypeDynamic, OutputDiskTypeFixed:
+	default:
+		errs = append(errs, fmt.Errorf("output_disk_type: must be one of %q or %q, but defined: %q",
+			OutputDiskTypeDynamic, OutputDiskTypeFixed, c.OutputDiskType))
+	}
+
+	if (c.OutputDiskFormat != "" || c.OutputDiskType != "") && c.ExportMode == ExportModeVHDSet {
+		errs = append(errs, fmt.Errorf("output_disk_format and output_disk_type can't be used with "+
+			"export_mode %q.", ExportModeVHDSet))
+	}
+
+	return errs
+}
+
+func (c *CommonConfig) checkIpAddress() []error {
+	var errs []error
+
+	switch strings.ToLower(c.IpAddressSource) {
+	case "":
+		c.IpAddressSource = IpAddressSourceAdapter
+	case IpAddressSourceAdapter, IpAddressSourceKvp:
+		c.IpAddressSource = strings.ToLower(c.IpAddressSource)
+	default:
+		errs = append(errs, fmt.Errorf("ip_address_source: must be one of %q or %q, but defined: %q",
+			IpAddressSourceAdapter, IpAddressSourceKvp, c.IpAddressSource))
+	}
+
+	switch strings.ToLower(c.IpAddressFamily) {
+	case "":
+	case IpAddressFamilyIPv4, IpAddressFamilyIPv6:
+		c.IpAddressFamily = strings.ToLower(c.IpAddressFamily)
+	default:
+		errs = append(errs, fmt.Errorf("ip_address_family: must be one of %q or %q, but defined: %q",
+			IpAddressFamilyIPv4, IpAddressFamilyIPv6, c.IpAddressFamily))
+	}
+
+	if c.IpAddressTimeout < 0 {
+		errs = append(errs, fmt.Errorf("ip_address_timeout: must be greater than or equal to 0, but defined: %s",
+			c.IpAddressTimeout))
+	}
+
+	return errs
+}
+
+func (c *CommonConfig) checkRemoteHost() []error {
+	var errs []error
+
+	if c.RemoteHost == "" {
+		if c.RemoteUsername != "" || c.RemotePassword != "" {
+			errs = append(errs, fmt.Errorf("remote_username and remote_password can only be used with remote_host."))
+		}
+		return errs
+	}
+
+	if c.RemoteUsername == "" {
+		errs = append(errs, fmt.Errorf("remote_username must be specified with remote_host."))
+	}
+	if c.RemotePassword == "" {
+		errs = append(errs, fmt.Errorf("remote_password must be specified with remote_host."))
+	}
+	if strings.ToLower(c.DriverMode) == DriverModeWMI {
+		errs = append(errs, fmt.Errorf("driver_mode %q can't be used with remote_host.", DriverModeWMI))
+	}
+	if c.SerialLogFile != "" {
+		errs = append(errs, fmt.Errorf("serial_log_file can't be used with remote_host."))
+	}
+
+	return errs
+}
+
+// Remote returns the remote Hyper-V host to build on, or nil to build on this
+// machine.
+func (c *CommonConfig) Remote() *powershell.Remote {
+	if c.RemoteHost == "" {
+		return nil
+	}
+	return &powershell.Remote{Host: c.RemoteHost, Username: c.RemoteUsername, Password: c.RemotePassword}
+}
+
+// PathExists reports whether path exists on the Hyper-V host, which is the
+// remote host if one is set. If that can't be checked, the path is assumed
+// to exist and using it fails later instead.
+func (c *CommonConfig) PathExists(path string) bool {
+	if c.RemoteHost == "" {
+		_, err := os.Stat(path)
+		return !os.IsNotExist(err)
+	}
+
+	if powershellAvailable, _, _ := powershell.IsPowershellAvailable(); !powershellAvailable {
+		return true
+	}
+	exists, err := hyperv.PathExists(c.Remote(), path)
+	if err != nil {
+		log.Printf("Checking if %s exists on %s: %s", path, c.RemoteHost, err)
+		return true
+	}
+	return exists
+}
+
+func (c *CommonConfig) checkSwitch() []error {
+	var errs []error
+
+	switch strings.ToLower(c.SwitchType) {
+	case "", strings.ToLower(SwitchTypeInternal):
+		c.SwitchType = SwitchTypeInternal
+	case strings.ToLower(SwitchTypePrivate):
+		c.SwitchType = SwitchTypePrivate
+	case strings.ToLower(SwitchTypeExternal):
+		c.SwitchType = SwitchTypeExternal
+	default:
+		errs = append(errs, fmt.Errorf("switch_type: must be one of %q, %q or %q, but defined: %q",
+			SwitchTypeInternal, SwitchTypePrivate, SwitchTypeExternal, c.SwitchType))
+	}
+
+	if c.SwitchNetAdapterName != "" && c.SwitchType != SwitchTypeExternal {
+		errs = append(errs, fmt.Errorf("switch_net_adapter_name can only be used with an %s switch_type.",
+			SwitchTypeExternal))
+	}
+
+	if c.SwitchNat {
+		if c.SwitchType != SwitchTypeInternal {
+			errs = append(errs, fmt.Errorf("switch_nat can only be used with an %s switch_type.",
+				SwitchTypeInternal))
+		}
+
+		if c.SwitchNatPrefix == "" {
+			c.SwitchNatPrefix = DefaultSwitchNatPrefix
+		}
+		ip, network, err := net.ParseCIDR(c.SwitchNatPrefix)
+		if err != nil || ip.To4() == nil || !ip.Equal(network.IP) {
+			errs = append(errs, fmt.Errorf("switch_nat_prefix: must be an IPv4 network in CIDR notation, "+
+				"for example %q, but defined: %q", DefaultSwitchNatPrefix, c.SwitchNatPrefix))
+		} else if ones, _ := network.Mask.Size(); ones > 30 {
+			errs = append(errs, fmt.Errorf("switch_nat_prefix: must have room for the host and a VM, "+
+				"but defined: %q", c.SwitchNatPrefix))
+		}
+	} else if c.SwitchNatPrefix != "" {
+		errs = append(errs, fmt.Errorf("switch_nat_prefix can only be used when switch_nat is true."))
+	}
+
+	return errs
+}
+
 func (c *CommonConfig) checkDiskBlockSize() error {
 	if c.DiskBlockSize == 0 {
 		c.DiskBlockSize = DefaultDiskBlockSize
@@ -377,7 +843,7 @@ func (c *CommonConfig) checkHostAvailableMemory() string {
 	powershellAvailable, _, _ := powershell.IsPowershellAvailable()
 
 	if powershellAvailable {
-		freeMB := powershell.GetHostAvailableMemory()
+		freeMB := powershell.GetHostAvailableMemory(c.Remote())
 
 		if (freeMB - float64(c.RamSize)) < LowRam {
 			return fmt.Sprintf("Hyper-V might fail to create a VM if there is not enough free memory in the system.")
@@ -405,12 +871,70 @@ func (c *CommonConfig) checkRamSize() error {
 	return nil
 }
 
+func (c *CommonConfig) checkDynamicMemory() []error {
+	var errs []error
+
+	if c.DynamicMemoryMinimum != 0 && c.DynamicMemoryMinimum > c.RamSize {
+		errs = append(errs, fmt.Errorf("dynamic_memory_minimum: must be less than or equal to memory (%v MB), "+
+			"but defined: %v", c.RamSize, c.DynamicMemoryMinimum))
+	}
+
+	if c.DynamicMemoryMaximum != 0 && c.DynamicMemoryMaximum < c.RamSize {
+		errs = append(errs, fmt.Errorf("dynamic_memory_maximum: must be greater than or equal to memory "+
+			"(%v MB), but defined: %v", c.RamSize, c.DynamicMemoryMaximum))
+	}
+
+	if c.MemoryBufferPercent != 0 &&
+		(c.MemoryBufferPercent < MinMemoryBufferPercent || c.MemoryBufferPercent > MaxMemoryBufferPercent) {
+		errs = append(errs, fmt.Errorf("memory_buffer_percent: must be between %v and %v, but defined: %v",
+			MinMemoryBufferPercent, MaxMemoryBufferPercent, c.MemoryBufferPercent))
+	}
+
+	return errs
+}
+
+func (c *CommonConfig) checkCpuTopology() []error {
+	var errs []error
+
+	if c.CpusPerSocket == 0 && c.NumaNodes == 0 {
+		return nil
+	}
+
+	if c.EnableDynamicMemory {
+		errs = append(errs, fmt.Errorf("cpus_per_socket and numa_nodes can't be used with dynamic memory"))
+	}
+
+	cpu := c.Cpu
+	if cpu < 1 {
+		cpu = 1
+	}
+
+	sockets := uint(1)
+	if c.CpusPerSocket != 0 {
+		if cpu%c.CpusPerSocket != 0 {
+			errs = append(errs, fmt.Errorf("cpus_per_socket: must divide cpus (%v), but defined: %v",
+				cpu, c.CpusPerSocket))
+			return errs
+		}
+		sockets = cpu / c.CpusPerSocket
+	}
+
+	if c.NumaNodes != 0 {
+		if c.NumaNodes%sockets != 0 || cpu%c.NumaNodes != 0 {
+			errs = append(errs, fmt.Errorf("numa_nodes: must be a multiple of the number of sockets (%v) "+
+				"and divide cpus (%v), but defined: %v", sockets, cpu, c.NumaNodes))
+		}
+	}
+
+	return errs
+}
+
 func (c *CommonConfig) detectSwitchName(buildName string) string {
 	powershellAvailable, _, _ := powershell.IsPowershellAvailable()
 
 	if powershellAvailable {
 		// no switch name, try to get one attached to a online network adapter
-		onlineSwitchName, err := hyperv.GetExternalOnlineVirtualSwitch()
+		onlineSwitchName, err := hyperv.GetExternalOnlineVirtualSwitch(c.Remote())
 		if onlineSwitchName != "" && err == nil {
 			return onlineSwitchName
 		}
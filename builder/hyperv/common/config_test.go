@@ -0,0 +1,328 @@
+package common
+
+import (
+	"testing"
+)
+
+func TestCommonConfig_checkNestedVirtualization(t *testing.T) {
+	var c *CommonConfig
+
+	// Test the default
+	c = &CommonConfig{}
+	if err := c.checkNestedVirtualization(); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if c.EnableVirtualizationExtensions || c.EnableMacSpoofing {
+		t.Fatalf("bad: %#v", c)
+	}
+
+	// Test with nested virtualization enabled
+	c = &CommonConfig{EnableNestedVirtualization: true}
+	if err := c.checkNestedVirtualization(); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if !c.EnableVirtualizationExtensions {
+		t.Fatal("should have enabled virtualization extensions")
+	}
+	if !c.EnableMacSpoofing {
+		t.Fatal("should have enabled mac spoofing")
+	}
+
+	// Test with dynamic memory
+	c = &CommonConfig{EnableNestedVirtualization: true, EnableDynamicMemory: true}
+	if err := c.checkNestedVirtualization(); err == nil {
+		t.Fatal("should have error")
+	}
+}
+
+func TestCommonConfig_checkDynamicMemory(t *testing.T) {
+	var c *CommonConfig
+
+	// Test with good values
+	c = &CommonConfig{
+		RamSize:              2048,
+		DynamicMemoryMinimum: 512,
+		DynamicMemoryMaximum: 8192,
+		MemoryBufferPercent:  20,
+	}
+	if errs := c.checkDynamicMemory(); len(errs) > 0 {
+		t.Fatalf("err: %#v", errs)
+	}
+
+	// Test with a minimum above memory
+	c = &CommonConfig{RamSize: 2048, DynamicMemoryMinimum: 4096}
+	if errs := c.checkDynamicMemory(); len(errs) != 1 {
+		t.Fatalf("bad: %#v", errs)
+	}
+
+	// Test with a maximum below memory
+	c = &CommonConfig{RamSize: 2048, DynamicMemoryMaximum: 1024}
+	if errs := c.checkDynamicMemory(); len(errs) != 1 {
+		t.Fatalf("bad: %#v", errs)
+	}
+
+	// Test with a bad buffer
+	c = &CommonConfig{RamSize: 2048, MemoryBufferPercent: 2}
+	if errs := c.checkDynamicMemory(); len(errs) != 1 {
+		t.Fatalf("bad: %#v", errs)
+	}
+}
+
+func TestCommonConfig_checkCpuTopology(t *testing.T) {
+	var c *CommonConfig
+
+	// Test with good values
+	c = &CommonConfig{Cpu: 8, CpusPerSocket: 4, NumaNodes: 4}
+	if errs := c.checkCpuTopology(); len(errs) > 0 {
+		t.Fatalf("err: %#v", errs)
+	}
+
+	// Test NUMA nodes without sockets
+	c = &CommonConfig{Cpu: 8, NumaNodes: 2}
+	if errs := c.checkCpuTopology(); len(errs) > 0 {
+		t.Fatalf("err: %#v", errs)
+	}
+
+	// Test CPUs per socket that don't divide the CPUs
+	c = &CommonConfig{Cpu: 6, CpusPerSocket: 4}
+	if errs := c.checkCpuTopology(); len(errs) != 1 {
+		t.Fatalf("bad: %#v", errs)
+	}
+
+	// Test fewer NUMA nodes than sockets
+	c = &CommonConfig{Cpu: 8, CpusPerSocket: 2, NumaNodes: 2}
+	if errs := c.checkCpuTopology(); len(errs) != 1 {
+		t.Fatalf("bad: %#v", errs)
+	}
+
+	// Test with dynamic memory
+	c = &CommonConfig{Cpu: 4, CpusPerSocket: 2, EnableDynamicMemory: true}
+	if errs := c.checkCpuTopology(); len(errs) != 1 {
+		t.Fatalf("bad: %#v", errs)
+	}
+}
+
+func TestCommonConfig_checkIpAddress(t *testing.T) {
+	var c *CommonConfig
+
+	// Test the default
+	c = &CommonConfig{}
+	if errs := c.checkIpAddress(); len(errs) > 0 {
+		t.Fatalf("err: %#v", errs)
+	}
+	if c.IpAddressSource != IpAddressSourceAdapter {
+		t.Fatalf("bad source: %s", c.IpAddressSource)
+	}
+
+	// Test values in a different case
+	c = &CommonConfig{IpAddressSource: "KVP", IpAddressFamily: "IPv4"}
+	if errs := c.checkIpAddress(); len(errs) > 0 {
+		t.Fatalf("err: %#v", errs)
+	}
+	if c.IpAddressSource != IpAddressSourceKvp {
+		t.Fatalf("bad source: %s", c.IpAddressSource)
+	}
+	if c.IpAddressFamily != IpAddressFamilyIPv4 {
+		t.Fatalf("bad family: %s", c.IpAddressFamily)
+	}
+
+	// Test bad values
+	c = &CommonConfig{IpAddressSource: "dhcp", IpAddressFamily: "ipx", IpAddressTimeout: -1}
+	if errs := c.checkIpAddress(); len(errs) != 3 {
+		t.Fatalf("bad: %#v", errs)
+	}
+}
+
+func TestCommonConfig_checkRemoteHost(t *testing.T) {
+	var c *CommonConfig
+
+	// Test the default
+	c = &CommonConfig{}
+	if errs := c.checkRemoteHost(); len(errs) > 0 {
+		t.Fatalf("err: %#v", errs)
+	}
+
+	// Test a remote host with credentials
+	c = &CommonConfig{RemoteHost: "hyperv01", RemoteUsername: "packer", RemotePassword: "secret"}
+	if errs := c.checkRemoteHost(); len(errs) > 0 {
+		t.Fatalf("err: %#v", errs)
+	}
+
+	// Test credentials without a remote host
+	c = &CommonConfig{RemoteUsername: "packer"}
+	if errs := c.checkRemoteHost(); len(errs) != 1 {
+		t.Fatalf("bad: %#v", errs)
+	}
+
+	// Test a remote host without credentials and with unsupported options
+	c = &CommonConfig{RemoteHost: "hyperv01", DriverMode: "WMI", SerialLogFile: "serial.log"}
+	if errs := c.checkRemoteHost(); len(errs) != 4 {
+		t.Fatalf("bad: %#v", errs)
+	}
+}
+
+func TestCommonConfig_Remote(t *testing.T) {
+	c := &CommonConfig{}
+	if remote := c.Remote(); remote != nil {
+		t.Fatalf("should build on this machine: %#v", remote)
+	}
+
+	c = &CommonConfig{RemoteHost: "hyperv01", RemoteUsername: "packer", RemotePassword: "secret"}
+	remote := c.Remote()
+	if remote == nil || remote.Host != "hyperv01" || remote.Username != "packer" || remote.Password != "secret" {
+		t.Fatalf("bad: %#v", remote)
+	}
+}
+
+func TestCommonConfig_checkSwitch(t *testing.T) {
+	var c *CommonConfig
+
+	// Test the default
+	c = &CommonConfig{}
+	if errs := c.checkSwitch(); len(errs) > 0 {
+		t.Fatalf("err: %#v", errs)
+	}
+	if c.SwitchType != SwitchTypeInternal {
+		t.Fatalf("bad switch type: %s", c.SwitchType)
+	}
+
+	// Test a switch type in a different case
+	c = &CommonConfig{SwitchType: "external", SwitchNetAdapterName: "Ethernet"}
+	if errs := c.checkSwitch(); len(errs) > 0 {
+		t.Fatalf("err: %#v", errs)
+	}
+	if c.SwitchType != SwitchTypeExternal {
+		t.Fatalf("bad switch type: %s", c.SwitchType)
+	}
+
+	// Test a bad switch type
+	c = &CommonConfig{SwitchType: "Bridged"}
+	if errs := c.checkSwitch(); len(errs) == 0 {
+		t.Fatal("should have error")
+	}
+
+	// Test a net adapter without an external switch
+	c = &CommonConfig{SwitchType: SwitchTypePrivate, SwitchNetAdapterName: "Ethernet"}
+	if errs := c.checkSwitch(); len(errs) == 0 {
+		t.Fatal("should have error")
+	}
+
+	// Test NAT with the default prefix
+	c = &CommonConfig{SwitchNat: true}
+	if errs := c.checkSwitch(); len(errs) > 0 {
+		t.Fatalf("err: %#v", errs)
+	}
+	if c.SwitchNatPrefix != DefaultSwitchNatPrefix {
+		t.Fatalf("bad prefix: %s", c.SwitchNatPrefix)
+	}
+
+	// Test NAT with a private switch
+	c = &CommonConfig{SwitchType: SwitchTypePrivate, SwitchNat: true}
+	if errs := c.checkSwitch(); len(errs) == 0 {
+		t.Fatal("should have error")
+	}
+
+	// Test bad NAT prefixes
+	for _, prefix := range []string{"192.168.250.1/24", "192.168.250.0", "fd00::/64", "192.168.250.0/31"} {
+		c = &CommonConfig{SwitchNat: true, SwitchNatPrefix: prefix}
+		if errs := c.checkSwitch(); len(errs) == 0 {
+			t.Fatalf("should have error for %s", prefix)
+		}
+	}
+
+	// Test a NAT prefix without NAT
+	c = &CommonConfig{SwitchNatPrefix: "10.0.0.0/24"}
+	if errs := c.checkSwitch(); len(errs) == 0 {
+		t.Fatal("should have error")
+	}
+}
+
+func TestCommonConfig_checkOutputDisk(t *testing.T) {
+	var c *CommonConfig
+
+	// Test the default
+	c = &CommonConfig{}
+	if errs := c.checkOutputDisk(); len(errs) > 0 {
+		t.Fatalf("err: %#v", errs)
+	}
+
+	// Test good values in a different case
+	c = &CommonConfig{Generation: 1, OutputDiskFormat: "VHD", OutputDiskType: "Fixed"}
+	if errs := c.checkOutputDisk(); len(errs) > 0 {
+		t.Fatalf("err: %#v", errs)
+	}
+	if c.OutputDiskFormat != OutputDiskFormatVHD || c.OutputDiskType != OutputDiskTypeFixed {
+		t.Fatalf("bad: %#v", c)
+	}
+
+	// Test bad values
+	c = &CommonConfig{OutputDiskFormat: "vmdk", OutputDiskType: "sparse"}
+	if errs := c.checkOutputDisk(); len(errs) != 2 {
+		t.Fatalf("should have 2 errors: %#v", errs)
+	}
+
+	// Test VHD with an exported Generation 2 VM
+	c = &CommonConfig{Generation: 2, OutputDiskFormat: OutputDiskFormatVHD}
+	if errs := c.checkOutputDisk(); len(errs) == 0 {
+		t.Fatal("should have error")
+	}
+	c = &CommonConfig{Generation: 2, OutputDiskFormat: OutputDiskFormatVHD, SkipExport: true}
+	if errs := c.checkOutputDisk(); len(errs) > 0 {
+		t.Fatalf("err: %#v", errs)
+	}
+
+	// Test with VHD Sets
+	c = &CommonConfig{OutputDiskType: OutputDiskTypeFixed, ExportMode: ExportModeVHDSet}
+	if errs := c.checkOutputDisk(); len(errs) == 0 {
+		t.Fatal("should have error")
+	}
+}
+
+func TestCommonConfig_checkAdditionalDisks(t *testing.T) {
+	var c *CommonConfig
+
+	// Test IDE disks that fit the free locations of the ide controllers
+	c = &CommonConfig{
+		Generation:         1,
+		SecondaryDvdImages: []string{"secondary.iso"},
+		AdditionalDisks:    []AdditionalDisk{{Size: 1024, ControllerType: "ide"}},
+	}
+	if errs := c.checkAdditionalDisks(); len(errs) > 0 {
+		t.Fatalf("err: %#v", errs)
+	}
+
+	// Test too many IDE disks
+	c = &CommonConfig{
+		Generation: 1,
+		AdditionalDisks: []AdditionalDisk{
+			{Size: 1024, ControllerType: ControllerTypeIDE},
+			{Size: 1024, ControllerType: ControllerTypeIDE},
+			{Size: 1024, ControllerType: ControllerTypeIDE},
+		},
+	}
+	if errs := c.checkAdditionalDisks(); len(errs) != 1 {
+		t.Fatalf("bad: %#v", errs)
+	}
+
+	// Test IDE disks next to secondary dvds and guest additions
+	c = &CommonConfig{
+		Generation:         1,
+		SecondaryDvdImages: []string{"secondary.iso"},
+		GuestAdditionsMode: "attach",
+		AdditionalDisks:    []AdditionalDisk{{Size: 1024, ControllerType: ControllerTypeIDE}},
+	}
+	if errs := c.checkAdditionalDisks(); len(errs) != 1 {
+		t.Fatalf("bad: %#v", errs)
+	}
+
+	// Test SCSI disks next to secondary dvds and guest additions
+	c = &CommonConfig{
+		Generation:         1,
+		SecondaryDvdImages: []string{"secondary.iso"},
+		GuestAdditionsMode: "attach",
+		AdditionalDisks:    []AdditionalDisk{{Size: 1024}, {Size: 1024}, {Size: 1024}},
+	}
+	if errs := c.checkAdditionalDisks(); len(errs) > 0 {
+		t.Fatalf("err: %#v", errs)
+	}
+}
@@ -2,8 +2,31 @@ package common
 
 import (
 	"context"
+	"io"
+
+	"github.com/hashicorp/packer/builder/hyperv/common/powershell"
+)
+
+const (
+	// DriverModePowerShell drives Hyper-V by running PowerShell cmdlets.
+	DriverModePowerShell = "powershell"
+	// DriverModeWMI queries virtual machine state over WMI and falls back to
+	// PowerShell for everything else.
+	DriverModeWMI = "wmi"
 )
 
+// NewDriver returns the Driver implementing the given driver mode. The
+// PowerShell driver runs its commands on the remote Hyper-V host if remote
+// isn't nil. WMI is only queried on this machine.
+func NewDriver(driverMode string, remote *powershell.Remote) (Driver, error) {
+	switch driverMode {
+	case DriverModeWMI:
+		return NewHypervWMIDriver()
+	default:
+		return NewHypervPS4Driver(remote)
+	}
+}
+
 // A driver is able to talk to HyperV and perform certain
 // operations with it. Some of the operations on here may seem overly
 // specific, but they were built specifically in mind to handle features
@@ -21,6 +44,13 @@ type Driver interface {
 	//How long has VM been on
 	Uptime(vmName string) (uint64, error)
 
+	// Returns the integration services heartbeat status of the VM, or "Off"
+	// if the VM is off.
+	GetVirtualMachineHeartbeatStatus(string) (string, error)
+
+	// Checks if any of the virtual hard disks of the VM are still locked.
+	AreVirtualMachineDisksLocked(string) (bool, error)
+
 	// Start starts a VM specified by the name given.
 	Start(string) error
 
@@ -38,6 +68,10 @@ type Driver interface {
 	// Finds the IP address of a VM connected that uses DHCP by its MAC address
 	IpAddress(string) (string, error)
 
+	// Finds all IP addresses of a VM by its MAC address, either from the
+	// network adapter or from the guest KVP exchange items
+	IpAddresses(string, string) ([]string, error)
+
 	// Finds the hostname for the ip address
 	GetHostName(string) (string, error)
 
@@ -47,6 +81,9 @@ type Driver interface {
 	// Type scan codes to virtual keyboard of vm
 	TypeScanCodes(string, string) error
 
+	// Type ASCII text to virtual keyboard of vm
+	TypeText(string, string) error
+
 	//Get the ip address for network adaptor
 	GetVirtualMachineNetworkAdapterAddress(string) (string, error)
 
@@ -56,6 +93,8 @@ type Driver interface {
 	//Set the vlan to use for machine
 	SetVirtualMachineVlanId(string, string) error
 
+	AddVirtualMachineNetworkAdapter(string, string, string, string, string, bool) error
+
 	SetVmNetworkAdapterMacAddress(string, string) error
 
 	//Replace the network adapter with a (non-)legacy adapter
@@ -69,15 +108,25 @@ type Driver interface {
 
 	ConnectVirtualMachineNetworkAdapterToSwitch(string, string) error
 
-	CreateVirtualSwitch(string, string) (bool, error)
+	CreateVirtualSwitch(string, string, string) (bool, error)
 
 	DeleteVirtualSwitch(string) error
 
+	// Checks if the switch named exists.
+	VirtualSwitchExists(string) (bool, error)
+
+	// Assigns the host the first address of the given network on the switch
+	// and creates a NAT with the given name for that network.
+	EnableVirtualSwitchNat(string, string, string) error
+
+	// Removes the NAT with the given name.
+	DisableVirtualSwitchNat(string) error
+
 	CheckVMName(string) error
 
 	CreateVirtualMachine(string, string, string, int64, int64, int64, string, uint, bool, bool, string) error
 
-	AddVirtualMachineHardDrive(string, string, string, int64, int64, string) error
+	AddVirtualMachineHardDrive(string, string, string, int64, int64, string, bool) error
 
 	CloneVirtualMachine(string, string, string, bool, string, string, string, int64, string, bool) error
 
@@ -87,22 +136,82 @@ type Driver interface {
 
 	SetVirtualMachineCpuCount(string, uint) error
 
+	// Sets the virtual NUMA topology of the vm: the number of CPUs of each
+	// NUMA node, the number of NUMA nodes of each socket and the maximum
+	// memory of each NUMA node in bytes
+	SetVirtualMachineCpuTopology(string, uint, uint, int64) error
+
 	SetVirtualMachineMacSpoofing(string, bool) error
 
 	SetVirtualMachineDynamicMemory(string, bool) error
 
+	SetVirtualMachineDynamicMemorySettings(string, int64, int64, uint) error
+
 	SetVirtualMachineSecureBoot(string, bool, string) error
 
+	EnableVirtualMachineTPM(string) error
+
 	SetVirtualMachineVirtualizationExtensions(string, bool) error
 
+	SetVirtualMachineProcessorCompatibility(string, bool) error
+
+	// Connects the given COM port of the VM to a named pipe, or disconnects
+	// it if the path is empty.
+	SetVirtualMachineComPort(string, uint, string) error
+
 	EnableVirtualMachineIntegrationService(string, string) error
 
+	// Runs a command in the VM over PowerShell Direct, streaming its output to
+	// the given writers, and returns its exit code.
+	InvokeVirtualMachineCommand(string, string, string, string, io.Writer, io.Writer) (int, error)
+
+	// Copies a file from the host into the VM through the Guest Service
+	// Interface integration service.
+	CopyFileToVirtualMachine(string, string, string) error
+
+	// Copies a file or directory from the VM to the host over PowerShell
+	// Direct.
+	CopyFileFromVirtualMachine(string, string, string, string, string) error
+
 	ExportVirtualMachine(string, string) error
 
+	CheckpointVirtualMachine(string, string, string) error
+
+	// Removes the checkpoints of the VM whose names start with the given
+	// prefix and waits for their disks to be merged
+	RemoveVirtualMachineCheckpoints(string, string) error
+
+	ConvertVirtualMachineDisksToVHDSet(string) error
+
+	// Converts the disks of the VM to the given format and type, keeping
+	// the current format or type if it is empty. If the last argument is
+	// true, the converted disks are attached in place of the original ones.
+	ConvertVirtualMachineDisks(string, string, string, bool) error
+
+	MergeVirtualMachineDifferencingDisks(string) error
+
 	PreserveLegacyExportBehaviour(string, string) error
 
 	MoveCreatedVHDsToOutputDir(string, string) error
 
+	// Checks whether a path exists on the Hyper-V host.
+	PathExists(string) (bool, error)
+
+	// Creates a uniquely named directory with the given prefix under a
+	// parent directory on the Hyper-V host, or under its temp directory if
+	// the parent is empty, and returns its path.
+	CreateHostTempDirectory(string, string) (string, error)
+
+	// Removes a directory and its contents from the Hyper-V host.
+	RemoveHostDirectory(string) error
+
+	// Copies a local file to a path on the Hyper-V host.
+	CopyFileToHost(string, string) error
+
+	// Copies the contents of a directory on the Hyper-V host into a local
+	// directory.
+	CopyDirectoryFromHost(string, string) error
+
 	CompactDisks(string) (string, error)
 
 	RestartVirtualMachine(string) error
@@ -2,6 +2,7 @@ package common
 
 import (
 	"context"
+	"io"
 )
 
 type DriverMock struct {
@@ -20,6 +21,16 @@ type DriverMock struct {
 	Uptime_Return uint64
 	Uptime_Err    error
 
+	GetVirtualMachineHeartbeatStatus_Called bool
+	GetVirtualMachineHeartbeatStatus_VmName string
+	GetVirtualMachineHeartbeatStatus_Return string
+	GetVirtualMachineHeartbeatStatus_Err    error
+
+	AreVirtualMachineDisksLocked_Called bool
+	AreVirtualMachineDisksLocked_VmName string
+	AreVirtualMachineDisksLocked_Return bool
+	AreVirtualMachineDisksLocked_Err    error
+
 	Start_Called bool
 	Start_VmName string
 	Start_Err    error
@@ -41,6 +52,12 @@ type DriverMock struct {
 	IpAddress_Return string
 	IpAddress_Err    error
 
+	IpAddresses_Called bool
+	IpAddresses_Mac    string
+	IpAddresses_Source string
+	IpAddresses_Return []string
+	IpAddresses_Err    error
+
 	GetHostName_Called bool
 	GetHostName_Ip     string
 	GetHostName_Return string
@@ -61,6 +78,11 @@ type DriverMock struct {
 	TypeScanCodes_ScanCodes string
 	TypeScanCodes_Err       error
 
+	TypeText_Called bool
+	TypeText_VmName string
+	TypeText_Text   []string
+	TypeText_Err    error
+
 	GetVirtualMachineNetworkAdapterAddress_Called bool
 	GetVirtualMachineNetworkAdapterAddress_VmName string
 	GetVirtualMachineNetworkAdapterAddress_Return string
@@ -81,6 +103,15 @@ type DriverMock struct {
 	SetVmNetworkAdapterMacAddress_Mac    string
 	SetVmNetworkAdapterMacAddress_Err    error
 
+	AddVirtualMachineNetworkAdapter_Called            bool
+	AddVirtualMachineNetworkAdapter_VmName            string
+	AddVirtualMachineNetworkAdapter_AdapterName       string
+	AddVirtualMachineNetworkAdapter_SwitchName        string
+	AddVirtualMachineNetworkAdapter_VlanId            string
+	AddVirtualMachineNetworkAdapter_Mac               string
+	AddVirtualMachineNetworkAdapter_EnableMacSpoofing bool
+	AddVirtualMachineNetworkAdapter_Err               error
+
 	SetVirtualMachineVlanId_Called bool
 	SetVirtualMachineVlanId_VmName string
 	SetVirtualMachineVlanId_VlanId string
@@ -113,11 +144,27 @@ type DriverMock struct {
 	CheckVMName_Called bool
 	CheckVMName_Err    error
 
-	CreateVirtualSwitch_Called     bool
-	CreateVirtualSwitch_SwitchName string
-	CreateVirtualSwitch_SwitchType string
-	CreateVirtualSwitch_Return     bool
-	CreateVirtualSwitch_Err        error
+	CreateVirtualSwitch_Called         bool
+	CreateVirtualSwitch_SwitchName     string
+	CreateVirtualSwitch_SwitchType     string
+	CreateVirtualSwitch_NetAdapterName string
+	CreateVirtualSwitch_Return         bool
+	CreateVirtualSwitch_Err            error
+
+	VirtualSwitchExists_Called     bool
+	VirtualSwitchExists_SwitchName string
+	VirtualSwitchExists_Return     bool
+	VirtualSwitchExists_Err        error
+
+	EnableVirtualSwitchNat_Called     bool
+	EnableVirtualSwitchNat_SwitchName string
+	EnableVirtualSwitchNat_NatName    string
+	EnableVirtualSwitchNat_Prefix     string
+	EnableVirtualSwitchNat_Err        error
+
+	DisableVirtualSwitchNat_Called  bool
+	DisableVirtualSwitchNat_NatName string
+	DisableVirtualSwitchNat_Err     error
 
 	AddVirtualMachineHardDrive_Called         bool
 	AddVirtualMachineHardDrive_VmName         string
@@ -126,6 +173,7 @@ type DriverMock struct {
 	AddVirtualMachineHardDrive_VhdSizeBytes   int64
 	AddVirtualMachineHardDrive_VhdBlockSize   int64
 	AddVirtualMachineHardDrive_ControllerType string
+	AddVirtualMachineHardDrive_FixedVHD       bool
 	AddVirtualMachineHardDrive_Err            error
 
 	CreateVirtualMachine_Called           bool
@@ -164,6 +212,13 @@ type DriverMock struct {
 	SetVirtualMachineCpuCount_Cpu    uint
 	SetVirtualMachineCpuCount_Err    error
 
+	SetVirtualMachineCpuTopology_Called                 bool
+	SetVirtualMachineCpuTopology_VmName                 string
+	SetVirtualMachineCpuTopology_CpusPerNumaNode        uint
+	SetVirtualMachineCpuTopology_NumaNodesPerSocket     uint
+	SetVirtualMachineCpuTopology_MemoryPerNumaNodeBytes int64
+	SetVirtualMachineCpuTopology_Err                    error
+
 	SetVirtualMachineMacSpoofing_Called bool
 	SetVirtualMachineMacSpoofing_VmName string
 	SetVirtualMachineMacSpoofing_Enable bool
@@ -174,27 +229,98 @@ type DriverMock struct {
 	SetVirtualMachineDynamicMemory_Enable bool
 	SetVirtualMachineDynamicMemory_Err    error
 
+	SetVirtualMachineDynamicMemorySettings_Called        bool
+	SetVirtualMachineDynamicMemorySettings_VmName        string
+	SetVirtualMachineDynamicMemorySettings_MinimumBytes  int64
+	SetVirtualMachineDynamicMemorySettings_MaximumBytes  int64
+	SetVirtualMachineDynamicMemorySettings_BufferPercent uint
+	SetVirtualMachineDynamicMemorySettings_Err           error
+
 	SetVirtualMachineSecureBoot_Called       bool
 	SetVirtualMachineSecureBoot_VmName       string
 	SetVirtualMachineSecureBoot_TemplateName string
 	SetVirtualMachineSecureBoot_Enable       bool
 	SetVirtualMachineSecureBoot_Err          error
 
+	EnableVirtualMachineTPM_Called bool
+	EnableVirtualMachineTPM_VmName string
+	EnableVirtualMachineTPM_Err    error
+
 	SetVirtualMachineVirtualizationExtensions_Called bool
 	SetVirtualMachineVirtualizationExtensions_VmName string
 	SetVirtualMachineVirtualizationExtensions_Enable bool
 	SetVirtualMachineVirtualizationExtensions_Err    error
 
+	SetVirtualMachineProcessorCompatibility_Called bool
+	SetVirtualMachineProcessorCompatibility_VmName string
+	SetVirtualMachineProcessorCompatibility_Enable bool
+	SetVirtualMachineProcessorCompatibility_Err    error
+
+	SetVirtualMachineComPort_Called bool
+	SetVirtualMachineComPort_VmName string
+	SetVirtualMachineComPort_Number uint
+	SetVirtualMachineComPort_Path   string
+	SetVirtualMachineComPort_Err    error
+
 	EnableVirtualMachineIntegrationService_Called                 bool
 	EnableVirtualMachineIntegrationService_VmName                 string
 	EnableVirtualMachineIntegrationService_IntegrationServiceName string
 	EnableVirtualMachineIntegrationService_Err                    error
 
+	InvokeVirtualMachineCommand_Called   bool
+	InvokeVirtualMachineCommand_VmName   string
+	InvokeVirtualMachineCommand_Username string
+	InvokeVirtualMachineCommand_Password string
+	InvokeVirtualMachineCommand_Command  string
+	InvokeVirtualMachineCommand_Stdout   string
+	InvokeVirtualMachineCommand_Return   int
+	InvokeVirtualMachineCommand_Err      error
+
+	CopyFileToVirtualMachine_Called          bool
+	CopyFileToVirtualMachine_VmName          string
+	CopyFileToVirtualMachine_SourcePath      string
+	CopyFileToVirtualMachine_DestinationPath string
+	CopyFileToVirtualMachine_Err             error
+
+	CopyFileFromVirtualMachine_Called          bool
+	CopyFileFromVirtualMachine_VmName          string
+	CopyFileFromVirtualMachine_Username        string
+	CopyFileFromVirtualMachine_Password        string
+	CopyFileFromVirtualMachine_SourcePath      string
+	CopyFileFromVirtualMachine_DestinationPath string
+	CopyFileFromVirtualMachine_Err             error
+
 	ExportVirtualMachine_Called bool
 	ExportVirtualMachine_VmName string
 	ExportVirtualMachine_Path   string
 	ExportVirtualMachine_Err    error
 
+	CheckpointVirtualMachine_Called         bool
+	CheckpointVirtualMachine_VmName         string
+	CheckpointVirtualMachine_CheckpointName string
+	CheckpointVirtualMachine_CheckpointType string
+	CheckpointVirtualMachine_Err            error
+
+	RemoveVirtualMachineCheckpoints_Called     bool
+	RemoveVirtualMachineCheckpoints_VmName     string
+	RemoveVirtualMachineCheckpoints_NamePrefix string
+	RemoveVirtualMachineCheckpoints_Err        error
+
+	ConvertVirtualMachineDisksToVHDSet_Called bool
+	ConvertVirtualMachineDisksToVHDSet_VmName string
+	ConvertVirtualMachineDisksToVHDSet_Err    error
+
+	ConvertVirtualMachineDisks_Called  bool
+	ConvertVirtualMachineDisks_VmName  string
+	ConvertVirtualMachineDisks_Format  string
+	ConvertVirtualMachineDisks_VhdType string
+	ConvertVirtualMachineDisks_Attach  bool
+	ConvertVirtualMachineDisks_Err     error
+
+	MergeVirtualMachineDifferencingDisks_Called bool
+	MergeVirtualMachineDifferencingDisks_VmName string
+	MergeVirtualMachineDifferencingDisks_Err    error
+
 	PreserveLegacyExportBehaviour_Called  bool
 	PreserveLegacyExportBehaviour_SrcPath string
 	PreserveLegacyExportBehaviour_DstPath string
@@ -270,6 +396,31 @@ type DriverMock struct {
 	UnmountFloppyDrive_VmName string
 	UnmountFloppyDrive_Err    error
 
+	PathExists_Called bool
+	PathExists_Path   string
+	PathExists_Return bool
+	PathExists_Err    error
+
+	CreateHostTempDirectory_Called bool
+	CreateHostTempDirectory_Parent string
+	CreateHostTempDirectory_Prefix string
+	CreateHostTempDirectory_Return string
+	CreateHostTempDirectory_Err    error
+
+	RemoveHostDirectory_Called bool
+	RemoveHostDirectory_Path   string
+	RemoveHostDirectory_Err    error
+
+	CopyFileToHost_Called      bool
+	CopyFileToHost_Source      []string
+	CopyFileToHost_Destination []string
+	CopyFileToHost_Err         error
+
+	CopyDirectoryFromHost_Called      bool
+	CopyDirectoryFromHost_Source      string
+	CopyDirectoryFromHost_Destination string
+	CopyDirectoryFromHost_Err         error
+
 	Connect_Called bool
 	Connect_VmName string
 	Connect_Cancel context.CancelFunc
@@ -297,6 +448,18 @@ func (d *DriverMock) Uptime(vmName string) (uint64, error) {
 	return d.Uptime_Return, d.Uptime_Err
 }
 
+func (d *DriverMock) GetVirtualMachineHeartbeatStatus(vmName string) (string, error) {
+	d.GetVirtualMachineHeartbeatStatus_Called = true
+	d.GetVirtualMachineHeartbeatStatus_VmName = vmName
+	return d.GetVirtualMachineHeartbeatStatus_Return, d.GetVirtualMachineHeartbeatStatus_Err
+}
+
+func (d *DriverMock) AreVirtualMachineDisksLocked(vmName string) (bool, error) {
+	d.AreVirtualMachineDisksLocked_Called = true
+	d.AreVirtualMachineDisksLocked_VmName = vmName
+	return d.AreVirtualMachineDisksLocked_Return, d.AreVirtualMachineDisksLocked_Err
+}
+
 func (d *DriverMock) Start(vmName string) error {
 	d.Start_Called = true
 	d.Start_VmName = vmName
@@ -326,6 +489,13 @@ func (d *DriverMock) IpAddress(mac string) (string, error) {
 	return d.IpAddress_Return, d.IpAddress_Err
 }
 
+func (d *DriverMock) IpAddresses(mac string, source string) ([]string, error) {
+	d.IpAddresses_Called = true
+	d.IpAddresses_Mac = mac
+	d.IpAddresses_Source = source
+	return d.IpAddresses_Return, d.IpAddresses_Err
+}
+
 func (d *DriverMock) GetHostName(ip string) (string, error) {
 	d.GetHostName_Called = true
 	d.GetHostName_Ip = ip
@@ -351,6 +521,13 @@ func (d *DriverMock) TypeScanCodes(vmName string, scanCodes string) error {
 	return d.TypeScanCodes_Err
 }
 
+func (d *DriverMock) TypeText(vmName string, text string) error {
+	d.TypeText_Called = true
+	d.TypeText_VmName = vmName
+	d.TypeText_Text = append(d.TypeText_Text, text)
+	return d.TypeText_Err
+}
+
 func (d *DriverMock) GetVirtualMachineNetworkAdapterAddress(vmName string) (string, error) {
 	d.GetVirtualMachineNetworkAdapterAddress_Called = true
 	d.GetVirtualMachineNetworkAdapterAddress_VmName = vmName
@@ -378,6 +555,18 @@ func (d *DriverMock) SetVmNetworkAdapterMacAddress(vmName string, mac string) er
 	return d.SetVmNetworkAdapterMacAddress_Err
 }
 
+func (d *DriverMock) AddVirtualMachineNetworkAdapter(vmName string, adapterName string, switchName string,
+	vlanId string, mac string, enableMacSpoofing bool) error {
+	d.AddVirtualMachineNetworkAdapter_Called = true
+	d.AddVirtualMachineNetworkAdapter_VmName = vmName
+	d.AddVirtualMachineNetworkAdapter_AdapterName = adapterName
+	d.AddVirtualMachineNetworkAdapter_SwitchName = switchName
+	d.AddVirtualMachineNetworkAdapter_VlanId = vlanId
+	d.AddVirtualMachineNetworkAdapter_Mac = mac
+	d.AddVirtualMachineNetworkAdapter_EnableMacSpoofing = enableMacSpoofing
+	return d.AddVirtualMachineNetworkAdapter_Err
+}
+
 func (d *DriverMock) SetVirtualMachineVlanId(vmName string, vlanId string) error {
 	d.SetVirtualMachineVlanId_Called = true
 	d.SetVirtualMachineVlanId_VmName = vmName
@@ -418,22 +607,44 @@ func (d *DriverMock) DeleteVirtualSwitch(switchName string) error {
 	return d.DeleteVirtualSwitch_Err
 }
 
-func (d *DriverMock) CreateVirtualSwitch(switchName string, switchType string) (bool, error) {
+func (d *DriverMock) CreateVirtualSwitch(switchName string, switchType string, netAdapterName string) (bool, error) {
 	d.CreateVirtualSwitch_Called = true
 	d.CreateVirtualSwitch_SwitchName = switchName
 	d.CreateVirtualSwitch_SwitchType = switchType
+	d.CreateVirtualSwitch_NetAdapterName = netAdapterName
 	return d.CreateVirtualSwitch_Return, d.CreateVirtualSwitch_Err
 }
 
+func (d *DriverMock) VirtualSwitchExists(switchName string) (bool, error) {
+	d.VirtualSwitchExists_Called = true
+	d.VirtualSwitchExists_SwitchName = switchName
+	return d.VirtualSwitchExists_Return, d.VirtualSwitchExists_Err
+}
+
+func (d *DriverMock) EnableVirtualSwitchNat(switchName string, natName string, prefix string) error {
+	d.EnableVirtualSwitchNat_Called = true
+	d.EnableVirtualSwitchNat_SwitchName = switchName
+	d.EnableVirtualSwitchNat_NatName = natName
+	d.EnableVirtualSwitchNat_Prefix = prefix
+	return d.EnableVirtualSwitchNat_Err
+}
+
+func (d *DriverMock) DisableVirtualSwitchNat(natName string) error {
+	d.DisableVirtualSwitchNat_Called = true
+	d.DisableVirtualSwitchNat_NatName = natName
+	return d.DisableVirtualSwitchNat_Err
+}
+
 func (d *DriverMock) AddVirtualMachineHardDrive(vmName string, vhdFile string, vhdName string,
-	vhdSizeBytes int64, vhdDiskBlockSize int64, controllerType string) error {
+	vhdSizeBytes int64, vhdDiskBlockSize int64, controllerType string, fixedVHD bool) error {
 	d.AddVirtualMachineHardDrive_Called = true
 	d.AddVirtualMachineHardDrive_VmName = vmName
 	d.AddVirtualMachineHardDrive_VhdFile = vhdFile
 	d.AddVirtualMachineHardDrive_VhdName = vhdName
 	d.AddVirtualMachineHardDrive_VhdSizeBytes = vhdSizeBytes
-	d.AddVirtualMachineHardDrive_VhdSizeBytes = vhdDiskBlockSize
+	d.AddVirtualMachineHardDrive_VhdBlockSize = vhdDiskBlockSize
 	d.AddVirtualMachineHardDrive_ControllerType = controllerType
+	d.AddVirtualMachineHardDrive_FixedVHD = fixedVHD
 	return d.AddVirtualMachineHardDrive_Err
 }
 
@@ -490,6 +701,26 @@ func (d *DriverMock) SetVirtualMachineCpuCount(vmName string, cpu uint) error {
 	return d.SetVirtualMachineCpuCount_Err
 }
 
+func (d *DriverMock) SetVirtualMachineCpuTopology(vmName string, cpusPerNumaNode uint,
+	numaNodesPerSocket uint, memoryPerNumaNodeBytes int64) error {
+	d.SetVirtualMachineCpuTopology_Called = true
+	d.SetVirtualMachineCpuTopology_VmName = vmName
+	d.SetVirtualMachineCpuTopology_CpusPerNumaNode = cpusPerNumaNode
+	d.SetVirtualMachineCpuTopology_NumaNodesPerSocket = numaNodesPerSocket
+	d.SetVirtualMachineCpuTopology_MemoryPerNumaNodeBytes = memoryPerNumaNodeBytes
+	return d.SetVirtualMachineCpuTopology_Err
+}
+
+func (d *DriverMock) SetVirtualMachineDynamicMemorySettings(vmName string, minimumBytes int64,
+	maximumBytes int64, bufferPercent uint) error {
+	d.SetVirtualMachineDynamicMemorySettings_Called = true
+	d.SetVirtualMachineDynamicMemorySettings_VmName = vmName
+	d.SetVirtualMachineDynamicMemorySettings_MinimumBytes = minimumBytes
+	d.SetVirtualMachineDynamicMemorySettings_MaximumBytes = maximumBytes
+	d.SetVirtualMachineDynamicMemorySettings_BufferPercent = bufferPercent
+	return d.SetVirtualMachineDynamicMemorySettings_Err
+}
+
 func (d *DriverMock) SetVirtualMachineMacSpoofing(vmName string, enable bool) error {
 	d.SetVirtualMachineMacSpoofing_Called = true
 	d.SetVirtualMachineMacSpoofing_VmName = vmName
@@ -512,6 +743,12 @@ func (d *DriverMock) SetVirtualMachineSecureBoot(vmName string, enable bool, tem
 	return d.SetVirtualMachineSecureBoot_Err
 }
 
+func (d *DriverMock) EnableVirtualMachineTPM(vmName string) error {
+	d.EnableVirtualMachineTPM_Called = true
+	d.EnableVirtualMachineTPM_VmName = vmName
+	return d.EnableVirtualMachineTPM_Err
+}
+
 func (d *DriverMock) SetVirtualMachineVirtualizationExtensions(vmName string, enable bool) error {
 	d.SetVirtualMachineVirtualizationExtensions_Called = true
 	d.SetVirtualMachineVirtualizationExtensions_VmName = vmName
@@ -519,6 +756,21 @@ func (d *DriverMock) SetVirtualMachineVirtualizationExtensions(vmName string, en
 	return d.SetVirtualMachineVirtualizationExtensions_Err
 }
 
+func (d *DriverMock) SetVirtualMachineProcessorCompatibility(vmName string, enable bool) error {
+	d.SetVirtualMachineProcessorCompatibility_Called = true
+	d.SetVirtualMachineProcessorCompatibility_VmName = vmName
+	d.SetVirtualMachineProcessorCompatibility_Enable = enable
+	return d.SetVirtualMachineProcessorCompatibility_Err
+}
+
+func (d *DriverMock) SetVirtualMachineComPort(vmName string, number uint, path string) error {
+	d.SetVirtualMachineComPort_Called = true
+	d.SetVirtualMachineComPort_VmName = vmName
+	d.SetVirtualMachineComPort_Number = number
+	d.SetVirtualMachineComPort_Path = path
+	return d.SetVirtualMachineComPort_Err
+}
+
 func (d *DriverMock) EnableVirtualMachineIntegrationService(vmName string, integrationServiceName string) error {
 	d.EnableVirtualMachineIntegrationService_Called = true
 	d.EnableVirtualMachineIntegrationService_VmName = vmName
@@ -526,6 +778,38 @@ func (d *DriverMock) EnableVirtualMachineIntegrationService(vmName string, integ
 	return d.EnableVirtualMachineIntegrationService_Err
 }
 
+func (d *DriverMock) InvokeVirtualMachineCommand(vmName string, username string, password string,
+	command string, stdout io.Writer, stderr io.Writer) (int, error) {
+	d.InvokeVirtualMachineCommand_Called = true
+	d.InvokeVirtualMachineCommand_VmName = vmName
+	d.InvokeVirtualMachineCommand_Username = username
+	d.InvokeVirtualMachineCommand_Password = password
+	d.InvokeVirtualMachineCommand_Command = command
+	if stdout != nil {
+		io.WriteString(stdout, d.InvokeVirtualMachineCommand_Stdout)
+	}
+	return d.InvokeVirtualMachineCommand_Return, d.InvokeVirtualMachineCommand_Err
+}
+
+func (d *DriverMock) CopyFileToVirtualMachine(vmName string, sourcePath string, destinationPath string) error {
+	d.CopyFileToVirtualMachine_Called = true
+	d.CopyFileToVirtualMachine_VmName = vmName
+	d.CopyFileToVirtualMachine_SourcePath = sourcePath
+	d.CopyFileToVirtualMachine_DestinationPath = destinationPath
+	return d.CopyFileToVirtualMachine_Err
+}
+
+func (d *DriverMock) CopyFileFromVirtualMachine(vmName string, username string, password string,
+	sourcePath string, destinationPath string) error {
+	d.CopyFileFromVirtualMachine_Called = true
+	d.CopyFileFromVirtualMachine_VmName = vmName
+	d.CopyFileFromVirtualMachine_Username = username
+	d.CopyFileFromVirtualMachine_Password = password
+	d.CopyFileFromVirtualMachine_SourcePath = sourcePath
+	d.CopyFileFromVirtualMachine_DestinationPath = destinationPath
+	return d.CopyFileFromVirtualMachine_Err
+}
+
 func (d *DriverMock) ExportVirtualMachine(vmName string, path string) error {
 	d.ExportVirtualMachine_Called = true
 	d.ExportVirtualMachine_VmName = vmName
@@ -533,6 +817,42 @@ func (d *DriverMock) ExportVirtualMachine(vmName string, path string) error {
 	return d.ExportVirtualMachine_Err
 }
 
+func (d *DriverMock) CheckpointVirtualMachine(vmName string, checkpointName string, checkpointType string) error {
+	d.CheckpointVirtualMachine_Called = true
+	d.CheckpointVirtualMachine_VmName = vmName
+	d.CheckpointVirtualMachine_CheckpointName = checkpointName
+	d.CheckpointVirtualMachine_CheckpointType = checkpointType
+	return d.CheckpointVirtualMachine_Err
+}
+
+func (d *DriverMock) RemoveVirtualMachineCheckpoints(vmName string, namePrefix string) error {
+	d.RemoveVirtualMachineCheckpoints_Called = true
+	d.RemoveVirtualMachineCheckpoints_VmName = vmName
+	d.RemoveVirtualMachineCheckpoints_NamePrefix = namePrefix
+	return d.RemoveVirtualMachineCheckpoints_Err
+}
+
+func (d *DriverMock) ConvertVirtualMachineDisksToVHDSet(vmName string) error {
+	d.ConvertVirtualMachineDisksToVHDSet_Called = true
+	d.ConvertVirtualMachineDisksToVHDSet_VmName = vmName
+	return d.ConvertVirtualMachineDisksToVHDSet_Err
+}
+
+func (d *DriverMock) ConvertVirtualMachineDisks(vmName string, format string, vhdType string, attach bool) error {
+	d.ConvertVirtualMachineDisks_Called = true
+	d.ConvertVirtualMachineDisks_VmName = vmName
+	d.ConvertVirtualMachineDisks_Format = format
+	d.ConvertVirtualMachineDisks_VhdType = vhdType
+	d.ConvertVirtualMachineDisks_Attach = attach
+	return d.ConvertVirtualMachineDisks_Err
+}
+
+func (d *DriverMock) MergeVirtualMachineDifferencingDisks(vmName string) error {
+	d.MergeVirtualMachineDifferencingDisks_Called = true
+	d.MergeVirtualMachineDifferencingDisks_VmName = vmName
+	return d.MergeVirtualMachineDifferencingDisks_Err
+}
+
 func (d *DriverMock) PreserveLegacyExportBehaviour(srcPath string, dstPath string) error {
 	d.PreserveLegacyExportBehaviour_Called = true
 	d.PreserveLegacyExportBehaviour_SrcPath = srcPath
@@ -635,6 +955,39 @@ func (d *DriverMock) UnmountFloppyDrive(vmName string) error {
 	return d.UnmountFloppyDrive_Err
 }
 
+func (d *DriverMock) PathExists(path string) (bool, error) {
+	d.PathExists_Called = true
+	d.PathExists_Path = path
+	return d.PathExists_Return, d.PathExists_Err
+}
+
+func (d *DriverMock) CreateHostTempDirectory(parent string, prefix string) (string, error) {
+	d.CreateHostTempDirectory_Called = true
+	d.CreateHostTempDirectory_Parent = parent
+	d.CreateHostTempDirectory_Prefix = prefix
+	return d.CreateHostTempDirectory_Return, d.CreateHostTempDirectory_Err
+}
+
+func (d *DriverMock) RemoveHostDirectory(path string) error {
+	d.RemoveHostDirectory_Called = true
+	d.RemoveHostDirectory_Path = path
+	return d.RemoveHostDirectory_Err
+}
+
+func (d *DriverMock) CopyFileToHost(source string, destination string) error {
+	d.CopyFileToHost_Called = true
+	d.CopyFileToHost_Source = append(d.CopyFileToHost_Source, source)
+	d.CopyFileToHost_Destination = append(d.CopyFileToHost_Destination, destination)
+	return d.CopyFileToHost_Err
+}
+
+func (d *DriverMock) CopyDirectoryFromHost(source string, destination string) error {
+	d.CopyDirectoryFromHost_Called = true
+	d.CopyDirectoryFromHost_Source = source
+	d.CopyDirectoryFromHost_Destination = destination
+	return d.CopyDirectoryFromHost_Err
+}
+
 func (d *DriverMock) Connect(vmName string) (context.CancelFunc, error) {
 	d.Connect_Called = true
 	d.Connect_VmName = vmName
@@ -3,19 +3,28 @@ package common
 import (
 	"context"
 	"fmt"
-	"log"
+	"io"
 	"runtime"
 	"strconv"
 	"strings"
 
 	"github.com/hashicorp/packer/builder/hyperv/common/powershell"
 	"github.com/hashicorp/packer/builder/hyperv/common/powershell/hyperv"
+	"github.com/hashicorp/packer/packer-plugin-sdk/logging"
 )
 
+// driverLogger logs the operations of the drivers.
+var driverLogger = logging.New("hyperv.driver")
+
 type HypervPS4Driver struct {
+	// The Hyper-V host the PowerShell commands run on, or nil to run them on
+	// this machine.
+	remote *powershell.Remote
 }
 
-func NewHypervPS4Driver() (Driver, error) {
+// NewHypervPS4Driver returns a driver that runs its PowerShell commands on
+// the remote Hyper-V host, or on this machine if remote is nil.
+func NewHypervPS4Driver(remote *powershell.Remote) (Driver, error) {
 	appliesTo := "Applies to Windows 8.1, Windows PowerShell 4.0, Windows Server 2012 R2 only"
 
 	// Check this is Windows
@@ -24,7 +33,7 @@ func NewHypervPS4Driver() (Driver, error) {
 		return nil, err
 	}
 
-	ps4Driver := &HypervPS4Driver{}
+	ps4Driver := &HypervPS4Driver{remote: remote}
 
 	if err := ps4Driver.Verify(); err != nil {
 		return nil, err
@@ -34,25 +43,33 @@ func NewHypervPS4Driver() (Driver, error) {
 }
 
 func (d *HypervPS4Driver) IsRunning(vmName string) (bool, error) {
-	return hyperv.IsRunning(vmName)
+	return hyperv.IsRunning(d.remote, vmName)
 }
 
 func (d *HypervPS4Driver) IsOff(vmName string) (bool, error) {
-	return hyperv.IsOff(vmName)
+	return hyperv.IsOff(d.remote, vmName)
 }
 
 func (d *HypervPS4Driver) Uptime(vmName string) (uint64, error) {
-	return hyperv.Uptime(vmName)
+	return hyperv.Uptime(d.remote, vmName)
+}
+
+func (d *HypervPS4Driver) GetVirtualMachineHeartbeatStatus(vmName string) (string, error) {
+	return hyperv.GetVirtualMachineHeartbeatStatus(d.remote, vmName)
+}
+
+func (d *HypervPS4Driver) AreVirtualMachineDisksLocked(vmName string) (bool, error) {
+	return hyperv.AreVirtualMachineDisksLocked(d.remote, vmName)
 }
 
 // Start starts a VM specified by the name given.
 func (d *HypervPS4Driver) Start(vmName string) error {
-	return hyperv.StartVirtualMachine(vmName)
+	return hyperv.StartVirtualMachine(d.remote, vmName)
 }
 
 // Stop stops a VM specified by the name given.
 func (d *HypervPS4Driver) Stop(vmName string) error {
-	return hyperv.StopVirtualMachine(vmName)
+	return hyperv.StopVirtualMachine(d.remote, vmName)
 }
 
 func (d *HypervPS4Driver) Verify() error {
@@ -74,7 +91,7 @@ func (d *HypervPS4Driver) Verify() error {
 
 // Get mac address for VM.
 func (d *HypervPS4Driver) Mac(vmName string) (string, error) {
-	res, err := hyperv.Mac(vmName)
+	res, err := hyperv.Mac(d.remote, vmName)
 
 	if err != nil {
 		return res, err
@@ -90,7 +107,7 @@ func (d *HypervPS4Driver) Mac(vmName string) (string, error) {
 
 // Get ip address for mac address.
 func (d *HypervPS4Driver) IpAddress(mac string) (string, error) {
-	res, err := hyperv.IpAddress(mac)
+	res, err := hyperv.IpAddress(d.remote, mac)
 
 	if err != nil {
 		return res, err
@@ -103,18 +120,23 @@ func (d *HypervPS4Driver) IpAddress(mac string) (string, error) {
 	return res, err
 }
 
+// Get all ip addresses for mac address from the given source.
+func (d *HypervPS4Driver) IpAddresses(mac string, source string) ([]string, error) {
+	return hyperv.IpAddresses(d.remote, mac, source == IpAddressSourceKvp)
+}
+
 // Get host name from ip address
 func (d *HypervPS4Driver) GetHostName(ip string) (string, error) {
-	return powershell.GetHostName(ip)
+	return powershell.GetHostName(d.remote, ip)
 }
 
 func (d *HypervPS4Driver) GetVirtualMachineGeneration(vmName string) (uint, error) {
-	return hyperv.GetVirtualMachineGeneration(vmName)
+	return hyperv.GetVirtualMachineGeneration(d.remote, vmName)
 }
 
 // Finds the IP address of a host adapter connected to switch
 func (d *HypervPS4Driver) GetHostAdapterIpAddressForSwitch(switchName string) (string, error) {
-	res, err := hyperv.GetHostAdapterIpAddressForSwitch(switchName)
+	res, err := hyperv.GetHostAdapterIpAddressForSwitch(d.remote, switchName)
 
 	if err != nil {
 		return res, err
@@ -129,183 +151,281 @@ func (d *HypervPS4Driver) GetHostAdapterIpAddressForSwitch(switchName string) (s
 
 // Type scan codes to virtual keyboard of vm
 func (d *HypervPS4Driver) TypeScanCodes(vmName string, scanCodes string) error {
-	return hyperv.TypeScanCodes(vmName, scanCodes)
+	return hyperv.TypeScanCodes(d.remote, vmName, scanCodes)
+}
+
+// Type ASCII text to virtual keyboard of vm
+func (d *HypervPS4Driver) TypeText(vmName string, text string) error {
+	return hyperv.TypeText(d.remote, vmName, text)
 }
 
 // Get network adapter address
 func (d *HypervPS4Driver) GetVirtualMachineNetworkAdapterAddress(vmName string) (string, error) {
-	return hyperv.GetVirtualMachineNetworkAdapterAddress(vmName)
+	return hyperv.GetVirtualMachineNetworkAdapterAddress(d.remote, vmName)
 }
 
 //Set the vlan to use for switch
 func (d *HypervPS4Driver) SetNetworkAdapterVlanId(switchName string, vlanId string) error {
-	return hyperv.SetNetworkAdapterVlanId(switchName, vlanId)
+	return hyperv.SetNetworkAdapterVlanId(d.remote, switchName, vlanId)
 }
 
 //Set the vlan to use for machine
 func (d *HypervPS4Driver) SetVirtualMachineVlanId(vmName string, vlanId string) error {
-	return hyperv.SetVirtualMachineVlanId(vmName, vlanId)
+	return hyperv.SetVirtualMachineVlanId(d.remote, vmName, vlanId)
+}
+
+func (d *HypervPS4Driver) AddVirtualMachineNetworkAdapter(vmName string, adapterName string, switchName string,
+	vlanId string, mac string, enableMacSpoofing bool) error {
+	return hyperv.AddVirtualMachineNetworkAdapter(d.remote, vmName, adapterName, switchName, vlanId, mac, enableMacSpoofing)
 }
 
 func (d *HypervPS4Driver) SetVmNetworkAdapterMacAddress(vmName string, mac string) error {
-	return hyperv.SetVmNetworkAdapterMacAddress(vmName, mac)
+	return hyperv.SetVmNetworkAdapterMacAddress(d.remote, vmName, mac)
 }
 
 //Replace the network adapter with a (non-)legacy adapter
 func (d *HypervPS4Driver) ReplaceVirtualMachineNetworkAdapter(vmName string, virtual bool) error {
-	return hyperv.ReplaceVirtualMachineNetworkAdapter(vmName, virtual)
+	return hyperv.ReplaceVirtualMachineNetworkAdapter(d.remote, vmName, virtual)
 }
 
 func (d *HypervPS4Driver) UntagVirtualMachineNetworkAdapterVlan(vmName string, switchName string) error {
-	return hyperv.UntagVirtualMachineNetworkAdapterVlan(vmName, switchName)
+	return hyperv.UntagVirtualMachineNetworkAdapterVlan(d.remote, vmName, switchName)
 }
 
 func (d *HypervPS4Driver) CreateExternalVirtualSwitch(vmName string, switchName string) error {
-	return hyperv.CreateExternalVirtualSwitch(vmName, switchName)
+	return hyperv.CreateExternalVirtualSwitch(d.remote, vmName, switchName)
 }
 
 func (d *HypervPS4Driver) GetVirtualMachineSwitchName(vmName string) (string, error) {
-	return hyperv.GetVirtualMachineSwitchName(vmName)
+	return hyperv.GetVirtualMachineSwitchName(d.remote, vmName)
 }
 
 func (d *HypervPS4Driver) ConnectVirtualMachineNetworkAdapterToSwitch(vmName string, switchName string) error {
-	return hyperv.ConnectVirtualMachineNetworkAdapterToSwitch(vmName, switchName)
+	return hyperv.ConnectVirtualMachineNetworkAdapterToSwitch(d.remote, vmName, switchName)
 }
 
 func (d *HypervPS4Driver) DeleteVirtualSwitch(switchName string) error {
-	return hyperv.DeleteVirtualSwitch(switchName)
+	return hyperv.DeleteVirtualSwitch(d.remote, switchName)
 }
 
-func (d *HypervPS4Driver) CreateVirtualSwitch(switchName string, switchType string) (bool, error) {
-	return hyperv.CreateVirtualSwitch(switchName, switchType)
+func (d *HypervPS4Driver) CreateVirtualSwitch(switchName string, switchType string, netAdapterName string) (bool, error) {
+	return hyperv.CreateVirtualSwitch(d.remote, switchName, switchType, netAdapterName)
+}
+
+func (d *HypervPS4Driver) VirtualSwitchExists(switchName string) (bool, error) {
+	return hyperv.VirtualSwitchExists(d.remote, switchName)
+}
+
+func (d *HypervPS4Driver) EnableVirtualSwitchNat(switchName string, natName string, prefix string) error {
+	return hyperv.EnableVirtualSwitchNat(d.remote, switchName, natName, prefix)
+}
+
+func (d *HypervPS4Driver) DisableVirtualSwitchNat(natName string) error {
+	return hyperv.DisableVirtualSwitchNat(d.remote, natName)
 }
 
 func (d *HypervPS4Driver) AddVirtualMachineHardDrive(vmName string, vhdFile string, vhdName string,
-	vhdSizeBytes int64, diskBlockSize int64, controllerType string) error {
-	return hyperv.AddVirtualMachineHardDiskDrive(vmName, vhdFile, vhdName, vhdSizeBytes,
-		diskBlockSize, controllerType)
+	vhdSizeBytes int64, diskBlockSize int64, controllerType string, fixedVHD bool) error {
+	return hyperv.AddVirtualMachineHardDiskDrive(d.remote, vmName, vhdFile, vhdName, vhdSizeBytes,
+		diskBlockSize, controllerType, fixedVHD)
 }
 
 func (d *HypervPS4Driver) CheckVMName(vmName string) error {
-	return hyperv.CheckVMName(vmName)
+	return hyperv.CheckVMName(d.remote, vmName)
 }
 
 func (d *HypervPS4Driver) CreateVirtualMachine(vmName string, path string, harddrivePath string, ram int64,
 	diskSize int64, diskBlockSize int64, switchName string, generation uint, diffDisks bool,
 	fixedVHD bool, version string) error {
-	return hyperv.CreateVirtualMachine(vmName, path, harddrivePath, ram, diskSize, diskBlockSize, switchName,
+	return hyperv.CreateVirtualMachine(d.remote, vmName, path, harddrivePath, ram, diskSize, diskBlockSize, switchName,
 		generation, diffDisks, fixedVHD, version)
 }
 
 func (d *HypervPS4Driver) CloneVirtualMachine(cloneFromVmcxPath string, cloneFromVmName string,
 	cloneFromSnapshotName string, cloneAllSnapshots bool, vmName string, path string, harddrivePath string,
 	ram int64, switchName string, copyTF bool) error {
-	return hyperv.CloneVirtualMachine(cloneFromVmcxPath, cloneFromVmName, cloneFromSnapshotName,
+	return hyperv.CloneVirtualMachine(d.remote, cloneFromVmcxPath, cloneFromVmName, cloneFromSnapshotName,
 		cloneAllSnapshots, vmName, path, harddrivePath, ram, switchName, copyTF)
 }
 
 func (d *HypervPS4Driver) DeleteVirtualMachine(vmName string) error {
-	return hyperv.DeleteVirtualMachine(vmName)
+	return hyperv.DeleteVirtualMachine(d.remote, vmName)
 }
 
 func (d *HypervPS4Driver) SetVirtualMachineCpuCount(vmName string, cpu uint) error {
-	return hyperv.SetVirtualMachineCpuCount(vmName, cpu)
+	return hyperv.SetVirtualMachineCpuCount(d.remote, vmName, cpu)
+}
+
+func (d *HypervPS4Driver) SetVirtualMachineCpuTopology(vmName string, cpusPerNumaNode uint,
+	numaNodesPerSocket uint, memoryPerNumaNodeBytes int64) error {
+	return hyperv.SetVirtualMachineCpuTopology(d.remote, vmName, cpusPerNumaNode, numaNodesPerSocket, memoryPerNumaNodeBytes)
+}
+
+func (d *HypervPS4Driver) SetVirtualMachineDynamicMemorySettings(vmName string, minimumBytes int64,
+	maximumBytes int64, bufferPercent uint) error {
+	return hyperv.SetVirtualMachineDynamicMemorySettings(d.remote, vmName, minimumBytes, maximumBytes, bufferPercent)
 }
 
 func (d *HypervPS4Driver) SetVirtualMachineMacSpoofing(vmName string, enable bool) error {
-	return hyperv.SetVirtualMachineMacSpoofing(vmName, enable)
+	return hyperv.SetVirtualMachineMacSpoofing(d.remote, vmName, enable)
 }
 
 func (d *HypervPS4Driver) SetVirtualMachineDynamicMemory(vmName string, enable bool) error {
-	return hyperv.SetVirtualMachineDynamicMemory(vmName, enable)
+	return hyperv.SetVirtualMachineDynamicMemory(d.remote, vmName, enable)
 }
 
 func (d *HypervPS4Driver) SetVirtualMachineSecureBoot(vmName string, enable bool, templateName string) error {
-	return hyperv.SetVirtualMachineSecureBoot(vmName, enable, templateName)
+	return hyperv.SetVirtualMachineSecureBoot(d.remote, vmName, enable, templateName)
+}
+
+func (d *HypervPS4Driver) EnableVirtualMachineTPM(vmName string) error {
+	return hyperv.EnableVirtualMachineTPM(d.remote, vmName)
 }
 
 func (d *HypervPS4Driver) SetVirtualMachineVirtualizationExtensions(vmName string, enable bool) error {
-	return hyperv.SetVirtualMachineVirtualizationExtensions(vmName, enable)
+	return hyperv.SetVirtualMachineVirtualizationExtensions(d.remote, vmName, enable)
+}
+
+func (d *HypervPS4Driver) SetVirtualMachineProcessorCompatibility(vmName string, enable bool) error {
+	return hyperv.SetVirtualMachineProcessorCompatibility(d.remote, vmName, enable)
+}
+
+func (d *HypervPS4Driver) SetVirtualMachineComPort(vmName string, number uint, path string) error {
+	return hyperv.SetVirtualMachineComPort(d.remote, vmName, number, path)
 }
 
 func (d *HypervPS4Driver) EnableVirtualMachineIntegrationService(vmName string,
 	integrationServiceName string) error {
-	return hyperv.EnableVirtualMachineIntegrationService(vmName, integrationServiceName)
+	return hyperv.EnableVirtualMachineIntegrationService(d.remote, vmName, integrationServiceName)
+}
+
+func (d *HypervPS4Driver) InvokeVirtualMachineCommand(vmName string, username string, password string,
+	command string, stdout io.Writer, stderr io.Writer) (int, error) {
+	return hyperv.InvokeVirtualMachineCommand(d.remote, vmName, username, password, command, stdout, stderr)
+}
+
+func (d *HypervPS4Driver) CopyFileToVirtualMachine(vmName string, sourcePath string, destinationPath string) error {
+	return hyperv.CopyFileToVirtualMachine(d.remote, vmName, sourcePath, destinationPath)
+}
+
+func (d *HypervPS4Driver) CopyFileFromVirtualMachine(vmName string, username string, password string,
+	sourcePath string, destinationPath string) error {
+	return hyperv.CopyFileFromVirtualMachine(d.remote, vmName, username, password, sourcePath, destinationPath)
 }
 
 func (d *HypervPS4Driver) ExportVirtualMachine(vmName string, path string) error {
-	return hyperv.ExportVirtualMachine(vmName, path)
+	return hyperv.ExportVirtualMachine(d.remote, vmName, path)
+}
+
+func (d *HypervPS4Driver) CheckpointVirtualMachine(vmName string, checkpointName string, checkpointType string) error {
+	return hyperv.CheckpointVirtualMachine(d.remote, vmName, checkpointName, checkpointType)
+}
+
+func (d *HypervPS4Driver) RemoveVirtualMachineCheckpoints(vmName string, namePrefix string) error {
+	return hyperv.RemoveVirtualMachineCheckpoints(d.remote, vmName, namePrefix)
+}
+
+func (d *HypervPS4Driver) PathExists(path string) (bool, error) {
+	return hyperv.PathExists(d.remote, path)
+}
+
+func (d *HypervPS4Driver) CreateHostTempDirectory(parent string, prefix string) (string, error) {
+	return hyperv.CreateTempDirectory(d.remote, parent, prefix)
+}
+
+func (d *HypervPS4Driver) RemoveHostDirectory(path string) error {
+	return hyperv.RemoveDirectory(d.remote, path)
+}
+
+func (d *HypervPS4Driver) CopyFileToHost(source string, destination string) error {
+	return hyperv.CopyFileToHost(d.remote, source, destination)
+}
+
+func (d *HypervPS4Driver) CopyDirectoryFromHost(source string, destination string) error {
+	return hyperv.CopyDirectoryFromHost(d.remote, source, destination)
+}
+
+func (d *HypervPS4Driver) ConvertVirtualMachineDisksToVHDSet(vmName string) error {
+	return hyperv.ConvertVirtualMachineDisksToVHDSet(d.remote, vmName)
+}
+
+func (d *HypervPS4Driver) ConvertVirtualMachineDisks(vmName string, format string, vhdType string, attach bool) error {
+	return hyperv.ConvertVirtualMachineDisks(d.remote, vmName, format, vhdType, attach)
+}
+
+func (d *HypervPS4Driver) MergeVirtualMachineDifferencingDisks(vmName string) error {
+	return hyperv.MergeVirtualMachineDifferencingDisks(d.remote, vmName)
 }
 
 func (d *HypervPS4Driver) PreserveLegacyExportBehaviour(srcPath string, dstPath string) error {
-	return hyperv.PreserveLegacyExportBehaviour(srcPath, dstPath)
+	return hyperv.PreserveLegacyExportBehaviour(d.remote, srcPath, dstPath)
 }
 
 func (d *HypervPS4Driver) MoveCreatedVHDsToOutputDir(srcPath string, dstPath string) error {
-	return hyperv.MoveCreatedVHDsToOutputDir(srcPath, dstPath)
+	return hyperv.MoveCreatedVHDsToOutputDir(d.remote, srcPath, dstPath)
 }
 
 func (d *HypervPS4Driver) CompactDisks(path string) (result string, err error) {
-	return hyperv.CompactDisks(path)
+	return hyperv.CompactDisks(d.remote, path)
 }
 
 func (d *HypervPS4Driver) RestartVirtualMachine(vmName string) error {
-	return hyperv.RestartVirtualMachine(vmName)
+	return hyperv.RestartVirtualMachine(d.remote, vmName)
 }
 
 func (d *HypervPS4Driver) CreateDvdDrive(vmName string, isoPath string, generation uint) (uint, uint, error) {
-	return hyperv.CreateDvdDrive(vmName, isoPath, generation)
+	return hyperv.CreateDvdDrive(d.remote, vmName, isoPath, generation)
 }
 
 func (d *HypervPS4Driver) MountDvdDrive(vmName string, path string, controllerNumber uint,
 	controllerLocation uint) error {
-	return hyperv.MountDvdDrive(vmName, path, controllerNumber, controllerLocation)
+	return hyperv.MountDvdDrive(d.remote, vmName, path, controllerNumber, controllerLocation)
 }
 
 func (d *HypervPS4Driver) SetBootDvdDrive(vmName string, controllerNumber uint, controllerLocation uint,
 	generation uint) error {
-	return hyperv.SetBootDvdDrive(vmName, controllerNumber, controllerLocation, generation)
+	return hyperv.SetBootDvdDrive(d.remote, vmName, controllerNumber, controllerLocation, generation)
 }
 
 func (d *HypervPS4Driver) SetFirstBootDevice(vmName string, controllerType string, controllerNumber uint,
 	controllerLocation uint, generation uint) error {
-	return hyperv.SetFirstBootDevice(vmName, controllerType, controllerNumber, controllerLocation, generation)
+	return hyperv.SetFirstBootDevice(d.remote, vmName, controllerType, controllerNumber, controllerLocation, generation)
 }
 
 func (d *HypervPS4Driver) SetBootOrder(vmName string, bootOrder []string) error {
-	return hyperv.SetBootOrder(vmName, bootOrder)
+	return hyperv.SetBootOrder(d.remote, vmName, bootOrder)
 }
 
 func (d *HypervPS4Driver) UnmountDvdDrive(vmName string, controllerNumber uint, controllerLocation uint) error {
-	return hyperv.UnmountDvdDrive(vmName, controllerNumber, controllerLocation)
+	return hyperv.UnmountDvdDrive(d.remote, vmName, controllerNumber, controllerLocation)
 }
 
 func (d *HypervPS4Driver) DeleteDvdDrive(vmName string, controllerNumber uint, controllerLocation uint) error {
-	return hyperv.DeleteDvdDrive(vmName, controllerNumber, controllerLocation)
+	return hyperv.DeleteDvdDrive(d.remote, vmName, controllerNumber, controllerLocation)
 }
 
 func (d *HypervPS4Driver) MountFloppyDrive(vmName string, path string) error {
-	return hyperv.MountFloppyDrive(vmName, path)
+	return hyperv.MountFloppyDrive(d.remote, vmName, path)
 }
 
 func (d *HypervPS4Driver) UnmountFloppyDrive(vmName string) error {
-	return hyperv.UnmountFloppyDrive(vmName)
+	return hyperv.UnmountFloppyDrive(d.remote, vmName)
 }
 
 func (d *HypervPS4Driver) verifyPSVersion() error {
 
-	log.Printf("Enter method: %s", "verifyPSVersion")
+	driverLogger.Tracef("Enter method: %s", "verifyPSVersion")
 	// check PS is available and is of proper version
 	versionCmd := "$host.version.Major"
 
-	var ps powershell.PowerShellCmd
+	ps := powershell.PowerShellCmd{Remote: d.remote}
 	cmdOut, err := ps.Output(versionCmd)
 	if err != nil {
 		return err
 	}
 
 	versionOutput := strings.TrimSpace(cmdOut)
-	log.Printf("%s output: %s", versionCmd, versionOutput)
+	driverLogger.Debugf("%s output: %s", versionCmd, versionOutput)
 
 	ver, err := strconv.ParseInt(versionOutput, 10, 32)
 
@@ -323,11 +443,11 @@ func (d *HypervPS4Driver) verifyPSVersion() error {
 
 func (d *HypervPS4Driver) verifyPSHypervModule() error {
 
-	log.Printf("Enter method: %s", "verifyPSHypervModule")
+	driverLogger.Tracef("Enter method: %s", "verifyPSHypervModule")
 
 	versionCmd := "function foo(){try{ $commands = Get-Command -Module Hyper-V;if($commands.Length -eq 0){return $false} }catch{return $false}; return $true} foo"
 
-	var ps powershell.PowerShellCmd
+	ps := powershell.PowerShellCmd{Remote: d.remote}
 	cmdOut, err := ps.Output(versionCmd)
 	if err != nil {
 		return err
@@ -352,7 +472,7 @@ $hypervrole = [System.Security.Principal.SecurityIdentifier]"S-1-5-32-578"
 return $principal.IsInRole($hypervrole)
 `
 
-	var ps powershell.PowerShellCmd
+	ps := powershell.PowerShellCmd{Remote: d.remote}
 	cmdOut, err := ps.Output(script)
 	if err != nil {
 		return false, err
@@ -363,15 +483,15 @@ return $principal.IsInRole($hypervrole)
 
 func (d *HypervPS4Driver) verifyHypervPermissions() error {
 
-	log.Printf("Enter method: %s", "verifyHypervPermissions")
+	driverLogger.Tracef("Enter method: %s", "verifyHypervPermissions")
 
 	hyperVAdmin, err := d.isCurrentUserAHyperVAdministrator()
 	if err != nil {
-		log.Printf("Error discovering if current is is a Hyper-V Admin: %s", err)
+		driverLogger.Warnf("Error discovering if current is is a Hyper-V Admin: %s", err)
 	}
 	if !hyperVAdmin {
 
-		isAdmin, _ := powershell.IsCurrentUserAnAdministrator()
+		isAdmin, _ := powershell.IsCurrentUserAnAdministrator(d.remote)
 
 		if !isAdmin {
 			err := fmt.Errorf("%s", "Current user is not a member of 'Hyper-V Administrators' or 'Administrators' group")
@@ -384,7 +504,7 @@ func (d *HypervPS4Driver) verifyHypervPermissions() error {
 
 // Connect connects to a VM specified by the name given.
 func (d *HypervPS4Driver) Connect(vmName string) (context.CancelFunc, error) {
-	return hyperv.ConnectVirtualMachine(vmName)
+	return hyperv.ConnectVirtualMachine(d.remote, vmName)
 }
 
 // Disconnect disconnects to a VM specified by calling the context cancel function returned
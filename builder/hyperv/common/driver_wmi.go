@@ -0,0 +1,415 @@
+package common
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Values of the EnabledState property of the Msvm_ComputerSystem class.
+const (
+	wmiEnabledStateEnabled  = 2
+	wmiEnabledStateDisabled = 3
+)
+
+// wmiResourceTypeLogicalDisk is the ResourceType of the virtual hard disks in
+// the Msvm_StorageAllocationSettingData class.
+const wmiResourceTypeLogicalDisk = 31
+
+var errWMINotSupported = errors.New("WMI is only supported on Windows")
+
+// wmiComputerSystem holds the properties of a Msvm_ComputerSystem instance
+// that the WMI driver reads. Name is the ID of the virtual machine.
+type wmiComputerSystem struct {
+	Name                 string
+	ElementName          string
+	EnabledState         uint16
+	OnTimeInMilliseconds uint64
+}
+
+// wmiEthernetPort holds the properties of a Msvm_SyntheticEthernetPortSettingData
+// or Msvm_EmulatedEthernetPortSettingData instance. Its InstanceID is
+// "Microsoft:<vm id>\<device id>".
+type wmiEthernetPort struct {
+	InstanceID string
+	Address    string
+}
+
+type wmiGuestNetworkAdapterConfiguration struct {
+	IPAddresses []string
+}
+
+type wmiKvpExchangeComponent struct {
+	GuestIntrinsicExchangeItems []string
+}
+
+type wmiHeartbeatComponent struct {
+	OperationalStatus []uint16
+}
+
+type wmiStorageAllocation struct {
+	HostResource []string
+}
+
+// HypervWMIDriver queries virtual machines from the Hyper-V WMI v2 namespace
+// instead of spawning PowerShell: their state, uptime and heartbeat, whether
+// their disks are locked, and their MAC and IP addresses. This makes the
+// operations that are polled repeatedly during a build much cheaper. The
+// operations that change a virtual machine or the host, and any query that
+// fails over WMI, are handled by the embedded PowerShell driver.
+type HypervWMIDriver struct {
+	*HypervPS4Driver
+}
+
+func NewHypervWMIDriver() (Driver, error) {
+	driver, err := NewHypervPS4Driver(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return &HypervWMIDriver{HypervPS4Driver: driver.(*HypervPS4Driver)}, nil
+}
+
+func (d *HypervWMIDriver) IsRunning(vmName string) (bool, error) {
+	vm, err := queryWMIComputerSystem(vmName)
+	if err != nil {
+		driverLogger.Warnf("Failed querying VM state over WMI, falling back to PowerShell: %s", err)
+		return d.HypervPS4Driver.IsRunning(vmName)
+	}
+	if vm == nil {
+		return false, nil
+	}
+
+	return vm.EnabledState == wmiEnabledStateEnabled, nil
+}
+
+func (d *HypervWMIDriver) IsOff(vmName string) (bool, error) {
+	vm, err := queryWMIComputerSystem(vmName)
+	if err != nil {
+		driverLogger.Warnf("Failed querying VM state over WMI, falling back to PowerShell: %s", err)
+		return d.HypervPS4Driver.IsOff(vmName)
+	}
+	if vm == nil {
+		return false, nil
+	}
+
+	return vm.EnabledState == wmiEnabledStateDisabled, nil
+}
+
+func (d *HypervWMIDriver) Uptime(vmName string) (uint64, error) {
+	vm, err := queryWMIComputerSystem(vmName)
+	if err != nil {
+		driverLogger.Warnf("Failed querying VM uptime over WMI, falling back to PowerShell: %s", err)
+		return d.HypervPS4Driver.Uptime(vmName)
+	}
+	if vm == nil {
+		return 0, nil
+	}
+
+	return vm.OnTimeInMilliseconds / 1000, nil
+}
+
+func (d *HypervWMIDriver) GetVirtualMachineHeartbeatStatus(vmName string) (string, error) {
+	status, err := wmiHeartbeatStatus(vmName)
+	if err != nil {
+		driverLogger.Warnf("Failed querying VM heartbeat over WMI, falling back to PowerShell: %s", err)
+		return d.HypervPS4Driver.GetVirtualMachineHeartbeatStatus(vmName)
+	}
+	return status, nil
+}
+
+func (d *HypervWMIDriver) AreVirtualMachineDisksLocked(vmName string) (bool, error) {
+	locked, err := wmiDisksLocked(vmName)
+	if err != nil {
+		driverLogger.Warnf("Failed querying VM disks over WMI, falling back to PowerShell: %s", err)
+		return d.HypervPS4Driver.AreVirtualMachineDisksLocked(vmName)
+	}
+	return locked, nil
+}
+
+func (d *HypervWMIDriver) Mac(vmName string) (string, error) {
+	mac, err := wmiMac(vmName)
+	if err != nil {
+		driverLogger.Warnf("Failed querying VM MAC address over WMI, falling back to PowerShell: %s", err)
+		return d.HypervPS4Driver.Mac(vmName)
+	}
+	if mac == "" {
+		return "", errors.New("No mac address.")
+	}
+	return mac, nil
+}
+
+func (d *HypervWMIDriver) IpAddress(mac string) (string, error) {
+	addresses, err := wmiIpAddresses(mac, false)
+	if err != nil {
+		driverLogger.Warnf("Failed querying VM IP address over WMI, falling back to PowerShell: %s", err)
+		return d.HypervPS4Driver.IpAddress(mac)
+	}
+	if len(addresses) == 0 {
+		return "", errors.New("No ip address.")
+	}
+	return addresses[0], nil
+}
+
+func (d *HypervWMIDriver) IpAddresses(mac string, source string) ([]string, error) {
+	addresses, err := wmiIpAddresses(mac, source == IpAddressSourceKvp)
+	if err != nil {
+		driverLogger.Warnf("Failed querying VM IP addresses over WMI, falling back to PowerShell: %s", err)
+		return d.HypervPS4Driver.IpAddresses(mac, source)
+	}
+	return addresses, nil
+}
+
+// queryWMIComputerSystem returns the Msvm_ComputerSystem instance of the
+// virtual machine named vmName, or nil if there is no such virtual machine.
+func queryWMIComputerSystem(vmName string) (*wmiComputerSystem, error) {
+	var dst []wmiComputerSystem
+	query := fmt.Sprintf("SELECT Name, ElementName, EnabledState, OnTimeInMilliseconds FROM Msvm_ComputerSystem "+
+		"WHERE Caption = 'Virtual Machine' AND ElementName = '%s'", escapeWQLString(vmName))
+	if err := queryWMI(query, &dst); err != nil {
+		return nil, err
+	}
+	if len(dst) == 0 {
+		return nil, nil
+	}
+
+	return &dst[0], nil
+}
+
+// queryWMIEthernetPorts returns the network adapters whose property
+// matches the WQL condition where, synthetic and legacy ones.
+func queryWMIEthernetPorts(where string) ([]wmiEthernetPort, error) {
+	var ports []wmiEthernetPort
+	for _, class := range []string{"Msvm_SyntheticEthernetPortSettingData", "Msvm_EmulatedEthernetPortSettingData"} {
+		var dst []wmiEthernetPort
+		if err := queryWMI(fmt.Sprintf("SELECT InstanceID, Address FROM %s WHERE %s", class, where), &dst); err != nil {
+			return nil, err
+		}
+		ports = append(ports, dst...)
+	}
+	return ports, nil
+}
+
+// wmiHeartbeatStatus returns the heartbeat of the virtual machine named
+// vmName, with the names of the Microsoft.HyperV.PowerShell.VMHeartbeatStatus
+// values, or Off when the virtual machine is off.
+func wmiHeartbeatStatus(vmName string) (string, error) {
+	vm, err := queryWMIComputerSystem(vmName)
+	if err != nil {
+		return "", err
+	}
+	if vm == nil {
+		return "", fmt.Errorf("no virtual machine named %q", vmName)
+	}
+	if vm.EnabledState == wmiEnabledStateDisabled {
+		return "Off", nil
+	}
+
+	var dst []wmiHeartbeatComponent
+	query := fmt.Sprintf("SELECT OperationalStatus FROM Msvm_HeartbeatComponent WHERE SystemName = '%s'",
+		escapeWQLString(vm.Name))
+	if err := queryWMI(query, &dst); err != nil {
+		return "", err
+	}
+	if len(dst) == 0 {
+		return "Disabled", nil
+	}
+	return heartbeatStatus(dst[0].OperationalStatus), nil
+}
+
+// heartbeatStatus converts the OperationalStatus of a Msvm_HeartbeatComponent
+// to the name of its Microsoft.HyperV.PowerShell.VMHeartbeatStatus value. The
+// second status, when there is one, is the health of the applications of the
+// guest.
+func heartbeatStatus(operationalStatus []uint16) string {
+	if len(operationalStatus) == 0 {
+		return "Unknown"
+	}
+	switch operationalStatus[0] {
+	case 2:
+		if len(operationalStatus) < 2 {
+			return "OkApplicationsUnknown"
+		}
+		switch operationalStatus[1] {
+		case 2:
+			return "OkApplicationsHealthy"
+		case 32782:
+			return "OkApplicationsCritical"
+		}
+		return "OkApplicationsUnknown"
+	case 3, 7:
+		return "Error"
+	case 12:
+		return "NoContact"
+	case 13:
+		return "LostCommunication"
+	case 15:
+		return "Paused"
+	}
+	return "Unknown"
+}
+
+// wmiDisksLocked reports whether a virtual hard disk of the virtual machine
+// named vmName is still opened by Hyper-V.
+func wmiDisksLocked(vmName string) (bool, error) {
+	vm, err := queryWMIComputerSystem(vmName)
+	if err != nil {
+		return false, err
+	}
+	if vm == nil {
+		return false, nil
+	}
+
+	var dst []wmiStorageAllocation
+	query := fmt.Sprintf("SELECT HostResource FROM Msvm_StorageAllocationSettingData "+
+		"WHERE InstanceID LIKE 'Microsoft:%s%%' AND ResourceType = %d",
+		escapeWQLString(vm.Name), wmiResourceTypeLogicalDisk)
+	if err := queryWMI(query, &dst); err != nil {
+		return false, err
+	}
+	for _, disk := range dst {
+		for _, path := range disk.HostResource {
+			if isFileLocked(path) {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// wmiMac returns the MAC address of the network adapter of the virtual
+// machine named vmName. Without a way to tell which adapter PowerShell
+// lists first, it fails when the virtual machine has several adapters, so
+// that the PowerShell driver picks the same one as before.
+func wmiMac(vmName string) (string, error) {
+	vm, err := queryWMIComputerSystem(vmName)
+	if err != nil {
+		return "", err
+	}
+	if vm == nil {
+		return "", nil
+	}
+
+	ports, err := queryWMIEthernetPorts(fmt.Sprintf("InstanceID LIKE 'Microsoft:%s%%'", escapeWQLString(vm.Name)))
+	if err != nil {
+		return "", err
+	}
+	switch len(ports) {
+	case 0:
+		return "", nil
+	case 1:
+		return ports[0].Address, nil
+	}
+	return "", fmt.Errorf("%s has %d network adapters", vmName, len(ports))
+}
+
+// wmiIpAddresses returns the IP addresses of the network adapter with the
+// MAC address mac, as reported by the guest through the adapter, or through
+// the key-value pair exchange when it reports none or kvpOnly is set.
+func wmiIpAddresses(mac string, kvpOnly bool) ([]string, error) {
+	ports, err := queryWMIEthernetPorts(fmt.Sprintf("Address = '%s'", escapeWQLString(mac)))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, port := range ports {
+		vmID, deviceID := splitWMIPortInstanceID(port.InstanceID)
+		if vmID == "" {
+			continue
+		}
+
+		var addresses []string
+		if !kvpOnly {
+			var dst []wmiGuestNetworkAdapterConfiguration
+			query := fmt.Sprintf("SELECT IPAddresses FROM Msvm_GuestNetworkAdapterConfiguration WHERE InstanceID = '%s'",
+				escapeWQLString(`Microsoft:GuestNetwork\`+vmID+`\`+deviceID))
+			if err := queryWMI(query, &dst); err != nil {
+				return nil, err
+			}
+			if len(dst) == 0 {
+				// The port of a checkpoint, that has the MAC address of
+				// the virtual machine
+				continue
+			}
+			for _, address := range dst[0].IPAddresses {
+				if address = strings.TrimSpace(address); address != "" {
+					addresses = append(addresses, address)
+				}
+			}
+		}
+		if len(addresses) == 0 {
+			var dst []wmiKvpExchangeComponent
+			query := fmt.Sprintf("SELECT GuestIntrinsicExchangeItems FROM Msvm_KvpExchangeComponent WHERE SystemName = '%s'",
+				escapeWQLString(vmID))
+			if err := queryWMI(query, &dst); err != nil {
+				return nil, err
+			}
+			if len(dst) > 0 {
+				addresses = kvpIpAddresses(dst[0].GuestIntrinsicExchangeItems)
+			}
+		}
+		return addresses, nil
+	}
+	return nil, nil
+}
+
+// splitWMIPortInstanceID returns the IDs of the virtual machine and of the
+// device of the InstanceID of a network adapter, like
+// "Microsoft:<vm id>\<device id>".
+func splitWMIPortInstanceID(instanceID string) (vmID, deviceID string) {
+	parts := strings.SplitN(strings.TrimPrefix(instanceID, "Microsoft:"), `\`, 2)
+	if len(parts) != 2 || !strings.HasPrefix(instanceID, "Microsoft:") {
+		return "", ""
+	}
+	return parts[0], parts[1]
+}
+
+// kvpDataItem is an item of the GuestIntrinsicExchangeItems of a
+// Msvm_KvpExchangeComponent, a Msvm_KvpExchangeDataItem instance in the DMTF
+// CIM XML format.
+type kvpDataItem struct {
+	Properties []struct {
+		Name  string `xml:"NAME,attr"`
+		Value string `xml:"VALUE"`
+	} `xml:"PROPERTY"`
+}
+
+func (item *kvpDataItem) property(name string) string {
+	for _, p := range item.Properties {
+		if p.Name == name {
+			return p.Value
+		}
+	}
+	return ""
+}
+
+// kvpIpAddresses returns the IPv4 then IPv6 addresses the guest reports in
+// the NetworkAddressIPv4 and NetworkAddressIPv6 items of the key-value pair
+// exchange.
+func kvpIpAddresses(items []string) []string {
+	values := map[string]string{}
+	for _, raw := range items {
+		item := &kvpDataItem{}
+		if err := xml.Unmarshal([]byte(raw), item); err != nil {
+			continue
+		}
+		values[item.property("Name")] = item.property("Data")
+	}
+
+	var addresses []string
+	for _, name := range []string{"NetworkAddressIPv4", "NetworkAddressIPv6"} {
+		for _, address := range strings.Split(values[name], ";") {
+			if address = strings.TrimSpace(address); address != "" {
+				addresses = append(addresses, address)
+			}
+		}
+	}
+	return addresses
+}
+
+// escapeWQLString escapes s so it can be used inside a single quoted WQL
+// string literal.
+func escapeWQLString(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(s)
+}
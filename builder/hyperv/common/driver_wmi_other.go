@@ -0,0 +1,11 @@
+// +build !windows
+
+package common
+
+func queryWMI(query string, dst interface{}) error {
+	return errWMINotSupported
+}
+
+func isFileLocked(path string) bool {
+	return false
+}
@@ -0,0 +1,99 @@
+package common
+
+import (
+	"testing"
+)
+
+func TestHypervWMIDriver_impl(t *testing.T) {
+	var _ Driver = new(HypervWMIDriver)
+}
+
+func TestEscapeWQLString(t *testing.T) {
+	cases := map[string]string{
+		"packer-foo":    "packer-foo",
+		"it's a vm":     `it\'s a vm`,
+		`back\slash`:    `back\\slash`,
+		`both\'quoted'`: `both\\\'quoted\'`,
+	}
+
+	for input, expected := range cases {
+		if actual := escapeWQLString(input); actual != expected {
+			t.Fatalf("Bad escape for %q. Got: %s Wanted: %s", input, actual, expected)
+		}
+	}
+}
+
+func TestHeartbeatStatus(t *testing.T) {
+	cases := []struct {
+		status   []uint16
+		expected string
+	}{
+		{nil, "Unknown"},
+		{[]uint16{2, 2}, "OkApplicationsHealthy"},
+		{[]uint16{2, 32782}, "OkApplicationsCritical"},
+		{[]uint16{2}, "OkApplicationsUnknown"},
+		{[]uint16{12}, "NoContact"},
+		{[]uint16{13}, "LostCommunication"},
+		{[]uint16{15}, "Paused"},
+		{[]uint16{7}, "Error"},
+	}
+
+	for _, c := range cases {
+		if actual := heartbeatStatus(c.status); actual != c.expected {
+			t.Fatalf("Bad heartbeat status for %v. Got: %s Wanted: %s", c.status, actual, c.expected)
+		}
+	}
+	for status := range heartbeatStoppedStatuses {
+		if status == "Off" {
+			continue
+		}
+		found := false
+		for _, c := range cases {
+			found = found || c.expected == status
+		}
+		if !found {
+			t.Fatalf("The stopped heartbeat status %s is never returned", status)
+		}
+	}
+}
+
+func TestSplitWMIPortInstanceID(t *testing.T) {
+	vmID, deviceID := splitWMIPortInstanceID(`Microsoft:5C0B9E44-3F0B-4E0D-9F8A-1D2E3F4A5B6C\C3F2A1B0-9D8E-4F7A-8B6C-5D4E3F2A1B0C`)
+	if vmID != "5C0B9E44-3F0B-4E0D-9F8A-1D2E3F4A5B6C" || deviceID != "C3F2A1B0-9D8E-4F7A-8B6C-5D4E3F2A1B0C" {
+		t.Fatalf("Bad IDs. Got: %s, %s", vmID, deviceID)
+	}
+	if vmID, _ := splitWMIPortInstanceID("not an instance id"); vmID != "" {
+		t.Fatalf("Should not split an invalid instance ID. Got: %s", vmID)
+	}
+}
+
+func TestKvpIpAddresses(t *testing.T) {
+	item := func(name, data string) string {
+		return `<INSTANCE CLASSNAME="Msvm_KvpExchangeDataItem">` +
+			`<PROPERTY NAME="Caption" PROPAGATED="true" TYPE="string"></PROPERTY>` +
+			`<PROPERTY NAME="Data" TYPE="string"><VALUE>` + data + `</VALUE></PROPERTY>` +
+			`<PROPERTY NAME="Name" TYPE="string"><VALUE>` + name + `</VALUE></PROPERTY>` +
+			`<PROPERTY NAME="Source" TYPE="uint16"><VALUE>2</VALUE></PROPERTY>` +
+			`</INSTANCE>`
+	}
+	items := []string{
+		item("FullyQualifiedDomainName", "packer-vm"),
+		item("NetworkAddressIPv6", "fe80::215:5dff:fe00:102;2001:db8::10"),
+		item("NetworkAddressIPv4", "192.168.1.10;10.0.0.5"),
+		"not xml",
+	}
+
+	expected := []string{"192.168.1.10", "10.0.0.5", "fe80::215:5dff:fe00:102", "2001:db8::10"}
+	actual := kvpIpAddresses(items)
+	if len(actual) != len(expected) {
+		t.Fatalf("Bad addresses. Got: %v Wanted: %v", actual, expected)
+	}
+	for i := range expected {
+		if actual[i] != expected[i] {
+			t.Fatalf("Bad addresses. Got: %v Wanted: %v", actual, expected)
+		}
+	}
+	if addresses := kvpIpAddresses(nil); len(addresses) != 0 {
+		t.Fatalf("Should have no addresses. Got: %v", addresses)
+	}
+}
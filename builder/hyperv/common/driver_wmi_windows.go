@@ -0,0 +1,31 @@
+package common
+
+import (
+	"syscall"
+
+	"github.com/StackExchange/wmi"
+)
+
+const wmiVirtualizationNamespace = `root\virtualization\v2`
+
+// queryWMI runs the WQL query in the Hyper-V WMI v2 namespace, and loads the
+// instances it returns in dst, a pointer to a slice of structs.
+func queryWMI(query string, dst interface{}) error {
+	return wmi.QueryNamespace(query, dst, wmiVirtualizationNamespace)
+}
+
+// isFileLocked reports whether the file at path can't be opened for
+// exclusive access, like the PowerShell driver checks it.
+func isFileLocked(path string) bool {
+	p, err := syscall.UTF16PtrFromString(path)
+	if err != nil {
+		return true
+	}
+	h, err := syscall.CreateFile(p, syscall.GENERIC_READ|syscall.GENERIC_WRITE, 0, nil,
+		syscall.OPEN_EXISTING, syscall.FILE_ATTRIBUTE_NORMAL, 0)
+	if err != nil {
+		return true
+	}
+	syscall.CloseHandle(h)
+	return false
+}
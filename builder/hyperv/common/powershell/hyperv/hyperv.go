@@ -3,8 +3,10 @@ package hyperv
 import (
 	"bytes"
 	"context"
+	"encoding/base64"
 	"errors"
 	"fmt"
+	"io"
 	"os/exec"
 	"regexp"
 	"strconv"
@@ -29,7 +31,7 @@ type scriptOptions struct {
 	FixedVHD           bool
 }
 
-func GetHostAdapterIpAddressForSwitch(switchName string) (string, error) {
+func GetHostAdapterIpAddressForSwitch(remote *powershell.Remote, switchName string) (string, error) {
 	var script = `
 param([string]$switchName, [int]$addressIndex)
 $HostVMAdapter = Hyper-V\Get-VMNetworkAdapter -ManagementOS -SwitchName $switchName | Select-Object -First 1
@@ -53,18 +55,18 @@ if ($HostVMAdapter){
 }
 `
 
-	var ps powershell.PowerShellCmd
+	ps := powershell.PowerShellCmd{Remote: remote}
 	cmdOut, err := ps.Output(script, switchName, "0")
 
 	return cmdOut, err
 }
 
-func GetVirtualMachineNetworkAdapterAddress(vmName string) (string, error) {
+func GetVirtualMachineNetworkAdapterAddress(remote *powershell.Remote, vmName string) (string, error) {
 
 	var script = `
 param([string]$vmName, [int]$addressIndex)
 try {
-  $adapter = Hyper-V\Get-VMNetworkAdapter -VMName $vmName -ErrorAction SilentlyContinue
+  $adapter = Hyper-V\Get-VMNetworkAdapter -VMName $vmName -ErrorAction SilentlyContinue | Select-Object -First 1
   if ($adapter.IPAddresses) {
     $ip = $adapter.IPAddresses[$addressIndex]
   } else {
@@ -84,14 +86,14 @@ try {
 $ip
 `
 
-	var ps powershell.PowerShellCmd
+	ps := powershell.PowerShellCmd{Remote: remote}
 	cmdOut, err := ps.Output(script, vmName, "0")
 
 	return cmdOut, err
 }
 
-func CreateDvdDrive(vmName string, isoPath string, generation uint) (uint, uint, error) {
-	var ps powershell.PowerShellCmd
+func CreateDvdDrive(remote *powershell.Remote, vmName string, isoPath string, generation uint) (uint, uint, error) {
+	ps := powershell.PowerShellCmd{Remote: remote}
 	var script string
 
 	script = `
@@ -127,7 +129,7 @@ $result
 	return controllerNumber, controllerLocation, err
 }
 
-func MountDvdDrive(vmName string, path string, controllerNumber uint, controllerLocation uint) error {
+func MountDvdDrive(remote *powershell.Remote, vmName string, path string, controllerNumber uint, controllerLocation uint) error {
 
 	var script = `
 param([string]$vmName,[string]$path,[string]$controllerNumber,[string]$controllerLocation)
@@ -136,13 +138,13 @@ if (!$vmDvdDrive) {throw 'unable to find dvd drive'}
 Hyper-V\Set-VMDvdDrive -VMName $vmName -ControllerNumber $controllerNumber -ControllerLocation $controllerLocation -Path $path
 `
 
-	var ps powershell.PowerShellCmd
+	ps := powershell.PowerShellCmd{Remote: remote}
 	err := ps.Run(script, vmName, path, strconv.FormatInt(int64(controllerNumber), 10),
 		strconv.FormatInt(int64(controllerLocation), 10))
 	return err
 }
 
-func UnmountDvdDrive(vmName string, controllerNumber uint, controllerLocation uint) error {
+func UnmountDvdDrive(remote *powershell.Remote, vmName string, controllerNumber uint, controllerLocation uint) error {
 	var script = `
 param([string]$vmName,[int]$controllerNumber,[int]$controllerLocation)
 $vmDvdDrive = Hyper-V\Get-VMDvdDrive -VMName $vmName -ControllerNumber $controllerNumber -ControllerLocation $controllerLocation
@@ -150,20 +152,20 @@ if (!$vmDvdDrive) {throw 'unable to find dvd drive'}
 Hyper-V\Set-VMDvdDrive -VMName $vmName -ControllerNumber $controllerNumber -ControllerLocation $controllerLocation -Path $null
 `
 
-	var ps powershell.PowerShellCmd
+	ps := powershell.PowerShellCmd{Remote: remote}
 	err := ps.Run(script, vmName, strconv.FormatInt(int64(controllerNumber), 10),
 		strconv.FormatInt(int64(controllerLocation), 10))
 	return err
 }
 
-func SetBootDvdDrive(vmName string, controllerNumber uint, controllerLocation uint, generation uint) error {
+func SetBootDvdDrive(remote *powershell.Remote, vmName string, controllerNumber uint, controllerLocation uint, generation uint) error {
 
 	if generation < 2 {
 		script := `
 param([string]$vmName)
 Hyper-V\Set-VMBios -VMName $vmName -StartupOrder @("IDE","CD","LegacyNetworkAdapter","Floppy")
 `
-		var ps powershell.PowerShellCmd
+		ps := powershell.PowerShellCmd{Remote: remote}
 		err := ps.Run(script, vmName)
 		return err
 	} else {
@@ -173,14 +175,14 @@ $vmDvdDrive = Hyper-V\Get-VMDvdDrive -VMName $vmName -ControllerNumber $controll
 if (!$vmDvdDrive) {throw 'unable to find dvd drive'}
 Hyper-V\Set-VMFirmware -VMName $vmName -FirstBootDevice $vmDvdDrive -ErrorAction SilentlyContinue
 `
-		var ps powershell.PowerShellCmd
+		ps := powershell.PowerShellCmd{Remote: remote}
 		err := ps.Run(script, vmName, strconv.FormatInt(int64(controllerNumber), 10),
 			strconv.FormatInt(int64(controllerLocation), 10))
 		return err
 	}
 }
 
-func SetFirstBootDeviceGen1(vmName string, controllerType string) error {
+func SetFirstBootDeviceGen1(remote *powershell.Remote, vmName string, controllerType string) error {
 
 	// for Generation 1 VMs, we read the value of the VM's boot order, strip the value specified in
 	// controllerType and insert that value back at the beginning of the list.
@@ -198,12 +200,12 @@ param([string] $vmName, [string] $controllerType)
 	Hyper-V\Set-VMBios -VMName $vmName -StartupOrder (@($controllerType) + $vmBootOrder)
 `
 
-	var ps powershell.PowerShellCmd
+	ps := powershell.PowerShellCmd{Remote: remote}
 	err := ps.Run(script, vmName, controllerType)
 	return err
 }
 
-func SetFirstBootDeviceGen2(vmName string, controllerType string, controllerNumber uint, controllerLocation uint) error {
+func SetFirstBootDeviceGen2(remote *powershell.Remote, vmName string, controllerType string, controllerNumber uint, controllerLocation uint) error {
 
 	script := `param ([string] $vmName, [string] $controllerType, [int] $controllerNumber, [int] $controllerLocation)`
 
@@ -235,21 +237,21 @@ if ($vmDevice -eq $null) { throw 'unable to find boot device' }
 Hyper-V\Set-VMFirmware -VMName $vmName -FirstBootDevice $vmDevice
 `
 
-	var ps powershell.PowerShellCmd
+	ps := powershell.PowerShellCmd{Remote: remote}
 	err := ps.Run(script, vmName, controllerType, strconv.FormatInt(int64(controllerNumber), 10), strconv.FormatInt(int64(controllerLocation), 10))
 	return err
 }
 
-func SetFirstBootDevice(vmName string, controllerType string, controllerNumber uint, controllerLocation uint, generation uint) error {
+func SetFirstBootDevice(remote *powershell.Remote, vmName string, controllerType string, controllerNumber uint, controllerLocation uint, generation uint) error {
 
 	if generation == 1 {
-		return SetFirstBootDeviceGen1(vmName, controllerType)
+		return SetFirstBootDeviceGen1(remote, vmName, controllerType)
 	} else {
-		return SetFirstBootDeviceGen2(vmName, controllerType, controllerNumber, controllerLocation)
+		return SetFirstBootDeviceGen2(remote, vmName, controllerType, controllerNumber, controllerLocation)
 	}
 }
 
-func SetBootOrder(vmName string, bootOrder []string) error {
+func SetBootOrder(remote *powershell.Remote, vmName string, bootOrder []string) error {
 	var script = `
 param([string]$vmName, [Parameter(ValueFromRemainingArguments=$true)]$bootOrder)
 
@@ -264,13 +266,13 @@ $bootOrderDrives = $bootOrder | ForEach-Object {
 
 Hyper-V\Set-VMFirmware $vmName -BootOrder $bootOrderDrives
 `
-	var ps powershell.PowerShellCmd
+	ps := powershell.PowerShellCmd{Remote: remote}
 	params := append([]string{vmName}, bootOrder...)
 	err := ps.Run(script, params...)
 	return err
 }
 
-func DeleteDvdDrive(vmName string, controllerNumber uint, controllerLocation uint) error {
+func DeleteDvdDrive(remote *powershell.Remote, vmName string, controllerNumber uint, controllerLocation uint) error {
 	var script = `
 param([string]$vmName,[int]$controllerNumber,[int]$controllerLocation)
 $vmDvdDrive = Hyper-V\Get-VMDvdDrive -VMName $vmName -ControllerNumber $controllerNumber -ControllerLocation $controllerLocation
@@ -278,42 +280,42 @@ if (!$vmDvdDrive) {throw 'unable to find dvd drive'}
 Hyper-V\Remove-VMDvdDrive -VMName $vmName -ControllerNumber $controllerNumber -ControllerLocation $controllerLocation
 `
 
-	var ps powershell.PowerShellCmd
+	ps := powershell.PowerShellCmd{Remote: remote}
 	err := ps.Run(script, vmName, strconv.FormatInt(int64(controllerNumber), 10),
 		strconv.FormatInt(int64(controllerLocation), 10))
 	return err
 }
 
-func DeleteAllDvdDrives(vmName string) error {
+func DeleteAllDvdDrives(remote *powershell.Remote, vmName string) error {
 	var script = `
 param([string]$vmName)
 Hyper-V\Get-VMDvdDrive -VMName $vmName | Hyper-V\Remove-VMDvdDrive
 `
 
-	var ps powershell.PowerShellCmd
+	ps := powershell.PowerShellCmd{Remote: remote}
 	err := ps.Run(script, vmName)
 	return err
 }
 
-func MountFloppyDrive(vmName string, path string) error {
+func MountFloppyDrive(remote *powershell.Remote, vmName string, path string) error {
 	var script = `
 param([string]$vmName, [string]$path)
 Hyper-V\Set-VMFloppyDiskDrive -VMName $vmName -Path $path
 `
 
-	var ps powershell.PowerShellCmd
+	ps := powershell.PowerShellCmd{Remote: remote}
 	err := ps.Run(script, vmName, path)
 	return err
 }
 
-func UnmountFloppyDrive(vmName string) error {
+func UnmountFloppyDrive(remote *powershell.Remote, vmName string) error {
 
 	var script = `
 param([string]$vmName)
 Hyper-V\Set-VMFloppyDiskDrive -VMName $vmName -Path $null
 `
 
-	var ps powershell.PowerShellCmd
+	ps := powershell.PowerShellCmd{Remote: remote}
 	err := ps.Run(script, vmName)
 	return err
 }
@@ -381,10 +383,10 @@ Hyper-V\New-VM -Name "{{ .VMName }}" -Path "{{ .Path }}" -MemoryStartupBytes {{
 	return final, nil
 }
 
-func CheckVMName(vmName string) error {
+func CheckVMName(remote *powershell.Remote, vmName string) error {
 	// Check that no vm with the same name is registered, to prevent
 	// namespace collisions
-	var gs powershell.PowerShellCmd
+	gs := powershell.PowerShellCmd{Remote: remote}
 	getVMCmd := fmt.Sprintf(`Hyper-V\Get-VM -Name "%s"`, vmName)
 	if err := gs.Run(getVMCmd); err == nil {
 		return fmt.Errorf("A virtual machine with the name %s is already"+
@@ -395,7 +397,7 @@ func CheckVMName(vmName string) error {
 	return nil
 }
 
-func CreateVirtualMachine(vmName string, path string, harddrivePath string, ram int64,
+func CreateVirtualMachine(remote *powershell.Remote, vmName string, path string, harddrivePath string, ram int64,
 	diskSize int64, diskBlockSize int64, switchName string, generation uint,
 	diffDisks bool, fixedVHD bool, version string) error {
 	opts := scriptOptions{
@@ -417,32 +419,32 @@ func CreateVirtualMachine(vmName string, path string, harddrivePath string, ram
 		return err
 	}
 
-	var ps powershell.PowerShellCmd
+	ps := powershell.PowerShellCmd{Remote: remote}
 	if err = ps.Run(script); err != nil {
 		return err
 	}
 
-	if err := DisableAutomaticCheckpoints(vmName); err != nil {
+	if err := DisableAutomaticCheckpoints(remote, vmName); err != nil {
 		return err
 	}
 	if generation != 2 {
-		return DeleteAllDvdDrives(vmName)
+		return DeleteAllDvdDrives(remote, vmName)
 	}
 	return nil
 }
 
-func DisableAutomaticCheckpoints(vmName string) error {
+func DisableAutomaticCheckpoints(remote *powershell.Remote, vmName string) error {
 	var script = `
 param([string]$vmName)
 if ((Get-Command Hyper-V\Set-Vm).Parameters["AutomaticCheckpointsEnabled"]) {
 	Hyper-V\Set-Vm -Name $vmName -AutomaticCheckpointsEnabled $false }
 `
-	var ps powershell.PowerShellCmd
+	ps := powershell.PowerShellCmd{Remote: remote}
 	err := ps.Run(script, vmName)
 	return err
 }
 
-func ExportVmcxVirtualMachine(exportPath string, vmName string, snapshotName string, allSnapshots bool) error {
+func ExportVmcxVirtualMachine(remote *powershell.Remote, exportPath string, vmName string, snapshotName string, allSnapshots bool) error {
 	var script = `
 param([string]$exportPath, [string]$vmName, [string]$snapshotName, [string]$allSnapshotsString)
 
@@ -483,13 +485,13 @@ $result = Remove-Item -Path $WorkingPath
 		allSnapshotsString = "True"
 	}
 
-	var ps powershell.PowerShellCmd
+	ps := powershell.PowerShellCmd{Remote: remote}
 	err := ps.Run(script, exportPath, vmName, snapshotName, allSnapshotsString)
 
 	return err
 }
 
-func CopyVmcxVirtualMachine(exportPath string, cloneFromVmcxPath string) error {
+func CopyVmcxVirtualMachine(remote *powershell.Remote, exportPath string, cloneFromVmcxPath string) error {
 	var script = `
 param([string]$exportPath, [string]$cloneFromVmcxPath)
 if (!(Test-Path $cloneFromVmcxPath)){
@@ -503,25 +505,55 @@ $cloneFromVmcxPath = Join-Path $cloneFromVmcxPath '\*'
 Copy-Item $cloneFromVmcxPath $exportPath -Recurse -Force
 	`
 
-	var ps powershell.PowerShellCmd
+	ps := powershell.PowerShellCmd{Remote: remote}
 	err := ps.Run(script, exportPath, cloneFromVmcxPath)
 
 	return err
 }
 
-func SetVmNetworkAdapterMacAddress(vmName string, mac string) error {
+func SetVmNetworkAdapterMacAddress(remote *powershell.Remote, vmName string, mac string) error {
 	var script = `
 param([string]$vmName, [string]$mac)
 Hyper-V\Set-VMNetworkAdapter $vmName -staticmacaddress $mac
 	`
 
-	var ps powershell.PowerShellCmd
+	ps := powershell.PowerShellCmd{Remote: remote}
 	err := ps.Run(script, vmName, mac)
 
 	return err
 }
 
-func ImportVmcxVirtualMachine(importPath string, vmName string, harddrivePath string,
+func AddVirtualMachineNetworkAdapter(remote *powershell.Remote, vmName string, adapterName string, switchName string, vlanId string,
+	mac string, enableMacSpoofing bool) error {
+	var script = `
+param([string]$vmName, [string]$adapterName, [string]$switchName, [string]$vlanId, [string]$mac, [string]$enableMacSpoofing)
+$adapter = Hyper-V\Get-VMNetworkAdapter -VMName $vmName -Name $adapterName -ErrorAction SilentlyContinue
+if ($adapter) {
+	Hyper-V\Connect-VMNetworkAdapter -VMNetworkAdapter $adapter -SwitchName $switchName
+} else {
+	$adapter = Hyper-V\Add-VMNetworkAdapter -VMName $vmName -Name $adapterName -SwitchName $switchName -Passthru
+}
+if ($vlanId) {
+	Hyper-V\Set-VMNetworkAdapterVlan -VMNetworkAdapter $adapter -Access -VlanId $vlanId
+}
+if ($mac) {
+	Hyper-V\Set-VMNetworkAdapter -VMNetworkAdapter $adapter -StaticMacAddress $mac
+}
+Hyper-V\Set-VMNetworkAdapter -VMNetworkAdapter $adapter -MacAddressSpoofing $enableMacSpoofing
+`
+
+	enableMacSpoofingString := "Off"
+	if enableMacSpoofing {
+		enableMacSpoofingString = "On"
+	}
+
+	ps := powershell.PowerShellCmd{Remote: remote}
+	err := ps.Run(script, vmName, adapterName, switchName, vlanId, mac, enableMacSpoofingString)
+
+	return err
+}
+
+func ImportVmcxVirtualMachine(remote *powershell.Remote, importPath string, vmName string, harddrivePath string,
 	ram int64, switchName string, copyTF bool) error {
 
 	var script = `
@@ -577,37 +609,37 @@ if ($vm) {
     $result = Hyper-V\Rename-VM -VM $vm -NewName $VMName
 }
 	`
-	var ps powershell.PowerShellCmd
+	ps := powershell.PowerShellCmd{Remote: remote}
 	err := ps.Run(script, importPath, vmName, harddrivePath, strconv.FormatInt(ram, 10), switchName, strconv.FormatBool(copyTF))
 
 	return err
 }
 
-func CloneVirtualMachine(cloneFromVmcxPath string, cloneFromVmName string,
+func CloneVirtualMachine(remote *powershell.Remote, cloneFromVmcxPath string, cloneFromVmName string,
 	cloneFromSnapshotName string, cloneAllSnapshots bool, vmName string,
 	path string, harddrivePath string, ram int64, switchName string, copyTF bool) error {
 
 	if cloneFromVmName != "" {
-		if err := ExportVmcxVirtualMachine(path, cloneFromVmName,
+		if err := ExportVmcxVirtualMachine(remote, path, cloneFromVmName,
 			cloneFromSnapshotName, cloneAllSnapshots); err != nil {
 			return err
 		}
 	}
 
 	if cloneFromVmcxPath != "" {
-		if err := CopyVmcxVirtualMachine(path, cloneFromVmcxPath); err != nil {
+		if err := CopyVmcxVirtualMachine(remote, path, cloneFromVmcxPath); err != nil {
 			return err
 		}
 	}
 
-	if err := ImportVmcxVirtualMachine(path, vmName, harddrivePath, ram, switchName, copyTF); err != nil {
+	if err := ImportVmcxVirtualMachine(remote, path, vmName, harddrivePath, ram, switchName, copyTF); err != nil {
 		return err
 	}
 
-	return DeleteAllDvdDrives(vmName)
+	return DeleteAllDvdDrives(remote, vmName)
 }
 
-func GetVirtualMachineGeneration(vmName string) (uint, error) {
+func GetVirtualMachineGeneration(remote *powershell.Remote, vmName string) (uint, error) {
 	var script = `
 param([string]$vmName)
 $generation = Hyper-V\Get-Vm -Name $vmName | %{$_.Generation}
@@ -616,7 +648,7 @@ if (!$generation){
 }
 return $generation
 `
-	var ps powershell.PowerShellCmd
+	ps := powershell.PowerShellCmd{Remote: remote}
 	cmdOut, err := ps.Output(script, vmName)
 
 	if err != nil {
@@ -634,18 +666,32 @@ return $generation
 	return generation, err
 }
 
-func SetVirtualMachineCpuCount(vmName string, cpu uint) error {
+func SetVirtualMachineCpuCount(remote *powershell.Remote, vmName string, cpu uint) error {
 
 	var script = `
 param([string]$vmName, [int]$cpu)
 Hyper-V\Set-VMProcessor -VMName $vmName -Count $cpu
 `
-	var ps powershell.PowerShellCmd
+	ps := powershell.PowerShellCmd{Remote: remote}
 	err := ps.Run(script, vmName, strconv.FormatInt(int64(cpu), 10))
 	return err
 }
 
-func SetVirtualMachineVirtualizationExtensions(vmName string, enableVirtualizationExtensions bool) error {
+func SetVirtualMachineCpuTopology(remote *powershell.Remote, vmName string, cpusPerNumaNode uint, numaNodesPerSocket uint,
+	memoryPerNumaNodeBytes int64) error {
+
+	var script = `
+param([string]$vmName, [int]$cpusPerNumaNode, [int]$numaNodesPerSocket, [long]$memoryPerNumaNodeBytes)
+Hyper-V\Set-VMProcessor -VMName $vmName -MaximumCountPerNumaNode $cpusPerNumaNode -MaximumCountPerNumaSocket $numaNodesPerSocket
+Hyper-V\Set-VMMemory -VMName $vmName -MaximumAmountPerNumaNodeBytes $memoryPerNumaNodeBytes
+`
+	ps := powershell.PowerShellCmd{Remote: remote}
+	err := ps.Run(script, vmName, strconv.FormatInt(int64(cpusPerNumaNode), 10),
+		strconv.FormatInt(int64(numaNodesPerSocket), 10), strconv.FormatInt(memoryPerNumaNodeBytes, 10))
+	return err
+}
+
+func SetVirtualMachineVirtualizationExtensions(remote *powershell.Remote, vmName string, enableVirtualizationExtensions bool) error {
 
 	var script = `
 param([string]$vmName, [string]$exposeVirtualizationExtensionsString)
@@ -656,12 +702,40 @@ Hyper-V\Set-VMProcessor -VMName $vmName -ExposeVirtualizationExtensions $exposeV
 	if enableVirtualizationExtensions {
 		exposeVirtualizationExtensionsString = "True"
 	}
-	var ps powershell.PowerShellCmd
+	ps := powershell.PowerShellCmd{Remote: remote}
 	err := ps.Run(script, vmName, exposeVirtualizationExtensionsString)
 	return err
 }
 
-func SetVirtualMachineDynamicMemory(vmName string, enableDynamicMemory bool) error {
+func SetVirtualMachineProcessorCompatibility(remote *powershell.Remote, vmName string, enableCompatibility bool) error {
+
+	var script = `
+param([string]$vmName, [string]$enableCompatibilityString)
+$enableCompatibility = [System.Boolean]::Parse($enableCompatibilityString)
+Hyper-V\Set-VMProcessor -VMName $vmName -CompatibilityForMigrationEnabled $enableCompatibility -CompatibilityForOlderOperatingSystemsEnabled $enableCompatibility
+`
+	enableCompatibilityString := "False"
+	if enableCompatibility {
+		enableCompatibilityString = "True"
+	}
+	ps := powershell.PowerShellCmd{Remote: remote}
+	err := ps.Run(script, vmName, enableCompatibilityString)
+	return err
+}
+
+func SetVirtualMachineComPort(remote *powershell.Remote, vmName string, number uint, path string) error {
+
+	var script = `
+param([string]$vmName, [int]$number, [string]$path)
+Hyper-V\Set-VMComPort -VMName $vmName -Number $number -Path $path
+`
+
+	ps := powershell.PowerShellCmd{Remote: remote}
+	err := ps.Run(script, vmName, strconv.FormatInt(int64(number), 10), path)
+	return err
+}
+
+func SetVirtualMachineDynamicMemory(remote *powershell.Remote, vmName string, enableDynamicMemory bool) error {
 
 	var script = `
 param([string]$vmName, [string]$enableDynamicMemoryString)
@@ -672,18 +746,35 @@ Hyper-V\Set-VMMemory -VMName $vmName -DynamicMemoryEnabled $enableDynamicMemory
 	if enableDynamicMemory {
 		enableDynamicMemoryString = "True"
 	}
-	var ps powershell.PowerShellCmd
+	ps := powershell.PowerShellCmd{Remote: remote}
 	err := ps.Run(script, vmName, enableDynamicMemoryString)
 	return err
 }
 
-func SetVirtualMachineMacSpoofing(vmName string, enableMacSpoofing bool) error {
+func SetVirtualMachineDynamicMemorySettings(remote *powershell.Remote, vmName string, minimumBytes int64, maximumBytes int64,
+	bufferPercent uint) error {
+
+	var script = `
+param([string]$vmName, [long]$minimumBytes, [long]$maximumBytes, [int]$bufferPercent)
+$memory = @{ VMName = $vmName }
+if ($minimumBytes -gt 0) { $memory.MinimumBytes = $minimumBytes }
+if ($maximumBytes -gt 0) { $memory.MaximumBytes = $maximumBytes }
+if ($bufferPercent -gt 0) { $memory.Buffer = $bufferPercent }
+Hyper-V\Set-VMMemory @memory
+`
+	ps := powershell.PowerShellCmd{Remote: remote}
+	err := ps.Run(script, vmName, strconv.FormatInt(minimumBytes, 10), strconv.FormatInt(maximumBytes, 10),
+		strconv.FormatUint(uint64(bufferPercent), 10))
+	return err
+}
+
+func SetVirtualMachineMacSpoofing(remote *powershell.Remote, vmName string, enableMacSpoofing bool) error {
 	var script = `
 param([string]$vmName, $enableMacSpoofing)
 Hyper-V\Set-VMNetworkAdapter -VMName $vmName -MacAddressSpoofing $enableMacSpoofing
 `
 
-	var ps powershell.PowerShellCmd
+	ps := powershell.PowerShellCmd{Remote: remote}
 
 	enableMacSpoofingString := "Off"
 	if enableMacSpoofing {
@@ -694,7 +785,19 @@ Hyper-V\Set-VMNetworkAdapter -VMName $vmName -MacAddressSpoofing $enableMacSpoof
 	return err
 }
 
-func SetVirtualMachineSecureBoot(vmName string, enableSecureBoot bool, templateName string) error {
+func EnableVirtualMachineTPM(remote *powershell.Remote, vmName string) error {
+	var script = `
+param([string]$vmName)
+Hyper-V\Set-VMKeyProtector -VMName $vmName -NewLocalKeyProtector
+Hyper-V\Enable-VMTPM -VMName $vmName
+`
+
+	ps := powershell.PowerShellCmd{Remote: remote}
+	err := ps.Run(script, vmName)
+	return err
+}
+
+func SetVirtualMachineSecureBoot(remote *powershell.Remote, vmName string, enableSecureBoot bool, templateName string) error {
 	var script = `
 param([string]$vmName, [string]$enableSecureBootString, [string]$templateName)
 $cmdlet = Get-Command Hyper-V\Set-VMFirmware
@@ -706,7 +809,7 @@ if ($cmdlet.Parameters.SecureBootTemplate) {
 }
 `
 
-	var ps powershell.PowerShellCmd
+	ps := powershell.PowerShellCmd{Remote: remote}
 
 	enableSecureBootString := "Off"
 	if enableSecureBoot {
@@ -721,7 +824,7 @@ if ($cmdlet.Parameters.SecureBootTemplate) {
 	return err
 }
 
-func DeleteVirtualMachine(vmName string) error {
+func DeleteVirtualMachine(remote *powershell.Remote, vmName string) error {
 
 	var script = `
 param([string]$vmName)
@@ -734,12 +837,12 @@ if (($vm.State -ne [Microsoft.HyperV.PowerShell.VMState]::Off) -and ($vm.State -
 Hyper-V\Remove-VM -Name $vmName -Force -Confirm:$false
 `
 
-	var ps powershell.PowerShellCmd
+	ps := powershell.PowerShellCmd{Remote: remote}
 	err := ps.Run(script, vmName)
 	return err
 }
 
-func ExportVirtualMachine(vmName string, path string) error {
+func ExportVirtualMachine(remote *powershell.Remote, vmName string, path string) error {
 
 	var script = `
 param([string]$vmName, [string]$path)
@@ -840,12 +943,121 @@ if (Test-Path -Path ([IO.Path]::Combine($path, $vmName, 'Virtual Machines', '*.V
 }
 `
 
-	var ps powershell.PowerShellCmd
+	ps := powershell.PowerShellCmd{Remote: remote}
 	err := ps.Run(script, vmName, path)
 	return err
 }
 
-func PreserveLegacyExportBehaviour(srcPath, dstPath string) error {
+func CheckpointVirtualMachine(remote *powershell.Remote, vmName string, checkpointName string, checkpointType string) error {
+
+	var script = `
+param([string]$vmName, [string]$checkpointName, [string]$checkpointType)
+Hyper-V\Set-VM -Name $vmName -CheckpointType $checkpointType
+Hyper-V\Checkpoint-VM -Name $vmName -SnapshotName $checkpointName
+`
+
+	ps := powershell.PowerShellCmd{Remote: remote}
+	err := ps.Run(script, vmName, checkpointName, checkpointType)
+	return err
+}
+
+func RemoveVirtualMachineCheckpoints(remote *powershell.Remote, vmName string, namePrefix string) error {
+
+	var script = `
+param([string]$vmName, [string]$namePrefix)
+Hyper-V\Get-VMSnapshot -VMName $vmName | ?{ $_.Name.StartsWith($namePrefix) } | Hyper-V\Remove-VMSnapshot
+while ((Hyper-V\Get-VM -Name $vmName).Status -like "Merging*") {
+  Start-Sleep -Seconds 1
+}
+`
+
+	ps := powershell.PowerShellCmd{Remote: remote}
+	err := ps.Run(script, vmName, namePrefix)
+	return err
+}
+
+func ConvertVirtualMachineDisksToVHDSet(remote *powershell.Remote, vmName string) error {
+
+	var script = `
+param([string]$vmName)
+Hyper-V\Get-VMHardDiskDrive -VMName $vmName | ForEach-Object {
+  if ([IO.Path]::GetExtension($_.Path) -ne '.vhds') {
+    $vhdSetPath = [IO.Path]::ChangeExtension($_.Path, '.vhds')
+    Hyper-V\Convert-VHD -Path $_.Path -DestinationPath $vhdSetPath
+    Hyper-V\Set-VMHardDiskDrive -VMHardDiskDrive $_ -Path $vhdSetPath
+    Remove-Item -Path $_.Path
+  }
+}
+`
+
+	ps := powershell.PowerShellCmd{Remote: remote}
+	err := ps.Run(script, vmName)
+	return err
+}
+
+func ConvertVirtualMachineDisks(remote *powershell.Remote, vmName string, format string, vhdType string, attach bool) error {
+
+	var script = `
+param([string]$vmName, [string]$format, [string]$vhdType, [string]$attachString)
+$attach = [System.Boolean]::Parse($attachString)
+Hyper-V\Get-VMHardDiskDrive -VMName $vmName | ForEach-Object {
+  $vhd = Hyper-V\Get-VHD -Path $_.Path
+  $diskPath = $_.Path
+  if ($format -ne '') {
+    $diskPath = [IO.Path]::ChangeExtension($_.Path, '.' + $format)
+  }
+  $type = $vhd.VhdType.ToString()
+  if ($type -eq 'Differencing') {
+    $type = 'Dynamic'
+  }
+  if ($vhdType -ne '') {
+    $type = $vhdType
+  }
+  if ($diskPath -ne $_.Path -or $type -ne $vhd.VhdType.ToString()) {
+    $convertedPath = Join-Path -Path (Split-Path -Path $diskPath) -ChildPath ('converted-' + (Split-Path -Path $diskPath -Leaf))
+    Hyper-V\Convert-VHD -Path $_.Path -DestinationPath $convertedPath -VHDType $type
+    Remove-Item -Path $_.Path
+    Move-Item -Path $convertedPath -Destination $diskPath
+    if ($attach) {
+      Hyper-V\Set-VMHardDiskDrive -VMHardDiskDrive $_ -Path $diskPath
+    }
+  }
+}
+`
+
+	attachString := "False"
+	if attach {
+		attachString = "True"
+	}
+	ps := powershell.PowerShellCmd{Remote: remote}
+	err := ps.Run(script, vmName, format, vhdType, attachString)
+	return err
+}
+
+func MergeVirtualMachineDifferencingDisks(remote *powershell.Remote, vmName string) error {
+
+	var script = `
+param([string]$vmName)
+Hyper-V\Get-VMHardDiskDrive -VMName $vmName | ForEach-Object {
+  $vhd = Hyper-V\Get-VHD -Path $_.Path
+  if ($vhd.VhdType -eq [Microsoft.Vhd.PowerShell.VhdType]::Differencing) {
+    # Convert-VHD writes a standalone copy of the whole chain
+    $diskPath = $_.Path
+    $mergedPath = Join-Path -Path (Split-Path -Path $diskPath) -ChildPath ('merged-' + (Split-Path -Path $diskPath -Leaf))
+    Hyper-V\Convert-VHD -Path $diskPath -DestinationPath $mergedPath -VHDType Dynamic
+    Remove-Item -Path $diskPath
+    Move-Item -Path $mergedPath -Destination $diskPath
+    Hyper-V\Set-VMHardDiskDrive -VMHardDiskDrive $_ -Path $diskPath
+  }
+}
+`
+
+	ps := powershell.PowerShellCmd{Remote: remote}
+	err := ps.Run(script, vmName)
+	return err
+}
+
+func PreserveLegacyExportBehaviour(remote *powershell.Remote, srcPath, dstPath string) error {
 
 	var script = `
 param([string]$srcPath, [string]$dstPath)
@@ -895,13 +1107,13 @@ if ( $((Get-Item $srcPath).GetFileSystemInfos().Count) -eq 0 ) {
 }
 `
 
-	var ps powershell.PowerShellCmd
+	ps := powershell.PowerShellCmd{Remote: remote}
 	err := ps.Run(script, srcPath, dstPath)
 
 	return err
 }
 
-func MoveCreatedVHDsToOutputDir(srcPath, dstPath string) error {
+func MoveCreatedVHDsToOutputDir(remote *powershell.Remote, srcPath, dstPath string) error {
 
 	var script = `
 param([string]$srcPath, [string]$dstPath)
@@ -943,13 +1155,13 @@ foreach ($disk in $disks) {
 }
 `
 
-	var ps powershell.PowerShellCmd
+	ps := powershell.PowerShellCmd{Remote: remote}
 	err := ps.Run(script, srcPath, dstPath)
 
 	return err
 }
 
-func CompactDisks(path string) (result string, err error) {
+func CompactDisks(remote *powershell.Remote, path string) (result string, err error) {
 	var script = `
 param([string]$srcPath)
 
@@ -962,10 +1174,23 @@ if ($disks.Length -eq 0) {
 }
 
 foreach ($disk in $disks) {
+    # Fixed size disks always take up their full size
+    if ((Hyper-V\Get-VHD -Path $disk).VhdType -eq [Microsoft.Vhd.PowerShell.VhdType]::Fixed) {
+        Write-Output "Skipping fixed size disk: $(Split-Path $disk -leaf)"
+        continue
+    }
+
     Write-Output "Compacting disk: $(Split-Path $disk -leaf)"
 
     $sizeBefore = (Get-Item -Path $disk).Length
-    Optimize-VHD -Path $disk -Mode Full
+    # A full optimization, which also reclaims the blocks the guest file
+    # system no longer uses, requires the disk to be mounted read-only
+    Hyper-V\Mount-VHD -Path $disk -ReadOnly -NoDriveLetter -ErrorAction Stop
+    try {
+        Hyper-V\Optimize-VHD -Path $disk -Mode Full
+    } finally {
+        Hyper-V\Dismount-VHD -Path $disk
+    }
     $sizeAfter = (Get-Item -Path $disk).Length
 
     # Calculate the percentage change in disk size
@@ -980,30 +1205,42 @@ foreach ($disk in $disks) {
 }
 `
 
-	var ps powershell.PowerShellCmd
+	ps := powershell.PowerShellCmd{Remote: remote}
 	result, err = ps.Output(script, path)
 	return
 }
 
-func CreateVirtualSwitch(switchName string, switchType string) (bool, error) {
+func CreateVirtualSwitch(remote *powershell.Remote, switchName string, switchType string, netAdapterName string) (bool, error) {
 
 	var script = `
-param([string]$switchName,[string]$switchType)
+param([string]$switchName,[string]$switchType,[string]$netAdapterName)
 $switches = Hyper-V\Get-VMSwitch -Name $switchName -ErrorAction SilentlyContinue
 if ($switches.Count -eq 0) {
-  Hyper-V\New-VMSwitch -Name $switchName -SwitchType $switchType
+  if ($switchType -eq 'External') {
+    if ($netAdapterName -eq '') {
+      $adapter = Get-NetAdapter -Physical | where status -eq 'up' | Select-Object -First 1
+      if ($adapter -eq $null) {
+        Write-Error 'No connected physical network adapters found'
+        return $false
+      }
+      $netAdapterName = $adapter.Name
+    }
+    Hyper-V\New-VMSwitch -Name $switchName -NetAdapterName $netAdapterName -AllowManagementOS $true | Out-Null
+  } else {
+    Hyper-V\New-VMSwitch -Name $switchName -SwitchType $switchType | Out-Null
+  }
   return $true
 }
 return $false
 `
 
-	var ps powershell.PowerShellCmd
-	cmdOut, err := ps.Output(script, switchName, switchType)
+	ps := powershell.PowerShellCmd{Remote: remote}
+	cmdOut, err := ps.Output(script, switchName, switchType, netAdapterName)
 	var created = strings.TrimSpace(cmdOut) == "True"
 	return created, err
 }
 
-func DeleteVirtualSwitch(switchName string) error {
+func DeleteVirtualSwitch(remote *powershell.Remote, switchName string) error {
 
 	var script = `
 param([string]$switchName)
@@ -1013,12 +1250,59 @@ if ($switch -ne $null) {
 }
 `
 
-	var ps powershell.PowerShellCmd
+	ps := powershell.PowerShellCmd{Remote: remote}
 	err := ps.Run(script, switchName)
 	return err
 }
 
-func StartVirtualMachine(vmName string) error {
+func VirtualSwitchExists(remote *powershell.Remote, switchName string) (bool, error) {
+
+	var script = `
+param([string]$switchName)
+$switch = Hyper-V\Get-VMSwitch -Name $switchName -ErrorAction SilentlyContinue
+return $switch -ne $null
+`
+
+	ps := powershell.PowerShellCmd{Remote: remote}
+	cmdOut, err := ps.Output(script, switchName)
+	if err != nil {
+		return false, err
+	}
+
+	return powershell.IsTrue(cmdOut), nil
+}
+
+func EnableVirtualSwitchNat(remote *powershell.Remote, switchName string, natName string, prefix string) error {
+
+	var script = `
+param([string]$switchName,[string]$natName,[string]$prefix)
+$network, $prefixLength = $prefix.Split('/')
+$bytes = ([System.Net.IPAddress]$network).GetAddressBytes()
+$bytes[3] += 1
+$hostAddress = (New-Object System.Net.IPAddress(,$bytes)).ToString()
+$adapter = Get-NetAdapter -Name "vEthernet ($switchName)" -ErrorAction Stop
+New-NetIPAddress -IPAddress $hostAddress -PrefixLength $prefixLength -InterfaceIndex $adapter.ifIndex -ErrorAction Stop | Out-Null
+New-NetNat -Name $natName -InternalIPInterfaceAddressPrefix $prefix -ErrorAction Stop | Out-Null
+`
+
+	ps := powershell.PowerShellCmd{Remote: remote}
+	err := ps.Run(script, switchName, natName, prefix)
+	return err
+}
+
+func DisableVirtualSwitchNat(remote *powershell.Remote, natName string) error {
+
+	var script = `
+param([string]$natName)
+Get-NetNat -Name $natName -ErrorAction SilentlyContinue | Remove-NetNat -Confirm:$false
+`
+
+	ps := powershell.PowerShellCmd{Remote: remote}
+	err := ps.Run(script, natName)
+	return err
+}
+
+func StartVirtualMachine(remote *powershell.Remote, vmName string) error {
 
 	var script = `
 param([string]$vmName)
@@ -1028,24 +1312,24 @@ if ($vm.State -eq [Microsoft.HyperV.PowerShell.VMState]::Off) {
 }
 `
 
-	var ps powershell.PowerShellCmd
+	ps := powershell.PowerShellCmd{Remote: remote}
 	err := ps.Run(script, vmName)
 	return err
 }
 
-func RestartVirtualMachine(vmName string) error {
+func RestartVirtualMachine(remote *powershell.Remote, vmName string) error {
 
 	var script = `
 param([string]$vmName)
 Hyper-V\Restart-VM $vmName -Force -Confirm:$false
 `
 
-	var ps powershell.PowerShellCmd
+	ps := powershell.PowerShellCmd{Remote: remote}
 	err := ps.Run(script, vmName)
 	return err
 }
 
-func StopVirtualMachine(vmName string) error {
+func StopVirtualMachine(remote *powershell.Remote, vmName string) error {
 
 	var script = `
 param([string]$vmName)
@@ -1055,12 +1339,12 @@ if ($vm.State -eq [Microsoft.HyperV.PowerShell.VMState]::Running) {
 }
 `
 
-	var ps powershell.PowerShellCmd
+	ps := powershell.PowerShellCmd{Remote: remote}
 	err := ps.Run(script, vmName)
 	return err
 }
 
-func EnableVirtualMachineIntegrationService(vmName string, integrationServiceName string) error {
+func EnableVirtualMachineIntegrationService(remote *powershell.Remote, vmName string, integrationServiceName string) error {
 
 	integrationServiceId := ""
 	switch integrationServiceName {
@@ -1085,35 +1369,104 @@ param([string]$vmName,[string]$integrationServiceId)
 Hyper-V\Get-VMIntegrationService -VmName $vmName | ?{$_.Id -match $integrationServiceId} | Hyper-V\Enable-VMIntegrationService
 `
 
-	var ps powershell.PowerShellCmd
+	ps := powershell.PowerShellCmd{Remote: remote}
 	err := ps.Run(script, vmName, integrationServiceId)
 	return err
 }
 
-func SetNetworkAdapterVlanId(switchName string, vlanId string) error {
+// powerShellDirectPasswordEnv is the environment variable the PowerShell
+// Direct scripts read the guest password from, so that it isn't logged.
+const powerShellDirectPasswordEnv = "PACKER_HYPERV_POWERSHELL_DIRECT_PASSWORD"
+
+// powerShellDirectSession opens a PowerShell Direct session to the guest in
+// $session, using the $vmName and $username script parameters.
+const powerShellDirectSession = `
+$password = ConvertTo-SecureString $env:` + powerShellDirectPasswordEnv + ` -AsPlainText -Force
+$credential = New-Object System.Management.Automation.PSCredential($username, $password)
+$session = New-PSSession -VMName $vmName -Credential $credential -ErrorAction Stop
+`
+
+func InvokeVirtualMachineCommand(remote *powershell.Remote, vmName string, username string, password string, command string,
+	stdout io.Writer, stderr io.Writer) (int, error) {
+
+	var script = `
+param([string]$vmName, [string]$username, [string]$command)
+` + powerShellDirectSession + `
+try {
+  Invoke-Command -Session $session -ScriptBlock { param($command) & cmd.exe /c $command } -ArgumentList $command
+  $exitCode = Invoke-Command -Session $session -ScriptBlock { $LASTEXITCODE }
+} finally {
+  Remove-PSSession -Session $session
+}
+exit $exitCode
+`
+
+	ps := powershell.PowerShellCmd{
+		Stdout: stdout,
+		Stderr: stderr,
+		Env:    []string{powerShellDirectPasswordEnv + "=" + password},
+		Remote: remote,
+	}
+	return ps.Exec(script, vmName, username, command)
+}
+
+func CopyFileToVirtualMachine(remote *powershell.Remote, vmName string, sourcePath string, destinationPath string) error {
+
+	var script = `
+param([string]$vmName, [string]$sourcePath, [string]$destinationPath)
+Hyper-V\Copy-VMFile -Name $vmName -SourcePath $sourcePath -DestinationPath $destinationPath -FileSource Host -CreateFullPath -Force
+`
+
+	ps := powershell.PowerShellCmd{Remote: remote}
+	err := ps.Run(script, vmName, sourcePath, destinationPath)
+	return err
+}
+
+func CopyFileFromVirtualMachine(remote *powershell.Remote, vmName string, username string, password string, sourcePath string,
+	destinationPath string) error {
+
+	var script = `
+param([string]$vmName, [string]$username, [string]$sourcePath, [string]$destinationPath)
+` + powerShellDirectSession + `
+try {
+  Copy-Item -FromSession $session -Path $sourcePath -Destination $destinationPath -Recurse -Force
+} finally {
+  Remove-PSSession -Session $session
+}
+`
+
+	ps := powershell.PowerShellCmd{
+		Env:    []string{powerShellDirectPasswordEnv + "=" + password},
+		Remote: remote,
+	}
+	err := ps.Run(script, vmName, username, sourcePath, destinationPath)
+	return err
+}
+
+func SetNetworkAdapterVlanId(remote *powershell.Remote, switchName string, vlanId string) error {
 
 	var script = `
 param([string]$networkAdapterName,[string]$vlanId)
 Hyper-V\Set-VMNetworkAdapterVlan -ManagementOS -VMNetworkAdapterName $networkAdapterName -Access -VlanId $vlanId
 `
 
-	var ps powershell.PowerShellCmd
+	ps := powershell.PowerShellCmd{Remote: remote}
 	err := ps.Run(script, switchName, vlanId)
 	return err
 }
 
-func SetVirtualMachineVlanId(vmName string, vlanId string) error {
+func SetVirtualMachineVlanId(remote *powershell.Remote, vmName string, vlanId string) error {
 
 	var script = `
 param([string]$vmName,[string]$vlanId)
 Hyper-V\Set-VMNetworkAdapterVlan -VMName $vmName -Access -VlanId $vlanId
 `
-	var ps powershell.PowerShellCmd
+	ps := powershell.PowerShellCmd{Remote: remote}
 	err := ps.Run(script, vmName, vlanId)
 	return err
 }
 
-func ReplaceVirtualMachineNetworkAdapter(vmName string, legacy bool) error {
+func ReplaceVirtualMachineNetworkAdapter(remote *powershell.Remote, vmName string, legacy bool) error {
 
 	var script = `
 param([string]$vmName,[string]$legacyString)
@@ -1126,12 +1479,12 @@ Add-VMNetworkAdapter -VMName $vmName -SwitchName $switch -Name $vmName -IsLegacy
 	if legacy {
 		legacyString = "True"
 	}
-	var ps powershell.PowerShellCmd
+	ps := powershell.PowerShellCmd{Remote: remote}
 	err := ps.Run(script, vmName, legacyString)
 	return err
 }
 
-func GetExternalOnlineVirtualSwitch() (string, error) {
+func GetExternalOnlineVirtualSwitch(remote *powershell.Remote) (string, error) {
 
 	var script = `
 $adapters = Get-NetAdapter -Physical -ErrorAction SilentlyContinue | Where-Object { $_.Status -eq 'Up' } | Sort-Object -Descending -Property Speed
@@ -1145,7 +1498,7 @@ foreach ($adapter in $adapters) {
 }
 `
 
-	var ps powershell.PowerShellCmd
+	ps := powershell.PowerShellCmd{Remote: remote}
 	cmdOut, err := ps.Output(script)
 	if err != nil {
 		return "", err
@@ -1155,7 +1508,7 @@ foreach ($adapter in $adapters) {
 	return switchName, nil
 }
 
-func CreateExternalVirtualSwitch(vmName string, switchName string) error {
+func CreateExternalVirtualSwitch(remote *powershell.Remote, vmName string, switchName string) error {
 
 	var script = `
 param([string]$vmName,[string]$switchName)
@@ -1183,19 +1536,19 @@ if($switch -ne $null) {
   Write-Error 'No internet adapters found'
 }
 `
-	var ps powershell.PowerShellCmd
+	ps := powershell.PowerShellCmd{Remote: remote}
 	err := ps.Run(script, vmName, switchName)
 	return err
 }
 
-func GetVirtualMachineSwitchName(vmName string) (string, error) {
+func GetVirtualMachineSwitchName(remote *powershell.Remote, vmName string) (string, error) {
 
 	var script = `
 param([string]$vmName)
-(Hyper-V\Get-VMNetworkAdapter -VMName $vmName).SwitchName
+(Hyper-V\Get-VMNetworkAdapter -VMName $vmName | Select-Object -First 1).SwitchName
 `
 
-	var ps powershell.PowerShellCmd
+	ps := powershell.PowerShellCmd{Remote: remote}
 	cmdOut, err := ps.Output(script, vmName)
 	if err != nil {
 		return "", err
@@ -1204,33 +1557,37 @@ param([string]$vmName)
 	return strings.TrimSpace(cmdOut), nil
 }
 
-func ConnectVirtualMachineNetworkAdapterToSwitch(vmName string, switchName string) error {
+func ConnectVirtualMachineNetworkAdapterToSwitch(remote *powershell.Remote, vmName string, switchName string) error {
 
 	var script = `
 param([string]$vmName,[string]$switchName)
 Hyper-V\Get-VMNetworkAdapter -VMName $vmName | Hyper-V\Connect-VMNetworkAdapter -SwitchName $switchName
 `
 
-	var ps powershell.PowerShellCmd
+	ps := powershell.PowerShellCmd{Remote: remote}
 	err := ps.Run(script, vmName, switchName)
 	return err
 }
 
-func AddVirtualMachineHardDiskDrive(vmName string, vhdRoot string, vhdName string, vhdSizeBytes int64,
-	vhdBlockSize int64, controllerType string) error {
+func AddVirtualMachineHardDiskDrive(remote *powershell.Remote, vmName string, vhdRoot string, vhdName string, vhdSizeBytes int64,
+	vhdBlockSize int64, controllerType string, fixedVHD bool) error {
 
 	var script = `
-param([string]$vmName,[string]$vhdRoot, [string]$vhdName, [string]$vhdSizeInBytes, [string]$vhdBlockSizeInByte, [string]$controllerType)
+param([string]$vmName,[string]$vhdRoot, [string]$vhdName, [string]$vhdSizeInBytes, [string]$vhdBlockSizeInByte, [string]$controllerType, [string]$fixedVHD)
 $vhdPath = Join-Path -Path $vhdRoot -ChildPath $vhdName
-Hyper-V\New-VHD -path $vhdPath -SizeBytes $vhdSizeInBytes -BlockSizeBytes $vhdBlockSizeInByte
+if ($fixedVHD -eq 'True') {
+	Hyper-V\New-VHD -path $vhdPath -Fixed -SizeBytes $vhdSizeInBytes
+} else {
+	Hyper-V\New-VHD -path $vhdPath -SizeBytes $vhdSizeInBytes -BlockSizeBytes $vhdBlockSizeInByte
+}
 Hyper-V\Add-VMHardDiskDrive -VMName $vmName -path $vhdPath -controllerType $controllerType
 `
-	var ps powershell.PowerShellCmd
-	err := ps.Run(script, vmName, vhdRoot, vhdName, strconv.FormatInt(vhdSizeBytes, 10), strconv.FormatInt(vhdBlockSize, 10), controllerType)
+	ps := powershell.PowerShellCmd{Remote: remote}
+	err := ps.Run(script, vmName, vhdRoot, vhdName, strconv.FormatInt(vhdSizeBytes, 10), strconv.FormatInt(vhdBlockSize, 10), controllerType, strconv.FormatBool(fixedVHD))
 	return err
 }
 
-func UntagVirtualMachineNetworkAdapterVlan(vmName string, switchName string) error {
+func UntagVirtualMachineNetworkAdapterVlan(remote *powershell.Remote, vmName string, switchName string) error {
 
 	var script = `
 param([string]$vmName,[string]$switchName)
@@ -1238,12 +1595,12 @@ Hyper-V\Set-VMNetworkAdapterVlan -VMName $vmName -Untagged
 Hyper-V\Set-VMNetworkAdapterVlan -ManagementOS -VMNetworkAdapterName $switchName -Untagged
 `
 
-	var ps powershell.PowerShellCmd
+	ps := powershell.PowerShellCmd{Remote: remote}
 	err := ps.Run(script, vmName, switchName)
 	return err
 }
 
-func IsRunning(vmName string) (bool, error) {
+func IsRunning(remote *powershell.Remote, vmName string) (bool, error) {
 
 	var script = `
 param([string]$vmName)
@@ -1251,7 +1608,7 @@ $vm = Hyper-V\Get-VM -Name $vmName -ErrorAction SilentlyContinue
 $vm.State -eq [Microsoft.HyperV.PowerShell.VMState]::Running
 `
 
-	var ps powershell.PowerShellCmd
+	ps := powershell.PowerShellCmd{Remote: remote}
 	cmdOut, err := ps.Output(script, vmName)
 
 	if err != nil {
@@ -1262,7 +1619,7 @@ $vm.State -eq [Microsoft.HyperV.PowerShell.VMState]::Running
 	return isRunning, err
 }
 
-func IsOff(vmName string) (bool, error) {
+func IsOff(remote *powershell.Remote, vmName string) (bool, error) {
 
 	var script = `
 param([string]$vmName)
@@ -1270,7 +1627,7 @@ $vm = Hyper-V\Get-VM -Name $vmName -ErrorAction SilentlyContinue
 $vm.State -eq [Microsoft.HyperV.PowerShell.VMState]::Off
 `
 
-	var ps powershell.PowerShellCmd
+	ps := powershell.PowerShellCmd{Remote: remote}
 	cmdOut, err := ps.Output(script, vmName)
 
 	if err != nil {
@@ -1281,14 +1638,63 @@ $vm.State -eq [Microsoft.HyperV.PowerShell.VMState]::Off
 	return isRunning, err
 }
 
-func Uptime(vmName string) (uint64, error) {
+func GetVirtualMachineHeartbeatStatus(remote *powershell.Remote, vmName string) (string, error) {
+
+	var script = `
+param([string]$vmName)
+$vm = Hyper-V\Get-VM -Name $vmName -ErrorAction SilentlyContinue
+if ($vm.State -eq [Microsoft.HyperV.PowerShell.VMState]::Off) {
+  'Off'
+} else {
+  [string]$vm.Heartbeat
+}
+`
+
+	ps := powershell.PowerShellCmd{Remote: remote}
+	cmdOut, err := ps.Output(script, vmName)
+
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(cmdOut), nil
+}
+
+func AreVirtualMachineDisksLocked(remote *powershell.Remote, vmName string) (bool, error) {
+
+	var script = `
+param([string]$vmName)
+$locked = $false
+foreach ($path in (Hyper-V\Get-VMHardDiskDrive -VMName $vmName).Path) {
+  try {
+    $stream = [System.IO.File]::Open($path, 'Open', 'ReadWrite', 'None')
+    $stream.Close()
+  } catch {
+    $locked = $true
+  }
+}
+$locked
+`
+
+	ps := powershell.PowerShellCmd{Remote: remote}
+	cmdOut, err := ps.Output(script, vmName)
+
+	if err != nil {
+		return false, err
+	}
+
+	var locked = strings.TrimSpace(cmdOut) == "True"
+	return locked, err
+}
+
+func Uptime(remote *powershell.Remote, vmName string) (uint64, error) {
 
 	var script = `
 param([string]$vmName)
 $vm = Hyper-V\Get-VM -Name $vmName -ErrorAction SilentlyContinue
 $vm.Uptime.TotalSeconds
 `
-	var ps powershell.PowerShellCmd
+	ps := powershell.PowerShellCmd{Remote: remote}
 	cmdOut, err := ps.Output(script, vmName)
 
 	if err != nil {
@@ -1300,7 +1706,7 @@ $vm.Uptime.TotalSeconds
 	return uptime, err
 }
 
-func Mac(vmName string) (string, error) {
+func Mac(remote *powershell.Remote, vmName string) (string, error) {
 	var script = `
 param([string]$vmName, [int]$adapterIndex)
 try {
@@ -1315,13 +1721,13 @@ try {
 $mac
 `
 
-	var ps powershell.PowerShellCmd
+	ps := powershell.PowerShellCmd{Remote: remote}
 	cmdOut, err := ps.Output(script, vmName, "0")
 
 	return cmdOut, err
 }
 
-func IpAddress(mac string) (string, error) {
+func IpAddress(remote *powershell.Remote, mac string) (string, error) {
 	var script = `
 param([string]$mac, [int]$addressIndex)
 try {
@@ -1349,13 +1755,62 @@ try {
 $ip
 `
 
-	var ps powershell.PowerShellCmd
+	ps := powershell.PowerShellCmd{Remote: remote}
 	cmdOut, err := ps.Output(script, mac, "0")
 
 	return cmdOut, err
 }
 
-func TurnOff(vmName string) error {
+// IpAddresses returns every IP address of the VM with the network adapter
+// with the given MAC address. Unless kvpOnly is true the addresses Hyper-V
+// reports for the adapter are used, falling back to the IPv4 and IPv6
+// addresses the guest reports through the KVP exchange.
+func IpAddresses(remote *powershell.Remote, mac string, kvpOnly bool) ([]string, error) {
+	var script = `
+param([string]$mac, [string]$kvpOnlyString)
+$kvpOnly = [System.Boolean]::Parse($kvpOnlyString)
+$vm = Hyper-V\Get-VM | ?{$_.NetworkAdapters.MacAddress -eq $mac}
+if ($vm -eq $null) {
+  return
+}
+$addresses = @()
+if (!$kvpOnly) {
+  $addresses = @($vm.NetworkAdapters | ?{$_.MacAddress -eq $mac} | %{ $_.IPAddresses })
+}
+if ($addresses.Count -eq 0) {
+  $vm_info = Get-CimInstance -ClassName Msvm_ComputerSystem -Namespace root\virtualization\v2 -Filter "ElementName='$($vm.Name)'"
+  $items = (Get-CimAssociatedInstance -InputObject $vm_info -ResultClassName Msvm_KvpExchangeComponent).GuestIntrinsicExchangeItems | %{ [xml]$_ }
+  foreach ($name in @('NetworkAddressIPv4', 'NetworkAddressIPv6')) {
+    $item = $items | ?{ $_.SelectSingleNode("/INSTANCE/PROPERTY[@NAME='Name']/VALUE[child::text()='$name']") } | select -first 1
+    if ($item -ne $null) {
+      $addresses += $item.SelectSingleNode("/INSTANCE/PROPERTY[@NAME='Data']/VALUE/child::text()").Value -split ";"
+    }
+  }
+}
+$addresses | ?{ $_ } | %{ $_.Trim() }
+`
+
+	kvpOnlyString := "False"
+	if kvpOnly {
+		kvpOnlyString = "True"
+	}
+
+	ps := powershell.PowerShellCmd{Remote: remote}
+	cmdOut, err := ps.Output(script, mac, kvpOnlyString)
+	if err != nil {
+		return nil, err
+	}
+
+	var addresses []string
+	for _, line := range strings.Split(cmdOut, "\n") {
+		if address := strings.TrimSpace(line); address != "" {
+			addresses = append(addresses, address)
+		}
+	}
+	return addresses, nil
+}
+
+func TurnOff(remote *powershell.Remote, vmName string) error {
 
 	var script = `
 param([string]$vmName)
@@ -1365,12 +1820,12 @@ if ($vm.State -eq [Microsoft.HyperV.PowerShell.VMState]::Running) {
 }
 `
 
-	var ps powershell.PowerShellCmd
+	ps := powershell.PowerShellCmd{Remote: remote}
 	err := ps.Run(script, vmName)
 	return err
 }
 
-func ShutDown(vmName string) error {
+func ShutDown(remote *powershell.Remote, vmName string) error {
 
 	var script = `
 param([string]$vmName)
@@ -1380,12 +1835,12 @@ if ($vm.State -eq [Microsoft.HyperV.PowerShell.VMState]::Running) {
 }
 `
 
-	var ps powershell.PowerShellCmd
+	ps := powershell.PowerShellCmd{Remote: remote}
 	err := ps.Run(script, vmName)
 	return err
 }
 
-func TypeScanCodes(vmName string, scanCodes string) error {
+func TypeScanCodes(remote *powershell.Remote, vmName string, scanCodes string) error {
 	if len(scanCodes) == 0 {
 		return nil
 	}
@@ -1564,14 +2019,48 @@ param([string]$vmName, [string]$scanCodes)
 	}
 `
 
-	var ps powershell.PowerShellCmd
+	ps := powershell.PowerShellCmd{Remote: remote}
 	err := ps.Run(script, vmName, scanCodes)
 	return err
 }
 
-func ConnectVirtualMachine(vmName string) (context.CancelFunc, error) {
+// TypeText types the ASCII text with the TypeText method of the virtual
+// keyboard, which is a lot faster than typing it as scan codes. The text is
+// passed base64 encoded so that it survives the command line unchanged.
+func TypeText(remote *powershell.Remote, vmName string, text string) error {
+	if len(text) == 0 {
+		return nil
+	}
+
+	var script = `
+param([string]$vmName, [string]$text)
+$vm = Get-CimInstance -Namespace "root\virtualization\v2" -ClassName Msvm_ComputerSystem | where ElementName -eq $vmName | select -first 1
+if ($vm -eq $null) {
+  throw "VirtualMachine($vmName) is not found!"
+}
+$keyboard = $vm | Get-CimAssociatedInstance -ResultClassName "Msvm_Keyboard"
+if ($keyboard -eq $null) {
+  throw "VirtualMachine($vmName) keyboard class is not found!"
+}
+$asciiText = [System.Text.Encoding]::ASCII.GetString([System.Convert]::FromBase64String($text))
+$result = $keyboard | Invoke-CimMethod -MethodName "TypeText" -Arguments @{ asciiText = $asciiText }
+if ($result.ReturnValue -ne 0) {
+  throw "Typing text failed with return value $($result.ReturnValue)"
+}
+`
+
+	ps := powershell.PowerShellCmd{Remote: remote}
+	err := ps.Run(script, vmName, base64.StdEncoding.EncodeToString([]byte(text)))
+	return err
+}
+
+func ConnectVirtualMachine(remote *powershell.Remote, vmName string) (context.CancelFunc, error) {
 	ctx, cancel := context.WithCancel(context.Background())
-	cmd := exec.CommandContext(ctx, "vmconnect.exe", "localhost", vmName)
+	host := "localhost"
+	if remote != nil {
+		host = remote.Host
+	}
+	cmd := exec.CommandContext(ctx, "vmconnect.exe", host, vmName)
 	err := cmd.Start()
 	if err != nil {
 		// Failed to start so cancel function not required
@@ -1583,3 +2072,100 @@ func ConnectVirtualMachine(vmName string) (context.CancelFunc, error) {
 func DisconnectVirtualMachine(cancel context.CancelFunc) {
 	cancel()
 }
+
+func PathExists(remote *powershell.Remote, path string) (bool, error) {
+	var script = `
+param([string]$path)
+Test-Path -LiteralPath $path
+`
+
+	ps := powershell.PowerShellCmd{Remote: remote}
+	cmdOut, err := ps.Output(script, path)
+	if err != nil {
+		return false, err
+	}
+
+	return powershell.IsTrue(cmdOut), nil
+}
+
+func CreateTempDirectory(remote *powershell.Remote, parent string, prefix string) (string, error) {
+	var script = `
+param([string]$parent, [string]$prefix)
+if (!$parent) {
+  $parent = [System.IO.Path]::GetTempPath()
+}
+$path = Join-Path $parent ($prefix + [System.IO.Path]::GetRandomFileName().Replace('.', ''))
+New-Item -ItemType Directory -Path $path -ErrorAction Stop | Out-Null
+$path
+`
+
+	ps := powershell.PowerShellCmd{Remote: remote}
+	cmdOut, err := ps.Output(script, parent, prefix)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(cmdOut), nil
+}
+
+func RemoveDirectory(remote *powershell.Remote, path string) error {
+	var script = `
+param([string]$path)
+Remove-Item -LiteralPath $path -Recurse -Force -ErrorAction Stop
+`
+
+	ps := powershell.PowerShellCmd{Remote: remote}
+	err := ps.Run(script, path)
+	return err
+}
+
+// CopyFileToHost copies the local file source to destination on the remote
+// host, or on this machine if remote is nil.
+func CopyFileToHost(remote *powershell.Remote, source string, destination string) error {
+	return copyItem(remote, source, destination, "ToSession")
+}
+
+// CopyDirectoryFromHost copies the contents of the directory source on the
+// remote host, or on this machine if remote is nil, into the local directory
+// destination.
+func CopyDirectoryFromHost(remote *powershell.Remote, source string, destination string) error {
+	return copyItem(remote, source+`\*`, destination, "FromSession")
+}
+
+func copyItem(remote *powershell.Remote, source string, destination string, direction string) error {
+	var script = `
+param([string]$source, [string]$destination, [string]$direction, [string]$computerName, [string]$username)
+$copy = @{
+  Path = $source
+  Destination = $destination
+  Recurse = $true
+  Force = $true
+  ErrorAction = 'Stop'
+}
+if ($computerName) {
+  $password = ConvertTo-SecureString $env:PACKER_HYPERV_REMOTE_PASSWORD -AsPlainText -Force
+  $credential = New-Object System.Management.Automation.PSCredential($username, $password)
+  $session = New-PSSession -ComputerName $computerName -Credential $credential -ErrorAction Stop
+  $copy[$direction] = $session
+}
+try {
+  Copy-Item @copy
+} finally {
+  if ($session) {
+    Remove-PSSession $session
+  }
+}
+`
+
+	var host, username, password string
+	if remote != nil {
+		host, username, password = remote.Host, remote.Username, remote.Password
+	}
+
+	// The copy has to run here to reach the local files
+	ps := powershell.PowerShellCmd{
+		Env: []string{"PACKER_HYPERV_REMOTE_PASSWORD=" + password},
+	}
+	err := ps.Run(script, source, destination, direction, host, username)
+	return err
+}
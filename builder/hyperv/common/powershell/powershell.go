@@ -10,9 +10,12 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/hashicorp/packer/packer-plugin-sdk/logging"
 	"github.com/hashicorp/packer/packer-plugin-sdk/tmp"
 )
 
+var logger = logging.New("hyperv.powershell")
+
 const (
 	powerShellFalse = "False"
 	powerShellTrue  = "True"
@@ -29,6 +32,13 @@ func IsFalse(s string) bool {
 type PowerShellCmd struct {
 	Stdout io.Writer
 	Stderr io.Writer
+	// Additional environment variables for the PowerShell process, in the
+	// form "key=value". Unlike the script parameters these are never logged,
+	// so they are used to pass secrets.
+	Env []string
+	// The Hyper-V host to run the script on, or nil to run it on this
+	// machine.
+	Remote *Remote
 }
 
 func (ps *PowerShellCmd) Run(fileContents string, params ...string) error {
@@ -55,16 +65,21 @@ func (ps *PowerShellCmd) Output(fileContents string, params ...string) (string,
 		defer os.Remove(filename)
 	}
 
-	args := createArgs(filename, params...)
+	args, cleanup, err := ps.wrap(filename, params, debug)
+	if err != nil {
+		return "", err
+	}
+	defer cleanup()
 
 	if verbose {
-		log.Printf("Run: %s %s", path, args)
+		logger.Debugf("Run: %s %s", path, args)
 	}
 
 	var stdout, stderr bytes.Buffer
 	command := exec.Command(path, args...)
 	command.Stdout = &stdout
 	command.Stderr = &stderr
+	command.Env = ps.environ()
 
 	err = command.Run()
 
@@ -89,18 +104,72 @@ func (ps *PowerShellCmd) Output(fileContents string, params ...string) (string,
 	stdoutString := strings.TrimSpace(stdout.String())
 
 	if verbose && stdoutString != "" {
-		log.Printf("stdout: %s", stdoutString)
+		logger.Debugf("stdout: %s", stdoutString)
 	}
 
 	// only write the stderr string if verbose because
 	// the error string will already be in the err return value.
 	if verbose && stderrString != "" {
-		log.Printf("stderr: %s", stderrString)
+		logger.Debugf("stderr: %s", stderrString)
 	}
 
 	return stdoutString, err
 }
 
+// Exec runs the PowerShell command, streaming its output to Stdout and Stderr
+// as it is produced, and returns its exit code. Unlike Output, a non-zero exit
+// code or output on standard error isn't treated as an error.
+func (ps *PowerShellCmd) Exec(fileContents string, params ...string) (int, error) {
+	path, err := ps.getPowerShellPath()
+	if err != nil {
+		return 0, fmt.Errorf("Cannot find PowerShell in the path")
+	}
+
+	filename, err := saveScript(fileContents)
+	if err != nil {
+		return 0, err
+	}
+
+	debug := os.Getenv("PACKER_POWERSHELL_DEBUG") != ""
+	verbose := debug || os.Getenv("PACKER_POWERSHELL_VERBOSE") != ""
+
+	if !debug {
+		defer os.Remove(filename)
+	}
+
+	args, cleanup, err := ps.wrap(filename, params, debug)
+	if err != nil {
+		return 0, err
+	}
+	defer cleanup()
+
+	if verbose {
+		logger.Debugf("Run: %s %s", path, args)
+	}
+
+	command := exec.Command(path, args...)
+	command.Stdout = ps.Stdout
+	command.Stderr = ps.Stderr
+	command.Env = ps.environ()
+
+	err = command.Run()
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		return exitErr.ExitCode(), nil
+	}
+
+	return 0, err
+}
+
+// environ returns the environment of the PowerShell process, or nil to
+// inherit the environment of Packer when no variables were added.
+func (ps *PowerShellCmd) environ() []string {
+	env := append(append([]string{}, ps.Env...), ps.remoteEnv()...)
+	if len(env) == 0 {
+		return nil
+	}
+	return append(os.Environ(), env...)
+}
+
 func IsPowershellAvailable() (bool, string, error) {
 	path, err := exec.LookPath("powershell")
 	if err != nil {
@@ -165,11 +234,11 @@ func createArgs(filename string, params ...string) []string {
 	return args
 }
 
-func GetHostAvailableMemory() float64 {
+func GetHostAvailableMemory(remote *Remote) float64 {
 
 	var script = "(Get-WmiObject Win32_OperatingSystem).FreePhysicalMemory / 1024"
 
-	var ps PowerShellCmd
+	ps := PowerShellCmd{Remote: remote}
 	output, _ := ps.Output(script)
 
 	freeMB, _ := strconv.ParseFloat(output, 64)
@@ -177,7 +246,7 @@ func GetHostAvailableMemory() float64 {
 	return freeMB
 }
 
-func GetHostName(ip string) (string, error) {
+func GetHostName(remote *Remote, ip string) (string, error) {
 
 	var script = `
 param([string]$ip)
@@ -191,7 +260,7 @@ try {
 `
 
 	//
-	var ps PowerShellCmd
+	ps := PowerShellCmd{Remote: remote}
 	cmdOut, err := ps.Output(script, ip)
 	if err != nil {
 		return "", err
@@ -200,7 +269,7 @@ try {
 	return cmdOut, nil
 }
 
-func IsCurrentUserAnAdministrator() (bool, error) {
+func IsCurrentUserAnAdministrator(remote *Remote) (bool, error) {
 	var script = `
 $identity = [System.Security.Principal.WindowsIdentity]::GetCurrent()
 $principal = new-object System.Security.Principal.WindowsPrincipal($identity)
@@ -208,7 +277,7 @@ $administratorRole = [System.Security.Principal.WindowsBuiltInRole]::Administrat
 return $principal.IsInRole($administratorRole)
 `
 
-	var ps PowerShellCmd
+	ps := PowerShellCmd{Remote: remote}
 	cmdOut, err := ps.Output(script)
 	if err != nil {
 		return false, err
@@ -218,13 +287,13 @@ return $principal.IsInRole($administratorRole)
 	return res == powerShellTrue, nil
 }
 
-func ModuleExists(moduleName string) (bool, error) {
+func ModuleExists(remote *Remote, moduleName string) (bool, error) {
 
 	var script = `
 param([string]$moduleName)
 (Get-Module -Name $moduleName) -ne $null
 `
-	var ps PowerShellCmd
+	ps := PowerShellCmd{Remote: remote}
 	cmdOut, err := ps.Output(script)
 	if err != nil {
 		return false, err
@@ -240,13 +309,13 @@ param([string]$moduleName)
 	return true, nil
 }
 
-func HasVirtualMachineVirtualizationExtensions() (bool, error) {
+func HasVirtualMachineVirtualizationExtensions(remote *Remote) (bool, error) {
 
 	var script = `
 (GET-Command Hyper-V\Set-VMProcessor).parameters.keys -contains "ExposeVirtualizationExtensions"
 `
 
-	var ps PowerShellCmd
+	ps := PowerShellCmd{Remote: remote}
 	cmdOut, err := ps.Output(script)
 
 	if err != nil {
@@ -257,14 +326,14 @@ func HasVirtualMachineVirtualizationExtensions() (bool, error) {
 	return hasVirtualMachineVirtualizationExtensions, err
 }
 
-func DoesVirtualMachineExist(vmName string) (bool, error) {
+func DoesVirtualMachineExist(remote *Remote, vmName string) (bool, error) {
 
 	var script = `
 param([string]$vmName)
 return (Hyper-V\Get-VM -Name $vmName | ?{$_.Name -eq $vmName}) -ne $null
 `
 
-	var ps PowerShellCmd
+	ps := PowerShellCmd{Remote: remote}
 	cmdOut, err := ps.Output(script, vmName)
 
 	if err != nil {
@@ -275,14 +344,14 @@ return (Hyper-V\Get-VM -Name $vmName | ?{$_.Name -eq $vmName}) -ne $null
 	return exists, err
 }
 
-func DoesVirtualMachineSnapshotExist(vmName string, snapshotName string) (bool, error) {
+func DoesVirtualMachineSnapshotExist(remote *Remote, vmName string, snapshotName string) (bool, error) {
 
 	var script = `
 param([string]$vmName, [string]$snapshotName)
 return (Hyper-V\Get-VMSnapshot -VMName $vmName | ?{$_.Name -eq $snapshotName}) -ne $null
 `
 
-	var ps PowerShellCmd
+	ps := PowerShellCmd{Remote: remote}
 	cmdOut, err := ps.Output(script, vmName, snapshotName)
 
 	if err != nil {
@@ -293,7 +362,7 @@ return (Hyper-V\Get-VMSnapshot -VMName $vmName | ?{$_.Name -eq $snapshotName}) -
 	return exists, err
 }
 
-func IsVirtualMachineOn(vmName string) (bool, error) {
+func IsVirtualMachineOn(remote *Remote, vmName string) (bool, error) {
 
 	var script = `
 param([string]$vmName)
@@ -301,7 +370,7 @@ $vm = Hyper-V\Get-VM -Name $vmName -ErrorAction SilentlyContinue
 $vm.State -eq [Microsoft.HyperV.PowerShell.VMState]::Running
 `
 
-	var ps PowerShellCmd
+	ps := PowerShellCmd{Remote: remote}
 	cmdOut, err := ps.Output(script, vmName)
 
 	if err != nil {
@@ -312,7 +381,7 @@ $vm.State -eq [Microsoft.HyperV.PowerShell.VMState]::Running
 	return isRunning, err
 }
 
-func GetVirtualMachineGeneration(vmName string) (uint, error) {
+func GetVirtualMachineGeneration(remote *Remote, vmName string) (uint, error) {
 	var script = `
 param([string]$vmName)
 $generation = Hyper-V\Get-Vm -Name $vmName | %{$_.Generation}
@@ -321,7 +390,7 @@ if (!$generation){
 }
 return $generation
 `
-	var ps PowerShellCmd
+	ps := PowerShellCmd{Remote: remote}
 	cmdOut, err := ps.Output(script, vmName)
 
 	if err != nil {
@@ -339,7 +408,7 @@ return $generation
 	return generation, err
 }
 
-func SetUnattendedProductKey(path string, productKey string) error {
+func SetUnattendedProductKey(remote *Remote, path string, productKey string) error {
 
 	var script = `
 param([string]$path,[string]$productKey)
@@ -365,7 +434,7 @@ $productKeyNode.InnerText = $productKey
 $unattend.Save($path)
 `
 
-	var ps PowerShellCmd
+	ps := PowerShellCmd{Remote: remote}
 	err := ps.Run(script, path, productKey)
 	return err
 }
@@ -2,6 +2,7 @@ package powershell
 
 import (
 	"bytes"
+	"strings"
 	"testing"
 )
 
@@ -67,3 +68,36 @@ func TestRunFile(t *testing.T) {
 		t.Fatalf("output '%v' is not 'a b 15'", cmdOut)
 	}
 }
+
+func TestRemoteHost(t *testing.T) {
+	ps := PowerShellCmd{
+		Remote: &Remote{Host: "hyperv01", Username: "packer", Password: "secret"},
+	}
+	args, cleanup, err := ps.wrap("script.ps1", []string{"a", "b"}, false)
+	if err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	defer cleanup()
+
+	if len(args) != 8 || args[5] != "script.ps1" || args[6] != "a" || args[7] != "b" {
+		t.Fatalf("bad args: %#v", args)
+	}
+
+	env := strings.Join(ps.environ(), "\n")
+	if !strings.Contains(env, "PACKER_HYPERV_REMOTE_HOST=hyperv01") ||
+		!strings.Contains(env, "PACKER_HYPERV_REMOTE_PASSWORD=secret") {
+		t.Fatal("should pass the remote host and credentials in the environment")
+	}
+
+	ps.Remote = nil
+	args, _, err = ps.wrap("script.ps1", []string{"a", "b"}, false)
+	if err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	if len(args) != 7 || args[4] != "script.ps1" {
+		t.Fatalf("bad args: %#v", args)
+	}
+	if ps.environ() != nil {
+		t.Fatal("should inherit the environment when running locally")
+	}
+}
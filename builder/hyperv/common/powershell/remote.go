@@ -0,0 +1,67 @@
+package powershell
+
+import (
+	"os"
+)
+
+// The script that runs another script on the remote host. The path of the
+// script to run is the first argument, the rest are passed on to it. The
+// credentials are read from the environment so that they are never logged.
+const invokeRemoteScript = `
+param([string]$path)
+$password = ConvertTo-SecureString $env:PACKER_HYPERV_REMOTE_PASSWORD -AsPlainText -Force
+$credential = New-Object System.Management.Automation.PSCredential($env:PACKER_HYPERV_REMOTE_USERNAME, $password)
+$invoke = @{
+  ComputerName = $env:PACKER_HYPERV_REMOTE_HOST
+  Credential = $credential
+  FilePath = $path
+  ErrorAction = 'Stop'
+}
+if ($args.Count -gt 0) {
+  $invoke.ArgumentList = $args
+}
+Invoke-Command @invoke
+`
+
+// Remote is a Hyper-V host that a PowerShellCmd runs its script on through
+// PowerShell remoting, authenticating as Username.
+type Remote struct {
+	Host     string
+	Username string
+	Password string
+}
+
+// remoteEnv returns the environment variables invokeRemoteScript reads, or
+// nil if the command runs locally.
+func (ps *PowerShellCmd) remoteEnv() []string {
+	if ps.Remote == nil {
+		return nil
+	}
+
+	return []string{
+		"PACKER_HYPERV_REMOTE_HOST=" + ps.Remote.Host,
+		"PACKER_HYPERV_REMOTE_USERNAME=" + ps.Remote.Username,
+		"PACKER_HYPERV_REMOTE_PASSWORD=" + ps.Remote.Password,
+	}
+}
+
+// wrap returns the arguments that run the script filename with params,
+// through invokeRemoteScript if the command runs on the remote host. The
+// returned function removes the wrapper script.
+func (ps *PowerShellCmd) wrap(filename string, params []string, debug bool) ([]string, func(), error) {
+	if ps.remoteEnv() == nil {
+		return createArgs(filename, params...), func() {}, nil
+	}
+
+	wrapper, err := saveScript(invokeRemoteScript)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cleanup := func() {
+		if !debug {
+			os.Remove(wrapper)
+		}
+	}
+	return createArgs(wrapper, append([]string{filename}, params...)...), cleanup, nil
+}
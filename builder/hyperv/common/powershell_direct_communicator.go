@@ -0,0 +1,136 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/packer/packer"
+	"github.com/hashicorp/packer/packer-plugin-sdk/tmp"
+)
+
+// PowerShellDirectCommunicatorType is the communicator type that talks to
+// the guest over PowerShell Direct.
+const PowerShellDirectCommunicatorType = "powershell-direct"
+
+// PowerShellDirectCommunicator talks to a Windows guest over the VMBus
+// instead of the network, so no connectivity between the host and the guest
+// is needed. Commands are run with PowerShell Direct and files are uploaded
+// with Copy-VMFile, which requires the Guest Service Interface integration
+// service. Downloads are copied from a PowerShell Direct session.
+type PowerShellDirectCommunicator struct {
+	Driver   Driver
+	VMName   string
+	Username string
+	Password string
+}
+
+var _ packer.Communicator = new(PowerShellDirectCommunicator)
+
+func (c *PowerShellDirectCommunicator) Start(ctx context.Context, cmd *packer.RemoteCmd) error {
+	log.Printf("Executing command over PowerShell Direct: %s", cmd.Command)
+
+	// Run the command in a goroutine so that Start doesn't block
+	go func() {
+		exitStatus, err := c.Driver.InvokeVirtualMachineCommand(c.VMName, c.Username, c.Password,
+			cmd.Command, cmd.Stdout, cmd.Stderr)
+		if err != nil {
+			log.Printf("Error executing command over PowerShell Direct: %s", err)
+			exitStatus = packer.CmdDisconnect
+		}
+		cmd.SetExited(exitStatus)
+	}()
+
+	return nil
+}
+
+// Upload writes src to a temporary file on the host and copies it to dst in
+// the guest.
+func (c *PowerShellDirectCommunicator) Upload(dst string, src io.Reader, fi *os.FileInfo) error {
+	tempfile, err := tmp.File("powershell-direct-upload")
+	if err != nil {
+		return fmt.Errorf("Failed to open temp file for writing: %s", err)
+	}
+	defer os.Remove(tempfile.Name())
+
+	_, err = io.Copy(tempfile, src)
+	tempfile.Close()
+	if err != nil {
+		return fmt.Errorf("Failed to copy upload file to tempfile: %s", err)
+	}
+
+	log.Printf("Copying to %s on VM %s.", dst, c.VMName)
+	return c.Driver.CopyFileToVirtualMachine(c.VMName, tempfile.Name(), dst)
+}
+
+// UploadDir copies the files in src to dst in the guest. If src ends with a
+// path separator its contents are copied into dst, otherwise the directory
+// itself is. Copy-VMFile only copies files, so empty directories are
+// skipped.
+func (c *PowerShellDirectCommunicator) UploadDir(dst string, src string, exclude []string) error {
+	root := src
+	if !strings.HasSuffix(src, "/") && !strings.HasSuffix(src, `\`) {
+		root = filepath.Dir(src)
+	}
+	dst = strings.TrimRight(dst, `/\`)
+
+	return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		target := dst + `\` + strings.Replace(filepath.ToSlash(rel), "/", `\`, -1)
+
+		log.Printf("Copying %s to %s on VM %s.", path, target, c.VMName)
+		return c.Driver.CopyFileToVirtualMachine(c.VMName, path, target)
+	})
+}
+
+// Download copies src from the guest to a temporary file on the host and
+// writes it to dst.
+func (c *PowerShellDirectCommunicator) Download(src string, dst io.Writer) error {
+	tempfile, err := tmp.File("powershell-direct-download")
+	if err != nil {
+		return fmt.Errorf("Failed to open temp file for writing: %s", err)
+	}
+	tempfile.Close()
+	defer os.Remove(tempfile.Name())
+
+	log.Printf("Copying from %s on VM %s.", src, c.VMName)
+	err = c.Driver.CopyFileFromVirtualMachine(c.VMName, c.Username, c.Password, src, tempfile.Name())
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Open(tempfile.Name())
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = io.Copy(dst, f)
+	return err
+}
+
+// DownloadDir copies the contents of the src directory in the guest into dst.
+func (c *PowerShellDirectCommunicator) DownloadDir(src string, dst string, exclude []string) error {
+	if err := os.MkdirAll(dst, 0755); err != nil {
+		return err
+	}
+
+	src = strings.TrimRight(src, `/\`) + `\*`
+
+	log.Printf("Copying from %s on VM %s.", src, c.VMName)
+	return c.Driver.CopyFileFromVirtualMachine(c.VMName, c.Username, c.Password, src, dst)
+}
@@ -0,0 +1,157 @@
+package common
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/packer/packer"
+)
+
+func testPowerShellDirectCommunicator() (*PowerShellDirectCommunicator, *DriverMock) {
+	driver := new(DriverMock)
+	comm := &PowerShellDirectCommunicator{
+		Driver:   driver,
+		VMName:   "foo",
+		Username: "Administrator",
+		Password: "packer",
+	}
+	return comm, driver
+}
+
+func TestPowerShellDirectCommunicator_impl(t *testing.T) {
+	var _ packer.Communicator = new(PowerShellDirectCommunicator)
+}
+
+func TestPowerShellDirectCommunicator_Start(t *testing.T) {
+	comm, driver := testPowerShellDirectCommunicator()
+	driver.InvokeVirtualMachineCommand_Stdout = "hello"
+	driver.InvokeVirtualMachineCommand_Return = 3
+
+	var stdout bytes.Buffer
+	cmd := &packer.RemoteCmd{
+		Command: "echo hello",
+		Stdout:  &stdout,
+	}
+	if err := comm.Start(context.Background(), cmd); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if exitStatus := cmd.Wait(); exitStatus != 3 {
+		t.Fatalf("bad exit status: %d", exitStatus)
+	}
+
+	if driver.InvokeVirtualMachineCommand_VmName != "foo" {
+		t.Fatalf("bad vm name: %s", driver.InvokeVirtualMachineCommand_VmName)
+	}
+	if driver.InvokeVirtualMachineCommand_Username != "Administrator" ||
+		driver.InvokeVirtualMachineCommand_Password != "packer" {
+		t.Fatal("Should have passed the credentials")
+	}
+	if driver.InvokeVirtualMachineCommand_Command != "echo hello" {
+		t.Fatalf("bad command: %s", driver.InvokeVirtualMachineCommand_Command)
+	}
+	if stdout.String() != "hello" {
+		t.Fatalf("bad stdout: %s", stdout.String())
+	}
+}
+
+func TestPowerShellDirectCommunicator_StartError(t *testing.T) {
+	comm, driver := testPowerShellDirectCommunicator()
+	driver.InvokeVirtualMachineCommand_Err = errors.New("PowerShell Direct is unavailable")
+
+	cmd := &packer.RemoteCmd{Command: "echo hello"}
+	if err := comm.Start(context.Background(), cmd); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if exitStatus := cmd.Wait(); exitStatus != packer.CmdDisconnect {
+		t.Fatalf("bad exit status: %d", exitStatus)
+	}
+}
+
+func TestPowerShellDirectCommunicator_Upload(t *testing.T) {
+	comm, driver := testPowerShellDirectCommunicator()
+
+	err := comm.Upload(`C:\Windows\Temp\script.ps1`, bytes.NewBufferString("Write-Host hello"), nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if !driver.CopyFileToVirtualMachine_Called {
+		t.Fatal("Should have called CopyFileToVirtualMachine")
+	}
+	if driver.CopyFileToVirtualMachine_VmName != "foo" {
+		t.Fatalf("bad vm name: %s", driver.CopyFileToVirtualMachine_VmName)
+	}
+	if driver.CopyFileToVirtualMachine_DestinationPath != `C:\Windows\Temp\script.ps1` {
+		t.Fatalf("bad destination: %s", driver.CopyFileToVirtualMachine_DestinationPath)
+	}
+	if _, err := os.Stat(driver.CopyFileToVirtualMachine_SourcePath); !os.IsNotExist(err) {
+		t.Fatal("Should have removed the temporary file")
+	}
+}
+
+func TestPowerShellDirectCommunicator_UploadDir(t *testing.T) {
+	td, err := ioutil.TempDir("", "packer")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer os.RemoveAll(td)
+
+	src := filepath.Join(td, "scripts")
+	if err := os.MkdirAll(filepath.Join(src, "sub"), 0755); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	file := filepath.Join(src, "sub", "script.ps1")
+	if err := ioutil.WriteFile(file, []byte("Write-Host hello"), 0644); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	cases := []struct {
+		src      string
+		expected string
+	}{
+		{src, `C:\dst\scripts\sub\script.ps1`},
+		{src + string(os.PathSeparator), `C:\dst\sub\script.ps1`},
+	}
+
+	for _, tc := range cases {
+		comm, driver := testPowerShellDirectCommunicator()
+		if err := comm.UploadDir(`C:\dst\`, tc.src, nil); err != nil {
+			t.Fatalf("err: %s", err)
+		}
+		if driver.CopyFileToVirtualMachine_SourcePath != file {
+			t.Fatalf("bad source for %s: %s", tc.src, driver.CopyFileToVirtualMachine_SourcePath)
+		}
+		if driver.CopyFileToVirtualMachine_DestinationPath != tc.expected {
+			t.Fatalf("bad destination for %s: %s", tc.src, driver.CopyFileToVirtualMachine_DestinationPath)
+		}
+	}
+}
+
+func TestPowerShellDirectCommunicator_DownloadDir(t *testing.T) {
+	td, err := ioutil.TempDir("", "packer")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer os.RemoveAll(td)
+
+	comm, driver := testPowerShellDirectCommunicator()
+	dst := filepath.Join(td, "logs")
+	if err := comm.DownloadDir(`C:\logs\`, dst, nil); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if driver.CopyFileFromVirtualMachine_SourcePath != `C:\logs\*` {
+		t.Fatalf("bad source: %s", driver.CopyFileFromVirtualMachine_SourcePath)
+	}
+	if driver.CopyFileFromVirtualMachine_DestinationPath != dst {
+		t.Fatalf("bad destination: %s", driver.CopyFileFromVirtualMachine_DestinationPath)
+	}
+	if _, err := os.Stat(dst); err != nil {
+		t.Fatalf("Should have created the destination: %s", err)
+	}
+}
@@ -0,0 +1,45 @@
+package common
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/packer/packer"
+)
+
+// vmResource and switchResource are the resources the steps record for
+// `packer destroy` to destroy them if a build crashes.
+func vmResource(name string) packer.Resource {
+	return packer.Resource{Provider: "hyperv", Type: "vm", ID: name}
+}
+
+func switchResource(name string) packer.Resource {
+	return packer.Resource{Provider: "hyperv", Type: "switch", ID: name}
+}
+
+// DestroyResource destroys a virtual machine or a switch recorded by the
+// steps of the Hyper-V builders, with the PowerShell driver. Resources that
+// don't exist anymore are ignored.
+func DestroyResource(ctx context.Context, r packer.Resource) error {
+	driver, err := NewHypervPS4Driver(nil)
+	if err != nil {
+		return err
+	}
+
+	switch r.Type {
+	case "vm":
+		// CheckVMName errors when the name is used
+		if driver.CheckVMName(r.ID) == nil {
+			return nil
+		}
+		return driver.DeleteVirtualMachine(r.ID)
+	case "switch":
+		exists, err := driver.VirtualSwitchExists(r.ID)
+		if err != nil || !exists {
+			return err
+		}
+		return driver.DeleteVirtualSwitch(r.ID)
+	default:
+		return fmt.Errorf("unknown type of resource %q", r.Type)
+	}
+}
@@ -0,0 +1,68 @@
+//go:generate struct-markdown
+
+package common
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/packer/packer-plugin-sdk/shutdowncommand"
+	"github.com/hashicorp/packer/packer-plugin-sdk/template/interpolate"
+)
+
+const (
+	DefaultShutdownRetryInterval = 10 * time.Second
+)
+
+type ShutdownConfig struct {
+	shutdowncommand.ShutdownConfig `mapstructure:",squash"`
+	// The number of times to re-send the `shutdown_command` if it could not
+	// be started on the guest, for example because the WinRM service was
+	// briefly unavailable during sysprep. If every attempt fails, Packer
+	// falls back to forcibly turning the virtual machine off. By default this
+	// is `0`, which means a failure to send the shutdown command halts the
+	// build.
+	ShutdownRetries int `mapstructure:"shutdown_retries" required:"false"`
+	// The amount of time to wait between attempts to send the
+	// `shutdown_command`. Only used when `shutdown_retries` is greater than
+	// zero. By default this is "10s" (ten seconds).
+	ShutdownRetryInterval time.Duration `mapstructure:"shutdown_retry_interval" required:"false"`
+	// If true, Packer turns the virtual machine off through Hyper-V when it
+	// hasn't shut down within `shutdown_timeout` after the `shutdown_command`
+	// was sent, and continues the build with a warning instead of failing it.
+	// This defaults to false.
+	ShutdownForceStop bool `mapstructure:"shutdown_force_stop" required:"false"`
+	// If true, Packer doesn't consider the virtual machine shut down until,
+	// besides no longer running, its integration services heartbeat is off
+	// or has lost communication and its virtual hard disks are no longer
+	// locked. Hyper-V may report a Windows guest as stopped while it is still
+	// flushing its disks, and exporting it at that point can produce
+	// corrupt disks. Waiting counts against `shutdown_timeout`. This defaults
+	// to false.
+	ShutdownWaitForHeartbeat bool `mapstructure:"shutdown_wait_for_heartbeat" required:"false"`
+	// The amount of time to wait after the virtual machine has shut down
+	// before continuing with the build. If exporting or compacting the disks
+	// fails because a file is still in use, you might need to set this to
+	// "10s" or so. By default, the delay is "0s" or disabled.
+	PostShutdownDelay time.Duration `mapstructure:"post_shutdown_delay" required:"false"`
+}
+
+func (c *ShutdownConfig) Prepare(ctx *interpolate.Context) []error {
+	errs := c.ShutdownConfig.Prepare(ctx)
+
+	if c.ShutdownRetries < 0 {
+		errs = append(errs, fmt.Errorf("shutdown_retries: must be greater than or equal to 0, but defined: %d",
+			c.ShutdownRetries))
+	}
+
+	if c.PostShutdownDelay < 0 {
+		errs = append(errs, fmt.Errorf("post_shutdown_delay: must be greater than or equal to 0, but defined: %s",
+			c.PostShutdownDelay))
+	}
+
+	if c.ShutdownRetryInterval == 0 {
+		c.ShutdownRetryInterval = DefaultShutdownRetryInterval
+	}
+
+	return errs
+}
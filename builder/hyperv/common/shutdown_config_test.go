@@ -0,0 +1,82 @@
+package common
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hashicorp/packer/packer-plugin-sdk/template/interpolate"
+)
+
+func testShutdownConfig() *ShutdownConfig {
+	return &ShutdownConfig{}
+}
+
+func TestShutdownConfigPrepare_ShutdownRetries(t *testing.T) {
+	var c *ShutdownConfig
+	var errs []error
+
+	// Test with a good one
+	c = testShutdownConfig()
+	c.ShutdownRetries = 3
+	errs = c.Prepare(interpolate.NewContext())
+	if len(errs) > 0 {
+		t.Fatalf("err: %#v", errs)
+	}
+
+	// Test with a bad one
+	c = testShutdownConfig()
+	c.ShutdownRetries = -1
+	errs = c.Prepare(interpolate.NewContext())
+	if len(errs) == 0 {
+		t.Fatal("should have error")
+	}
+}
+
+func TestShutdownConfigPrepare_ShutdownRetryInterval(t *testing.T) {
+	var c *ShutdownConfig
+	var errs []error
+
+	// Test with the default
+	c = testShutdownConfig()
+	errs = c.Prepare(interpolate.NewContext())
+	if len(errs) > 0 {
+		t.Fatalf("err: %#v", errs)
+	}
+	if c.ShutdownRetryInterval != DefaultShutdownRetryInterval {
+		t.Fatalf("bad: %s", c.ShutdownRetryInterval)
+	}
+
+	// Test with a good one
+	c = testShutdownConfig()
+	c.ShutdownRetryInterval = 5 * time.Second
+	errs = c.Prepare(interpolate.NewContext())
+	if len(errs) > 0 {
+		t.Fatalf("err: %#v", errs)
+	}
+	if c.ShutdownRetryInterval != 5*time.Second {
+		t.Fatalf("bad: %s", c.ShutdownRetryInterval)
+	}
+}
+
+func TestShutdownConfigPrepare_PostShutdownDelay(t *testing.T) {
+	var c *ShutdownConfig
+	var errs []error
+
+	// Test with the default
+	c = testShutdownConfig()
+	errs = c.Prepare(interpolate.NewContext())
+	if len(errs) > 0 {
+		t.Fatalf("err: %#v", errs)
+	}
+	if c.PostShutdownDelay != 0 {
+		t.Fatalf("bad: %s", c.PostShutdownDelay)
+	}
+
+	// Test with a bad value
+	c = testShutdownConfig()
+	c.PostShutdownDelay = -1 * time.Second
+	errs = c.Prepare(interpolate.NewContext())
+	if len(errs) == 0 {
+		t.Fatal("should have error")
+	}
+}
@@ -1,12 +1,26 @@
 package common
 
 import (
+	"fmt"
 	"log"
+	"net"
 
 	"github.com/hashicorp/packer/packer-plugin-sdk/multistep"
 )
 
-func CommHost(host string) func(multistep.StateBag) (string, error) {
+const (
+	// IpAddressSourceAdapter looks up the IP address of the network adapter
+	// and falls back to the KVP exchange.
+	IpAddressSourceAdapter = "adapter"
+	// IpAddressSourceKvp only uses the IP addresses the guest reports
+	// through the KVP exchange.
+	IpAddressSourceKvp = "kvp"
+
+	IpAddressFamilyIPv4 = "ipv4"
+	IpAddressFamilyIPv6 = "ipv6"
+)
+
+func CommHost(host string, source string, family string) func(multistep.StateBag) (string, error) {
 	return func(state multistep.StateBag) (string, error) {
 
 		// Skip IP auto detection if the configuration has an ssh host configured.
@@ -15,19 +29,63 @@ func CommHost(host string) func(multistep.StateBag) (string, error) {
 			return host, nil
 		}
 
-		vmName := state.Get("vmName").(string)
-		driver := state.Get("driver").(Driver)
+		vmName, err := getVMName(state)
+		if err != nil {
+			return "", err
+		}
+		driver, err := getDriver(state)
+		if err != nil {
+			return "", err
+		}
 
 		mac, err := driver.Mac(vmName)
 		if err != nil {
 			return "", err
 		}
 
-		ip, err := driver.IpAddress(mac)
+		addresses, err := driver.IpAddresses(mac, source)
 		if err != nil {
 			return "", err
 		}
 
+		ip := selectIpAddress(addresses, family)
+		if ip == "" {
+			return "", fmt.Errorf("No usable ip address found in %v", addresses)
+		}
+
 		return ip, nil
 	}
 }
+
+// selectIpAddress returns the first address of the given family, ignoring
+// loopback and link-local addresses. Without a family the first IPv4 address
+// is returned, or the first IPv6 address if there is no IPv4 address. It
+// returns an empty string if there is no such address.
+func selectIpAddress(addresses []string, family string) string {
+	var ipv4, ipv6 string
+	for _, address := range addresses {
+		ip := net.ParseIP(address)
+		if ip == nil || ip.IsLoopback() || ip.IsUnspecified() || ip.IsLinkLocalUnicast() {
+			continue
+		}
+
+		if ip.To4() != nil {
+			if ipv4 == "" {
+				ipv4 = address
+			}
+		} else if ipv6 == "" {
+			ipv6 = address
+		}
+	}
+
+	switch family {
+	case IpAddressFamilyIPv4:
+		return ipv4
+	case IpAddressFamilyIPv6:
+		return ipv6
+	}
+	if ipv4 != "" {
+		return ipv4
+	}
+	return ipv6
+}
@@ -1,6 +1,9 @@
 package common
 
 import (
+	"errors"
+	"time"
+
 	"github.com/hashicorp/packer/helper/communicator"
 	"github.com/hashicorp/packer/packer-plugin-sdk/template/interpolate"
 )
@@ -10,5 +13,18 @@ type SSHConfig struct {
 }
 
 func (c *SSHConfig) Prepare(ctx *interpolate.Context) []error {
-	return c.Comm.Prepare(ctx)
+	errs := c.Comm.Prepare(ctx)
+
+	// PowerShell Direct authenticates with the WinRM credentials and waits
+	// for the guest as long as WinRM would.
+	if c.Comm.Type == PowerShellDirectCommunicatorType {
+		if c.Comm.WinRMTimeout == 0 {
+			c.Comm.WinRMTimeout = 30 * time.Minute
+		}
+		if c.Comm.WinRMUser == "" {
+			errs = append(errs, errors.New("winrm_username must be specified."))
+		}
+	}
+
+	return errs
 }
@@ -0,0 +1,66 @@
+package common
+
+import (
+	"testing"
+)
+
+func TestCommHost(t *testing.T) {
+	state := testState(t)
+	state.Put("vmName", "foo")
+
+	driver := state.Get("driver").(*DriverMock)
+	driver.Mac_Return = "00155D010203"
+	driver.IpAddresses_Return = []string{"169.254.10.20", "fe80::1", "2001:db8::10", "10.0.0.10"}
+
+	ip, err := CommHost("", IpAddressSourceKvp, "")(state)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if ip != "10.0.0.10" {
+		t.Fatalf("bad ip: %s", ip)
+	}
+	if driver.IpAddresses_Mac != "00155D010203" {
+		t.Fatalf("bad mac: %s", driver.IpAddresses_Mac)
+	}
+	if driver.IpAddresses_Source != IpAddressSourceKvp {
+		t.Fatalf("bad source: %s", driver.IpAddresses_Source)
+	}
+
+	// Test a configured host
+	ip, err = CommHost("192.168.0.1", IpAddressSourceAdapter, "")(state)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if ip != "192.168.0.1" {
+		t.Fatalf("bad ip: %s", ip)
+	}
+
+	// Test without a usable address
+	driver.IpAddresses_Return = []string{"169.254.10.20", "fe80::1"}
+	if _, err := CommHost("", IpAddressSourceAdapter, "")(state); err == nil {
+		t.Fatal("should have error")
+	}
+}
+
+func TestSelectIpAddress(t *testing.T) {
+	addresses := []string{"fe80::1", "169.254.10.20", "2001:db8::10", "127.0.0.1", "10.0.0.10", "10.0.0.11"}
+
+	cases := map[string]string{
+		"":                  "10.0.0.10",
+		IpAddressFamilyIPv4: "10.0.0.10",
+		IpAddressFamilyIPv6: "2001:db8::10",
+	}
+	for family, expected := range cases {
+		if ip := selectIpAddress(addresses, family); ip != expected {
+			t.Fatalf("bad ip for family %q: %s", family, ip)
+		}
+	}
+
+	// Test falling back to IPv6
+	if ip := selectIpAddress([]string{"2001:db8::10"}, ""); ip != "2001:db8::10" {
+		t.Fatalf("bad ip: %s", ip)
+	}
+	if ip := selectIpAddress([]string{"2001:db8::10"}, IpAddressFamilyIPv4); ip != "" {
+		t.Fatalf("bad ip: %s", ip)
+	}
+}
@@ -0,0 +1,31 @@
+package common
+
+import (
+	"github.com/hashicorp/packer/packer"
+	"github.com/hashicorp/packer/packer-plugin-sdk/multistep"
+	"github.com/hashicorp/packer/packer-plugin-sdk/multistep/commonsteps"
+)
+
+// getDriver returns the Hyper-V driver of the state.
+func getDriver(state multistep.StateBag) (Driver, error) {
+	var driver Driver
+	err := multistep.GetValue(state, "driver", &driver)
+	return driver, err
+}
+
+// getUiAndDriver returns the UI and the driver of the state, which most steps
+// use. The UI is returned when only the driver is missing, to report it.
+func getUiAndDriver(state multistep.StateBag) (packer.Ui, Driver, error) {
+	ui, err := commonsteps.GetUi(state)
+	if err != nil {
+		return nil, nil, err
+	}
+	driver, err := getDriver(state)
+	return ui, driver, err
+}
+
+// getVMName returns the name of the VM of the state, set once it's created or
+// cloned.
+func getVMName(state multistep.StateBag) (string, error) {
+	return multistep.GetString(state, "vmName")
+}
@@ -0,0 +1,44 @@
+package common
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/packer/packer-plugin-sdk/multistep"
+)
+
+func TestStep_missingStateValue(t *testing.T) {
+	state := testState(t)
+	step := new(StepEnableIntegrationService)
+
+	// The name of the VM isn't set
+	if action := step.Run(context.Background(), state); action != multistep.ActionHalt {
+		t.Fatalf("Bad action: %v", action)
+	}
+	err, ok := state.GetOk("error")
+	if !ok {
+		t.Fatal("Should have error")
+	}
+	if _, ok := err.(*multistep.StateValueError); !ok {
+		t.Fatalf("Bad error: %#v", err)
+	}
+	if state.Get("driver").(*DriverMock).EnableVirtualMachineIntegrationService_Called {
+		t.Fatal("The driver should not be called")
+	}
+}
+
+func TestStep_missingDriver(t *testing.T) {
+	state := testState(t)
+	state.Remove("driver")
+	step := new(StepEnableIntegrationService)
+
+	if action := step.Run(context.Background(), state); action != multistep.ActionHalt {
+		t.Fatalf("Bad action: %v", action)
+	}
+	if _, ok := state.GetOk("error"); !ok {
+		t.Fatal("Should have error")
+	}
+
+	// Cleanups are skipped
+	(&StepCreateVM{VMName: "test"}).Cleanup(state)
+}
@@ -0,0 +1,57 @@
+package common
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/packer/packer-plugin-sdk/multistep"
+	"github.com/hashicorp/packer/packer-plugin-sdk/multistep/commonsteps"
+)
+
+// This step adds the additional network adapters to the VM. An adapter that
+// the VM already has, when a build is resumed, is connected to its switch again.
+//
+// Uses:
+//   driver Driver
+//   ui packer.Ui
+//   vmName string
+//
+// Produces:
+type StepAddNetworkAdapters struct {
+	Adapters []AdditionalNetworkAdapter
+}
+
+func (s *StepAddNetworkAdapters) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	if len(s.Adapters) == 0 {
+		return multistep.ActionContinue
+	}
+
+	ui, driver, err := getUiAndDriver(state)
+	if err != nil {
+		return commonsteps.HaltOnError(state, ui, err)
+	}
+	vmName, err := getVMName(state)
+	if err != nil {
+		return commonsteps.HaltOnError(state, ui, err)
+	}
+
+	ui.Say("Adding additional network adapters...")
+
+	for index, adapter := range s.Adapters {
+		adapterName := fmt.Sprintf("%s-%d", vmName, index+1)
+		err := driver.AddVirtualMachineNetworkAdapter(vmName, adapterName, adapter.SwitchName,
+			adapter.VlanId, adapter.MacAddress, adapter.EnableMacSpoofing)
+		if err != nil {
+			err := fmt.Errorf("Error adding network adapter connected to %s: %s", adapter.SwitchName, err)
+			state.Put("error", err)
+			ui.Error(err.Error())
+			return multistep.ActionHalt
+		}
+	}
+
+	return multistep.ActionContinue
+}
+
+func (s *StepAddNetworkAdapters) Cleanup(state multistep.StateBag) {
+	// do nothing, the adapters are removed along with the VM
+}
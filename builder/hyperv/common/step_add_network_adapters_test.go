@@ -0,0 +1,101 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/packer/packer-plugin-sdk/multistep"
+)
+
+func TestStepAddNetworkAdapters_impl(t *testing.T) {
+	var _ multistep.Step = new(StepAddNetworkAdapters)
+}
+
+func TestStepAddNetworkAdapters(t *testing.T) {
+	state := testState(t)
+	step := new(StepAddNetworkAdapters)
+	step.Adapters = []AdditionalNetworkAdapter{
+		{
+			SwitchName:        "Internal",
+			VlanId:            "20",
+			MacAddress:        "0000deadbeef",
+			EnableMacSpoofing: true,
+		},
+	}
+
+	vmName := "foo"
+	state.Put("vmName", vmName)
+
+	driver := state.Get("driver").(*DriverMock)
+
+	// Test the run
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("Bad action: %v", action)
+	}
+	if _, ok := state.GetOk("error"); ok {
+		t.Fatal("Should NOT have error")
+	}
+
+	// Test the driver
+	if !driver.AddVirtualMachineNetworkAdapter_Called {
+		t.Fatal("Should have called AddVirtualMachineNetworkAdapter")
+	}
+	if driver.AddVirtualMachineNetworkAdapter_VmName != vmName {
+		t.Fatalf("Should call with correct vm name. Got: %s Wanted: %s",
+			driver.AddVirtualMachineNetworkAdapter_VmName, vmName)
+	}
+	if driver.AddVirtualMachineNetworkAdapter_AdapterName != "foo-1" {
+		t.Fatalf("Bad adapter name: %s", driver.AddVirtualMachineNetworkAdapter_AdapterName)
+	}
+	if driver.AddVirtualMachineNetworkAdapter_SwitchName != "Internal" {
+		t.Fatalf("Bad switch name: %s", driver.AddVirtualMachineNetworkAdapter_SwitchName)
+	}
+	if driver.AddVirtualMachineNetworkAdapter_VlanId != "20" {
+		t.Fatalf("Bad vlan id: %s", driver.AddVirtualMachineNetworkAdapter_VlanId)
+	}
+	if driver.AddVirtualMachineNetworkAdapter_Mac != "0000deadbeef" {
+		t.Fatalf("Bad mac address: %s", driver.AddVirtualMachineNetworkAdapter_Mac)
+	}
+	if !driver.AddVirtualMachineNetworkAdapter_EnableMacSpoofing {
+		t.Fatal("Should have enabled mac spoofing")
+	}
+}
+
+func TestStepAddNetworkAdapters_skip(t *testing.T) {
+	state := testState(t)
+	step := new(StepAddNetworkAdapters)
+
+	state.Put("vmName", "foo")
+
+	driver := state.Get("driver").(*DriverMock)
+
+	// Test the run
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("Bad action: %v", action)
+	}
+
+	// Test the driver
+	if driver.AddVirtualMachineNetworkAdapter_Called {
+		t.Fatal("Should NOT have called AddVirtualMachineNetworkAdapter")
+	}
+}
+
+func TestStepAddNetworkAdapters_err(t *testing.T) {
+	state := testState(t)
+	step := new(StepAddNetworkAdapters)
+	step.Adapters = []AdditionalNetworkAdapter{{SwitchName: "Internal"}}
+
+	state.Put("vmName", "foo")
+
+	driver := state.Get("driver").(*DriverMock)
+	driver.AddVirtualMachineNetworkAdapter_Err = fmt.Errorf("switch not found")
+
+	// Test the run
+	if action := step.Run(context.Background(), state); action != multistep.ActionHalt {
+		t.Fatalf("Bad action: %v", action)
+	}
+	if _, ok := state.GetOk("error"); !ok {
+		t.Fatal("Should have error")
+	}
+}
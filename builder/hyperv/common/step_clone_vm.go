@@ -9,6 +9,7 @@ import (
 
 	"github.com/hashicorp/packer/packer"
 	"github.com/hashicorp/packer/packer-plugin-sdk/multistep"
+	"github.com/hashicorp/packer/packer-plugin-sdk/multistep/commonsteps"
 )
 
 // This step clones an existing virtual machine.
@@ -25,23 +26,36 @@ type StepCloneVM struct {
 	CompareCopy                    bool
 	RamSize                        uint
 	Cpu                            uint
+	CpusPerSocket                  uint
+	NumaNodes                      uint
 	EnableMacSpoofing              bool
 	EnableDynamicMemory            bool
+	DynamicMemoryMinimum           uint
+	DynamicMemoryMaximum           uint
+	MemoryBufferPercent            uint
 	EnableSecureBoot               bool
 	SecureBootTemplate             string
+	EnableVirtualTPM               bool
 	EnableVirtualizationExtensions bool
+	ExposeProcessorFeatures        bool
 	MacAddress                     string
 	KeepRegistered                 bool
 	AdditionalDiskSize             []uint
+	AdditionalDisks                []AdditionalDisk
 	DiskBlockSize                  uint
 }
 
 func (s *StepCloneVM) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
-	driver := state.Get("driver").(Driver)
-	ui := state.Get("ui").(packer.Ui)
+	ui, driver, err := getUiAndDriver(state)
+	if err != nil {
+		return commonsteps.HaltOnError(state, ui, err)
+	}
 	ui.Say("Cloning virtual machine...")
 
-	path := state.Get("build_dir").(string)
+	path, err := multistep.GetString(state, "build_dir")
+	if err != nil {
+		return commonsteps.HaltOnError(state, ui, err)
+	}
 
 	// Determine if we even have an existing virtual harddrive to attach
 	harddrivePath := ""
@@ -59,7 +73,14 @@ func (s *StepCloneVM) Run(ctx context.Context, state multistep.StateBag) multist
 	// convert the MB to bytes
 	ramSize := int64(s.RamSize * 1024 * 1024)
 
-	err := driver.CloneVirtualMachine(s.CloneFromVMCXPath, s.CloneFromVMName,
+	// The exported virtual machine may have been downloaded and extracted
+	// from a source_url.
+	cloneFromVmcxPath := s.CloneFromVMCXPath
+	if sourceVmcxPath, ok := state.GetOk("source_vmcx_path"); ok {
+		cloneFromVmcxPath = sourceVmcxPath.(string)
+	}
+
+	err = driver.CloneVirtualMachine(cloneFromVmcxPath, s.CloneFromVMName,
 		s.CloneFromSnapshotName, s.CloneAllSnapshots, s.VMName, path,
 		harddrivePath, ramSize, s.SwitchName, s.CompareCopy)
 	if err != nil {
@@ -68,6 +89,7 @@ func (s *StepCloneVM) Run(ctx context.Context, state multistep.StateBag) multist
 		ui.Error(err.Error())
 		return multistep.ActionHalt
 	}
+	packer.RecordResource(vmResource(s.VMName))
 
 	err = driver.SetVirtualMachineCpuCount(s.VMName, s.Cpu)
 	if err != nil {
@@ -77,6 +99,19 @@ func (s *StepCloneVM) Run(ctx context.Context, state multistep.StateBag) multist
 		return multistep.ActionHalt
 	}
 
+	if s.CpusPerSocket != 0 || s.NumaNodes != 0 {
+		cpusPerNumaNode, numaNodesPerSocket, memoryPerNumaNode := cpuTopology(s.Cpu, s.CpusPerSocket,
+			s.NumaNodes, s.RamSize)
+		err = driver.SetVirtualMachineCpuTopology(s.VMName, cpusPerNumaNode, numaNodesPerSocket,
+			int64(memoryPerNumaNode)*1024*1024)
+		if err != nil {
+			err := fmt.Errorf("Error setting virtual machine cpu topology: %s", err)
+			state.Put("error", err)
+			ui.Error(err.Error())
+			return multistep.ActionHalt
+		}
+	}
+
 	if s.EnableDynamicMemory {
 		err = driver.SetVirtualMachineDynamicMemory(s.VMName, s.EnableDynamicMemory)
 		if err != nil {
@@ -87,6 +122,18 @@ func (s *StepCloneVM) Run(ctx context.Context, state multistep.StateBag) multist
 		}
 	}
 
+	if s.EnableDynamicMemory && (s.DynamicMemoryMinimum != 0 || s.DynamicMemoryMaximum != 0 ||
+		s.MemoryBufferPercent != 0) {
+		err = driver.SetVirtualMachineDynamicMemorySettings(s.VMName, int64(s.DynamicMemoryMinimum)*1024*1024,
+			int64(s.DynamicMemoryMaximum)*1024*1024, s.MemoryBufferPercent)
+		if err != nil {
+			err := fmt.Errorf("Error setting virtual machine dynamic memory settings: %s", err)
+			state.Put("error", err)
+			ui.Error(err.Error())
+			return multistep.ActionHalt
+		}
+	}
+
 	if s.EnableMacSpoofing {
 		err = driver.SetVirtualMachineMacSpoofing(s.VMName, s.EnableMacSpoofing)
 		if err != nil {
@@ -114,6 +161,21 @@ func (s *StepCloneVM) Run(ctx context.Context, state multistep.StateBag) multist
 			ui.Error(err.Error())
 			return multistep.ActionHalt
 		}
+
+		if s.EnableVirtualTPM {
+			err = driver.EnableVirtualMachineTPM(s.VMName)
+			if err != nil {
+				err := fmt.Errorf("Error enabling virtual TPM: %s", err)
+				state.Put("error", err)
+				ui.Error(err.Error())
+				return multistep.ActionHalt
+			}
+		}
+	} else if s.EnableVirtualTPM {
+		err := fmt.Errorf("Virtual TPM is only supported on Generation 2 virtual machines.")
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
 	}
 
 	if s.EnableVirtualizationExtensions {
@@ -127,18 +189,23 @@ func (s *StepCloneVM) Run(ctx context.Context, state multistep.StateBag) multist
 		}
 	}
 
-	if len(s.AdditionalDiskSize) > 0 {
-		for index, size := range s.AdditionalDiskSize {
-			diskSize := int64(size * 1024 * 1024)
-			diskFile := fmt.Sprintf("%s-%d.vhdx", s.VMName, index)
-			diskBlockSize := int64(s.DiskBlockSize) * 1024 * 1024
-			err = driver.AddVirtualMachineHardDrive(s.VMName, path, diskFile, diskSize, diskBlockSize, "SCSI")
-			if err != nil {
-				err := fmt.Errorf("Error creating and attaching additional disk drive: %s", err)
-				state.Put("error", err)
-				ui.Error(err.Error())
-				return multistep.ActionHalt
-			}
+	if s.ExposeProcessorFeatures {
+		err = driver.SetVirtualMachineProcessorCompatibility(s.VMName, false)
+		if err != nil {
+			err := fmt.Errorf("Error setting virtual machine processor compatibility: %s", err)
+			state.Put("error", err)
+			ui.Error(err.Error())
+			return multistep.ActionHalt
+		}
+	}
+
+	if len(s.AdditionalDiskSize) > 0 || len(s.AdditionalDisks) > 0 {
+		err = addAdditionalDisks(driver, s.VMName, path, s.AdditionalDiskSize, s.DiskBlockSize, s.AdditionalDisks)
+		if err != nil {
+			err := fmt.Errorf("Error creating and attaching additional disk drive: %s", err)
+			state.Put("error", err)
+			ui.Error(err.Error())
+			return multistep.ActionHalt
 		}
 	}
 
@@ -153,7 +220,7 @@ func (s *StepCloneVM) Run(ctx context.Context, state multistep.StateBag) multist
 	}
 
 	// Set the final name in the state bag so others can use it
-	state.Put("vmName", s.VMName)
+	multistep.PutString(state, "vmName", s.VMName)
 	// instance_id is the generic term used so that users can have access to the
 	// instance id inside of the provisioners, used in step_provision.
 	state.Put("instance_id", s.VMName)
@@ -161,23 +228,37 @@ func (s *StepCloneVM) Run(ctx context.Context, state multistep.StateBag) multist
 	return multistep.ActionContinue
 }
 
+func (s *StepCloneVM) Save(state multistep.StateBag) map[string]interface{} {
+	return multistep.SaveKeys(state, "vmName", "instance_id")
+}
+
+func (s *StepCloneVM) Resume(ctx context.Context, state multistep.StateBag, saved map[string]interface{}) multistep.StepAction {
+	multistep.RestoreKeys(state, saved)
+	return multistep.ActionContinue
+}
+
 func (s *StepCloneVM) Cleanup(state multistep.StateBag) {
 	if s.VMName == "" {
 		return
 	}
 
-	driver := state.Get("driver").(Driver)
-	ui := state.Get("ui").(packer.Ui)
+	ui, driver, err := getUiAndDriver(state)
+	if commonsteps.SkipCleanupOnError(err) {
+		return
+	}
 
 	if s.KeepRegistered {
 		ui.Say("keep_registered set. Skipping unregister/deletion of VM.")
+		packer.ForgetResource(vmResource(s.VMName))
 		return
 	}
 
 	ui.Say("Unregistering and deleting virtual machine...")
 
-	err := driver.DeleteVirtualMachine(s.VMName)
+	err = driver.DeleteVirtualMachine(s.VMName)
 	if err != nil {
 		ui.Error(fmt.Sprintf("Error deleting virtual machine: %s", err))
+		return
 	}
+	packer.ForgetResource(vmResource(s.VMName))
 }
@@ -4,23 +4,36 @@ import (
 	"context"
 	"fmt"
 
-	"github.com/hashicorp/packer/packer"
 	"github.com/hashicorp/packer/packer-plugin-sdk/multistep"
+	"github.com/hashicorp/packer/packer-plugin-sdk/multistep/commonsteps"
 )
 
 type StepCollateArtifacts struct {
 	OutputDir  string
 	SkipExport bool
+	// Collate the artifacts in the output directory StepExportVm created on
+	// the remote Hyper-V host and copy them into OutputDir afterwards
+	Remote bool
 }
 
 // Runs the step required to collate all build artifacts under the
 // specified output directory
 func (s *StepCollateArtifacts) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
-	driver := state.Get("driver").(Driver)
-	ui := state.Get("ui").(packer.Ui)
+	ui, driver, err := getUiAndDriver(state)
+	if err != nil {
+		return commonsteps.HaltOnError(state, ui, err)
+	}
 
 	ui.Say("Collating build artifacts...")
 
+	outputDir := s.OutputDir
+	if s.Remote {
+		outputDir, err = multistep.GetString(state, "host_output_dir")
+		if err != nil {
+			return commonsteps.HaltOnError(state, ui, err)
+		}
+	}
+
 	if s.SkipExport {
 		// Get the path to the main build directory from the statebag
 		var buildDir string
@@ -32,7 +45,7 @@ func (s *StepCollateArtifacts) Run(ctx context.Context, state multistep.StateBag
 		// called function searches for all disks under the given source
 		// directory and moves them to a 'Virtual Hard Disks' folder under
 		// the destination directory
-		err := driver.MoveCreatedVHDsToOutputDir(buildDir, s.OutputDir)
+		err := driver.MoveCreatedVHDsToOutputDir(buildDir, outputDir)
 		if err != nil {
 			err = fmt.Errorf("Error moving VHDs from build dir to output dir: %s", err)
 			state.Put("error", err)
@@ -54,7 +67,7 @@ func (s *StepCollateArtifacts) Run(ctx context.Context, state multistep.StateBag
 		// when complete.
 		// The 'Snapshots' folder will not be moved into the output
 		// directory if it is empty.
-		err := driver.PreserveLegacyExportBehaviour(exportPath, s.OutputDir)
+		err := driver.PreserveLegacyExportBehaviour(exportPath, outputDir)
 		if err != nil {
 			// No need to halt here; Just warn the user instead
 			err = fmt.Errorf("WARNING: Error restoring legacy export dir structure: %s", err)
@@ -62,6 +75,17 @@ func (s *StepCollateArtifacts) Run(ctx context.Context, state multistep.StateBag
 		}
 	}
 
+	if s.Remote {
+		ui.Say("Copying build artifacts from the Hyper-V host...")
+		err := driver.CopyDirectoryFromHost(outputDir, s.OutputDir)
+		if err != nil {
+			err = fmt.Errorf("Error copying build artifacts from the Hyper-V host: %s", err)
+			state.Put("error", err)
+			ui.Error(err.Error())
+			return multistep.ActionHalt
+		}
+	}
+
 	return multistep.ActionContinue
 }
 
@@ -90,3 +90,33 @@ func TestStepCollateArtifacts_skipExportArtifacts(t *testing.T) {
 		t.Fatal("Should NOT have called PreserveLegacyExportBehaviour")
 	}
 }
+
+func TestStepCollateArtifacts_remote(t *testing.T) {
+	state := testState(t)
+	step := &StepCollateArtifacts{
+		OutputDir: "foopath",
+		Remote:    true,
+	}
+
+	hostOutputDir := `D:\Builds\hyperv12345\output67890`
+	state.Put("host_output_dir", hostOutputDir)
+	state.Put("export_path", hostOutputDir+`\foo`)
+
+	driver := state.Get("driver").(*DriverMock)
+
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("Bad action: %v", action)
+	}
+	if _, ok := state.GetOk("error"); ok {
+		t.Fatal("Should NOT have error")
+	}
+
+	if driver.PreserveLegacyExportBehaviour_DstPath != hostOutputDir {
+		t.Fatalf("Should collate on the host. Got: %s", driver.PreserveLegacyExportBehaviour_DstPath)
+	}
+	if driver.CopyDirectoryFromHost_Source != hostOutputDir ||
+		driver.CopyDirectoryFromHost_Destination != step.OutputDir {
+		t.Fatalf("Should copy the artifacts into the output directory. Got: %s %s",
+			driver.CopyDirectoryFromHost_Source, driver.CopyDirectoryFromHost_Destination)
+	}
+}
@@ -4,18 +4,23 @@ import (
 	"context"
 	"fmt"
 
-	"github.com/hashicorp/packer/packer"
 	"github.com/hashicorp/packer/packer-plugin-sdk/multistep"
+	"github.com/hashicorp/packer/packer-plugin-sdk/multistep/commonsteps"
 )
 
 type StepCompactDisk struct {
 	SkipCompaction bool
 }
 
-// Run runs a compaction/optimisation process on attached VHD/VHDX disks
+// Run runs a compaction/optimisation process on attached VHD/VHDX disks.
+// Each dynamically expanding disk is mounted read-only for a full
+// optimisation, which requires the VM to be turned off. Fixed size disks
+// are skipped.
 func (s *StepCompactDisk) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
-	driver := state.Get("driver").(Driver)
-	ui := state.Get("ui").(packer.Ui)
+	ui, driver, err := getUiAndDriver(state)
+	if err != nil {
+		return commonsteps.HaltOnError(state, ui, err)
+	}
 
 	if s.SkipCompaction {
 		ui.Say("Skipping disk compaction...")
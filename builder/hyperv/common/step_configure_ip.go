@@ -7,19 +7,24 @@ import (
 	"strings"
 	"time"
 
-	"github.com/hashicorp/packer/packer"
 	"github.com/hashicorp/packer/packer-plugin-sdk/multistep"
+	"github.com/hashicorp/packer/packer-plugin-sdk/multistep/commonsteps"
 )
 
 type StepConfigureIp struct {
 }
 
 func (s *StepConfigureIp) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
-	driver := state.Get("driver").(Driver)
-	ui := state.Get("ui").(packer.Ui)
+	ui, driver, err := getUiAndDriver(state)
+	if err != nil {
+		return commonsteps.HaltOnError(state, ui, err)
+	}
 
 	errorMsg := "Error configuring ip address: %s"
-	vmName := state.Get("vmName").(string)
+	vmName, err := getVMName(state)
+	if err != nil {
+		return commonsteps.HaltOnError(state, ui, err)
+	}
 
 	ui.Say("Configuring ip address...")
 
@@ -66,7 +71,7 @@ func (s *StepConfigureIp) Run(ctx context.Context, state multistep.StateBag) mul
 
 	ui.Say("hostname is " + hostName)
 
-	state.Put("ip", ip)
+	multistep.PutString(state, "ip", ip)
 	state.Put("hostname", hostName)
 
 	return multistep.ActionContinue
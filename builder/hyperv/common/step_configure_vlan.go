@@ -4,8 +4,8 @@ import (
 	"context"
 	"fmt"
 
-	"github.com/hashicorp/packer/packer"
 	"github.com/hashicorp/packer/packer-plugin-sdk/multistep"
+	"github.com/hashicorp/packer/packer-plugin-sdk/multistep/commonsteps"
 )
 
 type StepConfigureVlan struct {
@@ -14,12 +14,20 @@ type StepConfigureVlan struct {
 }
 
 func (s *StepConfigureVlan) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
-	driver := state.Get("driver").(Driver)
-	ui := state.Get("ui").(packer.Ui)
+	ui, driver, err := getUiAndDriver(state)
+	if err != nil {
+		return commonsteps.HaltOnError(state, ui, err)
+	}
 
 	errorMsg := "Error configuring vlan: %s"
-	vmName := state.Get("vmName").(string)
-	switchName := state.Get("SwitchName").(string)
+	vmName, err := getVMName(state)
+	if err != nil {
+		return commonsteps.HaltOnError(state, ui, err)
+	}
+	switchName, err := multistep.GetString(state, "SwitchName")
+	if err != nil {
+		return commonsteps.HaltOnError(state, ui, err)
+	}
 	vlanId := s.VlanId
 	switchVlanId := s.SwitchVlanId
 
@@ -0,0 +1,85 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/hashicorp/packer/helper/communicator"
+	"github.com/hashicorp/packer/packer-plugin-sdk/multistep"
+	"github.com/hashicorp/packer/packer-plugin-sdk/multistep/commonsteps"
+)
+
+// This step waits until the guest accepts PowerShell Direct sessions and sets
+// up a PowerShellDirectCommunicator for it. It authenticates with the WinRM
+// username and password and waits up to the WinRM timeout.
+//
+// Uses:
+//   driver Driver
+//   ui     packer.Ui
+//   vmName string
+//
+// Produces:
+//   communicator packer.Communicator
+type StepConnectPowerShellDirect struct {
+	Config *communicator.Config
+
+	// How long to wait between attempts to connect. Defaults to five
+	// seconds.
+	RetryInterval time.Duration
+}
+
+func (s *StepConnectPowerShellDirect) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	ui, driver, err := getUiAndDriver(state)
+	if err != nil {
+		return commonsteps.HaltOnError(state, ui, err)
+	}
+	vmName, err := getVMName(state)
+	if err != nil {
+		return commonsteps.HaltOnError(state, ui, err)
+	}
+
+	retryInterval := s.RetryInterval
+	if retryInterval == 0 {
+		retryInterval = 5 * time.Second
+	}
+
+	comm := &PowerShellDirectCommunicator{
+		Driver:   driver,
+		VMName:   vmName,
+		Username: s.Config.WinRMUser,
+		Password: s.Config.WinRMPassword,
+	}
+
+	ui.Say("Waiting for PowerShell Direct to become available...")
+	timeout := time.After(s.Config.WinRMTimeout)
+	for {
+		exitStatus, err := driver.InvokeVirtualMachineCommand(vmName, comm.Username, comm.Password,
+			"exit 0", nil, nil)
+		if err == nil && exitStatus == 0 {
+			break
+		}
+		log.Printf("PowerShell Direct isn't available yet (exit status %d): %v", exitStatus, err)
+
+		select {
+		case <-timeout:
+			err := errors.New("Timeout waiting for PowerShell Direct.")
+			state.Put("error", err)
+			ui.Error(err.Error())
+			return multistep.ActionHalt
+		case <-ctx.Done():
+			err := errors.New("Interrupted while waiting for PowerShell Direct.")
+			state.Put("error", err)
+			ui.Error(err.Error())
+			return multistep.ActionHalt
+		case <-time.After(retryInterval):
+		}
+	}
+
+	ui.Say("Connected to the VM over PowerShell Direct!")
+	state.Put("communicator", comm)
+	return multistep.ActionContinue
+}
+
+func (s *StepConnectPowerShellDirect) Cleanup(state multistep.StateBag) {}
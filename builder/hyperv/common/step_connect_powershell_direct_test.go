@@ -0,0 +1,78 @@
+package common
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/packer/helper/communicator"
+	"github.com/hashicorp/packer/packer-plugin-sdk/multistep"
+)
+
+func testStepConnectPowerShellDirect() *StepConnectPowerShellDirect {
+	return &StepConnectPowerShellDirect{
+		Config: &communicator.Config{
+			WinRM: communicator.WinRM{
+				WinRMUser:     "Administrator",
+				WinRMPassword: "packer",
+				WinRMTimeout:  50 * time.Millisecond,
+			},
+		},
+		RetryInterval: time.Millisecond,
+	}
+}
+
+func TestStepConnectPowerShellDirect_impl(t *testing.T) {
+	var _ multistep.Step = new(StepConnectPowerShellDirect)
+}
+
+func TestStepConnectPowerShellDirect(t *testing.T) {
+	state := testState(t)
+	state.Put("vmName", "foo")
+	step := testStepConnectPowerShellDirect()
+
+	driver := state.Get("driver").(*DriverMock)
+
+	// Test the run
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("Bad action: %v", action)
+	}
+	if _, ok := state.GetOk("error"); ok {
+		t.Fatal("Should NOT have error")
+	}
+
+	if !driver.InvokeVirtualMachineCommand_Called {
+		t.Fatal("Should have called InvokeVirtualMachineCommand")
+	}
+	if driver.InvokeVirtualMachineCommand_Username != "Administrator" {
+		t.Fatalf("bad username: %s", driver.InvokeVirtualMachineCommand_Username)
+	}
+
+	comm, ok := state.Get("communicator").(*PowerShellDirectCommunicator)
+	if !ok {
+		t.Fatal("Should have put the communicator")
+	}
+	if comm.VMName != "foo" || comm.Password != "packer" {
+		t.Fatalf("bad communicator: %#v", comm)
+	}
+}
+
+func TestStepConnectPowerShellDirect_timeout(t *testing.T) {
+	state := testState(t)
+	state.Put("vmName", "foo")
+	step := testStepConnectPowerShellDirect()
+
+	driver := state.Get("driver").(*DriverMock)
+	driver.InvokeVirtualMachineCommand_Return = 1
+
+	// Test the run
+	if action := step.Run(context.Background(), state); action != multistep.ActionHalt {
+		t.Fatalf("Bad action: %v", action)
+	}
+	if _, ok := state.GetOk("error"); !ok {
+		t.Fatal("Should have error")
+	}
+	if _, ok := state.GetOk("communicator"); ok {
+		t.Fatal("Should NOT have put the communicator")
+	}
+}
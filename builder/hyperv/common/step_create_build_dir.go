@@ -7,8 +7,8 @@ import (
 	"log"
 	"os"
 
-	"github.com/hashicorp/packer/packer"
 	"github.com/hashicorp/packer/packer-plugin-sdk/multistep"
+	"github.com/hashicorp/packer/packer-plugin-sdk/multistep/commonsteps"
 	"github.com/hashicorp/packer/packer-plugin-sdk/tmp"
 )
 
@@ -18,6 +18,9 @@ type StepCreateBuildDir struct {
 	// folders during the build. If unspecified the default temp directory
 	// for the OS is used
 	TempPath string
+	// Create the build directory on the remote Hyper-V host instead of this
+	// machine. TempPath is a path on the remote host then
+	Remote bool
 	// The full path to the build directory. This is the concatenation of
 	// TempPath plus a directory uniquely named for the build
 	buildDir string
@@ -26,12 +29,19 @@ type StepCreateBuildDir struct {
 // Creates the main directory used to house the VMs files and folders
 // during the build
 func (s *StepCreateBuildDir) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
-	ui := state.Get("ui").(packer.Ui)
+	ui, err := commonsteps.GetUi(state)
+	if err != nil {
+		return commonsteps.HaltOnError(state, nil, err)
+	}
 
 	ui.Say("Creating build directory...")
 
-	var err error
-	if s.TempPath == "" {
+	if s.Remote {
+		var driver Driver
+		if driver, err = getDriver(state); err == nil {
+			s.buildDir, err = driver.CreateHostTempDirectory(s.TempPath, "hyperv")
+		}
+	} else if s.TempPath == "" {
 		s.buildDir, err = tmp.Dir("hyperv")
 	} else {
 		s.buildDir, err = ioutil.TempDir(s.TempPath, "hyperv")
@@ -47,21 +57,41 @@ func (s *StepCreateBuildDir) Run(ctx context.Context, state multistep.StateBag)
 	log.Printf("Created build directory: %s", s.buildDir)
 
 	// Record the build directory location for later steps
-	state.Put("build_dir", s.buildDir)
+	multistep.PutString(state, "build_dir", s.buildDir)
 
 	return multistep.ActionContinue
 }
 
 // Cleanup removes the build directory
+func (s *StepCreateBuildDir) Save(state multistep.StateBag) map[string]interface{} {
+	return multistep.SaveKeys(state, "build_dir")
+}
+
+func (s *StepCreateBuildDir) Resume(ctx context.Context, state multistep.StateBag, saved map[string]interface{}) multistep.StepAction {
+	multistep.RestoreKeys(state, saved)
+	s.buildDir = saved["build_dir"].(string)
+	return multistep.ActionContinue
+}
+
 func (s *StepCreateBuildDir) Cleanup(state multistep.StateBag) {
 	if s.buildDir == "" {
 		return
 	}
 
-	ui := state.Get("ui").(packer.Ui)
+	ui, err := commonsteps.GetUi(state)
+	if commonsteps.SkipCleanupOnError(err) {
+		return
+	}
 	ui.Say("Deleting build directory...")
 
-	err := os.RemoveAll(s.buildDir)
+	if s.Remote {
+		var driver Driver
+		if driver, err = getDriver(state); err == nil {
+			err = driver.RemoveHostDirectory(s.buildDir)
+		}
+	} else {
+		err = os.RemoveAll(s.buildDir)
+	}
 	if err != nil {
 		ui.Error(fmt.Sprintf("Error deleting build directory: %s", err))
 	}
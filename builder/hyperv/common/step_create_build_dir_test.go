@@ -111,3 +111,34 @@ func TestStepCreateBuildDir_BadTempPath(t *testing.T) {
 		t.Fatal("Should have error due to bad path")
 	}
 }
+
+func TestStepCreateBuildDir_Remote(t *testing.T) {
+	state := testState(t)
+	step := &StepCreateBuildDir{
+		TempPath: `D:\Builds`,
+		Remote:   true,
+	}
+
+	driver := state.Get("driver").(*DriverMock)
+	driver.CreateHostTempDirectory_Return = `D:\Builds\hyperv12345`
+
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("Bad action: %v", action)
+	}
+	if _, ok := state.GetOk("error"); ok {
+		t.Fatal("Should NOT have error")
+	}
+
+	if driver.CreateHostTempDirectory_Parent != `D:\Builds` || driver.CreateHostTempDirectory_Prefix != "hyperv" {
+		t.Fatalf("bad parent or prefix: %s %s", driver.CreateHostTempDirectory_Parent,
+			driver.CreateHostTempDirectory_Prefix)
+	}
+	if v := state.Get("build_dir").(string); v != `D:\Builds\hyperv12345` {
+		t.Fatalf("bad build_dir: %s", v)
+	}
+
+	step.Cleanup(state)
+	if driver.RemoveHostDirectory_Path != `D:\Builds\hyperv12345` {
+		t.Fatalf("should remove the remote build directory: %s", driver.RemoveHostDirectory_Path)
+	}
+}
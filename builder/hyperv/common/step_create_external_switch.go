@@ -4,8 +4,8 @@ import (
 	"context"
 	"fmt"
 
-	"github.com/hashicorp/packer/packer"
 	"github.com/hashicorp/packer/packer-plugin-sdk/multistep"
+	"github.com/hashicorp/packer/packer-plugin-sdk/multistep/commonsteps"
 	"github.com/hashicorp/packer/packer-plugin-sdk/uuid"
 )
 
@@ -22,12 +22,16 @@ type StepCreateExternalSwitch struct {
 // the connectivity of the host machine, the external switch will allow the
 // build VM to connect to the outside world.
 func (s *StepCreateExternalSwitch) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
-	driver := state.Get("driver").(Driver)
-	ui := state.Get("ui").(packer.Ui)
+	ui, driver, err := getUiAndDriver(state)
+	if err != nil {
+		return commonsteps.HaltOnError(state, ui, err)
+	}
 
-	vmName := state.Get("vmName").(string)
+	vmName, err := getVMName(state)
+	if err != nil {
+		return commonsteps.HaltOnError(state, ui, err)
+	}
 	errorMsg := "Error creating external switch: %s"
-	var err error
 
 	ui.Say("Creating external switch...")
 
@@ -65,11 +69,14 @@ func (s *StepCreateExternalSwitch) Run(ctx context.Context, state multistep.Stat
 		s.SwitchName = ""
 	} else {
 		s.SwitchName = packerExternalSwitchName
-		s.oldSwitchName = state.Get("SwitchName").(string)
+		s.oldSwitchName, err = multistep.GetString(state, "SwitchName")
+		if err != nil {
+			return commonsteps.HaltOnError(state, ui, err)
+		}
 	}
 
 	// Set the final name in the state bag so others can use it
-	state.Put("SwitchName", switchName)
+	multistep.PutString(state, "SwitchName", switchName)
 
 	return multistep.ActionContinue
 }
@@ -78,9 +85,14 @@ func (s *StepCreateExternalSwitch) Cleanup(state multistep.StateBag) {
 	if s.SwitchName == "" {
 		return
 	}
-	driver := state.Get("driver").(Driver)
-	ui := state.Get("ui").(packer.Ui)
-	vmName := state.Get("vmName").(string)
+	ui, driver, err := getUiAndDriver(state)
+	if commonsteps.SkipCleanupOnError(err) {
+		return
+	}
+	vmName, err := getVMName(state)
+	if commonsteps.SkipCleanupOnError(err) {
+		return
+	}
 
 	ui.Say("Unregistering and deleting external switch...")
 
@@ -92,13 +104,13 @@ func (s *StepCreateExternalSwitch) Cleanup(state multistep.StateBag) {
 		return
 	}
 
-	err := driver.ConnectVirtualMachineNetworkAdapterToSwitch(vmName, s.oldSwitchName)
+	err = driver.ConnectVirtualMachineNetworkAdapterToSwitch(vmName, s.oldSwitchName)
 	if err != nil {
 		ui.Error(fmt.Sprintf(errMsg, err))
 		return
 	}
 
-	state.Put("SwitchName", s.oldSwitchName)
+	multistep.PutString(state, "SwitchName", s.oldSwitchName)
 
 	err = driver.DeleteVirtualSwitch(s.SwitchName)
 	if err != nil {
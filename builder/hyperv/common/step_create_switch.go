@@ -6,15 +6,20 @@ import (
 
 	"github.com/hashicorp/packer/packer"
 	"github.com/hashicorp/packer/packer-plugin-sdk/multistep"
+	"github.com/hashicorp/packer/packer-plugin-sdk/multistep/commonsteps"
 )
 
 const (
 	SwitchTypeInternal = "Internal"
 	SwitchTypePrivate  = "Private"
+	SwitchTypeExternal = "External"
 	DefaultSwitchType  = SwitchTypeInternal
 )
 
-// This step creates switch for VM.
+// This step creates switch for VM. A switch that already exists is used as
+// is. A switch created by this step, and its NAT if EnableNat is true, is
+// deleted again on cleanup. If SkipCreation is true, the switch must already
+// exist.
 //
 // Produces:
 //   SwitchName string - The name of the Switch
@@ -29,21 +34,48 @@ type StepCreateSwitch struct {
 	NetAdapterName string
 	// Specifies the interface description of the network adapter to be bound to the switch to be created.
 	NetAdapterInterfaceDescription string
+	// If true, the switch isn't created when it doesn't exist, and the step fails instead.
+	SkipCreation bool
+	// If true, the host gets the first address of NatPrefix on an Internal switch created by this step, and NAT is
+	// set up for the NatPrefix network.
+	EnableNat bool
+	// The IPv4 network, in CIDR notation, to set up NAT for.
+	NatPrefix string
 
 	createdSwitch bool
+	natName       string
 }
 
 func (s *StepCreateSwitch) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
-	driver := state.Get("driver").(Driver)
-	ui := state.Get("ui").(packer.Ui)
+	ui, driver, err := getUiAndDriver(state)
+	if err != nil {
+		return commonsteps.HaltOnError(state, ui, err)
+	}
 
 	if len(s.SwitchType) == 0 {
 		s.SwitchType = DefaultSwitchType
 	}
 
+	if s.SkipCreation {
+		exists, err := driver.VirtualSwitchExists(s.SwitchName)
+		if err == nil && !exists {
+			err = fmt.Errorf("switch '%v' doesn't exist and create_switch is false", s.SwitchName)
+		}
+		if err != nil {
+			err := fmt.Errorf("Error looking up switch: %s", err)
+			state.Put("error", err)
+			ui.Error(err.Error())
+			s.SwitchName = ""
+			return multistep.ActionHalt
+		}
+
+		multistep.PutString(state, "SwitchName", s.SwitchName)
+		return multistep.ActionContinue
+	}
+
 	ui.Say(fmt.Sprintf("Creating switch '%v' if required...", s.SwitchName))
 
-	createdSwitch, err := driver.CreateVirtualSwitch(s.SwitchName, s.SwitchType)
+	createdSwitch, err := driver.CreateVirtualSwitch(s.SwitchName, s.SwitchType, s.NetAdapterName)
 	if err != nil {
 		err := fmt.Errorf("Error creating switch: %s", err)
 		state.Put("error", err)
@@ -53,28 +85,72 @@ func (s *StepCreateSwitch) Run(ctx context.Context, state multistep.StateBag) mu
 	}
 
 	s.createdSwitch = createdSwitch
+	if s.createdSwitch {
+		packer.RecordResource(switchResource(s.SwitchName))
+	}
 
 	if !s.createdSwitch {
 		ui.Say(fmt.Sprintf("    switch '%v' already exists. Will not delete on cleanup...", s.SwitchName))
+		if s.EnableNat {
+			ui.Say("    NAT is only set up for switches created by Packer. Skipping...")
+		}
+	} else if s.EnableNat {
+		ui.Say(fmt.Sprintf("Setting up NAT for %s on switch '%v'...", s.NatPrefix, s.SwitchName))
+
+		// Set the name before enabling NAT, so a partial setup is removed on cleanup
+		s.natName = s.SwitchName + "-nat"
+		err := driver.EnableVirtualSwitchNat(s.SwitchName, s.natName, s.NatPrefix)
+		if err != nil {
+			err := fmt.Errorf("Error setting up NAT for switch: %s", err)
+			state.Put("error", err)
+			ui.Error(err.Error())
+			return multistep.ActionHalt
+		}
 	}
 
 	// Set the final name in the state bag so others can use it
-	state.Put("SwitchName", s.SwitchName)
+	multistep.PutString(state, "SwitchName", s.SwitchName)
 
 	return multistep.ActionContinue
 }
 
+func (s *StepCreateSwitch) Save(state multistep.StateBag) map[string]interface{} {
+	saved := multistep.SaveKeys(state, "SwitchName")
+	saved["created"] = s.createdSwitch
+	saved["nat"] = s.natName
+	return saved
+}
+
+func (s *StepCreateSwitch) Resume(ctx context.Context, state multistep.StateBag, saved map[string]interface{}) multistep.StepAction {
+	multistep.RestoreKeys(state, map[string]interface{}{"SwitchName": saved["SwitchName"]})
+	s.SwitchName = saved["SwitchName"].(string)
+	s.createdSwitch = saved["created"].(bool)
+	s.natName = saved["nat"].(string)
+	return multistep.ActionContinue
+}
+
 func (s *StepCreateSwitch) Cleanup(state multistep.StateBag) {
 	if len(s.SwitchName) == 0 || !s.createdSwitch {
 		return
 	}
 
-	driver := state.Get("driver").(Driver)
-	ui := state.Get("ui").(packer.Ui)
+	ui, driver, err := getUiAndDriver(state)
+	if commonsteps.SkipCleanupOnError(err) {
+		return
+	}
+	if s.natName != "" {
+		ui.Say("Removing switch NAT...")
+		if err := driver.DisableVirtualSwitchNat(s.natName); err != nil {
+			ui.Error(fmt.Sprintf("Error removing switch NAT: %s", err))
+		}
+	}
+
 	ui.Say("Unregistering and deleting switch...")
 
-	err := driver.DeleteVirtualSwitch(s.SwitchName)
+	err = driver.DeleteVirtualSwitch(s.SwitchName)
 	if err != nil {
 		ui.Error(fmt.Sprintf("Error deleting switch: %s", err))
+		return
 	}
+	packer.ForgetResource(switchResource(s.SwitchName))
 }
@@ -0,0 +1,203 @@
+package common
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/packer/packer-plugin-sdk/multistep"
+)
+
+func TestStepCreateSwitch_impl(t *testing.T) {
+	var _ multistep.Step = new(StepCreateSwitch)
+}
+
+func TestStepCreateSwitch(t *testing.T) {
+	state := testState(t)
+	step := &StepCreateSwitch{
+		SwitchName:     "packer-switch",
+		SwitchType:     SwitchTypeExternal,
+		NetAdapterName: "Ethernet",
+	}
+
+	driver := state.Get("driver").(*DriverMock)
+	driver.CreateVirtualSwitch_Return = true
+
+	// Test the run
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("Bad action: %v", action)
+	}
+	if _, ok := state.GetOk("error"); ok {
+		t.Fatal("Should NOT have error")
+	}
+
+	if driver.CreateVirtualSwitch_SwitchType != SwitchTypeExternal {
+		t.Fatalf("bad switch type: %s", driver.CreateVirtualSwitch_SwitchType)
+	}
+	if driver.CreateVirtualSwitch_NetAdapterName != "Ethernet" {
+		t.Fatalf("bad net adapter: %s", driver.CreateVirtualSwitch_NetAdapterName)
+	}
+	if driver.EnableVirtualSwitchNat_Called {
+		t.Fatal("Should NOT have called EnableVirtualSwitchNat")
+	}
+	if name := state.Get("SwitchName").(string); name != "packer-switch" {
+		t.Fatalf("bad switch name: %s", name)
+	}
+
+	// Test the cleanup
+	step.Cleanup(state)
+	if driver.DeleteVirtualSwitch_SwitchName != "packer-switch" {
+		t.Fatal("Should have deleted the switch")
+	}
+	if driver.DisableVirtualSwitchNat_Called {
+		t.Fatal("Should NOT have called DisableVirtualSwitchNat")
+	}
+}
+
+func TestStepCreateSwitch_existing(t *testing.T) {
+	state := testState(t)
+	step := &StepCreateSwitch{
+		SwitchName: "packer-switch",
+		EnableNat:  true,
+		NatPrefix:  DefaultSwitchNatPrefix,
+	}
+
+	driver := state.Get("driver").(*DriverMock)
+
+	// Test the run
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("Bad action: %v", action)
+	}
+	if driver.EnableVirtualSwitchNat_Called {
+		t.Fatal("Should NOT have set up NAT for an existing switch")
+	}
+
+	// Test the cleanup
+	step.Cleanup(state)
+	if driver.DeleteVirtualSwitch_Called {
+		t.Fatal("Should NOT have deleted an existing switch")
+	}
+}
+
+func TestStepCreateSwitch_nat(t *testing.T) {
+	state := testState(t)
+	step := &StepCreateSwitch{
+		SwitchName: "packer-switch",
+		EnableNat:  true,
+		NatPrefix:  DefaultSwitchNatPrefix,
+	}
+
+	driver := state.Get("driver").(*DriverMock)
+	driver.CreateVirtualSwitch_Return = true
+
+	// Test the run
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("Bad action: %v", action)
+	}
+	if driver.CreateVirtualSwitch_SwitchType != DefaultSwitchType {
+		t.Fatalf("bad switch type: %s", driver.CreateVirtualSwitch_SwitchType)
+	}
+	if driver.EnableVirtualSwitchNat_SwitchName != "packer-switch" {
+		t.Fatalf("bad switch name: %s", driver.EnableVirtualSwitchNat_SwitchName)
+	}
+	if driver.EnableVirtualSwitchNat_Prefix != DefaultSwitchNatPrefix {
+		t.Fatalf("bad prefix: %s", driver.EnableVirtualSwitchNat_Prefix)
+	}
+
+	// Test the cleanup
+	step.Cleanup(state)
+	if driver.DisableVirtualSwitchNat_NatName != driver.EnableVirtualSwitchNat_NatName {
+		t.Fatalf("Should have removed the NAT, got: %s", driver.DisableVirtualSwitchNat_NatName)
+	}
+	if !driver.DeleteVirtualSwitch_Called {
+		t.Fatal("Should have deleted the switch")
+	}
+}
+
+func TestStepCreateSwitch_skipCreation(t *testing.T) {
+	state := testState(t)
+	step := &StepCreateSwitch{
+		SwitchName:   "packer-switch",
+		SkipCreation: true,
+	}
+
+	driver := state.Get("driver").(*DriverMock)
+
+	// Test a missing switch
+	if action := step.Run(context.Background(), state); action != multistep.ActionHalt {
+		t.Fatalf("Bad action: %v", action)
+	}
+	if _, ok := state.GetOk("error"); !ok {
+		t.Fatal("Should have error")
+	}
+	if driver.CreateVirtualSwitch_Called {
+		t.Fatal("Should NOT have called CreateVirtualSwitch")
+	}
+
+	// Test an existing switch
+	state = testState(t)
+	step = &StepCreateSwitch{
+		SwitchName:   "packer-switch",
+		SkipCreation: true,
+	}
+	driver = state.Get("driver").(*DriverMock)
+	driver.VirtualSwitchExists_Return = true
+
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("Bad action: %v", action)
+	}
+	if driver.VirtualSwitchExists_SwitchName != "packer-switch" {
+		t.Fatalf("bad switch name: %s", driver.VirtualSwitchExists_SwitchName)
+	}
+	if driver.CreateVirtualSwitch_Called {
+		t.Fatal("Should NOT have called CreateVirtualSwitch")
+	}
+
+	step.Cleanup(state)
+	if driver.DeleteVirtualSwitch_Called {
+		t.Fatal("Should NOT have deleted an existing switch")
+	}
+}
+
+func TestStepCreateSwitch_resume(t *testing.T) {
+	state := testState(t)
+	step := &StepCreateSwitch{
+		SwitchName: "packer-switch",
+		EnableNat:  true,
+		NatPrefix:  "192.168.0.0/24",
+	}
+
+	driver := state.Get("driver").(*DriverMock)
+	driver.CreateVirtualSwitch_Return = true
+
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("Bad action: %v", action)
+	}
+	saved := step.Save(state)
+
+	// Resume the step in a new build
+	state = testState(t)
+	driver = state.Get("driver").(*DriverMock)
+	step = &StepCreateSwitch{
+		SwitchName: "packer-switch",
+		EnableNat:  true,
+		NatPrefix:  "192.168.0.0/24",
+	}
+	if action := step.Resume(context.Background(), state, saved); action != multistep.ActionContinue {
+		t.Fatalf("Bad action: %v", action)
+	}
+	if driver.CreateVirtualSwitch_Called {
+		t.Fatal("Should NOT have created the switch again")
+	}
+	if name := state.Get("SwitchName").(string); name != "packer-switch" {
+		t.Fatalf("bad switch name: %s", name)
+	}
+
+	// The switch created by the build that was resumed is removed
+	step.Cleanup(state)
+	if driver.DisableVirtualSwitchNat_NatName != "packer-switch-nat" {
+		t.Fatal("Should have removed the NAT")
+	}
+	if driver.DeleteVirtualSwitch_SwitchName != "packer-switch" {
+		t.Fatal("Should have deleted the switch")
+	}
+}
@@ -9,6 +9,7 @@ import (
 
 	"github.com/hashicorp/packer/packer"
 	"github.com/hashicorp/packer/packer-plugin-sdk/multistep"
+	"github.com/hashicorp/packer/packer-plugin-sdk/multistep/commonsteps"
 )
 
 // This step creates the actual virtual machine.
@@ -25,13 +26,22 @@ type StepCreateVM struct {
 	UseLegacyNetworkAdapter        bool
 	Generation                     uint
 	Cpu                            uint
+	CpusPerSocket                  uint
+	NumaNodes                      uint
 	EnableMacSpoofing              bool
 	EnableDynamicMemory            bool
+	DynamicMemoryMinimum           uint
+	DynamicMemoryMaximum           uint
+	MemoryBufferPercent            uint
 	EnableSecureBoot               bool
 	SecureBootTemplate             string
+	EnableVirtualTPM               bool
 	EnableVirtualizationExtensions bool
+	ExposeProcessorFeatures        bool
 	AdditionalDiskSize             []uint
+	AdditionalDisks                []AdditionalDisk
 	DifferencingDisk               bool
+	DifferencingDiskParentPath     string
 	MacAddress                     string
 	FixedVHD                       bool
 	Version                        string
@@ -39,8 +49,10 @@ type StepCreateVM struct {
 }
 
 func (s *StepCreateVM) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
-	driver := state.Get("driver").(Driver)
-	ui := state.Get("ui").(packer.Ui)
+	ui, driver, err := getUiAndDriver(state)
+	if err != nil {
+		return commonsteps.HaltOnError(state, ui, err)
+	}
 	ui.Say("Creating virtual machine...")
 
 	var path string
@@ -48,7 +60,7 @@ func (s *StepCreateVM) Run(ctx context.Context, state multistep.StateBag) multis
 		path = v.(string)
 	}
 
-	err := driver.CheckVMName(s.VMName)
+	err = driver.CheckVMName(s.VMName)
 	if err != nil {
 		s.KeepRegistered = true
 		state.Put("error", err)
@@ -58,7 +70,9 @@ func (s *StepCreateVM) Run(ctx context.Context, state multistep.StateBag) multis
 
 	// Determine if we even have an existing virtual harddrive to attach
 	harddrivePath := ""
-	if harddrivePathRaw, ok := state.GetOk("iso_path"); ok {
+	if s.DifferencingDiskParentPath != "" {
+		harddrivePath = s.DifferencingDiskParentPath
+	} else if harddrivePathRaw, ok := state.GetOk("iso_path"); ok {
 		extension := strings.ToLower(filepath.Ext(harddrivePathRaw.(string)))
 		if extension == ".vhd" || extension == ".vhdx" {
 			harddrivePath = harddrivePathRaw.(string)
@@ -82,6 +96,7 @@ func (s *StepCreateVM) Run(ctx context.Context, state multistep.StateBag) multis
 		ui.Error(err.Error())
 		return multistep.ActionHalt
 	}
+	packer.RecordResource(vmResource(s.VMName))
 
 	if s.UseLegacyNetworkAdapter {
 		err := driver.ReplaceVirtualMachineNetworkAdapter(s.VMName, true)
@@ -101,6 +116,19 @@ func (s *StepCreateVM) Run(ctx context.Context, state multistep.StateBag) multis
 		return multistep.ActionHalt
 	}
 
+	if s.CpusPerSocket != 0 || s.NumaNodes != 0 {
+		cpusPerNumaNode, numaNodesPerSocket, memoryPerNumaNode := cpuTopology(s.Cpu, s.CpusPerSocket,
+			s.NumaNodes, s.RamSize)
+		err = driver.SetVirtualMachineCpuTopology(s.VMName, cpusPerNumaNode, numaNodesPerSocket,
+			int64(memoryPerNumaNode)*1024*1024)
+		if err != nil {
+			err := fmt.Errorf("Error setting virtual machine cpu topology: %s", err)
+			state.Put("error", err)
+			ui.Error(err.Error())
+			return multistep.ActionHalt
+		}
+	}
+
 	err = driver.SetVirtualMachineDynamicMemory(s.VMName, s.EnableDynamicMemory)
 	if err != nil {
 		err := fmt.Errorf("Error setting virtual machine dynamic memory: %s", err)
@@ -109,6 +137,18 @@ func (s *StepCreateVM) Run(ctx context.Context, state multistep.StateBag) multis
 		return multistep.ActionHalt
 	}
 
+	if s.EnableDynamicMemory && (s.DynamicMemoryMinimum != 0 || s.DynamicMemoryMaximum != 0 ||
+		s.MemoryBufferPercent != 0) {
+		err = driver.SetVirtualMachineDynamicMemorySettings(s.VMName, int64(s.DynamicMemoryMinimum)*1024*1024,
+			int64(s.DynamicMemoryMaximum)*1024*1024, s.MemoryBufferPercent)
+		if err != nil {
+			err := fmt.Errorf("Error setting virtual machine dynamic memory settings: %s", err)
+			state.Put("error", err)
+			ui.Error(err.Error())
+			return multistep.ActionHalt
+		}
+	}
+
 	if s.EnableMacSpoofing {
 		err = driver.SetVirtualMachineMacSpoofing(s.VMName, s.EnableMacSpoofing)
 		if err != nil {
@@ -127,6 +167,16 @@ func (s *StepCreateVM) Run(ctx context.Context, state multistep.StateBag) multis
 			ui.Error(err.Error())
 			return multistep.ActionHalt
 		}
+
+		if s.EnableVirtualTPM {
+			err = driver.EnableVirtualMachineTPM(s.VMName)
+			if err != nil {
+				err := fmt.Errorf("Error enabling virtual TPM: %s", err)
+				state.Put("error", err)
+				ui.Error(err.Error())
+				return multistep.ActionHalt
+			}
+		}
 	}
 
 	if s.EnableVirtualizationExtensions {
@@ -140,17 +190,23 @@ func (s *StepCreateVM) Run(ctx context.Context, state multistep.StateBag) multis
 		}
 	}
 
-	if len(s.AdditionalDiskSize) > 0 {
-		for index, size := range s.AdditionalDiskSize {
-			diskSize := int64(size * 1024 * 1024)
-			diskFile := fmt.Sprintf("%s-%d.vhdx", s.VMName, index)
-			err = driver.AddVirtualMachineHardDrive(s.VMName, path, diskFile, diskSize, diskBlockSize, "SCSI")
-			if err != nil {
-				err := fmt.Errorf("Error creating and attaching additional disk drive: %s", err)
-				state.Put("error", err)
-				ui.Error(err.Error())
-				return multistep.ActionHalt
-			}
+	if s.ExposeProcessorFeatures {
+		err = driver.SetVirtualMachineProcessorCompatibility(s.VMName, false)
+		if err != nil {
+			err := fmt.Errorf("Error setting virtual machine processor compatibility: %s", err)
+			state.Put("error", err)
+			ui.Error(err.Error())
+			return multistep.ActionHalt
+		}
+	}
+
+	if len(s.AdditionalDiskSize) > 0 || len(s.AdditionalDisks) > 0 {
+		err = addAdditionalDisks(driver, s.VMName, path, s.AdditionalDiskSize, s.DiskBlockSize, s.AdditionalDisks)
+		if err != nil {
+			err := fmt.Errorf("Error creating and attaching additional disk drive: %s", err)
+			state.Put("error", err)
+			ui.Error(err.Error())
+			return multistep.ActionHalt
 		}
 	}
 
@@ -165,7 +221,7 @@ func (s *StepCreateVM) Run(ctx context.Context, state multistep.StateBag) multis
 	}
 
 	// Set the final name in the state bag so others can use it
-	state.Put("vmName", s.VMName)
+	multistep.PutString(state, "vmName", s.VMName)
 	// instance_id is the generic term used so that users can have access to the
 	// instance id inside of the provisioners, used in step_provision.
 	state.Put("instance_id", s.VMName)
@@ -173,25 +229,59 @@ func (s *StepCreateVM) Run(ctx context.Context, state multistep.StateBag) multis
 	return multistep.ActionContinue
 }
 
+// cpuTopology splits cpu CPUs into sockets of cpusPerSocket CPUs and
+// numaNodes NUMA nodes of the same size, as validated by CommonConfig, and
+// returns the number of CPUs of each NUMA node, the number of NUMA nodes of
+// each socket and the memory of each NUMA node in MB. Hyper-V needs the
+// memory to be a multiple of 2 MB.
+func cpuTopology(cpu uint, cpusPerSocket uint, numaNodes uint, ramSize uint) (uint, uint, uint) {
+	if cpusPerSocket == 0 {
+		cpusPerSocket = cpu
+	}
+	sockets := cpu / cpusPerSocket
+	if numaNodes == 0 {
+		numaNodes = sockets
+	}
+
+	memoryPerNumaNode := (ramSize + numaNodes - 1) / numaNodes
+	memoryPerNumaNode += memoryPerNumaNode % 2
+
+	return cpu / numaNodes, numaNodes / sockets, memoryPerNumaNode
+}
+
+func (s *StepCreateVM) Save(state multistep.StateBag) map[string]interface{} {
+	return multistep.SaveKeys(state, "vmName", "instance_id")
+}
+
+func (s *StepCreateVM) Resume(ctx context.Context, state multistep.StateBag, saved map[string]interface{}) multistep.StepAction {
+	multistep.RestoreKeys(state, saved)
+	return multistep.ActionContinue
+}
+
 func (s *StepCreateVM) Cleanup(state multistep.StateBag) {
 	if s.VMName == "" {
 		return
 	}
 
-	driver := state.Get("driver").(Driver)
-	ui := state.Get("ui").(packer.Ui)
+	ui, driver, err := getUiAndDriver(state)
+	if commonsteps.SkipCleanupOnError(err) {
+		return
+	}
 
 	if s.KeepRegistered {
 		ui.Say("keep_registered set. Skipping unregister/deletion of VM.")
+		packer.ForgetResource(vmResource(s.VMName))
 		return
 	}
 
 	ui.Say("Unregistering and deleting virtual machine...")
 
-	err := driver.DeleteVirtualMachine(s.VMName)
+	err = driver.DeleteVirtualMachine(s.VMName)
 	if err != nil {
 		ui.Error(fmt.Sprintf("Error deleting virtual machine: %s", err))
+		return
 	}
+	packer.ForgetResource(vmResource(s.VMName))
 
 	// TODO: Clean up created VHDX
 }
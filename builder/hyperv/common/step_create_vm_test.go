@@ -56,3 +56,224 @@ func TestStepCreateVM_CheckVMNameErr(t *testing.T) {
 		t.Fatal("Should have called CheckVMName")
 	}
 }
+
+func TestStepCreateVM_DifferencingDiskParentPath(t *testing.T) {
+	state := testState(t)
+	step := new(StepCreateVM)
+
+	step.VMName = "test-VM-Name"
+	step.DifferencingDisk = true
+	step.DifferencingDiskParentPath = "C:\\parent.vhdx"
+	state.Put("iso_path", "C:\\source.vhdx")
+	driver := state.Get("driver").(*DriverMock)
+
+	// Test the run
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("Bad action: %v", action)
+	}
+	if _, ok := state.GetOk("error"); ok {
+		t.Fatal("Should NOT have error")
+	}
+
+	// Test the driver
+	if driver.CreateVirtualMachine_HarddrivePath != step.DifferencingDiskParentPath {
+		t.Fatalf("Should create the VM on top of the parent disk. Got: %s Wanted: %s",
+			driver.CreateVirtualMachine_HarddrivePath, step.DifferencingDiskParentPath)
+	}
+	if !driver.CreateVirtualMachine_DifferentialDisk {
+		t.Fatal("Should create a differencing disk")
+	}
+}
+
+func TestStepCreateVM_AdditionalDisks(t *testing.T) {
+	state := testState(t)
+	step := new(StepCreateVM)
+
+	step.VMName = "test-VM-Name"
+	step.DiskBlockSize = 32
+	step.AdditionalDisks = []AdditionalDisk{
+		{Size: 2048, BlockSize: 1, ControllerType: ControllerTypeSCSI, Fixed: true},
+	}
+	driver := state.Get("driver").(*DriverMock)
+
+	// Test the run
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("Bad action: %v", action)
+	}
+	if _, ok := state.GetOk("error"); ok {
+		t.Fatal("Should NOT have error")
+	}
+
+	// Test the driver
+	if !driver.AddVirtualMachineHardDrive_Called {
+		t.Fatal("Should have called AddVirtualMachineHardDrive")
+	}
+	if driver.AddVirtualMachineHardDrive_VhdName != "test-VM-Name-0.vhdx" {
+		t.Fatalf("Bad disk name: %s", driver.AddVirtualMachineHardDrive_VhdName)
+	}
+	if driver.AddVirtualMachineHardDrive_VhdSizeBytes != 2048*1024*1024 {
+		t.Fatalf("Bad disk size: %d", driver.AddVirtualMachineHardDrive_VhdSizeBytes)
+	}
+	if driver.AddVirtualMachineHardDrive_VhdBlockSize != 1024*1024 {
+		t.Fatalf("Bad block size: %d", driver.AddVirtualMachineHardDrive_VhdBlockSize)
+	}
+	if driver.AddVirtualMachineHardDrive_ControllerType != ControllerTypeSCSI {
+		t.Fatalf("Bad controller type: %s", driver.AddVirtualMachineHardDrive_ControllerType)
+	}
+	if !driver.AddVirtualMachineHardDrive_FixedVHD {
+		t.Fatal("Should have created a fixed VHD")
+	}
+}
+
+func TestStepCreateVM_AdditionalDiskErr(t *testing.T) {
+	state := testState(t)
+	step := new(StepCreateVM)
+
+	step.VMName = "test-VM-Name"
+	step.AdditionalDiskSize = []uint{1024}
+	driver := state.Get("driver").(*DriverMock)
+	driver.AddVirtualMachineHardDrive_Err = fmt.Errorf("New-VHD failed")
+
+	// Test the run
+	if action := step.Run(context.Background(), state); action != multistep.ActionHalt {
+		t.Fatalf("Bad action: %v", action)
+	}
+	if _, ok := state.GetOk("error"); !ok {
+		t.Fatal("Should have error")
+	}
+}
+
+func TestStepCreateVM_ExposeProcessorFeatures(t *testing.T) {
+	state := testState(t)
+	step := new(StepCreateVM)
+
+	step.VMName = "test-VM-Name"
+	step.ExposeProcessorFeatures = true
+	driver := state.Get("driver").(*DriverMock)
+
+	// Test the run
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("Bad action: %v", action)
+	}
+	if _, ok := state.GetOk("error"); ok {
+		t.Fatal("Should NOT have error")
+	}
+
+	// Test the driver
+	if !driver.SetVirtualMachineProcessorCompatibility_Called {
+		t.Fatal("Should have called SetVirtualMachineProcessorCompatibility")
+	}
+	if driver.SetVirtualMachineProcessorCompatibility_Enable {
+		t.Fatal("Should have turned processor compatibility off")
+	}
+}
+
+func TestStepCreateVM_EnableVirtualTPM(t *testing.T) {
+	state := testState(t)
+	step := new(StepCreateVM)
+
+	step.VMName = "test-VM-Name"
+	step.Generation = 2
+	step.EnableVirtualTPM = true
+	driver := state.Get("driver").(*DriverMock)
+
+	// Test the run
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("Bad action: %v", action)
+	}
+	if _, ok := state.GetOk("error"); ok {
+		t.Fatal("Should NOT have error")
+	}
+
+	// Test the driver
+	if !driver.EnableVirtualMachineTPM_Called {
+		t.Fatal("Should have called EnableVirtualMachineTPM")
+	}
+	if driver.EnableVirtualMachineTPM_VmName != "test-VM-Name" {
+		t.Fatalf("Bad vm name: %s", driver.EnableVirtualMachineTPM_VmName)
+	}
+}
+
+func TestStepCreateVM_DynamicMemorySettings(t *testing.T) {
+	state := testState(t)
+	step := new(StepCreateVM)
+
+	step.VMName = "test-VM-Name"
+	step.EnableDynamicMemory = true
+	step.DynamicMemoryMinimum = 512
+	step.DynamicMemoryMaximum = 4096
+	step.MemoryBufferPercent = 50
+	driver := state.Get("driver").(*DriverMock)
+
+	// Test the run
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("Bad action: %v", action)
+	}
+	if _, ok := state.GetOk("error"); ok {
+		t.Fatal("Should NOT have error")
+	}
+
+	// Test the driver
+	if !driver.SetVirtualMachineDynamicMemorySettings_Called {
+		t.Fatal("Should have called SetVirtualMachineDynamicMemorySettings")
+	}
+	if driver.SetVirtualMachineDynamicMemorySettings_MinimumBytes != 512*1024*1024 {
+		t.Fatalf("Bad minimum: %d", driver.SetVirtualMachineDynamicMemorySettings_MinimumBytes)
+	}
+	if driver.SetVirtualMachineDynamicMemorySettings_MaximumBytes != 4096*1024*1024 {
+		t.Fatalf("Bad maximum: %d", driver.SetVirtualMachineDynamicMemorySettings_MaximumBytes)
+	}
+	if driver.SetVirtualMachineDynamicMemorySettings_BufferPercent != 50 {
+		t.Fatalf("Bad buffer: %d", driver.SetVirtualMachineDynamicMemorySettings_BufferPercent)
+	}
+}
+
+func TestStepCreateVM_CpuTopology(t *testing.T) {
+	state := testState(t)
+	step := new(StepCreateVM)
+
+	step.VMName = "test-VM-Name"
+	step.Cpu = 8
+	step.CpusPerSocket = 4
+	step.NumaNodes = 4
+	step.RamSize = 4095
+
+	driver := state.Get("driver").(*DriverMock)
+
+	// Test the run
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("Bad action: %v", action)
+	}
+	if _, ok := state.GetOk("error"); ok {
+		t.Fatal("Should NOT have error")
+	}
+
+	// Test the driver
+	if !driver.SetVirtualMachineCpuTopology_Called {
+		t.Fatal("Should have called SetVirtualMachineCpuTopology")
+	}
+	if driver.SetVirtualMachineCpuTopology_CpusPerNumaNode != 2 {
+		t.Fatalf("bad cpus per numa node: %d", driver.SetVirtualMachineCpuTopology_CpusPerNumaNode)
+	}
+	if driver.SetVirtualMachineCpuTopology_NumaNodesPerSocket != 2 {
+		t.Fatalf("bad numa nodes per socket: %d", driver.SetVirtualMachineCpuTopology_NumaNodesPerSocket)
+	}
+	if driver.SetVirtualMachineCpuTopology_MemoryPerNumaNodeBytes != 1024*1024*1024 {
+		t.Fatalf("bad memory per numa node: %d", driver.SetVirtualMachineCpuTopology_MemoryPerNumaNodeBytes)
+	}
+
+	// Test the default
+	state = testState(t)
+	step = new(StepCreateVM)
+	step.VMName = "test-VM-Name"
+	step.Cpu = 8
+
+	driver = state.Get("driver").(*DriverMock)
+
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("Bad action: %v", action)
+	}
+	if driver.SetVirtualMachineCpuTopology_Called {
+		t.Fatal("Should NOT have called SetVirtualMachineCpuTopology")
+	}
+}
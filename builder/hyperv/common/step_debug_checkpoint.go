@@ -0,0 +1,130 @@
+package common
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/packer/packer-plugin-sdk/multistep"
+	"github.com/hashicorp/packer/packer-plugin-sdk/multistep/commonsteps"
+)
+
+const (
+	// The prefix of the names of the checkpoints debug_checkpoints takes.
+	DebugCheckpointPrefix = "packer-debug-"
+
+	DebugCheckpointBeforeProvisioning = DebugCheckpointPrefix + "before-provisioning"
+	DebugCheckpointAfterProvisioning  = DebugCheckpointPrefix + "after-provisioning"
+	DebugCheckpointProvisioningFailed = DebugCheckpointPrefix + "provisioning-failed"
+)
+
+// This step takes a standard checkpoint called Name of the running VM, so
+// that the VM can be rolled back to it while debugging provisioners. If
+// FailedName is set and the build fails or is cancelled before the next
+// debug checkpoint, a checkpoint called FailedName is taken during cleanup
+// too. The step does nothing unless Enabled is true.
+//
+// Uses:
+//   driver Driver
+//   ui     packer.Ui
+//   vmName string
+//
+// Produces:
+//   debug_checkpoint string - The name of the last debug checkpoint
+type StepDebugCheckpoint struct {
+	Enabled    bool
+	Name       string
+	FailedName string
+}
+
+func (s *StepDebugCheckpoint) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	if !s.Enabled {
+		return multistep.ActionContinue
+	}
+
+	ui, driver, err := getUiAndDriver(state)
+	if err != nil {
+		return commonsteps.HaltOnError(state, ui, err)
+	}
+	vmName, err := getVMName(state)
+	if err != nil {
+		return commonsteps.HaltOnError(state, ui, err)
+	}
+
+	ui.Say(fmt.Sprintf("Creating debug checkpoint %s...", s.Name))
+	err = driver.CheckpointVirtualMachine(vmName, s.Name, "Standard")
+	if err != nil {
+		err := fmt.Errorf("Error creating debug checkpoint: %s", err)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+
+	state.Put("debug_checkpoint", s.Name)
+	return multistep.ActionContinue
+}
+
+func (s *StepDebugCheckpoint) Cleanup(state multistep.StateBag) {
+	if !s.Enabled || s.FailedName == "" {
+		return
+	}
+
+	_, cancelled := state.GetOk(multistep.StateCancelled)
+	_, halted := state.GetOk(multistep.StateHalted)
+	if !cancelled && !halted {
+		return
+	}
+	if last, ok := state.GetOk("debug_checkpoint"); !ok || last.(string) != s.Name {
+		return
+	}
+
+	ui, driver, err := getUiAndDriver(state)
+	if commonsteps.SkipCleanupOnError(err) {
+		return
+	}
+	vmName, err := getVMName(state)
+	if commonsteps.SkipCleanupOnError(err) {
+		return
+	}
+
+	ui.Say(fmt.Sprintf("Creating debug checkpoint %s...", s.FailedName))
+	err = driver.CheckpointVirtualMachine(vmName, s.FailedName, "Standard")
+	if err != nil {
+		ui.Error(fmt.Sprintf("Error creating debug checkpoint: %s", err))
+	}
+}
+
+// This step removes the checkpoints taken by StepDebugCheckpoint once the VM
+// has been shut down, so they don't end up in the export.
+type StepRemoveDebugCheckpoints struct {
+	Enabled bool
+}
+
+func (s *StepRemoveDebugCheckpoints) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	if !s.Enabled {
+		return multistep.ActionContinue
+	}
+
+	ui, driver, err := getUiAndDriver(state)
+	if err != nil {
+		return commonsteps.HaltOnError(state, ui, err)
+	}
+	vmName, err := getVMName(state)
+	if err != nil {
+		return commonsteps.HaltOnError(state, ui, err)
+	}
+
+	ui.Say("Removing debug checkpoints...")
+	err = driver.RemoveVirtualMachineCheckpoints(vmName, DebugCheckpointPrefix)
+	if err != nil {
+		err := fmt.Errorf("Error removing debug checkpoints: %s", err)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+
+	return multistep.ActionContinue
+}
+
+func (s *StepRemoveDebugCheckpoints) Cleanup(state multistep.StateBag) {
+	// do nothing
+}
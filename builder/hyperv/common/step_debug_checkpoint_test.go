@@ -0,0 +1,98 @@
+package common
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/packer/packer-plugin-sdk/multistep"
+)
+
+func TestStepDebugCheckpoint_impl(t *testing.T) {
+	var _ multistep.Step = new(StepDebugCheckpoint)
+	var _ multistep.Step = new(StepRemoveDebugCheckpoints)
+}
+
+func TestStepDebugCheckpoint(t *testing.T) {
+	state := testState(t)
+	state.Put("vmName", "foo")
+	step := &StepDebugCheckpoint{
+		Enabled:    true,
+		Name:       DebugCheckpointBeforeProvisioning,
+		FailedName: DebugCheckpointProvisioningFailed,
+	}
+
+	driver := state.Get("driver").(*DriverMock)
+
+	// Test the run
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("Bad action: %v", action)
+	}
+	if _, ok := state.GetOk("error"); ok {
+		t.Fatal("Should NOT have error")
+	}
+	if driver.CheckpointVirtualMachine_CheckpointName != DebugCheckpointBeforeProvisioning {
+		t.Fatalf("bad checkpoint name: %s", driver.CheckpointVirtualMachine_CheckpointName)
+	}
+	if driver.CheckpointVirtualMachine_CheckpointType != "Standard" {
+		t.Fatalf("bad checkpoint type: %s", driver.CheckpointVirtualMachine_CheckpointType)
+	}
+
+	// Test the cleanup of a successful build
+	driver.CheckpointVirtualMachine_Called = false
+	step.Cleanup(state)
+	if driver.CheckpointVirtualMachine_Called {
+		t.Fatal("Should NOT have called CheckpointVirtualMachine")
+	}
+
+	// Test the cleanup of a build that failed while provisioning
+	state.Put(multistep.StateHalted, true)
+	step.Cleanup(state)
+	if driver.CheckpointVirtualMachine_CheckpointName != DebugCheckpointProvisioningFailed {
+		t.Fatalf("bad checkpoint name: %s", driver.CheckpointVirtualMachine_CheckpointName)
+	}
+
+	// Test the cleanup of a build that failed after provisioning
+	driver.CheckpointVirtualMachine_Called = false
+	state.Put("debug_checkpoint", DebugCheckpointAfterProvisioning)
+	step.Cleanup(state)
+	if driver.CheckpointVirtualMachine_Called {
+		t.Fatal("Should NOT have called CheckpointVirtualMachine")
+	}
+}
+
+func TestStepDebugCheckpoint_disabled(t *testing.T) {
+	state := testState(t)
+	state.Put("vmName", "foo")
+	step := &StepDebugCheckpoint{Name: DebugCheckpointBeforeProvisioning}
+
+	driver := state.Get("driver").(*DriverMock)
+
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("Bad action: %v", action)
+	}
+	if driver.CheckpointVirtualMachine_Called {
+		t.Fatal("Should NOT have called CheckpointVirtualMachine")
+	}
+}
+
+func TestStepRemoveDebugCheckpoints(t *testing.T) {
+	state := testState(t)
+	state.Put("vmName", "foo")
+	step := &StepRemoveDebugCheckpoints{Enabled: true}
+
+	driver := state.Get("driver").(*DriverMock)
+
+	// Test the run
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("Bad action: %v", action)
+	}
+	if _, ok := state.GetOk("error"); ok {
+		t.Fatal("Should NOT have error")
+	}
+	if driver.RemoveVirtualMachineCheckpoints_VmName != "foo" {
+		t.Fatalf("bad vm name: %s", driver.RemoveVirtualMachineCheckpoints_VmName)
+	}
+	if driver.RemoveVirtualMachineCheckpoints_NamePrefix != DebugCheckpointPrefix {
+		t.Fatalf("bad name prefix: %s", driver.RemoveVirtualMachineCheckpoints_NamePrefix)
+	}
+}
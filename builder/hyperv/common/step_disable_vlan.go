@@ -4,24 +4,32 @@ import (
 	"context"
 	"fmt"
 
-	"github.com/hashicorp/packer/packer"
 	"github.com/hashicorp/packer/packer-plugin-sdk/multistep"
+	"github.com/hashicorp/packer/packer-plugin-sdk/multistep/commonsteps"
 )
 
 type StepDisableVlan struct {
 }
 
 func (s *StepDisableVlan) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
-	driver := state.Get("driver").(Driver)
-	ui := state.Get("ui").(packer.Ui)
+	ui, driver, err := getUiAndDriver(state)
+	if err != nil {
+		return commonsteps.HaltOnError(state, ui, err)
+	}
 
 	errorMsg := "Error disabling vlan: %s"
-	vmName := state.Get("vmName").(string)
-	switchName := state.Get("SwitchName").(string)
+	vmName, err := getVMName(state)
+	if err != nil {
+		return commonsteps.HaltOnError(state, ui, err)
+	}
+	switchName, err := multistep.GetString(state, "SwitchName")
+	if err != nil {
+		return commonsteps.HaltOnError(state, ui, err)
+	}
 
 	ui.Say("Disabling vlan...")
 
-	err := driver.UntagVirtualMachineNetworkAdapterVlan(vmName, switchName)
+	err = driver.UntagVirtualMachineNetworkAdapterVlan(vmName, switchName)
 	if err != nil {
 		err := fmt.Errorf(errorMsg, err)
 		state.Put("error", err)
@@ -0,0 +1,46 @@
+package common
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/packer/packer-plugin-sdk/multistep"
+	"github.com/hashicorp/packer/packer-plugin-sdk/multistep/commonsteps"
+)
+
+// This step disconnects the serial port connected by StepSerialLog, so the
+// exported VM doesn't refer to the named pipe of the build.
+type StepDisconnectSerialPort struct {
+	SerialLogFile string
+}
+
+func (s *StepDisconnectSerialPort) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	if s.SerialLogFile == "" {
+		return multistep.ActionContinue
+	}
+
+	ui, driver, err := getUiAndDriver(state)
+	if err != nil {
+		return commonsteps.HaltOnError(state, ui, err)
+	}
+	vmName, err := getVMName(state)
+	if err != nil {
+		return commonsteps.HaltOnError(state, ui, err)
+	}
+
+	ui.Say("Disconnecting serial port...")
+
+	err = driver.SetVirtualMachineComPort(vmName, serialLogComPort, "")
+	if err != nil {
+		err := fmt.Errorf("Error disconnecting serial port: %s", err)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+
+	return multistep.ActionContinue
+}
+
+func (s *StepDisconnectSerialPort) Cleanup(state multistep.StateBag) {
+	// do nothing
+}
@@ -4,8 +4,8 @@ import (
 	"context"
 	"fmt"
 
-	"github.com/hashicorp/packer/packer"
 	"github.com/hashicorp/packer/packer-plugin-sdk/multistep"
+	"github.com/hashicorp/packer/packer-plugin-sdk/multistep/commonsteps"
 )
 
 type StepEnableIntegrationService struct {
@@ -13,14 +13,19 @@ type StepEnableIntegrationService struct {
 }
 
 func (s *StepEnableIntegrationService) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
-	driver := state.Get("driver").(Driver)
-	ui := state.Get("ui").(packer.Ui)
+	ui, driver, err := getUiAndDriver(state)
+	if err != nil {
+		return commonsteps.HaltOnError(state, ui, err)
+	}
 	ui.Say("Enabling Integration Service...")
 
-	vmName := state.Get("vmName").(string)
+	vmName, err := getVMName(state)
+	if err != nil {
+		return commonsteps.HaltOnError(state, ui, err)
+	}
 	s.name = "Guest Service Interface"
 
-	err := driver.EnableVirtualMachineIntegrationService(vmName, s.name)
+	err = driver.EnableVirtualMachineIntegrationService(vmName, s.name)
 
 	if err != nil {
 		err := fmt.Errorf("Error enabling Integration Service: %s", err)
@@ -5,18 +5,84 @@ import (
 	"fmt"
 	"path/filepath"
 
-	"github.com/hashicorp/packer/packer"
 	"github.com/hashicorp/packer/packer-plugin-sdk/multistep"
+	"github.com/hashicorp/packer/packer-plugin-sdk/multistep/commonsteps"
 )
 
+const (
+	// ExportModeFlat exports the virtual machine as it is.
+	ExportModeFlat = "flat"
+	// ExportModeCheckpoints takes a production checkpoint of the virtual
+	// machine before exporting it, so the checkpoint is part of the export.
+	ExportModeCheckpoints = "checkpoints"
+	// ExportModeVHDSet converts the disks of the virtual machine to VHD Sets
+	// before exporting it.
+	ExportModeVHDSet = "vhdset"
+
+	exportCheckpointName = "packer-export"
+
+	OutputDiskFormatVHDX = "vhdx"
+	OutputDiskFormatVHD  = "vhd"
+
+	OutputDiskTypeDynamic = "dynamic"
+	OutputDiskTypeFixed   = "fixed"
+)
+
+// This step exports the VM to OutputDir. If OutputDiskFormat or
+// OutputDiskType are set, the disks of the VM are converted first, which is
+// also done when SkipExport is true, as the disks are the only artifacts
+// then. If Remote is true, the VM is exported to a directory in the build
+// directory on the remote Hyper-V host instead, which StepCollateArtifacts
+// copies into OutputDir.
 type StepExportVm struct {
-	OutputDir  string
-	SkipExport bool
+	OutputDir        string
+	SkipExport       bool
+	ExportMode       string
+	OutputDiskFormat string
+	OutputDiskType   string
+	Remote           bool
 }
 
 func (s *StepExportVm) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
-	driver := state.Get("driver").(Driver)
-	ui := state.Get("ui").(packer.Ui)
+	ui, driver, err := getUiAndDriver(state)
+	if err != nil {
+		return commonsteps.HaltOnError(state, ui, err)
+	}
+
+	// The VM name is needed for the export command
+	var vmName string
+	if v, ok := state.GetOk("vmName"); ok {
+		vmName = v.(string)
+	}
+
+	if s.OutputDiskFormat != "" || s.OutputDiskType != "" {
+		ui.Say("Converting virtual hard disks...")
+		// An exported VM has to refer to the converted disks, while the
+		// disks of a VM that isn't exported may not be attachable anymore
+		err := driver.ConvertVirtualMachineDisks(vmName, s.OutputDiskFormat, s.OutputDiskType, !s.SkipExport)
+		if err != nil {
+			err = fmt.Errorf("Error converting virtual hard disks: %s", err)
+			state.Put("error", err)
+			ui.Error(err.Error())
+			return multistep.ActionHalt
+		}
+	}
+
+	outputDir := s.OutputDir
+	if s.Remote {
+		buildDir, err := multistep.GetString(state, "build_dir")
+		if err != nil {
+			return commonsteps.HaltOnError(state, ui, err)
+		}
+		outputDir, err = driver.CreateHostTempDirectory(buildDir, "output")
+		if err != nil {
+			err = fmt.Errorf("Error creating output directory on the Hyper-V host: %s", err)
+			state.Put("error", err)
+			ui.Error(err.Error())
+			return multistep.ActionHalt
+		}
+		multistep.PutString(state, "host_output_dir", outputDir)
+	}
 
 	if s.SkipExport {
 		ui.Say("Skipping export of virtual machine...")
@@ -25,16 +91,31 @@ func (s *StepExportVm) Run(ctx context.Context, state multistep.StateBag) multis
 
 	ui.Say("Exporting virtual machine...")
 
-	// The VM name is needed for the export command
-	var vmName string
-	if v, ok := state.GetOk("vmName"); ok {
-		vmName = v.(string)
+	switch s.ExportMode {
+	case ExportModeCheckpoints:
+		ui.Say("Creating production checkpoint before export...")
+		err := driver.CheckpointVirtualMachine(vmName, exportCheckpointName, "Production")
+		if err != nil {
+			err = fmt.Errorf("Error creating checkpoint: %s", err)
+			state.Put("error", err)
+			ui.Error(err.Error())
+			return multistep.ActionHalt
+		}
+	case ExportModeVHDSet:
+		ui.Say("Converting virtual hard disks to VHD Sets before export...")
+		err := driver.ConvertVirtualMachineDisksToVHDSet(vmName)
+		if err != nil {
+			err = fmt.Errorf("Error converting disks to VHD Sets: %s", err)
+			state.Put("error", err)
+			ui.Error(err.Error())
+			return multistep.ActionHalt
+		}
 	}
 
 	// The export process exports the VM to a folder named 'vmName' under
 	// the output directory. This contains the usual 'Snapshots', 'Virtual
 	// Hard Disks' and 'Virtual Machines' directories.
-	err := driver.ExportVirtualMachine(vmName, s.OutputDir)
+	err = driver.ExportVirtualMachine(vmName, outputDir)
 	if err != nil {
 		err = fmt.Errorf("Error exporting vm: %s", err)
 		state.Put("error", err)
@@ -43,7 +124,7 @@ func (s *StepExportVm) Run(ctx context.Context, state multistep.StateBag) multis
 	}
 
 	// Store the path to the export directory for later steps
-	exportPath := filepath.Join(s.OutputDir, vmName)
+	exportPath := filepath.Join(outputDir, vmName)
 	state.Put("export_path", exportPath)
 
 	return multistep.ActionContinue
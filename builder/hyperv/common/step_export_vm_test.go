@@ -85,3 +85,181 @@ func TestStepExportVm_skip(t *testing.T) {
 		t.Fatal("Should NOT have stored export_path in the statebag")
 	}
 }
+
+func TestStepExportVm_checkpoints(t *testing.T) {
+	state := testState(t)
+	step := new(StepExportVm)
+	step.ExportMode = ExportModeCheckpoints
+
+	vmName := "foo"
+	state.Put("vmName", vmName)
+	step.OutputDir = "foopath"
+
+	driver := state.Get("driver").(*DriverMock)
+
+	// Test the run
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("Bad action: %v", action)
+	}
+	if _, ok := state.GetOk("error"); ok {
+		t.Fatal("Should NOT have error")
+	}
+
+	// Test the driver
+	if !driver.CheckpointVirtualMachine_Called {
+		t.Fatal("Should have called CheckpointVirtualMachine")
+	}
+	if driver.CheckpointVirtualMachine_VmName != vmName {
+		t.Fatalf("Should call with correct vm name. Got: %s Wanted: %s",
+			driver.CheckpointVirtualMachine_VmName, vmName)
+	}
+	if driver.CheckpointVirtualMachine_CheckpointType != "Production" {
+		t.Fatalf("Should create a production checkpoint. Got: %s",
+			driver.CheckpointVirtualMachine_CheckpointType)
+	}
+	if driver.ConvertVirtualMachineDisksToVHDSet_Called {
+		t.Fatal("Should NOT have called ConvertVirtualMachineDisksToVHDSet")
+	}
+	if !driver.ExportVirtualMachine_Called {
+		t.Fatal("Should have called ExportVirtualMachine")
+	}
+}
+
+func TestStepExportVm_vhdSet(t *testing.T) {
+	state := testState(t)
+	step := new(StepExportVm)
+	step.ExportMode = ExportModeVHDSet
+
+	vmName := "foo"
+	state.Put("vmName", vmName)
+	step.OutputDir = "foopath"
+
+	driver := state.Get("driver").(*DriverMock)
+
+	// Test the run
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("Bad action: %v", action)
+	}
+	if _, ok := state.GetOk("error"); ok {
+		t.Fatal("Should NOT have error")
+	}
+
+	// Test the driver
+	if !driver.ConvertVirtualMachineDisksToVHDSet_Called {
+		t.Fatal("Should have called ConvertVirtualMachineDisksToVHDSet")
+	}
+	if driver.ConvertVirtualMachineDisksToVHDSet_VmName != vmName {
+		t.Fatalf("Should call with correct vm name. Got: %s Wanted: %s",
+			driver.ConvertVirtualMachineDisksToVHDSet_VmName, vmName)
+	}
+	if driver.CheckpointVirtualMachine_Called {
+		t.Fatal("Should NOT have called CheckpointVirtualMachine")
+	}
+	if !driver.ExportVirtualMachine_Called {
+		t.Fatal("Should have called ExportVirtualMachine")
+	}
+}
+
+func TestStepExportVm_outputDisk(t *testing.T) {
+	state := testState(t)
+	step := new(StepExportVm)
+	step.OutputDir = "foopath"
+	step.OutputDiskFormat = OutputDiskFormatVHD
+	step.OutputDiskType = OutputDiskTypeFixed
+
+	vmName := "foo"
+	state.Put("vmName", vmName)
+
+	driver := state.Get("driver").(*DriverMock)
+
+	// Test the run
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("Bad action: %v", action)
+	}
+	if _, ok := state.GetOk("error"); ok {
+		t.Fatal("Should NOT have error")
+	}
+
+	// Test the driver
+	if !driver.ConvertVirtualMachineDisks_Called {
+		t.Fatal("Should have called ConvertVirtualMachineDisks")
+	}
+	if driver.ConvertVirtualMachineDisks_VmName != vmName {
+		t.Fatalf("Should call with correct vm name. Got: %s Wanted: %s",
+			driver.ConvertVirtualMachineDisks_VmName, vmName)
+	}
+	if driver.ConvertVirtualMachineDisks_Format != OutputDiskFormatVHD {
+		t.Fatalf("bad format: %s", driver.ConvertVirtualMachineDisks_Format)
+	}
+	if driver.ConvertVirtualMachineDisks_VhdType != OutputDiskTypeFixed {
+		t.Fatalf("bad type: %s", driver.ConvertVirtualMachineDisks_VhdType)
+	}
+	if !driver.ConvertVirtualMachineDisks_Attach {
+		t.Fatal("Should attach the converted disks to an exported VM")
+	}
+	if !driver.ExportVirtualMachine_Called {
+		t.Fatal("Should have called ExportVirtualMachine")
+	}
+}
+
+func TestStepExportVm_outputDiskSkipExport(t *testing.T) {
+	state := testState(t)
+	step := new(StepExportVm)
+	step.SkipExport = true
+	step.OutputDiskType = OutputDiskTypeFixed
+
+	state.Put("vmName", "foo")
+
+	driver := state.Get("driver").(*DriverMock)
+
+	// Test the run
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("Bad action: %v", action)
+	}
+	if _, ok := state.GetOk("error"); ok {
+		t.Fatal("Should NOT have error")
+	}
+
+	// Test the driver
+	if !driver.ConvertVirtualMachineDisks_Called {
+		t.Fatal("Should have called ConvertVirtualMachineDisks")
+	}
+	if driver.ConvertVirtualMachineDisks_Attach {
+		t.Fatal("Should NOT attach the converted disks when skipping the export")
+	}
+	if driver.ExportVirtualMachine_Called {
+		t.Fatal("Should NOT have called ExportVirtualMachine")
+	}
+}
+
+func TestStepExportVm_remote(t *testing.T) {
+	state := testState(t)
+	step := &StepExportVm{
+		OutputDir: "foopath",
+		Remote:    true,
+	}
+
+	state.Put("vmName", "foo")
+	state.Put("build_dir", `D:\Builds\hyperv12345`)
+
+	driver := state.Get("driver").(*DriverMock)
+	driver.CreateHostTempDirectory_Return = `D:\Builds\hyperv12345\output67890`
+
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("Bad action: %v", action)
+	}
+	if _, ok := state.GetOk("error"); ok {
+		t.Fatal("Should NOT have error")
+	}
+
+	if driver.CreateHostTempDirectory_Parent != `D:\Builds\hyperv12345` {
+		t.Fatalf("Should create the output directory in the build directory: %s",
+			driver.CreateHostTempDirectory_Parent)
+	}
+	if driver.ExportVirtualMachine_Path != driver.CreateHostTempDirectory_Return {
+		t.Fatalf("Should export to the output directory on the host: %s", driver.ExportVirtualMachine_Path)
+	}
+	if v := state.Get("host_output_dir").(string); v != driver.CreateHostTempDirectory_Return {
+		t.Fatalf("bad host_output_dir: %s", v)
+	}
+}
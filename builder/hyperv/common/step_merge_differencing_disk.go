@@ -0,0 +1,50 @@
+package common
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/packer/packer-plugin-sdk/multistep"
+	"github.com/hashicorp/packer/packer-plugin-sdk/multistep/commonsteps"
+)
+
+// This step merges the differencing disks of the virtual machine with their
+// parents, so that every disk of the virtual machine is standalone.
+//
+// Uses:
+//   driver Driver
+//   ui     packer.Ui
+//   vmName string
+//
+// Produces:
+//   <nothing>
+type StepMergeDifferencingDisk struct {
+	MergeDifferencingDisk bool
+}
+
+func (s *StepMergeDifferencingDisk) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	if !s.MergeDifferencingDisk {
+		return multistep.ActionContinue
+	}
+
+	ui, driver, err := getUiAndDriver(state)
+	if err != nil {
+		return commonsteps.HaltOnError(state, ui, err)
+	}
+	vmName, err := getVMName(state)
+	if err != nil {
+		return commonsteps.HaltOnError(state, ui, err)
+	}
+
+	ui.Say("Merging differencing disks...")
+	if err := driver.MergeVirtualMachineDifferencingDisks(vmName); err != nil {
+		err := fmt.Errorf("Error merging differencing disks: %s", err)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+
+	return multistep.ActionContinue
+}
+
+func (s *StepMergeDifferencingDisk) Cleanup(state multistep.StateBag) {}
@@ -0,0 +1,62 @@
+package common
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/packer/packer-plugin-sdk/multistep"
+)
+
+func TestStepMergeDifferencingDisk_impl(t *testing.T) {
+	var _ multistep.Step = new(StepMergeDifferencingDisk)
+}
+
+func TestStepMergeDifferencingDisk(t *testing.T) {
+	state := testState(t)
+	step := new(StepMergeDifferencingDisk)
+	step.MergeDifferencingDisk = true
+
+	vmName := "foo"
+	state.Put("vmName", vmName)
+
+	driver := state.Get("driver").(*DriverMock)
+
+	// Test the run
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("Bad action: %v", action)
+	}
+	if _, ok := state.GetOk("error"); ok {
+		t.Fatal("Should NOT have error")
+	}
+
+	// Test the driver
+	if !driver.MergeVirtualMachineDifferencingDisks_Called {
+		t.Fatal("Should have called MergeVirtualMachineDifferencingDisks")
+	}
+	if driver.MergeVirtualMachineDifferencingDisks_VmName != vmName {
+		t.Fatalf("Should call with correct vm name. Got: %s Wanted: %s",
+			driver.MergeVirtualMachineDifferencingDisks_VmName, vmName)
+	}
+}
+
+func TestStepMergeDifferencingDisk_skip(t *testing.T) {
+	state := testState(t)
+	step := new(StepMergeDifferencingDisk)
+
+	state.Put("vmName", "foo")
+
+	driver := state.Get("driver").(*DriverMock)
+
+	// Test the run
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("Bad action: %v", action)
+	}
+	if _, ok := state.GetOk("error"); ok {
+		t.Fatal("Should NOT have error")
+	}
+
+	// Test the driver
+	if driver.MergeVirtualMachineDifferencingDisks_Called {
+		t.Fatal("Should NOT have called MergeVirtualMachineDifferencingDisks")
+	}
+}
@@ -7,8 +7,8 @@ import (
 	"path/filepath"
 	"strings"
 
-	"github.com/hashicorp/packer/packer"
 	"github.com/hashicorp/packer/packer-plugin-sdk/multistep"
+	"github.com/hashicorp/packer/packer-plugin-sdk/multistep/commonsteps"
 )
 
 type StepMountDvdDrive struct {
@@ -17,11 +17,16 @@ type StepMountDvdDrive struct {
 }
 
 func (s *StepMountDvdDrive) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
-	driver := state.Get("driver").(Driver)
-	ui := state.Get("ui").(packer.Ui)
+	ui, driver, err := getUiAndDriver(state)
+	if err != nil {
+		return commonsteps.HaltOnError(state, ui, err)
+	}
 
 	errorMsg := "Error mounting dvd drive: %s"
-	vmName := state.Get("vmName").(string)
+	vmName, err := getVMName(state)
+	if err != nil {
+		return commonsteps.HaltOnError(state, ui, err)
+	}
 
 	// Determine if we even have a dvd disk to attach
 	var isoPath string
@@ -90,17 +95,32 @@ func (s *StepMountDvdDrive) Run(ctx context.Context, state multistep.StateBag) m
 	return multistep.ActionContinue
 }
 
-func (s *StepMountDvdDrive) Cleanup(state multistep.StateBag) {
-	dvdControllerState := state.Get("os.dvd.properties")
+func (s *StepMountDvdDrive) Save(state multistep.StateBag) map[string]interface{} {
+	return multistep.SaveKeys(state, "os.dvd.properties")
+}
+
+func (s *StepMountDvdDrive) Resume(ctx context.Context, state multistep.StateBag, saved map[string]interface{}) multistep.StepAction {
+	multistep.RestoreKeys(state, saved)
+	return multistep.ActionContinue
+}
 
-	if dvdControllerState == nil {
+func (s *StepMountDvdDrive) Cleanup(state multistep.StateBag) {
+	if state.Get("os.dvd.properties") == nil {
 		return
 	}
 
-	dvdController := dvdControllerState.(DvdControllerProperties)
-	driver := state.Get("driver").(Driver)
-	vmName := state.Get("vmName").(string)
-	ui := state.Get("ui").(packer.Ui)
+	var dvdController DvdControllerProperties
+	if err := multistep.GetValue(state, "os.dvd.properties", &dvdController); commonsteps.SkipCleanupOnError(err) {
+		return
+	}
+	ui, driver, err := getUiAndDriver(state)
+	if commonsteps.SkipCleanupOnError(err) {
+		return
+	}
+	vmName, err := getVMName(state)
+	if commonsteps.SkipCleanupOnError(err) {
+		return
+	}
 	errorMsg := "Error unmounting os dvd drive: %s"
 
 	ui.Say("Clean up os dvd drive...")
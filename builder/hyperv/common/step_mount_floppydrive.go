@@ -8,8 +8,8 @@ import (
 	"os"
 	"path/filepath"
 
-	"github.com/hashicorp/packer/packer"
 	"github.com/hashicorp/packer/packer-plugin-sdk/multistep"
+	"github.com/hashicorp/packer/packer-plugin-sdk/multistep/commonsteps"
 	"github.com/hashicorp/packer/packer-plugin-sdk/tmp"
 )
 
@@ -19,6 +19,9 @@ const (
 
 type StepMountFloppydrive struct {
 	Generation uint
+	// Copy the floppy to the build directory on the remote Hyper-V host
+	// instead of a local temp directory
+	Remote     bool
 	floppyPath string
 }
 
@@ -27,7 +30,10 @@ func (s *StepMountFloppydrive) Run(ctx context.Context, state multistep.StateBag
 		return multistep.ActionContinue
 	}
 
-	driver := state.Get("driver").(Driver)
+	ui, driver, err := getUiAndDriver(state)
+	if err != nil {
+		return commonsteps.HaltOnError(state, ui, err)
+	}
 
 	// Determine if we even have a floppy disk to attach
 	var floppyPath string
@@ -41,14 +47,25 @@ func (s *StepMountFloppydrive) Run(ctx context.Context, state multistep.StateBag
 	// Hyper-V is really dumb and can't figure out the format of the file
 	// without an extension, so we need to add the "vfd" extension to the
 	// floppy.
-	floppyPath, err := s.copyFloppy(floppyPath)
+	if s.Remote {
+		var buildDir string
+		if buildDir, err = multistep.GetString(state, "build_dir"); err == nil {
+			hostPath := buildDir + `\floppy.vfd`
+			err = driver.CopyFileToHost(floppyPath, hostPath)
+			floppyPath = hostPath
+		}
+	} else {
+		floppyPath, err = s.copyFloppy(floppyPath)
+	}
 	if err != nil {
 		state.Put("error", fmt.Errorf("Error preparing floppy: %s", err))
 		return multistep.ActionHalt
 	}
 
-	ui := state.Get("ui").(packer.Ui)
-	vmName := state.Get("vmName").(string)
+	vmName, err := getVMName(state)
+	if err != nil {
+		return commonsteps.HaltOnError(state, ui, err)
+	}
 
 	ui.Say("Mounting floppy drive...")
 
@@ -64,27 +81,46 @@ func (s *StepMountFloppydrive) Run(ctx context.Context, state multistep.StateBag
 	return multistep.ActionContinue
 }
 
+func (s *StepMountFloppydrive) Save(state multistep.StateBag) map[string]interface{} {
+	return map[string]interface{}{"floppy_path": s.floppyPath}
+}
+
+func (s *StepMountFloppydrive) Resume(ctx context.Context, state multistep.StateBag, saved map[string]interface{}) multistep.StepAction {
+	s.floppyPath = saved["floppy_path"].(string)
+	return multistep.ActionContinue
+}
+
 func (s *StepMountFloppydrive) Cleanup(state multistep.StateBag) {
 	if s.Generation > 1 {
 		return
 	}
-	driver := state.Get("driver").(Driver)
+	ui, driver, err := getUiAndDriver(state)
+	if commonsteps.SkipCleanupOnError(err) {
+		return
+	}
 	if s.floppyPath == "" {
 		return
 	}
 
 	errorMsg := "Error unmounting floppy drive: %s"
 
-	vmName := state.Get("vmName").(string)
-	ui := state.Get("ui").(packer.Ui)
+	vmName, err := getVMName(state)
+	if commonsteps.SkipCleanupOnError(err) {
+		return
+	}
 
 	ui.Say("Cleanup floppy drive...")
 
-	err := driver.UnmountFloppyDrive(vmName)
+	err = driver.UnmountFloppyDrive(vmName)
 	if err != nil {
 		log.Print(fmt.Sprintf(errorMsg, err))
 	}
 
+	// The copy on the remote host is removed with the build directory
+	if s.Remote {
+		return
+	}
+
 	err = os.Remove(s.floppyPath)
 
 	if err != nil {
@@ -5,8 +5,8 @@ import (
 	"fmt"
 	"log"
 
-	"github.com/hashicorp/packer/packer"
 	"github.com/hashicorp/packer/packer-plugin-sdk/multistep"
+	"github.com/hashicorp/packer/packer-plugin-sdk/multistep/commonsteps"
 )
 
 type StepMountGuestAdditions struct {
@@ -16,17 +16,22 @@ type StepMountGuestAdditions struct {
 }
 
 func (s *StepMountGuestAdditions) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
-	ui := state.Get("ui").(packer.Ui)
+	ui, driver, err := getUiAndDriver(state)
+	if err != nil {
+		return commonsteps.HaltOnError(state, ui, err)
+	}
 
 	if s.GuestAdditionsMode != "attach" {
 		ui.Say("Skipping mounting Integration Services Setup Disk...")
 		return multistep.ActionContinue
 	}
 
-	driver := state.Get("driver").(Driver)
 	ui.Say("Mounting Integration Services Setup Disk...")
 
-	vmName := state.Get("vmName").(string)
+	vmName, err := getVMName(state)
+	if err != nil {
+		return commonsteps.HaltOnError(state, ui, err)
+	}
 
 	// should be able to mount up to 60 additional iso images using SCSI
 	// but Windows would only allow a max of 22 due to available drive letters
@@ -62,21 +67,36 @@ func (s *StepMountGuestAdditions) Run(ctx context.Context, state multistep.State
 	return multistep.ActionContinue
 }
 
+func (s *StepMountGuestAdditions) Save(state multistep.StateBag) map[string]interface{} {
+	return multistep.SaveKeys(state, "guest.dvd.properties")
+}
+
+func (s *StepMountGuestAdditions) Resume(ctx context.Context, state multistep.StateBag, saved map[string]interface{}) multistep.StepAction {
+	multistep.RestoreKeys(state, saved)
+	return multistep.ActionContinue
+}
+
 func (s *StepMountGuestAdditions) Cleanup(state multistep.StateBag) {
 	if s.GuestAdditionsMode != "attach" {
 		return
 	}
 
-	dvdControllerState := state.Get("guest.dvd.properties")
-
-	if dvdControllerState == nil {
+	if state.Get("guest.dvd.properties") == nil {
 		return
 	}
 
-	dvdController := dvdControllerState.(DvdControllerProperties)
-	ui := state.Get("ui").(packer.Ui)
-	driver := state.Get("driver").(Driver)
-	vmName := state.Get("vmName").(string)
+	var dvdController DvdControllerProperties
+	if err := multistep.GetValue(state, "guest.dvd.properties", &dvdController); commonsteps.SkipCleanupOnError(err) {
+		return
+	}
+	ui, driver, err := getUiAndDriver(state)
+	if commonsteps.SkipCleanupOnError(err) {
+		return
+	}
+	vmName, err := getVMName(state)
+	if commonsteps.SkipCleanupOnError(err) {
+		return
+	}
 	errorMsg := "Error unmounting Integration Services dvd drive: %s"
 
 	ui.Say("Cleanup Integration Services dvd drive...")
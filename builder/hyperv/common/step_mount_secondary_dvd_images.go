@@ -2,11 +2,12 @@ package common
 
 import (
 	"context"
+	"encoding/gob"
 	"fmt"
 	"log"
 
-	"github.com/hashicorp/packer/packer"
 	"github.com/hashicorp/packer/packer-plugin-sdk/multistep"
+	"github.com/hashicorp/packer/packer-plugin-sdk/multistep/commonsteps"
 )
 
 type StepMountSecondaryDvdImages struct {
@@ -20,12 +21,23 @@ type DvdControllerProperties struct {
 	Existing           bool
 }
 
+func init() {
+	// The properties are saved to resume builds
+	gob.Register(DvdControllerProperties{})
+	gob.Register([]DvdControllerProperties{})
+}
+
 func (s *StepMountSecondaryDvdImages) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
-	driver := state.Get("driver").(Driver)
-	ui := state.Get("ui").(packer.Ui)
+	ui, driver, err := getUiAndDriver(state)
+	if err != nil {
+		return commonsteps.HaltOnError(state, ui, err)
+	}
 	ui.Say("Mounting secondary DVD images...")
 
-	vmName := state.Get("vmName").(string)
+	vmName, err := getVMName(state)
+	if err != nil {
+		return commonsteps.HaltOnError(state, ui, err)
+	}
 
 	// should be able to mount up to 60 additional iso images using SCSI
 	// but Windows would only allow a max of 22 due to available drive letters
@@ -75,17 +87,32 @@ func (s *StepMountSecondaryDvdImages) Run(ctx context.Context, state multistep.S
 	return multistep.ActionContinue
 }
 
-func (s *StepMountSecondaryDvdImages) Cleanup(state multistep.StateBag) {
-	dvdControllersState := state.Get("secondary.dvd.properties")
+func (s *StepMountSecondaryDvdImages) Save(state multistep.StateBag) map[string]interface{} {
+	return multistep.SaveKeys(state, "secondary.dvd.properties")
+}
+
+func (s *StepMountSecondaryDvdImages) Resume(ctx context.Context, state multistep.StateBag, saved map[string]interface{}) multistep.StepAction {
+	multistep.RestoreKeys(state, saved)
+	return multistep.ActionContinue
+}
 
-	if dvdControllersState == nil {
+func (s *StepMountSecondaryDvdImages) Cleanup(state multistep.StateBag) {
+	if state.Get("secondary.dvd.properties") == nil {
 		return
 	}
 
-	dvdControllers := dvdControllersState.([]DvdControllerProperties)
-	driver := state.Get("driver").(Driver)
-	ui := state.Get("ui").(packer.Ui)
-	vmName := state.Get("vmName").(string)
+	var dvdControllers []DvdControllerProperties
+	if err := multistep.GetValue(state, "secondary.dvd.properties", &dvdControllers); commonsteps.SkipCleanupOnError(err) {
+		return
+	}
+	ui, driver, err := getUiAndDriver(state)
+	if commonsteps.SkipCleanupOnError(err) {
+		return
+	}
+	vmName, err := getVMName(state)
+	if commonsteps.SkipCleanupOnError(err) {
+		return
+	}
 	errorMsg := "Error unmounting secondary dvd drive: %s"
 
 	ui.Say("Clean up secondary dvd drives...")
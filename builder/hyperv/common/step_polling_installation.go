@@ -9,8 +9,8 @@ import (
 	"strings"
 	"time"
 
-	"github.com/hashicorp/packer/packer"
 	"github.com/hashicorp/packer/packer-plugin-sdk/multistep"
+	"github.com/hashicorp/packer/packer-plugin-sdk/multistep/commonsteps"
 )
 
 const port string = "13000"
@@ -19,10 +19,16 @@ type StepPollingInstallation struct {
 }
 
 func (s *StepPollingInstallation) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
-	ui := state.Get("ui").(packer.Ui)
+	ui, err := commonsteps.GetUi(state)
+	if err != nil {
+		return commonsteps.HaltOnError(state, nil, err)
+	}
 
 	errorMsg := "Error polling VM: %s"
-	vmIp := state.Get("ip").(string)
+	vmIp, err := multistep.GetString(state, "ip")
+	if err != nil {
+		return commonsteps.HaltOnError(state, ui, err)
+	}
 
 	ui.Say("Start polling VM to check the installation is complete...")
 	host := "'" + vmIp + "'," + port
@@ -4,25 +4,39 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"net"
 
-	"github.com/hashicorp/packer/packer"
 	"github.com/hashicorp/packer/packer-plugin-sdk/multistep"
+	"github.com/hashicorp/packer/packer-plugin-sdk/multistep/commonsteps"
 )
 
 type StepRun struct {
 	GuiCancelFunc context.CancelFunc
 	Headless      bool
 	SwitchName    string
-	vmName        string
+	// The remote Hyper-V host the VM runs on, if any. The HTTP server then
+	// listens on the address of this machine that reaches that host
+	RemoteHost string
+	vmName     string
 }
 
 func (s *StepRun) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
-	driver := state.Get("driver").(Driver)
-	ui := state.Get("ui").(packer.Ui)
-	vmName := state.Get("vmName").(string)
+	ui, driver, err := getUiAndDriver(state)
+	if err != nil {
+		return commonsteps.HaltOnError(state, ui, err)
+	}
+	vmName, err := getVMName(state)
+	if err != nil {
+		return commonsteps.HaltOnError(state, ui, err)
+	}
 
 	ui.Say("Determine Host IP for HyperV machine...")
-	hostIp, err := driver.GetHostAdapterIpAddressForSwitch(s.SwitchName)
+	var hostIp string
+	if s.RemoteHost != "" {
+		hostIp, err = localAddressTo(s.RemoteHost)
+	} else {
+		hostIp, err = driver.GetHostAdapterIpAddressForSwitch(s.SwitchName)
+	}
 	if err != nil {
 		err := fmt.Errorf("Error getting host adapter ip address: %s", err)
 		state.Put("error", err)
@@ -31,7 +45,7 @@ func (s *StepRun) Run(ctx context.Context, state multistep.StateBag) multistep.S
 	}
 
 	ui.Say(fmt.Sprintf("Host IP for the HyperV machine: %s", hostIp))
-	state.Put("http_ip", hostIp)
+	multistep.PutString(state, "http_ip", hostIp)
 
 	if !s.Headless {
 		ui.Say("Attempting to connect with vmconnect...")
@@ -56,13 +70,56 @@ func (s *StepRun) Run(ctx context.Context, state multistep.StateBag) multistep.S
 	return multistep.ActionContinue
 }
 
+func (s *StepRun) Save(state multistep.StateBag) map[string]interface{} {
+	return multistep.SaveKeys(state, "http_ip")
+}
+
+// Resume starts the virtual machine again if it was turned off since the
+// build that is resumed failed.
+func (s *StepRun) Resume(ctx context.Context, state multistep.StateBag, saved map[string]interface{}) multistep.StepAction {
+	ui, driver, err := getUiAndDriver(state)
+	if err != nil {
+		return commonsteps.HaltOnError(state, ui, err)
+	}
+	vmName, err := getVMName(state)
+	if err != nil {
+		return commonsteps.HaltOnError(state, ui, err)
+	}
+
+	multistep.RestoreKeys(state, saved)
+	s.vmName = vmName
+
+	running, err := driver.IsRunning(vmName)
+	if err != nil {
+		err := fmt.Errorf("Error checking vm state: %s", err)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+	if running {
+		return multistep.ActionContinue
+	}
+
+	ui.Say("Starting the virtual machine...")
+	if err := driver.Start(vmName); err != nil {
+		err := fmt.Errorf("Error starting vm: %s", err)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+
+	return multistep.ActionContinue
+}
+
 func (s *StepRun) Cleanup(state multistep.StateBag) {
 	if s.vmName == "" {
 		return
 	}
 
-	driver := state.Get("driver").(Driver)
-	ui := state.Get("ui").(packer.Ui)
+	ui, driver, err := getUiAndDriver(state)
+	if commonsteps.SkipCleanupOnError(err) {
+		return
+	}
 
 	if !s.Headless && s.GuiCancelFunc != nil {
 		ui.Say("Disconnecting from vmconnect...")
@@ -75,3 +132,15 @@ func (s *StepRun) Cleanup(state multistep.StateBag) {
 		}
 	}
 }
+
+// localAddressTo returns the local IP address used to connect to host. No
+// packets are sent, dialing UDP only picks the route.
+func localAddressTo(host string) (string, error) {
+	conn, err := net.Dial("udp", net.JoinHostPort(host, "5985"))
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+
+	return conn.LocalAddr().(*net.UDPAddr).IP.String(), nil
+}
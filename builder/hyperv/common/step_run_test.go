@@ -0,0 +1,58 @@
+package common
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/packer/packer-plugin-sdk/multistep"
+)
+
+func TestStepRun_impl(t *testing.T) {
+	var _ multistep.ResumableStep = new(StepRun)
+}
+
+func TestStepRun_Resume(t *testing.T) {
+	state := testState(t)
+	state.Put("vmName", "packer-foo")
+	step := &StepRun{Headless: true}
+
+	driver := state.Get("driver").(*DriverMock)
+	driver.IsRunning_Return = false
+
+	saved := map[string]interface{}{"http_ip": "10.0.0.1"}
+	if action := step.Resume(context.Background(), state, saved); action != multistep.ActionContinue {
+		t.Fatalf("Bad action: %v", action)
+	}
+	if _, ok := state.GetOk("error"); ok {
+		t.Fatal("Should NOT have error")
+	}
+
+	if !driver.Start_Called || driver.Start_VmName != "packer-foo" {
+		t.Fatal("Should have started the stopped VM")
+	}
+	if ip := state.Get("http_ip").(string); ip != "10.0.0.1" {
+		t.Fatalf("bad http_ip: %s", ip)
+	}
+}
+
+func TestStepRun_ResumeRunning(t *testing.T) {
+	state := testState(t)
+	state.Put("vmName", "packer-foo")
+	step := &StepRun{Headless: true}
+
+	driver := state.Get("driver").(*DriverMock)
+	driver.IsRunning_Return = true
+
+	if action := step.Resume(context.Background(), state, nil); action != multistep.ActionContinue {
+		t.Fatalf("Bad action: %v", action)
+	}
+	if driver.Start_Called {
+		t.Fatal("Should NOT have started the running VM")
+	}
+
+	// The VM is stopped on cleanup like after Run
+	step.Cleanup(state)
+	if !driver.Stop_Called {
+		t.Fatal("Should have stopped the VM")
+	}
+}
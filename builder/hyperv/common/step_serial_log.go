@@ -0,0 +1,171 @@
+package common
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/packer/packer-plugin-sdk/multistep"
+	"github.com/hashicorp/packer/packer-plugin-sdk/multistep/commonsteps"
+)
+
+const (
+	// The serial port the log is read from.
+	serialLogComPort = 1
+	// The number of lines of the serial log shown when the build fails.
+	serialLogTailLines = 40
+)
+
+// openSerialPipe opens the named pipe Hyper-V serves the serial port on. The
+// pipe only exists while the VM is running.
+var openSerialPipe = func(path string) (io.ReadCloser, error) {
+	return os.OpenFile(path, os.O_RDONLY, 0)
+}
+
+// This step connects the first serial port of the VM to a named pipe and,
+// once the VM has started, copies everything the guest writes to it into the
+// file at Path. The copy ends when the VM is turned off. If ShowOnError is
+// true and the build fails, the last lines of the log are shown in the UI.
+//
+// Uses:
+//   driver Driver
+//   ui     packer.Ui
+//   vmName string
+//
+// Produces:
+//   <nothing>
+type StepSerialLog struct {
+	Path        string
+	ShowOnError bool
+
+	file *os.File
+	stop chan struct{}
+	done chan struct{}
+}
+
+func (s *StepSerialLog) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	if s.Path == "" {
+		return multistep.ActionContinue
+	}
+
+	ui, driver, err := getUiAndDriver(state)
+	if err != nil {
+		return commonsteps.HaltOnError(state, ui, err)
+	}
+	vmName, err := getVMName(state)
+	if err != nil {
+		return commonsteps.HaltOnError(state, ui, err)
+	}
+
+	pipe := fmt.Sprintf(`\\.\pipe\packer-%s-com%d`, vmName, serialLogComPort)
+
+	ui.Say(fmt.Sprintf("Logging serial port output to %s...", s.Path))
+
+	err = driver.SetVirtualMachineComPort(vmName, serialLogComPort, pipe)
+	if err != nil {
+		err := fmt.Errorf("Error connecting serial port: %s", err)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+
+	s.file, err = os.Create(s.Path)
+	if err != nil {
+		err := fmt.Errorf("Error creating serial log file: %s", err)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+
+	s.stop = make(chan struct{})
+	s.done = make(chan struct{})
+	go s.copy(pipe)
+
+	return multistep.ActionContinue
+}
+
+// copy waits for the VM to serve the pipe and copies its output into the log
+// file until the pipe is closed.
+func (s *StepSerialLog) copy(pipe string) {
+	defer close(s.done)
+
+	var r io.ReadCloser
+	for {
+		var err error
+		if r, err = openSerialPipe(pipe); err == nil {
+			break
+		}
+
+		select {
+		case <-s.stop:
+			return
+		case <-time.After(time.Second):
+		}
+	}
+	defer r.Close()
+
+	if _, err := io.Copy(s.file, r); err != nil {
+		log.Printf("Serial log stopped: %s", err)
+	}
+}
+
+func (s *StepSerialLog) Cleanup(state multistep.StateBag) {
+	if s.file == nil {
+		return
+	}
+
+	// The VM has been turned off by now, which closes the pipe
+	close(s.stop)
+	select {
+	case <-s.done:
+	case <-time.After(5 * time.Second):
+		log.Printf("Timeout waiting for the serial log to be written")
+	}
+	s.file.Close()
+
+	_, cancelled := state.GetOk(multistep.StateCancelled)
+	_, halted := state.GetOk(multistep.StateHalted)
+	if !s.ShowOnError || !(cancelled || halted) {
+		return
+	}
+
+	lines, err := tailLines(s.Path, serialLogTailLines)
+	if err != nil {
+		log.Printf("Error reading serial log: %s", err)
+		return
+	}
+	if len(lines) == 0 {
+		return
+	}
+
+	ui, err := commonsteps.GetUi(state)
+	if commonsteps.SkipCleanupOnError(err) {
+		return
+	}
+	ui.Error(fmt.Sprintf("Last %d lines of the serial log %s:\n%s", len(lines), s.Path,
+		strings.Join(lines, "\n")))
+}
+
+// tailLines returns at most the last n lines of the file at path.
+func tailLines(path string, n int) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		lines = append(lines, strings.TrimRight(scanner.Text(), "\r"))
+		if len(lines) > n {
+			lines = lines[1:]
+		}
+	}
+	return lines, scanner.Err()
+}
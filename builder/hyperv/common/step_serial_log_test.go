@@ -0,0 +1,136 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/packer/packer"
+	"github.com/hashicorp/packer/packer-plugin-sdk/multistep"
+)
+
+func testSerialPipe(t *testing.T, output string) {
+	openSerialPipe = func(path string) (io.ReadCloser, error) {
+		if path != `\\.\pipe\packer-foo-com1` {
+			t.Errorf("bad pipe: %s", path)
+		}
+		return ioutil.NopCloser(strings.NewReader(output)), nil
+	}
+}
+
+func TestStepSerialLog_impl(t *testing.T) {
+	var _ multistep.Step = new(StepSerialLog)
+}
+
+func TestStepSerialLog(t *testing.T) {
+	td, err := ioutil.TempDir("", "packer")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer os.RemoveAll(td)
+
+	defer func(open func(string) (io.ReadCloser, error)) { openSerialPipe = open }(openSerialPipe)
+	testSerialPipe(t, "Booting Linux\r\nKernel panic\r\n")
+
+	state := testState(t)
+	state.Put("vmName", "foo")
+	step := &StepSerialLog{Path: filepath.Join(td, "serial.log")}
+
+	driver := state.Get("driver").(*DriverMock)
+
+	// Test the run
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("Bad action: %v", action)
+	}
+	if _, ok := state.GetOk("error"); ok {
+		t.Fatal("Should NOT have error")
+	}
+	if driver.SetVirtualMachineComPort_Number != 1 {
+		t.Fatalf("bad com port: %d", driver.SetVirtualMachineComPort_Number)
+	}
+	if driver.SetVirtualMachineComPort_Path != `\\.\pipe\packer-foo-com1` {
+		t.Fatalf("bad pipe: %s", driver.SetVirtualMachineComPort_Path)
+	}
+
+	// Test the cleanup
+	step.Cleanup(state)
+	contents, err := ioutil.ReadFile(step.Path)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if string(contents) != "Booting Linux\r\nKernel panic\r\n" {
+		t.Fatalf("bad serial log: %q", contents)
+	}
+}
+
+func TestStepSerialLog_showOnError(t *testing.T) {
+	td, err := ioutil.TempDir("", "packer")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer os.RemoveAll(td)
+
+	var output strings.Builder
+	for i := 1; i <= serialLogTailLines+10; i++ {
+		fmt.Fprintf(&output, "line %d\n", i)
+	}
+
+	defer func(open func(string) (io.ReadCloser, error)) { openSerialPipe = open }(openSerialPipe)
+	testSerialPipe(t, output.String())
+
+	state := testState(t)
+	state.Put("vmName", "foo")
+	step := &StepSerialLog{Path: filepath.Join(td, "serial.log"), ShowOnError: true}
+
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("Bad action: %v", action)
+	}
+
+	state.Put(multistep.StateHalted, true)
+	step.Cleanup(state)
+
+	ui := state.Get("ui").(*packer.BasicUi)
+	uiOutput := ui.Writer.(interface{ String() string }).String()
+	if !strings.Contains(uiOutput, fmt.Sprintf("line %d\n", serialLogTailLines+10)) {
+		t.Fatalf("Should have shown the end of the serial log: %s", uiOutput)
+	}
+	if strings.Contains(uiOutput, "line 10\n") {
+		t.Fatalf("Should only have shown the last lines of the serial log: %s", uiOutput)
+	}
+}
+
+func TestStepSerialLog_disabled(t *testing.T) {
+	state := testState(t)
+	step := new(StepSerialLog)
+
+	driver := state.Get("driver").(*DriverMock)
+
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("Bad action: %v", action)
+	}
+	if driver.SetVirtualMachineComPort_Called {
+		t.Fatal("Should NOT have called SetVirtualMachineComPort")
+	}
+	step.Cleanup(state)
+}
+
+func TestStepDisconnectSerialPort(t *testing.T) {
+	state := testState(t)
+	state.Put("vmName", "foo")
+	step := &StepDisconnectSerialPort{SerialLogFile: "serial.log"}
+
+	driver := state.Get("driver").(*DriverMock)
+	driver.SetVirtualMachineComPort_Path = `\\.\pipe\packer-foo-com1`
+
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("Bad action: %v", action)
+	}
+	if !driver.SetVirtualMachineComPort_Called || driver.SetVirtualMachineComPort_Path != "" {
+		t.Fatal("Should have disconnected the serial port")
+	}
+}
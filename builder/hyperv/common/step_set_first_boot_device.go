@@ -7,8 +7,8 @@ import (
 	"strconv"
 	"strings"
 
-	"github.com/hashicorp/packer/packer"
 	"github.com/hashicorp/packer/packer-plugin-sdk/multistep"
+	"github.com/hashicorp/packer/packer-plugin-sdk/multistep/commonsteps"
 )
 
 type StepSetFirstBootDevice struct {
@@ -102,9 +102,14 @@ func ParseBootDeviceIdentifier(deviceIdentifier string, generation uint) (string
 
 func (s *StepSetFirstBootDevice) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
 
-	driver := state.Get("driver").(Driver)
-	ui := state.Get("ui").(packer.Ui)
-	vmName := state.Get("vmName").(string)
+	ui, driver, err := getUiAndDriver(state)
+	if err != nil {
+		return commonsteps.HaltOnError(state, ui, err)
+	}
+	vmName, err := getVMName(state)
+	if err != nil {
+		return commonsteps.HaltOnError(state, ui, err)
+	}
 
 	if s.FirstBootDevice != "" {
 
@@ -118,16 +123,18 @@ func (s *StepSetFirstBootDevice) Run(ctx context.Context, state multistep.StateB
 					// the "DVD" controller is special, we only apply the setting if we actually mounted
 					// an ISO and only if that was mounted as the "IsoUrl" not a secondary ISO.
 
-					dvdControllerState := state.Get("os.dvd.properties")
-					if dvdControllerState == nil {
+					if state.Get("os.dvd.properties") == nil {
 
 						ui.Say("First Boot Device is DVD, but no primary ISO mounted. Ignoring.")
 						return multistep.ActionContinue
 
 					}
 
+					var dvdController DvdControllerProperties
+					if err := multistep.GetValue(state, "os.dvd.properties", &dvdController); err != nil {
+						return commonsteps.HaltOnError(state, ui, err)
+					}
 					ui.Say(fmt.Sprintf("Setting boot device to %q", s.FirstBootDevice))
-					dvdController := dvdControllerState.(DvdControllerProperties)
 					err = driver.SetFirstBootDevice(vmName, controllerType, dvdController.ControllerNumber, dvdController.ControllerLocation, s.Generation)
 
 				}
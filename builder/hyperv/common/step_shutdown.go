@@ -1,19 +1,32 @@
 package common
 
 import (
-	"bytes"
 	"context"
-	"errors"
-	"fmt"
-	"log"
 	"time"
 
-	"github.com/hashicorp/packer/packer"
+	"github.com/hashicorp/packer/packer-plugin-sdk/logging"
 	"github.com/hashicorp/packer/packer-plugin-sdk/multistep"
+	"github.com/hashicorp/packer/packer-plugin-sdk/multistep/commonsteps"
 )
 
 // This step shuts down the machine. It first attempts to do so gracefully,
-// but ultimately forcefully shuts it down if that fails.
+// but ultimately forcefully shuts it down if that fails. The shutdown itself
+// is handled by commonsteps.StepShutdown.
+//
+// If Retries is greater than zero and the shutdown command can't be sent,
+// the command is re-sent every RetryInterval up to Retries more times before
+// the machine is forcefully turned off.
+//
+// If ForceStop is true and the machine hasn't shut down within Timeout after
+// the shutdown command was sent, it is forcefully turned off and the build
+// continues instead of failing.
+//
+// If WaitForHeartbeat is true, the machine only counts as shut down once,
+// in addition to no longer running, its integration services heartbeat has
+// stopped and its virtual hard disks are no longer locked.
+//
+// Once the machine is shut down, the step waits for PostShutdownDelay before
+// continuing.
 //
 // Uses:
 //   communicator packer.Communicator
@@ -24,67 +37,83 @@ import (
 // Produces:
 //   <nothing>
 type StepShutdown struct {
-	Command string
-	Timeout time.Duration
+	Command           string
+	Timeout           time.Duration
+	Retries           int
+	RetryInterval     time.Duration
+	ForceStop         bool
+	WaitForHeartbeat  bool
+	PostShutdownDelay time.Duration
+}
+
+// Heartbeat statuses reported once the guest has stopped talking to the
+// integration services.
+var heartbeatStoppedStatuses = map[string]bool{
+	"Off":               true,
+	"LostCommunication": true,
+	"NoContact":         true,
 }
 
 func (s *StepShutdown) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	driver, err := getDriver(state)
+	if err != nil {
+		return commonsteps.HaltOnError(state, nil, err)
+	}
+	vmName, err := getVMName(state)
+	if err != nil {
+		return commonsteps.HaltOnError(state, nil, err)
+	}
+	logger := multistep.GetLogger(state, "hyperv")
 
-	comm := state.Get("communicator").(packer.Communicator)
-	driver := state.Get("driver").(Driver)
-	ui := state.Get("ui").(packer.Ui)
-	vmName := state.Get("vmName").(string)
+	step := &commonsteps.StepShutdown{
+		Command:           s.Command,
+		Timeout:           s.Timeout,
+		Retries:           s.Retries,
+		RetryInterval:     s.RetryInterval,
+		ForceStop:         s.ForceStop,
+		PostShutdownDelay: s.PostShutdownDelay,
+		IsShutDown: func(multistep.StateBag) (bool, error) {
+			return s.isShutDown(logger, driver, vmName), nil
+		},
+		Stop: func(multistep.StateBag) error {
+			return driver.Stop(vmName)
+		},
+	}
+	return step.Run(ctx, state)
+}
 
-	if s.Command != "" {
-		ui.Say("Gracefully halting virtual machine...")
-		log.Printf("Executing shutdown command: %s", s.Command)
+// isShutDown reports whether the machine has finished shutting down.
+func (s *StepShutdown) isShutDown(logger *logging.Logger, driver Driver, vmName string) bool {
+	running, _ := driver.IsRunning(vmName)
+	if running {
+		return false
+	}
 
-		var stdout, stderr bytes.Buffer
-		cmd := &packer.RemoteCmd{
-			Command: s.Command,
-			Stdout:  &stdout,
-			Stderr:  &stderr,
-		}
-		if err := comm.Start(ctx, cmd); err != nil {
-			err := fmt.Errorf("Failed to send shutdown command: %s", err)
-			state.Put("error", err)
-			ui.Error(err.Error())
-			return multistep.ActionHalt
-		}
+	if !s.WaitForHeartbeat {
+		return true
+	}
 
-		// Wait for the machine to actually shut down
-		log.Printf("Waiting max %s for shutdown to complete", s.Timeout)
-		shutdownTimer := time.After(s.Timeout)
-		for {
-			running, _ := driver.IsRunning(vmName)
-			if !running {
-				break
-			}
+	status, err := driver.GetVirtualMachineHeartbeatStatus(vmName)
+	if err != nil {
+		logger.Warnf("Error checking the heartbeat of the VM: %s", err)
+		return false
+	}
+	if !heartbeatStoppedStatuses[status] {
+		logger.Debugf("Waiting for the heartbeat of the VM to stop, currently: %s", status)
+		return false
+	}
 
-			select {
-			case <-shutdownTimer:
-				log.Printf("Shutdown stdout: %s", stdout.String())
-				log.Printf("Shutdown stderr: %s", stderr.String())
-				err := errors.New("Timeout while waiting for machine to shut down.")
-				state.Put("error", err)
-				ui.Error(err.Error())
-				return multistep.ActionHalt
-			default:
-				time.Sleep(500 * time.Millisecond)
-			}
-		}
-	} else {
-		ui.Say("Forcibly halting virtual machine...")
-		if err := driver.Stop(vmName); err != nil {
-			err := fmt.Errorf("Error stopping VM: %s", err)
-			state.Put("error", err)
-			ui.Error(err.Error())
-			return multistep.ActionHalt
-		}
+	locked, err := driver.AreVirtualMachineDisksLocked(vmName)
+	if err != nil {
+		logger.Warnf("Error checking if the disks of the VM are locked: %s", err)
+		return false
+	}
+	if locked {
+		logger.Debugf("Waiting for the disks of the VM to be released")
+		return false
 	}
 
-	log.Println("VM shut down.")
-	return multistep.ActionContinue
+	return true
 }
 
 func (s *StepShutdown) Cleanup(state multistep.StateBag) {}
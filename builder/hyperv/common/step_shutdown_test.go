@@ -0,0 +1,338 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/packer/packer"
+	"github.com/hashicorp/packer/packer-plugin-sdk/multistep"
+)
+
+// failingCommunicator fails to start the first FailCount commands it is
+// given and behaves like a MockCommunicator afterwards.
+type failingCommunicator struct {
+	packer.MockCommunicator
+	FailCount  int
+	StartCount int
+}
+
+func (c *failingCommunicator) Start(ctx context.Context, rc *packer.RemoteCmd) error {
+	c.StartCount++
+	if c.StartCount <= c.FailCount {
+		return errors.New("connection refused")
+	}
+	return c.MockCommunicator.Start(ctx, rc)
+}
+
+func TestStepShutdown_impl(t *testing.T) {
+	var _ multistep.Step = new(StepShutdown)
+}
+
+func TestStepShutdown_noShutdownCommand(t *testing.T) {
+	state := testState(t)
+	step := new(StepShutdown)
+
+	comm := new(packer.MockCommunicator)
+	state.Put("communicator", comm)
+	state.Put("vmName", "foo")
+
+	driver := state.Get("driver").(*DriverMock)
+
+	// Test the run
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("Bad action: %v", action)
+	}
+	if _, ok := state.GetOk("error"); ok {
+		t.Fatal("Should NOT have error")
+	}
+
+	// Test that Stop was just called
+	if driver.Stop_VmName != "foo" {
+		t.Fatal("Should have called Stop")
+	}
+	if comm.StartCalled {
+		t.Fatal("Should NOT have called Start")
+	}
+}
+
+func TestStepShutdown_shutdownCommand(t *testing.T) {
+	state := testState(t)
+	step := new(StepShutdown)
+	step.Command = "shutdown /s /t 0"
+	step.Timeout = 1 * time.Second
+
+	comm := new(packer.MockCommunicator)
+	state.Put("communicator", comm)
+	state.Put("vmName", "foo")
+
+	driver := state.Get("driver").(*DriverMock)
+
+	// Test the run
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("Bad action: %v", action)
+	}
+	if _, ok := state.GetOk("error"); ok {
+		t.Fatal("Should NOT have error")
+	}
+
+	// Test that Stop was not called
+	if driver.Stop_Called {
+		t.Fatal("Should NOT have called Stop")
+	}
+	if comm.StartCmd.Command != step.Command {
+		t.Fatal("Should have called Start with the shutdown command")
+	}
+}
+
+func TestStepShutdown_shutdownCommandFailsWithoutRetries(t *testing.T) {
+	state := testState(t)
+	step := new(StepShutdown)
+	step.Command = "shutdown /s /t 0"
+	step.Timeout = 1 * time.Second
+
+	comm := &failingCommunicator{FailCount: 1}
+	state.Put("communicator", comm)
+	state.Put("vmName", "foo")
+
+	driver := state.Get("driver").(*DriverMock)
+
+	// Test the run
+	if action := step.Run(context.Background(), state); action != multistep.ActionHalt {
+		t.Fatalf("Bad action: %v", action)
+	}
+	if _, ok := state.GetOk("error"); !ok {
+		t.Fatal("Should have error")
+	}
+
+	if comm.StartCount != 1 {
+		t.Fatalf("Should have called Start once. Got: %d", comm.StartCount)
+	}
+	if driver.Stop_Called {
+		t.Fatal("Should NOT have called Stop")
+	}
+}
+
+func TestStepShutdown_shutdownCommandRetries(t *testing.T) {
+	state := testState(t)
+	step := new(StepShutdown)
+	step.Command = "shutdown /s /t 0"
+	step.Timeout = 1 * time.Second
+	step.Retries = 2
+	step.RetryInterval = 10 * time.Millisecond
+
+	comm := &failingCommunicator{FailCount: 2}
+	state.Put("communicator", comm)
+	state.Put("vmName", "foo")
+
+	driver := state.Get("driver").(*DriverMock)
+
+	// Test the run
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("Bad action: %v", action)
+	}
+	if _, ok := state.GetOk("error"); ok {
+		t.Fatal("Should NOT have error")
+	}
+
+	if comm.StartCount != 3 {
+		t.Fatalf("Should have called Start 3 times. Got: %d", comm.StartCount)
+	}
+	if driver.Stop_Called {
+		t.Fatal("Should NOT have called Stop")
+	}
+}
+
+func TestStepShutdown_shutdownCommandRetriesExhausted(t *testing.T) {
+	state := testState(t)
+	step := new(StepShutdown)
+	step.Command = "shutdown /s /t 0"
+	step.Timeout = 1 * time.Second
+	step.Retries = 2
+	step.RetryInterval = 10 * time.Millisecond
+
+	comm := &failingCommunicator{FailCount: 3}
+	state.Put("communicator", comm)
+	state.Put("vmName", "foo")
+
+	driver := state.Get("driver").(*DriverMock)
+
+	// Test the run
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("Bad action: %v", action)
+	}
+	if _, ok := state.GetOk("error"); ok {
+		t.Fatal("Should NOT have error")
+	}
+
+	if comm.StartCount != 3 {
+		t.Fatalf("Should have called Start 3 times. Got: %d", comm.StartCount)
+	}
+	if driver.Stop_VmName != "foo" {
+		t.Fatal("Should have fallen back to calling Stop")
+	}
+}
+
+func TestStepShutdown_shutdownTimeout(t *testing.T) {
+	state := testState(t)
+	step := new(StepShutdown)
+	step.Command = "shutdown /s /t 0"
+	step.Timeout = 10 * time.Millisecond
+
+	comm := new(packer.MockCommunicator)
+	state.Put("communicator", comm)
+	state.Put("vmName", "foo")
+
+	driver := state.Get("driver").(*DriverMock)
+	driver.IsRunning_Return = true
+
+	// Test the run
+	if action := step.Run(context.Background(), state); action != multistep.ActionHalt {
+		t.Fatalf("Bad action: %v", action)
+	}
+	if _, ok := state.GetOk("error"); !ok {
+		t.Fatal("Should have error")
+	}
+
+	if driver.Stop_Called {
+		t.Fatal("Should NOT have called Stop")
+	}
+}
+
+func TestStepShutdown_shutdownTimeoutForceStop(t *testing.T) {
+	state := testState(t)
+	step := new(StepShutdown)
+	step.Command = "shutdown /s /t 0"
+	step.Timeout = 10 * time.Millisecond
+	step.ForceStop = true
+
+	comm := new(packer.MockCommunicator)
+	state.Put("communicator", comm)
+	state.Put("vmName", "foo")
+
+	driver := state.Get("driver").(*DriverMock)
+	driver.IsRunning_Return = true
+
+	// Test the run
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("Bad action: %v", action)
+	}
+	if _, ok := state.GetOk("error"); ok {
+		t.Fatal("Should NOT have error")
+	}
+
+	if driver.Stop_VmName != "foo" {
+		t.Fatal("Should have called Stop")
+	}
+}
+
+func TestStepShutdown_waitForHeartbeat(t *testing.T) {
+	state := testState(t)
+	step := new(StepShutdown)
+	step.Command = "shutdown /s /t 0"
+	step.Timeout = 1 * time.Second
+	step.WaitForHeartbeat = true
+
+	comm := new(packer.MockCommunicator)
+	state.Put("communicator", comm)
+	state.Put("vmName", "foo")
+
+	driver := state.Get("driver").(*DriverMock)
+	driver.GetVirtualMachineHeartbeatStatus_Return = "LostCommunication"
+
+	// Test the run
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("Bad action: %v", action)
+	}
+	if _, ok := state.GetOk("error"); ok {
+		t.Fatal("Should NOT have error")
+	}
+
+	if !driver.GetVirtualMachineHeartbeatStatus_Called {
+		t.Fatal("Should have called GetVirtualMachineHeartbeatStatus")
+	}
+	if !driver.AreVirtualMachineDisksLocked_Called {
+		t.Fatal("Should have called AreVirtualMachineDisksLocked")
+	}
+}
+
+func TestStepShutdown_waitForHeartbeatTimeout(t *testing.T) {
+	state := testState(t)
+	step := new(StepShutdown)
+	step.Command = "shutdown /s /t 0"
+	step.Timeout = 1 * time.Second
+	step.WaitForHeartbeat = true
+
+	comm := new(packer.MockCommunicator)
+	state.Put("communicator", comm)
+	state.Put("vmName", "foo")
+
+	driver := state.Get("driver").(*DriverMock)
+	driver.GetVirtualMachineHeartbeatStatus_Return = "Off"
+	driver.AreVirtualMachineDisksLocked_Return = true
+
+	// Test the run
+	if action := step.Run(context.Background(), state); action != multistep.ActionHalt {
+		t.Fatalf("Bad action: %v", action)
+	}
+	if _, ok := state.GetOk("error"); !ok {
+		t.Fatal("Should have error")
+	}
+}
+
+func TestStepShutdown_postShutdownDelay(t *testing.T) {
+	state := testState(t)
+	step := new(StepShutdown)
+	step.Command = "shutdown /s /t 0"
+	step.Timeout = 1 * time.Second
+	step.PostShutdownDelay = 10 * time.Millisecond
+
+	comm := new(packer.MockCommunicator)
+	state.Put("communicator", comm)
+	state.Put("vmName", "foo")
+
+	// Test the run
+	start := time.Now()
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("Bad action: %v", action)
+	}
+	if _, ok := state.GetOk("error"); ok {
+		t.Fatal("Should NOT have error")
+	}
+	if time.Since(start) < step.PostShutdownDelay {
+		t.Fatal("Should have waited for the post shutdown delay")
+	}
+}
+
+func TestStepShutdown_shutdownCommandRetriesCancelled(t *testing.T) {
+	state := testState(t)
+	step := new(StepShutdown)
+	step.Command = "shutdown /s /t 0"
+	step.Timeout = 1 * time.Second
+	step.Retries = 2
+	step.RetryInterval = time.Minute
+
+	comm := &failingCommunicator{FailCount: 3}
+	state.Put("communicator", comm)
+	state.Put("vmName", "foo")
+
+	driver := state.Get("driver").(*DriverMock)
+
+	// Test the run
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	if action := step.Run(ctx, state); action != multistep.ActionHalt {
+		t.Fatalf("Bad action: %v", action)
+	}
+	if _, ok := state.GetOk("error"); !ok {
+		t.Fatal("Should have error")
+	}
+
+	if comm.StartCount != 1 {
+		t.Fatalf("Should have called Start once. Got: %d", comm.StartCount)
+	}
+	if driver.Stop_VmName != "" {
+		t.Fatal("Should NOT have called Stop")
+	}
+}
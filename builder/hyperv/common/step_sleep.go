@@ -5,8 +5,8 @@ import (
 	"fmt"
 	"time"
 
-	"github.com/hashicorp/packer/packer"
 	"github.com/hashicorp/packer/packer-plugin-sdk/multistep"
+	"github.com/hashicorp/packer/packer-plugin-sdk/multistep/commonsteps"
 )
 
 type StepSleep struct {
@@ -15,7 +15,10 @@ type StepSleep struct {
 }
 
 func (s *StepSleep) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
-	ui := state.Get("ui").(packer.Ui)
+	ui, err := commonsteps.GetUi(state)
+	if err != nil {
+		return commonsteps.HaltOnError(state, nil, err)
+	}
 
 	if len(s.ActionName) > 0 {
 		ui.Say(s.ActionName + "! Waiting for " + fmt.Sprintf("%v", uint(s.Minutes)) +
@@ -3,12 +3,14 @@ package common
 import (
 	"context"
 	"fmt"
+	"os"
 	"strings"
 	"time"
 
-	"github.com/hashicorp/packer/packer"
+	"github.com/hashicorp/packer/helper/communicator"
 	"github.com/hashicorp/packer/packer-plugin-sdk/bootcommand"
 	"github.com/hashicorp/packer/packer-plugin-sdk/multistep"
+	"github.com/hashicorp/packer/packer-plugin-sdk/multistep/commonsteps"
 	"github.com/hashicorp/packer/packer-plugin-sdk/template/interpolate"
 )
 
@@ -16,23 +18,42 @@ type bootCommandTemplateData struct {
 	HTTPIP   string
 	HTTPPort int
 	Name     string
+	// SSHPublicKey is the SSH public key in OpenSSH authorized_keys format.
+	SSHPublicKey string
+	// HTTPToken is the token the HTTP server serves the files under.
+	HTTPToken string
 }
 
-// This step "types" the boot command into the VM via the Hyper-V virtual keyboard
+// This step "types" the boot command into the VM via the Hyper-V virtual keyboard.
+// If TypeText is true, runs of characters are typed as text instead of scan
+// codes.
 type StepTypeBootCommand struct {
 	BootCommand   string
 	BootWait      time.Duration
 	SwitchName    string
 	Ctx           interpolate.Context
 	GroupInterval time.Duration
+	TypeText      bool
+	Comm          *communicator.Config
 }
 
 func (s *StepTypeBootCommand) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
-	httpPort := state.Get("http_port").(int)
-	ui := state.Get("ui").(packer.Ui)
-	driver := state.Get("driver").(Driver)
-	vmName := state.Get("vmName").(string)
-	hostIp := state.Get("http_ip").(string)
+	ui, driver, err := getUiAndDriver(state)
+	if err != nil {
+		return commonsteps.HaltOnError(state, ui, err)
+	}
+	vmName, err := getVMName(state)
+	if err != nil {
+		return commonsteps.HaltOnError(state, ui, err)
+	}
+	httpPort, err := multistep.GetInt(state, "http_port")
+	if err != nil {
+		return commonsteps.HaltOnError(state, ui, err)
+	}
+	hostIp, err := multistep.GetString(state, "http_ip")
+	if err != nil {
+		return commonsteps.HaltOnError(state, ui, err)
+	}
 
 	// Wait the for the vm to boot.
 	if int64(s.BootWait) > 0 {
@@ -45,17 +66,41 @@ func (s *StepTypeBootCommand) Run(ctx context.Context, state multistep.StateBag)
 		}
 	}
 
+	var sshPublicKey string
+	if s.Comm != nil {
+		sshPublicKey = string(s.Comm.SSHPublicKey)
+	}
+	httpToken, _ := state.Get("http_token").(string)
 	s.Ctx.Data = &bootCommandTemplateData{
 		hostIp,
 		httpPort,
 		vmName,
+		sshPublicKey,
+		httpToken,
 	}
 
 	sendCodes := func(codes []string) error {
 		scanCodesToSendString := strings.Join(codes, " ")
 		return driver.TypeScanCodes(vmName, scanCodesToSendString)
 	}
-	d := bootcommand.NewPCXTDriver(sendCodes, 32, s.GroupInterval)
+	var d bootcommand.BCDriver = bootcommand.NewPCXTDriver(sendCodes, 32, s.GroupInterval)
+	if s.TypeText {
+		// Wait as long after typing text as the scan code driver does
+		interval := bootcommand.PackerKeyDefault
+		if delay, err := time.ParseDuration(os.Getenv(bootcommand.PackerKeyEnv)); err == nil {
+			interval = delay
+		}
+		if s.GroupInterval > 0 {
+			interval = s.GroupInterval
+		}
+		d = &typeTextDriver{
+			BCDriver: d,
+			typeText: func(text string) error {
+				return driver.TypeText(vmName, text)
+			},
+			interval: interval,
+		}
+	}
 
 	ui.Say("Typing the boot command...")
 	command, err := interpolate.Render(s.BootCommand, &s.Ctx)
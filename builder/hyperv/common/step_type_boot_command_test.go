@@ -0,0 +1,128 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/packer/packer-plugin-sdk/multistep"
+)
+
+func TestStepTypeBootCommand_impl(t *testing.T) {
+	var _ multistep.Step = new(StepTypeBootCommand)
+}
+
+func testBootCommandState(t *testing.T) multistep.StateBag {
+	state := testState(t)
+	state.Put("http_port", 8080)
+	state.Put("http_ip", "10.0.0.1")
+	state.Put("vmName", "foo")
+	return state
+}
+
+func TestStepTypeBootCommand(t *testing.T) {
+	state := testBootCommandState(t)
+	step := &StepTypeBootCommand{
+		BootCommand:   "ab<enter>",
+		GroupInterval: time.Millisecond,
+	}
+
+	driver := state.Get("driver").(*DriverMock)
+
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("Bad action: %v", action)
+	}
+	if _, ok := state.GetOk("error"); ok {
+		t.Fatal("Should NOT have error")
+	}
+
+	if driver.TypeText_Called {
+		t.Fatal("Should NOT have called TypeText")
+	}
+	if driver.TypeScanCodes_ScanCodes != "1e 9e 30 b0 1c 9c" {
+		t.Fatalf("bad scan codes: %s", driver.TypeScanCodes_ScanCodes)
+	}
+}
+
+func TestStepTypeBootCommand_typeText(t *testing.T) {
+	state := testBootCommandState(t)
+	step := &StepTypeBootCommand{
+		BootCommand:   "<esc>linux ks=http://{{ .HTTPIP }}:{{ .HTTPPort }}/ks.cfg<enter><wait1ms>A<leftShiftOn>b<leftShiftOff>",
+		GroupInterval: time.Millisecond,
+		TypeText:      true,
+	}
+
+	driver := state.Get("driver").(*DriverMock)
+	var scanCodes []string
+
+	// Record every call, the mock only keeps the last scan codes
+	state.Put("driver", &recordingDriver{DriverMock: driver, scanCodes: &scanCodes})
+
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("Bad action: %v", action)
+	}
+	if _, ok := state.GetOk("error"); ok {
+		t.Fatal("Should NOT have error")
+	}
+
+	if driver.TypeText_VmName != "foo" {
+		t.Fatalf("bad vm name: %s", driver.TypeText_VmName)
+	}
+	expectedText := []string{"linux ks=http://10.0.0.1:8080/ks.cfg", "A"}
+	if !reflect.DeepEqual(driver.TypeText_Text, expectedText) {
+		t.Fatalf("bad text: %#v", driver.TypeText_Text)
+	}
+	expectedCodes := []string{"01 81", "1c 9c", "2a 30 b0 aa"}
+	if !reflect.DeepEqual(scanCodes, expectedCodes) {
+		t.Fatalf("bad scan codes: %#v", scanCodes)
+	}
+}
+
+func TestStepTypeBootCommand_typeTextError(t *testing.T) {
+	state := testBootCommandState(t)
+	step := &StepTypeBootCommand{
+		BootCommand: "abc",
+		TypeText:    true,
+	}
+
+	driver := state.Get("driver").(*DriverMock)
+	driver.TypeText_Err = errors.New("keyboard is unavailable")
+
+	if action := step.Run(context.Background(), state); action != multistep.ActionHalt {
+		t.Fatalf("Bad action: %v", action)
+	}
+	if _, ok := state.GetOk("error"); !ok {
+		t.Fatal("Should have error")
+	}
+}
+
+func TestCheckBootCommand(t *testing.T) {
+	warns := CheckBootCommand("<esc><wait10s>linux <leftShiftOn>a<leftShiftOff> <aOn><aOff><enter>")
+	if len(warns) != 0 {
+		t.Fatalf("should not have warnings: %#v", warns)
+	}
+
+	warns = CheckBootCommand("linux<enetr><wait>")
+	if len(warns) != 1 || !strings.Contains(warns[0], "<enetr>") {
+		t.Fatalf("bad warnings: %#v", warns)
+	}
+
+	warns = CheckBootCommand("linux\nks=ks.cfg\n<enter>\t")
+	if len(warns) != 2 {
+		t.Fatalf("bad warnings: %#v", warns)
+	}
+}
+
+// recordingDriver records the scan codes of every call to TypeScanCodes.
+type recordingDriver struct {
+	*DriverMock
+	scanCodes *[]string
+}
+
+func (d *recordingDriver) TypeScanCodes(vmName string, scanCodes string) error {
+	*d.scanCodes = append(*d.scanCodes, scanCodes)
+	return d.DriverMock.TypeScanCodes(vmName, scanCodes)
+}
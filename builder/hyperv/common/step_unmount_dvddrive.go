@@ -4,27 +4,33 @@ import (
 	"context"
 	"fmt"
 
-	"github.com/hashicorp/packer/packer"
 	"github.com/hashicorp/packer/packer-plugin-sdk/multistep"
+	"github.com/hashicorp/packer/packer-plugin-sdk/multistep/commonsteps"
 )
 
 type StepUnmountDvdDrive struct {
 }
 
 func (s *StepUnmountDvdDrive) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
-	driver := state.Get("driver").(Driver)
-	vmName := state.Get("vmName").(string)
-	ui := state.Get("ui").(packer.Ui)
+	ui, driver, err := getUiAndDriver(state)
+	if err != nil {
+		return commonsteps.HaltOnError(state, ui, err)
+	}
+	vmName, err := getVMName(state)
+	if err != nil {
+		return commonsteps.HaltOnError(state, ui, err)
+	}
 
 	ui.Say("Unmount/delete os dvd drive...")
 
-	dvdControllerState := state.Get("os.dvd.properties")
-
-	if dvdControllerState == nil {
+	if state.Get("os.dvd.properties") == nil {
 		return multistep.ActionContinue
 	}
 
-	dvdController := dvdControllerState.(DvdControllerProperties)
+	var dvdController DvdControllerProperties
+	if err := multistep.GetValue(state, "os.dvd.properties", &dvdController); err != nil {
+		return commonsteps.HaltOnError(state, ui, err)
+	}
 
 	if dvdController.Existing {
 		ui.Say(fmt.Sprintf("Unmounting os dvd drives controller %d location %d ...",
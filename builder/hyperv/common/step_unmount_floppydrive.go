@@ -4,8 +4,8 @@ import (
 	"context"
 	"fmt"
 
-	"github.com/hashicorp/packer/packer"
 	"github.com/hashicorp/packer/packer-plugin-sdk/multistep"
+	"github.com/hashicorp/packer/packer-plugin-sdk/multistep/commonsteps"
 )
 
 type StepUnmountFloppyDrive struct {
@@ -13,19 +13,24 @@ type StepUnmountFloppyDrive struct {
 }
 
 func (s *StepUnmountFloppyDrive) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
-	driver := state.Get("driver").(Driver)
-	ui := state.Get("ui").(packer.Ui)
+	ui, driver, err := getUiAndDriver(state)
+	if err != nil {
+		return commonsteps.HaltOnError(state, ui, err)
+	}
 
 	if s.Generation > 1 {
 		return multistep.ActionContinue
 	}
 
-	vmName := state.Get("vmName").(string)
+	vmName, err := getVMName(state)
+	if err != nil {
+		return commonsteps.HaltOnError(state, ui, err)
+	}
 	ui.Say("Unmount/delete floppy drive (Run)...")
 
 	errorMsg := "Error Unmounting floppy drive: %s"
 
-	err := driver.UnmountFloppyDrive(vmName)
+	err = driver.UnmountFloppyDrive(vmName)
 	if err != nil {
 		err := fmt.Errorf(errorMsg, err)
 		state.Put("error", err)
@@ -4,27 +4,33 @@ import (
 	"context"
 	"fmt"
 
-	"github.com/hashicorp/packer/packer"
 	"github.com/hashicorp/packer/packer-plugin-sdk/multistep"
+	"github.com/hashicorp/packer/packer-plugin-sdk/multistep/commonsteps"
 )
 
 type StepUnmountGuestAdditions struct {
 }
 
 func (s *StepUnmountGuestAdditions) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
-	driver := state.Get("driver").(Driver)
-	vmName := state.Get("vmName").(string)
-	ui := state.Get("ui").(packer.Ui)
+	ui, driver, err := getUiAndDriver(state)
+	if err != nil {
+		return commonsteps.HaltOnError(state, ui, err)
+	}
+	vmName, err := getVMName(state)
+	if err != nil {
+		return commonsteps.HaltOnError(state, ui, err)
+	}
 
 	ui.Say("Unmount/delete Integration Services dvd drive...")
 
-	dvdControllerState := state.Get("guest.dvd.properties")
-
-	if dvdControllerState == nil {
+	if state.Get("guest.dvd.properties") == nil {
 		return multistep.ActionContinue
 	}
 
-	dvdController := dvdControllerState.(DvdControllerProperties)
+	var dvdController DvdControllerProperties
+	if err := multistep.GetValue(state, "guest.dvd.properties", &dvdController); err != nil {
+		return commonsteps.HaltOnError(state, ui, err)
+	}
 
 	if dvdController.Existing {
 		ui.Say(fmt.Sprintf("Unmounting Integration Services dvd drives controller %d location %d ...",
@@ -4,27 +4,33 @@ import (
 	"context"
 	"fmt"
 
-	"github.com/hashicorp/packer/packer"
 	"github.com/hashicorp/packer/packer-plugin-sdk/multistep"
+	"github.com/hashicorp/packer/packer-plugin-sdk/multistep/commonsteps"
 )
 
 type StepUnmountSecondaryDvdImages struct {
 }
 
 func (s *StepUnmountSecondaryDvdImages) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
-	driver := state.Get("driver").(Driver)
-	ui := state.Get("ui").(packer.Ui)
-	vmName := state.Get("vmName").(string)
+	ui, driver, err := getUiAndDriver(state)
+	if err != nil {
+		return commonsteps.HaltOnError(state, ui, err)
+	}
+	vmName, err := getVMName(state)
+	if err != nil {
+		return commonsteps.HaltOnError(state, ui, err)
+	}
 
 	ui.Say("Unmount/delete secondary dvd drives...")
 
-	dvdControllersState := state.Get("secondary.dvd.properties")
-
-	if dvdControllersState == nil {
+	if state.Get("secondary.dvd.properties") == nil {
 		return multistep.ActionContinue
 	}
 
-	dvdControllers := dvdControllersState.([]DvdControllerProperties)
+	var dvdControllers []DvdControllerProperties
+	if err := multistep.GetValue(state, "secondary.dvd.properties", &dvdControllers); err != nil {
+		return commonsteps.HaltOnError(state, ui, err)
+	}
 
 	for _, dvdController := range dvdControllers {
 		if dvdController.Existing {
@@ -0,0 +1,81 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	"github.com/hashicorp/packer/packer-plugin-sdk/multistep"
+	"github.com/hashicorp/packer/packer-plugin-sdk/multistep/commonsteps"
+)
+
+// This step copies the local files that later steps attach to the VM, such
+// as the downloaded ISO, to the build directory on the remote Hyper-V host
+// and replaces their paths in the state with the paths on the host. The
+// copies are removed together with the build directory. The step does
+// nothing unless Enabled is true.
+//
+// Uses:
+//   build_dir string
+//   driver    Driver
+//   ui        packer.Ui
+//   <Keys>    string - The local paths of the files to copy
+//
+// Produces:
+//   <Keys> string - The paths of the copies on the remote host
+type StepUploadToHost struct {
+	Enabled bool
+	// The state keys of the paths to copy. Keys that aren't set are skipped.
+	Keys []string
+}
+
+func (s *StepUploadToHost) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	if !s.Enabled {
+		return multistep.ActionContinue
+	}
+
+	ui, driver, err := getUiAndDriver(state)
+	if err != nil {
+		return commonsteps.HaltOnError(state, ui, err)
+	}
+	buildDir, err := multistep.GetString(state, "build_dir")
+	if err != nil {
+		return commonsteps.HaltOnError(state, ui, err)
+	}
+
+	for _, key := range s.Keys {
+		v, ok := state.GetOk(key)
+		if !ok || v.(string) == "" {
+			continue
+		}
+		path := v.(string)
+
+		// The key keeps the names of the copies apart, as two files may
+		// have the same name
+		hostPath := buildDir + `\` + key + "-" + filepath.Base(path)
+
+		ui.Say(fmt.Sprintf("Copying %s to the Hyper-V host...", filepath.Base(path)))
+		err := driver.CopyFileToHost(path, hostPath)
+		if err != nil {
+			err := fmt.Errorf("Error copying %s to the Hyper-V host: %s", path, err)
+			state.Put("error", err)
+			ui.Error(err.Error())
+			return multistep.ActionHalt
+		}
+
+		state.Put(key, hostPath)
+	}
+
+	return multistep.ActionContinue
+}
+
+func (s *StepUploadToHost) Save(state multistep.StateBag) map[string]interface{} {
+	return multistep.SaveKeys(state, s.Keys...)
+}
+
+func (s *StepUploadToHost) Resume(ctx context.Context, state multistep.StateBag, saved map[string]interface{}) multistep.StepAction {
+	multistep.RestoreKeys(state, saved)
+	return multistep.ActionContinue
+}
+
+func (s *StepUploadToHost) Cleanup(state multistep.StateBag) {}
@@ -0,0 +1,85 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/packer/packer-plugin-sdk/multistep"
+)
+
+func TestStepUploadToHost_impl(t *testing.T) {
+	var _ multistep.Step = new(StepUploadToHost)
+}
+
+func TestStepUploadToHost(t *testing.T) {
+	state := testState(t)
+	step := &StepUploadToHost{
+		Enabled: true,
+		Keys:    []string{"iso_path", "cd_path"},
+	}
+
+	state.Put("build_dir", `D:\Builds\hyperv12345`)
+	state.Put("iso_path", "/cache/ubuntu.iso")
+
+	driver := state.Get("driver").(*DriverMock)
+
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("Bad action: %v", action)
+	}
+	if _, ok := state.GetOk("error"); ok {
+		t.Fatal("Should NOT have error")
+	}
+
+	hostPath := `D:\Builds\hyperv12345\iso_path-ubuntu.iso`
+	if !reflect.DeepEqual(driver.CopyFileToHost_Source, []string{"/cache/ubuntu.iso"}) ||
+		!reflect.DeepEqual(driver.CopyFileToHost_Destination, []string{hostPath}) {
+		t.Fatalf("bad copies: %#v %#v", driver.CopyFileToHost_Source, driver.CopyFileToHost_Destination)
+	}
+	if v := state.Get("iso_path").(string); v != hostPath {
+		t.Fatalf("bad iso_path: %s", v)
+	}
+	if _, ok := state.GetOk("cd_path"); ok {
+		t.Fatal("Should NOT set cd_path")
+	}
+}
+
+func TestStepUploadToHost_disabled(t *testing.T) {
+	state := testState(t)
+	step := &StepUploadToHost{
+		Keys: []string{"iso_path"},
+	}
+
+	state.Put("iso_path", "/cache/ubuntu.iso")
+
+	driver := state.Get("driver").(*DriverMock)
+
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("Bad action: %v", action)
+	}
+	if driver.CopyFileToHost_Called {
+		t.Fatal("Should NOT have called CopyFileToHost")
+	}
+}
+
+func TestStepUploadToHost_error(t *testing.T) {
+	state := testState(t)
+	step := &StepUploadToHost{
+		Enabled: true,
+		Keys:    []string{"iso_path"},
+	}
+
+	state.Put("build_dir", `D:\Builds\hyperv12345`)
+	state.Put("iso_path", "/cache/ubuntu.iso")
+
+	driver := state.Get("driver").(*DriverMock)
+	driver.CopyFileToHost_Err = errors.New("access denied")
+
+	if action := step.Run(context.Background(), state); action != multistep.ActionHalt {
+		t.Fatalf("Bad action: %v", action)
+	}
+	if _, ok := state.GetOk("error"); !ok {
+		t.Fatal("Should have error")
+	}
+}
@@ -0,0 +1,84 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/hashicorp/packer/helper/communicator"
+	"github.com/hashicorp/packer/packer-plugin-sdk/multistep"
+	"github.com/hashicorp/packer/packer-plugin-sdk/multistep/commonsteps"
+)
+
+// This step waits until the virtual machine has a usable IP address, as
+// looked up by CommHost, so that connecting the communicator doesn't start
+// with an APIPA or link-local address. It does nothing if Timeout is zero, a
+// host is configured or the communicator doesn't use the network.
+//
+// Uses:
+//   driver Driver
+//   ui     packer.Ui
+//   vmName string
+//
+// Produces:
+//   <nothing>
+type StepWaitForIp struct {
+	Config  *communicator.Config
+	Source  string
+	Family  string
+	Timeout time.Duration
+
+	// How long to wait between lookups. Defaults to five seconds.
+	RetryInterval time.Duration
+}
+
+func (s *StepWaitForIp) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	if s.Timeout == 0 || s.Config.Host() != "" {
+		return multistep.ActionContinue
+	}
+	switch s.Config.Type {
+	case "none", PowerShellDirectCommunicatorType:
+		return multistep.ActionContinue
+	}
+
+	ui, err := commonsteps.GetUi(state)
+	if err != nil {
+		return commonsteps.HaltOnError(state, nil, err)
+	}
+
+	retryInterval := s.RetryInterval
+	if retryInterval == 0 {
+		retryInterval = 5 * time.Second
+	}
+
+	host := CommHost("", s.Source, s.Family)
+
+	ui.Say("Waiting for the virtual machine to get an IP address...")
+	timeout := time.After(s.Timeout)
+	for {
+		ip, err := host(state)
+		if err == nil {
+			ui.Say(fmt.Sprintf("IP address: %s", ip))
+			return multistep.ActionContinue
+		}
+		log.Printf("No IP address yet: %s", err)
+
+		select {
+		case <-timeout:
+			err := errors.New("Timeout waiting for an IP address.")
+			state.Put("error", err)
+			ui.Error(err.Error())
+			return multistep.ActionHalt
+		case <-ctx.Done():
+			err := errors.New("Interrupted while waiting for an IP address.")
+			state.Put("error", err)
+			ui.Error(err.Error())
+			return multistep.ActionHalt
+		case <-time.After(retryInterval):
+		}
+	}
+}
+
+func (s *StepWaitForIp) Cleanup(state multistep.StateBag) {}
@@ -0,0 +1,87 @@
+package common
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/packer/helper/communicator"
+	"github.com/hashicorp/packer/packer-plugin-sdk/multistep"
+)
+
+func TestStepWaitForIp_impl(t *testing.T) {
+	var _ multistep.Step = new(StepWaitForIp)
+}
+
+func TestStepWaitForIp(t *testing.T) {
+	state := testState(t)
+	state.Put("vmName", "foo")
+	step := &StepWaitForIp{
+		Config:        &communicator.Config{Type: "ssh"},
+		Source:        IpAddressSourceAdapter,
+		Timeout:       time.Minute,
+		RetryInterval: time.Millisecond,
+	}
+
+	driver := state.Get("driver").(*DriverMock)
+	driver.IpAddresses_Return = []string{"10.0.0.10"}
+
+	// Test the run
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("Bad action: %v", action)
+	}
+	if _, ok := state.GetOk("error"); ok {
+		t.Fatal("Should NOT have error")
+	}
+	if !driver.IpAddresses_Called {
+		t.Fatal("Should have called IpAddresses")
+	}
+}
+
+func TestStepWaitForIp_timeout(t *testing.T) {
+	state := testState(t)
+	state.Put("vmName", "foo")
+	step := &StepWaitForIp{
+		Config:        &communicator.Config{Type: "winrm"},
+		Source:        IpAddressSourceAdapter,
+		Timeout:       10 * time.Millisecond,
+		RetryInterval: time.Millisecond,
+	}
+
+	driver := state.Get("driver").(*DriverMock)
+	driver.IpAddresses_Return = []string{"169.254.10.20"}
+
+	// Test the run
+	if action := step.Run(context.Background(), state); action != multistep.ActionHalt {
+		t.Fatalf("Bad action: %v", action)
+	}
+	if _, ok := state.GetOk("error"); !ok {
+		t.Fatal("Should have error")
+	}
+}
+
+func TestStepWaitForIp_skip(t *testing.T) {
+	configs := []*communicator.Config{
+		{Type: "ssh"},
+		{Type: "ssh", SSH: communicator.SSH{SSHHost: "10.0.0.10"}},
+		{Type: PowerShellDirectCommunicatorType},
+	}
+
+	for i, config := range configs {
+		state := testState(t)
+		state.Put("vmName", "foo")
+		step := &StepWaitForIp{Config: config, Timeout: time.Minute}
+		if i == 0 {
+			step.Timeout = 0
+		}
+
+		driver := state.Get("driver").(*DriverMock)
+
+		if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+			t.Fatalf("Bad action: %v", action)
+		}
+		if driver.IpAddresses_Called {
+			t.Fatalf("Should NOT have called IpAddresses for %#v", config)
+		}
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"os"
 	"path/filepath"
 	"strings"
 
@@ -19,7 +20,6 @@ import (
 	"github.com/hashicorp/packer/packer-plugin-sdk/common"
 	"github.com/hashicorp/packer/packer-plugin-sdk/multistep"
 	"github.com/hashicorp/packer/packer-plugin-sdk/multistep/commonsteps"
-	"github.com/hashicorp/packer/packer-plugin-sdk/shutdowncommand"
 	"github.com/hashicorp/packer/packer-plugin-sdk/template/config"
 	"github.com/hashicorp/packer/packer-plugin-sdk/template/interpolate"
 )
@@ -53,14 +53,14 @@ type Builder struct {
 }
 
 type Config struct {
-	common.PackerConfig            `mapstructure:",squash"`
-	commonsteps.HTTPConfig         `mapstructure:",squash"`
-	commonsteps.ISOConfig          `mapstructure:",squash"`
-	bootcommand.BootConfig         `mapstructure:",squash"`
-	hypervcommon.OutputConfig      `mapstructure:",squash"`
-	hypervcommon.SSHConfig         `mapstructure:",squash"`
-	hypervcommon.CommonConfig      `mapstructure:",squash"`
-	shutdowncommand.ShutdownConfig `mapstructure:",squash"`
+	common.PackerConfig         `mapstructure:",squash"`
+	commonsteps.HTTPConfig      `mapstructure:",squash"`
+	commonsteps.ISOConfig       `mapstructure:",squash"`
+	bootcommand.BootConfig      `mapstructure:",squash"`
+	hypervcommon.OutputConfig   `mapstructure:",squash"`
+	hypervcommon.SSHConfig      `mapstructure:",squash"`
+	hypervcommon.CommonConfig   `mapstructure:",squash"`
+	hypervcommon.ShutdownConfig `mapstructure:",squash"`
 	// The size, in megabytes, of the hard disk to create
 	// for the VM. By default, this is 40 GB.
 	DiskSize uint `mapstructure:"disk_size" required:"false"`
@@ -73,6 +73,17 @@ type Config struct {
 	// the changes will be written to the new disk. This is especially useful if
 	// your source is a VHD/VHDX. This defaults to false.
 	DifferencingDisk bool `mapstructure:"differencing_disk" required:"false"`
+	// The path to an existing VHD/VHDX to use as the parent of the boot disk
+	// of the VM. The boot disk is created as a differencing disk on top of
+	// it, so only the changes made during the build are written, and the
+	// parent is never modified. Setting this implies `differencing_disk`
+	// and takes precedence over a VHD/VHDX given as `iso_url`.
+	DifferencingDiskParentPath string `mapstructure:"differencing_disk_parent_path" required:"false"`
+	// If true, the differencing disk chain of the boot disk is merged into a
+	// single standalone disk after the VM is shut down and before it is
+	// compacted and exported, so the artifact doesn't depend on the parent
+	// disk. This defaults to false.
+	MergeDifferencingDisk bool `mapstructure:"differencing_disk_merge" required:"false"`
 	// If true, creates the boot disk on the
 	// virtual machine as a fixed VHD format disk. The default is false, which
 	// creates a dynamic VHDX format disk. This option requires setting
@@ -112,16 +123,40 @@ func (b *Builder) Prepare(raws ...interface{}) ([]string, []string, error) {
 	errs = packer.MultiErrorAppend(errs, isoErrs...)
 
 	errs = packer.MultiErrorAppend(errs, b.config.BootConfig.Prepare(&b.config.ctx)...)
+	warnings = append(warnings, hypervcommon.CheckBootCommand(b.config.FlatBootCommand())...)
 	errs = packer.MultiErrorAppend(errs, b.config.HTTPConfig.Prepare(&b.config.ctx)...)
 	errs = packer.MultiErrorAppend(errs, b.config.OutputConfig.Prepare(&b.config.ctx, &b.config.PackerConfig)...)
 	errs = packer.MultiErrorAppend(errs, b.config.SSHConfig.Prepare(&b.config.ctx)...)
 	errs = packer.MultiErrorAppend(errs, b.config.ShutdownConfig.Prepare(&b.config.ctx)...)
 
 	commonErrs, commonWarns := b.config.CommonConfig.Prepare(&b.config.ctx, &b.config.PackerConfig)
-	packer.MultiErrorAppend(errs, commonErrs...)
+	errs = packer.MultiErrorAppend(errs, commonErrs...)
 	warnings = append(warnings, commonWarns...)
 
-	if len(b.config.ISOConfig.ISOUrls) < 1 ||
+	if b.config.RemoteHost != "" && b.config.SSHConfig.Comm.Type == hypervcommon.PowerShellDirectCommunicatorType {
+		errs = packer.MultiErrorAppend(errs, fmt.Errorf("The %s communicator can't be used with remote_host.",
+			hypervcommon.PowerShellDirectCommunicatorType))
+	}
+
+	if b.config.DifferencingDiskParentPath != "" {
+		b.config.DifferencingDisk = true
+
+		extension := strings.ToLower(filepath.Ext(b.config.DifferencingDiskParentPath))
+		if extension != ".vhd" && extension != ".vhdx" {
+			err = fmt.Errorf("differencing_disk_parent_path: must be a VHD or VHDX file: %s",
+				b.config.DifferencingDiskParentPath)
+			errs = packer.MultiErrorAppend(errs, err)
+		} else if b.config.RemoteHost != "" {
+			if !b.config.PathExists(b.config.DifferencingDiskParentPath) {
+				err = fmt.Errorf("differencing_disk_parent_path: parent disk does not exist: %s",
+					b.config.DifferencingDiskParentPath)
+				errs = packer.MultiErrorAppend(errs, err)
+			}
+		} else if _, err := os.Stat(b.config.DifferencingDiskParentPath); err != nil {
+			err = fmt.Errorf("differencing_disk_parent_path: parent disk is invalid: %s", err)
+			errs = packer.MultiErrorAppend(errs, err)
+		}
+	} else if len(b.config.ISOConfig.ISOUrls) < 1 ||
 		(strings.ToLower(filepath.Ext(b.config.ISOConfig.ISOUrls[0])) != ".vhd" &&
 			strings.ToLower(filepath.Ext(b.config.ISOConfig.ISOUrls[0])) != ".vhdx") {
 		//We only create a new hard drive if an existing one to copy from does not exist
@@ -140,6 +175,15 @@ func (b *Builder) Prepare(raws ...interface{}) ([]string, []string, error) {
 			err = errors.New("Generation 2 vms don't support legacy network adapters.")
 			errs = packer.MultiErrorAppend(errs, err)
 		}
+	} else {
+		if b.config.EnableSecureBoot {
+			err = errors.New("Secure boot is only supported on Generation 2 virtual machines.")
+			errs = packer.MultiErrorAppend(errs, err)
+		}
+		if b.config.EnableVirtualTPM {
+			err = errors.New("Virtual TPM is only supported on Generation 2 virtual machines.")
+			errs = packer.MultiErrorAppend(errs, err)
+		}
 	}
 
 	// Errors
@@ -159,6 +203,12 @@ func (b *Builder) Prepare(raws ...interface{}) ([]string, []string, error) {
 		errs = packer.MultiErrorAppend(errs, err)
 	}
 
+	if b.config.MergeDifferencingDisk && !b.config.DifferencingDisk {
+		err = errors.New("differencing_disk_merge requires differencing_disk or " +
+			"differencing_disk_parent_path to be set.")
+		errs = packer.MultiErrorAppend(errs, err)
+	}
+
 	// Warnings
 
 	if b.config.ShutdownCommand == "" {
@@ -178,7 +228,7 @@ func (b *Builder) Prepare(raws ...interface{}) ([]string, []string, error) {
 // a Hyperv appliance.
 func (b *Builder) Run(ctx context.Context, ui packer.Ui, hook packer.Hook) (packer.Artifact, error) {
 	// Create the driver that we'll use to communicate with Hyperv
-	driver, err := hypervcommon.NewHypervPS4Driver()
+	driver, err := hypervcommon.NewDriver(b.config.DriverMode, b.config.Remote())
 	if err != nil {
 		return nil, fmt.Errorf("Failed creating Hyper-V driver: %s", err)
 	}
@@ -193,6 +243,7 @@ func (b *Builder) Run(ctx context.Context, ui packer.Ui, hook packer.Hook) (pack
 	steps := []multistep.Step{
 		&hypervcommon.StepCreateBuildDir{
 			TempPath: b.config.TempPath,
+			Remote:   b.config.RemoteHost != "",
 		},
 		&commonsteps.StepOutputDir{
 			Force: b.config.PackerForce,
@@ -205,6 +256,7 @@ func (b *Builder) Run(ctx context.Context, ui packer.Ui, hook packer.Hook) (pack
 			Url:         b.config.ISOUrls,
 			Extension:   b.config.TargetExtension,
 			TargetPath:  b.config.TargetPath,
+			Segments:    b.config.ISODownloadSegments,
 		},
 		&commonsteps.StepCreateFloppy{
 			Files:       b.config.FloppyConfig.FloppyFiles,
@@ -212,13 +264,33 @@ func (b *Builder) Run(ctx context.Context, ui packer.Ui, hook packer.Hook) (pack
 			Label:       b.config.FloppyConfig.FloppyLabel,
 		},
 		&commonsteps.StepHTTPServer{
-			HTTPDir:     b.config.HTTPDir,
-			HTTPPortMin: b.config.HTTPPortMin,
-			HTTPPortMax: b.config.HTTPPortMax,
-			HTTPAddress: b.config.HTTPAddress,
+			HTTPDir:      b.config.HTTPDir,
+			HTTPPortMin:  b.config.HTTPPortMin,
+			HTTPPortMax:  b.config.HTTPPortMax,
+			HTTPAddress:  b.config.HTTPAddress,
+			UseTLS:       b.config.HTTPUseTLS,
+			TLSCertFile:  b.config.HTTPTLSCertFile,
+			TLSKeyFile:   b.config.HTTPTLSKeyFile,
+			AuthToken:    b.config.HTTPAuthToken,
+			Templates:    b.config.HTTPTemplates,
+			TemplateVars: b.config.HTTPTemplateVars,
+		},
+		&communicator.StepSSHKeyPair{
+			Debug:        b.config.PackerDebug,
+			DebugKeyPath: fmt.Sprintf("%s.pem", b.config.PackerBuildName),
+			Comm:         &b.config.SSHConfig.Comm,
 		},
 		&hypervcommon.StepCreateSwitch{
-			SwitchName: b.config.SwitchName,
+			SwitchName:     b.config.SwitchName,
+			SwitchType:     b.config.SwitchType,
+			NetAdapterName: b.config.SwitchNetAdapterName,
+			SkipCreation:   b.config.CreateSwitch.False(),
+			EnableNat:      b.config.SwitchNat,
+			NatPrefix:      b.config.SwitchNatPrefix,
+		},
+		&hypervcommon.StepUploadToHost{
+			Enabled: b.config.RemoteHost != "",
+			Keys:    []string{"iso_path"},
 		},
 		&hypervcommon.StepCreateVM{
 			VMName:                         b.config.VMName,
@@ -228,14 +300,23 @@ func (b *Builder) Run(ctx context.Context, ui packer.Ui, hook packer.Hook) (pack
 			DiskBlockSize:                  b.config.DiskBlockSize,
 			Generation:                     b.config.Generation,
 			Cpu:                            b.config.Cpu,
+			CpusPerSocket:                  b.config.CpusPerSocket,
+			NumaNodes:                      b.config.NumaNodes,
 			EnableMacSpoofing:              b.config.EnableMacSpoofing,
 			EnableDynamicMemory:            b.config.EnableDynamicMemory,
+			DynamicMemoryMinimum:           b.config.DynamicMemoryMinimum,
+			DynamicMemoryMaximum:           b.config.DynamicMemoryMaximum,
+			MemoryBufferPercent:            b.config.MemoryBufferPercent,
 			EnableSecureBoot:               b.config.EnableSecureBoot,
 			SecureBootTemplate:             b.config.SecureBootTemplate,
+			EnableVirtualTPM:               b.config.EnableVirtualTPM,
 			EnableVirtualizationExtensions: b.config.EnableVirtualizationExtensions,
+			ExposeProcessorFeatures:        b.config.ExposeProcessorFeatures,
 			UseLegacyNetworkAdapter:        b.config.UseLegacyNetworkAdapter,
 			AdditionalDiskSize:             b.config.AdditionalDiskSize,
+			AdditionalDisks:                b.config.AdditionalDisks,
 			DifferencingDisk:               b.config.DifferencingDisk,
+			DifferencingDiskParentPath:     b.config.DifferencingDiskParentPath,
 			MacAddress:                     b.config.MacAddress,
 			FixedVHD:                       b.config.FixedVHD,
 			Version:                        b.config.Version,
@@ -249,6 +330,7 @@ func (b *Builder) Run(ctx context.Context, ui packer.Ui, hook packer.Hook) (pack
 		},
 		&hypervcommon.StepMountFloppydrive{
 			Generation: b.config.Generation,
+			Remote:     b.config.RemoteHost != "",
 		},
 
 		&hypervcommon.StepMountGuestAdditions{
@@ -260,6 +342,10 @@ func (b *Builder) Run(ctx context.Context, ui packer.Ui, hook packer.Hook) (pack
 			Files: b.config.CDConfig.CDFiles,
 			Label: b.config.CDConfig.CDLabel,
 		},
+		&hypervcommon.StepUploadToHost{
+			Enabled: b.config.RemoteHost != "",
+			Keys:    []string{"cd_path"},
+		},
 		&hypervcommon.StepMountSecondaryDvdImages{
 			IsoPaths:   b.config.SecondaryDvdImages,
 			Generation: b.config.Generation,
@@ -269,6 +355,9 @@ func (b *Builder) Run(ctx context.Context, ui packer.Ui, hook packer.Hook) (pack
 			VlanId:       b.config.VlanId,
 			SwitchVlanId: b.config.SwitchVlanId,
 		},
+		&hypervcommon.StepAddNetworkAdapters{
+			Adapters: b.config.AdditionalNetworkAdapters,
+		},
 
 		&hypervcommon.StepSetBootOrder{
 			BootOrder: b.config.BootOrder,
@@ -278,9 +367,15 @@ func (b *Builder) Run(ctx context.Context, ui packer.Ui, hook packer.Hook) (pack
 			FirstBootDevice: b.config.FirstBootDevice,
 		},
 
+		&hypervcommon.StepSerialLog{
+			Path:        b.config.SerialLogFile,
+			ShowOnError: b.config.SerialLogOnError,
+		},
+
 		&hypervcommon.StepRun{
 			Headless:   b.config.Headless,
 			SwitchName: b.config.SwitchName,
+			RemoteHost: b.config.RemoteHost,
 		},
 
 		&hypervcommon.StepTypeBootCommand{
@@ -289,30 +384,66 @@ func (b *Builder) Run(ctx context.Context, ui packer.Ui, hook packer.Hook) (pack
 			SwitchName:    b.config.SwitchName,
 			Ctx:           b.config.ctx,
 			GroupInterval: b.config.BootConfig.BootGroupInterval,
+			TypeText:      b.config.BootTypeText,
+			Comm:          &b.config.SSHConfig.Comm,
+		},
+
+		&hypervcommon.StepWaitForIp{
+			Config:  &b.config.SSHConfig.Comm,
+			Source:  b.config.IpAddressSource,
+			Family:  b.config.IpAddressFamily,
+			Timeout: b.config.IpAddressTimeout,
 		},
 
 		// configure the communicator ssh, winrm
 		&communicator.StepConnect{
-			Config:    &b.config.SSHConfig.Comm,
-			Host:      hypervcommon.CommHost(b.config.SSHConfig.Comm.SSHHost),
+			Config: &b.config.SSHConfig.Comm,
+			Host: hypervcommon.CommHost(b.config.SSHConfig.Comm.SSHHost, b.config.IpAddressSource,
+				b.config.IpAddressFamily),
 			SSHConfig: b.config.SSHConfig.Comm.SSHConfigFunc(),
+			CustomConnect: map[string]multistep.Step{
+				hypervcommon.PowerShellDirectCommunicatorType: &hypervcommon.StepConnectPowerShellDirect{
+					Config: &b.config.SSHConfig.Comm,
+				},
+			},
 		},
 
 		// provision requires communicator to be setup
+		&hypervcommon.StepDebugCheckpoint{
+			Enabled:    b.config.DebugCheckpoints,
+			Name:       hypervcommon.DebugCheckpointBeforeProvisioning,
+			FailedName: hypervcommon.DebugCheckpointProvisioningFailed,
+		},
 		&commonsteps.StepProvision{},
+		&hypervcommon.StepDebugCheckpoint{
+			Enabled: b.config.DebugCheckpoints,
+			Name:    hypervcommon.DebugCheckpointAfterProvisioning,
+		},
 
 		// Remove ephemeral key from authorized_hosts if using SSH communicator
 		&commonsteps.StepCleanupTempKeys{
 			Comm: &b.config.SSHConfig.Comm,
 		},
 
+		&commonsteps.StepPauseBeforeShutdown{
+			Duration:    b.config.PauseBeforeShutdown,
+			Interactive: b.config.PauseBeforeShutdownInteractive,
+		},
 		&hypervcommon.StepShutdown{
-			Command: b.config.ShutdownCommand,
-			Timeout: b.config.ShutdownTimeout,
+			Command:           b.config.ShutdownCommand,
+			Timeout:           b.config.ShutdownTimeout,
+			Retries:           b.config.ShutdownRetries,
+			RetryInterval:     b.config.ShutdownRetryInterval,
+			ForceStop:         b.config.ShutdownForceStop,
+			WaitForHeartbeat:  b.config.ShutdownWaitForHeartbeat,
+			PostShutdownDelay: b.config.PostShutdownDelay,
 		},
 
 		// wait for the vm to be powered off
 		&hypervcommon.StepWaitForPowerOff{},
+		&hypervcommon.StepRemoveDebugCheckpoints{
+			Enabled: b.config.DebugCheckpoints,
+		},
 
 		// remove the secondary dvd images
 		// after we power down
@@ -322,16 +453,27 @@ func (b *Builder) Run(ctx context.Context, ui packer.Ui, hook packer.Hook) (pack
 		&hypervcommon.StepUnmountFloppyDrive{
 			Generation: b.config.Generation,
 		},
+		&hypervcommon.StepDisconnectSerialPort{
+			SerialLogFile: b.config.SerialLogFile,
+		},
+		&hypervcommon.StepMergeDifferencingDisk{
+			MergeDifferencingDisk: b.config.MergeDifferencingDisk,
+		},
 		&hypervcommon.StepCompactDisk{
 			SkipCompaction: b.config.SkipCompaction,
 		},
 		&hypervcommon.StepExportVm{
-			OutputDir:  b.config.OutputDir,
-			SkipExport: b.config.SkipExport,
+			OutputDir:        b.config.OutputDir,
+			SkipExport:       b.config.SkipExport,
+			ExportMode:       b.config.ExportMode,
+			OutputDiskFormat: b.config.OutputDiskFormat,
+			OutputDiskType:   b.config.OutputDiskType,
+			Remote:           b.config.RemoteHost != "",
 		},
 		&hypervcommon.StepCollateArtifacts{
 			OutputDir:  b.config.OutputDir,
 			SkipExport: b.config.SkipExport,
+			Remote:     b.config.RemoteHost != "",
 		},
 
 		// the clean up actions for each step will be executed reverse order
@@ -354,7 +496,13 @@ func (b *Builder) Run(ctx context.Context, ui packer.Ui, hook packer.Hook) (pack
 	if _, ok := state.GetOk(multistep.StateHalted); ok {
 		return nil, errors.New("Build was halted.")
 	}
-	generatedData := map[string]interface{}{"generated_data": state.Get("generated_data")}
+	generatedData := map[string]interface{}{
+		"generated_data": state.Get("generated_data"),
+		// The Vagrant post-processor packages the virtual machine according
+		// to its generation and configuration version
+		"generation":            b.config.Generation,
+		"configuration_version": b.config.Version,
+	}
 	return hypervcommon.NewArtifact(b.config.OutputDir, generatedData)
 }
 
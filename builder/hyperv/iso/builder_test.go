@@ -5,9 +5,12 @@ package iso
 import (
 	"context"
 	"fmt"
+	"io/ioutil"
+	"os"
 	"reflect"
 	"strconv"
 	"testing"
+	"time"
 
 	hypervcommon "github.com/hashicorp/packer/builder/hyperv/common"
 	"github.com/hashicorp/packer/packer"
@@ -260,6 +263,20 @@ func TestBuilderPrepare_InvalidKey(t *testing.T) {
 	}
 }
 
+func TestBuilderPrepare_CommonConfigErrors(t *testing.T) {
+	var b Builder
+	config := testConfig()
+
+	config["first_boot_device"] = "bad"
+	_, warns, err := b.Prepare(config)
+	if len(warns) > 0 {
+		t.Fatalf("bad: %#v", warns)
+	}
+	if err == nil {
+		t.Fatal("should have error")
+	}
+}
+
 func TestBuilderPrepare_ISOChecksum(t *testing.T) {
 	var b Builder
 	config := testConfig()
@@ -527,6 +544,161 @@ func TestBuilderPrepare_MaximumOfSixtyFourAdditionalDisks(t *testing.T) {
 
 }
 
+func TestBuilderPrepare_AdditionalDisks(t *testing.T) {
+	var b Builder
+	config := testConfig()
+
+	// Test defaults
+	config["additional_disks"] = []map[string]interface{}{
+		{"size": 1024},
+		{"size": 2048, "block_size": 8, "controller_type": "ide", "fixed": true},
+	}
+	b = Builder{}
+	_, warns, err := b.Prepare(config)
+	if len(warns) > 0 {
+		t.Fatalf("bad: %#v", warns)
+	}
+	if err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	if len(b.config.AdditionalDisks) != 2 {
+		t.Fatalf("bad: %#v", b.config.AdditionalDisks)
+	}
+	disk := b.config.AdditionalDisks[0]
+	if disk.BlockSize != b.config.DiskBlockSize {
+		t.Fatalf("bad block size: %d", disk.BlockSize)
+	}
+	if disk.ControllerType != hypervcommon.ControllerTypeSCSI {
+		t.Fatalf("bad controller type: %s", disk.ControllerType)
+	}
+	disk = b.config.AdditionalDisks[1]
+	if disk.BlockSize != 8 || disk.ControllerType != hypervcommon.ControllerTypeIDE || !disk.Fixed {
+		t.Fatalf("bad: %#v", disk)
+	}
+
+	// Test with a missing size
+	config["additional_disks"] = []map[string]interface{}{
+		{"controller_type": "SCSI"},
+	}
+	b = Builder{}
+	_, _, err = b.Prepare(config)
+	if err == nil {
+		t.Fatal("should have error")
+	}
+
+	// Test with a bad controller type
+	config["additional_disks"] = []map[string]interface{}{
+		{"size": 1024, "controller_type": "SATA"},
+	}
+	b = Builder{}
+	_, _, err = b.Prepare(config)
+	if err == nil {
+		t.Fatal("should have error")
+	}
+
+	// Test with a bad block size
+	config["additional_disks"] = []map[string]interface{}{
+		{"size": 1024, "block_size": 512},
+	}
+	b = Builder{}
+	_, _, err = b.Prepare(config)
+	if err == nil {
+		t.Fatal("should have error")
+	}
+
+	// Test IDE on a Generation 2 VM
+	config["additional_disks"] = []map[string]interface{}{
+		{"size": 1024, "controller_type": "IDE"},
+	}
+	config["generation"] = 2
+	b = Builder{}
+	_, _, err = b.Prepare(config)
+	if err == nil {
+		t.Fatal("should have error")
+	}
+}
+
+func TestBuilderPrepare_MaximumOfSixtyFourAdditionalDisksCombined(t *testing.T) {
+	var b Builder
+	config := testConfig()
+
+	disks := make([]map[string]interface{}, 62)
+	for i := range disks {
+		disks[i] = map[string]interface{}{"size": 1024}
+	}
+	// testConfig() already defines three disks in disk_additional_size
+	config["additional_disks"] = disks
+
+	b = Builder{}
+	_, _, err := b.Prepare(config)
+	if err == nil {
+		t.Errorf("should have error")
+	}
+}
+
+func TestBuilderPrepare_AdditionalNetworkAdapters(t *testing.T) {
+	var b Builder
+	config := testConfig()
+
+	// Test with a good value
+	config["additional_network_adapters"] = []map[string]interface{}{
+		{"switch_name": "Internal", "vlan_id": "20", "mac_address": "0000deadbeef"},
+		{"switch_name": "Storage", "enable_mac_spoofing": true},
+	}
+	b = Builder{}
+	_, warns, err := b.Prepare(config)
+	if len(warns) > 0 {
+		t.Fatalf("bad: %#v", warns)
+	}
+	if err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	if len(b.config.AdditionalNetworkAdapters) != 2 {
+		t.Fatalf("bad: %#v", b.config.AdditionalNetworkAdapters)
+	}
+	if !b.config.AdditionalNetworkAdapters[1].EnableMacSpoofing {
+		t.Fatalf("bad: %#v", b.config.AdditionalNetworkAdapters[1])
+	}
+
+	// Test without a switch name
+	config["additional_network_adapters"] = []map[string]interface{}{
+		{"vlan_id": "20"},
+	}
+	b = Builder{}
+	_, _, err = b.Prepare(config)
+	if err == nil {
+		t.Fatal("should have error")
+	}
+}
+
+func TestBuilderPrepare_DynamicMemory(t *testing.T) {
+	var b Builder
+	config := testConfig()
+
+	// Test with good values
+	config["dynamic_memory_minimum"] = 32
+	config["dynamic_memory_maximum"] = 4096
+	config["memory_buffer_percent"] = 50
+	_, warns, err := b.Prepare(config)
+	if len(warns) > 0 {
+		t.Fatalf("bad: %#v", warns)
+	}
+	if err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	if !b.config.EnableDynamicMemory {
+		t.Fatal("should have enabled dynamic memory")
+	}
+
+	// Test with a bad value
+	config["dynamic_memory_maximum"] = 32
+	b = Builder{}
+	_, _, err = b.Prepare(config)
+	if err == nil {
+		t.Fatal("should have error")
+	}
+}
+
 func TestBuilderPrepare_CommConfig(t *testing.T) {
 	// Test Winrm
 	{
@@ -584,6 +756,39 @@ func TestBuilderPrepare_CommConfig(t *testing.T) {
 		}
 	}
 
+	// Test PowerShell Direct
+	{
+		config := testConfig()
+		config["communicator"] = "powershell-direct"
+		config["winrm_username"] = "username"
+		config["winrm_password"] = "password"
+
+		var b Builder
+		_, warns, err := b.Prepare(config)
+		if len(warns) > 0 {
+			t.Fatalf("bad: %#v", warns)
+		}
+		if err != nil {
+			t.Fatalf("should not have error: %s", err)
+		}
+
+		if b.config.Comm.WinRMTimeout != 30*time.Minute {
+			t.Errorf("bad winrm_timeout: %s", b.config.Comm.WinRMTimeout)
+		}
+	}
+
+	// Test PowerShell Direct without a username
+	{
+		config := testConfig()
+		config["communicator"] = "powershell-direct"
+
+		var b Builder
+		_, _, err := b.Prepare(config)
+		if err == nil {
+			t.Fatal("should have error")
+		}
+	}
+
 }
 
 func TestUserVariablesInBootCommand(t *testing.T) {
@@ -655,3 +860,332 @@ func TestBuilderPrepare_UseLegacyNetworkAdapter(t *testing.T) {
 		t.Fatal("should have error")
 	}
 }
+
+func TestBuilderPrepare_ExportMode(t *testing.T) {
+	var b Builder
+	config := testConfig()
+
+	// Test the default
+	_, warns, err := b.Prepare(config)
+	if len(warns) > 0 {
+		t.Fatalf("bad: %#v", warns)
+	}
+	if err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	if b.config.ExportMode != hypervcommon.ExportModeFlat {
+		t.Fatalf("bad export mode: %s", b.config.ExportMode)
+	}
+
+	// Test with good values
+	for _, mode := range []string{"flat", "checkpoints", "vhdset"} {
+		config["export_mode"] = mode
+		b = Builder{}
+		_, warns, err = b.Prepare(config)
+		if len(warns) > 0 {
+			t.Fatalf("bad: %#v", warns)
+		}
+		if err != nil {
+			t.Fatalf("should not have error for %q: %s", mode, err)
+		}
+	}
+
+	// Test with a bad value
+	config["export_mode"] = "bad"
+	b = Builder{}
+	_, warns, err = b.Prepare(config)
+	if len(warns) > 0 {
+		t.Fatalf("bad: %#v", warns)
+	}
+	if err == nil {
+		t.Fatal("should have error")
+	}
+
+	// Test that a non default mode warns when the export is skipped
+	config["export_mode"] = "vhdset"
+	config["skip_export"] = true
+	b = Builder{}
+	_, warns, err = b.Prepare(config)
+	if len(warns) == 0 {
+		t.Fatal("should have warning")
+	}
+	if err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+}
+
+func TestBuilderPrepare_SecureBootTemplate(t *testing.T) {
+	var b Builder
+	config := testConfig()
+
+	// Test the default
+	_, warns, err := b.Prepare(config)
+	if len(warns) > 0 {
+		t.Fatalf("bad: %#v", warns)
+	}
+	if err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	if b.config.SecureBootTemplate != "MicrosoftWindows" {
+		t.Fatalf("bad secure boot template: %s", b.config.SecureBootTemplate)
+	}
+
+	// Test with good values
+	config["generation"] = 2
+	config["enable_secure_boot"] = true
+	for _, template := range []string{
+		"MicrosoftWindows",
+		"MicrosoftUEFICertificateAuthority",
+		"OpenSourceShieldedVM",
+	} {
+		config["secure_boot_template"] = template
+		b = Builder{}
+		_, warns, err = b.Prepare(config)
+		if len(warns) > 0 {
+			t.Fatalf("bad: %#v", warns)
+		}
+		if err != nil {
+			t.Fatalf("should not have error for %q: %s", template, err)
+		}
+	}
+
+	// Test with a bad value
+	config["secure_boot_template"] = "bad"
+	b = Builder{}
+	_, warns, err = b.Prepare(config)
+	if len(warns) > 0 {
+		t.Fatalf("bad: %#v", warns)
+	}
+	if err == nil {
+		t.Fatal("should have error")
+	}
+
+	// Secure boot should not be allowed for gen 1
+	config["generation"] = 1
+	config["secure_boot_template"] = "MicrosoftUEFICertificateAuthority"
+	b = Builder{}
+	_, warns, err = b.Prepare(config)
+	if len(warns) > 0 {
+		t.Fatalf("bad: %#v", warns)
+	}
+	if err == nil {
+		t.Fatal("should have error")
+	}
+}
+
+func TestBuilderPrepare_EnableVirtualTPM(t *testing.T) {
+	var b Builder
+	config := testConfig()
+
+	// Test with a Generation 2 VM
+	config["generation"] = 2
+	config["enable_virtual_tpm"] = true
+	_, warns, err := b.Prepare(config)
+	if len(warns) > 0 {
+		t.Fatalf("bad: %#v", warns)
+	}
+	if err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+
+	// Test with a Generation 1 VM
+	config["generation"] = 1
+	b = Builder{}
+	_, warns, err = b.Prepare(config)
+	if len(warns) > 0 {
+		t.Fatalf("bad: %#v", warns)
+	}
+	if err == nil {
+		t.Fatal("should have error")
+	}
+}
+
+func TestBuilderPrepare_DriverMode(t *testing.T) {
+	var b Builder
+	config := testConfig()
+
+	// Test the default
+	_, warns, err := b.Prepare(config)
+	if len(warns) > 0 {
+		t.Fatalf("bad: %#v", warns)
+	}
+	if err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	if b.config.DriverMode != hypervcommon.DriverModePowerShell {
+		t.Fatalf("bad driver mode: %s", b.config.DriverMode)
+	}
+
+	// Test with a good value
+	config["driver_mode"] = "wmi"
+	b = Builder{}
+	_, warns, err = b.Prepare(config)
+	if len(warns) > 0 {
+		t.Fatalf("bad: %#v", warns)
+	}
+	if err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+
+	// Test with a bad value
+	config["driver_mode"] = "bad"
+	b = Builder{}
+	_, warns, err = b.Prepare(config)
+	if len(warns) > 0 {
+		t.Fatalf("bad: %#v", warns)
+	}
+	if err == nil {
+		t.Fatal("should have error")
+	}
+}
+
+func TestBuilderPrepare_Switch(t *testing.T) {
+	var b Builder
+	config := testConfig()
+
+	// Test the default
+	_, warns, err := b.Prepare(config)
+	if len(warns) > 0 {
+		t.Fatalf("bad: %#v", warns)
+	}
+	if err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	if b.config.CreateSwitch.False() {
+		t.Fatal("create_switch should default to true")
+	}
+	if b.config.SwitchType != hypervcommon.SwitchTypeInternal {
+		t.Fatalf("bad switch type: %s", b.config.SwitchType)
+	}
+
+	// Test with NAT
+	config["create_switch"] = true
+	config["switch_nat"] = true
+	b = Builder{}
+	_, warns, err = b.Prepare(config)
+	if len(warns) > 0 {
+		t.Fatalf("bad: %#v", warns)
+	}
+	if err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	if b.config.SwitchNatPrefix != hypervcommon.DefaultSwitchNatPrefix {
+		t.Fatalf("bad switch nat prefix: %s", b.config.SwitchNatPrefix)
+	}
+
+	// Test with a bad switch type
+	config["switch_type"] = "bad"
+	b = Builder{}
+	_, warns, err = b.Prepare(config)
+	if len(warns) > 0 {
+		t.Fatalf("bad: %#v", warns)
+	}
+	if err == nil {
+		t.Fatal("should have error")
+	}
+}
+
+func TestBuilderPrepare_DifferencingDiskParentPath(t *testing.T) {
+	var b Builder
+	config := testConfig()
+	delete(config, "disk_size")
+
+	parent, err := ioutil.TempFile("", "packer-parent-*.vhdx")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	parent.Close()
+	defer os.Remove(parent.Name())
+
+	// Test with an existing parent
+	config["differencing_disk_parent_path"] = parent.Name()
+	_, warns, err := b.Prepare(config)
+	if len(warns) > 0 {
+		t.Fatalf("bad: %#v", warns)
+	}
+	if err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	if !b.config.DifferencingDisk {
+		t.Fatal("should enable differencing_disk")
+	}
+	if b.config.DiskSize != 0 {
+		t.Fatalf("disk_size should not be defaulted: %d", b.config.DiskSize)
+	}
+
+	// Test with a parent that doesn't exist
+	config["differencing_disk_parent_path"] = parent.Name() + ".missing.vhdx"
+	b = Builder{}
+	_, warns, err = b.Prepare(config)
+	if len(warns) > 0 {
+		t.Fatalf("bad: %#v", warns)
+	}
+	if err == nil {
+		t.Fatal("should have error")
+	}
+
+	// Test with a parent that isn't a VHD/VHDX
+	config["differencing_disk_parent_path"] = parent.Name() + ".iso"
+	b = Builder{}
+	_, warns, err = b.Prepare(config)
+	if len(warns) > 0 {
+		t.Fatalf("bad: %#v", warns)
+	}
+	if err == nil {
+		t.Fatal("should have error")
+	}
+}
+
+func TestBuilderPrepare_MergeDifferencingDisk(t *testing.T) {
+	var b Builder
+	config := testConfig()
+
+	// Test that merging requires differencing disks
+	config["differencing_disk_merge"] = true
+	_, warns, err := b.Prepare(config)
+	if len(warns) > 0 {
+		t.Fatalf("bad: %#v", warns)
+	}
+	if err == nil {
+		t.Fatal("should have error")
+	}
+
+	config["differencing_disk"] = true
+	b = Builder{}
+	_, warns, err = b.Prepare(config)
+	if len(warns) > 0 {
+		t.Fatalf("bad: %#v", warns)
+	}
+	if err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+}
+
+func TestBuilderPrepare_BootCommand(t *testing.T) {
+	var b Builder
+	config := testConfig()
+
+	config["boot_command"] = []string{"<esc>linux ks=http://{{ .HTTPIP }}/ks.cfg<enter>"}
+	config["boot_type_text"] = true
+	_, warns, err := b.Prepare(config)
+	if len(warns) > 0 {
+		t.Fatalf("bad: %#v", warns)
+	}
+	if err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	if !b.config.BootTypeText {
+		t.Fatal("boot_type_text should be true")
+	}
+
+	// Test a misspelled special key
+	config["boot_command"] = []string{"<esc>linux ks=http://{{ .HTTPIP }}/ks.cfg<enetr>"}
+	b = Builder{}
+	_, warns, err = b.Prepare(config)
+	if len(warns) != 1 {
+		t.Fatalf("should have a warning: %#v", warns)
+	}
+	if err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+}
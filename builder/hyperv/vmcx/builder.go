@@ -19,7 +19,6 @@ import (
 	"github.com/hashicorp/packer/packer-plugin-sdk/common"
 	"github.com/hashicorp/packer/packer-plugin-sdk/multistep"
 	"github.com/hashicorp/packer/packer-plugin-sdk/multistep/commonsteps"
-	"github.com/hashicorp/packer/packer-plugin-sdk/shutdowncommand"
 	"github.com/hashicorp/packer/packer-plugin-sdk/template/config"
 	"github.com/hashicorp/packer/packer-plugin-sdk/template/interpolate"
 )
@@ -44,19 +43,30 @@ type Builder struct {
 }
 
 type Config struct {
-	common.PackerConfig            `mapstructure:",squash"`
-	commonsteps.HTTPConfig         `mapstructure:",squash"`
-	commonsteps.ISOConfig          `mapstructure:",squash"`
-	bootcommand.BootConfig         `mapstructure:",squash"`
-	hypervcommon.OutputConfig      `mapstructure:",squash"`
-	hypervcommon.SSHConfig         `mapstructure:",squash"`
-	hypervcommon.CommonConfig      `mapstructure:",squash"`
-	shutdowncommand.ShutdownConfig `mapstructure:",squash"`
+	common.PackerConfig         `mapstructure:",squash"`
+	commonsteps.HTTPConfig      `mapstructure:",squash"`
+	commonsteps.ISOConfig       `mapstructure:",squash"`
+	bootcommand.BootConfig      `mapstructure:",squash"`
+	hypervcommon.OutputConfig   `mapstructure:",squash"`
+	hypervcommon.SSHConfig      `mapstructure:",squash"`
+	hypervcommon.CommonConfig   `mapstructure:",squash"`
+	hypervcommon.ShutdownConfig `mapstructure:",squash"`
 
 	// This is the path to a directory containing an exported virtual machine.
 	CloneFromVMCXPath string `mapstructure:"clone_from_vmcx_path"`
 	// This is the name of the virtual machine to clone from.
 	CloneFromVMName string `mapstructure:"clone_from_vm_name"`
+	// A URL to an archive of an exported virtual machine to clone from,
+	// instead of `clone_from_vmcx_path` or `clone_from_vm_name`. The archive
+	// is downloaded to the Packer cache, and it can be a `zip`, `tar`,
+	// `tar.gz`, `tar.bz2` or `tar.xz` file whose contents are the output of
+	// a previous Hyper-V build, or any other directory holding a
+	// "Virtual Machines" directory. Any URL supported for `iso_url` works.
+	SourceURL string `mapstructure:"source_url" required:"false"`
+	// The checksum of the archive at `source_url`, in the same format as
+	// `iso_checksum`. Required if `source_url` is set; use "none" to skip
+	// verification.
+	SourceChecksum string `mapstructure:"source_checksum" required:"false"`
 	// The name of a snapshot in the
 	// source machine to use as a starting point for the clone. If the value
 	// given is an empty string, the last snapshot present in the source will
@@ -107,26 +117,52 @@ func (b *Builder) Prepare(raws ...interface{}) ([]string, []string, error) {
 	}
 
 	errs = packer.MultiErrorAppend(errs, b.config.BootConfig.Prepare(&b.config.ctx)...)
+	warnings = append(warnings, hypervcommon.CheckBootCommand(b.config.FlatBootCommand())...)
 	errs = packer.MultiErrorAppend(errs, b.config.HTTPConfig.Prepare(&b.config.ctx)...)
 	errs = packer.MultiErrorAppend(errs, b.config.OutputConfig.Prepare(&b.config.ctx, &b.config.PackerConfig)...)
 	errs = packer.MultiErrorAppend(errs, b.config.SSHConfig.Prepare(&b.config.ctx)...)
 	errs = packer.MultiErrorAppend(errs, b.config.ShutdownConfig.Prepare(&b.config.ctx)...)
 
 	commonErrs, commonWarns := b.config.CommonConfig.Prepare(&b.config.ctx, &b.config.PackerConfig)
-	packer.MultiErrorAppend(errs, commonErrs...)
+	errs = packer.MultiErrorAppend(errs, commonErrs...)
 	warnings = append(warnings, commonWarns...)
 
+	if b.config.RemoteHost != "" && b.config.SSHConfig.Comm.Type == hypervcommon.PowerShellDirectCommunicatorType {
+		errs = packer.MultiErrorAppend(errs, fmt.Errorf("The %s communicator can't be used with remote_host.",
+			hypervcommon.PowerShellDirectCommunicatorType))
+	}
+
 	if b.config.Cpu < 1 {
 		b.config.Cpu = 1
 	}
 
+	if b.config.SourceURL != "" {
+		if b.config.CloneFromVMName != "" || b.config.CloneFromVMCXPath != "" {
+			errs = packer.MultiErrorAppend(errs, fmt.Errorf("The source_url can't be specified together "+
+				"with clone_from_vm_name or clone_from_vmcx_path."))
+		}
+		if b.config.SourceChecksum == "" {
+			errs = packer.MultiErrorAppend(errs, fmt.Errorf("A source_checksum must be specified with "+
+				"source_url. Use \"none\" to skip verification."))
+		}
+		if sourceArchiveType(sourceURLPath(b.config.SourceURL)) == "" {
+			errs = packer.MultiErrorAppend(errs, fmt.Errorf("The source_url must point to a zip, tar, "+
+				"tar.gz, tar.bz2 or tar.xz archive."))
+		}
+		if b.config.RemoteHost != "" {
+			errs = packer.MultiErrorAppend(errs, fmt.Errorf("The source_url can't be used with "+
+				"remote_host, copy the exported virtual machine to the Hyper-V host and use "+
+				"clone_from_vmcx_path instead."))
+		}
+	}
+
 	if b.config.CloneFromVMName == "" {
-		if b.config.CloneFromVMCXPath == "" {
+		if b.config.CloneFromVMCXPath == "" && b.config.SourceURL == "" {
 			errs = packer.MultiErrorAppend(errs, fmt.Errorf("The clone_from_vm_name must be specified if "+
 				"clone_from_vmcx_path is not specified."))
 		}
 	} else {
-		virtualMachineExists, err := powershell.DoesVirtualMachineExist(b.config.CloneFromVMName)
+		virtualMachineExists, err := powershell.DoesVirtualMachineExist(b.config.Remote(), b.config.CloneFromVMName)
 		if err != nil {
 			errs = packer.MultiErrorAppend(errs, fmt.Errorf("Failed detecting if virtual machine to clone "+
 				"from exists: %s", err))
@@ -135,14 +171,16 @@ func (b *Builder) Prepare(raws ...interface{}) ([]string, []string, error) {
 				errs = packer.MultiErrorAppend(errs, fmt.Errorf("Virtual machine '%s' to clone from does not "+
 					"exist.", b.config.CloneFromVMName))
 			} else {
-				b.config.Generation, err = powershell.GetVirtualMachineGeneration(b.config.CloneFromVMName)
+				b.config.Generation, err = powershell.GetVirtualMachineGeneration(b.config.Remote(), b.config.CloneFromVMName)
 				if err != nil {
 					errs = packer.MultiErrorAppend(errs, fmt.Errorf("Failed detecting virtual machine to clone "+
 						"from generation: %s", err))
+				} else {
+					errs = packer.MultiErrorAppend(errs, b.checkGeneration()...)
 				}
 
 				if b.config.CloneFromSnapshotName != "" {
-					virtualMachineSnapshotExists, err := powershell.DoesVirtualMachineSnapshotExist(
+					virtualMachineSnapshotExists, err := powershell.DoesVirtualMachineSnapshotExist(b.config.Remote(),
 						b.config.CloneFromVMName, b.config.CloneFromSnapshotName)
 					if err != nil {
 						errs = packer.MultiErrorAppend(errs, fmt.Errorf("Failed detecting if virtual machine "+
@@ -156,7 +194,7 @@ func (b *Builder) Prepare(raws ...interface{}) ([]string, []string, error) {
 					}
 				}
 
-				virtualMachineOn, err := powershell.IsVirtualMachineOn(b.config.CloneFromVMName)
+				virtualMachineOn, err := powershell.IsVirtualMachineOn(b.config.Remote(), b.config.CloneFromVMName)
 				if err != nil {
 					errs = packer.MultiErrorAppend(errs, fmt.Errorf("Failed detecting if virtual machine to "+
 						"clone is running: %s", err))
@@ -171,12 +209,17 @@ func (b *Builder) Prepare(raws ...interface{}) ([]string, []string, error) {
 	}
 
 	if b.config.CloneFromVMCXPath == "" {
-		if b.config.CloneFromVMName == "" {
+		if b.config.CloneFromVMName == "" && b.config.SourceURL == "" {
 			errs = packer.MultiErrorAppend(errs, fmt.Errorf("The clone_from_vmcx_path be specified if "+
 				"clone_from_vm_name must is not specified."))
 		}
 	} else {
-		if _, err := os.Stat(b.config.CloneFromVMCXPath); os.IsNotExist(err) {
+		if b.config.RemoteHost != "" {
+			if !b.config.PathExists(b.config.CloneFromVMCXPath) {
+				errs = packer.MultiErrorAppend(
+					errs, fmt.Errorf("CloneFromVMCXPath does not exist: %s", b.config.CloneFromVMCXPath))
+			}
+		} else if _, err := os.Stat(b.config.CloneFromVMCXPath); os.IsNotExist(err) {
 			if err != nil {
 				errs = packer.MultiErrorAppend(
 					errs, fmt.Errorf("CloneFromVMCXPath does not exist: %s", err))
@@ -214,11 +257,29 @@ func (b *Builder) Prepare(raws ...interface{}) ([]string, []string, error) {
 	return nil, warnings, nil
 }
 
+// checkGeneration checks the options that are only supported on Generation 2
+// virtual machines, once the generation of the virtual machine to clone from
+// is known.
+func (b *Builder) checkGeneration() []error {
+	if b.config.Generation == 2 {
+		return nil
+	}
+
+	var errs []error
+	if b.config.EnableSecureBoot {
+		errs = append(errs, errors.New("Secure boot is only supported on Generation 2 virtual machines."))
+	}
+	if b.config.EnableVirtualTPM {
+		errs = append(errs, errors.New("Virtual TPM is only supported on Generation 2 virtual machines."))
+	}
+	return errs
+}
+
 // Run executes a Packer build and returns a packer.Artifact representing
 // a Hyperv appliance.
 func (b *Builder) Run(ctx context.Context, ui packer.Ui, hook packer.Hook) (packer.Artifact, error) {
 	// Create the driver that we'll use to communicate with Hyperv
-	driver, err := hypervcommon.NewHypervPS4Driver()
+	driver, err := hypervcommon.NewDriver(b.config.DriverMode, b.config.Remote())
 	if err != nil {
 		return nil, fmt.Errorf("Failed creating Hyper-V driver: %s", err)
 	}
@@ -233,6 +294,7 @@ func (b *Builder) Run(ctx context.Context, ui packer.Ui, hook packer.Hook) (pack
 	steps := []multistep.Step{
 		&hypervcommon.StepCreateBuildDir{
 			TempPath: b.config.TempPath,
+			Remote:   b.config.RemoteHost != "",
 		},
 		&commonsteps.StepOutputDir{
 			Force: b.config.PackerForce,
@@ -245,6 +307,17 @@ func (b *Builder) Run(ctx context.Context, ui packer.Ui, hook packer.Hook) (pack
 			Url:         b.config.ISOUrls,
 			Extension:   b.config.TargetExtension,
 			TargetPath:  b.config.TargetPath,
+			Segments:    b.config.ISODownloadSegments,
+		},
+		&commonsteps.StepDownload{
+			Checksum:    b.config.SourceChecksum,
+			Description: "exported VM",
+			ResultKey:   "source_archive_path",
+			Url:         sourceURLs(b.config.SourceURL),
+			Extension:   sourceArchiveType(sourceURLPath(b.config.SourceURL)),
+		},
+		&StepExtractSource{
+			ArchiveType: sourceArchiveType(sourceURLPath(b.config.SourceURL)),
 		},
 		&commonsteps.StepCreateFloppy{
 			Files:       b.config.FloppyFiles,
@@ -252,13 +325,33 @@ func (b *Builder) Run(ctx context.Context, ui packer.Ui, hook packer.Hook) (pack
 			Label:       b.config.FloppyConfig.FloppyLabel,
 		},
 		&commonsteps.StepHTTPServer{
-			HTTPDir:     b.config.HTTPDir,
-			HTTPPortMin: b.config.HTTPPortMin,
-			HTTPPortMax: b.config.HTTPPortMax,
-			HTTPAddress: b.config.HTTPAddress,
+			HTTPDir:      b.config.HTTPDir,
+			HTTPPortMin:  b.config.HTTPPortMin,
+			HTTPPortMax:  b.config.HTTPPortMax,
+			HTTPAddress:  b.config.HTTPAddress,
+			UseTLS:       b.config.HTTPUseTLS,
+			TLSCertFile:  b.config.HTTPTLSCertFile,
+			TLSKeyFile:   b.config.HTTPTLSKeyFile,
+			AuthToken:    b.config.HTTPAuthToken,
+			Templates:    b.config.HTTPTemplates,
+			TemplateVars: b.config.HTTPTemplateVars,
+		},
+		&communicator.StepSSHKeyPair{
+			Debug:        b.config.PackerDebug,
+			DebugKeyPath: fmt.Sprintf("%s.pem", b.config.PackerBuildName),
+			Comm:         &b.config.SSHConfig.Comm,
 		},
 		&hypervcommon.StepCreateSwitch{
-			SwitchName: b.config.SwitchName,
+			SwitchName:     b.config.SwitchName,
+			SwitchType:     b.config.SwitchType,
+			NetAdapterName: b.config.SwitchNetAdapterName,
+			SkipCreation:   b.config.CreateSwitch.False(),
+			EnableNat:      b.config.SwitchNat,
+			NatPrefix:      b.config.SwitchNatPrefix,
+		},
+		&hypervcommon.StepUploadToHost{
+			Enabled: b.config.RemoteHost != "",
+			Keys:    []string{"iso_path"},
 		},
 		&hypervcommon.StepCloneVM{
 			CloneFromVMCXPath:              b.config.CloneFromVMCXPath,
@@ -270,14 +363,22 @@ func (b *Builder) Run(ctx context.Context, ui packer.Ui, hook packer.Hook) (pack
 			CompareCopy:                    b.config.CompareCopy,
 			RamSize:                        b.config.RamSize,
 			Cpu:                            b.config.Cpu,
+			CpusPerSocket:                  b.config.CpusPerSocket,
+			NumaNodes:                      b.config.NumaNodes,
 			EnableMacSpoofing:              b.config.EnableMacSpoofing,
 			EnableDynamicMemory:            b.config.EnableDynamicMemory,
+			DynamicMemoryMinimum:           b.config.DynamicMemoryMinimum,
+			DynamicMemoryMaximum:           b.config.DynamicMemoryMaximum,
+			MemoryBufferPercent:            b.config.MemoryBufferPercent,
 			EnableSecureBoot:               b.config.EnableSecureBoot,
 			SecureBootTemplate:             b.config.SecureBootTemplate,
+			EnableVirtualTPM:               b.config.EnableVirtualTPM,
 			EnableVirtualizationExtensions: b.config.EnableVirtualizationExtensions,
+			ExposeProcessorFeatures:        b.config.ExposeProcessorFeatures,
 			MacAddress:                     b.config.MacAddress,
 			KeepRegistered:                 b.config.KeepRegistered,
 			AdditionalDiskSize:             b.config.AdditionalDiskSize,
+			AdditionalDisks:                b.config.AdditionalDisks,
 			DiskBlockSize:                  b.config.DiskBlockSize,
 		},
 
@@ -289,6 +390,7 @@ func (b *Builder) Run(ctx context.Context, ui packer.Ui, hook packer.Hook) (pack
 		},
 		&hypervcommon.StepMountFloppydrive{
 			Generation: b.config.Generation,
+			Remote:     b.config.RemoteHost != "",
 		},
 
 		&hypervcommon.StepMountGuestAdditions{
@@ -300,6 +402,10 @@ func (b *Builder) Run(ctx context.Context, ui packer.Ui, hook packer.Hook) (pack
 			Files: b.config.CDConfig.CDFiles,
 			Label: b.config.CDConfig.CDLabel,
 		},
+		&hypervcommon.StepUploadToHost{
+			Enabled: b.config.RemoteHost != "",
+			Keys:    []string{"cd_path"},
+		},
 		&hypervcommon.StepMountSecondaryDvdImages{
 			IsoPaths:   b.config.SecondaryDvdImages,
 			Generation: b.config.Generation,
@@ -309,6 +415,9 @@ func (b *Builder) Run(ctx context.Context, ui packer.Ui, hook packer.Hook) (pack
 			VlanId:       b.config.VlanId,
 			SwitchVlanId: b.config.SwitchVlanId,
 		},
+		&hypervcommon.StepAddNetworkAdapters{
+			Adapters: b.config.AdditionalNetworkAdapters,
+		},
 
 		&hypervcommon.StepSetBootOrder{
 			BootOrder: b.config.BootOrder,
@@ -318,9 +427,15 @@ func (b *Builder) Run(ctx context.Context, ui packer.Ui, hook packer.Hook) (pack
 			FirstBootDevice: b.config.FirstBootDevice,
 		},
 
+		&hypervcommon.StepSerialLog{
+			Path:        b.config.SerialLogFile,
+			ShowOnError: b.config.SerialLogOnError,
+		},
+
 		&hypervcommon.StepRun{
 			Headless:   b.config.Headless,
 			SwitchName: b.config.SwitchName,
+			RemoteHost: b.config.RemoteHost,
 		},
 
 		&hypervcommon.StepTypeBootCommand{
@@ -329,30 +444,66 @@ func (b *Builder) Run(ctx context.Context, ui packer.Ui, hook packer.Hook) (pack
 			SwitchName:    b.config.SwitchName,
 			Ctx:           b.config.ctx,
 			GroupInterval: b.config.BootConfig.BootGroupInterval,
+			TypeText:      b.config.BootTypeText,
+			Comm:          &b.config.SSHConfig.Comm,
+		},
+
+		&hypervcommon.StepWaitForIp{
+			Config:  &b.config.SSHConfig.Comm,
+			Source:  b.config.IpAddressSource,
+			Family:  b.config.IpAddressFamily,
+			Timeout: b.config.IpAddressTimeout,
 		},
 
 		// configure the communicator ssh, winrm
 		&communicator.StepConnect{
-			Config:    &b.config.SSHConfig.Comm,
-			Host:      hypervcommon.CommHost(b.config.SSHConfig.Comm.SSHHost),
+			Config: &b.config.SSHConfig.Comm,
+			Host: hypervcommon.CommHost(b.config.SSHConfig.Comm.SSHHost, b.config.IpAddressSource,
+				b.config.IpAddressFamily),
 			SSHConfig: b.config.SSHConfig.Comm.SSHConfigFunc(),
+			CustomConnect: map[string]multistep.Step{
+				hypervcommon.PowerShellDirectCommunicatorType: &hypervcommon.StepConnectPowerShellDirect{
+					Config: &b.config.SSHConfig.Comm,
+				},
+			},
 		},
 
 		// provision requires communicator to be setup
+		&hypervcommon.StepDebugCheckpoint{
+			Enabled:    b.config.DebugCheckpoints,
+			Name:       hypervcommon.DebugCheckpointBeforeProvisioning,
+			FailedName: hypervcommon.DebugCheckpointProvisioningFailed,
+		},
 		&commonsteps.StepProvision{},
+		&hypervcommon.StepDebugCheckpoint{
+			Enabled: b.config.DebugCheckpoints,
+			Name:    hypervcommon.DebugCheckpointAfterProvisioning,
+		},
 
 		// Remove ephemeral SSH keys, if using
 		&commonsteps.StepCleanupTempKeys{
 			Comm: &b.config.SSHConfig.Comm,
 		},
 
+		&commonsteps.StepPauseBeforeShutdown{
+			Duration:    b.config.PauseBeforeShutdown,
+			Interactive: b.config.PauseBeforeShutdownInteractive,
+		},
 		&hypervcommon.StepShutdown{
-			Command: b.config.ShutdownCommand,
-			Timeout: b.config.ShutdownTimeout,
+			Command:           b.config.ShutdownCommand,
+			Timeout:           b.config.ShutdownTimeout,
+			Retries:           b.config.ShutdownRetries,
+			RetryInterval:     b.config.ShutdownRetryInterval,
+			ForceStop:         b.config.ShutdownForceStop,
+			WaitForHeartbeat:  b.config.ShutdownWaitForHeartbeat,
+			PostShutdownDelay: b.config.PostShutdownDelay,
 		},
 
 		// wait for the vm to be powered off
 		&hypervcommon.StepWaitForPowerOff{},
+		&hypervcommon.StepRemoveDebugCheckpoints{
+			Enabled: b.config.DebugCheckpoints,
+		},
 
 		// remove the secondary dvd images
 		// after we power down
@@ -362,16 +513,24 @@ func (b *Builder) Run(ctx context.Context, ui packer.Ui, hook packer.Hook) (pack
 		&hypervcommon.StepUnmountFloppyDrive{
 			Generation: b.config.Generation,
 		},
+		&hypervcommon.StepDisconnectSerialPort{
+			SerialLogFile: b.config.SerialLogFile,
+		},
 		&hypervcommon.StepCompactDisk{
 			SkipCompaction: b.config.SkipCompaction,
 		},
 		&hypervcommon.StepExportVm{
-			OutputDir:  b.config.OutputDir,
-			SkipExport: b.config.SkipExport,
+			OutputDir:        b.config.OutputDir,
+			SkipExport:       b.config.SkipExport,
+			ExportMode:       b.config.ExportMode,
+			OutputDiskFormat: b.config.OutputDiskFormat,
+			OutputDiskType:   b.config.OutputDiskType,
+			Remote:           b.config.RemoteHost != "",
 		},
 		&hypervcommon.StepCollateArtifacts{
 			OutputDir:  b.config.OutputDir,
 			SkipExport: b.config.SkipExport,
+			Remote:     b.config.RemoteHost != "",
 		},
 	}
 
@@ -395,8 +554,33 @@ func (b *Builder) Run(ctx context.Context, ui packer.Ui, hook packer.Hook) (pack
 		return nil, errors.New("Build was halted.")
 	}
 
-	generatedData := map[string]interface{}{"generated_data": state.Get("generated_data")}
+	generatedData := map[string]interface{}{
+		"generated_data": state.Get("generated_data"),
+		// The Vagrant post-processor packages the virtual machine according
+		// to its generation and configuration version
+		"generation":            b.config.Generation,
+		"configuration_version": b.config.Version,
+	}
 	return hypervcommon.NewArtifact(b.config.OutputDir, generatedData)
 }
 
 // Cancel.
+
+// sourceURLs returns the URLs StepDownload fetches the source archive from.
+// Archive detection is turned off, since go-getter can only unpack archives
+// holding a single file when downloading a file; StepExtractSource unpacks
+// it instead.
+func sourceURLs(sourceURL string) []string {
+	if sourceURL == "" {
+		return nil
+	}
+	if strings.Contains(sourceURL, "?") {
+		return []string{sourceURL + "&archive=false"}
+	}
+	return []string{sourceURL + "?archive=false"}
+}
+
+// sourceURLPath returns sourceURL without its query.
+func sourceURLPath(sourceURL string) string {
+	return strings.SplitN(sourceURL, "?", 2)[0]
+}
@@ -3,122 +3,184 @@ package vmcx
 
 import (
 	"github.com/hashicorp/hcl/v2/hcldec"
+	"github.com/hashicorp/packer/builder/hyperv/common"
 	"github.com/zclconf/go-cty/cty"
 )
 
 // FlatConfig is an auto-generated flat version of Config.
 // Where the contents of a field with a `mapstructure:,squash` tag are bubbled up.
 type FlatConfig struct {
-	PackerBuildName                *string           `mapstructure:"packer_build_name" cty:"packer_build_name" hcl:"packer_build_name"`
-	PackerBuilderType              *string           `mapstructure:"packer_builder_type" cty:"packer_builder_type" hcl:"packer_builder_type"`
-	PackerCoreVersion              *string           `mapstructure:"packer_core_version" cty:"packer_core_version" hcl:"packer_core_version"`
-	PackerDebug                    *bool             `mapstructure:"packer_debug" cty:"packer_debug" hcl:"packer_debug"`
-	PackerForce                    *bool             `mapstructure:"packer_force" cty:"packer_force" hcl:"packer_force"`
-	PackerOnError                  *string           `mapstructure:"packer_on_error" cty:"packer_on_error" hcl:"packer_on_error"`
-	PackerUserVars                 map[string]string `mapstructure:"packer_user_variables" cty:"packer_user_variables" hcl:"packer_user_variables"`
-	PackerSensitiveVars            []string          `mapstructure:"packer_sensitive_variables" cty:"packer_sensitive_variables" hcl:"packer_sensitive_variables"`
-	HTTPDir                        *string           `mapstructure:"http_directory" cty:"http_directory" hcl:"http_directory"`
-	HTTPPortMin                    *int              `mapstructure:"http_port_min" cty:"http_port_min" hcl:"http_port_min"`
-	HTTPPortMax                    *int              `mapstructure:"http_port_max" cty:"http_port_max" hcl:"http_port_max"`
-	HTTPAddress                    *string           `mapstructure:"http_bind_address" cty:"http_bind_address" hcl:"http_bind_address"`
-	HTTPInterface                  *string           `mapstructure:"http_interface" undocumented:"true" cty:"http_interface" hcl:"http_interface"`
-	ISOChecksum                    *string           `mapstructure:"iso_checksum" required:"true" cty:"iso_checksum" hcl:"iso_checksum"`
-	RawSingleISOUrl                *string           `mapstructure:"iso_url" required:"true" cty:"iso_url" hcl:"iso_url"`
-	ISOUrls                        []string          `mapstructure:"iso_urls" cty:"iso_urls" hcl:"iso_urls"`
-	TargetPath                     *string           `mapstructure:"iso_target_path" cty:"iso_target_path" hcl:"iso_target_path"`
-	TargetExtension                *string           `mapstructure:"iso_target_extension" cty:"iso_target_extension" hcl:"iso_target_extension"`
-	BootGroupInterval              *string           `mapstructure:"boot_keygroup_interval" cty:"boot_keygroup_interval" hcl:"boot_keygroup_interval"`
-	BootWait                       *string           `mapstructure:"boot_wait" cty:"boot_wait" hcl:"boot_wait"`
-	BootCommand                    []string          `mapstructure:"boot_command" cty:"boot_command" hcl:"boot_command"`
-	OutputDir                      *string           `mapstructure:"output_directory" required:"false" cty:"output_directory" hcl:"output_directory"`
-	Type                           *string           `mapstructure:"communicator" cty:"communicator" hcl:"communicator"`
-	PauseBeforeConnect             *string           `mapstructure:"pause_before_connecting" cty:"pause_before_connecting" hcl:"pause_before_connecting"`
-	SSHHost                        *string           `mapstructure:"ssh_host" cty:"ssh_host" hcl:"ssh_host"`
-	SSHPort                        *int              `mapstructure:"ssh_port" cty:"ssh_port" hcl:"ssh_port"`
-	SSHUsername                    *string           `mapstructure:"ssh_username" cty:"ssh_username" hcl:"ssh_username"`
-	SSHPassword                    *string           `mapstructure:"ssh_password" cty:"ssh_password" hcl:"ssh_password"`
-	SSHKeyPairName                 *string           `mapstructure:"ssh_keypair_name" undocumented:"true" cty:"ssh_keypair_name" hcl:"ssh_keypair_name"`
-	SSHTemporaryKeyPairName        *string           `mapstructure:"temporary_key_pair_name" undocumented:"true" cty:"temporary_key_pair_name" hcl:"temporary_key_pair_name"`
-	SSHTemporaryKeyPairType        *string           `mapstructure:"temporary_key_pair_type" cty:"temporary_key_pair_type" hcl:"temporary_key_pair_type"`
-	SSHTemporaryKeyPairBits        *int              `mapstructure:"temporary_key_pair_bits" cty:"temporary_key_pair_bits" hcl:"temporary_key_pair_bits"`
-	SSHCiphers                     []string          `mapstructure:"ssh_ciphers" cty:"ssh_ciphers" hcl:"ssh_ciphers"`
-	SSHClearAuthorizedKeys         *bool             `mapstructure:"ssh_clear_authorized_keys" cty:"ssh_clear_authorized_keys" hcl:"ssh_clear_authorized_keys"`
-	SSHKEXAlgos                    []string          `mapstructure:"ssh_key_exchange_algorithms" cty:"ssh_key_exchange_algorithms" hcl:"ssh_key_exchange_algorithms"`
-	SSHPrivateKeyFile              *string           `mapstructure:"ssh_private_key_file" undocumented:"true" cty:"ssh_private_key_file" hcl:"ssh_private_key_file"`
-	SSHCertificateFile             *string           `mapstructure:"ssh_certificate_file" cty:"ssh_certificate_file" hcl:"ssh_certificate_file"`
-	SSHPty                         *bool             `mapstructure:"ssh_pty" cty:"ssh_pty" hcl:"ssh_pty"`
-	SSHTimeout                     *string           `mapstructure:"ssh_timeout" cty:"ssh_timeout" hcl:"ssh_timeout"`
-	SSHWaitTimeout                 *string           `mapstructure:"ssh_wait_timeout" undocumented:"true" cty:"ssh_wait_timeout" hcl:"ssh_wait_timeout"`
-	SSHAgentAuth                   *bool             `mapstructure:"ssh_agent_auth" undocumented:"true" cty:"ssh_agent_auth" hcl:"ssh_agent_auth"`
-	SSHDisableAgentForwarding      *bool             `mapstructure:"ssh_disable_agent_forwarding" cty:"ssh_disable_agent_forwarding" hcl:"ssh_disable_agent_forwarding"`
-	SSHHandshakeAttempts           *int              `mapstructure:"ssh_handshake_attempts" cty:"ssh_handshake_attempts" hcl:"ssh_handshake_attempts"`
-	SSHBastionHost                 *string           `mapstructure:"ssh_bastion_host" cty:"ssh_bastion_host" hcl:"ssh_bastion_host"`
-	SSHBastionPort                 *int              `mapstructure:"ssh_bastion_port" cty:"ssh_bastion_port" hcl:"ssh_bastion_port"`
-	SSHBastionAgentAuth            *bool             `mapstructure:"ssh_bastion_agent_auth" cty:"ssh_bastion_agent_auth" hcl:"ssh_bastion_agent_auth"`
-	SSHBastionUsername             *string           `mapstructure:"ssh_bastion_username" cty:"ssh_bastion_username" hcl:"ssh_bastion_username"`
-	SSHBastionPassword             *string           `mapstructure:"ssh_bastion_password" cty:"ssh_bastion_password" hcl:"ssh_bastion_password"`
-	SSHBastionInteractive          *bool             `mapstructure:"ssh_bastion_interactive" cty:"ssh_bastion_interactive" hcl:"ssh_bastion_interactive"`
-	SSHBastionPrivateKeyFile       *string           `mapstructure:"ssh_bastion_private_key_file" cty:"ssh_bastion_private_key_file" hcl:"ssh_bastion_private_key_file"`
-	SSHBastionCertificateFile      *string           `mapstructure:"ssh_bastion_certificate_file" cty:"ssh_bastion_certificate_file" hcl:"ssh_bastion_certificate_file"`
-	SSHFileTransferMethod          *string           `mapstructure:"ssh_file_transfer_method" cty:"ssh_file_transfer_method" hcl:"ssh_file_transfer_method"`
-	SSHProxyHost                   *string           `mapstructure:"ssh_proxy_host" cty:"ssh_proxy_host" hcl:"ssh_proxy_host"`
-	SSHProxyPort                   *int              `mapstructure:"ssh_proxy_port" cty:"ssh_proxy_port" hcl:"ssh_proxy_port"`
-	SSHProxyUsername               *string           `mapstructure:"ssh_proxy_username" cty:"ssh_proxy_username" hcl:"ssh_proxy_username"`
-	SSHProxyPassword               *string           `mapstructure:"ssh_proxy_password" cty:"ssh_proxy_password" hcl:"ssh_proxy_password"`
-	SSHKeepAliveInterval           *string           `mapstructure:"ssh_keep_alive_interval" cty:"ssh_keep_alive_interval" hcl:"ssh_keep_alive_interval"`
-	SSHReadWriteTimeout            *string           `mapstructure:"ssh_read_write_timeout" cty:"ssh_read_write_timeout" hcl:"ssh_read_write_timeout"`
-	SSHRemoteTunnels               []string          `mapstructure:"ssh_remote_tunnels" cty:"ssh_remote_tunnels" hcl:"ssh_remote_tunnels"`
-	SSHLocalTunnels                []string          `mapstructure:"ssh_local_tunnels" cty:"ssh_local_tunnels" hcl:"ssh_local_tunnels"`
-	SSHPublicKey                   []byte            `mapstructure:"ssh_public_key" undocumented:"true" cty:"ssh_public_key" hcl:"ssh_public_key"`
-	SSHPrivateKey                  []byte            `mapstructure:"ssh_private_key" undocumented:"true" cty:"ssh_private_key" hcl:"ssh_private_key"`
-	WinRMUser                      *string           `mapstructure:"winrm_username" cty:"winrm_username" hcl:"winrm_username"`
-	WinRMPassword                  *string           `mapstructure:"winrm_password" cty:"winrm_password" hcl:"winrm_password"`
-	WinRMHost                      *string           `mapstructure:"winrm_host" cty:"winrm_host" hcl:"winrm_host"`
-	WinRMNoProxy                   *bool             `mapstructure:"winrm_no_proxy" cty:"winrm_no_proxy" hcl:"winrm_no_proxy"`
-	WinRMPort                      *int              `mapstructure:"winrm_port" cty:"winrm_port" hcl:"winrm_port"`
-	WinRMTimeout                   *string           `mapstructure:"winrm_timeout" cty:"winrm_timeout" hcl:"winrm_timeout"`
-	WinRMUseSSL                    *bool             `mapstructure:"winrm_use_ssl" cty:"winrm_use_ssl" hcl:"winrm_use_ssl"`
-	WinRMInsecure                  *bool             `mapstructure:"winrm_insecure" cty:"winrm_insecure" hcl:"winrm_insecure"`
-	WinRMUseNTLM                   *bool             `mapstructure:"winrm_use_ntlm" cty:"winrm_use_ntlm" hcl:"winrm_use_ntlm"`
-	FloppyFiles                    []string          `mapstructure:"floppy_files" cty:"floppy_files" hcl:"floppy_files"`
-	FloppyDirectories              []string          `mapstructure:"floppy_dirs" cty:"floppy_dirs" hcl:"floppy_dirs"`
-	FloppyLabel                    *string           `mapstructure:"floppy_label" cty:"floppy_label" hcl:"floppy_label"`
-	CDFiles                        []string          `mapstructure:"cd_files" cty:"cd_files" hcl:"cd_files"`
-	CDLabel                        *string           `mapstructure:"cd_label" cty:"cd_label" hcl:"cd_label"`
-	DiskBlockSize                  *uint             `mapstructure:"disk_block_size" required:"false" cty:"disk_block_size" hcl:"disk_block_size"`
-	RamSize                        *uint             `mapstructure:"memory" required:"false" cty:"memory" hcl:"memory"`
-	SecondaryDvdImages             []string          `mapstructure:"secondary_iso_images" required:"false" cty:"secondary_iso_images" hcl:"secondary_iso_images"`
-	AdditionalDiskSize             []uint            `mapstructure:"disk_additional_size" required:"false" cty:"disk_additional_size" hcl:"disk_additional_size"`
-	GuestAdditionsMode             *string           `mapstructure:"guest_additions_mode" required:"false" cty:"guest_additions_mode" hcl:"guest_additions_mode"`
-	GuestAdditionsPath             *string           `mapstructure:"guest_additions_path" required:"false" cty:"guest_additions_path" hcl:"guest_additions_path"`
-	VMName                         *string           `mapstructure:"vm_name" required:"false" cty:"vm_name" hcl:"vm_name"`
-	SwitchName                     *string           `mapstructure:"switch_name" required:"false" cty:"switch_name" hcl:"switch_name"`
-	SwitchVlanId                   *string           `mapstructure:"switch_vlan_id" required:"false" cty:"switch_vlan_id" hcl:"switch_vlan_id"`
-	MacAddress                     *string           `mapstructure:"mac_address" required:"false" cty:"mac_address" hcl:"mac_address"`
-	VlanId                         *string           `mapstructure:"vlan_id" required:"false" cty:"vlan_id" hcl:"vlan_id"`
-	Cpu                            *uint             `mapstructure:"cpus" required:"false" cty:"cpus" hcl:"cpus"`
-	Generation                     *uint             `mapstructure:"generation" required:"false" cty:"generation" hcl:"generation"`
-	EnableMacSpoofing              *bool             `mapstructure:"enable_mac_spoofing" required:"false" cty:"enable_mac_spoofing" hcl:"enable_mac_spoofing"`
-	EnableDynamicMemory            *bool             `mapstructure:"enable_dynamic_memory" required:"false" cty:"enable_dynamic_memory" hcl:"enable_dynamic_memory"`
-	EnableSecureBoot               *bool             `mapstructure:"enable_secure_boot" required:"false" cty:"enable_secure_boot" hcl:"enable_secure_boot"`
-	SecureBootTemplate             *string           `mapstructure:"secure_boot_template" required:"false" cty:"secure_boot_template" hcl:"secure_boot_template"`
-	EnableVirtualizationExtensions *bool             `mapstructure:"enable_virtualization_extensions" required:"false" cty:"enable_virtualization_extensions" hcl:"enable_virtualization_extensions"`
-	TempPath                       *string           `mapstructure:"temp_path" required:"false" cty:"temp_path" hcl:"temp_path"`
-	Version                        *string           `mapstructure:"configuration_version" required:"false" cty:"configuration_version" hcl:"configuration_version"`
-	KeepRegistered                 *bool             `mapstructure:"keep_registered" required:"false" cty:"keep_registered" hcl:"keep_registered"`
-	SkipCompaction                 *bool             `mapstructure:"skip_compaction" required:"false" cty:"skip_compaction" hcl:"skip_compaction"`
-	SkipExport                     *bool             `mapstructure:"skip_export" required:"false" cty:"skip_export" hcl:"skip_export"`
-	Headless                       *bool             `mapstructure:"headless" required:"false" cty:"headless" hcl:"headless"`
-	FirstBootDevice                *string           `mapstructure:"first_boot_device" required:"false" cty:"first_boot_device" hcl:"first_boot_device"`
-	BootOrder                      []string          `mapstructure:"boot_order" required:"false" cty:"boot_order" hcl:"boot_order"`
-	ShutdownCommand                *string           `mapstructure:"shutdown_command" required:"false" cty:"shutdown_command" hcl:"shutdown_command"`
-	ShutdownTimeout                *string           `mapstructure:"shutdown_timeout" required:"false" cty:"shutdown_timeout" hcl:"shutdown_timeout"`
-	CloneFromVMCXPath              *string           `mapstructure:"clone_from_vmcx_path" cty:"clone_from_vmcx_path" hcl:"clone_from_vmcx_path"`
-	CloneFromVMName                *string           `mapstructure:"clone_from_vm_name" cty:"clone_from_vm_name" hcl:"clone_from_vm_name"`
-	CloneFromSnapshotName          *string           `mapstructure:"clone_from_snapshot_name" required:"false" cty:"clone_from_snapshot_name" hcl:"clone_from_snapshot_name"`
-	CloneAllSnapshots              *bool             `mapstructure:"clone_all_snapshots" required:"false" cty:"clone_all_snapshots" hcl:"clone_all_snapshots"`
-	DifferencingDisk               *bool             `mapstructure:"differencing_disk" required:"false" cty:"differencing_disk" hcl:"differencing_disk"`
-	CompareCopy                    *bool             `mapstructure:"copy_in_compare" required:"false" cty:"copy_in_compare" hcl:"copy_in_compare"`
+	PackerBuildName      *string           `mapstructure:"packer_build_name" cty:"packer_build_name" hcl:"packer_build_name"`
+	PackerBuilderType    *string           `mapstructure:"packer_builder_type" cty:"packer_builder_type" hcl:"packer_builder_type"`
+	PackerCoreVersion    *string           `mapstructure:"packer_core_version" cty:"packer_core_version" hcl:"packer_core_version"`
+	PackerDebug          *bool             `mapstructure:"packer_debug" cty:"packer_debug" hcl:"packer_debug"`
+	PackerForce          *bool             `mapstructure:"packer_force" cty:"packer_force" hcl:"packer_force"`
+	PackerOnError        *string           `mapstructure:"packer_on_error" cty:"packer_on_error" hcl:"packer_on_error"`
+	PackerResume         *bool             `mapstructure:"packer_resume" cty:"packer_resume" hcl:"packer_resume"`
+	PackerCleanupTimeout *string           `mapstructure:"packer_cleanup_timeout" cty:"packer_cleanup_timeout" hcl:"packer_cleanup_timeout"`
+	PackerUserVars       map[string]string `mapstructure:"packer_user_variables" cty:"packer_user_variables" hcl:"packer_user_variables"`
+	PackerSensitiveVars  []string          `mapstructure:"packer_sensitive_variables" cty:"packer_sensitive_variables" hcl:"packer_sensitive_variables"`
+	HTTPDir              *string           `mapstructure:"http_directory" cty:"http_directory" hcl:"http_directory"`
+	HTTPPortMin          *int              `mapstructure:"http_port_min" cty:"http_port_min" hcl:"http_port_min"`
+	HTTPPortMax          *int              `mapstructure:"http_port_max" cty:"http_port_max" hcl:"http_port_max"`
+	HTTPAddress          *string           `mapstructure:"http_bind_address" cty:"http_bind_address" hcl:"http_bind_address"`
+	HTTPInterface        *string           `mapstructure:"http_interface" undocumented:"true" cty:"http_interface" hcl:"http_interface"`
+
+	HTTPUseTLS *bool `mapstructure:"http_use_tls" cty:"http_use_tls" hcl:"http_use_tls"`
+
+	HTTPTLSCertFile *string `mapstructure:"http_tls_cert_file" cty:"http_tls_cert_file" hcl:"http_tls_cert_file"`
+
+	HTTPTLSKeyFile *string `mapstructure:"http_tls_key_file" cty:"http_tls_key_file" hcl:"http_tls_key_file"`
+
+	HTTPAuthToken *bool `mapstructure:"http_auth_token" cty:"http_auth_token" hcl:"http_auth_token"`
+
+	HTTPTemplates []string `mapstructure:"http_templates" cty:"http_templates" hcl:"http_templates"`
+
+	HTTPTemplateVars               map[string]string                     `mapstructure:"http_template_vars" cty:"http_template_vars" hcl:"http_template_vars"`
+	ISOChecksum                    *string                               `mapstructure:"iso_checksum" required:"true" cty:"iso_checksum" hcl:"iso_checksum"`
+	RawSingleISOUrl                *string                               `mapstructure:"iso_url" required:"true" cty:"iso_url" hcl:"iso_url"`
+	ISOUrls                        []string                              `mapstructure:"iso_urls" cty:"iso_urls" hcl:"iso_urls"`
+	TargetPath                     *string                               `mapstructure:"iso_target_path" cty:"iso_target_path" hcl:"iso_target_path"`
+	TargetExtension                *string                               `mapstructure:"iso_target_extension" cty:"iso_target_extension" hcl:"iso_target_extension"`
+	ISODownloadSegments            *int                                  `mapstructure:"iso_download_segments" cty:"iso_download_segments" hcl:"iso_download_segments"`
+	BootGroupInterval              *string                               `mapstructure:"boot_keygroup_interval" cty:"boot_keygroup_interval" hcl:"boot_keygroup_interval"`
+	BootWait                       *string                               `mapstructure:"boot_wait" cty:"boot_wait" hcl:"boot_wait"`
+	BootCommand                    []string                              `mapstructure:"boot_command" cty:"boot_command" hcl:"boot_command"`
+	OutputDir                      *string                               `mapstructure:"output_directory" required:"false" cty:"output_directory" hcl:"output_directory"`
+	Type                           *string                               `mapstructure:"communicator" cty:"communicator" hcl:"communicator"`
+	PauseBeforeConnect             *string                               `mapstructure:"pause_before_connecting" cty:"pause_before_connecting" hcl:"pause_before_connecting"`
+	ReconnectTimeout               *string                               `mapstructure:"reconnect_timeout" cty:"reconnect_timeout" hcl:"reconnect_timeout"`
+	SSHHost                        *string                               `mapstructure:"ssh_host" cty:"ssh_host" hcl:"ssh_host"`
+	SSHPort                        *int                                  `mapstructure:"ssh_port" cty:"ssh_port" hcl:"ssh_port"`
+	SSHUsername                    *string                               `mapstructure:"ssh_username" cty:"ssh_username" hcl:"ssh_username"`
+	SSHPassword                    *string                               `mapstructure:"ssh_password" cty:"ssh_password" hcl:"ssh_password"`
+	SSHKeyPairName                 *string                               `mapstructure:"ssh_keypair_name" undocumented:"true" cty:"ssh_keypair_name" hcl:"ssh_keypair_name"`
+	SSHTemporaryKeyPairName        *string                               `mapstructure:"temporary_key_pair_name" undocumented:"true" cty:"temporary_key_pair_name" hcl:"temporary_key_pair_name"`
+	SSHTemporaryKeyPairType        *string                               `mapstructure:"temporary_key_pair_type" cty:"temporary_key_pair_type" hcl:"temporary_key_pair_type"`
+	SSHTemporaryKeyPairBits        *int                                  `mapstructure:"temporary_key_pair_bits" cty:"temporary_key_pair_bits" hcl:"temporary_key_pair_bits"`
+	SSHCiphers                     []string                              `mapstructure:"ssh_ciphers" cty:"ssh_ciphers" hcl:"ssh_ciphers"`
+	SSHClearAuthorizedKeys         *bool                                 `mapstructure:"ssh_clear_authorized_keys" cty:"ssh_clear_authorized_keys" hcl:"ssh_clear_authorized_keys"`
+	SSHKEXAlgos                    []string                              `mapstructure:"ssh_key_exchange_algorithms" cty:"ssh_key_exchange_algorithms" hcl:"ssh_key_exchange_algorithms"`
+	SSHPrivateKeyFile              *string                               `mapstructure:"ssh_private_key_file" undocumented:"true" cty:"ssh_private_key_file" hcl:"ssh_private_key_file"`
+	SSHCertificateFile             *string                               `mapstructure:"ssh_certificate_file" cty:"ssh_certificate_file" hcl:"ssh_certificate_file"`
+	SSHPty                         *bool                                 `mapstructure:"ssh_pty" cty:"ssh_pty" hcl:"ssh_pty"`
+	SSHTimeout                     *string                               `mapstructure:"ssh_timeout" cty:"ssh_timeout" hcl:"ssh_timeout"`
+	SSHWaitTimeout                 *string                               `mapstructure:"ssh_wait_timeout" undocumented:"true" cty:"ssh_wait_timeout" hcl:"ssh_wait_timeout"`
+	SSHAgentAuth                   *bool                                 `mapstructure:"ssh_agent_auth" undocumented:"true" cty:"ssh_agent_auth" hcl:"ssh_agent_auth"`
+	SSHDisableAgentForwarding      *bool                                 `mapstructure:"ssh_disable_agent_forwarding" cty:"ssh_disable_agent_forwarding" hcl:"ssh_disable_agent_forwarding"`
+	SSHPinHostKey                  *bool                                 `mapstructure:"ssh_pin_host_key" cty:"ssh_pin_host_key" hcl:"ssh_pin_host_key"`
+	SSHHandshakeAttempts           *int                                  `mapstructure:"ssh_handshake_attempts" cty:"ssh_handshake_attempts" hcl:"ssh_handshake_attempts"`
+	SSHBastionHost                 *string                               `mapstructure:"ssh_bastion_host" cty:"ssh_bastion_host" hcl:"ssh_bastion_host"`
+	SSHBastionPort                 *int                                  `mapstructure:"ssh_bastion_port" cty:"ssh_bastion_port" hcl:"ssh_bastion_port"`
+	SSHBastionAgentAuth            *bool                                 `mapstructure:"ssh_bastion_agent_auth" cty:"ssh_bastion_agent_auth" hcl:"ssh_bastion_agent_auth"`
+	SSHBastionUsername             *string                               `mapstructure:"ssh_bastion_username" cty:"ssh_bastion_username" hcl:"ssh_bastion_username"`
+	SSHBastionPassword             *string                               `mapstructure:"ssh_bastion_password" cty:"ssh_bastion_password" hcl:"ssh_bastion_password"`
+	SSHBastionInteractive          *bool                                 `mapstructure:"ssh_bastion_interactive" cty:"ssh_bastion_interactive" hcl:"ssh_bastion_interactive"`
+	SSHBastionPrivateKeyFile       *string                               `mapstructure:"ssh_bastion_private_key_file" cty:"ssh_bastion_private_key_file" hcl:"ssh_bastion_private_key_file"`
+	SSHBastionCertificateFile      *string                               `mapstructure:"ssh_bastion_certificate_file" cty:"ssh_bastion_certificate_file" hcl:"ssh_bastion_certificate_file"`
+	SSHFileTransferMethod          *string                               `mapstructure:"ssh_file_transfer_method" cty:"ssh_file_transfer_method" hcl:"ssh_file_transfer_method"`
+	SSHUploadConcurrency           *int                                  `mapstructure:"ssh_upload_concurrency" cty:"ssh_upload_concurrency" hcl:"ssh_upload_concurrency"`
+	SSHUploadBufferSize            *int                                  `mapstructure:"ssh_upload_buffer_size" cty:"ssh_upload_buffer_size" hcl:"ssh_upload_buffer_size"`
+	SSHUploadCompression           *bool                                 `mapstructure:"ssh_upload_compression" cty:"ssh_upload_compression" hcl:"ssh_upload_compression"`
+	SSHProxyHost                   *string                               `mapstructure:"ssh_proxy_host" cty:"ssh_proxy_host" hcl:"ssh_proxy_host"`
+	SSHProxyPort                   *int                                  `mapstructure:"ssh_proxy_port" cty:"ssh_proxy_port" hcl:"ssh_proxy_port"`
+	SSHProxyUsername               *string                               `mapstructure:"ssh_proxy_username" cty:"ssh_proxy_username" hcl:"ssh_proxy_username"`
+	SSHProxyPassword               *string                               `mapstructure:"ssh_proxy_password" cty:"ssh_proxy_password" hcl:"ssh_proxy_password"`
+	SSHKeepAliveInterval           *string                               `mapstructure:"ssh_keep_alive_interval" cty:"ssh_keep_alive_interval" hcl:"ssh_keep_alive_interval"`
+	SSHKeepAliveCountMax           *int                                  `mapstructure:"ssh_keep_alive_count_max" cty:"ssh_keep_alive_count_max" hcl:"ssh_keep_alive_count_max"`
+	SSHReadWriteTimeout            *string                               `mapstructure:"ssh_read_write_timeout" cty:"ssh_read_write_timeout" hcl:"ssh_read_write_timeout"`
+	SSHRemoteTunnels               []string                              `mapstructure:"ssh_remote_tunnels" cty:"ssh_remote_tunnels" hcl:"ssh_remote_tunnels"`
+	SSHLocalTunnels                []string                              `mapstructure:"ssh_local_tunnels" cty:"ssh_local_tunnels" hcl:"ssh_local_tunnels"`
+	SSHPublicKey                   []byte                                `mapstructure:"ssh_public_key" undocumented:"true" cty:"ssh_public_key" hcl:"ssh_public_key"`
+	SSHPrivateKey                  []byte                                `mapstructure:"ssh_private_key" undocumented:"true" cty:"ssh_private_key" hcl:"ssh_private_key"`
+	WinRMUser                      *string                               `mapstructure:"winrm_username" cty:"winrm_username" hcl:"winrm_username"`
+	WinRMPassword                  *string                               `mapstructure:"winrm_password" cty:"winrm_password" hcl:"winrm_password"`
+	WinRMHost                      *string                               `mapstructure:"winrm_host" cty:"winrm_host" hcl:"winrm_host"`
+	WinRMNoProxy                   *bool                                 `mapstructure:"winrm_no_proxy" cty:"winrm_no_proxy" hcl:"winrm_no_proxy"`
+	WinRMPort                      *int                                  `mapstructure:"winrm_port" cty:"winrm_port" hcl:"winrm_port"`
+	WinRMTimeout                   *string                               `mapstructure:"winrm_timeout" cty:"winrm_timeout" hcl:"winrm_timeout"`
+	WinRMUseSSL                    *bool                                 `mapstructure:"winrm_use_ssl" cty:"winrm_use_ssl" hcl:"winrm_use_ssl"`
+	WinRMInsecure                  *bool                                 `mapstructure:"winrm_insecure" cty:"winrm_insecure" hcl:"winrm_insecure"`
+	WinRMUseNTLM                   *bool                                 `mapstructure:"winrm_use_ntlm" cty:"winrm_use_ntlm" hcl:"winrm_use_ntlm"`
+	WinRMClientCertFile            *string                               `mapstructure:"winrm_client_cert_file" cty:"winrm_client_cert_file" hcl:"winrm_client_cert_file"`
+	WinRMClientKeyFile             *string                               `mapstructure:"winrm_client_key_file" cty:"winrm_client_key_file" hcl:"winrm_client_key_file"`
+	FloppyFiles                    []string                              `mapstructure:"floppy_files" cty:"floppy_files" hcl:"floppy_files"`
+	FloppyDirectories              []string                              `mapstructure:"floppy_dirs" cty:"floppy_dirs" hcl:"floppy_dirs"`
+	FloppyLabel                    *string                               `mapstructure:"floppy_label" cty:"floppy_label" hcl:"floppy_label"`
+	CDFiles                        []string                              `mapstructure:"cd_files" cty:"cd_files" hcl:"cd_files"`
+	CDLabel                        *string                               `mapstructure:"cd_label" cty:"cd_label" hcl:"cd_label"`
+	DiskBlockSize                  *uint                                 `mapstructure:"disk_block_size" required:"false" cty:"disk_block_size" hcl:"disk_block_size"`
+	RamSize                        *uint                                 `mapstructure:"memory" required:"false" cty:"memory" hcl:"memory"`
+	SecondaryDvdImages             []string                              `mapstructure:"secondary_iso_images" required:"false" cty:"secondary_iso_images" hcl:"secondary_iso_images"`
+	AdditionalDiskSize             []uint                                `mapstructure:"disk_additional_size" required:"false" cty:"disk_additional_size" hcl:"disk_additional_size"`
+	AdditionalDisks                []common.FlatAdditionalDisk           `mapstructure:"additional_disks" required:"false" cty:"additional_disks" hcl:"additional_disks"`
+	GuestAdditionsMode             *string                               `mapstructure:"guest_additions_mode" required:"false" cty:"guest_additions_mode" hcl:"guest_additions_mode"`
+	GuestAdditionsPath             *string                               `mapstructure:"guest_additions_path" required:"false" cty:"guest_additions_path" hcl:"guest_additions_path"`
+	VMName                         *string                               `mapstructure:"vm_name" required:"false" cty:"vm_name" hcl:"vm_name"`
+	SwitchName                     *string                               `mapstructure:"switch_name" required:"false" cty:"switch_name" hcl:"switch_name"`
+	SwitchVlanId                   *string                               `mapstructure:"switch_vlan_id" required:"false" cty:"switch_vlan_id" hcl:"switch_vlan_id"`
+	CreateSwitch                   *bool                                 `mapstructure:"create_switch" required:"false" cty:"create_switch" hcl:"create_switch"`
+	SwitchType                     *string                               `mapstructure:"switch_type" required:"false" cty:"switch_type" hcl:"switch_type"`
+	SwitchNetAdapterName           *string                               `mapstructure:"switch_net_adapter_name" required:"false" cty:"switch_net_adapter_name" hcl:"switch_net_adapter_name"`
+	SwitchNat                      *bool                                 `mapstructure:"switch_nat" required:"false" cty:"switch_nat" hcl:"switch_nat"`
+	SwitchNatPrefix                *string                               `mapstructure:"switch_nat_prefix" required:"false" cty:"switch_nat_prefix" hcl:"switch_nat_prefix"`
+	MacAddress                     *string                               `mapstructure:"mac_address" required:"false" cty:"mac_address" hcl:"mac_address"`
+	VlanId                         *string                               `mapstructure:"vlan_id" required:"false" cty:"vlan_id" hcl:"vlan_id"`
+	AdditionalNetworkAdapters      []common.FlatAdditionalNetworkAdapter `mapstructure:"additional_network_adapters" required:"false" cty:"additional_network_adapters" hcl:"additional_network_adapters"`
+	Cpu                            *uint                                 `mapstructure:"cpus" required:"false" cty:"cpus" hcl:"cpus"`
+	CpusPerSocket                  *uint                                 `mapstructure:"cpus_per_socket" required:"false" cty:"cpus_per_socket" hcl:"cpus_per_socket"`
+	NumaNodes                      *uint                                 `mapstructure:"numa_nodes" required:"false" cty:"numa_nodes" hcl:"numa_nodes"`
+	Generation                     *uint                                 `mapstructure:"generation" required:"false" cty:"generation" hcl:"generation"`
+	EnableMacSpoofing              *bool                                 `mapstructure:"enable_mac_spoofing" required:"false" cty:"enable_mac_spoofing" hcl:"enable_mac_spoofing"`
+	EnableDynamicMemory            *bool                                 `mapstructure:"enable_dynamic_memory" required:"false" cty:"enable_dynamic_memory" hcl:"enable_dynamic_memory"`
+	DynamicMemoryMinimum           *uint                                 `mapstructure:"dynamic_memory_minimum" required:"false" cty:"dynamic_memory_minimum" hcl:"dynamic_memory_minimum"`
+	DynamicMemoryMaximum           *uint                                 `mapstructure:"dynamic_memory_maximum" required:"false" cty:"dynamic_memory_maximum" hcl:"dynamic_memory_maximum"`
+	MemoryBufferPercent            *uint                                 `mapstructure:"memory_buffer_percent" required:"false" cty:"memory_buffer_percent" hcl:"memory_buffer_percent"`
+	EnableSecureBoot               *bool                                 `mapstructure:"enable_secure_boot" required:"false" cty:"enable_secure_boot" hcl:"enable_secure_boot"`
+	SecureBootTemplate             *string                               `mapstructure:"secure_boot_template" required:"false" cty:"secure_boot_template" hcl:"secure_boot_template"`
+	EnableVirtualTPM               *bool                                 `mapstructure:"enable_virtual_tpm" required:"false" cty:"enable_virtual_tpm" hcl:"enable_virtual_tpm"`
+	EnableVirtualizationExtensions *bool                                 `mapstructure:"enable_virtualization_extensions" required:"false" cty:"enable_virtualization_extensions" hcl:"enable_virtualization_extensions"`
+	EnableNestedVirtualization     *bool                                 `mapstructure:"enable_nested_virtualization" required:"false" cty:"enable_nested_virtualization" hcl:"enable_nested_virtualization"`
+	ExposeProcessorFeatures        *bool                                 `mapstructure:"expose_processor_features" required:"false" cty:"expose_processor_features" hcl:"expose_processor_features"`
+	SerialLogFile                  *string                               `mapstructure:"serial_log_file" required:"false" cty:"serial_log_file" hcl:"serial_log_file"`
+	SerialLogOnError               *bool                                 `mapstructure:"serial_log_on_error" required:"false" cty:"serial_log_on_error" hcl:"serial_log_on_error"`
+	TempPath                       *string                               `mapstructure:"temp_path" required:"false" cty:"temp_path" hcl:"temp_path"`
+	Version                        *string                               `mapstructure:"configuration_version" required:"false" cty:"configuration_version" hcl:"configuration_version"`
+	KeepRegistered                 *bool                                 `mapstructure:"keep_registered" required:"false" cty:"keep_registered" hcl:"keep_registered"`
+	SkipCompaction                 *bool                                 `mapstructure:"skip_compaction" required:"false" cty:"skip_compaction" hcl:"skip_compaction"`
+	SkipExport                     *bool                                 `mapstructure:"skip_export" required:"false" cty:"skip_export" hcl:"skip_export"`
+	ExportMode                     *string                               `mapstructure:"export_mode" required:"false" cty:"export_mode" hcl:"export_mode"`
+	OutputDiskFormat               *string                               `mapstructure:"output_disk_format" required:"false" cty:"output_disk_format" hcl:"output_disk_format"`
+	OutputDiskType                 *string                               `mapstructure:"output_disk_type" required:"false" cty:"output_disk_type" hcl:"output_disk_type"`
+	Headless                       *bool                                 `mapstructure:"headless" required:"false" cty:"headless" hcl:"headless"`
+	BootTypeText                   *bool                                 `mapstructure:"boot_type_text" required:"false" cty:"boot_type_text" hcl:"boot_type_text"`
+	FirstBootDevice                *string                               `mapstructure:"first_boot_device" required:"false" cty:"first_boot_device" hcl:"first_boot_device"`
+	BootOrder                      []string                              `mapstructure:"boot_order" required:"false" cty:"boot_order" hcl:"boot_order"`
+	IpAddressSource                *string                               `mapstructure:"ip_address_source" required:"false" cty:"ip_address_source" hcl:"ip_address_source"`
+	IpAddressFamily                *string                               `mapstructure:"ip_address_family" required:"false" cty:"ip_address_family" hcl:"ip_address_family"`
+	IpAddressTimeout               *string                               `mapstructure:"ip_address_timeout" required:"false" cty:"ip_address_timeout" hcl:"ip_address_timeout"`
+	DebugCheckpoints               *bool                                 `mapstructure:"debug_checkpoints" required:"false" cty:"debug_checkpoints" hcl:"debug_checkpoints"`
+	DriverMode                     *string                               `mapstructure:"driver_mode" required:"false" cty:"driver_mode" hcl:"driver_mode"`
+	RemoteHost                     *string                               `mapstructure:"remote_host" required:"false" cty:"remote_host" hcl:"remote_host"`
+	RemoteUsername                 *string                               `mapstructure:"remote_username" required:"false" cty:"remote_username" hcl:"remote_username"`
+	RemotePassword                 *string                               `mapstructure:"remote_password" required:"false" cty:"remote_password" hcl:"remote_password"`
+	ShutdownCommand                *string                               `mapstructure:"shutdown_command" required:"false" cty:"shutdown_command" hcl:"shutdown_command"`
+	ShutdownTimeout                *string                               `mapstructure:"shutdown_timeout" required:"false" cty:"shutdown_timeout" hcl:"shutdown_timeout"`
+	PauseBeforeShutdown            *string                               `mapstructure:"pause_before_shutdown" required:"false" cty:"pause_before_shutdown" hcl:"pause_before_shutdown"`
+	PauseBeforeShutdownInteractive *bool                                 `mapstructure:"pause_before_shutdown_interactive" required:"false" cty:"pause_before_shutdown_interactive" hcl:"pause_before_shutdown_interactive"`
+	ShutdownRetries                *int                                  `mapstructure:"shutdown_retries" required:"false" cty:"shutdown_retries" hcl:"shutdown_retries"`
+	ShutdownRetryInterval          *string                               `mapstructure:"shutdown_retry_interval" required:"false" cty:"shutdown_retry_interval" hcl:"shutdown_retry_interval"`
+	ShutdownForceStop              *bool                                 `mapstructure:"shutdown_force_stop" required:"false" cty:"shutdown_force_stop" hcl:"shutdown_force_stop"`
+	ShutdownWaitForHeartbeat       *bool                                 `mapstructure:"shutdown_wait_for_heartbeat" required:"false" cty:"shutdown_wait_for_heartbeat" hcl:"shutdown_wait_for_heartbeat"`
+	PostShutdownDelay              *string                               `mapstructure:"post_shutdown_delay" required:"false" cty:"post_shutdown_delay" hcl:"post_shutdown_delay"`
+	CloneFromVMCXPath              *string                               `mapstructure:"clone_from_vmcx_path" cty:"clone_from_vmcx_path" hcl:"clone_from_vmcx_path"`
+	CloneFromVMName                *string                               `mapstructure:"clone_from_vm_name" cty:"clone_from_vm_name" hcl:"clone_from_vm_name"`
+	SourceURL                      *string                               `mapstructure:"source_url" required:"false" cty:"source_url" hcl:"source_url"`
+	SourceChecksum                 *string                               `mapstructure:"source_checksum" required:"false" cty:"source_checksum" hcl:"source_checksum"`
+	CloneFromSnapshotName          *string                               `mapstructure:"clone_from_snapshot_name" required:"false" cty:"clone_from_snapshot_name" hcl:"clone_from_snapshot_name"`
+	CloneAllSnapshots              *bool                                 `mapstructure:"clone_all_snapshots" required:"false" cty:"clone_all_snapshots" hcl:"clone_all_snapshots"`
+	DifferencingDisk               *bool                                 `mapstructure:"differencing_disk" required:"false" cty:"differencing_disk" hcl:"differencing_disk"`
+	CompareCopy                    *bool                                 `mapstructure:"copy_in_compare" required:"false" cty:"copy_in_compare" hcl:"copy_in_compare"`
 }
 
 // FlatMapstructure returns a new FlatConfig.
@@ -133,116 +195,177 @@ func (*Config) FlatMapstructure() interface{ HCL2Spec() map[string]hcldec.Spec }
 // The decoded values from this spec will then be applied to a FlatConfig.
 func (*FlatConfig) HCL2Spec() map[string]hcldec.Spec {
 	s := map[string]hcldec.Spec{
-		"packer_build_name":                &hcldec.AttrSpec{Name: "packer_build_name", Type: cty.String, Required: false},
-		"packer_builder_type":              &hcldec.AttrSpec{Name: "packer_builder_type", Type: cty.String, Required: false},
-		"packer_core_version":              &hcldec.AttrSpec{Name: "packer_core_version", Type: cty.String, Required: false},
-		"packer_debug":                     &hcldec.AttrSpec{Name: "packer_debug", Type: cty.Bool, Required: false},
-		"packer_force":                     &hcldec.AttrSpec{Name: "packer_force", Type: cty.Bool, Required: false},
-		"packer_on_error":                  &hcldec.AttrSpec{Name: "packer_on_error", Type: cty.String, Required: false},
-		"packer_user_variables":            &hcldec.AttrSpec{Name: "packer_user_variables", Type: cty.Map(cty.String), Required: false},
-		"packer_sensitive_variables":       &hcldec.AttrSpec{Name: "packer_sensitive_variables", Type: cty.List(cty.String), Required: false},
-		"http_directory":                   &hcldec.AttrSpec{Name: "http_directory", Type: cty.String, Required: false},
-		"http_port_min":                    &hcldec.AttrSpec{Name: "http_port_min", Type: cty.Number, Required: false},
-		"http_port_max":                    &hcldec.AttrSpec{Name: "http_port_max", Type: cty.Number, Required: false},
-		"http_bind_address":                &hcldec.AttrSpec{Name: "http_bind_address", Type: cty.String, Required: false},
-		"http_interface":                   &hcldec.AttrSpec{Name: "http_interface", Type: cty.String, Required: false},
-		"iso_checksum":                     &hcldec.AttrSpec{Name: "iso_checksum", Type: cty.String, Required: false},
-		"iso_url":                          &hcldec.AttrSpec{Name: "iso_url", Type: cty.String, Required: false},
-		"iso_urls":                         &hcldec.AttrSpec{Name: "iso_urls", Type: cty.List(cty.String), Required: false},
-		"iso_target_path":                  &hcldec.AttrSpec{Name: "iso_target_path", Type: cty.String, Required: false},
-		"iso_target_extension":             &hcldec.AttrSpec{Name: "iso_target_extension", Type: cty.String, Required: false},
-		"boot_keygroup_interval":           &hcldec.AttrSpec{Name: "boot_keygroup_interval", Type: cty.String, Required: false},
-		"boot_wait":                        &hcldec.AttrSpec{Name: "boot_wait", Type: cty.String, Required: false},
-		"boot_command":                     &hcldec.AttrSpec{Name: "boot_command", Type: cty.List(cty.String), Required: false},
-		"output_directory":                 &hcldec.AttrSpec{Name: "output_directory", Type: cty.String, Required: false},
-		"communicator":                     &hcldec.AttrSpec{Name: "communicator", Type: cty.String, Required: false},
-		"pause_before_connecting":          &hcldec.AttrSpec{Name: "pause_before_connecting", Type: cty.String, Required: false},
-		"ssh_host":                         &hcldec.AttrSpec{Name: "ssh_host", Type: cty.String, Required: false},
-		"ssh_port":                         &hcldec.AttrSpec{Name: "ssh_port", Type: cty.Number, Required: false},
-		"ssh_username":                     &hcldec.AttrSpec{Name: "ssh_username", Type: cty.String, Required: false},
-		"ssh_password":                     &hcldec.AttrSpec{Name: "ssh_password", Type: cty.String, Required: false},
-		"ssh_keypair_name":                 &hcldec.AttrSpec{Name: "ssh_keypair_name", Type: cty.String, Required: false},
-		"temporary_key_pair_name":          &hcldec.AttrSpec{Name: "temporary_key_pair_name", Type: cty.String, Required: false},
-		"temporary_key_pair_type":          &hcldec.AttrSpec{Name: "temporary_key_pair_type", Type: cty.String, Required: false},
-		"temporary_key_pair_bits":          &hcldec.AttrSpec{Name: "temporary_key_pair_bits", Type: cty.Number, Required: false},
-		"ssh_ciphers":                      &hcldec.AttrSpec{Name: "ssh_ciphers", Type: cty.List(cty.String), Required: false},
-		"ssh_clear_authorized_keys":        &hcldec.AttrSpec{Name: "ssh_clear_authorized_keys", Type: cty.Bool, Required: false},
-		"ssh_key_exchange_algorithms":      &hcldec.AttrSpec{Name: "ssh_key_exchange_algorithms", Type: cty.List(cty.String), Required: false},
-		"ssh_private_key_file":             &hcldec.AttrSpec{Name: "ssh_private_key_file", Type: cty.String, Required: false},
-		"ssh_certificate_file":             &hcldec.AttrSpec{Name: "ssh_certificate_file", Type: cty.String, Required: false},
-		"ssh_pty":                          &hcldec.AttrSpec{Name: "ssh_pty", Type: cty.Bool, Required: false},
-		"ssh_timeout":                      &hcldec.AttrSpec{Name: "ssh_timeout", Type: cty.String, Required: false},
-		"ssh_wait_timeout":                 &hcldec.AttrSpec{Name: "ssh_wait_timeout", Type: cty.String, Required: false},
-		"ssh_agent_auth":                   &hcldec.AttrSpec{Name: "ssh_agent_auth", Type: cty.Bool, Required: false},
-		"ssh_disable_agent_forwarding":     &hcldec.AttrSpec{Name: "ssh_disable_agent_forwarding", Type: cty.Bool, Required: false},
-		"ssh_handshake_attempts":           &hcldec.AttrSpec{Name: "ssh_handshake_attempts", Type: cty.Number, Required: false},
-		"ssh_bastion_host":                 &hcldec.AttrSpec{Name: "ssh_bastion_host", Type: cty.String, Required: false},
-		"ssh_bastion_port":                 &hcldec.AttrSpec{Name: "ssh_bastion_port", Type: cty.Number, Required: false},
-		"ssh_bastion_agent_auth":           &hcldec.AttrSpec{Name: "ssh_bastion_agent_auth", Type: cty.Bool, Required: false},
-		"ssh_bastion_username":             &hcldec.AttrSpec{Name: "ssh_bastion_username", Type: cty.String, Required: false},
-		"ssh_bastion_password":             &hcldec.AttrSpec{Name: "ssh_bastion_password", Type: cty.String, Required: false},
-		"ssh_bastion_interactive":          &hcldec.AttrSpec{Name: "ssh_bastion_interactive", Type: cty.Bool, Required: false},
-		"ssh_bastion_private_key_file":     &hcldec.AttrSpec{Name: "ssh_bastion_private_key_file", Type: cty.String, Required: false},
-		"ssh_bastion_certificate_file":     &hcldec.AttrSpec{Name: "ssh_bastion_certificate_file", Type: cty.String, Required: false},
-		"ssh_file_transfer_method":         &hcldec.AttrSpec{Name: "ssh_file_transfer_method", Type: cty.String, Required: false},
-		"ssh_proxy_host":                   &hcldec.AttrSpec{Name: "ssh_proxy_host", Type: cty.String, Required: false},
-		"ssh_proxy_port":                   &hcldec.AttrSpec{Name: "ssh_proxy_port", Type: cty.Number, Required: false},
-		"ssh_proxy_username":               &hcldec.AttrSpec{Name: "ssh_proxy_username", Type: cty.String, Required: false},
-		"ssh_proxy_password":               &hcldec.AttrSpec{Name: "ssh_proxy_password", Type: cty.String, Required: false},
-		"ssh_keep_alive_interval":          &hcldec.AttrSpec{Name: "ssh_keep_alive_interval", Type: cty.String, Required: false},
-		"ssh_read_write_timeout":           &hcldec.AttrSpec{Name: "ssh_read_write_timeout", Type: cty.String, Required: false},
-		"ssh_remote_tunnels":               &hcldec.AttrSpec{Name: "ssh_remote_tunnels", Type: cty.List(cty.String), Required: false},
-		"ssh_local_tunnels":                &hcldec.AttrSpec{Name: "ssh_local_tunnels", Type: cty.List(cty.String), Required: false},
-		"ssh_public_key":                   &hcldec.AttrSpec{Name: "ssh_public_key", Type: cty.List(cty.Number), Required: false},
-		"ssh_private_key":                  &hcldec.AttrSpec{Name: "ssh_private_key", Type: cty.List(cty.Number), Required: false},
-		"winrm_username":                   &hcldec.AttrSpec{Name: "winrm_username", Type: cty.String, Required: false},
-		"winrm_password":                   &hcldec.AttrSpec{Name: "winrm_password", Type: cty.String, Required: false},
-		"winrm_host":                       &hcldec.AttrSpec{Name: "winrm_host", Type: cty.String, Required: false},
-		"winrm_no_proxy":                   &hcldec.AttrSpec{Name: "winrm_no_proxy", Type: cty.Bool, Required: false},
-		"winrm_port":                       &hcldec.AttrSpec{Name: "winrm_port", Type: cty.Number, Required: false},
-		"winrm_timeout":                    &hcldec.AttrSpec{Name: "winrm_timeout", Type: cty.String, Required: false},
-		"winrm_use_ssl":                    &hcldec.AttrSpec{Name: "winrm_use_ssl", Type: cty.Bool, Required: false},
-		"winrm_insecure":                   &hcldec.AttrSpec{Name: "winrm_insecure", Type: cty.Bool, Required: false},
-		"winrm_use_ntlm":                   &hcldec.AttrSpec{Name: "winrm_use_ntlm", Type: cty.Bool, Required: false},
-		"floppy_files":                     &hcldec.AttrSpec{Name: "floppy_files", Type: cty.List(cty.String), Required: false},
-		"floppy_dirs":                      &hcldec.AttrSpec{Name: "floppy_dirs", Type: cty.List(cty.String), Required: false},
-		"floppy_label":                     &hcldec.AttrSpec{Name: "floppy_label", Type: cty.String, Required: false},
-		"cd_files":                         &hcldec.AttrSpec{Name: "cd_files", Type: cty.List(cty.String), Required: false},
-		"cd_label":                         &hcldec.AttrSpec{Name: "cd_label", Type: cty.String, Required: false},
-		"disk_block_size":                  &hcldec.AttrSpec{Name: "disk_block_size", Type: cty.Number, Required: false},
-		"memory":                           &hcldec.AttrSpec{Name: "memory", Type: cty.Number, Required: false},
-		"secondary_iso_images":             &hcldec.AttrSpec{Name: "secondary_iso_images", Type: cty.List(cty.String), Required: false},
-		"disk_additional_size":             &hcldec.AttrSpec{Name: "disk_additional_size", Type: cty.List(cty.Number), Required: false},
-		"guest_additions_mode":             &hcldec.AttrSpec{Name: "guest_additions_mode", Type: cty.String, Required: false},
-		"guest_additions_path":             &hcldec.AttrSpec{Name: "guest_additions_path", Type: cty.String, Required: false},
-		"vm_name":                          &hcldec.AttrSpec{Name: "vm_name", Type: cty.String, Required: false},
-		"switch_name":                      &hcldec.AttrSpec{Name: "switch_name", Type: cty.String, Required: false},
-		"switch_vlan_id":                   &hcldec.AttrSpec{Name: "switch_vlan_id", Type: cty.String, Required: false},
-		"mac_address":                      &hcldec.AttrSpec{Name: "mac_address", Type: cty.String, Required: false},
-		"vlan_id":                          &hcldec.AttrSpec{Name: "vlan_id", Type: cty.String, Required: false},
-		"cpus":                             &hcldec.AttrSpec{Name: "cpus", Type: cty.Number, Required: false},
-		"generation":                       &hcldec.AttrSpec{Name: "generation", Type: cty.Number, Required: false},
-		"enable_mac_spoofing":              &hcldec.AttrSpec{Name: "enable_mac_spoofing", Type: cty.Bool, Required: false},
-		"enable_dynamic_memory":            &hcldec.AttrSpec{Name: "enable_dynamic_memory", Type: cty.Bool, Required: false},
-		"enable_secure_boot":               &hcldec.AttrSpec{Name: "enable_secure_boot", Type: cty.Bool, Required: false},
-		"secure_boot_template":             &hcldec.AttrSpec{Name: "secure_boot_template", Type: cty.String, Required: false},
-		"enable_virtualization_extensions": &hcldec.AttrSpec{Name: "enable_virtualization_extensions", Type: cty.Bool, Required: false},
-		"temp_path":                        &hcldec.AttrSpec{Name: "temp_path", Type: cty.String, Required: false},
-		"configuration_version":            &hcldec.AttrSpec{Name: "configuration_version", Type: cty.String, Required: false},
-		"keep_registered":                  &hcldec.AttrSpec{Name: "keep_registered", Type: cty.Bool, Required: false},
-		"skip_compaction":                  &hcldec.AttrSpec{Name: "skip_compaction", Type: cty.Bool, Required: false},
-		"skip_export":                      &hcldec.AttrSpec{Name: "skip_export", Type: cty.Bool, Required: false},
-		"headless":                         &hcldec.AttrSpec{Name: "headless", Type: cty.Bool, Required: false},
-		"first_boot_device":                &hcldec.AttrSpec{Name: "first_boot_device", Type: cty.String, Required: false},
-		"boot_order":                       &hcldec.AttrSpec{Name: "boot_order", Type: cty.List(cty.String), Required: false},
-		"shutdown_command":                 &hcldec.AttrSpec{Name: "shutdown_command", Type: cty.String, Required: false},
-		"shutdown_timeout":                 &hcldec.AttrSpec{Name: "shutdown_timeout", Type: cty.String, Required: false},
-		"clone_from_vmcx_path":             &hcldec.AttrSpec{Name: "clone_from_vmcx_path", Type: cty.String, Required: false},
-		"clone_from_vm_name":               &hcldec.AttrSpec{Name: "clone_from_vm_name", Type: cty.String, Required: false},
-		"clone_from_snapshot_name":         &hcldec.AttrSpec{Name: "clone_from_snapshot_name", Type: cty.String, Required: false},
-		"clone_all_snapshots":              &hcldec.AttrSpec{Name: "clone_all_snapshots", Type: cty.Bool, Required: false},
-		"differencing_disk":                &hcldec.AttrSpec{Name: "differencing_disk", Type: cty.Bool, Required: false},
-		"copy_in_compare":                  &hcldec.AttrSpec{Name: "copy_in_compare", Type: cty.Bool, Required: false},
+		"packer_build_name":          &hcldec.AttrSpec{Name: "packer_build_name", Type: cty.String, Required: false},
+		"packer_builder_type":        &hcldec.AttrSpec{Name: "packer_builder_type", Type: cty.String, Required: false},
+		"packer_core_version":        &hcldec.AttrSpec{Name: "packer_core_version", Type: cty.String, Required: false},
+		"packer_debug":               &hcldec.AttrSpec{Name: "packer_debug", Type: cty.Bool, Required: false},
+		"packer_force":               &hcldec.AttrSpec{Name: "packer_force", Type: cty.Bool, Required: false},
+		"packer_on_error":            &hcldec.AttrSpec{Name: "packer_on_error", Type: cty.String, Required: false},
+		"packer_resume":              &hcldec.AttrSpec{Name: "packer_resume", Type: cty.Bool, Required: false},
+		"packer_cleanup_timeout":     &hcldec.AttrSpec{Name: "packer_cleanup_timeout", Type: cty.String, Required: false},
+		"packer_user_variables":      &hcldec.AttrSpec{Name: "packer_user_variables", Type: cty.Map(cty.String), Required: false},
+		"packer_sensitive_variables": &hcldec.AttrSpec{Name: "packer_sensitive_variables", Type: cty.List(cty.String), Required: false},
+		"http_directory":             &hcldec.AttrSpec{Name: "http_directory", Type: cty.String, Required: false},
+		"http_port_min":              &hcldec.AttrSpec{Name: "http_port_min", Type: cty.Number, Required: false},
+		"http_port_max":              &hcldec.AttrSpec{Name: "http_port_max", Type: cty.Number, Required: false},
+		"http_bind_address":          &hcldec.AttrSpec{Name: "http_bind_address", Type: cty.String, Required: false},
+		"http_interface":             &hcldec.AttrSpec{Name: "http_interface", Type: cty.String, Required: false},
+
+		"http_use_tls": &hcldec.AttrSpec{Name: "http_use_tls", Type: cty.Bool, Required: false},
+
+		"http_tls_cert_file": &hcldec.AttrSpec{Name: "http_tls_cert_file", Type: cty.String, Required: false},
+
+		"http_tls_key_file": &hcldec.AttrSpec{Name: "http_tls_key_file", Type: cty.String, Required: false},
+
+		"http_auth_token": &hcldec.AttrSpec{Name: "http_auth_token", Type: cty.Bool, Required: false},
+
+		"http_templates": &hcldec.AttrSpec{Name: "http_templates", Type: cty.List(cty.String), Required: false},
+
+		"http_template_vars":                &hcldec.AttrSpec{Name: "http_template_vars", Type: cty.Map(cty.String), Required: false},
+		"iso_checksum":                      &hcldec.AttrSpec{Name: "iso_checksum", Type: cty.String, Required: false},
+		"iso_url":                           &hcldec.AttrSpec{Name: "iso_url", Type: cty.String, Required: false},
+		"iso_urls":                          &hcldec.AttrSpec{Name: "iso_urls", Type: cty.List(cty.String), Required: false},
+		"iso_target_path":                   &hcldec.AttrSpec{Name: "iso_target_path", Type: cty.String, Required: false},
+		"iso_target_extension":              &hcldec.AttrSpec{Name: "iso_target_extension", Type: cty.String, Required: false},
+		"iso_download_segments":             &hcldec.AttrSpec{Name: "iso_download_segments", Type: cty.Number, Required: false},
+		"boot_keygroup_interval":            &hcldec.AttrSpec{Name: "boot_keygroup_interval", Type: cty.String, Required: false},
+		"boot_wait":                         &hcldec.AttrSpec{Name: "boot_wait", Type: cty.String, Required: false},
+		"boot_command":                      &hcldec.AttrSpec{Name: "boot_command", Type: cty.List(cty.String), Required: false},
+		"output_directory":                  &hcldec.AttrSpec{Name: "output_directory", Type: cty.String, Required: false},
+		"communicator":                      &hcldec.AttrSpec{Name: "communicator", Type: cty.String, Required: false},
+		"pause_before_connecting":           &hcldec.AttrSpec{Name: "pause_before_connecting", Type: cty.String, Required: false},
+		"reconnect_timeout":                 &hcldec.AttrSpec{Name: "reconnect_timeout", Type: cty.String, Required: false},
+		"ssh_host":                          &hcldec.AttrSpec{Name: "ssh_host", Type: cty.String, Required: false},
+		"ssh_port":                          &hcldec.AttrSpec{Name: "ssh_port", Type: cty.Number, Required: false},
+		"ssh_username":                      &hcldec.AttrSpec{Name: "ssh_username", Type: cty.String, Required: false},
+		"ssh_password":                      &hcldec.AttrSpec{Name: "ssh_password", Type: cty.String, Required: false},
+		"ssh_keypair_name":                  &hcldec.AttrSpec{Name: "ssh_keypair_name", Type: cty.String, Required: false},
+		"temporary_key_pair_name":           &hcldec.AttrSpec{Name: "temporary_key_pair_name", Type: cty.String, Required: false},
+		"temporary_key_pair_type":           &hcldec.AttrSpec{Name: "temporary_key_pair_type", Type: cty.String, Required: false},
+		"temporary_key_pair_bits":           &hcldec.AttrSpec{Name: "temporary_key_pair_bits", Type: cty.Number, Required: false},
+		"ssh_ciphers":                       &hcldec.AttrSpec{Name: "ssh_ciphers", Type: cty.List(cty.String), Required: false},
+		"ssh_clear_authorized_keys":         &hcldec.AttrSpec{Name: "ssh_clear_authorized_keys", Type: cty.Bool, Required: false},
+		"ssh_key_exchange_algorithms":       &hcldec.AttrSpec{Name: "ssh_key_exchange_algorithms", Type: cty.List(cty.String), Required: false},
+		"ssh_private_key_file":              &hcldec.AttrSpec{Name: "ssh_private_key_file", Type: cty.String, Required: false},
+		"ssh_certificate_file":              &hcldec.AttrSpec{Name: "ssh_certificate_file", Type: cty.String, Required: false},
+		"ssh_pty":                           &hcldec.AttrSpec{Name: "ssh_pty", Type: cty.Bool, Required: false},
+		"ssh_timeout":                       &hcldec.AttrSpec{Name: "ssh_timeout", Type: cty.String, Required: false},
+		"ssh_wait_timeout":                  &hcldec.AttrSpec{Name: "ssh_wait_timeout", Type: cty.String, Required: false},
+		"ssh_agent_auth":                    &hcldec.AttrSpec{Name: "ssh_agent_auth", Type: cty.Bool, Required: false},
+		"ssh_disable_agent_forwarding":      &hcldec.AttrSpec{Name: "ssh_disable_agent_forwarding", Type: cty.Bool, Required: false},
+		"ssh_pin_host_key":                  &hcldec.AttrSpec{Name: "ssh_pin_host_key", Type: cty.Bool, Required: false},
+		"ssh_handshake_attempts":            &hcldec.AttrSpec{Name: "ssh_handshake_attempts", Type: cty.Number, Required: false},
+		"ssh_bastion_host":                  &hcldec.AttrSpec{Name: "ssh_bastion_host", Type: cty.String, Required: false},
+		"ssh_bastion_port":                  &hcldec.AttrSpec{Name: "ssh_bastion_port", Type: cty.Number, Required: false},
+		"ssh_bastion_agent_auth":            &hcldec.AttrSpec{Name: "ssh_bastion_agent_auth", Type: cty.Bool, Required: false},
+		"ssh_bastion_username":              &hcldec.AttrSpec{Name: "ssh_bastion_username", Type: cty.String, Required: false},
+		"ssh_bastion_password":              &hcldec.AttrSpec{Name: "ssh_bastion_password", Type: cty.String, Required: false},
+		"ssh_bastion_interactive":           &hcldec.AttrSpec{Name: "ssh_bastion_interactive", Type: cty.Bool, Required: false},
+		"ssh_bastion_private_key_file":      &hcldec.AttrSpec{Name: "ssh_bastion_private_key_file", Type: cty.String, Required: false},
+		"ssh_bastion_certificate_file":      &hcldec.AttrSpec{Name: "ssh_bastion_certificate_file", Type: cty.String, Required: false},
+		"ssh_file_transfer_method":          &hcldec.AttrSpec{Name: "ssh_file_transfer_method", Type: cty.String, Required: false},
+		"ssh_upload_concurrency":            &hcldec.AttrSpec{Name: "ssh_upload_concurrency", Type: cty.Number, Required: false},
+		"ssh_upload_buffer_size":            &hcldec.AttrSpec{Name: "ssh_upload_buffer_size", Type: cty.Number, Required: false},
+		"ssh_upload_compression":            &hcldec.AttrSpec{Name: "ssh_upload_compression", Type: cty.Bool, Required: false},
+		"ssh_proxy_host":                    &hcldec.AttrSpec{Name: "ssh_proxy_host", Type: cty.String, Required: false},
+		"ssh_proxy_port":                    &hcldec.AttrSpec{Name: "ssh_proxy_port", Type: cty.Number, Required: false},
+		"ssh_proxy_username":                &hcldec.AttrSpec{Name: "ssh_proxy_username", Type: cty.String, Required: false},
+		"ssh_proxy_password":                &hcldec.AttrSpec{Name: "ssh_proxy_password", Type: cty.String, Required: false},
+		"ssh_keep_alive_interval":           &hcldec.AttrSpec{Name: "ssh_keep_alive_interval", Type: cty.String, Required: false},
+		"ssh_keep_alive_count_max":          &hcldec.AttrSpec{Name: "ssh_keep_alive_count_max", Type: cty.Number, Required: false},
+		"ssh_read_write_timeout":            &hcldec.AttrSpec{Name: "ssh_read_write_timeout", Type: cty.String, Required: false},
+		"ssh_remote_tunnels":                &hcldec.AttrSpec{Name: "ssh_remote_tunnels", Type: cty.List(cty.String), Required: false},
+		"ssh_local_tunnels":                 &hcldec.AttrSpec{Name: "ssh_local_tunnels", Type: cty.List(cty.String), Required: false},
+		"ssh_public_key":                    &hcldec.AttrSpec{Name: "ssh_public_key", Type: cty.List(cty.Number), Required: false},
+		"ssh_private_key":                   &hcldec.AttrSpec{Name: "ssh_private_key", Type: cty.List(cty.Number), Required: false},
+		"winrm_username":                    &hcldec.AttrSpec{Name: "winrm_username", Type: cty.String, Required: false},
+		"winrm_password":                    &hcldec.AttrSpec{Name: "winrm_password", Type: cty.String, Required: false},
+		"winrm_host":                        &hcldec.AttrSpec{Name: "winrm_host", Type: cty.String, Required: false},
+		"winrm_no_proxy":                    &hcldec.AttrSpec{Name: "winrm_no_proxy", Type: cty.Bool, Required: false},
+		"winrm_port":                        &hcldec.AttrSpec{Name: "winrm_port", Type: cty.Number, Required: false},
+		"winrm_timeout":                     &hcldec.AttrSpec{Name: "winrm_timeout", Type: cty.String, Required: false},
+		"winrm_use_ssl":                     &hcldec.AttrSpec{Name: "winrm_use_ssl", Type: cty.Bool, Required: false},
+		"winrm_insecure":                    &hcldec.AttrSpec{Name: "winrm_insecure", Type: cty.Bool, Required: false},
+		"winrm_use_ntlm":                    &hcldec.AttrSpec{Name: "winrm_use_ntlm", Type: cty.Bool, Required: false},
+		"winrm_client_cert_file":            &hcldec.AttrSpec{Name: "winrm_client_cert_file", Type: cty.String, Required: false},
+		"winrm_client_key_file":             &hcldec.AttrSpec{Name: "winrm_client_key_file", Type: cty.String, Required: false},
+		"floppy_files":                      &hcldec.AttrSpec{Name: "floppy_files", Type: cty.List(cty.String), Required: false},
+		"floppy_dirs":                       &hcldec.AttrSpec{Name: "floppy_dirs", Type: cty.List(cty.String), Required: false},
+		"floppy_label":                      &hcldec.AttrSpec{Name: "floppy_label", Type: cty.String, Required: false},
+		"cd_files":                          &hcldec.AttrSpec{Name: "cd_files", Type: cty.List(cty.String), Required: false},
+		"cd_label":                          &hcldec.AttrSpec{Name: "cd_label", Type: cty.String, Required: false},
+		"disk_block_size":                   &hcldec.AttrSpec{Name: "disk_block_size", Type: cty.Number, Required: false},
+		"memory":                            &hcldec.AttrSpec{Name: "memory", Type: cty.Number, Required: false},
+		"secondary_iso_images":              &hcldec.AttrSpec{Name: "secondary_iso_images", Type: cty.List(cty.String), Required: false},
+		"disk_additional_size":              &hcldec.AttrSpec{Name: "disk_additional_size", Type: cty.List(cty.Number), Required: false},
+		"additional_disks":                  &hcldec.BlockListSpec{TypeName: "additional_disks", Nested: hcldec.ObjectSpec((*common.FlatAdditionalDisk)(nil).HCL2Spec())},
+		"guest_additions_mode":              &hcldec.AttrSpec{Name: "guest_additions_mode", Type: cty.String, Required: false},
+		"guest_additions_path":              &hcldec.AttrSpec{Name: "guest_additions_path", Type: cty.String, Required: false},
+		"vm_name":                           &hcldec.AttrSpec{Name: "vm_name", Type: cty.String, Required: false},
+		"switch_name":                       &hcldec.AttrSpec{Name: "switch_name", Type: cty.String, Required: false},
+		"switch_vlan_id":                    &hcldec.AttrSpec{Name: "switch_vlan_id", Type: cty.String, Required: false},
+		"create_switch":                     &hcldec.AttrSpec{Name: "create_switch", Type: cty.Bool, Required: false},
+		"switch_type":                       &hcldec.AttrSpec{Name: "switch_type", Type: cty.String, Required: false},
+		"switch_net_adapter_name":           &hcldec.AttrSpec{Name: "switch_net_adapter_name", Type: cty.String, Required: false},
+		"switch_nat":                        &hcldec.AttrSpec{Name: "switch_nat", Type: cty.Bool, Required: false},
+		"switch_nat_prefix":                 &hcldec.AttrSpec{Name: "switch_nat_prefix", Type: cty.String, Required: false},
+		"mac_address":                       &hcldec.AttrSpec{Name: "mac_address", Type: cty.String, Required: false},
+		"vlan_id":                           &hcldec.AttrSpec{Name: "vlan_id", Type: cty.String, Required: false},
+		"additional_network_adapters":       &hcldec.BlockListSpec{TypeName: "additional_network_adapters", Nested: hcldec.ObjectSpec((*common.FlatAdditionalNetworkAdapter)(nil).HCL2Spec())},
+		"cpus":                              &hcldec.AttrSpec{Name: "cpus", Type: cty.Number, Required: false},
+		"cpus_per_socket":                   &hcldec.AttrSpec{Name: "cpus_per_socket", Type: cty.Number, Required: false},
+		"numa_nodes":                        &hcldec.AttrSpec{Name: "numa_nodes", Type: cty.Number, Required: false},
+		"generation":                        &hcldec.AttrSpec{Name: "generation", Type: cty.Number, Required: false},
+		"enable_mac_spoofing":               &hcldec.AttrSpec{Name: "enable_mac_spoofing", Type: cty.Bool, Required: false},
+		"enable_dynamic_memory":             &hcldec.AttrSpec{Name: "enable_dynamic_memory", Type: cty.Bool, Required: false},
+		"dynamic_memory_minimum":            &hcldec.AttrSpec{Name: "dynamic_memory_minimum", Type: cty.Number, Required: false},
+		"dynamic_memory_maximum":            &hcldec.AttrSpec{Name: "dynamic_memory_maximum", Type: cty.Number, Required: false},
+		"memory_buffer_percent":             &hcldec.AttrSpec{Name: "memory_buffer_percent", Type: cty.Number, Required: false},
+		"enable_secure_boot":                &hcldec.AttrSpec{Name: "enable_secure_boot", Type: cty.Bool, Required: false},
+		"secure_boot_template":              &hcldec.AttrSpec{Name: "secure_boot_template", Type: cty.String, Required: false},
+		"enable_virtual_tpm":                &hcldec.AttrSpec{Name: "enable_virtual_tpm", Type: cty.Bool, Required: false},
+		"enable_virtualization_extensions":  &hcldec.AttrSpec{Name: "enable_virtualization_extensions", Type: cty.Bool, Required: false},
+		"enable_nested_virtualization":      &hcldec.AttrSpec{Name: "enable_nested_virtualization", Type: cty.Bool, Required: false},
+		"expose_processor_features":         &hcldec.AttrSpec{Name: "expose_processor_features", Type: cty.Bool, Required: false},
+		"serial_log_file":                   &hcldec.AttrSpec{Name: "serial_log_file", Type: cty.String, Required: false},
+		"serial_log_on_error":               &hcldec.AttrSpec{Name: "serial_log_on_error", Type: cty.Bool, Required: false},
+		"temp_path":                         &hcldec.AttrSpec{Name: "temp_path", Type: cty.String, Required: false},
+		"configuration_version":             &hcldec.AttrSpec{Name: "configuration_version", Type: cty.String, Required: false},
+		"keep_registered":                   &hcldec.AttrSpec{Name: "keep_registered", Type: cty.Bool, Required: false},
+		"skip_compaction":                   &hcldec.AttrSpec{Name: "skip_compaction", Type: cty.Bool, Required: false},
+		"skip_export":                       &hcldec.AttrSpec{Name: "skip_export", Type: cty.Bool, Required: false},
+		"export_mode":                       &hcldec.AttrSpec{Name: "export_mode", Type: cty.String, Required: false},
+		"output_disk_format":                &hcldec.AttrSpec{Name: "output_disk_format", Type: cty.String, Required: false},
+		"output_disk_type":                  &hcldec.AttrSpec{Name: "output_disk_type", Type: cty.String, Required: false},
+		"headless":                          &hcldec.AttrSpec{Name: "headless", Type: cty.Bool, Required: false},
+		"boot_type_text":                    &hcldec.AttrSpec{Name: "boot_type_text", Type: cty.Bool, Required: false},
+		"first_boot_device":                 &hcldec.AttrSpec{Name: "first_boot_device", Type: cty.String, Required: false},
+		"boot_order":                        &hcldec.AttrSpec{Name: "boot_order", Type: cty.List(cty.String), Required: false},
+		"ip_address_source":                 &hcldec.AttrSpec{Name: "ip_address_source", Type: cty.String, Required: false},
+		"ip_address_family":                 &hcldec.AttrSpec{Name: "ip_address_family", Type: cty.String, Required: false},
+		"ip_address_timeout":                &hcldec.AttrSpec{Name: "ip_address_timeout", Type: cty.String, Required: false},
+		"debug_checkpoints":                 &hcldec.AttrSpec{Name: "debug_checkpoints", Type: cty.Bool, Required: false},
+		"driver_mode":                       &hcldec.AttrSpec{Name: "driver_mode", Type: cty.String, Required: false},
+		"remote_host":                       &hcldec.AttrSpec{Name: "remote_host", Type: cty.String, Required: false},
+		"remote_username":                   &hcldec.AttrSpec{Name: "remote_username", Type: cty.String, Required: false},
+		"remote_password":                   &hcldec.AttrSpec{Name: "remote_password", Type: cty.String, Required: false},
+		"shutdown_command":                  &hcldec.AttrSpec{Name: "shutdown_command", Type: cty.String, Required: false},
+		"shutdown_timeout":                  &hcldec.AttrSpec{Name: "shutdown_timeout", Type: cty.String, Required: false},
+		"pause_before_shutdown":             &hcldec.AttrSpec{Name: "pause_before_shutdown", Type: cty.String, Required: false},
+		"pause_before_shutdown_interactive": &hcldec.AttrSpec{Name: "pause_before_shutdown_interactive", Type: cty.Bool, Required: false},
+		"shutdown_retries":                  &hcldec.AttrSpec{Name: "shutdown_retries", Type: cty.Number, Required: false},
+		"shutdown_retry_interval":           &hcldec.AttrSpec{Name: "shutdown_retry_interval", Type: cty.String, Required: false},
+		"shutdown_force_stop":               &hcldec.AttrSpec{Name: "shutdown_force_stop", Type: cty.Bool, Required: false},
+		"shutdown_wait_for_heartbeat":       &hcldec.AttrSpec{Name: "shutdown_wait_for_heartbeat", Type: cty.Bool, Required: false},
+		"post_shutdown_delay":               &hcldec.AttrSpec{Name: "post_shutdown_delay", Type: cty.String, Required: false},
+		"clone_from_vmcx_path":              &hcldec.AttrSpec{Name: "clone_from_vmcx_path", Type: cty.String, Required: false},
+		"clone_from_vm_name":                &hcldec.AttrSpec{Name: "clone_from_vm_name", Type: cty.String, Required: false},
+		"source_url":                        &hcldec.AttrSpec{Name: "source_url", Type: cty.String, Required: false},
+		"source_checksum":                   &hcldec.AttrSpec{Name: "source_checksum", Type: cty.String, Required: false},
+		"clone_from_snapshot_name":          &hcldec.AttrSpec{Name: "clone_from_snapshot_name", Type: cty.String, Required: false},
+		"clone_all_snapshots":               &hcldec.AttrSpec{Name: "clone_all_snapshots", Type: cty.Bool, Required: false},
+		"differencing_disk":                 &hcldec.AttrSpec{Name: "differencing_disk", Type: cty.Bool, Required: false},
+		"copy_in_compare":                   &hcldec.AttrSpec{Name: "copy_in_compare", Type: cty.Bool, Required: false},
 	}
 	return s
 }
@@ -84,6 +84,28 @@ func TestBuilderPrepare_InvalidKey(t *testing.T) {
 	}
 }
 
+func TestBuilderPrepare_CommonConfigErrors(t *testing.T) {
+	var b Builder
+	config := testConfig()
+
+	//Create vmcx folder
+	td, err := ioutil.TempDir("", "packer")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer os.RemoveAll(td)
+	config["clone_from_vmcx_path"] = td
+
+	config["first_boot_device"] = "bad"
+	_, warns, err := b.Prepare(config)
+	if len(warns) > 0 {
+		t.Fatalf("bad: %#v", warns)
+	}
+	if err == nil {
+		t.Fatal("should have error")
+	}
+}
+
 func TestBuilderPrepare_CloneFromExistingMachineOrImportFromExportedMachineSettingsRequired(t *testing.T) {
 	var b Builder
 	config := testConfig()
@@ -98,6 +120,30 @@ func TestBuilderPrepare_CloneFromExistingMachineOrImportFromExportedMachineSetti
 	}
 }
 
+func TestBuilder_checkGeneration(t *testing.T) {
+	var b Builder
+
+	// Test a Generation 1 virtual machine to clone from
+	b.config.Generation = 1
+	if errs := b.checkGeneration(); len(errs) > 0 {
+		t.Fatalf("err: %#v", errs)
+	}
+	b.config.EnableSecureBoot = true
+	if errs := b.checkGeneration(); len(errs) != 1 {
+		t.Fatalf("bad: %#v", errs)
+	}
+	b.config.EnableVirtualTPM = true
+	if errs := b.checkGeneration(); len(errs) != 2 {
+		t.Fatalf("bad: %#v", errs)
+	}
+
+	// Test a Generation 2 virtual machine to clone from
+	b.config.Generation = 2
+	if errs := b.checkGeneration(); len(errs) > 0 {
+		t.Fatalf("err: %#v", errs)
+	}
+}
+
 func TestBuilderPrepare_ExportedMachinePathDoesNotExist(t *testing.T) {
 	var b Builder
 	config := testConfig()
@@ -169,6 +215,54 @@ func disabled_TestBuilderPrepare_CloneFromVmSettingUsedSoNoCloneFromVmcxPathRequ
 	}
 }
 
+func TestBuilderPrepare_SourceURL(t *testing.T) {
+	var b Builder
+	config := testConfig()
+	delete(config, "clone_from_vmcx_path")
+
+	// Test with a good value
+	config["source_url"] = "https://example.com/exports/base.tar.gz"
+	config["source_checksum"] = "md5:0B0F137F17AC10944716020B018F8126"
+	_, warns, err := b.Prepare(config)
+	if len(warns) > 0 {
+		t.Fatalf("bad: %#v", warns)
+	}
+	if err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+
+	// Test without a checksum
+	delete(config, "source_checksum")
+	b = Builder{}
+	_, _, err = b.Prepare(config)
+	if err == nil {
+		t.Fatal("should have error")
+	}
+
+	// Test with an unsupported archive
+	config["source_url"] = "https://example.com/exports/base.iso"
+	config["source_checksum"] = "none"
+	b = Builder{}
+	_, _, err = b.Prepare(config)
+	if err == nil {
+		t.Fatal("should have error")
+	}
+
+	// Test together with clone_from_vmcx_path
+	td, err := ioutil.TempDir("", "packer")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer os.RemoveAll(td)
+	config["source_url"] = "https://example.com/exports/base.zip"
+	config["clone_from_vmcx_path"] = td
+	b = Builder{}
+	_, _, err = b.Prepare(config)
+	if err == nil {
+		t.Fatal("should have error")
+	}
+}
+
 func TestBuilderPrepare_ISOChecksum(t *testing.T) {
 	var b Builder
 	config := testConfig()
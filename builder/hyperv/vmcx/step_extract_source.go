@@ -0,0 +1,169 @@
+package vmcx
+
+import (
+	"archive/tar"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	getter "github.com/hashicorp/go-getter/v2"
+	"github.com/hashicorp/packer/packer"
+	"github.com/hashicorp/packer/packer-plugin-sdk/multistep"
+)
+
+// sourceDecompressors are the archive formats accepted for source_url. Only
+// formats that can hold a whole directory tree are listed.
+var sourceDecompressors = map[string]getter.Decompressor{
+	"zip":     getter.Decompressors["zip"],
+	"tar":     new(tarDecompressor),
+	"tar.gz":  getter.Decompressors["tar.gz"],
+	"tgz":     getter.Decompressors["tgz"],
+	"tar.bz2": getter.Decompressors["tar.bz2"],
+	"tbz2":    getter.Decompressors["tbz2"],
+	"tar.xz":  getter.Decompressors["tar.xz"],
+	"txz":     getter.Decompressors["txz"],
+}
+
+// sourceArchiveType returns the archive format of path based on its
+// extension, or an empty string if the format isn't supported.
+func sourceArchiveType(path string) string {
+	path = strings.ToLower(path)
+	archiveType := ""
+	for k := range sourceDecompressors {
+		if strings.HasSuffix(path, "."+k) && len(k) > len(archiveType) {
+			archiveType = k
+		}
+	}
+	return archiveType
+}
+
+// This step extracts the downloaded exported virtual machine into the build
+// directory and looks up the directory to clone it from.
+//
+// Uses:
+//   build_dir           string
+//   source_archive_path string
+//   ui                  packer.Ui
+//
+// Produces:
+//   source_vmcx_path string - The directory containing the "Virtual Machines"
+//                             directory of the exported virtual machine.
+type StepExtractSource struct {
+	ArchiveType string
+}
+
+func (s *StepExtractSource) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	archivePath, ok := state.GetOk("source_archive_path")
+	if !ok {
+		return multistep.ActionContinue
+	}
+
+	ui := state.Get("ui").(packer.Ui)
+	buildDir := state.Get("build_dir").(string)
+
+	ui.Say("Extracting exported virtual machine...")
+
+	dst := filepath.Join(buildDir, "source")
+	decompressor := sourceDecompressors[s.ArchiveType]
+	if decompressor == nil {
+		err := fmt.Errorf("Unsupported archive type for source_url: %q", s.ArchiveType)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+	if err := decompressor.Decompress(dst, archivePath.(string), true); err != nil {
+		err := fmt.Errorf("Error extracting exported virtual machine: %s", err)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+
+	vmcxPath, err := findExportedVM(dst)
+	if err != nil {
+		err := fmt.Errorf("Error extracting exported virtual machine: %s", err)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+
+	state.Put("source_vmcx_path", vmcxPath)
+	return multistep.ActionContinue
+}
+
+func (s *StepExtractSource) Cleanup(state multistep.StateBag) {
+	// do nothing, the build directory is removed by StepCreateBuildDir
+}
+
+// findExportedVM returns the first directory under root that contains a
+// "Virtual Machines" directory.
+func findExportedVM(root string) (string, error) {
+	var found string
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() && strings.EqualFold(info.Name(), "Virtual Machines") {
+			found = filepath.Dir(path)
+			return io.EOF
+		}
+		return nil
+	})
+	if err != nil && err != io.EOF {
+		return "", err
+	}
+	if found == "" {
+		return "", fmt.Errorf("no \"Virtual Machines\" directory found in the archive")
+	}
+	return found, nil
+}
+
+// tarDecompressor extracts uncompressed tar archives, which go-getter only
+// supports when they are compressed.
+type tarDecompressor struct{}
+
+func (d *tarDecompressor) Decompress(dst, src string, dir bool) error {
+	f, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	tarR := tar.NewReader(f)
+	for {
+		hdr, err := tarR.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		path := filepath.Join(dst, hdr.Name)
+		if !strings.HasPrefix(path, filepath.Clean(dst)+string(os.PathSeparator)) {
+			return fmt.Errorf("entry is outside of the destination: %s", hdr.Name)
+		}
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(path, 0755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				return err
+			}
+			out, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+			if err != nil {
+				return err
+			}
+			_, err = io.Copy(out, tarR)
+			out.Close()
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
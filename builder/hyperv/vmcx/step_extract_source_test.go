@@ -0,0 +1,132 @@
+package vmcx
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/packer/packer"
+	"github.com/hashicorp/packer/packer-plugin-sdk/multistep"
+)
+
+func testExtractSourceState(t *testing.T) (multistep.StateBag, string) {
+	td, err := ioutil.TempDir("", "packer")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	state := new(multistep.BasicStateBag)
+	state.Put("build_dir", td)
+	state.Put("ui", packer.TestUi(t))
+	return state, td
+}
+
+func TestStepExtractSource_impl(t *testing.T) {
+	var _ multistep.Step = new(StepExtractSource)
+}
+
+func TestStepExtractSource_zip(t *testing.T) {
+	state, td := testExtractSourceState(t)
+	defer os.RemoveAll(td)
+
+	archivePath := filepath.Join(td, "source.zip")
+	f, err := os.Create(archivePath)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	zw := zip.NewWriter(f)
+	w, err := zw.Create("base/Virtual Machines/0000.vmcx")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	w.Write([]byte("vmcx"))
+	zw.Close()
+	f.Close()
+	state.Put("source_archive_path", archivePath)
+
+	step := &StepExtractSource{ArchiveType: "zip"}
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("Bad action: %v", action)
+	}
+	if _, ok := state.GetOk("error"); ok {
+		t.Fatal("Should NOT have error")
+	}
+
+	expected := filepath.Join(td, "source", "base")
+	if path := state.Get("source_vmcx_path").(string); path != expected {
+		t.Fatalf("Bad path. Got: %s Wanted: %s", path, expected)
+	}
+}
+
+func TestStepExtractSource_tar(t *testing.T) {
+	state, td := testExtractSourceState(t)
+	defer os.RemoveAll(td)
+
+	archivePath := filepath.Join(td, "source.tar")
+	f, err := os.Create(archivePath)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	tw := tar.NewWriter(f)
+	tw.WriteHeader(&tar.Header{Name: "Virtual Machines/", Typeflag: tar.TypeDir, Mode: 0755})
+	tw.WriteHeader(&tar.Header{Name: "Virtual Machines/0000.vmcx", Typeflag: tar.TypeReg, Mode: 0644, Size: 4})
+	tw.Write([]byte("vmcx"))
+	tw.Close()
+	f.Close()
+	state.Put("source_archive_path", archivePath)
+
+	step := &StepExtractSource{ArchiveType: "tar"}
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("Bad action: %v", action)
+	}
+
+	expected := filepath.Join(td, "source")
+	if path := state.Get("source_vmcx_path").(string); path != expected {
+		t.Fatalf("Bad path. Got: %s Wanted: %s", path, expected)
+	}
+}
+
+func TestStepExtractSource_noVirtualMachines(t *testing.T) {
+	state, td := testExtractSourceState(t)
+	defer os.RemoveAll(td)
+
+	archivePath := filepath.Join(td, "source.zip")
+	f, err := os.Create(archivePath)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	zw := zip.NewWriter(f)
+	w, err := zw.Create("readme.txt")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	w.Write([]byte("nothing here"))
+	zw.Close()
+	f.Close()
+	state.Put("source_archive_path", archivePath)
+
+	step := &StepExtractSource{ArchiveType: "zip"}
+	if action := step.Run(context.Background(), state); action != multistep.ActionHalt {
+		t.Fatalf("Bad action: %v", action)
+	}
+	if _, ok := state.GetOk("error"); !ok {
+		t.Fatal("Should have error")
+	}
+}
+
+func TestStepExtractSource_skip(t *testing.T) {
+	state, td := testExtractSourceState(t)
+	defer os.RemoveAll(td)
+
+	step := new(StepExtractSource)
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("Bad action: %v", action)
+	}
+	if _, ok := state.GetOk("source_vmcx_path"); ok {
+		t.Fatal("Should NOT have set source_vmcx_path")
+	}
+}
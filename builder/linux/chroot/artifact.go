@@ -0,0 +1,38 @@
+package chroot
+
+import (
+	"fmt"
+	"os"
+)
+
+// Artifact is the result of running the linux-chroot builder, namely the
+// resulting image in the output directory.
+type Artifact struct {
+	dir   string
+	f     []string
+	state map[string]interface{}
+}
+
+func (*Artifact) BuilderId() string {
+	return BuilderID
+}
+
+func (a *Artifact) Files() []string {
+	return a.f
+}
+
+func (a *Artifact) Id() string {
+	return a.f[0]
+}
+
+func (a *Artifact) String() string {
+	return fmt.Sprintf("Image file: %s", a.f[0])
+}
+
+func (a *Artifact) State(name string) interface{} {
+	return a.state[name]
+}
+
+func (a *Artifact) Destroy() error {
+	return os.RemoveAll(a.dir)
+}
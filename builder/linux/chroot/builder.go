@@ -0,0 +1,300 @@
+//go:generate struct-markdown
+//go:generate mapstructure-to-hcl2 -type Config
+
+// Package chroot is able to customize an existing disk image of a Linux
+// system without booting it. It does this by attaching the image to a loop
+// device, mounting its root partition and chrooting into that directory.
+// The image is then written in the output directory.
+package chroot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2/hcldec"
+	"github.com/hashicorp/packer/packer"
+	"github.com/hashicorp/packer/packer-plugin-sdk/chroot"
+	"github.com/hashicorp/packer/packer-plugin-sdk/common"
+	"github.com/hashicorp/packer/packer-plugin-sdk/multistep"
+	"github.com/hashicorp/packer/packer-plugin-sdk/multistep/commonsteps"
+	"github.com/hashicorp/packer/packer-plugin-sdk/template/config"
+	"github.com/hashicorp/packer/packer-plugin-sdk/template/interpolate"
+)
+
+// BuilderID is the unique ID for this builder
+const BuilderID = "linux.chroot"
+
+// Config is the configuration that is chained through the steps and settable
+// from the template.
+type Config struct {
+	common.PackerConfig `mapstructure:",squash"`
+
+	// The path to the disk image to customize. The image isn't modified, it
+	// is copied to the output directory first.
+	SourcePath string `mapstructure:"source_path" required:"true"`
+	// The format of the source image, `raw` or `qcow2`. Defaults to `qcow2`
+	// when the source path ends with `.qcow2`, and to `raw` otherwise.
+	SourceFormat string `mapstructure:"source_format"`
+	// The format of the resulting image, `raw` or `qcow2`. Defaults to the
+	// format of the source image.
+	Format string `mapstructure:"format"`
+	// Compress the resulting image. Only supported by the `qcow2` format.
+	// Defaults to `false`.
+	Compress bool `mapstructure:"compress"`
+	// Grow the image to this size before attaching it, like `10G`. The
+	// partitions and file systems of the image aren't grown, which can be done
+	// with `pre_mount_commands`. If not specified, the image keeps its
+	// original size.
+	DiskSize string `mapstructure:"disk_size"`
+	// The path to the `qemu-img` binary, used to copy and convert the images.
+	// Defaults to `qemu-img`.
+	QemuImgBinary string `mapstructure:"qemu_img_binary"`
+	// The directory the resulting image is written to. This directory must
+	// not exist, unless `-force` is used. Defaults to `output-BUILDNAME`.
+	OutputDir string `mapstructure:"output_directory"`
+	// The name of the resulting image file in the output directory. Defaults
+	// to `packer-BUILDNAME`.
+	VMName string `mapstructure:"vm_name"`
+
+	// How to run shell commands. This may be useful to set environment variables or perhaps run
+	// a command with sudo or so on. This is a configuration template where the `.Command` variable
+	// is replaced with the command to be run. Defaults to `{{.Command}}`.
+	CommandWrapper string `mapstructure:"command_wrapper"`
+	// A series of commands to execute after attaching the image and before mounting the chroot,
+	// like growing the root partition and file system after `disk_size`. The path to the loop
+	// device is provided by `{{.Device}}`.
+	PreMountCommands []string `mapstructure:"pre_mount_commands"`
+	// Options to supply the `mount` command when mounting devices. Each option will be prefixed with
+	// `-o` and supplied to the `mount` command ran by Packer. Because this command is ran in a shell,
+	// user discretion is advised. See this manual page for the `mount` command for valid file system specific options.
+	MountOptions []string `mapstructure:"mount_options"`
+	// The partition number containing the / partition. By default this is the first partition of
+	// the image. Use `0` for images without a partition table.
+	MountPartition string `mapstructure:"mount_partition"`
+	// The path where the image will be mounted. This is where the chroot environment will be. This defaults
+	// to `/mnt/packer-linux-chroot/{{.Device}}`. This is a configuration template where the `.Device`
+	// variable is replaced with the name of the loop device the image is attached to.
+	MountPath string `mapstructure:"mount_path"`
+	// As `pre_mount_commands`, but the commands are executed after mounting the root device and before the
+	// extra mount and copy steps. The device and mount path are provided by `{{.Device}}` and `{{.MountPath}}`.
+	PostMountCommands []string `mapstructure:"post_mount_commands"`
+	// This is a list of devices to mount into the chroot environment. This configuration parameter requires
+	// some additional documentation which is in the "Chroot Mounts" section below. Please read that section
+	// for more information on how to use this.
+	ChrootMounts [][]string `mapstructure:"chroot_mounts"`
+	// Paths to files on the build host that will be copied into the chroot environment prior to
+	// provisioning. Defaults to `/etc/resolv.conf` so that DNS lookups work. Pass an empty list to skip copying
+	// `/etc/resolv.conf`. You may need to do this if you're building an image that uses systemd.
+	CopyFiles []string `mapstructure:"copy_files"`
+
+	ctx interpolate.Context
+}
+
+// GetContext implements ContextProvider to allow steps to use the config context
+// for template interpolation
+func (c *Config) GetContext() interpolate.Context {
+	return c.ctx
+}
+
+type Builder struct {
+	config Config
+	runner multistep.Runner
+}
+
+// verify interface implementation
+var _ packer.Builder = &Builder{}
+
+func (b *Builder) ConfigSpec() hcldec.ObjectSpec { return b.config.FlatMapstructure().HCL2Spec() }
+
+func (b *Builder) Prepare(raws ...interface{}) ([]string, []string, error) {
+	err := config.Decode(&b.config, &config.DecodeOpts{
+		PluginType:         BuilderID,
+		Interpolate:        true,
+		InterpolateContext: &b.config.ctx,
+		InterpolateFilter: &interpolate.RenderFilter{
+			Exclude: []string{
+				// these fields are interpolated in the steps,
+				// when more information is available
+				"command_wrapper",
+				"post_mount_commands",
+				"pre_mount_commands",
+				"mount_path",
+			},
+		},
+	}, raws...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var errs *packer.MultiError
+
+	// Defaults
+	if b.config.SourceFormat == "" {
+		b.config.SourceFormat = "raw"
+		if strings.HasSuffix(b.config.SourcePath, ".qcow2") {
+			b.config.SourceFormat = "qcow2"
+		}
+	}
+
+	if b.config.Format == "" {
+		b.config.Format = b.config.SourceFormat
+	}
+
+	if b.config.QemuImgBinary == "" {
+		b.config.QemuImgBinary = "qemu-img"
+	}
+
+	if b.config.OutputDir == "" {
+		b.config.OutputDir = fmt.Sprintf("output-%s", b.config.PackerBuildName)
+	}
+
+	if b.config.VMName == "" {
+		b.config.VMName = fmt.Sprintf("packer-%s", b.config.PackerBuildName)
+	}
+
+	if b.config.ChrootMounts == nil {
+		b.config.ChrootMounts = make([][]string, 0)
+	}
+
+	if len(b.config.ChrootMounts) == 0 {
+		b.config.ChrootMounts = [][]string{
+			{"proc", "proc", "/proc"},
+			{"sysfs", "sysfs", "/sys"},
+			{"bind", "/dev", "/dev"},
+			{"devpts", "devpts", "/dev/pts"},
+			{"binfmt_misc", "binfmt_misc", "/proc/sys/fs/binfmt_misc"},
+		}
+	}
+
+	// set default copy file if we're not giving our own
+	if b.config.CopyFiles == nil {
+		b.config.CopyFiles = []string{"/etc/resolv.conf"}
+	}
+
+	if b.config.CommandWrapper == "" {
+		b.config.CommandWrapper = "{{.Command}}"
+	}
+
+	if b.config.MountPath == "" {
+		b.config.MountPath = "/mnt/packer-linux-chroot/{{.Device}}"
+	}
+
+	if b.config.MountPartition == "" {
+		b.config.MountPartition = "1"
+	}
+
+	// checks, accumulate any errors or warnings
+
+	if b.config.SourcePath == "" {
+		errs = packer.MultiErrorAppend(errs, errors.New("source_path is required"))
+	}
+
+	if err := checkFormat(b.config.SourceFormat); err != nil {
+		errs = packer.MultiErrorAppend(errs, fmt.Errorf("source_format: %v", err))
+	}
+
+	if err := checkFormat(b.config.Format); err != nil {
+		errs = packer.MultiErrorAppend(errs, fmt.Errorf("format: %v", err))
+	}
+
+	if b.config.Compress && b.config.Format != "qcow2" {
+		errs = packer.MultiErrorAppend(errs, errors.New("compress is only supported by the qcow2 format"))
+	}
+
+	if errs != nil {
+		return nil, nil, errs
+	}
+
+	return nil, nil, nil
+}
+
+func checkFormat(s string) error {
+	switch s {
+	case "raw", "qcow2":
+		return nil
+	}
+	return fmt.Errorf("%q is not a valid value [raw qcow2]", s)
+}
+
+func (b *Builder) Run(ctx context.Context, ui packer.Ui, hook packer.Hook) (packer.Artifact, error) {
+	if runtime.GOOS != "linux" {
+		return nil, errors.New("the linux-chroot builder only works on Linux environments")
+	}
+
+	wrappedCommand := func(command string) (string, error) {
+		ictx := b.config.ctx
+		ictx.Data = &struct{ Command string }{Command: command}
+		return interpolate.Render(b.config.CommandWrapper, &ictx)
+	}
+
+	// Setup the state bag and initial state for the steps
+	state := new(multistep.BasicStateBag)
+	state.Put("config", &b.config)
+	state.Put("hook", hook)
+	state.Put("ui", ui)
+	state.Put("wrappedCommand", common.CommandWrapper(wrappedCommand))
+
+	// Build the step array from the config
+	steps := buildsteps(b.config)
+
+	// Run!
+	b.runner = commonsteps.NewRunner(steps, b.config.PackerConfig, ui)
+	b.runner.Run(ctx, state)
+
+	// If there was an error, return that
+	if rawErr, ok := state.GetOk("error"); ok {
+		return nil, rawErr.(error)
+	}
+
+	// If we were interrupted or cancelled, then just exit.
+	if _, ok := state.GetOk(multistep.StateCancelled); ok {
+		return nil, errors.New("Build was cancelled.")
+	}
+
+	if _, ok := state.GetOk(multistep.StateHalted); ok {
+		return nil, errors.New("Build was halted.")
+	}
+
+	artifact := &Artifact{
+		dir:   b.config.OutputDir,
+		f:     []string{filepath.Join(b.config.OutputDir, b.config.VMName)},
+		state: map[string]interface{}{"generated_data": state.Get("generated_data")},
+	}
+
+	return artifact, nil
+}
+
+func buildsteps(config Config) []multistep.Step {
+	return []multistep.Step{
+		&commonsteps.StepOutputDir{
+			Force: config.PackerForce,
+			Path:  config.OutputDir,
+		},
+		&StepPrepareImage{}, // sets 'image_path' in stateBag
+		&StepAttachImage{},  // uses image_path and sets 'device' in stateBag
+		&chroot.StepPreMountCommands{
+			Commands: config.PreMountCommands,
+		},
+		&StepMountDevice{
+			MountOptions:   config.MountOptions,
+			MountPartition: config.MountPartition,
+			MountPath:      config.MountPath,
+		},
+		&chroot.StepPostMountCommands{
+			Commands: config.PostMountCommands,
+		},
+		&chroot.StepMountExtra{
+			ChrootMounts: config.ChrootMounts,
+		},
+		&chroot.StepCopyFiles{
+			Files: config.CopyFiles,
+		},
+		&chroot.StepChrootProvision{},
+		&chroot.StepEarlyCleanup{},
+		&StepExportImage{},
+	}
+}
@@ -0,0 +1,109 @@
+package chroot
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBuilder_Prepare(t *testing.T) {
+	type config map[string]interface{}
+
+	tests := []struct {
+		name     string
+		config   config
+		validate func(Config)
+		wantErr  bool
+	}{
+		{
+			name: "raw image",
+			config: config{
+				"source_path": "ubuntu.img",
+			},
+			validate: func(c Config) {
+				if c.SourceFormat != "raw" {
+					t.Errorf("Expected SourceFormat to be %s, but found %s", "raw", c.SourceFormat)
+				}
+				if c.Format != "raw" {
+					t.Errorf("Expected Format to be %s, but found %s", "raw", c.Format)
+				}
+				if c.MountPartition != "1" {
+					t.Errorf("Expected MountPartition to be %s, but found %s", "1", c.MountPartition)
+				}
+				if len(c.CopyFiles) != 1 || c.CopyFiles[0] != "/etc/resolv.conf" {
+					t.Errorf("Expected CopyFiles to be %v, but found %v", []string{"/etc/resolv.conf"}, c.CopyFiles)
+				}
+				if len(c.ChrootMounts) != 5 {
+					t.Errorf("Expected 5 ChrootMounts, but found %d", len(c.ChrootMounts))
+				}
+			},
+		},
+		{
+			name: "qcow2 image from the extension",
+			config: config{
+				"source_path": "ubuntu.qcow2",
+				"compress":    true,
+			},
+			validate: func(c Config) {
+				if c.SourceFormat != "qcow2" {
+					t.Errorf("Expected SourceFormat to be %s, but found %s", "qcow2", c.SourceFormat)
+				}
+				if c.Format != "qcow2" {
+					t.Errorf("Expected Format to be %s, but found %s", "qcow2", c.Format)
+				}
+			},
+		},
+		{
+			name:    "missing source path",
+			config:  config{},
+			wantErr: true,
+		},
+		{
+			name: "unknown format",
+			config: config{
+				"source_path": "ubuntu.img",
+				"format":      "vmdk",
+			},
+			wantErr: true,
+		},
+		{
+			name: "compressed raw image",
+			config: config{
+				"source_path": "ubuntu.img",
+				"compress":    true,
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &Builder{}
+
+			_, _, err := b.Prepare(tt.config)
+
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Builder.Prepare() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if tt.validate != nil {
+				tt.validate(b.config)
+			}
+		})
+	}
+}
+
+func TestPartitionDevice(t *testing.T) {
+	if d := partitionDevice("/dev/loop0", "1"); d != "/dev/loop0p1" {
+		t.Errorf("Expected /dev/loop0p1, got %s", d)
+	}
+	if d := partitionDevice("/dev/loop0", "0"); d != "/dev/loop0" {
+		t.Errorf("Expected /dev/loop0, got %s", d)
+	}
+}
+
+func TestExportArgs(t *testing.T) {
+	c := &Config{Format: "qcow2", Compress: true}
+	expected := []string{"convert", "-f", "raw", "-O", "qcow2", "-c", "image.raw", "image"}
+	if args := exportArgs(c, "image.raw", "image"); !reflect.DeepEqual(args, expected) {
+		t.Errorf("Expected %v, got %v", expected, args)
+	}
+}
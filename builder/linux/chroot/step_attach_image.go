@@ -0,0 +1,98 @@
+package chroot
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/hashicorp/packer/packer"
+	"github.com/hashicorp/packer/packer-plugin-sdk/common"
+	"github.com/hashicorp/packer/packer-plugin-sdk/multistep"
+)
+
+var _ multistep.Step = &StepAttachImage{}
+
+// StepAttachImage attaches the image to a free loop device, scanning its
+// partition table.
+//
+// Produces:
+//   device string - The path to the loop device, like /dev/loop0.
+type StepAttachImage struct {
+	device string
+}
+
+func (s *StepAttachImage) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	imagePath := state.Get("image_path").(string)
+	ui := state.Get("ui").(packer.Ui)
+	wrappedCommand := state.Get("wrappedCommand").(common.CommandWrapper)
+
+	ui.Say("Attaching the image to a loop device...")
+	attachCommand, err := wrappedCommand(
+		fmt.Sprintf("losetup --find --show --partscan %s", imagePath))
+	if err != nil {
+		err := fmt.Errorf("error creating attach command: %s", err)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+	log.Printf("[DEBUG] (step attach) attach command is %s", attachCommand)
+
+	stdout := new(bytes.Buffer)
+	stderr := new(bytes.Buffer)
+	cmd := common.ShellCommand(attachCommand)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	if err := cmd.Run(); err != nil {
+		err := fmt.Errorf(
+			"error attaching image: %s\nStderr: %s", err, stderr.String())
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+
+	device := strings.TrimSpace(stdout.String())
+	if device == "" {
+		err := fmt.Errorf("error attaching image: no loop device was returned")
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+
+	ui.Say(fmt.Sprintf("Image available at %q", device))
+	s.device = device
+	state.Put("device", device)
+	state.Put("attach_cleanup", s)
+	return multistep.ActionContinue
+}
+
+func (s *StepAttachImage) Cleanup(state multistep.StateBag) {
+	ui := state.Get("ui").(packer.Ui)
+	if err := s.CleanupFunc(state); err != nil {
+		ui.Error(err.Error())
+	}
+}
+
+func (s *StepAttachImage) CleanupFunc(state multistep.StateBag) error {
+	if s.device == "" {
+		return nil
+	}
+
+	ui := state.Get("ui").(packer.Ui)
+	wrappedCommand := state.Get("wrappedCommand").(common.CommandWrapper)
+
+	ui.Say(fmt.Sprintf("Detaching loop device %s...", s.device))
+	detachCommand, err := wrappedCommand(fmt.Sprintf("losetup --detach %s", s.device))
+	if err != nil {
+		return fmt.Errorf("error creating detach command: %s", err)
+	}
+
+	cmd := common.ShellCommand(detachCommand)
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("error detaching loop device: %s", err)
+	}
+
+	s.device = ""
+	return nil
+}
@@ -0,0 +1,55 @@
+package chroot
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/hashicorp/packer/packer"
+	"github.com/hashicorp/packer/packer-plugin-sdk/multistep"
+)
+
+var _ multistep.Step = &StepExportImage{}
+
+// StepExportImage converts the customized raw image to the output format.
+// Raw images are already in place.
+type StepExportImage struct{}
+
+func (s *StepExportImage) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	config := state.Get("config").(*Config)
+	imagePath := state.Get("image_path").(string)
+	ui := state.Get("ui").(packer.Ui)
+
+	if config.Format == "raw" {
+		return multistep.ActionContinue
+	}
+
+	ui.Say(fmt.Sprintf("Converting image to %s...", config.Format))
+	outputPath := filepath.Join(config.OutputDir, config.VMName)
+	if err := qemuImg(config.QemuImgBinary, exportArgs(config, imagePath, outputPath)...); err != nil {
+		err := fmt.Errorf("error converting image: %s", err)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+
+	if err := os.Remove(imagePath); err != nil {
+		err := fmt.Errorf("error removing raw image: %s", err)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+
+	return multistep.ActionContinue
+}
+
+func (s *StepExportImage) Cleanup(state multistep.StateBag) {}
+
+func exportArgs(config *Config, imagePath string, outputPath string) []string {
+	args := []string{"convert", "-f", "raw", "-O", config.Format}
+	if config.Compress {
+		args = append(args, "-c")
+	}
+	return append(args, imagePath, outputPath)
+}
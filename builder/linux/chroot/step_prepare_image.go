@@ -0,0 +1,78 @@
+package chroot
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/packer/packer"
+	"github.com/hashicorp/packer/packer-plugin-sdk/multistep"
+)
+
+var _ multistep.Step = &StepPrepareImage{}
+
+// StepPrepareImage copies the source image to a raw image in the output
+// directory, which can be attached to a loop device, and grows it to the disk
+// size.
+//
+// Produces:
+//   image_path string - The path to the raw image to customize.
+type StepPrepareImage struct{}
+
+func (s *StepPrepareImage) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	config := state.Get("config").(*Config)
+	ui := state.Get("ui").(packer.Ui)
+
+	imagePath := rawImagePath(config)
+
+	ui.Say(fmt.Sprintf("Copying source image %s...", config.SourcePath))
+	if err := qemuImg(config.QemuImgBinary,
+		"convert", "-f", config.SourceFormat, "-O", "raw", config.SourcePath, imagePath); err != nil {
+		err := fmt.Errorf("error copying source image: %s", err)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+
+	if config.DiskSize != "" {
+		ui.Say(fmt.Sprintf("Resizing image to %s...", config.DiskSize))
+		if err := qemuImg(config.QemuImgBinary,
+			"resize", "-f", "raw", imagePath, config.DiskSize); err != nil {
+			err := fmt.Errorf("error resizing image: %s", err)
+			state.Put("error", err)
+			ui.Error(err.Error())
+			return multistep.ActionHalt
+		}
+	}
+
+	state.Put("image_path", imagePath)
+	return multistep.ActionContinue
+}
+
+func (s *StepPrepareImage) Cleanup(state multistep.StateBag) {}
+
+// rawImagePath returns the path of the raw image that is customized. It is
+// the resulting image when the output format is raw.
+func rawImagePath(config *Config) string {
+	path := filepath.Join(config.OutputDir, config.VMName)
+	if config.Format != "raw" {
+		path += ".raw"
+	}
+	return path
+}
+
+func qemuImg(binary string, args ...string) error {
+	var stderr bytes.Buffer
+
+	log.Printf("Executing %s: %#v", binary, args)
+	cmd := exec.Command(binary, args...)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
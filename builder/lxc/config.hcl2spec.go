@@ -9,26 +9,28 @@ import (
 // FlatConfig is an auto-generated flat version of Config.
 // Where the contents of a field with a `mapstructure:,squash` tag are bubbled up.
 type FlatConfig struct {
-	PackerBuildName     *string           `mapstructure:"packer_build_name" cty:"packer_build_name" hcl:"packer_build_name"`
-	PackerBuilderType   *string           `mapstructure:"packer_builder_type" cty:"packer_builder_type" hcl:"packer_builder_type"`
-	PackerCoreVersion   *string           `mapstructure:"packer_core_version" cty:"packer_core_version" hcl:"packer_core_version"`
-	PackerDebug         *bool             `mapstructure:"packer_debug" cty:"packer_debug" hcl:"packer_debug"`
-	PackerForce         *bool             `mapstructure:"packer_force" cty:"packer_force" hcl:"packer_force"`
-	PackerOnError       *string           `mapstructure:"packer_on_error" cty:"packer_on_error" hcl:"packer_on_error"`
-	PackerUserVars      map[string]string `mapstructure:"packer_user_variables" cty:"packer_user_variables" hcl:"packer_user_variables"`
-	PackerSensitiveVars []string          `mapstructure:"packer_sensitive_variables" cty:"packer_sensitive_variables" hcl:"packer_sensitive_variables"`
-	ConfigFile          *string           `mapstructure:"config_file" required:"true" cty:"config_file" hcl:"config_file"`
-	OutputDir           *string           `mapstructure:"output_directory" required:"false" cty:"output_directory" hcl:"output_directory"`
-	ContainerName       *string           `mapstructure:"container_name" required:"false" cty:"container_name" hcl:"container_name"`
-	CommandWrapper      *string           `mapstructure:"command_wrapper" required:"false" cty:"command_wrapper" hcl:"command_wrapper"`
-	InitTimeout         *string           `mapstructure:"init_timeout" required:"false" cty:"init_timeout" hcl:"init_timeout"`
-	CreateOptions       []string          `mapstructure:"create_options" required:"false" cty:"create_options" hcl:"create_options"`
-	StartOptions        []string          `mapstructure:"start_options" required:"false" cty:"start_options" hcl:"start_options"`
-	AttachOptions       []string          `mapstructure:"attach_options" required:"false" cty:"attach_options" hcl:"attach_options"`
-	Name                *string           `mapstructure:"template_name" required:"true" cty:"template_name" hcl:"template_name"`
-	Parameters          []string          `mapstructure:"template_parameters" required:"false" cty:"template_parameters" hcl:"template_parameters"`
-	EnvVars             []string          `mapstructure:"template_environment_vars" required:"true" cty:"template_environment_vars" hcl:"template_environment_vars"`
-	TargetRunlevel      *int              `mapstructure:"target_runlevel" required:"false" cty:"target_runlevel" hcl:"target_runlevel"`
+	PackerBuildName      *string           `mapstructure:"packer_build_name" cty:"packer_build_name" hcl:"packer_build_name"`
+	PackerBuilderType    *string           `mapstructure:"packer_builder_type" cty:"packer_builder_type" hcl:"packer_builder_type"`
+	PackerCoreVersion    *string           `mapstructure:"packer_core_version" cty:"packer_core_version" hcl:"packer_core_version"`
+	PackerDebug          *bool             `mapstructure:"packer_debug" cty:"packer_debug" hcl:"packer_debug"`
+	PackerForce          *bool             `mapstructure:"packer_force" cty:"packer_force" hcl:"packer_force"`
+	PackerOnError        *string           `mapstructure:"packer_on_error" cty:"packer_on_error" hcl:"packer_on_error"`
+	PackerResume         *bool             `mapstructure:"packer_resume" cty:"packer_resume" hcl:"packer_resume"`
+	PackerCleanupTimeout *string           `mapstructure:"packer_cleanup_timeout" cty:"packer_cleanup_timeout" hcl:"packer_cleanup_timeout"`
+	PackerUserVars       map[string]string `mapstructure:"packer_user_variables" cty:"packer_user_variables" hcl:"packer_user_variables"`
+	PackerSensitiveVars  []string          `mapstructure:"packer_sensitive_variables" cty:"packer_sensitive_variables" hcl:"packer_sensitive_variables"`
+	ConfigFile           *string           `mapstructure:"config_file" required:"true" cty:"config_file" hcl:"config_file"`
+	OutputDir            *string           `mapstructure:"output_directory" required:"false" cty:"output_directory" hcl:"output_directory"`
+	ContainerName        *string           `mapstructure:"container_name" required:"false" cty:"container_name" hcl:"container_name"`
+	CommandWrapper       *string           `mapstructure:"command_wrapper" required:"false" cty:"command_wrapper" hcl:"command_wrapper"`
+	InitTimeout          *string           `mapstructure:"init_timeout" required:"false" cty:"init_timeout" hcl:"init_timeout"`
+	CreateOptions        []string          `mapstructure:"create_options" required:"false" cty:"create_options" hcl:"create_options"`
+	StartOptions         []string          `mapstructure:"start_options" required:"false" cty:"start_options" hcl:"start_options"`
+	AttachOptions        []string          `mapstructure:"attach_options" required:"false" cty:"attach_options" hcl:"attach_options"`
+	Name                 *string           `mapstructure:"template_name" required:"true" cty:"template_name" hcl:"template_name"`
+	Parameters           []string          `mapstructure:"template_parameters" required:"false" cty:"template_parameters" hcl:"template_parameters"`
+	EnvVars              []string          `mapstructure:"template_environment_vars" required:"true" cty:"template_environment_vars" hcl:"template_environment_vars"`
+	TargetRunlevel       *int              `mapstructure:"target_runlevel" required:"false" cty:"target_runlevel" hcl:"target_runlevel"`
 }
 
 // FlatMapstructure returns a new FlatConfig.
@@ -49,6 +51,8 @@ func (*FlatConfig) HCL2Spec() map[string]hcldec.Spec {
 		"packer_debug":               &hcldec.AttrSpec{Name: "packer_debug", Type: cty.Bool, Required: false},
 		"packer_force":               &hcldec.AttrSpec{Name: "packer_force", Type: cty.Bool, Required: false},
 		"packer_on_error":            &hcldec.AttrSpec{Name: "packer_on_error", Type: cty.String, Required: false},
+		"packer_resume":              &hcldec.AttrSpec{Name: "packer_resume", Type: cty.Bool, Required: false},
+		"packer_cleanup_timeout":     &hcldec.AttrSpec{Name: "packer_cleanup_timeout", Type: cty.String, Required: false},
 		"packer_user_variables":      &hcldec.AttrSpec{Name: "packer_user_variables", Type: cty.Map(cty.String), Required: false},
 		"packer_sensitive_variables": &hcldec.AttrSpec{Name: "packer_sensitive_variables", Type: cty.List(cty.String), Required: false},
 		"config_file":                &hcldec.AttrSpec{Name: "config_file", Type: cty.String, Required: false},
@@ -6,6 +6,10 @@ import (
 
 type Artifact struct {
 	id string
+	// remote is the name of the remote the image was published to, it is
+	// empty for the local image store.
+	remote string
+	client string
 
 	// StateData should store data such as GeneratedData
 	// to be shared with post-processors
@@ -25,7 +29,7 @@ func (a *Artifact) Id() string {
 }
 
 func (a *Artifact) String() string {
-	return fmt.Sprintf("image: %s", a.id)
+	return fmt.Sprintf("image: %s", a.image())
 }
 
 func (a *Artifact) State(name string) interface{} {
@@ -33,6 +37,14 @@ func (a *Artifact) State(name string) interface{} {
 }
 
 func (a *Artifact) Destroy() error {
-	_, err := LXDCommand("image", "delete", a.id)
+	_, err := LXDCommand(a.client, "image", "delete", a.image())
 	return err
 }
+
+// image returns the fingerprint of the image, prefixed with its remote.
+func (a *Artifact) image() string {
+	if a.remote == "" {
+		return a.id
+	}
+	return fmt.Sprintf("%s:%s", a.remote, a.id)
+}
@@ -63,6 +63,8 @@ func (b *Builder) Run(ctx context.Context, ui packer.Ui, hook packer.Hook) (pack
 
 	artifact := &Artifact{
 		id:        state.Get("imageFingerprint").(string),
+		remote:    state.Get("imageRemote").(string),
+		client:    b.config.Client,
 		StateData: map[string]interface{}{"generated_data": state.Get("generated_data")},
 	}
 
@@ -3,6 +3,7 @@ package lxd
 import (
 	"os"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/packer/packer"
 )
@@ -75,3 +76,80 @@ func TestBuilder_ImplementsBuilder(t *testing.T) {
 		t.Fatalf("Builder should be a builder")
 	}
 }
+
+func TestBuilderPrepare_Defaults(t *testing.T) {
+	var b Builder
+	_, warnings, err := b.Prepare(testConfig())
+	if len(warnings) > 0 {
+		t.Fatalf("bad: %#v", warnings)
+	}
+	if err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+
+	if b.config.Client != "lxc" {
+		t.Fatalf("bad client: %s", b.config.Client)
+	}
+	if b.config.AgentTimeout != 5*time.Minute {
+		t.Fatalf("bad agent timeout: %s", b.config.AgentTimeout)
+	}
+}
+
+func TestBuilderPrepare_VirtualMachine(t *testing.T) {
+	var b Builder
+	config := testConfig()
+	config["client"] = "incus"
+	config["virtual_machine"] = true
+	config["agent_timeout"] = "10m"
+	config["publish_compression"] = "zstd"
+	_, warnings, err := b.Prepare(config)
+	if len(warnings) > 0 {
+		t.Fatalf("bad: %#v", warnings)
+	}
+	if err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+
+	if b.config.Client != "incus" || !b.config.VirtualMachine ||
+		b.config.AgentTimeout != 10*time.Minute || b.config.PublishCompression != "zstd" {
+		t.Fatalf("bad: %#v", b.config)
+	}
+}
+
+func TestBuilderPrepare_RemoteWithoutAlias(t *testing.T) {
+	var b Builder
+	config := testConfig()
+	config["output_image"] = "remote:"
+	_, _, err := b.Prepare(config)
+	if err == nil {
+		t.Fatal("should have error")
+	}
+}
+
+func TestSplitOutputImage(t *testing.T) {
+	cases := []struct {
+		outputImage, remote, alias string
+	}{
+		{"foo", "", "foo"},
+		{"remote:foo", "remote", "foo"},
+		{"remote:", "remote", ""},
+	}
+	for _, tc := range cases {
+		remote, alias := splitOutputImage(tc.outputImage)
+		if remote != tc.remote || alias != tc.alias {
+			t.Errorf("%s: bad: %q, %q", tc.outputImage, remote, alias)
+		}
+	}
+}
+
+func TestArtifact_String(t *testing.T) {
+	a := &Artifact{id: "08fababf6f27"}
+	if a.String() != "image: 08fababf6f27" {
+		t.Fatalf("bad: %s", a.String())
+	}
+
+	a.remote = "remote"
+	if a.String() != "image: remote:08fababf6f27" {
+		t.Fatalf("bad: %s", a.String())
+	}
+}
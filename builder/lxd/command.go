@@ -20,11 +20,12 @@ func ShellCommand(command string) *exec.Cmd {
 
 // Yeah...LXD calls `lxc` because the command line is different between the
 // packages. This should also avoid a naming collision between the LXC builder.
-func LXDCommand(args ...string) (string, error) {
+// client is the command line client, `lxc` for LXD or `incus` for Incus.
+func LXDCommand(client string, args ...string) (string, error) {
 	var stdout, stderr bytes.Buffer
 
-	log.Printf("Executing lxc command: %#v", args)
-	cmd := exec.Command("lxc", args...)
+	log.Printf("Executing %s command: %#v", client, args)
+	cmd := exec.Command(client, args...)
 	cmd.Stdout = &stdout
 	cmd.Stderr = &stderr
 	err := cmd.Run()
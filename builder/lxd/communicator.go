@@ -16,6 +16,8 @@ import (
 type Communicator struct {
 	ContainerName string
 	CmdWrapper    CommandWrapper
+	// Client is the command line client, lxc or incus.
+	Client string
 }
 
 func (c *Communicator) Start(ctx context.Context, cmd *packer.RemoteCmd) error {
@@ -75,7 +77,7 @@ func (c *Communicator) Upload(dst string, r io.Reader, fi *os.FileInfo) error {
 		fileDestination = filepath.Join(c.ContainerName, dst, (*fi).Name())
 	}
 
-	cpCmd, err := c.CmdWrapper(fmt.Sprintf("lxc file push - %s", fileDestination))
+	cpCmd, err := c.CmdWrapper(fmt.Sprintf("%s file push - %s", c.Client, fileDestination))
 	if err != nil {
 		return err
 	}
@@ -89,7 +91,7 @@ func (c *Communicator) Upload(dst string, r io.Reader, fi *os.FileInfo) error {
 
 func (c *Communicator) UploadDir(dst string, src string, exclude []string) error {
 	fileDestination := fmt.Sprintf("%s/%s", c.ContainerName, dst)
-	pushCommand := fmt.Sprintf("lxc file push --debug -pr %s %s", src, fileDestination)
+	pushCommand := fmt.Sprintf("%s file push --debug -pr %s %s", c.Client, src, fileDestination)
 	log.Printf(pushCommand)
 	cp, err := c.CmdWrapper(pushCommand)
 	if err != nil {
@@ -110,7 +112,7 @@ func (c *Communicator) UploadDir(dst string, src string, exclude []string) error
 }
 
 func (c *Communicator) Download(src string, w io.Writer) error {
-	cpCmd, err := c.CmdWrapper(fmt.Sprintf("lxc file pull %s -", filepath.Join(c.ContainerName, src)))
+	cpCmd, err := c.CmdWrapper(fmt.Sprintf("%s file pull %s -", c.Client, filepath.Join(c.ContainerName, src)))
 	if err != nil {
 		return err
 	}
@@ -130,7 +132,7 @@ func (c *Communicator) DownloadDir(src string, dst string, exclude []string) err
 func (c *Communicator) Execute(commandString string) (*exec.Cmd, error) {
 	log.Printf("Executing with lxc exec in container: %s %s", c.ContainerName, commandString)
 	command, err := c.CmdWrapper(
-		fmt.Sprintf("lxc exec %s -- /bin/sh -c \"%s\"", c.ContainerName, commandString))
+		fmt.Sprintf("%s exec %s -- /bin/sh -c \"%s\"", c.Client, c.ContainerName, commandString))
 	if err != nil {
 		return nil, err
 	}
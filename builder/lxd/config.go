@@ -5,6 +5,8 @@ package lxd
 
 import (
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/hashicorp/packer/packer"
 	"github.com/hashicorp/packer/packer-plugin-sdk/common"
@@ -16,13 +18,17 @@ import (
 type Config struct {
 	common.PackerConfig `mapstructure:",squash"`
 	// The name of the output artifact. Defaults to
-	// name.
+	// name. Prefix it with the name of a remote, like `images:my-image`, to
+	// publish the image to that remote rather than to the local image store.
 	OutputImage   string `mapstructure:"output_image" required:"false"`
 	ContainerName string `mapstructure:"container_name"`
 	// Lets you prefix all builder commands, such as
 	// with ssh for a remote build host. Defaults to `{{.Command}}`; i.e. no
 	// wrapper.
 	CommandWrapper string `mapstructure:"command_wrapper" required:"false"`
+	// The command line client to run, `lxc` for LXD or `incus` for Incus.
+	// Defaults to `lxc`.
+	Client string `mapstructure:"client" required:"false"`
 	// The source image to use when creating the build
 	// container. This can be a (local or remote) image (name or fingerprint).
 	// E.G. my-base-image, ubuntu-daily:x, 08fababf6f27, ...
@@ -31,12 +37,22 @@ type Config struct {
 	// The number of seconds to sleep between launching
 	// the LXD instance and provisioning it; defaults to 3 seconds.
 	InitSleep string `mapstructure:"init_sleep" required:"false"`
+	// Launch a virtual machine rather than a container. The provisioners run
+	// once the agent of the virtual machine answers. Defaults to false.
+	VirtualMachine bool `mapstructure:"virtual_machine" required:"false"`
+	// The time to wait for the agent of the virtual machine to answer after
+	// `init_sleep`, when `virtual_machine` is set. Defaults to 5m.
+	AgentTimeout time.Duration `mapstructure:"agent_timeout" required:"false"`
 	// Pass key values to the publish
 	// step to be set as properties on the output image. This is most helpful to
 	// set the description, but can be used to set anything needed. See
 	// https://stgraber.org/2016/03/30/lxd-2-0-image-management-512/
 	// for more properties.
 	PublishProperties map[string]string `mapstructure:"publish_properties" required:"false"`
+	// The compression algorithm of the output image, like `gzip`, `xz`,
+	// `zstd` or `none`. Defaults to the `images.compression_algorithm`
+	// setting of the server.
+	PublishCompression string `mapstructure:"publish_compression" required:"false"`
 	// List of key/value pairs you wish to
 	// pass to lxc launch via --config. Defaults to empty.
 	LaunchConfig map[string]string `mapstructure:"launch_config" required:"false"`
@@ -70,6 +86,18 @@ func (c *Config) Prepare(raws ...interface{}) error {
 		c.CommandWrapper = "{{.Command}}"
 	}
 
+	if c.Client == "" {
+		c.Client = "lxc"
+	}
+
+	if c.AgentTimeout == 0 {
+		c.AgentTimeout = 5 * time.Minute
+	}
+
+	if remote, alias := splitOutputImage(c.OutputImage); remote != "" && alias == "" {
+		errs = packer.MultiErrorAppend(errs, fmt.Errorf("`output_image` %q has no alias after the remote name", c.OutputImage))
+	}
+
 	if c.Image == "" {
 		errs = packer.MultiErrorAppend(errs, fmt.Errorf("`image` is a required parameter for LXD. Please specify an image by alias or fingerprint. e.g. `ubuntu-daily:x`"))
 	}
@@ -90,3 +118,13 @@ func (c *Config) Prepare(raws ...interface{}) error {
 
 	return nil
 }
+
+// splitOutputImage splits the remote name off output_image, like remote for
+// remote:alias. The remote name is empty when output_image is a local alias.
+func splitOutputImage(outputImage string) (remote string, alias string) {
+	i := strings.Index(outputImage, ":")
+	if i < 0 {
+		return "", outputImage
+	}
+	return outputImage[:i], outputImage[i+1:]
+}
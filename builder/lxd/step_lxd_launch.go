@@ -9,6 +9,7 @@ import (
 
 	"github.com/hashicorp/packer/packer"
 	"github.com/hashicorp/packer/packer-plugin-sdk/multistep"
+	"github.com/hashicorp/packer/packer-plugin-sdk/retry"
 )
 
 type stepLxdLaunch struct{}
@@ -25,12 +26,16 @@ func (s *stepLxdLaunch) Run(ctx context.Context, state multistep.StateBag) multi
 		"launch", "--ephemeral=false", profile, image, name,
 	}
 
+	if config.VirtualMachine {
+		launch_args = append(launch_args, "--vm")
+	}
+
 	for k, v := range config.LaunchConfig {
 		launch_args = append(launch_args, "--config", fmt.Sprintf("%s=%s", k, v))
 	}
 
 	ui.Say("Creating container...")
-	_, err := LXDCommand(launch_args...)
+	_, err := LXDCommand(config.Client, launch_args...)
 	if err != nil {
 		err := fmt.Errorf("Error creating container: %s", err)
 		state.Put("error", err)
@@ -50,6 +55,25 @@ func (s *stepLxdLaunch) Run(ctx context.Context, state multistep.StateBag) multi
 
 	time.Sleep(time.Duration(sleep_seconds) * time.Second)
 	log.Printf("Sleeping for %d seconds...", sleep_seconds)
+
+	if config.VirtualMachine {
+		// Commands run through the agent of the virtual machine, which only
+		// starts once the virtual machine booted
+		ui.Say("Waiting for the agent of the virtual machine...")
+		err := retry.Config{
+			StartTimeout: config.AgentTimeout,
+			RetryDelay:   func() time.Duration { return 5 * time.Second },
+		}.Run(ctx, func(context.Context) error {
+			_, err := LXDCommand(config.Client, "exec", name, "--", "true")
+			return err
+		})
+		if err != nil {
+			err := fmt.Errorf("Error waiting for the agent of the virtual machine: %s", err)
+			state.Put("error", err)
+			ui.Error(err.Error())
+			return multistep.ActionHalt
+		}
+	}
 	return multistep.ActionContinue
 }
 
@@ -62,7 +86,7 @@ func (s *stepLxdLaunch) Cleanup(state multistep.StateBag) {
 	}
 
 	ui.Say("Unregistering and deleting deleting container...")
-	if _, err := LXDCommand(cleanup_args...); err != nil {
+	if _, err := LXDCommand(config.Client, cleanup_args...); err != nil {
 		ui.Error(fmt.Sprintf("Error deleting container: %s", err))
 	}
 }
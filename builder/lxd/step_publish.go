@@ -22,7 +22,7 @@ func (s *stepPublish) Run(ctx context.Context, state multistep.StateBag) multist
 	}
 
 	ui.Say("Stopping container...")
-	_, err := LXDCommand(stop_args...)
+	_, err := LXDCommand(config.Client, stop_args...)
 	if err != nil {
 		err := fmt.Errorf("Error stopping container: %s", err)
 		state.Put("error", err)
@@ -30,8 +30,17 @@ func (s *stepPublish) Run(ctx context.Context, state multistep.StateBag) multist
 		return multistep.ActionHalt
 	}
 
+	remote, alias := splitOutputImage(config.OutputImage)
 	publish_args := []string{
-		"publish", name, "--alias", config.OutputImage,
+		"publish", name,
+	}
+	if remote != "" {
+		publish_args = append(publish_args, remote+":")
+	}
+	publish_args = append(publish_args, "--alias", alias)
+
+	if config.PublishCompression != "" {
+		publish_args = append(publish_args, "--compression", config.PublishCompression)
 	}
 
 	for k, v := range config.PublishProperties {
@@ -39,7 +48,7 @@ func (s *stepPublish) Run(ctx context.Context, state multistep.StateBag) multist
 	}
 
 	ui.Say("Publishing container...")
-	stdoutString, err := LXDCommand(publish_args...)
+	stdoutString, err := LXDCommand(config.Client, publish_args...)
 	if err != nil {
 		err := fmt.Errorf("Error publishing container: %s", err)
 		state.Put("error", err)
@@ -53,6 +62,7 @@ func (s *stepPublish) Run(ctx context.Context, state multistep.StateBag) multist
 	ui.Say(fmt.Sprintf("Created image: %s", fingerprint))
 
 	state.Put("imageFingerprint", fingerprint)
+	state.Put("imageRemote", remote)
 
 	return multistep.ActionContinue
 }
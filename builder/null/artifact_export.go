@@ -2,10 +2,16 @@ package null
 
 import (
 	"fmt"
+	"strings"
 )
 
-// dummy Artifact implementation - does nothing
+// Artifact implementation of the null builder. It has no files unless
+// artifact_files are configured, which are never destroyed since they
+// weren't created by the build.
 type NullArtifact struct {
+	id       string
+	files    []string
+	metadata map[string]string
 }
 
 func (*NullArtifact) BuilderId() string {
@@ -13,22 +19,37 @@ func (*NullArtifact) BuilderId() string {
 }
 
 func (a *NullArtifact) Files() []string {
-	return []string{}
+	if a.files == nil {
+		return []string{}
+	}
+	return a.files
 }
 
-func (*NullArtifact) Id() string {
-	return "Null"
+func (a *NullArtifact) Id() string {
+	if a.id == "" {
+		return "Null"
+	}
+	return a.id
 }
 
 func (a *NullArtifact) String() string {
-	return fmt.Sprintf("Did not export anything. This is the null builder")
+	if len(a.files) == 0 {
+		return fmt.Sprintf("Did not export anything. This is the null builder")
+	}
+	return fmt.Sprintf("Files: %s", strings.Join(a.files, ", "))
 }
 
 func (a *NullArtifact) State(name string) interface{} {
 	if name == "generated_data" {
-		return map[interface{}]interface{}{
-			"ID": "Null",
+		data := map[interface{}]interface{}{}
+		for k, v := range a.metadata {
+			data[k] = v
 		}
+		data["ID"] = a.Id()
+		return data
+	}
+	if v, ok := a.metadata[name]; ok {
+		return v
 	}
 	return nil
 }
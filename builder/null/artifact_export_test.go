@@ -9,3 +9,26 @@ import (
 func TestNullArtifact(t *testing.T) {
 	var _ packer.Artifact = new(NullArtifact)
 }
+
+func TestNullArtifact_files(t *testing.T) {
+	a := &NullArtifact{
+		id:       "my-image",
+		files:    []string{"image.qcow2"},
+		metadata: map[string]string{"version": "1.0"},
+	}
+
+	if a.Id() != "my-image" {
+		t.Fatalf("bad: %s", a.Id())
+	}
+	if len(a.Files()) != 1 || a.Files()[0] != "image.qcow2" {
+		t.Fatalf("bad: %#v", a.Files())
+	}
+	if a.State("version") != "1.0" {
+		t.Fatalf("bad: %#v", a.State("version"))
+	}
+
+	data := a.State("generated_data").(map[interface{}]interface{})
+	if data["ID"] != "my-image" || data["version"] != "1.0" {
+		t.Fatalf("bad: %#v", data)
+	}
+}
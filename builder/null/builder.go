@@ -2,6 +2,8 @@ package null
 
 import (
 	"context"
+	"fmt"
+	"path/filepath"
 
 	"github.com/hashicorp/hcl/v2/hcldec"
 	"github.com/hashicorp/packer/helper/communicator"
@@ -58,7 +60,33 @@ func (b *Builder) Run(ctx context.Context, ui packer.Ui, hook packer.Hook) (pack
 		return nil, rawErr.(error)
 	}
 
+	files, err := artifactFiles(b.config.ArtifactFiles)
+	if err != nil {
+		return nil, err
+	}
+
 	// No errors, must've worked
-	artifact := &NullArtifact{}
+	artifact := &NullArtifact{
+		id:       b.config.ArtifactID,
+		files:    files,
+		metadata: b.config.ArtifactMetadata,
+	}
 	return artifact, nil
 }
+
+// artifactFiles returns the files matching the artifact_files patterns. Each
+// pattern must match at least one file.
+func artifactFiles(patterns []string) ([]string, error) {
+	var files []string
+	for _, pattern := range patterns {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid artifact_files pattern %q: %s", pattern, err)
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("no artifact file matches %q", pattern)
+		}
+		files = append(files, matches...)
+	}
+	return files, nil
+}
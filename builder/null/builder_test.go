@@ -1,6 +1,9 @@
 package null
 
 import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/hashicorp/packer/packer"
@@ -9,3 +12,29 @@ import (
 func TestBuilder_implBuilder(t *testing.T) {
 	var _ packer.Builder = new(Builder)
 }
+
+func TestArtifactFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "packer")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, name := range []string{"disk-1.qcow2", "disk-2.qcow2"} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte("disk"), 0644); err != nil {
+			t.Fatalf("err: %s", err)
+		}
+	}
+
+	files, err := artifactFiles([]string{filepath.Join(dir, "*.qcow2")})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if len(files) != 2 {
+		t.Fatalf("bad: %#v", files)
+	}
+
+	if _, err := artifactFiles([]string{filepath.Join(dir, "*.vmdk")}); err == nil {
+		t.Fatal("should error when a pattern matches no file")
+	}
+}
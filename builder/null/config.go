@@ -4,6 +4,7 @@ package null
 
 import (
 	"fmt"
+	"path/filepath"
 
 	"github.com/hashicorp/packer/helper/communicator"
 	"github.com/hashicorp/packer/packer"
@@ -16,6 +17,15 @@ type Config struct {
 	common.PackerConfig `mapstructure:",squash"`
 
 	CommConfig communicator.Config `mapstructure:",squash"`
+
+	// Files, or glob patterns of files, to return as the files of the
+	// artifact, for the post-processors. They're looked up after the
+	// provisioners ran and are never deleted by Packer.
+	ArtifactFiles []string `mapstructure:"artifact_files"`
+	// The ID of the artifact. Defaults to "Null".
+	ArtifactID string `mapstructure:"artifact_id"`
+	// Metadata of the artifact, added to the generated data of the build.
+	ArtifactMetadata map[string]string `mapstructure:"artifact_metadata"`
 }
 
 func (c *Config) Prepare(raws ...interface{}) ([]string, error) {
@@ -29,7 +39,18 @@ func (c *Config) Prepare(raws ...interface{}) ([]string, error) {
 		return nil, err
 	}
 
+	if c.ArtifactID == "" {
+		c.ArtifactID = "Null"
+	}
+
 	var errs *packer.MultiError
+	for _, pattern := range c.ArtifactFiles {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			errs = packer.MultiErrorAppend(errs,
+				fmt.Errorf("invalid artifact_files pattern %q: %s", pattern, err))
+		}
+	}
+
 	if es := c.CommConfig.Prepare(nil); len(es) > 0 {
 		errs = packer.MultiErrorAppend(errs, es...)
 	}
@@ -101,3 +101,25 @@ func TestConfigPrepare_sshCredential(t *testing.T) {
 	warns, errs = (&Config{}).Prepare(raw)
 	testConfigErr(t, warns, errs)
 }
+
+func TestConfigPrepare_artifact(t *testing.T) {
+	raw := testConfig()
+
+	// the artifact id defaults to Null
+	var c Config
+	warns, errs := c.Prepare(raw)
+	testConfigOk(t, warns, errs)
+	if c.ArtifactID != "Null" {
+		t.Fatalf("bad: artifact_id should default to Null, not %s", c.ArtifactID)
+	}
+
+	// valid patterns
+	raw["artifact_files"] = []string{"output/*.qcow2"}
+	warns, errs = (&Config{}).Prepare(raw)
+	testConfigOk(t, warns, errs)
+
+	// invalid pattern
+	raw["artifact_files"] = []string{"output/[.qcow2"}
+	warns, errs = (&Config{}).Prepare(raw)
+	testConfigErr(t, warns, errs)
+}
@@ -117,6 +117,17 @@ func (b *Builder) Run(ctx context.Context, ui packer.Ui, hook packer.Hook) (pack
 			NetworkDiscoveryCIDRs: b.config.NetworkDiscoveryCIDRs,
 			Ports:                 b.config.Ports,
 		},
+		&StepCreateTemporaryNetwork{
+			UseTemporaryNetwork: b.config.UseTemporaryNetwork,
+			FloatingIPNetwork:   b.config.FloatingIPNetwork,
+			CIDR:                b.config.TemporaryNetworkCIDR,
+			DNSNameservers:      b.config.TemporaryNetworkDNSNameservers,
+		},
+		&StepCreateTemporarySecurityGroup{
+			UseTemporarySecurityGroup: b.config.UseTemporarySecurityGroup,
+			SourceCIDRs:               b.config.TemporarySecurityGroupSourceCIDRs,
+			CommunicatorPort:          b.config.Comm.Port(),
+		},
 		&StepCreateVolume{
 			UseBlockStorageVolume:  b.config.UseBlockStorageVolume,
 			VolumeName:             b.config.VolumeName,
@@ -167,6 +178,9 @@ func (b *Builder) Run(ctx context.Context, ui packer.Ui, hook packer.Hook) (pack
 		&stepCreateImage{
 			UseBlockStorageVolume: b.config.UseBlockStorageVolume,
 		},
+		&stepUpdateImageProperties{
+			UseBlockStorageVolume: b.config.UseBlockStorageVolume,
+		},
 		&stepUpdateImageTags{},
 		&stepUpdateImageVisibility{},
 		&stepAddImageMembers{},
@@ -26,7 +26,8 @@ type ImageConfig struct {
 	// project. This requires a user with priveleges both in the build project and
 	// in the members provided. Defaults to false.
 	ImageAutoAcceptMembers bool `mapstructure:"image_auto_accept_members" required:"false"`
-	// Disk format of the resulting image. This option works if
+	// Disk format of the resulting image. One of "raw", "qcow2", "vmdk",
+	// "vdi", "vhd", "vhdx" or "ploop". This option works if
 	// use_blockstorage_volume is true.
 	ImageDiskFormat string `mapstructure:"image_disk_format" required:"false"`
 	// List of tags to add to the image after creation.
@@ -69,6 +70,21 @@ func (c *ImageConfig) Prepare(ctx *interpolate.Context) []error {
 		}
 	}
 
+	if c.ImageDiskFormat != "" {
+		validFormats := []string{"raw", "qcow2", "vmdk", "vdi", "vhd", "vhdx", "ploop"}
+		valid := false
+		for _, format := range validFormats {
+			if c.ImageDiskFormat == format {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			errs = append(errs, fmt.Errorf("Unknown image_disk_format %s, must be one of %s",
+				c.ImageDiskFormat, strings.Join(validFormats, ", ")))
+		}
+	}
+
 	if c.ImageMinDisk < 0 {
 		errs = append(errs, fmt.Errorf("An image min disk size must be greater than or equal to 0"))
 	}
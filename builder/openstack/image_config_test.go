@@ -21,3 +21,16 @@ func TestImageConfigPrepare_Region(t *testing.T) {
 		t.Fatal("should have error")
 	}
 }
+
+func TestImageConfigPrepare_DiskFormat(t *testing.T) {
+	c := testImageConfig()
+	c.ImageDiskFormat = "qcow2"
+	if err := c.Prepare(nil); err != nil {
+		t.Fatalf("shouldn't have err: %s", err)
+	}
+
+	c.ImageDiskFormat = "qcow3"
+	if err := c.Prepare(nil); err == nil {
+		t.Fatal("should have error")
+	}
+}
@@ -5,6 +5,7 @@ package openstack
 import (
 	"errors"
 	"fmt"
+	"net"
 
 	"github.com/gophercloud/gophercloud/openstack/imageservice/v2/images"
 	"github.com/hashicorp/packer/helper/communicator"
@@ -124,6 +125,23 @@ type RunConfig struct {
 	// The first network whose subnet is contained within any of the given CIDRs
 	// is used. Ignored if either of the above two options are provided.
 	NetworkDiscoveryCIDRs []string `mapstructure:"network_discovery_cidrs" required:"false"`
+	// Create a temporary network, subnet and router to attach the instance
+	// to, and delete them at the end of the build. The router uses the
+	// floating_ip_network as its gateway, which must be set. Can't be used
+	// with networks, ports or network_discovery_cidrs. Defaults to false.
+	UseTemporaryNetwork bool `mapstructure:"use_temporary_network" required:"false"`
+	// The CIDR of the subnet of the temporary network. Defaults to
+	// `10.255.0.0/24`.
+	TemporaryNetworkCIDR string `mapstructure:"temporary_network_cidr" required:"false"`
+	// A list of DNS nameservers for the subnet of the temporary network.
+	TemporaryNetworkDNSNameservers []string `mapstructure:"temporary_network_dns_nameservers" required:"false"`
+	// Create a temporary security group allowing the communicator to connect
+	// to the instance, and delete it at the end of the build. Can't be used
+	// with security_groups. Defaults to false.
+	UseTemporarySecurityGroup bool `mapstructure:"use_temporary_security_group" required:"false"`
+	// A list of IPv4 CIDRs allowed to connect to the communicator port of the
+	// instance through the temporary security group. Defaults to `0.0.0.0/0`.
+	TemporarySecurityGroupSourceCIDRs []string `mapstructure:"temporary_security_group_source_cidrs" required:"false"`
 	// User data to apply when launching the instance. Note that you need to be
 	// careful about escaping characters due to the templates being JSON. It is
 	// often more convenient to use user_data_file, instead. Packer will not
@@ -291,6 +309,35 @@ func (c *RunConfig) Prepare(ctx *interpolate.Context) []error {
 		}
 	}
 
+	if c.UseTemporaryNetwork {
+		if c.FloatingIPNetwork == "" {
+			errs = append(errs, errors.New("A floating_ip_network must be specified when use_temporary_network is true"))
+		}
+		if len(c.Networks) > 0 || len(c.Ports) > 0 || len(c.NetworkDiscoveryCIDRs) > 0 {
+			errs = append(errs, errors.New("use_temporary_network can't be used with networks, ports or network_discovery_cidrs"))
+		}
+		if c.TemporaryNetworkCIDR == "" {
+			c.TemporaryNetworkCIDR = "10.255.0.0/24"
+		}
+		if _, _, err := net.ParseCIDR(c.TemporaryNetworkCIDR); err != nil {
+			errs = append(errs, fmt.Errorf("Invalid temporary_network_cidr: %s", err))
+		}
+	}
+
+	if c.UseTemporarySecurityGroup {
+		if len(c.SecurityGroups) > 0 {
+			errs = append(errs, errors.New("use_temporary_security_group can't be used with security_groups"))
+		}
+		if len(c.TemporarySecurityGroupSourceCIDRs) == 0 {
+			c.TemporarySecurityGroupSourceCIDRs = []string{"0.0.0.0/0"}
+		}
+		for _, cidr := range c.TemporarySecurityGroupSourceCIDRs {
+			if _, _, err := net.ParseCIDR(cidr); err != nil {
+				errs = append(errs, fmt.Errorf("Invalid temporary_security_group_source_cidrs: %s", err))
+			}
+		}
+	}
+
 	if c.UseBlockStorageVolume {
 		// Use Compute instance availability zone for the Block Storage volume
 		// if it's not provided.
@@ -133,6 +133,51 @@ func TestRunConfigPrepare_FloatingIPPoolCompat(t *testing.T) {
 	}
 }
 
+func TestRunConfigPrepare_TemporaryNetwork(t *testing.T) {
+	c := testRunConfig()
+	c.UseTemporaryNetwork = true
+	if err := c.Prepare(nil); len(err) != 1 {
+		t.Fatalf("should error without a floating_ip_network: %s", err)
+	}
+
+	c = testRunConfig()
+	c.UseTemporaryNetwork = true
+	c.FloatingIPNetwork = "public"
+	if err := c.Prepare(nil); len(err) != 0 {
+		t.Fatalf("err: %s", err)
+	}
+	if c.TemporaryNetworkCIDR != "10.255.0.0/24" {
+		t.Fatalf("invalid value: %s", c.TemporaryNetworkCIDR)
+	}
+
+	c.Networks = []string{"uuid1"}
+	if err := c.Prepare(nil); len(err) != 1 {
+		t.Fatalf("should error with networks: %s", err)
+	}
+
+	c.Networks = nil
+	c.TemporaryNetworkCIDR = "10.255.0.0"
+	if err := c.Prepare(nil); len(err) != 1 {
+		t.Fatalf("should error with an invalid CIDR: %s", err)
+	}
+}
+
+func TestRunConfigPrepare_TemporarySecurityGroup(t *testing.T) {
+	c := testRunConfig()
+	c.UseTemporarySecurityGroup = true
+	if err := c.Prepare(nil); len(err) != 0 {
+		t.Fatalf("err: %s", err)
+	}
+	if len(c.TemporarySecurityGroupSourceCIDRs) != 1 || c.TemporarySecurityGroupSourceCIDRs[0] != "0.0.0.0/0" {
+		t.Fatalf("invalid value: %s", c.TemporarySecurityGroupSourceCIDRs)
+	}
+
+	c.SecurityGroups = []string{"default"}
+	if err := c.Prepare(nil); len(err) != 1 {
+		t.Fatalf("should error with security_groups: %s", err)
+	}
+}
+
 func TestRunConfigPrepare_ExternalSourceImageURL(t *testing.T) {
 	c := testRunConfig()
 	// test setting both ExternalSourceImageURL and SourceImage causes an error
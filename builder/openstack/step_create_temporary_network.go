@@ -0,0 +1,170 @@
+package openstack
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gophercloud/gophercloud/openstack/compute/v2/servers"
+	"github.com/gophercloud/gophercloud/openstack/networking/v2/extensions/layer3/routers"
+	"github.com/gophercloud/gophercloud/openstack/networking/v2/networks"
+	"github.com/gophercloud/gophercloud/openstack/networking/v2/subnets"
+	"github.com/hashicorp/packer/packer"
+	"github.com/hashicorp/packer/packer-plugin-sdk/multistep"
+	"github.com/hashicorp/packer/packer-plugin-sdk/retry"
+	"github.com/hashicorp/packer/packer-plugin-sdk/uuid"
+)
+
+// StepCreateTemporaryNetwork creates a network, a subnet and a router to the
+// floating IP network to attach the instance to, in place of the networks
+// found by StepDiscoverNetwork.
+type StepCreateTemporaryNetwork struct {
+	UseTemporaryNetwork bool
+	FloatingIPNetwork   string
+	CIDR                string
+	DNSNameservers      []string
+
+	networkID string
+	subnetID  string
+	routerID  string
+}
+
+func (s *StepCreateTemporaryNetwork) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	if !s.UseTemporaryNetwork {
+		return multistep.ActionContinue
+	}
+
+	config := state.Get("config").(*Config)
+	ui := state.Get("ui").(packer.Ui)
+
+	networkClient, err := config.networkV2Client()
+	if err != nil {
+		err = fmt.Errorf("Error initializing network client: %s", err)
+		state.Put("error", err)
+		return multistep.ActionHalt
+	}
+
+	externalNetworkID, err := CheckFloatingIPNetwork(networkClient, s.FloatingIPNetwork)
+	if err != nil {
+		err := fmt.Errorf("Error using the floating IP network %s: %s", s.FloatingIPNetwork, err)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+
+	name := fmt.Sprintf("packer_%s", uuid.TimeOrderedUUID())
+	ui.Say(fmt.Sprintf("Creating temporary network %s...", name))
+
+	network, err := networks.Create(networkClient, networks.CreateOpts{
+		Name: name,
+	}).Extract()
+	if err != nil {
+		err := fmt.Errorf("Error creating temporary network: %s", err)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+	s.networkID = network.ID
+
+	subnet, err := subnets.Create(networkClient, subnets.CreateOpts{
+		NetworkID:      network.ID,
+		Name:           name,
+		CIDR:           s.CIDR,
+		IPVersion:      4,
+		DNSNameservers: s.DNSNameservers,
+	}).Extract()
+	if err != nil {
+		err := fmt.Errorf("Error creating temporary subnet: %s", err)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+
+	router, err := routers.Create(networkClient, routers.CreateOpts{
+		Name: name,
+		GatewayInfo: &routers.GatewayInfo{
+			NetworkID: externalNetworkID,
+		},
+	}).Extract()
+	if err != nil {
+		err := fmt.Errorf("Error creating temporary router: %s", err)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+	s.routerID = router.ID
+
+	_, err = routers.AddInterface(networkClient, router.ID, routers.AddInterfaceOpts{
+		SubnetID: subnet.ID,
+	}).Extract()
+	if err != nil {
+		err := fmt.Errorf("Error adding the temporary subnet to the temporary router: %s", err)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+	// The subnet is deleted with the network, it only needs to be removed
+	// from the router once it was added to it.
+	s.subnetID = subnet.ID
+
+	ui.Message(fmt.Sprintf("Network ID: %s", network.ID))
+	state.Put("networks", []servers.Network{{UUID: network.ID}})
+	return multistep.ActionContinue
+}
+
+func (s *StepCreateTemporaryNetwork) Cleanup(state multistep.StateBag) {
+	if s.networkID == "" {
+		return
+	}
+
+	config := state.Get("config").(*Config)
+	ui := state.Get("ui").(packer.Ui)
+
+	networkClient, err := config.networkV2Client()
+	if err != nil {
+		ui.Error(fmt.Sprintf(
+			"Error cleaning up temporary network. Please delete the network manually: %s", s.networkID))
+		return
+	}
+
+	ui.Say("Deleting temporary network...")
+
+	// The ports of the instance and of the floating IP can take a while to be
+	// removed after they're deleted, so deleting the router and the network
+	// is retried for a bit.
+	cfg := retry.Config{
+		Tries:      10,
+		RetryDelay: (&retry.Backoff{InitialBackoff: 2 * time.Second, MaxBackoff: 30 * time.Second, Multiplier: 2}).Linear,
+	}
+
+	if s.routerID != "" {
+		if s.subnetID != "" {
+			err := cfg.Run(context.TODO(), func(context.Context) error {
+				_, err := routers.RemoveInterface(networkClient, s.routerID, routers.RemoveInterfaceOpts{
+					SubnetID: s.subnetID,
+				}).Extract()
+				return err
+			})
+			if err != nil {
+				ui.Error(fmt.Sprintf(
+					"Error removing the temporary subnet from the temporary router %s: %s", s.routerID, err))
+			}
+		}
+
+		err := cfg.Run(context.TODO(), func(context.Context) error {
+			return routers.Delete(networkClient, s.routerID).ExtractErr()
+		})
+		if err != nil {
+			ui.Error(fmt.Sprintf(
+				"Error cleaning up temporary router. Please delete the router manually: %s", s.routerID))
+		}
+	}
+
+	err = cfg.Run(context.TODO(), func(context.Context) error {
+		return networks.Delete(networkClient, s.networkID).ExtractErr()
+	})
+	if err != nil {
+		ui.Error(fmt.Sprintf(
+			"Error cleaning up temporary network. Please delete the network manually: %s", s.networkID))
+	}
+}
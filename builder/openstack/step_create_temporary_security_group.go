@@ -0,0 +1,99 @@
+package openstack
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gophercloud/gophercloud/openstack/networking/v2/extensions/security/groups"
+	"github.com/gophercloud/gophercloud/openstack/networking/v2/extensions/security/rules"
+	"github.com/hashicorp/packer/packer"
+	"github.com/hashicorp/packer/packer-plugin-sdk/multistep"
+	"github.com/hashicorp/packer/packer-plugin-sdk/uuid"
+)
+
+// StepCreateTemporarySecurityGroup creates a security group allowing the
+// communicator to connect to the instance from the given CIDRs. The name of
+// the security group is put in the state as "security_groups", for
+// StepRunSourceServer.
+type StepCreateTemporarySecurityGroup struct {
+	UseTemporarySecurityGroup bool
+	SourceCIDRs               []string
+	CommunicatorPort          int
+
+	securityGroupID string
+}
+
+func (s *StepCreateTemporarySecurityGroup) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	if !s.UseTemporarySecurityGroup {
+		return multistep.ActionContinue
+	}
+
+	config := state.Get("config").(*Config)
+	ui := state.Get("ui").(packer.Ui)
+
+	networkClient, err := config.networkV2Client()
+	if err != nil {
+		err = fmt.Errorf("Error initializing network client: %s", err)
+		state.Put("error", err)
+		return multistep.ActionHalt
+	}
+
+	name := fmt.Sprintf("packer_%s", uuid.TimeOrderedUUID())
+	ui.Say(fmt.Sprintf("Creating temporary security group %s...", name))
+
+	group, err := groups.Create(networkClient, groups.CreateOpts{
+		Name:        name,
+		Description: "Temporary security group created by Packer",
+	}).Extract()
+	if err != nil {
+		err := fmt.Errorf("Error creating temporary security group: %s", err)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+	s.securityGroupID = group.ID
+
+	for _, cidr := range s.SourceCIDRs {
+		_, err := rules.Create(networkClient, rules.CreateOpts{
+			Direction:      rules.DirIngress,
+			EtherType:      rules.EtherType4,
+			SecGroupID:     group.ID,
+			Protocol:       rules.ProtocolTCP,
+			PortRangeMin:   s.CommunicatorPort,
+			PortRangeMax:   s.CommunicatorPort,
+			RemoteIPPrefix: cidr,
+		}).Extract()
+		if err != nil {
+			err := fmt.Errorf("Error allowing %s in the temporary security group: %s", cidr, err)
+			state.Put("error", err)
+			ui.Error(err.Error())
+			return multistep.ActionHalt
+		}
+	}
+
+	ui.Message(fmt.Sprintf("Security group ID: %s", group.ID))
+	state.Put("security_groups", []string{group.Name})
+	return multistep.ActionContinue
+}
+
+func (s *StepCreateTemporarySecurityGroup) Cleanup(state multistep.StateBag) {
+	if s.securityGroupID == "" {
+		return
+	}
+
+	config := state.Get("config").(*Config)
+	ui := state.Get("ui").(packer.Ui)
+
+	networkClient, err := config.networkV2Client()
+	if err != nil {
+		ui.Error(fmt.Sprintf(
+			"Error cleaning up temporary security group. Please delete the security group manually: %s", s.securityGroupID))
+		return
+	}
+
+	ui.Say(fmt.Sprintf("Deleting temporary security group %s...", s.securityGroupID))
+	if err := groups.Delete(networkClient, s.securityGroupID).ExtractErr(); err != nil {
+		ui.Error(fmt.Sprintf(
+			"Error cleaning up temporary security group. Please delete the security group manually: %s", s.securityGroupID))
+	}
+}
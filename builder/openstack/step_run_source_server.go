@@ -51,13 +51,19 @@ func (s *StepRunSourceServer) Run(ctx context.Context, state multistep.StateBag)
 		}
 	}
 
+	// The temporary security group replaces the configured ones.
+	securityGroups := s.SecurityGroups
+	if groups, ok := state.GetOk("security_groups"); ok {
+		securityGroups = groups.([]string)
+	}
+
 	ui.Say("Launching server...")
 
 	serverOpts := servers.CreateOpts{
 		Name:             s.Name,
 		ImageRef:         sourceImage,
 		FlavorRef:        flavor,
-		SecurityGroups:   s.SecurityGroups,
+		SecurityGroups:   securityGroups,
 		Networks:         networks,
 		AvailabilityZone: s.AvailabilityZone,
 		UserData:         userData,
@@ -0,0 +1,85 @@
+package openstack
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	imageservice "github.com/gophercloud/gophercloud/openstack/imageservice/v2/images"
+	"github.com/hashicorp/packer/packer"
+	"github.com/hashicorp/packer/packer-plugin-sdk/multistep"
+)
+
+// stepUpdateImageProperties sets the metadata as properties of the images
+// uploaded from a Block Storage volume, since only some of the image
+// metadata of the volume is copied to the image.
+type stepUpdateImageProperties struct {
+	UseBlockStorageVolume bool
+}
+
+func (s *stepUpdateImageProperties) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	imageId := state.Get("image").(string)
+	ui := state.Get("ui").(packer.Ui)
+	config := state.Get("config").(*Config)
+
+	if !s.UseBlockStorageVolume || len(config.ImageMetadata) == 0 {
+		return multistep.ActionContinue
+	}
+	imageClient, err := config.imageV2Client()
+	if err != nil {
+		err = fmt.Errorf("Error initializing image service client: %s", err)
+		state.Put("error", err)
+		return multistep.ActionHalt
+	}
+
+	image, err := imageservice.Get(imageClient, imageId).Extract()
+	if err != nil {
+		err = fmt.Errorf("Error reading image properties: %s", err)
+		state.Put("error", err)
+		return multistep.ActionHalt
+	}
+
+	ui.Say("Updating image properties...")
+	r := imageservice.Update(
+		imageClient,
+		imageId,
+		imagePropertiesUpdate(image.Properties, config.ImageMetadata),
+	)
+
+	if _, err = r.Extract(); err != nil {
+		err = fmt.Errorf("Error updating image properties: %s", err)
+		state.Put("error", err)
+		return multistep.ActionHalt
+	}
+
+	return multistep.ActionContinue
+}
+
+func (s *stepUpdateImageProperties) Cleanup(multistep.StateBag) {
+	// No cleanup...
+}
+
+// imagePropertiesUpdate returns the operations setting the metadata as
+// properties of an image with the given properties. Glance refuses to add a
+// property that is already set, so those are replaced instead.
+func imagePropertiesUpdate(properties map[string]interface{}, metadata map[string]string) imageservice.UpdateOpts {
+	keys := make([]string, 0, len(metadata))
+	for k := range metadata {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	opts := imageservice.UpdateOpts{}
+	for _, k := range keys {
+		op := imageservice.AddOp
+		if _, ok := properties[k]; ok {
+			op = imageservice.ReplaceOp
+		}
+		opts = append(opts, imageservice.UpdateImageProperty{
+			Op:    op,
+			Name:  k,
+			Value: metadata[k],
+		})
+	}
+	return opts
+}
@@ -0,0 +1,26 @@
+package openstack
+
+import (
+	"reflect"
+	"testing"
+
+	imageservice "github.com/gophercloud/gophercloud/openstack/imageservice/v2/images"
+)
+
+func TestImagePropertiesUpdate(t *testing.T) {
+	properties := map[string]interface{}{
+		"image_type": "image",
+	}
+	metadata := map[string]string{
+		"os_distro":  "ubuntu",
+		"image_type": "image",
+	}
+
+	expected := imageservice.UpdateOpts{
+		imageservice.UpdateImageProperty{Op: imageservice.ReplaceOp, Name: "image_type", Value: "image"},
+		imageservice.UpdateImageProperty{Op: imageservice.AddOp, Name: "os_distro", Value: "ubuntu"},
+	}
+	if actual := imagePropertiesUpdate(properties, metadata); !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("bad: %#v", actual)
+	}
+}
@@ -169,7 +169,7 @@ func (c *Config) Prepare(raws ...interface{}) error {
 		// This check is used to facilitate testing. During testing a Mock struct
 		// is assigned to c.configProvider otherwise testing fails because Instance
 		// Principals cannot be obtained.
-		if c.configProvider == nil {
+		if c.configProvider == nil && !c.ctx.Validate {
 			// Even though the previous configuraion checks might fail we don't want
 			// to skip this step. It seems that the logic behind the checks in this
 			// file is to check everything even getting the configProvider.
@@ -178,10 +178,16 @@ func (c *Config) Prepare(raws ...interface{}) error {
 				return err
 			}
 		}
-		tenancyOCID, err = c.configProvider.TenancyOCID()
-		if err != nil {
-			return err
+		if c.configProvider != nil {
+			tenancyOCID, err = c.configProvider.TenancyOCID()
+			if err != nil {
+				return err
+			}
 		}
+	} else if c.ctx.Validate {
+		// The instance metadata and the API signing key are not read when
+		// the configuration is only validated.
+		log.Println("Skipping the OCI credentials, the configuration is only validated")
 	} else {
 		// Determine where the SDK config is located
 		if c.AccessCfgFile == "" {
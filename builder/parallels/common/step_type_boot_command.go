@@ -12,9 +12,10 @@ import (
 )
 
 type bootCommandTemplateData struct {
-	HTTPIP   string
-	HTTPPort int
-	Name     string
+	HTTPIP    string
+	HTTPPort  int
+	Name      string
+	HTTPToken string
 }
 
 // StepTypeBootCommand is a step that "types" the boot command into the VM via
@@ -70,10 +71,12 @@ func (s *StepTypeBootCommand) Run(ctx context.Context, state multistep.StateBag)
 	ui.Say(fmt.Sprintf("Host IP for the Parallels machine: %s", hostIP))
 
 	state.Put("http_ip", hostIP)
+	httpToken, _ := state.Get("http_token").(string)
 	s.Ctx.Data = &bootCommandTemplateData{
 		hostIP,
 		httpPort,
 		s.VMName,
+		httpToken,
 	}
 
 	sendCodes := func(codes []string) error {
@@ -199,6 +199,7 @@ func (b *Builder) Run(ctx context.Context, ui packer.Ui, hook packer.Hook) (pack
 			ResultKey:   "iso_path",
 			TargetPath:  b.config.TargetPath,
 			Url:         b.config.ISOUrls,
+			Segments:    b.config.ISODownloadSegments,
 		},
 		&parallelscommon.StepOutputDir{
 			Force: b.config.PackerForce,
@@ -210,10 +211,16 @@ func (b *Builder) Run(ctx context.Context, ui packer.Ui, hook packer.Hook) (pack
 			Label:       b.config.FloppyConfig.FloppyLabel,
 		},
 		&commonsteps.StepHTTPServer{
-			HTTPDir:     b.config.HTTPDir,
-			HTTPPortMin: b.config.HTTPPortMin,
-			HTTPPortMax: b.config.HTTPPortMax,
-			HTTPAddress: b.config.HTTPAddress,
+			HTTPDir:      b.config.HTTPDir,
+			HTTPPortMin:  b.config.HTTPPortMin,
+			HTTPPortMax:  b.config.HTTPPortMax,
+			HTTPAddress:  b.config.HTTPAddress,
+			UseTLS:       b.config.HTTPUseTLS,
+			TLSCertFile:  b.config.HTTPTLSCertFile,
+			TLSKeyFile:   b.config.HTTPTLSKeyFile,
+			AuthToken:    b.config.HTTPAuthToken,
+			Templates:    b.config.HTTPTemplates,
+			TemplateVars: b.config.HTTPTemplateVars,
 		},
 		new(stepCreateVM),
 		new(stepCreateDisk),
@@ -254,6 +261,10 @@ func (b *Builder) Run(ctx context.Context, ui packer.Ui, hook packer.Hook) (pack
 		&commonsteps.StepCleanupTempKeys{
 			Comm: &b.config.SSHConfig.Comm,
 		},
+		&commonsteps.StepPauseBeforeShutdown{
+			Duration:    b.config.PauseBeforeShutdown,
+			Interactive: b.config.PauseBeforeShutdownInteractive,
+		},
 		&parallelscommon.StepShutdown{
 			Command: b.config.ShutdownCommand,
 			Timeout: b.config.ShutdownTimeout,
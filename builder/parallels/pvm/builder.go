@@ -100,6 +100,10 @@ func (b *Builder) Run(ctx context.Context, ui packer.Ui, hook packer.Hook) (pack
 			Ctx:                     b.config.ctx,
 		},
 		new(commonsteps.StepProvision),
+		&commonsteps.StepPauseBeforeShutdown{
+			Duration:    b.config.PauseBeforeShutdown,
+			Interactive: b.config.PauseBeforeShutdownInteractive,
+		},
 		&parallelscommon.StepShutdown{
 			Command: b.config.ShutdownCommand,
 			Timeout: b.config.ShutdownTimeout,
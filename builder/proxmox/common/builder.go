@@ -62,10 +62,16 @@ func (b *Builder) Run(ctx context.Context, ui packer.Ui, hook packer.Hook, state
 			vmCreator: b.vmCreator,
 		},
 		&commonsteps.StepHTTPServer{
-			HTTPDir:     b.config.HTTPDir,
-			HTTPPortMin: b.config.HTTPPortMin,
-			HTTPPortMax: b.config.HTTPPortMax,
-			HTTPAddress: b.config.HTTPAddress,
+			HTTPDir:      b.config.HTTPDir,
+			HTTPPortMin:  b.config.HTTPPortMin,
+			HTTPPortMax:  b.config.HTTPPortMax,
+			HTTPAddress:  b.config.HTTPAddress,
+			UseTLS:       b.config.HTTPUseTLS,
+			TLSCertFile:  b.config.HTTPTLSCertFile,
+			TLSKeyFile:   b.config.HTTPTLSKeyFile,
+			AuthToken:    b.config.HTTPAuthToken,
+			Templates:    b.config.HTTPTemplates,
+			TemplateVars: b.config.HTTPTemplateVars,
 		},
 		&stepTypeBootCommand{
 			BootConfig: b.config.BootConfig,
@@ -1,4 +1,4 @@
-//go:generate mapstructure-to-hcl2 -type Config,nicConfig,diskConfig,vgaConfig,storageConfig
+//go:generate mapstructure-to-hcl2 -type Config,nicConfig,diskConfig,vgaConfig,storageConfig,efiConfig,tpmConfig
 
 package proxmox
 
@@ -8,6 +8,7 @@ import (
 	"log"
 	"net/url"
 	"os"
+	"regexp"
 	"strings"
 	"time"
 
@@ -41,6 +42,9 @@ type Config struct {
 	VMID   int    `mapstructure:"vm_id"`
 
 	Boot           string       `mapstructure:"boot"`
+	BIOS           string       `mapstructure:"bios"`
+	EFIConfig      efiConfig    `mapstructure:"efi_config"`
+	TPMConfig      tpmConfig    `mapstructure:"tpm_config"`
 	Memory         int          `mapstructure:"memory"`
 	Cores          int          `mapstructure:"cores"`
 	CPUType        string       `mapstructure:"cpu_type"`
@@ -54,11 +58,13 @@ type Config struct {
 	Onboot         bool         `mapstructure:"onboot"`
 	DisableKVM     bool         `mapstructure:"disable_kvm"`
 
-	TemplateName        string `mapstructure:"template_name"`
-	TemplateDescription string `mapstructure:"template_description"`
+	TemplateName        string   `mapstructure:"template_name"`
+	TemplateDescription string   `mapstructure:"template_description"`
+	TemplateTags        []string `mapstructure:"template_tags"`
 
-	CloudInit            bool   `mapstructure:"cloud_init"`
-	CloudInitStoragePool string `mapstructure:"cloud_init_storage_pool"`
+	CloudInit                 bool   `mapstructure:"cloud_init"`
+	CloudInitStoragePool      string `mapstructure:"cloud_init_storage_pool"`
+	CloudInitFromCommunicator bool   `mapstructure:"cloud_init_from_communicator"`
 
 	AdditionalISOFiles []storageConfig `mapstructure:"additional_iso_files"`
 	VMInterface        string          `mapstructure:"vm_interface"`
@@ -92,11 +98,22 @@ type diskConfig struct {
 	CacheMode       string `mapstructure:"cache_mode"`
 	DiskFormat      string `mapstructure:"format"`
 	IOThread        bool   `mapstructure:"io_thread"`
+	SSD             bool   `mapstructure:"ssd"`
+	Discard         bool   `mapstructure:"discard"`
 }
 type vgaConfig struct {
 	Type   string `mapstructure:"type"`
 	Memory int    `mapstructure:"memory"`
 }
+type efiConfig struct {
+	EFIStoragePool  string `mapstructure:"efi_storage_pool"`
+	EFIType         string `mapstructure:"efi_type"`
+	PreEnrolledKeys bool   `mapstructure:"pre_enrolled_keys"`
+}
+type tpmConfig struct {
+	TPMStoragePool string `mapstructure:"tpm_storage_pool"`
+	Version        string `mapstructure:"tpm_version"`
+}
 
 func (c *Config) Prepare(upper interface{}, raws ...interface{}) ([]string, []string, error) {
 	// Agent defaults to true
@@ -199,6 +216,9 @@ func (c *Config) Prepare(upper interface{}, raws ...interface{}) ([]string, []st
 				}
 			}
 		}
+		if c.Disks[idx].SSD && c.Disks[idx].Type == "virtio" {
+			errs = packer.MultiErrorAppend(errs, fmt.Errorf("disks[%d].ssd is not supported for virtio disks", idx))
+		}
 		// For any storage pool types which aren't in rxStorageTypes in proxmox-api/proxmox/config_qemu.go:890
 		// (currently zfspool|lvm|rbd|cephfs), the format parameter is mandatory. Make sure this is still up to date
 		// when updating the vendored code!
@@ -210,10 +230,60 @@ func (c *Config) Prepare(upper interface{}, raws ...interface{}) ([]string, []st
 		log.Printf("SCSI controller not set, using default 'lsi'")
 		c.SCSIController = "lsi"
 	}
+	if !contains([]string{"lsi", "lsi53c810", "virtio-scsi-pci", "virtio-scsi-single", "megasas", "pvscsi"}, c.SCSIController) {
+		errs = packer.MultiErrorAppend(errs, fmt.Errorf("scsi_controller must be one of lsi, lsi53c810, virtio-scsi-pci, virtio-scsi-single, megasas or pvscsi"))
+	}
+
+	switch c.BIOS {
+	case "", "seabios":
+		if c.EFIConfig.EFIStoragePool != "" {
+			errs = packer.MultiErrorAppend(errs, errors.New("efi_config requires bios to be ovmf"))
+		}
+	case "ovmf":
+		if c.EFIConfig.EFIStoragePool == "" {
+			warnings = append(warnings, "bios is ovmf without efi_config, the EFI settings of the template won't be persisted")
+		}
+	default:
+		errs = packer.MultiErrorAppend(errs, errors.New("bios must be seabios or ovmf"))
+	}
+	if c.EFIConfig.EFIStoragePool != "" {
+		if c.EFIConfig.EFIType == "" {
+			log.Printf("EFI disk type not set, using default '4m'")
+			c.EFIConfig.EFIType = "4m"
+		}
+		if !contains([]string{"2m", "4m"}, c.EFIConfig.EFIType) {
+			errs = packer.MultiErrorAppend(errs, errors.New("efi_config.efi_type must be 2m or 4m"))
+		}
+	}
+	if c.TPMConfig.TPMStoragePool != "" {
+		if c.TPMConfig.Version == "" {
+			log.Printf("TPM version not set, using default 'v2.0'")
+			c.TPMConfig.Version = "v2.0"
+		}
+		if !contains([]string{"v1.2", "v2.0"}, c.TPMConfig.Version) {
+			errs = packer.MultiErrorAppend(errs, errors.New("tpm_config.tpm_version must be v1.2 or v2.0"))
+		}
+	}
+
+	for _, tag := range c.TemplateTags {
+		if !rxTemplateTag.MatchString(tag) {
+			errs = packer.MultiErrorAppend(errs, fmt.Errorf("template tag %q must only contain letters, digits and the characters _-+.", tag))
+		}
+	}
 
 	errs = packer.MultiErrorAppend(errs, c.Comm.Prepare(&c.Ctx)...)
 	errs = packer.MultiErrorAppend(errs, c.BootConfig.Prepare(&c.Ctx)...)
 	errs = packer.MultiErrorAppend(errs, c.HTTPConfig.Prepare(&c.Ctx)...)
+	if c.CloudInitFromCommunicator {
+		if !c.CloudInit {
+			errs = packer.MultiErrorAppend(errs, errors.New("cloud_init_from_communicator requires cloud_init"))
+		}
+		if c.Comm.Type != "ssh" {
+			errs = packer.MultiErrorAppend(errs, errors.New("cloud_init_from_communicator requires the ssh communicator"))
+		} else if c.Comm.SSHPassword == "" && c.Comm.SSHPrivateKeyFile == "" {
+			errs = packer.MultiErrorAppend(errs, errors.New("cloud_init_from_communicator requires ssh_password or ssh_private_key_file"))
+		}
+	}
 
 	// Required configurations that will display errors if not set
 	if c.Username == "" {
@@ -257,6 +327,9 @@ func (c *Config) Prepare(upper interface{}, raws ...interface{}) ([]string, []st
 	return nil, warnings, nil
 }
 
+// rxTemplateTag matches the tags Proxmox accepts
+var rxTemplateTag = regexp.MustCompile(`^[a-zA-Z0-9_][a-zA-Z0-9_\-+.]*$`)
+
 func contains(haystack []string, needle string) bool {
 	for _, candidate := range haystack {
 		if candidate == needle {
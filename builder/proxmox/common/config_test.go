@@ -102,3 +102,121 @@ func TestPacketQueueSupportForNetworkAdapters(t *testing.T) {
 		}
 	}
 }
+
+func TestEFIAndTPMConfig(t *testing.T) {
+	cs := []struct {
+		name           string
+		config         map[string]interface{}
+		expectedToFail bool
+	}{
+		{
+			name: "efi disk and tpm state",
+			config: map[string]interface{}{
+				"bios":       "ovmf",
+				"efi_config": map[string]interface{}{"efi_storage_pool": "local-lvm", "pre_enrolled_keys": true},
+				"tpm_config": map[string]interface{}{"tpm_storage_pool": "local-lvm"},
+			},
+		},
+		{
+			name:           "efi disk requires ovmf",
+			config:         map[string]interface{}{"efi_config": map[string]interface{}{"efi_storage_pool": "local-lvm"}},
+			expectedToFail: true,
+		},
+		{
+			name:           "unknown bios",
+			config:         map[string]interface{}{"bios": "uefi"},
+			expectedToFail: true,
+		},
+		{
+			name: "unknown efi type",
+			config: map[string]interface{}{
+				"bios":       "ovmf",
+				"efi_config": map[string]interface{}{"efi_storage_pool": "local-lvm", "efi_type": "8m"},
+			},
+			expectedToFail: true,
+		},
+		{
+			name:           "unknown tpm version",
+			config:         map[string]interface{}{"tpm_config": map[string]interface{}{"tpm_storage_pool": "local-lvm", "tpm_version": "v3"}},
+			expectedToFail: true,
+		},
+	}
+
+	for _, tc := range cs {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := mandatoryConfig(t)
+			for k, v := range tc.config {
+				cfg[k] = v
+			}
+
+			var c Config
+			_, _, err := c.Prepare(&c, cfg)
+			if tc.expectedToFail && err == nil {
+				t.Fatal("expected config preparation to fail, but no error occured")
+			}
+			if !tc.expectedToFail && err != nil {
+				t.Fatalf("expected config preparation to succeed, but %s", err.Error())
+			}
+			if !tc.expectedToFail {
+				if c.EFIConfig.EFIType != "4m" {
+					t.Errorf("Expected efi_type to default to 4m, got %q", c.EFIConfig.EFIType)
+				}
+				if c.TPMConfig.Version != "v2.0" {
+					t.Errorf("Expected tpm_version to default to v2.0, got %q", c.TPMConfig.Version)
+				}
+			}
+		})
+	}
+}
+
+func TestTemplateTagsAndControllers(t *testing.T) {
+	cs := []struct {
+		name           string
+		config         map[string]interface{}
+		expectedToFail bool
+	}{
+		{name: "valid tags", config: map[string]interface{}{"template_tags": []string{"linux", "fedora-29", "v1.2+build"}}},
+		{name: "tag with a space", config: map[string]interface{}{"template_tags": []string{"my tag"}}, expectedToFail: true},
+		{name: "virtio scsi single", config: map[string]interface{}{"scsi_controller": "virtio-scsi-single"}},
+		{name: "unknown scsi controller", config: map[string]interface{}{"scsi_controller": "virtio"}, expectedToFail: true},
+		{
+			name: "ssd on a virtio disk",
+			config: map[string]interface{}{"disks": []map[string]interface{}{
+				{"type": "virtio", "storage_pool": "local-lvm", "storage_pool_type": "lvm", "ssd": true},
+			}},
+			expectedToFail: true,
+		},
+		{
+			name:   "cloud-init from the communicator",
+			config: map[string]interface{}{"cloud_init": true, "cloud_init_from_communicator": true, "ssh_password": "supersecret"},
+		},
+		{
+			name:           "cloud-init from the communicator requires cloud-init",
+			config:         map[string]interface{}{"cloud_init_from_communicator": true, "ssh_password": "supersecret"},
+			expectedToFail: true,
+		},
+		{
+			name:           "cloud-init from the communicator requires credentials",
+			config:         map[string]interface{}{"cloud_init": true, "cloud_init_from_communicator": true},
+			expectedToFail: true,
+		},
+	}
+
+	for _, tc := range cs {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := mandatoryConfig(t)
+			for k, v := range tc.config {
+				cfg[k] = v
+			}
+
+			var c Config
+			_, _, err := c.Prepare(&c, cfg)
+			if tc.expectedToFail && err == nil {
+				t.Error("expected config preparation to fail, but no error occured")
+			}
+			if !tc.expectedToFail && err != nil {
+				t.Errorf("expected config preparation to succeed, but %s", err.Error())
+			}
+		})
+	}
+}
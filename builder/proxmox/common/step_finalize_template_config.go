@@ -3,11 +3,13 @@ package proxmox
 import (
 	"context"
 	"fmt"
+	"net/url"
 	"strings"
 
 	"github.com/Telmate/proxmox-api-go/proxmox"
 	"github.com/hashicorp/packer/packer"
 	"github.com/hashicorp/packer/packer-plugin-sdk/multistep"
+	"golang.org/x/crypto/ssh"
 )
 
 // stepFinalizeTemplateConfig does any required modifications to the configuration _after_
@@ -38,6 +40,10 @@ func (s *stepFinalizeTemplateConfig) Run(ctx context.Context, state multistep.St
 	// set, we need to clear it
 	changes["description"] = c.TemplateDescription
 
+	if len(c.TemplateTags) > 0 {
+		changes["tags"] = strings.Join(c.TemplateTags, ";")
+	}
+
 	if c.CloudInit {
 		vmParams, err := client.GetVmConfig(vmRef)
 		if err != nil {
@@ -73,6 +79,23 @@ func (s *stepFinalizeTemplateConfig) Run(ctx context.Context, state multistep.St
 				return multistep.ActionHalt
 			}
 		}
+
+		if c.CloudInitFromCommunicator {
+			ui.Say("Configuring cloud-init from the communicator settings")
+			ciChanges, err := cloudInitCommunicatorConfig(c)
+			if err != nil {
+				err := fmt.Errorf("Error configuring cloud-init: %s", err)
+				state.Put("error", err)
+				ui.Error(err.Error())
+				return multistep.ActionHalt
+			}
+			for k, v := range ciChanges {
+				changes[k] = v
+			}
+			if vmParams["ipconfig0"] == nil {
+				changes["ipconfig0"] = "ip=dhcp"
+			}
+		}
 	}
 
 	if len(changes) > 0 {
@@ -88,4 +111,34 @@ func (s *stepFinalizeTemplateConfig) Run(ctx context.Context, state multistep.St
 	return multistep.ActionContinue
 }
 
+// cloudInitCommunicatorConfig returns the cloud-init settings of the template
+// for the user and the credentials the build connected with. Temporary SSH
+// keys are removed at the end of the build, so only the public key of
+// ssh_private_key_file is added.
+func cloudInitCommunicatorConfig(c *Config) (map[string]interface{}, error) {
+	changes := map[string]interface{}{
+		"ciuser": c.Comm.SSHUsername,
+	}
+	if c.Comm.SSHPassword != "" {
+		changes["cipassword"] = c.Comm.SSHPassword
+	}
+	if c.Comm.SSHPrivateKeyFile != "" {
+		privateKey, err := c.Comm.ReadSSHPrivateKeyFile()
+		if err != nil {
+			return nil, err
+		}
+		signer, err := ssh.ParsePrivateKey(privateKey)
+		if err != nil {
+			return nil, fmt.Errorf("Error parsing ssh_private_key_file: %s", err)
+		}
+		// Encoded like the API client encodes the keys of clones
+		sshKeys := url.PathEscape(string(ssh.MarshalAuthorizedKey(signer.PublicKey())))
+		sshKeys = strings.Replace(sshKeys, "+", "%2B", -1)
+		sshKeys = strings.Replace(sshKeys, "@", "%40", -1)
+		sshKeys = strings.Replace(sshKeys, "=", "%3D", -1)
+		changes["sshkeys"] = sshKeys
+	}
+	return changes, nil
+}
+
 func (s *stepFinalizeTemplateConfig) Cleanup(state multistep.StateBag) {}
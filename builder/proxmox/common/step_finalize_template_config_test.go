@@ -3,9 +3,12 @@ package proxmox
 import (
 	"context"
 	"fmt"
+	"os"
+	"strings"
 	"testing"
 
 	"github.com/Telmate/proxmox-api-go/proxmox"
+	"github.com/hashicorp/packer/helper/communicator"
 	"github.com/hashicorp/packer/packer"
 	"github.com/hashicorp/packer/packer-plugin-sdk/multistep"
 )
@@ -89,6 +92,36 @@ func TestTemplateFinalize(t *testing.T) {
 			},
 			expectedAction: multistep.ActionContinue,
 		},
+		{
+			name: "tags and cloud-init from the communicator",
+			builderConfig: &Config{
+				TemplateTags:              []string{"linux", "fedora-29"},
+				CloudInit:                 true,
+				CloudInitFromCommunicator: true,
+				Comm: communicator.Config{
+					SSH: communicator.SSH{
+						SSHUsername: "fedora",
+						SSHPassword: "supersecret",
+					},
+				},
+			},
+			initialVMConfig: map[string]interface{}{
+				"name":        "dummy",
+				"description": "Packer ephemeral build VM",
+				"bootdisk":    "virtio0",
+				"virtio0":     "ceph01:base-223-disk-0,cache=unsafe,media=disk,size=32G",
+			},
+			expectCallSetConfig: true,
+			expectedVMConfig: map[string]interface{}{
+				"tags":       "linux;fedora-29",
+				"ide3":       "ceph01:cloudinit",
+				"ciuser":     "fedora",
+				"cipassword": "supersecret",
+				"ipconfig0":  "ip=dhcp",
+				"sshkeys":    nil,
+			},
+			expectedAction: multistep.ActionContinue,
+		},
 		{
 			name: "no available controller for cloud-init drive",
 			builderConfig: &Config{
@@ -170,3 +203,30 @@ func TestTemplateFinalize(t *testing.T) {
 		})
 	}
 }
+
+func TestCloudInitCommunicatorConfig(t *testing.T) {
+	c := &Config{}
+	c.Comm.SSHUsername = "fedora"
+	c.Comm.SSHPrivateKeyFile = communicator.TestPEM(t)
+	defer os.Remove(c.Comm.SSHPrivateKeyFile)
+
+	changes, err := cloudInitCommunicatorConfig(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if changes["ciuser"] != "fedora" {
+		t.Errorf("Expected ciuser to be fedora, got %v", changes["ciuser"])
+	}
+	if _, ok := changes["cipassword"]; ok {
+		t.Error("Did not expect cipassword to be set")
+	}
+	sshKeys, ok := changes["sshkeys"].(string)
+	if !ok || !strings.HasPrefix(sshKeys, "ssh-rsa%20") || strings.ContainsAny(sshKeys, " +=@\n") {
+		t.Errorf("Expected an encoded ssh-rsa key, got %q", sshKeys)
+	}
+
+	c.Comm.SSHPrivateKeyFile = "does-not-exist"
+	if _, err := cloudInitCommunicatorConfig(c); err == nil {
+		t.Error("Expected a missing ssh_private_key_file to fail")
+	}
+}
@@ -44,6 +44,7 @@ func (s *stepStartVM) Run(ctx context.Context, state multistep.StateBag) multist
 		Agent:        agent,
 		QemuKVM:      kvm,
 		Boot:         c.Boot, // Boot priority, example: "order=virtio0;ide2;net0", virtio0:Disk0 -> ide0:CDROM -> net0:Network
+		Bios:         c.BIOS,
 		QemuCpu:      c.CPUType,
 		Description:  "Packer ephemeral build VM",
 		Memory:       c.Memory,
@@ -97,6 +98,19 @@ func (s *stepStartVM) Run(ctx context.Context, state multistep.StateBag) multist
 	// info available in the vmref type.
 	state.Put("instance_id", vmRef.VmId())
 
+	// The API client doesn't know of EFI disks and TPM states, add them
+	// before the first boot
+	if devices := generateProxmoxEFIAndTPM(c.EFIConfig, c.TPMConfig); len(devices) > 0 {
+		ui.Say("Adding EFI disk and TPM state")
+		_, err = client.SetVmConfig(vmRef, devices)
+		if err != nil {
+			err := fmt.Errorf("Error adding EFI disk and TPM state: %s", err)
+			state.Put("error", err)
+			ui.Error(err.Error())
+			return multistep.ActionHalt
+		}
+	}
+
 	ui.Say("Starting VM")
 	_, err = client.StartVm(vmRef)
 	if err != nil {
@@ -139,9 +153,31 @@ func generateProxmoxDisks(disks []diskConfig) proxmox.QemuDevices {
 		if devs[idx]["type"] == "scsi" || devs[idx]["type"] == "virtio" {
 			setDeviceParamIfDefined(devs[idx], "iothread", strconv.FormatBool(disks[idx].IOThread))
 		}
+		if disks[idx].SSD {
+			devs[idx]["ssd"] = true
+		}
+		if disks[idx].Discard {
+			devs[idx]["discard"] = "on"
+		}
 	}
 	return devs
 }
+func generateProxmoxEFIAndTPM(efi efiConfig, tpm tpmConfig) map[string]interface{} {
+	devices := make(map[string]interface{})
+	if efi.EFIStoragePool != "" {
+		// The size of EFI disks is fixed, 1 only allocates a new volume
+		preEnrolledKeys := 0
+		if efi.PreEnrolledKeys {
+			preEnrolledKeys = 1
+		}
+		devices["efidisk0"] = fmt.Sprintf("%s:1,efitype=%s,pre-enrolled-keys=%d",
+			efi.EFIStoragePool, efi.EFIType, preEnrolledKeys)
+	}
+	if tpm.TPMStoragePool != "" {
+		devices["tpmstate0"] = fmt.Sprintf("%s:1,version=%s", tpm.TPMStoragePool, tpm.Version)
+	}
+	return devices
+}
 func generateProxmoxVga(vga vgaConfig) proxmox.QemuDevice {
 	dev := make(proxmox.QemuDevice)
 	setDeviceParamIfDefined(dev, "type", vga.Type)
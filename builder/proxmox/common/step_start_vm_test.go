@@ -106,3 +106,21 @@ func TestCleanupStartVM(t *testing.T) {
 		})
 	}
 }
+
+func TestGenerateProxmoxEFIAndTPM(t *testing.T) {
+	devices := generateProxmoxEFIAndTPM(efiConfig{}, tpmConfig{})
+	if len(devices) != 0 {
+		t.Errorf("Expected no devices, got %v", devices)
+	}
+
+	devices = generateProxmoxEFIAndTPM(
+		efiConfig{EFIStoragePool: "local-lvm", EFIType: "4m", PreEnrolledKeys: true},
+		tpmConfig{TPMStoragePool: "local-lvm", Version: "v2.0"},
+	)
+	if devices["efidisk0"] != "local-lvm:1,efitype=4m,pre-enrolled-keys=1" {
+		t.Errorf("Unexpected efidisk0 %q", devices["efidisk0"])
+	}
+	if devices["tpmstate0"] != "local-lvm:1,version=v2.0" {
+		t.Errorf("Unexpected tpmstate0 %q", devices["tpmstate0"])
+	}
+}
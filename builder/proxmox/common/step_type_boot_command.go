@@ -23,8 +23,9 @@ type stepTypeBootCommand struct {
 }
 
 type bootCommandTemplateData struct {
-	HTTPIP   string
-	HTTPPort int
+	HTTPIP    string
+	HTTPPort  int
+	HTTPToken string
 }
 
 type commandTyper interface {
@@ -68,9 +69,11 @@ func (s *stepTypeBootCommand) Run(ctx context.Context, state multistep.StateBag)
 	}
 
 	state.Put("http_ip", httpIP)
+	httpToken, _ := state.Get("http_token").(string)
 	s.Ctx.Data = &bootCommandTemplateData{
-		HTTPIP:   httpIP,
-		HTTPPort: state.Get("http_port").(int),
+		HTTPIP:    httpIP,
+		HTTPPort:  state.Get("http_port").(int),
+		HTTPToken: httpToken,
 	}
 
 	ui.Say("Typing the boot command")
@@ -41,6 +41,7 @@ func (b *Builder) Run(ctx context.Context, ui packer.Ui, hook packer.Hook) (pack
 			ResultKey:   downloadPathKey,
 			TargetPath:  b.config.TargetPath,
 			Url:         b.config.ISOUrls,
+			Segments:    b.config.ISODownloadSegments,
 		},
 	}
 	for idx := range b.config.AdditionalISOFiles {
@@ -51,6 +52,7 @@ func (b *Builder) Run(ctx context.Context, ui packer.Ui, hook packer.Hook) (pack
 			ResultKey:   b.config.AdditionalISOFiles[idx].DownloadPathKey,
 			TargetPath:  b.config.AdditionalISOFiles[idx].DownloadPathKey,
 			Url:         b.config.AdditionalISOFiles[idx].ISOUrls,
+			Segments:    b.config.AdditionalISOFiles[idx].ISODownloadSegments,
 		})
 	}
 	preSteps = append(preSteps,
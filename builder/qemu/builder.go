@@ -41,6 +41,27 @@ func (b *Builder) Run(ctx context.Context, ui packer.Ui, hook packer.Hook) (pack
 		return nil, fmt.Errorf("Failed creating Qemu driver: %s", err)
 	}
 
+	// When the operating system installed by a previous build is cached,
+	// the machine boots a disk backed by it instead of installing it again.
+	var baseLayer string
+	cachedBaseLayer := false
+	if b.config.BaseLayerCache {
+		baseLayer, err = b.config.baseLayerPath()
+		if err != nil {
+			return nil, fmt.Errorf("Error finding the base layer cache: %s", err)
+		}
+		if _, err := os.Stat(baseLayer); err == nil {
+			ui.Say(fmt.Sprintf("Using cached base layer %s", filepath.Base(baseLayer)))
+			cachedBaseLayer = true
+		} else {
+			ui.Say("No cached base layer, the operating system will be installed and cached")
+		}
+	}
+	var cachedLayer string
+	if cachedBaseLayer {
+		cachedLayer = baseLayer
+	}
+
 	steps := []multistep.Step{}
 	if !b.config.ISOSkipCache {
 		steps = append(steps, &commonsteps.StepDownload{
@@ -50,6 +71,7 @@ func (b *Builder) Run(ctx context.Context, ui packer.Ui, hook packer.Hook) (pack
 			ResultKey:   "iso_path",
 			TargetPath:  b.config.TargetPath,
 			Url:         b.config.ISOUrls,
+			Segments:    b.config.ISODownloadSegments,
 		})
 	} else {
 		steps = append(steps, &stepSetISO{
@@ -59,6 +81,10 @@ func (b *Builder) Run(ctx context.Context, ui packer.Ui, hook packer.Hook) (pack
 	}
 
 	steps = append(steps, new(stepPrepareOutputDir),
+		&stepCreateEFIVars{
+			FirmwareVars: b.config.FirmwareVars,
+			OutputDir:    b.config.OutputDir,
+		},
 		&commonsteps.StepCreateFloppy{
 			Files:       b.config.FloppyConfig.FloppyFiles,
 			Directories: b.config.FloppyConfig.FloppyDirectories,
@@ -77,6 +103,7 @@ func (b *Builder) Run(ctx context.Context, ui packer.Ui, hook packer.Hook) (pack
 			UseBackingFile:     b.config.UseBackingFile,
 			VMName:             b.config.VMName,
 			QemuImgArgs:        b.config.QemuImgArgs,
+			BaseLayer:          cachedLayer,
 		},
 		&stepCopyDisk{
 			DiskImage:      b.config.DiskImage,
@@ -97,10 +124,21 @@ func (b *Builder) Run(ctx context.Context, ui packer.Ui, hook packer.Hook) (pack
 		},
 		new(stepHTTPIPDiscover),
 		&commonsteps.StepHTTPServer{
-			HTTPDir:     b.config.HTTPDir,
-			HTTPPortMin: b.config.HTTPPortMin,
-			HTTPPortMax: b.config.HTTPPortMax,
-			HTTPAddress: b.config.HTTPAddress,
+			HTTPDir:      b.config.HTTPDir,
+			HTTPPortMin:  b.config.HTTPPortMin,
+			HTTPPortMax:  b.config.HTTPPortMax,
+			HTTPAddress:  b.config.HTTPAddress,
+			UseTLS:       b.config.HTTPUseTLS,
+			TLSCertFile:  b.config.HTTPTLSCertFile,
+			TLSKeyFile:   b.config.HTTPTLSKeyFile,
+			AuthToken:    b.config.HTTPAuthToken,
+			Templates:    b.config.HTTPTemplates,
+			TemplateVars: b.config.HTTPTemplateVars,
+		},
+		&communicator.StepSSHKeyPair{
+			Debug:        b.config.PackerDebug,
+			DebugKeyPath: fmt.Sprintf("%s.pem", b.config.PackerBuildName),
+			Comm:         &b.config.CommConfig.Comm,
 		},
 		&stepPortForward{
 			CommunicatorType: b.config.CommConfig.Comm.Type,
@@ -108,28 +146,48 @@ func (b *Builder) Run(ctx context.Context, ui packer.Ui, hook packer.Hook) (pack
 		},
 		new(stepConfigureVNC),
 		&stepRun{
-			DiskImage: b.config.DiskImage,
+			DiskImage: b.config.DiskImage || cachedBaseLayer,
 		},
 		&stepConfigureQMP{
 			QMPSocketPath: b.config.QMPSocketPath,
 		},
-		&stepTypeBootCommand{},
-		&stepWaitGuestAddress{
-			CommunicatorType: b.config.CommConfig.Comm.Type,
-			NetBridge:        b.config.NetBridge,
-			timeout:          b.config.CommConfig.Comm.SSHTimeout,
-		},
-		&communicator.StepConnect{
-			Config:    &b.config.CommConfig.Comm,
-			Host:      commHost(b.config.CommConfig.Comm.Host()),
-			SSHConfig: b.config.CommConfig.Comm.SSHConfigFunc(),
-			SSHPort:   commPort,
-			WinRMPort: commPort,
-		},
+	)
+	if !cachedBaseLayer {
+		steps = append(steps, &stepTypeBootCommand{})
+	}
+	steps = append(steps, b.connectSteps()...)
+	if b.config.BaseLayerCache && !cachedBaseLayer {
+		// Shut the installed operating system down to cache its disk, and
+		// restart it from the disk to provision it
+		steps = append(steps,
+			&stepShutdown{
+				ShutdownTimeout: b.config.ShutdownTimeout,
+				ShutdownCommand: b.config.ShutdownCommand,
+				Comm:            &b.config.CommConfig.Comm,
+			},
+			&stepCacheBaseLayer{
+				OutputDir: b.config.OutputDir,
+				VMName:    b.config.VMName,
+				Path:      baseLayer,
+			},
+			&stepRun{
+				DiskImage: true,
+			},
+			&stepConfigureQMP{
+				QMPSocketPath: b.config.QMPSocketPath,
+			},
+		)
+		steps = append(steps, b.connectSteps()...)
+	}
+	steps = append(steps,
 		new(commonsteps.StepProvision),
 		&commonsteps.StepCleanupTempKeys{
 			Comm: &b.config.CommConfig.Comm,
 		},
+		&commonsteps.StepPauseBeforeShutdown{
+			Duration:    b.config.PauseBeforeShutdown,
+			Interactive: b.config.PauseBeforeShutdownInteractive,
+		},
 		&stepShutdown{
 			ShutdownTimeout: b.config.ShutdownTimeout,
 			ShutdownCommand: b.config.ShutdownCommand,
@@ -141,6 +199,7 @@ func (b *Builder) Run(ctx context.Context, ui packer.Ui, hook packer.Hook) (pack
 			OutputDir:       b.config.OutputDir,
 			SkipCompaction:  b.config.SkipCompaction,
 			VMName:          b.config.VMName,
+			BaseLayer:       cachedBaseLayer,
 			QemuImgArgs:     b.config.QemuImgArgs,
 		},
 	)
@@ -209,6 +268,24 @@ func (b *Builder) Run(ctx context.Context, ui packer.Ui, hook packer.Hook) (pack
 	return artifact, nil
 }
 
+// connectSteps waits for the machine to get an address and connects to it.
+func (b *Builder) connectSteps() []multistep.Step {
+	return []multistep.Step{
+		&stepWaitGuestAddress{
+			CommunicatorType: b.config.CommConfig.Comm.Type,
+			NetBridge:        b.config.NetBridge,
+			timeout:          b.config.CommConfig.Comm.SSHTimeout,
+		},
+		&communicator.StepConnect{
+			Config:    &b.config.CommConfig.Comm,
+			Host:      commHost(b.config.CommConfig.Comm.Host()),
+			SSHConfig: b.config.CommConfig.Comm.SSHConfigFunc(),
+			SSHPort:   commPort,
+			WinRMPort: commPort,
+		},
+	}
+}
+
 func (b *Builder) newDriver(qemuBinary string) (Driver, error) {
 	qemuPath, err := exec.LookPath(qemuBinary)
 	if err != nil {
@@ -37,6 +37,7 @@ var diskInterface = map[string]bool{
 	"scsi":        true,
 	"virtio":      true,
 	"virtio-scsi": true,
+	"nvme":        true,
 }
 
 var diskCache = map[string]bool{
@@ -109,9 +110,11 @@ type Config struct {
 	//  The default is `1` CPU.
 	CpuCount int `mapstructure:"cpus" required:"false"`
 	// The interface to use for the disk. Allowed values include any of `ide`,
-	// `scsi`, `virtio` or `virtio-scsi`^\*. Note also that any boot commands
-	// or kickstart type scripts must have proper adjustments for resulting
-	// device names. The Qemu builder uses `virtio` by default.
+	// `scsi`, `virtio`, `virtio-scsi`^\* or `nvme`. Note also that any boot
+	// commands or kickstart type scripts must have proper adjustments for
+	// resulting device names. The Qemu builder uses `virtio` by default. Use
+	// `nvme` for Windows aarch64 guests, whose installer has no virtio
+	// driver.
 	//
 	// ^\* Please be aware that use of the `scsi` disk interface has been
 	// disabled by Red Hat due to a bug described
@@ -178,10 +181,39 @@ type Config struct {
 	// will force the `skip_compaction` also to be true as well to skip disk
 	// conversion which would render the backing file feature useless.
 	UseBackingFile bool `mapstructure:"use_backing_file" required:"false"`
+	// Cache the disk of the installed operating system as a QCOW2 base layer
+	// in the Packer cache directory, as it is once the `boot_command` ran and
+	// Packer connected to the machine, before the provisioners run. The
+	// next builds with the same base layer key boot a disk backed by the
+	// cached base layer instead of installing the operating system again,
+	// which speeds up the development of the provisioners. The key is made
+	// of `iso_checksum`, `boot_command`, `disk_size`, `disk_interface`,
+	// `machine_type` and `base_layer_cache_key`. Only applicable when
+	// disk_image is false and format is qcow2. Defaults to false.
+	BaseLayerCache bool `mapstructure:"base_layer_cache" required:"false"`
+	// An arbitrary string added to the key of the base layer, to stop using
+	// the cached base layers when something the key is not made of changes,
+	// like the preseed or kickstart file served from `http_directory`.
+	BaseLayerCacheKey string `mapstructure:"base_layer_cache_key" required:"false"`
 	// The type of machine emulation to use. Run your qemu binary with the
 	// flags `-machine help` to list available types for your system. This
-	// defaults to `pc`.
+	// defaults to `virt` for aarch64 guests, and to `pc` otherwise.
 	MachineType string `mapstructure:"machine_type" required:"false"`
+	// The UEFI firmware to boot the virtual machine with, like
+	// `OVMF_CODE.fd` for x86_64 guests. aarch64 guests need a UEFI firmware,
+	// so the EDK2 firmware shipped with QEMU or installed by the packages of
+	// the distribution is used by default for them. Firmware of 64 MiB, the
+	// size of the flash of the aarch64 `virt` machine, is loaded in flash
+	// along with a variable store; other firmware of aarch64 guests is
+	// loaded with `-bios`. Unset by default for the other guests, which boot
+	// the BIOS of QEMU.
+	Firmware string `mapstructure:"firmware" required:"false"`
+	// The template of the UEFI variable store of `firmware`, which is copied
+	// to `efivars.fd` in the output directory so that the boot entries
+	// created by the installation are kept. Defaults to the template found
+	// along with the default firmware of aarch64 guests. When unset, only
+	// the firmware is loaded.
+	FirmwareVars string `mapstructure:"firmware_vars" required:"false"`
 	// The amount of memory to use when building the VM
 	// in megabytes. This defaults to 512 megabytes.
 	MemorySize int `mapstructure:"memory" required:"false"`
@@ -376,21 +408,36 @@ type Config struct {
 	// default this is 5900 to 6000. The minimum and maximum ports are inclusive.
 	VNCPortMin int `mapstructure:"vnc_port_min" required:"false"`
 	VNCPortMax int `mapstructure:"vnc_port_max"`
+	// Read the screen for the `<waitFor 'regexp'>` expressions of the
+	// `boot_command` from screenshots taken over VNC, with
+	// [tesseract](https://github.com/tesseract-ocr/tesseract), which must be
+	// in the PATH. Defaults to `false`.
+	BootScreenOCR bool `mapstructure:"boot_screen_ocr" required:"false"`
+	// Read the screen for the `<waitFor 'regexp'>` expressions of the
+	// `boot_command` from the first serial port of the VM, which is written
+	// to a temporary file. The boot loader or installer must use the serial
+	// port as its console, and `qemuargs` must not set `-serial`. Defaults to
+	// `false`.
+	BootScreenSerial bool `mapstructure:"boot_screen_serial" required:"false"`
 	// This is the name of the image (QCOW2 or IMG) file for
 	// the new virtual machine. By default this is packer-BUILDNAME, where
 	// "BUILDNAME" is the name of the build. Currently, no file extension will be
 	// used unless it is specified in this option.
 	VMName string `mapstructure:"vm_name" required:"false"`
 	// The interface to use for the CDROM device which contains the ISO image.
-	// Allowed values include any of `ide`, `scsi`, `virtio` or
-	// `virtio-scsi`. The Qemu builder uses `virtio` by default.
-	// Some ARM64 images require `virtio-scsi`.
+	// Allowed values include any of `ide`, `scsi`, `virtio`, `virtio-scsi`
+	// or `usb`. The Qemu builder uses `virtio` by default.
+	// Some ARM64 images require `virtio-scsi`, and the installer of Windows
+	// for ARM64 requires `usb`.
 	CDROMInterface string `mapstructure:"cdrom_interface" required:"false"`
 
 	// TODO(mitchellh): deprecate
 	RunOnce bool `mapstructure:"run_once"`
 
 	ctx interpolate.Context
+	// firmwareBIOS is whether firmware is loaded with -bios instead of in
+	// flash.
+	firmwareBIOS bool
 }
 
 func (c *Config) Prepare(raws ...interface{}) ([]string, error) {
@@ -448,8 +495,17 @@ func (c *Config) Prepare(raws ...interface{}) ([]string, error) {
 		c.DetectZeroes = "off"
 	}
 
+	if c.QemuBinary == "" {
+		c.QemuBinary = "qemu-system-x86_64"
+	}
+	arch := guestArch(c.QemuBinary)
+
 	if c.Accelerator == "" {
-		if runtime.GOOS == "windows" {
+		if arch != hostArch() {
+			// Hardware acceleration only runs guests of the architecture
+			// of the host
+			c.Accelerator = "tcg"
+		} else if runtime.GOOS == "windows" {
 			c.Accelerator = "tcg"
 		} else {
 			// /dev/kvm is a kernel module that may be loaded if kvm is
@@ -470,16 +526,15 @@ func (c *Config) Prepare(raws ...interface{}) ([]string, error) {
 
 	if c.MachineType == "" {
 		c.MachineType = "pc"
+		if arch == "aarch64" {
+			c.MachineType = "virt"
+		}
 	}
 
 	if c.OutputDir == "" {
 		c.OutputDir = fmt.Sprintf("output-%s", c.PackerBuildName)
 	}
 
-	if c.QemuBinary == "" {
-		c.QemuBinary = "qemu-system-x86_64"
-	}
-
 	if c.MemorySize < 10 {
 		log.Printf("MemorySize %d is too small, using default: 512", c.MemorySize)
 		c.MemorySize = 512
@@ -516,6 +571,9 @@ func (c *Config) Prepare(raws ...interface{}) ([]string, error) {
 
 	if c.NetDevice == "" {
 		c.NetDevice = "virtio-net"
+		if arch == "aarch64" {
+			c.NetDevice = "virtio-net-pci"
+		}
 	}
 
 	if c.DiskInterface == "" {
@@ -554,6 +612,40 @@ func (c *Config) Prepare(raws ...interface{}) ([]string, error) {
 		}
 	}
 
+	if c.BaseLayerCache {
+		if c.DiskImage || c.Format != "qcow2" {
+			errs = packer.MultiErrorAppend(
+				errs, errors.New("base_layer_cache can only be enabled for QCOW2 images and when disk_image is false"))
+		}
+		if len(c.AdditionalDiskSize) > 0 {
+			errs = packer.MultiErrorAppend(
+				errs, errors.New("base_layer_cache can't be used with disk_additional_size"))
+		}
+		if c.CommConfig.Comm.Type == "none" {
+			errs = packer.MultiErrorAppend(
+				errs, errors.New("base_layer_cache needs a communicator to know when the operating system is installed"))
+		}
+		if strings.ToLower(c.ISOChecksum) == "none" {
+			errs = packer.MultiErrorAppend(
+				errs, errors.New("base_layer_cache needs an iso_checksum to identify the base layer"))
+		}
+	}
+
+	if arch == "aarch64" {
+		errs = packer.MultiErrorAppend(errs, c.prepareAarch64()...)
+	} else if c.Firmware != "" {
+		if _, err := os.Stat(c.Firmware); err != nil {
+			errs = packer.MultiErrorAppend(
+				errs, fmt.Errorf("firmware %s is not accessible: %s", c.Firmware, err))
+		}
+	}
+	if c.FirmwareVars != "" && !c.firmwareBIOS {
+		if _, err := os.Stat(c.FirmwareVars); err != nil {
+			errs = packer.MultiErrorAppend(
+				errs, fmt.Errorf("firmware_vars %s is not accessible: %s", c.FirmwareVars, err))
+		}
+	}
+
 	if c.DiskImage && len(c.AdditionalDiskSize) > 0 {
 		errs = packer.MultiErrorAppend(
 			errs, errors.New("disk_additional_size can only be used when disk_image is false"))
@@ -597,6 +689,11 @@ func (c *Config) Prepare(raws ...interface{}) ([]string, error) {
 		}
 	}
 
+	if c.BootScreenOCR && c.BootScreenSerial {
+		errs = packer.MultiErrorAppend(
+			errs, fmt.Errorf("only one of boot_screen_ocr and boot_screen_serial can be set"))
+	}
+
 	if c.VNCPortMin > c.VNCPortMax {
 		errs = packer.MultiErrorAppend(
 			errs, fmt.Errorf("vnc_port_min must be less than vnc_port_max"))
@@ -627,3 +724,43 @@ func (c *Config) Prepare(raws ...interface{}) ([]string, error) {
 	return warnings, nil
 
 }
+
+// prepareAarch64 validates the configuration of aarch64 guests, and finds
+// their firmware.
+func (c *Config) prepareAarch64() []error {
+	var errs []error
+
+	if c.MachineType == "pc" || strings.HasPrefix(c.MachineType, "pc-") || strings.HasPrefix(c.MachineType, "q35") {
+		errs = append(errs, fmt.Errorf("machine_type %s is a x86 machine, use virt for aarch64 guests", c.MachineType))
+	}
+	if len(c.FloppyConfig.FloppyFiles) > 0 || len(c.FloppyConfig.FloppyDirectories) > 0 {
+		errs = append(errs, errors.New("the virt machine of aarch64 guests has no floppy drive, use cd_files instead of floppy_files and floppy_dirs"))
+	}
+
+	if c.Firmware == "" {
+		firmware, ok := discoverFirmware("aarch64", c.QemuBinary)
+		if !ok {
+			return append(errs, firmwareNotFoundError("aarch64"))
+		}
+		log.Printf("Using firmware %s and variable store %s", firmware.Code, firmware.Vars)
+		c.Firmware = firmware.Code
+		if c.FirmwareVars == "" {
+			c.FirmwareVars = firmware.Vars
+		}
+	}
+
+	fi, err := os.Stat(c.Firmware)
+	if err != nil {
+		return append(errs, fmt.Errorf("firmware %s is not accessible: %s", c.Firmware, err))
+	}
+	if fi.Size() != aarch64PflashSize {
+		// Like the QEMU_EFI.fd of Debian, which isn't padded to the size of
+		// the flash
+		c.firmwareBIOS = true
+		if c.FirmwareVars != "" {
+			errs = append(errs, fmt.Errorf("firmware %s is not the size of the flash of the virt machine, "+
+				"so it is loaded with -bios and firmware_vars can't be used", c.Firmware))
+		}
+	}
+	return errs
+}
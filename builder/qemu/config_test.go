@@ -252,6 +252,134 @@ func TestBuilderPrepare_Format(t *testing.T) {
 	}
 }
 
+func TestBuilderPrepare_BaseLayerCache(t *testing.T) {
+	var c Config
+	config := testConfig()
+	config["base_layer_cache"] = true
+
+	// Bad: iso_url is a disk_image
+	config["disk_image"] = true
+	c = Config{}
+	if _, err := c.Prepare(config); err == nil {
+		t.Fatal("should have error")
+	}
+
+	// Bad: no communicator
+	config["disk_image"] = false
+	config["communicator"] = "none"
+	c = Config{}
+	if _, err := c.Prepare(config); err == nil {
+		t.Fatal("should have error")
+	}
+
+	// Good
+	delete(config, "communicator")
+	c = Config{}
+	if _, err := c.Prepare(config); err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	key := c.baseLayerKey()
+
+	// The key changes with the boot command
+	config["boot_command"] = []string{"<enter>"}
+	c = Config{}
+	if _, err := c.Prepare(config); err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	if c.baseLayerKey() == key {
+		t.Fatal("the base layer key should change with the boot command")
+	}
+	key = c.baseLayerKey()
+
+	// and with the user provided key
+	config["base_layer_cache_key"] = "preseed-v2"
+	c = Config{}
+	if _, err := c.Prepare(config); err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	if c.baseLayerKey() == key {
+		t.Fatal("the base layer key should change with base_layer_cache_key")
+	}
+}
+
+func TestBuilderPrepare_Aarch64(t *testing.T) {
+	firmware, err := ioutil.TempFile("", "packer-firmware")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer os.Remove(firmware.Name())
+	if err := firmware.Truncate(aarch64PflashSize); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	firmware.Close()
+
+	config := testConfig()
+	config["qemu_binary"] = "qemu-system-aarch64"
+	config["firmware"] = firmware.Name()
+	config["firmware_vars"] = firmware.Name()
+
+	var c Config
+	if _, err := c.Prepare(config); err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	if c.MachineType != "virt" || c.NetDevice != "virtio-net-pci" {
+		t.Fatalf("bad aarch64 defaults: %s, %s", c.MachineType, c.NetDevice)
+	}
+	if c.firmwareBIOS {
+		t.Fatal("the firmware should be loaded in flash")
+	}
+	if hostArch() != "aarch64" && c.Accelerator != "tcg" {
+		t.Fatalf("aarch64 guests can't be accelerated on %s hosts: %s", hostArch(), c.Accelerator)
+	}
+
+	// Bad: x86 machine type
+	config["machine_type"] = "q35"
+	c = Config{}
+	if _, err := c.Prepare(config); err == nil {
+		t.Fatal("should have error")
+	}
+	delete(config, "machine_type")
+
+	// Bad: floppy
+	config["floppy_files"] = []string{firmware.Name()}
+	c = Config{}
+	if _, err := c.Prepare(config); err == nil {
+		t.Fatal("should have error")
+	}
+	delete(config, "floppy_files")
+
+	// Bad: firmware loaded with -bios can't have a variable store
+	if err := os.Truncate(firmware.Name(), 2*1024*1024); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	c = Config{}
+	if _, err := c.Prepare(config); err == nil {
+		t.Fatal("should have error")
+	}
+	delete(config, "firmware_vars")
+	c = Config{}
+	if _, err := c.Prepare(config); err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+	if !c.firmwareBIOS {
+		t.Fatal("the firmware should be loaded with -bios")
+	}
+}
+
+func TestGuestArch(t *testing.T) {
+	for binary, arch := range map[string]string{
+		"":                             "x86_64",
+		"qemu-system-x86_64":           "x86_64",
+		"/usr/bin/qemu-system-aarch64": "aarch64",
+		"qemu-system-aarch64.exe":      "aarch64",
+		"kvm":                          "x86_64",
+	} {
+		if got := guestArch(binary); got != arch {
+			t.Errorf("bad arch of %q: %s", binary, got)
+		}
+	}
+}
+
 func TestBuilderPrepare_UseBackingFile(t *testing.T) {
 	var c Config
 	config := testConfig()
@@ -638,6 +766,24 @@ func TestBuilderPrepare_VNCPassword(t *testing.T) {
 	}
 }
 
+func TestBuilderPrepare_BootScreen(t *testing.T) {
+	var c Config
+	config := testConfig()
+	config["boot_screen_serial"] = true
+
+	_, err := c.Prepare(config)
+	if err != nil {
+		t.Fatalf("should not have error: %s", err)
+	}
+
+	config["boot_screen_ocr"] = true
+	c = Config{}
+	_, err = c.Prepare(config)
+	if err == nil {
+		t.Fatal("should have error")
+	}
+}
+
 func TestCommConfigPrepare_BackwardsCompatibility(t *testing.T) {
 	var c Config
 	config := testConfig()
@@ -0,0 +1,101 @@
+package qemu
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// aarch64PflashSize is the size of the flash devices of the aarch64 virt
+// machine. Firmware of another size is loaded with -bios instead.
+const aarch64PflashSize = 64 * 1024 * 1024
+
+// firmwarePair is a UEFI firmware and the template of its variable store.
+type firmwarePair struct {
+	Code string
+	Vars string
+}
+
+// aarch64Firmware is where the distributions install the EDK2 firmware of
+// aarch64 guests, in order of preference.
+var aarch64Firmware = []firmwarePair{
+	// Debian and Ubuntu, qemu-efi-aarch64 package
+	{"/usr/share/AAVMF/AAVMF_CODE.fd", "/usr/share/AAVMF/AAVMF_VARS.fd"},
+	// Fedora and RHEL, edk2-aarch64 package
+	{"/usr/share/edk2/aarch64/QEMU_EFI-pflash.raw", "/usr/share/edk2/aarch64/vars-template-pflash.raw"},
+	// Firmware shipped with QEMU, like on Arch Linux and openSUSE
+	{"/usr/share/qemu/edk2-aarch64-code.fd", "/usr/share/qemu/edk2-arm-vars.fd"},
+	// Homebrew on Apple silicon and Intel Macs
+	{"/opt/homebrew/share/qemu/edk2-aarch64-code.fd", "/opt/homebrew/share/qemu/edk2-arm-vars.fd"},
+	{"/usr/local/share/qemu/edk2-aarch64-code.fd", "/usr/local/share/qemu/edk2-arm-vars.fd"},
+}
+
+// guestArch returns the architecture emulated by the QEMU binary, from its
+// name.
+func guestArch(qemuBinary string) string {
+	name := strings.TrimSuffix(filepath.Base(qemuBinary), ".exe")
+	if arch := strings.TrimPrefix(name, "qemu-system-"); arch != name && arch != "" {
+		return arch
+	}
+	return "x86_64"
+}
+
+// hostArch returns the architecture of the machine running Packer, named
+// like the QEMU binaries.
+func hostArch() string {
+	switch runtime.GOARCH {
+	case "amd64":
+		return "x86_64"
+	case "arm64":
+		return "aarch64"
+	case "386":
+		return "i386"
+	default:
+		return runtime.GOARCH
+	}
+}
+
+// discoverFirmware looks for the UEFI firmware of the guest architecture,
+// next to the QEMU binary first and in the directories of the
+// distributions then. Only aarch64 guests need UEFI firmware.
+func discoverFirmware(arch string, qemuBinary string) (firmwarePair, bool) {
+	if arch != "aarch64" {
+		return firmwarePair{}, false
+	}
+
+	var candidates []firmwarePair
+	if path, err := exec.LookPath(qemuBinary); err == nil {
+		// QEMU installs its firmware in share/qemu next to its bin
+		// directory, or in share next to the binary on Windows
+		dir := filepath.Dir(path)
+		for _, share := range []string{filepath.Join(dir, "..", "share", "qemu"), filepath.Join(dir, "share")} {
+			candidates = append(candidates, firmwarePair{
+				filepath.Join(share, "edk2-aarch64-code.fd"),
+				filepath.Join(share, "edk2-arm-vars.fd"),
+			})
+		}
+	}
+	candidates = append(candidates, aarch64Firmware...)
+
+	for _, c := range candidates {
+		if _, err := os.Stat(c.Code); err != nil {
+			continue
+		}
+		if _, err := os.Stat(c.Vars); err != nil {
+			c.Vars = ""
+		}
+		return c, true
+	}
+	return firmwarePair{}, false
+}
+
+// firmwareNotFoundError explains how to get the firmware of the guest
+// architecture.
+func firmwareNotFoundError(arch string) error {
+	return fmt.Errorf("No UEFI firmware found for %s guests. Install the qemu-efi-aarch64 "+
+		"package on Debian and Ubuntu, or the edk2-aarch64 package on Fedora and RHEL, "+
+		"or set firmware to the path of the firmware", arch)
+}
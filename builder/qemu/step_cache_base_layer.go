@@ -0,0 +1,87 @@
+package qemu
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/hashicorp/packer/packer"
+	"github.com/hashicorp/packer/packer-plugin-sdk/multistep"
+)
+
+// baseLayerKey identifies the installed operating system, from what the
+// installation depends on.
+func (c *Config) baseLayerKey() string {
+	h := sha256.New()
+	for _, v := range []string{
+		c.ISOChecksum,
+		c.FlatBootCommand(),
+		c.DiskSize,
+		c.DiskInterface,
+		c.MachineType,
+		c.BaseLayerCacheKey,
+	} {
+		// Prefix the values with their length so they can't run into
+		// each other
+		fmt.Fprintf(h, "%d:%s", len(v), v)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// baseLayerPath returns the path of the cached base layer of the
+// configuration.
+func (c *Config) baseLayerPath() (string, error) {
+	return packer.CachePath("qemu-base-layers", c.baseLayerKey()+".qcow2")
+}
+
+// This step stores the disk of the machine as the base layer of the next
+// builds, once the operating system is installed and the machine shut down.
+//
+// Uses:
+//   driver Driver
+//   ui     packer.Ui
+//
+// Produces:
+//   <nothing>
+type stepCacheBaseLayer struct {
+	OutputDir string
+	VMName    string
+	Path      string
+}
+
+func (s *stepCacheBaseLayer) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	driver := state.Get("driver").(Driver)
+	ui := state.Get("ui").(packer.Ui)
+
+	ui.Say(fmt.Sprintf("Caching the installed operating system as base layer %s...", filepath.Base(s.Path)))
+	// Convert to a temporary file first, so that an interrupted build
+	// doesn't leave a partial base layer behind.
+	tmp := s.Path + ".tmp"
+	disk := filepath.Join(s.OutputDir, s.VMName)
+	if err := driver.QemuImg("convert", "-O", "qcow2", disk, tmp); err != nil {
+		err := fmt.Errorf("Error caching the base layer: %s", err)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+	if err := os.Rename(tmp, s.Path); err != nil {
+		err := fmt.Errorf("Error caching the base layer: %s", err)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+
+	ui.Say("Restarting the virtual machine to provision it...")
+	return multistep.ActionContinue
+}
+
+func (s *stepCacheBaseLayer) Cleanup(state multistep.StateBag) {
+	tmp := s.Path + ".tmp"
+	if err := os.Remove(tmp); err != nil && !os.IsNotExist(err) {
+		log.Printf("Error removing %s: %s", tmp, err)
+	}
+}
@@ -22,6 +22,9 @@ type stepConvertDisk struct {
 	OutputDir       string
 	SkipCompaction  bool
 	VMName          string
+	// BaseLayer is set when the disk is backed by a cached base layer, so
+	// that the disk is always converted to a standalone image.
+	BaseLayer bool
 
 	QemuImgArgs QemuImgArgs
 }
@@ -32,7 +35,7 @@ func (s *stepConvertDisk) Run(ctx context.Context, state multistep.StateBag) mul
 
 	diskName := s.VMName
 
-	if s.SkipCompaction && !s.DiskCompression {
+	if s.SkipCompaction && !s.DiskCompression && !s.BaseLayer {
 		return multistep.ActionContinue
 	}
 
@@ -21,6 +21,8 @@ type stepCreateDisk struct {
 	UseBackingFile     bool
 	VMName             string
 	QemuImgArgs        QemuImgArgs
+	// BaseLayer is the cached base layer backing the main disk, if any.
+	BaseLayer string
 }
 
 func (s *stepCreateDisk) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
@@ -74,6 +76,8 @@ func (s *stepCreateDisk) buildCreateCommand(path string, size string, i int, sta
 	if s.UseBackingFile && i == 0 {
 		isoPath := state.Get("iso_path").(string)
 		command = append(command, "-b", isoPath)
+	} else if s.BaseLayer != "" && i == 0 {
+		command = append(command, "-b", s.BaseLayer, "-F", "qcow2")
 	}
 
 	// add user-provided convert args
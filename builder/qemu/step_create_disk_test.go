@@ -67,6 +67,15 @@ func Test_buildCreateCommand(t *testing.T) {
 			[]string{"create", "-f", "qcow2", "-foo", "bar", "target.qcow2", "1234M"},
 			"Basic, happy path, backing store set but not at first index, extra args",
 		},
+		{
+			&stepCreateDisk{
+				Format:    "qcow2",
+				BaseLayer: "base.qcow2",
+			},
+			0,
+			[]string{"create", "-f", "qcow2", "-b", "base.qcow2", "-F", "qcow2", "target.qcow2", "1234M"},
+			"Cached base layer",
+		},
 	}
 
 	for _, tc := range testcases {
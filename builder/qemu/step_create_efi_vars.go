@@ -0,0 +1,61 @@
+package qemu
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/hashicorp/packer/packer"
+	"github.com/hashicorp/packer/packer-plugin-sdk/multistep"
+)
+
+// This step copies the template of the UEFI variable store in the output
+// directory, for the firmware to keep the boot entries in it.
+//
+// Produces:
+//   efivars_path string - The path of the variable store.
+type stepCreateEFIVars struct {
+	FirmwareVars string
+	OutputDir    string
+}
+
+func (s *stepCreateEFIVars) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	ui := state.Get("ui").(packer.Ui)
+
+	if s.FirmwareVars == "" {
+		return multistep.ActionContinue
+	}
+
+	path := filepath.Join(s.OutputDir, "efivars.fd")
+	if err := copyFile(s.FirmwareVars, path); err != nil {
+		err := fmt.Errorf("Error creating the UEFI variable store: %s", err)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+	state.Put("efivars_path", path)
+
+	return multistep.ActionContinue
+}
+
+func (s *stepCreateEFIVars) Cleanup(state multistep.StateBag) {}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
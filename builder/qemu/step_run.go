@@ -3,7 +3,9 @@ package qemu
 import (
 	"context"
 	"fmt"
+	"io/ioutil"
 	"log"
+	"os"
 	"path/filepath"
 	"strings"
 
@@ -19,6 +21,7 @@ type stepRun struct {
 
 	atLeastVersion2 bool
 	ui              packer.Ui
+	serialPath      string
 }
 
 func (s *stepRun) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
@@ -43,6 +46,19 @@ func (s *stepRun) Run(ctx context.Context, state multistep.StateBag) multistep.S
 
 	s.atLeastVersion2 = qemuVersion.GreaterThanOrEqual(v2)
 
+	if config.BootScreenSerial {
+		// The boot command reads the screen from the serial console
+		f, err := ioutil.TempFile("", "packer-qemu-serial-")
+		if err != nil {
+			err := fmt.Errorf("Error creating the serial console file: %s", err)
+			s.ui.Error(err.Error())
+			return multistep.ActionHalt
+		}
+		f.Close()
+		s.serialPath = f.Name()
+		state.Put("serial_path", s.serialPath)
+	}
+
 	// Generate the qemu command
 	command, err := s.getCommandArgs(config, state)
 	if err != nil {
@@ -68,6 +84,10 @@ func (s *stepRun) Cleanup(state multistep.StateBag) {
 	if err := driver.Stop(); err != nil {
 		ui.Error(fmt.Sprintf("Error shutting down VM: %s", err))
 	}
+
+	if s.serialPath != "" {
+		os.Remove(s.serialPath)
+	}
 }
 
 func (s *stepRun) getDefaultArgs(config *Config, state multistep.StateBag) map[string]interface{} {
@@ -104,6 +124,20 @@ func (s *stepRun) getDefaultArgs(config *Config, state multistep.StateBag) map[s
 			config.MachineType, config.Accelerator)
 	}
 
+	// aarch64 guests need a 64-bit CPU model, as the default one of the virt
+	// machine is 32-bit
+	if guestArch(config.QemuBinary) == "aarch64" {
+		defaultArgs["-cpu"] = "max"
+		if config.Accelerator == "kvm" || config.Accelerator == "hvf" {
+			defaultArgs["-cpu"] = "host"
+		}
+	}
+
+	// Configure the UEFI firmware
+	if config.Firmware != "" && config.firmwareBIOS {
+		defaultArgs["-bios"] = config.Firmware
+	}
+
 	// Configure "-netdev" arguments
 	defaultArgs["-netdev"] = fmt.Sprintf("bridge,id=user.0,br=%s", config.NetBridge)
 	if config.NetBridge == "" {
@@ -143,6 +177,11 @@ func (s *stepRun) getDefaultArgs(config *Config, state multistep.StateBag) map[s
 		defaultArgs["-smp"] = fmt.Sprintf("cpus=%d,sockets=%d", config.CpuCount, config.CpuCount)
 	}
 
+	// Configure "-serial" for the boot command to read the serial console
+	if serialPath, ok := state.GetOk("serial_path"); ok {
+		defaultArgs["-serial"] = fmt.Sprintf("file:%s", serialPath.(string))
+	}
+
 	// Configure "-fda" floppy disk attachment
 	if floppyPathRaw, ok := state.GetOk("floppy_path"); ok {
 		defaultArgs["-fda"] = floppyPathRaw.(string)
@@ -207,6 +246,26 @@ func (s *stepRun) getDeviceAndDriveArgs(config *Config, state multistep.StateBag
 	vmName := config.VMName
 	imgPath := filepath.Join(config.OutputDir, vmName)
 
+	// The firmware goes in the first flash device, and its variable store
+	// in the second one
+	if config.Firmware != "" && !config.firmwareBIOS {
+		if efiVars, ok := state.GetOk("efivars_path"); ok {
+			driveArgs = append(driveArgs,
+				fmt.Sprintf("if=pflash,format=raw,readonly=on,file=%s", config.Firmware),
+				fmt.Sprintf("if=pflash,format=raw,file=%s", efiVars.(string)))
+		} else {
+			driveArgs = append(driveArgs, fmt.Sprintf("if=pflash,format=raw,file=%s", config.Firmware))
+		}
+	}
+
+	// The virt machine of aarch64 guests has no display nor keyboard by
+	// default, which VNC and the boot command need
+	hasUSB := false
+	if guestArch(config.QemuBinary) == "aarch64" {
+		deviceArgs = append(deviceArgs, "ramfb", "qemu-xhci", "usb-kbd", "usb-tablet")
+		hasUSB = true
+	}
+
 	// Configure virtual hard drives
 	if s.atLeastVersion2 {
 		// We have different things to attach based on whether we are booting
@@ -235,6 +294,10 @@ func (s *stepRun) getDeviceAndDriveArgs(config *Config, state multistep.StateBag
 				deviceArgs = append(deviceArgs, fmt.Sprintf("scsi-hd,bus=scsi0.0,drive=drive%d", i))
 				driveArgumentString = fmt.Sprintf("if=none,file=%s,id=drive%d,cache=%s,discard=%s,format=%s", drivePath, i, config.DiskCache, config.DiskDiscard, config.Format)
 			}
+			if config.DiskInterface == "nvme" {
+				deviceArgs = append(deviceArgs, fmt.Sprintf("nvme,drive=drive%d,serial=drive%d", i, i))
+				driveArgumentString = fmt.Sprintf("if=none,file=%s,id=drive%d,cache=%s,discard=%s,format=%s", drivePath, i, config.DiskCache, config.DiskDiscard, config.Format)
+			}
 			if config.DetectZeroes != "off" {
 				driveArgumentString = fmt.Sprintf("%s,detect-zeroes=%s", driveArgumentString, config.DetectZeroes)
 			}
@@ -266,6 +329,13 @@ func (s *stepRun) getDeviceAndDriveArgs(config *Config, state multistep.StateBag
 		} else if config.CDROMInterface == "virtio-scsi" {
 			driveArgs = append(driveArgs, fmt.Sprintf("file=%s,if=none,index=%d,id=cdrom%d,media=cdrom", cdPath, i, i))
 			deviceArgs = append(deviceArgs, "virtio-scsi-device", fmt.Sprintf("scsi-cd,drive=cdrom%d", i))
+		} else if config.CDROMInterface == "usb" {
+			if !hasUSB {
+				deviceArgs = append(deviceArgs, "qemu-xhci")
+				hasUSB = true
+			}
+			driveArgs = append(driveArgs, fmt.Sprintf("file=%s,if=none,id=cdrom%d,media=cdrom,readonly=on", cdPath, i))
+			deviceArgs = append(deviceArgs, fmt.Sprintf("usb-storage,drive=cdrom%d,removable=on", i))
 		} else {
 			driveArgs = append(driveArgs, fmt.Sprintf("file=%s,if=%s,index=%d,id=cdrom%d,media=cdrom", cdPath, config.CDROMInterface, i, i))
 		}
@@ -396,6 +396,63 @@ func Test_DriveAndDeviceArgs(t *testing.T) {
 			},
 			"virtio interface with disk image",
 		},
+		{
+			&Config{
+				QemuBinary:     "qemu-system-aarch64",
+				Firmware:       "edk2-aarch64-code.fd",
+				DiskImage:      true,
+				DiskInterface:  "nvme",
+				CDROMInterface: "usb",
+
+				OutputDir:    "path_to_output",
+				DiskCache:    "writeback",
+				Format:       "qcow2",
+				DetectZeroes: "off",
+			},
+			map[string]interface{}{
+				"cd_path":      "fake_cd_path.iso",
+				"efivars_path": "path_to_output/efivars.fd",
+			},
+			&stepRun{
+				DiskImage:       true,
+				atLeastVersion2: true,
+				ui:              packer.TestUi(t),
+			},
+			[]string{
+				"-display", "gtk",
+				"-boot", "c",
+				"-cpu", "max",
+				"-drive", "if=pflash,format=raw,readonly=on,file=edk2-aarch64-code.fd",
+				"-drive", "if=pflash,format=raw,file=path_to_output/efivars.fd",
+				"-device", "ramfb",
+				"-device", "qemu-xhci",
+				"-device", "usb-kbd",
+				"-device", "usb-tablet",
+				"-device", "nvme,drive=drive0,serial=drive0",
+				"-drive", "if=none,file=path_to_output,id=drive0,cache=writeback,discard=,format=qcow2",
+				"-drive", "file=fake_cd_path.iso,if=none,id=cdrom0,media=cdrom,readonly=on",
+				"-device", "usb-storage,drive=cdrom0,removable=on",
+			},
+			"aarch64 guest with firmware, nvme disk and usb cdrom",
+		},
+		{
+			&Config{
+				CDROMInterface: "usb",
+			},
+			map[string]interface{}{},
+			&stepRun{
+				atLeastVersion2: true,
+				ui:              packer.TestUi(t),
+			},
+			[]string{
+				"-display", "gtk",
+				"-boot", "once=d",
+				"-device", "qemu-xhci",
+				"-drive", "file=/path/to/test.iso,if=none,id=cdrom0,media=cdrom,readonly=on",
+				"-device", "usb-storage,drive=cdrom0,removable=on",
+			},
+			"usb cdrom adds a USB controller",
+		},
 	}
 	for _, tc := range testcases {
 		state := runTestState(t, &Config{})
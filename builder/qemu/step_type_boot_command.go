@@ -20,6 +20,10 @@ type bootCommandTemplateData struct {
 	HTTPIP   string
 	HTTPPort int
 	Name     string
+	// SSHPublicKey is the SSH public key in OpenSSH authorized_keys format.
+	SSHPublicKey string
+	// HTTPToken is the token the HTTP server serves the files under.
+	HTTPToken string
 }
 
 // This step "types" the boot command into the VM over VNC.
@@ -29,6 +33,7 @@ type bootCommandTemplateData struct {
 //   http_port int
 //   ui     packer.Ui
 //   vnc_port int
+//   serial_path string - The serial console file, read by <waitFor> when set.
 //
 // Produces:
 //   <nothing>
@@ -84,7 +89,13 @@ func (s *stepTypeBootCommand) Run(ctx context.Context, state multistep.StateBag)
 		auth = []vnc.ClientAuth{new(vnc.ClientAuthNone)}
 	}
 
-	c, err := vnc.Client(nc, &vnc.ClientConfig{Auth: auth, Exclusive: false})
+	var screen *bootcommand.VNCScreen
+	clientConfig := &vnc.ClientConfig{Auth: auth, Exclusive: false}
+	if config.BootScreenOCR {
+		screen = bootcommand.NewVNCScreen()
+		clientConfig.ServerMessageCh = screen.Messages
+	}
+	c, err := vnc.Client(nc, clientConfig)
 	if err != nil {
 		err := fmt.Errorf("Error handshaking with VNC: %s", err)
 		state.Put("error", err)
@@ -96,14 +107,24 @@ func (s *stepTypeBootCommand) Run(ctx context.Context, state multistep.StateBag)
 	log.Printf("Connected to VNC desktop: %s", c.DesktopName)
 
 	hostIP := state.Get("http_ip").(string)
+	httpToken, _ := state.Get("http_token").(string)
 	configCtx := config.ctx
 	configCtx.Data = &bootCommandTemplateData{
 		hostIP,
 		httpPort,
 		config.VMName,
+		string(config.CommConfig.Comm.SSHPublicKey),
+		httpToken,
 	}
 
-	d := bootcommand.NewVNCDriver(c, config.VNCConfig.BootKeyInterval)
+	var d bootcommand.BCDriver = bootcommand.NewVNCDriver(c, config.VNCConfig.BootKeyInterval)
+	if screen != nil {
+		screen.Start(c)
+		defer screen.Stop()
+		d = bootcommand.WithScreen(d, &bootcommand.OCRScreen{Screenshot: screen.Screenshot})
+	} else if serialPath, ok := state.GetOk("serial_path"); ok {
+		d = bootcommand.WithScreen(d, &bootcommand.SerialScreen{Path: serialPath.(string)})
+	}
 
 	ui.Say("Typing the boot command over VNC...")
 	command, err := interpolate.Render(config.VNCConfig.FlatBootCommand(), &configCtx)
@@ -302,6 +302,7 @@ func (b *Builder) Run(ctx context.Context, ui packer.Ui, hook packer.Hook) (pack
 			Extension:   "box",
 			ResultKey:   "box_path",
 			Url:         []string{b.config.SourceBox},
+			Segments:    b.config.ISODownloadSegments,
 		})
 	}
 	steps = append(steps,
@@ -47,6 +47,14 @@ type GuestAdditionsConfig struct {
 	//  on the local file system. If it is not available locally, the builder will
 	//  download the proper guest additions ISO from the internet.
 	GuestAdditionsURL string `mapstructure:"guest_additions_url" required:"false"`
+	// Only use the guest additions ISO installed with VirtualBox when it is
+	//  the one of the running version of VirtualBox, as packages of the
+	//  distributions sometimes install another one. The guest additions of
+	//  the running version are downloaded from the VirtualBox website
+	//  otherwise, verified with their published checksum, and cached like the
+	//  ISOs. Has no effect when `guest_additions_url` is set. Defaults to
+	//  `false`.
+	GuestAdditionsMatchVersion bool `mapstructure:"guest_additions_match_version" required:"false"`
 }
 
 func (c *GuestAdditionsConfig) Prepare(communicatorType string) []error {
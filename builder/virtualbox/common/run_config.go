@@ -4,6 +4,7 @@ package common
 
 import (
 	"fmt"
+	"path/filepath"
 
 	"github.com/hashicorp/packer/packer-plugin-sdk/template/interpolate"
 )
@@ -24,6 +25,11 @@ type RunConfig struct {
 	// 6000. The minimum and maximum ports are inclusive.
 	VRDPPortMin int `mapstructure:"vrdp_port_min" required:"false"`
 	VRDPPortMax int `mapstructure:"vrdp_port_max"`
+	// The path of a WebM video file to record the console of the virtual
+	// machine to, which helps debugging boot failures of headless builds.
+	// The recording is finished when the virtual machine stops and is
+	// disabled before exporting it. Not recorded by default.
+	ConsoleRecordingFile string `mapstructure:"console_recording_file" required:"false"`
 }
 
 func (c *RunConfig) Prepare(ctx *interpolate.Context) (errs []error) {
@@ -39,6 +45,16 @@ func (c *RunConfig) Prepare(ctx *interpolate.Context) (errs []error) {
 		c.VRDPPortMax = 6000
 	}
 
+	if c.ConsoleRecordingFile != "" {
+		// VirtualBox resolves relative paths from the directory of the VM
+		path, err := filepath.Abs(c.ConsoleRecordingFile)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("Error resolving console_recording_file: %s", err))
+		} else {
+			c.ConsoleRecordingFile = path
+		}
+	}
+
 	if c.VRDPPortMin > c.VRDPPortMax {
 		errs = append(
 			errs, fmt.Errorf("vrdp_port_min must be less than vrdp_port_max"))
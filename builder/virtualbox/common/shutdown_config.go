@@ -3,6 +3,7 @@
 package common
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/hashicorp/packer/packer-plugin-sdk/template/interpolate"
@@ -37,9 +38,22 @@ type ShutdownConfig struct {
 	// If it's set to true, it will shutdown the VM via power button. It could be a good option
 	// when keeping the machine state is necessary after shutting it down.
 	ACPIShutdown bool `mapstructure:"acpi_shutdown" required:"false"`
+	// The amount of time to wait once provisioning is complete before the
+	// machine is shut down, for example "30m". This gives you the chance to
+	// log in to the machine and inspect it before it is halted and exported,
+	// without adding a provisioner that sleeps. Interrupting the build with
+	// Ctrl-C during the pause cancels it. By default, there is no pause.
+	PauseBeforeShutdown time.Duration `mapstructure:"pause_before_shutdown" required:"false"`
+	// If true, Packer waits for you to press enter once provisioning is
+	// complete before it shuts down the machine, instead of waiting for
+	// `pause_before_shutdown`. This is ignored with `-machine-readable`,
+	// since there is no one to press enter then. This defaults to false.
+	PauseBeforeShutdownInteractive bool `mapstructure:"pause_before_shutdown_interactive" required:"false"`
 }
 
 func (c *ShutdownConfig) Prepare(ctx *interpolate.Context) []error {
+	var errs []error
+
 	if c.ShutdownTimeout == 0 {
 		c.ShutdownTimeout = 5 * time.Minute
 	}
@@ -48,5 +62,13 @@ func (c *ShutdownConfig) Prepare(ctx *interpolate.Context) []error {
 		c.PostShutdownDelay = 2 * time.Second
 	}
 
-	return nil
+	if c.PauseBeforeShutdown < 0 {
+		errs = append(errs, fmt.Errorf("pause_before_shutdown: must be greater than or equal to 0, but defined: %s",
+			c.PauseBeforeShutdown))
+	}
+	if c.PauseBeforeShutdown != 0 && c.PauseBeforeShutdownInteractive {
+		errs = append(errs, fmt.Errorf("pause_before_shutdown can't be used with pause_before_shutdown_interactive."))
+	}
+
+	return errs
 }
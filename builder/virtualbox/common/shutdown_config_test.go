@@ -88,3 +88,36 @@ func TestShutdownConfigPrepare_DisableShutdown(t *testing.T) {
 		t.Fatalf("bad: %t", c.DisableShutdown)
 	}
 }
+
+func TestShutdownConfigPrepare_PauseBeforeShutdown(t *testing.T) {
+	var c *ShutdownConfig
+	var errs []error
+
+	// Test with a bad value
+	c = testShutdownConfig()
+	c.PauseBeforeShutdown = -1 * time.Second
+	errs = c.Prepare(interpolate.NewContext())
+	if len(errs) == 0 {
+		t.Fatalf("should have error")
+	}
+
+	// Test with both pause options
+	c = testShutdownConfig()
+	c.PauseBeforeShutdown = 30 * time.Second
+	c.PauseBeforeShutdownInteractive = true
+	errs = c.Prepare(interpolate.NewContext())
+	if len(errs) == 0 {
+		t.Fatalf("should have error")
+	}
+
+	// Test with a good one
+	c = testShutdownConfig()
+	c.PauseBeforeShutdown = 30 * time.Second
+	errs = c.Prepare(interpolate.NewContext())
+	if len(errs) > 0 {
+		t.Fatalf("err: %#v", errs)
+	}
+	if c.PauseBeforeShutdown != 30*time.Second {
+		t.Fatalf("bad: %s", c.PauseBeforeShutdown)
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"strconv"
+	"strings"
 
 	"github.com/hashicorp/packer/packer"
 	"github.com/hashicorp/packer/packer-plugin-sdk/multistep"
@@ -11,7 +13,7 @@ import (
 )
 
 // This step configures the VM to enable the VRDP server
-// on the guest machine.
+// on the guest machine, and to record its console when asked to.
 //
 // Uses:
 //   driver Driver
@@ -20,10 +22,12 @@ import (
 //
 // Produces:
 // vrdp_port unit - The port that VRDP is configured to listen on.
+// console_recording string - The file the console is recorded to.
 type StepConfigureVRDP struct {
 	VRDPBindAddress string
 	VRDPPortMin     int
 	VRDPPortMax     int
+	RecordingFile   string
 
 	l *net.Listener
 }
@@ -68,6 +72,21 @@ func (s *StepConfigureVRDP) Run(ctx context.Context, state multistep.StateBag) m
 	state.Put("vrdpIp", s.VRDPBindAddress)
 	state.Put("vrdpPort", vrdpPort)
 
+	if s.RecordingFile != "" {
+		command, err := recordingCommand(driver, vmName, true, s.RecordingFile)
+		if err == nil {
+			err = driver.VBoxManage(command...)
+		}
+		if err != nil {
+			err := fmt.Errorf("Error enabling console recording: %s", err)
+			state.Put("error", err)
+			ui.Error(err.Error())
+			return multistep.ActionHalt
+		}
+		ui.Say(fmt.Sprintf("Recording the console to %s", s.RecordingFile))
+		state.Put("console_recording", s.RecordingFile)
+	}
+
 	return multistep.ActionContinue
 }
 
@@ -79,3 +98,27 @@ func (s *StepConfigureVRDP) Cleanup(state multistep.StateBag) {
 		}
 	}
 }
+
+// recordingCommand returns the VBoxManage command enabling or disabling the
+// recording of the console of the VM. VirtualBox 6.0 renamed the video
+// capture options to recording.
+func recordingCommand(driver Driver, vmName string, enable bool, file string) ([]string, error) {
+	version, err := driver.Version()
+	if err != nil {
+		return nil, err
+	}
+	prefix := "--recording"
+	if major, err := strconv.Atoi(strings.SplitN(version, ".", 2)[0]); err == nil && major < 6 {
+		prefix = "--videocap"
+	}
+
+	if !enable {
+		return []string{"modifyvm", vmName, prefix, "off"}, nil
+	}
+	return []string{
+		"modifyvm", vmName,
+		prefix, "on",
+		prefix + "screens", "0",
+		prefix + "file", file,
+	}, nil
+}
@@ -0,0 +1,42 @@
+package common
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRecordingCommand(t *testing.T) {
+	cases := []struct {
+		version  string
+		enable   bool
+		expected []string
+	}{
+		{
+			"6.1.16", true,
+			[]string{"modifyvm", "foo", "--recording", "on", "--recordingscreens", "0", "--recordingfile", "/tmp/foo.webm"},
+		},
+		{
+			"5.2.44", true,
+			[]string{"modifyvm", "foo", "--videocap", "on", "--videocapscreens", "0", "--videocapfile", "/tmp/foo.webm"},
+		},
+		{
+			"6.0.24", false,
+			[]string{"modifyvm", "foo", "--recording", "off"},
+		},
+		{
+			"5.2.44", false,
+			[]string{"modifyvm", "foo", "--videocap", "off"},
+		},
+	}
+
+	for _, tc := range cases {
+		driver := &DriverMock{VersionResult: tc.version}
+		command, err := recordingCommand(driver, "foo", tc.enable, "/tmp/foo.webm")
+		if err != nil {
+			t.Fatalf("%s: err: %s", tc.version, err)
+		}
+		if !reflect.DeepEqual(command, tc.expected) {
+			t.Fatalf("%s: bad: %#v", tc.version, command)
+		}
+	}
+}
@@ -34,7 +34,10 @@ type StepDownloadGuestAdditions struct {
 	GuestAdditionsMode   string
 	GuestAdditionsURL    string
 	GuestAdditionsSHA256 string
-	Ctx                  interpolate.Context
+	// MatchVersion downloads the guest additions of the running version of
+	// VirtualBox when the ISO installed with it is another version.
+	MatchVersion bool
+	Ctx          interpolate.Context
 }
 
 func (s *StepDownloadGuestAdditions) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
@@ -54,6 +57,7 @@ func (s *StepDownloadGuestAdditions) Run(ctx context.Context, state multistep.St
 		state.Put("error", fmt.Errorf("Error reading version for guest additions download: %s", err))
 		return multistep.ActionHalt
 	}
+	vboxVersion := version
 
 	if newVersion, ok := additionsVersionMap[version]; ok {
 		log.Printf("Rewriting guest additions version: %s to %s", version, newVersion)
@@ -86,7 +90,14 @@ func (s *StepDownloadGuestAdditions) Run(ctx context.Context, state multistep.St
 		log.Printf("guest_additions_url is blank; querying driver for iso.")
 		url, err = driver.Iso()
 
-		if err == nil {
+		if err == nil && s.MatchVersion && !guestAdditionsMatch(url, vboxVersion, version) {
+			ui.Say(fmt.Sprintf("Guest additions ISO %s isn't the one of VirtualBox %s, "+
+				"downloading the guest additions of VirtualBox %s", url, vboxVersion, version))
+			url = fmt.Sprintf(
+				"https://download.virtualbox.org/virtualbox/%s/%s",
+				version,
+				additionsName)
+		} else if err == nil {
 			checksumType = "none"
 		} else {
 			ui.Error(err.Error())
@@ -202,3 +213,45 @@ func (s *StepDownloadGuestAdditions) downloadAdditionsSHA256(ctx context.Context
 	return checksum, multistep.ActionContinue
 
 }
+
+// guestAdditionsMatch returns whether the guest additions ISO at path is the
+// one of one of the given versions of VirtualBox, from its volume label.
+func guestAdditionsMatch(path string, versions ...string) bool {
+	isoVersion, err := guestAdditionsVersion(path)
+	if err != nil {
+		log.Printf("Unable to read the version of guest additions ISO %s: %s", path, err)
+		return false
+	}
+	log.Printf("Guest additions ISO %s is version %s", path, isoVersion)
+	for _, v := range versions {
+		if isoVersion == v {
+			return true
+		}
+	}
+	return false
+}
+
+// guestAdditionsVersion reads the version of the guest additions ISO at path
+// from its volume label, like VBox_GAs_6.1.16.
+func guestAdditionsVersion(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	// The primary volume descriptor of ISO 9660 is at sector 16, with the
+	// volume identifier at offset 40
+	descriptor := make([]byte, 72)
+	if _, err := f.ReadAt(descriptor, 16*2048); err != nil {
+		return "", err
+	}
+	if descriptor[0] != 1 || string(descriptor[1:6]) != "CD001" {
+		return "", fmt.Errorf("not an ISO 9660 image")
+	}
+	label := strings.TrimSpace(string(descriptor[40:72]))
+	if !strings.HasPrefix(label, "VBox_GAs_") {
+		return "", fmt.Errorf("unexpected volume label %q", label)
+	}
+	return strings.TrimPrefix(label, "VBox_GAs_"), nil
+}
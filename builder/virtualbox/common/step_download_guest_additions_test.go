@@ -0,0 +1,54 @@
+package common
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func testGuestAdditionsISO(t *testing.T, label string) string {
+	descriptor := make([]byte, 2048)
+	descriptor[0] = 1
+	copy(descriptor[1:], "CD001")
+	copy(descriptor[40:72], label+"                                ")
+
+	dir, err := ioutil.TempDir("", "packer")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	path := filepath.Join(dir, "VBoxGuestAdditions.iso")
+	if err := ioutil.WriteFile(path, append(make([]byte, 16*2048), descriptor...), 0644); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	return path
+}
+
+func TestGuestAdditionsVersion(t *testing.T) {
+	version, err := guestAdditionsVersion(testGuestAdditionsISO(t, "VBox_GAs_6.1.16"))
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if version != "6.1.16" {
+		t.Fatalf("bad: %s", version)
+	}
+
+	if _, err := guestAdditionsVersion(testGuestAdditionsISO(t, "CDROM")); err == nil {
+		t.Fatal("should have error")
+	}
+}
+
+func TestGuestAdditionsMatch(t *testing.T) {
+	path := testGuestAdditionsISO(t, "VBox_GAs_6.1.16")
+	if !guestAdditionsMatch(path, "6.1.16") {
+		t.Fatal("should match")
+	}
+	if guestAdditionsMatch(path, "6.1.18") {
+		t.Fatal("should not match")
+	}
+	if guestAdditionsMatch(filepath.Join(filepath.Dir(path), "missing.iso"), "6.1.16") {
+		t.Fatal("should not match a missing ISO")
+	}
+}
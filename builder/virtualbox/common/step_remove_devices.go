@@ -29,6 +29,22 @@ func (s *StepRemoveDevices) Run(ctx context.Context, state multistep.StateBag) m
 	ui := state.Get("ui").(packer.Ui)
 	vmName := state.Get("vmName").(string)
 
+	// Disable the recording of the console, which would be exported along
+	// with the VM otherwise
+	if _, ok := state.GetOk("console_recording"); ok {
+		ui.Message("Disabling console recording...")
+		command, err := recordingCommand(driver, vmName, false, "")
+		if err == nil {
+			err = driver.VBoxManage(command...)
+		}
+		if err != nil {
+			err := fmt.Errorf("Error disabling console recording: %s", err)
+			state.Put("error", err)
+			ui.Error(err.Error())
+			return multistep.ActionHalt
+		}
+	}
+
 	// Remove the attached floppy disk, if it exists
 	if _, ok := state.GetOk("floppy_path"); ok {
 		ui.Message("Removing floppy drive...")
@@ -2,6 +2,7 @@ package common
 
 import (
 	"context"
+	"reflect"
 	"testing"
 
 	"github.com/hashicorp/packer/packer-plugin-sdk/multistep"
@@ -133,3 +134,27 @@ func TestStepRemoveDevices_floppyPath(t *testing.T) {
 		t.Fatalf("bad: %#v", driver.VBoxManageCalls)
 	}
 }
+
+func TestStepRemoveDevices_consoleRecording(t *testing.T) {
+	state := testState(t)
+	step := new(StepRemoveDevices)
+
+	state.Put("vmName", "foo")
+	state.Put("console_recording", "/tmp/foo.webm")
+
+	driver := state.Get("driver").(*DriverMock)
+	driver.VersionResult = "6.1.16"
+
+	// Test the run
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("bad action: %#v", action)
+	}
+	if _, ok := state.GetOk("error"); ok {
+		t.Fatal("should NOT have error")
+	}
+
+	expected := [][]string{{"modifyvm", "foo", "--recording", "off"}}
+	if !reflect.DeepEqual(driver.VBoxManageCalls, expected) {
+		t.Fatalf("bad: %#v", driver.VBoxManageCalls)
+	}
+}
@@ -28,6 +28,9 @@ type bootCommandTemplateData struct {
 
 	// SSHPublicKey is the SSH public key in OpenSSH authorized_keys format.
 	SSHPublicKey string
+
+	// HTTPToken is the token the HTTP server serves the files under.
+	HTTPToken string
 }
 
 type StepTypeBootCommand struct {
@@ -63,11 +66,13 @@ func (s *StepTypeBootCommand) Run(ctx context.Context, state multistep.StateBag)
 	}
 
 	hostIP := state.Get("http_ip").(string)
+	httpToken, _ := state.Get("http_token").(string)
 	s.Ctx.Data = &bootCommandTemplateData{
 		HTTPIP:       hostIP,
 		HTTPPort:     httpPort,
 		Name:         s.VMName,
 		SSHPublicKey: string(s.Comm.SSHPublicKey),
+		HTTPToken:    httpToken,
 	}
 
 	sendCodes := func(codes []string) error {
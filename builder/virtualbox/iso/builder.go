@@ -242,6 +242,7 @@ func (b *Builder) Run(ctx context.Context, ui packer.Ui, hook packer.Hook) (pack
 			GuestAdditionsMode:   b.config.GuestAdditionsMode,
 			GuestAdditionsURL:    b.config.GuestAdditionsURL,
 			GuestAdditionsSHA256: b.config.GuestAdditionsSHA256,
+			MatchVersion:         b.config.GuestAdditionsMatchVersion,
 			Ctx:                  b.config.ctx,
 		},
 		&commonsteps.StepDownload{
@@ -251,6 +252,7 @@ func (b *Builder) Run(ctx context.Context, ui packer.Ui, hook packer.Hook) (pack
 			ResultKey:   "iso_path",
 			TargetPath:  b.config.TargetPath,
 			Url:         b.config.ISOUrls,
+			Segments:    b.config.ISODownloadSegments,
 		},
 		&commonsteps.StepOutputDir{
 			Force: b.config.PackerForce,
@@ -267,12 +269,18 @@ func (b *Builder) Run(ctx context.Context, ui packer.Ui, hook packer.Hook) (pack
 		},
 		new(vboxcommon.StepHTTPIPDiscover),
 		&commonsteps.StepHTTPServer{
-			HTTPDir:     b.config.HTTPDir,
-			HTTPPortMin: b.config.HTTPPortMin,
-			HTTPPortMax: b.config.HTTPPortMax,
-			HTTPAddress: b.config.HTTPAddress,
+			HTTPDir:      b.config.HTTPDir,
+			HTTPPortMin:  b.config.HTTPPortMin,
+			HTTPPortMax:  b.config.HTTPPortMax,
+			HTTPAddress:  b.config.HTTPAddress,
+			UseTLS:       b.config.HTTPUseTLS,
+			TLSCertFile:  b.config.HTTPTLSCertFile,
+			TLSKeyFile:   b.config.HTTPTLSKeyFile,
+			AuthToken:    b.config.HTTPAuthToken,
+			Templates:    b.config.HTTPTemplates,
+			TemplateVars: b.config.HTTPTemplateVars,
 		},
-		&vboxcommon.StepSshKeyPair{
+		&communicator.StepSSHKeyPair{
 			Debug:        b.config.PackerDebug,
 			DebugKeyPath: fmt.Sprintf("%s.pem", b.config.PackerBuildName),
 			Comm:         &b.config.Comm,
@@ -290,6 +298,7 @@ func (b *Builder) Run(ctx context.Context, ui packer.Ui, hook packer.Hook) (pack
 			VRDPBindAddress: b.config.VRDPBindAddress,
 			VRDPPortMin:     b.config.VRDPPortMin,
 			VRDPPortMax:     b.config.VRDPPortMax,
+			RecordingFile:   b.config.ConsoleRecordingFile,
 		},
 		new(vboxcommon.StepAttachFloppy),
 		&vboxcommon.StepPortForwarding{
@@ -332,6 +341,10 @@ func (b *Builder) Run(ctx context.Context, ui packer.Ui, hook packer.Hook) (pack
 		&commonsteps.StepCleanupTempKeys{
 			Comm: &b.config.CommConfig.Comm,
 		},
+		&commonsteps.StepPauseBeforeShutdown{
+			Duration:    b.config.PauseBeforeShutdown,
+			Interactive: b.config.PauseBeforeShutdownInteractive,
+		},
 		&vboxcommon.StepShutdown{
 			Command:         b.config.ShutdownCommand,
 			Timeout:         b.config.ShutdownTimeout,
@@ -29,9 +29,10 @@ type StepVNCBootCommand struct {
 }
 
 type VNCBootCommandTemplateData struct {
-	HTTPIP   string
-	HTTPPort int
-	Name     string
+	HTTPIP    string
+	HTTPPort  int
+	Name      string
+	HTTPToken string
 }
 
 func (s *StepVNCBootCommand) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
@@ -62,10 +63,12 @@ func (s *StepVNCBootCommand) Run(ctx context.Context, state multistep.StateBag)
 	}
 
 	hostIP := state.Get("http_ip").(string)
+	httpToken, _ := state.Get("http_token").(string)
 	s.Ctx.Data = &VNCBootCommandTemplateData{
-		HTTPIP:   hostIP,
-		HTTPPort: httpPort,
-		Name:     s.VMName,
+		HTTPIP:    hostIP,
+		HTTPPort:  httpPort,
+		Name:      s.VMName,
+		HTTPToken: httpToken,
 	}
 
 	d := bootcommand.NewVNCDriver(conn, s.Config.BootKeyInterval)
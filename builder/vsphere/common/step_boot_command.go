@@ -22,9 +22,10 @@ type BootConfig struct {
 }
 
 type bootCommandTemplateData struct {
-	HTTPIP   string
-	HTTPPort int
-	Name     string
+	HTTPIP    string
+	HTTPPort  int
+	Name      string
+	HTTPToken string
 }
 
 func (c *BootConfig) Prepare(ctx *interpolate.Context) []error {
@@ -68,10 +69,12 @@ func (s *StepBootCommand) Run(ctx context.Context, state multistep.StateBag) mul
 	port := state.Get("http_port").(int)
 	if port > 0 {
 		ip := state.Get("http_ip").(string)
+		httpToken, _ := state.Get("http_token").(string)
 		s.Ctx.Data = &bootCommandTemplateData{
 			ip,
 			port,
 			s.VMName,
+			httpToken,
 		}
 		ui.Say(fmt.Sprintf("HTTP server is working at http://%v:%v/", ip, port))
 	}
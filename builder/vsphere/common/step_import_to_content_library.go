@@ -5,11 +5,13 @@ package common
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"github.com/hashicorp/packer/builder/vsphere/driver"
 	"github.com/hashicorp/packer/packer"
 	"github.com/hashicorp/packer/packer-plugin-sdk/multistep"
 	"github.com/hashicorp/packer/packer-plugin-sdk/template/interpolate"
+	"github.com/vmware/govmomi/vapi/library"
 	"github.com/vmware/govmomi/vapi/vcenter"
 )
 
@@ -30,10 +32,13 @@ type ContentLibraryDestinationConfig struct {
 	// item is necessary, use an OVF template instead by setting the [ovf](#ovf) option as `true`.
 	//
 	Name string `mapstructure:"name"`
-	// Description of the library item that will be created.
-	// This option is not used when importing OVF templates.
-	// Defaults to "Packer imported [vm_name](#vm_name) VM template".
+	// Description of the library item that will be created or updated.
+	// Defaults to "Packer imported [vm_name](#vm_name) VM template" for VM templates.
 	Description string `mapstructure:"description"`
+	// Notes about this version of the template, like what changed since the previous one.
+	// vSphere keeps a single description per library item, so the notes follow [description](#description)
+	// in the description of the item, while the version of the item is incremented on each update.
+	VersionNotes string `mapstructure:"version_notes"`
 	// Cluster onto which the virtual machine template should be placed.
 	// If cluster and resource_pool are both specified, resource_pool must belong to cluster.
 	// If cluster and host are both specified, host must be a member of cluster.
@@ -112,21 +117,27 @@ func (c *ContentLibraryDestinationConfig) Prepare(lc *LocationConfig) []error {
 	return nil
 }
 
+// This step imports the VM to a content library item.
+//
+// Produces:
+//   content_library_item_id string - The ID of the library item.
+//   content_library_item_version string - The version of the library item.
 type StepImportToContentLibrary struct {
 	ContentLibConfig *ContentLibraryDestinationConfig
 }
 
 func (s *StepImportToContentLibrary) Run(_ context.Context, state multistep.StateBag) multistep.StepAction {
 	ui := state.Get("ui").(packer.Ui)
-	vm := state.Get("vm").(*driver.VirtualMachineDriver)
+	vm := state.Get("vm").(driver.VirtualMachine)
+	var item *library.Item
 	var err error
 
 	if s.ContentLibConfig.Ovf {
 		ui.Say(fmt.Sprintf("Importing VM OVF template %s to Content Library...", s.ContentLibConfig.Name))
-		err = s.importOvfTemplate(vm)
+		item, err = s.importOvfTemplate(vm)
 	} else {
 		ui.Say(fmt.Sprintf("Importing VM template %s to Content Library...", s.ContentLibConfig.Name))
-		err = s.importVmTemplate(vm)
+		item, err = s.importVmTemplate(vm)
 	}
 
 	if err != nil {
@@ -135,6 +146,10 @@ func (s *StepImportToContentLibrary) Run(_ context.Context, state multistep.Stat
 		return multistep.ActionHalt
 	}
 
+	ui.Message(fmt.Sprintf("Library item %s is at version %s", item.Name, item.Version))
+	state.Put("content_library_item_id", item.ID)
+	state.Put("content_library_item_version", item.Version)
+
 	if s.ContentLibConfig.Destroy {
 		state.Put("destroy_vm", s.ContentLibConfig.Destroy)
 	}
@@ -142,10 +157,23 @@ func (s *StepImportToContentLibrary) Run(_ context.Context, state multistep.Stat
 	return multistep.ActionContinue
 }
 
-func (s *StepImportToContentLibrary) importOvfTemplate(vm *driver.VirtualMachineDriver) error {
+// description returns the description of the library item, followed by the
+// notes of the version.
+func (s *StepImportToContentLibrary) description() string {
+	parts := []string{}
+	for _, p := range []string{s.ContentLibConfig.Description, s.ContentLibConfig.VersionNotes} {
+		if p != "" {
+			parts = append(parts, p)
+		}
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+func (s *StepImportToContentLibrary) importOvfTemplate(vm driver.VirtualMachine) (*library.Item, error) {
 	ovf := vcenter.OVF{
 		Spec: vcenter.CreateSpec{
-			Name: s.ContentLibConfig.Name,
+			Name:        s.ContentLibConfig.Name,
+			Description: s.description(),
 		},
 		Target: vcenter.LibraryTarget{
 			LibraryID: s.ContentLibConfig.Library,
@@ -154,10 +182,10 @@ func (s *StepImportToContentLibrary) importOvfTemplate(vm *driver.VirtualMachine
 	return vm.ImportOvfToContentLibrary(ovf)
 }
 
-func (s *StepImportToContentLibrary) importVmTemplate(vm *driver.VirtualMachineDriver) error {
+func (s *StepImportToContentLibrary) importVmTemplate(vm driver.VirtualMachine) (*library.Item, error) {
 	template := vcenter.Template{
 		Name:        s.ContentLibConfig.Name,
-		Description: s.ContentLibConfig.Description,
+		Description: s.description(),
 		Library:     s.ContentLibConfig.Library,
 		Placement: &vcenter.Placement{
 			Cluster:      s.ContentLibConfig.Cluster,
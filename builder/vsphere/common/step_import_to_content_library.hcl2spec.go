@@ -12,6 +12,7 @@ type FlatContentLibraryDestinationConfig struct {
 	Library      *string `mapstructure:"library" cty:"library" hcl:"library"`
 	Name         *string `mapstructure:"name" cty:"name" hcl:"name"`
 	Description  *string `mapstructure:"description" cty:"description" hcl:"description"`
+	VersionNotes *string `mapstructure:"version_notes" cty:"version_notes" hcl:"version_notes"`
 	Cluster      *string `mapstructure:"cluster" cty:"cluster" hcl:"cluster"`
 	Folder       *string `mapstructure:"folder" cty:"folder" hcl:"folder"`
 	Host         *string `mapstructure:"host" cty:"host" hcl:"host"`
@@ -36,6 +37,7 @@ func (*FlatContentLibraryDestinationConfig) HCL2Spec() map[string]hcldec.Spec {
 		"library":       &hcldec.AttrSpec{Name: "library", Type: cty.String, Required: false},
 		"name":          &hcldec.AttrSpec{Name: "name", Type: cty.String, Required: false},
 		"description":   &hcldec.AttrSpec{Name: "description", Type: cty.String, Required: false},
+		"version_notes": &hcldec.AttrSpec{Name: "version_notes", Type: cty.String, Required: false},
 		"cluster":       &hcldec.AttrSpec{Name: "cluster", Type: cty.String, Required: false},
 		"folder":        &hcldec.AttrSpec{Name: "folder", Type: cty.String, Required: false},
 		"host":          &hcldec.AttrSpec{Name: "host", Type: cty.String, Required: false},
@@ -0,0 +1,78 @@
+package common
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/packer/builder/vsphere/driver"
+	"github.com/hashicorp/packer/packer-plugin-sdk/multistep"
+)
+
+func TestStepImportToContentLibrary_Ovf(t *testing.T) {
+	vm := new(driver.VirtualMachineMock)
+	errorBuffer := &strings.Builder{}
+	state := basicStateBag(errorBuffer)
+	state.Put("vm", vm)
+
+	step := &StepImportToContentLibrary{
+		ContentLibConfig: &ContentLibraryDestinationConfig{
+			Library:      "library",
+			Name:         "ubuntu",
+			Description:  "Ubuntu 20.04",
+			VersionNotes: "Security updates",
+			Ovf:          true,
+		},
+	}
+	if action := step.Run(context.TODO(), state); action != multistep.ActionContinue {
+		t.Fatalf("bad action: %#v: %s", action, errorBuffer.String())
+	}
+
+	if !vm.ImportOvfToContentLibraryCalled {
+		t.Fatal("should import an OVF template")
+	}
+	spec := vm.ImportOvfToContentLibraryOvf.Spec
+	if spec.Name != "ubuntu" || spec.Description != "Ubuntu 20.04\n\nSecurity updates" {
+		t.Fatalf("bad spec: %#v", spec)
+	}
+	if id := state.Get("content_library_item_id"); id != "item-id" {
+		t.Fatalf("bad item id: %#v", id)
+	}
+	if version := state.Get("content_library_item_version"); version != "2" {
+		t.Fatalf("bad item version: %#v", version)
+	}
+}
+
+func TestStepImportToContentLibrary_VmTemplate(t *testing.T) {
+	vm := new(driver.VirtualMachineMock)
+	errorBuffer := &strings.Builder{}
+	state := basicStateBag(errorBuffer)
+	state.Put("vm", vm)
+
+	step := &StepImportToContentLibrary{
+		ContentLibConfig: &ContentLibraryDestinationConfig{
+			Library:     "library",
+			Name:        "ubuntu-template",
+			Description: "Packer imported ubuntu VM template",
+			Datastore:   "datastore",
+			Destroy:     true,
+		},
+	}
+	if action := step.Run(context.TODO(), state); action != multistep.ActionContinue {
+		t.Fatalf("bad action: %#v: %s", action, errorBuffer.String())
+	}
+
+	if !vm.ImportToContentLibraryCalled {
+		t.Fatal("should import a VM template")
+	}
+	template := vm.ImportToContentLibraryTemplate
+	if template.Description != "Packer imported ubuntu VM template" {
+		t.Fatalf("bad description: %q", template.Description)
+	}
+	if template.VMHomeStorage == nil || template.VMHomeStorage.Datastore != "datastore" {
+		t.Fatalf("bad storage: %#v", template.VMHomeStorage)
+	}
+	if _, ok := state.GetOk("destroy_vm"); !ok {
+		t.Fatal("should destroy the VM")
+	}
+}
@@ -16,6 +16,7 @@ import (
 	"github.com/vmware/govmomi/object"
 	"github.com/vmware/govmomi/ovf"
 	"github.com/vmware/govmomi/property"
+	"github.com/vmware/govmomi/vapi/library"
 	"github.com/vmware/govmomi/vapi/vcenter"
 	"github.com/vmware/govmomi/vim25/mo"
 	"github.com/vmware/govmomi/vim25/types"
@@ -41,8 +42,8 @@ type VirtualMachine interface {
 	WaitForShutdown(ctx context.Context, timeout time.Duration) error
 	CreateSnapshot(name string) error
 	ConvertToTemplate() error
-	ImportOvfToContentLibrary(ovf vcenter.OVF) error
-	ImportToContentLibrary(template vcenter.Template) error
+	ImportOvfToContentLibrary(ovf vcenter.OVF) (*library.Item, error)
+	ImportToContentLibrary(template vcenter.Template) (*library.Item, error)
 	GetDir() (string, error)
 	AddFloppy(imgPath string) error
 	SetBootOrder(order []string) error
@@ -730,18 +731,21 @@ func (vm *VirtualMachineDriver) ConvertToTemplate() error {
 	return vm.vm.MarkAsTemplate(vm.driver.ctx)
 }
 
-func (vm *VirtualMachineDriver) ImportOvfToContentLibrary(ovf vcenter.OVF) error {
+// ImportOvfToContentLibrary creates an OVF template from the VM in a new
+// library item, or updates the library item of the same name, and returns the
+// library item.
+func (vm *VirtualMachineDriver) ImportOvfToContentLibrary(ovf vcenter.OVF) (*library.Item, error) {
 	err := vm.driver.restClient.Login(vm.driver.ctx)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	l, err := vm.driver.FindContentLibraryByName(ovf.Target.LibraryID)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	if l.library.Type != "LOCAL" {
-		return fmt.Errorf("can not deploy a VM to the content library %s of type %s; "+
+		return nil, fmt.Errorf("can not deploy a VM to the content library %s of type %s; "+
 			"the content library must be of type LOCAL", ovf.Target.LibraryID, l.library.Type)
 	}
 
@@ -756,57 +760,65 @@ func (vm *VirtualMachineDriver) ImportOvfToContentLibrary(ovf vcenter.OVF) error
 	ovf.Source.Type = "VirtualMachine"
 
 	vcm := vcenter.NewManager(vm.driver.restClient.client)
-	_, err = vcm.CreateOVF(vm.driver.ctx, ovf)
+	id, err := vcm.CreateOVF(vm.driver.ctx, ovf)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	return vm.driver.restClient.Logout(vm.driver.ctx)
+	return vm.importedLibraryItem(id)
 }
 
-func (vm *VirtualMachineDriver) ImportToContentLibrary(template vcenter.Template) error {
+// ImportToContentLibrary creates a VM template from the VM in a new library
+// item, and returns the library item. Library items holding a VM template
+// can't be updated with another VM.
+func (vm *VirtualMachineDriver) ImportToContentLibrary(template vcenter.Template) (*library.Item, error) {
 	err := vm.driver.restClient.Login(vm.driver.ctx)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	l, err := vm.driver.FindContentLibraryByName(template.Library)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	if l.library.Type != "LOCAL" {
-		return fmt.Errorf("can not deploy a VM to the content library %s of type %s; "+
+		return nil, fmt.Errorf("can not deploy a VM to the content library %s of type %s; "+
 			"the content library must be of type LOCAL", template.Library, l.library.Type)
 	}
 
+	if _, err := vm.driver.FindContentLibraryItem(l.library.ID, template.Name); err == nil {
+		return nil, fmt.Errorf("the library item %s already exists in the content library %s; "+
+			"library items can only be updated with an OVF template", template.Name, template.Library)
+	}
+
 	template.Library = l.library.ID
 	template.SourceVM = vm.vm.Reference().Value
 
 	if template.Placement.Cluster != "" {
 		c, err := vm.driver.FindCluster(template.Placement.Cluster)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		template.Placement.Cluster = c.cluster.Reference().Value
 	}
 	if template.Placement.Folder != "" {
 		f, err := vm.driver.FindFolder(template.Placement.Folder)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		template.Placement.Folder = f.folder.Reference().Value
 	}
 	if template.Placement.Host != "" {
 		h, err := vm.driver.FindHost(template.Placement.Host)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		template.Placement.Host = h.host.Reference().Value
 	}
 	if template.Placement.ResourcePool != "" {
 		rp, err := vm.driver.FindResourcePool(template.Placement.Cluster, template.Placement.Host, template.Placement.ResourcePool)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		template.Placement.ResourcePool = rp.pool.Reference().Value
 	}
@@ -814,18 +826,28 @@ func (vm *VirtualMachineDriver) ImportToContentLibrary(template vcenter.Template
 	if template.VMHomeStorage != nil {
 		d, err := vm.driver.FindDatastore(template.VMHomeStorage.Datastore, template.Placement.Host)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		template.VMHomeStorage.Datastore = d.Reference().Value
 	}
 
 	vcm := vcenter.NewManager(vm.driver.restClient.client)
-	_, err = vcm.CreateTemplate(vm.driver.ctx, template)
+	id, err := vcm.CreateTemplate(vm.driver.ctx, template)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	return vm.driver.restClient.Logout(vm.driver.ctx)
+	return vm.importedLibraryItem(id)
+}
+
+// importedLibraryItem reads the library item a template was imported to,
+// for its version, and ends the session of the import.
+func (vm *VirtualMachineDriver) importedLibraryItem(id string) (*library.Item, error) {
+	item, err := library.NewManager(vm.driver.restClient.client).GetLibraryItem(vm.driver.ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return item, vm.driver.restClient.Logout(vm.driver.ctx)
 }
 
 func (vm *VirtualMachineDriver) GetDir() (string, error) {
@@ -8,6 +8,7 @@ import (
 	"github.com/vmware/govmomi/nfc"
 	"github.com/vmware/govmomi/object"
 	"github.com/vmware/govmomi/ovf"
+	"github.com/vmware/govmomi/vapi/library"
 	"github.com/vmware/govmomi/vapi/vcenter"
 	"github.com/vmware/govmomi/vim25/mo"
 	"github.com/vmware/govmomi/vim25/types"
@@ -37,6 +38,12 @@ type VirtualMachineMock struct {
 	GetDirResponse string
 	GetDirErr      error
 
+	ImportOvfToContentLibraryCalled bool
+	ImportOvfToContentLibraryOvf    vcenter.OVF
+
+	ImportToContentLibraryCalled   bool
+	ImportToContentLibraryTemplate vcenter.Template
+
 	AddFloppyCalled    bool
 	AddFloppyImagePath string
 	AddFloppyErr       error
@@ -143,12 +150,16 @@ func (vm *VirtualMachineMock) ConvertToTemplate() error {
 	return nil
 }
 
-func (vm *VirtualMachineMock) ImportOvfToContentLibrary(ovf vcenter.OVF) error {
-	return nil
+func (vm *VirtualMachineMock) ImportOvfToContentLibrary(ovf vcenter.OVF) (*library.Item, error) {
+	vm.ImportOvfToContentLibraryCalled = true
+	vm.ImportOvfToContentLibraryOvf = ovf
+	return &library.Item{ID: "item-id", Name: ovf.Spec.Name, Version: "2"}, nil
 }
 
-func (vm *VirtualMachineMock) ImportToContentLibrary(template vcenter.Template) error {
-	return nil
+func (vm *VirtualMachineMock) ImportToContentLibrary(template vcenter.Template) (*library.Item, error) {
+	vm.ImportToContentLibraryCalled = true
+	vm.ImportToContentLibraryTemplate = template
+	return &library.Item{ID: "item-id", Name: template.Name, Version: "1"}, nil
 }
 
 func (vm *VirtualMachineMock) GetDir() (string, error) {
@@ -48,6 +48,7 @@ func (b *Builder) Run(ctx context.Context, ui packer.Ui, hook packer.Hook) (pack
 				ResultKey:   "iso_path",
 				TargetPath:  b.config.TargetPath,
 				Url:         b.config.ISOUrls,
+				Segments:    b.config.ISODownloadSegments,
 			},
 			Url:       b.config.ISOUrls,
 			ResultKey: "iso_path",
@@ -93,10 +94,16 @@ func (b *Builder) Run(ctx context.Context, ui packer.Ui, hook packer.Hook) (pack
 			Network: b.config.WaitIpConfig.GetIPNet(),
 		},
 		&commonsteps.StepHTTPServer{
-			HTTPDir:     b.config.HTTPDir,
-			HTTPPortMin: b.config.HTTPPortMin,
-			HTTPPortMax: b.config.HTTPPortMax,
-			HTTPAddress: b.config.HTTPAddress,
+			HTTPDir:      b.config.HTTPDir,
+			HTTPPortMin:  b.config.HTTPPortMin,
+			HTTPPortMax:  b.config.HTTPPortMax,
+			HTTPAddress:  b.config.HTTPAddress,
+			UseTLS:       b.config.HTTPUseTLS,
+			TLSCertFile:  b.config.HTTPTLSCertFile,
+			TLSKeyFile:   b.config.HTTPTLSKeyFile,
+			AuthToken:    b.config.HTTPAuthToken,
+			Templates:    b.config.HTTPTemplates,
+			TemplateVars: b.config.HTTPTemplateVars,
 		},
 		&common.StepRun{
 			Config:   &b.config.RunConfig,
@@ -175,6 +182,11 @@ func (b *Builder) Run(ctx context.Context, ui packer.Ui, hook packer.Hook) (pack
 		VM:        state.Get("vm").(*driver.VirtualMachineDriver),
 		StateData: map[string]interface{}{"generated_data": state.Get("generated_data")},
 	}
+	for _, key := range []string{"content_library_item_id", "content_library_item_version"} {
+		if v, ok := state.GetOk(key); ok {
+			artifact.StateData[key] = v
+		}
+	}
 
 	if b.config.Export != nil {
 		artifact.Outconfig = &b.config.Export.OutputDir
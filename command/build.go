@@ -3,9 +3,11 @@ package command
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"log"
 	"math"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -69,6 +71,7 @@ func (m *Meta) GetConfigFromHCL(cla *MetaArgs) (*hcl2template.PackerConfig, int)
 		BuilderSchemas:          m.CoreConfig.Components.BuilderStore,
 		ProvisionersSchemas:     m.CoreConfig.Components.ProvisionerStore,
 		PostProcessorsSchemas:   m.CoreConfig.Components.PostProcessorStore,
+		DatasourceSchemas:       m.CoreConfig.Components.DatasourceStore,
 	}
 	cfg, diags := parser.Parse(cla.Path, cla.VarFiles, cla.Vars)
 	return cfg, writeDiags(m.Ui, parser.Files(), diags)
@@ -146,6 +149,9 @@ func (c *BuildCommand) RunContext(buildCtx context.Context, cla *BuildArgs) int
 	if ret != 0 {
 		return ret
 	}
+	if ret := c.loadRequiredPlugins(packerStarter, cla.IgnoreLockfile); ret != 0 {
+		return ret
+	}
 	diags := packerStarter.Initialize()
 	ret = writeDiags(c.Ui, nil, diags)
 	if ret != 0 {
@@ -157,13 +163,20 @@ func (c *BuildCommand) RunContext(buildCtx context.Context, cla *BuildArgs) int
 		Except:  cla.Except,
 		Debug:   cla.Debug,
 		Force:   cla.Force,
+		Resume:  cla.Resume,
 		OnError: cla.OnError,
+
+		CleanupTimeout: cla.CleanupTimeout,
 	})
 
 	// here, something could have gone wrong but we still want to run valid
 	// builds.
 	ret = writeDiags(c.Ui, nil, diags)
 
+	if cla.DryRun {
+		return c.showBuildPlans(builds, ret)
+	}
+
 	if cla.Debug {
 		c.Ui.Say("Debug mode enabled. Builds will not be parallelized.")
 	}
@@ -179,9 +192,12 @@ func (c *BuildCommand) RunContext(buildCtx context.Context, cla *BuildArgs) int
 	buildUis := make(map[packer.Build]packer.Ui)
 	for i := range builds {
 		ui := c.Ui
+		_, machineReadable := c.Ui.(*packer.MachineReadableUi)
+		_, jsonEvents := c.Ui.(*packer.JSONEventsUi)
 		if cla.Color {
-			// Only set up UI colors if -machine-readable isn't set.
-			if _, ok := c.Ui.(*packer.MachineReadableUi); !ok {
+			// Only set up UI colors if -machine-readable and -json-events
+			// aren't set.
+			if !machineReadable && !jsonEvents {
 				ui = &packer.ColoredUi{
 					Color: colors[i%len(colors)],
 					Ui:    ui,
@@ -193,8 +209,8 @@ func (c *BuildCommand) RunContext(buildCtx context.Context, cla *BuildArgs) int
 				}
 			}
 		}
-		// Now add timestamps if requested
-		if cla.TimestampUi {
+		// Now add timestamps if requested, events have their own
+		if cla.TimestampUi && !jsonEvents {
 			ui = &packer.TimestampedUi{
 				Ui: ui,
 			}
@@ -203,9 +219,22 @@ func (c *BuildCommand) RunContext(buildCtx context.Context, cla *BuildArgs) int
 		buildUis[builds[i]] = ui
 	}
 
+	var dashboard *packer.Dashboard
+	if cla.Dashboard {
+		dashboard = c.startDashboard(cla, builds, buildUis)
+	}
+
+	traceExporter, err := packer.NewTraceExporter()
+	if err != nil {
+		log.Printf("[WARN] Not exporting traces: %s", err)
+		c.Ui.Say(fmt.Sprintf("Warning: the traces of the builds are not exported: %s", err))
+	}
+
 	log.Printf("Build debug mode: %v", cla.Debug)
 	log.Printf("Force build: %v", cla.Force)
 	log.Printf("On error: %v", cla.OnError)
+	log.Printf("Resume build: %v", cla.Resume)
+	log.Printf("Cleanup timeout: %v", cla.CleanupTimeout)
 
 	// Get the start of the build command
 	buildCommandStart := time.Now()
@@ -221,7 +250,12 @@ func (c *BuildCommand) RunContext(buildCtx context.Context, cla *BuildArgs) int
 		sync.RWMutex
 		m map[string]error
 	}{m: make(map[string]error)}
+	var outputs = struct {
+		sync.Mutex
+		m map[string]map[string]packer.BuildOutput
+	}{m: make(map[string]map[string]packer.BuildOutput)}
 	limitParallel := semaphore.NewWeighted(cla.ParallelBuilds)
+	limitGroups := buildLimitGroups(builds)
 	for i := range builds {
 		if err := buildCtx.Err(); err != nil {
 			log.Println("Interrupted, not going to start any more builds.")
@@ -231,6 +265,7 @@ func (c *BuildCommand) RunContext(buildCtx context.Context, cla *BuildArgs) int
 		b := builds[i]
 		name := b.Name()
 		ui := buildUis[b]
+		dashboardUi, _ := ui.(*packer.DashboardBuild)
 		if err := limitParallel.Acquire(buildCtx, 1); err != nil {
 			ui.Error(fmt.Sprintf("Build '%s' failed to acquire semaphore: %s", name, err))
 			errors.Lock()
@@ -243,13 +278,28 @@ func (c *BuildCommand) RunContext(buildCtx context.Context, cla *BuildArgs) int
 
 		// Run the build in a goroutine
 		go func() {
-			// Get the start of the build
-			buildStart := time.Now()
-
 			defer wg.Done()
 
 			defer limitParallel.Release(1)
 
+			// Wait for the build to be allowed to run within its limits,
+			// without holding up the builds that are subject to other limits
+			release, err := acquireBuildLimits(buildCtx, ui, b, limitGroups)
+			if err != nil {
+				ui.Error(fmt.Sprintf("Build '%s' failed to acquire semaphore: %s", name, err))
+				errors.Lock()
+				errors.m[name] = err
+				errors.Unlock()
+				return
+			}
+			defer release()
+
+			// Get the start of the build
+			buildStart := time.Now()
+			if dashboardUi != nil {
+				dashboardUi.Start()
+			}
+
 			log.Printf("Starting build run: %s", name)
 			runArtifacts, err := b.Run(buildCtx, ui)
 
@@ -257,6 +307,12 @@ func (c *BuildCommand) RunContext(buildCtx context.Context, cla *BuildArgs) int
 			buildEnd := time.Now()
 			buildDuration := buildEnd.Sub(buildStart)
 			fmtBuildDuration := durafmt.Parse(buildDuration).LimitFirstN(2)
+			if dashboardUi != nil {
+				dashboardUi.Done(err)
+			}
+			if traceExporter != nil {
+				exportBuildTrace(traceExporter, b, buildEnd, runArtifacts, err)
+			}
 
 			if err != nil {
 				ui.Error(fmt.Sprintf("Build '%s' errored after %s: %s", name, fmtBuildDuration, err))
@@ -270,6 +326,20 @@ func (c *BuildCommand) RunContext(buildCtx context.Context, cla *BuildArgs) int
 					artifacts.m[name] = runArtifacts
 					artifacts.Unlock()
 				}
+
+				if ob, ok := b.(packer.OutputBuild); ok {
+					buildOutputs, err := ob.Outputs(runArtifacts)
+					if err != nil {
+						ui.Error(fmt.Sprintf("Build '%s' failed to evaluate its outputs: %s", name, err))
+						errors.Lock()
+						errors.m[name] = err
+						errors.Unlock()
+					} else if len(buildOutputs) > 0 {
+						outputs.Lock()
+						outputs.m[name] = buildOutputs
+						outputs.Unlock()
+					}
+				}
 			}
 		}()
 
@@ -289,6 +359,9 @@ func (c *BuildCommand) RunContext(buildCtx context.Context, cla *BuildArgs) int
 	// if it is interrupted.
 	log.Printf("Waiting on builds to complete...")
 	wg.Wait()
+	if dashboard != nil {
+		dashboard.Stop()
+	}
 
 	// Get the duration of the buildCommand command and parse it
 	buildCommandEnd := time.Now()
@@ -296,6 +369,20 @@ func (c *BuildCommand) RunContext(buildCtx context.Context, cla *BuildArgs) int
 	fmtBuildCommandDuration := durafmt.Parse(buildCommandDuration).LimitFirstN(2)
 	c.Ui.Say(fmt.Sprintf("\n==> Wait completed after %s", fmtBuildCommandDuration))
 
+	if cla.Profile || cla.ProfileOutput != "" {
+		profiles := buildProfiles(builds)
+		c.Ui.Say("\n==> Build profiles:")
+		for _, profile := range profiles {
+			c.Ui.Say(formatBuildProfile(profile))
+		}
+		if cla.ProfileOutput != "" {
+			if err := writeBuildProfiles(cla.ProfileOutput, profiles); err != nil {
+				c.Ui.Error(fmt.Sprintf("Error writing the build profiles: %s", err))
+				ret = 1
+			}
+		}
+	}
+
 	if err := buildCtx.Err(); err != nil {
 		c.Ui.Say("Cleanly cancelled builds after being interrupted.")
 		return 1
@@ -366,6 +453,23 @@ func (c *BuildCommand) RunContext(buildCtx context.Context, cla *BuildArgs) int
 		c.Ui.Say("\n==> Builds finished but no artifacts were created.")
 	}
 
+	if len(outputs.m) > 0 {
+		if err := writeBuildOutputs(buildOutputsFile, outputs.m); err != nil {
+			c.Ui.Error(fmt.Sprintf("Error writing the outputs of the builds: %s", err))
+			ret = 1
+		} else {
+			c.Ui.Say(fmt.Sprintf("\n==> Outputs of the builds, written to %s:", buildOutputsFile))
+			names := make([]string, 0, len(outputs.m))
+			for name := range outputs.m {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				c.Ui.Say(formatBuildOutputs(name, outputs.m[name]))
+			}
+		}
+	}
+
 	if len(errors.m) > 0 {
 		// If any errors occurred, exit with a non-zero exit status
 		ret = 1
@@ -374,6 +478,28 @@ func (c *BuildCommand) RunContext(buildCtx context.Context, cla *BuildArgs) int
 	return ret
 }
 
+// showBuildPlans shows what the builds would run instead of running them.
+func (c *BuildCommand) showBuildPlans(builds []packer.Build, ret int) int {
+	c.Ui.Say("==> Dry run, the builds would run:")
+	for _, plan := range buildPlans(builds) {
+		out, err := formatBuildPlan(plan)
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Error showing the plan of build '%s': %s", plan.Build, err))
+			ret = 1
+			continue
+		}
+		if js, err := json.Marshal(plan); err == nil {
+			ui := &packer.TargetedUI{
+				Target: plan.Build,
+				Ui:     c.Ui,
+			}
+			ui.Machine("plan", string(js))
+		}
+		c.Ui.Say(out)
+	}
+	return ret
+}
+
 func (*BuildCommand) Help() string {
 	helpText := `
 Usage: packer build [options] TEMPLATE
@@ -383,14 +509,23 @@ Usage: packer build [options] TEMPLATE
 
 Options:
 
+  -cleanup-timeout=10m          Stop waiting for the cleanup of a step after this long, and report what it may have left behind. (Default: no limit)
   -color=false                  Disable color output. (Default: color)
+  -dashboard                    Show the progress of the builds as a table redrawn in place, instead of their interleaved output.
   -debug                        Debug mode enabled for builds.
+  -dry-run                      Show the configuration of the builders, provisioners and post-processors that would run, without running the builds.
   -except=foo,bar,baz           Run all builds and post-processors other than these.
   -only=foo,bar,baz             Build only the specified builds.
   -force                        Force a build to continue if artifacts exist, deletes existing artifacts.
+  -ignore-lockfile              Load the newest installed versions of the required plugins, without verifying them against the lockfile.
+  -json-events                  Produce a JSON object per line for each event of the builds.
+  -log-level=level              Enable the logs, keeping the lines of this level and above: trace, debug, info, warn or error.
   -machine-readable             Produce machine-readable output.
   -on-error=[cleanup|abort|ask|run-cleanup-provisioner] If the build fails do: clean up (default), abort, ask, or run-cleanup-provisioner.
   -parallel-builds=1            Number of builds to run in parallel. 1 disables parallelization. 0 means no limit (Default: 0)
+  -profile                      Show how long the steps, provisioners and post-processors of the builds took.
+  -profile-output=path          Write how long the parts of the builds took to path as JSON. Implies -profile.
+  -resume                       Save the state of failed builds, and resume builds that failed from where they stopped.
   -timestamp-ui                 Enable prefixing of each ui output with an RFC3339 timestamp.
   -var 'key=value'              Variable for templates, can be used multiple times.
   -var-file=path                JSON or HCL2 file containing user variables.
@@ -409,14 +544,23 @@ func (*BuildCommand) AutocompleteArgs() complete.Predictor {
 
 func (*BuildCommand) AutocompleteFlags() complete.Flags {
 	return complete.Flags{
+		"-cleanup-timeout":  complete.PredictNothing,
 		"-color":            complete.PredictNothing,
+		"-dashboard":        complete.PredictNothing,
 		"-debug":            complete.PredictNothing,
+		"-dry-run":          complete.PredictNothing,
 		"-except":           complete.PredictNothing,
 		"-only":             complete.PredictNothing,
 		"-force":            complete.PredictNothing,
+		"-ignore-lockfile":  complete.PredictNothing,
+		"-json-events":      complete.PredictNothing,
+		"-log-level":        complete.PredictSet("trace", "debug", "info", "warn", "error"),
 		"-machine-readable": complete.PredictNothing,
 		"-on-error":         complete.PredictNothing,
 		"-parallel":         complete.PredictNothing,
+		"-profile":          complete.PredictNothing,
+		"-profile-output":   complete.PredictNothing,
+		"-resume":           complete.PredictNothing,
 		"-timestamp-ui":     complete.PredictNothing,
 		"-var":              complete.PredictNothing,
 		"-var-file":         complete.PredictNothing,
@@ -0,0 +1,40 @@
+package command
+
+import (
+	"os"
+	"time"
+
+	"github.com/hashicorp/packer/packer"
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+// startDashboard replaces the UIs of the builds with the ones of a dashboard
+// drawn to the terminal, and starts it. It returns nil when the dashboard
+// can't be used with the other options or without a terminal.
+func (c *BuildCommand) startDashboard(cla *BuildArgs, builds []packer.Build, buildUis map[packer.Build]packer.Ui) *packer.Dashboard {
+	switch c.Ui.(type) {
+	case *packer.MachineReadableUi, *packer.JSONEventsUi:
+		c.Ui.Error("-dashboard can't be used with -machine-readable or -json-events, it is ignored.")
+		return nil
+	}
+	switch {
+	case cla.Debug:
+		c.Ui.Error("-dashboard can't be used with -debug, it is ignored.")
+		return nil
+	case !terminal.IsTerminal(int(os.Stdout.Fd())):
+		c.Ui.Error("The output isn't a terminal, -dashboard is ignored.")
+		return nil
+	}
+
+	dashboard := &packer.Dashboard{
+		Writer: os.Stdout,
+		Size: func() (int, int, error) {
+			return terminal.GetSize(int(os.Stdout.Fd()))
+		},
+	}
+	for _, b := range builds {
+		buildUis[b] = dashboard.Build(b.Name())
+	}
+	dashboard.Start(os.Stdin, 500*time.Millisecond)
+	return dashboard
+}
@@ -0,0 +1,61 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/hashicorp/packer/packer"
+	"golang.org/x/sync/semaphore"
+)
+
+// buildLimits returns the limits a build is subject to, sorted by name so
+// that all builds acquire them in the same order.
+func buildLimits(b packer.Build) []packer.BuildLimit {
+	lb, ok := b.(packer.LimitedBuild)
+	if !ok {
+		return nil
+	}
+	limits := append([]packer.BuildLimit{}, lb.Limits()...)
+	sort.Slice(limits, func(i, j int) bool { return limits[i].Name < limits[j].Name })
+	return limits
+}
+
+// buildLimitGroups returns a semaphore per limit of the builds.
+func buildLimitGroups(builds []packer.Build) map[string]*semaphore.Weighted {
+	groups := map[string]*semaphore.Weighted{}
+	for _, b := range builds {
+		for _, limit := range buildLimits(b) {
+			if _, found := groups[limit.Name]; !found {
+				groups[limit.Name] = semaphore.NewWeighted(limit.Max)
+			}
+		}
+	}
+	return groups
+}
+
+// acquireBuildLimits blocks until b can run within its limits. It returns a
+// function that releases them once the build is done.
+func acquireBuildLimits(ctx context.Context, ui packer.Ui, b packer.Build, groups map[string]*semaphore.Weighted) (func(), error) {
+	var acquired []*semaphore.Weighted
+	release := func() {
+		for _, sem := range acquired {
+			sem.Release(1)
+		}
+	}
+
+	for _, limit := range buildLimits(b) {
+		sem := groups[limit.Name]
+		if !sem.TryAcquire(1) {
+			ui.Say(fmt.Sprintf("Build '%s' is waiting, at most %d builds of limit %q run at once.",
+				b.Name(), limit.Max, limit.Name))
+			if err := sem.Acquire(ctx, 1); err != nil {
+				release()
+				return nil, err
+			}
+		}
+		acquired = append(acquired, sem)
+	}
+
+	return release, nil
+}
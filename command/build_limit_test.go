@@ -0,0 +1,53 @@
+package command
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/packer/packer"
+)
+
+func TestAcquireBuildLimits(t *testing.T) {
+	hyperv := packer.BuildLimit{Name: "hyperv", Max: 1}
+	first := &packer.CoreBuild{Type: "hyperv-iso.first", ConcurrencyLimits: []packer.BuildLimit{hyperv}}
+	second := &packer.CoreBuild{Type: "hyperv-iso.second", ConcurrencyLimits: []packer.BuildLimit{hyperv}}
+	other := &packer.CoreBuild{Type: "null.other"}
+
+	builds := []packer.Build{first, second, other}
+	groups := buildLimitGroups(builds)
+	if len(groups) != 1 {
+		t.Fatalf("bad: %#v", groups)
+	}
+	ui := packer.TestUi(t)
+
+	release, err := acquireBuildLimits(context.Background(), ui, first, groups)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	// The other build isn't subject to the hyperv limit
+	releaseOther, err := acquireBuildLimits(context.Background(), ui, other, groups)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	releaseOther()
+
+	// The second build waits for the first one
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := acquireBuildLimits(ctx, ui, second, groups); err == nil {
+		t.Fatal("should wait for the first build")
+	}
+
+	release()
+	releaseSecond, err := acquireBuildLimits(context.Background(), ui, second, groups)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	releaseSecond()
+
+	if !groups["hyperv"].TryAcquire(1) {
+		t.Fatal("builds should release their limits")
+	}
+}
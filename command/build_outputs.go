@@ -0,0 +1,51 @@
+package command
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/packer/packer"
+)
+
+// buildOutputsFile is the file packer build writes the outputs of the
+// successful builds to, in the current directory.
+const buildOutputsFile = "packer-outputs.json"
+
+// buildOutputsFileContent is the content of buildOutputsFile.
+type buildOutputsFileContent struct {
+	// Builds are the outputs of each build, by build name.
+	Builds map[string]map[string]packer.BuildOutput `json:"builds"`
+}
+
+// writeBuildOutputs writes the outputs of the builds as JSON to path.
+func writeBuildOutputs(path string, outputs map[string]map[string]packer.BuildOutput) error {
+	out, err := json.MarshalIndent(buildOutputsFileContent{Builds: outputs}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, out, 0644)
+}
+
+// formatBuildOutputs returns the outputs of a build as lines like
+// `--> amazon-ebs.ubuntu: ami_id = "ami-0123"`, without the values of the
+// sensitive outputs.
+func formatBuildOutputs(build string, outputs map[string]packer.BuildOutput) string {
+	names := make([]string, 0, len(outputs))
+	for name := range outputs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		value := string(outputs[name].Value)
+		if outputs[name].Sensitive {
+			value = "<sensitive>"
+		}
+		fmt.Fprintf(&b, "--> %s: %s = %s\n", build, name, value)
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
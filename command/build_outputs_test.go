@@ -0,0 +1,65 @@
+package command
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/hashicorp/packer/packer"
+)
+
+func testBuildOutputs() map[string]packer.BuildOutput {
+	return map[string]packer.BuildOutput{
+		"image_id": {Value: []byte(`"ami-0123"`), Description: "The ID of the AMI."},
+		"password": {Value: []byte(`"secret"`), Sensitive: true},
+		"regions":  {Value: []byte(`["us-east-1","eu-west-1"]`)},
+	}
+}
+
+func TestFormatBuildOutputs(t *testing.T) {
+	expected := `--> amazon-ebs.ubuntu: image_id = "ami-0123"
+--> amazon-ebs.ubuntu: password = <sensitive>
+--> amazon-ebs.ubuntu: regions = ["us-east-1","eu-west-1"]`
+	if diff := cmp.Diff(expected, formatBuildOutputs("amazon-ebs.ubuntu", testBuildOutputs())); diff != "" {
+		t.Fatalf("unexpected outputs: %s", diff)
+	}
+}
+
+func TestWriteBuildOutputs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), buildOutputsFile)
+	outputs := map[string]map[string]packer.BuildOutput{"amazon-ebs.ubuntu": testBuildOutputs()}
+	if err := writeBuildOutputs(path, outputs); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	out, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	expected := `{
+  "builds": {
+    "amazon-ebs.ubuntu": {
+      "image_id": {
+        "value": "ami-0123",
+        "description": "The ID of the AMI.",
+        "sensitive": false
+      },
+      "password": {
+        "value": "secret",
+        "sensitive": true
+      },
+      "regions": {
+        "value": [
+          "us-east-1",
+          "eu-west-1"
+        ],
+        "sensitive": false
+      }
+    }
+  }
+}`
+	if diff := cmp.Diff(expected, string(out)); diff != "" {
+		t.Fatalf("unexpected outputs file: %s", diff)
+	}
+}
@@ -0,0 +1,57 @@
+package command
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/packer/packer"
+)
+
+// buildPlans returns the plans of the builds that can tell theirs.
+func buildPlans(builds []packer.Build) []packer.BuildPlan {
+	plans := []packer.BuildPlan{}
+	for _, b := range builds {
+		if pb, ok := b.(packer.PlannedBuild); ok {
+			plans = append(plans, pb.Plan())
+		}
+	}
+	return plans
+}
+
+// formatBuildPlan returns the components a build would run, with their
+// configuration as indented JSON.
+func formatBuildPlan(plan packer.BuildPlan) (string, error) {
+	var b strings.Builder
+	entry := func(indent, kind string, e packer.PlanEntry) error {
+		config, err := json.MarshalIndent(e.Config, indent+"  ", "  ")
+		if err != nil {
+			return fmt.Errorf("%s %q: %s", kind, e.Type, err)
+		}
+		fmt.Fprintf(&b, "%s%s %q", indent, kind, e.Type)
+		if e.Name != "" {
+			fmt.Fprintf(&b, " (%s)", e.Name)
+		}
+		fmt.Fprintf(&b, ":\n%s  %s\n", indent, config)
+		return nil
+	}
+
+	fmt.Fprintf(&b, "--> %s:\n", plan.Build)
+	if err := entry("    ", "builder", plan.Builder); err != nil {
+		return "", err
+	}
+	for _, p := range plan.Provisioners {
+		if err := entry("    ", "provisioner", p); err != nil {
+			return "", err
+		}
+	}
+	for _, seq := range plan.PostProcessors {
+		b.WriteString("    post-processors:\n")
+		for _, pp := range seq {
+			if err := entry("      ", "post-processor", pp); err != nil {
+				return "", err
+			}
+		}
+	}
+	return b.String(), nil
+}
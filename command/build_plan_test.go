@@ -0,0 +1,49 @@
+package command
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/hashicorp/packer/packer"
+)
+
+func TestFormatBuildPlan(t *testing.T) {
+	plan := packer.BuildPlan{
+		Build: "file.chocolate",
+		Builder: packer.PlanEntry{
+			Type:   "file",
+			Config: map[string]interface{}{"content": "chocolate"},
+		},
+		Provisioners: []packer.PlanEntry{
+			{Type: "shell-local", Name: "greet", Config: map[string]interface{}{"inline": []interface{}{"echo hi"}}},
+		},
+		PostProcessors: [][]packer.PlanEntry{
+			{
+				{Type: "manifest", Config: map[string]interface{}{}},
+			},
+		},
+	}
+
+	expected := `--> file.chocolate:
+    builder "file":
+      {
+        "content": "chocolate"
+      }
+    provisioner "shell-local" (greet):
+      {
+        "inline": [
+          "echo hi"
+        ]
+      }
+    post-processors:
+      post-processor "manifest":
+        {}
+`
+	out, err := formatBuildPlan(plan)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if diff := cmp.Diff(expected, out); diff != "" {
+		t.Fatalf("unexpected plan: %s", diff)
+	}
+}
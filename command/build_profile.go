@@ -0,0 +1,75 @@
+package command
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/packer/packer"
+)
+
+// buildProfiles returns the profiles of the builds that record one.
+func buildProfiles(builds []packer.Build) []packer.BuildProfile {
+	profiles := []packer.BuildProfile{}
+	for _, b := range builds {
+		if pb, ok := b.(packer.ProfiledBuild); ok {
+			profiles = append(profiles, pb.Profile())
+		}
+	}
+	return profiles
+}
+
+// formatBuildProfile returns a table of how long the parts of a build took.
+func formatBuildProfile(profile packer.BuildProfile) string {
+	type row struct{ name, duration string }
+	rows := []row{}
+	section := func(title string, entries []packer.ProfileEntry) {
+		if len(entries) == 0 {
+			return
+		}
+		rows = append(rows, row{name: "    " + title + ":"})
+		for _, e := range entries {
+			rows = append(rows, row{"      " + e.Name, formatProfileDuration(e.Duration())})
+		}
+	}
+	section("Steps", profile.Steps)
+	section("Provisioners", profile.Provisioners)
+	section("Post-processors", profile.PostProcessors)
+	rows = append(rows, row{"    Total", formatProfileDuration(profile.Duration())})
+
+	width := 0
+	for _, r := range rows {
+		if r.duration != "" && len(r.name) > width {
+			width = len(r.name)
+		}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "--> %s:\n", profile.Build)
+	for _, r := range rows {
+		if r.duration == "" {
+			fmt.Fprintf(&b, "%s\n", r.name)
+			continue
+		}
+		fmt.Fprintf(&b, "%-*s  %s\n", width, r.name, r.duration)
+	}
+	return b.String()
+}
+
+func formatProfileDuration(d time.Duration) string {
+	if d < time.Second {
+		return d.Round(time.Millisecond).String()
+	}
+	return d.Round(100 * time.Millisecond).String()
+}
+
+// writeBuildProfiles writes the profiles as JSON to path.
+func writeBuildProfiles(path string, profiles []packer.BuildProfile) error {
+	out, err := json.MarshalIndent(profiles, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, out, 0644)
+}
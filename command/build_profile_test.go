@@ -0,0 +1,64 @@
+package command
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/hashicorp/packer/packer"
+)
+
+func testBuildProfile() packer.BuildProfile {
+	return packer.BuildProfile{
+		Build:   "hyperv-iso.vm",
+		Seconds: 95.25,
+		Steps: []packer.ProfileEntry{
+			{Name: "StepCreateVM", Seconds: 3.14159},
+			{Name: "StepRun", Seconds: 0.0421},
+		},
+		Provisioners: []packer.ProfileEntry{
+			{Name: "shell", Seconds: 60},
+		},
+		PostProcessors: []packer.ProfileEntry{},
+	}
+}
+
+func TestFormatBuildProfile(t *testing.T) {
+	expected := `--> hyperv-iso.vm:
+    Steps:
+      StepCreateVM  3.1s
+      StepRun       42ms
+    Provisioners:
+      shell         1m0s
+    Total           1m35.3s
+`
+	if diff := cmp.Diff(expected, formatBuildProfile(testBuildProfile())); diff != "" {
+		t.Fatalf("unexpected profile table: %s", diff)
+	}
+}
+
+func TestWriteBuildProfiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "profile.json")
+	profiles := []packer.BuildProfile{testBuildProfile()}
+	if err := writeBuildProfiles(path, profiles); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	out, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if !strings.Contains(string(out), `"post_processors": []`) {
+		t.Fatalf("bad: %s", out)
+	}
+	var read []packer.BuildProfile
+	if err := json.Unmarshal(out, &read); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if diff := cmp.Diff(profiles, read); diff != "" {
+		t.Fatalf("unexpected profiles: %s", diff)
+	}
+}
@@ -20,6 +20,7 @@ import (
 	filep "github.com/hashicorp/packer/provisioner/file"
 	"github.com/hashicorp/packer/provisioner/shell"
 	shell_local "github.com/hashicorp/packer/provisioner/shell-local"
+	"github.com/hashicorp/packer/version"
 )
 
 var (
@@ -146,6 +147,7 @@ func TestBuild(t *testing.T) {
 			fileCheck: fileCheck{
 				expectedContent: map[string]string{
 					"manifest.json": `{
+  "version": 1,
   "builds": [
     {
       "name": "test",
@@ -153,7 +155,20 @@ func TestBuild(t *testing.T) {
       "files": null,
       "artifact_id": "Null",
       "packer_run_uuid": "",
-      "custom_data": null
+      "custom_data": null,
+      "provenance": {
+        "build": "null.test",
+        "builder_type": "null",
+        "packer_version": "` + version.FormattedVersion() + `",
+        "builder_config_sha256": "2f1eae4cb7f4d60ed8d8500ed39b4ac98bd7a6ae81d9d2869cddc474c855b22c",
+        "provisioners": [
+          {
+            "type": "shell-local",
+            "config_sha256": "6bdfa6b2d31a12ddf3521913fc26e307565d6791276831168f8df0bab9fc8006"
+          }
+        ],
+        "post_processors": []
+      }
     },
     {
       "name": "potato",
@@ -161,7 +176,20 @@ func TestBuild(t *testing.T) {
       "files": null,
       "artifact_id": "Null",
       "packer_run_uuid": "",
-      "custom_data": null
+      "custom_data": null,
+      "provenance": {
+        "build": "null.potato",
+        "builder_type": "null",
+        "packer_version": "` + version.FormattedVersion() + `",
+        "builder_config_sha256": "2f1eae4cb7f4d60ed8d8500ed39b4ac98bd7a6ae81d9d2869cddc474c855b22c",
+        "provisioners": [
+          {
+            "type": "shell-local",
+            "config_sha256": "53dccbe366da3a861f27af84a392d7fb797366560827694d16781653f53d3a1d"
+          }
+        ],
+        "post_processors": []
+      }
     }
   ],
   "last_run_uuid": ""
@@ -184,6 +212,7 @@ func TestBuild(t *testing.T) {
 				},
 				expectedContent: map[string]string{
 					"manifest.json": `{
+  "version": 1,
   "builds": [
     {
       "name": "test",
@@ -191,7 +220,15 @@ func TestBuild(t *testing.T) {
       "files": null,
       "artifact_id": "Null",
       "packer_run_uuid": "",
-      "custom_data": null
+      "custom_data": null,
+      "provenance": {
+        "build": "test",
+        "builder_type": "null",
+        "packer_version": "",
+        "builder_config_sha256": "2f1eae4cb7f4d60ed8d8500ed39b4ac98bd7a6ae81d9d2869cddc474c855b22c",
+        "provisioners": [],
+        "post_processors": []
+      }
     }
   ],
   "last_run_uuid": ""
@@ -210,6 +247,7 @@ func TestBuild(t *testing.T) {
 			fileCheck: fileCheck{
 				expectedContent: map[string]string{
 					"manifest.json": `{
+  "version": 1,
   "builds": [
     {
       "name": "potato",
@@ -217,7 +255,15 @@ func TestBuild(t *testing.T) {
       "files": null,
       "artifact_id": "Null",
       "packer_run_uuid": "",
-      "custom_data": null
+      "custom_data": null,
+      "provenance": {
+        "build": "potato",
+        "builder_type": "null",
+        "packer_version": "",
+        "builder_config_sha256": "2f1eae4cb7f4d60ed8d8500ed39b4ac98bd7a6ae81d9d2869cddc474c855b22c",
+        "provisioners": [],
+        "post_processors": []
+      }
     }
   ],
   "last_run_uuid": ""
@@ -470,6 +516,40 @@ func TestBuildOnlyFileCommaFlags(t *testing.T) {
 	}
 }
 
+func TestBuild_invalidTraceConfig(t *testing.T) {
+	for env, value := range map[string]string{
+		"OTEL_EXPORTER_OTLP_ENDPOINT": "http://127.0.0.1:4318",
+		"OTEL_EXPORTER_OTLP_PROTOCOL": "grpc",
+		"TRACEPARENT":                 "00-nothex-b7ad6b7169203331-01",
+	} {
+		defer os.Setenv(env, os.Getenv(env))
+		os.Setenv(env, value)
+	}
+
+	c := &BuildCommand{
+		Meta: testMetaFile(t),
+	}
+
+	args := []string{
+		"-only=chocolate",
+		filepath.Join(testFixture("build-only"), "template.json"),
+	}
+
+	defer cleanup()
+
+	if code := c.Run(args); code != 0 {
+		fatalCommand(t, c.Meta)
+	}
+
+	if !fileExists("chocolate.txt") {
+		t.Error("Expected to find chocolate.txt")
+	}
+	out, _ := outputCommand(t, c.Meta)
+	if !strings.Contains(out, "traces of the builds are not exported") {
+		t.Errorf("expected a warning about the traces, got %q", out)
+	}
+}
+
 func TestBuildStdin(t *testing.T) {
 	c := &BuildCommand{
 		Meta: testMetaFile(t),
@@ -0,0 +1,39 @@
+package command
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/hashicorp/packer/packer"
+)
+
+// traceExportTimeout is how long exporting the trace of a build may take.
+const traceExportTimeout = 10 * time.Second
+
+// exportBuildTrace sends the trace of the run of b that ended at end, if b
+// records its profile. Failing to export it doesn't fail the build.
+func exportBuildTrace(exporter *packer.TraceExporter, b packer.Build, end time.Time, artifacts []packer.Artifact, err error) {
+	pb, ok := b.(packer.ProfiledBuild)
+	if !ok {
+		return
+	}
+	trace := packer.BuildTrace{
+		Name:      b.Name(),
+		Profile:   pb.Profile(),
+		End:       end,
+		Artifacts: artifacts,
+		Err:       err,
+	}
+	if cb, ok := b.(*packer.CoreBuild); ok {
+		trace.BuilderType = cb.BuilderType
+	}
+
+	// The build context may be cancelled, and the trace of a cancelled build
+	// is still of interest
+	ctx, cancel := context.WithTimeout(context.Background(), traceExportTimeout)
+	defer cancel()
+	if err := exporter.ExportBuild(ctx, trace); err != nil {
+		log.Printf("Error exporting the trace of build '%s': %s", b.Name(), err)
+	}
+}
@@ -0,0 +1,133 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/c2h5oh/datasize"
+	"github.com/hashicorp/packer/packer"
+	"github.com/mitchellh/cli"
+	"github.com/posener/complete"
+)
+
+// CacheCommand only shows the help of the `packer cache` subcommands.
+type CacheCommand struct {
+	Meta
+}
+
+func (c *CacheCommand) Run(args []string) int {
+	return cli.RunResultHelp
+}
+
+func (*CacheCommand) Help() string {
+	helpText := `
+Usage: packer cache <subcommand> [options]
+
+  Manages the cache shared by all templates and builds, enabled by setting
+  PACKER_SHARED_CACHE_DIR. Downloads with a checksum are stored in this
+  directory by their checksum, so that every build downloading the same file
+  uses the same copy.
+
+Subcommands:
+  gc    Removes the least recently used files of the shared cache
+`
+
+	return strings.TrimSpace(helpText)
+}
+
+func (*CacheCommand) Synopsis() string {
+	return "Manages the shared download cache"
+}
+
+type CacheGCCommand struct {
+	Meta
+}
+
+func (c *CacheGCCommand) Run(args []string) int {
+	ctx := context.Background()
+	cfg, ret := c.ParseArgs(args)
+	if ret != 0 {
+		return ret
+	}
+
+	return c.RunContext(ctx, cfg)
+}
+
+func (c *CacheGCCommand) ParseArgs(args []string) (*CacheGCArgs, int) {
+	var cfg CacheGCArgs
+	flags := c.Meta.FlagSet("cache gc", FlagSetNone)
+	flags.Usage = func() { c.Ui.Say(c.Help()) }
+	cfg.AddFlagSets(flags)
+	if err := flags.Parse(args); err != nil {
+		return &cfg, 1
+	}
+
+	if len(flags.Args()) != 0 || cfg.MaxSize == "" {
+		flags.Usage()
+		return &cfg, 1
+	}
+	return &cfg, 0
+}
+
+func (c *CacheGCCommand) RunContext(ctx context.Context, cla *CacheGCArgs) int {
+	var maxSize datasize.ByteSize
+	if err := maxSize.UnmarshalText([]byte(cla.MaxSize)); err != nil {
+		c.Ui.Error(fmt.Sprintf("Invalid -max-size %q: %s", cla.MaxSize, err))
+		return 1
+	}
+
+	dir, err := packer.SharedCacheDir()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error finding the shared cache: %s", err))
+		return 1
+	}
+	if dir == "" {
+		c.Ui.Error("The shared cache isn't used, PACKER_SHARED_CACHE_DIR is not set")
+		return 1
+	}
+
+	removed, size, err := packer.CacheGC(dir, int64(maxSize.Bytes()))
+	for _, path := range removed {
+		c.Ui.Say(fmt.Sprintf("Removed %s", path))
+	}
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error pruning the shared cache: %s", err))
+		return 1
+	}
+
+	c.Ui.Say(fmt.Sprintf("The shared cache uses %s", datasize.ByteSize(size).HR()))
+	if size > int64(maxSize.Bytes()) {
+		c.Ui.Error(fmt.Sprintf("Files in use by other builds are kept, the shared cache is larger than %s", maxSize.HR()))
+		return 1
+	}
+	return 0
+}
+
+func (*CacheGCCommand) Help() string {
+	helpText := `
+Usage: packer cache gc -max-size=SIZE
+
+  Removes the least recently used files of the shared cache until it is at
+  most SIZE. Files being downloaded or used by a running build are kept.
+
+Options:
+  -max-size=SIZE  The size to prune the shared cache to, like 500MB or 20GB.
+`
+
+	return strings.TrimSpace(helpText)
+}
+
+func (*CacheGCCommand) Synopsis() string {
+	return "Removes the least recently used files of the shared cache"
+}
+
+func (*CacheGCCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (*CacheGCCommand) AutocompleteFlags() complete.Flags {
+	return complete.Flags{
+		"-max-size": complete.PredictNothing,
+	}
+}
@@ -3,6 +3,7 @@ package command
 import (
 	"flag"
 	"strings"
+	"time"
 
 	"github.com/hashicorp/packer/command/enumflag"
 	kvflag "github.com/hashicorp/packer/command/flag-kv"
@@ -73,11 +74,19 @@ type MetaArgs struct {
 func (ba *BuildArgs) AddFlagSets(flags *flag.FlagSet) {
 	flags.BoolVar(&ba.Color, "color", true, "")
 	flags.BoolVar(&ba.Debug, "debug", false, "")
+	flags.BoolVar(&ba.DryRun, "dry-run", false, "")
 	flags.BoolVar(&ba.Force, "force", false, "")
+	flags.BoolVar(&ba.Resume, "resume", false, "")
+	flags.BoolVar(&ba.Profile, "profile", false, "")
+	flags.StringVar(&ba.ProfileOutput, "profile-output", "", "")
 	flags.BoolVar(&ba.TimestampUi, "timestamp-ui", false, "")
 	flags.BoolVar(&ba.MachineReadable, "machine-readable", false, "")
+	flags.BoolVar(&ba.JSONEvents, "json-events", false, "")
+	flags.BoolVar(&ba.IgnoreLockfile, "ignore-lockfile", false, "")
+	flags.BoolVar(&ba.Dashboard, "dashboard", false, "")
 
 	flags.Int64Var(&ba.ParallelBuilds, "parallel-builds", 0, "")
+	flags.DurationVar(&ba.CleanupTimeout, "cleanup-timeout", 0, "")
 
 	flagOnError := enumflag.New(&ba.OnError, "cleanup", "abort", "ask", "run-cleanup-provisioner")
 	flags.Var(flagOnError, "on-error", "")
@@ -88,9 +97,11 @@ func (ba *BuildArgs) AddFlagSets(flags *flag.FlagSet) {
 // BuildArgs represents a parsed cli line for a `packer build`
 type BuildArgs struct {
 	MetaArgs
-	Color, Debug, Force, TimestampUi, MachineReadable bool
-	ParallelBuilds                                    int64
-	OnError                                           string
+	Color, Debug, Force, Resume, TimestampUi, MachineReadable bool
+	JSONEvents, Profile, DryRun, IgnoreLockfile, Dashboard    bool
+	ParallelBuilds                                            int64
+	OnError, ProfileOutput                                    string
+	CleanupTimeout                                            time.Duration
 }
 
 // ConsoleArgs represents a parsed cli line for a `packer console`
@@ -112,6 +123,8 @@ type FixArgs struct {
 
 func (va *ValidateArgs) AddFlagSets(flags *flag.FlagSet) {
 	flags.BoolVar(&va.SyntaxOnly, "syntax-only", false, "check syntax only")
+	flags.BoolVar(&va.Deep, "deep", false, "validate the configuration of all the components, without credentials")
+	flags.BoolVar(&va.IgnoreLockfile, "ignore-lockfile", false, "load the installed plugins without verifying them against the lockfile")
 
 	va.MetaArgs.AddFlagSets(flags)
 }
@@ -119,7 +132,19 @@ func (va *ValidateArgs) AddFlagSets(flags *flag.FlagSet) {
 // ValidateArgs represents a parsed cli line for a `packer validate`
 type ValidateArgs struct {
 	MetaArgs
-	SyntaxOnly bool
+	SyntaxOnly, Deep, IgnoreLockfile bool
+}
+
+func (ia *InitArgs) AddFlagSets(flags *flag.FlagSet) {
+	flags.BoolVar(&ia.Upgrade, "upgrade", false, "lock the newest installed versions that match the version constraints")
+
+	ia.MetaArgs.AddFlagSets(flags)
+}
+
+// InitArgs represents a parsed cli line for a `packer init`
+type InitArgs struct {
+	MetaArgs
+	Upgrade bool
 }
 
 func (va *InspectArgs) AddFlagSets(flags *flag.FlagSet) {
@@ -156,3 +181,23 @@ type FormatArgs struct {
 	MetaArgs
 	Check, Diff, Write bool
 }
+
+func (va *CacheGCArgs) AddFlagSets(flags *flag.FlagSet) {
+	flags.StringVar(&va.MaxSize, "max-size", "", "size the shared cache is pruned to, like 20GB")
+}
+
+// CacheGCArgs represents a parsed cli line for `packer cache gc`
+type CacheGCArgs struct {
+	MaxSize string
+}
+
+func (da *DestroyArgs) AddFlagSets(flags *flag.FlagSet) {
+	flags.Var((*sliceflag.StringFlag)(&da.Only), "only", "")
+	flags.BoolVar(&da.DryRun, "dry-run", false, "")
+}
+
+// DestroyArgs represents a parsed cli line for `packer destroy`
+type DestroyArgs struct {
+	Only   []string
+	DryRun bool
+}
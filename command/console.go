@@ -5,9 +5,11 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"log"
 	"strings"
 
 	"github.com/chzyer/readline"
+	"github.com/hashicorp/hcl/v2"
 	"github.com/hashicorp/packer/helper/wrappedreadline"
 	"github.com/hashicorp/packer/helper/wrappedstreams"
 	"github.com/hashicorp/packer/packer"
@@ -60,28 +62,42 @@ func (c *ConsoleCommand) RunContext(ctx context.Context, cla *ConsoleArgs) int {
 		return ret
 	}
 
-	_ = packerStarter.Initialize()
+	// Data sources and locals that can't be evaluated are reported but don't
+	// stop the console, so that what is resolvable can still be inspected.
+	diags := packerStarter.Initialize()
 
 	// Determine if stdin is a pipe. If so, we evaluate directly.
 	if c.StdinPiped() {
+		// The output of a pipe is the result, so the diagnostics all go to
+		// stderr.
+		writeDiagsToStderr(diags)
 		return c.modePiped(packerStarter)
 	}
 
+	writeDiags(c.Ui, nil, diags)
 	return c.modeInteractive(packerStarter)
 }
 
+// writeDiagsToStderr writes diags to stderr, warnings included.
+func writeDiagsToStderr(diags hcl.Diagnostics) {
+	err := hcl.NewDiagnosticTextWriter(wrappedstreams.Stderr(), nil, 80, false).WriteDiagnostics(diags)
+	if err != nil {
+		log.Printf("could not write diagnostic: %s", err)
+	}
+}
+
 func (*ConsoleCommand) Help() string {
 	helpText := `
 Usage: packer console [options] [TEMPLATE]
 
   Creates a console for testing variable interpolation.
   If a template is provided, this command will load the template and any
-  variables defined therein into its context to be referenced during
-  interpolation.
+  variables, locals and data sources defined therein into its context to be
+  referenced during interpolation, as a build would see them.
 
 Options:
   -var 'key=value'       Variable for templates, can be used multiple times.
-  -var-file=path         JSON or HCL2 file containing user variables. [ Note that even in HCL mode this expects file to contain JSON, a fix is comming soon ]
+  -var-file=path         JSON or HCL2 file containing user variables.
 `
 
 	return strings.TrimSpace(helpText)
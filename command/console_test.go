@@ -1,6 +1,7 @@
 package command
 
 import (
+	"bytes"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -52,3 +53,18 @@ func Test_console(t *testing.T) {
 		})
 	}
 }
+
+func Test_console_pipedDiagnostics(t *testing.T) {
+	p := helperCommand(t, "console", filepath.Join(testFixture("hcl", "console", "undefined_local.pkr.hcl")))
+	p.Stdin = strings.NewReader("local.fruit")
+	stderr := &bytes.Buffer{}
+	p.Stderr = stderr
+	bs, err := p.Output()
+	if err != nil {
+		t.Fatalf("%v: %s", err, bs)
+	}
+	assert.Equal(t, "potato\n", string(bs))
+	if !strings.Contains(stderr.String(), `"undefined_thing"`) {
+		t.Fatalf("the diagnostics of the config should be on stderr, got: %q", stderr.String())
+	}
+}
@@ -0,0 +1,134 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	awscommon "github.com/hashicorp/packer/builder/amazon/common"
+	hypervcommon "github.com/hashicorp/packer/builder/hyperv/common"
+	"github.com/hashicorp/packer/packer"
+	"github.com/posener/complete"
+)
+
+// resourceDestroyers destroy the resources recorded by builds, by the
+// provider of the resources.
+var resourceDestroyers = map[string]func(context.Context, packer.Resource) error{
+	"amazon": awscommon.DestroyResource,
+	"hyperv": hypervcommon.DestroyResource,
+}
+
+type DestroyCommand struct {
+	Meta
+}
+
+func (c *DestroyCommand) Run(args []string) int {
+	ctx, cleanup := handleTermInterrupt(c.Ui)
+	defer cleanup()
+
+	cfg, ret := c.ParseArgs(args)
+	if ret != 0 {
+		return ret
+	}
+
+	return c.RunContext(ctx, cfg)
+}
+
+func (c *DestroyCommand) ParseArgs(args []string) (*DestroyArgs, int) {
+	var cfg DestroyArgs
+	flags := c.Meta.FlagSet("destroy", FlagSetNone)
+	flags.Usage = func() { c.Ui.Say(c.Help()) }
+	cfg.AddFlagSets(flags)
+	if err := flags.Parse(args); err != nil {
+		return &cfg, 1
+	}
+
+	if len(flags.Args()) != 0 {
+		flags.Usage()
+		return &cfg, 1
+	}
+	return &cfg, 0
+}
+
+func (c *DestroyCommand) RunContext(ctx context.Context, cla *DestroyArgs) int {
+	resources, err := packer.RecordedResources()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error reading the resources recorded by builds: %s", err))
+		return 1
+	}
+
+	only := make(map[string]bool, len(cla.Only))
+	for _, provider := range cla.Only {
+		only[provider] = true
+	}
+
+	ret := 0
+	destroyed := 0
+	for _, r := range resources {
+		if len(only) > 0 && !only[r.Provider] {
+			continue
+		}
+		if cla.DryRun {
+			c.Ui.Say(fmt.Sprintf("Would destroy %s, created at %s", r, r.CreatedAt.Local().Format("2006-01-02 15:04:05")))
+			continue
+		}
+		if ctx.Err() != nil {
+			c.Ui.Error("Interrupted, the remaining resources are kept")
+			return 1
+		}
+
+		destroy, ok := resourceDestroyers[r.Provider]
+		if !ok {
+			c.Ui.Error(fmt.Sprintf("Can't destroy %s: unknown provider %q", r, r.Provider))
+			ret = 1
+			continue
+		}
+		c.Ui.Say(fmt.Sprintf("Destroying %s...", r))
+		if err := destroy(ctx, r); err != nil {
+			c.Ui.Error(fmt.Sprintf("Error destroying %s: %s", r, err))
+			ret = 1
+			continue
+		}
+		packer.ForgetResource(r)
+		destroyed++
+	}
+
+	if !cla.DryRun {
+		c.Ui.Say(fmt.Sprintf("Destroyed %d resources", destroyed))
+	}
+	return ret
+}
+
+func (*DestroyCommand) Help() string {
+	helpText := `
+Usage: packer destroy [options]
+
+  Destroys the resources that builds created and couldn't destroy, like the
+  virtual machines, switches, key pairs and security groups of builds that
+  crashed or were aborted. Builds record the resources they create in the
+  "resources" directory of the Packer configuration directory, until they are
+  destroyed. Don't run it while builds are running, it would destroy their
+  resources.
+
+Options:
+  -dry-run             List the resources that would be destroyed.
+  -only=amazon,hyperv  Only destroy the resources of these providers.
+`
+
+	return strings.TrimSpace(helpText)
+}
+
+func (*DestroyCommand) Synopsis() string {
+	return "destroy the resources left behind by builds"
+}
+
+func (*DestroyCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (*DestroyCommand) AutocompleteFlags() complete.Flags {
+	return complete.Flags{
+		"-dry-run": complete.PredictNothing,
+		"-only":    complete.PredictSet("amazon", "hyperv"),
+	}
+}
@@ -0,0 +1,70 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/packer/packer"
+)
+
+func TestDestroy(t *testing.T) {
+	dir, err := ioutil.TempDir("", "packer")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer os.RemoveAll(dir)
+	os.Setenv("PACKER_CONFIG_DIR", dir)
+	defer os.Unsetenv("PACKER_CONFIG_DIR")
+	os.Setenv("PACKER_RUN_UUID", "run")
+	defer os.Unsetenv("PACKER_RUN_UUID")
+
+	packer.RecordResource(packer.Resource{Provider: "test", Type: "vm", ID: "kept"})
+	packer.RecordResource(packer.Resource{Provider: "test", Type: "vm", ID: "leaked"})
+	packer.RecordResource(packer.Resource{Provider: "other", Type: "vm", ID: "other"})
+
+	var destroyed []string
+	resourceDestroyers["test"] = func(_ context.Context, r packer.Resource) error {
+		if r.ID == "kept" {
+			return errors.New("in use")
+		}
+		destroyed = append(destroyed, r.ID)
+		return nil
+	}
+	defer delete(resourceDestroyers, "test")
+
+	c := &DestroyCommand{Meta: testMeta(t)}
+	if code := c.Run([]string{"-only=test", "-dry-run"}); code != 0 {
+		fatalCommand(t, c.Meta)
+	}
+	if len(destroyed) != 0 {
+		t.Fatalf("nothing should be destroyed: %#v", destroyed)
+	}
+
+	if code := c.Run([]string{"-only=test"}); code != 1 {
+		t.Fatalf("bad exit code: %d", code)
+	}
+	if !reflect.DeepEqual(destroyed, []string{"leaked"}) {
+		t.Fatalf("bad: %#v", destroyed)
+	}
+	_, stderr := outputCommand(t, c.Meta)
+	if !strings.Contains(stderr, "Error destroying test vm kept: in use") {
+		t.Fatalf("bad: %s", stderr)
+	}
+
+	resources, err := packer.RecordedResources()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	var ids []string
+	for _, r := range resources {
+		ids = append(ids, r.ID)
+	}
+	if len(ids) != 2 || strings.Contains(strings.Join(ids, ","), "leaked") {
+		t.Fatalf("bad: %#v", ids)
+	}
+}
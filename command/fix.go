@@ -69,20 +69,10 @@ func (c *FixCommand) RunContext(ctx context.Context, cla *FixArgs) int {
 	// Close the file since we're done with that
 	tplF.Close()
 
-	input := templateData
-	for _, name := range fix.FixerOrder {
-		var err error
-		fixer, ok := fix.Fixers[name]
-		if !ok {
-			panic("fixer not found: " + name)
-		}
-
-		log.Printf("Running fixer: %s", name)
-		input, err = fixer.Fix(input)
-		if err != nil {
-			c.Ui.Error(fmt.Sprintf("Error fixing: %s", err))
-			return 1
-		}
+	input, err := fixTemplate(templateData)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error fixing: %s", err))
+		return 1
 	}
 
 	var output bytes.Buffer
@@ -129,6 +119,24 @@ func (c *FixCommand) RunContext(ctx context.Context, cla *FixArgs) int {
 	return 0
 }
 
+// fixTemplate runs all the fixers, in order, on the decoded JSON template.
+func fixTemplate(input map[string]interface{}) (map[string]interface{}, error) {
+	for _, name := range fix.FixerOrder {
+		var err error
+		fixer, ok := fix.Fixers[name]
+		if !ok {
+			panic("fixer not found: " + name)
+		}
+
+		log.Printf("Running fixer: %s", name)
+		input, err = fixer.Fix(input)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return input, nil
+}
+
 func (*FixCommand) Help() string {
 	helpText := `
 Usage: packer fix [options] TEMPLATE
@@ -72,17 +72,19 @@ func (*FormatCommand) Help() string {
 Usage: packer fmt [options] [TEMPLATE]
 
   Rewrites all Packer configuration files to a canonical format. Both
-  configuration files (.pkr.hcl) and variable files (.pkrvars) are updated.
-  JSON files (.json) are not modified.
+  configuration files (.pkr.hcl) and variable files (.pkrvars.hcl) are
+  updated. JSON files (.json) are not modified. The content of indented
+  heredocs (<<-EOT) is reindented without changing its value.
 
   If TEMPATE is "." the current directory will be used. The given content must
   be in Packer's HCL2 configuration language; JSON is not supported.
 
 Options:
   -check        Check if the input is formatted. Exit status will be 0 if all
-                 input is properly formatted and non-zero otherwise.
+                 input is properly formatted and 3 otherwise.
 
-  -diff         Display diffs of formatting change
+  -diff         Display unified diffs of formatting change, use with -check
+                to show what a CI job should fix
 
   -write=false  Don't write to source files
                 (always disabled if using -check)
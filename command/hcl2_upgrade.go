@@ -3,11 +3,14 @@ package command
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
 	texttemplate "text/template"
 
@@ -114,16 +117,20 @@ func (c *HCL2UpgradeCommand) RunContext(buildCtx context.Context, cla *HCL2Upgra
 		return 1
 	}
 
-	hdl, ret := c.GetConfigFromJSON(&cla.MetaArgs)
-	if ret != 0 {
-		return ret
+	tpl, err := parseFixedTemplate(cla.Path)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Failed to parse template: %s", err))
+		return 1
 	}
 
-	core := hdl.(*CoreWrapper).Core
+	core, err := c.Meta.Core(tpl, &cla.MetaArgs)
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return 1
+	}
 	if err := core.Initialize(); err != nil {
 		c.Ui.Error(fmt.Sprintf("Ignoring following initialization error: %v", err))
 	}
-	tpl := core.Template
 
 	// Packer section
 	if tpl.MinVersion != "" {
@@ -186,6 +193,10 @@ func (c *HCL2UpgradeCommand) RunContext(buildCtx context.Context, cla *HCL2Upgra
 
 	out.Write([]byte(sourcesHeader))
 
+	// sources maps the names of the JSON builders to their HCL2 source, for
+	// the only, except and override fields of the provisioners and
+	// post-processors.
+	sources := map[string]*template.Builder{}
 	for i, builderCfg := range builders {
 		sourcesContent := hclwrite.NewEmptyFile()
 		body := sourcesContent.Body()
@@ -195,6 +206,7 @@ func (c *HCL2UpgradeCommand) RunContext(buildCtx context.Context, cla *HCL2Upgra
 			c.Ui.Error(fmt.Sprintf("unknown builder type: %q\n", builderCfg.Type))
 			return 1
 		}
+		sources[builderCfg.Name] = builderCfg
 		if builderCfg.Name == "" || builderCfg.Name == builderCfg.Type {
 			builderCfg.Name = fmt.Sprintf("autogenerated_%d", i+1)
 		}
@@ -231,18 +243,36 @@ func (c *HCL2UpgradeCommand) RunContext(buildCtx context.Context, cla *HCL2Upgra
 		block := body.AppendNewBlock("provisioner", []string{provisioner.Type})
 		cfg := provisioner.Config
 		if len(provisioner.Except) > 0 {
-			cfg["except"] = provisioner.Except
+			cfg["except"] = sourceNamesOf(sources, provisioner.Except)
 		}
 		if len(provisioner.Only) > 0 {
-			cfg["only"] = provisioner.Only
+			cfg["only"] = sourceNamesOf(sources, provisioner.Only)
 		}
 		if provisioner.MaxRetries != "" {
-			cfg["max_retries"] = provisioner.MaxRetries
+			if retries, err := strconv.Atoi(provisioner.MaxRetries); err == nil {
+				cfg["max_retries"] = retries
+			} else {
+				cfg["max_retries"] = provisioner.MaxRetries
+			}
+		}
+		if provisioner.PauseBefore > 0 {
+			cfg["pause_before"] = provisioner.PauseBefore.String()
 		}
 		if provisioner.Timeout > 0 {
 			cfg["timeout"] = provisioner.Timeout.String()
 		}
 		jsonBodyToHCL2Body(block.Body(), cfg)
+		if len(provisioner.Override) > 0 {
+			// Overrides are keyed by the name of the sources in HCL2
+			override := map[string]interface{}{}
+			for name, cfg := range provisioner.Override {
+				if builder, ok := sources[name]; ok {
+					name = builder.Name
+				}
+				override[name] = cfg
+			}
+			block.Body().SetAttributeValue("override", hcl2shim.HCL2ValueFromConfigValue(override))
+		}
 
 		out.Write(transposeTemplatingCalls(provisionerContent.Bytes()))
 	}
@@ -264,10 +294,10 @@ func (c *HCL2UpgradeCommand) RunContext(buildCtx context.Context, cla *HCL2Upgra
 			}
 			cfg := pp.Config
 			if len(pp.Except) > 0 {
-				cfg["except"] = pp.Except
+				cfg["except"] = sourceNamesOf(sources, pp.Except)
 			}
 			if len(pp.Only) > 0 {
-				cfg["only"] = pp.Only
+				cfg["only"] = sourceNamesOf(sources, pp.Only)
 			}
 			if pp.Name != "" && pp.Name != pp.Type {
 				cfg["name"] = pp.Name
@@ -287,6 +317,47 @@ func (c *HCL2UpgradeCommand) RunContext(buildCtx context.Context, cla *HCL2Upgra
 	return 0
 }
 
+// parseFixedTemplate parses the JSON template at path after running the
+// fixers on it, so that deprecated fields are upgraded too.
+func parseFixedTemplate(path string) (*template.Template, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var templateData map[string]interface{}
+	if err := json.Unmarshal(b, &templateData); err != nil {
+		// Let the template parser point out the syntax error
+		return template.ParseFile(path)
+	}
+	templateData, err = fixTemplate(templateData)
+	if err != nil {
+		return nil, fmt.Errorf("Error fixing: %s", err)
+	}
+	b, err = json.Marshal(templateData)
+	if err != nil {
+		return nil, err
+	}
+	tpl, err := template.Parse(bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	tpl.Path, err = filepath.Abs(path)
+	return tpl, err
+}
+
+// sourceNamesOf returns the HCL2 names of JSON builders, as they are
+// referenced by the only and except fields.
+func sourceNamesOf(sources map[string]*template.Builder, names []string) []string {
+	res := make([]string, 0, len(names))
+	for _, name := range names {
+		if builder, ok := sources[name]; ok {
+			name = builder.Type + "." + builder.Name
+		}
+		res = append(res, name)
+	}
+	return res
+}
+
 type UnhandleableArgumentError struct {
 	Call           string
 	Correspondance string
@@ -339,35 +410,53 @@ func transposeTemplatingCalls(s []byte) []byte {
 		"uuid": func() string {
 			return fmt.Sprintf("${uuidv4()}")
 		},
-		"lower": func(_ string) (string, error) {
+		"lower": func(s string) (string, error) {
+			if expr, ok := hcl2Expression(s); ok {
+				return fmt.Sprintf("${lower(%s)}", expr), nil
+			}
 			return "", UnhandleableArgumentError{
 				"lower",
 				"`lower(var.example)`",
 				"https://www.packer.io/docs/from-1.5/functions/string/lower",
 			}
 		},
-		"upper": func(_ string) (string, error) {
+		"upper": func(s string) (string, error) {
+			if expr, ok := hcl2Expression(s); ok {
+				return fmt.Sprintf("${upper(%s)}", expr), nil
+			}
 			return "", UnhandleableArgumentError{
 				"upper",
 				"`upper(var.example)`",
 				"https://www.packer.io/docs/from-1.5/functions/string/upper",
 			}
 		},
-		"split": func(_, _ string, _ int) (string, error) {
+		"split": func(s, sep string, i int) (string, error) {
+			exprs, ok := hcl2Expressions(s, sep)
+			if ok {
+				return fmt.Sprintf("${split(%s, %s)[%d]}", exprs[1], exprs[0], i), nil
+			}
 			return "", UnhandleableArgumentError{
 				"split",
 				"`split(separator, string)`",
 				"https://www.packer.io/docs/from-1.5/functions/string/split",
 			}
 		},
-		"replace": func(_, _, _ string, _ int) (string, error) {
+		"replace": func(old, new string, n int, src string) (string, error) {
+			// HCL2 can only replace all the occurrences
+			exprs, ok := hcl2Expressions(src, old, new)
+			if ok && n < 0 {
+				return fmt.Sprintf("${replace(%s, %s, %s)}", exprs[0], exprs[1], exprs[2]), nil
+			}
 			return "", UnhandleableArgumentError{
 				"replace",
 				"`replace(string, substring, replacement)` or `regex_replace(string, substring, replacement)`",
 				"https://www.packer.io/docs/from-1.5/functions/string/replace or https://www.packer.io/docs/from-1.5/functions/string/regex_replace",
 			}
 		},
-		"replace_all": func(_, _, _ string) (string, error) {
+		"replace_all": func(old, new, src string) (string, error) {
+			if exprs, ok := hcl2Expressions(src, old, new); ok {
+				return fmt.Sprintf("${replace(%s, %s, %s)}", exprs[0], exprs[1], exprs[2]), nil
+			}
 			return "", UnhandleableArgumentError{
 				"replace_all",
 				"`replace(string, substring, replacement)` or `regex_replace(string, substring, replacement)`",
@@ -400,20 +489,47 @@ func transposeTemplatingCalls(s []byte) []byte {
 	}
 
 	str := &bytes.Buffer{}
-	v := struct {
-		HTTPIP   string
-		HTTPPort string
-	}{
-		HTTPIP:   "{{ .HTTPIP }}",
-		HTTPPort: "{{ .HTTPPort }}",
-	}
-	if err := tpl.Execute(str, v); err != nil {
+	// The variables set by builders and provisioners at runtime, like the
+	// ones of boot and execute commands, are kept as they are.
+	v := map[string]string{}
+	for _, k := range []string{"HTTPIP", "HTTPPort", "Path", "Vars", "EnvVarFile", "Command", "Script"} {
+		v[k] = fmt.Sprintf("{{ .%s }}", k)
+	}
+	if err := tpl.Option("missingkey=error").Execute(str, v); err != nil {
 		return fallbackReturn(err)
 	}
 
 	return str.Bytes()
 }
 
+// hcl2Expression returns the HCL2 expression of the argument of a go template
+// call: the expression of an already upgraded call like "${var.example}", or
+// a quoted string for a literal. Arguments mixing both aren't handled.
+func hcl2Expression(arg string) (string, bool) {
+	if strings.HasPrefix(arg, "${") && strings.HasSuffix(arg, "}") &&
+		strings.Count(arg, "${") == 1 && strings.Count(arg, "}") == 1 {
+		return arg[2 : len(arg)-1], true
+	}
+	if strings.ContainsAny(arg, "${}%\\\"\n") {
+		return "", false
+	}
+	return `"` + arg + `"`, true
+}
+
+// hcl2Expressions returns the HCL2 expressions of all args, or false when one
+// of them isn't handled.
+func hcl2Expressions(args ...string) ([]string, bool) {
+	exprs := make([]string, len(args))
+	for i, arg := range args {
+		expr, ok := hcl2Expression(arg)
+		if !ok {
+			return nil, false
+		}
+		exprs[i] = expr
+	}
+	return exprs, true
+}
+
 func jsonBodyToHCL2Body(out *hclwrite.Body, kvs map[string]interface{}) {
 	ks := []string{}
 	for k := range kvs {
@@ -20,6 +20,7 @@ func Test_hcl2_upgrade(t *testing.T) {
 		folder string
 	}{
 		{"hcl2_upgrade_basic"},
+		{"hcl2_upgrade_complex"},
 	}
 
 	for _, tc := range tc {
@@ -0,0 +1,219 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/go-version"
+	plugingetter "github.com/hashicorp/packer/packer/plugin-getter"
+	"github.com/posener/complete"
+)
+
+type InitCommand struct {
+	Meta
+}
+
+func (c *InitCommand) Run(args []string) int {
+	ctx, cleanup := handleTermInterrupt(c.Ui)
+	defer cleanup()
+
+	cfg, ret := c.ParseArgs(args)
+	if ret != 0 {
+		return ret
+	}
+
+	return c.RunContext(ctx, cfg)
+}
+
+func (c *InitCommand) ParseArgs(args []string) (*InitArgs, int) {
+	var cfg InitArgs
+	flags := c.Meta.FlagSet("init", FlagSetVars)
+	flags.Usage = func() { c.Ui.Say(c.Help()) }
+	cfg.AddFlagSets(flags)
+	if err := flags.Parse(args); err != nil {
+		return &cfg, 1
+	}
+
+	args = flags.Args()
+	if len(args) != 1 {
+		flags.Usage()
+		return &cfg, 1
+	}
+	cfg.Path = args[0]
+	return &cfg, 0
+}
+
+func (c *InitCommand) RunContext(ctx context.Context, cla *InitArgs) int {
+	cfgType, err := cla.GetConfigType()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("%q: %s", cla.Path, err))
+		return 1
+	}
+	if cfgType != ConfigTypeHCL2 {
+		c.Ui.Error("packer init only works with HCL2 templates, that can have required plugins.")
+		return 1
+	}
+
+	cfg, ret := c.GetConfigFromHCL(&cla.MetaArgs)
+	if ret != 0 {
+		return ret
+	}
+
+	opts, err := listInstallationsOptions()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Failed to find the plugin folders: %s", err))
+		return 1
+	}
+	lockfilePath := filepath.Join(cfg.Basedir, plugingetter.LockfileName)
+	lock, err := plugingetter.ReadLockfile(lockfilePath)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Failed to read the lockfile: %s", err))
+		return 1
+	}
+
+	reqs := cfg.PluginRequirements()
+	for _, pr := range reqs {
+		g, err := c.PluginRegistries.Getter(pr.Identifier.Hostname)
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Invalid registry in the packer config file: %s", err))
+			return 1
+		}
+		install, err := initInstallation(ctx, pr, lock, opts, cla.Upgrade, g)
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Plugin %q (%s): %s", pr.Accessor, pr.Identifier, err))
+			ret = 1
+			continue
+		}
+
+		sum, err := plugingetter.HashDir(install.Dir)
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Failed to compute the checksum of the plugin %q: %s", pr.Accessor, err))
+			ret = 1
+			continue
+		}
+		locked := lock.Plugin(pr.Identifier.String())
+		if locked != nil && locked.Version == install.Version.String() {
+			if expected, found := locked.Hashes[opts.Platform()]; found && expected != sum {
+				c.Ui.Error(fmt.Sprintf("Plugin %q: the checksum %s of %s %s in %s doesn't match the checksum %s of the lockfile; "+
+					"the binaries of the plugin changed since it was locked. Remove the plugin from %s to trust the binaries that are installed.",
+					pr.Accessor, sum, pr.Identifier, install.Version, install.Dir, expected, lockfilePath))
+				ret = 1
+				continue
+			}
+		}
+
+		lock.Lock(pr, install.Version, opts.Platform(), sum)
+		c.Ui.Say(fmt.Sprintf("Locked %s %s for %s", pr.Identifier, install.Version, opts.Platform()))
+	}
+	if ret != 0 {
+		return ret
+	}
+
+	for _, source := range lock.Prune(reqs) {
+		c.Ui.Say(fmt.Sprintf("Removed %s from the lockfile, it is not required anymore", source))
+	}
+	if len(lock.Plugins) == 0 {
+		if _, err := os.Stat(lockfilePath); os.IsNotExist(err) {
+			c.Ui.Say("No plugins are required, there is nothing to lock.")
+			return 0
+		}
+	}
+	if err := lock.Write(lockfilePath); err != nil {
+		c.Ui.Error(fmt.Sprintf("Failed to write the lockfile: %s", err))
+		return 1
+	}
+	return 0
+}
+
+// initInstallation returns the installation of the plugin of pr to lock: the
+// locked version while it matches the version constraints, unless upgrade
+// is set, or else the newest version that does. When a registry serves the
+// plugin, the version is installed from the registry if it isn't yet.
+func initInstallation(ctx context.Context, pr *plugingetter.Requirement, lock *plugingetter.Lockfile, opts plugingetter.ListInstallationsOptions, upgrade bool, g plugingetter.Getter) (*plugingetter.Installation, error) {
+	installs, err := pr.ListInstallations(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if locked := lock.Plugin(pr.Identifier.String()); locked != nil && !upgrade {
+		v, err := version.NewVersion(locked.Version)
+		if err == nil && pr.VersionConstraints.Check(v) {
+			if install := installs.Find(v); install != nil {
+				return install, nil
+			}
+			if g != nil {
+				return pr.Install(ctx, g, v, opts)
+			}
+			return nil, fmt.Errorf("the locked version %s is not installed. Install it in %s, "+
+				"or run packer init -upgrade to lock the newest version that is installed.",
+				v, pr.InstallDir(opts.FromFolders[0], v, opts))
+		}
+	}
+
+	install := installs.Newest()
+	if g != nil {
+		versions, err := g.Versions(ctx, pr.Identifier, opts.Platform())
+		if err != nil {
+			return nil, err
+		}
+		var newest *version.Version
+		for _, v := range versions {
+			if pr.VersionConstraints.Check(v) && (newest == nil || v.GreaterThan(newest)) {
+				newest = v
+			}
+		}
+		if newest != nil && (install == nil || newest.GreaterThan(install.Version)) {
+			return pr.Install(ctx, g, newest, opts)
+		}
+	}
+	if install == nil {
+		dir := filepath.Join(append(append([]string{opts.FromFolders[0]}, pr.Identifier.Parts()...), "VERSION", opts.Platform())...)
+		if g != nil {
+			return nil, fmt.Errorf("no version of the registry of %s matches %q.", pr.Identifier.Hostname, pr.VersionConstraints)
+		}
+		return nil, fmt.Errorf("no installed version matches %q. Install one in %s, "+
+			"or set a registry for %s in the packer config file.", pr.VersionConstraints, dir, pr.Identifier.Hostname)
+	}
+	return install, nil
+}
+
+func (*InitCommand) Help() string {
+	helpText := `
+Usage: packer init [options] TEMPLATE
+
+  Locks the versions of the plugins the required_plugins blocks of an HCL2
+  template require, in the .packer.lock.hcl file next to the template, with
+  the checksums of their binaries. packer build then refuses to load
+  plugins that don't match the lockfile.
+
+  The versions of a plugin are installed in the
+  HOSTNAME/NAMESPACE/TYPE/VERSION/OS_ARCH folders of the folders of
+  PACKER_PLUGIN_PATH, or of the plugins folder of the config directory.
+  When a registry of the packer config file serves the HOSTNAME of a
+  plugin, the missing versions are installed from the registry.
+  The locked version of a plugin is kept while it matches the version
+  constraints; else the newest version that does is locked.
+
+Options:
+  -upgrade                Lock the newest versions that match the version constraints.
+`
+
+	return strings.TrimSpace(helpText)
+}
+
+func (*InitCommand) Synopsis() string {
+	return "lock the versions of the plugins a template requires"
+}
+
+func (*InitCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (*InitCommand) AutocompleteFlags() complete.Flags {
+	return complete.Flags{
+		"-upgrade": complete.PredictNothing,
+	}
+}
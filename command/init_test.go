@@ -0,0 +1,259 @@
+package command
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	plugingetter "github.com/hashicorp/packer/packer/plugin-getter"
+)
+
+const requiredPluginsTemplate = `
+packer {
+  required_plugins {
+    null = {
+      source  = "github.com/hashicorp/null"
+      version = ">= 1.0.0, < 2.0.0"
+    }
+  }
+}
+
+source "null" "test" {
+  communicator = "none"
+}
+
+build {
+  sources = ["source.null.test"]
+}
+`
+
+// testRequiredPluginsDirs writes a template requiring a null plugin, and
+// installs versions 1.0.0, 1.1.0 and 2.0.0 of the plugin in a plugin folder
+// set as PACKER_PLUGIN_PATH. It returns the folder of the template, the
+// folder of version 1.1.0 and a func to clean them up.
+func testRequiredPluginsDirs(t *testing.T) (string, string, func()) {
+	templateDir, err := ioutil.TempDir("", "packer-init")
+	if err != nil {
+		t.Fatalf("TempDir: %s", err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(templateDir, "build.pkr.hcl"), []byte(requiredPluginsTemplate), 0644); err != nil {
+		t.Fatalf("WriteFile: %s", err)
+	}
+
+	pluginDir, err := ioutil.TempDir("", "packer-plugins")
+	if err != nil {
+		t.Fatalf("TempDir: %s", err)
+	}
+	opts, _ := listInstallationsOptions()
+	var installDir string
+	for _, v := range []string{"1.0.0", "1.1.0", "2.0.0"} {
+		dir := filepath.Join(pluginDir, "github.com", "hashicorp", "null", v, opts.Platform())
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatalf("MkdirAll: %s", err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(dir, "packer-builder-null"), []byte(v), 0755); err != nil {
+			t.Fatalf("WriteFile: %s", err)
+		}
+		if v == "1.1.0" {
+			installDir = dir
+		}
+	}
+	os.Setenv("PACKER_PLUGIN_PATH", pluginDir)
+
+	return templateDir, installDir, func() {
+		os.Unsetenv("PACKER_PLUGIN_PATH")
+		os.RemoveAll(templateDir)
+		os.RemoveAll(pluginDir)
+	}
+}
+
+func TestInit(t *testing.T) {
+	templateDir, installDir, cleanup := testRequiredPluginsDirs(t)
+	defer cleanup()
+
+	c := &InitCommand{
+		Meta: testMetaFile(t),
+	}
+	if code := c.Run([]string{templateDir}); code != 0 {
+		fatalCommand(t, c.Meta)
+	}
+
+	lock, err := plugingetter.ReadLockfile(filepath.Join(templateDir, plugingetter.LockfileName))
+	if err != nil {
+		t.Fatalf("ReadLockfile: %s", err)
+	}
+	locked := lock.Plugin("github.com/hashicorp/null")
+	if locked == nil || locked.Version != "1.1.0" {
+		t.Fatalf("expected null 1.1.0 to be locked, got %#v", lock.Plugins)
+	}
+	sum, err := plugingetter.HashDir(installDir)
+	if err != nil {
+		t.Fatalf("HashDir: %s", err)
+	}
+	opts, _ := listInstallationsOptions()
+	if locked.Hashes[opts.Platform()] != sum {
+		t.Fatalf("expected the checksum %s for %s, got %v", sum, opts.Platform(), locked.Hashes)
+	}
+
+	// The locked version is kept even when a newer one is installed.
+	newer := filepath.Join(filepath.Dir(filepath.Dir(installDir)), "1.2.0", opts.Platform())
+	if err := os.MkdirAll(newer, 0755); err != nil {
+		t.Fatalf("MkdirAll: %s", err)
+	}
+	if code := c.Run([]string{templateDir}); code != 0 {
+		fatalCommand(t, c.Meta)
+	}
+	lock, _ = plugingetter.ReadLockfile(filepath.Join(templateDir, plugingetter.LockfileName))
+	if v := lock.Plugin("github.com/hashicorp/null").Version; v != "1.1.0" {
+		t.Fatalf("expected null 1.1.0 to stay locked, got %s", v)
+	}
+
+	if code := c.Run([]string{"-upgrade", templateDir}); code != 0 {
+		fatalCommand(t, c.Meta)
+	}
+	lock, _ = plugingetter.ReadLockfile(filepath.Join(templateDir, plugingetter.LockfileName))
+	if v := lock.Plugin("github.com/hashicorp/null").Version; v != "1.2.0" {
+		t.Fatalf("expected null 1.2.0 to be locked with -upgrade, got %s", v)
+	}
+}
+
+func TestBuild_requiredPlugins(t *testing.T) {
+	templateDir, installDir, cleanup := testRequiredPluginsDirs(t)
+	defer cleanup()
+
+	var loaded []string
+	meta := testMetaFile(t)
+	meta.CoreConfig.Components.LoadPluginFolder = func(path string) error {
+		loaded = append(loaded, path)
+		return nil
+	}
+
+	c := &BuildCommand{
+		Meta: meta,
+	}
+	if code := c.Run([]string{templateDir}); code != 1 {
+		t.Fatalf("expected the build to fail without a lockfile, got %d", code)
+	}
+	if len(loaded) != 0 {
+		t.Fatalf("expected no plugin to be loaded, got %v", loaded)
+	}
+
+	initCommand := &InitCommand{
+		Meta: meta,
+	}
+	if code := initCommand.Run([]string{templateDir}); code != 0 {
+		fatalCommand(t, initCommand.Meta)
+	}
+	if code := c.Run([]string{templateDir}); code != 0 {
+		fatalCommand(t, c.Meta)
+	}
+	if len(loaded) != 1 || loaded[0] != installDir {
+		t.Fatalf("expected %s to be loaded, got %v", installDir, loaded)
+	}
+
+	// Tampering with the binaries of the locked version fails the build,
+	// unless the lockfile is ignored.
+	loaded = nil
+	if err := ioutil.WriteFile(filepath.Join(installDir, "packer-builder-null"), []byte("tampered"), 0755); err != nil {
+		t.Fatalf("WriteFile: %s", err)
+	}
+	if code := c.Run([]string{templateDir}); code != 1 {
+		t.Fatalf("expected the build to fail with a tampered plugin, got %d", code)
+	}
+	if len(loaded) != 0 {
+		t.Fatalf("expected no plugin to be loaded, got %v", loaded)
+	}
+	if code := c.Run([]string{"-ignore-lockfile", templateDir}); code != 0 {
+		fatalCommand(t, c.Meta)
+	}
+	if len(loaded) != 1 || loaded[0] != installDir {
+		t.Fatalf("expected %s to be loaded with -ignore-lockfile, got %v", installDir, loaded)
+	}
+}
+
+func TestInit_registry(t *testing.T) {
+	templateDir, installDir, cleanup := testRequiredPluginsDirs(t)
+	defer cleanup()
+	template := strings.Replace(requiredPluginsTemplate, "github.com/hashicorp/null", "plugins.example.com/hashicorp/null", 1)
+	if err := ioutil.WriteFile(filepath.Join(templateDir, "build.pkr.hcl"), []byte(template), 0644); err != nil {
+		t.Fatalf("WriteFile: %s", err)
+	}
+
+	opts, _ := listInstallationsOptions()
+	buf := &bytes.Buffer{}
+	w := zip.NewWriter(buf)
+	f, _ := w.Create("packer-builder-null")
+	f.Write([]byte("1.5.0"))
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close: %s", err)
+	}
+	archive := buf.Bytes()
+	sum := sha256.Sum256(archive)
+	index := fmt.Sprintf(`{"versions": {"1.5.0": {%q: {"url": "null_1.5.0.zip", "sha256": "%x"}}}}`, opts.Platform(), sum)
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey: %s", err)
+	}
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/hashicorp/null/index.json":
+			w.Write([]byte(index))
+		case "/hashicorp/null/index.json.sig":
+			w.Write([]byte(base64.StdEncoding.EncodeToString(ed25519.Sign(priv, []byte(index)))))
+		case "/hashicorp/null/null_1.5.0.zip":
+			w.Write(archive)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	caFile := filepath.Join(templateDir, "ca.pem")
+	ca := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	if err := ioutil.WriteFile(caFile, ca, 0644); err != nil {
+		t.Fatalf("WriteFile: %s", err)
+	}
+
+	c := &InitCommand{
+		Meta: testMetaFile(t),
+	}
+	if code := c.Run([]string{templateDir}); code != 1 {
+		t.Fatalf("expected init to fail without a registry, got %d", code)
+	}
+
+	c.Meta.PluginRegistries = plugingetter.Registries{{
+		Hostname:   "plugins.example.com",
+		Index:      srv.URL,
+		PublicKey:  base64.StdEncoding.EncodeToString(pub),
+		CACertFile: caFile,
+	}}
+	if code := c.Run([]string{templateDir}); code != 0 {
+		fatalCommand(t, c.Meta)
+	}
+
+	// The plugin is installed in the first plugin folder.
+	pluginDir := filepath.Dir(filepath.Dir(filepath.Dir(filepath.Dir(filepath.Dir(installDir)))))
+	dir := filepath.Join(pluginDir, "plugins.example.com", "hashicorp", "null", "1.5.0", opts.Platform())
+	content, err := ioutil.ReadFile(filepath.Join(dir, "packer-builder-null"))
+	if err != nil || string(content) != "1.5.0" {
+		t.Fatalf("unexpected installed binary %q: %v", content, err)
+	}
+	lock, err := plugingetter.ReadLockfile(filepath.Join(templateDir, plugingetter.LockfileName))
+	if err != nil {
+		t.Fatalf("ReadLockfile: %s", err)
+	}
+	if locked := lock.Plugin("plugins.example.com/hashicorp/null"); locked == nil || locked.Version != "1.5.0" {
+		t.Fatalf("expected null 1.5.0 to be locked, got %#v", lock.Plugins)
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/hashicorp/packer/helper/wrappedstreams"
 	"github.com/hashicorp/packer/packer"
 	"github.com/hashicorp/packer/packer-plugin-sdk/template"
+	plugingetter "github.com/hashicorp/packer/packer/plugin-getter"
 )
 
 // FlagSetFlags is an enum to define what flags are present in the
@@ -28,6 +29,10 @@ type Meta struct {
 	CoreConfig *packer.CoreConfig
 	Ui         packer.Ui
 	Version    string
+
+	// PluginRegistries are the registries packer init installs the
+	// required plugins from.
+	PluginRegistries plugingetter.Registries
 }
 
 // Core returns the core for the given template given the configured
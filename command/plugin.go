@@ -33,6 +33,7 @@ import (
 	hypervvmcxbuilder "github.com/hashicorp/packer/builder/hyperv/vmcx"
 	jdcloudbuilder "github.com/hashicorp/packer/builder/jdcloud"
 	linodebuilder "github.com/hashicorp/packer/builder/linode"
+	linuxchrootbuilder "github.com/hashicorp/packer/builder/linux/chroot"
 	lxcbuilder "github.com/hashicorp/packer/builder/lxc"
 	lxdbuilder "github.com/hashicorp/packer/builder/lxd"
 	ncloudbuilder "github.com/hashicorp/packer/builder/ncloud"
@@ -65,9 +66,14 @@ import (
 	vsphereclonebuilder "github.com/hashicorp/packer/builder/vsphere/clone"
 	vsphereisobuilder "github.com/hashicorp/packer/builder/vsphere/iso"
 	yandexbuilder "github.com/hashicorp/packer/builder/yandex"
+	filedatasource "github.com/hashicorp/packer/datasource/file"
+	httpdatasource "github.com/hashicorp/packer/datasource/http"
+	vaultdatasource "github.com/hashicorp/packer/datasource/vault"
 	alicloudimportpostprocessor "github.com/hashicorp/packer/post-processor/alicloud-import"
 	amazonimportpostprocessor "github.com/hashicorp/packer/post-processor/amazon-import"
+	artifactuploadpostprocessor "github.com/hashicorp/packer/post-processor/artifact-upload"
 	artificepostprocessor "github.com/hashicorp/packer/post-processor/artifice"
+	azurecomputegallerypostprocessor "github.com/hashicorp/packer/post-processor/azure-compute-gallery"
 	checksumpostprocessor "github.com/hashicorp/packer/post-processor/checksum"
 	compresspostprocessor "github.com/hashicorp/packer/post-processor/compress"
 	digitaloceanimportpostprocessor "github.com/hashicorp/packer/post-processor/digitalocean-import"
@@ -79,6 +85,7 @@ import (
 	googlecomputeexportpostprocessor "github.com/hashicorp/packer/post-processor/googlecompute-export"
 	googlecomputeimportpostprocessor "github.com/hashicorp/packer/post-processor/googlecompute-import"
 	manifestpostprocessor "github.com/hashicorp/packer/post-processor/manifest"
+	ovapostprocessor "github.com/hashicorp/packer/post-processor/ova"
 	shelllocalpostprocessor "github.com/hashicorp/packer/post-processor/shell-local"
 	ucloudimportpostprocessor "github.com/hashicorp/packer/post-processor/ucloud-import"
 	vagrantpostprocessor "github.com/hashicorp/packer/post-processor/vagrant"
@@ -97,14 +104,17 @@ import (
 	fileprovisioner "github.com/hashicorp/packer/provisioner/file"
 	inspecprovisioner "github.com/hashicorp/packer/provisioner/inspec"
 	powershellprovisioner "github.com/hashicorp/packer/provisioner/powershell"
+	powershelldscprovisioner "github.com/hashicorp/packer/provisioner/powershell-dsc"
 	puppetmasterlessprovisioner "github.com/hashicorp/packer/provisioner/puppet-masterless"
 	puppetserverprovisioner "github.com/hashicorp/packer/provisioner/puppet-server"
 	saltmasterlessprovisioner "github.com/hashicorp/packer/provisioner/salt-masterless"
 	shellprovisioner "github.com/hashicorp/packer/provisioner/shell"
 	shelllocalprovisioner "github.com/hashicorp/packer/provisioner/shell-local"
 	sleepprovisioner "github.com/hashicorp/packer/provisioner/sleep"
+	verifyprovisioner "github.com/hashicorp/packer/provisioner/verify"
 	windowsrestartprovisioner "github.com/hashicorp/packer/provisioner/windows-restart"
 	windowsshellprovisioner "github.com/hashicorp/packer/provisioner/windows-shell"
+	windowsupdateprovisioner "github.com/hashicorp/packer/provisioner/windows-update"
 )
 
 type PluginCommand struct {
@@ -132,6 +142,7 @@ var Builders = map[string]packer.Builder{
 	"hyperv-vmcx":         new(hypervvmcxbuilder.Builder),
 	"jdcloud":             new(jdcloudbuilder.Builder),
 	"linode":              new(linodebuilder.Builder),
+	"linux-chroot":        new(linuxchrootbuilder.Builder),
 	"lxc":                 new(lxcbuilder.Builder),
 	"lxd":                 new(lxdbuilder.Builder),
 	"ncloud":              new(ncloudbuilder.Builder),
@@ -177,39 +188,51 @@ var Provisioners = map[string]packer.Provisioner{
 	"file":              new(fileprovisioner.Provisioner),
 	"inspec":            new(inspecprovisioner.Provisioner),
 	"powershell":        new(powershellprovisioner.Provisioner),
+	"powershell-dsc":    new(powershelldscprovisioner.Provisioner),
 	"puppet-masterless": new(puppetmasterlessprovisioner.Provisioner),
 	"puppet-server":     new(puppetserverprovisioner.Provisioner),
 	"salt-masterless":   new(saltmasterlessprovisioner.Provisioner),
 	"shell":             new(shellprovisioner.Provisioner),
 	"shell-local":       new(shelllocalprovisioner.Provisioner),
 	"sleep":             new(sleepprovisioner.Provisioner),
+	"verify":            new(verifyprovisioner.Provisioner),
 	"windows-restart":   new(windowsrestartprovisioner.Provisioner),
 	"windows-shell":     new(windowsshellprovisioner.Provisioner),
+	"windows-update":    new(windowsupdateprovisioner.Provisioner),
 }
 
 var PostProcessors = map[string]packer.PostProcessor{
-	"alicloud-import":      new(alicloudimportpostprocessor.PostProcessor),
-	"amazon-import":        new(amazonimportpostprocessor.PostProcessor),
-	"artifice":             new(artificepostprocessor.PostProcessor),
-	"checksum":             new(checksumpostprocessor.PostProcessor),
-	"compress":             new(compresspostprocessor.PostProcessor),
-	"digitalocean-import":  new(digitaloceanimportpostprocessor.PostProcessor),
-	"docker-import":        new(dockerimportpostprocessor.PostProcessor),
-	"docker-push":          new(dockerpushpostprocessor.PostProcessor),
-	"docker-save":          new(dockersavepostprocessor.PostProcessor),
-	"docker-tag":           new(dockertagpostprocessor.PostProcessor),
-	"exoscale-import":      new(exoscaleimportpostprocessor.PostProcessor),
-	"googlecompute-export": new(googlecomputeexportpostprocessor.PostProcessor),
-	"googlecompute-import": new(googlecomputeimportpostprocessor.PostProcessor),
-	"manifest":             new(manifestpostprocessor.PostProcessor),
-	"shell-local":          new(shelllocalpostprocessor.PostProcessor),
-	"ucloud-import":        new(ucloudimportpostprocessor.PostProcessor),
-	"vagrant":              new(vagrantpostprocessor.PostProcessor),
-	"vagrant-cloud":        new(vagrantcloudpostprocessor.PostProcessor),
-	"vsphere":              new(vspherepostprocessor.PostProcessor),
-	"vsphere-template":     new(vspheretemplatepostprocessor.PostProcessor),
-	"yandex-export":        new(yandexexportpostprocessor.PostProcessor),
-	"yandex-import":        new(yandeximportpostprocessor.PostProcessor),
+	"alicloud-import":       new(alicloudimportpostprocessor.PostProcessor),
+	"amazon-import":         new(amazonimportpostprocessor.PostProcessor),
+	"artifice":              new(artificepostprocessor.PostProcessor),
+	"artifact-upload":       new(artifactuploadpostprocessor.PostProcessor),
+	"azure-compute-gallery": new(azurecomputegallerypostprocessor.PostProcessor),
+	"checksum":              new(checksumpostprocessor.PostProcessor),
+	"compress":              new(compresspostprocessor.PostProcessor),
+	"digitalocean-import":   new(digitaloceanimportpostprocessor.PostProcessor),
+	"docker-import":         new(dockerimportpostprocessor.PostProcessor),
+	"docker-push":           new(dockerpushpostprocessor.PostProcessor),
+	"docker-save":           new(dockersavepostprocessor.PostProcessor),
+	"docker-tag":            new(dockertagpostprocessor.PostProcessor),
+	"exoscale-import":       new(exoscaleimportpostprocessor.PostProcessor),
+	"googlecompute-export":  new(googlecomputeexportpostprocessor.PostProcessor),
+	"googlecompute-import":  new(googlecomputeimportpostprocessor.PostProcessor),
+	"manifest":              new(manifestpostprocessor.PostProcessor),
+	"ova":                   new(ovapostprocessor.PostProcessor),
+	"shell-local":           new(shelllocalpostprocessor.PostProcessor),
+	"ucloud-import":         new(ucloudimportpostprocessor.PostProcessor),
+	"vagrant":               new(vagrantpostprocessor.PostProcessor),
+	"vagrant-cloud":         new(vagrantcloudpostprocessor.PostProcessor),
+	"vsphere":               new(vspherepostprocessor.PostProcessor),
+	"vsphere-template":      new(vspheretemplatepostprocessor.PostProcessor),
+	"yandex-export":         new(yandexexportpostprocessor.PostProcessor),
+	"yandex-import":         new(yandeximportpostprocessor.PostProcessor),
+}
+
+var Datasources = map[string]packer.Datasource{
+	"file":  new(filedatasource.Datasource),
+	"http":  new(httpdatasource.Datasource),
+	"vault": new(vaultdatasource.Datasource),
 }
 
 var pluginRegexp = regexp.MustCompile("packer-(builder|post-processor|provisioner)-(.+)")
@@ -0,0 +1,112 @@
+package command
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/hashicorp/packer/hcl2template"
+	"github.com/hashicorp/packer/packer"
+	plugingetter "github.com/hashicorp/packer/packer/plugin-getter"
+)
+
+// pluginFolders returns the folders the required plugins are installed in,
+// by order of preference: the folders of PACKER_PLUGIN_PATH, then the
+// plugins folder of the config directory.
+func pluginFolders() ([]string, error) {
+	var folders []string
+	if packerPluginPath := os.Getenv("PACKER_PLUGIN_PATH"); packerPluginPath != "" {
+		folders = append(folders, filepath.SplitList(packerPluginPath)...)
+	}
+	dir, err := packer.ConfigDir()
+	if err != nil {
+		return nil, err
+	}
+	return append(folders, filepath.Join(dir, "plugins")), nil
+}
+
+func listInstallationsOptions() (plugingetter.ListInstallationsOptions, error) {
+	folders, err := pluginFolders()
+	return plugingetter.ListInstallationsOptions{
+		FromFolders: folders,
+		OS:          runtime.GOOS,
+		ARCH:        runtime.GOARCH,
+	}, err
+}
+
+// loadRequiredPlugins loads the versions of the required plugins of an HCL2
+// config that its lockfile locks, once it checked that their binaries are
+// the ones that were locked. With ignoreLockfile, the newest installed
+// versions that match the version constraints are loaded, unchecked.
+func (m *Meta) loadRequiredPlugins(handler packer.Handler, ignoreLockfile bool) int {
+	cfg, ok := handler.(*hcl2template.PackerConfig)
+	if !ok {
+		return 0
+	}
+	reqs := cfg.PluginRequirements()
+	if len(reqs) == 0 {
+		return 0
+	}
+	if m.CoreConfig.Components.LoadPluginFolder == nil {
+		m.Ui.Error("This configuration has required plugins, but plugins can't be loaded.")
+		return 1
+	}
+
+	opts, err := listInstallationsOptions()
+	if err != nil {
+		m.Ui.Error(fmt.Sprintf("Failed to find the plugin folders: %s", err))
+		return 1
+	}
+
+	lockfilePath := filepath.Join(cfg.Basedir, plugingetter.LockfileName)
+	lock := &plugingetter.Lockfile{}
+	if ignoreLockfile {
+		m.Ui.Say(fmt.Sprintf("Warning: -ignore-lockfile is set, the plugins are not verified against %s.", lockfilePath))
+	} else {
+		lock, err = plugingetter.ReadLockfile(lockfilePath)
+		if err != nil {
+			m.Ui.Error(fmt.Sprintf("Failed to read the lockfile: %s", err))
+			return 1
+		}
+	}
+
+	ret := 0
+	for _, pr := range reqs {
+		var install *plugingetter.Installation
+		if ignoreLockfile {
+			installs, err := pr.ListInstallations(opts)
+			if err != nil {
+				m.Ui.Error(fmt.Sprintf("Failed to list the installations of the plugin %q: %s", pr.Accessor, err))
+				ret = 1
+				continue
+			}
+			install = installs.Newest()
+			if install == nil {
+				m.Ui.Error(fmt.Sprintf("No installed version of the plugin %q (%s) matches %q, run packer init to install one.",
+					pr.Accessor, pr.Identifier, pr.VersionConstraints))
+				ret = 1
+				continue
+			}
+		} else {
+			install, err = pr.Verify(lock, opts)
+			if err != nil {
+				m.Ui.Error(fmt.Sprintf("Plugin %q: %s", pr.Accessor, err))
+				ret = 1
+				continue
+			}
+		}
+
+		log.Printf("[INFO] Loading the plugin %s %s from %s", pr.Identifier, install.Version, install.Dir)
+		if err := m.CoreConfig.Components.LoadPluginFolder(install.Dir); err != nil {
+			m.Ui.Error(fmt.Sprintf("Failed to load the plugin %q: %s", pr.Accessor, err))
+			ret = 1
+		}
+	}
+	if ret != 0 && !ignoreLockfile {
+		m.Ui.Error(fmt.Sprintf("The installed plugins don't match the lockfile %s. Run packer init "+
+			"to update it, or pass -ignore-lockfile to use the installed plugins anyway.", lockfilePath))
+	}
+	return ret
+}
@@ -0,0 +1,4 @@
+locals {
+  x     = local.undefined_thing
+  fruit = "potato"
+}
@@ -97,9 +97,9 @@ build {
   sources = ["source.amazon-ebs.autogenerated_1"]
 
   provisioner "shell" {
-    except      = ["amazon-ebs"]
+    except      = ["amazon-ebs.autogenerated_1"]
     inline      = ["echo ${var.secret_account}", "echo ${build.ID}", "echo ${build.SSHPublicKey} | head -c 14", "echo ${path.root} is not ${path.cwd}", "echo ${packer.version}", "echo ${uuidv4()}"]
-    max_retries = "5"
+    max_retries = 5
   }
 
   # template: hcl2_upgrade:2:38: executing "hcl2_upgrade" at <clean_resource_name>: error calling clean_resource_name: unhandled "clean_resource_name" call:
@@ -109,49 +109,29 @@ build {
   provisioner "shell" {
     inline = ["echo mybuild-{{isotime | clean_resource_name}}"]
   }
-
-  # template: hcl2_upgrade:2:35: executing "hcl2_upgrade" at <lower>: error calling lower: unhandled "lower" call:
-  # there is no way to automatically upgrade the "lower" call.
-  # Please manually upgrade to `lower(var.example)`
-  # Visit https://www.packer.io/docs/from-1.5/functions/string/lower for more infos.
   provisioner "shell" {
-    inline = ["echo {{ `SOMETHING` | lower }}"]
+    inline = ["echo ${lower("SOMETHING")}"]
   }
-
-  # template: hcl2_upgrade:2:35: executing "hcl2_upgrade" at <upper>: error calling upper: unhandled "upper" call:
-  # there is no way to automatically upgrade the "upper" call.
-  # Please manually upgrade to `upper(var.example)`
-  # Visit https://www.packer.io/docs/from-1.5/functions/string/upper for more infos.
   provisioner "shell" {
-    inline = ["echo {{ `something` | upper }}"]
+    inline = ["echo ${upper("something")}"]
   }
-
-  # template: hcl2_upgrade:2:21: executing "hcl2_upgrade" at <split `some-string` `-` 0>: error calling split: unhandled "split" call:
-  # there is no way to automatically upgrade the "split" call.
-  # Please manually upgrade to `split(separator, string)`
-  # Visit https://www.packer.io/docs/from-1.5/functions/string/split for more infos.
   provisioner "shell" {
-    inline = ["echo {{ split `some-string` `-` 0 }}"]
+    inline = ["echo ${split("-", "some-string")[0]}"]
   }
-
-  # template: hcl2_upgrade:2:21: executing "hcl2_upgrade" at <replace_all `-` `/` build_name>: error calling replace_all: unhandled "replace_all" call:
-  # there is no way to automatically upgrade the "replace_all" call.
-  # Please manually upgrade to `replace(string, substring, replacement)` or `regex_replace(string, substring, replacement)`
-  # Visit https://www.packer.io/docs/from-1.5/functions/string/replace or https://www.packer.io/docs/from-1.5/functions/string/regex_replace for more infos.
   provisioner "shell" {
-    inline = ["echo {{ replace_all `-` `/` build_name }}"]
+    inline = ["echo ${replace(build.name, "-", "/")}"]
   }
 
-  # template: hcl2_upgrade:2:21: executing "hcl2_upgrade" at <replace `some-string` `-` `/` 1>: error calling replace: unhandled "replace" call:
+  # template: hcl2_upgrade:2:21: executing "hcl2_upgrade" at <replace `-` `/` 1 `some-string`>: error calling replace: unhandled "replace" call:
   # there is no way to automatically upgrade the "replace" call.
   # Please manually upgrade to `replace(string, substring, replacement)` or `regex_replace(string, substring, replacement)`
   # Visit https://www.packer.io/docs/from-1.5/functions/string/replace or https://www.packer.io/docs/from-1.5/functions/string/regex_replace for more infos.
   provisioner "shell" {
-    inline = ["echo {{ replace `some-string` `-` `/` 1 }}"]
+    inline = ["echo {{ replace `-` `/` 1 `some-string` }}"]
   }
   provisioner "shell-local" {
     inline  = ["sleep 100000"]
-    only    = ["amazon-ebs"]
+    only    = ["amazon-ebs.autogenerated_1"]
     timeout = "5s"
   }
   post-processor "amazon-import" {
@@ -168,10 +148,10 @@ build {
       keep_input_artifact = true
       files               = ["path/something.ova"]
       name                = "very_special_artifice_post-processor"
-      only                = ["amazon-ebs"]
+      only                = ["amazon-ebs.autogenerated_1"]
     }
     post-processor "amazon-import" {
-      except         = ["amazon-ebs"]
+      except         = ["amazon-ebs.autogenerated_1"]
       license_type   = "BYOL"
       s3_bucket_name = "hashicorp.adrien"
       tags = {
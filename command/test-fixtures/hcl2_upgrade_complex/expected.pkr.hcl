@@ -0,0 +1,84 @@
+# This file was autogenerated by the BETA 'packer hcl2_upgrade' command. We
+# recommend double checking that everything is correct before going forward. We
+# also recommend treating this file as disposable. The HCL2 blocks in this
+# file can be moved to other files. For example, the variable blocks could be
+# moved to their own 'variables.pkr.hcl' file, etc. Those files need to be
+# suffixed with '.pkr.hcl' to be visible to Packer. To use multiple files at
+# once they also need to be in the same folder. 'packer inspect folder/'
+# will describe to you what is in that folder.
+
+# Avoid mixing go templating calls ( for example ```{{ upper(`string`) }}``` ) 
+# and HCL2 calls (for example '${ var.string_value_example }' ). They won't be
+# executed together and the outcome will be unknown.
+
+# All generated input variables will be of 'string' type as this is how Packer JSON
+# views them; you can change their type later on. Read the variables type
+# constraints documentation
+# https://www.packer.io/docs/from-1.5/variables#type-constraints for more info.
+variable "image_name" {
+  type    = string
+  default = "Ubuntu-Xenial"
+}
+
+variable "ssh_password" {
+  type    = string
+  default = "packer"
+}
+
+# "timestamp" template function replacement
+locals { timestamp = regex_replace(timestamp(), "[- TZ:]", "") }
+
+# source blocks are generated from your builders; a source can be referenced in
+# build blocks. A build block runs provisioner and post-processors on a
+# source. Read the documentation for source blocks here:
+# https://www.packer.io/docs/from-1.5/blocks/source
+source "amazon-ebs" "ubuntu" {
+  ami_name          = "${lower(var.image_name)}-${local.timestamp}"
+  ena_support       = true
+  instance_type     = "t2.micro"
+  region            = "eu-west-3"
+  shutdown_behavior = "terminate"
+  source_ami        = "ami-0ab0a2e2d9e3a4e9a"
+  ssh_interface     = "private_ip"
+  ssh_timeout       = "30m"
+  ssh_username      = "ubuntu"
+}
+
+source "null" "autogenerated_2" {
+  communicator = "none"
+}
+
+# a build block invokes sources and runs provisioning steps on them. The
+# documentation for build blocks can be found here:
+# https://www.packer.io/docs/from-1.5/blocks/build
+build {
+  sources = ["source.amazon-ebs.ubuntu", "source.null.autogenerated_2"]
+
+  provisioner "shell" {
+    inline       = ["echo ${split("-", var.image_name)[0]}"]
+    max_retries  = 3
+    only         = ["amazon-ebs.ubuntu"]
+    pause_before = "10s"
+    override = {
+      ubuntu = {
+        execute_command = "echo '${var.ssh_password}' | sudo -S sh '{{ .Path }}'"
+      }
+    }
+  }
+  provisioner "shell-local" {
+    except = ["amazon-ebs.ubuntu"]
+    inline = ["echo ${replace(var.image_name, "-", "_")}", "echo ${var.image_name}-${build.name} | ${upper("tr")}"]
+  }
+  post-processor "manifest" {
+  }
+  post-processors {
+    post-processor "shell-local" {
+      inline = ["echo ${var.image_name}"]
+      only   = ["amazon-ebs.ubuntu"]
+    }
+    post-processor "manifest" {
+      except = ["null.autogenerated_2"]
+      output = "${var.image_name}-manifest.json"
+    }
+  }
+}
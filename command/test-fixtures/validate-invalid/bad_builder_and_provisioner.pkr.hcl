@@ -0,0 +1,10 @@
+source "file" "chocolate" {
+  content = "chocolate"
+}
+
+build {
+  sources = ["source.file.chocolate"]
+
+  provisioner "shell-local" {
+  }
+}
@@ -55,6 +55,9 @@ func (c *ValidateCommand) RunContext(ctx context.Context, cla *ValidateArgs) int
 		c.Ui.Say("Syntax-only check passed. Everything looks okay.")
 		return 0
 	}
+	if ret := c.loadRequiredPlugins(packerStarter, cla.IgnoreLockfile); ret != 0 {
+		return ret
+	}
 
 	diags := packerStarter.Initialize()
 	ret = writeDiags(c.Ui, nil, diags)
@@ -63,8 +66,9 @@ func (c *ValidateCommand) RunContext(ctx context.Context, cla *ValidateArgs) int
 	}
 
 	_, diags = packerStarter.GetBuilds(packer.GetBuildsOptions{
-		Only:   cla.Only,
-		Except: cla.Except,
+		Only:     cla.Only,
+		Except:   cla.Except,
+		Validate: cla.Deep,
 	})
 
 	fixerDiags := packerStarter.FixConfig(packer.FixConfigOptions{
@@ -89,6 +93,8 @@ Usage: packer validate [options] TEMPLATE
 Options:
 
   -syntax-only           Only check syntax. Do not verify config of the template.
+  -deep                  Check the config of every builder, provisioner and post-processor, and report all the errors. Credentials are not required and remote services are not reached.
+  -ignore-lockfile       Load the newest installed versions of the required plugins, without verifying them against the lockfile.
   -except=foo,bar,baz    Validate all builds other than these.
   -only=foo,bar,baz      Validate only these builds.
   -var 'key=value'       Variable for templates, can be used multiple times.
@@ -108,10 +114,12 @@ func (*ValidateCommand) AutocompleteArgs() complete.Predictor {
 
 func (*ValidateCommand) AutocompleteFlags() complete.Flags {
 	return complete.Flags{
-		"-syntax-only": complete.PredictNothing,
-		"-except":      complete.PredictNothing,
-		"-only":        complete.PredictNothing,
-		"-var":         complete.PredictNothing,
-		"-var-file":    complete.PredictNothing,
+		"-syntax-only":     complete.PredictNothing,
+		"-deep":            complete.PredictNothing,
+		"-ignore-lockfile": complete.PredictNothing,
+		"-except":          complete.PredictNothing,
+		"-only":            complete.PredictNothing,
+		"-var":             complete.PredictNothing,
+		"-var-file":        complete.PredictNothing,
 	}
 }
@@ -2,6 +2,7 @@ package command
 
 import (
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
@@ -72,6 +73,38 @@ func TestValidateCommand_SyntaxOnly(t *testing.T) {
 	}
 }
 
+func TestValidateCommand_Deep(t *testing.T) {
+	for _, name := range []string{"bad_builder_and_provisioner.json", "bad_builder_and_provisioner.pkr.hcl"} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(testFixture("validate-invalid"), name)
+
+			c := &ValidateCommand{
+				Meta: testMetaFile(t),
+			}
+			if code := c.Run([]string{path}); code != 1 {
+				fatalCommand(t, c.Meta)
+			}
+			out, stderr := outputCommand(t, c.Meta)
+			if strings.Contains(out+stderr, "Command, Inline, Script") {
+				t.Fatalf("provisioner should not be validated once the builder failed:\n%s%s", out, stderr)
+			}
+
+			c = &ValidateCommand{
+				Meta: testMetaFile(t),
+			}
+			if code := c.Run([]string{"-deep", path}); code != 1 {
+				fatalCommand(t, c.Meta)
+			}
+			out, stderr = outputCommand(t, c.Meta)
+			for _, err := range []string{"target required", "Command, Inline, Script"} {
+				if !strings.Contains(out+stderr, err) {
+					t.Fatalf("expected error %q:\n%s%s", err, out, stderr)
+				}
+			}
+		})
+	}
+}
+
 func TestValidateCommandOKVersion(t *testing.T) {
 	c := &ValidateCommand{
 		Meta: testMetaFile(t),
@@ -20,12 +20,30 @@ func init() {
 		"build": func() (cli.Command, error) {
 			return &command.BuildCommand{Meta: *CommandMeta}, nil
 		},
+		"cache": func() (cli.Command, error) {
+			return &command.CacheCommand{
+				Meta: *CommandMeta,
+			}, nil
+		},
+
+		"cache gc": func() (cli.Command, error) {
+			return &command.CacheGCCommand{
+				Meta: *CommandMeta,
+			}, nil
+		},
+
 		"console": func() (cli.Command, error) {
 			return &command.ConsoleCommand{
 				Meta: *CommandMeta,
 			}, nil
 		},
 
+		"destroy": func() (cli.Command, error) {
+			return &command.DestroyCommand{
+				Meta: *CommandMeta,
+			}, nil
+		},
+
 		"fix": func() (cli.Command, error) {
 			return &command.FixCommand{
 				Meta: *CommandMeta,
@@ -44,6 +62,12 @@ func init() {
 			}, nil
 		},
 
+		"init": func() (cli.Command, error) {
+			return &command.InitCommand{
+				Meta: *CommandMeta,
+			}, nil
+		},
+
 		"inspect": func() (cli.Command, error) {
 			return &command.InspectCommand{
 				Meta: *CommandMeta,
@@ -8,6 +8,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"reflect"
 	"runtime"
 	"sort"
 	"strings"
@@ -15,6 +16,7 @@ import (
 	"github.com/hashicorp/packer/command"
 	"github.com/hashicorp/packer/packer"
 	"github.com/hashicorp/packer/packer/plugin"
+	plugingetter "github.com/hashicorp/packer/packer/plugin-getter"
 )
 
 // PACKERSPACE is used to represent the spaces that separate args for a command
@@ -32,6 +34,8 @@ type config struct {
 	Builders                   packer.MapOfBuilder       `json:"-"`
 	Provisioners               packer.MapOfProvisioner   `json:"-"`
 	PostProcessors             packer.MapOfPostProcessor `json:"-"`
+	Datasources                packer.MapOfDatasource    `json:"-"`
+	PluginRegistries           plugingetter.Registries   `json:"registries"`
 }
 
 // decodeConfig decodes configuration in JSON format from the given io.Reader into
@@ -352,6 +356,18 @@ func (c *config) discoverInternalComponents() error {
 		}
 	}
 
+	// Data sources are run while the template is evaluated, so they are
+	// started in-process instead of as plugins.
+	for datasource, d := range command.Datasources {
+		t := reflect.TypeOf(d).Elem()
+		_, found := (c.Datasources)[datasource]
+		if !found {
+			c.Datasources[datasource] = func() (packer.Datasource, error) {
+				return reflect.New(t).Interface().(packer.Datasource), nil
+			}
+		}
+	}
+
 	return nil
 }
 
@@ -22,6 +22,7 @@ func newConfig() config {
 	conf.Builders = packer.MapOfBuilder{}
 	conf.PostProcessors = packer.MapOfPostProcessor{}
 	conf.Provisioners = packer.MapOfProvisioner{}
+	conf.Datasources = packer.MapOfDatasource{}
 
 	return conf
 }
@@ -123,7 +124,11 @@ func TestDecodeConfig(t *testing.T) {
 		"disable_checkpoint_signature": true,
 		"provisioners": {
 		    "super-shell": "packer-provisioner-super-shell"
-		}
+		},
+		"registries": [{
+		    "hostname": "plugins.example.com",
+		    "oci": "registry.example.com/packer-plugins"
+		}]
 	}`
 
 	var cfg config
@@ -137,6 +142,9 @@ func TestDecodeConfig(t *testing.T) {
 	if !reflect.DeepEqual(cfg, expectedCfg) {
 		t.Errorf("failed to load custom configuration data; expected %v got %v", expectedCfg, cfg)
 	}
+	if len(cfg.PluginRegistries) != 1 || cfg.PluginRegistries[0].OCI != "registry.example.com/packer-plugins" {
+		t.Errorf("failed to load the registries; got %v", cfg.PluginRegistries)
+	}
 
 }
 
@@ -0,0 +1,122 @@
+//go:generate mapstructure-to-hcl2 -type Config
+
+// Package file implements the file data source, that reads a local file when
+// the template is evaluated and can decode it from JSON or YAML.
+package file
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2/hcldec"
+	"github.com/hashicorp/packer/packer"
+	"github.com/hashicorp/packer/packer-plugin-sdk/template/config"
+	ctyyaml "github.com/zclconf/go-cty-yaml"
+	"github.com/zclconf/go-cty/cty"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+)
+
+const (
+	formatText = "text"
+	formatJSON = "json"
+	formatYAML = "yaml"
+)
+
+type Config struct {
+	// The path of the file to read. A relative path is relative to the
+	// directory Packer is run from; use `path.root` to read a file next to
+	// the template.
+	Path string `mapstructure:"path" required:"true"`
+	// How to decode the file into the `decoded` attribute of the data source:
+	// `json`, `yaml` or `text` to not decode it. Defaults to `json` for the
+	// .json files, to `yaml` for the .yaml and .yml files and to `text`
+	// otherwise.
+	Format string `mapstructure:"format" required:"false"`
+}
+
+type Datasource struct {
+	config Config
+}
+
+var _ packer.Datasource = new(Datasource)
+
+func (d *Datasource) ConfigSpec() hcldec.ObjectSpec { return d.config.FlatMapstructure().HCL2Spec() }
+
+func (d *Datasource) Configure(raws ...interface{}) error {
+	err := config.Decode(&d.config, &config.DecodeOpts{
+		PluginType: "packer.datasource.file",
+	}, raws...)
+	if err != nil {
+		return err
+	}
+
+	if d.config.Path == "" {
+		return fmt.Errorf("path must be specified")
+	}
+	if d.config.Format == "" {
+		switch strings.ToLower(filepath.Ext(d.config.Path)) {
+		case ".json":
+			d.config.Format = formatJSON
+		case ".yaml", ".yml":
+			d.config.Format = formatYAML
+		default:
+			d.config.Format = formatText
+		}
+	}
+	switch d.config.Format {
+	case formatText, formatJSON, formatYAML:
+	default:
+		return fmt.Errorf("format must be one of %q, %q or %q", formatText, formatJSON, formatYAML)
+	}
+	return nil
+}
+
+func (d *Datasource) OutputSpec() hcldec.ObjectSpec {
+	return hcldec.ObjectSpec{
+		"path":    &hcldec.AttrSpec{Name: "path", Type: cty.String},
+		"content": &hcldec.AttrSpec{Name: "content", Type: cty.String},
+		"sha256":  &hcldec.AttrSpec{Name: "sha256", Type: cty.String},
+		"decoded": &hcldec.AttrSpec{Name: "decoded", Type: cty.DynamicPseudoType},
+	}
+}
+
+func (d *Datasource) Execute() (cty.Value, error) {
+	content, err := ioutil.ReadFile(d.config.Path)
+	if err != nil {
+		return cty.NilVal, err
+	}
+	sum := sha256.Sum256(content)
+
+	decoded := cty.NullVal(cty.DynamicPseudoType)
+	switch d.config.Format {
+	case formatJSON:
+		ty, err := ctyjson.ImpliedType(content)
+		if err != nil {
+			return cty.NilVal, fmt.Errorf("%s is not JSON: %s", d.config.Path, err)
+		}
+		decoded, err = ctyjson.Unmarshal(content, ty)
+		if err != nil {
+			return cty.NilVal, fmt.Errorf("%s is not JSON: %s", d.config.Path, err)
+		}
+	case formatYAML:
+		ty, err := ctyyaml.ImpliedType(content)
+		if err != nil {
+			return cty.NilVal, fmt.Errorf("%s is not YAML: %s", d.config.Path, err)
+		}
+		decoded, err = ctyyaml.Unmarshal(content, ty)
+		if err != nil {
+			return cty.NilVal, fmt.Errorf("%s is not YAML: %s", d.config.Path, err)
+		}
+	}
+
+	return cty.ObjectVal(map[string]cty.Value{
+		"path":    cty.StringVal(d.config.Path),
+		"content": cty.StringVal(string(content)),
+		"sha256":  cty.StringVal(hex.EncodeToString(sum[:])),
+		"decoded": decoded,
+	}), nil
+}
@@ -0,0 +1,98 @@
+package file
+
+import (
+	"testing"
+
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestDatasourceConfigure(t *testing.T) {
+	cases := []struct {
+		raw     map[string]interface{}
+		format  string
+		wantErr bool
+	}{
+		{map[string]interface{}{}, "", true},
+		{map[string]interface{}{"path": "a.json"}, formatJSON, false},
+		{map[string]interface{}{"path": "a.YML"}, formatYAML, false},
+		{map[string]interface{}{"path": "a.yaml"}, formatYAML, false},
+		{map[string]interface{}{"path": "a.txt"}, formatText, false},
+		{map[string]interface{}{"path": "a.txt", "format": "yaml"}, formatYAML, false},
+		{map[string]interface{}{"path": "a.txt", "format": "toml"}, "", true},
+	}
+
+	for _, tc := range cases {
+		var d Datasource
+		err := d.Configure(tc.raw)
+		if (err != nil) != tc.wantErr {
+			t.Fatalf("%v: unexpected error: %v", tc.raw, err)
+		}
+		if err == nil && d.config.Format != tc.format {
+			t.Fatalf("%v: bad format %q, expected %q", tc.raw, d.config.Format, tc.format)
+		}
+	}
+}
+
+func TestDatasourceExecute(t *testing.T) {
+	var d Datasource
+	if err := d.Configure(map[string]interface{}{"path": "test-fixtures/versions.yaml"}); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	out, err := d.Execute()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	decoded := out.GetAttr("decoded")
+	if v := decoded.GetAttr("ubuntu").GetAttr("version"); !v.RawEquals(cty.StringVal("20.04")) {
+		t.Fatalf("bad yaml: %#v", decoded)
+	}
+	if r := decoded.GetAttr("regions").Index(cty.NumberIntVal(1)); !r.RawEquals(cty.StringVal("us-east-1")) {
+		t.Fatalf("bad yaml: %#v", decoded)
+	}
+
+	d = Datasource{}
+	if err := d.Configure(map[string]interface{}{"path": "test-fixtures/versions.json"}); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	out, err = d.Execute()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if v := out.GetAttr("decoded").GetAttr("ubuntu").GetAttr("version"); !v.RawEquals(cty.StringVal("20.04")) {
+		t.Fatalf("bad json: %#v", out.GetAttr("decoded"))
+	}
+
+	d = Datasource{}
+	if err := d.Configure(map[string]interface{}{"path": "test-fixtures/hello.txt"}); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	out, err = d.Execute()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if c := out.GetAttr("content"); !c.RawEquals(cty.StringVal("hello\n")) {
+		t.Fatalf("bad content: %#v", c)
+	}
+	sum := "5891b5b522d5df086d0ff0b110fbd9d21bb4fc7163af34d08286a2e846f6be03"
+	if s := out.GetAttr("sha256"); !s.RawEquals(cty.StringVal(sum)) {
+		t.Fatalf("bad sha256: %#v", s)
+	}
+	if !out.GetAttr("decoded").IsNull() {
+		t.Fatalf("text should not be decoded")
+	}
+}
+
+func TestDatasourceExecute_errors(t *testing.T) {
+	for _, raw := range []map[string]interface{}{
+		{"path": "test-fixtures/missing.yaml"},
+		{"path": "test-fixtures/hello.txt", "format": "json"},
+	} {
+		var d Datasource
+		if err := d.Configure(raw); err != nil {
+			t.Fatalf("err: %s", err)
+		}
+		if _, err := d.Execute(); err == nil {
+			t.Fatalf("%v should error", raw)
+		}
+	}
+}
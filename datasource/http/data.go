@@ -0,0 +1,129 @@
+//go:generate mapstructure-to-hcl2 -type Config
+
+// Package http implements the http data source, that fetches a URL when the
+// template is evaluated.
+package http
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/hashicorp/hcl/v2/hcldec"
+	"github.com/hashicorp/packer/packer"
+	"github.com/hashicorp/packer/packer-plugin-sdk/template/config"
+	"github.com/zclconf/go-cty/cty"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+)
+
+type Config struct {
+	// The URL to request.
+	Url string `mapstructure:"url" required:"true"`
+	// Additional headers to send with the request.
+	RequestHeaders map[string]string `mapstructure:"request_headers" required:"false"`
+	// Decode the body of the response as JSON, into the `json` attribute of
+	// the data source. Defaults to false.
+	DecodeJSON bool `mapstructure:"decode_json" required:"false"`
+	// How long to wait for the response. Defaults to 30s.
+	Timeout time.Duration `mapstructure:"timeout" required:"false"`
+}
+
+type Datasource struct {
+	config Config
+}
+
+var _ packer.Datasource = new(Datasource)
+
+func (d *Datasource) ConfigSpec() hcldec.ObjectSpec { return d.config.FlatMapstructure().HCL2Spec() }
+
+func (d *Datasource) Configure(raws ...interface{}) error {
+	err := config.Decode(&d.config, &config.DecodeOpts{
+		PluginType: "packer.datasource.http",
+	}, raws...)
+	if err != nil {
+		return err
+	}
+
+	var errs *packer.MultiError
+	if d.config.Url == "" {
+		errs = packer.MultiErrorAppend(errs, fmt.Errorf("url must be specified"))
+	}
+	if d.config.Timeout < 0 {
+		errs = packer.MultiErrorAppend(errs, fmt.Errorf("timeout can't be negative"))
+	}
+	if d.config.Timeout == 0 {
+		d.config.Timeout = 30 * time.Second
+	}
+	if errs != nil && len(errs.Errors) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func (d *Datasource) OutputSpec() hcldec.ObjectSpec {
+	return hcldec.ObjectSpec{
+		"url":              &hcldec.AttrSpec{Name: "url", Type: cty.String},
+		"status_code":      &hcldec.AttrSpec{Name: "status_code", Type: cty.Number},
+		"body":             &hcldec.AttrSpec{Name: "body", Type: cty.String},
+		"response_headers": &hcldec.AttrSpec{Name: "response_headers", Type: cty.Map(cty.String)},
+		"json":             &hcldec.AttrSpec{Name: "json", Type: cty.DynamicPseudoType},
+	}
+}
+
+func (d *Datasource) Execute() (cty.Value, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), d.config.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, d.config.Url, nil)
+	if err != nil {
+		return cty.NilVal, err
+	}
+	for name, value := range d.config.RequestHeaders {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return cty.NilVal, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return cty.NilVal, fmt.Errorf("%s returned %s", d.config.Url, resp.Status)
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return cty.NilVal, fmt.Errorf("error reading the response of %s: %s", d.config.Url, err)
+	}
+
+	headers := map[string]cty.Value{}
+	for name := range resp.Header {
+		headers[name] = cty.StringVal(resp.Header.Get(name))
+	}
+	responseHeaders := cty.MapValEmpty(cty.String)
+	if len(headers) > 0 {
+		responseHeaders = cty.MapVal(headers)
+	}
+
+	decoded := cty.NullVal(cty.DynamicPseudoType)
+	if d.config.DecodeJSON {
+		ty, err := ctyjson.ImpliedType(body)
+		if err != nil {
+			return cty.NilVal, fmt.Errorf("the response of %s is not JSON: %s", d.config.Url, err)
+		}
+		decoded, err = ctyjson.Unmarshal(body, ty)
+		if err != nil {
+			return cty.NilVal, fmt.Errorf("the response of %s is not JSON: %s", d.config.Url, err)
+		}
+	}
+
+	return cty.ObjectVal(map[string]cty.Value{
+		"url":              cty.StringVal(d.config.Url),
+		"status_code":      cty.NumberIntVal(int64(resp.StatusCode)),
+		"body":             cty.StringVal(string(body)),
+		"response_headers": responseHeaders,
+		"json":             decoded,
+	}), nil
+}
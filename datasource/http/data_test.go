@@ -0,0 +1,115 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/zclconf/go-cty/cty"
+)
+
+func testServer(t *testing.T) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/meta.json":
+			if r.Header.Get("Authorization") != "Bearer token" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"iso_url": "http://example.com/ubuntu.iso", "sizes": [1, 2]}`))
+		case "/SHA256SUMS":
+			w.Write([]byte("abc123  ubuntu.iso\n"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestDatasourceConfigure(t *testing.T) {
+	var d Datasource
+	if err := d.Configure(map[string]interface{}{}); err == nil {
+		t.Fatal("should error without url")
+	}
+
+	d = Datasource{}
+	err := d.Configure(map[string]interface{}{"url": "http://example.com"})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if d.config.Timeout.String() != "30s" {
+		t.Fatalf("bad default timeout: %s", d.config.Timeout)
+	}
+}
+
+func TestDatasourceExecute(t *testing.T) {
+	server := testServer(t)
+
+	var d Datasource
+	err := d.Configure(map[string]interface{}{"url": server.URL + "/SHA256SUMS"})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	out, err := d.Execute()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if body := out.GetAttr("body"); !body.RawEquals(cty.StringVal("abc123  ubuntu.iso\n")) {
+		t.Fatalf("bad body: %#v", body)
+	}
+	if code := out.GetAttr("status_code"); !code.RawEquals(cty.NumberIntVal(200)) {
+		t.Fatalf("bad status code: %#v", code)
+	}
+	if !out.GetAttr("json").IsNull() {
+		t.Fatalf("json should not be decoded: %#v", out.GetAttr("json"))
+	}
+}
+
+func TestDatasourceExecute_json(t *testing.T) {
+	server := testServer(t)
+
+	var d Datasource
+	err := d.Configure(map[string]interface{}{
+		"url":             server.URL + "/meta.json",
+		"request_headers": map[string]string{"Authorization": "Bearer token"},
+		"decode_json":     true,
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	out, err := d.Execute()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	decoded := out.GetAttr("json")
+	if url := decoded.GetAttr("iso_url"); !url.RawEquals(cty.StringVal("http://example.com/ubuntu.iso")) {
+		t.Fatalf("bad json: %#v", decoded)
+	}
+	headers := out.GetAttr("response_headers")
+	if ct := headers.Index(cty.StringVal("Content-Type")); !ct.RawEquals(cty.StringVal("application/json")) {
+		t.Fatalf("bad headers: %#v", headers)
+	}
+}
+
+func TestDatasourceExecute_errors(t *testing.T) {
+	server := testServer(t)
+
+	for _, raw := range []map[string]interface{}{
+		// not found
+		{"url": server.URL + "/missing"},
+		// unauthorized
+		{"url": server.URL + "/meta.json"},
+		// not JSON
+		{"url": server.URL + "/SHA256SUMS", "decode_json": true},
+	} {
+		var d Datasource
+		if err := d.Configure(raw); err != nil {
+			t.Fatalf("err: %s", err)
+		}
+		if _, err := d.Execute(); err == nil {
+			t.Fatalf("%v should error", raw)
+		}
+	}
+}
@@ -0,0 +1,160 @@
+//go:generate mapstructure-to-hcl2 -type Config
+
+// Package vault implements the vault data source, that reads a secret from
+// HashiCorp Vault when the template is evaluated.
+package vault
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"github.com/hashicorp/hcl/v2/hcldec"
+	"github.com/hashicorp/packer/packer"
+	"github.com/hashicorp/packer/packer-plugin-sdk/template/config"
+	vaultapi "github.com/hashicorp/vault/api"
+	"github.com/zclconf/go-cty/cty"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+)
+
+type Config struct {
+	// The path of the secret to read, like `secret/data/packer` for a KV v2
+	// secret or `aws/creds/packer` for dynamic AWS credentials.
+	Path string `mapstructure:"path" required:"true"`
+	// The address of the Vault server. Defaults to the `VAULT_ADDR`
+	// environment variable. The token is read from the `VAULT_TOKEN`
+	// environment variable.
+	Address string `mapstructure:"address" required:"false"`
+	// The Vault Enterprise namespace of the secret. Defaults to the
+	// `VAULT_NAMESPACE` environment variable.
+	Namespace string `mapstructure:"namespace" required:"false"`
+	// Don't renew the lease of the secret while Packer runs. By default, the
+	// lease of a renewable secret, like dynamic credentials, is renewed until
+	// Packer exits, so that the secret stays valid during long builds.
+	DisableRenewal bool `mapstructure:"disable_renewal" required:"false"`
+}
+
+type Datasource struct {
+	config Config
+}
+
+var _ packer.Datasource = new(Datasource)
+
+func (d *Datasource) ConfigSpec() hcldec.ObjectSpec { return d.config.FlatMapstructure().HCL2Spec() }
+
+func (d *Datasource) Configure(raws ...interface{}) error {
+	err := config.Decode(&d.config, &config.DecodeOpts{
+		PluginType: "packer.datasource.vault",
+	}, raws...)
+	if err != nil {
+		return err
+	}
+
+	if d.config.Path == "" {
+		return fmt.Errorf("path must be specified")
+	}
+	return nil
+}
+
+func (d *Datasource) OutputSpec() hcldec.ObjectSpec {
+	return hcldec.ObjectSpec{
+		"data":           &hcldec.AttrSpec{Name: "data", Type: cty.DynamicPseudoType},
+		"lease_id":       &hcldec.AttrSpec{Name: "lease_id", Type: cty.String},
+		"lease_duration": &hcldec.AttrSpec{Name: "lease_duration", Type: cty.Number},
+		"renewable":      &hcldec.AttrSpec{Name: "renewable", Type: cty.Bool},
+	}
+}
+
+func (d *Datasource) Execute() (cty.Value, error) {
+	client, err := vaultapi.NewClient(vaultapi.DefaultConfig())
+	if err != nil {
+		return cty.NilVal, fmt.Errorf("Error getting Vault client: %s", err)
+	}
+	if d.config.Address != "" {
+		if err := client.SetAddress(d.config.Address); err != nil {
+			return cty.NilVal, fmt.Errorf("Error setting Vault address: %s", err)
+		}
+	}
+	if d.config.Namespace != "" {
+		client.SetNamespace(d.config.Namespace)
+	}
+	if client.Token() == "" {
+		return cty.NilVal, fmt.Errorf("Must set VAULT_TOKEN env var in order to use the vault data source")
+	}
+
+	secret, err := client.Logical().Read(d.config.Path)
+	if err != nil {
+		return cty.NilVal, fmt.Errorf("Error reading vault secret: %s", err)
+	}
+	if secret == nil {
+		return cty.NilVal, fmt.Errorf("Vault secret %s does not exist", d.config.Path)
+	}
+
+	data, err := secretData(secret)
+	if err != nil {
+		return cty.NilVal, fmt.Errorf("Error decoding vault secret %s: %s", d.config.Path, err)
+	}
+	// The values of the secret must never be shown
+	packer.LogSecretFilter.SetValue(data)
+
+	if secret.Renewable && secret.LeaseID != "" && !d.config.DisableRenewal {
+		if err := renew(client, secret); err != nil {
+			return cty.NilVal, err
+		}
+	}
+
+	return cty.ObjectVal(map[string]cty.Value{
+		"data":           data,
+		"lease_id":       cty.StringVal(secret.LeaseID),
+		"lease_duration": cty.NumberIntVal(int64(secret.LeaseDuration)),
+		"renewable":      cty.BoolVal(secret.Renewable),
+	}), nil
+}
+
+// secretData returns the data of secret. The data of a KV v2 secret is
+// wrapped with its metadata, it is unwrapped.
+func secretData(secret *vaultapi.Secret) (cty.Value, error) {
+	data := secret.Data
+	if inner, ok := data["data"].(map[string]interface{}); ok {
+		if _, ok := data["metadata"]; ok {
+			data = inner
+		}
+	}
+	if data == nil {
+		data = map[string]interface{}{}
+	}
+
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return cty.NilVal, err
+	}
+	ty, err := ctyjson.ImpliedType(raw)
+	if err != nil {
+		return cty.NilVal, err
+	}
+	return ctyjson.Unmarshal(raw, ty)
+}
+
+// renew renews the lease of secret in the background, until Packer exits or
+// the lease can't be renewed anymore.
+func renew(client *vaultapi.Client, secret *vaultapi.Secret) error {
+	renewer, err := client.NewRenewer(&vaultapi.RenewerInput{Secret: secret})
+	if err != nil {
+		return fmt.Errorf("Error renewing the lease of vault secret: %s", err)
+	}
+	go renewer.Renew()
+	go func() {
+		for {
+			select {
+			case err := <-renewer.DoneCh():
+				if err != nil {
+					log.Printf("[WARN] stopped renewing vault lease %s: %s", secret.LeaseID, err)
+				}
+				return
+			case r := <-renewer.RenewCh():
+				log.Printf("[INFO] renewed vault lease %s at %s", secret.LeaseID, r.RenewedAt)
+			}
+		}
+	}()
+	return nil
+}
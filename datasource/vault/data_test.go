@@ -0,0 +1,105 @@
+package vault
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/zclconf/go-cty/cty"
+)
+
+func testVault(t *testing.T) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/v1/secret/data/packer":
+			w.Write([]byte(`{
+				"data": {
+					"data": {"password": "hunter2", "ports": [22, 5985]},
+					"metadata": {"version": 3}
+				}
+			}`))
+		case "/v1/aws/creds/packer":
+			w.Write([]byte(`{
+				"lease_id": "aws/creds/packer/abc",
+				"lease_duration": 3600,
+				"renewable": false,
+				"data": {"access_key": "AKIA", "secret_key": "secret"}
+			}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestDatasourceExecute(t *testing.T) {
+	server := testVault(t)
+	defer os.Setenv("VAULT_TOKEN", os.Getenv("VAULT_TOKEN"))
+	os.Setenv("VAULT_TOKEN", "token")
+
+	var d Datasource
+	err := d.Configure(map[string]interface{}{
+		"path":    "secret/data/packer",
+		"address": server.URL,
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	out, err := d.Execute()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	data := out.GetAttr("data")
+	if password := data.GetAttr("password"); !password.RawEquals(cty.StringVal("hunter2")) {
+		t.Fatalf("the kv v2 secret should be unwrapped: %#v", data)
+	}
+
+	d = Datasource{}
+	err = d.Configure(map[string]interface{}{
+		"path":    "aws/creds/packer",
+		"address": server.URL,
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	out, err = d.Execute()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if key := out.GetAttr("data").GetAttr("secret_key"); !key.RawEquals(cty.StringVal("secret")) {
+		t.Fatalf("bad: %#v", out)
+	}
+	if id := out.GetAttr("lease_id"); !id.RawEquals(cty.StringVal("aws/creds/packer/abc")) {
+		t.Fatalf("bad lease: %#v", id)
+	}
+	if duration := out.GetAttr("lease_duration"); !duration.RawEquals(cty.NumberIntVal(3600)) {
+		t.Fatalf("bad lease duration: %#v", duration)
+	}
+}
+
+func TestDatasourceExecute_errors(t *testing.T) {
+	server := testVault(t)
+	defer os.Setenv("VAULT_TOKEN", os.Getenv("VAULT_TOKEN"))
+
+	for token, path := range map[string]string{
+		"token": "secret/data/missing",
+		"wrong": "secret/data/packer",
+		"":      "secret/data/packer",
+	} {
+		os.Setenv("VAULT_TOKEN", token)
+		var d Datasource
+		if err := d.Configure(map[string]interface{}{"path": path, "address": server.URL}); err != nil {
+			t.Fatalf("err: %s", err)
+		}
+		if _, err := d.Execute(); err == nil {
+			t.Fatalf("reading %s with token %q should error", path, token)
+		}
+	}
+}
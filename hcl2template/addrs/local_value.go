@@ -0,0 +1,11 @@
+package addrs
+
+// LocalValue is the address of a local variable.
+type LocalValue struct {
+	referenceable
+	Name string
+}
+
+func (v LocalValue) String() string {
+	return "local." + v.Name
+}
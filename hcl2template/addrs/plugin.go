@@ -0,0 +1,54 @@
+package addrs
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Plugin is the address of a plugin, as set by the source of a required
+// plugin, like "github.com/hashicorp/amazon".
+type Plugin struct {
+	Hostname  string
+	Namespace string
+	Type      string
+}
+
+// Parts returns the hostname, namespace and type of the plugin; these are
+// also the folders a plugin is installed in.
+func (p *Plugin) Parts() []string {
+	return []string{p.Hostname, p.Namespace, p.Type}
+}
+
+func (p *Plugin) String() string {
+	return strings.Join(p.Parts(), "/")
+}
+
+var (
+	pluginHostnameRegexp = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]*[a-z0-9])?(\.[a-z0-9]([a-z0-9-]*[a-z0-9])?)*(:[0-9]+)?$`)
+	pluginNameRegexp     = regexp.MustCompile(`^[a-z0-9]([a-z0-9_-]*[a-z0-9])?$`)
+)
+
+// ParsePluginSourceString parses the source of a plugin, made of a hostname,
+// a namespace and a type, like "github.com/hashicorp/amazon".
+func ParsePluginSourceString(str string) (*Plugin, error) {
+	parts := strings.Split(str, "/")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("The source %q must be made of a hostname, a namespace and a type, like \"github.com/hashicorp/amazon\".", str)
+	}
+	p := &Plugin{
+		Hostname:  parts[0],
+		Namespace: parts[1],
+		Type:      parts[2],
+	}
+	if !pluginHostnameRegexp.MatchString(p.Hostname) {
+		return nil, fmt.Errorf("The hostname %q of the source %q is not a valid hostname.", p.Hostname, str)
+	}
+	if !pluginNameRegexp.MatchString(p.Namespace) {
+		return nil, fmt.Errorf("The namespace %q of the source %q can only contain lowercase letters, digits, dashes and underscores.", p.Namespace, str)
+	}
+	if !pluginNameRegexp.MatchString(p.Type) {
+		return nil, fmt.Errorf("The type %q of the source %q can only contain lowercase letters, digits, dashes and underscores.", p.Type, str)
+	}
+	return p, nil
+}
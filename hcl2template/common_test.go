@@ -32,6 +32,9 @@ func getBasicParser() *Parser {
 			"amazon-import": func() (packer.PostProcessor, error) { return &MockPostProcessor{}, nil },
 			"manifest":      func() (packer.PostProcessor, error) { return &MockPostProcessor{}, nil },
 		},
+		DatasourceSchemas: packer.MapOfDatasource{
+			"mock": func() (packer.Datasource, error) { return &MockDatasource{}, nil },
+		},
 	}
 }
 
@@ -225,6 +228,10 @@ var cmpOpts = []cmp.Option{
 		PackerConfig{},
 		Variable{},
 		SourceBlock{},
+		ModuleBlock{},
+		LimitBlock{},
+		DatasourceBlock{},
+		BuildBlock{},
 		ProvisionerBlock{},
 		PostProcessorBlock{},
 		packer.CoreBuild{},
@@ -241,6 +248,10 @@ var cmpOpts = []cmp.Option{
 	cmpopts.IgnoreFields(VariableAssignment{},
 		"Expr", // its an interface
 	),
+	// The plan configs are tested separately
+	cmpopts.IgnoreFields(packer.CoreBuild{}, "PlanConfig"),
+	cmpopts.IgnoreFields(packer.CoreBuildProvisioner{}, "PlanConfig"),
+	cmpopts.IgnoreFields(packer.CoreBuildPostProcessor{}, "PlanConfig"),
 	cmpopts.IgnoreTypes(HCL2Ref{}),
 	cmpopts.IgnoreTypes([]*LocalBlock{}),
 	cmpopts.IgnoreTypes([]hcl.Range{}),
@@ -3,6 +3,7 @@ package hcl2template
 import (
 	"github.com/hashicorp/hcl/v2"
 	"github.com/hashicorp/hcl/v2/hcldec"
+	hcl2shim "github.com/hashicorp/packer/hcl2template/shim"
 	"github.com/zclconf/go-cty/cty"
 )
 
@@ -16,3 +17,38 @@ type Decodable interface {
 func decodeHCL2Spec(body hcl.Body, ectx *hcl.EvalContext, dec Decodable) (cty.Value, hcl.Diagnostics) {
 	return hcldec.Decode(body, dec.ConfigSpec(), ectx)
 }
+
+// planConfig returns a decoded configuration the way the plan of a build
+// shows it, without the attributes that are not set. Blocks decode to empty
+// lists when they are not set, so empty lists are left out as well. The
+// attributes of the overrides replace the decoded ones.
+func planConfig(decoded cty.Value, overrides ...map[string]interface{}) map[string]interface{} {
+	config, _ := hcl2shim.ConfigValueFromHCL2(decoded).(map[string]interface{})
+	if config == nil {
+		config = map[string]interface{}{}
+	}
+	prunePlanConfig(config)
+	for _, override := range overrides {
+		for k, v := range override {
+			config[k] = v
+		}
+	}
+	return config
+}
+
+func prunePlanConfig(v interface{}) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, e := range v {
+			if l, ok := e.([]interface{}); ok && len(l) == 0 {
+				delete(v, k)
+				continue
+			}
+			prunePlanConfig(e)
+		}
+	case []interface{}:
+		for _, e := range v {
+			prunePlanConfig(e)
+		}
+	}
+}
@@ -6,11 +6,15 @@ import (
 	"io"
 	"io/ioutil"
 	"os"
-	"os/exec"
+	"strings"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/hashicorp/hcl/v2"
 	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
 	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/pmezard/go-difflib/difflib"
 )
 
 type HCL2Formatter struct {
@@ -95,7 +99,7 @@ func (f *HCL2Formatter) processFile(filename string) ([]byte, error) {
 		return nil, fmt.Errorf("failed to parse HCL %s", filename)
 	}
 
-	outSrc := hclwrite.Format(inSrc)
+	outSrc := formatHeredocs(hclwrite.Format(inSrc), filename)
 
 	if bytes.Equal(inSrc, outSrc) {
 		return nil, nil
@@ -121,31 +125,129 @@ func (f *HCL2Formatter) processFile(filename string) ([]byte, error) {
 	return outSrc, nil
 }
 
-// bytesDiff returns the unified diff of b1 and b2
-// Shamelessly copied from Terraform's fmt command.
-func bytesDiff(b1, b2 []byte, path string) (data []byte, err error) {
-	f1, err := ioutil.TempFile("", "")
-	if err != nil {
-		return
+// formatHeredocs indents the content of the indented heredocs of src, like
+// the inline scripts of provisioners, two spaces deeper than the line the
+// heredoc starts on, and their closing marker like that line. The value of a
+// heredoc doesn't change, as the common indentation of its lines is removed.
+// Lines with only spaces are left as they are, as they are part of the value.
+func formatHeredocs(src []byte, filename string) []byte {
+	tokens, diags := hclsyntax.LexConfig(src, filename, hcl.Pos{Line: 1, Column: 1})
+	if diags.HasErrors() {
+		return src
+	}
+
+	type heredoc struct {
+		start, contentStart, contentEnd, end int
+	}
+	var heredocs []heredoc
+	var open []heredoc
+	// nested is set when heredocs are nested in the interpolations of another
+	// one, in which case they are left as they are.
+	nested := false
+	for _, token := range tokens {
+		switch token.Type {
+		case hclsyntax.TokenOHeredoc:
+			nested = nested || len(open) > 0
+			open = append(open, heredoc{start: token.Range.Start.Byte, contentStart: token.Range.End.Byte})
+		case hclsyntax.TokenCHeredoc:
+			if len(open) == 0 {
+				return src
+			}
+			h := open[len(open)-1]
+			open = open[:len(open)-1]
+			h.contentEnd, h.end = token.Range.Start.Byte, token.Range.End.Byte
+			if len(open) == 0 {
+				if !nested && bytes.HasPrefix(src[h.start:], []byte("<<-")) {
+					heredocs = append(heredocs, h)
+				}
+				nested = false
+			}
+		}
 	}
-	defer os.Remove(f1.Name())
-	defer f1.Close()
 
-	f2, err := ioutil.TempFile("", "")
-	if err != nil {
-		return
+	// Replace the heredocs from the end, so that the offsets of the previous
+	// ones stay valid
+	out := append([]byte{}, src...)
+	for i := len(heredocs) - 1; i >= 0; i-- {
+		h := heredocs[i]
+		lineStart := bytes.LastIndexByte(src[:h.start], '\n') + 1
+		indent := leadingSpaces(string(src[lineStart:h.start]))
+		if indent < 0 {
+			continue
+		}
+		content, ok := indentHeredoc(string(src[h.contentStart:h.contentEnd]), indent+2)
+		if !ok {
+			continue
+		}
+		marker := strings.TrimLeft(string(src[h.contentEnd:h.end]), " \t")
+		replacement := content + strings.Repeat(" ", indent) + marker
+
+		out = append(out[:h.contentStart], append([]byte(replacement), out[h.end:]...)...)
+	}
+	return out
+}
+
+// indentHeredoc indents the lines of the content of a heredoc by indent
+// spaces once their common indentation is removed.
+func indentHeredoc(content string, indent int) (string, bool) {
+	lines := strings.SplitAfter(content, "\n")
+	common := -1
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		n := leadingSpaces(line)
+		if n < 0 {
+			return "", false
+		}
+		if common == -1 || n < common {
+			common = n
+		}
+	}
+	if common == -1 {
+		return content, true
 	}
-	defer os.Remove(f2.Name())
-	defer f2.Close()
 
-	_, _ = f1.Write(b1)
-	_, _ = f2.Write(b2)
+	var b strings.Builder
+	prefix := strings.Repeat(" ", indent)
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			b.WriteString(line)
+			continue
+		}
+		b.WriteString(prefix)
+		b.WriteString(line[common:])
+	}
+	return b.String(), true
+}
+
+// leadingSpaces returns the number of spaces and tabs line starts with, or -1
+// when it starts with other white space, which isn't reindented.
+func leadingSpaces(line string) int {
+	trimmed := strings.TrimLeft(line, " \t")
+	if r, _ := utf8.DecodeRuneInString(trimmed); r != '\n' && unicode.IsSpace(r) {
+		return -1
+	}
+	return len(line) - len(trimmed)
+}
+
+// bytesDiff returns the unified diff of b1 and b2, with the lines around the
+// changes.
+func bytesDiff(b1, b2 []byte, path string) ([]byte, error) {
+	diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        diffLines(b1),
+		B:        diffLines(b2),
+		FromFile: "old/" + path,
+		ToFile:   "new/" + path,
+		Context:  3,
+	})
+	return []byte(diff), err
+}
 
-	data, err = exec.Command("diff", "--label=old/"+path, "--label=new/"+path, "-u", f1.Name(), f2.Name()).CombinedOutput()
-	if len(data) > 0 {
-		// diff exits with a non-zero status when the files don't match.
-		// Ignore that failure as long as we get output.
-		err = nil
+func diffLines(b []byte) []string {
+	lines := strings.SplitAfter(string(b), "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
 	}
-	return
+	return lines
 }
@@ -4,11 +4,13 @@ import (
 	"bytes"
 	"io/ioutil"
 	"os"
-	"os/exec"
 	"strings"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
 )
 
 func TestHCL2Formatter_Format(t *testing.T) {
@@ -19,6 +21,8 @@ func TestHCL2Formatter_Format(t *testing.T) {
 	}{
 		{Name: "Unformatted file", Path: "testdata/format/unformatted.pkr.hcl", FormatExpected: true},
 		{Name: "Formatted file", Path: "testdata/format/formatted.pkr.hcl"},
+		{Name: "Unformatted var file", Path: "testdata/format/unformatted.pkrvars.hcl", FormatExpected: true},
+		{Name: "Formatted var file", Path: "testdata/format/formatted.pkrvars.hcl"},
 		{Name: "Directory", Path: "testdata/format", FormatExpected: true},
 	}
 
@@ -35,6 +39,9 @@ func TestHCL2Formatter_Format(t *testing.T) {
 		if buf.String() != "" && tc.FormatExpected == false {
 			t.Errorf("Format(%q) should contain the name of the formatted file(s), but got %q", tc.Path, buf.String())
 		}
+		if buf.String() == "" && tc.FormatExpected {
+			t.Errorf("Format(%q) should have formatted %q", tc.Path, tc.Path)
+		}
 
 	}
 }
@@ -82,11 +89,6 @@ func TestHCL2Formatter_Format_Write(t *testing.T) {
 }
 
 func TestHCL2Formatter_Format_ShowDiff(t *testing.T) {
-
-	if _, err := exec.LookPath("diff"); err != nil {
-		t.Skip("Skipping test because diff is not in the executable PATH")
-	}
-
 	var buf bytes.Buffer
 	f := HCL2Formatter{
 		Output:   &buf,
@@ -108,3 +110,64 @@ func TestHCL2Formatter_Format_ShowDiff(t *testing.T) {
 	}
 
 }
+
+func TestHCL2Formatter_formatHeredocs(t *testing.T) {
+	in := `build {
+  provisioner "shell" {
+    inline = [<<-EOT
+echo ${var.greeting}
+
+        if true; then
+          ls
+        fi
+            EOT
+    ]
+    environment_vars = [<<EOT
+  untouched
+EOT
+    ]
+  }
+}
+`
+	expected := `build {
+  provisioner "shell" {
+    inline = [<<-EOT
+      echo ${var.greeting}
+
+              if true; then
+                ls
+              fi
+    EOT
+    ]
+    environment_vars = [<<EOT
+  untouched
+EOT
+    ]
+  }
+}
+`
+	out := formatHeredocs([]byte(in), "test.pkr.hcl")
+	if diff := cmp.Diff(expected, string(out)); diff != "" {
+		t.Fatalf("Unexpected format output %s", diff)
+	}
+	if again := formatHeredocs(out, "test.pkr.hcl"); !bytes.Equal(again, out) {
+		t.Fatalf("formatting heredocs again should not change them: %s", again)
+	}
+
+	// The values of the heredocs don't change
+	value := func(src []byte) string {
+		file, diags := hclsyntax.ParseConfig(src, "test.pkr.hcl", hcl.Pos{Line: 1, Column: 1})
+		if diags.HasErrors() {
+			t.Fatalf("failed to parse: %s", diags)
+		}
+		build := file.Body.(*hclsyntax.Body).Blocks[0]
+		expr := build.Body.Blocks[0].Body.Attributes["inline"].Expr
+		v, _ := expr.Value(&hcl.EvalContext{Variables: map[string]cty.Value{
+			"var": cty.ObjectVal(map[string]cty.Value{"greeting": cty.StringVal("hi")}),
+		}})
+		return v.Index(cty.NumberIntVal(0)).AsString()
+	}
+	if before, after := value([]byte(in)), value(out); before != after {
+		t.Fatalf("the value of the heredoc changed from %q to %q", before, after)
+	}
+}
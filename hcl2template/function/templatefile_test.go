@@ -0,0 +1,91 @@
+package function
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+	"github.com/zclconf/go-cty/cty/function/stdlib"
+)
+
+func TestTemplateFile(t *testing.T) {
+	var funcs map[string]function.Function
+	templateFile := MakeTemplateFileFunc("testdata/templatefile", func() map[string]function.Function {
+		return funcs
+	})
+	funcs = map[string]function.Function{
+		"upper":        stdlib.UpperFunc,
+		"templatefile": templateFile,
+	}
+
+	tests := []struct {
+		Path    cty.Value
+		Vars    cty.Value
+		Want    cty.Value
+		WantErr string
+	}{
+		{
+			cty.StringVal("preseed.cfg.pkrtpl"),
+			cty.ObjectVal(map[string]cty.Value{
+				"username": cty.StringVal("packer"),
+				"packages": cty.TupleVal([]cty.Value{cty.StringVal("openssh-server"), cty.StringVal("curl")}),
+			}),
+			cty.StringVal("d-i passwd/username string packer\n" +
+				"d-i pkgsel/include string OPENSSH-SERVER\n" +
+				"d-i pkgsel/include string CURL\n"),
+			"",
+		},
+		{
+			cty.StringVal("preseed.cfg.pkrtpl"),
+			cty.MapVal(map[string]cty.Value{"username": cty.StringVal("packer")}),
+			cty.NilVal,
+			`vars map does not contain key "packages"`,
+		},
+		{
+			cty.StringVal("preseed.cfg.pkrtpl"),
+			cty.StringVal("packer"),
+			cty.NilVal,
+			"invalid vars value: must be a map",
+		},
+		{
+			cty.StringVal("recursive.pkrtpl"),
+			cty.EmptyObjectVal,
+			cty.NilVal,
+			"cannot recursively call templatefile from inside templatefile call",
+		},
+		{
+			cty.StringVal("missing.pkrtpl"),
+			cty.EmptyObjectVal,
+			cty.NilVal,
+			"missing.pkrtpl",
+		},
+		{
+			cty.UnknownVal(cty.String),
+			cty.EmptyObjectVal,
+			cty.DynamicVal,
+			"",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("TemplateFile(%#v, %#v)", test.Path, test.Vars), func(t *testing.T) {
+			got, err := templateFile.Call([]cty.Value{test.Path, test.Vars})
+
+			if test.WantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.WantErr) {
+					t.Fatalf("expected an error containing %q, got %v", test.WantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if !got.RawEquals(test.Want) {
+				t.Errorf("wrong result\ngot:  %#v\nwant: %#v", got, test.Want)
+			}
+		})
+	}
+}
@@ -4,10 +4,12 @@ import (
 	"github.com/zclconf/go-cty/cty"
 	"github.com/zclconf/go-cty/cty/function"
 
+	"github.com/hashicorp/packer/packer"
 	commontpl "github.com/hashicorp/packer/packer-plugin-sdk/template"
 )
 
-// VaultFunc constructs a function that retrieves KV secrets from HC vault
+// VaultFunc constructs a function that retrieves KV secrets from HC vault.
+// The secrets are filtered out of the output and the logs.
 var VaultFunc = function.New(&function.Spec{
 	Params: []function.Parameter{
 		{
@@ -25,6 +27,8 @@ var VaultFunc = function.New(&function.Spec{
 		key := args[1].AsString()
 
 		val, err := commontpl.Vault(path, key)
+		// secrets must never be shown
+		packer.LogSecretFilter.Set(val)
 
 		return cty.StringVal(val), err
 	},
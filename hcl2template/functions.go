@@ -82,6 +82,8 @@ func Functions(basedir string) map[string]function.Function {
 		"range":              stdlib.RangeFunc,
 		"reverse":            stdlib.ReverseFunc,
 		"replace":            stdlib.ReplaceFunc,
+		"regex":              stdlib.RegexFunc,
+		"regexall":           stdlib.RegexAllFunc,
 		"regex_replace":      stdlib.RegexReplaceFunc,
 		"rsadecrypt":         crypto.RsaDecryptFunc,
 		"setintersection":    stdlib.SetIntersectionFunc,
@@ -115,6 +117,12 @@ func Functions(basedir string) map[string]function.Function {
 		"zipmap":             stdlib.ZipmapFunc,
 	}
 
+	funcs["templatefile"] = pkrfunction.MakeTemplateFileFunc(basedir, func() map[string]function.Function {
+		// The templates can use all the functions, except for templatefile
+		// which is replaced by MakeTemplateFileFunc.
+		return funcs
+	})
+
 	return funcs
 }
 
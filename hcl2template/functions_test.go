@@ -0,0 +1,41 @@
+package hcl2template
+
+import (
+	"testing"
+
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestFunctions_regexall(t *testing.T) {
+	regexall, ok := Functions(".")["regexall"]
+	if !ok {
+		t.Fatal("regexall is not registered")
+	}
+
+	tests := []struct {
+		Pattern cty.Value
+		String  cty.Value
+		Want    cty.Value
+	}{
+		{
+			cty.StringVal("[a-z]+"),
+			cty.StringVal("1234abcd5678efgh9"),
+			cty.ListVal([]cty.Value{cty.StringVal("abcd"), cty.StringVal("efgh")}),
+		},
+		{
+			cty.StringVal("[a-z]+"),
+			cty.StringVal("123456789"),
+			cty.ListValEmpty(cty.String),
+		},
+	}
+
+	for _, test := range tests {
+		got, err := regexall.Call([]cty.Value{test.Pattern, test.String})
+		if err != nil {
+			t.Fatalf("regexall(%#v, %#v): %s", test.Pattern, test.String, err)
+		}
+		if !got.RawEquals(test.Want) {
+			t.Errorf("regexall(%#v, %#v) = %#v, want %#v", test.Pattern, test.String, got, test.Want)
+		}
+	}
+}
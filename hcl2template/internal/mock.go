@@ -144,6 +144,38 @@ func (b *MockCommunicator) Configure(raws ...interface{}) ([]string, error) {
 	return nil, b.Config.Prepare(raws...)
 }
 
+//////
+// MockDatasource
+//////
+
+type MockDatasource struct {
+	Config MockConfig
+}
+
+var _ packer.Datasource = new(MockDatasource)
+
+func (d *MockDatasource) ConfigSpec() hcldec.ObjectSpec {
+	return d.Config.FlatMapstructure().HCL2Spec()
+}
+
+func (d *MockDatasource) Configure(raws ...interface{}) error {
+	return d.Config.Prepare(raws...)
+}
+
+func (d *MockDatasource) OutputSpec() hcldec.ObjectSpec {
+	return hcldec.ObjectSpec{
+		"string": &hcldec.AttrSpec{Name: "string", Type: cty.String},
+		"int":    &hcldec.AttrSpec{Name: "int", Type: cty.Number},
+	}
+}
+
+func (d *MockDatasource) Execute() (cty.Value, error) {
+	return cty.ObjectVal(map[string]cty.Value{
+		"string": cty.StringVal(d.Config.String),
+		"int":    cty.NumberIntVal(int64(d.Config.Int)),
+	}), nil
+}
+
 //////
 // Utils
 //////
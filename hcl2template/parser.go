@@ -10,7 +10,6 @@ import (
 	"github.com/hashicorp/hcl/v2/ext/dynblock"
 	"github.com/hashicorp/hcl/v2/hclparse"
 	"github.com/hashicorp/packer/packer"
-	"github.com/zclconf/go-cty/cty"
 )
 
 const (
@@ -21,6 +20,9 @@ const (
 	localsLabel       = "locals"
 	buildLabel        = "build"
 	communicatorLabel = "communicator"
+	limitLabel        = "limit"
+	moduleLabel       = "module"
+	dataLabel         = "data"
 )
 
 var configSchema = &hcl.BodySchema{
@@ -32,6 +34,9 @@ var configSchema = &hcl.BodySchema{
 		{Type: localsLabel},
 		{Type: buildLabel},
 		{Type: communicatorLabel, LabelNames: []string{"type", "name"}},
+		{Type: limitLabel, LabelNames: []string{"name"}},
+		{Type: moduleLabel, LabelNames: []string{"name"}},
+		{Type: dataLabel, LabelNames: []string{"type", "name"}},
 	},
 }
 
@@ -41,6 +46,9 @@ var packerBlockSchema = &hcl.BodySchema{
 	Attributes: []hcl.AttributeSchema{
 		{Name: "required_version"},
 	},
+	Blocks: []hcl.BlockHeaderSchema{
+		{Type: requiredPluginsLabel},
+	},
 }
 
 // Parser helps you parse HCL folders. It will parse an hcl file or directory
@@ -59,13 +67,17 @@ type Parser struct {
 	ProvisionersSchemas packer.ProvisionerStore
 
 	PostProcessorsSchemas packer.PostProcessorStore
+
+	DatasourceSchemas packer.DatasourceStore
 }
 
 const (
-	hcl2FileExt        = ".pkr.hcl"
-	hcl2JsonFileExt    = ".pkr.json"
-	hcl2VarFileExt     = ".auto.pkrvars.hcl"
-	hcl2VarJsonFileExt = ".auto.pkrvars.json"
+	hcl2FileExt            = ".pkr.hcl"
+	hcl2JsonFileExt        = ".pkr.json"
+	hcl2VarFileExt         = ".pkrvars.hcl"
+	hcl2VarJsonFileExt     = ".pkrvars.json"
+	hcl2AutoVarFileExt     = ".auto.pkrvars.hcl"
+	hcl2AutoVarJsonFileExt = ".auto.pkrvars.json"
 )
 
 // Parse will Parse all HCL files in filename. Path can be a folder or a file.
@@ -77,6 +89,85 @@ const (
 // build; sources(builders)/provisioners/posts-processors will not be started
 // and their contents wont be verified; Most syntax errors will cause an error.
 func (p *Parser) Parse(filename string, varFiles []string, argVars map[string]string) (*PackerConfig, hcl.Diagnostics) {
+	cfg, diags := p.parseFiles(filename)
+	if cfg == nil {
+		return nil, diags
+	}
+
+	// Before we go further, we'll check to make sure this version can read
+	// that file, so we can produce a version-related error message rather than
+	// potentially-confusing downstream errors.
+	versionDiags := cfg.CheckCoreVersionRequirements(p.CorePackerVersion)
+	diags = append(diags, versionDiags...)
+	if versionDiags.HasErrors() {
+		return cfg, diags
+	}
+
+	// Decode variable blocks so that they are available later on. Here locals
+	// can use input variables so we decode them firsthand.
+	diags = append(diags, cfg.decodeVariableBlocks()...)
+
+	// parse var files
+	{
+		hclVarFiles, jsonVarFiles, moreDiags := GetHCL2Files(filename, hcl2AutoVarFileExt, hcl2AutoVarJsonFileExt)
+		diags = append(diags, moreDiags...)
+		for _, file := range varFiles {
+			switch filepath.Ext(file) {
+			case ".hcl":
+				hclVarFiles = append(hclVarFiles, file)
+			case ".json":
+				jsonVarFiles = append(jsonVarFiles, file)
+			default:
+				diags = append(moreDiags, &hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Could not guess format of " + file,
+					Detail:   "A var file must be suffixed with `.hcl` or `.json`.",
+				})
+			}
+		}
+		var varFiles []*hcl.File
+		for _, filename := range hclVarFiles {
+			f, moreDiags := p.ParseHCLFile(filename)
+			diags = append(diags, moreDiags...)
+			if moreDiags.HasErrors() {
+				continue
+			}
+			varFiles = append(varFiles, f)
+		}
+		for _, filename := range jsonVarFiles {
+			f, moreDiags := p.ParseJSONFile(filename)
+			diags = append(diags, moreDiags...)
+			if moreDiags.HasErrors() {
+				continue
+			}
+			varFiles = append(varFiles, f)
+		}
+
+		diags = append(diags, cfg.collectInputVariableValues(os.Environ(), varFiles, argVars)...)
+	}
+
+	// The diagnostics of the parsing are shown before the config is
+	// initialized, they must not show the sensitive variables already.
+	cfg.filterSensitiveVariables()
+	return cfg, diags
+}
+
+// filterSensitiveVariables scrubs the values of the sensitive input variables
+// from the output and the logs.
+func (cfg *PackerConfig) filterSensitiveVariables() {
+	for _, variable := range cfg.InputVariables {
+		if !variable.Sensitive {
+			continue
+		}
+		value, _ := variable.Value()
+		packer.LogSecretFilter.SetValue(value)
+	}
+}
+
+// parseFiles parses the HCL files in filename, a folder or a file, and the
+// version requirements they declare. The returned config is nil if the files
+// can't be parsed.
+func (p *Parser) parseFiles(filename string) (*PackerConfig, hcl.Diagnostics) {
 	var files []*hcl.File
 	var diags hcl.Diagnostics
 
@@ -126,6 +217,7 @@ func (p *Parser) Parse(filename string, varFiles []string, argVars map[string]st
 		builderSchemas:          p.BuilderSchemas,
 		provisionersSchemas:     p.ProvisionersSchemas,
 		postProcessorsSchemas:   p.PostProcessorsSchemas,
+		datasourceSchemas:       p.DatasourceSchemas,
 		parser:                  p,
 		files:                   files,
 	}
@@ -134,70 +226,25 @@ func (p *Parser) Parse(filename string, varFiles []string, argVars map[string]st
 		coreVersionConstraints, moreDiags := sniffCoreVersionRequirements(file.Body)
 		cfg.Packer.VersionConstraints = append(cfg.Packer.VersionConstraints, coreVersionConstraints...)
 		diags = append(diags, moreDiags...)
+		diags = append(diags, cfg.decodeRequiredPlugins(file)...)
 	}
 
-	// Before we go further, we'll check to make sure this version can read
-	// that file, so we can produce a version-related error message rather than
-	// potentially-confusing downstream errors.
-	versionDiags := cfg.CheckCoreVersionRequirements(p.CorePackerVersion)
-	diags = append(diags, versionDiags...)
-	if versionDiags.HasErrors() {
-		return cfg, diags
-	}
-
-	// Decode variable blocks so that they are available later on. Here locals
-	// can use input variables so we decode them firsthand.
-	{
-		for _, file := range files {
-			diags = append(diags, cfg.decodeInputVariables(file)...)
-		}
+	return cfg, diags
+}
 
-		for _, file := range files {
-			moreLocals, morediags := cfg.parseLocalVariables(file)
-			diags = append(diags, morediags...)
-			cfg.LocalBlocks = append(cfg.LocalBlocks, moreLocals...)
-		}
+// decodeVariableBlocks decodes the variable and locals blocks of the config.
+func (cfg *PackerConfig) decodeVariableBlocks() hcl.Diagnostics {
+	var diags hcl.Diagnostics
+	for _, file := range cfg.files {
+		diags = append(diags, cfg.decodeInputVariables(file)...)
 	}
 
-	// parse var files
-	{
-		hclVarFiles, jsonVarFiles, moreDiags := GetHCL2Files(filename, hcl2VarFileExt, hcl2VarJsonFileExt)
-		diags = append(diags, moreDiags...)
-		for _, file := range varFiles {
-			switch filepath.Ext(file) {
-			case ".hcl":
-				hclVarFiles = append(hclVarFiles, file)
-			case ".json":
-				jsonVarFiles = append(jsonVarFiles, file)
-			default:
-				diags = append(moreDiags, &hcl.Diagnostic{
-					Severity: hcl.DiagError,
-					Summary:  "Could not guess format of " + file,
-					Detail:   "A var file must be suffixed with `.hcl` or `.json`.",
-				})
-			}
-		}
-		var varFiles []*hcl.File
-		for _, filename := range hclVarFiles {
-			f, moreDiags := p.ParseHCLFile(filename)
-			diags = append(diags, moreDiags...)
-			if moreDiags.HasErrors() {
-				continue
-			}
-			varFiles = append(varFiles, f)
-		}
-		for _, filename := range jsonVarFiles {
-			f, moreDiags := p.ParseJSONFile(filename)
-			diags = append(diags, moreDiags...)
-			if moreDiags.HasErrors() {
-				continue
-			}
-			varFiles = append(varFiles, f)
-		}
-
-		diags = append(diags, cfg.collectInputVariableValues(os.Environ(), varFiles, argVars)...)
+	for _, file := range cfg.files {
+		moreLocals, morediags := cfg.parseLocalVariables(file)
+		diags = append(diags, morediags...)
+		cfg.LocalBlocks = append(cfg.LocalBlocks, moreLocals...)
 	}
-	return cfg, diags
+	return diags
 }
 
 // sniffCoreVersionRequirements does minimal parsing of the given body for
@@ -251,19 +298,18 @@ func (cfg *PackerConfig) Initialize() hcl.Diagnostics {
 	diags = append(diags, moreDiags...)
 	_, moreDiags = cfg.LocalVariables.Values()
 	diags = append(diags, moreDiags...)
+
+	// data sources can use input variables, and locals can use data sources
+	for _, file := range cfg.files {
+		diags = append(diags, cfg.decodeDatasources(file)...)
+	}
 	diags = append(diags, cfg.evaluateLocalVariables(cfg.LocalBlocks)...)
 
-	for _, variable := range cfg.InputVariables {
-		if !variable.Sensitive {
-			continue
-		}
-		value, _ := variable.Value()
-		_ = cty.Walk(value, func(_ cty.Path, nested cty.Value) (bool, error) {
-			if nested.IsWhollyKnown() && !nested.IsNull() && nested.Type().Equals(cty.String) {
-				packer.LogSecretFilter.Set(nested.AsString())
-			}
-			return true, nil
-		})
+	cfg.filterSensitiveVariables()
+
+	// load the modules first, so that sources and builds can use them
+	for _, file := range cfg.files {
+		diags = append(diags, cfg.decodeModules(file)...)
 	}
 
 	// decode the actual content
@@ -287,38 +333,57 @@ func (p *Parser) decodeConfig(f *hcl.File, cfg *PackerConfig) hcl.Diagnostics {
 	for _, block := range content.Blocks {
 		switch block.Type {
 		case sourceLabel:
-			source, moreDiags := p.decodeSource(block)
+			sources, moreDiags := p.decodeSources(block, cfg.EvalContext(nil))
 			diags = append(diags, moreDiags...)
 			if moreDiags.HasErrors() {
 				continue
 			}
 
-			ref := source.Ref()
-			if existing, found := cfg.Sources[ref]; found {
-				diags = append(diags, &hcl.Diagnostic{
-					Severity: hcl.DiagError,
-					Summary:  "Duplicate " + sourceLabel + " block",
-					Detail: fmt.Sprintf("This "+sourceLabel+" block has the "+
-						"same builder type and name as a previous block declared "+
-						"at %s. Each "+sourceLabel+" must have a unique name per builder type.",
-						existing.block.DefRange.Ptr()),
-					Subject: source.block.DefRange.Ptr(),
-				})
-				continue
-			}
-
-			if cfg.Sources == nil {
-				cfg.Sources = map[SourceRef]SourceBlock{}
+			for _, source := range sources {
+				ref := source.Ref()
+				if existing, found := cfg.Sources[ref]; found {
+					diags = append(diags, &hcl.Diagnostic{
+						Severity: hcl.DiagError,
+						Summary:  "Duplicate " + sourceLabel + " block",
+						Detail: fmt.Sprintf("This "+sourceLabel+" block has the "+
+							"same builder type and name as a previous block declared "+
+							"at %s. Each "+sourceLabel+" must have a unique name per builder type.",
+							existing.block.DefRange.Ptr()),
+						Subject: source.block.DefRange.Ptr(),
+					})
+					continue
+				}
+
+				if cfg.Sources == nil {
+					cfg.Sources = map[SourceRef]SourceBlock{}
+				}
+				cfg.Sources[ref] = source
 			}
-			cfg.Sources[ref] = source
 
 		case buildLabel:
-			build, moreDiags := p.decodeBuildConfig(block, cfg)
+			builds, moreDiags := p.decodeBuildConfigs(block, cfg)
+			diags = append(diags, moreDiags...)
+			cfg.Builds = append(cfg.Builds, builds...)
+
+		case limitLabel:
+			limit, moreDiags := p.decodeLimit(block)
 			diags = append(diags, moreDiags...)
 			if moreDiags.HasErrors() {
 				continue
 			}
-			cfg.Builds = append(cfg.Builds, build)
+
+			if existing := cfg.Limits.get(limit.Name); existing != nil {
+				diags = append(diags, &hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Duplicate " + limitLabel + " block",
+					Detail: fmt.Sprintf("This "+limitLabel+" block has the "+
+						"same name as a previous block declared at %s.",
+						existing.block.DefRange.Ptr()),
+					Subject: block.DefRange.Ptr(),
+				})
+				continue
+			}
+			cfg.Limits = append(cfg.Limits, limit)
 
 		}
 	}
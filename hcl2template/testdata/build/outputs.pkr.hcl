@@ -0,0 +1,29 @@
+
+build {
+    name = "ubuntu"
+
+    sources = [
+        "source.virtualbox-iso.ubuntu-1204"
+    ]
+
+    output "image_id" {
+        description = "The ID of the image."
+        value       = build.ArtifactId
+    }
+
+    output "image" {
+        value = {
+            name = "${build.name}-${source.name}"
+            ids  = build.ArtifactIds
+            host = build.Host
+        }
+    }
+
+    output "password" {
+        value     = build.Password
+        sensitive = true
+    }
+}
+
+source "virtualbox-iso" "ubuntu-1204" {
+}
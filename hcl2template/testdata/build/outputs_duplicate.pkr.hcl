@@ -0,0 +1,17 @@
+
+build {
+    sources = [
+        "source.virtualbox-iso.ubuntu-1204"
+    ]
+
+    output "image_id" {
+        value = build.ArtifactId
+    }
+
+    output "image_id" {
+        value = build.ArtifactIds[0]
+    }
+}
+
+source "virtualbox-iso" "ubuntu-1204" {
+}
@@ -0,0 +1,13 @@
+
+build {
+    sources = [
+        "source.virtualbox-iso.ubuntu-1204"
+    ]
+
+    output "image_id" {
+        value = build.ImageId
+    }
+}
+
+source "virtualbox-iso" "ubuntu-1204" {
+}
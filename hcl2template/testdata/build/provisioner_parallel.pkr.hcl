@@ -0,0 +1,25 @@
+
+// a shell provisioner, followed by two provisioners that run in parallel
+build {
+    sources = [
+        "source.virtualbox-iso.ubuntu-1204"
+    ]
+
+    provisioner "shell" {
+    }
+
+    parallel {
+        provisioner "shell" {
+            name = "packages"
+        }
+        provisioner "file" {
+            name = "caches"
+        }
+    }
+
+    provisioner "file" {
+    }
+}
+
+source "virtualbox-iso" "ubuntu-1204" {
+}
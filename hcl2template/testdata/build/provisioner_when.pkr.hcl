@@ -0,0 +1,25 @@
+
+// starts resources to provision them.
+build {
+    sources = [
+        "source.virtualbox-iso.ubuntu-1204",
+        "source.amazon-ebs.ubuntu-1604",
+    ]
+
+    provisioner "shell" {
+        when = source.type == "virtualbox-iso"
+    }
+    provisioner "file" {
+        when = source.type != "virtualbox-iso"
+    }
+
+    post-processor "manifest" {
+        when = source.name == "ubuntu-1604"
+    }
+}
+
+source "virtualbox-iso" "ubuntu-1204" {
+}
+
+source "amazon-ebs" "ubuntu-1604" {
+}
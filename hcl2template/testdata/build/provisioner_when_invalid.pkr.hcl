@@ -0,0 +1,13 @@
+
+build {
+    sources = [
+        "source.virtualbox-iso.ubuntu-1204",
+    ]
+
+    provisioner "shell" {
+        when = source.name
+    }
+}
+
+source "virtualbox-iso" "ubuntu-1204" {
+}
@@ -0,0 +1,12 @@
+
+// a build that is retried twice when it fails
+build {
+    retries = 2
+
+    sources = [
+        "source.virtualbox-iso.ubuntu-1204"
+    ]
+}
+
+source "virtualbox-iso" "ubuntu-1204" {
+}
@@ -0,0 +1,11 @@
+
+build {
+    retries = -1
+
+    sources = [
+        "source.virtualbox-iso.ubuntu-1204"
+    ]
+}
+
+source "virtualbox-iso" "ubuntu-1204" {
+}
@@ -0,0 +1,22 @@
+
+variable "image" {
+    type    = string
+    default = "ubuntu"
+}
+
+data "mock" "image" {
+    string = "${var.image}-1604"
+    int    = 42
+}
+
+locals {
+    image_name = "${data.mock.image.string}-${data.mock.image.int}"
+}
+
+source "virtualbox-iso" "ubuntu-1204" {
+    string = local.image_name
+}
+
+build {
+    sources = ["source.virtualbox-iso.ubuntu-1204"]
+}
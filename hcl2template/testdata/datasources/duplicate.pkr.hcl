@@ -0,0 +1,8 @@
+
+data "mock" "image" {
+    string = "ubuntu"
+}
+
+data "mock" "image" {
+    string = "debian"
+}
@@ -0,0 +1,4 @@
+
+data "potato" "image" {
+    string = "ubuntu"
+}
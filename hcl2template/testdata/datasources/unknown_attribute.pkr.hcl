@@ -0,0 +1,4 @@
+
+data "mock" "image" {
+    potato = "ubuntu"
+}
@@ -0,0 +1,25 @@
+
+// declares the virtualbox-iso.ubuntu-1204 and virtualbox-iso.ubuntu-1604
+// sources
+source "virtualbox-iso" "ubuntu" {
+    for_each = {
+        "1204" = 12
+        "1604" = 16
+    }
+    string = "ubuntu-${each.key}"
+    int    = each.value
+}
+
+// builds each source twice
+build {
+    for_each = convert(["a", "b"], set(string))
+    name     = "build-${each.key}"
+
+    sources = [
+        "source.virtualbox-iso.ubuntu"
+    ]
+
+    provisioner "shell" {
+        string = "${each.value}-${source.name}"
+    }
+}
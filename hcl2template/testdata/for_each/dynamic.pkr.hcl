@@ -0,0 +1,23 @@
+
+dynamic "source" {
+    for_each = ["1204", "1604"]
+    labels   = ["virtualbox-iso", "ubuntu-${source.value}"]
+    content {
+        string = "ubuntu-${source.value}"
+    }
+}
+
+build {
+    sources = [
+        "source.virtualbox-iso.ubuntu-1204",
+        "source.virtualbox-iso.ubuntu-1604",
+    ]
+
+    dynamic "provisioner" {
+        for_each = ["first", "second"]
+        labels   = ["shell"]
+        content {
+            string = provisioner.value
+        }
+    }
+}
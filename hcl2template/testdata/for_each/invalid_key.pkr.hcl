@@ -0,0 +1,4 @@
+
+source "virtualbox-iso" "ubuntu" {
+    for_each = convert(["12.04"], set(string))
+}
@@ -0,0 +1,4 @@
+
+build {
+    for_each = ["a", "b"]
+}
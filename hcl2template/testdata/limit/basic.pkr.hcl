@@ -0,0 +1,23 @@
+
+// applies to the virtualbox-iso builder
+limit "virtualbox" {
+    max = 2
+}
+
+limit "aws" {
+    max      = 1
+    builders = ["amazon-ebs"]
+}
+
+build {
+    sources = [
+        "source.virtualbox-iso.ubuntu-1204",
+        "source.amazon-ebs.ubuntu-1604",
+    ]
+}
+
+source "virtualbox-iso" "ubuntu-1204" {
+}
+
+source "amazon-ebs" "ubuntu-1604" {
+}
@@ -0,0 +1,8 @@
+
+limit "virtualbox" {
+    max = 2
+}
+
+limit "virtualbox" {
+    max = 1
+}
@@ -0,0 +1,4 @@
+
+limit "virtualbox" {
+    max = 0
+}
@@ -0,0 +1,5 @@
+
+limit "hyperv" {
+    max      = 2
+    builders = ["hyperv-iso"]
+}
@@ -0,0 +1,24 @@
+
+module "linux" {
+    source   = "./linux"
+    username = "packer"
+}
+
+build {
+    name = "web"
+
+    // overrides a setting of the source of the module
+    source "module.linux.source.virtualbox-iso.ubuntu-1204" {
+        int = 42
+    }
+
+    provisioner "shell" {
+        string = "before"
+    }
+
+    include "module.linux.hardening" {}
+
+    provisioner "shell" {
+        string = "after"
+    }
+}
@@ -0,0 +1,25 @@
+
+variable "username" {
+    type = string
+}
+
+locals {
+    prefix = "linux"
+}
+
+source "virtualbox-iso" "ubuntu-1204" {
+    string = "${local.prefix}-${var.username}"
+    int    = 1
+}
+
+build {
+    name = "hardening"
+
+    provisioner "shell" {
+        string = "${local.prefix}-${source.name}"
+    }
+
+    post-processor "manifest" {
+        string = var.username
+    }
+}
@@ -0,0 +1,4 @@
+
+module "linux" {
+    username = "packer"
+}
@@ -0,0 +1,11 @@
+
+module "linux" {
+    source   = "./linux"
+    username = "packer"
+}
+
+build {
+    sources = ["module.linux.source.virtualbox-iso.ubuntu-1204"]
+
+    include "module.linux.unknown" {}
+}
@@ -0,0 +1,6 @@
+
+module "linux" {
+    source   = "./linux"
+    username = "packer"
+    password = "packer"
+}
@@ -0,0 +1,36 @@
+
+variable "greeting" {
+    default = "hello"
+}
+
+locals {
+    target = "${var.greeting}-world"
+}
+
+source "virtualbox-iso" "ubuntu-1204" {
+    string = local.target
+}
+
+build {
+    sources = [
+        "source.virtualbox-iso.ubuntu-1204"
+    ]
+
+    provisioner "shell" {
+        slice_string = ["echo ${var.greeting}"]
+    }
+
+    parallel {
+        provisioner "shell" {
+            name = "packages"
+            int  = 40 + 2
+        }
+        provisioner "file" {
+            string = upper(var.greeting)
+        }
+    }
+
+    post-processor "manifest" {
+        string = "${source.name}.json"
+    }
+}
@@ -0,0 +1,15 @@
+packer {
+  required_plugins {
+    amazon = {
+      source = "github.com/hashicorp/amazon"
+    }
+  }
+}
+
+packer {
+  required_plugins {
+    amazon = {
+      source = "github.com/hashicorp/amazon"
+    }
+  }
+}
@@ -0,0 +1,10 @@
+packer {
+  required_plugins {
+    amazon = {
+      source = "github.com/hashicorp/amazon"
+    }
+    aws = {
+      source = "github.com/hashicorp/amazon"
+    }
+  }
+}
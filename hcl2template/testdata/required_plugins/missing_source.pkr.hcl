@@ -0,0 +1,7 @@
+packer {
+  required_plugins {
+    amazon = {
+      version = ">= 1.0.0"
+    }
+  }
+}
@@ -0,0 +1,8 @@
+packer {
+  required_plugins {
+    amazon = {
+      source = "github.com/hashicorp/amazon"
+      url    = "https://example.com/amazon"
+    }
+  }
+}
@@ -0,0 +1,8 @@
+
+locals {
+  first  = local.second
+  second = "${local.third}-2"
+  third  = upper(local.first)
+  uses   = local.third
+  other  = "fine"
+}
@@ -0,0 +1,8 @@
+
+locals {
+  image = "ubuntu"
+}
+
+locals {
+  image = "debian"
+}
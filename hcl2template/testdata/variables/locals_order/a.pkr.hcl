@@ -0,0 +1,4 @@
+
+locals {
+  image_name = "${local.image}-${local.suffix}"
+}
@@ -0,0 +1,13 @@
+
+locals {
+  image  = data.mock.image.string
+  suffix = local.version
+}
+
+locals {
+  version = "1604"
+}
+
+data "mock" "image" {
+  string = "ubuntu"
+}
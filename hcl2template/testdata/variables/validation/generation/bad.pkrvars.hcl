@@ -0,0 +1 @@
+generation = 3
@@ -0,0 +1,18 @@
+
+variable "generation" {
+  type    = number
+  default = 2
+  validation {
+    condition     = contains([1, 2], var.generation)
+    error_message = "The generation must be 1 or 2."
+  }
+}
+
+variable "iso_checksum" {
+  type    = string
+  default = "sha256:e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+  validation {
+    condition     = can(regex("^(md5|sha1|sha256|sha512):[0-9a-f]+$", var.iso_checksum))
+    error_message = "The iso_checksum must be a checksum type and a hexadecimal checksum, like \"sha256:e3b0c442...\"."
+  }
+}
@@ -9,6 +9,14 @@ func sourceRefFromString(in string) SourceRef {
 	if len(args) < 2 {
 		return NoSource
 	}
+	if len(args) == 5 && args[0] == moduleAccessor {
+		// module.module_name.source.type.name
+		return SourceRef{
+			Module: args[1],
+			Type:   args[3],
+			Name:   args[4],
+		}
+	}
 	if len(args) > 2 {
 		// source.type.name
 		args = args[1:]
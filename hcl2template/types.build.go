@@ -1,9 +1,12 @@
 package hcl2template
 
 import (
+	"fmt"
+
 	"github.com/hashicorp/hcl/v2"
 	"github.com/hashicorp/hcl/v2/gohcl"
 	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
 )
 
 const (
@@ -13,9 +16,15 @@ const (
 
 	buildProvisionerLabel = "provisioner"
 
+	buildParallelLabel = "parallel"
+
 	buildPostProcessorLabel = "post-processor"
 
 	buildPostProcessorsLabel = "post-processors"
+
+	buildIncludeLabel = "include"
+
+	buildOutputLabel = "output"
 )
 
 var buildSchema = &hcl.BodySchema{
@@ -23,8 +32,17 @@ var buildSchema = &hcl.BodySchema{
 		{Type: buildFromLabel, LabelNames: []string{"type"}},
 		{Type: sourceLabel, LabelNames: []string{"reference"}},
 		{Type: buildProvisionerLabel, LabelNames: []string{"type"}},
+		{Type: buildParallelLabel, LabelNames: []string{}},
 		{Type: buildPostProcessorLabel, LabelNames: []string{"type"}},
 		{Type: buildPostProcessorsLabel, LabelNames: []string{}},
+		{Type: buildIncludeLabel, LabelNames: []string{"reference"}},
+		{Type: buildOutputLabel, LabelNames: []string{"name"}},
+	},
+}
+
+var parallelSchema = &hcl.BodySchema{
+	Blocks: []hcl.BlockHeaderSchema{
+		{Type: buildProvisionerLabel, LabelNames: []string{"type"}},
 	},
 }
 
@@ -41,7 +59,12 @@ var postProcessorsSchema = &hcl.BodySchema{
 //			...
 //		]
 //		provisioner "" { ... }
+//		parallel {
+//			provisioner "" { ... }
+//			provisioner "" { ... }
+//		}
 //		post-processor "" { ... }
+//		output "" { ... }
 //	}
 type BuildBlock struct {
 	// Name is a string representing the named build to show in the logs
@@ -54,8 +77,13 @@ type BuildBlock struct {
 	// Sources is the list of sources that we want to start in this build block.
 	Sources []SourceRef
 
+	// Retries is the number of times a build of a source is run again when
+	// it fails.
+	Retries int
+
 	// ProvisionerBlocks references a list of HCL provisioner block that will
-	// will be ran against the sources.
+	// will be ran against the sources. The provisioners of a parallel block
+	// are part of the list too, and share the same Group.
 	ProvisionerBlocks []*ProvisionerBlock
 
 	// PostProcessorLists references the lists of lists of HCL post-processors
@@ -63,34 +91,115 @@ type BuildBlock struct {
 	// steps.
 	PostProcessorsLists [][]*PostProcessorBlock
 
+	// OutputBlocks are the outputs evaluated once a build of a source of
+	// the block succeeded.
+	OutputBlocks []*OutputBlock
+
 	HCL2Ref HCL2Ref
+
+	// each is set when the build is one of the builds of a block with a
+	// for_each meta-argument.
+	each *eachValue
 }
 
 type Builds []*BuildBlock
 
-// decodeBuildConfig is called when a 'build' block has been detected. It will
-// load the references to the contents of the build block.
-func (p *Parser) decodeBuildConfig(block *hcl.Block, cfg *PackerConfig) (*BuildBlock, hcl.Diagnostics) {
-	build := &BuildBlock{}
+// output returns the output named name of the build, if any.
+func (build *BuildBlock) output(name string) *OutputBlock {
+	for _, output := range build.OutputBlocks {
+		if output.Name == name {
+			return output
+		}
+	}
+	return nil
+}
+
+// variables returns the variables of the build to add to the contexts its
+// content is evaluated in.
+func (build *BuildBlock) variables() map[string]cty.Value {
+	variables := map[string]cty.Value{}
+	if build.each != nil {
+		variables[eachAccessor] = build.each.ctyValue()
+	}
+	return variables
+}
+
+// decodeBuildConfigs is called when a 'build' block has been detected. The
+// block is decoded once for each element of its for_each meta-argument if it
+// has one:
+//	build {
+//		for_each = convert(["us-east-1", "eu-west-1"], set(string))
+//		name     = "ubuntu-${each.key}"
+//		...
+//	}
+func (p *Parser) decodeBuildConfigs(block *hcl.Block, cfg *PackerConfig) (Builds, hcl.Diagnostics) {
+	_, values, forEach, diags := decodeForEach(block.Body, cfg.EvalContext(nil))
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	if !forEach {
+		values = []*eachValue{nil}
+	}
+
+	builds := Builds{}
+	for _, each := range values {
+		build, moreDiags := p.decodeBuildConfig(block, cfg, each)
+		diags = append(diags, moreDiags...)
+		if moreDiags.HasErrors() {
+			continue
+		}
+		builds = append(builds, build)
+	}
+	return builds, diags
+}
+
+// decodeBuildConfig will load the references to the contents of a build
+// block, for the element each of its for_each meta-argument if it has one.
+func (p *Parser) decodeBuildConfig(block *hcl.Block, cfg *PackerConfig, each *eachValue) (*BuildBlock, hcl.Diagnostics) {
+	build := &BuildBlock{
+		each: each,
+	}
+
+	// The attributes of the build, its sources and post-processors can only
+	// refer to the element of for_each
+	var attrsCtx *hcl.EvalContext
+	if each != nil {
+		attrsCtx = cfg.EvalContext(build.variables())
+	}
+	ectx := cfg.EvalContext(build.variables())
 
 	var b struct {
-		Name        string   `hcl:"name,optional"`
-		Description string   `hcl:"description,optional"`
-		FromSources []string `hcl:"sources,optional"`
-		Config      hcl.Body `hcl:",remain"`
+		Name        string         `hcl:"name,optional"`
+		Description string         `hcl:"description,optional"`
+		FromSources []string       `hcl:"sources,optional"`
+		Retries     int            `hcl:"retries,optional"`
+		ForEach     hcl.Expression `hcl:"for_each,optional"`
+		Config      hcl.Body       `hcl:",remain"`
 	}
-	diags := gohcl.DecodeBody(block.Body, nil, &b)
+	diags := gohcl.DecodeBody(block.Body, attrsCtx, &b)
 	if diags.HasErrors() {
 		return nil, diags
 	}
 
 	build.Name = b.Name
 	build.Description = b.Description
+	build.Retries = b.Retries
+
+	if b.Retries < 0 {
+		diags = append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Invalid retries",
+			Detail:   "retries must not be negative",
+			Subject:  block.DefRange.Ptr(),
+		})
+		return nil, diags
+	}
 
 	for _, buildFrom := range b.FromSources {
 		ref := sourceRefFromString(buildFrom)
 
 		if ref == NoSource ||
+			(ref.Module != "" && !hclsyntax.ValidIdentifier(ref.Module)) ||
 			!hclsyntax.ValidIdentifier(ref.Type) ||
 			!hclsyntax.ValidIdentifier(ref.Name) {
 			diags = append(diags, &hcl.Diagnostic{
@@ -99,7 +208,8 @@ func (p *Parser) decodeBuildConfig(block *hcl.Block, cfg *PackerConfig) (*BuildB
 				Detail: "A " + sourceLabel + " type is made of three parts that are" +
 					"split by a dot `.`; each part must start with a letter and " +
 					"may contain only letters, digits, underscores, and dashes." +
-					"A valid source reference looks like: `source.type.name`",
+					"A valid source reference looks like: `source.type.name`, or " +
+					"`module.module_name.source.type.name` for the source of a module.",
 				Subject: block.DefRange.Ptr(),
 			})
 			continue
@@ -113,24 +223,43 @@ func (p *Parser) decodeBuildConfig(block *hcl.Block, cfg *PackerConfig) (*BuildB
 	if diags.HasErrors() {
 		return nil, diags
 	}
+	// The number of the last parallel block
+	group := 0
 	for _, block := range content.Blocks {
 		switch block.Type {
 		case sourceLabel:
-			ref, moreDiags := p.decodeBuildSource(block)
+			ref, moreDiags := p.decodeBuildSource(block, attrsCtx)
 			diags = append(diags, moreDiags...)
 			if moreDiags.HasErrors() {
 				continue
 			}
 			build.Sources = append(build.Sources, ref)
 		case buildProvisionerLabel:
-			p, moreDiags := p.decodeProvisioner(block, cfg)
+			p, moreDiags := p.decodeProvisioner(block, ectx)
 			diags = append(diags, moreDiags...)
 			if moreDiags.HasErrors() {
 				continue
 			}
 			build.ProvisionerBlocks = append(build.ProvisionerBlocks, p)
+		case buildParallelLabel:
+			content, moreDiags := block.Body.Content(parallelSchema)
+			diags = append(diags, moreDiags...)
+			if moreDiags.HasErrors() {
+				continue
+			}
+
+			group++
+			for _, block := range content.Blocks {
+				p, moreDiags := p.decodeProvisioner(block, ectx)
+				diags = append(diags, moreDiags...)
+				if moreDiags.HasErrors() {
+					continue
+				}
+				p.Group = group
+				build.ProvisionerBlocks = append(build.ProvisionerBlocks, p)
+			}
 		case buildPostProcessorLabel:
-			pp, moreDiags := p.decodePostProcessor(block)
+			pp, moreDiags := p.decodePostProcessor(block, attrsCtx)
 			diags = append(diags, moreDiags...)
 			if moreDiags.HasErrors() {
 				continue
@@ -147,7 +276,7 @@ func (p *Parser) decodeBuildConfig(block *hcl.Block, cfg *PackerConfig) (*BuildB
 			errored := false
 			postProcessors := []*PostProcessorBlock{}
 			for _, block := range content.Blocks {
-				pp, moreDiags := p.decodePostProcessor(block)
+				pp, moreDiags := p.decodePostProcessor(block, attrsCtx)
 				diags = append(diags, moreDiags...)
 				if moreDiags.HasErrors() {
 					errored = true
@@ -158,6 +287,52 @@ func (p *Parser) decodeBuildConfig(block *hcl.Block, cfg *PackerConfig) (*BuildB
 			if errored == false {
 				build.PostProcessorsLists = append(build.PostProcessorsLists, postProcessors)
 			}
+		case buildOutputLabel:
+			output, moreDiags := p.decodeOutput(block)
+			diags = append(diags, moreDiags...)
+			if moreDiags.HasErrors() {
+				continue
+			}
+			if existing := build.output(output.Name); existing != nil {
+				diags = append(diags, &hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Duplicate " + buildOutputLabel + " block",
+					Detail: fmt.Sprintf("This "+buildOutputLabel+" block has the "+
+						"same name as a previous block declared at %s.",
+						existing.DefRange.Ptr()),
+					Subject: block.DefRange.Ptr(),
+				})
+				continue
+			}
+			build.OutputBlocks = append(build.OutputBlocks, output)
+		case buildIncludeLabel:
+			included, err := cfg.moduleBuild(block.Labels[0])
+			if err != nil {
+				diags = append(diags, &hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Invalid " + buildIncludeLabel + " reference",
+					Detail:   err.Error(),
+					Subject:  block.LabelRanges[0].Ptr(),
+				})
+				continue
+			}
+
+			// The parallel groups of the included build follow the ones of
+			// this build
+			last := group
+			for _, pb := range included.ProvisionerBlocks {
+				pb := *pb
+				if pb.Group > 0 {
+					pb.Group += group
+					if pb.Group > last {
+						last = pb.Group
+					}
+				}
+				build.ProvisionerBlocks = append(build.ProvisionerBlocks, &pb)
+			}
+			group = last
+			build.PostProcessorsLists = append(build.PostProcessorsLists, included.PostProcessorsLists...)
+			build.OutputBlocks = append(build.OutputBlocks, included.OutputBlocks...)
 		}
 	}
 
@@ -0,0 +1,147 @@
+package hcl2template
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/gohcl"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	hcl2shim "github.com/hashicorp/packer/hcl2template/shim"
+	"github.com/hashicorp/packer/packer"
+	"github.com/zclconf/go-cty/cty"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+)
+
+// OutputBlock references an 'output' block of a build, evaluated once a build
+// of the block succeeded, for example:
+//
+//	output "ami_id" {
+//		value = build.ArtifactId
+//	}
+type OutputBlock struct {
+	Name        string
+	Description string
+	Sensitive   bool
+	Value       hcl.Expression
+
+	DefRange hcl.Range
+}
+
+func (p *Parser) decodeOutput(block *hcl.Block) (*OutputBlock, hcl.Diagnostics) {
+	var b struct {
+		Value       hcl.Expression `hcl:"value"`
+		Description string         `hcl:"description,optional"`
+		Sensitive   bool           `hcl:"sensitive,optional"`
+	}
+	diags := gohcl.DecodeBody(block.Body, nil, &b)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	if !hclsyntax.ValidIdentifier(block.Labels[0]) {
+		diags = append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Invalid " + buildOutputLabel + " name",
+			Detail:   badIdentifierDetail,
+			Subject:  block.LabelRanges[0].Ptr(),
+		})
+		return nil, diags
+	}
+
+	return &OutputBlock{
+		Name:        block.Labels[0],
+		Description: b.Description,
+		Sensitive:   b.Sensitive,
+		Value:       b.Value,
+		DefRange:    block.DefRange,
+	}, diags
+}
+
+// hcl2Outputs evaluates the outputs of a build of a source, with the
+// artifacts of the build as build variables.
+type hcl2Outputs struct {
+	blocks      []*OutputBlock
+	evalContext *hcl.EvalContext
+	buildName   string
+}
+
+// check evaluates the outputs with the variables of the build that are only
+// known once it ran, so that errors show before the build runs.
+func (o *hcl2Outputs) check(unknownBuildValues map[string]cty.Value) hcl.Diagnostics {
+	buildValues := map[string]cty.Value{
+		"ArtifactId":  cty.UnknownVal(cty.String),
+		"ArtifactIds": cty.UnknownVal(cty.List(cty.String)),
+	}
+	for k, v := range unknownBuildValues {
+		buildValues[k] = v
+	}
+	_, diags := o.evaluate(buildValues)
+	return diags
+}
+
+func (o *hcl2Outputs) EvaluateOutputs(artifacts []packer.Artifact) (map[string]packer.BuildOutput, error) {
+	buildValues := map[string]cty.Value{}
+	ids := []cty.Value{}
+	for _, artifact := range artifacts {
+		if artifact == nil {
+			continue
+		}
+		if len(ids) == 0 {
+			// The generated data of the first artifact, the one of the
+			// builder unless a post-processor discarded it
+			if data, ok := artifact.State("generated_data").(map[interface{}]interface{}); ok {
+				for k, v := range data {
+					buildValues[k.(string)] = hcl2shim.HCL2ValueFromConfigValue(v)
+				}
+			}
+		}
+		ids = append(ids, cty.StringVal(artifact.Id()))
+	}
+	buildValues["name"] = cty.StringVal(o.buildName)
+	buildValues["ArtifactId"] = cty.StringVal("")
+	buildValues["ArtifactIds"] = cty.ListValEmpty(cty.String)
+	if len(ids) > 0 {
+		buildValues["ArtifactId"] = ids[0]
+		buildValues["ArtifactIds"] = cty.ListVal(ids)
+	}
+
+	outputs, diags := o.evaluate(buildValues)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	return outputs, nil
+}
+
+func (o *hcl2Outputs) evaluate(buildValues map[string]cty.Value) (map[string]packer.BuildOutput, hcl.Diagnostics) {
+	var diags hcl.Diagnostics
+
+	ectx := o.evalContext.NewChild()
+	ectx.Variables = map[string]cty.Value{
+		buildAccessor: cty.ObjectVal(buildValues),
+	}
+
+	outputs := map[string]packer.BuildOutput{}
+	for _, block := range o.blocks {
+		value, moreDiags := block.Value.Value(ectx)
+		diags = append(diags, moreDiags...)
+		if moreDiags.HasErrors() || !value.IsWhollyKnown() {
+			continue
+		}
+		js, err := ctyjson.Marshal(value, value.Type())
+		if err != nil {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  fmt.Sprintf("Invalid value for %s %q", buildOutputLabel, block.Name),
+				Detail:   err.Error(),
+				Subject:  block.Value.Range().Ptr(),
+			})
+			continue
+		}
+		outputs[block.Name] = packer.BuildOutput{
+			Value:       js,
+			Description: block.Description,
+			Sensitive:   block.Sensitive,
+		}
+	}
+	return outputs, diags
+}
@@ -0,0 +1,80 @@
+package hcl2template
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/hashicorp/packer/packer"
+)
+
+func TestBuildOutputs(t *testing.T) {
+	cfg, diags := getBasicParser().Parse("testdata/build/outputs.pkr.hcl", nil, nil)
+	diags = append(diags, cfg.Initialize()...)
+	if diags.HasErrors() {
+		t.Fatalf("Parse: %s", diags)
+	}
+	builds, diags := cfg.GetBuilds(packer.GetBuildsOptions{})
+	if diags.HasErrors() {
+		t.Fatalf("GetBuilds: %s", diags)
+	}
+	build := builds[0].(*packer.CoreBuild)
+	if build.OutputsEvaluator == nil {
+		t.Fatal("the build should have outputs")
+	}
+
+	outputs, err := build.Outputs([]packer.Artifact{
+		&packer.MockArtifact{
+			IdValue: "vm-1",
+			StateValues: map[string]interface{}{
+				"generated_data": map[interface{}]interface{}{
+					"Host":     "10.0.0.2",
+					"Password": "secret",
+				},
+			},
+		},
+		nil,
+		&packer.MockArtifact{IdValue: "box-1"},
+	})
+	if err != nil {
+		t.Fatalf("Outputs: %s", err)
+	}
+
+	got := map[string]string{}
+	for name, output := range outputs {
+		got[name] = string(output.Value)
+	}
+	want := map[string]string{
+		"image_id": `"vm-1"`,
+		"image":    `{"host":"10.0.0.2","ids":["vm-1","box-1"],"name":"ubuntu-ubuntu-1204"}`,
+		"password": `"secret"`,
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("wrong outputs: %s", diff)
+	}
+	if outputs["image_id"].Description != "The ID of the image." || !outputs["password"].Sensitive {
+		t.Fatalf("wrong outputs: %#v", outputs)
+	}
+}
+
+func TestBuildOutputs_invalid(t *testing.T) {
+	tests := []struct {
+		file string
+		want string
+	}{
+		{"testdata/build/outputs_duplicate.pkr.hcl", "Duplicate output block"},
+		{"testdata/build/outputs_invalid.pkr.hcl", `This object does not have an attribute named "ImageId".`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			cfg, diags := getBasicParser().Parse(tt.file, nil, nil)
+			diags = append(diags, cfg.Initialize()...)
+			if !diags.HasErrors() {
+				_, diags = cfg.GetBuilds(packer.GetBuildsOptions{})
+			}
+			if !diags.HasErrors() || !strings.Contains(diags.Error(), tt.want) {
+				t.Fatalf("expected an error with %q, got %s", tt.want, diags)
+			}
+		})
+	}
+}
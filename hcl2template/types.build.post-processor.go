@@ -5,7 +5,6 @@ import (
 
 	"github.com/hashicorp/hcl/v2"
 	"github.com/hashicorp/hcl/v2/gohcl"
-	"github.com/hashicorp/packer/packer"
 )
 
 // ProvisionerBlock references a detected but unparsed post processor
@@ -13,6 +12,7 @@ type PostProcessorBlock struct {
 	PType             string
 	PName             string
 	OnlyExcept        OnlyExcept
+	When              hcl.Expression
 	KeepInputArtifact *bool
 
 	HCL2Ref
@@ -22,15 +22,16 @@ func (p *PostProcessorBlock) String() string {
 	return fmt.Sprintf(buildPostProcessorLabel+"-block %q %q", p.PType, p.PName)
 }
 
-func (p *Parser) decodePostProcessor(block *hcl.Block) (*PostProcessorBlock, hcl.Diagnostics) {
+func (p *Parser) decodePostProcessor(block *hcl.Block, ectx *hcl.EvalContext) (*PostProcessorBlock, hcl.Diagnostics) {
 	var b struct {
-		Name              string   `hcl:"name,optional"`
-		Only              []string `hcl:"only,optional"`
-		Except            []string `hcl:"except,optional"`
-		KeepInputArtifact *bool    `hcl:"keep_input_artifact,optional"`
-		Rest              hcl.Body `hcl:",remain"`
+		Name              string         `hcl:"name,optional"`
+		Only              []string       `hcl:"only,optional"`
+		Except            []string       `hcl:"except,optional"`
+		When              hcl.Expression `hcl:"when,optional"`
+		KeepInputArtifact *bool          `hcl:"keep_input_artifact,optional"`
+		Rest              hcl.Body       `hcl:",remain"`
 	}
-	diags := gohcl.DecodeBody(block.Body, nil, &b)
+	diags := gohcl.DecodeBody(block.Body, ectx, &b)
 	if diags.HasErrors() {
 		return nil, diags
 	}
@@ -39,6 +40,7 @@ func (p *Parser) decodePostProcessor(block *hcl.Block) (*PostProcessorBlock, hcl
 		PType:             block.Labels[0],
 		PName:             b.Name,
 		OnlyExcept:        OnlyExcept{Only: b.Only, Except: b.Except},
+		When:              b.When,
 		HCL2Ref:           newHCL2Ref(block, b.Rest),
 		KeepInputArtifact: b.KeepInputArtifact,
 	}
@@ -61,7 +63,7 @@ func (p *Parser) decodePostProcessor(block *hcl.Block) (*PostProcessorBlock, hcl
 	return postProcessor, diags
 }
 
-func (cfg *PackerConfig) startPostProcessor(source SourceBlock, pp *PostProcessorBlock, ectx *hcl.EvalContext) (packer.PostProcessor, hcl.Diagnostics) {
+func (cfg *PackerConfig) startPostProcessor(source SourceBlock, pp *PostProcessorBlock, ectx *hcl.EvalContext) (*HCL2PostProcessor, hcl.Diagnostics) {
 	// ProvisionerBlock represents a detected but unparsed provisioner
 	var diags hcl.Diagnostics
 
@@ -7,7 +7,6 @@ import (
 	"github.com/hashicorp/hcl/v2"
 	"github.com/hashicorp/hcl/v2/gohcl"
 	hcl2shim "github.com/hashicorp/packer/hcl2template/shim"
-	"github.com/hashicorp/packer/packer"
 	"github.com/zclconf/go-cty/cty"
 )
 
@@ -66,6 +65,12 @@ type ProvisionerBlock struct {
 	Timeout     time.Duration
 	Override    map[string]interface{}
 	OnlyExcept  OnlyExcept
+	// When is evaluated for each source, the provisioner is skipped for the
+	// sources it is false for.
+	When hcl.Expression
+	// The number of the parallel block the provisioner is part of, starting
+	// at 1, or 0 if it runs on its own.
+	Group int
 	HCL2Ref
 }
 
@@ -73,18 +78,19 @@ func (p *ProvisionerBlock) String() string {
 	return fmt.Sprintf(buildProvisionerLabel+"-block %q %q", p.PType, p.PName)
 }
 
-func (p *Parser) decodeProvisioner(block *hcl.Block, cfg *PackerConfig) (*ProvisionerBlock, hcl.Diagnostics) {
+func (p *Parser) decodeProvisioner(block *hcl.Block, ectx *hcl.EvalContext) (*ProvisionerBlock, hcl.Diagnostics) {
 	var b struct {
-		Name        string    `hcl:"name,optional"`
-		PauseBefore string    `hcl:"pause_before,optional"`
-		MaxRetries  int       `hcl:"max_retries,optional"`
-		Timeout     string    `hcl:"timeout,optional"`
-		Only        []string  `hcl:"only,optional"`
-		Except      []string  `hcl:"except,optional"`
-		Override    cty.Value `hcl:"override,optional"`
-		Rest        hcl.Body  `hcl:",remain"`
+		Name        string         `hcl:"name,optional"`
+		PauseBefore string         `hcl:"pause_before,optional"`
+		MaxRetries  int            `hcl:"max_retries,optional"`
+		Timeout     string         `hcl:"timeout,optional"`
+		Only        []string       `hcl:"only,optional"`
+		Except      []string       `hcl:"except,optional"`
+		When        hcl.Expression `hcl:"when,optional"`
+		Override    cty.Value      `hcl:"override,optional"`
+		Rest        hcl.Body       `hcl:",remain"`
 	}
-	diags := gohcl.DecodeBody(block.Body, cfg.EvalContext(nil), &b)
+	diags := gohcl.DecodeBody(block.Body, ectx, &b)
 	if diags.HasErrors() {
 		return nil, diags
 	}
@@ -94,6 +100,7 @@ func (p *Parser) decodeProvisioner(block *hcl.Block, cfg *PackerConfig) (*Provis
 		PName:      b.Name,
 		MaxRetries: b.MaxRetries,
 		OnlyExcept: OnlyExcept{Only: b.Only, Except: b.Except},
+		When:       b.When,
 		HCL2Ref:    newHCL2Ref(block, b.Rest),
 	}
 
@@ -148,7 +155,7 @@ func (p *Parser) decodeProvisioner(block *hcl.Block, cfg *PackerConfig) (*Provis
 	return provisioner, diags
 }
 
-func (cfg *PackerConfig) startProvisioner(source SourceBlock, pb *ProvisionerBlock, ectx *hcl.EvalContext) (packer.Provisioner, hcl.Diagnostics) {
+func (cfg *PackerConfig) startProvisioner(source SourceBlock, pb *ProvisionerBlock, ectx *hcl.EvalContext) (*HCL2Provisioner, hcl.Diagnostics) {
 	var diags hcl.Diagnostics
 
 	provisioner, err := cfg.provisionersSchemas.Start(pb.PType)
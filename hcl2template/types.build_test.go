@@ -174,6 +174,7 @@ func TestParse_build(t *testing.T) {
 			[]packer.Build{
 				&packer.CoreBuild{
 					Type:         "virtualbox-iso.ubuntu-1204",
+					BuilderType:  "virtualbox-iso",
 					Prepared:     true,
 					Builder:      emptyMockBuilder,
 					Provisioners: []packer.CoreBuildProvisioner{},
@@ -208,6 +209,7 @@ func TestParse_build(t *testing.T) {
 				},
 				&packer.CoreBuild{
 					Type:         "amazon-ebs.aws-ubuntu-16.04",
+					BuilderType:  "amazon-ebs",
 					Prepared:     true,
 					Builder:      emptyMockBuilder,
 					Provisioners: []packer.CoreBuildProvisioner{},
@@ -243,6 +245,139 @@ func TestParse_build(t *testing.T) {
 			},
 			false,
 		},
+		{"parallel provisioners",
+			defaultParser,
+			parseTestArgs{"testdata/build/provisioner_parallel.pkr.hcl", nil, nil},
+			&PackerConfig{
+				Basedir: filepath.Join("testdata", "build"),
+				Sources: map[SourceRef]SourceBlock{
+					refVBIsoUbuntu1204: {Type: "virtualbox-iso", Name: "ubuntu-1204"},
+				},
+				Builds: Builds{
+					&BuildBlock{
+						Sources: []SourceRef{refVBIsoUbuntu1204},
+						ProvisionerBlocks: []*ProvisionerBlock{
+							{
+								PType: "shell",
+							},
+							{
+								PType: "shell",
+								PName: "packages",
+								Group: 1,
+							},
+							{
+								PType: "file",
+								PName: "caches",
+								Group: 1,
+							},
+							{
+								PType: "file",
+							},
+						},
+					},
+				},
+			},
+			false, false,
+			[]packer.Build{
+				&packer.CoreBuild{
+					Type:        "virtualbox-iso.ubuntu-1204",
+					BuilderType: "virtualbox-iso",
+					Prepared:    true,
+					Builder:     emptyMockBuilder,
+					Provisioners: []packer.CoreBuildProvisioner{
+						{
+							PType: "shell",
+							Provisioner: &HCL2Provisioner{
+								Provisioner: &MockProvisioner{
+									Config: MockConfig{
+										NestedMockConfig: NestedMockConfig{Tags: []MockTag{}},
+										NestedSlice:      []NestedMockConfig{},
+									},
+								},
+							},
+						},
+						{
+							PType: "parallel",
+							Provisioner: &packer.ParallelProvisioner{
+								Provisioners: []packer.Provisioner{
+									&HCL2Provisioner{
+										Provisioner: &MockProvisioner{
+											Config: MockConfig{
+												NestedMockConfig: NestedMockConfig{Tags: []MockTag{}},
+												NestedSlice:      []NestedMockConfig{},
+											},
+										},
+									},
+									&HCL2Provisioner{
+										Provisioner: &MockProvisioner{
+											Config: MockConfig{
+												NestedMockConfig: NestedMockConfig{Tags: []MockTag{}},
+												NestedSlice:      []NestedMockConfig{},
+											},
+										},
+									},
+								},
+							},
+						},
+						{
+							PType: "file",
+							Provisioner: &HCL2Provisioner{
+								Provisioner: &MockProvisioner{
+									Config: MockConfig{
+										NestedMockConfig: NestedMockConfig{Tags: []MockTag{}},
+										NestedSlice:      []NestedMockConfig{},
+									},
+								},
+							},
+						},
+					},
+					PostProcessors: [][]packer.CoreBuildPostProcessor{},
+				},
+			},
+			false,
+		},
+		{"retries",
+			defaultParser,
+			parseTestArgs{"testdata/build/retries.pkr.hcl", nil, nil},
+			&PackerConfig{
+				Basedir: filepath.Join("testdata", "build"),
+				Sources: map[SourceRef]SourceBlock{
+					refVBIsoUbuntu1204: {Type: "virtualbox-iso", Name: "ubuntu-1204"},
+				},
+				Builds: Builds{
+					&BuildBlock{
+						Sources: []SourceRef{refVBIsoUbuntu1204},
+						Retries: 2,
+					},
+				},
+			},
+			false, false,
+			[]packer.Build{
+				&packer.CoreBuild{
+					Type:           "virtualbox-iso.ubuntu-1204",
+					BuilderType:    "virtualbox-iso",
+					Prepared:       true,
+					Builder:        emptyMockBuilder,
+					Retries:        2,
+					Provisioners:   []packer.CoreBuildProvisioner{},
+					PostProcessors: [][]packer.CoreBuildPostProcessor{},
+				},
+			},
+			false,
+		},
+		{"negative retries",
+			defaultParser,
+			parseTestArgs{"testdata/build/retries_negative.pkr.hcl", nil, nil},
+			&PackerConfig{
+				Basedir: filepath.Join("testdata", "build"),
+				Sources: map[SourceRef]SourceBlock{
+					refVBIsoUbuntu1204: {Type: "virtualbox-iso", Name: "ubuntu-1204"},
+				},
+			},
+			true, true,
+			[]packer.Build{},
+			false,
+		},
 		{"provisioner with only and except",
 			defaultParser,
 			parseTestArgs{"testdata/build/provisioner_onlyexcept.pkr.hcl", nil, nil},
@@ -282,9 +417,10 @@ func TestParse_build(t *testing.T) {
 			false, false,
 			[]packer.Build{
 				&packer.CoreBuild{
-					Type:     "virtualbox-iso.ubuntu-1204",
-					Prepared: true,
-					Builder:  emptyMockBuilder,
+					Type:        "virtualbox-iso.ubuntu-1204",
+					BuilderType: "virtualbox-iso",
+					Prepared:    true,
+					Builder:     emptyMockBuilder,
 					Provisioners: []packer.CoreBuildProvisioner{
 						{
 							PType: "shell",
@@ -312,9 +448,10 @@ func TestParse_build(t *testing.T) {
 					PostProcessors: [][]packer.CoreBuildPostProcessor{},
 				},
 				&packer.CoreBuild{
-					Type:     "amazon-ebs.aws-ubuntu-16.04",
-					Prepared: true,
-					Builder:  emptyMockBuilder,
+					Type:        "amazon-ebs.aws-ubuntu-16.04",
+					BuilderType: "amazon-ebs",
+					Prepared:    true,
+					Builder:     emptyMockBuilder,
 					Provisioners: []packer.CoreBuildProvisioner{
 						{
 							PType: "file",
@@ -344,6 +481,110 @@ func TestParse_build(t *testing.T) {
 			},
 			false,
 		},
+		{"provisioner and post-processor with when",
+			defaultParser,
+			parseTestArgs{"testdata/build/provisioner_when.pkr.hcl", nil, nil},
+			&PackerConfig{
+				Basedir: filepath.Join("testdata", "build"),
+				Sources: map[SourceRef]SourceBlock{
+					refVBIsoUbuntu1204:  {Type: "virtualbox-iso", Name: "ubuntu-1204"},
+					refAWSEBSUbuntu1604: {Type: "amazon-ebs", Name: "ubuntu-1604"},
+				},
+				Builds: Builds{
+					&BuildBlock{
+						Sources: []SourceRef{refVBIsoUbuntu1204, refAWSEBSUbuntu1604},
+						ProvisionerBlocks: []*ProvisionerBlock{
+							{PType: "shell"},
+							{PType: "file"},
+						},
+						PostProcessorsLists: [][]*PostProcessorBlock{
+							{
+								{PType: "manifest"},
+							},
+						},
+					},
+				},
+			},
+			false, false,
+			[]packer.Build{
+				&packer.CoreBuild{
+					Type:        "virtualbox-iso.ubuntu-1204",
+					BuilderType: "virtualbox-iso",
+					Prepared:    true,
+					Builder:     emptyMockBuilder,
+					Provisioners: []packer.CoreBuildProvisioner{
+						{
+							PType: "shell",
+							Provisioner: &HCL2Provisioner{
+								Provisioner: &MockProvisioner{
+									Config: MockConfig{
+										NestedMockConfig: NestedMockConfig{Tags: []MockTag{}},
+										NestedSlice:      []NestedMockConfig{},
+									},
+								},
+							},
+						},
+					},
+					PostProcessors: [][]packer.CoreBuildPostProcessor{},
+				},
+				&packer.CoreBuild{
+					Type:        "amazon-ebs.ubuntu-1604",
+					BuilderType: "amazon-ebs",
+					Prepared:    true,
+					Builder:     emptyMockBuilder,
+					Provisioners: []packer.CoreBuildProvisioner{
+						{
+							PType: "file",
+							Provisioner: &HCL2Provisioner{
+								Provisioner: &MockProvisioner{
+									Config: MockConfig{
+										NestedMockConfig: NestedMockConfig{Tags: []MockTag{}},
+										NestedSlice:      []NestedMockConfig{},
+									},
+								},
+							},
+						},
+					},
+					PostProcessors: [][]packer.CoreBuildPostProcessor{
+						{
+							{
+								PType: "manifest",
+								PostProcessor: &HCL2PostProcessor{
+									PostProcessor: &MockPostProcessor{
+										Config: MockConfig{
+											NestedMockConfig: NestedMockConfig{Tags: []MockTag{}},
+											NestedSlice:      []NestedMockConfig{},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			false,
+		},
+		{"provisioner with an invalid when",
+			defaultParser,
+			parseTestArgs{"testdata/build/provisioner_when_invalid.pkr.hcl", nil, nil},
+			&PackerConfig{
+				Basedir: filepath.Join("testdata", "build"),
+				Sources: map[SourceRef]SourceBlock{
+					refVBIsoUbuntu1204: {Type: "virtualbox-iso", Name: "ubuntu-1204"},
+				},
+				Builds: Builds{
+					&BuildBlock{
+						Sources: []SourceRef{refVBIsoUbuntu1204},
+						ProvisionerBlocks: []*ProvisionerBlock{
+							{PType: "shell"},
+						},
+					},
+				},
+			},
+			false, false,
+			[]packer.Build{},
+			true,
+		},
 	}
 	testParse(t, tests)
 }
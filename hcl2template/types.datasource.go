@@ -0,0 +1,191 @@
+package hcl2template
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hcldec"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+)
+
+const datasourceAccessor = "data"
+
+var datasourceSchema = &hcl.BodySchema{
+	Blocks: []hcl.BlockHeaderSchema{
+		{Type: dataLabel, LabelNames: []string{"type", "name"}},
+	},
+}
+
+// DatasourceBlock references an HCL 'data' block, that fetches data when the
+// template is evaluated, for example :
+//
+//	data "http" "ubuntu" {
+//		url         = "https://example.com/ubuntu/latest.json"
+//		decode_json = true
+//	}
+//
+// The output of the data source can then be used by locals, sources and
+// builds, like "data.http.ubuntu.json.iso_url".
+type DatasourceBlock struct {
+	// Type of the data source; "http"
+	Type string
+	// Name of the data source; "ubuntu"
+	Name string
+
+	// Value is the output of the data source.
+	Value cty.Value
+
+	block *hcl.Block
+}
+
+// DatasourceRef is a reference to a data source, by type and name.
+type DatasourceRef struct {
+	Type string
+	Name string
+}
+
+func (d *DatasourceBlock) Ref() DatasourceRef {
+	return DatasourceRef{
+		Type: d.Type,
+		Name: d.Name,
+	}
+}
+
+func (r DatasourceRef) String() string {
+	return fmt.Sprintf("%s.%s.%s", datasourceAccessor, r.Type, r.Name)
+}
+
+type Datasources map[DatasourceRef]DatasourceBlock
+
+// Values returns the outputs of the data sources by type and then by name,
+// the way templates access them.
+func (datasources Datasources) Values() map[string]cty.Value {
+	byType := map[string]map[string]cty.Value{}
+	for ref, datasource := range datasources {
+		if byType[ref.Type] == nil {
+			byType[ref.Type] = map[string]cty.Value{}
+		}
+		byType[ref.Type][ref.Name] = datasource.Value
+	}
+	res := map[string]cty.Value{}
+	for t, values := range byType {
+		res[t] = cty.ObjectVal(values)
+	}
+	return res
+}
+
+// decodeDatasources runs the data sources declared in f. It should be called
+// after input variables are set, as data sources can use them, and before
+// locals are evaluated, as locals can use data sources.
+func (cfg *PackerConfig) decodeDatasources(f *hcl.File) hcl.Diagnostics {
+	content, _, diags := f.Body.PartialContent(datasourceSchema)
+
+	for _, block := range content.Blocks {
+		ref := DatasourceRef{
+			Type: block.Labels[0],
+			Name: block.Labels[1],
+		}
+		if existing, found := cfg.Datasources[ref]; found {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Duplicate " + dataLabel + " block",
+				Detail: fmt.Sprintf("This "+dataLabel+" block has the "+
+					"same type and name as a previous block declared "+
+					"at %s. Each "+dataLabel+" must have a unique name per type.",
+					existing.block.DefRange.Ptr()),
+				Subject: block.DefRange.Ptr(),
+			})
+			continue
+		}
+
+		value, moreDiags := cfg.executeDatasource(block)
+		diags = append(diags, moreDiags...)
+		if moreDiags.HasErrors() {
+			continue
+		}
+
+		if cfg.Datasources == nil {
+			cfg.Datasources = Datasources{}
+		}
+		cfg.Datasources[ref] = DatasourceBlock{
+			Type:  ref.Type,
+			Name:  ref.Name,
+			Value: value,
+			block: block,
+		}
+	}
+
+	return diags
+}
+
+func (cfg *PackerConfig) executeDatasource(block *hcl.Block) (cty.Value, hcl.Diagnostics) {
+	var diags hcl.Diagnostics
+	datasourceType := block.Labels[0]
+
+	if cfg.datasourceSchemas == nil || !cfg.datasourceSchemas.Has(datasourceType) {
+		var known []string
+		if cfg.datasourceSchemas != nil {
+			known = cfg.datasourceSchemas.List()
+		}
+		diags = append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Unknown " + dataLabel + " type " + datasourceType,
+			Detail:   fmt.Sprintf("known data sources: %v", known),
+			Subject:  block.LabelRanges[0].Ptr(),
+		})
+		return cty.NilVal, diags
+	}
+
+	datasource, err := cfg.datasourceSchemas.Start(datasourceType)
+	if err != nil {
+		diags = append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Failed to load " + dataLabel + " type " + datasourceType,
+			Detail:   err.Error(),
+			Subject:  block.LabelRanges[0].Ptr(),
+		})
+		return cty.NilVal, diags
+	}
+
+	decoded, moreDiags := decodeHCL2Spec(block.Body, cfg.EvalContext(nil), datasource)
+	diags = append(diags, moreDiags...)
+	if moreDiags.HasErrors() {
+		return cty.NilVal, diags
+	}
+
+	ref := DatasourceRef{Type: datasourceType, Name: block.Labels[1]}
+	if err := datasource.Configure(decoded); err != nil {
+		diags = append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Failed preparing " + ref.String(),
+			Detail:   err.Error(),
+			Subject:  block.DefRange.Ptr(),
+		})
+		return cty.NilVal, diags
+	}
+
+	value, err := datasource.Execute()
+	if err != nil {
+		diags = append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Failed executing " + ref.String(),
+			Detail:   err.Error(),
+			Subject:  block.DefRange.Ptr(),
+		})
+		return cty.NilVal, diags
+	}
+
+	value, err = convert.Convert(value, hcldec.ImpliedType(datasource.OutputSpec()))
+	if err != nil {
+		diags = append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Invalid output of " + ref.String(),
+			Detail:   err.Error(),
+			Subject:  block.DefRange.Ptr(),
+		})
+		return cty.NilVal, diags
+	}
+
+	return value, diags
+}
@@ -0,0 +1,151 @@
+package hcl2template
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	. "github.com/hashicorp/packer/hcl2template/internal"
+	"github.com/hashicorp/packer/packer"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestParse_datasource(t *testing.T) {
+	defaultParser := getBasicParser()
+
+	tests := []parseTest{
+		{"data source used by a local",
+			defaultParser,
+			parseTestArgs{"testdata/datasources/basic.pkr.hcl", nil, nil},
+			&PackerConfig{
+				Basedir: filepath.Join("testdata", "datasources"),
+				InputVariables: Variables{
+					"image": &Variable{
+						Name:   "image",
+						Values: []VariableAssignment{{From: "default", Value: cty.StringVal("ubuntu")}},
+						Type:   cty.String,
+					},
+				},
+				LocalVariables: Variables{
+					"image_name": &Variable{
+						Name:   "image_name",
+						Values: []VariableAssignment{{From: "default", Value: cty.StringVal("ubuntu-1604-42")}},
+						Type:   cty.String,
+					},
+				},
+				Datasources: Datasources{
+					{Type: "mock", Name: "image"}: {
+						Type: "mock",
+						Name: "image",
+						Value: cty.ObjectVal(map[string]cty.Value{
+							"string": cty.StringVal("ubuntu-1604"),
+							"int":    cty.NumberIntVal(42),
+						}),
+					},
+				},
+				Sources: map[SourceRef]SourceBlock{
+					refVBIsoUbuntu1204: {Type: "virtualbox-iso", Name: "ubuntu-1204"},
+				},
+				Builds: Builds{
+					&BuildBlock{
+						Sources: []SourceRef{refVBIsoUbuntu1204},
+					},
+				},
+			},
+			false, false,
+			[]packer.Build{
+				&packer.CoreBuild{
+					Type:        "virtualbox-iso.ubuntu-1204",
+					BuilderType: "virtualbox-iso",
+					Prepared:    true,
+					Builder: &MockBuilder{
+						Config: MockConfig{
+							NestedMockConfig: NestedMockConfig{
+								String: "ubuntu-1604-42",
+								Tags:   []MockTag{},
+							},
+							NestedSlice: []NestedMockConfig{},
+						},
+					},
+					Provisioners:   []packer.CoreBuildProvisioner{},
+					PostProcessors: [][]packer.CoreBuildPostProcessor{},
+				},
+			},
+			false,
+		},
+		{"duplicate data source",
+			defaultParser,
+			parseTestArgs{"testdata/datasources/duplicate.pkr.hcl", nil, nil},
+			&PackerConfig{
+				Basedir: filepath.Join("testdata", "datasources"),
+				Datasources: Datasources{
+					{Type: "mock", Name: "image"}: {
+						Type: "mock",
+						Name: "image",
+						Value: cty.ObjectVal(map[string]cty.Value{
+							"string": cty.StringVal("ubuntu"),
+							"int":    cty.NumberIntVal(0),
+						}),
+					},
+				},
+			},
+			true, true,
+			[]packer.Build{},
+			false,
+		},
+		{"unknown data source type",
+			defaultParser,
+			parseTestArgs{"testdata/datasources/unknown.pkr.hcl", nil, nil},
+			&PackerConfig{
+				Basedir: filepath.Join("testdata", "datasources"),
+			},
+			true, true,
+			[]packer.Build{},
+			false,
+		},
+		{"unknown data source attribute",
+			defaultParser,
+			parseTestArgs{"testdata/datasources/unknown_attribute.pkr.hcl", nil, nil},
+			&PackerConfig{
+				Basedir: filepath.Join("testdata", "datasources"),
+			},
+			true, true,
+			[]packer.Build{},
+			false,
+		},
+	}
+	testParse(t, tests)
+}
+
+func TestPackerConfig_EvaluateExpression_datasource(t *testing.T) {
+	parser := getBasicParser()
+	cfg, diags := parser.Parse("testdata/datasources/basic.pkr.hcl", nil, map[string]string{"image": "debian"})
+	if diags.HasErrors() {
+		t.Fatalf("Parse: %s", diags)
+	}
+	if diags := cfg.Initialize(); diags.HasErrors() {
+		t.Fatalf("Initialize: %s", diags)
+	}
+
+	tests := map[string]string{
+		"data.mock.image.string":        "debian-1604",
+		"local.image_name":              "debian-1604-42",
+		"upper(data.mock.image.string)": "DEBIAN-1604",
+		"source.name":                   "ubuntu-1204",
+		"build.ID":                      "<unknown>",
+	}
+	for expr, expected := range tests {
+		out, _, diags := cfg.EvaluateExpression(expr)
+		if diags.HasErrors() {
+			t.Fatalf("%s: %s", expr, diags)
+		}
+		if out != expected {
+			t.Fatalf("%s: expected %q, got %q", expr, expected, out)
+		}
+	}
+
+	out, _, _ := cfg.EvaluateExpression("variables")
+	if !strings.Contains(out, `data.mock.image: "{`) {
+		t.Fatalf("the data sources should be listed: %s", out)
+	}
+}
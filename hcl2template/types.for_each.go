@@ -0,0 +1,93 @@
+package hcl2template
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/zclconf/go-cty/cty"
+)
+
+const (
+	forEachAttribute = "for_each"
+
+	eachAccessor = "each"
+)
+
+var forEachSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{Name: forEachAttribute},
+	},
+}
+
+// eachValue is one of the elements a source or build block with a for_each
+// meta-argument is repeated for. It can be accessed with each.key and
+// each.value in the block.
+type eachValue struct {
+	Key   string
+	Value cty.Value
+}
+
+func (e *eachValue) ctyValue() cty.Value {
+	return cty.ObjectVal(map[string]cty.Value{
+		"key":   cty.StringVal(e.Key),
+		"value": e.Value,
+	})
+}
+
+// decodeForEach evaluates the for_each meta-argument of body. It returns the
+// rest of body and the elements of for_each, in the order of their keys. ok
+// is false when body has no for_each meta-argument.
+func decodeForEach(body hcl.Body, ectx *hcl.EvalContext) (rest hcl.Body, values []*eachValue, ok bool, diags hcl.Diagnostics) {
+	content, rest, diags := body.PartialContent(forEachSchema)
+	if diags.HasErrors() {
+		return nil, nil, false, diags
+	}
+	attr, ok := content.Attributes[forEachAttribute]
+	if !ok {
+		return rest, nil, false, diags
+	}
+
+	v, moreDiags := attr.Expr.Value(ectx)
+	diags = append(diags, moreDiags...)
+	if moreDiags.HasErrors() {
+		return nil, nil, true, diags
+	}
+
+	invalid := func(detail string) hcl.Diagnostics {
+		return append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Invalid " + forEachAttribute + " argument",
+			Detail:   detail,
+			Subject:  attr.Expr.Range().Ptr(),
+		})
+	}
+	switch {
+	case v.IsNull():
+		return nil, nil, true, invalid("The given " + forEachAttribute + " argument value is null.")
+	case !v.IsWhollyKnown():
+		return nil, nil, true, invalid("The " + forEachAttribute + " value must be known when the template is loaded.")
+	}
+
+	ty := v.Type()
+	switch {
+	case ty.IsMapType() || ty.IsObjectType():
+		for it := v.ElementIterator(); it.Next(); {
+			k, elem := it.Element()
+			values = append(values, &eachValue{Key: k.AsString(), Value: elem})
+		}
+	case ty.IsSetType() && ty.ElementType() == cty.String:
+		for it := v.ElementIterator(); it.Next(); {
+			_, elem := it.Element()
+			if elem.IsNull() {
+				return nil, nil, true, invalid("The given " + forEachAttribute + " argument value must not contain null.")
+			}
+			values = append(values, &eachValue{Key: elem.AsString(), Value: elem})
+		}
+	default:
+		return nil, nil, true, invalid(fmt.Sprintf("The %s argument must be a map, or "+
+			"set of strings, and you have provided a value of type %s. A list can be "+
+			"converted to a set with convert(list, set(string)).", forEachAttribute, ty.FriendlyName()))
+	}
+
+	return rest, values, true, diags
+}
@@ -0,0 +1,126 @@
+package hcl2template
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	. "github.com/hashicorp/packer/hcl2template/internal"
+	"github.com/hashicorp/packer/packer"
+)
+
+func TestParse_for_each(t *testing.T) {
+	defaultParser := getBasicParser()
+
+	refVBIsoUbuntu1604 := SourceRef{Type: "virtualbox-iso", Name: "ubuntu-1604"}
+	mockBuilder := func(str string, i int) *MockBuilder {
+		return &MockBuilder{
+			Config: MockConfig{
+				NestedMockConfig: NestedMockConfig{String: str, Int: i, Tags: []MockTag{}},
+				NestedSlice:      []NestedMockConfig{},
+			},
+		}
+	}
+	shellProvisioner := func(str string) packer.CoreBuildProvisioner {
+		return packer.CoreBuildProvisioner{
+			PType: "shell",
+			Provisioner: &HCL2Provisioner{
+				Provisioner: &MockProvisioner{
+					Config: MockConfig{
+						NestedMockConfig: NestedMockConfig{String: str, Tags: []MockTag{}},
+						NestedSlice:      []NestedMockConfig{},
+					},
+				},
+			},
+		}
+	}
+	build := func(buildName, source string, builder *MockBuilder, provisioners ...packer.CoreBuildProvisioner) *packer.CoreBuild {
+		return &packer.CoreBuild{
+			BuildName:      buildName,
+			Type:           source,
+			BuilderType:    strings.SplitN(source, ".", 2)[0],
+			Prepared:       true,
+			Builder:        builder,
+			Provisioners:   provisioners,
+			PostProcessors: [][]packer.CoreBuildPostProcessor{},
+		}
+	}
+
+	tests := []parseTest{
+		{"for_each sources and builds",
+			defaultParser,
+			parseTestArgs{"testdata/for_each/basic.pkr.hcl", nil, nil},
+			&PackerConfig{
+				Basedir: filepath.Join("testdata", "for_each"),
+				Sources: map[SourceRef]SourceBlock{
+					refVBIsoUbuntu1204: {Type: "virtualbox-iso", Name: "ubuntu-1204"},
+					refVBIsoUbuntu1604: {Type: "virtualbox-iso", Name: "ubuntu-1604"},
+				},
+				Builds: Builds{
+					&BuildBlock{
+						Name:              "build-a",
+						Sources:           []SourceRef{{Type: "virtualbox-iso", Name: "ubuntu"}},
+						ProvisionerBlocks: []*ProvisionerBlock{{PType: "shell"}},
+					},
+					&BuildBlock{
+						Name:              "build-b",
+						Sources:           []SourceRef{{Type: "virtualbox-iso", Name: "ubuntu"}},
+						ProvisionerBlocks: []*ProvisionerBlock{{PType: "shell"}},
+					},
+				},
+			},
+			false, false,
+			[]packer.Build{
+				build("build-a", "virtualbox-iso.ubuntu-1204", mockBuilder("ubuntu-1204", 12), shellProvisioner("a-ubuntu-1204")),
+				build("build-a", "virtualbox-iso.ubuntu-1604", mockBuilder("ubuntu-1604", 16), shellProvisioner("a-ubuntu-1604")),
+				build("build-b", "virtualbox-iso.ubuntu-1204", mockBuilder("ubuntu-1204", 12), shellProvisioner("b-ubuntu-1204")),
+				build("build-b", "virtualbox-iso.ubuntu-1604", mockBuilder("ubuntu-1604", 16), shellProvisioner("b-ubuntu-1604")),
+			},
+			false,
+		},
+		{"dynamic sources and provisioners",
+			defaultParser,
+			parseTestArgs{"testdata/for_each/dynamic.pkr.hcl", nil, nil},
+			&PackerConfig{
+				Basedir: filepath.Join("testdata", "for_each"),
+				Sources: map[SourceRef]SourceBlock{
+					refVBIsoUbuntu1204: {Type: "virtualbox-iso", Name: "ubuntu-1204"},
+					refVBIsoUbuntu1604: {Type: "virtualbox-iso", Name: "ubuntu-1604"},
+				},
+				Builds: Builds{
+					&BuildBlock{
+						Sources:           []SourceRef{refVBIsoUbuntu1204, refVBIsoUbuntu1604},
+						ProvisionerBlocks: []*ProvisionerBlock{{PType: "shell"}, {PType: "shell"}},
+					},
+				},
+			},
+			false, false,
+			[]packer.Build{
+				build("", "virtualbox-iso.ubuntu-1204", mockBuilder("ubuntu-1204", 0), shellProvisioner("first"), shellProvisioner("second")),
+				build("", "virtualbox-iso.ubuntu-1604", mockBuilder("ubuntu-1604", 0), shellProvisioner("first"), shellProvisioner("second")),
+			},
+			false,
+		},
+		{"invalid for_each key",
+			defaultParser,
+			parseTestArgs{"testdata/for_each/invalid_key.pkr.hcl", nil, nil},
+			&PackerConfig{
+				Basedir: filepath.Join("testdata", "for_each"),
+			},
+			true, true,
+			[]packer.Build{},
+			false,
+		},
+		{"for_each list",
+			defaultParser,
+			parseTestArgs{"testdata/for_each/list.pkr.hcl", nil, nil},
+			&PackerConfig{
+				Basedir: filepath.Join("testdata", "for_each"),
+			},
+			true, true,
+			[]packer.Build{},
+			false,
+		},
+	}
+	testParse(t, tests)
+}
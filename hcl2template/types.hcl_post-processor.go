@@ -19,6 +19,9 @@ type HCL2PostProcessor struct {
 	postProcessorBlock *PostProcessorBlock
 	evalContext        *hcl.EvalContext
 	builderVariables   map[string]string
+	// decoded is the configuration decoded from the block when the
+	// post-processor was last prepared.
+	decoded cty.Value
 }
 
 func (p *HCL2PostProcessor) ConfigSpec() hcldec.ObjectSpec {
@@ -55,9 +58,16 @@ func (p *HCL2PostProcessor) HCL2Prepare(buildVars map[string]interface{}) error
 	if diags.HasErrors() {
 		return diags
 	}
+	p.decoded = flatPostProcessorCfg
 	return p.PostProcessor.Configure(p.builderVariables, flatPostProcessorCfg)
 }
 
+// planConfig returns the configuration of the post-processor for the plan of
+// the build.
+func (p *HCL2PostProcessor) planConfig() map[string]interface{} {
+	return planConfig(p.decoded)
+}
+
 func (p *HCL2PostProcessor) Configure(args ...interface{}) error {
 	return p.PostProcessor.Configure(args...)
 }
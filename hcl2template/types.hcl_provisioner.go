@@ -19,6 +19,9 @@ type HCL2Provisioner struct {
 	evalContext      *hcl.EvalContext
 	builderVariables map[string]string
 	override         map[string]interface{}
+	// decoded is the configuration decoded from the block when the
+	// provisioner was last prepared.
+	decoded cty.Value
 }
 
 func (p *HCL2Provisioner) ConfigSpec() hcldec.ObjectSpec {
@@ -58,9 +61,16 @@ func (p *HCL2Provisioner) HCL2Prepare(buildVars map[string]interface{}) error {
 	if diags.HasErrors() {
 		return diags
 	}
+	p.decoded = flatProvisionerCfg
 	return p.Provisioner.Prepare(p.builderVariables, flatProvisionerCfg, p.override)
 }
 
+// planConfig returns the configuration of the provisioner for the plan of the
+// build.
+func (p *HCL2Provisioner) planConfig() map[string]interface{} {
+	return planConfig(p.decoded, p.override)
+}
+
 func (p *HCL2Provisioner) Prepare(args ...interface{}) error {
 	return p.Provisioner.Prepare(args...)
 }
@@ -0,0 +1,114 @@
+package hcl2template
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/gohcl"
+	"github.com/hashicorp/packer/packer"
+)
+
+// LimitBlock references an HCL 'limit' block, that limits how many builds of
+// some builder types run at the same time, for example :
+//
+//	limit "hyperv" {
+//		max      = 2
+//		builders = ["hyperv-iso", "hyperv-vmcx"]
+//	}
+type LimitBlock struct {
+	// Name is the label of the block.
+	Name string
+
+	// Max is how many builds the limit applies to can run at once.
+	Max int
+
+	// Builders are the builder types the limit applies to. When empty, the
+	// limit applies to the builder type named like the limit and to the
+	// builder types starting with the name followed by a dash.
+	Builders []string
+
+	block *hcl.Block
+}
+
+type Limits []*LimitBlock
+
+func (limits Limits) get(name string) *LimitBlock {
+	for _, limit := range limits {
+		if limit.Name == name {
+			return limit
+		}
+	}
+	return nil
+}
+
+func (p *Parser) decodeLimit(block *hcl.Block) (*LimitBlock, hcl.Diagnostics) {
+	limit := &LimitBlock{
+		Name:  block.Labels[0],
+		block: block,
+	}
+
+	var b struct {
+		Max      int      `hcl:"max"`
+		Builders []string `hcl:"builders,optional"`
+	}
+	diags := gohcl.DecodeBody(block.Body, nil, &b)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	limit.Max = b.Max
+	limit.Builders = b.Builders
+
+	if b.Max < 1 {
+		diags = append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Invalid " + limitLabel + " max",
+			Detail:   "max must be at least 1",
+			Subject:  block.DefRange.Ptr(),
+		})
+		return nil, diags
+	}
+
+	for _, builder := range b.Builders {
+		if !p.BuilderSchemas.Has(builder) {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Unknown builder type " + builder,
+				Detail:   fmt.Sprintf("known builders: %v", p.BuilderSchemas.List()),
+				Subject:  block.DefRange.Ptr(),
+			})
+		}
+	}
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	return limit, diags
+}
+
+// appliesTo tells whether builds of builderType are subject to the limit.
+func (l *LimitBlock) appliesTo(builderType string) bool {
+	if len(l.Builders) == 0 {
+		return builderType == l.Name || strings.HasPrefix(builderType, l.Name+"-")
+	}
+	for _, builder := range l.Builders {
+		if builder == builderType {
+			return true
+		}
+	}
+	return false
+}
+
+// buildLimits returns the limits that apply to builds of builderType.
+func (cfg *PackerConfig) buildLimits(builderType string) []packer.BuildLimit {
+	var limits []packer.BuildLimit
+	for _, limit := range cfg.Limits {
+		if limit.appliesTo(builderType) {
+			limits = append(limits, packer.BuildLimit{
+				Name: limit.Name,
+				Max:  int64(limit.Max),
+			})
+		}
+	}
+	return limits
+}
@@ -0,0 +1,91 @@
+package hcl2template
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/packer/packer"
+)
+
+func TestParse_limit(t *testing.T) {
+	defaultParser := getBasicParser()
+
+	tests := []parseTest{
+		{"limits of builder types",
+			defaultParser,
+			parseTestArgs{"testdata/limit/basic.pkr.hcl", nil, nil},
+			&PackerConfig{
+				Basedir: filepath.Join("testdata", "limit"),
+				Sources: map[SourceRef]SourceBlock{
+					refVBIsoUbuntu1204:  {Type: "virtualbox-iso", Name: "ubuntu-1204"},
+					refAWSEBSUbuntu1604: {Type: "amazon-ebs", Name: "ubuntu-1604"},
+				},
+				Limits: Limits{
+					{Name: "virtualbox", Max: 2},
+					{Name: "aws", Max: 1, Builders: []string{"amazon-ebs"}},
+				},
+				Builds: Builds{
+					&BuildBlock{
+						Sources: []SourceRef{refVBIsoUbuntu1204, refAWSEBSUbuntu1604},
+					},
+				},
+			},
+			false, false,
+			[]packer.Build{
+				&packer.CoreBuild{
+					Type:              "virtualbox-iso.ubuntu-1204",
+					BuilderType:       "virtualbox-iso",
+					Prepared:          true,
+					Builder:           emptyMockBuilder,
+					ConcurrencyLimits: []packer.BuildLimit{{Name: "virtualbox", Max: 2}},
+					Provisioners:      []packer.CoreBuildProvisioner{},
+					PostProcessors:    [][]packer.CoreBuildPostProcessor{},
+				},
+				&packer.CoreBuild{
+					Type:              "amazon-ebs.ubuntu-1604",
+					BuilderType:       "amazon-ebs",
+					Prepared:          true,
+					Builder:           emptyMockBuilder,
+					ConcurrencyLimits: []packer.BuildLimit{{Name: "aws", Max: 1}},
+					Provisioners:      []packer.CoreBuildProvisioner{},
+					PostProcessors:    [][]packer.CoreBuildPostProcessor{},
+				},
+			},
+			false,
+		},
+		{"invalid max",
+			defaultParser,
+			parseTestArgs{"testdata/limit/invalid_max.pkr.hcl", nil, nil},
+			&PackerConfig{
+				Basedir: filepath.Join("testdata", "limit"),
+			},
+			true, true,
+			[]packer.Build{},
+			false,
+		},
+		{"duplicate limit",
+			defaultParser,
+			parseTestArgs{"testdata/limit/duplicate.pkr.hcl", nil, nil},
+			&PackerConfig{
+				Basedir: filepath.Join("testdata", "limit"),
+				Limits: Limits{
+					{Name: "virtualbox", Max: 2},
+				},
+			},
+			true, true,
+			[]packer.Build{},
+			false,
+		},
+		{"unknown builder type",
+			defaultParser,
+			parseTestArgs{"testdata/limit/unknown_builder.pkr.hcl", nil, nil},
+			&PackerConfig{
+				Basedir: filepath.Join("testdata", "limit"),
+			},
+			true, true,
+			[]packer.Build{},
+			false,
+		},
+	}
+	testParse(t, tests)
+}
@@ -0,0 +1,492 @@
+package hcl2template
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	getter "github.com/hashicorp/go-getter/v2"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/gohcl"
+	"github.com/hashicorp/packer/packer"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+)
+
+const (
+	moduleAccessor = "module"
+
+	moduleSourceAttribute = "source"
+)
+
+var moduleSchema = &hcl.BodySchema{
+	Blocks: []hcl.BlockHeaderSchema{
+		{Type: moduleLabel, LabelNames: []string{"name"}},
+	},
+}
+
+// ModuleBlock references an HCL 'module' block, that loads the sources and
+// the builds of a shared directory, for example :
+//
+//	module "linux" {
+//		source   = "./modules/linux"
+//		username = "packer"
+//	}
+//
+// The other arguments of the block set the input variables of the module.
+// Builds can then use the sources of the module, like
+// "module.linux.source.amazon-ebs.base", and include the provisioners and
+// post-processors of its named builds:
+//
+//	build {
+//		sources = ["module.linux.source.amazon-ebs.base"]
+//		include "module.linux.hardening" {}
+//	}
+type ModuleBlock struct {
+	// Name is the label of the block.
+	Name string
+
+	// Source is the directory of the module, relative to the template, or a
+	// go-getter URL to download it from, like
+	// "git::https://example.com/modules.git//linux?ref=v1.0.0".
+	Source string
+
+	// config is the loaded configuration of the module.
+	config *PackerConfig
+
+	block *hcl.Block
+}
+
+// decodeModules loads the modules declared in f, so that the blocks of any
+// file can use them.
+func (cfg *PackerConfig) decodeModules(f *hcl.File) hcl.Diagnostics {
+	content, _, diags := f.Body.PartialContent(moduleSchema)
+
+	for _, block := range content.Blocks {
+		if cfg.module != nil {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Nested " + moduleLabel + " block",
+				Detail:   "A module can't load other modules.",
+				Subject:  block.DefRange.Ptr(),
+			})
+			continue
+		}
+
+		name := block.Labels[0]
+		if existing, found := cfg.Modules[name]; found {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Duplicate " + moduleLabel + " block",
+				Detail: fmt.Sprintf("This "+moduleLabel+" block has the "+
+					"same name as a previous block declared at %s.",
+					existing.block.DefRange.Ptr()),
+				Subject: block.DefRange.Ptr(),
+			})
+			continue
+		}
+
+		module, moreDiags := cfg.decodeModule(block)
+		diags = append(diags, moreDiags...)
+		if moreDiags.HasErrors() {
+			continue
+		}
+		if cfg.Modules == nil {
+			cfg.Modules = map[string]*ModuleBlock{}
+		}
+		cfg.Modules[name] = module
+	}
+	return diags
+}
+
+func (cfg *PackerConfig) decodeModule(block *hcl.Block) (*ModuleBlock, hcl.Diagnostics) {
+	module := &ModuleBlock{
+		Name:  block.Labels[0],
+		block: block,
+	}
+
+	attrs, diags := block.Body.JustAttributes()
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	sourceAttr, found := attrs[moduleSourceAttribute]
+	if !found {
+		diags = append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Missing required argument",
+			Detail:   "The argument \"" + moduleSourceAttribute + "\" is required.",
+			Subject:  block.DefRange.Ptr(),
+		})
+		return nil, diags
+	}
+	// The source of a module can't depend on anything
+	diags = append(diags, gohcl.DecodeExpression(sourceAttr.Expr, nil, &module.Source)...)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	dir, err := fetchModule(module.Source, cfg.Basedir)
+	if err != nil {
+		diags = append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Failed to load module " + module.Name,
+			Detail:   err.Error(),
+			Subject:  sourceAttr.Expr.Range().Ptr(),
+		})
+		return nil, diags
+	}
+
+	mcfg, moreDiags := cfg.parser.parseFiles(dir)
+	diags = append(diags, moreDiags...)
+	if mcfg == nil || moreDiags.HasErrors() {
+		return nil, diags
+	}
+	mcfg.module = module
+	moreDiags = mcfg.CheckCoreVersionRequirements(cfg.parser.CorePackerVersion)
+	diags = append(diags, moreDiags...)
+	if moreDiags.HasErrors() {
+		return nil, diags
+	}
+	diags = append(diags, mcfg.decodeVariableBlocks()...)
+
+	// The other arguments set the input variables of the module
+	ectx := cfg.EvalContext(nil)
+	for name, attr := range attrs {
+		if name == moduleSourceAttribute {
+			continue
+		}
+		variable, found := mcfg.InputVariables[name]
+		if !found {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Unsupported argument",
+				Detail:   fmt.Sprintf("Module %s has no %q input variable.", module.Name, name),
+				Subject:  attr.NameRange.Ptr(),
+			})
+			continue
+		}
+
+		val, moreDiags := attr.Expr.Value(ectx)
+		diags = append(diags, moreDiags...)
+		if variable.Type != cty.NilType {
+			var err error
+			val, err = convert.Convert(val, variable.Type)
+			if err != nil {
+				diags = append(diags, &hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Invalid value for variable",
+					Detail:   fmt.Sprintf("The value for %s is not compatible with the variable's type constraint: %s.", name, err),
+					Subject:  attr.Expr.Range().Ptr(),
+				})
+				val = cty.DynamicVal
+			}
+		}
+		variable.Values = append(variable.Values, VariableAssignment{
+			From:  moduleAccessor,
+			Value: val,
+			Expr:  attr.Expr,
+		})
+	}
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	diags = append(diags, mcfg.Initialize()...)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	for _, limit := range mcfg.Limits {
+		diags = append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Unsupported " + limitLabel + " block",
+			Detail:   "The limits of the builds can only be set by the templates that use a module.",
+			Subject:  limit.block.DefRange.Ptr(),
+		})
+	}
+	for _, build := range mcfg.Builds {
+		if len(build.Sources) > 0 {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Unsupported sources in module build",
+				Detail: "The builds of a module are included by the builds of the " +
+					"templates that use it, they can't start sources.",
+				Subject: build.HCL2Ref.DefRange.Ptr(),
+			})
+		}
+	}
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	mcfg.bindToModule()
+	module.config = mcfg
+	return module, diags
+}
+
+// fetchModule returns the directory of the module at source, downloading it
+// to the packer cache if it is not a local directory.
+func fetchModule(source, basedir string) (string, error) {
+	if filepath.IsAbs(source) {
+		return source, nil
+	}
+	if strings.HasPrefix(source, "./") || strings.HasPrefix(source, "../") {
+		return filepath.Join(basedir, source), nil
+	}
+
+	// Remote modules are downloaded once, a module that changes should be
+	// referenced with a new ref or version
+	dst, err := packer.CachePath("modules", fmt.Sprintf("%x", sha256.Sum256([]byte(source))))
+	if err != nil {
+		return "", err
+	}
+	if _, err := os.Stat(dst); err == nil {
+		return dst, nil
+	}
+
+	client := getter.Client{
+		Getters: getter.Getters,
+	}
+	req := &getter.Request{
+		Src:  source,
+		Dst:  dst,
+		Pwd:  basedir,
+		Mode: getter.ModeDir,
+	}
+	if _, err := client.Get(context.TODO(), req); err != nil {
+		os.RemoveAll(dst)
+		return "", fmt.Errorf("downloading %s: %s", source, err)
+	}
+	return dst, nil
+}
+
+// bindToModule makes the expressions of the sources and builds of a module
+// refer to the variables, locals and files of the module, even when they are
+// decoded with the context of the template that uses it.
+func (cfg *PackerConfig) bindToModule() {
+	variables := cfg.EvalContext(nil).Variables
+	// The contextual variables are set by the template
+	delete(variables, sourcesAccessor)
+	delete(variables, buildAccessor)
+	functions := Functions(cfg.Basedir)
+
+	for ref, src := range cfg.Sources {
+		ectx := &hcl.EvalContext{Variables: variables, Functions: functions}
+		block := *src.block
+		block.Body = bindBody(block.Body, ectx)
+		src.block = &block
+		if src.forEachBody != nil {
+			src.forEachBody = bindBody(src.forEachBody, ectx)
+		}
+		src.module = cfg.module
+		cfg.Sources[ref] = src
+	}
+
+	for _, build := range cfg.Builds {
+		buildVariables := build.variables()
+		for k, v := range variables {
+			buildVariables[k] = v
+		}
+		ectx := &hcl.EvalContext{Variables: buildVariables, Functions: functions}
+		for _, pb := range build.ProvisionerBlocks {
+			pb.HCL2Ref.Rest = bindBody(pb.HCL2Ref.Rest, ectx)
+		}
+		for _, ppbs := range build.PostProcessorsLists {
+			for _, ppb := range ppbs {
+				ppb.HCL2Ref.Rest = bindBody(ppb.HCL2Ref.Rest, ectx)
+			}
+		}
+	}
+}
+
+// moduleBuild returns the build of a module that ref, like
+// "module.linux.hardening", refers to.
+func (cfg *PackerConfig) moduleBuild(ref string) (*BuildBlock, error) {
+	parts := strings.Split(ref, ".")
+	if len(parts) != 3 || parts[0] != moduleAccessor {
+		return nil, fmt.Errorf("%q is not a reference to the build of a module, "+
+			"like `module.<module name>.<build name>`", ref)
+	}
+	module, found := cfg.Modules[parts[1]]
+	if !found {
+		return nil, fmt.Errorf("there is no %q module", parts[1])
+	}
+	for _, build := range module.config.Builds {
+		if build.Name == parts[2] {
+			return build, nil
+		}
+	}
+	return nil, fmt.Errorf("module %s has no %q build", module.Name, parts[2])
+}
+
+// boundBody is an hcl.Body whose expressions are evaluated with the variables
+// and the functions of ctx. The variables of the context they are decoded
+// with, like each or source, are still available if ctx doesn't set them.
+type boundBody struct {
+	body hcl.Body
+	ctx  *hcl.EvalContext
+}
+
+func bindBody(body hcl.Body, ctx *hcl.EvalContext) hcl.Body {
+	return &boundBody{body: body, ctx: ctx}
+}
+
+func (b *boundBody) Content(schema *hcl.BodySchema) (*hcl.BodyContent, hcl.Diagnostics) {
+	content, diags := b.body.Content(schema)
+	return b.bindContent(content), diags
+}
+
+func (b *boundBody) PartialContent(schema *hcl.BodySchema) (*hcl.BodyContent, hcl.Body, hcl.Diagnostics) {
+	content, rest, diags := b.body.PartialContent(schema)
+	return b.bindContent(content), bindBody(rest, b.ctx), diags
+}
+
+func (b *boundBody) JustAttributes() (hcl.Attributes, hcl.Diagnostics) {
+	attrs, diags := b.body.JustAttributes()
+	return b.bindAttributes(attrs), diags
+}
+
+func (b *boundBody) MissingItemRange() hcl.Range {
+	return b.body.MissingItemRange()
+}
+
+func (b *boundBody) bindContent(content *hcl.BodyContent) *hcl.BodyContent {
+	if content == nil {
+		return nil
+	}
+	bound := *content
+	bound.Attributes = b.bindAttributes(content.Attributes)
+	bound.Blocks = make(hcl.Blocks, len(content.Blocks))
+	for i, block := range content.Blocks {
+		boundBlock := *block
+		boundBlock.Body = bindBody(block.Body, b.ctx)
+		bound.Blocks[i] = &boundBlock
+	}
+	return &bound
+}
+
+func (b *boundBody) bindAttributes(attrs hcl.Attributes) hcl.Attributes {
+	if attrs == nil {
+		return nil
+	}
+	bound := make(hcl.Attributes, len(attrs))
+	for name, attr := range attrs {
+		boundAttr := *attr
+		boundAttr.Expr = &boundExpr{Expression: attr.Expr, ctx: b.ctx}
+		bound[name] = &boundAttr
+	}
+	return bound
+}
+
+type boundExpr struct {
+	hcl.Expression
+	ctx *hcl.EvalContext
+}
+
+func (e *boundExpr) Value(ctx *hcl.EvalContext) (cty.Value, hcl.Diagnostics) {
+	if ctx == nil {
+		return e.Expression.Value(e.ctx)
+	}
+	child := ctx.NewChild()
+	child.Variables = e.ctx.Variables
+	child.Functions = e.ctx.Functions
+	return e.Expression.Value(child)
+}
+
+func (e *boundExpr) UnwrapExpression() hcl.Expression {
+	return e.Expression
+}
+
+// overriddenBody is an hcl.Body whose attributes are the ones of override,
+// and the ones of body that override doesn't set. The blocks of both bodies
+// are kept.
+type overriddenBody struct {
+	body     hcl.Body
+	override hcl.Body
+}
+
+func overrideBody(body, override hcl.Body) hcl.Body {
+	return &overriddenBody{body: body, override: override}
+}
+
+// split returns the schema to decode the override with, and the one to
+// decode the body with, where the attributes set by the override are
+// optional.
+func (b *overriddenBody) split(schema *hcl.BodySchema) (*hcl.BodySchema, *hcl.BodySchema, hcl.Diagnostics) {
+	overrideSchema := &hcl.BodySchema{Blocks: schema.Blocks}
+	for _, attr := range schema.Attributes {
+		attr.Required = false
+		overrideSchema.Attributes = append(overrideSchema.Attributes, attr)
+	}
+	content, _, diags := b.override.PartialContent(overrideSchema)
+
+	bodySchema := &hcl.BodySchema{Blocks: schema.Blocks}
+	for _, attr := range schema.Attributes {
+		if _, found := content.Attributes[attr.Name]; found {
+			attr.Required = false
+		}
+		bodySchema.Attributes = append(bodySchema.Attributes, attr)
+	}
+	return overrideSchema, bodySchema, diags
+}
+
+func (b *overriddenBody) Content(schema *hcl.BodySchema) (*hcl.BodyContent, hcl.Diagnostics) {
+	overrideSchema, bodySchema, diags := b.split(schema)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	content, moreDiags := b.body.Content(bodySchema)
+	diags = append(diags, moreDiags...)
+	override, moreDiags := b.override.Content(overrideSchema)
+	diags = append(diags, moreDiags...)
+	return mergeContent(content, override), diags
+}
+
+func (b *overriddenBody) PartialContent(schema *hcl.BodySchema) (*hcl.BodyContent, hcl.Body, hcl.Diagnostics) {
+	overrideSchema, bodySchema, diags := b.split(schema)
+	if diags.HasErrors() {
+		return nil, nil, diags
+	}
+	content, rest, moreDiags := b.body.PartialContent(bodySchema)
+	diags = append(diags, moreDiags...)
+	override, overrideRest, moreDiags := b.override.PartialContent(overrideSchema)
+	diags = append(diags, moreDiags...)
+	return mergeContent(content, override), overrideBody(rest, overrideRest), diags
+}
+
+func (b *overriddenBody) JustAttributes() (hcl.Attributes, hcl.Diagnostics) {
+	attrs, diags := b.body.JustAttributes()
+	override, moreDiags := b.override.JustAttributes()
+	diags = append(diags, moreDiags...)
+	merged := hcl.Attributes{}
+	for name, attr := range attrs {
+		merged[name] = attr
+	}
+	for name, attr := range override {
+		merged[name] = attr
+	}
+	return merged, diags
+}
+
+func (b *overriddenBody) MissingItemRange() hcl.Range {
+	return b.body.MissingItemRange()
+}
+
+func mergeContent(content, override *hcl.BodyContent) *hcl.BodyContent {
+	merged := &hcl.BodyContent{
+		Attributes:       hcl.Attributes{},
+		MissingItemRange: content.MissingItemRange,
+	}
+	for name, attr := range content.Attributes {
+		merged.Attributes[name] = attr
+	}
+	for name, attr := range override.Attributes {
+		merged.Attributes[name] = attr
+	}
+	merged.Blocks = append(merged.Blocks, content.Blocks...)
+	merged.Blocks = append(merged.Blocks, override.Blocks...)
+	return merged
+}
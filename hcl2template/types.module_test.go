@@ -0,0 +1,125 @@
+package hcl2template
+
+import (
+	"path/filepath"
+	"testing"
+
+	. "github.com/hashicorp/packer/hcl2template/internal"
+	"github.com/hashicorp/packer/packer"
+)
+
+func TestParse_module(t *testing.T) {
+	defaultParser := getBasicParser()
+
+	refLinuxVBIsoUbuntu1204 := SourceRef{Module: "linux", Type: "virtualbox-iso", Name: "ubuntu-1204"}
+	shellProvisioner := func(str string) packer.CoreBuildProvisioner {
+		return packer.CoreBuildProvisioner{
+			PType: "shell",
+			Provisioner: &HCL2Provisioner{
+				Provisioner: &MockProvisioner{
+					Config: MockConfig{
+						NestedMockConfig: NestedMockConfig{String: str, Tags: []MockTag{}},
+						NestedSlice:      []NestedMockConfig{},
+					},
+				},
+			},
+		}
+	}
+
+	tests := []parseTest{
+		{"module sources and builds",
+			defaultParser,
+			parseTestArgs{"testdata/module/basic.pkr.hcl", nil, nil},
+			&PackerConfig{
+				Basedir: filepath.Join("testdata", "module"),
+				Modules: map[string]*ModuleBlock{
+					"linux": {Name: "linux", Source: "./linux"},
+				},
+				Builds: Builds{
+					&BuildBlock{
+						Name:    "web",
+						Sources: []SourceRef{refLinuxVBIsoUbuntu1204},
+						ProvisionerBlocks: []*ProvisionerBlock{
+							{PType: "shell"},
+							{PType: "shell"},
+							{PType: "shell"},
+						},
+						PostProcessorsLists: [][]*PostProcessorBlock{
+							{{PType: "manifest"}},
+						},
+					},
+				},
+			},
+			false, false,
+			[]packer.Build{
+				&packer.CoreBuild{
+					BuildName:   "web",
+					Type:        "virtualbox-iso.ubuntu-1204",
+					BuilderType: "virtualbox-iso",
+					Prepared:    true,
+					Builder: &MockBuilder{
+						Config: MockConfig{
+							NestedMockConfig: NestedMockConfig{String: "linux-packer", Int: 42, Tags: []MockTag{}},
+							NestedSlice:      []NestedMockConfig{},
+						},
+					},
+					Provisioners: []packer.CoreBuildProvisioner{
+						shellProvisioner("before"),
+						shellProvisioner("linux-ubuntu-1204"),
+						shellProvisioner("after"),
+					},
+					PostProcessors: [][]packer.CoreBuildPostProcessor{
+						{
+							{
+								PType: "manifest",
+								PostProcessor: &HCL2PostProcessor{
+									PostProcessor: &MockPostProcessor{
+										Config: MockConfig{
+											NestedMockConfig: NestedMockConfig{String: "packer", Tags: []MockTag{}},
+											NestedSlice:      []NestedMockConfig{},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			false,
+		},
+		{"unknown module input variable",
+			defaultParser,
+			parseTestArgs{"testdata/module/unknown_input.pkr.hcl", nil, nil},
+			&PackerConfig{
+				Basedir: filepath.Join("testdata", "module"),
+			},
+			true, true,
+			[]packer.Build{},
+			false,
+		},
+		{"missing module source",
+			defaultParser,
+			parseTestArgs{"testdata/module/missing_source.pkr.hcl", nil, nil},
+			&PackerConfig{
+				Basedir: filepath.Join("testdata", "module"),
+			},
+			true, true,
+			[]packer.Build{},
+			false,
+		},
+		{"unknown included build",
+			defaultParser,
+			parseTestArgs{"testdata/module/unknown_include.pkr.hcl", nil, nil},
+			&PackerConfig{
+				Basedir: filepath.Join("testdata", "module"),
+				Modules: map[string]*ModuleBlock{
+					"linux": {Name: "linux", Source: "./linux"},
+				},
+			},
+			true, true,
+			[]packer.Build{},
+			false,
+		},
+	}
+	testParse(t, tests)
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/gobwas/glob"
 	"github.com/hashicorp/hcl/v2"
 	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/packer/hcl2template/addrs"
 	pkrfunction "github.com/hashicorp/packer/hcl2template/function"
 	"github.com/hashicorp/packer/packer"
 	"github.com/zclconf/go-cty/cty"
@@ -19,6 +20,9 @@ import (
 type PackerConfig struct {
 	Packer struct {
 		VersionConstraints []VersionConstraint
+		// RequiredPlugins are the plugins of the required_plugins blocks,
+		// by name.
+		RequiredPlugins map[string]*RequiredPlugin
 	}
 	// Directory where the config files are defined
 	Basedir string
@@ -46,17 +50,32 @@ type PackerConfig struct {
 	// Builds is the list of Build blocks defined in the config files.
 	Builds Builds
 
+	// Limits is the list of Limit blocks defined in the config files.
+	Limits Limits
+
+	// Modules are the modules loaded by the config files, by name.
+	Modules map[string]*ModuleBlock
+
+	// Datasources are the data sources of the config files, with their
+	// output.
+	Datasources Datasources
+
 	builderSchemas packer.BuilderStore
 
 	provisionersSchemas packer.ProvisionerStore
 
 	postProcessorsSchemas packer.PostProcessorStore
 
+	datasourceSchemas packer.DatasourceStore
+
 	except []glob.Glob
 	only   []glob.Glob
 
 	parser *Parser
 	files  []*hcl.File
+
+	// module is set when the config is loaded by a module block.
+	module *ModuleBlock
 }
 
 type ValidationOptions struct {
@@ -87,7 +106,8 @@ func (cfg *PackerConfig) EvalContext(variables map[string]cty.Value) *hcl.EvalCo
 				"type": cty.UnknownVal(cty.String),
 				"name": cty.UnknownVal(cty.String),
 			}),
-			buildAccessor: cty.UnknownVal(cty.EmptyObject),
+			buildAccessor:      cty.UnknownVal(cty.EmptyObject),
+			datasourceAccessor: cty.ObjectVal(cfg.Datasources.Values()),
 			packerAccessor: cty.ObjectVal(map[string]cty.Value{
 				"version": cty.StringVal(cfg.CorePackerVersionString),
 			}),
@@ -109,7 +129,8 @@ func (cfg *PackerConfig) EvalContext(variables map[string]cty.Value) *hcl.EvalCo
 func (c *PackerConfig) decodeInputVariables(f *hcl.File) hcl.Diagnostics {
 	var diags hcl.Diagnostics
 
-	content, moreDiags := f.Body.Content(configSchema)
+	// dynamic blocks and unknown blocks are left to decodeConfig
+	content, _, moreDiags := f.Body.PartialContent(configSchema)
 	diags = append(diags, moreDiags...)
 
 	// for input variables we allow to use env in the default value section.
@@ -142,7 +163,8 @@ func (c *PackerConfig) decodeInputVariables(f *hcl.File) hcl.Diagnostics {
 func (c *PackerConfig) parseLocalVariables(f *hcl.File) ([]*LocalBlock, hcl.Diagnostics) {
 	var diags hcl.Diagnostics
 
-	content, moreDiags := f.Body.Content(configSchema)
+	// dynamic blocks and unknown blocks are left to decodeConfig
+	content, _, moreDiags := f.Body.PartialContent(configSchema)
 	diags = append(diags, moreDiags...)
 	var locals []*LocalBlock
 
@@ -151,8 +173,11 @@ func (c *PackerConfig) parseLocalVariables(f *hcl.File) ([]*LocalBlock, hcl.Diag
 		case localsLabel:
 			attrs, moreDiags := block.Body.JustAttributes()
 			diags = append(diags, moreDiags...)
-			for name, attr := range attrs {
-				if _, found := c.LocalVariables[name]; found {
+			// In declaration order, so that the locals are evaluated and
+			// their cycles reported in the same order on every run.
+			for _, attr := range sortedAttributes(attrs) {
+				name := attr.Name
+				if c.localBlock(name, locals) != nil {
 					diags = append(diags, &hcl.Diagnostic{
 						Severity: hcl.DiagError,
 						Summary:  "Duplicate value in " + localsLabel,
@@ -173,44 +198,136 @@ func (c *PackerConfig) parseLocalVariables(f *hcl.File) ([]*LocalBlock, hcl.Diag
 	return locals, diags
 }
 
-func (c *PackerConfig) evaluateLocalVariables(locals []*LocalBlock) hcl.Diagnostics {
-	var diags hcl.Diagnostics
+// localBlock returns the local named name, declared in the files parsed
+// before or in locals.
+func (c *PackerConfig) localBlock(name string, locals []*LocalBlock) *LocalBlock {
+	for _, local := range append(c.LocalBlocks[:len(c.LocalBlocks):len(c.LocalBlocks)], locals...) {
+		if local.Name == name {
+			return local
+		}
+	}
+	return nil
+}
 
+// evaluateLocalVariables evaluates each local once, after the locals it
+// refers to, whatever the order they are declared in. The locals that refer
+// to a local that could not be evaluated are skipped, as their errors would
+// only repeat its own.
+func (c *PackerConfig) evaluateLocalVariables(locals []*LocalBlock) hcl.Diagnostics {
 	if len(locals) > 0 && c.LocalVariables == nil {
 		c.LocalVariables = Variables{}
 	}
 
-	var retry, previousL int
-	for len(locals) > 0 {
-		local := locals[0]
+	sorted, diags := sortLocalVariables(locals)
+	failed := map[string]bool{}
+	for _, local := range sorted {
+		skip := false
+		for _, ref := range localReferences(local.Expr) {
+			skip = skip || failed[ref]
+		}
+		if skip {
+			failed[local.Name] = true
+			continue
+		}
+
 		moreDiags := c.evaluateLocalVariable(local)
+		diags = append(diags, moreDiags...)
 		if moreDiags.HasErrors() {
-			if len(locals) == 1 {
-				// If this is the only local left there's no need
-				// to try evaluating again
-				return append(diags, moreDiags...)
-			}
-			if previousL == len(locals) {
-				if retry == 100 {
-					// To get to this point, locals must have a circle dependency
-					return append(diags, moreDiags...)
+			failed[local.Name] = true
+		}
+	}
+
+	return diags
+}
+
+// sortLocalVariables returns the locals ordered so that each one comes after
+// the locals it refers to. The locals that are part of a cycle of references,
+// or refer to one, are left out and each cycle is an error.
+func sortLocalVariables(locals []*LocalBlock) ([]*LocalBlock, hcl.Diagnostics) {
+	var diags hcl.Diagnostics
+
+	byName := map[string]*LocalBlock{}
+	for _, local := range locals {
+		byName[local.Name] = local
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := map[string]int{}
+	valid := map[string]bool{}
+	sorted := []*LocalBlock{}
+	var path []string
+
+	var visit func(local *LocalBlock) bool
+	visit = func(local *LocalBlock) bool {
+		switch state[local.Name] {
+		case visiting:
+			var cycle []string
+			for i := len(path) - 1; i >= 0; i-- {
+				cycle = append([]string{path[i]}, cycle...)
+				if path[i] == local.Name {
+					break
 				}
-				retry++
 			}
-			previousL = len(locals)
+			detail := "local." + cycle[0] + " refers to "
+			for _, name := range cycle[1:] {
+				detail += "local." + name + ", which refers to "
+			}
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Cycle in " + localsLabel,
+				Detail: detail + "local." + cycle[0] + ". A local can only refer to " +
+					"locals that don't refer back to it.",
+				Subject: local.Expr.Range().Ptr(),
+			})
+			return false
+		case visited:
+			return valid[local.Name]
+		}
 
-			// If local uses another local that has not been evaluated yet this could be the reason of errors
-			// Push local to the end of slice to be evaluated later
-			locals = append(locals, local)
-		} else {
-			retry = 0
-			diags = append(diags, moreDiags...)
+		state[local.Name] = visiting
+		path = append(path, local.Name)
+		ok := true
+		for _, ref := range localReferences(local.Expr) {
+			// An unknown local is an error of the evaluation
+			if dep, found := byName[ref]; found && !visit(dep) {
+				ok = false
+			}
+		}
+		path = path[:len(path)-1]
+		state[local.Name] = visited
+		valid[local.Name] = ok
+		if ok {
+			sorted = append(sorted, local)
 		}
-		// Remove local from slice
-		locals = append(locals[:0], locals[1:]...)
+		return ok
+	}
+	for _, local := range locals {
+		visit(local)
 	}
 
-	return diags
+	return sorted, diags
+}
+
+// localReferences returns the names of the locals expr refers to.
+func localReferences(expr hcl.Expression) []string {
+	var names []string
+	for _, traversal := range expr.Variables() {
+		if traversal.RootName() != localsAccessor {
+			continue
+		}
+		ref, diags := addrs.ParseRef(traversal)
+		if diags.HasErrors() {
+			continue
+		}
+		if local, ok := ref.Subject.(addrs.LocalValue); ok {
+			names = append(names, local.Name)
+		}
+	}
+	return names
 }
 
 func (c *PackerConfig) evaluateLocalVariable(local *LocalBlock) hcl.Diagnostics {
@@ -239,15 +356,23 @@ func (c *PackerConfig) evaluateLocalVariable(local *LocalBlock) hcl.Diagnostics
 func (cfg *PackerConfig) getCoreBuildProvisioners(source SourceBlock, blocks []*ProvisionerBlock, ectx *hcl.EvalContext) ([]packer.CoreBuildProvisioner, hcl.Diagnostics) {
 	var diags hcl.Diagnostics
 	res := []packer.CoreBuildProvisioner{}
+	// The parallel block of the last provisioner added
+	group := 0
 	for _, pb := range blocks {
 		if pb.OnlyExcept.Skip(source.String()) {
 			continue
 		}
-		provisioner, moreDiags := cfg.startProvisioner(source, pb, ectx)
+		run, moreDiags := evalWhen(pb.When, ectx)
+		diags = append(diags, moreDiags...)
+		if !run {
+			continue
+		}
+		hclProvisioner, moreDiags := cfg.startProvisioner(source, pb, ectx)
 		diags = append(diags, moreDiags...)
 		if moreDiags.HasErrors() {
 			continue
 		}
+		var provisioner packer.Provisioner = hclProvisioner
 
 		// If we're pausing, we wrap the provisioner in a special pauser.
 		if pb.PauseBefore != 0 {
@@ -268,11 +393,42 @@ func (cfg *PackerConfig) getCoreBuildProvisioners(source SourceBlock, blocks []*
 			}
 		}
 
-		res = append(res, packer.CoreBuildProvisioner{
+		coreProvisioner := packer.CoreBuildProvisioner{
 			PType:       pb.PType,
 			PName:       pb.PName,
 			Provisioner: provisioner,
-		})
+			PlanConfig:  hclProvisioner.planConfig(),
+		}
+
+		// Provisioners of the same parallel block run together
+		if pb.Group != 0 && pb.Group == group && len(res) > 0 {
+			last := &res[len(res)-1]
+			parallel, ok := last.Provisioner.(*packer.ParallelProvisioner)
+			if !ok {
+				parallel = &packer.ParallelProvisioner{
+					Provisioners: []packer.Provisioner{last.Provisioner},
+				}
+				*last = packer.CoreBuildProvisioner{
+					PType:       buildParallelLabel,
+					Provisioner: parallel,
+					PlanConfig: map[string]interface{}{
+						"provisioners": []packer.PlanEntry{
+							{Type: last.PType, Name: last.PName, Config: last.PlanConfig},
+						},
+					},
+				}
+			}
+			parallel.Provisioners = append(parallel.Provisioners, provisioner)
+			last.PlanConfig["provisioners"] = append(last.PlanConfig["provisioners"].([]packer.PlanEntry), packer.PlanEntry{
+				Type:   coreProvisioner.PType,
+				Name:   coreProvisioner.PName,
+				Config: coreProvisioner.PlanConfig,
+			})
+			continue
+		}
+		group = pb.Group
+
+		res = append(res, coreProvisioner)
 	}
 	return res, diags
 }
@@ -288,6 +444,11 @@ func (cfg *PackerConfig) getCoreBuildPostProcessors(source SourceBlock, blocksLi
 			if ppb.OnlyExcept.Skip(source.String()) {
 				continue
 			}
+			run, moreDiags := evalWhen(ppb.When, ectx)
+			diags = append(diags, moreDiags...)
+			if !run {
+				continue
+			}
 
 			name := ppb.PName
 			if name == "" {
@@ -315,6 +476,7 @@ func (cfg *PackerConfig) getCoreBuildPostProcessors(source SourceBlock, blocksLi
 				PName:             ppb.PName,
 				PType:             ppb.PType,
 				KeepInputArtifact: ppb.KeepInputArtifact,
+				PlanConfig:        postProcessor.planConfig(),
 			})
 		}
 		if len(pps) > 0 {
@@ -333,8 +495,12 @@ func (cfg *PackerConfig) GetBuilds(opts packer.GetBuildsOptions) ([]packer.Build
 	var diags hcl.Diagnostics
 
 	for _, build := range cfg.Builds {
+		var sources []SourceRef
 		for _, from := range build.Sources {
-			src, found := cfg.Sources[from.Ref()]
+			sources = append(sources, cfg.sourcesOf(from)...)
+		}
+		for _, from := range sources {
+			src, found := cfg.sourceBlock(from)
 			if !found {
 				diags = append(diags, &hcl.Diagnostic{
 					Summary:  "Unknown " + sourceLabel + " " + from.String(),
@@ -348,9 +514,14 @@ func (cfg *PackerConfig) GetBuilds(opts packer.GetBuildsOptions) ([]packer.Build
 			src.LocalName = from.LocalName
 
 			pcb := &packer.CoreBuild{
-				BuildName: build.Name,
-				Type:      src.String(),
+				BuildName:         build.Name,
+				Type:              src.String(),
+				BuilderType:       src.Type,
+				Retries:           build.Retries,
+				ConcurrencyLimits: cfg.buildLimits(src.Type),
+				PackerVersion:     cfg.CorePackerVersionString,
 			}
+			pcb.SetOnError(opts.OnError)
 
 			// Apply the -only and -except command-line options to exclude matching builds.
 			buildName := pcb.Name()
@@ -392,9 +563,18 @@ func (cfg *PackerConfig) GetBuilds(opts packer.GetBuildsOptions) ([]packer.Build
 				}
 			}
 
-			builder, moreDiags, generatedVars := cfg.startBuilder(src, cfg.EvalContext(nil), opts)
+			// The source can refer to the element of the for_each of the
+			// build, or of its own for_each
+			builderVariables := build.variables()
+			if src.each != nil {
+				builderVariables[eachAccessor] = src.each.ctyValue()
+			}
+			builder, moreDiags, generatedVars, builderConfig := cfg.startBuilder(src, cfg.EvalContext(builderVariables), opts)
 			diags = append(diags, moreDiags...)
-			if moreDiags.HasErrors() {
+			// When validating, the provisioners and post-processors are
+			// prepared even if the builder fails, to report all the errors
+			failed := moreDiags.HasErrors()
+			if failed && !opts.Validate {
 				continue
 			}
 
@@ -409,27 +589,42 @@ func (cfg *PackerConfig) GetBuilds(opts packer.GetBuildsOptions) ([]packer.Build
 			}
 			unknownBuildValues["name"] = cty.StringVal(build.Name)
 
-			variables := map[string]cty.Value{
-				sourcesAccessor: cty.ObjectVal(src.ctyValues()),
-				buildAccessor:   cty.ObjectVal(unknownBuildValues),
-			}
+			variables := build.variables()
+			variables[sourcesAccessor] = cty.ObjectVal(src.ctyValues())
+			variables[buildAccessor] = cty.ObjectVal(unknownBuildValues)
 
 			provisioners, moreDiags := cfg.getCoreBuildProvisioners(src, build.ProvisionerBlocks, cfg.EvalContext(variables))
 			diags = append(diags, moreDiags...)
-			if moreDiags.HasErrors() {
+			failed = failed || moreDiags.HasErrors()
+			if failed && !opts.Validate {
 				continue
 			}
 			pps, moreDiags := cfg.getCoreBuildPostProcessors(src, build.PostProcessorsLists, cfg.EvalContext(variables))
 			diags = append(diags, moreDiags...)
-			if moreDiags.HasErrors() {
+			if failed || moreDiags.HasErrors() {
 				continue
 			}
 
 			pcb.Builder = builder
+			pcb.PlanConfig = builderConfig
 			pcb.Provisioners = provisioners
 			pcb.PostProcessors = pps
 			pcb.Prepared = true
 
+			if len(build.OutputBlocks) > 0 {
+				outputs := &hcl2Outputs{
+					blocks:      build.OutputBlocks,
+					evalContext: cfg.EvalContext(variables),
+					buildName:   build.Name,
+				}
+				moreDiags := outputs.check(unknownBuildValues)
+				diags = append(diags, moreDiags...)
+				if moreDiags.HasErrors() {
+					continue
+				}
+				pcb.OutputsEvaluator = outputs
+			}
+
 			// Prepare just sets the "prepareCalled" flag on CoreBuild, since
 			// we did all the prep here.
 			_, err := pcb.Prepare()
@@ -460,7 +655,13 @@ Type in the interpolation to test and hit <enter> to see the result.
 "upper(var.foo.id)" would evaluate to the ID of "foo" and uppercase is, if it
 exists in your config file.
 
-"variables" will dump all available variables and their values.
+"variables" will dump all available variables, locals and data sources and
+their values.
+
+Data sources are read when the console starts, like they are for a build, so
+"data.http.ubuntu.body" returns what the build would see. "build.name" and
+"source.name" are set when the config has only one build or source, other
+build variables like "build.ID" are only known while building.
 
 To exit the console, type "exit" and hit <enter>, or use Control-C.
 
@@ -501,6 +702,19 @@ func (p *PackerConfig) printVariables() string {
 		val, _ := v.Value()
 		fmt.Fprintf(out, "local.%s: %q\n", v.Name, PrintableCtyValue(val))
 	}
+	if len(p.Datasources) > 0 {
+		out.WriteString("\n> data-sources:\n\n")
+		refs := make([]string, 0, len(p.Datasources))
+		values := map[string]cty.Value{}
+		for ref, datasource := range p.Datasources {
+			refs = append(refs, ref.String())
+			values[ref.String()] = datasource.Value
+		}
+		sort.Strings(refs)
+		for _, ref := range refs {
+			fmt.Fprintf(out, "%s: %q\n", ref, PrintableCtyValue(values[ref]))
+		}
+	}
 	return out.String()
 }
 
@@ -527,11 +741,19 @@ func (p *PackerConfig) printBuilds() string {
 		if len(build.ProvisionerBlocks) == 0 {
 			fmt.Fprintf(out, "      <no provisioner>\n")
 		}
+		group := 0
 		for _, prov := range build.ProvisionerBlocks {
 			str := prov.PType
 			if prov.PName != "" {
 				str = strings.Join([]string{prov.PType, prov.PName}, ".")
 			}
+			if prov.Group != 0 {
+				if prov.Group != group {
+					fmt.Fprintf(out, "      %s:\n", buildParallelLabel)
+				}
+				str = "  " + str
+			}
+			group = prov.Group
 			fmt.Fprintf(out, "      %s\n", str)
 		}
 		fmt.Fprintf(out, "\n    post-processors:\n")
@@ -561,7 +783,7 @@ func (p *PackerConfig) handleEval(line string) (out string, exit bool, diags hcl
 		return "", false, diags
 	}
 
-	val, valueDiags := expr.Value(p.EvalContext(nil))
+	val, valueDiags := expr.Value(p.EvalContext(p.consoleVariables()))
 	diags = append(diags, valueDiags...)
 	if valueDiags.HasErrors() {
 		return "", false, diags
@@ -570,6 +792,29 @@ func (p *PackerConfig) handleEval(line string) (out string, exit bool, diags hcl
 	return PrintableCtyValue(val), false, diags
 }
 
+// consoleVariables returns the build and source variables the console can
+// resolve: the build and the source are only known when the config has one of
+// each, and the variables of the builder are placeholders until it runs.
+func (p *PackerConfig) consoleVariables() map[string]cty.Value {
+	buildValues := map[string]cty.Value{}
+	for _, k := range packer.BuilderDataCommonKeys {
+		buildValues[k] = cty.StringVal("<unknown>")
+	}
+	buildValues["name"] = cty.UnknownVal(cty.String)
+	if len(p.Builds) == 1 {
+		buildValues["name"] = cty.StringVal(p.Builds[0].Name)
+	}
+	variables := map[string]cty.Value{
+		buildAccessor: cty.ObjectVal(buildValues),
+	}
+	if len(p.Sources) == 1 {
+		for _, src := range p.Sources {
+			variables[sourcesAccessor] = cty.ObjectVal(src.ctyValues())
+		}
+	}
+	return variables
+}
+
 func (p *PackerConfig) FixConfig(_ packer.FixConfigOptions) (diags hcl.Diagnostics) {
 	// No Fixers exist for HCL2 configs so there is nothing to do here for now.
 	return
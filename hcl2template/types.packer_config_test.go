@@ -3,6 +3,8 @@ package hcl2template
 import (
 	"testing"
 
+	"github.com/google/go-cmp/cmp"
+
 	. "github.com/hashicorp/packer/hcl2template/internal"
 	"github.com/hashicorp/packer/packer"
 	"github.com/zclconf/go-cty/cty"
@@ -156,9 +158,10 @@ func TestParser_complete(t *testing.T) {
 			false, false,
 			[]packer.Build{
 				&packer.CoreBuild{
-					Type:     "virtualbox-iso.ubuntu-1204",
-					Prepared: true,
-					Builder:  basicMockBuilder,
+					Type:        "virtualbox-iso.ubuntu-1204",
+					BuilderType: "virtualbox-iso",
+					Prepared:    true,
+					Builder:     basicMockBuilder,
 					Provisioners: []packer.CoreBuildProvisioner{
 						{
 							PType: "shell",
@@ -228,8 +231,9 @@ func TestParser_complete(t *testing.T) {
 					},
 				},
 				&packer.CoreBuild{
-					Type:     "amazon-ebs.ubuntu-1604",
-					Prepared: true,
+					Type:        "amazon-ebs.ubuntu-1604",
+					BuilderType: "amazon-ebs",
+					Prepared:    true,
 					Builder: &MockBuilder{
 						Config: MockConfig{
 							NestedMockConfig: NestedMockConfig{
@@ -340,3 +344,53 @@ func TestParser_ValidateFilterOption(t *testing.T) {
 func pointerToBool(b bool) *bool {
 	return &b
 }
+
+func TestParser_plan(t *testing.T) {
+	parser := getBasicParser()
+	cfg, diags := parser.Parse("testdata/plan/basic.pkr.hcl", nil, map[string]string{"greeting": "hi"})
+	if diags.HasErrors() {
+		t.Fatalf("Parse: %s", diags)
+	}
+	if diags := cfg.Initialize(); diags.HasErrors() {
+		t.Fatalf("Initialize: %s", diags)
+	}
+	builds, diags := cfg.GetBuilds(packer.GetBuildsOptions{})
+	if diags.HasErrors() {
+		t.Fatalf("GetBuilds: %s", diags)
+	}
+	if len(builds) != 1 {
+		t.Fatalf("bad: %#v", builds)
+	}
+
+	expected := packer.BuildPlan{
+		Build: "virtualbox-iso.ubuntu-1204",
+		Builder: packer.PlanEntry{
+			Type:   "virtualbox-iso",
+			Config: map[string]interface{}{"string": "hi-world"},
+		},
+		Provisioners: []packer.PlanEntry{
+			{
+				Type:   "shell",
+				Config: map[string]interface{}{"slice_string": []interface{}{"echo hi"}},
+			},
+			{
+				Type: "parallel",
+				Config: map[string]interface{}{
+					"provisioners": []packer.PlanEntry{
+						{Type: "shell", Name: "packages", Config: map[string]interface{}{"int": 42}},
+						{Type: "file", Config: map[string]interface{}{"string": "HI"}},
+					},
+				},
+			},
+		},
+		PostProcessors: [][]packer.PlanEntry{
+			{
+				{Type: "manifest", Config: map[string]interface{}{"string": "ubuntu-1204.json"}},
+			},
+		},
+	}
+	plan := builds[0].(packer.PlannedBuild).Plan()
+	if diff := cmp.Diff(expected, plan); diff != "" {
+		t.Fatalf("unexpected plan: %s", diff)
+	}
+}
@@ -0,0 +1,196 @@
+package hcl2template
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/packer/hcl2template/addrs"
+	plugingetter "github.com/hashicorp/packer/packer/plugin-getter"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+)
+
+const requiredPluginsLabel = "required_plugins"
+
+// RequiredPlugin is a plugin the config requires, set in a required_plugins
+// block of a packer block:
+//
+//	packer {
+//	  required_plugins {
+//	    amazon = {
+//	      source  = "github.com/hashicorp/amazon"
+//	      version = ">= 1.0.0"
+//	    }
+//	  }
+//	}
+type RequiredPlugin struct {
+	Name string
+	// Source is the address of the plugin, like
+	// "github.com/hashicorp/amazon".
+	Source string
+	Type   *addrs.Plugin
+	// Requirement is the versions of the plugin the config can use.
+	Requirement VersionConstraint
+
+	DeclRange hcl.Range
+}
+
+// decodeRequiredPlugins decodes the required_plugins blocks of the packer
+// blocks of file. The other errors of the packer blocks are reported by
+// sniffCoreVersionRequirements.
+func (cfg *PackerConfig) decodeRequiredPlugins(file *hcl.File) hcl.Diagnostics {
+	var diags hcl.Diagnostics
+
+	rootContent, _, _ := file.Body.PartialContent(&hcl.BodySchema{
+		Blocks: []hcl.BlockHeaderSchema{{Type: packerLabel}},
+	})
+	for _, packerBlock := range rootContent.Blocks {
+		content, _ := packerBlock.Body.Content(packerBlockSchema)
+		for _, block := range content.Blocks {
+			diags = append(diags, cfg.decodeRequiredPluginsBlock(block)...)
+		}
+	}
+	return diags
+}
+
+func (cfg *PackerConfig) decodeRequiredPluginsBlock(block *hcl.Block) hcl.Diagnostics {
+	attrs, diags := block.Body.JustAttributes()
+	if diags.HasErrors() {
+		return diags
+	}
+
+	for _, attr := range sortedAttributes(attrs) {
+		rp, moreDiags := decodeRequiredPlugin(attr)
+		diags = append(diags, moreDiags...)
+		if moreDiags.HasErrors() {
+			continue
+		}
+
+		if previous, exists := cfg.Packer.RequiredPlugins[rp.Name]; exists {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Duplicate required plugin",
+				Detail: fmt.Sprintf("The plugin %q was already required at %s. "+
+					"A plugin can only be required once.", rp.Name, previous.DeclRange),
+				Subject: rp.DeclRange.Ptr(),
+			})
+			continue
+		}
+		duplicate := false
+		for _, other := range cfg.Packer.RequiredPlugins {
+			if other.Source == rp.Source {
+				diags = append(diags, &hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Duplicate plugin source",
+					Detail: fmt.Sprintf("The source %q is already the one of the plugin %q, required at %s.",
+						rp.Source, other.Name, other.DeclRange),
+					Subject: rp.DeclRange.Ptr(),
+				})
+				duplicate = true
+				break
+			}
+		}
+		if duplicate {
+			continue
+		}
+
+		if cfg.Packer.RequiredPlugins == nil {
+			cfg.Packer.RequiredPlugins = map[string]*RequiredPlugin{}
+		}
+		cfg.Packer.RequiredPlugins[rp.Name] = rp
+	}
+	return diags
+}
+
+func decodeRequiredPlugin(attr *hcl.Attribute) (*RequiredPlugin, hcl.Diagnostics) {
+	rp := &RequiredPlugin{
+		Name:      attr.Name,
+		DeclRange: attr.Range,
+	}
+
+	pairs, diags := hcl.ExprMap(attr.Expr)
+	if diags.HasErrors() {
+		return nil, hcl.Diagnostics{&hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Invalid required plugin",
+			Detail: "A required plugin must be an object with a source and a version, " +
+				`like { source = "github.com/hashicorp/amazon", version = ">= 1.0.0" }.`,
+			Subject: attr.Expr.Range().Ptr(),
+		}}
+	}
+
+	for _, pair := range pairs {
+		switch key := hcl.ExprAsKeyword(pair.Key); key {
+		case "source":
+			val, moreDiags := pair.Value.Value(nil)
+			diags = append(diags, moreDiags...)
+			if moreDiags.HasErrors() {
+				continue
+			}
+			val, err := convert.Convert(val, cty.String)
+			if err != nil || val.IsNull() || !val.IsKnown() {
+				diags = append(diags, &hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Invalid plugin source",
+					Detail:   "The source of a plugin must be a string.",
+					Subject:  pair.Value.Range().Ptr(),
+				})
+				continue
+			}
+			rp.Source = val.AsString()
+			rp.Type, err = addrs.ParsePluginSourceString(rp.Source)
+			if err != nil {
+				diags = append(diags, &hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Invalid plugin source",
+					Detail:   err.Error(),
+					Subject:  pair.Value.Range().Ptr(),
+				})
+			}
+		case "version":
+			constraint, moreDiags := decodeVersionConstraint(&hcl.Attribute{
+				Name:  key,
+				Expr:  pair.Value,
+				Range: pair.Value.Range(),
+			})
+			diags = append(diags, moreDiags...)
+			rp.Requirement = constraint
+		default:
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Unsupported argument",
+				Detail: fmt.Sprintf("An argument named %q is not expected here. "+
+					"A required plugin only has a source and a version.", key),
+				Subject: pair.Key.Range().Ptr(),
+			})
+		}
+	}
+
+	if rp.Source == "" && !diags.HasErrors() {
+		diags = append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Missing plugin source",
+			Detail: fmt.Sprintf("The plugin %q must have a source, "+
+				`like "github.com/hashicorp/%s".`, rp.Name, rp.Name),
+			Subject: attr.Expr.Range().Ptr(),
+		})
+	}
+	return rp, diags
+}
+
+// PluginRequirements returns the plugins the config requires, sorted by name.
+func (cfg *PackerConfig) PluginRequirements() plugingetter.Requirements {
+	var reqs plugingetter.Requirements
+	for _, rp := range cfg.Packer.RequiredPlugins {
+		reqs = append(reqs, &plugingetter.Requirement{
+			Accessor:           rp.Name,
+			Identifier:         rp.Type,
+			VersionConstraints: rp.Requirement.Required,
+		})
+	}
+	sort.Slice(reqs, func(i, j int) bool {
+		return reqs[i].Accessor < reqs[j].Accessor
+	})
+	return reqs
+}
@@ -0,0 +1,59 @@
+package hcl2template
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParser_requiredPlugins(t *testing.T) {
+	defaultParser := getBasicParser()
+
+	type want struct {
+		source, constraints string
+	}
+	tests := []struct {
+		name         string
+		file         string
+		wantPlugins  map[string]want
+		wantDiagSumm string
+	}{
+		{
+			"basic",
+			"basic.pkr.hcl",
+			map[string]want{
+				"amazon": {"github.com/hashicorp/amazon", ">= 1.0.0, < 2.0.0"},
+				"docker": {"internal.example.com:8443/infra/docker", ""},
+			},
+			"",
+		},
+		{"invalid source", "invalid_source.pkr.hcl", nil, "Invalid plugin source"},
+		{"missing source", "missing_source.pkr.hcl", nil, "Missing plugin source"},
+		{"unsupported argument", "unsupported_argument.pkr.hcl", nil, "Unsupported argument"},
+		{"duplicate source", "duplicate_source.pkr.hcl", nil, "Duplicate plugin source"},
+		{"duplicate plugin", "duplicate_plugin.pkr.hcl", nil, "Duplicate required plugin"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, diags := defaultParser.Parse(filepath.Join("testdata", "required_plugins", tt.file), nil, nil)
+			if tt.wantDiagSumm != "" {
+				if !diags.HasErrors() || diags[0].Summary != tt.wantDiagSumm {
+					t.Fatalf("expected a %q error, got %s", tt.wantDiagSumm, diags)
+				}
+				return
+			}
+			if diags.HasErrors() {
+				t.Fatalf("unexpected diagnostics: %s", diags)
+			}
+
+			got := map[string]want{}
+			for _, pr := range cfg.PluginRequirements() {
+				got[pr.Accessor] = want{pr.Identifier.String(), pr.VersionConstraints.String()}
+			}
+			if diff := cmp.Diff(tt.wantPlugins, got, cmp.AllowUnexported(want{})); diff != "" {
+				t.Fatalf("unexpected plugin requirements: %s", diff)
+			}
+		})
+	}
+}
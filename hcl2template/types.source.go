@@ -2,10 +2,12 @@ package hcl2template
 
 import (
 	"fmt"
+	"sort"
 	"strconv"
 
 	"github.com/hashicorp/hcl/v2"
 	"github.com/hashicorp/hcl/v2/gohcl"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
 	"github.com/hashicorp/packer/packer"
 	"github.com/zclconf/go-cty/cty"
 )
@@ -25,6 +27,17 @@ type SourceBlock struct {
 	// LocalName can be set in a singular source block from a build block, it
 	// allows to give a special name to a build in the logs.
 	LocalName string
+
+	// each is set when the source is one of the sources of a block with a
+	// for_each meta-argument. forEachBody is then the body of the block
+	// without the for_each meta-argument.
+	each        *eachValue
+	forEachBody hcl.Body
+
+	// module is set when the source is declared by a module. The settings of
+	// the addition then override the ones of the module instead of being
+	// added to them.
+	module *ModuleBlock
 }
 
 func (b *SourceBlock) name() string {
@@ -47,18 +60,19 @@ func (b *SourceBlock) ctyValues() map[string]cty.Value {
 }
 
 // decodeBuildSource reads a used source block from a build:
-//  build {
-//    source "type.example" {
-//      name = "local_name"
-//    }
-//  }
-func (p *Parser) decodeBuildSource(block *hcl.Block) (SourceRef, hcl.Diagnostics) {
+//
+//	build {
+//	  source "type.example" {
+//	    name = "local_name"
+//	  }
+//	}
+func (p *Parser) decodeBuildSource(block *hcl.Block, ectx *hcl.EvalContext) (SourceRef, hcl.Diagnostics) {
 	ref := sourceRefFromString(block.Labels[0])
 	var b struct {
 		Name string   `hcl:"name,optional"`
 		Rest hcl.Body `hcl:",remain"`
 	}
-	diags := gohcl.DecodeBody(block.Body, nil, &b)
+	diags := gohcl.DecodeBody(block.Body, ectx, &b)
 	if diags.HasErrors() {
 		return ref, diags
 	}
@@ -67,6 +81,52 @@ func (p *Parser) decodeBuildSource(block *hcl.Block) (SourceRef, hcl.Diagnostics
 	return ref, nil
 }
 
+// decodeSources reads a source block, that is repeated for each element
+// of its for_each meta-argument if it has one:
+//
+//	source "amazon-ebs" "ubuntu" {
+//	  for_each   = { focal = "ami-1", bionic = "ami-2" }
+//	  source_ami = each.value
+//	}
+//
+// declares the amazon-ebs.ubuntu-focal and amazon-ebs.ubuntu-bionic sources.
+func (p *Parser) decodeSources(block *hcl.Block, ectx *hcl.EvalContext) ([]SourceBlock, hcl.Diagnostics) {
+	source, diags := p.decodeSource(block)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	body, values, forEach, moreDiags := decodeForEach(block.Body, ectx)
+	diags = append(diags, moreDiags...)
+	if moreDiags.HasErrors() {
+		return nil, diags
+	}
+	if !forEach {
+		return []SourceBlock{source}, diags
+	}
+
+	sources := []SourceBlock{}
+	for _, each := range values {
+		instance := source
+		instance.Name = source.Name + "-" + each.Key
+		instance.each = each
+		instance.forEachBody = body
+		if !hclsyntax.ValidIdentifier(instance.Name) {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Invalid " + forEachAttribute + " key",
+				Detail: fmt.Sprintf("The %s keys of a %s block are part of the names of its sources, "+
+					"%q may contain only letters, digits, underscores, and dashes.",
+					forEachAttribute, sourceLabel, each.Key),
+				Subject: block.DefRange.Ptr(),
+			})
+			continue
+		}
+		sources = append(sources, instance)
+	}
+	return sources, diags
+}
+
 func (p *Parser) decodeSource(block *hcl.Block) (SourceBlock, hcl.Diagnostics) {
 	source := SourceBlock{
 		Type:  block.Labels[0],
@@ -88,7 +148,10 @@ func (p *Parser) decodeSource(block *hcl.Block) (SourceBlock, hcl.Diagnostics) {
 	return source, diags
 }
 
-func (cfg *PackerConfig) startBuilder(source SourceBlock, ectx *hcl.EvalContext, opts packer.GetBuildsOptions) (packer.Builder, hcl.Diagnostics, []string) {
+// startBuilder starts and prepares the builder of a source. It also returns
+// the variables the builder generates, and its decoded configuration for the
+// plan of the build.
+func (cfg *PackerConfig) startBuilder(source SourceBlock, ectx *hcl.EvalContext, opts packer.GetBuildsOptions) (packer.Builder, hcl.Diagnostics, []string, map[string]interface{}) {
 	var diags hcl.Diagnostics
 
 	builder, err := cfg.builderSchemas.Start(source.Type)
@@ -98,18 +161,23 @@ func (cfg *PackerConfig) startBuilder(source SourceBlock, ectx *hcl.EvalContext,
 			Detail:  err.Error(),
 			Subject: &source.block.LabelRanges[0],
 		})
-		return builder, diags, nil
+		return builder, diags, nil, nil
 	}
 
 	body := source.block.Body
-	if source.addition != nil {
-		body = hcl.MergeBodies([]hcl.Body{source.block.Body, source.addition})
+	if source.each != nil {
+		body = source.forEachBody
+	}
+	if source.module != nil && source.addition != nil {
+		body = overrideBody(body, source.addition)
+	} else if source.addition != nil {
+		body = hcl.MergeBodies([]hcl.Body{body, source.addition})
 	}
 
 	decoded, moreDiags := decodeHCL2Spec(body, ectx, builder)
 	diags = append(diags, moreDiags...)
 	if moreDiags.HasErrors() {
-		return nil, diags, nil
+		return nil, diags, nil, nil
 	}
 
 	// Note: HCL prepares inside of the Start func, but Json does not. Json
@@ -121,11 +189,14 @@ func (cfg *PackerConfig) startBuilder(source SourceBlock, ectx *hcl.EvalContext,
 	builderVars["packer_debug"] = strconv.FormatBool(opts.Debug)
 	builderVars["packer_force"] = strconv.FormatBool(opts.Force)
 	builderVars["packer_on_error"] = opts.OnError
+	builderVars["packer_resume"] = strconv.FormatBool(opts.Resume)
+	builderVars["packer_validate"] = strconv.FormatBool(opts.Validate)
+	builderVars["packer_cleanup_timeout"] = opts.CleanupTimeout.String()
 
 	generatedVars, warning, err := builder.Prepare(builderVars, decoded)
 	moreDiags = warningErrorsToDiags(source.block, warning, err)
 	diags = append(diags, moreDiags...)
-	return builder, diags, generatedVars
+	return builder, diags, generatedVars, planConfig(decoded)
 }
 
 // These variables will populate the PackerConfig inside of the builders.
@@ -144,6 +215,10 @@ func (source *SourceBlock) Ref() SourceRef {
 }
 
 type SourceRef struct {
+	// Module is the name of the module that declares the source, if it is
+	// not declared by the template.
+	Module string
+
 	Type string
 	Name string
 
@@ -159,15 +234,79 @@ type SourceRef struct {
 // Ref is here to make sure only one is returned.
 func (r *SourceRef) Ref() SourceRef {
 	return SourceRef{
-		Type: r.Type,
-		Name: r.Name,
+		Module: r.Module,
+		Type:   r.Type,
+		Name:   r.Name,
 	}
 }
 
+// sourcesOf returns the sources that ref refers to. A reference to a source
+// block with a for_each meta-argument refers to all of its sources.
+func (cfg *PackerConfig) sourcesOf(ref SourceRef) []SourceRef {
+	if ref.Module != "" {
+		module, found := cfg.Modules[ref.Module]
+		if !found {
+			return []SourceRef{ref}
+		}
+		local := ref
+		local.Module = ""
+		refs := module.config.sourcesOf(local)
+		for i := range refs {
+			refs[i].Module = ref.Module
+		}
+		return refs
+	}
+
+	if _, found := cfg.Sources[ref.Ref()]; found {
+		return []SourceRef{ref}
+	}
+
+	var instances []SourceBlock
+	for _, src := range cfg.Sources {
+		if src.each != nil && src.Type == ref.Type && src.block.Labels[1] == ref.Name {
+			instances = append(instances, src)
+		}
+	}
+	if len(instances) == 0 {
+		// unknown source
+		return []SourceRef{ref}
+	}
+	sort.Slice(instances, func(i, j int) bool { return instances[i].each.Key < instances[j].each.Key })
+
+	refs := []SourceRef{}
+	for _, instance := range instances {
+		instanceRef := instance.Ref()
+		instanceRef.addition = ref.addition
+		if ref.LocalName != "" {
+			instanceRef.LocalName = ref.LocalName + "-" + instance.each.Key
+		}
+		refs = append(refs, instanceRef)
+	}
+	return refs
+}
+
 // NoSource is the zero value of sourceRef, representing the absense of an
 // source.
 var NoSource SourceRef
 
 func (r SourceRef) String() string {
+	if r.Module != "" {
+		return fmt.Sprintf("%s.%s.%s.%s", moduleAccessor, r.Module, r.Type, r.Name)
+	}
 	return fmt.Sprintf("%s.%s", r.Type, r.Name)
 }
+
+// sourceBlock returns the source block that ref refers to, that can be
+// declared by a module.
+func (cfg *PackerConfig) sourceBlock(ref SourceRef) (SourceBlock, bool) {
+	sources := cfg.Sources
+	if ref.Module != "" {
+		module, found := cfg.Modules[ref.Module]
+		if !found {
+			return SourceBlock{}, false
+		}
+		sources = module.config.Sources
+	}
+	src, found := sources[SourceRef{Type: ref.Type, Name: ref.Name}]
+	return src, found
+}
@@ -134,14 +134,16 @@ func (v *Variable) validateValue(val VariableAssignment) (diags hcl.Diagnostics)
 
 		if result.False() {
 			subj := validation.DeclRange.Ptr()
-			if val.Expr != nil {
+			// The values set with -var don't always have a source range
+			if val.Expr != nil && val.Expr.Range().Filename != "" {
 				subj = val.Expr.Range().Ptr()
 			}
 			diags = append(diags, &hcl.Diagnostic{
 				Severity: hcl.DiagError,
-				Summary:  fmt.Sprintf("Invalid value for %s variable", val.From),
-				Detail:   fmt.Sprintf("%s\n\nThis was checked by the validation rule at %s.", validation.ErrorMessage, validation.DeclRange.String()),
-				Subject:  subj,
+				Summary:  fmt.Sprintf("Invalid value for variable %q", v.Name),
+				Detail: fmt.Sprintf("%s\n\nThe value was set %s, and was checked by the validation rule at %s.",
+					validation.ErrorMessage, assignmentOrigin(v.Name, val.From), validation.DeclRange.String()),
+				Subject: subj,
 			})
 		}
 	}
@@ -149,6 +151,24 @@ func (v *Variable) validateValue(val VariableAssignment) (diags hcl.Diagnostics)
 	return diags
 }
 
+// assignmentOrigin tells where the value of variable name was set from, to
+// complete "The value was set ...".
+func assignmentOrigin(name, from string) string {
+	switch from {
+	case "default":
+		return "by its default"
+	case "env":
+		return "by the " + VarEnvPrefix + name + " environment variable"
+	case "varfile":
+		return "in a var file"
+	case "cmd":
+		return "with -var"
+	case moduleAccessor:
+		return "by the module block"
+	}
+	return "by " + from
+}
+
 // Value returns the last found value from the list of variable settings.
 func (v *Variable) Value() (cty.Value, hcl.Diagnostics) {
 	if len(v.Values) == 0 {
@@ -2,7 +2,9 @@ package hcl2template
 
 import (
 	"fmt"
+	"os"
 	"path/filepath"
+	"sort"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
@@ -295,6 +297,76 @@ func TestParse_variables(t *testing.T) {
 			false,
 		},
 
+		{"locals referring to data sources and locals declared later",
+			defaultParser,
+			parseTestArgs{"testdata/variables/locals_order", nil, nil},
+			&PackerConfig{
+				Basedir: filepath.Join("testdata", "variables", "locals_order"),
+				LocalVariables: Variables{
+					"image_name": &Variable{
+						Name:   "image_name",
+						Values: []VariableAssignment{{From: "default", Value: cty.StringVal("ubuntu-1604")}},
+						Type:   cty.String,
+					},
+					"image": &Variable{
+						Name:   "image",
+						Values: []VariableAssignment{{From: "default", Value: cty.StringVal("ubuntu")}},
+						Type:   cty.String,
+					},
+					"suffix": &Variable{
+						Name:   "suffix",
+						Values: []VariableAssignment{{From: "default", Value: cty.StringVal("1604")}},
+						Type:   cty.String,
+					},
+					"version": &Variable{
+						Name:   "version",
+						Values: []VariableAssignment{{From: "default", Value: cty.StringVal("1604")}},
+						Type:   cty.String,
+					},
+				},
+				Datasources: Datasources{
+					{Type: "mock", Name: "image"}: {
+						Type: "mock",
+						Name: "image",
+						Value: cty.ObjectVal(map[string]cty.Value{
+							"string": cty.StringVal("ubuntu"),
+							"int":    cty.NumberIntVal(0),
+						}),
+					},
+				},
+			},
+			false, false,
+			[]packer.Build{},
+			false,
+		},
+		{"cyclic locals",
+			defaultParser,
+			parseTestArgs{"testdata/variables/cyclic_locals.pkr.hcl", nil, nil},
+			&PackerConfig{
+				Basedir: filepath.Join("testdata", "variables"),
+				LocalVariables: Variables{
+					"other": &Variable{
+						Name:   "other",
+						Values: []VariableAssignment{{From: "default", Value: cty.StringVal("fine")}},
+						Type:   cty.String,
+					},
+				},
+			},
+			true, true,
+			[]packer.Build{},
+			false,
+		},
+		{"duplicate locals",
+			defaultParser,
+			parseTestArgs{"testdata/variables/duplicate_locals.pkr.hcl", nil, nil},
+			&PackerConfig{
+				Basedir: filepath.Join("testdata", "variables"),
+			},
+			true, true,
+			[]packer.Build{},
+			false,
+		},
+
 		{"set variable from var-file",
 			defaultParser,
 			parseTestArgs{"testdata/variables/foo-string.variable.pkr.hcl", nil, []string{"testdata/variables/set-foo-too-wee.hcl"}},
@@ -370,9 +442,10 @@ func TestParse_variables(t *testing.T) {
 			},
 			false, false,
 			[]packer.Build{&packer.CoreBuild{
-				Type:     "null.null-builder",
-				Prepared: true,
-				Builder:  &null.Builder{},
+				Type:        "null.null-builder",
+				BuilderType: "null",
+				Prepared:    true,
+				Builder:     &null.Builder{},
 				Provisioners: []packer.CoreBuildProvisioner{
 					{
 						PType: "shell",
@@ -795,7 +868,7 @@ func TestVariables_collectVariableValues(t *testing.T) {
 			var files []*hcl.File
 			parser := getBasicParser()
 			for i, hclContent := range tt.args.hclFiles {
-				file, diags := parser.ParseHCL([]byte(hclContent), fmt.Sprintf("test_file_%d_*"+hcl2VarFileExt, i))
+				file, diags := parser.ParseHCL([]byte(hclContent), fmt.Sprintf("test_file_%d_*"+hcl2AutoVarFileExt, i))
 				if diags != nil {
 					t.Fatalf("ParseHCLFile %d: %v", i, diags)
 				}
@@ -841,3 +914,79 @@ func stringListVal(strings ...string) cty.Value {
 	}
 	return list
 }
+
+func TestVariables_validation(t *testing.T) {
+	tests := []struct {
+		name     string
+		vars     map[string]string
+		varFiles []string
+		env      map[string]string
+		want     []string
+	}{
+		{"valid defaults", nil, nil, nil, nil},
+		{"-var",
+			map[string]string{"generation": "3", "iso_checksum": "sha256:not-hex"}, nil, nil,
+			[]string{
+				`Invalid value for variable "generation": The generation must be 1 or 2.` +
+					"\n\nThe value was set with -var, and was checked by the validation rule at testdata/variables/validation/generation/definition.pkr.hcl:5,3-13.",
+				`Invalid value for variable "iso_checksum": The iso_checksum must be a checksum type and a hexadecimal checksum, like "sha256:e3b0c442...".` +
+					"\n\nThe value was set with -var, and was checked by the validation rule at testdata/variables/validation/generation/definition.pkr.hcl:14,3-13.",
+			},
+		},
+		{"var file",
+			nil, []string{"testdata/variables/validation/generation/bad.pkrvars.hcl"}, nil,
+			[]string{
+				`Invalid value for variable "generation": The generation must be 1 or 2.` +
+					"\n\nThe value was set in a var file, and was checked by the validation rule at testdata/variables/validation/generation/definition.pkr.hcl:5,3-13.",
+			},
+		},
+		{"env",
+			nil, nil, map[string]string{"PKR_VAR_generation": "0"},
+			[]string{
+				`Invalid value for variable "generation": The generation must be 1 or 2.` +
+					"\n\nThe value was set by the PKR_VAR_generation environment variable, and was checked by the validation rule at testdata/variables/validation/generation/definition.pkr.hcl:5,3-13.",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.env {
+				defer os.Unsetenv(k)
+				os.Setenv(k, v)
+			}
+			cfg, diags := getBasicParser().Parse("testdata/variables/validation/generation", tt.varFiles, tt.vars)
+			if diags.HasErrors() {
+				t.Fatalf("Parse: %s", diags)
+			}
+			diags = cfg.Initialize()
+
+			got := []string{}
+			for _, diag := range diags {
+				if diag.Subject == nil || diag.Subject.Filename == "" {
+					t.Fatalf("the diagnostic should point to the value or to the rule: %s", diag)
+				}
+				got = append(got, diag.Summary+": "+diag.Detail)
+			}
+			sort.Strings(got)
+			if diff := cmp.Diff(append([]string{}, tt.want...), got); diff != "" {
+				t.Fatalf("unexpected diagnostics: %s", diff)
+			}
+		})
+	}
+}
+
+func TestParse_cyclicLocals(t *testing.T) {
+	cfg, diags := getBasicParser().Parse("testdata/variables/cyclic_locals.pkr.hcl", nil, nil)
+	if diags.HasErrors() {
+		t.Fatalf("Parse: %s", diags)
+	}
+	diags = cfg.Initialize()
+	if len(diags) != 1 {
+		t.Fatalf("expected only the cycle to be an error, got %s", diags)
+	}
+	want := "local.first refers to local.second, which refers to local.third, which refers to local.first. " +
+		"A local can only refer to locals that don't refer back to it."
+	if diags[0].Summary != "Cycle in locals" || diags[0].Detail != want {
+		t.Fatalf("unexpected diagnostic: %s: %s", diags[0].Summary, diags[0].Detail)
+	}
+}
@@ -0,0 +1,45 @@
+package hcl2template
+
+import (
+	"github.com/hashicorp/hcl/v2"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+)
+
+const whenAttribute = "when"
+
+// evalWhen evaluates the when meta-argument of a provisioner or
+// post-processor block, in the context of the source it is started for. It
+// returns false when the block has to be skipped for that source. Blocks
+// without a when meta-argument are never skipped.
+func evalWhen(expr hcl.Expression, ectx *hcl.EvalContext) (bool, hcl.Diagnostics) {
+	if expr == nil {
+		return true, nil
+	}
+
+	v, diags := expr.Value(ectx)
+	if diags.HasErrors() {
+		return false, diags
+	}
+	if v.IsNull() {
+		return true, diags
+	}
+
+	invalid := func(detail string) hcl.Diagnostics {
+		return append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Invalid " + whenAttribute + " argument",
+			Detail:   detail,
+			Subject:  expr.Range().Ptr(),
+		})
+	}
+	if !v.IsWhollyKnown() {
+		return false, invalid("The " + whenAttribute + " value must be known when the build is started, " +
+			"so it can only refer to variables, locals and the source.")
+	}
+	v, err := convert.Convert(v, cty.Bool)
+	if err != nil {
+		return false, invalid("The " + whenAttribute + " value must be a bool: " + err.Error())
+	}
+	return v.True(), diags
+}
@@ -5,6 +5,7 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/gobwas/glob"
@@ -34,6 +35,19 @@ func warningErrorsToDiags(block *hcl.Block, warnings []string, err error) hcl.Di
 	return diags
 }
 
+// sortedAttributes returns the attributes of attrs in the order they are
+// declared in, as JustAttributes returns them in a map.
+func sortedAttributes(attrs hcl.Attributes) []*hcl.Attribute {
+	sorted := make([]*hcl.Attribute, 0, len(attrs))
+	for _, attr := range attrs {
+		sorted = append(sorted, attr)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Range.Start.Byte < sorted[j].Range.Start.Byte
+	})
+	return sorted
+}
+
 func isDir(name string) (bool, error) {
 	s, err := os.Stat(name)
 	if err != nil {
@@ -4,6 +4,7 @@
 package communicator
 
 import (
+	"crypto/tls"
 	"errors"
 	"fmt"
 	"io/ioutil"
@@ -39,7 +40,8 @@ type Config struct {
 	// In addition to the above, some builders have custom communicators they
 	// can use. For example, the Docker builder has a "docker" communicator
 	// that uses `docker exec` and `docker cp` to execute scripts and copy
-	// files.
+	// files, and the Hyper-V builders have a "powershell-direct" communicator
+	// that talks to Windows guests over the VMBus.
 	Type string `mapstructure:"communicator"`
 	// We recommend that you enable SSH or WinRM as the very last step in your
 	// guest's bootstrap script, but sometimes you may have a race condition
@@ -53,6 +55,10 @@ type Config struct {
 	// will disconnect and then wait 10 minutes before connecting to the guest
 	// and beginning provisioning.
 	PauseBeforeConnect time.Duration `mapstructure:"pause_before_connecting"`
+	// How long to wait for the machine to come back when a provisioner
+	// restarts it, for example a `shell` provisioner with `expect_disconnect`
+	// or the `windows-restart` provisioner. Defaults to `5m`.
+	ReconnectTimeout time.Duration `mapstructure:"reconnect_timeout"`
 
 	SSH   `mapstructure:",squash"`
 	WinRM `mapstructure:",squash"`
@@ -110,7 +116,10 @@ type SSH struct {
 	// The `~` can be used in path and will be expanded to the home directory
 	// of current user.
 	SSHPrivateKeyFile string `mapstructure:"ssh_private_key_file" undocumented:"true"`
-	// Path to user certificate used to authenticate with SSH.
+	// Path to user certificate used to authenticate with SSH, like a
+	// certificate signed by the SSH secrets engine of Vault. Packer checks
+	// before the build starts that it is valid now and that `ssh_username`
+	// is one of its principals.
 	// The `~` can be used in path and will be expanded to the
 	// home directory of current user.
 	SSHCertificateFile string `mapstructure:"ssh_certificate_file"`
@@ -132,6 +141,13 @@ type SSH struct {
 	SSHAgentAuth bool `mapstructure:"ssh_agent_auth" undocumented:"true"`
 	// If true, SSH agent forwarding will be disabled. Defaults to `false`.
 	SSHDisableAgentForwarding bool `mapstructure:"ssh_disable_agent_forwarding"`
+	// If true, Packer refuses to reconnect to the machine when its host key
+	// changed since the first connection. A provisioner that expects the
+	// machine to restart, like a `shell` provisioner with
+	// `expect_disconnect`, allows the host key to change once, since sysprep
+	// or cloud-init may regenerate it; the new key is then pinned. Defaults
+	// to `false`.
+	SSHPinHostKey bool `mapstructure:"ssh_pin_host_key"`
 	// The number of handshakes to attempt with SSH once it can connect. This
 	// defaults to `10`.
 	SSHHandshakeAttempts int `mapstructure:"ssh_handshake_attempts"`
@@ -153,12 +169,29 @@ type SSH struct {
 	// home directory of current user.
 	SSHBastionPrivateKeyFile string `mapstructure:"ssh_bastion_private_key_file"`
 	// Path to user certificate used to authenticate with bastion host.
+	// Like `ssh_certificate_file`, it must be valid for
+	// `ssh_bastion_username`.
 	// The `~` can be used in path and will be expanded to the
 	//home directory of current user.
 	SSHBastionCertificateFile string `mapstructure:"ssh_bastion_certificate_file"`
 	// `scp` or `sftp` - How to transfer files, Secure copy (default) or SSH
 	// File Transfer Protocol.
 	SSHFileTransferMethod string `mapstructure:"ssh_file_transfer_method"`
+	// The number of chunks of a file that are written at once when it is
+	// uploaded with sftp, for example by the file provisioner. Writing
+	// several chunks at once speeds up the upload of large files on
+	// connections with a high latency. Defaults to `1`, which writes the file
+	// sequentially.
+	SSHUploadConcurrency int `mapstructure:"ssh_upload_concurrency"`
+	// The size in bytes of the chunks written at once with
+	// `ssh_upload_concurrency`. Up to `ssh_upload_concurrency` chunks are held
+	// in memory. Defaults to `1048576` (1MiB).
+	SSHUploadBufferSize int `mapstructure:"ssh_upload_buffer_size"`
+	// If true, uploaded files are compressed with gzip and decompressed on
+	// the remote host, when it has `gzip`. Packer checks for it once and
+	// uploads the files uncompressed otherwise. This doesn't apply to
+	// directories. Defaults to `false`.
+	SSHUploadCompression bool `mapstructure:"ssh_upload_compression"`
 	// A SOCKS proxy host to use for SSH connection
 	SSHProxyHost string `mapstructure:"ssh_proxy_host"`
 	// A port of the SOCKS proxy. Defaults to `1080`.
@@ -170,6 +203,12 @@ type SSH struct {
 	// How often to send "keep alive" messages to the server. Set to a negative
 	// value (`-1s`) to disable. Example value: `10s`. Defaults to `5s`.
 	SSHKeepAliveInterval time.Duration `mapstructure:"ssh_keep_alive_interval"`
+	// The number of keep alive messages that can go unanswered before Packer
+	// closes the SSH connection and opens a new one. Commands that are
+	// running on the closed connection fail. The connection is never closed
+	// with the default of `0`; `3` with the default `ssh_keep_alive_interval`
+	// detects a dead connection in about 15 seconds.
+	SSHKeepAliveCountMax int `mapstructure:"ssh_keep_alive_count_max"`
 	// The amount of time to wait for a remote command to end. This might be
 	// useful if, for example, packer hangs on a connection after a reboot.
 	// Example: `5m`. Disabled by default.
@@ -239,7 +278,15 @@ type WinRM struct {
 	// requirement for basic authentication to be enabled within the target
 	// guest. Further reading for remote connection authentication can be found
 	// [here](https://msdn.microsoft.com/en-us/library/aa384295(v=vs.85).aspx).
-	WinRMUseNTLM            bool `mapstructure:"winrm_use_ntlm"`
+	WinRMUseNTLM bool `mapstructure:"winrm_use_ntlm"`
+	// Path to a PEM encoded client certificate to authenticate with over
+	// HTTPS, instead of a password. The certificate must be mapped to a
+	// local user of the guest, see [Certificate-based
+	// authentication](https://docs.microsoft.com/en-us/windows/win32/winrm/authentication-for-remote-connections#client-certificate-based-authentication).
+	// Requires `winrm_use_ssl` and `winrm_client_key_file`.
+	WinRMClientCertFile string `mapstructure:"winrm_client_cert_file"`
+	// Path to the PEM encoded private key of `winrm_client_cert_file`.
+	WinRMClientKeyFile      string `mapstructure:"winrm_client_key_file"`
 	WinRMTransportDecorator func() winrm.Transporter
 }
 
@@ -438,6 +485,10 @@ func (c *Config) Prepare(ctx *interpolate.Context) []error {
 	}
 
 	var errs []error
+	if c.ReconnectTimeout < 0 {
+		errs = append(errs, errors.New("reconnect_timeout can't be negative"))
+	}
+
 	switch c.Type {
 	case "ssh":
 		if es := c.prepareSSH(ctx); len(es) > 0 {
@@ -447,7 +498,7 @@ func (c *Config) Prepare(ctx *interpolate.Context) []error {
 		if es := c.prepareWinRM(ctx); len(es) > 0 {
 			errs = append(errs, es...)
 		}
-	case "docker", "dockerWindowsContainer", "none":
+	case "docker", "dockerWindowsContainer", "powershell-direct", "none":
 		break
 	default:
 		return []error{fmt.Errorf("Communicator type %s is invalid", c.Type)}
@@ -509,6 +560,18 @@ func (c *Config) prepareSSH(ctx *interpolate.Context) []error {
 		errs = append(errs, errors.New("An ssh_username must be specified\n  Note: some builders used to default ssh_username to \"root\"."))
 	}
 
+	if c.SSHKeepAliveCountMax < 0 {
+		errs = append(errs, errors.New("ssh_keep_alive_count_max can't be negative"))
+	}
+
+	if c.SSHUploadConcurrency < 0 {
+		errs = append(errs, errors.New("ssh_upload_concurrency can't be negative"))
+	}
+
+	if c.SSHUploadBufferSize < 0 {
+		errs = append(errs, errors.New("ssh_upload_buffer_size can't be negative"))
+	}
+
 	if c.SSHPrivateKeyFile != "" {
 		path, err := packer.ExpandUser(c.SSHPrivateKeyFile)
 		if err != nil {
@@ -521,12 +584,15 @@ func (c *Config) prepareSSH(ctx *interpolate.Context) []error {
 			if c.SSHCertificateFile != "" {
 				certPath, err := packer.ExpandUser(c.SSHCertificateFile)
 				if err != nil {
-					errs = append(errs, fmt.Errorf("invalid identity certificate: #{err}"))
+					errs = append(errs, fmt.Errorf("invalid identity certificate: %s", err))
 				}
 
 				if _, err := helperssh.FileSignerWithCert(path, certPath); err != nil {
 					errs = append(errs, fmt.Errorf(
 						"ssh_private_key_file is invalid: %s", err))
+				} else if err := checkCertificateFile(certPath, c.SSHUsername); err != nil {
+					errs = append(errs, fmt.Errorf(
+						"ssh_certificate_file is invalid: %s", err))
 				}
 			} else {
 				if _, err := helperssh.FileSigner(path); err != nil {
@@ -553,11 +619,14 @@ func (c *Config) prepareSSH(ctx *interpolate.Context) []error {
 				if c.SSHBastionCertificateFile != "" {
 					certPath, err := packer.ExpandUser(c.SSHBastionCertificateFile)
 					if err != nil {
-						errs = append(errs, fmt.Errorf("invalid identity certificate: #{err}"))
+						errs = append(errs, fmt.Errorf("invalid identity certificate: %s", err))
 					}
 					if _, err := helperssh.FileSignerWithCert(path, certPath); err != nil {
 						errs = append(errs, fmt.Errorf(
 							"ssh_bastion_private_key_file is invalid: %s", err))
+					} else if err := checkCertificateFile(certPath, c.SSHBastionUsername); err != nil {
+						errs = append(errs, fmt.Errorf(
+							"ssh_bastion_certificate_file is invalid: %s", err))
 					}
 				} else {
 					if _, err := helperssh.FileSigner(path); err != nil {
@@ -598,6 +667,17 @@ func (c *Config) prepareSSH(ctx *interpolate.Context) []error {
 	return errs
 }
 
+// checkCertificateFile checks that the certificate at path can be used to
+// log in as user, so that a wrong certificate fails before the build starts
+// instead of when connecting.
+func checkCertificateFile(path string, user string) error {
+	cert, err := helperssh.ReadCertificateFile(path)
+	if err != nil {
+		return err
+	}
+	return helperssh.CheckUserCertificate(cert, user)
+}
+
 func (c *Config) prepareWinRM(ctx *interpolate.Context) (errs []error) {
 	if c.WinRMPort == 0 && c.WinRMUseSSL {
 		c.WinRMPort = 5986
@@ -613,9 +693,55 @@ func (c *Config) prepareWinRM(ctx *interpolate.Context) (errs []error) {
 		c.WinRMTransportDecorator = func() winrm.Transporter { return &winrm.ClientNTLM{} }
 	}
 
-	if c.WinRMUser == "" {
+	if c.WinRMClientCertFile != "" || c.WinRMClientKeyFile != "" {
+		errs = append(errs, c.prepareWinRMClientCert()...)
+	} else if c.WinRMUser == "" {
 		errs = append(errs, errors.New("winrm_username must be specified."))
 	}
 
 	return errs
 }
+
+func (c *Config) prepareWinRMClientCert() (errs []error) {
+	if c.WinRMClientCertFile == "" || c.WinRMClientKeyFile == "" {
+		return append(errs, errors.New(
+			"winrm_client_cert_file and winrm_client_key_file must be set together"))
+	}
+	if !c.WinRMUseSSL {
+		errs = append(errs, errors.New(
+			"winrm_use_ssl must be true to authenticate with winrm_client_cert_file"))
+	}
+	if c.WinRMUseNTLM {
+		errs = append(errs, errors.New(
+			"winrm_use_ntlm can't be used with winrm_client_cert_file"))
+	}
+	if _, _, err := c.ReadWinRMClientCert(); err != nil {
+		errs = append(errs, err)
+	}
+	return errs
+}
+
+// ReadWinRMClientCert returns the PEM encoded client certificate and key of
+// WinRM, after checking that they match.
+func (c *Config) ReadWinRMClientCert() (cert []byte, key []byte, err error) {
+	certPath, err := packer.ExpandUser(c.WinRMClientCertFile)
+	if err != nil {
+		return nil, nil, fmt.Errorf("winrm_client_cert_file is invalid: %s", err)
+	}
+	keyPath, err := packer.ExpandUser(c.WinRMClientKeyFile)
+	if err != nil {
+		return nil, nil, fmt.Errorf("winrm_client_key_file is invalid: %s", err)
+	}
+	cert, err = ioutil.ReadFile(certPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("winrm_client_cert_file is invalid: %s", err)
+	}
+	key, err = ioutil.ReadFile(keyPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("winrm_client_key_file is invalid: %s", err)
+	}
+	if _, err := tls.X509KeyPair(cert, key); err != nil {
+		return nil, nil, fmt.Errorf("winrm_client_cert_file and winrm_client_key_file are invalid: %s", err)
+	}
+	return cert, key, nil
+}
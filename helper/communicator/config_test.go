@@ -1,8 +1,19 @@
 package communicator
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/packer/packer-plugin-sdk/multistep"
 	"github.com/hashicorp/packer/packer-plugin-sdk/template/interpolate"
@@ -35,6 +46,13 @@ func TestConfig_none(t *testing.T) {
 	}
 }
 
+func TestConfig_powershellDirect(t *testing.T) {
+	c := &Config{Type: "powershell-direct"}
+	if err := c.Prepare(testContext(t)); len(err) > 0 {
+		t.Fatalf("bad: %#v", err)
+	}
+}
+
 func TestConfig_badtype(t *testing.T) {
 	c := &Config{Type: "foo"}
 	if err := c.Prepare(testContext(t)); len(err) != 1 {
@@ -247,6 +265,76 @@ func TestConfig_winrm(t *testing.T) {
 	}
 }
 
+func TestConfig_winrmClientCert(t *testing.T) {
+	dir, err := ioutil.TempDir("", "packer-winrm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	certFile, keyFile := testClientCert(t, dir)
+
+	cases := []struct {
+		name  string
+		winrm WinRM
+		ok    bool
+	}{
+		{"cert and key", WinRM{WinRMUseSSL: true, WinRMClientCertFile: certFile, WinRMClientKeyFile: keyFile}, true},
+		{"no ssl", WinRM{WinRMClientCertFile: certFile, WinRMClientKeyFile: keyFile}, false},
+		{"no key", WinRM{WinRMUseSSL: true, WinRMClientCertFile: certFile}, false},
+		{"key as cert", WinRM{WinRMUseSSL: true, WinRMClientCertFile: keyFile, WinRMClientKeyFile: keyFile}, false},
+		{"missing cert", WinRM{WinRMUseSSL: true, WinRMClientCertFile: filepath.Join(dir, "missing"), WinRMClientKeyFile: keyFile}, false},
+		{"with ntlm", WinRM{WinRMUseSSL: true, WinRMUseNTLM: true, WinRMClientCertFile: certFile, WinRMClientKeyFile: keyFile}, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := &Config{
+				Type:  "winrm",
+				WinRM: tc.winrm,
+			}
+			errs := c.Prepare(testContext(t))
+			if tc.ok && len(errs) > 0 {
+				t.Fatalf("bad: %#v", errs)
+			}
+			if !tc.ok && len(errs) == 0 {
+				t.Fatal("should have an error")
+			}
+		})
+	}
+}
+
+// testClientCert writes a self-signed client certificate and its key in dir.
+func testClientCert(t *testing.T, dir string) (certFile, keyFile string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "packer"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certFile = filepath.Join(dir, "client.pem")
+	keyFile = filepath.Join(dir, "client-key.pem")
+	if err := ioutil.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return certFile, keyFile
+}
+
 func testContext(t *testing.T) *interpolate.Context {
 	return nil
 }
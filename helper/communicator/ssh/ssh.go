@@ -59,21 +59,9 @@ func ReadCertificate(certificatePath string, keySigner ssh.Signer) (ssh.Signer,
 		return keySigner, fmt.Errorf("no certificate file provided")
 	}
 
-	// Load the certificate
-	cert, err := ioutil.ReadFile(certificatePath)
+	certificate, err := ReadCertificateFile(certificatePath)
 	if err != nil {
-		return nil, fmt.Errorf("unable to read certificate file: %v", err)
-	}
-
-	pk, _, _, _, err := ssh.ParseAuthorizedKey(cert)
-	if err != nil {
-		return nil, fmt.Errorf("unable to parse public key: %v", err)
-	}
-
-	certificate, ok := pk.(*ssh.Certificate)
-
-	if !ok {
-		return nil, fmt.Errorf("Error loading certificate")
+		return nil, err
 	}
 
 	err = checkValidCert(certificate)
@@ -101,6 +89,49 @@ func FileSignerWithCert(path string, certificatePath string) (ssh.Signer, error)
 	return ReadCertificate(certificatePath, keySigner)
 }
 
+// ReadCertificateFile reads the OpenSSH certificate at path, like the
+// id_rsa-cert.pub files signed by ssh-keygen or by the SSH secrets engine of
+// Vault.
+func ReadCertificateFile(path string) (*ssh.Certificate, error) {
+	cert, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read certificate file: %v", err)
+	}
+
+	pk, _, _, _, err := ssh.ParseAuthorizedKey(cert)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse public key: %v", err)
+	}
+
+	certificate, ok := pk.(*ssh.Certificate)
+	if !ok {
+		return nil, fmt.Errorf("Error loading certificate: %s is a public key, not a certificate", path)
+	}
+	return certificate, nil
+}
+
+// CheckUserCertificate returns an error if cert can't be used to log in as
+// user: when it isn't a user certificate, when it isn't valid now or when
+// user isn't one of its principals. A certificate without principals can be
+// used by any user.
+func CheckUserCertificate(cert *ssh.Certificate, user string) error {
+	if cert.CertType != ssh.UserCert {
+		return fmt.Errorf("ssh: cert is a host certificate, not a user certificate")
+	}
+	if err := checkValidCert(cert); err != nil {
+		return err
+	}
+	if len(cert.ValidPrincipals) == 0 {
+		return nil
+	}
+	for _, principal := range cert.ValidPrincipals {
+		if principal == user {
+			return nil
+		}
+	}
+	return fmt.Errorf("ssh: cert is not valid for user %q, its principals are %v", user, cert.ValidPrincipals)
+}
+
 func checkValidCert(cert *ssh.Certificate) error {
 	const CertTimeInfinity = 1<<64 - 1
 	unixNow := time.Now().Unix()
@@ -0,0 +1,72 @@
+package ssh
+
+import (
+	"crypto/rand"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ed25519"
+	gossh "golang.org/x/crypto/ssh"
+)
+
+func TestCheckUserCertificate(t *testing.T) {
+	_, caKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca, err := gossh.NewSignerFromKey(caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	userKey, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub, err := gossh.NewPublicKey(userKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	newCert := func(certType uint32, principals []string, after, before time.Time) *gossh.Certificate {
+		cert := &gossh.Certificate{
+			Key:             pub,
+			CertType:        certType,
+			ValidPrincipals: principals,
+			ValidAfter:      uint64(after.Unix()),
+			ValidBefore:     uint64(before.Unix()),
+		}
+		if err := cert.SignCert(rand.Reader, ca); err != nil {
+			t.Fatal(err)
+		}
+		return cert
+	}
+
+	cases := []struct {
+		name string
+		cert *gossh.Certificate
+		err  string
+	}{
+		{"valid", newCert(gossh.UserCert, []string{"root", "ubuntu"}, now.Add(-time.Hour), now.Add(time.Hour)), ""},
+		{"no principals", newCert(gossh.UserCert, nil, now.Add(-time.Hour), now.Add(time.Hour)), ""},
+		{"other principal", newCert(gossh.UserCert, []string{"root"}, now.Add(-time.Hour), now.Add(time.Hour)), "not valid for user"},
+		{"host certificate", newCert(gossh.HostCert, []string{"ubuntu"}, now.Add(-time.Hour), now.Add(time.Hour)), "host certificate"},
+		{"expired", newCert(gossh.UserCert, []string{"ubuntu"}, now.Add(-2*time.Hour), now.Add(-time.Hour)), "expired"},
+		{"not valid yet", newCert(gossh.UserCert, []string{"ubuntu"}, now.Add(time.Hour), now.Add(2*time.Hour)), "not yet valid"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := CheckUserCertificate(tc.cert, "ubuntu")
+			if tc.err == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %s", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Fatalf("expected an error containing %q, got %v", tc.err, err)
+			}
+		})
+	}
+}
@@ -5,7 +5,6 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"log"
 	"net"
 	"os"
 	"strings"
@@ -35,6 +34,7 @@ type StepConnectSSH struct {
 
 func (s *StepConnectSSH) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
 	ui := state.Get("ui").(packer.Ui)
+	logger := multistep.GetLogger(state, "ssh")
 
 	var comm packer.Communicator
 	var err error
@@ -48,7 +48,7 @@ func (s *StepConnectSSH) Run(ctx context.Context, state multistep.StateBag) mult
 		waitDone <- true
 	}()
 
-	log.Printf("[INFO] Waiting for SSH, up to timeout: %s", s.Config.SSHTimeout)
+	logger.Infof("Waiting for SSH, up to timeout: %s", s.Config.SSHTimeout)
 	timeout := time.After(s.Config.SSHTimeout)
 	for {
 		// Wait for either SSH to become available, a timeout to occur,
@@ -74,7 +74,7 @@ func (s *StepConnectSSH) Run(ctx context.Context, state multistep.StateBag) mult
 			// The step sequence was cancelled, so cancel waiting for SSH
 			// and just start the halting process.
 			cancel()
-			log.Println("[WARN] Interrupt detected, quitting waiting for SSH.")
+			logger.Warnf("Interrupt detected, quitting waiting for SSH.")
 			return multistep.ActionHalt
 		case <-time.After(1 * time.Second):
 		}
@@ -85,6 +85,8 @@ func (s *StepConnectSSH) Cleanup(multistep.StateBag) {
 }
 
 func (s *StepConnectSSH) waitForSSH(state multistep.StateBag, ctx context.Context) (packer.Communicator, error) {
+	logger := multistep.GetLogger(state, "ssh")
+
 	// Determine if we're using a bastion host, and if so, retrieve
 	// that configuration. This configuration doesn't change so we
 	// do this one before entering the retry loop.
@@ -124,7 +126,7 @@ func (s *StepConnectSSH) waitForSSH(state multistep.StateBag, ctx context.Contex
 		if !first {
 			select {
 			case <-ctx.Done():
-				log.Println("[DEBUG] SSH wait cancelled. Exiting loop.")
+				logger.Debugf("SSH wait cancelled. Exiting loop.")
 				return nil, errors.New("SSH wait cancelled")
 			case <-time.After(5 * time.Second):
 			}
@@ -134,7 +136,7 @@ func (s *StepConnectSSH) waitForSSH(state multistep.StateBag, ctx context.Contex
 		// First we request the TCP connection information
 		host, err := s.Host(state)
 		if err != nil {
-			log.Printf("[DEBUG] Error getting SSH address: %s", err)
+			logger.Debugf("Error getting SSH address: %s", err)
 			continue
 		}
 		// store host and port in config so we can access them from provisioners
@@ -143,7 +145,7 @@ func (s *StepConnectSSH) waitForSSH(state multistep.StateBag, ctx context.Contex
 		if s.SSHPort != nil {
 			port, err = s.SSHPort(state)
 			if err != nil {
-				log.Printf("[DEBUG] Error getting SSH port: %s", err)
+				logger.Debugf("Error getting SSH port: %s", err)
 				continue
 			}
 			s.Config.SSHPort = port
@@ -153,7 +155,7 @@ func (s *StepConnectSSH) waitForSSH(state multistep.StateBag, ctx context.Contex
 		// Retrieve the SSH configuration
 		sshConfig, err := s.SSHConfig(state)
 		if err != nil {
-			log.Printf("[DEBUG] Error getting SSH config: %s", err)
+			logger.Debugf("Error getting SSH config: %s", err)
 			continue
 		}
 
@@ -174,7 +176,7 @@ func (s *StepConnectSSH) waitForSSH(state multistep.StateBag, ctx context.Contex
 
 		nc, err := connFunc()
 		if err != nil {
-			log.Printf("[DEBUG] TCP connection to SSH ip/port failed: %s", err)
+			logger.Debugf("TCP connection to SSH ip/port failed: %s", err)
 			continue
 		}
 		nc.Close()
@@ -206,21 +208,26 @@ func (s *StepConnectSSH) waitForSSH(state multistep.StateBag, ctx context.Contex
 			DisableAgentForwarding: s.Config.SSHDisableAgentForwarding,
 			UseSftp:                s.Config.SSHFileTransferMethod == "sftp",
 			KeepAliveInterval:      s.Config.SSHKeepAliveInterval,
+			KeepAliveCountMax:      s.Config.SSHKeepAliveCountMax,
 			Timeout:                s.Config.SSHReadWriteTimeout,
+			UploadConcurrency:      s.Config.SSHUploadConcurrency,
+			UploadBufferSize:       s.Config.SSHUploadBufferSize,
+			UploadCompression:      s.Config.SSHUploadCompression,
+			ReconnectTimeout:       s.Config.ReconnectTimeout,
+			PinHostKey:             s.Config.SSHPinHostKey,
 			Tunnels:                tunnels,
 		}
 
-		log.Printf("[INFO] Attempting SSH connection to %s...", address)
+		logger.Infof("Attempting SSH connection to %s...", address)
 		comm, err = ssh.New(address, config)
 		if err != nil {
-			log.Printf("[DEBUG] SSH handshake err: %s", err)
+			logger.Debugf("SSH handshake err: %s", err)
 
 			// Only count this as an attempt if we were able to attempt
 			// to authenticate. Note this is very brittle since it depends
 			// on the string of the error... but I don't see any other way.
 			if strings.Contains(err.Error(), "authenticate") {
-				log.Printf(
-					"[DEBUG] Detected authentication error. Increasing handshake attempts.")
+				logger.Debugf("Detected authentication error. Increasing handshake attempts.")
 				err = fmt.Errorf("Packer experienced an authentication error "+
 					"when trying to connect via SSH. This can happen if your "+
 					"username/password are wrong. You may want to double-check"+
@@ -146,6 +146,14 @@ func (s *StepConnectWinRM) waitForWinRM(state multistep.StateBag, ctx context.Co
 			s.Config.WinRMTransportDecorator = ProxyTransportDecorator
 		}
 
+		var clientCert, clientKey []byte
+		if s.Config.WinRMClientCertFile != "" {
+			clientCert, clientKey, err = s.Config.ReadWinRMClientCert()
+			if err != nil {
+				return nil, err
+			}
+		}
+
 		log.Println("[INFO] Attempting WinRM connection...")
 		comm, err = winrm.New(&winrm.Config{
 			Host:               host,
@@ -156,6 +164,9 @@ func (s *StepConnectWinRM) waitForWinRM(state multistep.StateBag, ctx context.Co
 			Https:              s.Config.WinRMUseSSL,
 			Insecure:           s.Config.WinRMInsecure,
 			TransportDecorator: s.Config.WinRMTransportDecorator,
+			ClientCert:         clientCert,
+			ClientKey:          clientKey,
+			ReconnectTimeout:   s.Config.ReconnectTimeout,
 		})
 		if err != nil {
 			log.Printf("[ERROR] WinRM connection err: %s", err)
@@ -0,0 +1,123 @@
+package communicator
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/hashicorp/packer/helper/communicator/ssh"
+	"github.com/hashicorp/packer/helper/communicator/sshkey"
+	"github.com/hashicorp/packer/packer"
+	"github.com/hashicorp/packer/packer-plugin-sdk/multistep"
+	"github.com/hashicorp/packer/packer-plugin-sdk/uuid"
+)
+
+// StepSSHKeyPair sets the SSH key pair of the communicator of builders that
+// create the machine themselves, like the virtualbox, qemu or hyperv
+// builders. When no ssh_password, ssh_private_key_file or ssh_agent_auth is
+// set, it generates an ephemeral key pair for the build; the builder then
+// injects Comm.SSHPublicKey in the machine, for example with the
+// SSHPublicKey variable of the boot command.
+type StepSSHKeyPair struct {
+	Debug        bool
+	DebugKeyPath string
+	Comm         *Config
+}
+
+func (s *StepSSHKeyPair) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	if s.Comm.Type != "ssh" || s.Comm.SSHPassword != "" {
+		return multistep.ActionContinue
+	}
+
+	ui := state.Get("ui").(packer.Ui)
+
+	comment := s.Comm.SSHTemporaryKeyPairName
+	if comment == "" {
+		comment = fmt.Sprintf("packer_%s", uuid.TimeOrderedUUID())
+	}
+
+	if s.Comm.SSHPrivateKeyFile != "" {
+		ui.Say("Using existing SSH private key for the communicator...")
+		privateKeyBytes, err := s.Comm.ReadSSHPrivateKeyFile()
+		if err != nil {
+			state.Put("error", err)
+			return multistep.ActionHalt
+		}
+
+		kp, err := ssh.KeyPairFromPrivateKey(ssh.FromPrivateKeyConfig{
+			RawPrivateKeyPemBlock: privateKeyBytes,
+			Comment:               comment,
+		})
+		if err != nil {
+			state.Put("error", err)
+			return multistep.ActionHalt
+		}
+
+		s.Comm.SSHPrivateKey = privateKeyBytes
+		s.Comm.SSHKeyPairName = kp.Comment
+		s.Comm.SSHTemporaryKeyPairName = kp.Comment
+		s.Comm.SSHPublicKey = kp.PublicKeyAuthorizedKeysLine
+
+		return multistep.ActionContinue
+	}
+
+	if s.Comm.SSHAgentAuth {
+		ui.Say("Using local SSH Agent to authenticate connections for the communicator...")
+		return multistep.ActionContinue
+	}
+
+	algorithm := s.Comm.SSHTemporaryKeyPairType
+	if algorithm == "" {
+		algorithm = sshkey.RSA.String()
+	}
+	a, err := sshkey.AlgorithmString(algorithm)
+	if err != nil {
+		err := fmt.Errorf("%w: possible algorithm types are `dsa` | `ecdsa` | `ed25519` | `rsa` ( the default )", err)
+		state.Put("error", err)
+		return multistep.ActionHalt
+	}
+
+	ui.Say(fmt.Sprintf("Creating ephemeral %s key pair for SSH communicator...", a.String()))
+	pair, err := sshkey.GeneratePair(a, nil, s.Comm.SSHTemporaryKeyPairBits)
+	if err != nil {
+		state.Put("error", fmt.Errorf("Error creating temporary keypair: %s", err))
+		return multistep.ActionHalt
+	}
+
+	// The public key is used in templates, like boot commands, where a
+	// trailing new line would be typed too.
+	publicKey := append(bytes.TrimSpace(pair.Public), ' ')
+	publicKey = append(publicKey, comment...)
+
+	s.Comm.SSHKeyPairName = comment
+	s.Comm.SSHTemporaryKeyPairName = comment
+	s.Comm.SSHPrivateKey = pair.Private
+	s.Comm.SSHPublicKey = publicKey
+	s.Comm.SSHClearAuthorizedKeys = true
+
+	ui.Say("Created ephemeral SSH key pair for communicator")
+
+	// If we're in debug mode, output the private key to the working
+	// directory.
+	if s.Debug {
+		ui.Message(fmt.Sprintf("Saving communicator private key for debug purposes: %s", s.DebugKeyPath))
+		if err := ioutil.WriteFile(s.DebugKeyPath, pair.Private, 0600); err != nil {
+			state.Put("error", fmt.Errorf("Error saving debug key: %s", err))
+			return multistep.ActionHalt
+		}
+	}
+
+	return multistep.ActionContinue
+}
+
+func (s *StepSSHKeyPair) Cleanup(state multistep.StateBag) {
+	if s.Debug && s.DebugKeyPath != "" {
+		if err := os.Remove(s.DebugKeyPath); err != nil && !os.IsNotExist(err) {
+			ui := state.Get("ui").(packer.Ui)
+			ui.Error(fmt.Sprintf(
+				"Error removing debug key '%s': %s", s.DebugKeyPath, err))
+		}
+	}
+}
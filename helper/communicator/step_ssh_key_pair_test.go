@@ -0,0 +1,65 @@
+package communicator
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/packer/packer-plugin-sdk/multistep"
+	gossh "golang.org/x/crypto/ssh"
+)
+
+func TestStepSSHKeyPair_impl(t *testing.T) {
+	var _ multistep.Step = new(StepSSHKeyPair)
+}
+
+func TestStepSSHKeyPair_ephemeral(t *testing.T) {
+	state := testState(t)
+
+	comm := &Config{
+		Type: "ssh",
+		SSH: SSH{
+			SSHTemporaryKeyPairName: "packer_test",
+			SSHTemporaryKeyPair: SSHTemporaryKeyPair{
+				SSHTemporaryKeyPairType: "ed25519",
+			},
+		},
+	}
+	step := &StepSSHKeyPair{Comm: comm}
+	defer step.Cleanup(state)
+
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("bad action: %#v", state.Get("error"))
+	}
+
+	publicKey := string(comm.SSHPublicKey)
+	if !strings.HasPrefix(publicKey, "ssh-ed25519 ") || !strings.HasSuffix(publicKey, " packer_test") {
+		t.Fatalf("bad public key: %q", publicKey)
+	}
+	if _, err := gossh.ParsePrivateKey(comm.SSHPrivateKey); err != nil {
+		t.Fatalf("bad private key: %s", err)
+	}
+	if !comm.SSHClearAuthorizedKeys {
+		t.Fatal("the authorized keys should be cleared")
+	}
+}
+
+func TestStepSSHKeyPair_password(t *testing.T) {
+	state := testState(t)
+
+	comm := &Config{
+		Type: "ssh",
+		SSH: SSH{
+			SSHPassword: "packer",
+		},
+	}
+	step := &StepSSHKeyPair{Comm: comm}
+	defer step.Cleanup(state)
+
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("bad action: %#v", state.Get("error"))
+	}
+	if comm.SSHPublicKey != nil || comm.SSHPrivateKey != nil {
+		t.Fatal("no key pair should be generated when a password is set")
+	}
+}
@@ -7,6 +7,8 @@ import (
 	"io"
 	"os"
 	"strings"
+
+	"github.com/hashicorp/packer/packer-plugin-sdk/logging"
 )
 
 // These are the environmental variables that determine if we log, and if
@@ -14,6 +16,11 @@ import (
 const EnvLog = "PACKER_LOG"          //Set to True
 const EnvLogFile = "PACKER_LOG_PATH" //Set to a file
 
+// These filter the log lines: the lowest level of the lines to keep, like
+// "warn", and the levels of components, like "hyperv=debug,ssh=error".
+const EnvLogLevel = "PACKER_LOG_LEVEL"
+const EnvLogFilter = "PACKER_LOG_FILTER"
+
 // logOutput determines where we should send logs (if anywhere).
 func logOutput() (logOutput io.Writer, err error) {
 	logOutput = nil
@@ -52,6 +59,14 @@ func logOutput() (logOutput io.Writer, err error) {
 		}
 	}
 
+	if logOutput != nil && (os.Getenv(EnvLogLevel) != "" || os.Getenv(EnvLogFilter) != "") {
+		filter, err := logging.ParseFilter(os.Getenv(EnvLogLevel), os.Getenv(EnvLogFilter))
+		if err != nil {
+			return nil, err
+		}
+		logOutput = filter.Writer(logOutput)
+	}
+
 	return
 }
 
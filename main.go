@@ -12,6 +12,7 @@ import (
 	"math/rand"
 	"os"
 	"runtime"
+	"strings"
 	"sync"
 	"syscall"
 	"time"
@@ -54,6 +55,14 @@ func realMain() int {
 	UUID, _ := uuid.GenerateUUID()
 	os.Setenv("PACKER_RUN_UUID", UUID)
 
+	// -log-level enables the logs, keeping the lines of this level and above
+	if _, level := extractLogLevel(os.Args[1:]); level != "" {
+		os.Setenv(EnvLogLevel, level)
+		if os.Getenv(EnvLog) == "" {
+			os.Setenv(EnvLog, "1")
+		}
+	}
+
 	// Determine where logs should go in general (requested by the user)
 	logWriter, err := logOutput()
 	if err != nil {
@@ -176,12 +185,25 @@ func wrappedMain() int {
 
 	// Determine if we're in machine-readable mode by mucking around with
 	// the arguments...
-	args, machineReadable := extractMachineReadable(os.Args[1:])
+	// -log-level was handled by realMain
+	args, _ := extractLogLevel(os.Args[1:])
+	args, machineReadable := extractMachineReadable(args)
+	args, jsonEvents := extractFlag(args, "-json-events")
 
 	defer plugin.CleanupClients()
 
 	var ui packer.Ui
-	if machineReadable {
+	if jsonEvents {
+		// Setup the UI to write events as JSON lines
+		ui = &packer.JSONEventsUi{
+			Writer: os.Stdout,
+		}
+
+		if err := os.Setenv("PACKER_NO_COLOR", "1"); err != nil {
+			fmt.Fprintf(os.Stderr, "Packer failed to initialize UI: %s\n", err)
+			return 1
+		}
+	} else if machineReadable {
 		// Setup the UI as we're being machine-readable
 		ui = &packer.MachineReadableUi{
 			Writer: os.Stdout,
@@ -228,10 +250,13 @@ func wrappedMain() int {
 				BuilderStore:       config.Builders,
 				ProvisionerStore:   config.Provisioners,
 				PostProcessorStore: config.PostProcessors,
+				DatasourceStore:    config.Datasources,
+				LoadPluginFolder:   config.discoverExternalComponents,
 			},
 			Version: version.Version,
 		},
-		Ui: ui,
+		Ui:               ui,
+		PluginRegistries: config.PluginRegistries,
 	}
 
 	cli := &cli.CLI{
@@ -284,8 +309,14 @@ func excludeHelpFunc(commands map[string]cli.CommandFactory, exclude []string) c
 // flag and returns whether or not it is on. It modifies the args
 // to remove this flag.
 func extractMachineReadable(args []string) ([]string, bool) {
+	return extractFlag(args, "-machine-readable")
+}
+
+// extractFlag checks the args for a boolean flag and returns whether or not
+// it is on. It modifies the args to remove this flag.
+func extractFlag(args []string, flag string) ([]string, bool) {
 	for i, arg := range args {
-		if arg == "-machine-readable" {
+		if arg == flag {
 			// We found it. Slice it out.
 			result := make([]string, len(args)-1)
 			copy(result, args[:i])
@@ -297,6 +328,31 @@ func extractMachineReadable(args []string) ([]string, bool) {
 	return args, false
 }
 
+// extractLogLevel checks the args for the -log-level flag and returns its
+// value, empty when it isn't set. It modifies the args to remove this flag.
+func extractLogLevel(args []string) ([]string, string) {
+	for i, arg := range args {
+		var level string
+		n := 1
+		switch {
+		case strings.HasPrefix(arg, "-log-level="):
+			level = strings.TrimPrefix(arg, "-log-level=")
+		case arg == "-log-level" && i+1 < len(args):
+			level = args[i+1]
+			n = 2
+		default:
+			continue
+		}
+
+		result := make([]string, 0, len(args)-n)
+		result = append(result, args[:i]...)
+		result = append(result, args[i+n:]...)
+		return result, level
+	}
+
+	return args, ""
+}
+
 func loadConfig() (*config, error) {
 	var config config
 	config.PluginMinPort = 10000
@@ -304,6 +360,7 @@ func loadConfig() (*config, error) {
 	config.Builders = packer.MapOfBuilder{}
 	config.PostProcessors = packer.MapOfPostProcessor{}
 	config.Provisioners = packer.MapOfProvisioner{}
+	config.Datasources = packer.MapOfDatasource{}
 	if err := config.Discover(); err != nil {
 		return nil, err
 	}
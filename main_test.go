@@ -62,6 +62,24 @@ func TestExtractMachineReadable(t *testing.T) {
 	}
 }
 
+func TestExtractLogLevel(t *testing.T) {
+	cases := []struct {
+		args     []string
+		expected []string
+		level    string
+	}{
+		{[]string{"build", "foo.json"}, []string{"build", "foo.json"}, ""},
+		{[]string{"build", "-log-level=debug", "foo.json"}, []string{"build", "foo.json"}, "debug"},
+		{[]string{"-log-level", "warn", "build", "foo.json"}, []string{"build", "foo.json"}, "warn"},
+	}
+	for _, tc := range cases {
+		result, level := extractLogLevel(tc.args)
+		if !reflect.DeepEqual(result, tc.expected) || level != tc.level {
+			t.Fatalf("%#v: bad: %#v %q", tc.args, result, level)
+		}
+	}
+}
+
 func TestRandom(t *testing.T) {
 	if rand.Intn(9999999) == 8498210 {
 		t.Fatal("math.rand is not seeded properly")
@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"regexp"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -72,19 +74,47 @@ func (s expressionSequence) Validate() (errs []error) {
 	return
 }
 
+// waitForRe matches the `<waitFor 'regexp'>` expressions, with an optional
+// timeout like `<waitFor10m 'regexp'>`. They are extracted before the rest of
+// the command is parsed.
+var waitForRe = regexp.MustCompile(`<waitFor((?:[0-9.]+(?:ns|us|µs|ms|s|m|h))+|[0-9]+)?\s+'(.*?)'>`)
+
 // GenerateExpressionSequence generates a sequence of expressions from the
 // given command. This is the primary entry point to the boot command parser.
 func GenerateExpressionSequence(command string) (expressionSequence, error) {
 	seq := expressionSequence{}
-	if command == "" {
-		return seq, nil
+	parse := func(command string) error {
+		if command == "" {
+			return nil
+		}
+		got, err := ParseReader("", strings.NewReader(command))
+		if err != nil {
+			return err
+		}
+		for _, exp := range got.([]interface{}) {
+			seq = append(seq, exp.(expression))
+		}
+		return nil
 	}
-	got, err := ParseReader("", strings.NewReader(command))
-	if err != nil {
-		return nil, err
+
+	last := 0
+	for _, m := range waitForRe.FindAllStringSubmatchIndex(command, -1) {
+		if err := parse(command[last:m[0]]); err != nil {
+			return nil, err
+		}
+		var timeout string
+		if m[2] != -1 {
+			timeout = command[m[2]:m[3]]
+		}
+		exp, err := newWaitForExpression(timeout, command[m[4]:m[5]])
+		if err != nil {
+			return nil, err
+		}
+		seq = append(seq, exp)
+		last = m[1]
 	}
-	for _, exp := range got.([]interface{}) {
-		seq = append(seq, exp.(expression))
+	if err := parse(command[last:]); err != nil {
+		return nil, err
 	}
 	return seq, nil
 }
@@ -118,6 +148,76 @@ func (w *waitExpression) String() string {
 	return fmt.Sprintf("Wait<%s>", w.d)
 }
 
+// defaultWaitForTimeout is how long `<waitFor 'regexp'>` waits for the text.
+const defaultWaitForTimeout = 5 * time.Minute
+
+// waitForInterval is the time between two reads of the screen.
+var waitForInterval = time.Second
+
+type waitForExpression struct {
+	re      *regexp.Regexp
+	timeout time.Duration
+}
+
+func newWaitForExpression(timeout, pattern string) (*waitForExpression, error) {
+	w := &waitForExpression{timeout: defaultWaitForTimeout}
+	if timeout != "" {
+		if seconds, err := strconv.ParseInt(timeout, 10, 64); err == nil {
+			w.timeout = time.Duration(seconds) * time.Second
+		} else if w.timeout, err = time.ParseDuration(timeout); err != nil {
+			return nil, fmt.Errorf("Invalid waitFor timeout %q: %s", timeout, err)
+		}
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid waitFor regular expression %q: %s", pattern, err)
+	}
+	w.re = re
+	return w, nil
+}
+
+// Do reads the screen until the text matches the regular expression, or
+// fails after the timeout. The driver must be able to read the screen.
+func (w *waitForExpression) Do(ctx context.Context, driver BCDriver) error {
+	driver.Flush()
+	screen, ok := driver.(ScreenReader)
+	if !ok {
+		return fmt.Errorf("Waiting for %q: this builder can't read the screen", w.re)
+	}
+
+	log.Printf("[INFO] Waiting %s for %q on the screen", w.timeout, w.re)
+	ctx, cancel := context.WithTimeout(ctx, w.timeout)
+	defer cancel()
+	for {
+		text, err := screen.ReadScreen(ctx)
+		if err != nil {
+			log.Printf("[WARN] Error reading the screen: %s", err)
+		} else if w.re.MatchString(text) {
+			return nil
+		}
+		select {
+		case <-time.After(waitForInterval):
+		case <-ctx.Done():
+			if ctx.Err() == context.DeadlineExceeded {
+				return fmt.Errorf("Timeout waiting %s for %q on the screen", w.timeout, w.re)
+			}
+			return ctx.Err()
+		}
+	}
+}
+
+// Validate returns an error if the timeout is <= 0
+func (w *waitForExpression) Validate() error {
+	if w.timeout <= 0 {
+		return fmt.Errorf("Expecting a positive waitFor timeout. Got %s", w.timeout)
+	}
+	return nil
+}
+
+func (w *waitForExpression) String() string {
+	return fmt.Sprintf("WaitFor<%s %q>", w.timeout, w.re)
+}
+
 type specialExpression struct {
 	s      string
 	action KeyAction
@@ -1,9 +1,11 @@
 package bootcommand
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -132,3 +134,64 @@ func Test_empty(t *testing.T) {
 	assert.NoError(t, err, "should have parsed an empty input okay.")
 	assert.Len(t, exp, 0)
 }
+
+func Test_waitFor(t *testing.T) {
+	in := "<esc><waitFor 'boot:'>linux<enter><waitFor10m 'Press (Enter|any key)'><wait5><waitFor90 'x'>"
+	expected := []string{
+		"Spec-Press(esc)",
+		`WaitFor<5m0s "boot:">`,
+		"LIT-Press(l)",
+		"LIT-Press(i)",
+		"LIT-Press(n)",
+		"LIT-Press(u)",
+		"LIT-Press(x)",
+		"Spec-Press(enter)",
+		`WaitFor<10m0s "Press (Enter|any key)">`,
+		"Wait<5s>",
+		`WaitFor<1m30s "x">`,
+	}
+
+	seq, err := GenerateExpressionSequence(in)
+	assert.NoError(t, err)
+	assert.Len(t, seq, len(expected))
+	for i, exp := range seq {
+		assert.Equal(t, expected[i], fmt.Sprintf("%s", exp))
+	}
+
+	_, err = GenerateExpressionSequence("<waitFor '(boot'>")
+	assert.Error(t, err, "should not accept an invalid regular expression")
+}
+
+type screenTestDriver struct {
+	BCDriver
+	screens []string
+}
+
+func (d *screenTestDriver) ReadScreen(context.Context) (string, error) {
+	screen := d.screens[0]
+	if len(d.screens) > 1 {
+		d.screens = d.screens[1:]
+	}
+	return screen, nil
+}
+
+func Test_waitForDo(t *testing.T) {
+	defer func(interval time.Duration) { waitForInterval = interval }(waitForInterval)
+	waitForInterval = time.Millisecond
+
+	exp, err := newWaitForExpression("", "boot:")
+	assert.NoError(t, err)
+
+	driver := &screenTestDriver{
+		BCDriver: NewPCXTDriver(func([]string) error { return nil }, -1, time.Duration(0)),
+		screens:  []string{"", "Loading", "ISOLINUX\nboot: "},
+	}
+	assert.NoError(t, exp.Do(context.Background(), driver))
+	assert.Len(t, driver.screens, 1)
+
+	exp, err = newWaitForExpression("10ms", "login:")
+	assert.NoError(t, err)
+	assert.Error(t, exp.Do(context.Background(), driver), "should time out")
+
+	assert.Error(t, exp.Do(context.Background(), driver.BCDriver), "should not read the screen of a keyboard driver")
+}
@@ -73,6 +73,15 @@ const PackerKeyDefault = 100 * time.Millisecond
 //     Valid time units are `ns`, `us` (or `µs`), `ms`, `s`, `m`, `h`. For
 //     example `<wait10m>` or `<wait1m20s>`.
 //
+// -   `<waitFor 'regexp'>` - Waits until the text on the screen of the VM
+//     matches the regular expression before sending any additional keys, for
+//     example `<waitFor 'boot:'>`, so that the boot command follows the
+//     installer instead of waiting arbitrary times. It waits 5 minutes at
+//     most by default, `<waitForXX 'regexp'>` waits `XX` like `<waitXX>`, for
+//     example `<waitFor10m 'Login:'>`. Only builders able to read the screen
+//     support it, see `boot_screen_ocr` and `boot_screen_serial` of the QEMU
+//     builder.
+//
 // -   `<XXXOn> <XXXOff>` - Any printable keyboard character, and of these
 //      "special" expressions, with the exception of the `<wait>` types, can
 //      also be toggled on or off. For example, to simulate ctrl+c, use
@@ -85,6 +94,10 @@ const PackerKeyDefault = 100 * time.Millisecond
 //     `http_directory` configuration parameter. If `http_directory` isn't
 //     specified, these will be blank!
 //
+// -   `{{ .HTTPToken }}` - The token the HTTP server serves the files under
+//     when `http_auth_token` is set, like
+//     `http://{{ .HTTPIP }}:{{ .HTTPPort }}/{{ .HTTPToken }}/preseed.cfg`.
+//
 // -   `{{ .Name }}` - The name of the VM.
 //
 // Example boot command. This is actually a working boot command used to start an
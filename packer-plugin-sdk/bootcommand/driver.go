@@ -1,5 +1,7 @@
 package bootcommand
 
+import "context"
+
 const shiftedChars = "~!@#$%^&*()_+{}|:\"<>?"
 
 // BCDriver is our access to the VM we want to type boot commands to
@@ -9,3 +11,20 @@ type BCDriver interface {
 	// Flush will be called when we want to send scancodes to the VM.
 	Flush() error
 }
+
+// ScreenReader reads the text on the screen of the VM, so that boot commands
+// can wait for installer prompts with `<waitFor 'regexp'>`.
+type ScreenReader interface {
+	ReadScreen(ctx context.Context) (string, error)
+}
+
+type screenDriver struct {
+	BCDriver
+	ScreenReader
+}
+
+// WithScreen returns a driver typing with driver and reading the screen with
+// screen.
+func WithScreen(driver BCDriver, screen ScreenReader) BCDriver {
+	return &screenDriver{driver, screen}
+}
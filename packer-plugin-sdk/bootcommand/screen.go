@@ -0,0 +1,196 @@
+package bootcommand
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"sync"
+
+	"github.com/mitchellh/go-vnc"
+)
+
+// serialScreenSize is how much of the end of a serial console makes its
+// screen, about 80 columns by 25 lines.
+const serialScreenSize = 80 * 25
+
+// SerialScreen reads the screen of a serial console written to a file, the
+// screen being the end of the output.
+type SerialScreen struct {
+	Path string
+}
+
+func (s *SerialScreen) ReadScreen(ctx context.Context) (string, error) {
+	f, err := os.Open(s.Path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return "", err
+	}
+	if offset := info.Size() - serialScreenSize; offset > 0 {
+		if _, err := f.Seek(offset, io.SeekStart); err != nil {
+			return "", err
+		}
+	}
+	b, err := ioutil.ReadAll(f)
+	return string(b), err
+}
+
+// OCRScreen reads the text of screenshots of the screen with tesseract.
+type OCRScreen struct {
+	Screenshot func(ctx context.Context) (image.Image, error)
+	// The tesseract command, tesseract from the PATH by default.
+	Tesseract string
+}
+
+func (s *OCRScreen) ReadScreen(ctx context.Context) (string, error) {
+	img, err := s.Screenshot(ctx)
+	if err != nil {
+		return "", fmt.Errorf("Error taking a screenshot: %s", err)
+	}
+
+	f, err := ioutil.TempFile("", "packer-screen-*.png")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(f.Name())
+	err = png.Encode(f, img)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", err
+	}
+
+	tesseract := s.Tesseract
+	if tesseract == "" {
+		tesseract = "tesseract"
+	}
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, tesseract, f.Name(), "stdout")
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("Error running %s: %s: %s", tesseract, err, stderr.String())
+	}
+	return stdout.String(), nil
+}
+
+// VNCScreen takes screenshots of a VNC desktop. It reads the messages of the
+// client, which must be created with Messages as its ServerMessageCh.
+type VNCScreen struct {
+	Messages chan vnc.ServerMessage
+
+	c       *vnc.ClientConn
+	lock    sync.Mutex
+	fb      *image.RGBA
+	updated chan struct{}
+	done    chan struct{}
+}
+
+// NewVNCScreen returns a screen to start with the client reading its
+// messages.
+func NewVNCScreen() *VNCScreen {
+	return &VNCScreen{
+		Messages: make(chan vnc.ServerMessage, 16),
+		updated:  make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// Start takes screenshots of the desktop of c, which must send its messages
+// to s.Messages.
+func (s *VNCScreen) Start(c *vnc.ClientConn) {
+	s.c = c
+	s.fb = image.NewRGBA(image.Rect(0, 0, int(c.FrameBufferWidth), int(c.FrameBufferHeight)))
+	go func() {
+		for {
+			var msg vnc.ServerMessage
+			select {
+			case msg = <-s.Messages:
+			case <-s.done:
+				return
+			}
+			update, ok := msg.(*vnc.FramebufferUpdateMessage)
+			if !ok {
+				continue
+			}
+			s.lock.Lock()
+			for _, rect := range update.Rectangles {
+				s.draw(rect)
+			}
+			close(s.updated)
+			s.updated = make(chan struct{})
+			s.lock.Unlock()
+		}
+	}()
+}
+
+// Stop stops reading the messages of the client.
+func (s *VNCScreen) Stop() {
+	close(s.done)
+}
+
+func (s *VNCScreen) draw(rect vnc.Rectangle) {
+	raw, ok := rect.Enc.(*vnc.RawEncoding)
+	if !ok {
+		return
+	}
+	format := s.c.PixelFormat
+	scale := func(v, max uint16) uint8 {
+		if !format.TrueColor {
+			// Colors of the color map are 16 bits
+			return uint8(v >> 8)
+		}
+		if max == 0 {
+			return 0
+		}
+		return uint8(uint32(v) * 255 / uint32(max))
+	}
+	for y := 0; y < int(rect.Height); y++ {
+		for x := 0; x < int(rect.Width); x++ {
+			c := raw.Colors[y*int(rect.Width)+x]
+			s.fb.SetRGBA(int(rect.X)+x, int(rect.Y)+y, color.RGBA{
+				R: scale(c.R, format.RedMax),
+				G: scale(c.G, format.GreenMax),
+				B: scale(c.B, format.BlueMax),
+				A: 0xff,
+			})
+		}
+	}
+}
+
+// Screenshot requests the whole desktop and returns it once it's updated.
+func (s *VNCScreen) Screenshot(ctx context.Context) (image.Image, error) {
+	if s.c == nil {
+		return nil, fmt.Errorf("the VNC screen isn't started")
+	}
+	s.lock.Lock()
+	updated := s.updated
+	s.lock.Unlock()
+
+	if err := s.c.FramebufferUpdateRequest(false, 0, 0, s.c.FrameBufferWidth, s.c.FrameBufferHeight); err != nil {
+		return nil, err
+	}
+	select {
+	case <-updated:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	screenshot := image.NewRGBA(s.fb.Rect)
+	copy(screenshot.Pix, s.fb.Pix)
+	return screenshot, nil
+}
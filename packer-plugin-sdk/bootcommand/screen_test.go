@@ -0,0 +1,27 @@
+package bootcommand
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestSerialScreen(t *testing.T) {
+	f, err := ioutil.TempFile("", "packer-serial")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer os.Remove(f.Name())
+	f.WriteString(strings.Repeat("x", 4096) + "\nboot: ")
+	f.Close()
+
+	screen, err := (&SerialScreen{Path: f.Name()}).ReadScreen(context.Background())
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if len(screen) != serialScreenSize || !strings.HasSuffix(screen, "\nboot: ") {
+		t.Fatalf("bad screen: %q", screen)
+	}
+}
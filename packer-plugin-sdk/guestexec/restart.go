@@ -0,0 +1,77 @@
+package guestexec
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/packer/packer"
+	"github.com/hashicorp/packer/packer-plugin-sdk/retry"
+	"github.com/masterzen/winrm"
+)
+
+const windowsRestartCommand = `shutdown /r /f /t 0 /c "packer restart"`
+
+var windowsBootTimeCommand = winrm.Powershell(`(Get-CimInstance Win32_OperatingSystem).LastBootUpTime.ToFileTimeUtc()`)
+
+// WindowsRestartRetryDelay is how long RestartWindows waits between two
+// attempts to reach the machine after restarting it.
+var WindowsRestartRetryDelay = 10 * time.Second
+
+// RestartWindows restarts a Windows machine and waits up to timeout until
+// it booted again. The communicator may reconnect before the machine goes
+// down, so RestartWindows waits for the boot time of the machine to change.
+func RestartWindows(ctx context.Context, comm packer.Communicator, timeout time.Duration) error {
+	bootTime, err := windowsBootTime(ctx, comm)
+	if err != nil {
+		return err
+	}
+
+	if err := packer.ExpectDisconnect(comm); err != nil {
+		return err
+	}
+	cmd := &packer.RemoteCmd{Command: windowsRestartCommand}
+	if err := comm.Start(ctx, cmd); err != nil {
+		return fmt.Errorf("Error restarting the machine: %s", err)
+	}
+	cmd.Wait()
+
+	err = retry.Config{
+		StartTimeout: timeout,
+		RetryDelay:   func() time.Duration { return WindowsRestartRetryDelay },
+	}.Run(ctx, func(ctx context.Context) error {
+		if err := packer.WaitForReconnect(ctx, comm); err != nil {
+			return err
+		}
+		t, err := windowsBootTime(ctx, comm)
+		if err != nil {
+			return err
+		}
+		if t == bootTime {
+			return fmt.Errorf("the machine didn't restart yet")
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("Timeout waiting for the machine to restart: %s", err)
+	}
+	return nil
+}
+
+// windowsBootTime returns when the machine booted.
+func windowsBootTime(ctx context.Context, comm packer.Communicator) (string, error) {
+	var stdout bytes.Buffer
+	cmd := &packer.RemoteCmd{Command: windowsBootTimeCommand, Stdout: &stdout}
+	if err := comm.Start(ctx, cmd); err != nil {
+		return "", err
+	}
+	if status := cmd.Wait(); status != 0 {
+		return "", fmt.Errorf("can't get the boot time of the machine: exit status %d", status)
+	}
+	t := strings.TrimSpace(stdout.String())
+	log.Printf("[DEBUG] boot time of the machine: %s", t)
+	return t, nil
+}
@@ -0,0 +1,153 @@
+package logging
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Level is the severity of a log line.
+type Level int
+
+const (
+	LevelTrace Level = iota
+	LevelDebug
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+var levelNames = map[Level]string{
+	LevelTrace: "TRACE",
+	LevelDebug: "DEBUG",
+	LevelInfo:  "INFO",
+	LevelWarn:  "WARN",
+	LevelError: "ERROR",
+}
+
+func (l Level) String() string {
+	return levelNames[l]
+}
+
+// ParseLevel parses the name of a level, like "debug" or "WARN".
+func ParseLevel(s string) (Level, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	if s == "ERR" {
+		return LevelError, nil
+	}
+	for level, name := range levelNames {
+		if name == s {
+			return level, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown log level %q, expected one of trace, debug, info, warn or error", s)
+}
+
+// lineRe matches the level of a log line and its component, if any. It isn't
+// anchored: the lines of the plugins are prefixed by the core, and the ones
+// logged with the standard flags by a timestamp.
+var lineRe = regexp.MustCompile(`\[(TRACE|DEBUG|INFO|WARN|ERR|ERROR)\](?: ([A-Za-z0-9_.\-]+):)?`)
+
+// Filter tells the log lines to keep, by their level and their component.
+// The lines that don't tell their level, written with log.Printf without a
+// Logger, are considered INFO lines.
+type Filter struct {
+	// Level is the lowest level of the lines kept, of the components that
+	// have no level of their own.
+	Level Level
+	// Components are the lowest levels of the lines of components, and of
+	// their sub-components: "hyperv" is also the level of "hyperv.driver",
+	// unless it has its own.
+	Components map[string]Level
+}
+
+// ParseFilter parses a level, like "warn", and the levels of components,
+// like "hyperv=debug,ssh=error". An empty level keeps every line.
+func ParseFilter(level, components string) (*Filter, error) {
+	f := &Filter{Level: LevelTrace, Components: map[string]Level{}}
+	if level != "" {
+		l, err := ParseLevel(level)
+		if err != nil {
+			return nil, err
+		}
+		f.Level = l
+	}
+	for _, entry := range strings.Split(components, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		parts := strings.SplitN(entry, "=", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+			return nil, fmt.Errorf("bad log filter %q, expected component=level", entry)
+		}
+		l, err := ParseLevel(parts[1])
+		if err != nil {
+			return nil, err
+		}
+		f.Components[strings.TrimSpace(parts[0])] = l
+	}
+	return f, nil
+}
+
+// Allow tells whether line is kept.
+func (f *Filter) Allow(line string) bool {
+	level, component := LevelInfo, ""
+	if m := lineRe.FindStringSubmatch(line); m != nil {
+		level, _ = ParseLevel(m[1])
+		component = m[2]
+	}
+	return level >= f.componentLevel(component)
+}
+
+func (f *Filter) componentLevel(component string) Level {
+	for component != "" {
+		if level, ok := f.Components[component]; ok {
+			return level
+		}
+		i := strings.LastIndex(component, ".")
+		if i == -1 {
+			break
+		}
+		component = component[:i]
+	}
+	return f.Level
+}
+
+// Writer returns a writer writing the lines that f allows to w. Lines are
+// written once complete.
+func (f *Filter) Writer(w io.Writer) io.Writer {
+	return &filterWriter{w: w, f: f}
+}
+
+type filterWriter struct {
+	w io.Writer
+	f *Filter
+
+	l   sync.Mutex
+	buf []byte
+}
+
+func (w *filterWriter) Write(p []byte) (int, error) {
+	w.l.Lock()
+	defer w.l.Unlock()
+
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i == -1 {
+			break
+		}
+		line := w.buf[:i+1]
+		if w.f.Allow(string(line)) {
+			if _, err := w.w.Write(line); err != nil {
+				return 0, err
+			}
+		}
+		w.buf = w.buf[i+1:]
+	}
+	return len(p), nil
+}
@@ -0,0 +1,61 @@
+package logging
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestFilter(t *testing.T) {
+	f, err := ParseFilter("warn", "hyperv=debug, hyperv.powershell=error")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	cases := []struct {
+		line  string
+		allow bool
+	}{
+		{"[DEBUG] hyperv: Shutting down build=hyperv-iso.vm", true},
+		{"2021/01/18 10:21:03 packer-builder-hyperv-iso plugin: [DEBUG] hyperv.driver: Running Stop-VM", true},
+		{"[TRACE] hyperv.driver: Stop-VM output", false},
+		{"[INFO] hyperv.powershell: Run: powershell.exe", false},
+		{"[ERR] hyperv.powershell: Exited with 1", true},
+		{"[DEBUG] ssh: handshaking with SSH", false},
+		{"[WARN] ssh: Host key changed", true},
+		{"[DEBUG] Upload dir", false},
+		{"Enter method: verifyPSVersion", false},
+		{"[ERROR] reconnection error: EOF", true},
+	}
+	for _, tc := range cases {
+		if allow := f.Allow(tc.line); allow != tc.allow {
+			t.Errorf("%q: expected %t", tc.line, tc.allow)
+		}
+	}
+}
+
+func TestParseFilter_bad(t *testing.T) {
+	if _, err := ParseFilter("verbose", ""); err == nil {
+		t.Fatal("should fail on an unknown level")
+	}
+	if _, err := ParseFilter("", "hyperv"); err == nil {
+		t.Fatal("should fail without a level")
+	}
+}
+
+func TestFilter_Writer(t *testing.T) {
+	f, err := ParseFilter("info", "")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	buf := new(bytes.Buffer)
+	w := f.Writer(buf)
+
+	w.Write([]byte("[DEBUG] ssh: handshaking\n[INFO] ssh: Connec"))
+	if buf.String() != "" {
+		t.Fatalf("bad: %s", buf)
+	}
+	w.Write([]byte("ted\n"))
+	if buf.String() != "[INFO] ssh: Connected\n" {
+		t.Fatalf("bad: %s", buf)
+	}
+}
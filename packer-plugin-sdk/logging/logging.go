@@ -0,0 +1,63 @@
+// Package logging writes leveled log lines telling the component, the build
+// and the step they come from, and filters the log lines of Packer and its
+// plugins by level and by component.
+//
+// The lines are written with the standard log package, so that they go where
+// the other logs of Packer go, and look like:
+//
+//	[DEBUG] hyperv.driver: Running Stop-VM build=hyperv-iso.vm step=StepShutdown
+package logging
+
+import (
+	"fmt"
+	"log"
+	"strings"
+)
+
+// Logger writes the log lines of a component. Its methods take a format like
+// log.Printf, and it is safe to use from multiple goroutines.
+type Logger struct {
+	component string
+	fields    []string
+}
+
+// New returns a Logger for component, like "hyperv.driver" or "ssh".
+func New(component string) *Logger {
+	return &Logger{component: component}
+}
+
+// Named returns a copy of the logger for component, keeping its fields.
+func (l *Logger) Named(component string) *Logger {
+	return &Logger{component: component, fields: l.fields}
+}
+
+// With returns a copy of the logger adding key=value to its lines, like the
+// build or the step that runs.
+func (l *Logger) With(key, value string) *Logger {
+	fields := make([]string, len(l.fields), len(l.fields)+1)
+	copy(fields, l.fields)
+	return &Logger{
+		component: l.component,
+		fields:    append(fields, key+"="+value),
+	}
+}
+
+func (l *Logger) Tracef(format string, args ...interface{}) { l.output(LevelTrace, format, args) }
+func (l *Logger) Debugf(format string, args ...interface{}) { l.output(LevelDebug, format, args) }
+func (l *Logger) Infof(format string, args ...interface{})  { l.output(LevelInfo, format, args) }
+func (l *Logger) Warnf(format string, args ...interface{})  { l.output(LevelWarn, format, args) }
+func (l *Logger) Errorf(format string, args ...interface{}) { l.output(LevelError, format, args) }
+
+func (l *Logger) output(level Level, format string, args []interface{}) {
+	var line strings.Builder
+	fmt.Fprintf(&line, "[%s] ", level)
+	if l.component != "" {
+		line.WriteString(l.component + ": ")
+	}
+	fmt.Fprintf(&line, format, args...)
+	for _, field := range l.fields {
+		line.WriteString(" " + field)
+	}
+	// 3 skips output and the method calling it
+	_ = log.Output(3, line.String())
+}
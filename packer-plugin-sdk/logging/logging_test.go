@@ -0,0 +1,28 @@
+package logging
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"testing"
+)
+
+func TestLogger(t *testing.T) {
+	buf := new(bytes.Buffer)
+	log.SetOutput(buf)
+	log.SetFlags(0)
+	defer func() {
+		log.SetOutput(os.Stderr)
+		log.SetFlags(log.LstdFlags)
+	}()
+
+	logger := New("hyperv").With("build", "hyperv-iso.vm")
+	logger.Named("hyperv.driver").With("step", "StepShutdown").Debugf("Running %s", "Stop-VM")
+	logger.Warnf("No heartbeat")
+
+	expected := "[DEBUG] hyperv.driver: Running Stop-VM build=hyperv-iso.vm step=StepShutdown\n" +
+		"[WARN] hyperv: No heartbeat build=hyperv-iso.vm\n"
+	if buf.String() != expected {
+		t.Fatalf("bad: %s", buf)
+	}
+}
@@ -0,0 +1,107 @@
+package multistep
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// This is the key set in the state bag to the resources that couldn't be
+// destroyed by the cleanup of the steps, see AddOrphanedResource.
+const StateOrphanedResources = "orphaned_resources"
+
+// orphansLock guards the list of orphaned resources, cleanups that timed out
+// can still be running while the next ones run.
+var orphansLock sync.Mutex
+
+// AddOrphanedResource records a resource that the cleanup of a step couldn't
+// destroy, like "security group sg-1234", so that it's reported to the user at
+// the end of the build.
+func AddOrphanedResource(state StateBag, resource string) {
+	orphansLock.Lock()
+	defer orphansLock.Unlock()
+
+	orphans, _ := state.GetOk(StateOrphanedResources)
+	resources, _ := orphans.([]string)
+	state.Put(StateOrphanedResources, append(resources[:len(resources):len(resources)], resource))
+}
+
+// OrphanedResources returns the resources recorded with AddOrphanedResource.
+func OrphanedResources(state StateBag) []string {
+	orphansLock.Lock()
+	defer orphansLock.Unlock()
+
+	orphans, _ := state.GetOk(StateOrphanedResources)
+	resources, _ := orphans.([]string)
+	return resources
+}
+
+// ContextCleanupStep is a Step whose cleanup waits on something, like on a
+// resource being destroyed. CleanupContext is called instead of Cleanup, with
+// a context that is done once the cleanup deadline of the step is exceeded.
+// The step should stop waiting then, and record what it couldn't destroy with
+// AddOrphanedResource.
+type ContextCleanupStep interface {
+	Step
+
+	CleanupContext(context.Context, StateBag)
+}
+
+// CleanupStep cleans up step with ctx if it is a ContextCleanupStep, or with
+// its Cleanup method otherwise. Steps wrapping another step call it to pass
+// the context of their cleanup on.
+func CleanupStep(ctx context.Context, step Step, state StateBag) {
+	if s, ok := step.(ContextCleanupStep); ok {
+		s.CleanupContext(ctx, state)
+		return
+	}
+	step.Cleanup(state)
+}
+
+// StepCleanupTimeout returns a StepMiddleware that stops waiting for the
+// cleanup of each step once it has been running for timeout, so that an
+// interrupted build can't hang forever in a cleanup. The context passed to
+// the CleanupContext method of the step is cancelled at that point, and the
+// step is recorded as an orphaned resource. A timeout of 0 means no deadline.
+func StepCleanupTimeout(timeout time.Duration) StepMiddleware {
+	return func(step Step) Step {
+		if timeout <= 0 {
+			return step
+		}
+		return &cleanupTimeoutStep{step: step, timeout: timeout}
+	}
+}
+
+type cleanupTimeoutStep struct {
+	step    Step
+	timeout time.Duration
+}
+
+func (s *cleanupTimeoutStep) InnerStepName() string {
+	return StepName(s.step)
+}
+
+func (s *cleanupTimeoutStep) Run(ctx context.Context, state StateBag) StepAction {
+	return s.step.Run(ctx, state)
+}
+
+func (s *cleanupTimeoutStep) Cleanup(state StateBag) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	doneCh := make(chan struct{})
+	go func() {
+		defer close(doneCh)
+		CleanupStep(ctx, s.step, state)
+	}()
+
+	select {
+	case <-doneCh:
+	case <-ctx.Done():
+		name := s.InnerStepName()
+		log.Printf("Cleanup of step %q didn't complete in %s, not waiting for it", name, s.timeout)
+		AddOrphanedResource(state, fmt.Sprintf("resources of step %q (cleanup timed out after %s)", name, s.timeout))
+	}
+}
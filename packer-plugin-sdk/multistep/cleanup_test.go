@@ -0,0 +1,77 @@
+package multistep
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// contextCleanupStep is a TestStepFn whose cleanup is given a context.
+type contextCleanupStep struct {
+	TestStepFn
+	cleanupContext func(context.Context, StateBag)
+}
+
+func (s contextCleanupStep) CleanupContext(ctx context.Context, state StateBag) {
+	s.cleanupContext(ctx, state)
+}
+
+func TestStepCleanupTimeout(t *testing.T) {
+	data := new(BasicStateBag)
+	run := func(context.Context, StateBag) StepAction { return ActionContinue }
+	released := make(chan struct{})
+	defer close(released)
+	stopped := make(chan struct{})
+
+	var cleanedUp []string
+	steps := WrapSteps([]Step{
+		TestStepFn{
+			run: run,
+			cleanup: func(state StateBag) {
+				cleanedUp = append(cleanedUp, "first")
+			},
+		},
+		contextCleanupStep{
+			TestStepFn: TestStepFn{run: run},
+			cleanupContext: func(ctx context.Context, state StateBag) {
+				// Waits for its context, until the deadline
+				<-ctx.Done()
+				close(stopped)
+			},
+		},
+		TestStepFn{
+			run: run,
+			cleanup: func(state StateBag) {
+				// Hangs
+				<-released
+			},
+		},
+	}, StepCleanupTimeout(10*time.Millisecond), StepHooks{}.Middleware)
+
+	r := &BasicRunner{Steps: steps}
+	r.Run(context.Background(), data)
+
+	if !reflect.DeepEqual(cleanedUp, []string{"first"}) {
+		t.Fatalf("bad: %#v", cleanedUp)
+	}
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("the context of the cleanup should be done at the deadline")
+	}
+	expected := []string{
+		`resources of step "TestStepFn" (cleanup timed out after 10ms)`,
+		`resources of step "contextCleanupStep" (cleanup timed out after 10ms)`,
+	}
+	if orphans := OrphanedResources(data); !reflect.DeepEqual(orphans, expected) {
+		t.Fatalf("bad: %#v", orphans)
+	}
+}
+
+func TestStepCleanupTimeout_none(t *testing.T) {
+	step := &TestStepAcc{Data: "a"}
+	if wrapped := StepCleanupTimeout(0)(step); wrapped != step {
+		t.Fatalf("the step should not be wrapped: %#v", wrapped)
+	}
+}
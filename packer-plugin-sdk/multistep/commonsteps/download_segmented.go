@@ -0,0 +1,405 @@
+package commonsteps
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-cleanhttp"
+	getter "github.com/hashicorp/go-getter/v2"
+)
+
+// errRangesNotSupported is returned by a segmented download when none of the
+// mirrors serves byte ranges, in which case the file is downloaded in one go.
+var errRangesNotSupported = errors.New("the mirrors don't support byte ranges")
+
+// downloadSegment is a byte range of a segmented download. End is inclusive
+// and Done is the number of bytes of the range already written.
+type downloadSegment struct {
+	Start int64 `json:"start"`
+	End   int64 `json:"end"`
+	Done  int64 `json:"done"`
+}
+
+func (s *downloadSegment) size() int64 { return s.End - s.Start + 1 }
+
+// segmentedDownload downloads a file over HTTP as parallel byte ranges,
+// spreading the ranges over the mirrors and moving a range to the next mirror
+// when one fails. The progress of the ranges is saved next to the file, so
+// that an interrupted download resumes where it stopped. The checksum is
+// computed while the beginning of the file is complete, so that only the end
+// of the file remains to be hashed when all the ranges are written.
+type segmentedDownload struct {
+	Mirrors  []string
+	Segments int
+	// Checksum is nil when the download isn't verified.
+	Checksum *getter.FileChecksum
+	Dst      string
+	Progress getter.ProgressTracker
+
+	client *http.Client
+
+	lock     sync.Mutex
+	size     int64
+	segments []*downloadSegment
+	written  chan struct{}
+}
+
+// SegmentsPath is where the progress of the ranges is saved.
+func (d *segmentedDownload) SegmentsPath() string { return d.Dst + ".segments" }
+
+// PartPath is where the ranges are written until the download is complete.
+func (d *segmentedDownload) PartPath() string { return d.Dst + ".part" }
+
+func (d *segmentedDownload) Run(ctx context.Context) error {
+	if d.client == nil {
+		d.client = cleanhttp.DefaultClient()
+	}
+	d.written = make(chan struct{}, 1)
+
+	size, err := d.probeSize(ctx)
+	if err != nil {
+		return err
+	}
+	d.size = size
+	d.segments = d.loadSegments(size)
+
+	f, err := os.OpenFile(d.PartPath(), os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var hashErr chan error
+	if d.Checksum != nil {
+		hashErr = make(chan error, 1)
+		go func() { hashErr <- d.hash(ctx, f, d.Checksum.Hash) }()
+	}
+
+	saveDone := make(chan struct{})
+	go func() {
+		defer close(saveDone)
+		ticker := time.NewTicker(2 * time.Second)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				d.saveSegments()
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	errs := make([]error, len(d.segments))
+	for i, segment := range d.segments {
+		wg.Add(1)
+		go func(i int, segment *downloadSegment) {
+			defer wg.Done()
+			errs[i] = d.fetchSegment(ctx, f, i, segment)
+			if errs[i] != nil {
+				// There is no point in downloading the other ranges.
+				cancel()
+			}
+		}(i, segment)
+	}
+	wg.Wait()
+	cancel()
+	<-saveDone
+	d.saveSegments()
+
+	for _, err := range errs {
+		if err != nil && err != context.Canceled {
+			return err
+		}
+	}
+	if err := ctx.Err(); err != nil && !d.complete() {
+		return err
+	}
+
+	if hashErr != nil {
+		// The context is cancelled, the hash finishes with what is written,
+		// which is everything.
+		if err := <-hashErr; err != nil {
+			return err
+		}
+		if actual := d.Checksum.Hash.Sum(nil); !bytes.Equal(actual, d.Checksum.Value) {
+			return &getter.ChecksumError{
+				Hash:     d.Checksum.Hash,
+				Actual:   actual,
+				Expected: d.Checksum.Value,
+				File:     d.Dst,
+			}
+		}
+	}
+
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(d.PartPath(), d.Dst); err != nil {
+		return err
+	}
+	return os.Remove(d.SegmentsPath())
+}
+
+// probeSize requests the first byte of the file from the mirrors, returning
+// the size of the file from the first mirror serving byte ranges.
+func (d *segmentedDownload) probeSize(ctx context.Context) (int64, error) {
+	var errs []string
+	for _, mirror := range d.Mirrors {
+		req, err := http.NewRequest("GET", mirror, nil)
+		if err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+		req.Header.Set("Range", "bytes=0-0")
+		resp, err := d.client.Do(req.WithContext(ctx))
+		if err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+		resp.Body.Close()
+
+		switch resp.StatusCode {
+		case http.StatusPartialContent:
+			size, err := contentRangeSize(resp.Header.Get("Content-Range"))
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("%s: %s", mirror, err))
+				continue
+			}
+			return size, nil
+		case http.StatusOK:
+			log.Printf("%s doesn't support byte ranges", mirror)
+		default:
+			errs = append(errs, fmt.Sprintf("%s: bad response code: %d", mirror, resp.StatusCode))
+		}
+	}
+	if len(errs) > 0 {
+		log.Printf("Error probing mirrors: %s", strings.Join(errs, ", "))
+	}
+	return 0, errRangesNotSupported
+}
+
+// contentRangeSize returns the complete length of a Content-Range header,
+// like bytes 0-0/1024.
+func contentRangeSize(contentRange string) (int64, error) {
+	i := strings.LastIndex(contentRange, "/")
+	if !strings.HasPrefix(contentRange, "bytes ") || i == -1 {
+		return 0, fmt.Errorf("invalid Content-Range %q", contentRange)
+	}
+	size, err := strconv.ParseInt(contentRange[i+1:], 10, 64)
+	if err != nil || size <= 0 {
+		return 0, fmt.Errorf("unknown size in Content-Range %q", contentRange)
+	}
+	return size, nil
+}
+
+// loadSegments returns the ranges saved by a previous download of a file of
+// the same size, or splits the file in new ranges.
+func (d *segmentedDownload) loadSegments(size int64) []*downloadSegment {
+	var saved struct {
+		Size     int64              `json:"size"`
+		Segments []*downloadSegment `json:"segments"`
+	}
+	if b, err := ioutil.ReadFile(d.SegmentsPath()); err == nil {
+		if err := json.Unmarshal(b, &saved); err == nil && saved.Size == size && len(saved.Segments) > 0 {
+			if _, err := os.Stat(d.PartPath()); err == nil {
+				log.Printf("Resuming download of %s", d.Dst)
+				return saved.Segments
+			}
+		}
+	}
+	return splitSegments(size, d.Segments)
+}
+
+// splitSegments splits size bytes in n ranges of about the same size.
+func splitSegments(size int64, n int) []*downloadSegment {
+	if int64(n) > size {
+		n = int(size)
+	}
+	segmentSize := size / int64(n)
+	segments := make([]*downloadSegment, 0, n)
+	for i := 0; i < n; i++ {
+		start := int64(i) * segmentSize
+		end := start + segmentSize - 1
+		if i == n-1 {
+			end = size - 1
+		}
+		segments = append(segments, &downloadSegment{Start: start, End: end})
+	}
+	return segments
+}
+
+func (d *segmentedDownload) saveSegments() {
+	d.lock.Lock()
+	b, err := json.Marshal(map[string]interface{}{
+		"size":     d.size,
+		"segments": d.segments,
+	})
+	d.lock.Unlock()
+	if err != nil {
+		log.Printf("Error saving the download progress: %s", err)
+		return
+	}
+	if err := ioutil.WriteFile(d.SegmentsPath(), b, 0644); err != nil {
+		log.Printf("Error saving the download progress: %s", err)
+	}
+}
+
+// fetchSegment downloads what remains of range i, starting from mirror i so
+// that the ranges are spread over the mirrors. Each failure moves the range to
+// the next mirror, until every mirror failed twice in a row.
+func (d *segmentedDownload) fetchSegment(ctx context.Context, f *os.File, i int, segment *downloadSegment) error {
+	maxFailures := 2 * len(d.Mirrors)
+	failures := 0
+	mirror := i % len(d.Mirrors)
+	for {
+		d.lock.Lock()
+		done := segment.Done
+		d.lock.Unlock()
+		if done == segment.size() {
+			return nil
+		}
+
+		written, err := d.fetchRange(ctx, f, i, segment, d.Mirrors[mirror])
+		if err == nil {
+			continue
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if written > 0 {
+			failures = 0
+		}
+		failures++
+		log.Printf("Error downloading range %d from %s: %s", i, d.Mirrors[mirror], err)
+		if failures >= maxFailures {
+			return fmt.Errorf("error downloading range %d-%d: %s", segment.Start, segment.End, err)
+		}
+		mirror = (mirror + 1) % len(d.Mirrors)
+	}
+}
+
+// fetchRange requests what remains of a range from a mirror and writes it,
+// returning the number of bytes written.
+func (d *segmentedDownload) fetchRange(ctx context.Context, f *os.File, i int, segment *downloadSegment, mirror string) (int64, error) {
+	d.lock.Lock()
+	offset := segment.Start + segment.Done
+	d.lock.Unlock()
+
+	req, err := http.NewRequest("GET", mirror, nil)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", offset, segment.End))
+	resp, err := d.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusPartialContent {
+		return 0, fmt.Errorf("bad response code: %d", resp.StatusCode)
+	}
+
+	var body io.ReadCloser = resp.Body
+	if d.Progress != nil {
+		name := fmt.Sprintf("%s [%d/%d]", path.Base(req.URL.Path), i+1, len(d.segments))
+		body = d.Progress.TrackProgress(name, segment.Done, segment.size(), body)
+		defer body.Close()
+	}
+
+	var written int64
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := body.Read(buf)
+		if n > 0 {
+			d.lock.Lock()
+			remaining := segment.size() - segment.Done
+			d.lock.Unlock()
+			if int64(n) > remaining {
+				n = int(remaining)
+			}
+			if _, err := f.WriteAt(buf[:n], offset+written); err != nil {
+				return written, err
+			}
+			written += int64(n)
+			d.lock.Lock()
+			segment.Done += int64(n)
+			d.lock.Unlock()
+			select {
+			case d.written <- struct{}{}:
+			default:
+			}
+		}
+		if err == io.EOF {
+			if offset+written <= segment.End {
+				return written, io.ErrUnexpectedEOF
+			}
+			return written, nil
+		}
+		if err != nil {
+			return written, err
+		}
+	}
+}
+
+// contiguous returns the number of bytes written from the beginning of the
+// file without gaps.
+func (d *segmentedDownload) contiguous() int64 {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	var n int64
+	for _, segment := range d.segments {
+		n += segment.Done
+		if segment.Done != segment.size() {
+			break
+		}
+	}
+	return n
+}
+
+func (d *segmentedDownload) complete() bool {
+	return d.contiguous() == d.size
+}
+
+// hash hashes the file as its beginning is written, until the whole file is
+// hashed or the download stops.
+func (d *segmentedDownload) hash(ctx context.Context, f *os.File, h hash.Hash) error {
+	h.Reset()
+	var hashed int64
+	for {
+		if available := d.contiguous(); available > hashed {
+			if _, err := io.Copy(h, io.NewSectionReader(f, hashed, available-hashed)); err != nil {
+				return fmt.Errorf("Failed to hash: %s", err)
+			}
+			hashed = available
+		}
+		if hashed == d.size {
+			return nil
+		}
+		select {
+		case <-d.written:
+		case <-ctx.Done():
+			if d.contiguous() == hashed {
+				return nil
+			}
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package commonsteps
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	getter "github.com/hashicorp/go-getter/v2"
+)
+
+func testSegmentedContent(t *testing.T) ([]byte, *httptest.Server) {
+	content := make([]byte, 1024*1024+17)
+	rand.New(rand.NewSource(1)).Read(content)
+	srvr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeContent(w, r, "image.iso", time.Time{}, bytes.NewReader(content))
+	}))
+	return content, srvr
+}
+
+func testSegmentedChecksum(content []byte) *getter.FileChecksum {
+	sum := sha256.Sum256(content)
+	return &getter.FileChecksum{
+		Type:  "sha256",
+		Hash:  sha256.New(),
+		Value: sum[:],
+	}
+}
+
+func TestSegmentedDownload(t *testing.T) {
+	content, srvr := testSegmentedContent(t)
+	defer srvr.Close()
+
+	// Every request to the first mirror fails, its ranges move to the
+	// second one.
+	broken := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer broken.Close()
+
+	dir, err := ioutil.TempDir("", "packer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	d := &segmentedDownload{
+		Mirrors:  []string{broken.URL + "/image.iso", srvr.URL + "/image.iso"},
+		Segments: 4,
+		Checksum: testSegmentedChecksum(content),
+		Dst:      filepath.Join(dir, "image.iso"),
+	}
+	if err := d.Run(context.Background()); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	b, err := ioutil.ReadFile(d.Dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(b, content) {
+		t.Fatal("downloaded file differs")
+	}
+	if _, err := os.Stat(d.SegmentsPath()); !os.IsNotExist(err) {
+		t.Fatalf("the progress of the download should be removed: %v", err)
+	}
+}
+
+func TestSegmentedDownload_resume(t *testing.T) {
+	content, srvr := testSegmentedContent(t)
+	defer srvr.Close()
+
+	dir, err := ioutil.TempDir("", "packer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	d := &segmentedDownload{
+		Mirrors:  []string{srvr.URL + "/image.iso"},
+		Segments: 2,
+		Checksum: testSegmentedChecksum(content),
+		Dst:      filepath.Join(dir, "image.iso"),
+	}
+
+	// A previous download wrote the first half of the first range.
+	size := int64(len(content))
+	segments := splitSegments(size, 2)
+	segments[0].Done = segments[0].size() / 2
+	part := make([]byte, size)
+	copy(part, content[:segments[0].Done])
+	if err := ioutil.WriteFile(d.PartPath(), part, 0644); err != nil {
+		t.Fatal(err)
+	}
+	b, _ := json.Marshal(map[string]interface{}{"size": size, "segments": segments})
+	if err := ioutil.WriteFile(d.SegmentsPath(), b, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := d.Run(context.Background()); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	b, err = ioutil.ReadFile(d.Dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(b, content) {
+		t.Fatal("downloaded file differs")
+	}
+}
+
+func TestSegmentedDownload_checksumMismatch(t *testing.T) {
+	content, srvr := testSegmentedContent(t)
+	defer srvr.Close()
+
+	dir, err := ioutil.TempDir("", "packer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	d := &segmentedDownload{
+		Mirrors:  []string{srvr.URL + "/image.iso"},
+		Segments: 3,
+		Checksum: testSegmentedChecksum(content[1:]),
+		Dst:      filepath.Join(dir, "image.iso"),
+	}
+	err = d.Run(context.Background())
+	if _, ok := err.(*getter.ChecksumError); !ok {
+		t.Fatalf("should be a checksum error: %#v", err)
+	}
+}
+
+func TestSegmentedDownload_rangesNotSupported(t *testing.T) {
+	srvr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("no ranges here"))
+	}))
+	defer srvr.Close()
+
+	dir, err := ioutil.TempDir("", "packer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	d := &segmentedDownload{
+		Mirrors:  []string{srvr.URL + "/image.iso"},
+		Segments: 3,
+		Dst:      filepath.Join(dir, "image.iso"),
+	}
+	if err := d.Run(context.Background()); err != errRangesNotSupported {
+		t.Fatalf("should not support ranges: %v", err)
+	}
+}
+
+func TestSplitSegments(t *testing.T) {
+	segments := splitSegments(10, 3)
+	expected := []downloadSegment{{Start: 0, End: 2}, {Start: 3, End: 5}, {Start: 6, End: 9}}
+	if len(segments) != len(expected) {
+		t.Fatalf("bad: %#v", segments)
+	}
+	for i, s := range segments {
+		if *s != expected[i] {
+			t.Fatalf("bad segment %d: %#v", i, s)
+		}
+	}
+
+	if segments := splitSegments(2, 4); len(segments) != 2 {
+		t.Fatalf("there should be a segment per byte: %#v", segments)
+	}
+}
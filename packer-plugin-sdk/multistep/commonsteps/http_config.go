@@ -4,6 +4,8 @@ package commonsteps
 
 import (
 	"errors"
+	"fmt"
+	"path/filepath"
 
 	"github.com/hashicorp/packer/packer-plugin-sdk/template/interpolate"
 )
@@ -15,6 +17,11 @@ import (
 // Example usage from a builder:
 //
 //   `wget http://{{ .HTTPIP }}:{{ .HTTPPort }}/foo/bar/preseed.cfg`
+//
+// When `http_auth_token` is set, the files are only served under a random
+// token generated for each build, available as `{{ .HTTPToken }}`:
+//
+//   `wget https://{{ .HTTPIP }}:{{ .HTTPPort }}/{{ .HTTPToken }}/foo/bar/preseed.cfg`
 type HTTPConfig struct {
 	// Path to a directory to serve using an HTTP server. The files in this
 	// directory will be available over HTTP that will be requestable from the
@@ -38,6 +45,39 @@ type HTTPConfig struct {
 	// interface with a non-loopback address. Either `http_bind_address` or
 	// `http_interface` can be specified.
 	HTTPInterface string `mapstructure:"http_interface" undocumented:"true"`
+	// Serve the `http_directory` over HTTPS. The certificate and key are
+	// `http_tls_cert_file` and `http_tls_key_file` when they are set,
+	// otherwise a self-signed certificate is generated for the build, which
+	// the installer must be told not to verify, for example with
+	// `inst.noverifyssl` for kickstart.
+	HTTPUseTLS bool `mapstructure:"http_use_tls"`
+	// The path to a PEM encoded certificate to serve the `http_directory`
+	// with. Requires `http_use_tls` and `http_tls_key_file`.
+	HTTPTLSCertFile string `mapstructure:"http_tls_cert_file"`
+	// The path to the PEM encoded private key of `http_tls_cert_file`.
+	HTTPTLSKeyFile string `mapstructure:"http_tls_key_file"`
+	// Only serve the files under a random token generated for each build,
+	// like `/<token>/preseed.cfg`, so that other machines of the network
+	// can't fetch them. The token is available as `{{ .HTTPToken }}` in
+	// `boot_command`.
+	HTTPAuthToken bool `mapstructure:"http_auth_token"`
+	// Glob patterns of files of the `http_directory`, like `*.cfg`, which
+	// are rendered as templates when they are requested, so that secrets
+	// don't need to be written in the files. The templates can use
+	// `{{ .HTTPIP }}`, `{{ .HTTPPort }}`, `{{ .HTTPToken }}`,
+	// `{{ .SSHPublicKey }}` - the public key of the SSH communicator, when
+	// it is known - and the variables of `http_template_vars`.
+	HTTPTemplates []string `mapstructure:"http_templates"`
+	// Variables available in the templates of `http_templates`, like
+	// `{{ .Hostname }}` for:
+	//
+	// ```json
+	//   "http_template_vars": {
+	//     "Hostname": "ubuntu",
+	//     "Password": "{{ user `password` }}"
+	//   }
+	// ```
+	HTTPTemplateVars map[string]string `mapstructure:"http_template_vars"`
 }
 
 func (c *HTTPConfig) Prepare(ctx *interpolate.Context) []error {
@@ -66,5 +106,22 @@ func (c *HTTPConfig) Prepare(ctx *interpolate.Context) []error {
 			errors.New("either http_interface of http_bind_address can be specified"))
 	}
 
+	if (c.HTTPTLSCertFile == "") != (c.HTTPTLSKeyFile == "") {
+		errs = append(errs,
+			errors.New("http_tls_cert_file and http_tls_key_file must be specified together"))
+	}
+
+	if c.HTTPTLSCertFile != "" && !c.HTTPUseTLS {
+		errs = append(errs,
+			errors.New("http_tls_cert_file requires http_use_tls"))
+	}
+
+	for _, pattern := range c.HTTPTemplates {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			errs = append(errs,
+				fmt.Errorf("invalid http_templates pattern %q: %s", pattern, err))
+		}
+	}
+
 	return errs
 }
@@ -43,3 +43,42 @@ func TestHTTPConfigPrepare_Bounds(t *testing.T) {
 		t.Fatalf("should not have error: %s", err)
 	}
 }
+
+func TestHTTPConfigPrepare_TLS(t *testing.T) {
+	// Test bad
+	h := HTTPConfig{
+		HTTPUseTLS:      true,
+		HTTPTLSCertFile: "cert.pem",
+	}
+	if errs := h.Prepare(nil); len(errs) == 0 {
+		t.Fatal("should have error")
+	}
+
+	// Test bad
+	h = HTTPConfig{
+		HTTPTLSCertFile: "cert.pem",
+		HTTPTLSKeyFile:  "key.pem",
+	}
+	if errs := h.Prepare(nil); len(errs) == 0 {
+		t.Fatal("should have error")
+	}
+
+	// Test bad
+	h = HTTPConfig{
+		HTTPTemplates: []string{"[ks.cfg"},
+	}
+	if errs := h.Prepare(nil); len(errs) == 0 {
+		t.Fatal("should have error")
+	}
+
+	// Test good
+	h = HTTPConfig{
+		HTTPUseTLS:      true,
+		HTTPTLSCertFile: "cert.pem",
+		HTTPTLSKeyFile:  "key.pem",
+		HTTPTemplates:   []string{"*.cfg"},
+	}
+	if errs := h.Prepare(nil); len(errs) != 0 {
+		t.Fatalf("should not have error: %s", errs)
+	}
+}
@@ -113,6 +113,13 @@ type ISOConfig struct {
 	TargetPath string `mapstructure:"iso_target_path"`
 	// The extension of the iso file after download. This defaults to `iso`.
 	TargetExtension string `mapstructure:"iso_target_extension"`
+	// The number of byte ranges of the ISO to download in parallel. When
+	// more than one URL is given, the ranges are spread over all of them and
+	// a range moves to the next URL when one fails. An interrupted download
+	// is resumed where it stopped by the next build. This only applies to
+	// HTTP URLs of servers supporting byte ranges, otherwise the ISO is
+	// downloaded in one go. Defaults to 0, downloading the ISO in one go.
+	ISODownloadSegments int `mapstructure:"iso_download_segments"`
 }
 
 func (c *ISOConfig) Prepare(*interpolate.Context) (warnings []string, errs []error) {
@@ -138,6 +145,10 @@ func (c *ISOConfig) Prepare(*interpolate.Context) (warnings []string, errs []err
 	}
 	c.TargetExtension = strings.ToLower(c.TargetExtension)
 
+	if c.ISODownloadSegments < 0 {
+		errs = append(errs, errors.New("iso_download_segments must not be negative"))
+	}
+
 	// Warnings
 	if c.ISOChecksum == "none" {
 		warnings = append(warnings,
@@ -0,0 +1,47 @@
+package commonsteps
+
+import (
+	"context"
+
+	"github.com/hashicorp/packer/packer-plugin-sdk/logging"
+	"github.com/hashicorp/packer/packer-plugin-sdk/multistep"
+)
+
+// logSteps wraps steps so that the logger of the state, returned by
+// multistep.GetLogger, tells the build and the step its lines come from.
+func logSteps(steps []multistep.Step, buildName string) []multistep.Step {
+	logger := logging.New("")
+	if buildName != "" {
+		logger = logger.With("build", buildName)
+	}
+
+	wrapped := make([]multistep.Step, len(steps))
+	for i, step := range steps {
+		if step != nil {
+			wrapped[i] = logStep{
+				step:   step,
+				logger: logger.With("step", multistep.StepName(step)),
+			}
+		}
+	}
+	return wrapped
+}
+
+type logStep struct {
+	step   multistep.Step
+	logger *logging.Logger
+}
+
+func (s logStep) InnerStepName() string {
+	return multistep.StepName(s.step)
+}
+
+func (s logStep) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	state.Put("logger", s.logger)
+	return s.step.Run(ctx, state)
+}
+
+func (s logStep) Cleanup(state multistep.StateBag) {
+	state.Put("logger", s.logger)
+	s.step.Cleanup(state)
+}
@@ -0,0 +1,41 @@
+package commonsteps
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"os"
+	"testing"
+
+	"github.com/hashicorp/packer/packer-plugin-sdk/multistep"
+)
+
+type testStepLog struct{}
+
+func (testStepLog) Run(_ context.Context, state multistep.StateBag) multistep.StepAction {
+	multistep.GetLogger(state, "test").Debugf("running")
+	return multistep.ActionContinue
+}
+
+func (testStepLog) Cleanup(state multistep.StateBag) {
+	multistep.GetLogger(state, "test").Debugf("cleaning up")
+}
+
+func TestLogSteps(t *testing.T) {
+	var out bytes.Buffer
+	log.SetOutput(&out)
+	log.SetFlags(0)
+	defer func() {
+		log.SetOutput(os.Stderr)
+		log.SetFlags(log.LstdFlags)
+	}()
+
+	runner := &multistep.BasicRunner{Steps: logSteps([]multistep.Step{testStepLog{}}, "vm")}
+	runner.Run(context.Background(), testState(t))
+
+	expected := "[DEBUG] test: running build=vm step=testStepLog\n" +
+		"[DEBUG] test: cleaning up build=vm step=testStepLog\n"
+	if out.String() != expected {
+		t.Fatalf("bad: %s", out.String())
+	}
+}
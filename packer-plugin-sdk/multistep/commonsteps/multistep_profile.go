@@ -0,0 +1,42 @@
+package commonsteps
+
+import (
+	"context"
+	"time"
+
+	"github.com/hashicorp/packer/packer"
+	"github.com/hashicorp/packer/packer-plugin-sdk/multistep"
+)
+
+// profileSteps wraps steps so that their start and the time each of them
+// takes to run are reported to ui, for the profile of the build.
+func profileSteps(steps []multistep.Step, ui packer.Ui) []multistep.Step {
+	wrapped := make([]multistep.Step, len(steps))
+	for i, step := range steps {
+		if step != nil {
+			wrapped[i] = profileStep{step: step, ui: ui}
+		}
+	}
+	return wrapped
+}
+
+type profileStep struct {
+	step multistep.Step
+	ui   packer.Ui
+}
+
+func (s profileStep) InnerStepName() string {
+	return multistep.StepName(s.step)
+}
+
+func (s profileStep) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	s.ui.Machine(packer.StepStartMachineType, s.InnerStepName())
+	start := time.Now()
+	action := s.step.Run(ctx, state)
+	s.ui.Machine(packer.ProfileStepMachineType, s.InnerStepName(), time.Since(start).String())
+	return action
+}
+
+func (s profileStep) Cleanup(state multistep.StateBag) {
+	s.step.Cleanup(state)
+}
@@ -0,0 +1,43 @@
+package commonsteps
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/packer/packer"
+	"github.com/hashicorp/packer/packer-plugin-sdk/multistep"
+)
+
+func TestProfileSteps(t *testing.T) {
+	var out bytes.Buffer
+	ui := &packer.MachineReadableUi{Writer: &out}
+
+	created := &testStepRecord{Key: "created"}
+	provisioned := &testStepRecord{Key: "provisioned", Halt: true}
+	state := testState(t)
+	runner := &multistep.BasicRunner{Steps: profileSteps([]multistep.Step{created, nil, provisioned}, ui)}
+	runner.Run(context.Background(), state)
+
+	if created.runs != 1 || provisioned.runs != 1 {
+		t.Fatal("steps should run")
+	}
+	if created.cleanups != 1 || provisioned.cleanups != 1 {
+		t.Fatal("steps should be cleaned up")
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("should report the start and the end of each step that ran: %q", out.String())
+	}
+	for i, line := range lines {
+		machineType := packer.StepStartMachineType
+		if i%2 == 1 {
+			machineType = packer.ProfileStepMachineType
+		}
+		if !strings.Contains(line, ","+machineType+",testStepRecord") {
+			t.Fatalf("bad: %q", line)
+		}
+	}
+}
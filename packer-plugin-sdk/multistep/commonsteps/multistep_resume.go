@@ -0,0 +1,224 @@
+package commonsteps
+
+import (
+	"context"
+	"encoding/gob"
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+
+	"github.com/hashicorp/packer/packer"
+	"github.com/hashicorp/packer/packer-plugin-sdk/multistep"
+)
+
+// resumeData is what is saved of a build for -resume.
+type resumeData struct {
+	Steps []resumeStepData
+}
+
+type resumeStepData struct {
+	Name      string
+	Completed bool
+	Saved     map[string]interface{}
+}
+
+// resumer keeps track of the steps of a build that run with -resume, and
+// saves what they produced after each of them, so that the build can be
+// resumed if it fails.
+type resumer struct {
+	path string
+	ui   packer.Ui
+
+	// The data of the build that is resumed, nil if it is a new build
+	previous *resumeData
+	current  resumeData
+}
+
+var resumeFileNameRe = regexp.MustCompile(`[^a-zA-Z0-9_.-]`)
+
+// ResumeStatePath returns the path to the file the state of a build that runs
+// with -resume is saved to.
+func ResumeStatePath(builderType string, buildName string) (string, error) {
+	name := resumeFileNameRe.ReplaceAllString(builderType+"."+buildName, "_")
+	return packer.CachePath("resume", name+".state")
+}
+
+// resumeSteps wraps steps so that a build that fails can be resumed at the
+// step that failed, with the state saved to path. If path holds the state of
+// a previous run of the same steps, the resumable steps that completed in
+// that run are resumed instead of run again.
+func resumeSteps(steps []multistep.Step, path string, ui packer.Ui) []multistep.Step {
+	r := &resumer{
+		path: path,
+		ui:   ui,
+	}
+	r.current.Steps = make([]resumeStepData, len(steps))
+	for i, step := range steps {
+		if step != nil {
+			r.current.Steps[i].Name = multistep.StepName(step)
+		}
+	}
+	r.load()
+
+	first := true
+	wrapped := make([]multistep.Step, len(steps))
+	for i, step := range steps {
+		if step == nil {
+			continue
+		}
+		wrapped[i] = resumeStep{
+			step:  step,
+			index: i,
+			first: first,
+			r:     r,
+		}
+		first = false
+	}
+	return wrapped
+}
+
+func (r *resumer) load() {
+	f, err := os.Open(r.path)
+	if os.IsNotExist(err) {
+		return
+	}
+	if err != nil {
+		r.ui.Error(fmt.Sprintf("Error reading the state of the build to resume: %s", err))
+		return
+	}
+	defer f.Close()
+
+	var previous resumeData
+	if err := gob.NewDecoder(f).Decode(&previous); err != nil {
+		r.ui.Error(fmt.Sprintf("Error reading the state of the build to resume: %s", err))
+		return
+	}
+
+	if len(previous.Steps) != len(r.current.Steps) {
+		r.ui.Error("The steps of the build changed, starting it over.")
+		return
+	}
+	for i := range previous.Steps {
+		if previous.Steps[i].Name != r.current.Steps[i].Name {
+			r.ui.Error("The steps of the build changed, starting it over.")
+			return
+		}
+	}
+
+	r.ui.Say(fmt.Sprintf("Resuming the build saved in %s...", r.path))
+	r.previous = &previous
+}
+
+// completed records that step i completed with saved, and writes the state
+// of the build to disk.
+func (r *resumer) completed(i int, saved map[string]interface{}) {
+	r.current.Steps[i].Completed = true
+	r.current.Steps[i].Saved = saved
+
+	tmp := r.path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		log.Printf("Error saving the state of the build: %s", err)
+		return
+	}
+	err = gob.NewEncoder(f).Encode(&r.current)
+	f.Close()
+	if err == nil {
+		err = os.Rename(tmp, r.path)
+	}
+	if err != nil {
+		os.Remove(tmp)
+		r.ui.Error(fmt.Sprintf("Error saving the state of the build, it can't be resumed: %s", err))
+	}
+}
+
+// resumable returns the step to resume if step i completed in the build that
+// is resumed, and the values it saved.
+func (r *resumer) resumable(i int, step multistep.Step) (multistep.ResumableStep, map[string]interface{}, bool) {
+	rs, ok := innerStep(step).(multistep.ResumableStep)
+	if !ok || r.previous == nil || !r.previous.Steps[i].Completed {
+		return nil, nil, false
+	}
+	return rs, r.previous.Steps[i].Saved, true
+}
+
+type resumeStep struct {
+	step  multistep.Step
+	index int
+	// Whether this is the first step, which is cleaned up last
+	first bool
+	r     *resumer
+}
+
+func (s resumeStep) InnerStepName() string {
+	return multistep.StepName(s.step)
+}
+
+func (s resumeStep) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	if rs, saved, ok := s.r.resumable(s.index, s.step); ok {
+		log.Printf("Resuming step %s", s.InnerStepName())
+		action := rs.Resume(ctx, state, saved)
+		if action == multistep.ActionContinue {
+			s.r.completed(s.index, saved)
+		}
+		return action
+	}
+
+	action := s.step.Run(ctx, state)
+	if action != multistep.ActionContinue {
+		return action
+	}
+	if _, ok := state.GetOk(multistep.StateCancelled); ok {
+		return action
+	}
+
+	var saved map[string]interface{}
+	if rs, ok := innerStep(s.step).(multistep.ResumableStep); ok {
+		saved = rs.Save(state)
+	}
+	s.r.completed(s.index, saved)
+	return action
+}
+
+func (s resumeStep) Cleanup(state multistep.StateBag) {
+	s.CleanupContext(context.Background(), state)
+}
+
+func (s resumeStep) CleanupContext(ctx context.Context, state multistep.StateBag) {
+	_, cancelled := state.GetOk(multistep.StateCancelled)
+	_, halted := state.GetOk(multistep.StateHalted)
+	failed := cancelled || halted
+
+	_, resumable := innerStep(s.step).(multistep.ResumableStep)
+	if failed && resumable && s.r.current.Steps[s.index].Completed {
+		log.Printf("Not cleaning up step %s, so that the build can be resumed", s.InnerStepName())
+	} else {
+		multistep.CleanupStep(ctx, s.step, state)
+	}
+
+	if !s.first {
+		return
+	}
+	if failed {
+		if _, err := os.Stat(s.r.path); err == nil {
+			s.r.ui.Say(fmt.Sprintf("The state of the build was saved to %s. "+
+				"Run the build again with -resume to resume it.", s.r.path))
+		}
+		return
+	}
+	if err := os.Remove(s.r.path); err != nil && !os.IsNotExist(err) {
+		log.Printf("Error removing the state of the build: %s", err)
+	}
+}
+
+// innerStep returns the step wrapped for -on-error.
+func innerStep(step multistep.Step) multistep.Step {
+	switch s := step.(type) {
+	case abortStep:
+		return s.step
+	case askStep:
+		return s.step
+	}
+	return step
+}
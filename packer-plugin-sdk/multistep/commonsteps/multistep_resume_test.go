@@ -0,0 +1,136 @@
+package commonsteps
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/packer/packer"
+	"github.com/hashicorp/packer/packer-plugin-sdk/multistep"
+)
+
+// testStepRecord puts Key in the state and counts how often it is run and
+// cleaned up.
+type testStepRecord struct {
+	Key  string
+	Halt bool
+
+	runs     int
+	resumes  int
+	cleanups int
+}
+
+func (s *testStepRecord) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	s.runs++
+	state.Put(s.Key, "value")
+	if s.Halt {
+		state.Put("error", errors.New("halt"))
+		return multistep.ActionHalt
+	}
+	return multistep.ActionContinue
+}
+
+func (s *testStepRecord) Cleanup(multistep.StateBag) {
+	s.cleanups++
+}
+
+type testStepResumable struct {
+	testStepRecord
+}
+
+func (s *testStepResumable) Save(state multistep.StateBag) map[string]interface{} {
+	return multistep.SaveKeys(state, s.Key)
+}
+
+func (s *testStepResumable) Resume(ctx context.Context, state multistep.StateBag, saved map[string]interface{}) multistep.StepAction {
+	s.resumes++
+	multistep.RestoreKeys(state, saved)
+	return multistep.ActionContinue
+}
+
+func TestResumeSteps_impl(t *testing.T) {
+	var _ multistep.StepWrapper = resumeStep{}
+	var _ multistep.ResumableStep = new(StepOutputDir)
+	var _ multistep.ResumableStep = new(StepDownload)
+	var _ multistep.ResumableStep = new(StepCreateFloppy)
+	var _ multistep.ResumableStep = new(StepCreateCD)
+}
+
+func runResumeSteps(t *testing.T, path string, steps ...multistep.Step) multistep.StateBag {
+	state := testState(t)
+	runner := &multistep.BasicRunner{Steps: resumeSteps(steps, path, state.Get("ui").(packer.Ui))}
+	runner.Run(context.Background(), state)
+	return state
+}
+
+func TestResumeSteps(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "build.state")
+
+	created := &testStepResumable{testStepRecord{Key: "created"}}
+	connected := &testStepRecord{Key: "connected"}
+	provisioned := &testStepResumable{testStepRecord{Key: "provisioned", Halt: true}}
+	state := runResumeSteps(t, path, created, connected, provisioned)
+
+	if _, ok := state.GetOk(multistep.StateHalted); !ok {
+		t.Fatal("should halt")
+	}
+	if created.cleanups != 0 {
+		t.Fatal("completed resumable step should not be cleaned up")
+	}
+	if connected.cleanups != 1 {
+		t.Fatal("step that isn't resumable should be cleaned up")
+	}
+	if provisioned.cleanups != 1 {
+		t.Fatal("failed step should be cleaned up")
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("state should be saved: %s", err)
+	}
+
+	// Resume the build
+	created = &testStepResumable{testStepRecord{Key: "created"}}
+	connected = &testStepRecord{Key: "connected"}
+	provisioned = &testStepResumable{testStepRecord{Key: "provisioned"}}
+	state = runResumeSteps(t, path, created, connected, provisioned)
+
+	if _, ok := state.GetOk(multistep.StateHalted); ok {
+		t.Fatalf("should not halt: %s", state.Get("error"))
+	}
+	if created.runs != 0 || created.resumes != 1 {
+		t.Fatalf("completed resumable step should be resumed: %d runs, %d resumes", created.runs, created.resumes)
+	}
+	if state.Get("created") != "value" {
+		t.Fatalf("state of resumed step should be restored: %#v", state.Get("created"))
+	}
+	if connected.runs != 1 {
+		t.Fatal("step that isn't resumable should run again")
+	}
+	if provisioned.runs != 1 || provisioned.resumes != 0 {
+		t.Fatal("failed step should run again")
+	}
+	if created.cleanups != 1 {
+		t.Fatal("resumed step should be cleaned up once the build succeeds")
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatal("state should be removed once the build succeeds")
+	}
+}
+
+func TestResumeSteps_changedSteps(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "build.state")
+
+	created := &testStepResumable{testStepRecord{Key: "created"}}
+	provisioned := &testStepRecord{Key: "provisioned", Halt: true}
+	runResumeSteps(t, path, created, provisioned)
+
+	created = &testStepResumable{testStepRecord{Key: "created"}}
+	connected := &testStepRecord{Key: "connected"}
+	provisioned = &testStepRecord{Key: "provisioned"}
+	runResumeSteps(t, path, created, connected, provisioned)
+
+	if created.runs != 1 || created.resumes != 0 {
+		t.Fatal("build with different steps should not be resumed")
+	}
+}
@@ -13,52 +13,93 @@ import (
 	"github.com/hashicorp/packer/packer-plugin-sdk/multistep"
 )
 
-func newRunner(steps []multistep.Step, config common.PackerConfig, ui packer.Ui) (multistep.Runner, multistep.DebugPauseFn) {
+func newRunner(steps []multistep.Step, config common.PackerConfig, ui packer.Ui, middlewares []multistep.StepMiddleware) (multistep.Runner, multistep.DebugPauseFn) {
 	switch config.PackerOnError {
 	case "", "cleanup":
 	case "abort":
-		for i, step := range steps {
-			steps[i] = abortStep{
+		steps = multistep.WrapSteps(steps, func(step multistep.Step) multistep.Step {
+			return abortStep{
 				step:        step,
 				cleanupProv: false,
 				ui:          ui,
 			}
-		}
+		})
 	case "ask":
-		for i, step := range steps {
-			steps[i] = askStep{step, ui}
-		}
+		steps = multistep.WrapSteps(steps, func(step multistep.Step) multistep.Step {
+			return askStep{step, ui}
+		})
 	case "run-cleanup-provisioner":
-		for i, step := range steps {
-			steps[i] = abortStep{
+		steps = multistep.WrapSteps(steps, func(step multistep.Step) multistep.Step {
+			return abortStep{
 				step:        step,
 				cleanupProv: true,
 				ui:          ui,
 			}
+		})
+	}
+
+	if config.PackerResume {
+		path, err := ResumeStatePath(config.PackerBuilderType, config.PackerBuildName)
+		if err != nil {
+			ui.Error(fmt.Sprintf("The build can't be resumed: %s", err))
+		} else {
+			steps = resumeSteps(steps, path, ui)
 		}
 	}
 
+	steps = multistep.WrapSteps(steps, middlewares...)
+	steps = multistep.WrapSteps(steps, multistep.StepCleanupTimeout(config.PackerCleanupTimeout))
+	steps = logSteps(steps, config.PackerBuildName)
+	steps = profileSteps(steps, ui)
+
 	if config.PackerDebug {
 		pauseFn := MultistepDebugFn(ui)
-		return &multistep.DebugRunner{Steps: steps, PauseFn: pauseFn}, pauseFn
+		return orphansReporter{&multistep.DebugRunner{Steps: steps, PauseFn: pauseFn}, ui}, pauseFn
 	} else {
-		return &multistep.BasicRunner{Steps: steps}, nil
+		return orphansReporter{&multistep.BasicRunner{Steps: steps}, ui}, nil
 	}
 }
 
+// orphansReporter is a multistep.Runner reporting the resources that the
+// cleanup of the steps couldn't destroy once they all ran.
+type orphansReporter struct {
+	multistep.Runner
+	ui packer.Ui
+}
+
+func (r orphansReporter) Run(ctx context.Context, state multistep.StateBag) {
+	r.Runner.Run(ctx, state)
+
+	orphans := multistep.OrphanedResources(state)
+	if len(orphans) == 0 {
+		return
+	}
+	msg := "The following resources could not be destroyed and may need to be deleted manually:"
+	for _, orphan := range orphans {
+		msg += "\n  - " + orphan
+	}
+	r.ui.Error(msg)
+}
+
 // NewRunner returns a multistep.Runner that runs steps augmented with support
-// for -debug and -on-error command line arguments.
-func NewRunner(steps []multistep.Step, config common.PackerConfig, ui packer.Ui) multistep.Runner {
-	runner, _ := newRunner(steps, config, ui)
+// for -debug, -on-error and -resume command line arguments. Each step is
+// wrapped with the middlewares, after the wrappers of -on-error and -resume.
+// The cleanup of each step is given up after -cleanup-timeout, and the
+// resources that couldn't be destroyed are reported once the steps ran. The
+// lines of the loggers returned by multistep.GetLogger tell the build and
+// the step.
+func NewRunner(steps []multistep.Step, config common.PackerConfig, ui packer.Ui, middlewares ...multistep.StepMiddleware) multistep.Runner {
+	runner, _ := newRunner(steps, config, ui, middlewares)
 	return runner
 }
 
 // NewRunnerWithPauseFn returns a multistep.Runner that runs steps augmented
-// with support for -debug and -on-error command line arguments.  With -debug it
-// puts the multistep.DebugPauseFn that will pause execution between steps into
-// the state under the key "pauseFn".
-func NewRunnerWithPauseFn(steps []multistep.Step, config common.PackerConfig, ui packer.Ui, state multistep.StateBag) multistep.Runner {
-	runner, pauseFn := newRunner(steps, config, ui)
+// with support for -debug, -on-error and -resume command line arguments.  With
+// -debug it puts the multistep.DebugPauseFn that will pause execution between
+// steps into the state under the key "pauseFn". Each step is wrapped with the
+// middlewares, after the wrappers of -on-error and -resume.
+func NewRunnerWithPauseFn(steps []multistep.Step, config common.PackerConfig, ui packer.Ui, state multistep.StateBag, middlewares ...multistep.StepMiddleware) multistep.Runner {
+	runner, pauseFn := newRunner(steps, config, ui, middlewares)
 	if pauseFn != nil {
 		state.Put("pauseFn", pauseFn)
 	}
@@ -84,8 +125,12 @@ func (s abortStep) Run(ctx context.Context, state multistep.StateBag) multistep.
 }
 
 func (s abortStep) Cleanup(state multistep.StateBag) {
+	s.CleanupContext(context.Background(), state)
+}
+
+func (s abortStep) CleanupContext(ctx context.Context, state multistep.StateBag) {
 	if s.InnerStepName() == typeName(StepProvision{}) && s.cleanupProv {
-		s.step.Cleanup(state)
+		multistep.CleanupStep(ctx, s.step, state)
 		return
 	}
 
@@ -93,7 +138,7 @@ func (s abortStep) Cleanup(state multistep.StateBag) {
 	if !shouldCleanup {
 		return
 	}
-	s.step.Cleanup(state)
+	multistep.CleanupStep(ctx, s.step, state)
 }
 
 type askStep struct {
@@ -131,13 +176,17 @@ func (s askStep) Run(ctx context.Context, state multistep.StateBag) (action mult
 }
 
 func (s askStep) Cleanup(state multistep.StateBag) {
+	s.CleanupContext(context.Background(), state)
+}
+
+func (s askStep) CleanupContext(ctx context.Context, state multistep.StateBag) {
 	if _, ok := state.GetOk("aborted"); ok {
 		shouldCleanup := handleAbortsAndInterupts(state, s.ui, typeName(s.step))
 		if !shouldCleanup {
 			return
 		}
 	}
-	s.step.Cleanup(state)
+	multistep.CleanupStep(ctx, s.step, state)
 }
 
 type askResponse int
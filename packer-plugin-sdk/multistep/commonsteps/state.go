@@ -0,0 +1,43 @@
+package commonsteps
+
+import (
+	"log"
+
+	"github.com/hashicorp/packer/packer"
+	"github.com/hashicorp/packer/packer-plugin-sdk/multistep"
+)
+
+// GetUi returns the packer.Ui of the state, put under the "ui" key.
+func GetUi(state multistep.StateBag) (packer.Ui, error) {
+	var ui packer.Ui
+	err := multistep.GetValue(state, "ui", &ui)
+	return ui, err
+}
+
+// GetCommunicator returns the packer.Communicator of the state, put under the
+// "communicator" key once connected to the machine.
+func GetCommunicator(state multistep.StateBag) (packer.Communicator, error) {
+	var comm packer.Communicator
+	err := multistep.GetValue(state, "communicator", &comm)
+	return comm, err
+}
+
+// HaltOnError puts err into the state as the error of the build, reports it
+// to ui when it isn't nil, and returns multistep.ActionHalt.
+func HaltOnError(state multistep.StateBag, ui packer.Ui, err error) multistep.StepAction {
+	state.Put("error", err)
+	if ui != nil {
+		ui.Error(err.Error())
+	}
+	return multistep.ActionHalt
+}
+
+// SkipCleanupOnError logs err and returns true when it isn't nil, for the
+// Cleanup of a step to return when a value it needs is missing from the state.
+func SkipCleanupOnError(err error) bool {
+	if err == nil {
+		return false
+	}
+	log.Printf("[ERR] Skipping the cleanup of the step: %s", err)
+	return true
+}
@@ -106,6 +106,20 @@ func (s *StepCreateCD) Run(ctx context.Context, state multistep.StateBag) multis
 	return multistep.ActionContinue
 }
 
+func (s *StepCreateCD) Save(state multistep.StateBag) map[string]interface{} {
+	return multistep.SaveKeys(state, "cd_path")
+}
+
+// Resume keeps the CD of the build that is resumed, which may be attached to
+// its machine.
+func (s *StepCreateCD) Resume(ctx context.Context, state multistep.StateBag, saved map[string]interface{}) multistep.StepAction {
+	multistep.RestoreKeys(state, saved)
+	if path, ok := saved["cd_path"]; ok {
+		s.CDPath = path.(string)
+	}
+	return multistep.ActionContinue
+}
+
 func (s *StepCreateCD) Cleanup(multistep.StateBag) {
 	if s.CDPath != "" {
 		log.Printf("Deleting CD disk: %s", s.CDPath)
@@ -305,6 +305,20 @@ func (s *StepCreateFloppy) Add(dircache directoryCache, src string) error {
 	return filepath.Walk(src, visit)
 }
 
+func (s *StepCreateFloppy) Save(state multistep.StateBag) map[string]interface{} {
+	return multistep.SaveKeys(state, "floppy_path")
+}
+
+// Resume keeps the floppy disk of the build that is resumed, which may be
+// attached to its machine.
+func (s *StepCreateFloppy) Resume(ctx context.Context, state multistep.StateBag, saved map[string]interface{}) multistep.StepAction {
+	multistep.RestoreKeys(state, saved)
+	if path, ok := saved["floppy_path"]; ok {
+		s.floppyPath = path.(string)
+	}
+	return multistep.ActionContinue
+}
+
 func (s *StepCreateFloppy) Cleanup(multistep.StateBag) {
 	if s.floppyPath != "" {
 		log.Printf("Deleting floppy disk: %s", s.floppyPath)
@@ -11,6 +11,7 @@ import (
 	"path/filepath"
 	"runtime"
 	"strings"
+	"time"
 
 	gcs "github.com/hashicorp/go-getter/gcs/v2"
 	s3 "github.com/hashicorp/go-getter/s3/v2"
@@ -53,6 +54,16 @@ type StepDownload struct {
 	// extension on the URL is used. Otherwise, this will be forced
 	// on the downloaded file for every URL.
 	Extension string
+
+	// Segments is the number of byte ranges to download in parallel, from
+	// all the URLs at once. The download is resumed where it stopped when it
+	// is interrupted. Downloads are segmented only when this is greater than
+	// one and all the URLs are HTTP URLs serving byte ranges.
+	Segments int
+
+	// useLock is shared with the other builds using the downloaded file of
+	// the shared cache, until the build ends.
+	useLock *filelock.Flock
 }
 
 var defaultGetterClient = getter.Client{
@@ -75,6 +86,22 @@ func (s *StepDownload) Run(ctx context.Context, state multistep.StateBag) multis
 	ui := state.Get("ui").(packer.Ui)
 	ui.Say(fmt.Sprintf("Retrieving %s", s.Description))
 
+	if s.Segments > 1 {
+		dst, err := s.downloadSegmented(ctx, ui)
+		switch err {
+		case nil:
+			state.Put(s.ResultKey, dst)
+			return multistep.ActionContinue
+		case errRangesNotSupported:
+			ui.Say(fmt.Sprintf("Can't download %s in segments, downloading it in one go", s.Description))
+		default:
+			err := fmt.Errorf("error downloading %s: %s", s.Description, err)
+			state.Put("error", err)
+			ui.Error(err.Error())
+			return multistep.ActionHalt
+		}
+	}
+
 	var errs []error
 
 	for _, source := range s.Url {
@@ -165,6 +192,13 @@ func (s *StepDownload) download(ctx context.Context, ui packer.Ui, source string
 	if err != nil {
 		return "", err
 	}
+	sharedPath, _, err := s.sharedCacheTarget(ctx, u)
+	if err != nil {
+		return "", err
+	}
+	if sharedPath != "" {
+		targetPath = sharedPath
+	}
 	lockFile := targetPath + ".lock"
 
 	log.Printf("Acquiring lock for: %s (%s)", u.String(), lockFile)
@@ -209,6 +243,9 @@ func (s *StepDownload) download(ctx context.Context, ui packer.Ui, source string
 	switch op, err := defaultGetterClient.Get(ctx, req); err.(type) {
 	case nil: // success !
 		ui.Say(fmt.Sprintf("%s => %s", u.String(), op.Dst))
+		if sharedPath != "" {
+			s.useSharedCacheFile(op.Dst)
+		}
 		return op.Dst, nil
 	case *getter.ChecksumError:
 		ui.Say(fmt.Sprintf("Checksum did not match, removing %s", targetPath))
@@ -222,6 +259,149 @@ func (s *StepDownload) download(ctx context.Context, ui packer.Ui, source string
 	}
 }
 
+// downloadSegmented downloads the file in byte ranges from all the URLs at
+// once. It returns errRangesNotSupported when the file can't be downloaded
+// this way.
+func (s *StepDownload) downloadSegmented(ctx context.Context, ui packer.Ui) (string, error) {
+	var mirrors []string
+	for _, source := range s.Url {
+		u, err := parseSourceURL(source)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			log.Printf("Not downloading %s in segments: %s isn't an HTTP URL", s.Description, source)
+			return "", errRangesNotSupported
+		}
+		// The checksum is verified by the download, not by the mirrors.
+		q := u.Query()
+		q.Del("checksum")
+		u.RawQuery = q.Encode()
+		mirrors = append(mirrors, u.String())
+	}
+
+	u, targetPath, err := s.UseSourceToFindCacheTarget(s.Url[0])
+	if err != nil {
+		return "", err
+	}
+	sharedPath, checksum, err := s.sharedCacheTarget(ctx, u)
+	if err != nil {
+		return "", err
+	}
+	if sharedPath != "" {
+		targetPath = sharedPath
+	}
+	lockFile := targetPath + ".lock"
+
+	log.Printf("Acquiring lock for: %s (%s)", u.String(), lockFile)
+	lock := filelock.New(lockFile)
+	lock.Lock()
+	defer lock.Unlock()
+
+	if checksum == nil {
+		if checksum, err = s.getChecksum(ctx, u); err != nil {
+			return "", err
+		}
+	}
+
+	if _, err := os.Stat(targetPath); err == nil && checksum != nil {
+		if err := checksum.Checksum(targetPath); err == nil {
+			ui.Say(fmt.Sprintf("Using already downloaded %s", targetPath))
+			if sharedPath != "" {
+				s.useSharedCacheFile(targetPath)
+			}
+			return targetPath, nil
+		}
+	}
+
+	d := &segmentedDownload{
+		Mirrors:  mirrors,
+		Segments: s.Segments,
+		Checksum: checksum,
+		Dst:      targetPath,
+		Progress: ui,
+	}
+	ui.Say(fmt.Sprintf("Downloading %s in %d segments from %d URL(s)", s.Description, s.Segments, len(mirrors)))
+	switch err := d.Run(ctx); err.(type) {
+	case nil:
+		ui.Say(fmt.Sprintf("%s => %s", u.String(), targetPath))
+		if sharedPath != "" {
+			s.useSharedCacheFile(targetPath)
+		}
+		return targetPath, nil
+	case *getter.ChecksumError:
+		ui.Say(fmt.Sprintf("Checksum did not match, removing %s", d.PartPath()))
+		os.Remove(d.SegmentsPath())
+		if err := os.Remove(d.PartPath()); err != nil {
+			ui.Error(fmt.Sprintf("Failed to remove cache file. Please remove manually: %s", d.PartPath()))
+		}
+		return "", err
+	default:
+		return "", err
+	}
+}
+
+// getChecksum returns the checksum of the download, or nil when the download
+// isn't verified.
+func (s *StepDownload) getChecksum(ctx context.Context, u *url.URL) (*getter.FileChecksum, error) {
+	if s.Checksum == "" || s.Checksum == "none" {
+		return nil, nil
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		log.Printf("get working directory: %v", err)
+	}
+	checksum, err := defaultGetterClient.GetChecksum(ctx, &getter.Request{
+		Src: u.String(),
+		Pwd: wd,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error getting checksum: %s", err)
+	}
+	return checksum, nil
+}
+
+// sharedCacheTarget returns where the download goes in the shared cache,
+// addressed by its checksum, so that every template and build downloading the
+// same file uses the same copy. It returns an empty path when the shared cache
+// isn't used, when a target path is set, when the download isn't verified or
+// when the file is local, local files being used in place.
+func (s *StepDownload) sharedCacheTarget(ctx context.Context, u *url.URL) (string, *getter.FileChecksum, error) {
+	dir, err := packer.SharedCacheDir()
+	if err != nil || dir == "" || s.TargetPath != "" {
+		return "", nil, err
+	}
+	if u.Scheme == "" || strings.ToLower(u.Scheme) == "file" {
+		return "", nil, nil
+	}
+	checksum, err := s.getChecksum(ctx, u)
+	if err != nil || checksum == nil {
+		return "", nil, err
+	}
+	extension := s.Extension
+	if extension == "" {
+		extension = strings.TrimPrefix(filepath.Ext(u.Path), ".")
+	}
+	path, err := packer.SharedCachePath(dir, checksum.Type, checksum.Value, extension)
+	if err != nil {
+		return "", nil, fmt.Errorf("SharedCachePath: %s", err)
+	}
+	return path, checksum, nil
+}
+
+// useSharedCacheFile marks a file of the shared cache as used, so that
+// `packer cache gc` keeps it while the build runs and removes the least
+// recently used files first.
+func (s *StepDownload) useSharedCacheFile(path string) {
+	now := time.Now()
+	if err := os.Chtimes(path, now, now); err != nil {
+		log.Printf("Error updating the modification time of %s: %s", path, err)
+	}
+	lock := filelock.New(path + packer.CacheUseLockSuffix)
+	if _, err := lock.TryRLock(); err != nil {
+		log.Printf("Error locking %s: %s", path, err)
+		return
+	}
+	s.useLock = lock
+}
+
 func parseSourceURL(source string) (*url.URL, error) {
 	if runtime.GOOS == "windows" {
 		// Check that the user specified a UNC path, and promote it to an smb:// uri.
@@ -235,4 +415,17 @@ func parseSourceURL(source string) (*url.URL, error) {
 	return u, err
 }
 
-func (s *StepDownload) Cleanup(multistep.StateBag) {}
+func (s *StepDownload) Save(state multistep.StateBag) map[string]interface{} {
+	return multistep.SaveKeys(state, s.ResultKey)
+}
+
+func (s *StepDownload) Resume(ctx context.Context, state multistep.StateBag, saved map[string]interface{}) multistep.StepAction {
+	multistep.RestoreKeys(state, saved)
+	return multistep.ActionContinue
+}
+
+func (s *StepDownload) Cleanup(multistep.StateBag) {
+	if s.useLock != nil {
+		s.useLock.Unlock()
+	}
+}
@@ -281,6 +281,62 @@ func TestStepDownload_download(t *testing.T) {
 	os.RemoveAll(step.TargetPath)
 }
 
+func TestStepDownload_sharedCache(t *testing.T) {
+	ui := &packer.BasicUi{
+		Reader: new(bytes.Buffer),
+		Writer: new(bytes.Buffer),
+		PB:     &packer.NoopProgressTracker{},
+	}
+
+	dir := createTempDir(t)
+	defer os.RemoveAll(dir)
+
+	defer os.Setenv("PACKER_SHARED_CACHE_DIR", os.Getenv("PACKER_SHARED_CACHE_DIR"))
+	os.Setenv("PACKER_SHARED_CACHE_DIR", dir)
+
+	srvr := httptest.NewServer(http.FileServer(http.Dir("test-fixtures")))
+	defer srvr.Close()
+	mirror := httptest.NewServer(http.FileServer(http.Dir("test-fixtures")))
+	defer mirror.Close()
+
+	// Both templates use the same copy of the file, whatever its URL.
+	expected := filepath.Join(dir, "sha1", "f572d396fae9206628714fb2ce00f72e94f2258f.txt")
+	for _, source := range []string{srvr.URL + "/root/basic.txt", mirror.URL + "/root/basic.txt"} {
+		step := &StepDownload{
+			Checksum:    "sha1:f572d396fae9206628714fb2ce00f72e94f2258f",
+			Description: "ISO",
+			ResultKey:   "iso_path",
+		}
+		dst, err := step.download(context.TODO(), ui, source)
+		if err != nil {
+			t.Fatalf("Bad: non expected error %s", err.Error())
+		}
+		if dst != expected {
+			t.Fatalf("Bad: %s should be in the shared cache at %s", dst, expected)
+		}
+		if step.useLock == nil {
+			t.Fatalf("Bad: the file should be locked while it's used")
+		}
+		step.Cleanup(nil)
+	}
+
+	// Downloads that aren't verified aren't shared.
+	step := &StepDownload{
+		Checksum:    "none",
+		Description: "ISO",
+		ResultKey:   "iso_path",
+	}
+	defer os.Setenv("PACKER_CACHE_DIR", os.Getenv("PACKER_CACHE_DIR"))
+	os.Setenv("PACKER_CACHE_DIR", filepath.Join(dir, "cache"))
+	dst, err := step.download(context.TODO(), ui, srvr.URL+"/root/basic.txt")
+	if err != nil {
+		t.Fatalf("Bad: non expected error %s", err.Error())
+	}
+	if filepath.Dir(dst) != filepath.Join(dir, "cache") {
+		t.Fatalf("Bad: %s should be in the cache of the template", dst)
+	}
+}
+
 func TestStepDownload_WindowsParseSourceURL(t *testing.T) {
 	if runtime.GOOS != "windows" {
 		t.Skip("skip windows specific tests")
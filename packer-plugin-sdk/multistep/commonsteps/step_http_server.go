@@ -1,14 +1,31 @@
 package commonsteps
 
 import (
+	"bytes"
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
 	"fmt"
-
+	"io/ioutil"
+	"log"
+	"math/big"
 	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
 
+	"github.com/hashicorp/packer/helper/communicator"
 	"github.com/hashicorp/packer/packer"
 	"github.com/hashicorp/packer/packer-plugin-sdk/multistep"
 	"github.com/hashicorp/packer/packer-plugin-sdk/net"
+	"github.com/hashicorp/packer/packer-plugin-sdk/template/interpolate"
 )
 
 // This step creates and runs the HTTP server that is serving files from the
@@ -20,23 +37,64 @@ import (
 //
 // Produces:
 //   http_port int - The port the HTTP server started on.
+//   http_token string - The token the files are served under, or an empty
+//     string when the files are served at the root.
 type StepHTTPServer struct {
 	HTTPDir     string
 	HTTPPortMin int
 	HTTPPortMax int
 	HTTPAddress string
 
+	// Serve the files over HTTPS with the certificate and key files, or with
+	// a self-signed certificate when they aren't set.
+	UseTLS      bool
+	TLSCertFile string
+	TLSKeyFile  string
+
+	// Serve the files under a random token, like /<token>/preseed.cfg.
+	AuthToken bool
+
+	// Glob patterns of the files rendered as templates when they are
+	// requested, with the variables of TemplateVars.
+	Templates    []string
+	TemplateVars map[string]string
+
 	l *net.Listener
 }
 
 func (s *StepHTTPServer) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
 	ui := state.Get("ui").(packer.Ui)
 
+	state.Put("http_token", "")
 	if s.HTTPDir == "" {
-		state.Put("http_port", 0)
+		multistep.PutInt(state, "http_port", 0)
 		return multistep.ActionContinue
 	}
 
+	var token string
+	if s.AuthToken {
+		b := make([]byte, 16)
+		if _, err := rand.Read(b); err != nil {
+			err := fmt.Errorf("Error generating the HTTP token: %s", err)
+			state.Put("error", err)
+			ui.Error(err.Error())
+			return multistep.ActionHalt
+		}
+		token = hex.EncodeToString(b)
+	}
+
+	var tlsConfig *tls.Config
+	if s.UseTLS {
+		cert, err := s.certificate()
+		if err != nil {
+			err := fmt.Errorf("Error loading the HTTPS certificate: %s", err)
+			state.Put("error", err)
+			ui.Error(err.Error())
+			return multistep.ActionHalt
+		}
+		tlsConfig = &tls.Config{Certificates: []tls.Certificate{cert}}
+	}
+
 	// Find an available TCP port for our HTTP server
 	var httpAddr string
 	var err error
@@ -54,19 +112,125 @@ func (s *StepHTTPServer) Run(ctx context.Context, state multistep.StateBag) mult
 		return multistep.ActionHalt
 	}
 
-	ui.Say(fmt.Sprintf("Starting HTTP server on port %d", s.l.Port))
-
 	// Start the HTTP server and run it in the background
-	fileServer := http.FileServer(http.Dir(s.HTTPDir))
-	server := &http.Server{Addr: httpAddr, Handler: fileServer}
-	go server.Serve(s.l)
+	server := &http.Server{Addr: httpAddr, Handler: s.handler(state, token)}
+	if tlsConfig != nil {
+		ui.Say(fmt.Sprintf("Starting HTTPS server on port %d", s.l.Port))
+		server.TLSConfig = tlsConfig
+		go server.ServeTLS(s.l, "", "")
+	} else {
+		ui.Say(fmt.Sprintf("Starting HTTP server on port %d", s.l.Port))
+		go server.Serve(s.l)
+	}
 
 	// Save the address into the state so it can be accessed in the future
-	state.Put("http_port", s.l.Port)
+	multistep.PutInt(state, "http_port", s.l.Port)
+	state.Put("http_token", token)
 
 	return multistep.ActionContinue
 }
 
+// handler serves the files of the directory, rendering the templates, under
+// the token when there is one.
+func (s *StepHTTPServer) handler(state multistep.StateBag, token string) http.Handler {
+	fileServer := http.FileServer(http.Dir(s.HTTPDir))
+	var handler http.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := strings.TrimPrefix(path.Clean("/"+r.URL.Path), "/")
+		if !s.isTemplate(name) {
+			fileServer.ServeHTTP(w, r)
+			return
+		}
+		content, err := s.render(state, token, name, r.Host)
+		if err != nil {
+			log.Printf("Error rendering %s: %s", name, err)
+			http.Error(w, "error rendering template", http.StatusInternalServerError)
+			return
+		}
+		http.ServeContent(w, r, name, time.Time{}, bytes.NewReader(content))
+	})
+	if token == "" {
+		return handler
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		segments := strings.SplitN(strings.TrimPrefix(r.URL.Path, "/"), "/", 2)
+		if len(segments) != 2 || subtle.ConstantTimeCompare([]byte(segments[0]), []byte(token)) != 1 {
+			http.NotFound(w, r)
+			return
+		}
+		http.StripPrefix("/"+token, handler).ServeHTTP(w, r)
+	})
+}
+
+func (s *StepHTTPServer) isTemplate(name string) bool {
+	for _, pattern := range s.Templates {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// render renders a template of the directory with the variables of the build.
+// host is the address the file was requested from, which is the HTTP IP of
+// the build.
+func (s *StepHTTPServer) render(state multistep.StateBag, token, name, host string) ([]byte, error) {
+	f, err := http.Dir(s.HTTPDir).Open("/" + name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	b, err := ioutil.ReadAll(f)
+	if err != nil {
+		return nil, err
+	}
+
+	data := map[string]interface{}{}
+	for k, v := range s.TemplateVars {
+		data[k] = v
+	}
+	data["HTTPIP"] = (&url.URL{Host: host}).Hostname()
+	data["HTTPPort"] = s.l.Port
+	data["HTTPToken"] = token
+	data["SSHPublicKey"] = ""
+	if comm, ok := state.GetOk("communicator_config"); ok {
+		data["SSHPublicKey"] = strings.TrimSpace(string(comm.(*communicator.Config).SSHPublicKey))
+	}
+
+	rendered, err := interpolate.RenderOnce(string(b), &interpolate.Context{Data: data})
+	return []byte(rendered), err
+}
+
+// certificate returns the configured certificate, or a self-signed
+// certificate valid for the next day.
+func (s *StepHTTPServer) certificate() (tls.Certificate, error) {
+	if s.TLSCertFile != "" {
+		return tls.LoadX509KeyPair(s.TLSCertFile, s.TLSKeyFile)
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: "packer"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(24 * time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, nil
+}
+
 func (s *StepHTTPServer) Cleanup(multistep.StateBag) {
 	if s.l != nil {
 		// Close the listener so that the HTTP server stops
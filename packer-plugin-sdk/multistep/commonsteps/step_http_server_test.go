@@ -0,0 +1,128 @@
+package commonsteps
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/packer/helper/communicator"
+	"github.com/hashicorp/packer/packer-plugin-sdk/multistep"
+)
+
+func testStepHTTPServer(t *testing.T) *StepHTTPServer {
+	dir, err := ioutil.TempDir("", "packer")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	files := map[string]string{
+		"static.cfg": "{{ .HTTPIP }}",
+		"ks.cfg":     "network --hostname={{ .Hostname }}\nsshkey {{ .SSHPublicKey }}\n# {{ .HTTPIP }}:{{ .HTTPPort }}",
+	}
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("err: %s", err)
+		}
+	}
+	return &StepHTTPServer{
+		HTTPDir:      dir,
+		HTTPPortMin:  8000,
+		HTTPPortMax:  9000,
+		HTTPAddress:  "127.0.0.1",
+		Templates:    []string{"*.cfg"},
+		TemplateVars: map[string]string{"Hostname": "ubuntu"},
+	}
+}
+
+func testHTTPGet(t *testing.T, client *http.Client, url string) (int, string) {
+	resp, err := client.Get(url)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer resp.Body.Close()
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	return resp.StatusCode, string(b)
+}
+
+func TestStepHTTPServer_templates(t *testing.T) {
+	state := testState(t)
+	state.Put("communicator_config", &communicator.Config{
+		SSH: communicator.SSH{SSHPublicKey: []byte("ssh-rsa AAAA packer\n")},
+	})
+	step := testStepHTTPServer(t)
+	step.Templates = []string{"ks.cfg"}
+	defer os.RemoveAll(step.HTTPDir)
+
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("bad action: %#v", action)
+	}
+	defer step.Cleanup(state)
+	port := state.Get("http_port").(int)
+
+	code, body := testHTTPGet(t, http.DefaultClient, fmt.Sprintf("http://127.0.0.1:%d/ks.cfg", port))
+	expected := fmt.Sprintf("network --hostname=ubuntu\nsshkey ssh-rsa AAAA packer\n# 127.0.0.1:%d", port)
+	if code != http.StatusOK || body != expected {
+		t.Fatalf("bad: %d %q, expected %q", code, body, expected)
+	}
+
+	// Files that aren't templates are served as they are.
+	code, body = testHTTPGet(t, http.DefaultClient, fmt.Sprintf("http://127.0.0.1:%d/static.cfg", port))
+	if code != http.StatusOK || body != "{{ .HTTPIP }}" {
+		t.Fatalf("bad: %d %q", code, body)
+	}
+}
+
+func TestStepHTTPServer_authToken(t *testing.T) {
+	state := testState(t)
+	step := testStepHTTPServer(t)
+	step.AuthToken = true
+	step.UseTLS = true
+	defer os.RemoveAll(step.HTTPDir)
+
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("bad action: %#v", action)
+	}
+	defer step.Cleanup(state)
+	port := state.Get("http_port").(int)
+	token := state.Get("http_token").(string)
+	if len(token) != 32 {
+		t.Fatalf("bad token: %q", token)
+	}
+
+	client := &http.Client{Transport: &http.Transport{
+		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+	}}
+	url := fmt.Sprintf("https://127.0.0.1:%d", port)
+	code, body := testHTTPGet(t, client, fmt.Sprintf("%s/%s/ks.cfg", url, token))
+	if code != http.StatusOK || body != fmt.Sprintf("network --hostname=ubuntu\nsshkey \n# 127.0.0.1:%d", port) {
+		t.Fatalf("bad: %d %q", code, body)
+	}
+
+	for _, path := range []string{"/ks.cfg", "/bad/ks.cfg", "/" + token, "/" + token + "x/ks.cfg"} {
+		if code, _ := testHTTPGet(t, client, url+path); code != http.StatusNotFound {
+			t.Fatalf("%s should not be found: %d", path, code)
+		}
+	}
+}
+
+func TestStepHTTPServer_noDirectory(t *testing.T) {
+	state := testState(t)
+	step := &StepHTTPServer{AuthToken: true}
+
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("bad action: %#v", action)
+	}
+	if port := state.Get("http_port").(int); port != 0 {
+		t.Fatalf("bad port: %d", port)
+	}
+	if token := state.Get("http_token").(string); token != "" {
+		t.Fatalf("bad token: %q", token)
+	}
+}
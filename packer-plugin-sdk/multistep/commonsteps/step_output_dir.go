@@ -61,6 +61,17 @@ func (s *StepOutputDir) Run(ctx context.Context, state multistep.StateBag) multi
 	return multistep.ActionContinue
 }
 
+func (s *StepOutputDir) Save(state multistep.StateBag) map[string]interface{} {
+	return nil
+}
+
+// Resume keeps the output directory of the build that is resumed, and
+// deletes it if the build fails again.
+func (s *StepOutputDir) Resume(ctx context.Context, state multistep.StateBag, saved map[string]interface{}) multistep.StepAction {
+	s.cleanup = true
+	return multistep.ActionContinue
+}
+
 func (s *StepOutputDir) Cleanup(state multistep.StateBag) {
 	if !s.cleanup {
 		return
@@ -0,0 +1,62 @@
+package commonsteps
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/hashicorp/packer/packer"
+	"github.com/hashicorp/packer/packer-plugin-sdk/multistep"
+)
+
+// StepPauseBeforeShutdown pauses the build before the machine is shut down,
+// so that it can be inspected once provisioning is complete. It either waits
+// for Duration or, if Interactive is true, for the user to press enter. It
+// does nothing if neither is set.
+//
+// Builders add it right before their shutdown step, with the values of
+// shutdowncommand.ShutdownConfig.
+//
+// Uses:
+//   ui packer.Ui
+//
+// Produces:
+//   <nothing>
+type StepPauseBeforeShutdown struct {
+	Duration    time.Duration
+	Interactive bool
+}
+
+func (s *StepPauseBeforeShutdown) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	ui := state.Get("ui").(packer.Ui)
+
+	interrupted := false
+	if s.Interactive {
+		_, err := ui.Ask("Pausing before shutdown, press enter to continue...")
+		if err == packer.ErrInterrupted {
+			interrupted = true
+		} else if err != nil {
+			// There is no one to answer, e.g. with -machine-readable
+			log.Printf("Not pausing before shutdown: %s", err)
+		}
+	} else if s.Duration > 0 {
+		ui.Say(fmt.Sprintf("Pausing %s before shutdown...", s.Duration))
+		select {
+		case <-time.After(s.Duration):
+		case <-ctx.Done():
+		}
+	}
+
+	if interrupted || ctx.Err() != nil {
+		err := errors.New("Interrupted while pausing before shutdown.")
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+
+	return multistep.ActionContinue
+}
+
+func (s *StepPauseBeforeShutdown) Cleanup(state multistep.StateBag) {}
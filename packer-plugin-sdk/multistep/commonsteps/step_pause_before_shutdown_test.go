@@ -0,0 +1,62 @@
+package commonsteps
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/packer/packer"
+	"github.com/hashicorp/packer/packer-plugin-sdk/multistep"
+)
+
+func TestStepPauseBeforeShutdown_impl(t *testing.T) {
+	var _ multistep.Step = new(StepPauseBeforeShutdown)
+}
+
+func TestStepPauseBeforeShutdown_duration(t *testing.T) {
+	state := new(multistep.BasicStateBag)
+	state.Put("ui", packer.TestUi(t))
+	step := &StepPauseBeforeShutdown{Duration: 50 * time.Millisecond}
+
+	start := time.Now()
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("bad action: %#v", action)
+	}
+	if time.Since(start) < step.Duration {
+		t.Fatal("should have paused")
+	}
+}
+
+func TestStepPauseBeforeShutdown_cancelled(t *testing.T) {
+	state := new(multistep.BasicStateBag)
+	state.Put("ui", packer.TestUi(t))
+	step := &StepPauseBeforeShutdown{Duration: time.Hour}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if action := step.Run(ctx, state); action != multistep.ActionHalt {
+		t.Fatalf("bad action: %#v", action)
+	}
+	if _, ok := state.GetOk("error"); !ok {
+		t.Fatal("should have error")
+	}
+}
+
+func TestStepPauseBeforeShutdown_interactiveWithoutTTY(t *testing.T) {
+	state := new(multistep.BasicStateBag)
+	state.Put("ui", &packer.BasicUi{
+		Reader: new(bytes.Buffer),
+		Writer: new(bytes.Buffer),
+	})
+	step := &StepPauseBeforeShutdown{Interactive: true}
+
+	// Without anyone to press enter, the build goes on
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("bad action: %#v", action)
+	}
+	if _, ok := state.GetOk("error"); ok {
+		t.Fatal("should NOT have error")
+	}
+}
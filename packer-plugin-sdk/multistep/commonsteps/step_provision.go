@@ -91,6 +91,14 @@ func PopulateProvisionHookData(state multistep.StateBag) map[string]interface{}
 	// Password.
 	hookData["WinRMPassword"] = commConf.WinRMPassword
 
+	// WinRM connection settings, so that provisioners like ansible can
+	// connect to the machine directly the same way the communicator does.
+	hookData["WinRMUseSSL"] = commConf.WinRMUseSSL
+	hookData["WinRMInsecure"] = commConf.WinRMInsecure
+	hookData["WinRMUseNTLM"] = commConf.WinRMUseNTLM
+	hookData["WinRMClientCertFile"] = commConf.WinRMClientCertFile
+	hookData["WinRMClientKeyFile"] = commConf.WinRMClientKeyFile
+
 	return hookData
 }
 
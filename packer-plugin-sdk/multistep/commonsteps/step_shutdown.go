@@ -0,0 +1,193 @@
+package commonsteps
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/packer/packer"
+	"github.com/hashicorp/packer/packer-plugin-sdk/logging"
+	"github.com/hashicorp/packer/packer-plugin-sdk/multistep"
+)
+
+// StepShutdown shuts down the machine. If a shutdown command is given it is
+// run on the guest to gracefully halt the machine, and the step waits up to
+// Timeout for the machine to shut down. Without a shutdown command, the
+// machine is forcibly stopped right away.
+//
+// The builder specific parts, checking whether the machine is shut down and
+// forcibly stopping it, are provided through the IsShutDown and Stop
+// functions, both of which are required.
+//
+// Uses:
+//   communicator packer.Communicator
+//   ui           packer.Ui
+//
+// Produces:
+//   <nothing>
+type StepShutdown struct {
+	// The command to run on the guest to gracefully shut it down. If this is
+	// empty, the machine is forcibly stopped.
+	Command string
+	// How long to wait for the machine to shut down after the shutdown
+	// command was sent.
+	Timeout time.Duration
+	// The number of times to re-send the shutdown command if it could not be
+	// started on the guest. If every attempt fails, the machine is forcibly
+	// stopped. If this is zero, a failure to send the command halts the build.
+	Retries int
+	// How long to wait between attempts to send the shutdown command.
+	RetryInterval time.Duration
+	// If true, the machine is forcibly stopped when it hasn't shut down
+	// within Timeout, and the build continues instead of failing.
+	ForceStop bool
+	// How long to wait after the machine has shut down, for example to let
+	// the hypervisor release file locks before the next steps use its disks.
+	PostShutdownDelay time.Duration
+
+	// IsShutDown reports whether the machine has finished shutting down.
+	// Errors are logged and the check is retried until Timeout.
+	IsShutDown func(multistep.StateBag) (bool, error)
+	// Stop forcibly stops the machine.
+	Stop func(multistep.StateBag) error
+}
+
+func (s *StepShutdown) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	ui, err := GetUi(state)
+	if err != nil {
+		return HaltOnError(state, nil, err)
+	}
+	logger := multistep.GetLogger(state, "shutdown")
+
+	if s.Command == "" {
+		ui.Say("Forcibly halting virtual machine...")
+		return s.stop(state, ui)
+	}
+
+	comm, err := GetCommunicator(state)
+	if err != nil {
+		return HaltOnError(state, ui, err)
+	}
+
+	ui.Say("Gracefully halting virtual machine...")
+	logger.Debugf("Executing shutdown command: %s", s.Command)
+
+	var stdout, stderr bytes.Buffer
+	if err := s.sendCommand(ctx, comm, ui, &stdout, &stderr); err != nil {
+		if ctx.Err() != nil {
+			// The build was cancelled, the machine is left to the cleanup
+			// of the build instead of being forcibly stopped.
+			err := errors.New("Interrupted while sending the shutdown command.")
+			state.Put("error", err)
+			ui.Error(err.Error())
+			return multistep.ActionHalt
+		}
+		if s.Retries == 0 {
+			err := fmt.Errorf("Failed to send shutdown command: %s", err)
+			state.Put("error", err)
+			ui.Error(err.Error())
+			return multistep.ActionHalt
+		}
+
+		ui.Error(fmt.Sprintf("Failed to send shutdown command after %d attempts: %s", s.Retries+1, err))
+		ui.Say("Forcibly halting virtual machine...")
+		return s.stop(state, ui)
+	}
+
+	// Wait for the machine to actually shut down
+	logger.Debugf("Waiting max %s for shutdown to complete", s.Timeout)
+	shutdownTimer := time.After(s.Timeout)
+	for {
+		shutDown, err := s.IsShutDown(state)
+		if err != nil {
+			logger.Warnf("Error checking if the machine is shut down: %s", err)
+		}
+		if shutDown {
+			break
+		}
+
+		select {
+		case <-shutdownTimer:
+			logger.Debugf("Shutdown stdout: %s", stdout.String())
+			logger.Debugf("Shutdown stderr: %s", stderr.String())
+			if s.ForceStop {
+				ui.Error(fmt.Sprintf("Warning: Timeout while waiting for machine to shut down, "+
+					"forcibly halting virtual machine after %s...", s.Timeout))
+				return s.stop(state, ui)
+			}
+
+			err := errors.New("Timeout while waiting for machine to shut down.")
+			state.Put("error", err)
+			ui.Error(err.Error())
+			return multistep.ActionHalt
+		case <-ctx.Done():
+			err := errors.New("Interrupted while waiting for machine to shut down.")
+			state.Put("error", err)
+			ui.Error(err.Error())
+			return multistep.ActionHalt
+		default:
+			time.Sleep(500 * time.Millisecond)
+		}
+	}
+
+	s.delay(logger)
+	logger.Infof("VM shut down.")
+	return multistep.ActionContinue
+}
+
+// stop forcibly stops the machine.
+func (s *StepShutdown) stop(state multistep.StateBag, ui packer.Ui) multistep.StepAction {
+	if err := s.Stop(state); err != nil {
+		err := fmt.Errorf("Error stopping VM: %s", err)
+		state.Put("error", err)
+		ui.Error(err.Error())
+		return multistep.ActionHalt
+	}
+
+	logger := multistep.GetLogger(state, "shutdown")
+	s.delay(logger)
+	logger.Infof("VM shut down.")
+	return multistep.ActionContinue
+}
+
+func (s *StepShutdown) delay(logger *logging.Logger) {
+	if s.PostShutdownDelay > 0 {
+		logger.Debugf("Delay for %s after shutdown to allow locks to clear...", s.PostShutdownDelay)
+		time.Sleep(s.PostShutdownDelay)
+	}
+}
+
+// sendCommand starts the shutdown command on the guest, re-sending it up to
+// s.Retries more times if it could not be started.
+func (s *StepShutdown) sendCommand(ctx context.Context, comm packer.Communicator, ui packer.Ui, stdout, stderr *bytes.Buffer) error {
+	var err error
+	for attempt := 0; attempt <= s.Retries; attempt++ {
+		if attempt > 0 {
+			ui.Error(fmt.Sprintf("Failed to send shutdown command: %s", err))
+			ui.Say(fmt.Sprintf("Retrying shutdown command in %s (attempt %d of %d)...",
+				s.RetryInterval, attempt+1, s.Retries+1))
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(s.RetryInterval):
+			}
+		}
+
+		stdout.Reset()
+		stderr.Reset()
+		cmd := &packer.RemoteCmd{
+			Command: s.Command,
+			Stdout:  stdout,
+			Stderr:  stderr,
+		}
+		if err = comm.Start(ctx, cmd); err == nil {
+			return nil
+		}
+	}
+
+	return err
+}
+
+func (s *StepShutdown) Cleanup(state multistep.StateBag) {}
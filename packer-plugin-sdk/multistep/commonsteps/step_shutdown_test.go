@@ -0,0 +1,258 @@
+package commonsteps
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/packer/packer"
+	"github.com/hashicorp/packer/packer-plugin-sdk/multistep"
+)
+
+// shutdownMachine records the calls StepShutdown makes to the builder.
+type shutdownMachine struct {
+	Running     bool
+	StopCalled  bool
+	StopErr     error
+	CheckCalled bool
+}
+
+func (m *shutdownMachine) step(command string) *StepShutdown {
+	return &StepShutdown{
+		Command: command,
+		Timeout: 1 * time.Second,
+		IsShutDown: func(multistep.StateBag) (bool, error) {
+			m.CheckCalled = true
+			return !m.Running, nil
+		},
+		Stop: func(multistep.StateBag) error {
+			m.StopCalled = true
+			return m.StopErr
+		},
+	}
+}
+
+// failingShutdownCommunicator fails to start the first FailCount commands
+// it is given.
+type failingShutdownCommunicator struct {
+	packer.MockCommunicator
+	FailCount  int
+	StartCount int
+}
+
+func (c *failingShutdownCommunicator) Start(ctx context.Context, rc *packer.RemoteCmd) error {
+	c.StartCount++
+	if c.StartCount <= c.FailCount {
+		return errors.New("connection refused")
+	}
+	return c.MockCommunicator.Start(ctx, rc)
+}
+
+func TestStepShutdown_impl(t *testing.T) {
+	var _ multistep.Step = new(StepShutdown)
+}
+
+func TestStepShutdown_noCommunicator(t *testing.T) {
+	state := testState(t)
+
+	machine := &shutdownMachine{Running: true}
+	step := machine.step("shutdown")
+
+	if action := step.Run(context.Background(), state); action != multistep.ActionHalt {
+		t.Fatalf("bad action: %#v", action)
+	}
+	err, ok := state.GetOk("error")
+	if !ok {
+		t.Fatal("should have error")
+	}
+	if _, ok := err.(*multistep.StateValueError); !ok {
+		t.Fatalf("bad error: %#v", err)
+	}
+	if machine.StopCalled {
+		t.Fatal("the machine should not be stopped")
+	}
+}
+
+func TestStepShutdown_noShutdownCommand(t *testing.T) {
+	state := testState(t)
+	comm := new(packer.MockCommunicator)
+	state.Put("communicator", comm)
+
+	machine := &shutdownMachine{Running: true}
+	step := machine.step("")
+
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("bad action: %#v", action)
+	}
+	if _, ok := state.GetOk("error"); ok {
+		t.Fatal("should NOT have error")
+	}
+	if !machine.StopCalled {
+		t.Fatal("should have called Stop")
+	}
+	if comm.StartCalled {
+		t.Fatal("should NOT have called Start")
+	}
+}
+
+func TestStepShutdown_noShutdownCommandStopErr(t *testing.T) {
+	state := testState(t)
+	state.Put("communicator", new(packer.MockCommunicator))
+
+	machine := &shutdownMachine{Running: true, StopErr: errors.New("foo")}
+	step := machine.step("")
+
+	if action := step.Run(context.Background(), state); action != multistep.ActionHalt {
+		t.Fatalf("bad action: %#v", action)
+	}
+	if _, ok := state.GetOk("error"); !ok {
+		t.Fatal("should have error")
+	}
+}
+
+func TestStepShutdown_shutdownCommand(t *testing.T) {
+	state := testState(t)
+	comm := new(packer.MockCommunicator)
+	state.Put("communicator", comm)
+
+	machine := &shutdownMachine{}
+	step := machine.step("shutdown now")
+
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("bad action: %#v", action)
+	}
+	if _, ok := state.GetOk("error"); ok {
+		t.Fatal("should NOT have error")
+	}
+	if comm.StartCmd.Command != "shutdown now" {
+		t.Fatalf("bad command: %s", comm.StartCmd.Command)
+	}
+	if !machine.CheckCalled {
+		t.Fatal("should have called IsShutDown")
+	}
+	if machine.StopCalled {
+		t.Fatal("should NOT have called Stop")
+	}
+}
+
+func TestStepShutdown_shutdownCommandTimeout(t *testing.T) {
+	state := testState(t)
+	state.Put("communicator", new(packer.MockCommunicator))
+
+	machine := &shutdownMachine{Running: true}
+	step := machine.step("shutdown now")
+	step.Timeout = 100 * time.Millisecond
+
+	if action := step.Run(context.Background(), state); action != multistep.ActionHalt {
+		t.Fatalf("bad action: %#v", action)
+	}
+	if _, ok := state.GetOk("error"); !ok {
+		t.Fatal("should have error")
+	}
+	if machine.StopCalled {
+		t.Fatal("should NOT have called Stop")
+	}
+}
+
+func TestStepShutdown_shutdownCommandTimeoutForceStop(t *testing.T) {
+	state := testState(t)
+	state.Put("communicator", new(packer.MockCommunicator))
+
+	machine := &shutdownMachine{Running: true}
+	step := machine.step("shutdown now")
+	step.Timeout = 100 * time.Millisecond
+	step.ForceStop = true
+
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("bad action: %#v", action)
+	}
+	if _, ok := state.GetOk("error"); ok {
+		t.Fatal("should NOT have error")
+	}
+	if !machine.StopCalled {
+		t.Fatal("should have called Stop")
+	}
+}
+
+func TestStepShutdown_shutdownCommandRetries(t *testing.T) {
+	state := testState(t)
+	comm := &failingShutdownCommunicator{FailCount: 2}
+	state.Put("communicator", comm)
+
+	machine := &shutdownMachine{}
+	step := machine.step("shutdown now")
+	step.Retries = 2
+	step.RetryInterval = 10 * time.Millisecond
+
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("bad action: %#v", action)
+	}
+	if comm.StartCount != 3 {
+		t.Fatalf("should have called Start 3 times. Got: %d", comm.StartCount)
+	}
+	if machine.StopCalled {
+		t.Fatal("should NOT have called Stop")
+	}
+}
+
+func TestStepShutdown_shutdownCommandRetriesExhausted(t *testing.T) {
+	state := testState(t)
+	comm := &failingShutdownCommunicator{FailCount: 3}
+	state.Put("communicator", comm)
+
+	machine := &shutdownMachine{Running: true}
+	step := machine.step("shutdown now")
+	step.Retries = 2
+	step.RetryInterval = 10 * time.Millisecond
+
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("bad action: %#v", action)
+	}
+	if !machine.StopCalled {
+		t.Fatal("should have called Stop")
+	}
+}
+
+func TestStepShutdown_shutdownCommandRetriesCancelled(t *testing.T) {
+	state := testState(t)
+	comm := &failingShutdownCommunicator{FailCount: 3}
+	state.Put("communicator", comm)
+
+	machine := &shutdownMachine{Running: true}
+	step := machine.step("shutdown now")
+	step.Retries = 2
+	step.RetryInterval = time.Minute
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	if action := step.Run(ctx, state); action != multistep.ActionHalt {
+		t.Fatalf("bad action: %#v", action)
+	}
+	if _, ok := state.GetOk("error"); !ok {
+		t.Fatal("should have error")
+	}
+	if comm.StartCount != 1 {
+		t.Fatalf("should have called Start once. Got: %d", comm.StartCount)
+	}
+	if machine.StopCalled {
+		t.Fatal("should NOT have called Stop")
+	}
+}
+
+func TestStepShutdown_postShutdownDelay(t *testing.T) {
+	state := testState(t)
+	state.Put("communicator", new(packer.MockCommunicator))
+
+	machine := &shutdownMachine{}
+	step := machine.step("shutdown now")
+	step.PostShutdownDelay = 200 * time.Millisecond
+
+	start := time.Now()
+	if action := step.Run(context.Background(), state); action != multistep.ActionContinue {
+		t.Fatalf("bad action: %#v", action)
+	}
+	if elapsed := time.Since(start); elapsed < step.PostShutdownDelay {
+		t.Fatalf("should have waited %s after shutdown, waited %s", step.PostShutdownDelay, elapsed)
+	}
+}
@@ -3,7 +3,6 @@ package multistep
 import (
 	"context"
 	"fmt"
-	"reflect"
 	"sync"
 )
 
@@ -67,14 +66,8 @@ func (r *DebugRunner) Run(ctx context.Context, state StateBag) {
 			continue
 		}
 		steps[i*2] = step
-		name := ""
-		if wrapped, ok := step.(StepWrapper); ok {
-			name = wrapped.InnerStepName()
-		} else {
-			name = reflect.Indirect(reflect.ValueOf(step)).Type().Name()
-		}
 		steps[(i*2)+1] = &debugStepPause{
-			name,
+			StepName(step),
 			pauseFn,
 		}
 	}
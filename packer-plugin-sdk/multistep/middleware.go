@@ -0,0 +1,117 @@
+package multistep
+
+import (
+	"context"
+	"reflect"
+	"time"
+)
+
+// StepMiddleware wraps a step to add behaviour around it, like timing,
+// logging or checkpoints. The returned step should implement StepWrapper so
+// that the name of the wrapped step is kept.
+type StepMiddleware func(Step) Step
+
+// WrapSteps returns the steps wrapped with the middlewares, the first
+// middleware being the outermost one. nil steps are left as they are.
+func WrapSteps(steps []Step, middlewares ...StepMiddleware) []Step {
+	wrapped := make([]Step, len(steps))
+	for i, step := range steps {
+		if step == nil {
+			continue
+		}
+		for j := len(middlewares) - 1; j >= 0; j-- {
+			step = middlewares[j](step)
+		}
+		wrapped[i] = step
+	}
+	return wrapped
+}
+
+// StepName returns the human readable name of a step: the name of the step it
+// wraps for a StepWrapper, or the name of its type.
+func StepName(step Step) string {
+	if wrapped, ok := step.(StepWrapper); ok {
+		return wrapped.InnerStepName()
+	}
+	return reflect.Indirect(reflect.ValueOf(step)).Type().Name()
+}
+
+// StepHooks are functions called around the run and the cleanup of steps,
+// with the name of the step. Each of them is optional.
+type StepHooks struct {
+	BeforeRun     func(ctx context.Context, name string, state StateBag)
+	AfterRun      func(ctx context.Context, name string, state StateBag, action StepAction)
+	BeforeCleanup func(name string, state StateBag)
+	AfterCleanup  func(name string, state StateBag)
+}
+
+// Middleware is the StepMiddleware calling the hooks around each step.
+func (h StepHooks) Middleware(step Step) Step {
+	return &hookedStep{step: step, hooks: h}
+}
+
+type hookedStep struct {
+	step  Step
+	hooks StepHooks
+}
+
+func (s *hookedStep) InnerStepName() string {
+	return StepName(s.step)
+}
+
+func (s *hookedStep) Run(ctx context.Context, state StateBag) StepAction {
+	if s.hooks.BeforeRun != nil {
+		s.hooks.BeforeRun(ctx, s.InnerStepName(), state)
+	}
+	action := s.step.Run(ctx, state)
+	if s.hooks.AfterRun != nil {
+		s.hooks.AfterRun(ctx, s.InnerStepName(), state, action)
+	}
+	return action
+}
+
+func (s *hookedStep) Cleanup(state StateBag) {
+	s.CleanupContext(context.Background(), state)
+}
+
+func (s *hookedStep) CleanupContext(ctx context.Context, state StateBag) {
+	if s.hooks.BeforeCleanup != nil {
+		s.hooks.BeforeCleanup(s.InnerStepName(), state)
+	}
+	CleanupStep(ctx, s.step, state)
+	if s.hooks.AfterCleanup != nil {
+		s.hooks.AfterCleanup(s.InnerStepName(), state)
+	}
+}
+
+// StepTimeout returns a StepMiddleware cancelling the context of each step
+// once it has been running for timeout. It's up to the steps to stop when
+// their context is cancelled.
+func StepTimeout(timeout time.Duration) StepMiddleware {
+	return func(step Step) Step {
+		return &timeoutStep{step: step, timeout: timeout}
+	}
+}
+
+type timeoutStep struct {
+	step    Step
+	timeout time.Duration
+}
+
+func (s *timeoutStep) InnerStepName() string {
+	return StepName(s.step)
+}
+
+func (s *timeoutStep) Run(ctx context.Context, state StateBag) StepAction {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+	return s.step.Run(ctx, state)
+}
+
+func (s *timeoutStep) Cleanup(state StateBag) {
+	s.CleanupContext(context.Background(), state)
+}
+
+func (s *timeoutStep) CleanupContext(ctx context.Context, state StateBag) {
+	CleanupStep(ctx, s.step, state)
+}
@@ -0,0 +1,75 @@
+package multistep
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestWrapSteps(t *testing.T) {
+	data := new(BasicStateBag)
+	var calls []string
+	middleware := func(prefix string) StepMiddleware {
+		return StepHooks{
+			BeforeRun: func(_ context.Context, name string, _ StateBag) {
+				calls = append(calls, prefix+" before run "+name)
+			},
+			AfterRun: func(_ context.Context, name string, _ StateBag, action StepAction) {
+				calls = append(calls, prefix+" after run "+name+" "+action.String())
+			},
+			BeforeCleanup: func(name string, _ StateBag) {
+				calls = append(calls, prefix+" before cleanup "+name)
+			},
+			AfterCleanup: func(name string, _ StateBag) {
+				calls = append(calls, prefix+" after cleanup "+name)
+			},
+		}.Middleware
+	}
+
+	steps := WrapSteps([]Step{&TestStepAcc{Data: "a"}, nil}, middleware("outer"), middleware("inner"))
+	if steps[1] != nil {
+		t.Fatalf("nil steps should not be wrapped: %#v", steps[1])
+	}
+	if name := StepName(steps[0]); name != "TestStepAcc" {
+		t.Fatalf("bad name: %q", name)
+	}
+
+	r := &BasicRunner{Steps: steps}
+	r.Run(context.Background(), data)
+
+	expected := []string{
+		"outer before run TestStepAcc",
+		"inner before run TestStepAcc",
+		"inner after run TestStepAcc ActionContinue",
+		"outer after run TestStepAcc ActionContinue",
+		"outer before cleanup TestStepAcc",
+		"inner before cleanup TestStepAcc",
+		"inner after cleanup TestStepAcc",
+		"outer after cleanup TestStepAcc",
+	}
+	if !reflect.DeepEqual(calls, expected) {
+		t.Fatalf("unexpected calls: %#v", calls)
+	}
+	if results := data.Get("data").([]string); !reflect.DeepEqual(results, []string{"a"}) {
+		t.Fatalf("unexpected result: %#v", results)
+	}
+}
+
+func TestStepTimeout(t *testing.T) {
+	data := new(BasicStateBag)
+	step := TestStepFn{run: func(ctx context.Context, state StateBag) StepAction {
+		<-ctx.Done()
+		return ActionHalt
+	}}
+
+	r := &BasicRunner{Steps: WrapSteps([]Step{step}, StepTimeout(10*time.Millisecond))}
+	r.Run(context.Background(), data)
+
+	if _, ok := data.GetOk(StateHalted); !ok {
+		t.Fatal("the step should have halted once timed out")
+	}
+	if _, ok := data.GetOk(StateCancelled); ok {
+		t.Fatal("the runner should not be cancelled by the timeout of a step")
+	}
+}
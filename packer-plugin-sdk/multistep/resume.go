@@ -0,0 +1,44 @@
+package multistep
+
+import "context"
+
+// ResumableStep is a Step whose results outlive a failed build, such as a
+// created virtual machine, so that the build can be resumed with -resume
+// without running the step again.
+//
+// When a build fails in resume mode, the steps that completed are not cleaned
+// up if they are resumable, and the values returned by their Save method are
+// written to disk. When the build is resumed, Resume is called instead of Run
+// for each of these steps. Steps that aren't resumable run again.
+type ResumableStep interface {
+	Step
+
+	// Save is called after the step ran successfully. It returns the values
+	// the step needs to be resumed, typically the state it produced. The
+	// values must be encodable with encoding/gob.
+	Save(StateBag) map[string]interface{}
+
+	// Resume is called instead of Run when the step completed in the build
+	// that is resumed, with the values returned by Save. It puts back what Run
+	// put in the state, and sets up what Cleanup needs.
+	Resume(context.Context, StateBag, map[string]interface{}) StepAction
+}
+
+// SaveKeys returns the values of the given state keys that are set, for a
+// ResumableStep to save them.
+func SaveKeys(state StateBag, keys ...string) map[string]interface{} {
+	saved := make(map[string]interface{})
+	for _, key := range keys {
+		if v, ok := state.GetOk(key); ok {
+			saved[key] = v
+		}
+	}
+	return saved
+}
+
+// RestoreKeys puts the values saved with SaveKeys back in the state.
+func RestoreKeys(state StateBag, saved map[string]interface{}) {
+	for key, v := range saved {
+		state.Put(key, v)
+	}
+}
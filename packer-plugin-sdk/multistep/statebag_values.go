@@ -0,0 +1,91 @@
+package multistep
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/hashicorp/packer/packer-plugin-sdk/logging"
+)
+
+// StateValueError is the error of a value of a StateBag that isn't set or
+// isn't of the expected type, which is usually a bug in the wiring of steps.
+type StateValueError struct {
+	Key string
+	// Value is the value of the state, nil when it isn't set.
+	Value interface{}
+	// Expected is the type the value was expected to be.
+	Expected reflect.Type
+}
+
+func (e *StateValueError) Error() string {
+	if e.Value == nil {
+		return fmt.Sprintf("%q isn't set in the state, expected a %s", e.Key, e.Expected)
+	}
+	return fmt.Sprintf("%q of the state is a %T, expected a %s", e.Key, e.Value, e.Expected)
+}
+
+// GetValue sets the value of key in state into the value ptr points to. ptr
+// must be a non-nil pointer, like to a string or to an interface. A
+// *StateValueError is returned when the value isn't set or can't be assigned
+// to the value of ptr.
+func GetValue(state StateBag, key string, ptr interface{}) error {
+	v := reflect.ValueOf(ptr)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		panic("multistep: GetValue needs a non-nil pointer")
+	}
+	v = v.Elem()
+
+	value, _ := state.GetOk(key)
+	if value == nil || !reflect.TypeOf(value).AssignableTo(v.Type()) {
+		return &StateValueError{Key: key, Value: value, Expected: v.Type()}
+	}
+	v.Set(reflect.ValueOf(value))
+	return nil
+}
+
+// GetString returns the string value of key in state.
+func GetString(state StateBag, key string) (string, error) {
+	var s string
+	err := GetValue(state, key, &s)
+	return s, err
+}
+
+// GetInt returns the int value of key in state.
+func GetInt(state StateBag, key string) (int, error) {
+	var i int
+	err := GetValue(state, key, &i)
+	return i, err
+}
+
+// GetBool returns the bool value of key in state.
+func GetBool(state StateBag, key string) (bool, error) {
+	var b bool
+	err := GetValue(state, key, &b)
+	return b, err
+}
+
+// PutString puts the string s into state under key. Unlike Put, the type of
+// the value is checked when compiling, so that GetString can read it back.
+func PutString(state StateBag, key string, s string) {
+	state.Put(key, s)
+}
+
+// PutInt puts the int i into state under key, for GetInt to read it back.
+func PutInt(state StateBag, key string, i int) {
+	state.Put(key, i)
+}
+
+// PutBool puts the bool b into state under key, for GetBool to read it back.
+func PutBool(state StateBag, key string, b bool) {
+	state.Put(key, b)
+}
+
+// GetLogger returns the logger of component for the step that runs. Its lines
+// tell the build and the step they come from when the runner puts a logger
+// under the "logger" key, like the runners of commonsteps do.
+func GetLogger(state StateBag, component string) *logging.Logger {
+	if logger, ok := state.Get("logger").(*logging.Logger); ok {
+		return logger.Named(component)
+	}
+	return logging.New(component)
+}
@@ -0,0 +1,71 @@
+package multistep
+
+import "testing"
+
+func TestGetValue(t *testing.T) {
+	state := new(BasicStateBag)
+	state.Put("name", "vm")
+	state.Put("port", 8080)
+	state.Put("step", TestStepAcc{Data: "a"})
+
+	if name, err := GetString(state, "name"); err != nil || name != "vm" {
+		t.Fatalf("bad: %q %s", name, err)
+	}
+	if port, err := GetInt(state, "port"); err != nil || port != 8080 {
+		t.Fatalf("bad: %d %s", port, err)
+	}
+
+	var step Step
+	if err := GetValue(state, "step", &step); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if step.(TestStepAcc).Data != "a" {
+		t.Fatalf("bad: %#v", step)
+	}
+}
+
+func TestPutValue(t *testing.T) {
+	state := new(BasicStateBag)
+	PutString(state, "name", "vm")
+	PutInt(state, "port", 8080)
+	PutBool(state, "headless", true)
+
+	if name, err := GetString(state, "name"); err != nil || name != "vm" {
+		t.Fatalf("bad: %q %s", name, err)
+	}
+	if port, err := GetInt(state, "port"); err != nil || port != 8080 {
+		t.Fatalf("bad: %d %s", port, err)
+	}
+	if headless, err := GetBool(state, "headless"); err != nil || !headless {
+		t.Fatalf("bad: %t %s", headless, err)
+	}
+}
+
+func TestGetValue_errors(t *testing.T) {
+	state := new(BasicStateBag)
+	state.Put("port", "8080")
+
+	_, err := GetInt(state, "port")
+	if err == nil || err.Error() != `"port" of the state is a string, expected a int` {
+		t.Fatalf("bad: %v", err)
+	}
+	if _, ok := err.(*StateValueError); !ok {
+		t.Fatalf("bad: %#v", err)
+	}
+
+	var runner Runner
+	err = GetValue(state, "runner", &runner)
+	if err == nil || err.Error() != `"runner" isn't set in the state, expected a multistep.Runner` {
+		t.Fatalf("bad: %v", err)
+	}
+
+	// The value is only set when it's of the expected type.
+	state.Put("runner", TestStepAcc{})
+	runner = &BasicRunner{}
+	if err := GetValue(state, "runner", &runner); err == nil {
+		t.Fatal("should error")
+	}
+	if _, ok := runner.(*BasicRunner); !ok {
+		t.Fatalf("the value should not be changed: %#v", runner)
+	}
+}
@@ -8,31 +8,58 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
-	"log"
 	"net"
 	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/hashicorp/packer/packer"
+	"github.com/hashicorp/packer/packer-plugin-sdk/logging"
+	"github.com/hashicorp/packer/packer-plugin-sdk/retry"
 	"github.com/hashicorp/packer/packer-plugin-sdk/tmp"
 	"github.com/pkg/sftp"
 	"golang.org/x/crypto/ssh"
 	"golang.org/x/crypto/ssh/agent"
 )
 
+// logger logs the connections, commands and file transfers of the
+// communicator.
+var logger = logging.New("ssh")
+
 // ErrHandshakeTimeout is returned from New() whenever we're unable to establish
 // an ssh connection within a certain timeframe. By default the handshake time-
 // out period is 1 minute. You can change it with Config.HandshakeTimeout.
 var ErrHandshakeTimeout = fmt.Errorf("Timeout during SSH handshake")
 
+// comm runs all the commands and file transfers of a build as channels of a
+// single SSH connection, that it reopens when it is lost.
 type comm struct {
 	client  *ssh.Client
 	config  *Config
 	conn    net.Conn
 	address string
+
+	// sftpClient is reused by the sftp file transfers, so that they don't
+	// each start a new sftp subsystem.
+	sftpClient *sftpClient
+
+	// m protects the connection, the client and the sftp client from
+	// concurrent reconnections.
+	m sync.Mutex
+
+	// gzip tells whether the remote host has gzip, for compressed uploads.
+	gzip     bool
+	gzipOnce sync.Once
+
+	// sshConfig is Config.SSHConfig, checking the host key with checkHostKey.
+	sshConfig *ssh.ClientConfig
+	// hostKey is the host key of the last connection.
+	hostKey ssh.PublicKey
+	// expectDisconnect is set by ExpectDisconnect until the next connection.
+	expectDisconnect bool
 }
 
 // TunnelDirection is the supported tunnel directions
@@ -76,13 +103,38 @@ type Config struct {
 	// UseSftp, if true, sftp will be used instead of scp for file transfers
 	UseSftp bool
 
-	// KeepAliveInterval sets how often we send a channel request to the
+	// KeepAliveInterval sets how often we send a keepalive request to the
 	// server. A value < 0 disables.
 	KeepAliveInterval time.Duration
 
+	// KeepAliveCountMax is how many keepalive requests can go unanswered
+	// before the connection is closed, so that the next session reconnects.
+	// 0 never closes the connection.
+	KeepAliveCountMax int
+
 	// Timeout is how long to wait for a read or write to succeed.
 	Timeout time.Duration
 
+	// UploadConcurrency is how many chunks of a file are written at once by
+	// sftp uploads. Files are written sequentially when it is less than 2.
+	UploadConcurrency int
+
+	// UploadBufferSize is the size of the chunks of the concurrent sftp
+	// uploads. Defaults to DefaultUploadBufferSize.
+	UploadBufferSize int
+
+	// UploadCompression, if true, compresses uploaded files with gzip when
+	// the remote host has it.
+	UploadCompression bool
+
+	// ReconnectTimeout is how long Reconnect waits for the machine to come
+	// back. Defaults to packer.DefaultReconnectTimeout.
+	ReconnectTimeout time.Duration
+
+	// PinHostKey, if true, refuses to reconnect to a machine whose host key
+	// changed, unless ExpectDisconnect was called before.
+	PinHostKey bool
+
 	Tunnels []TunnelSpec
 }
 
@@ -94,6 +146,9 @@ func New(address string, config *Config) (result *comm, err error) {
 		config:  config,
 		address: address,
 	}
+	sshConfig := *config.SSHConfig
+	sshConfig.HostKeyCallback = result.checkHostKey(config.SSHConfig.HostKeyCallback)
+	result.sshConfig = &sshConfig
 
 	if err = result.reconnect(); err != nil {
 		result = nil
@@ -127,26 +182,12 @@ func (c *comm) Start(ctx context.Context, cmd *packer.RemoteCmd) (err error) {
 		}
 	}
 
-	log.Printf("[DEBUG] starting remote command: %s", cmd.Command)
+	logger.Debugf("starting remote command: %s", cmd.Command)
 	err = session.Start(cmd.Command + "\n")
 	if err != nil {
 		return
 	}
 
-	go func() {
-		if c.config.KeepAliveInterval <= 0 {
-			return
-		}
-		c := time.NewTicker(c.config.KeepAliveInterval)
-		defer c.Stop()
-		for range c.C {
-			_, err := session.SendRequest("keepalive@packer.io", true, nil)
-			if err != nil {
-				return
-			}
-		}
-	}()
-
 	// Start a goroutine to wait for the session to end and set the
 	// exit boolean and status.
 	go func() {
@@ -158,12 +199,12 @@ func (c *comm) Start(ctx context.Context, cmd *packer.RemoteCmd) (err error) {
 			switch err.(type) {
 			case *ssh.ExitError:
 				exitStatus = err.(*ssh.ExitError).ExitStatus()
-				log.Printf("[ERROR] Remote command exited with '%d': %s", exitStatus, cmd.Command)
+				logger.Errorf("Remote command exited with '%d': %s", exitStatus, cmd.Command)
 			case *ssh.ExitMissingError:
-				log.Printf("[ERROR] Remote command exited without exit status or exit signal.")
+				logger.Errorf("Remote command exited without exit status or exit signal.")
 				exitStatus = packer.CmdDisconnect
 			default:
-				log.Printf("[ERROR] Error occurred waiting for ssh session: %s", err.Error())
+				logger.Errorf("Error occurred waiting for ssh session: %s", err.Error())
 			}
 		}
 		cmd.SetExited(exitStatus)
@@ -172,6 +213,9 @@ func (c *comm) Start(ctx context.Context, cmd *packer.RemoteCmd) (err error) {
 }
 
 func (c *comm) Upload(path string, input io.Reader, fi *os.FileInfo) error {
+	if c.config.UploadCompression && c.remoteGzip() {
+		return c.gzipUploadSession(path, input, fi)
+	}
 	if c.config.UseSftp {
 		return c.sftpUploadSession(path, input, fi)
 	} else {
@@ -180,7 +224,7 @@ func (c *comm) Upload(path string, input io.Reader, fi *os.FileInfo) error {
 }
 
 func (c *comm) UploadDir(dst string, src string, excl []string) error {
-	log.Printf("[DEBUG] Upload dir '%s' to '%s'", src, dst)
+	logger.Debugf("Upload dir '%s' to '%s'", src, dst)
 	if c.config.UseSftp {
 		return c.sftpUploadDirSession(dst, src, excl)
 	} else {
@@ -189,7 +233,7 @@ func (c *comm) UploadDir(dst string, src string, excl []string) error {
 }
 
 func (c *comm) DownloadDir(src string, dst string, excl []string) error {
-	log.Printf("[DEBUG] Download dir '%s' to '%s'", src, dst)
+	logger.Debugf("Download dir '%s' to '%s'", src, dst)
 	scpFunc := func(w io.Writer, stdoutR *bufio.Reader) error {
 		dirStack := []string{dst}
 		for {
@@ -224,7 +268,7 @@ func (c *comm) DownloadDir(src string, dst string, excl []string) error {
 			var mode int64
 			var size int64
 			var name string
-			log.Printf("[DEBUG] Download dir str:%s", fi)
+			logger.Debugf("Download dir str:%s", fi)
 			n, err := fmt.Sscanf(fi[1:], "%o %d %s", &mode, &size, &name)
 			if err != nil || n != 3 {
 				return fmt.Errorf("can't parse server response (%s)", fi)
@@ -233,7 +277,7 @@ func (c *comm) DownloadDir(src string, dst string, excl []string) error {
 				return fmt.Errorf("negative file size")
 			}
 
-			log.Printf("[DEBUG] Download dir mode:%0o size:%d name:%s", mode, size, name)
+			logger.Debugf("Download dir mode:%0o size:%d name:%s", mode, size, name)
 
 			dst = filepath.Join(dirStack...)
 			switch fi[0] {
@@ -268,7 +312,10 @@ func (c *comm) Download(path string, output io.Writer) error {
 }
 
 func (c *comm) newSession() (session *ssh.Session, err error) {
-	log.Println("[DEBUG] Opening new ssh session")
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	logger.Debugf("Opening new ssh session")
 	if c.client == nil {
 		err = errors.New("client not available")
 	} else {
@@ -276,7 +323,7 @@ func (c *comm) newSession() (session *ssh.Session, err error) {
 	}
 
 	if err != nil {
-		log.Printf("[ERROR] ssh session open error: '%s', attempting reconnect", err)
+		logger.Errorf("ssh session open error: '%s', attempting reconnect", err)
 		if err := c.reconnect(); err != nil {
 			return nil, err
 		}
@@ -291,17 +338,23 @@ func (c *comm) newSession() (session *ssh.Session, err error) {
 	return session, nil
 }
 
+// reconnect opens a new SSH connection. The caller must hold c.m, except
+// in New.
 func (c *comm) reconnect() (err error) {
+	if c.sftpClient != nil {
+		c.sftpClient.retire()
+	}
 	if c.conn != nil {
 		// Ignore errors here because we don't care if it fails
 		c.conn.Close()
 	}
 
-	// Set the conn and client to nil since we'll recreate it
+	// Set the conn and clients to nil since we'll recreate them
 	c.conn = nil
 	c.client = nil
+	c.sftpClient = nil
 
-	log.Printf("[DEBUG] reconnecting to TCP connection for SSH")
+	logger.Debugf("reconnecting to TCP connection for SSH")
 	c.conn, err = c.config.Connection()
 	if err != nil {
 		// Explicitly set this to the REAL nil. Connection() can return
@@ -312,7 +365,7 @@ func (c *comm) reconnect() (err error) {
 		// http://golang.org/doc/faq#nil_error
 		c.conn = nil
 
-		log.Printf("[ERROR] reconnection error: %s", err)
+		logger.Errorf("reconnection error: %s", err)
 		return
 	}
 
@@ -320,7 +373,7 @@ func (c *comm) reconnect() (err error) {
 		c.conn = &timeoutConn{c.conn, c.config.Timeout, c.config.Timeout}
 	}
 
-	log.Printf("[DEBUG] handshaking with SSH")
+	logger.Debugf("handshaking with SSH")
 
 	// Default timeout to 1 minute if it wasn't specified (zero value). For
 	// when you need to handshake from low orbit.
@@ -338,7 +391,7 @@ func (c *comm) reconnect() (err error) {
 	var req <-chan *ssh.Request
 
 	go func() {
-		sshConn, sshChan, req, err = ssh.NewClientConn(c.conn, c.address, c.config.SSHConfig)
+		sshConn, sshChan, req, err = ssh.NewClientConn(c.conn, c.address, c.sshConfig)
 		close(connectionEstablished)
 	}()
 
@@ -359,9 +412,11 @@ func (c *comm) reconnect() (err error) {
 	if err != nil {
 		return
 	}
-	log.Printf("[DEBUG] handshake complete!")
+	logger.Debugf("handshake complete!")
+	c.expectDisconnect = false
 	if sshConn != nil {
 		c.client = ssh.NewClient(sshConn, sshChan, req)
+		go c.keepAlive(c.client)
 	}
 	c.connectToAgent()
 	err = c.connectTunnels(sshConn)
@@ -372,6 +427,97 @@ func (c *comm) reconnect() (err error) {
 	return
 }
 
+// ExpectDisconnect allows the host key to change on the next connection,
+// since the machine is about to restart.
+func (c *comm) ExpectDisconnect() error {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	c.expectDisconnect = true
+	return nil
+}
+
+// Reconnect closes the current connection and opens a new one, retrying
+// until ReconnectTimeout elapses.
+func (c *comm) Reconnect(ctx context.Context) error {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	timeout := c.config.ReconnectTimeout
+	if timeout <= 0 {
+		timeout = packer.DefaultReconnectTimeout
+	}
+	logger.Infof("waiting up to %s for the machine to come back", timeout)
+	err := retry.Config{StartTimeout: timeout}.Run(ctx, func(context.Context) error {
+		return c.reconnect()
+	})
+	if err != nil {
+		return fmt.Errorf("the machine didn't come back after %s: %s", timeout, err)
+	}
+	return nil
+}
+
+// checkHostKey returns a host key callback that remembers the host key of
+// each connection, and that refuses a changed host key if PinHostKey is set
+// and no disconnect was expected. next is called first.
+func (c *comm) checkHostKey(next ssh.HostKeyCallback) ssh.HostKeyCallback {
+	return func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		if next != nil {
+			if err := next(hostname, remote, key); err != nil {
+				return err
+			}
+		}
+		if c.hostKey != nil && !bytes.Equal(c.hostKey.Marshal(), key.Marshal()) {
+			if c.config.PinHostKey && !c.expectDisconnect {
+				return fmt.Errorf("host key of %s changed from %s to %s",
+					hostname, ssh.FingerprintSHA256(c.hostKey), ssh.FingerprintSHA256(key))
+			}
+			logger.Infof("host key of %s changed to %s", hostname, ssh.FingerprintSHA256(key))
+		}
+		c.hostKey = key
+		return nil
+	}
+}
+
+// keepAlive sends keepalive requests over the connection of client until it
+// is closed, so that idle connections between two provisioners are not
+// dropped. It closes the connection when KeepAliveCountMax requests in a
+// row get no reply.
+func (c *comm) keepAlive(client *ssh.Client) {
+	interval := c.config.KeepAliveInterval
+	if interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	missed := 0
+	for range ticker.C {
+		replied := make(chan error, 1)
+		go func() {
+			_, _, err := client.SendRequest("keepalive@packer.io", true, nil)
+			replied <- err
+		}()
+
+		select {
+		case err := <-replied:
+			if err != nil {
+				// The connection is closed
+				return
+			}
+			missed = 0
+		case <-time.After(interval):
+			missed++
+		}
+
+		if c.config.KeepAliveCountMax > 0 && missed >= c.config.KeepAliveCountMax {
+			logger.Errorf("%d ssh keepalive requests got no reply, closing the connection", missed)
+			client.Close()
+			return
+		}
+	}
+}
+
 func (c *comm) connectTunnels(sshConn ssh.Conn) (err error) {
 	if c.client == nil {
 		return
@@ -383,7 +529,7 @@ func (c *comm) connectTunnels(sshConn ssh.Conn) (err error) {
 	}
 
 	// Start remote forwards of ports to ourselves.
-	log.Printf("[DEBUG] Tunnel configuration: %v", c.config.Tunnels)
+	logger.Debugf("Tunnel configuration: %v", c.config.Tunnels)
 	for _, v := range c.config.Tunnels {
 		done := make(chan struct{})
 		var listener net.Listener
@@ -395,7 +541,7 @@ func (c *comm) connectTunnels(sshConn ssh.Conn) (err error) {
 				err = fmt.Errorf("Tunnel: Failed to bind remote ('%v'): %s", v, err)
 				return
 			}
-			log.Printf("[INFO] Tunnel: Remote bound on %s forwarding to %s", v.ListenAddr, v.ForwardAddr)
+			logger.Infof("Tunnel: Remote bound on %s forwarding to %s", v.ListenAddr, v.ForwardAddr)
 			connectFunc := ConnectFunc(v.ForwardType, v.ForwardAddr)
 			go ProxyServe(listener, done, connectFunc)
 			// Wait for our sshConn to be shutdown
@@ -408,7 +554,7 @@ func (c *comm) connectTunnels(sshConn ssh.Conn) (err error) {
 				err = fmt.Errorf("Tunnel: Failed to bind local ('%v'): %s", v, err)
 				return
 			}
-			log.Printf("[INFO] Tunnel: Local bound on %s forwarding to %s", v.ListenAddr, v.ForwardAddr)
+			logger.Infof("Tunnel: Local bound on %s forwarding to %s", v.ListenAddr, v.ForwardAddr)
 			connectFunc := func() (net.Conn, error) {
 				// This Dial occurs on the SSH server's side
 				return c.client.Dial(v.ForwardType, v.ForwardAddr)
@@ -428,7 +574,7 @@ func (c *comm) connectTunnels(sshConn ssh.Conn) (err error) {
 // shutdownProxyTunnel waits for our sshConn to be shutdown and closes the listeners
 func shutdownProxyTunnel(sshConn ssh.Conn, done chan struct{}, listener net.Listener) {
 	sshConn.Wait()
-	log.Printf("[INFO] Tunnel: Shutting down listener %v", listener)
+	logger.Infof("Tunnel: Shutting down listener %v", listener)
 	done <- struct{}{}
 	close(done)
 	listener.Close()
@@ -440,26 +586,26 @@ func (c *comm) connectToAgent() {
 	}
 
 	if c.config.DisableAgentForwarding {
-		log.Printf("[INFO] SSH agent forwarding is disabled.")
+		logger.Infof("SSH agent forwarding is disabled.")
 		return
 	}
 
 	// open connection to the local agent
 	socketLocation := os.Getenv("SSH_AUTH_SOCK")
 	if socketLocation == "" {
-		log.Printf("[INFO] no local agent socket, will not connect agent")
+		logger.Infof("no local agent socket, will not connect agent")
 		return
 	}
 	agentConn, err := net.Dial("unix", socketLocation)
 	if err != nil {
-		log.Printf("[ERROR] could not connect to local agent socket: %s", socketLocation)
+		logger.Errorf("could not connect to local agent socket: %s", socketLocation)
 		return
 	}
 
 	// create agent and add in auth
 	forwardingAgent := agent.NewClient(agentConn)
 	if forwardingAgent == nil {
-		log.Printf("[ERROR] Could not create agent client")
+		logger.Errorf("Could not create agent client")
 		agentConn.Close()
 		return
 	}
@@ -470,20 +616,22 @@ func (c *comm) connectToAgent() {
 	c.config.SSHConfig.Auth = append(c.config.SSHConfig.Auth, auth)
 	agent.ForwardToAgent(c.client, forwardingAgent)
 
-	// Setup a session to request agent forwarding
-	session, err := c.newSession()
+	// Setup a session to request agent forwarding. This runs while
+	// connecting, so c.m is already held and newSession can't be used.
+	session, err := c.client.NewSession()
 	if err != nil {
+		logger.Errorf("ssh session open error: '%s'", err)
 		return
 	}
 	defer session.Close()
 
 	err = agent.RequestAgentForwarding(session)
 	if err != nil {
-		log.Printf("[ERROR] RequestAgentForwarding: %#v", err)
+		logger.Errorf("RequestAgentForwarding: %#v", err)
 		return
 	}
 
-	log.Printf("[INFO] agent forwarding enabled")
+	logger.Infof("agent forwarding enabled")
 	return
 }
 
@@ -496,21 +644,27 @@ func (c *comm) sftpUploadSession(path string, input io.Reader, fi *os.FileInfo)
 }
 
 func (c *comm) sftpUploadFile(path string, input io.Reader, client *sftp.Client, fi *os.FileInfo) error {
-	log.Printf("[DEBUG] sftp: uploading %s", path)
-	f, err := client.Create(path)
-	if err != nil {
-		return err
-	}
-	defer f.Close()
+	logger.Debugf("sftp: uploading %s", path)
+	if c.config.UploadConcurrency > 1 {
+		err := sftpChunkedUpload(client, path, input, c.config.UploadConcurrency, c.config.UploadBufferSize)
+		if err != nil {
+			return err
+		}
+	} else {
+		f, err := client.Create(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
 
-	if _, err = io.Copy(f, input); err != nil {
-		return err
+		if _, err = io.Copy(f, input); err != nil {
+			return err
+		}
 	}
 
 	if fi != nil && (*fi).Mode().IsRegular() {
 		mode := (*fi).Mode().Perm()
-		err = client.Chmod(path, mode)
-		if err != nil {
+		if err := client.Chmod(path, mode); err != nil {
 			return err
 		}
 	}
@@ -522,7 +676,7 @@ func (c *comm) sftpUploadDirSession(dst string, src string, excl []string) error
 	sftpFunc := func(client *sftp.Client) error {
 		rootDst := dst
 		if src[len(src)-1] != '/' {
-			log.Printf("[DEBUG] No trailing slash, creating the source directory name")
+			logger.Debugf("No trailing slash, creating the source directory name")
 			rootDst = filepath.Join(dst, filepath.Base(src))
 		}
 		walkFunc := func(path string, info os.FileInfo, err error) error {
@@ -557,7 +711,7 @@ func (c *comm) sftpUploadDirSession(dst string, src string, excl []string) error
 }
 
 func (c *comm) sftpMkdir(path string, client *sftp.Client, fi os.FileInfo) error {
-	log.Printf("[DEBUG] sftp: creating dir %s", path)
+	logger.Debugf("sftp: creating dir %s", path)
 
 	if err := client.Mkdir(path); err != nil {
 		// Do not consider it an error if the directory existed
@@ -607,16 +761,71 @@ func (c *comm) sftpDownloadSession(path string, output io.Writer) error {
 }
 
 func (c *comm) sftpSession(f func(*sftp.Client) error) error {
-	client, err := c.newSftpClient()
+	client, err := c.sftp()
 	if err != nil {
 		return fmt.Errorf("sftpSession error: %s", err.Error())
 	}
-	defer client.Close()
+	defer c.releaseSftp(client)
+
+	err = f(client.Client)
+	if err != nil && client.broken() {
+		// The sftp session is gone, the next transfer starts a new one.
+		c.m.Lock()
+		if c.sftpClient == client {
+			client.retire()
+			c.sftpClient = nil
+		}
+		c.m.Unlock()
+	}
+	return err
+}
+
+// sftp returns the sftp client shared by the file transfers, starting it if
+// needed. The caller must release it with releaseSftp once done.
+func (c *comm) sftp() (*sftpClient, error) {
+	c.m.Lock()
+	if client := c.sftpClient; client != nil {
+		if !client.broken() {
+			client.refs++
+			c.m.Unlock()
+			return client, nil
+		}
+		client.retire()
+		c.sftpClient = nil
+	}
+	c.m.Unlock()
+
+	client, err := c.newSftpClient()
+	if err != nil {
+		return nil, err
+	}
 
-	return f(client)
+	c.m.Lock()
+	defer c.m.Unlock()
+	if c.sftpClient != nil {
+		// Another transfer started one first
+		client.Close()
+		c.sftpClient.refs++
+		return c.sftpClient, nil
+	}
+	client.refs++
+	c.sftpClient = client
+	return client, nil
+}
+
+// releaseSftp releases a client returned by sftp, closing it if it was
+// retired and no other transfer uses it.
+func (c *comm) releaseSftp(client *sftpClient) {
+	c.m.Lock()
+	client.refs--
+	closing := client.retired && client.refs == 0
+	c.m.Unlock()
+	if closing {
+		client.Close()
+	}
 }
 
-func (c *comm) newSftpClient() (*sftp.Client, error) {
+func (c *comm) newSftpClient() (*sftpClient, error) {
 	session, err := c.newSession()
 	if err != nil {
 		return nil, err
@@ -637,13 +846,69 @@ func (c *comm) newSftpClient() (*sftp.Client, error) {
 
 	// Capture stdout so we can return errors to the user
 	var stdout bytes.Buffer
-	tee := io.TeeReader(pr, &stdout)
-	client, err := sftp.NewClientPipe(tee, pw)
-	if err != nil && stdout.Len() > 0 {
-		log.Printf("[ERROR] Upload failed: %s", stdout.Bytes())
+	r := &sftpReader{
+		r:      io.TeeReader(pr, &stdout),
+		closed: make(chan struct{}),
+	}
+	client, err := sftp.NewClientPipe(r, pw)
+	if err != nil {
+		if stdout.Len() > 0 {
+			logger.Errorf("Upload failed: %s", stdout.Bytes())
+		}
+		return nil, err
+	}
+
+	return &sftpClient{Client: client, closed: r.closed}, nil
+}
+
+// sftpClient is an sftp client shared by concurrent file transfers.
+type sftpClient struct {
+	*sftp.Client
+
+	// closed is closed once the sftp session ended.
+	closed <-chan struct{}
+
+	// refs is the number of transfers using the client, and retired tells
+	// whether the client was replaced, to be closed by the last of them.
+	// They are protected by comm.m.
+	refs    int
+	retired bool
+}
+
+// broken tells whether the sftp session of the client ended, like when the
+// SSH connection was lost.
+func (c *sftpClient) broken() bool {
+	select {
+	case <-c.closed:
+		return true
+	default:
+		return false
+	}
+}
+
+// retire closes the client once no transfer uses it anymore. The caller
+// must hold comm.m.
+func (c *sftpClient) retire() {
+	c.retired = true
+	if c.refs == 0 {
+		go c.Close()
 	}
+}
 
-	return client, err
+// sftpReader reads the output of an sftp session, and closes closed once it
+// ended.
+type sftpReader struct {
+	r      io.Reader
+	once   sync.Once
+	closed chan struct{}
+}
+
+func (r *sftpReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	if err != nil {
+		r.once.Do(func() { close(r.closed) })
+	}
+	return n, err
 }
 
 func (c *comm) scpUploadSession(path string, input io.Reader, fi *os.FileInfo) error {
@@ -685,7 +950,7 @@ func (c *comm) scpUploadDirSession(dst string, src string, excl []string) error
 		}
 
 		if src[len(src)-1] != '/' {
-			log.Printf("[DEBUG] No trailing slash, creating the source directory name")
+			logger.Debugf("No trailing slash, creating the source directory name")
 			fi, err := os.Stat(src)
 			if err != nil {
 				return err
@@ -786,7 +1051,7 @@ func (c *comm) scpSession(scpCommand string, f func(io.Writer, *bufio.Reader) er
 
 	// Start the sink mode on the other side
 	// TODO(mitchellh): There are probably issues with shell escaping the path
-	log.Println("[DEBUG] Starting remote scp process: ", scpCommand)
+	logger.Debugf("Starting remote scp process: %s", scpCommand)
 	if err := session.Start(scpCommand); err != nil {
 		return err
 	}
@@ -794,7 +1059,7 @@ func (c *comm) scpSession(scpCommand string, f func(io.Writer, *bufio.Reader) er
 	// Call our callback that executes in the context of SCP. We ignore
 	// EOF errors if they occur because it usually means that SCP prematurely
 	// ended on the other side.
-	log.Println("[DEBUG] Started SCP session, beginning transfers...")
+	logger.Debugf("Started SCP session, beginning transfers...")
 	if err := f(stdinW, stdoutR); err != nil && err != io.EOF {
 		return err
 	}
@@ -802,25 +1067,25 @@ func (c *comm) scpSession(scpCommand string, f func(io.Writer, *bufio.Reader) er
 	// Close the stdin, which sends an EOF, and then set w to nil so that
 	// our defer func doesn't close it again since that is unsafe with
 	// the Go SSH package.
-	log.Println("[DEBUG] SCP session complete, closing stdin pipe.")
+	logger.Debugf("SCP session complete, closing stdin pipe.")
 	stdinW.Close()
 	stdinW = nil
 
 	// Wait for the SCP connection to close, meaning it has consumed all
 	// our data and has completed. Or has errored.
-	log.Println("[DEBUG] Waiting for SSH session to complete.")
+	logger.Debugf("Waiting for SSH session to complete.")
 	err = session.Wait()
-	log.Printf("[DEBUG] scp stderr (length %d): %s", stderr.Len(), stderr.String())
+	logger.Debugf("scp stderr (length %d): %s", stderr.Len(), stderr.String())
 	if err != nil {
 		if exitErr, ok := err.(*ssh.ExitError); ok {
 			// Otherwise, we have an ExitError, meaning we can just read the
 			// exit status
-			log.Printf("[DEBUG] non-zero exit status: %d, %v", exitErr.ExitStatus(), err)
+			logger.Debugf("non-zero exit status: %d, %v", exitErr.ExitStatus(), err)
 			stdoutB, err := ioutil.ReadAll(stdoutR)
 			if err != nil {
 				return err
 			}
-			log.Printf("[DEBUG] scp output: %s", stdoutB)
+			logger.Debugf("scp output: %s", stdoutB)
 
 			// If we exited with status 127, it means SCP isn't available.
 			// Return a more descriptive error for that.
@@ -890,7 +1155,7 @@ func scpUploadFile(dst string, src io.Reader, w io.Writer, r *bufio.Reader, fi *
 
 		mode = 0644
 
-		log.Println("[DEBUG] Copying input data into temporary file so we can read the length")
+		logger.Debugf("Copying input data into temporary file so we can read the length")
 		if _, err := io.Copy(tf, src); err != nil {
 			return fmt.Errorf("Error copying input data into local temporary "+
 				"file. Check that TEMPDIR has enough space. Please see "+
@@ -920,7 +1185,7 @@ func scpUploadFile(dst string, src io.Reader, w io.Writer, r *bufio.Reader, fi *
 
 	// Start the protocol
 	perms := fmt.Sprintf("C%04o", mode)
-	log.Printf("[DEBUG] scp: Uploading %s: perms=%s size=%d", dst, perms, size)
+	logger.Debugf("scp: Uploading %s: perms=%s size=%d", dst, perms, size)
 
 	fmt.Fprintln(w, perms, size, dst)
 	if err := checkSCPStatus(r); err != nil {
@@ -936,7 +1201,7 @@ func scpUploadFile(dst string, src io.Reader, w io.Writer, r *bufio.Reader, fi *
 }
 
 func scpUploadDirProtocol(name string, w io.Writer, r *bufio.Reader, f func() error, fi os.FileInfo) error {
-	log.Printf("[DEBUG] SCP: starting directory upload: %s", name)
+	logger.Debugf("SCP: starting directory upload: %s", name)
 
 	mode := fi.Mode().Perm()
 
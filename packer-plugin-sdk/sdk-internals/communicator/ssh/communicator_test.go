@@ -5,12 +5,20 @@ package ssh
 import (
 	"bytes"
 	"context"
+	"crypto/rand"
 	"fmt"
+	"io/ioutil"
 	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/hashicorp/packer/packer"
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ed25519"
 	"golang.org/x/crypto/ssh"
 )
 
@@ -227,3 +235,259 @@ func TestHandshakeTimeout(t *testing.T) {
 		t.Fatalf("Expected handshake timeout, got: %s", err)
 	}
 }
+
+// newMockDeadServer accepts an SSH connection and then never replies to it.
+func newMockDeadServer(t *testing.T) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Unable to listen for connection: %s", err)
+	}
+
+	go func() {
+		defer l.Close()
+		c, err := l.Accept()
+		if err != nil {
+			t.Errorf("Unable to accept incoming connection: %s", err)
+		}
+		defer c.Close()
+		conn, _, _, err := ssh.NewServerConn(c, serverConfig)
+		if err != nil {
+			t.Logf("Handshaking error: %v", err)
+			return
+		}
+		conn.Wait()
+	}()
+
+	return l.Addr().String()
+}
+
+func TestKeepAlive_closesDeadConnection(t *testing.T) {
+	clientConfig := &ssh.ClientConfig{
+		User: "user",
+		Auth: []ssh.AuthMethod{
+			ssh.Password("pass"),
+		},
+		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+	}
+
+	address := newMockDeadServer(t)
+	conn := func() (net.Conn, error) {
+		return net.Dial("tcp", address)
+	}
+
+	config := &Config{
+		Connection:        conn,
+		SSHConfig:         clientConfig,
+		KeepAliveInterval: 10 * time.Millisecond,
+		KeepAliveCountMax: 2,
+	}
+
+	client, err := New(address, config)
+	if err != nil {
+		t.Fatalf("error connecting to SSH: %s", err)
+	}
+
+	closed := make(chan struct{})
+	go func() {
+		client.client.Wait()
+		close(closed)
+	}()
+
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the connection should be closed when keepalives get no reply")
+	}
+}
+
+// newMockSftpServer serves the sftp subsystem, and counts the sftp sessions
+// in sessions.
+func newMockSftpServer(t *testing.T, sessions *int32) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Unable to listen for connection: %s", err)
+	}
+
+	go func() {
+		defer l.Close()
+		c, err := l.Accept()
+		if err != nil {
+			t.Errorf("Unable to accept incoming connection: %s", err)
+			return
+		}
+		defer c.Close()
+		conn, chans, reqs, err := ssh.NewServerConn(c, serverConfig)
+		if err != nil {
+			t.Logf("Handshaking error: %v", err)
+			return
+		}
+		defer conn.Close()
+		go ssh.DiscardRequests(reqs)
+		for newChannel := range chans {
+			channel, requests, err := newChannel.Accept()
+			if err != nil {
+				t.Errorf("Unable to accept channel.")
+				return
+			}
+			go func() {
+				defer channel.Close()
+				for req := range requests {
+					ok := req.Type == "subsystem" && strings.HasSuffix(string(req.Payload), "sftp")
+					req.Reply(ok, nil)
+					if !ok {
+						continue
+					}
+					atomic.AddInt32(sessions, 1)
+					server, err := sftp.NewServer(channel, channel)
+					if err != nil {
+						t.Errorf("Unable to start sftp server: %s", err)
+						return
+					}
+					go func() {
+						server.Serve()
+						channel.Close()
+					}()
+				}
+			}()
+		}
+	}()
+
+	return l.Addr().String()
+}
+
+func newSftpComm(t *testing.T, sessions *int32) *comm {
+	address := newMockSftpServer(t, sessions)
+	config := &Config{
+		Connection: func() (net.Conn, error) {
+			return net.Dial("tcp", address)
+		},
+		SSHConfig: &ssh.ClientConfig{
+			User: "user",
+			Auth: []ssh.AuthMethod{
+				ssh.Password("pass"),
+			},
+			HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+		},
+		UseSftp: true,
+	}
+
+	client, err := New(address, config)
+	if err != nil {
+		t.Fatalf("error connecting to SSH: %s", err)
+	}
+	return client
+}
+
+func TestSftp_keepsClientOnTransferError(t *testing.T) {
+	var sessions int32
+	c := newSftpComm(t, &sessions)
+	dir, err := ioutil.TempDir("", "packer-sftp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if err := c.Download(filepath.Join(dir, "missing"), new(bytes.Buffer)); err == nil {
+		t.Fatal("downloading a missing file should fail")
+	}
+	client := c.sftpClient
+	if client == nil {
+		t.Fatal("a transfer error should not close the sftp client")
+	}
+
+	path := filepath.Join(dir, "file")
+	if err := c.Upload(path, strings.NewReader("foo"), nil); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if c.sftpClient != client {
+		t.Fatal("the upload should reuse the sftp client")
+	}
+	if n := atomic.LoadInt32(&sessions); n != 1 {
+		t.Fatalf("expected 1 sftp session, got %d", n)
+	}
+	if b, err := ioutil.ReadFile(path); err != nil || string(b) != "foo" {
+		t.Fatalf("bad upload: %q, %v", b, err)
+	}
+}
+
+func TestSftp_retiredClientClosedByLastTransfer(t *testing.T) {
+	var sessions int32
+	c := newSftpComm(t, &sessions)
+
+	client, err := c.sftp()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	// Like a reconnection while a transfer is in flight
+	c.m.Lock()
+	c.sftpClient.retire()
+	c.sftpClient = nil
+	c.m.Unlock()
+
+	if _, err := client.Getwd(); err != nil {
+		t.Fatalf("a retired client should work until released: %s", err)
+	}
+	if client.broken() {
+		t.Fatal("a retired client should not be closed while in use")
+	}
+
+	c.releaseSftp(client)
+	select {
+	case <-client.closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the last transfer should close the retired client")
+	}
+
+	dir, err := ioutil.TempDir("", "packer-sftp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := c.Upload(filepath.Join(dir, "file"), strings.NewReader("foo"), nil); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if n := atomic.LoadInt32(&sessions); n != 2 {
+		t.Fatalf("expected a new sftp session, got %d sessions", n)
+	}
+}
+
+func TestCheckHostKey(t *testing.T) {
+	newKey := func() ssh.PublicKey {
+		pub, _, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		key, err := ssh.NewPublicKey(pub)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return key
+	}
+	key, sysprepKey := newKey(), newKey()
+	addr := &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 22}
+
+	c := &comm{config: &Config{PinHostKey: true}}
+	check := c.checkHostKey(nil)
+
+	if err := check("host", addr, key); err != nil {
+		t.Fatalf("the first host key should be accepted: %s", err)
+	}
+	if err := check("host", addr, key); err != nil {
+		t.Fatalf("the same host key should be accepted: %s", err)
+	}
+	if err := check("host", addr, sysprepKey); err == nil {
+		t.Fatal("a changed host key should be refused")
+	}
+
+	if err := c.ExpectDisconnect(); err != nil {
+		t.Fatal(err)
+	}
+	if err := check("host", addr, sysprepKey); err != nil {
+		t.Fatalf("the host key can change after an expected disconnect: %s", err)
+	}
+	c.expectDisconnect = false
+	if err := check("host", addr, sysprepKey); err != nil {
+		t.Fatalf("the new host key should be pinned: %s", err)
+	}
+}
@@ -2,7 +2,6 @@ package ssh
 
 import (
 	"io"
-	"log"
 
 	"golang.org/x/crypto/ssh"
 	"golang.org/x/crypto/ssh/terminal"
@@ -15,10 +14,10 @@ func KeyboardInteractive(c io.ReadWriter) ssh.KeyboardInteractiveChallenge {
 			return []string{}, nil
 		}
 
-		log.Printf("[INFO] -- User: %s", user)
-		log.Printf("[INFO] -- Instructions: %s", instruction)
+		logger.Infof("-- User: %s", user)
+		logger.Infof("-- Instructions: %s", instruction)
 		for i, question := range questions {
-			log.Printf("[INFO] -- Question %d: %s", i+1, question)
+			logger.Infof("-- Question %d: %s", i+1, question)
 		}
 		answers := make([]string, len(questions))
 		for i := range questions {
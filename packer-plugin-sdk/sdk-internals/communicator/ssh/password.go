@@ -1,7 +1,6 @@
 package ssh
 
 import (
-	"log"
 
 	"golang.org/x/crypto/ssh"
 )
@@ -10,11 +9,11 @@ import (
 // back the password for all questions. The questions are logged.
 func PasswordKeyboardInteractive(password string) ssh.KeyboardInteractiveChallenge {
 	return func(user, instruction string, questions []string, echos []bool) ([]string, error) {
-		log.Printf("Keyboard interactive challenge: ")
-		log.Printf("-- User: %s", user)
-		log.Printf("-- Instructions: %s", instruction)
+		logger.Debugf("Keyboard interactive challenge: ")
+		logger.Debugf("-- User: %s", user)
+		logger.Debugf("-- Instructions: %s", instruction)
 		for i, question := range questions {
-			log.Printf("-- Question %d: %s", i+1, question)
+			logger.Debugf("-- Question %d: %s", i+1, question)
 		}
 
 		// Just send the password back for all questions
@@ -2,7 +2,6 @@ package ssh
 
 import (
 	"io"
-	"log"
 	"net"
 )
 
@@ -15,14 +14,14 @@ func ProxyServe(l net.Listener, done <-chan struct{}, dialer func() (net.Conn, e
 		client, err := l.Accept()
 		select {
 		case <-done:
-			log.Printf("[WARN] Tunnel: received Done event: %v", err)
+			logger.Warnf("Tunnel: received Done event: %v", err)
 			return
 		default:
 			if err != nil {
-				log.Printf("[ERROR] Tunnel: listen.Accept failed: %v", err)
+				logger.Errorf("Tunnel: listen.Accept failed: %v", err)
 				continue
 			}
-			log.Printf("[DEBUG] Tunnel: client '%s' accepted", client.RemoteAddr())
+			logger.Debugf("Tunnel: client '%s' accepted", client.RemoteAddr())
 			// Proxy bytes from one side to the other
 			go handleProxyClient(client, dialer)
 		}
@@ -34,7 +33,7 @@ func handleProxyClient(clientConn net.Conn, dialer func() (net.Conn, error)) {
 	//We have a client connected, open an upstream connection to the destination
 	upstreamConn, err := dialer()
 	if err != nil {
-		log.Printf("[ERROR] Tunnel: failed to open connection to upstream: %v", err)
+		logger.Errorf("Tunnel: failed to open connection to upstream: %v", err)
 		clientConn.Close()
 		return
 	}
@@ -55,17 +54,17 @@ func handleProxyClient(clientConn net.Conn, dialer func() (net.Conn, error)) {
 		upstreamConn.Close()
 		<-upstreamClosed
 	}
-	log.Printf("[DEBUG] Tunnel: client ('%s') proxy closed", clientConn.RemoteAddr())
+	logger.Debugf("Tunnel: client ('%s') proxy closed", clientConn.RemoteAddr())
 }
 
 // brokerData is responsible for copying data src => dest. It will also close the src when there are no more bytes to transfer
 func brokerData(src net.Conn, dest net.Conn, srcClosed chan struct{}) {
 	_, err := io.Copy(src, dest)
 	if err != nil {
-		log.Printf("[ERROR] Tunnel: Copy error: %s", err)
+		logger.Errorf("Tunnel: Copy error: %s", err)
 	}
 	if err := src.Close(); err != nil {
-		log.Printf("[ERROR] Tunnel: Close error: %s", err)
+		logger.Errorf("Tunnel: Close error: %s", err)
 	}
 	srcClosed <- struct{}{}
 }
@@ -0,0 +1,198 @@
+package ssh
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/pkg/sftp"
+)
+
+// DefaultUploadBufferSize is the size of the chunks of the concurrent sftp
+// uploads when Config.UploadBufferSize isn't set.
+const DefaultUploadBufferSize = 1024 * 1024
+
+// uploadChunk is a part of an uploaded file, that goes at offset.
+type uploadChunk struct {
+	offset int64
+	data   []byte
+}
+
+// sftpChunkedUpload writes input to path, bufferSize bytes at a time, with
+// up to concurrency chunks being written at once. Each writer has its own
+// handle of the file, so the chunks don't have to be written in order. If
+// the upload fails, the partially written file is removed.
+func sftpChunkedUpload(client *sftp.Client, path string, input io.Reader, concurrency, bufferSize int) error {
+	if bufferSize <= 0 {
+		bufferSize = DefaultUploadBufferSize
+	}
+
+	f, err := client.Create(path)
+	if err != nil {
+		return err
+	}
+	f.Close()
+
+	err = writeChunks(client, path, input, concurrency, bufferSize)
+	if err != nil {
+		if rmErr := client.Remove(path); rmErr != nil {
+			logger.Warnf("sftp: can't remove partial upload %s: %s", path, rmErr)
+		}
+		return err
+	}
+	return nil
+}
+
+func writeChunks(client *sftp.Client, path string, input io.Reader, concurrency, bufferSize int) error {
+	var errOnce sync.Once
+	var firstErr error
+	failed := make(chan struct{})
+	fail := func(err error) {
+		errOnce.Do(func() {
+			firstErr = err
+			close(failed)
+		})
+	}
+
+	// The buffers are recycled once written, so that at most concurrency
+	// chunks are in memory.
+	buffers := make(chan []byte, concurrency)
+	for i := 0; i < concurrency; i++ {
+		buffers <- make([]byte, bufferSize)
+	}
+	chunks := make(chan uploadChunk)
+
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			f, err := client.OpenFile(path, os.O_WRONLY)
+			if err != nil {
+				fail(err)
+			} else {
+				defer f.Close()
+			}
+			for chunk := range chunks {
+				if err == nil {
+					err = writeChunk(f, chunk)
+					if err != nil {
+						fail(err)
+					}
+				}
+				buffers <- chunk.data[:cap(chunk.data)]
+			}
+		}()
+	}
+
+	var offset int64
+read:
+	for {
+		var buf []byte
+		select {
+		case buf = <-buffers:
+		case <-failed:
+			break read
+		}
+
+		n, err := io.ReadFull(input, buf)
+		if n > 0 {
+			select {
+			case chunks <- uploadChunk{offset: offset, data: buf[:n]}:
+				offset += int64(n)
+			case <-failed:
+				break read
+			}
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		}
+		if err != nil {
+			fail(err)
+			break
+		}
+	}
+	close(chunks)
+	wg.Wait()
+
+	return firstErr
+}
+
+func writeChunk(f *sftp.File, chunk uploadChunk) error {
+	if _, err := f.Seek(chunk.offset, io.SeekStart); err != nil {
+		return err
+	}
+	_, err := f.Write(chunk.data)
+	return err
+}
+
+// remoteGzip tells whether the remote host can decompress gzip streams. It
+// is only checked once per communicator.
+func (c *comm) remoteGzip() bool {
+	c.gzipOnce.Do(func() {
+		session, err := c.newSession()
+		if err != nil {
+			logger.Warnf("can't check for gzip on the remote host: %s", err)
+			return
+		}
+		defer session.Close()
+
+		c.gzip = session.Run("command -v gzip >/dev/null 2>&1") == nil
+		if !c.gzip {
+			logger.Infof("gzip is not available on the remote host, uploads won't be compressed")
+		}
+	})
+	return c.gzip
+}
+
+// gzipUploadSession uploads input to path compressed with gzip, and
+// decompresses it on the remote host.
+func (c *comm) gzipUploadSession(path string, input io.Reader, fi *os.FileInfo) error {
+	session, err := c.newSession()
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+
+	stdin, err := session.StdinPipe()
+	if err != nil {
+		return err
+	}
+	var stderr bytes.Buffer
+	session.Stderr = &stderr
+
+	command := fmt.Sprintf("gzip -dc > %s", shellQuote(path))
+	if fi != nil && (*fi).Mode().IsRegular() {
+		command += fmt.Sprintf(" && chmod %04o %s", (*fi).Mode().Perm(), shellQuote(path))
+	}
+	logger.Debugf("uploading %s compressed with gzip", path)
+	if err := session.Start(command); err != nil {
+		return err
+	}
+
+	zw := gzip.NewWriter(stdin)
+	_, err = io.Copy(zw, input)
+	if err == nil {
+		err = zw.Close()
+	}
+	stdin.Close()
+	if err != nil {
+		session.Close()
+		return fmt.Errorf("Error uploading %s: %s", path, err)
+	}
+
+	if err := session.Wait(); err != nil {
+		return fmt.Errorf("Error decompressing %s on the remote host: %s: %s",
+			path, err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// shellQuote quotes s for a POSIX shell.
+func shellQuote(s string) string {
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
+}
@@ -0,0 +1,146 @@
+package ssh
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pkg/sftp"
+)
+
+// newTestSftpClient returns an sftp client talking to an in-process sftp
+// server.
+func newTestSftpClient(t *testing.T) *sftp.Client {
+	serverR, clientW := io.Pipe()
+	clientR, serverW := io.Pipe()
+
+	server, err := sftp.NewServer(serverR, serverW)
+	if err != nil {
+		t.Fatalf("Unable to create sftp server: %s", err)
+	}
+	go server.Serve()
+
+	client, err := sftp.NewClientPipe(clientR, clientW)
+	if err != nil {
+		t.Fatalf("Unable to create sftp client: %s", err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func TestSftpChunkedUpload(t *testing.T) {
+	client := newTestSftpClient(t)
+	dir, err := ioutil.TempDir("", "packer-sftp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, tc := range []struct {
+		name        string
+		size        int
+		concurrency int
+		bufferSize  int
+	}{
+		{"empty", 0, 4, 1000},
+		{"smaller than a chunk", 10, 4, 1000},
+		{"partial last chunk", 100007, 4, 1000},
+		{"more chunks than writers", 1 << 20, 8, 4096},
+		{"chunks larger than a packet", 1<<20 + 3, 3, 100000},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			content := make([]byte, tc.size)
+			rand.Read(content)
+			path := filepath.Join(dir, "upload")
+
+			// A reader that returns short reads, to check that chunks are
+			// still put at the right offset.
+			input := &shortReader{r: bytes.NewReader(content), max: 777}
+			if err := sftpChunkedUpload(client, path, input, tc.concurrency, tc.bufferSize); err != nil {
+				t.Fatalf("upload failed: %s", err)
+			}
+
+			uploaded, err := ioutil.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(uploaded, content) {
+				t.Fatalf("uploaded file differs: got %d bytes, expected %d", len(uploaded), len(content))
+			}
+		})
+	}
+}
+
+func TestSftpChunkedUpload_readError(t *testing.T) {
+	client := newTestSftpClient(t)
+	dir, err := ioutil.TempDir("", "packer-sftp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "upload")
+	readErr := errors.New("read failed")
+	input := io.MultiReader(bytes.NewReader(make([]byte, 50000)), &errReader{readErr})
+
+	err = sftpChunkedUpload(client, path, input, 4, 1000)
+	if err != readErr {
+		t.Fatalf("expected the read error, got %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("the partial upload should be removed: %v", err)
+	}
+}
+
+func TestSftpChunkedUpload_writeError(t *testing.T) {
+	client := newTestSftpClient(t)
+	dir, err := ioutil.TempDir("", "packer-sftp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "missing", "upload")
+	err = sftpChunkedUpload(client, path, bytes.NewReader(make([]byte, 5000)), 4, 1000)
+	if err == nil {
+		t.Fatal("uploading to a missing directory should fail")
+	}
+}
+
+func TestShellQuote(t *testing.T) {
+	cases := map[string]string{
+		"/tmp/file":        "'/tmp/file'",
+		"/tmp/my file":     "'/tmp/my file'",
+		"/tmp/it's a file": `'/tmp/it'\''s a file'`,
+	}
+	for in, expected := range cases {
+		if actual := shellQuote(in); actual != expected {
+			t.Fatalf("shellQuote(%q) = %q, expected %q", in, actual, expected)
+		}
+	}
+}
+
+type shortReader struct {
+	r   io.Reader
+	max int
+}
+
+func (r *shortReader) Read(p []byte) (int, error) {
+	if len(p) > r.max {
+		p = p[:r.max]
+	}
+	return r.r.Read(p)
+}
+
+type errReader struct {
+	err error
+}
+
+func (r *errReader) Read(p []byte) (int, error) {
+	return 0, r.err
+}
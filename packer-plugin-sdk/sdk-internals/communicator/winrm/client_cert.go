@@ -0,0 +1,22 @@
+package winrm
+
+import (
+	"github.com/masterzen/winrm"
+)
+
+// clientCertTransport authenticates with a client certificate. The
+// endpoints created by winrmcp for file transfers don't carry the
+// certificate, so it sets it on the endpoints it is given.
+type clientCertTransport struct {
+	winrm.ClientAuthRequest
+
+	cert []byte
+	key  []byte
+}
+
+func (t *clientCertTransport) Transport(endpoint *winrm.Endpoint) error {
+	e := *endpoint
+	e.Cert = t.cert
+	e.Key = t.key
+	return t.ClientAuthRequest.Transport(&e)
+}
@@ -0,0 +1,53 @@
+package winrm
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/masterzen/winrm"
+)
+
+func TestClientCertTransport(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "packer"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer})
+
+	// The endpoints of winrmcp have no certificate, the transport sets it.
+	endpoint := &winrm.Endpoint{Host: "localhost", Port: 5986, HTTPS: true}
+	transport := &clientCertTransport{cert: certPEM, key: keyPEM}
+	if err := transport.Transport(endpoint); err != nil {
+		t.Fatalf("bad: %s", err)
+	}
+	if endpoint.Cert != nil {
+		t.Fatal("the endpoint should not be modified")
+	}
+
+	transport = &clientCertTransport{cert: certPEM, key: certPEM}
+	if err := transport.Transport(endpoint); err == nil {
+		t.Fatal("an invalid key should fail")
+	}
+}
@@ -40,6 +40,17 @@ func New(config *Config) (*Communicator, error) {
 		*/
 	}
 
+	if config.ClientCert != nil {
+		endpoint.Cert = config.ClientCert
+		endpoint.Key = config.ClientKey
+		config.TransportDecorator = func() winrm.Transporter {
+			return &clientCertTransport{
+				cert: config.ClientCert,
+				key:  config.ClientKey,
+			}
+		}
+	}
+
 	// Create the client
 	params := *winrm.DefaultParameters
 
@@ -91,6 +102,22 @@ func (c *Communicator) Start(ctx context.Context, rc *packer.RemoteCmd) error {
 	return nil
 }
 
+// ExpectDisconnect does nothing: WinRM uses a new HTTP connection for each
+// request.
+func (c *Communicator) ExpectDisconnect() error {
+	return nil
+}
+
+// Reconnect waits until commands can run on the machine again, for up to
+// ReconnectTimeout.
+func (c *Communicator) Reconnect(ctx context.Context) error {
+	timeout := c.config.ReconnectTimeout
+	if timeout <= 0 {
+		timeout = packer.DefaultReconnectTimeout
+	}
+	return packer.PollReconnect(ctx, c, timeout)
+}
+
 func runCommand(shell *winrm.Shell, cmd *winrm.Command, rc *packer.RemoteCmd) {
 	defer shell.Close()
 	var wg sync.WaitGroup
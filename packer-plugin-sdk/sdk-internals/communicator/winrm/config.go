@@ -16,4 +16,13 @@ type Config struct {
 	Https              bool
 	Insecure           bool
 	TransportDecorator func() winrm.Transporter
+
+	// ClientCert and ClientKey are the PEM encoded certificate and key
+	// used to authenticate over HTTPS instead of the username and password.
+	ClientCert []byte
+	ClientKey  []byte
+
+	// ReconnectTimeout is how long Reconnect waits for the machine to come
+	// back. Defaults to packer.DefaultReconnectTimeout.
+	ReconnectTimeout time.Duration
 }
@@ -0,0 +1,87 @@
+package shell
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/packer/packer"
+)
+
+// OutputTimeFormat is the format of the timestamps of the output lines of
+// the scripts.
+const OutputTimeFormat = "2006-01-02T15:04:05.000Z07:00"
+
+// OutputUi returns the Ui to run the scripts of the provisioner with, so that
+// their output follows OutputTimestamps and OutputFile. The caller must
+// close it once the scripts ran.
+func (p *ProvisionerRemoteSpecific) OutputUi(ui packer.Ui) (*OutputUi, error) {
+	u := &OutputUi{
+		Ui:         ui,
+		timestamps: p.OutputTimestamps,
+		now:        time.Now,
+	}
+	if p.OutputFile != "" {
+		f, err := os.OpenFile(p.OutputFile, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+		if err != nil {
+			return nil, fmt.Errorf("Error opening output_file: %s", err)
+		}
+		u.file = f
+	}
+	return u, nil
+}
+
+// OutputUi is a Ui for packer.RemoteCmd.RunWithUi, that gets each line the
+// command writes on stdout as a Message and each line of stderr as an
+// Error. It prefixes the lines with their time and stream when asked, and
+// writes them to a file too, always prefixed since both streams are mixed
+// in it.
+type OutputUi struct {
+	packer.Ui
+
+	timestamps bool
+	now        func() time.Time
+
+	l    sync.Mutex
+	file *os.File
+}
+
+func (u *OutputUi) Message(line string) {
+	u.write("stdout", line, u.Ui.Message)
+}
+
+func (u *OutputUi) Error(line string) {
+	u.write("stderr", line, u.Ui.Error)
+}
+
+func (u *OutputUi) write(stream, line string, display func(string)) {
+	prefixed := fmt.Sprintf("%s %s: %s", u.now().Format(OutputTimeFormat), stream, line)
+	if u.timestamps {
+		display(prefixed)
+	} else {
+		display(line)
+	}
+
+	u.l.Lock()
+	defer u.l.Unlock()
+	if u.file != nil {
+		if _, err := fmt.Fprintln(u.file, prefixed); err != nil {
+			u.Ui.Error(fmt.Sprintf("Error writing output_file, not writing it anymore: %s", err))
+			u.file.Close()
+			u.file = nil
+		}
+	}
+}
+
+// Close closes the output file.
+func (u *OutputUi) Close() error {
+	u.l.Lock()
+	defer u.l.Unlock()
+	if u.file == nil {
+		return nil
+	}
+	err := u.file.Close()
+	u.file = nil
+	return err
+}
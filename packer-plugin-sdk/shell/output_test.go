@@ -0,0 +1,70 @@
+package shell
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/packer/packer"
+)
+
+func TestOutputUi(t *testing.T) {
+	dir, err := ioutil.TempDir("", "packer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	outputFile := filepath.Join(dir, "output.log")
+
+	for _, timestamps := range []bool{false, true} {
+		var stdout, stderr bytes.Buffer
+		ui := &packer.BasicUi{
+			Reader:      new(bytes.Buffer),
+			Writer:      &stdout,
+			ErrorWriter: &stderr,
+		}
+		p := &ProvisionerRemoteSpecific{
+			OutputTimestamps: timestamps,
+			OutputFile:       outputFile,
+		}
+		outputUi, err := p.OutputUi(ui)
+		if err != nil {
+			t.Fatal(err)
+		}
+		outputUi.now = func() time.Time {
+			return time.Date(2020, 11, 5, 10, 30, 0, 0, time.UTC)
+		}
+
+		outputUi.Message("installing packages")
+		outputUi.Error("warning: no cache")
+		if err := outputUi.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		expectedStdout, expectedStderr := "installing packages\n", "warning: no cache\n"
+		if timestamps {
+			expectedStdout = "2020-11-05T10:30:00.000Z stdout: installing packages\n"
+			expectedStderr = "2020-11-05T10:30:00.000Z stderr: warning: no cache\n"
+		}
+		if stdout.String() != expectedStdout {
+			t.Fatalf("bad stdout: %q", stdout.String())
+		}
+		if stderr.String() != expectedStderr {
+			t.Fatalf("bad stderr: %q", stderr.String())
+		}
+	}
+
+	// The file is appended to, and always has timestamps
+	content, err := ioutil.ReadFile(outputFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	line := "2020-11-05T10:30:00.000Z stdout: installing packages\n" +
+		"2020-11-05T10:30:00.000Z stderr: warning: no cache\n"
+	if string(content) != line+line {
+		t.Fatalf("bad output file: %q", content)
+	}
+}
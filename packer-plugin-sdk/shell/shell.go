@@ -55,4 +55,13 @@ type ProvisionerRemoteSpecific struct {
 	// should be used to specify where the script goes, {{ .Vars }}
 	// can be used to inject the environment_vars into the environment.
 	ExecuteCommand string `mapstructure:"execute_command"`
+
+	// If true, each line of the output of the scripts is prefixed with the
+	// time it was received and the stream it comes from, `stdout` or
+	// `stderr`, so that the output of parallel builds can be told apart.
+	OutputTimestamps bool `mapstructure:"output_timestamps"`
+
+	// The local path of a file the output of the scripts is also appended
+	// to, each line prefixed with its time and stream.
+	OutputFile string `mapstructure:"output_file"`
 }
@@ -37,3 +37,33 @@ func TestShutdownConfigPrepare_ShutdownTimeout(t *testing.T) {
 		t.Fatalf("bad: %s", c.ShutdownTimeout)
 	}
 }
+
+func TestShutdownConfigPrepare_PauseBeforeShutdown(t *testing.T) {
+	var c *ShutdownConfig
+	var errs []error
+
+	// Test with a good one
+	c = testShutdownConfig()
+	c.PauseBeforeShutdown = 10 * time.Minute
+	errs = c.Prepare(interpolate.NewContext())
+	if len(errs) > 0 {
+		t.Fatalf("err: %#v", errs)
+	}
+
+	// Test with a bad one
+	c = testShutdownConfig()
+	c.PauseBeforeShutdown = -1 * time.Second
+	errs = c.Prepare(interpolate.NewContext())
+	if len(errs) != 1 {
+		t.Fatalf("bad: %#v", errs)
+	}
+
+	// Test together with the interactive pause
+	c = testShutdownConfig()
+	c.PauseBeforeShutdown = 10 * time.Minute
+	c.PauseBeforeShutdownInteractive = true
+	errs = c.Prepare(interpolate.NewContext())
+	if len(errs) != 1 {
+		t.Fatalf("bad: %#v", errs)
+	}
+}
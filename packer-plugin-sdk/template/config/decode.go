@@ -115,6 +115,7 @@ func Decode(target interface{}, config *DecodeOpts, raws ...interface{}) error {
 			config.InterpolateContext.BuildType = ctx.BuildType
 			config.InterpolateContext.CorePackerVersionString = ctx.CorePackerVersionString
 			config.InterpolateContext.TemplatePath = ctx.TemplatePath
+			config.InterpolateContext.Validate = ctx.Validate
 			config.InterpolateContext.UserVariables = ctx.UserVariables
 			if config.InterpolateContext.Data == nil {
 				config.InterpolateContext.Data = ctxData
@@ -266,10 +267,19 @@ func DetectContext(raws ...interface{}) (*interpolate.Context, error) {
 		TemplatePath            string            `mapstructure:"packer_template_path"`
 		Vars                    map[string]string `mapstructure:"packer_user_variables"`
 		SensitiveVars           []string          `mapstructure:"packer_sensitive_variables"`
+		Validate                bool              `mapstructure:"packer_validate"`
 	}
 
+	// HCL2 templates set the packer_ keys as strings
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		WeaklyTypedInput: true,
+		Result:           &s,
+	})
+	if err != nil {
+		return nil, err
+	}
 	for _, r := range raws {
-		if err := mapstructure.Decode(r, &s); err != nil {
+		if err := decoder.Decode(r); err != nil {
 			log.Printf("Error detecting context: %s", err)
 			return nil, err
 		}
@@ -282,6 +292,7 @@ func DetectContext(raws ...interface{}) (*interpolate.Context, error) {
 		TemplatePath:            s.TemplatePath,
 		UserVariables:           s.Vars,
 		SensitiveVariables:      s.SensitiveVars,
+		Validate:                s.Validate,
 	}, nil
 }
 
@@ -204,3 +204,17 @@ func TestDecode_fixerRecommendations(t *testing.T) {
 		}
 	}
 }
+
+func TestDetectContext_validate(t *testing.T) {
+	for _, v := range []interface{}{true, "true"} {
+		ctx, err := DetectContext(map[string]interface{}{
+			"packer_validate": v,
+		})
+		if err != nil {
+			t.Fatalf("err: %s", err)
+		}
+		if !ctx.Validate {
+			t.Fatalf("packer_validate %#v should be detected", v)
+		}
+	}
+}
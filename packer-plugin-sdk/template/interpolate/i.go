@@ -39,6 +39,11 @@ type Context struct {
 	BuildType               string
 	CorePackerVersionString string
 	TemplatePath            string
+
+	// Validate is set when the configuration is only validated, by `packer
+	// validate -deep`. Components should then not reach remote services or
+	// require credentials, and report every other error of the configuration.
+	Validate bool
 }
 
 // NewContext returns an initialized empty context.
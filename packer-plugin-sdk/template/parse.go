@@ -22,6 +22,7 @@ import (
 type rawTemplate struct {
 	MinVersion  string `mapstructure:"min_packer_version" json:"min_packer_version,omitempty"`
 	Description string `json:"description,omitempty"`
+	Retries     int    `json:"retries,omitempty"`
 
 	Builders           []interface{}          `mapstructure:"builders" json:"builders,omitempty"`
 	Comments           []map[string]string    `json:"comments,omitempty"`
@@ -95,6 +96,7 @@ func (r *rawTemplate) Template() (*Template, error) {
 	// Copy some literals
 	result.Description = r.Description
 	result.MinVersion = r.MinVersion
+	result.Retries = r.Retries
 	result.RawContents = r.RawContents
 
 	// Gather the comments
@@ -371,6 +371,14 @@ func TestParse(t *testing.T) {
 			false,
 		},
 
+		{
+			"parse-retries.json",
+			&Template{
+				Retries: 3,
+			},
+			false,
+		},
+
 		{
 			"parse-comment.json",
 			&Template{
@@ -21,6 +21,9 @@ type Template struct {
 	Description string
 	MinVersion  string
 
+	// The number of times a failed build is retried
+	Retries int
+
 	Comments           map[string]string
 	Variables          map[string]*Variable
 	SensitiveVariables []*Variable
@@ -39,6 +42,7 @@ func (t *Template) Raw() (*rawTemplate, error) {
 
 	out.MinVersion = t.MinVersion
 	out.Description = t.Description
+	out.Retries = t.Retries
 
 	for k, v := range t.Comments {
 		out.Comments = append(out.Comments, map[string]string{k: v})
@@ -218,6 +222,11 @@ func (t *Template) Validate() error {
 			"at least one builder must be defined"))
 	}
 
+	if t.Retries < 0 {
+		err = multierror.Append(err, errors.New(
+			"retries must not be negative"))
+	}
+
 	// Verify that the provisioner overrides target builders that exist
 	for i, p := range t.Provisioners {
 		// Validate only/except
@@ -28,6 +28,11 @@ func TestTemplateValidate(t *testing.T) {
 			true,
 		},
 
+		{
+			"validate-bad-retries.json",
+			true,
+		},
+
 		{
 			"validate-bad-override.json",
 			true,
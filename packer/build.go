@@ -5,8 +5,11 @@ import (
 	"fmt"
 	"log"
 	"sync"
+	"time"
 
 	"github.com/hashicorp/packer/packer-plugin-sdk/packerbuilderdata"
+	"github.com/hashicorp/packer/packer-plugin-sdk/retry"
+	"github.com/hashicorp/packer/packer-plugin-sdk/template/interpolate"
 	"github.com/hashicorp/packer/version"
 )
 
@@ -39,6 +42,19 @@ const (
 	// - "ask" - ask the user
 	OnErrorConfigKey = "packer_on_error"
 
+	// This is the key in configurations that is set to "true" when a failed
+	// build should be resumed, and its state saved for that.
+	ResumeConfigKey = "packer_resume"
+
+	// This is the key in configurations that is set to "true" when the build
+	// is only prepared to validate its configuration, by `packer validate
+	// -deep`.
+	ValidateConfigKey = "packer_validate"
+
+	// This is the key in configurations that is set to how long the cleanup
+	// of each step of a build is waited for, "0s" for no limit.
+	CleanupTimeoutConfigKey = "packer_cleanup_timeout"
+
 	// TemplatePathKey is the path to the template that configured this build
 	TemplatePathKey = "packer_template_path"
 
@@ -85,6 +101,27 @@ type Build interface {
 	// - "abort" - exit without cleanup
 	// - "ask" - ask the user
 	SetOnError(string)
+
+	// SetResume will enable/disable resuming a failed build.
+	//
+	// When SetResume is set to true, the state of the build is saved when it
+	// fails, and a build that failed before is resumed from where it failed.
+	SetResume(bool)
+
+	// SetValidate will enable/disable the validation mode.
+	//
+	// When SetValidate is set to true, the build is only prepared to validate
+	// its configuration: the components don't reach remote services or require
+	// credentials, and Prepare reports the errors of all of them.
+	SetValidate(bool)
+
+	// SetCleanupTimeout sets how long the cleanup of each step of the build
+	// is waited for, 0 for no limit.
+	//
+	// When the cleanup of a step takes longer, the build goes on with the
+	// cleanup of the other steps, and reports what the step may have left
+	// behind at the end.
+	SetCleanupTimeout(time.Duration)
 }
 
 // A CoreBuild struct represents a single build job, the result of which should
@@ -104,14 +141,40 @@ type CoreBuild struct {
 	TemplatePath       string
 	Variables          map[string]string
 
+	// PackerVersion is the version of Packer running the build, recorded in
+	// the provenance of the artifacts.
+	PackerVersion string
+
+	// The number of times the builder is run again if it fails. The builder
+	// cleans up after itself before returning the error, so every retry
+	// provisions a new machine from scratch.
+	Retries int
+
+	// ConcurrencyLimits are the limits on how many builds run at the same
+	// time that apply to this build.
+	ConcurrencyLimits []BuildLimit
+
+	// PlanConfig is the configuration of the builder once interpolated, as
+	// shown by the plan of the build. The plan interpolates BuilderConfig
+	// when it is nil.
+	PlanConfig map[string]interface{}
+
+	// OutputsEvaluator evaluates the outputs of the build once it succeeded,
+	// nil when the build has no outputs.
+	OutputsEvaluator OutputsEvaluator
+
 	// Indicates whether the build is already initialized before calling Prepare(..)
 	Prepared bool
 
-	debug         bool
-	force         bool
-	onError       string
-	l             sync.Mutex
-	prepareCalled bool
+	debug          bool
+	force          bool
+	onError        string
+	resume         bool
+	validate       bool
+	cleanupTimeout time.Duration
+	l              sync.Mutex
+	prepareCalled  bool
+	profiler       buildProfiler
 }
 
 // CoreBuildPostProcessor Keeps track of the post-processor and the
@@ -122,6 +185,10 @@ type CoreBuildPostProcessor struct {
 	PName             string
 	config            map[string]interface{}
 	KeepInputArtifact *bool
+
+	// PlanConfig is the configuration of the post-processor once
+	// interpolated, see CoreBuild.PlanConfig.
+	PlanConfig map[string]interface{}
 }
 
 // CoreBuildProvisioner keeps track of the provisioner and the configuration of
@@ -131,6 +198,10 @@ type CoreBuildProvisioner struct {
 	PName       string
 	Provisioner Provisioner
 	config      []interface{}
+
+	// PlanConfig is the configuration of the provisioner once interpolated,
+	// see CoreBuild.PlanConfig.
+	PlanConfig map[string]interface{}
 }
 
 // Returns the name of the build.
@@ -164,20 +235,45 @@ func (b *CoreBuild) Prepare() (warn []string, err error) {
 	b.prepareCalled = true
 
 	packerConfig := map[string]interface{}{
-		BuildNameConfigKey:     b.Type,
-		BuilderTypeConfigKey:   b.BuilderType,
-		CoreVersionConfigKey:   version.FormattedVersion(),
-		DebugConfigKey:         b.debug,
-		ForceConfigKey:         b.force,
-		OnErrorConfigKey:       b.onError,
-		TemplatePathKey:        b.TemplatePath,
-		UserVariablesConfigKey: b.Variables,
+		BuildNameConfigKey:      b.Type,
+		BuilderTypeConfigKey:    b.BuilderType,
+		CoreVersionConfigKey:    version.FormattedVersion(),
+		DebugConfigKey:          b.debug,
+		ForceConfigKey:          b.force,
+		OnErrorConfigKey:        b.onError,
+		ResumeConfigKey:         b.resume,
+		TemplatePathKey:         b.TemplatePath,
+		UserVariablesConfigKey:  b.Variables,
+		ValidateConfigKey:       b.validate,
+		CleanupTimeoutConfigKey: b.cleanupTimeout.String(),
 	}
 
+	// When validating, every component is prepared, to report all the errors
+	// at once
+	var errs *MultiError
+	failed := func(e error) bool {
+		if e == nil {
+			return false
+		}
+		if !b.validate {
+			err = e
+			return true
+		}
+		errs = MultiErrorAppend(errs, e)
+		return false
+	}
+	defer func() {
+		if errs != nil {
+			err = errs
+		}
+	}()
+
 	// Prepare the builder
-	generatedVars, warn, err := b.Builder.Prepare(b.BuilderConfig, packerConfig)
-	if err != nil {
-		log.Printf("Build '%s' prepare failure: %s\n", b.Type, err)
+	generatedVars, warn, builderErr := b.Builder.Prepare(b.BuilderConfig, packerConfig)
+	if builderErr != nil {
+		log.Printf("Build '%s' prepare failure: %s\n", b.Type, builderErr)
+	}
+	if failed(builderErr) {
 		return
 	}
 
@@ -200,7 +296,7 @@ func (b *CoreBuild) Prepare() (warn []string, err error) {
 		configs = append(configs, packerConfig)
 		configs = append(configs, generatedPlaceholderMap)
 
-		if err = coreProv.Provisioner.Prepare(configs...); err != nil {
+		if failed(coreProv.Provisioner.Prepare(configs...)) {
 			return
 		}
 	}
@@ -211,8 +307,7 @@ func (b *CoreBuild) Prepare() (warn []string, err error) {
 		copy(configs, b.CleanupProvisioner.config)
 		configs = append(configs, packerConfig)
 		configs = append(configs, generatedPlaceholderMap)
-		err = b.CleanupProvisioner.Provisioner.Prepare(configs...)
-		if err != nil {
+		if failed(b.CleanupProvisioner.Provisioner.Prepare(configs...)) {
 			return
 		}
 	}
@@ -220,8 +315,7 @@ func (b *CoreBuild) Prepare() (warn []string, err error) {
 	// Prepare the post-processors
 	for _, ppSeq := range b.PostProcessors {
 		for _, corePP := range ppSeq {
-			err = corePP.PostProcessor.Configure(corePP.config, packerConfig, generatedPlaceholderMap)
-			if err != nil {
+			if failed(corePP.PostProcessor.Configure(corePP.config, packerConfig, generatedPlaceholderMap)) {
 				return
 			}
 		}
@@ -236,6 +330,10 @@ func (b *CoreBuild) Run(ctx context.Context, originalUi Ui) ([]Artifact, error)
 		panic("Prepare must be called first")
 	}
 
+	b.profiler.start(b.Name())
+	start := time.Now()
+	defer func() { b.profiler.end(time.Since(start)) }()
+
 	// Copy the hooks
 	hooks := make(map[string][]Hook)
 	for hookName, hookList := range b.hooks {
@@ -250,6 +348,9 @@ func (b *CoreBuild) Run(ctx context.Context, originalUi Ui) ([]Artifact, error)
 			var pConfig interface{}
 			if len(p.config) > 0 {
 				pConfig = p.config[0]
+			} else if p.PlanConfig != nil {
+				// HCL2 provisioners are only described by their plan
+				pConfig = p.PlanConfig
 			}
 			if b.debug {
 				hookedProvisioners[i] = &HookedProvisioner{
@@ -272,6 +373,7 @@ func (b *CoreBuild) Run(ctx context.Context, originalUi Ui) ([]Artifact, error)
 
 		hooks[HookProvision] = append(hooks[HookProvision], &ProvisionHook{
 			Provisioners: hookedProvisioners,
+			profiler:     &b.profiler,
 		})
 	}
 
@@ -283,22 +385,28 @@ func (b *CoreBuild) Run(ctx context.Context, originalUi Ui) ([]Artifact, error)
 		}
 		hooks[HookCleanupProvision] = []Hook{&ProvisionHook{
 			Provisioners: []*HookedProvisioner{hookedCleanupProvisioner},
+			profiler:     &b.profiler,
 		}}
 	}
 
 	hook := &DispatchHook{Mapping: hooks}
 	artifacts := make([]Artifact, 0, 1)
 
-	// The builder just has a normal Ui, but targeted
-	builderUi := &TargetedUI{
-		Target: b.Name(),
-		Ui:     originalUi,
+	// The builder just has a normal Ui, but targeted, that records the
+	// durations of the steps of the builder and the metadata the
+	// provisioners report
+	metadataUi := &metadataUi{
+		Ui: &TargetedUI{
+			Target: b.Name(),
+			Ui:     originalUi,
+		},
+	}
+	builderUi := &profileUi{
+		Ui:       metadataUi,
+		profiler: &b.profiler,
 	}
 
-	log.Printf("Running builder: %s", b.BuilderType)
-	ts := CheckpointReporter.AddSpan(b.BuilderType, "builder", b.BuilderConfig)
-	builderArtifact, err := b.Builder.Run(ctx, builderUi, hook)
-	ts.End(err)
+	builderArtifact, err := b.runBuilder(ctx, builderUi, hook)
 	if err != nil {
 		return nil, err
 	}
@@ -309,6 +417,14 @@ func (b *CoreBuild) Run(ctx context.Context, originalUi Ui) ([]Artifact, error)
 		return nil, nil
 	}
 
+	plan := b.Plan()
+	provenance := newProvenance(plan, b.PackerVersion, start)
+	provenance.Metadata = metadataUi.Metadata()
+	builderArtifact = &provenanceArtifact{
+		Artifact:   builderArtifact,
+		provenance: provenance,
+	}
+
 	errors := make([]error, 0)
 	keepOriginalArtifact := len(b.PostProcessors) == 0
 
@@ -321,8 +437,9 @@ func (b *CoreBuild) Run(ctx context.Context, originalUi Ui) ([]Artifact, error)
 
 	// Run the post-processors
 PostProcessorRunSeqLoop:
-	for _, ppSeq := range b.PostProcessors {
+	for j, ppSeq := range b.PostProcessors {
 		priorArtifact := builderArtifact
+		priorProvenance := provenance
 		for i, corePP := range ppSeq {
 			ppUi := &TargetedUI{
 				Target: fmt.Sprintf("%s (%s)", b.Name(), corePP.PType),
@@ -335,7 +452,9 @@ PostProcessorRunSeqLoop:
 				builderUi.Say(fmt.Sprintf("Running post-processor: %s (type %s)", corePP.PName, corePP.PType))
 			}
 			ts := CheckpointReporter.AddSpan(corePP.PType, "post-processor", corePP.config)
+			ppStart := time.Now()
 			artifact, defaultKeep, forceOverride, err := corePP.PostProcessor.PostProcess(ctx, ppUi, priorArtifact)
+			b.profiler.addPostProcessor(corePP.PType, time.Since(ppStart))
 			ts.End(err)
 			if err != nil {
 				errors = append(errors, fmt.Errorf("Post-processor failed: %s", err))
@@ -346,6 +465,11 @@ PostProcessorRunSeqLoop:
 				log.Println("Nil artifact, halting post-processor chain.")
 				continue PostProcessorRunSeqLoop
 			}
+			priorProvenance = priorProvenance.withPostProcessor(plan.PostProcessors[j][i])
+			artifact = &provenanceArtifact{
+				Artifact:   artifact,
+				provenance: priorProvenance,
+			}
 
 			keep := defaultKeep
 			// When user has not set keep_input_artifact
@@ -417,6 +541,116 @@ PostProcessorRunSeqLoop:
 	return artifacts, err
 }
 
+// The time to wait before the first retry of a failed build. The wait doubles
+// with every retry, up to buildRetryMaxBackoff.
+var (
+	buildRetryInitialBackoff = 30 * time.Second
+	buildRetryMaxBackoff     = 10 * time.Minute
+)
+
+// runBuilder runs the builder, and runs it again up to Retries times if it
+// fails. Builds are only retried if the builder runs its cleanup steps on
+// error, so that nothing is left behind by the failed run, and never once ctx
+// is cancelled.
+func (b *CoreBuild) runBuilder(ctx context.Context, ui Ui, hook Hook) (Artifact, error) {
+	backoff := retry.Backoff{
+		InitialBackoff: buildRetryInitialBackoff,
+		MaxBackoff:     buildRetryMaxBackoff,
+		Multiplier:     2,
+	}
+
+	for try := 0; ; try++ {
+		log.Printf("Running builder: %s", b.BuilderType)
+		ts := CheckpointReporter.AddSpan(b.BuilderType, "builder", b.BuilderConfig)
+		artifact, err := b.Builder.Run(ctx, ui, hook)
+		ts.End(err)
+		if err == nil || try >= b.Retries || ctx.Err() != nil {
+			return artifact, err
+		}
+		if b.onError != "" && b.onError != "cleanup" {
+			log.Printf("Not retrying build '%s' with on-error=%s", b.Name(), b.onError)
+			return artifact, err
+		}
+
+		wait := backoff.Linear()
+		ui.Error(fmt.Sprintf("Build failed: %s", err))
+		ui.Say(fmt.Sprintf("Retrying the build in %s (retry %d of %d)...", wait, try+1, b.Retries))
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return nil, err
+		}
+	}
+}
+
+// Limits returns the limits on how many builds run at the same time that
+// apply to this build.
+func (b *CoreBuild) Limits() []BuildLimit {
+	return b.ConcurrencyLimits
+}
+
+// Plan returns what the build would run. The components of JSON templates
+// are shown with their configuration interpolated by the template variables.
+func (b *CoreBuild) Plan() BuildPlan {
+	ctx := &interpolate.Context{
+		BuildName:     b.Type,
+		BuildType:     b.BuilderType,
+		TemplatePath:  b.TemplatePath,
+		UserVariables: b.Variables,
+	}
+
+	plan := BuildPlan{
+		Build: b.Name(),
+		Builder: PlanEntry{
+			Type:   b.BuilderType,
+			Config: b.PlanConfig,
+		},
+		Provisioners:   []PlanEntry{},
+		PostProcessors: [][]PlanEntry{},
+	}
+	if plan.Builder.Type == "" {
+		plan.Builder.Type = b.Type
+	}
+	if plan.Builder.Config == nil {
+		plan.Builder.Config = planConfig(ctx, b.BuilderConfig)
+	}
+
+	for _, p := range b.Provisioners {
+		config := p.PlanConfig
+		if config == nil {
+			config = planConfig(ctx, p.config...)
+		}
+		plan.Provisioners = append(plan.Provisioners, PlanEntry{
+			Type:   p.PType,
+			Name:   p.PName,
+			Config: config,
+		})
+	}
+
+	for _, ppSeq := range b.PostProcessors {
+		seq := []PlanEntry{}
+		for _, corePP := range ppSeq {
+			config := corePP.PlanConfig
+			if config == nil {
+				config = planConfig(ctx, corePP.config)
+			}
+			seq = append(seq, PlanEntry{
+				Type:   corePP.PType,
+				Name:   corePP.PName,
+				Config: config,
+			})
+		}
+		plan.PostProcessors = append(plan.PostProcessors, seq)
+	}
+
+	return plan
+}
+
+// Profile returns how long the parts of the last run of the build took.
+func (b *CoreBuild) Profile() BuildProfile {
+	return b.profiler.get()
+}
+
 func (b *CoreBuild) SetDebug(val bool) {
 	if b.prepareCalled {
 		panic("prepare has already been called")
@@ -440,3 +674,27 @@ func (b *CoreBuild) SetOnError(val string) {
 
 	b.onError = val
 }
+
+func (b *CoreBuild) SetResume(val bool) {
+	if b.prepareCalled {
+		panic("prepare has already been called")
+	}
+
+	b.resume = val
+}
+
+func (b *CoreBuild) SetValidate(val bool) {
+	if b.prepareCalled {
+		panic("prepare has already been called")
+	}
+
+	b.validate = val
+}
+
+func (b *CoreBuild) SetCleanupTimeout(val time.Duration) {
+	if b.prepareCalled {
+		panic("prepare has already been called")
+	}
+
+	b.cleanupTimeout = val
+}
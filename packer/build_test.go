@@ -2,8 +2,10 @@ package packer
 
 import (
 	"context"
+	"errors"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/packer/packer-plugin-sdk/packerbuilderdata"
 	"github.com/hashicorp/packer/version"
@@ -30,7 +32,7 @@ func testBuild() *CoreBuild {
 		},
 		PostProcessors: [][]CoreBuildPostProcessor{
 			{
-				{&MockPostProcessor{ArtifactId: "pp"}, "testPP", "testPPName", make(map[string]interface{}), boolPointer(true)},
+				{&MockPostProcessor{ArtifactId: "pp"}, "testPP", "testPPName", make(map[string]interface{}), boolPointer(true), nil},
 			},
 		},
 		Variables: make(map[string]string),
@@ -40,14 +42,17 @@ func testBuild() *CoreBuild {
 
 func testDefaultPackerConfig() map[string]interface{} {
 	return map[string]interface{}{
-		BuildNameConfigKey:     "test",
-		BuilderTypeConfigKey:   "foo",
-		CoreVersionConfigKey:   version.FormattedVersion(),
-		DebugConfigKey:         false,
-		ForceConfigKey:         false,
-		OnErrorConfigKey:       "cleanup",
-		TemplatePathKey:        "",
-		UserVariablesConfigKey: make(map[string]string),
+		BuildNameConfigKey:      "test",
+		BuilderTypeConfigKey:    "foo",
+		CoreVersionConfigKey:    version.FormattedVersion(),
+		DebugConfigKey:          false,
+		ForceConfigKey:          false,
+		OnErrorConfigKey:        "cleanup",
+		ResumeConfigKey:         false,
+		TemplatePathKey:         "",
+		UserVariablesConfigKey:  make(map[string]string),
+		ValidateConfigKey:       false,
+		CleanupTimeoutConfigKey: "0s",
 	}
 }
 func TestBuild_Name(t *testing.T) {
@@ -90,6 +95,30 @@ func TestBuild_Prepare(t *testing.T) {
 	}
 }
 
+func TestBuild_Prepare_Validate(t *testing.T) {
+	build := testBuild()
+	builder := build.Builder.(*MockBuilder)
+	builder.PrepareErrResult = true
+
+	build.SetValidate(true)
+	_, err := build.Prepare()
+	if err == nil {
+		t.Fatal("should error")
+	}
+
+	prov := build.Provisioners[0].Provisioner.(*MockProvisioner)
+	if !prov.PrepCalled {
+		t.Fatal("provisioners should be prepared when the builder fails")
+	}
+	pp := build.PostProcessors[0][0].PostProcessor.(*MockPostProcessor)
+	if !pp.ConfigureCalled {
+		t.Fatal("post-processors should be configured when the builder fails")
+	}
+	if builder.PrepareConfig[1].(map[string]interface{})[ValidateConfigKey] != true {
+		t.Fatalf("bad: %#v", builder.PrepareConfig)
+	}
+}
+
 func TestBuild_Prepare_SkipWhenBuilderAlreadyInitialized(t *testing.T) {
 	build := testBuild()
 	builder := build.Builder.(*MockBuilder)
@@ -297,7 +326,7 @@ func TestBuild_Run_Artifacts(t *testing.T) {
 	build = testBuild()
 	build.PostProcessors = [][]CoreBuildPostProcessor{
 		{
-			{&MockPostProcessor{ArtifactId: "pp"}, "pp", "testPPName", make(map[string]interface{}), boolPointer(false)},
+			{&MockPostProcessor{ArtifactId: "pp"}, "pp", "testPPName", make(map[string]interface{}), boolPointer(false), nil},
 		},
 	}
 
@@ -322,10 +351,10 @@ func TestBuild_Run_Artifacts(t *testing.T) {
 	build = testBuild()
 	build.PostProcessors = [][]CoreBuildPostProcessor{
 		{
-			{&MockPostProcessor{ArtifactId: "pp1"}, "pp", "testPPName", make(map[string]interface{}), boolPointer(false)},
+			{&MockPostProcessor{ArtifactId: "pp1"}, "pp", "testPPName", make(map[string]interface{}), boolPointer(false), nil},
 		},
 		{
-			{&MockPostProcessor{ArtifactId: "pp2"}, "pp", "testPPName", make(map[string]interface{}), boolPointer(true)},
+			{&MockPostProcessor{ArtifactId: "pp2"}, "pp", "testPPName", make(map[string]interface{}), boolPointer(true), nil},
 		},
 	}
 
@@ -350,12 +379,12 @@ func TestBuild_Run_Artifacts(t *testing.T) {
 	build = testBuild()
 	build.PostProcessors = [][]CoreBuildPostProcessor{
 		{
-			{&MockPostProcessor{ArtifactId: "pp1a"}, "pp", "testPPName", make(map[string]interface{}), boolPointer(false)},
-			{&MockPostProcessor{ArtifactId: "pp1b"}, "pp", "testPPName", make(map[string]interface{}), boolPointer(true)},
+			{&MockPostProcessor{ArtifactId: "pp1a"}, "pp", "testPPName", make(map[string]interface{}), boolPointer(false), nil},
+			{&MockPostProcessor{ArtifactId: "pp1b"}, "pp", "testPPName", make(map[string]interface{}), boolPointer(true), nil},
 		},
 		{
-			{&MockPostProcessor{ArtifactId: "pp2a"}, "pp", "testPPName", make(map[string]interface{}), boolPointer(false)},
-			{&MockPostProcessor{ArtifactId: "pp2b"}, "pp", "testPPName", make(map[string]interface{}), boolPointer(false)},
+			{&MockPostProcessor{ArtifactId: "pp2a"}, "pp", "testPPName", make(map[string]interface{}), boolPointer(false), nil},
+			{&MockPostProcessor{ArtifactId: "pp2b"}, "pp", "testPPName", make(map[string]interface{}), boolPointer(false), nil},
 		},
 	}
 
@@ -381,7 +410,7 @@ func TestBuild_Run_Artifacts(t *testing.T) {
 	build.PostProcessors = [][]CoreBuildPostProcessor{
 		{
 			{
-				&MockPostProcessor{ArtifactId: "pp", Keep: true, ForceOverride: true}, "pp", "testPPName", make(map[string]interface{}), boolPointer(false),
+				&MockPostProcessor{ArtifactId: "pp", Keep: true, ForceOverride: true}, "pp", "testPPName", make(map[string]interface{}), boolPointer(false), nil,
 			},
 		},
 	}
@@ -409,7 +438,7 @@ func TestBuild_Run_Artifacts(t *testing.T) {
 	build.PostProcessors = [][]CoreBuildPostProcessor{
 		{
 			{
-				&MockPostProcessor{ArtifactId: "pp", Keep: true, ForceOverride: false}, "pp", "testPPName", make(map[string]interface{}), boolPointer(false),
+				&MockPostProcessor{ArtifactId: "pp", Keep: true, ForceOverride: false}, "pp", "testPPName", make(map[string]interface{}), boolPointer(false), nil,
 			},
 		},
 	}
@@ -436,7 +465,7 @@ func TestBuild_Run_Artifacts(t *testing.T) {
 	build.PostProcessors = [][]CoreBuildPostProcessor{
 		{
 			{
-				&MockPostProcessor{ArtifactId: "pp", Keep: true, ForceOverride: false}, "pp", "testPPName", make(map[string]interface{}), nil,
+				&MockPostProcessor{ArtifactId: "pp", Keep: true, ForceOverride: false}, "pp", "testPPName", make(map[string]interface{}), nil, nil,
 			},
 		},
 	}
@@ -491,3 +520,130 @@ func TestBuild_Cancel(t *testing.T) {
 		t.Fatal("build should err")
 	}
 }
+
+// flakyBuilder is a MockBuilder that fails the first Failures runs.
+type flakyBuilder struct {
+	MockBuilder
+	Failures int
+	Runs     int
+}
+
+func (b *flakyBuilder) Run(ctx context.Context, ui Ui, h Hook) (Artifact, error) {
+	b.Runs++
+	if b.Runs <= b.Failures {
+		return nil, errors.New("flaky")
+	}
+	return b.MockBuilder.Run(ctx, ui, h)
+}
+
+func testRetriedBuild(t *testing.T, failures, retries int) (*CoreBuild, *flakyBuilder) {
+	initial := buildRetryInitialBackoff
+	buildRetryInitialBackoff = time.Millisecond
+	t.Cleanup(func() { buildRetryInitialBackoff = initial })
+
+	builder := &flakyBuilder{MockBuilder: MockBuilder{ArtifactId: "b"}, Failures: failures}
+	build := testBuild()
+	build.Builder = builder
+	build.Retries = retries
+	return build, builder
+}
+
+func TestBuild_Run_Retries(t *testing.T) {
+	build, builder := testRetriedBuild(t, 2, 2)
+	build.Prepare()
+
+	artifacts, err := build.Run(context.Background(), testUi())
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if len(artifacts) == 0 {
+		t.Fatal("should have artifacts")
+	}
+	if builder.Runs != 3 {
+		t.Fatalf("bad: %d runs", builder.Runs)
+	}
+}
+
+func TestBuild_Run_RetriesExhausted(t *testing.T) {
+	build, builder := testRetriedBuild(t, 3, 2)
+	build.Prepare()
+
+	_, err := build.Run(context.Background(), testUi())
+	if err == nil {
+		t.Fatal("build should err")
+	}
+	if builder.Runs != 3 {
+		t.Fatalf("bad: %d runs", builder.Runs)
+	}
+}
+
+func TestBuild_Run_RetriesOnErrorAbort(t *testing.T) {
+	build, builder := testRetriedBuild(t, 1, 2)
+	build.onError = "abort"
+	build.Prepare()
+
+	_, err := build.Run(context.Background(), testUi())
+	if err == nil {
+		t.Fatal("build should err")
+	}
+	if builder.Runs != 1 {
+		t.Fatalf("bad: %d runs", builder.Runs)
+	}
+}
+
+func TestBuild_Run_RetriesCancelled(t *testing.T) {
+	build, builder := testRetriedBuild(t, 1, 2)
+	build.Prepare()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := build.Run(ctx, testUi())
+	if err == nil {
+		t.Fatal("build should err")
+	}
+	if builder.Runs != 1 {
+		t.Fatalf("bad: %d runs", builder.Runs)
+	}
+}
+
+func TestBuild_Plan(t *testing.T) {
+	build := testBuild()
+	build.Variables["greeting"] = "hello"
+	build.BuilderConfig = map[string]interface{}{
+		"content": "{{user `greeting`}} {{build_name}}",
+	}
+	build.Provisioners[0].config = []interface{}{
+		map[string]interface{}{
+			"inline":          []interface{}{"echo {{user `greeting`}}"},
+			"execute_command": "{{.Vars}} {{.Path}}",
+		},
+		map[string]interface{}{"inline": []interface{}{"echo override"}},
+	}
+	build.PostProcessors[0][0].PlanConfig = map[string]interface{}{"output": "manifest.json"}
+
+	expected := BuildPlan{
+		Build: "test",
+		Builder: PlanEntry{
+			Type:   "foo",
+			Config: map[string]interface{}{"content": "hello test"},
+		},
+		Provisioners: []PlanEntry{
+			{
+				Type: "mock-provisioner",
+				Config: map[string]interface{}{
+					"inline":          []interface{}{"echo override"},
+					"execute_command": "{{.Vars}} {{.Path}}",
+				},
+			},
+		},
+		PostProcessors: [][]PlanEntry{
+			{
+				{Type: "testPP", Name: "testPPName", Config: map[string]interface{}{"output": "manifest.json"}},
+			},
+		},
+	}
+	if plan := build.Plan(); !reflect.DeepEqual(plan, expected) {
+		t.Fatalf("bad: %#v", plan)
+	}
+}
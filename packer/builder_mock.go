@@ -13,10 +13,11 @@ import (
 // You can set some fake return values and you can keep track of what
 // methods were called on the builder. It is fairly basic.
 type MockBuilder struct {
-	ArtifactId      string
-	PrepareWarnings []string
-	RunErrResult    bool
-	RunNilResult    bool
+	ArtifactId       string
+	PrepareWarnings  []string
+	PrepareErrResult bool
+	RunErrResult     bool
+	RunNilResult     bool
 
 	PrepareCalled bool
 	PrepareConfig []interface{}
@@ -36,6 +37,9 @@ func (tb *MockBuilder) FlatConfig() interface{} { return tb.FlatMapstructure() }
 func (tb *MockBuilder) Prepare(config ...interface{}) ([]string, []string, error) {
 	tb.PrepareCalled = true
 	tb.PrepareConfig = config
+	if tb.PrepareErrResult {
+		return nil, tb.PrepareWarnings, errors.New("foo")
+	}
 	return tb.GeneratedVars, tb.PrepareWarnings, nil
 }
 
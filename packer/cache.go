@@ -1,8 +1,15 @@
 package packer
 
 import (
+	"encoding/hex"
+	"log"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/packer/packer-plugin-sdk/filelock"
 )
 
 var DefaultCacheDir = "packer_cache"
@@ -33,3 +40,129 @@ func CachePath(paths ...string) (path string, err error) {
 	paths = append([]string{cacheDir}, paths...)
 	return filepath.Abs(filepath.Join(paths...))
 }
+
+// SharedCacheDir returns the directory of the cache shared by all templates
+// and concurrent builds, set with PACKER_SHARED_CACHE_DIR. Files in the shared
+// cache are addressed by their checksum. SharedCacheDir returns an empty
+// string when the shared cache isn't used.
+func SharedCacheDir() (string, error) {
+	cd := os.Getenv("PACKER_SHARED_CACHE_DIR")
+	if cd == "" {
+		return "", nil
+	}
+	return filepath.Abs(cd)
+}
+
+// SharedCachePath returns the path of a file of the shared cache from its
+// checksum, like <dir>/sha256/<hex checksum>.<extension>, creating its
+// directory if it doesn't exist.
+func SharedCachePath(dir, checksumType string, checksum []byte, extension string) (string, error) {
+	name := hex.EncodeToString(checksum)
+	if extension != "" {
+		name += "." + extension
+	}
+	path := filepath.Join(dir, strings.ToLower(checksumType), name)
+	return path, os.MkdirAll(filepath.Dir(path), os.ModePerm)
+}
+
+// Files of the shared cache have lock files next to them: the download lock
+// is held while the file is downloaded and the use lock is shared by the
+// builds using the file. A file can be removed when both can be held.
+const (
+	CacheDownloadLockSuffix = ".lock"
+	CacheUseLockSuffix      = ".use"
+)
+
+// cacheEntry is a file of the shared cache, with what remains of its
+// interrupted downloads.
+type cacheEntry struct {
+	path    string
+	size    int64
+	modTime time.Time
+}
+
+// CacheGC removes the least recently used files of the shared cache in dir
+// until the cache is at most maxSize bytes. Files being downloaded or used
+// by a build are kept. CacheGC returns the paths of the removed files and
+// the size of the cache after it.
+func CacheGC(dir string, maxSize int64) (removed []string, size int64, err error) {
+	entries := map[string]*cacheEntry{}
+	err = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		name := path
+		switch filepath.Ext(path) {
+		case CacheDownloadLockSuffix, CacheUseLockSuffix:
+			return nil
+		case ".part", ".segments":
+			name = strings.TrimSuffix(path, filepath.Ext(path))
+		}
+		entry, ok := entries[name]
+		if !ok {
+			entry = &cacheEntry{path: name}
+			entries[name] = entry
+		}
+		entry.size += info.Size()
+		if info.ModTime().After(entry.modTime) {
+			entry.modTime = info.ModTime()
+		}
+		size += info.Size()
+		return nil
+	})
+	if err != nil {
+		return nil, size, err
+	}
+
+	sorted := make([]*cacheEntry, 0, len(entries))
+	for _, entry := range entries {
+		sorted = append(sorted, entry)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].modTime.Before(sorted[j].modTime)
+	})
+
+	for _, entry := range sorted {
+		if size <= maxSize {
+			break
+		}
+		ok, err := removeCacheEntry(entry)
+		if err != nil {
+			return removed, size, err
+		}
+		if !ok {
+			log.Printf("Keeping %s, it is in use", entry.path)
+			continue
+		}
+		removed = append(removed, entry.path)
+		size -= entry.size
+	}
+	return removed, size, nil
+}
+
+// removeCacheEntry removes a file of the shared cache unless another process
+// downloads or uses it, in which case it returns false.
+func removeCacheEntry(entry *cacheEntry) (bool, error) {
+	downloadLock := filelock.New(entry.path + CacheDownloadLockSuffix)
+	if ok, err := downloadLock.TryLock(); !ok || err != nil {
+		return false, err
+	}
+	defer downloadLock.Unlock()
+	useLock := filelock.New(entry.path + CacheUseLockSuffix)
+	if ok, err := useLock.TryLock(); !ok || err != nil {
+		return false, err
+	}
+	defer useLock.Unlock()
+
+	// The lock files are kept, a process waiting for them would otherwise
+	// hold a lock on a removed file.
+	for _, path := range []string{entry.path, entry.path + ".part", entry.path + ".segments"} {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return false, err
+		}
+	}
+	return true, nil
+}
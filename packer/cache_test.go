@@ -1,9 +1,14 @@
 package packer
 
 import (
+	"io/ioutil"
 	"os"
 	"path/filepath"
+	"reflect"
 	"testing"
+	"time"
+
+	"github.com/hashicorp/packer/packer-plugin-sdk/filelock"
 )
 
 func TestCachePath(t *testing.T) {
@@ -50,3 +55,68 @@ func TestCachePath(t *testing.T) {
 		})
 	}
 }
+
+func TestSharedCachePath(t *testing.T) {
+	dir, err := ioutil.TempDir("", "packer")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	got, err := SharedCachePath(dir, "SHA256", []byte{0xca, 0xfe}, "iso")
+	if err != nil {
+		t.Fatalf("SharedCachePath: %v", err)
+	}
+	if want := filepath.Join(dir, "sha256", "cafe.iso"); got != want {
+		t.Fatalf("SharedCachePath() = %v, want %v", got, want)
+	}
+	if _, err := os.Stat(filepath.Dir(got)); err != nil {
+		t.Fatalf("the directory of the file should be created: %v", err)
+	}
+}
+
+func TestCacheGC(t *testing.T) {
+	dir, err := ioutil.TempDir("", "packer")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	// The files are used from the oldest to the newest.
+	names := []string{"old.iso", "used.iso", "partial.iso", "new.iso"}
+	for i, name := range names {
+		path := filepath.Join(dir, name)
+		if name == "partial.iso" {
+			path += ".part"
+		}
+		if err := ioutil.WriteFile(path, make([]byte, 100), 0644); err != nil {
+			t.Fatalf("WriteFile: %v", err)
+		}
+		modTime := time.Now().Add(time.Duration(i-len(names)) * time.Hour)
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatalf("Chtimes: %v", err)
+		}
+	}
+	lock := filelock.New(filepath.Join(dir, "used.iso") + CacheUseLockSuffix)
+	if _, err := lock.TryRLock(); err != nil {
+		t.Fatalf("TryRLock: %v", err)
+	}
+	defer lock.Unlock()
+
+	removed, size, err := CacheGC(dir, 200)
+	if err != nil {
+		t.Fatalf("CacheGC: %v", err)
+	}
+	want := []string{filepath.Join(dir, "old.iso"), filepath.Join(dir, "partial.iso")}
+	if !reflect.DeepEqual(removed, want) {
+		t.Fatalf("CacheGC() removed %v, want %v", removed, want)
+	}
+	if size != 200 {
+		t.Fatalf("CacheGC() size = %d, want 200", size)
+	}
+	for _, name := range []string{"used.iso", "new.iso"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Fatalf("%s should be kept: %v", name, err)
+		}
+	}
+}
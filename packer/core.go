@@ -80,6 +80,11 @@ type PostProcessorStore interface {
 	Start(name string) (PostProcessor, error)
 }
 
+type DatasourceStore interface {
+	BasicStore
+	Start(name string) (Datasource, error)
+}
+
 // ComponentFinder is a struct that contains the various function
 // pointers necessary to look up components of Packer such as builders,
 // commands, etc.
@@ -90,6 +95,12 @@ type ComponentFinder struct {
 	BuilderStore       BuilderStore
 	ProvisionerStore   ProvisionerStore
 	PostProcessorStore PostProcessorStore
+	DatasourceStore    DatasourceStore
+
+	// LoadPluginFolder registers the components of the plugin binaries of
+	// the folder at path, over the components of the same name. It loads the
+	// required plugins of HCL2 templates.
+	LoadPluginFolder func(path string) error
 }
 
 // NewCore creates a new Core.
@@ -240,6 +251,9 @@ func (c *Core) GetBuilds(opts GetBuildsOptions) ([]Build, hcl.Diagnostics) {
 		b.SetDebug(opts.Debug)
 		b.SetForce(opts.Force)
 		b.SetOnError(opts.OnError)
+		b.SetResume(opts.Resume)
+		b.SetValidate(opts.Validate)
+		b.SetCleanupTimeout(opts.CleanupTimeout)
 
 		warnings, err := b.Prepare()
 		if err != nil {
@@ -382,6 +396,8 @@ func (c *Core) Build(n string) (Build, error) {
 		CleanupProvisioner: cleanupProvisioner,
 		TemplatePath:       c.Template.Path,
 		Variables:          c.variables,
+		PackerVersion:      c.version,
+		Retries:            c.Template.Retries,
 	}, nil
 }
 
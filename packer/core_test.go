@@ -70,7 +70,7 @@ func TestCoreBuild_basic(t *testing.T) {
 		t.Fatalf("err: %s", err)
 	}
 
-	artifact, err := build.Run(context.Background(), nil)
+	artifact, err := build.Run(context.Background(), testUi())
 	if err != nil {
 		t.Fatalf("err: %s", err)
 	}
@@ -100,7 +100,7 @@ func TestCoreBuild_basicInterpolated(t *testing.T) {
 		t.Fatalf("err: %s", err)
 	}
 
-	artifact, err := build.Run(context.Background(), nil)
+	artifact, err := build.Run(context.Background(), testUi())
 	if err != nil {
 		t.Fatalf("err: %s", err)
 	}
@@ -263,7 +263,7 @@ func TestCoreBuild_prov(t *testing.T) {
 		t.Fatalf("err: %s", err)
 	}
 
-	artifact, err := build.Run(context.Background(), nil)
+	artifact, err := build.Run(context.Background(), testUi())
 	if err != nil {
 		t.Fatalf("err: %s", err)
 	}
@@ -297,7 +297,7 @@ func TestCoreBuild_provSkip(t *testing.T) {
 		t.Fatalf("err: %s", err)
 	}
 
-	artifact, err := build.Run(context.Background(), nil)
+	artifact, err := build.Run(context.Background(), testUi())
 	if err != nil {
 		t.Fatalf("err: %s", err)
 	}
@@ -331,7 +331,7 @@ func TestCoreBuild_provSkipInclude(t *testing.T) {
 		t.Fatalf("err: %s", err)
 	}
 
-	artifact, err := build.Run(context.Background(), nil)
+	artifact, err := build.Run(context.Background(), testUi())
 	if err != nil {
 		t.Fatalf("err: %s", err)
 	}
@@ -365,7 +365,7 @@ func TestCoreBuild_provOverride(t *testing.T) {
 		t.Fatalf("err: %s", err)
 	}
 
-	artifact, err := build.Run(context.Background(), nil)
+	artifact, err := build.Run(context.Background(), testUi())
 	if err != nil {
 		t.Fatalf("err: %s", err)
 	}
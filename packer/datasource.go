@@ -0,0 +1,25 @@
+package packer
+
+import (
+	"github.com/hashicorp/hcl/v2/hcldec"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// A Datasource fetches data that HCL2 templates can use through
+// `data.<type>.<name>`. Data sources run once, when the template is
+// evaluated and before any build is started.
+type Datasource interface {
+	HCL2Speccer
+
+	// Configure is responsible for configuring the data source and validating
+	// its configuration. It should not fetch anything: this is what Execute
+	// is for.
+	Configure(...interface{}) error
+
+	// OutputSpec is the spec of the value returned by Execute.
+	OutputSpec() hcldec.ObjectSpec
+
+	// Execute fetches the data and returns it as an object conforming to
+	// OutputSpec.
+	Execute() (cty.Value, error)
+}
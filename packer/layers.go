@@ -0,0 +1,70 @@
+package packer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+)
+
+// A Layerer is a Communicator that can save the machine, as provisioned so
+// far, as a layer and replace the machine with a saved layer, like the docker
+// builder does to cache provisioning between builds. Provisioners don't use
+// it, the provision hook saves a layer after each provisioner.
+type Layerer interface {
+	// LayersEnabled returns whether the builder saves layers.
+	LayersEnabled() (bool, error)
+
+	// ResumeFromLayer replaces the machine with the layer saved with key. It
+	// returns false when no layer was saved with key.
+	ResumeFromLayer(key string) (bool, error)
+
+	// CommitLayer saves the machine as the layer of key.
+	CommitLayer(key string) error
+}
+
+// LayersEnabled returns whether comm saves layers.
+func LayersEnabled(comm Communicator) (bool, error) {
+	if l, ok := comm.(Layerer); ok {
+		return l.LayersEnabled()
+	}
+	return false, nil
+}
+
+// ResumeFromLayer replaces the machine of comm with the layer saved with key,
+// if comm is a Layerer. It returns false when no layer was saved with key.
+func ResumeFromLayer(comm Communicator, key string) (bool, error) {
+	if l, ok := comm.(Layerer); ok {
+		return l.ResumeFromLayer(key)
+	}
+	return false, nil
+}
+
+// CommitLayer saves the machine of comm as the layer of key, if comm is a
+// Layerer.
+func CommitLayer(comm Communicator, key string) error {
+	if l, ok := comm.(Layerer); ok {
+		return l.CommitLayer(key)
+	}
+	return nil
+}
+
+// provisionerLayerKeys returns the keys of the layers saved after each of
+// the provisioners. The key of a layer hashes the type and configuration of
+// its provisioner and the key of the layer before, so that changing a
+// provisioner changes the keys of its layer and of all the layers after it.
+func provisionerLayerKeys(provisioners []*HookedProvisioner) []string {
+	keys := make([]string, len(provisioners))
+	previous := ""
+	for i, p := range provisioners {
+		config, err := json.Marshal(p.Config)
+		if err != nil {
+			// fmt prints maps sorted by key, so the key is still stable
+			config = []byte(fmt.Sprintf("%v", p.Config))
+		}
+		sum := sha256.Sum256([]byte(previous + "\n" + p.TypeName + "\n" + string(config)))
+		keys[i] = hex.EncodeToString(sum[:])
+		previous = keys[i]
+	}
+	return keys
+}
@@ -0,0 +1,117 @@
+package packer
+
+import (
+	"context"
+	"testing"
+)
+
+type mockLayerer struct {
+	MockCommunicator
+
+	layers    map[string]bool
+	resumedTo string
+	committed []string
+}
+
+func (c *mockLayerer) LayersEnabled() (bool, error) {
+	return true, nil
+}
+
+func (c *mockLayerer) ResumeFromLayer(key string) (bool, error) {
+	if !c.layers[key] {
+		return false, nil
+	}
+	c.resumedTo = key
+	return true, nil
+}
+
+func (c *mockLayerer) CommitLayer(key string) error {
+	if c.layers == nil {
+		c.layers = map[string]bool{}
+	}
+	c.layers[key] = true
+	c.committed = append(c.committed, key)
+	return nil
+}
+
+func testLayeredHook() (*ProvisionHook, []*MockProvisioner) {
+	provisioners := []*MockProvisioner{{}, {}, {}}
+	hook := &ProvisionHook{}
+	for i, p := range provisioners {
+		hook.Provisioners = append(hook.Provisioners, &HookedProvisioner{
+			Provisioner: p,
+			Config:      map[string]interface{}{"inline": []string{"step", string(rune('a' + i))}},
+			TypeName:    "shell",
+		})
+	}
+	return hook, provisioners
+}
+
+func TestProvisionHook_layers(t *testing.T) {
+	comm := new(mockLayerer)
+	ui := TestUi(t)
+
+	// The first build runs all the provisioners and saves their layers
+	hook, provisioners := testLayeredHook()
+	if err := hook.Run(context.Background(), HookProvision, ui, comm, nil); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	for i, p := range provisioners {
+		if !p.ProvCalled {
+			t.Fatalf("provisioner %d should run", i)
+		}
+	}
+	if len(comm.committed) != 3 || comm.resumedTo != "" {
+		t.Fatalf("bad: committed %d layers, resumed to %q", len(comm.committed), comm.resumedTo)
+	}
+
+	// Changing the last provisioner only runs it again
+	comm.committed = nil
+	hook, provisioners = testLayeredHook()
+	hook.Provisioners[2].Config = map[string]interface{}{"inline": []string{"changed"}}
+	if err := hook.Run(context.Background(), HookProvision, ui, comm, nil); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if provisioners[0].ProvCalled || provisioners[1].ProvCalled || !provisioners[2].ProvCalled {
+		t.Fatal("only the changed provisioner should run")
+	}
+	keys := provisionerLayerKeys(hook.Provisioners)
+	if comm.resumedTo != keys[1] {
+		t.Fatalf("should resume from the layer of the second provisioner")
+	}
+	if len(comm.committed) != 1 || comm.committed[0] != keys[2] {
+		t.Fatalf("should only save the layer of the changed provisioner: %v", comm.committed)
+	}
+}
+
+func TestProvisionHook_layersCleanup(t *testing.T) {
+	comm := new(mockLayerer)
+	hook, provisioners := testLayeredHook()
+	if err := hook.Run(context.Background(), HookCleanupProvision, TestUi(t), comm, nil); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if !provisioners[0].ProvCalled || len(comm.committed) != 0 {
+		t.Fatal("the cleanup provisioners should not be cached")
+	}
+}
+
+func TestProvisionerLayerKeys(t *testing.T) {
+	hook, _ := testLayeredHook()
+	keys := provisionerLayerKeys(hook.Provisioners)
+
+	hook.Provisioners[1].Config = map[string]interface{}{"inline": []string{"changed"}}
+	changed := provisionerLayerKeys(hook.Provisioners)
+	if keys[0] != changed[0] {
+		t.Fatal("the layer before the changed provisioner should not change")
+	}
+	if keys[1] == changed[1] || keys[2] == changed[2] {
+		t.Fatal("the layers from the changed provisioner should change")
+	}
+
+	again := provisionerLayerKeys(hook.Provisioners)
+	for i := range again {
+		if again[i] != changed[i] {
+			t.Fatal("the keys should be stable")
+		}
+	}
+}
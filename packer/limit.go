@@ -0,0 +1,18 @@
+package packer
+
+// BuildLimit limits how many of the builds that share it run at once.
+type BuildLimit struct {
+	// Name of the limit, builds with a limit of the same name share it.
+	Name string
+	// Max is how many of these builds can run at once.
+	Max int64
+}
+
+// LimitedBuild is a Build that can't run at the same time as too many other
+// builds.
+type LimitedBuild interface {
+	Build
+
+	// Limits returns the limits the build is subject to.
+	Limits() []BuildLimit
+}
@@ -1,11 +1,16 @@
 package packer
 
 import (
-	"bytes"
 	"io"
+	"strings"
 	"sync"
+
+	"github.com/zclconf/go-cty/cty"
 )
 
+// secretFilter replaces the secrets it knows by "<sensitive>". Packer uses
+// LogSecretFilter to scrub the logs, the output of the Ui and the errors
+// plugins send back to the core.
 type secretFilter struct {
 	s map[string]struct{}
 	m sync.Mutex
@@ -20,6 +25,29 @@ func (l *secretFilter) Set(secrets ...string) {
 	}
 }
 
+// SetValue sets every string of v as a secret, like the values of a
+// sensitive variable or the output of a secret data source.
+func (l *secretFilter) SetValue(v cty.Value) {
+	_ = cty.Walk(v, func(_ cty.Path, nested cty.Value) (bool, error) {
+		if nested.IsWhollyKnown() && !nested.IsNull() && nested.Type().Equals(cty.String) {
+			l.Set(nested.AsString())
+		}
+		return true, nil
+	})
+}
+
+// Scrub returns message with the secrets replaced by "<sensitive>".
+func (l *secretFilter) Scrub(message string) string {
+	l.m.Lock()
+	defer l.m.Unlock()
+	for s := range l.s {
+		if s != "" {
+			message = strings.Replace(message, s, "<sensitive>", -1)
+		}
+	}
+	return message
+}
+
 func (l *secretFilter) SetOutput(output io.Writer) {
 	l.m.Lock()
 	defer l.m.Unlock()
@@ -27,12 +55,7 @@ func (l *secretFilter) SetOutput(output io.Writer) {
 }
 
 func (l *secretFilter) Write(p []byte) (n int, err error) {
-	for s := range l.s {
-		if s != "" {
-			p = bytes.Replace(p, []byte(s), []byte("<sensitive>"), -1)
-		}
-	}
-	return l.w.Write(p)
+	return l.w.Write([]byte(l.Scrub(string(p))))
 }
 
 func (l *secretFilter) get() (s []string) {
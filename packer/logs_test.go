@@ -0,0 +1,33 @@
+package packer
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestSecretFilter(t *testing.T) {
+	buf := new(bytes.Buffer)
+	filter := &secretFilter{s: map[string]struct{}{}, w: buf}
+	filter.Set("hunter2", "")
+	filter.SetValue(cty.ObjectVal(map[string]cty.Value{
+		"password": cty.StringVal("s3cr3t"),
+		"tokens":   cty.ListVal([]cty.Value{cty.StringVal("t0k3n")}),
+		"port":     cty.NumberIntVal(22),
+		"unknown":  cty.UnknownVal(cty.String),
+	}))
+
+	message := "hunter2 s3cr3t t0k3n 22"
+	expected := "<sensitive> <sensitive> <sensitive> 22"
+	if actual := filter.Scrub(message); actual != expected {
+		t.Fatalf("bad scrubbed message: %q", actual)
+	}
+
+	if _, err := filter.Write([]byte(message)); err != nil {
+		t.Fatal(err)
+	}
+	if actual := buf.String(); actual != expected {
+		t.Fatalf("bad written message: %q", actual)
+	}
+}
@@ -72,3 +72,26 @@ func (mob MapOfBuilder) List() []string {
 	}
 	return res
 }
+
+type MapOfDatasource map[string]func() (Datasource, error)
+
+func (mod MapOfDatasource) Has(datasource string) bool {
+	_, res := mod[datasource]
+	return res
+}
+
+func (mod MapOfDatasource) Start(datasource string) (Datasource, error) {
+	d, found := mod[datasource]
+	if !found {
+		return nil, fmt.Errorf("Unknown data source %s", datasource)
+	}
+	return d()
+}
+
+func (mod MapOfDatasource) List() []string {
+	res := []string{}
+	for k := range mod {
+		res = append(res, k)
+	}
+	return res
+}
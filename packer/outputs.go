@@ -0,0 +1,40 @@
+package packer
+
+import (
+	"encoding/json"
+)
+
+// BuildOutput is the value of an output of a build, evaluated once the build
+// succeeded, like the ID of an image for the pipeline that uses it.
+type BuildOutput struct {
+	// Value is the value of the output, encoded as JSON.
+	Value       json.RawMessage `json:"value"`
+	Description string          `json:"description,omitempty"`
+	// Sensitive outputs aren't shown, they are only written to the outputs
+	// file.
+	Sensitive bool `json:"sensitive"`
+}
+
+// OutputsEvaluator evaluates the outputs a config declares for a build, from
+// the artifacts of the build.
+type OutputsEvaluator interface {
+	EvaluateOutputs(artifacts []Artifact) (map[string]BuildOutput, error)
+}
+
+// OutputBuild is a Build that can have outputs.
+type OutputBuild interface {
+	Build
+
+	// Outputs evaluates the outputs of the build from the artifacts of a
+	// successful run. It returns no outputs when the build declares none.
+	Outputs(artifacts []Artifact) (map[string]BuildOutput, error)
+}
+
+// Outputs evaluates the outputs of the build from the artifacts of a
+// successful run.
+func (b *CoreBuild) Outputs(artifacts []Artifact) (map[string]BuildOutput, error) {
+	if b.OutputsEvaluator == nil {
+		return nil, nil
+	}
+	return b.OutputsEvaluator.EvaluateOutputs(artifacts)
+}
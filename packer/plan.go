@@ -0,0 +1,73 @@
+package packer
+
+import (
+	"strings"
+
+	"github.com/hashicorp/packer/packer-plugin-sdk/template/interpolate"
+)
+
+// BuildPlan is what a build would run, with the configuration of its
+// components once interpolated. It is shown by `packer build -dry-run`.
+type BuildPlan struct {
+	Build          string        `json:"build"`
+	Builder        PlanEntry     `json:"builder"`
+	Provisioners   []PlanEntry   `json:"provisioners"`
+	PostProcessors [][]PlanEntry `json:"post_processors"`
+}
+
+// PlanEntry is a builder, provisioner or post-processor of a BuildPlan.
+type PlanEntry struct {
+	Type   string                 `json:"type"`
+	Name   string                 `json:"name,omitempty"`
+	Config map[string]interface{} `json:"config"`
+}
+
+// PlannedBuild is a Build that can tell what it would run.
+type PlannedBuild interface {
+	Build
+
+	// Plan returns what the build would run.
+	Plan() BuildPlan
+}
+
+// planConfig merges the raw configurations of a component of a JSON template
+// and interpolates them. The strings that can't be interpolated before the
+// build runs, like `{{ .Path }}` in an execute_command, are left as they are.
+func planConfig(ctx *interpolate.Context, raws ...interface{}) map[string]interface{} {
+	config := map[string]interface{}{}
+	for _, raw := range raws {
+		m, ok := raw.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for k, v := range m {
+			config[k] = planValue(ctx, v)
+		}
+	}
+	return config
+}
+
+func planValue(ctx *interpolate.Context, v interface{}) interface{} {
+	switch v := v.(type) {
+	case string:
+		rendered, err := interpolate.RenderOnce(v, ctx)
+		if err != nil || strings.Contains(rendered, "<no value>") {
+			return v
+		}
+		return rendered
+	case []interface{}:
+		res := make([]interface{}, len(v))
+		for i := range v {
+			res[i] = planValue(ctx, v[i])
+		}
+		return res
+	case map[string]interface{}:
+		res := make(map[string]interface{}, len(v))
+		for k := range v {
+			res[k] = planValue(ctx, v[k])
+		}
+		return res
+	default:
+		return v
+	}
+}
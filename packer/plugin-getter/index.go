@@ -0,0 +1,183 @@
+package plugingetter
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/hashicorp/go-version"
+	"github.com/hashicorp/packer/hcl2template/addrs"
+)
+
+// pluginIndex is the index of the versions of a plugin in an HTTPS registry,
+// like:
+//
+//	{
+//	  "versions": {
+//	    "1.0.2": {
+//	      "linux_amd64": {
+//	        "url": "1.0.2/packer-plugin-amazon_1.0.2_linux_amd64.zip",
+//	        "sha256": "5b2c..."
+//	      }
+//	    }
+//	  }
+//	}
+//
+// The URLs of the archives are relative to the URL of the index.
+type pluginIndex struct {
+	Versions map[string]map[string]indexArchive `json:"versions"`
+}
+
+type indexArchive struct {
+	URL    string `json:"url"`
+	SHA256 string `json:"sha256"`
+}
+
+// indexGetter gets plugins from the signed indexes of an HTTPS registry.
+type indexGetter struct {
+	url       string
+	publicKey ed25519.PublicKey
+	client    *http.Client
+}
+
+func (g *indexGetter) indexURL(id *addrs.Plugin) string {
+	return fmt.Sprintf("%s/%s/%s/index.json", g.url, id.Namespace, id.Type)
+}
+
+// index downloads the index of the plugin of id, and checks its signature.
+func (g *indexGetter) index(ctx context.Context, id *addrs.Plugin) (*pluginIndex, error) {
+	indexURL := g.indexURL(id)
+	body, err := g.fetch(ctx, indexURL)
+	if err != nil {
+		return nil, err
+	}
+	sig, err := g.fetch(ctx, indexURL+".sig")
+	if err != nil {
+		return nil, err
+	}
+	sig, err = base64.StdEncoding.DecodeString(strings.TrimSpace(string(sig)))
+	if err != nil {
+		return nil, fmt.Errorf("invalid signature of %s: %s", indexURL, err)
+	}
+	if !ed25519.Verify(g.publicKey, body, sig) {
+		return nil, fmt.Errorf("the signature of %s doesn't match the public key of the registry", indexURL)
+	}
+
+	index := &pluginIndex{}
+	if err := json.Unmarshal(body, index); err != nil {
+		return nil, fmt.Errorf("invalid index %s: %s", indexURL, err)
+	}
+	return index, nil
+}
+
+func (g *indexGetter) fetch(ctx context.Context, u string) ([]byte, error) {
+	resp, err := g.get(ctx, u)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	return ioutil.ReadAll(resp.Body)
+}
+
+func (g *indexGetter) get(ctx context.Context, u string) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := g.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("GET %s: %s", u, resp.Status)
+	}
+	return resp, nil
+}
+
+func (g *indexGetter) Versions(ctx context.Context, id *addrs.Plugin, platform string) (version.Collection, error) {
+	index, err := g.index(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	var res version.Collection
+	for raw, archives := range index.Versions {
+		v, err := version.NewVersion(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid version %q in %s: %s", raw, g.indexURL(id), err)
+		}
+		if _, found := archives[platform]; found {
+			res = append(res, v)
+		}
+	}
+	return res, nil
+}
+
+func (g *indexGetter) Get(ctx context.Context, id *addrs.Plugin, v *version.Version, platform string, dst string) error {
+	index, err := g.index(ctx, id)
+	if err != nil {
+		return err
+	}
+	var archive *indexArchive
+	for raw, archives := range index.Versions {
+		if iv, err := version.NewVersion(raw); err == nil && iv.Equal(v) {
+			if a, found := archives[platform]; found {
+				archive = &a
+			}
+		}
+	}
+	if archive == nil {
+		return fmt.Errorf("%s has no archive of %s %s for %s", g.indexURL(id), id, v, platform)
+	}
+
+	base, err := url.Parse(g.indexURL(id))
+	if err != nil {
+		return err
+	}
+	ref, err := url.Parse(archive.URL)
+	if err != nil {
+		return fmt.Errorf("invalid URL of the archive of %s %s: %s", id, v, err)
+	}
+	u := base.ResolveReference(ref)
+	if u.Scheme != "https" {
+		return fmt.Errorf("the archive %s of %s %s must be downloaded over https", u, id, v)
+	}
+
+	resp, err := g.get(ctx, u.String())
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return writeChecked(resp.Body, dst, archive.SHA256)
+}
+
+// writeChecked writes r to dst, and removes dst unless the SHA256 sum of r
+// is the hex encoded sum.
+func writeChecked(r io.Reader, dst string, sum string) error {
+	f, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	h := sha256.New()
+	_, err = io.Copy(io.MultiWriter(f, h), r)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil && !strings.EqualFold(hex.EncodeToString(h.Sum(nil)), sum) {
+		err = fmt.Errorf("the SHA256 sum %x of the archive doesn't match the expected sum %s", h.Sum(nil), sum)
+	}
+	if err != nil {
+		os.Remove(dst)
+	}
+	return err
+}
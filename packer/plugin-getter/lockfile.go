@@ -0,0 +1,205 @@
+package plugingetter
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+
+	"github.com/hashicorp/go-version"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/gohcl"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// LockfileName is the name of the lockfile of a config, written next to its
+// files by packer init.
+const LockfileName = ".packer.lock.hcl"
+
+const lockfileHeader = `# This file is maintained automatically by "packer init".
+# Manual edits may be lost in future updates.
+
+`
+
+// Lockfile locks the versions of the plugins a config requires, and the
+// checksums of their installations for each platform, like:
+//
+//	plugin "github.com/hashicorp/amazon" {
+//	  version     = "1.0.0"
+//	  constraints = ">= 1.0.0"
+//	  hashes = {
+//	    darwin_arm64 = "sha256:9d3f..."
+//	    linux_amd64  = "sha256:5b2c..."
+//	  }
+//	}
+type Lockfile struct {
+	Plugins []*LockedPlugin `hcl:"plugin,block"`
+}
+
+// LockedPlugin is the locked version of a plugin.
+type LockedPlugin struct {
+	Source      string            `hcl:"source,label"`
+	Version     string            `hcl:"version"`
+	Constraints string            `hcl:"constraints,optional"`
+	Hashes      map[string]string `hcl:"hashes,optional"`
+}
+
+// ReadLockfile reads the lockfile at path. The lockfile is empty when there
+// is no file at path.
+func ReadLockfile(path string) (*Lockfile, error) {
+	l := &Lockfile{}
+	src, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return l, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	f, diags := hclsyntax.ParseConfig(src, path, hcl.Pos{Line: 1, Column: 1})
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	if diags := gohcl.DecodeBody(f.Body, nil, l); diags.HasErrors() {
+		return nil, diags
+	}
+
+	seen := map[string]bool{}
+	for _, p := range l.Plugins {
+		if seen[p.Source] {
+			return nil, fmt.Errorf("%s: %s is locked more than once", path, p.Source)
+		}
+		seen[p.Source] = true
+		if _, err := version.NewVersion(p.Version); err != nil {
+			return nil, fmt.Errorf("%s: invalid version of %s: %s", path, p.Source, err)
+		}
+	}
+	return l, nil
+}
+
+// Write writes the lockfile to path.
+func (l *Lockfile) Write(path string) error {
+	f := hclwrite.NewEmptyFile()
+	body := f.Body()
+	for i, p := range l.Plugins {
+		if i > 0 {
+			body.AppendNewline()
+		}
+		block := body.AppendNewBlock("plugin", []string{p.Source}).Body()
+		block.SetAttributeValue("version", cty.StringVal(p.Version))
+		if p.Constraints != "" {
+			block.SetAttributeValue("constraints", cty.StringVal(p.Constraints))
+		}
+		hashes := cty.MapValEmpty(cty.String)
+		if len(p.Hashes) > 0 {
+			m := map[string]cty.Value{}
+			for platform, sum := range p.Hashes {
+				m[platform] = cty.StringVal(sum)
+			}
+			hashes = cty.MapVal(m)
+		}
+		block.SetAttributeValue("hashes", hashes)
+	}
+	return ioutil.WriteFile(path, append([]byte(lockfileHeader), hclwrite.Format(f.Bytes())...), 0644)
+}
+
+// Plugin returns the locked version of the plugin of source, or nil.
+func (l *Lockfile) Plugin(source string) *LockedPlugin {
+	for _, p := range l.Plugins {
+		if p.Source == source {
+			return p
+		}
+	}
+	return nil
+}
+
+// Lock locks version v of the plugin of pr, with the checksum of its
+// installation for platform. The checksums of the other platforms are kept
+// when the locked version doesn't change.
+func (l *Lockfile) Lock(pr *Requirement, v *version.Version, platform, sum string) {
+	source := pr.Identifier.String()
+	p := l.Plugin(source)
+	if p == nil {
+		p = &LockedPlugin{Source: source}
+		l.Plugins = append(l.Plugins, p)
+		sort.Slice(l.Plugins, func(i, j int) bool {
+			return l.Plugins[i].Source < l.Plugins[j].Source
+		})
+	}
+	if p.Version != v.String() {
+		p.Version = v.String()
+		p.Hashes = nil
+	}
+	p.Constraints = pr.VersionConstraints.String()
+	if p.Hashes == nil {
+		p.Hashes = map[string]string{}
+	}
+	p.Hashes[platform] = sum
+}
+
+// Prune removes the plugins that reqs don't require anymore, and returns
+// their sources.
+func (l *Lockfile) Prune(reqs Requirements) []string {
+	required := map[string]bool{}
+	for _, pr := range reqs {
+		required[pr.Identifier.String()] = true
+	}
+	var kept []*LockedPlugin
+	var removed []string
+	for _, p := range l.Plugins {
+		if required[p.Source] {
+			kept = append(kept, p)
+			continue
+		}
+		removed = append(removed, p.Source)
+	}
+	l.Plugins = kept
+	return removed
+}
+
+// Verify returns the installation of the version of the plugin that is
+// locked in l, once it checked that this version matches the version
+// constraints of pr, and that the checksum of the installation is the one
+// locked for the platform of opts.
+func (pr *Requirement) Verify(l *Lockfile, opts ListInstallationsOptions) (*Installation, error) {
+	source := pr.Identifier.String()
+	locked := l.Plugin(source)
+	if locked == nil {
+		return nil, fmt.Errorf("%s is not in the lockfile, run packer init to lock a version of it", source)
+	}
+	v, err := version.NewVersion(locked.Version)
+	if err != nil {
+		return nil, fmt.Errorf("invalid locked version of %s: %s", source, err)
+	}
+	if !pr.VersionConstraints.Check(v) {
+		return nil, fmt.Errorf("the locked version %s of %s doesn't match the version constraints %q, run packer init -upgrade to lock a version that does",
+			v, source, pr.VersionConstraints)
+	}
+
+	installs, err := pr.ListInstallations(opts)
+	if err != nil {
+		return nil, err
+	}
+	install := installs.Find(v)
+	if install == nil {
+		return nil, fmt.Errorf("the locked version %s of %s is not installed for %s, run packer init to install it",
+			v, source, opts.Platform())
+	}
+
+	expected, found := locked.Hashes[opts.Platform()]
+	if !found {
+		return nil, fmt.Errorf("the lockfile has no checksum of %s %s for %s, run packer init to add it",
+			source, v, opts.Platform())
+	}
+	sum, err := HashDir(install.Dir)
+	if err != nil {
+		return nil, err
+	}
+	if sum != expected {
+		return nil, fmt.Errorf("the checksum %s of %s %s in %s doesn't match the checksum %s of the lockfile; the binaries of the plugin changed since it was locked",
+			sum, source, v, install.Dir, expected)
+	}
+	return install, nil
+}
@@ -0,0 +1,146 @@
+package plugingetter
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/hashicorp/go-version"
+)
+
+func TestLockfile_WriteRead(t *testing.T) {
+	dir, err := ioutil.TempDir("", "packer-lockfile")
+	if err != nil {
+		t.Fatalf("TempDir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, LockfileName)
+
+	l, err := ReadLockfile(path)
+	if err != nil {
+		t.Fatalf("ReadLockfile of a missing file: %s", err)
+	}
+	if len(l.Plugins) != 0 {
+		t.Fatalf("expected an empty lockfile, got %#v", l.Plugins)
+	}
+
+	pr := testRequirement(t, ">= 1.0.0")
+	l.Lock(pr, version.Must(version.NewVersion("1.2.0")), "linux_amd64", "sha256:aa")
+	l.Lock(pr, version.Must(version.NewVersion("1.2.0")), "darwin_arm64", "sha256:bb")
+	if err := l.Write(path); err != nil {
+		t.Fatalf("Write: %s", err)
+	}
+
+	read, err := ReadLockfile(path)
+	if err != nil {
+		t.Fatalf("ReadLockfile: %s", err)
+	}
+	expected := []*LockedPlugin{{
+		Source:      "github.com/hashicorp/amazon",
+		Version:     "1.2.0",
+		Constraints: ">= 1.0.0",
+		Hashes: map[string]string{
+			"linux_amd64":  "sha256:aa",
+			"darwin_arm64": "sha256:bb",
+		},
+	}}
+	if diff := cmp.Diff(expected, read.Plugins); diff != "" {
+		t.Fatalf("unexpected lockfile: %s", diff)
+	}
+
+	// Locking another version drops the checksums of the other platforms.
+	read.Lock(pr, version.Must(version.NewVersion("1.3.0")), "linux_amd64", "sha256:cc")
+	if diff := cmp.Diff(map[string]string{"linux_amd64": "sha256:cc"}, read.Plugins[0].Hashes); diff != "" {
+		t.Fatalf("unexpected hashes: %s", diff)
+	}
+
+	if removed := read.Prune(nil); len(removed) != 1 || removed[0] != "github.com/hashicorp/amazon" {
+		t.Fatalf("unexpected pruned plugins: %v", removed)
+	}
+	if len(read.Plugins) != 0 {
+		t.Fatalf("expected an empty lockfile, got %#v", read.Plugins)
+	}
+}
+
+func TestReadLockfile_invalid(t *testing.T) {
+	dir, err := ioutil.TempDir("", "packer-lockfile")
+	if err != nil {
+		t.Fatalf("TempDir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	tc := map[string]string{
+		"locked more than once": `
+plugin "github.com/hashicorp/amazon" { version = "1.0.0" }
+plugin "github.com/hashicorp/amazon" { version = "1.1.0" }
+`,
+		"invalid version": `
+plugin "github.com/hashicorp/amazon" { version = "latest" }
+`,
+	}
+	for expected, content := range tc {
+		path := filepath.Join(dir, LockfileName)
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("WriteFile: %s", err)
+		}
+		_, err := ReadLockfile(path)
+		if err == nil || !strings.Contains(err.Error(), expected) {
+			t.Fatalf("expected an error with %q, got %v", expected, err)
+		}
+	}
+}
+
+func TestRequirement_Verify(t *testing.T) {
+	folder, err := ioutil.TempDir("", "packer-plugins")
+	if err != nil {
+		t.Fatalf("TempDir: %s", err)
+	}
+	defer os.RemoveAll(folder)
+
+	opts := ListInstallationsOptions{
+		FromFolders: []string{folder},
+		OS:          "linux",
+		ARCH:        "amd64",
+	}
+	pr := testRequirement(t, ">= 1.0.0")
+	dir := installPlugin(t, pr, folder, "1.2.0", opts)
+	installPlugin(t, pr, folder, "1.3.0", opts)
+	sum, err := HashDir(dir)
+	if err != nil {
+		t.Fatalf("HashDir: %s", err)
+	}
+
+	l := &Lockfile{}
+	if _, err := pr.Verify(l, opts); err == nil || !strings.Contains(err.Error(), "not in the lockfile") {
+		t.Fatalf("expected an unlocked plugin error, got %v", err)
+	}
+
+	l.Lock(pr, version.Must(version.NewVersion("1.2.0")), "darwin_arm64", "sha256:bb")
+	if _, err := pr.Verify(l, opts); err == nil || !strings.Contains(err.Error(), "no checksum") {
+		t.Fatalf("expected a missing checksum error, got %v", err)
+	}
+
+	l.Lock(pr, version.Must(version.NewVersion("1.2.0")), "linux_amd64", sum)
+	install, err := pr.Verify(l, opts)
+	if err != nil {
+		t.Fatalf("Verify: %s", err)
+	}
+	if install.Dir != dir {
+		t.Fatalf("expected the locked version 1.2.0 in %s, got %s", dir, install.Dir)
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(dir, "packer-builder-amazon-ebs"), []byte("tampered"), 0755); err != nil {
+		t.Fatalf("WriteFile: %s", err)
+	}
+	if _, err := pr.Verify(l, opts); err == nil || !strings.Contains(err.Error(), "changed since it was locked") {
+		t.Fatalf("expected a checksum mismatch error, got %v", err)
+	}
+
+	strict := testRequirement(t, ">= 1.3.0")
+	if _, err := strict.Verify(l, opts); err == nil || !strings.Contains(err.Error(), "doesn't match the version constraints") {
+		t.Fatalf("expected a version constraints error, got %v", err)
+	}
+}
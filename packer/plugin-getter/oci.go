@@ -0,0 +1,224 @@
+package plugingetter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/hashicorp/go-version"
+	"github.com/hashicorp/packer/hcl2template/addrs"
+)
+
+const (
+	ociManifestMediaType = "application/vnd.oci.image.manifest.v1+json"
+
+	// ociTitleAnnotation is the annotation of the file name of a layer, set
+	// by oras push.
+	ociTitleAnnotation = "org.opencontainers.image.title"
+)
+
+// ociManifest is the manifest of the artifact of a version of a plugin. It
+// has a layer per platform, the zip archive of the binaries of the plugin
+// for this platform, titled OS_ARCH.zip, like pushed by:
+//
+//	oras push registry.example.com/packer-plugins/hashicorp/amazon:1.0.2 \
+//	  linux_amd64.zip darwin_arm64.zip
+type ociManifest struct {
+	Layers []struct {
+		Digest      string            `json:"digest"`
+		Annotations map[string]string `json:"annotations"`
+	} `json:"layers"`
+}
+
+// ociGetter gets plugins from an OCI registry, through the OCI distribution
+// API.
+type ociGetter struct {
+	host     string
+	prefix   string
+	username string
+	password string
+	client   *http.Client
+
+	// tokens are the bearer tokens of the repositories.
+	tokens map[string]string
+}
+
+func (g *ociGetter) repository(id *addrs.Plugin) string {
+	return path.Join(g.prefix, id.Namespace, id.Type)
+}
+
+func (g *ociGetter) Versions(ctx context.Context, id *addrs.Plugin, platform string) (version.Collection, error) {
+	repo := g.repository(id)
+	var tags struct {
+		Tags []string `json:"tags"`
+	}
+	if err := g.getJSON(ctx, repo, "/tags/list", "application/json", &tags); err != nil {
+		return nil, err
+	}
+
+	var res version.Collection
+	for _, tag := range tags.Tags {
+		v, err := version.NewVersion(tag)
+		if err != nil {
+			// Not a version tag, like latest
+			continue
+		}
+		manifest := &ociManifest{}
+		if err := g.getJSON(ctx, repo, "/manifests/"+tag, ociManifestMediaType, manifest); err != nil {
+			return nil, err
+		}
+		if manifest.layer(platform) != "" {
+			res = append(res, v)
+		}
+	}
+	return res, nil
+}
+
+func (g *ociGetter) Get(ctx context.Context, id *addrs.Plugin, v *version.Version, platform string, dst string) error {
+	repo := g.repository(id)
+	manifest := &ociManifest{}
+	if err := g.getJSON(ctx, repo, "/manifests/"+v.Original(), ociManifestMediaType, manifest); err != nil {
+		return err
+	}
+	digest := manifest.layer(platform)
+	if digest == "" {
+		return fmt.Errorf("%s/%s:%s has no %s.zip layer", g.host, repo, v.Original(), platform)
+	}
+	if !strings.HasPrefix(digest, "sha256:") {
+		return fmt.Errorf("unsupported digest %s of the %s.zip layer of %s/%s:%s", digest, platform, g.host, repo, v.Original())
+	}
+
+	resp, err := g.get(ctx, repo, "/blobs/"+digest, "")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return writeChecked(resp.Body, dst, strings.TrimPrefix(digest, "sha256:"))
+}
+
+// layer returns the digest of the layer of platform, or "".
+func (m *ociManifest) layer(platform string) string {
+	for _, l := range m.Layers {
+		if l.Annotations[ociTitleAnnotation] == platform+".zip" {
+			return l.Digest
+		}
+	}
+	return ""
+}
+
+func (g *ociGetter) getJSON(ctx context.Context, repo, p, accept string, v interface{}) error {
+	resp, err := g.get(ctx, repo, p, accept)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("invalid response of %s: %s", resp.Request.URL, err)
+	}
+	return nil
+}
+
+// get gets https://<host>/v2/<repo><p>. When the registry requires a
+// bearer token, it gets one from the realm of the challenge of the
+// registry, with the username and password.
+func (g *ociGetter) get(ctx context.Context, repo, p, accept string) (*http.Response, error) {
+	u := fmt.Sprintf("https://%s/v2/%s%s", g.host, repo, p)
+	resp, err := g.do(ctx, u, accept, repo)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusUnauthorized && g.tokens[repo] == "" {
+		challenge := resp.Header.Get("WWW-Authenticate")
+		resp.Body.Close()
+		if !strings.HasPrefix(strings.ToLower(challenge), "bearer ") {
+			return nil, fmt.Errorf("GET %s: %s", u, resp.Status)
+		}
+		token, err := g.token(ctx, challenge)
+		if err != nil {
+			return nil, fmt.Errorf("failed to authenticate to %s: %s", g.host, err)
+		}
+		g.tokens[repo] = token
+		resp, err = g.do(ctx, u, accept, repo)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("GET %s: %s", u, resp.Status)
+	}
+	return resp, nil
+}
+
+func (g *ociGetter) do(ctx context.Context, u, accept, repo string) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	if token := g.tokens[repo]; token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	} else if g.username != "" {
+		req.SetBasicAuth(g.username, g.password)
+	}
+	return g.client.Do(req.WithContext(ctx))
+}
+
+var challengeParamRegexp = regexp.MustCompile(`(\w+)="([^"]*)"`)
+
+// token gets a bearer token from the realm of challenge, like
+// Bearer realm="https://auth.example.com/token",service="registry",scope="repository:x:pull".
+func (g *ociGetter) token(ctx context.Context, challenge string) (string, error) {
+	params := map[string]string{}
+	for _, m := range challengeParamRegexp.FindAllStringSubmatch(challenge, -1) {
+		params[strings.ToLower(m[1])] = m[2]
+	}
+	realm, err := url.Parse(params["realm"])
+	if err != nil || realm.Scheme != "https" {
+		return "", fmt.Errorf("invalid realm %q", params["realm"])
+	}
+	q := realm.Query()
+	for _, k := range []string{"service", "scope"} {
+		if params[k] != "" {
+			q.Set(k, params[k])
+		}
+	}
+	realm.RawQuery = q.Encode()
+
+	req, err := http.NewRequest(http.MethodGet, realm.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	if g.username != "" {
+		req.SetBasicAuth(g.username, g.password)
+	}
+	resp, err := g.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("GET %s: %s", realm, resp.Status)
+	}
+	var body struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", err
+	}
+	if body.Token != "" {
+		return body.Token, nil
+	}
+	if body.AccessToken != "" {
+		return body.AccessToken, nil
+	}
+	return "", fmt.Errorf("no token in the response of %s", realm)
+}
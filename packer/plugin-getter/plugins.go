@@ -0,0 +1,259 @@
+// Package plugingetter finds the installed versions of the plugins a config
+// requires, installs them from registries, and locks their versions and
+// checksums in a lockfile.
+package plugingetter
+
+import (
+	"archive/zip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/go-version"
+	"github.com/hashicorp/packer/hcl2template/addrs"
+)
+
+// Requirement is a plugin a config requires, with the versions it can use.
+type Requirement struct {
+	// Accessor is the name of the plugin in the required_plugins block of
+	// the config, like "amazon".
+	Accessor string
+
+	// Identifier is the source of the plugin, like
+	// "github.com/hashicorp/amazon".
+	Identifier *addrs.Plugin
+
+	// VersionConstraints are the versions the config can use. Any version
+	// can be used when there are none.
+	VersionConstraints version.Constraints
+}
+
+// Requirements are the plugins a config requires.
+type Requirements []*Requirement
+
+// ListInstallationsOptions sets where and for which platform the
+// installations of a plugin are looked for.
+type ListInstallationsOptions struct {
+	// FromFolders are the plugin folders, by order of preference. A version
+	// of a plugin is installed in the
+	// <folder>/<hostname>/<namespace>/<type>/<version>/<os>_<arch> folder.
+	FromFolders []string
+
+	// OS and ARCH are the platform of the installations, like runtime.GOOS
+	// and runtime.GOARCH.
+	OS, ARCH string
+}
+
+// Platform returns the platform of the installations, like "linux_amd64".
+func (opts ListInstallationsOptions) Platform() string {
+	return opts.OS + "_" + opts.ARCH
+}
+
+// Installation is an installed version of a plugin: a folder with the
+// binaries of its builders, provisioners and post-processors.
+type Installation struct {
+	Dir     string
+	Version *version.Version
+}
+
+// InstallList is a list of installations, from the oldest version to the
+// newest.
+type InstallList []*Installation
+
+// Find returns the installation of version v, or nil.
+func (l InstallList) Find(v *version.Version) *Installation {
+	for _, install := range l {
+		if install.Version.Equal(v) {
+			return install
+		}
+	}
+	return nil
+}
+
+// Newest returns the installation of the newest version, or nil.
+func (l InstallList) Newest() *Installation {
+	if len(l) == 0 {
+		return nil
+	}
+	return l[len(l)-1]
+}
+
+// InstallDir returns the folder version v of the plugin is installed in,
+// in the plugin folder pluginFolder.
+func (pr *Requirement) InstallDir(pluginFolder string, v *version.Version, opts ListInstallationsOptions) string {
+	parts := append([]string{pluginFolder}, pr.Identifier.Parts()...)
+	parts = append(parts, v.String(), opts.Platform())
+	return filepath.Join(parts...)
+}
+
+// ListInstallations returns the installed versions of the plugin that match
+// its version constraints, for the platform of opts. A version installed in
+// several plugin folders is listed once, from the first folder.
+func (pr *Requirement) ListInstallations(opts ListInstallationsOptions) (InstallList, error) {
+	var res InstallList
+	for _, folder := range opts.FromFolders {
+		pluginDir := filepath.Join(append([]string{folder}, pr.Identifier.Parts()...)...)
+		entries, err := ioutil.ReadDir(pluginDir)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			if !entry.IsDir() {
+				continue
+			}
+			v, err := version.NewVersion(entry.Name())
+			if err != nil {
+				// Not a version folder
+				continue
+			}
+			if !pr.VersionConstraints.Check(v) || res.Find(v) != nil {
+				continue
+			}
+			dir := filepath.Join(pluginDir, entry.Name(), opts.Platform())
+			if fi, err := os.Stat(dir); err != nil || !fi.IsDir() {
+				continue
+			}
+			res = append(res, &Installation{
+				Dir:     dir,
+				Version: v,
+			})
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Version.LessThan(res[j].Version)
+	})
+	return res, nil
+}
+
+// Install installs version v of the plugin of pr for the platform of opts
+// from g, in the first plugin folder of opts, and returns the installation.
+// The binaries of the plugin are the files of the zip archive g gets.
+func (pr *Requirement) Install(ctx context.Context, g Getter, v *version.Version, opts ListInstallationsOptions) (*Installation, error) {
+	dir := pr.InstallDir(opts.FromFolders[0], v, opts)
+	if err := os.MkdirAll(filepath.Dir(dir), 0755); err != nil {
+		return nil, err
+	}
+	// The plugin is extracted next to its folder first, so that the
+	// folder only exists once the plugin is fully installed.
+	tmp, err := ioutil.TempDir(filepath.Dir(dir), ".install-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmp)
+
+	archive := filepath.Join(tmp, "plugin.zip")
+	if err := g.Get(ctx, pr.Identifier, v, opts.Platform(), archive); err != nil {
+		return nil, err
+	}
+	bin := filepath.Join(tmp, "bin")
+	if err := unzipFlat(archive, bin); err != nil {
+		return nil, fmt.Errorf("failed to extract %s %s: %s", pr.Identifier, v, err)
+	}
+	if err := os.Rename(bin, dir); err != nil {
+		return nil, err
+	}
+	return &Installation{
+		Dir:     dir,
+		Version: v,
+	}, nil
+}
+
+// unzipFlat extracts the files of the zip archive at src in dst. The
+// binaries of a plugin are at the root of its archive.
+func unzipFlat(src, dst string) error {
+	r, err := zip.OpenReader(src)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	if err := os.Mkdir(dst, 0755); err != nil {
+		return err
+	}
+	for _, f := range r.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		if strings.ContainsAny(f.Name, `/\`) || f.Name == ".." {
+			return fmt.Errorf("unexpected path %q, the files of the archive must be at its root", f.Name)
+		}
+		if err := unzipFile(f, filepath.Join(dst, f.Name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func unzipFile(f *zip.File, dst string) error {
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0755)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, rc); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// HashDir returns the checksum of the files of dir, like "sha256:0a1b...".
+// It is the SHA256 sum of a list of the SHA256 sums of the files and of
+// their paths, sorted by path, in the format of sha256sum.
+func HashDir(dir string) (string, error) {
+	var paths []string
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.Mode().IsRegular() {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	sort.Strings(paths)
+
+	sums := &strings.Builder{}
+	for _, path := range paths {
+		sum, err := hashFile(path)
+		if err != nil {
+			return "", err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(sums, "%s  %s\n", sum, filepath.ToSlash(rel))
+	}
+	h := sha256.Sum256([]byte(sums.String()))
+	return "sha256:" + hex.EncodeToString(h[:]), nil
+}
+
+func hashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,129 @@
+package plugingetter
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/go-version"
+	"github.com/hashicorp/packer/hcl2template/addrs"
+)
+
+func testRequirement(t *testing.T, constraints string) *Requirement {
+	id, err := addrs.ParsePluginSourceString("github.com/hashicorp/amazon")
+	if err != nil {
+		t.Fatalf("ParsePluginSourceString: %s", err)
+	}
+	vc, err := version.NewConstraint(constraints)
+	if err != nil {
+		t.Fatalf("NewConstraint: %s", err)
+	}
+	return &Requirement{
+		Accessor:           "amazon",
+		Identifier:         id,
+		VersionConstraints: vc,
+	}
+}
+
+// installPlugin writes a fake plugin binary in the folder of version v of
+// the plugin of pr, and returns that folder.
+func installPlugin(t *testing.T, pr *Requirement, folder, v string, opts ListInstallationsOptions) string {
+	dir := pr.InstallDir(folder, version.Must(version.NewVersion(v)), opts)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatalf("MkdirAll: %s", err)
+	}
+	bin := filepath.Join(dir, "packer-builder-amazon-ebs")
+	if err := ioutil.WriteFile(bin, []byte("v"+v), 0755); err != nil {
+		t.Fatalf("WriteFile: %s", err)
+	}
+	return dir
+}
+
+func TestRequirement_ListInstallations(t *testing.T) {
+	first, err := ioutil.TempDir("", "packer-plugins")
+	if err != nil {
+		t.Fatalf("TempDir: %s", err)
+	}
+	defer os.RemoveAll(first)
+	second, err := ioutil.TempDir("", "packer-plugins")
+	if err != nil {
+		t.Fatalf("TempDir: %s", err)
+	}
+	defer os.RemoveAll(second)
+
+	opts := ListInstallationsOptions{
+		FromFolders: []string{first, second},
+		OS:          "linux",
+		ARCH:        "amd64",
+	}
+	pr := testRequirement(t, ">= 1.1.0")
+
+	installPlugin(t, pr, first, "1.0.0", opts)
+	expected := installPlugin(t, pr, first, "1.2.0", opts)
+	installPlugin(t, pr, second, "1.2.0", opts)
+	installPlugin(t, pr, second, "1.10.0", opts)
+	installPlugin(t, pr, second, "2.0.0", ListInstallationsOptions{OS: "darwin", ARCH: "arm64"})
+	if err := os.MkdirAll(filepath.Join(second, "github.com", "hashicorp", "amazon", "not-a-version"), 0755); err != nil {
+		t.Fatalf("MkdirAll: %s", err)
+	}
+
+	installs, err := pr.ListInstallations(opts)
+	if err != nil {
+		t.Fatalf("ListInstallations: %s", err)
+	}
+	var versions []string
+	for _, install := range installs {
+		versions = append(versions, install.Version.String())
+	}
+	if len(versions) != 2 || versions[0] != "1.2.0" || versions[1] != "1.10.0" {
+		t.Fatalf("unexpected versions: %v", versions)
+	}
+	if installs[0].Dir != expected {
+		t.Fatalf("expected 1.2.0 from the first folder %s, got %s", expected, installs[0].Dir)
+	}
+	if newest := installs.Newest(); newest.Version.String() != "1.10.0" {
+		t.Fatalf("unexpected newest version: %s", newest.Version)
+	}
+}
+
+func TestHashDir(t *testing.T) {
+	dir, err := ioutil.TempDir("", "packer-plugins")
+	if err != nil {
+		t.Fatalf("TempDir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	bin := filepath.Join(dir, "packer-builder-amazon-ebs")
+	if err := ioutil.WriteFile(bin, []byte("v1"), 0755); err != nil {
+		t.Fatalf("WriteFile: %s", err)
+	}
+	sum, err := HashDir(dir)
+	if err != nil {
+		t.Fatalf("HashDir: %s", err)
+	}
+	again, err := HashDir(dir)
+	if err != nil {
+		t.Fatalf("HashDir: %s", err)
+	}
+	if sum != again {
+		t.Fatalf("HashDir is not stable: %s != %s", sum, again)
+	}
+
+	if err := ioutil.WriteFile(bin, []byte("v2"), 0755); err != nil {
+		t.Fatalf("WriteFile: %s", err)
+	}
+	if changed, _ := HashDir(dir); changed == sum {
+		t.Fatalf("HashDir didn't change when a binary changed")
+	}
+
+	if err := ioutil.WriteFile(bin, []byte("v1"), 0755); err != nil {
+		t.Fatalf("WriteFile: %s", err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "packer-provisioner-extra"), []byte("x"), 0755); err != nil {
+		t.Fatalf("WriteFile: %s", err)
+	}
+	if added, _ := HashDir(dir); added == sum {
+		t.Fatalf("HashDir didn't change when a binary was added")
+	}
+}
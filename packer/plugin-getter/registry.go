@@ -0,0 +1,147 @@
+package plugingetter
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/hashicorp/go-version"
+	"github.com/hashicorp/packer/hcl2template/addrs"
+)
+
+// Getter gets the versions of plugins from a source other than the plugin
+// folders, like a registry.
+type Getter interface {
+	// Versions returns the versions of the plugin of id that the getter has
+	// for platform, like "linux_amd64".
+	Versions(ctx context.Context, id *addrs.Plugin, platform string) (version.Collection, error)
+
+	// Get writes the zip archive of the binaries of version v of the plugin
+	// of id for platform to dst, once it checked the archive.
+	Get(ctx context.Context, id *addrs.Plugin, v *version.Version, platform string, dst string) error
+}
+
+// Registry is a private source of plugins, set in the registries of the
+// packer config file, like:
+//
+//	"registries": [{
+//	  "hostname": "plugins.example.com",
+//	  "index": "https://plugins.example.com/packer",
+//	  "public_key": "base64 of an ed25519 public key"
+//	}, {
+//	  "hostname": "registry.example.com",
+//	  "oci": "registry.example.com/packer-plugins"
+//	}]
+//
+// A registry serves the plugins whose source has its hostname, from either
+// an HTTPS index or an OCI registry.
+type Registry struct {
+	// Hostname is the hostname of the sources of the plugins of the
+	// registry, like "plugins.example.com".
+	Hostname string `json:"hostname"`
+
+	// Index is the HTTPS URL of an index of plugins. The index of the
+	// plugin NAMESPACE/TYPE is at <index>/NAMESPACE/TYPE/index.json, and is
+	// signed by <index>/NAMESPACE/TYPE/index.json.sig.
+	Index string `json:"index"`
+	// PublicKey is the base64 ed25519 public key the signatures of the
+	// indexes are checked with. Required with Index.
+	PublicKey string `json:"public_key"`
+
+	// OCI is the repository prefix of the plugins in an OCI registry, like
+	// "registry.example.com/packer-plugins". Version VERSION of the plugin
+	// NAMESPACE/TYPE is the artifact <oci>/NAMESPACE/TYPE:VERSION.
+	OCI string `json:"oci"`
+	// Username and Password authenticate to the OCI registry.
+	Username string `json:"username"`
+	Password string `json:"password"`
+
+	// CACertFile is a PEM file of the certificate authorities of the
+	// registry, when its certificate isn't signed by one of the system.
+	CACertFile string `json:"ca_cert_file"`
+}
+
+// Registries are the registries of the packer config file.
+type Registries []*Registry
+
+// Getter returns the getter of the registry of hostname, or nil when no
+// registry serves hostname.
+func (rs Registries) Getter(hostname string) (Getter, error) {
+	for _, r := range rs {
+		if strings.EqualFold(r.Hostname, hostname) {
+			return r.Getter()
+		}
+	}
+	return nil, nil
+}
+
+// Getter returns the getter of the registry.
+func (r *Registry) Getter() (Getter, error) {
+	if r.Hostname == "" {
+		return nil, fmt.Errorf("a registry must have a hostname")
+	}
+	client, err := r.httpClient()
+	if err != nil {
+		return nil, fmt.Errorf("registry %s: %s", r.Hostname, err)
+	}
+
+	switch {
+	case r.Index != "" && r.OCI != "":
+		return nil, fmt.Errorf("registry %s: only one of index and oci can be set", r.Hostname)
+	case r.Index != "":
+		if !strings.HasPrefix(r.Index, "https://") {
+			return nil, fmt.Errorf("registry %s: the index %q must be an https URL", r.Hostname, r.Index)
+		}
+		if r.PublicKey == "" {
+			return nil, fmt.Errorf("registry %s: a public_key is required to check the signatures of the index", r.Hostname)
+		}
+		key, err := base64.StdEncoding.DecodeString(r.PublicKey)
+		if err != nil || len(key) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("registry %s: the public_key must be the base64 of an ed25519 public key", r.Hostname)
+		}
+		return &indexGetter{
+			url:       strings.TrimSuffix(r.Index, "/"),
+			publicKey: ed25519.PublicKey(key),
+			client:    client,
+		}, nil
+	case r.OCI != "":
+		parts := strings.SplitN(strings.TrimSuffix(r.OCI, "/"), "/", 2)
+		g := &ociGetter{
+			host:     parts[0],
+			username: r.Username,
+			password: r.Password,
+			client:   client,
+			tokens:   map[string]string{},
+		}
+		if len(parts) == 2 {
+			g.prefix = parts[1]
+		}
+		return g, nil
+	}
+	return nil, fmt.Errorf("registry %s: one of index and oci must be set", r.Hostname)
+}
+
+func (r *Registry) httpClient() (*http.Client, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if r.CACertFile != "" {
+		pem, err := ioutil.ReadFile(r.CACertFile)
+		if err != nil {
+			return nil, err
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificate found in %s", r.CACertFile)
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool}
+	}
+	return &http.Client{Transport: transport}, nil
+}
@@ -0,0 +1,362 @@
+package plugingetter
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/go-version"
+)
+
+func testZip(t *testing.T, files map[string]string) []byte {
+	buf := &bytes.Buffer{}
+	w := zip.NewWriter(buf)
+	for name, content := range files {
+		f, err := w.Create(name)
+		if err != nil {
+			t.Fatalf("Create: %s", err)
+		}
+		if _, err := f.Write([]byte(content)); err != nil {
+			t.Fatalf("Write: %s", err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close: %s", err)
+	}
+	return buf.Bytes()
+}
+
+// testCACertFile writes the certificate of srv to a PEM file in dir.
+func testCACertFile(t *testing.T, srv *httptest.Server, dir string) string {
+	path := filepath.Join(dir, "ca.pem")
+	block := &pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw}
+	if err := ioutil.WriteFile(path, pem.EncodeToMemory(block), 0644); err != nil {
+		t.Fatalf("WriteFile: %s", err)
+	}
+	return path
+}
+
+func sha256Hex(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+// testIndexRegistry starts an HTTPS registry with an index of versions
+// 1.2.0 and 1.3.0 of the plugin hashicorp/amazon for linux_amd64, signed
+// with the returned private key. tamper is called with the archive before
+// it is served.
+func testIndexRegistry(t *testing.T, tamper func([]byte) []byte) (*httptest.Server, ed25519.PublicKey) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey: %s", err)
+	}
+	archives := map[string][]byte{}
+	index := pluginIndex{Versions: map[string]map[string]indexArchive{}}
+	for _, v := range []string{"1.2.0", "1.3.0"} {
+		archive := testZip(t, map[string]string{"packer-builder-amazon-ebs": "v" + v})
+		name := fmt.Sprintf("%s/packer-plugin-amazon_%s_linux_amd64.zip", v, v)
+		archives["/packer/hashicorp/amazon/"+name] = archive
+		index.Versions[v] = map[string]indexArchive{
+			"linux_amd64": {URL: name, SHA256: sha256Hex(archive)},
+		}
+	}
+	body, err := json.Marshal(index)
+	if err != nil {
+		t.Fatalf("Marshal: %s", err)
+	}
+	sig := base64.StdEncoding.EncodeToString(ed25519.Sign(priv, body))
+
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/packer/hashicorp/amazon/index.json":
+			w.Write(body)
+		case "/packer/hashicorp/amazon/index.json.sig":
+			w.Write([]byte(sig))
+		default:
+			archive, found := archives[r.URL.Path]
+			if !found {
+				http.NotFound(w, r)
+				return
+			}
+			if tamper != nil {
+				archive = tamper(archive)
+			}
+			w.Write(archive)
+		}
+	}))
+	return srv, pub
+}
+
+func TestRegistry_index(t *testing.T) {
+	folder, err := ioutil.TempDir("", "packer-plugins")
+	if err != nil {
+		t.Fatalf("TempDir: %s", err)
+	}
+	defer os.RemoveAll(folder)
+
+	srv, pub := testIndexRegistry(t, nil)
+	defer srv.Close()
+	registries := Registries{{
+		Hostname:   "plugins.example.com",
+		Index:      srv.URL + "/packer/",
+		PublicKey:  base64.StdEncoding.EncodeToString(pub),
+		CACertFile: testCACertFile(t, srv, folder),
+	}}
+
+	if g, err := registries.Getter("github.com"); g != nil || err != nil {
+		t.Fatalf("expected no getter for github.com, got %v, %v", g, err)
+	}
+	g, err := registries.Getter("plugins.example.com")
+	if err != nil {
+		t.Fatalf("Getter: %s", err)
+	}
+
+	pr := testRequirement(t, ">= 1.0.0")
+	pr.Identifier.Hostname = "plugins.example.com"
+	opts := ListInstallationsOptions{
+		FromFolders: []string{folder},
+		OS:          "linux",
+		ARCH:        "amd64",
+	}
+
+	versions, err := g.Versions(context.Background(), pr.Identifier, opts.Platform())
+	if err != nil {
+		t.Fatalf("Versions: %s", err)
+	}
+	if len(versions) != 2 {
+		t.Fatalf("expected 2 versions, got %v", versions)
+	}
+	if versions, _ := g.Versions(context.Background(), pr.Identifier, "darwin_arm64"); len(versions) != 0 {
+		t.Fatalf("expected no version for darwin_arm64, got %v", versions)
+	}
+
+	install, err := pr.Install(context.Background(), g, version.Must(version.NewVersion("1.3.0")), opts)
+	if err != nil {
+		t.Fatalf("Install: %s", err)
+	}
+	if expected := pr.InstallDir(folder, install.Version, opts); install.Dir != expected {
+		t.Fatalf("expected the plugin to be installed in %s, got %s", expected, install.Dir)
+	}
+	content, err := ioutil.ReadFile(filepath.Join(install.Dir, "packer-builder-amazon-ebs"))
+	if err != nil || string(content) != "v1.3.0" {
+		t.Fatalf("unexpected binary %q: %v", content, err)
+	}
+	installs, err := pr.ListInstallations(opts)
+	if err != nil || len(installs) != 1 || installs[0].Dir != install.Dir {
+		t.Fatalf("expected the installation to be listed, got %v, %v", installs, err)
+	}
+}
+
+func TestRegistry_indexSignature(t *testing.T) {
+	srv, _ := testIndexRegistry(t, nil)
+	defer srv.Close()
+	dir, err := ioutil.TempDir("", "packer-plugins")
+	if err != nil {
+		t.Fatalf("TempDir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	otherKey, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey: %s", err)
+	}
+	r := &Registry{
+		Hostname:   "plugins.example.com",
+		Index:      srv.URL + "/packer",
+		PublicKey:  base64.StdEncoding.EncodeToString(otherKey),
+		CACertFile: testCACertFile(t, srv, dir),
+	}
+	g, err := r.Getter()
+	if err != nil {
+		t.Fatalf("Getter: %s", err)
+	}
+	pr := testRequirement(t, ">= 1.0.0")
+	_, err = g.Versions(context.Background(), pr.Identifier, "linux_amd64")
+	if err == nil || !strings.Contains(err.Error(), "doesn't match the public key") {
+		t.Fatalf("expected a signature error, got %v", err)
+	}
+}
+
+func TestRegistry_indexChecksum(t *testing.T) {
+	srv, pub := testIndexRegistry(t, func(archive []byte) []byte {
+		return testZip(t, map[string]string{"packer-builder-amazon-ebs": "tampered"})
+	})
+	defer srv.Close()
+	folder, err := ioutil.TempDir("", "packer-plugins")
+	if err != nil {
+		t.Fatalf("TempDir: %s", err)
+	}
+	defer os.RemoveAll(folder)
+
+	r := &Registry{
+		Hostname:   "plugins.example.com",
+		Index:      srv.URL + "/packer",
+		PublicKey:  base64.StdEncoding.EncodeToString(pub),
+		CACertFile: testCACertFile(t, srv, folder),
+	}
+	g, err := r.Getter()
+	if err != nil {
+		t.Fatalf("Getter: %s", err)
+	}
+	pr := testRequirement(t, ">= 1.0.0")
+	opts := ListInstallationsOptions{
+		FromFolders: []string{folder},
+		OS:          "linux",
+		ARCH:        "amd64",
+	}
+	_, err = pr.Install(context.Background(), g, version.Must(version.NewVersion("1.2.0")), opts)
+	if err == nil || !strings.Contains(err.Error(), "doesn't match the expected sum") {
+		t.Fatalf("expected a checksum error, got %v", err)
+	}
+	if installs, _ := pr.ListInstallations(opts); len(installs) != 0 {
+		t.Fatalf("expected nothing to be installed, got %v", installs)
+	}
+}
+
+func TestRegistry_oci(t *testing.T) {
+	archive := testZip(t, map[string]string{"packer-builder-amazon-ebs": "v1.2.0"})
+	digest := "sha256:" + sha256Hex(archive)
+	manifest := fmt.Sprintf(`{
+  "schemaVersion": 2,
+  "mediaType": %q,
+  "layers": [{
+    "mediaType": "application/zip",
+    "digest": %q,
+    "annotations": {%q: "linux_amd64.zip"}
+  }]
+}`, ociManifestMediaType, digest, ociTitleAnnotation)
+
+	var srv *httptest.Server
+	srv = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			if user, pass, _ := r.BasicAuth(); user != "packer" || pass != "secret" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			if r.URL.Query().Get("scope") != "repository:packer-plugins/hashicorp/amazon:pull" {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			w.Write([]byte(`{"token": "t0k3n"}`))
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer t0k3n" {
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="registry",scope="repository:packer-plugins/hashicorp/amazon:pull"`, srv.URL))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/v2/packer-plugins/hashicorp/amazon/tags/list":
+			w.Write([]byte(`{"name": "packer-plugins/hashicorp/amazon", "tags": ["latest", "1.2.0"]}`))
+		case "/v2/packer-plugins/hashicorp/amazon/manifests/1.2.0", "/v2/packer-plugins/hashicorp/amazon/manifests/latest":
+			w.Header().Set("Content-Type", ociManifestMediaType)
+			w.Write([]byte(manifest))
+		case "/v2/packer-plugins/hashicorp/amazon/blobs/" + digest:
+			w.Write(archive)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	folder, err := ioutil.TempDir("", "packer-plugins")
+	if err != nil {
+		t.Fatalf("TempDir: %s", err)
+	}
+	defer os.RemoveAll(folder)
+
+	r := &Registry{
+		Hostname:   "registry.example.com",
+		OCI:        strings.TrimPrefix(srv.URL, "https://") + "/packer-plugins",
+		Username:   "packer",
+		Password:   "secret",
+		CACertFile: testCACertFile(t, srv, folder),
+	}
+	g, err := r.Getter()
+	if err != nil {
+		t.Fatalf("Getter: %s", err)
+	}
+
+	pr := testRequirement(t, ">= 1.0.0")
+	opts := ListInstallationsOptions{
+		FromFolders: []string{folder},
+		OS:          "linux",
+		ARCH:        "amd64",
+	}
+	versions, err := g.Versions(context.Background(), pr.Identifier, opts.Platform())
+	if err != nil {
+		t.Fatalf("Versions: %s", err)
+	}
+	if len(versions) != 1 || versions[0].String() != "1.2.0" {
+		t.Fatalf("expected version 1.2.0, got %v", versions)
+	}
+
+	install, err := pr.Install(context.Background(), g, versions[0], opts)
+	if err != nil {
+		t.Fatalf("Install: %s", err)
+	}
+	content, err := ioutil.ReadFile(filepath.Join(install.Dir, "packer-builder-amazon-ebs"))
+	if err != nil || string(content) != "v1.2.0" {
+		t.Fatalf("unexpected binary %q: %v", content, err)
+	}
+}
+
+func TestRegistry_Getter_invalid(t *testing.T) {
+	tc := map[string]*Registry{
+		"must have a hostname": {Index: "https://example.com"},
+		"one of index and oci": {Hostname: "example.com"},
+		"only one of index":    {Hostname: "example.com", Index: "https://example.com", OCI: "example.com/plugins"},
+		"must be an https URL": {Hostname: "example.com", Index: "http://example.com"},
+		"public_key is required": {
+			Hostname: "example.com",
+			Index:    "https://example.com",
+		},
+		"base64 of an ed25519 public key": {
+			Hostname:  "example.com",
+			Index:     "https://example.com",
+			PublicKey: "bm90IGEga2V5",
+		},
+	}
+	for expected, r := range tc {
+		_, err := r.Getter()
+		if err == nil || !strings.Contains(err.Error(), expected) {
+			t.Fatalf("expected an error with %q, got %v", expected, err)
+		}
+	}
+}
+
+func TestUnzipFlat(t *testing.T) {
+	dir, err := ioutil.TempDir("", "packer-plugins")
+	if err != nil {
+		t.Fatalf("TempDir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	archive := filepath.Join(dir, "plugin.zip")
+	content := testZip(t, map[string]string{"../packer-builder-amazon-ebs": "escape"})
+	if err := ioutil.WriteFile(archive, content, 0644); err != nil {
+		t.Fatalf("WriteFile: %s", err)
+	}
+	err = unzipFlat(archive, filepath.Join(dir, "bin"))
+	if err == nil || !strings.Contains(err.Error(), "must be at its root") {
+		t.Fatalf("expected a path error, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "packer-builder-amazon-ebs")); !os.IsNotExist(err) {
+		t.Fatalf("the archive was extracted outside of its folder")
+	}
+}
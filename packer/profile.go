@@ -0,0 +1,145 @@
+package packer
+
+import (
+	"log"
+	"sync"
+	"time"
+)
+
+// ProfileStepMachineType is the type of the machine-readable message a
+// builder sends to its Ui with the name and the duration of a step it ran, so
+// that the step is part of the profile of the build. The message isn't shown.
+const ProfileStepMachineType = "profile-step"
+
+// StepStartMachineType is the type of the machine-readable message a builder
+// sends to its Ui with the name of a step it starts.
+const StepStartMachineType = "step-start"
+
+// StepEndMachineType is the type of the machine-readable message with the
+// name and the duration of a step that ended, sent once the step is part of
+// the profile of the build.
+const StepEndMachineType = "step-end"
+
+// BuildProfile is how long the parts of a build took, in the order they ran.
+type BuildProfile struct {
+	Build          string         `json:"build"`
+	Seconds        float64        `json:"seconds"`
+	Steps          []ProfileEntry `json:"steps"`
+	Provisioners   []ProfileEntry `json:"provisioners"`
+	PostProcessors []ProfileEntry `json:"post_processors"`
+
+	// Start is when the build started.
+	Start time.Time `json:"-"`
+}
+
+// Duration returns how long the build took.
+func (p BuildProfile) Duration() time.Duration {
+	return time.Duration(p.Seconds * float64(time.Second))
+}
+
+// ProfileEntry is how long a step, provisioner or post-processor took.
+type ProfileEntry struct {
+	Name    string  `json:"name"`
+	Seconds float64 `json:"seconds"`
+
+	// Start is when the entry started.
+	Start time.Time `json:"-"`
+}
+
+// Duration returns how long the entry took.
+func (e ProfileEntry) Duration() time.Duration {
+	return time.Duration(e.Seconds * float64(time.Second))
+}
+
+// ProfiledBuild is a Build that records its BuildProfile.
+type ProfiledBuild interface {
+	Build
+
+	// Profile returns the profile of the last run of the build.
+	Profile() BuildProfile
+}
+
+// buildProfiler records the profile of a build. Parts of the build may run
+// concurrently.
+type buildProfiler struct {
+	l       sync.Mutex
+	profile BuildProfile
+}
+
+func (p *buildProfiler) start(build string) {
+	p.l.Lock()
+	defer p.l.Unlock()
+	p.profile = BuildProfile{
+		Build:          build,
+		Steps:          []ProfileEntry{},
+		Provisioners:   []ProfileEntry{},
+		PostProcessors: []ProfileEntry{},
+		Start:          time.Now(),
+	}
+}
+
+func (p *buildProfiler) end(d time.Duration) {
+	p.l.Lock()
+	defer p.l.Unlock()
+	p.profile.Seconds = d.Seconds()
+}
+
+func (p *buildProfiler) add(entries *[]ProfileEntry, name string, d time.Duration) {
+	p.l.Lock()
+	defer p.l.Unlock()
+	// The entries are added once they ended
+	*entries = append(*entries, ProfileEntry{
+		Name:    name,
+		Seconds: d.Seconds(),
+		Start:   time.Now().Add(-d),
+	})
+}
+
+func (p *buildProfiler) addStep(name string, d time.Duration) {
+	p.add(&p.profile.Steps, name, d)
+}
+
+func (p *buildProfiler) addProvisioner(name string, d time.Duration) {
+	p.add(&p.profile.Provisioners, name, d)
+}
+
+func (p *buildProfiler) addPostProcessor(name string, d time.Duration) {
+	p.add(&p.profile.PostProcessors, name, d)
+}
+
+func (p *buildProfiler) get() BuildProfile {
+	p.l.Lock()
+	defer p.l.Unlock()
+
+	profile := p.profile
+	profile.Steps = append([]ProfileEntry{}, p.profile.Steps...)
+	profile.Provisioners = append([]ProfileEntry{}, p.profile.Provisioners...)
+	profile.PostProcessors = append([]ProfileEntry{}, p.profile.PostProcessors...)
+	return profile
+}
+
+// profileUi is the Ui of a builder that records the durations of the steps
+// the builder reports with ProfileStepMachineType messages.
+type profileUi struct {
+	Ui
+	profiler *buildProfiler
+}
+
+func (u *profileUi) Machine(t string, args ...string) {
+	if t != ProfileStepMachineType {
+		u.Ui.Machine(t, args...)
+		return
+	}
+
+	if len(args) != 2 {
+		log.Printf("Bad %s message: %v", t, args)
+		return
+	}
+	d, err := time.ParseDuration(args[1])
+	if err != nil {
+		log.Printf("Bad %s message: %s", t, err)
+		return
+	}
+	u.profiler.addStep(args[0], d)
+	u.Ui.Machine(StepEndMachineType, args...)
+}
@@ -0,0 +1,56 @@
+package packer
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestProfileUi_Machine(t *testing.T) {
+	var p buildProfiler
+	p.start("test")
+	bui := testUi()
+	ui := &profileUi{Ui: &MachineReadableUi{Writer: bui.Writer}, profiler: &p}
+
+	ui.Machine(ProfileStepMachineType, "StepCreateVM", "1.5s")
+	ui.Machine(ProfileStepMachineType, "bad-duration", "later")
+	ui.Machine("artifact", "0", "id")
+
+	profile := p.get()
+	if len(profile.Steps) != 1 {
+		t.Fatalf("bad: %#v", profile.Steps)
+	}
+	if profile.Steps[0].Name != "StepCreateVM" || profile.Steps[0].Duration() != 1500*time.Millisecond {
+		t.Fatalf("bad: %#v", profile.Steps[0])
+	}
+	if out := readWriter(bui); out == "" {
+		t.Fatal("other machine-readable messages should be forwarded")
+	}
+}
+
+func TestBuild_Run_Profile(t *testing.T) {
+	build := testBuild()
+	build.Prepare()
+	builder := build.Builder.(*MockBuilder)
+	builder.RunFn = func(context.Context) {
+		builder.RunUi.Machine(ProfileStepMachineType, "StepCreateVM", "2s")
+	}
+
+	if _, err := build.Run(context.Background(), testUi()); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	profile := build.Profile()
+	if profile.Build != "test" {
+		t.Fatalf("bad: %#v", profile.Build)
+	}
+	if len(profile.Steps) != 1 || profile.Steps[0].Name != "StepCreateVM" {
+		t.Fatalf("bad: %#v", profile.Steps)
+	}
+	if len(profile.Provisioners) != 1 || profile.Provisioners[0].Name != "mock-provisioner" {
+		t.Fatalf("bad: %#v", profile.Provisioners)
+	}
+	if len(profile.PostProcessors) != 1 || profile.PostProcessors[0].Name != "testPP" {
+		t.Fatalf("bad: %#v", profile.PostProcessors)
+	}
+}
@@ -0,0 +1,195 @@
+package packer
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sync"
+	"time"
+
+	getter "github.com/hashicorp/go-getter/v2"
+)
+
+// TransferProgress measures a transfer of bytes, like a download or an
+// upload: how much of it is done, its rate and how long the rest should take.
+// The Uis that don't draw a progress bar report transfers with it, so that
+// they all show the same figures.
+type TransferProgress struct {
+	// Src names what is transferred.
+	Src string
+	// Total is the size of the transfer, 0 when it isn't known.
+	Total int64
+
+	l       sync.Mutex
+	start   time.Time
+	initial int64 // the bytes done before the transfer was tracked
+	current int64
+}
+
+// NewTransferProgress starts measuring a transfer of total bytes, current of
+// which are already done, like when a download is resumed.
+func NewTransferProgress(src string, current, total int64) *TransferProgress {
+	return &TransferProgress{
+		Src:     src,
+		Total:   total,
+		start:   time.Now(),
+		initial: current,
+		current: current,
+	}
+}
+
+// Add counts n more bytes transferred.
+func (p *TransferProgress) Add(n int64) {
+	p.l.Lock()
+	defer p.l.Unlock()
+	p.current += n
+}
+
+// Current returns the number of bytes transferred.
+func (p *TransferProgress) Current() int64 {
+	p.l.Lock()
+	defer p.l.Unlock()
+	return p.current
+}
+
+// Rate returns the bytes transferred per second since the transfer started
+// being tracked.
+func (p *TransferProgress) Rate(now time.Time) float64 {
+	p.l.Lock()
+	defer p.l.Unlock()
+	elapsed := now.Sub(p.start).Seconds()
+	if elapsed <= 0 {
+		return 0
+	}
+	return float64(p.current-p.initial) / elapsed
+}
+
+// Remaining returns how long the rest of the transfer should take at its
+// current rate, and false when it can't be told.
+func (p *TransferProgress) Remaining(now time.Time) (time.Duration, bool) {
+	rate := p.Rate(now)
+	current := p.Current()
+	if p.Total <= 0 || rate <= 0 {
+		return 0, false
+	}
+	if current >= p.Total {
+		return 0, true
+	}
+	seconds := float64(p.Total-current) / rate
+	return time.Duration(seconds * float64(time.Second)).Round(time.Second), true
+}
+
+// Format describes the progress of the transfer at now, like
+// "ubuntu.iso: 52.4 MB/100.0 MB (52%), 4.2 MB/s, 11s left".
+func (p *TransferProgress) Format(now time.Time) string {
+	current := p.Current()
+	s := fmt.Sprintf("%s: %s", p.Src, formatBytes(current))
+	if p.Total > 0 {
+		s += fmt.Sprintf("/%s (%d%%)", formatBytes(p.Total), current*100/p.Total)
+	}
+	s += fmt.Sprintf(", %s/s", formatBytes(int64(p.Rate(now))))
+	if left, ok := p.Remaining(now); ok && current < p.Total {
+		s += fmt.Sprintf(", %s left", left)
+	}
+	return s
+}
+
+// formatBytes formats n bytes with a decimal unit, like "4.2 MB".
+func formatBytes(n int64) string {
+	const unit = 1000
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "kMGTPE"[exp])
+}
+
+// progressReportInterval is how often the Uis writing their output as lines
+// report the progress of a transfer.
+const progressReportInterval = 5 * time.Second
+
+// reportProgress returns stream counting the bytes read from it in p, and
+// calling report at most every interval while it's read and once it's
+// closed, with done set.
+func reportProgress(stream io.ReadCloser, p *TransferProgress, interval time.Duration, report func(p *TransferProgress, done bool)) io.ReadCloser {
+	return &progressReader{
+		ReadCloser: stream,
+		p:          p,
+		interval:   interval,
+		report:     report,
+	}
+}
+
+type progressReader struct {
+	io.ReadCloser
+	p        *TransferProgress
+	interval time.Duration
+	report   func(p *TransferProgress, done bool)
+
+	l        sync.Mutex
+	reported time.Time
+	closed   bool
+}
+
+func (r *progressReader) Read(b []byte) (int, error) {
+	n, err := r.ReadCloser.Read(b)
+	r.p.Add(int64(n))
+
+	r.l.Lock()
+	defer r.l.Unlock()
+	if now := time.Now(); !r.closed && now.Sub(r.reported) >= r.interval {
+		r.reported = now
+		r.report(r.p, false)
+	}
+	return n, err
+}
+
+func (r *progressReader) Close() error {
+	r.l.Lock()
+	if !r.closed {
+		r.closed = true
+		r.report(r.p, true)
+	}
+	r.l.Unlock()
+	return r.ReadCloser.Close()
+}
+
+// ProgressCounter tracks the progress of a transfer that isn't read from a
+// stream, like an upload in parts, with the tracker of a Ui.
+type ProgressCounter struct {
+	l      sync.Mutex
+	stream io.ReadCloser
+}
+
+// NewProgressCounter starts tracking a transfer of size bytes named src with
+// tracker. It must be closed once the transfer ends.
+func NewProgressCounter(tracker getter.ProgressTracker, src string, size int64) *ProgressCounter {
+	return &ProgressCounter{
+		stream: tracker.TrackProgress(src, 0, size, ioutil.NopCloser(zeroReader{})),
+	}
+}
+
+// Add counts n more bytes transferred.
+func (c *ProgressCounter) Add(n int64) {
+	c.l.Lock()
+	defer c.l.Unlock()
+	io.CopyN(ioutil.Discard, c.stream, n)
+}
+
+func (c *ProgressCounter) Close() error {
+	return c.stream.Close()
+}
+
+// zeroReader is an endless reader of zeros.
+type zeroReader struct{}
+
+func (zeroReader) Read(b []byte) (int, error) {
+	for i := range b {
+		b[i] = 0
+	}
+	return len(b), nil
+}
@@ -0,0 +1,48 @@
+package packer
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestTransferProgress(t *testing.T) {
+	p := NewTransferProgress("ubuntu.iso", 10e6, 100e6)
+	p.Add(42e6)
+
+	now := p.start.Add(10 * time.Second)
+	if rate := p.Rate(now); rate != 4.2e6 {
+		t.Fatalf("bad: %f", rate)
+	}
+	if left, ok := p.Remaining(now); !ok || left != 11*time.Second {
+		t.Fatalf("bad: %s", left)
+	}
+	expected := "ubuntu.iso: 52.0 MB/100.0 MB (52%), 4.2 MB/s, 11s left"
+	if s := p.Format(now); s != expected {
+		t.Fatalf("bad: %s", s)
+	}
+
+	p = NewTransferProgress("output.log", 0, 0)
+	p.Add(500)
+	if _, ok := p.Remaining(now); ok {
+		t.Fatal("the remaining time of a transfer of unknown size can't be told")
+	}
+	if s := p.Format(p.start.Add(time.Second)); s != "output.log: 500 B, 500 B/s" {
+		t.Fatalf("bad: %s", s)
+	}
+}
+
+func TestProgressCounter(t *testing.T) {
+	buf := new(bytes.Buffer)
+	ui := &MachineReadableUi{Writer: buf}
+
+	c := NewProgressCounter(ui, "disk.vmdk", 10)
+	c.Add(4)
+	c.Add(6)
+	c.Close()
+
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	if last := string(lines[len(lines)-1]); !bytes.Contains(lines[len(lines)-1], []byte(",progress,disk.vmdk,10,10,")) {
+		t.Fatalf("bad: %s", last)
+	}
+}
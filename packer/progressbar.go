@@ -13,6 +13,9 @@ import (
 func ProgressBarConfig(bar *pb.ProgressBar, prefix string) {
 	bar.SetUnits(pb.U_BYTES)
 	bar.Prefix(prefix)
+	// Like the progress reported by the other Uis
+	bar.ShowSpeed = true
+	bar.ShowTimeLeft = true
 }
 
 // UiProgressBar is a progress bar compatible with go-getter used in our
@@ -0,0 +1,216 @@
+package packer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"sync"
+	"time"
+)
+
+// ProvenanceStateKey is the artifact state holding the provenance of an
+// artifact, as JSON. Post-processors run as plugins only see the artifacts
+// through RPC, so this is how they can tell where an artifact comes from;
+// ArtifactProvenance reads it.
+const ProvenanceStateKey = "packer.provenance"
+
+// MetadataMachineType is the type of the machine-readable message a
+// provisioner sends to its Ui with a key and a value to add to the Metadata
+// of the provenance of the build, like the updates it installed. The message
+// isn't shown.
+const MetadataMachineType = "build-metadata"
+
+// Provenance documents how an artifact was made: the configuration of the
+// builder, the provisioners that ran on it and the post-processors that
+// produced it.
+type Provenance struct {
+	Build         string `json:"build"`
+	BuilderType   string `json:"builder_type"`
+	PackerVersion string `json:"packer_version"`
+
+	// BuilderConfigHash is the SHA256 of the interpolated configuration of
+	// the builder, as JSON.
+	BuilderConfigHash string `json:"builder_config_sha256"`
+	// ISOChecksum is the iso_checksum of the builder, if it has one.
+	ISOChecksum string `json:"iso_checksum,omitempty"`
+
+	Provisioners []ProvenanceComponent `json:"provisioners"`
+	// PostProcessors are the post-processors that produced the artifact, in
+	// order.
+	PostProcessors []ProvenanceComponent `json:"post_processors"`
+	// Metadata is what the provisioners reported about the machine with
+	// MetadataMachineType messages, in the order they reported it.
+	Metadata map[string][]string `json:"metadata,omitempty"`
+
+	// StartTime is when the build started, and EndTime when the artifact
+	// was produced, as Unix timestamps.
+	StartTime int64 `json:"start_time,omitempty"`
+	EndTime   int64 `json:"end_time,omitempty"`
+}
+
+// ProvenanceComponent is a provisioner or a post-processor of a Provenance.
+type ProvenanceComponent struct {
+	Type       string `json:"type"`
+	Name       string `json:"name,omitempty"`
+	ConfigHash string `json:"config_sha256"`
+	// Scripts are the SHA256 of the scripts the component runs, by path.
+	Scripts map[string]string `json:"scripts,omitempty"`
+}
+
+// ProvenanceArtifact is an Artifact that knows its provenance. The artifacts
+// returned by a CoreBuild are ProvenanceArtifacts.
+type ProvenanceArtifact interface {
+	Artifact
+
+	Provenance() *Provenance
+}
+
+// ArtifactProvenance returns the provenance of a, or nil if it has none.
+func ArtifactProvenance(a Artifact) *Provenance {
+	if pa, ok := a.(ProvenanceArtifact); ok {
+		return pa.Provenance()
+	}
+	raw, ok := a.State(ProvenanceStateKey).(string)
+	if !ok {
+		return nil
+	}
+	p := &Provenance{}
+	if err := json.Unmarshal([]byte(raw), p); err != nil {
+		log.Printf("[WARN] invalid artifact provenance: %s", err)
+		return nil
+	}
+	return p
+}
+
+type provenanceArtifact struct {
+	Artifact
+	provenance *Provenance
+}
+
+func (a *provenanceArtifact) Provenance() *Provenance {
+	return a.provenance
+}
+
+func (a *provenanceArtifact) State(name string) interface{} {
+	if name == ProvenanceStateKey {
+		raw, err := json.Marshal(a.provenance)
+		if err != nil {
+			log.Printf("[WARN] can't marshal artifact provenance: %s", err)
+			return nil
+		}
+		return string(raw)
+	}
+	return a.Artifact.State(name)
+}
+
+// metadataUi records the values the provisioners report with
+// MetadataMachineType messages.
+type metadataUi struct {
+	Ui
+
+	l        sync.Mutex
+	metadata map[string][]string
+}
+
+func (u *metadataUi) Machine(t string, args ...string) {
+	if t != MetadataMachineType {
+		u.Ui.Machine(t, args...)
+		return
+	}
+
+	if len(args) != 2 {
+		log.Printf("Bad %s message: %v", t, args)
+		return
+	}
+	u.l.Lock()
+	defer u.l.Unlock()
+	if u.metadata == nil {
+		u.metadata = map[string][]string{}
+	}
+	u.metadata[args[0]] = append(u.metadata[args[0]], args[1])
+}
+
+// Metadata returns the values reported so far.
+func (u *metadataUi) Metadata() map[string][]string {
+	u.l.Lock()
+	defer u.l.Unlock()
+	return u.metadata
+}
+
+// newProvenance returns the provenance of the artifact of the builder of
+// plan.
+func newProvenance(plan BuildPlan, packerVersion string, start time.Time) *Provenance {
+	p := &Provenance{
+		Build:             plan.Build,
+		BuilderType:       plan.Builder.Type,
+		PackerVersion:     packerVersion,
+		BuilderConfigHash: configHash(plan.Builder.Config),
+		Provisioners:      []ProvenanceComponent{},
+		PostProcessors:    []ProvenanceComponent{},
+		StartTime:         start.Unix(),
+		EndTime:           time.Now().Unix(),
+	}
+	if checksum, ok := plan.Builder.Config["iso_checksum"].(string); ok {
+		p.ISOChecksum = checksum
+	}
+	for _, entry := range plan.Provisioners {
+		p.Provisioners = append(p.Provisioners, provenanceComponent(entry))
+	}
+	return p
+}
+
+// withPostProcessor returns the provenance of the artifact produced by the
+// post-processor of entry from an artifact of provenance p.
+func (p *Provenance) withPostProcessor(entry PlanEntry) *Provenance {
+	res := *p
+	res.PostProcessors = append(append([]ProvenanceComponent{}, p.PostProcessors...), provenanceComponent(entry))
+	res.EndTime = time.Now().Unix()
+	return &res
+}
+
+func provenanceComponent(entry PlanEntry) ProvenanceComponent {
+	c := ProvenanceComponent{
+		Type:       entry.Type,
+		Name:       entry.Name,
+		ConfigHash: configHash(entry.Config),
+	}
+
+	var scripts []string
+	if script, ok := entry.Config["script"].(string); ok {
+		scripts = append(scripts, script)
+	}
+	if list, ok := entry.Config["scripts"].([]interface{}); ok {
+		for _, script := range list {
+			if script, ok := script.(string); ok {
+				scripts = append(scripts, script)
+			}
+		}
+	}
+	for _, script := range scripts {
+		content, err := ioutil.ReadFile(script)
+		if err != nil {
+			log.Printf("[WARN] can't hash script %s: %s", script, err)
+			continue
+		}
+		if c.Scripts == nil {
+			c.Scripts = map[string]string{}
+		}
+		sum := sha256.Sum256(content)
+		c.Scripts[script] = hex.EncodeToString(sum[:])
+	}
+	return c
+}
+
+// configHash returns the SHA256 of config as JSON, whose keys are sorted so
+// that the same configuration always has the same hash.
+func configHash(config map[string]interface{}) string {
+	raw, err := json.Marshal(config)
+	if err != nil {
+		log.Printf("[WARN] can't hash configuration: %s", err)
+		return ""
+	}
+	sum := sha256.Sum256(raw)
+	return hex.EncodeToString(sum[:])
+}
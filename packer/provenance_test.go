@@ -0,0 +1,119 @@
+package packer
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestBuild_Run_Provenance(t *testing.T) {
+	dir, err := ioutil.TempDir("", "packer")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer os.RemoveAll(dir)
+	script := filepath.Join(dir, "script.sh")
+	if err := ioutil.WriteFile(script, []byte("hello\n"), 0644); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	build := testBuild()
+	build.PackerVersion = "1.2.3"
+	build.BuilderConfig = map[string]interface{}{"iso_checksum": "sha256:abc"}
+	build.Provisioners[0].config = []interface{}{
+		map[string]interface{}{"scripts": []interface{}{script}},
+	}
+	build.Prepare()
+	artifacts, err := build.Run(context.Background(), testUi())
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if len(artifacts) != 2 {
+		t.Fatalf("bad: %#v", artifacts)
+	}
+
+	builderProvenance := ArtifactProvenance(artifacts[0])
+	if builderProvenance == nil {
+		t.Fatal("the builder artifact should have a provenance")
+	}
+	if builderProvenance.Build != "test" || builderProvenance.BuilderType != "foo" {
+		t.Fatalf("bad: %#v", builderProvenance)
+	}
+	if builderProvenance.PackerVersion != "1.2.3" {
+		t.Fatalf("bad version: %s", builderProvenance.PackerVersion)
+	}
+	if builderProvenance.ISOChecksum != "sha256:abc" {
+		t.Fatalf("bad iso checksum: %s", builderProvenance.ISOChecksum)
+	}
+	if builderProvenance.BuilderConfigHash != configHash(map[string]interface{}{"iso_checksum": "sha256:abc"}) {
+		t.Fatalf("bad config hash: %s", builderProvenance.BuilderConfigHash)
+	}
+	if len(builderProvenance.Provisioners) != 1 {
+		t.Fatalf("bad provisioners: %#v", builderProvenance.Provisioners)
+	}
+	sum := "5891b5b522d5df086d0ff0b110fbd9d21bb4fc7163af34d08286a2e846f6be03"
+	if hash := builderProvenance.Provisioners[0].Scripts[script]; hash != sum {
+		t.Fatalf("bad script hash: %#v", builderProvenance.Provisioners[0])
+	}
+	if len(builderProvenance.PostProcessors) != 0 {
+		t.Fatalf("bad post-processors: %#v", builderProvenance.PostProcessors)
+	}
+
+	ppProvenance := ArtifactProvenance(artifacts[1])
+	if ppProvenance == nil {
+		t.Fatal("the post-processor artifact should have a provenance")
+	}
+	if len(ppProvenance.PostProcessors) != 1 || ppProvenance.PostProcessors[0].Name != "testPPName" {
+		t.Fatalf("bad post-processors: %#v", ppProvenance.PostProcessors)
+	}
+}
+
+func TestArtifactProvenance_state(t *testing.T) {
+	provenance := &Provenance{
+		Build:        "test",
+		BuilderType:  "foo",
+		Provisioners: []ProvenanceComponent{{Type: "shell", ConfigHash: "abc"}},
+	}
+	a := &provenanceArtifact{Artifact: new(MockArtifact), provenance: provenance}
+
+	// Post-processors only see the state of the artifact through RPC
+	state := &MockArtifact{
+		StateValues: map[string]interface{}{
+			ProvenanceStateKey: a.State(ProvenanceStateKey),
+		},
+	}
+	got := ArtifactProvenance(state)
+	if got == nil || got.Build != "test" || got.Provisioners[0].ConfigHash != "abc" {
+		t.Fatalf("bad: %#v", got)
+	}
+
+	if ArtifactProvenance(new(MockArtifact)) != nil {
+		t.Fatal("should have no provenance")
+	}
+}
+
+func TestBuild_Run_ProvenanceMetadata(t *testing.T) {
+	build := testBuild()
+	build.Prepare()
+	builder := build.Builder.(*MockBuilder)
+	builder.RunFn = func(context.Context) {
+		builder.RunUi.Machine(MetadataMachineType, "windows-update.installed", "KB4580325")
+		builder.RunUi.Machine(MetadataMachineType, "windows-update.installed", "KB4586793")
+		builder.RunUi.Machine(MetadataMachineType, "bad")
+	}
+
+	artifacts, err := build.Run(context.Background(), testUi())
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	provenance := ArtifactProvenance(artifacts[0])
+	expected := map[string][]string{
+		"windows-update.installed": {"KB4580325", "KB4586793"},
+	}
+	if !reflect.DeepEqual(provenance.Metadata, expected) {
+		t.Fatalf("bad metadata: %#v", provenance.Metadata)
+	}
+}
@@ -28,6 +28,14 @@ type Provisioner interface {
 	Provision(context.Context, Ui, Communicator, map[string]interface{}) error
 }
 
+// ProvisionerStartMachineType is the type of the machine-readable message
+// with the type of a provisioner that starts.
+const ProvisionerStartMachineType = "provisioner-start"
+
+// ProvisionerEndMachineType is the type of the machine-readable message with
+// the type and the duration of a provisioner that ended.
+const ProvisionerEndMachineType = "provisioner-end"
+
 // A HookedProvisioner represents a provisioner and information describing it
 type HookedProvisioner struct {
 	Provisioner Provisioner
@@ -40,6 +48,9 @@ type ProvisionHook struct {
 	// The provisioners to run as part of the hook. These should already
 	// be prepared (by calling Prepare) at some earlier stage.
 	Provisioners []*HookedProvisioner
+
+	// Records the durations of the provisioners, if set
+	profiler *buildProfiler
 }
 
 // BuilderDataCommonKeys is the list of common keys that all builder will
@@ -131,16 +142,61 @@ func (h *ProvisionHook) Run(ctx context.Context, name string, ui Ui, comm Commun
 				"`communicator` config was set to \"none\". If you have any provisioners\n" +
 				"then a communicator is required. Please fix this to continue.")
 	}
-	for _, p := range h.Provisioners {
+
+	// When the builder saves layers, resume from the layer of the last
+	// provisioners that didn't change and save a layer after each of the
+	// provisioners run
+	var keys []string
+	first := 0
+	if name == HookProvision {
+		enabled, err := LayersEnabled(comm)
+		if err != nil {
+			return err
+		}
+		if enabled {
+			keys = provisionerLayerKeys(h.Provisioners)
+			for i := len(keys) - 1; i >= 0; i-- {
+				resumed, err := ResumeFromLayer(comm, keys[i])
+				if err != nil {
+					return fmt.Errorf("Error resuming from the layer of provisioner %d: %s", i+1, err)
+				}
+				if resumed {
+					ui.Say(fmt.Sprintf("Using the cached layers of the first %d provisioners", i+1))
+					first = i + 1
+					break
+				}
+			}
+		}
+	}
+
+	for i := first; i < len(h.Provisioners); i++ {
+		p := h.Provisioners[i]
 		ts := CheckpointReporter.AddSpan(p.TypeName, "provisioner", p.Config)
 
 		cast := CastDataToMap(data)
+		if ui != nil {
+			ui.Machine(ProvisionerStartMachineType, p.TypeName)
+		}
+		start := time.Now()
 		err := p.Provisioner.Provision(ctx, ui, comm, cast)
+		d := time.Since(start)
+		if h.profiler != nil {
+			h.profiler.addProvisioner(p.TypeName, d)
+		}
+		if ui != nil {
+			ui.Machine(ProvisionerEndMachineType, p.TypeName, d.String())
+		}
 
 		ts.End(err)
 		if err != nil {
 			return err
 		}
+
+		if keys != nil {
+			if err := CommitLayer(comm, keys[i]); err != nil {
+				return fmt.Errorf("Error saving the layer of provisioner %d: %s", i+1, err)
+			}
+		}
 	}
 
 	return nil
@@ -214,6 +270,63 @@ func (r *RetriedProvisioner) Provision(ctx context.Context, ui Ui, comm Communic
 	return err
 }
 
+// ParallelProvisioner is a Provisioner implementation that runs several
+// provisioners at the same time. Each of them starts its own commands on the
+// communicator, so they must not depend on each other. Once one of them
+// fails, the others are cancelled and its error is returned.
+type ParallelProvisioner struct {
+	Provisioners []Provisioner
+}
+
+func (p *ParallelProvisioner) ConfigSpec() hcldec.ObjectSpec { return nil }
+func (p *ParallelProvisioner) FlatConfig() interface{}       { return nil }
+func (p *ParallelProvisioner) Prepare(raws ...interface{}) error {
+	for _, provisioner := range p.Provisioners {
+		if err := provisioner.Prepare(raws...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *ParallelProvisioner) Provision(ctx context.Context, ui Ui, comm Communicator, generatedData map[string]interface{}) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	var once sync.Once
+	var firstErr error
+	for _, provisioner := range p.Provisioners {
+		// Every provisioner gets its own copy, as they may change it
+		data := make(map[string]interface{}, len(generatedData))
+		for k, v := range generatedData {
+			data[k] = v
+		}
+
+		wg.Add(1)
+		go func(provisioner Provisioner) {
+			defer wg.Done()
+
+			err := provisioner.Provision(ctx, ui, comm, data)
+			if err == nil {
+				return
+			}
+			first := false
+			once.Do(func() {
+				firstErr = err
+				first = true
+				cancel()
+			})
+			if !first {
+				log.Printf("Parallel provisioner failed after another one did: %s", err)
+			}
+		}(provisioner)
+	}
+	wg.Wait()
+
+	return firstErr
+}
+
 // DebuggedProvisioner is a Provisioner implementation that waits until a key
 // press before the provisioner is actually run.
 type DebuggedProvisioner struct {
@@ -341,3 +341,78 @@ func TestRetriedProvisionerCancel(t *testing.T) {
 		t.Fatal("should have err")
 	}
 }
+
+func TestParallelProvisioner_impl(t *testing.T) {
+	var _ Provisioner = new(ParallelProvisioner)
+}
+
+func TestParallelProvisionerPrepare(t *testing.T) {
+	first, second := new(MockProvisioner), new(MockProvisioner)
+	prov := &ParallelProvisioner{
+		Provisioners: []Provisioner{first, second},
+	}
+
+	prov.Prepare(42)
+	if !first.PrepCalled || !second.PrepCalled {
+		t.Fatal("prepare should be called")
+	}
+	if second.PrepConfigs[0] != 42 {
+		t.Fatal("should have proper configs")
+	}
+}
+
+func TestParallelProvisionerProvision(t *testing.T) {
+	// Both provisioners only finish once the other one has started, which
+	// only works if they run at the same time
+	started := make(chan struct{}, 2)
+	provFunc := func(ctx context.Context) error {
+		started <- struct{}{}
+		for len(started) < 2 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(time.Millisecond):
+			}
+		}
+		return nil
+	}
+	first := &MockProvisioner{ProvFunc: provFunc}
+	second := &MockProvisioner{ProvFunc: provFunc}
+	prov := &ParallelProvisioner{
+		Provisioners: []Provisioner{first, second},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	comm := new(MockCommunicator)
+	err := prov.Provision(ctx, testUi(), comm, make(map[string]interface{}))
+	if err != nil {
+		t.Fatalf("prov failed: %v", err)
+	}
+	if first.ProvCommunicator != comm || second.ProvCommunicator != comm {
+		t.Fatal("should have proper comm")
+	}
+}
+
+func TestParallelProvisionerProvision_fails(t *testing.T) {
+	failing := &MockProvisioner{
+		ProvFunc: func(context.Context) error {
+			return errors.New("test error")
+		},
+	}
+	waiting := &MockProvisioner{
+		ProvFunc: func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		},
+	}
+	prov := &ParallelProvisioner{
+		Provisioners: []Provisioner{waiting, failing},
+	}
+
+	err := prov.Provision(context.Background(), testUi(), new(MockCommunicator), make(map[string]interface{}))
+	if err == nil || err.Error() != "test error" {
+		t.Fatalf("should return the error of the failing provisioner: %v", err)
+	}
+}
@@ -0,0 +1,69 @@
+package packer
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/hashicorp/packer/packer-plugin-sdk/retry"
+)
+
+// DefaultReconnectTimeout is how long WaitForReconnect waits for a machine
+// to come back when its communicator doesn't set a reconnect timeout.
+const DefaultReconnectTimeout = 5 * time.Minute
+
+// A Reconnector is a Communicator that knows how to get back to a machine
+// that restarts during a build, like when a provisioner installs updates.
+// Provisioners should use ExpectDisconnect and WaitForReconnect rather than
+// calling it directly, so that they work with any communicator.
+type Reconnector interface {
+	// ExpectDisconnect tells the communicator that the machine is about to
+	// restart. The machine is then allowed to present a new identity, like
+	// a new SSH host key after sysprep, on the next connection.
+	ExpectDisconnect() error
+
+	// Reconnect blocks until the machine can be reached again, or until the
+	// reconnect timeout of the communicator elapses.
+	Reconnect(ctx context.Context) error
+}
+
+// ExpectDisconnect tells comm that the machine is about to restart, if comm
+// is a Reconnector.
+func ExpectDisconnect(comm Communicator) error {
+	if r, ok := comm.(Reconnector); ok {
+		return r.ExpectDisconnect()
+	}
+	return nil
+}
+
+// WaitForReconnect blocks until comm can reach the machine again after it
+// disconnected, with the reconnect policy of comm if it is a Reconnector.
+// Otherwise it waits up to DefaultReconnectTimeout.
+func WaitForReconnect(ctx context.Context, comm Communicator) error {
+	if r, ok := comm.(Reconnector); ok {
+		return r.Reconnect(ctx)
+	}
+	return PollReconnect(ctx, comm, DefaultReconnectTimeout)
+}
+
+// PollReconnect runs a command that does nothing on comm until it succeeds,
+// or until timeout elapses. Communicators that have no connection to reopen
+// can implement Reconnect with it.
+func PollReconnect(ctx context.Context, comm Communicator, timeout time.Duration) error {
+	log.Printf("[INFO] waiting up to %s for the machine to come back", timeout)
+	err := retry.Config{StartTimeout: timeout}.Run(ctx, func(ctx context.Context) error {
+		cmd := &RemoteCmd{Command: "echo"}
+		if err := comm.Start(ctx, cmd); err != nil {
+			return err
+		}
+		if status := cmd.Wait(); status != 0 {
+			return fmt.Errorf("command exited with %d", status)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("the machine didn't come back after %s: %s", timeout, err)
+	}
+	return nil
+}
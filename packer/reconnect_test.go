@@ -0,0 +1,61 @@
+package packer
+
+import (
+	"context"
+	"testing"
+)
+
+type mockReconnector struct {
+	MockCommunicator
+
+	expectDisconnectCalled bool
+	reconnectCalled        bool
+}
+
+func (c *mockReconnector) ExpectDisconnect() error {
+	c.expectDisconnectCalled = true
+	return nil
+}
+
+func (c *mockReconnector) Reconnect(ctx context.Context) error {
+	c.reconnectCalled = true
+	return nil
+}
+
+func TestWaitForReconnect_reconnector(t *testing.T) {
+	comm := new(mockReconnector)
+	if err := ExpectDisconnect(comm); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if err := WaitForReconnect(context.Background(), comm); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if !comm.expectDisconnectCalled || !comm.reconnectCalled {
+		t.Fatal("the communicator should handle the reconnection")
+	}
+	if comm.StartCalled {
+		t.Fatal("no command should run")
+	}
+}
+
+func TestWaitForReconnect_poll(t *testing.T) {
+	comm := new(MockCommunicator)
+	if err := ExpectDisconnect(comm); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if err := WaitForReconnect(context.Background(), comm); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if !comm.StartCalled {
+		t.Fatal("a command should run to check the machine is back")
+	}
+}
+
+func TestPollReconnect_fails(t *testing.T) {
+	comm := &MockCommunicator{StartExitStatus: 1}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := PollReconnect(ctx, comm, DefaultReconnectTimeout); err == nil {
+		t.Fatal("should fail when the command fails")
+	}
+}
@@ -0,0 +1,142 @@
+package packer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// A Resource is something a build creates and destroys before it ends, like a
+// virtual machine or a temporary key pair. Builders record the resources they
+// create until they are destroyed, so that `packer destroy` can destroy the
+// ones left behind by builds that crashed.
+type Resource struct {
+	// Provider is the family of builders that created the resource, like
+	// "amazon" or "hyperv", which knows how to destroy it.
+	Provider string
+	// Type is the type of the resource for the provider, like "instance".
+	Type string
+	// ID identifies the resource for the provider.
+	ID string
+	// Attributes are what else the provider needs to destroy the resource,
+	// like its region.
+	Attributes map[string]string `json:",omitempty"`
+
+	// RunID is the PACKER_RUN_UUID of the run that created the resource.
+	RunID string
+	// CreatedAt is when the resource was recorded.
+	CreatedAt time.Time
+}
+
+func (r Resource) String() string {
+	s := fmt.Sprintf("%s %s %s", r.Provider, r.Type, r.ID)
+	if len(r.Attributes) == 0 {
+		return s
+	}
+	keys := make([]string, 0, len(r.Attributes))
+	for k := range r.Attributes {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	attrs := make([]string, len(keys))
+	for i, k := range keys {
+		attrs[i] = k + "=" + r.Attributes[k]
+	}
+	return s + " (" + strings.Join(attrs, ", ") + ")"
+}
+
+var resourceFileNameRe = regexp.MustCompile(`[^a-zA-Z0-9_.-]`)
+
+func (r Resource) path(dir string) string {
+	name := r.Provider + "." + r.Type + "." + r.ID
+	if region := r.Attributes["region"]; region != "" {
+		name += "." + region
+	}
+	return filepath.Join(dir, resourceFileNameRe.ReplaceAllString(name, "_")+".json")
+}
+
+// ResourcesDir returns the directory the resources created by builds are
+// recorded in, "resources" in the configuration directory.
+func ResourcesDir() (string, error) {
+	dir, err := ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "resources"), nil
+}
+
+// RecordResource records that a build created r, until ForgetResource is
+// called once it's destroyed. Only the resources of builds run by Packer are
+// recorded. Recording is best effort: errors are logged, and never fail a
+// build.
+func RecordResource(r Resource) {
+	r.RunID = os.Getenv("PACKER_RUN_UUID")
+	if r.RunID == "" {
+		return
+	}
+	r.CreatedAt = time.Now().UTC()
+
+	dir, err := ResourcesDir()
+	if err == nil {
+		err = os.MkdirAll(dir, 0755)
+	}
+	var data []byte
+	if err == nil {
+		data, err = json.MarshalIndent(r, "", "  ")
+	}
+	if err == nil {
+		err = ioutil.WriteFile(r.path(dir), data, 0644)
+	}
+	if err != nil {
+		log.Printf("Error recording %s: %s", r, err)
+	}
+}
+
+// ForgetResource removes the record of r, once it's destroyed or kept on
+// purpose.
+func ForgetResource(r Resource) {
+	dir, err := ResourcesDir()
+	if err == nil {
+		err = os.Remove(r.path(dir))
+	}
+	if err != nil && !os.IsNotExist(err) {
+		log.Printf("Error removing the record of %s: %s", r, err)
+	}
+}
+
+// RecordedResources returns the resources recorded by builds that weren't
+// forgotten, the most recent first, which is the order to destroy them in.
+func RecordedResources() ([]Resource, error) {
+	dir, err := ResourcesDir()
+	if err != nil {
+		return nil, err
+	}
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	var resources []Resource
+	for _, path := range paths {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		var r Resource
+		if err := json.Unmarshal(data, &r); err != nil {
+			return nil, fmt.Errorf("%s: %s", path, err)
+		}
+		resources = append(resources, r)
+	}
+	sort.SliceStable(resources, func(i, j int) bool {
+		return resources[i].CreatedAt.After(resources[j].CreatedAt)
+	})
+	return resources, nil
+}
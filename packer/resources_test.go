@@ -0,0 +1,78 @@
+package packer
+
+import (
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+)
+
+// testResourcesDir sets up the environment of a run of Packer recording its
+// resources to a temporary directory, and returns a function to restore it.
+func testResourcesDir(t *testing.T) func() {
+	dir, err := ioutil.TempDir("", "packer")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	os.Setenv("PACKER_CONFIG_DIR", dir)
+	os.Setenv("PACKER_RUN_UUID", "run")
+	return func() {
+		os.Unsetenv("PACKER_CONFIG_DIR")
+		os.Unsetenv("PACKER_RUN_UUID")
+		os.RemoveAll(dir)
+	}
+}
+
+func TestRecordResource(t *testing.T) {
+	defer testResourcesDir(t)()
+
+	vm := Resource{Provider: "hyperv", Type: "vm", ID: "packer-vm"}
+	instance := Resource{
+		Provider:   "amazon",
+		Type:       "instance",
+		ID:         "i-1234",
+		Attributes: map[string]string{"region": "us-east-1"},
+	}
+	RecordResource(vm)
+	RecordResource(instance)
+
+	resources, err := RecordedResources()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	var names []string
+	for _, r := range resources {
+		if r.RunID != "run" || r.CreatedAt.IsZero() {
+			t.Fatalf("bad: %#v", r)
+		}
+		names = append(names, r.String())
+	}
+	expected := []string{"amazon instance i-1234 (region=us-east-1)", "hyperv vm packer-vm"}
+	if !reflect.DeepEqual(names, expected) {
+		t.Fatalf("bad: %#v", names)
+	}
+
+	ForgetResource(instance)
+	ForgetResource(instance)
+	resources, err = RecordedResources()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if len(resources) != 1 || resources[0].ID != "packer-vm" {
+		t.Fatalf("bad: %#v", resources)
+	}
+}
+
+func TestRecordResource_notRunByPacker(t *testing.T) {
+	defer testResourcesDir(t)()
+	os.Unsetenv("PACKER_RUN_UUID")
+
+	RecordResource(Resource{Provider: "hyperv", Type: "vm", ID: "packer-vm"})
+	resources, err := RecordedResources()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if len(resources) != 0 {
+		t.Fatalf("bad: %#v", resources)
+	}
+}
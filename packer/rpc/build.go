@@ -3,6 +3,7 @@ package rpc
 import (
 	"context"
 	"log"
+	"time"
 
 	"github.com/hashicorp/packer/packer"
 )
@@ -101,6 +102,24 @@ func (b *build) SetOnError(val string) {
 	}
 }
 
+func (b *build) SetResume(val bool) {
+	if err := b.client.Call("Build.SetResume", val, new(interface{})); err != nil {
+		panic(err)
+	}
+}
+
+func (b *build) SetCleanupTimeout(val time.Duration) {
+	if err := b.client.Call("Build.SetCleanupTimeout", val, new(interface{})); err != nil {
+		panic(err)
+	}
+}
+
+func (b *build) SetValidate(val bool) {
+	if err := b.client.Call("Build.SetValidate", val, new(interface{})); err != nil {
+		panic(err)
+	}
+}
+
 func (b *build) Cancel() {
 	if err := b.client.Call("Build.Cancel", new(interface{}), new(interface{})); err != nil {
 		panic(err)
@@ -165,6 +184,21 @@ func (b *BuildServer) SetOnError(val *string, reply *interface{}) error {
 	return nil
 }
 
+func (b *BuildServer) SetResume(val *bool, reply *interface{}) error {
+	b.build.SetResume(*val)
+	return nil
+}
+
+func (b *BuildServer) SetCleanupTimeout(val *time.Duration, reply *interface{}) error {
+	b.build.SetCleanupTimeout(*val)
+	return nil
+}
+
+func (b *BuildServer) SetValidate(val *bool, reply *interface{}) error {
+	b.build.SetValidate(*val)
+	return nil
+}
+
 func (b *BuildServer) Cancel(args *interface{}, reply *interface{}) error {
 	if b.contextCancel != nil {
 		b.contextCancel()
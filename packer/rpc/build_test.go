@@ -5,6 +5,7 @@ import (
 	"errors"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/packer/packer"
 )
@@ -12,15 +13,18 @@ import (
 var testBuildArtifact = &packer.MockArtifact{}
 
 type testBuild struct {
-	nameCalled       bool
-	prepareCalled    bool
-	prepareWarnings  []string
-	runFn            func(context.Context)
-	runCalled        bool
-	runUi            packer.Ui
-	setDebugCalled   bool
-	setForceCalled   bool
-	setOnErrorCalled bool
+	nameCalled              bool
+	prepareCalled           bool
+	prepareWarnings         []string
+	runFn                   func(context.Context)
+	runCalled               bool
+	runUi                   packer.Ui
+	setDebugCalled          bool
+	setForceCalled          bool
+	setOnErrorCalled        bool
+	setResumeCalled         bool
+	setValidateCalled       bool
+	setCleanupTimeoutCalled bool
 
 	errRunResult bool
 }
@@ -62,6 +66,18 @@ func (b *testBuild) SetOnError(string) {
 	b.setOnErrorCalled = true
 }
 
+func (b *testBuild) SetResume(bool) {
+	b.setResumeCalled = true
+}
+
+func (b *testBuild) SetCleanupTimeout(time.Duration) {
+	b.setCleanupTimeoutCalled = true
+}
+
+func (b *testBuild) SetValidate(bool) {
+	b.setValidateCalled = true
+}
+
 func TestBuild(t *testing.T) {
 	b := new(testBuild)
 	client, server := testClientServer(t)
@@ -127,6 +143,24 @@ func TestBuild(t *testing.T) {
 	if !b.setOnErrorCalled {
 		t.Fatal("should be called")
 	}
+
+	// Test SetResume
+	bClient.SetResume(true)
+	if !b.setResumeCalled {
+		t.Fatal("should be called")
+	}
+
+	// Test SetValidate
+	bClient.SetValidate(true)
+	if !b.setValidateCalled {
+		t.Fatal("should be called")
+	}
+
+	// Test SetCleanupTimeout
+	bClient.SetCleanupTimeout(time.Minute)
+	if !b.setCleanupTimeoutCalled {
+		t.Fatal("should be called")
+	}
 }
 
 func TestBuild_cancel(t *testing.T) {
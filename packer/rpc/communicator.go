@@ -205,6 +205,42 @@ func (c *communicator) Download(path string, w io.Writer) (err error) {
 	return
 }
 
+func (c *communicator) ExpectDisconnect() error {
+	var reply error
+	err := c.client.Call(c.endpoint+".ExpectDisconnect", new(interface{}), &reply)
+	if err == nil {
+		err = reply
+	}
+
+	return err
+}
+
+func (c *communicator) Reconnect(ctx context.Context) error {
+	var reply error
+	err := c.client.Call(c.endpoint+".Reconnect", new(interface{}), &reply)
+	if err == nil {
+		err = reply
+	}
+
+	return err
+}
+
+func (c *communicator) LayersEnabled() (bool, error) {
+	var reply bool
+	err := c.client.Call(c.endpoint+".LayersEnabled", new(interface{}), &reply)
+	return reply, err
+}
+
+func (c *communicator) ResumeFromLayer(key string) (bool, error) {
+	var reply bool
+	err := c.client.Call(c.endpoint+".ResumeFromLayer", &key, &reply)
+	return reply, err
+}
+
+func (c *communicator) CommitLayer(key string) error {
+	return c.client.Call(c.endpoint+".CommitLayer", &key, new(interface{}))
+}
+
 func (c *CommunicatorServer) Start(args *CommunicatorStartArgs, reply *interface{}) error {
 	ctx := context.TODO()
 
@@ -321,6 +357,28 @@ func (c *CommunicatorServer) Download(args *CommunicatorDownloadArgs, reply *int
 	return
 }
 
+func (c *CommunicatorServer) ExpectDisconnect(args *interface{}, reply *error) error {
+	return packer.ExpectDisconnect(c.c)
+}
+
+func (c *CommunicatorServer) Reconnect(args *interface{}, reply *error) error {
+	return packer.WaitForReconnect(context.TODO(), c.c)
+}
+
+func (c *CommunicatorServer) LayersEnabled(args *interface{}, reply *bool) (err error) {
+	*reply, err = packer.LayersEnabled(c.c)
+	return
+}
+
+func (c *CommunicatorServer) ResumeFromLayer(key *string, reply *bool) (err error) {
+	*reply, err = packer.ResumeFromLayer(c.c, *key)
+	return
+}
+
+func (c *CommunicatorServer) CommitLayer(key *string, reply *interface{}) error {
+	return packer.CommitLayer(c.c, *key)
+}
+
 func serveSingleCopy(name string, mux *muxBroker, id uint32, dst io.Writer, src io.Reader) {
 	conn, err := mux.Accept(id)
 	if err != nil {
@@ -167,3 +167,40 @@ func TestCommunicator_ImplementsCommunicator(t *testing.T) {
 		t.Fatal("should be a Communicator")
 	}
 }
+
+func TestCommunicator_ImplementsReconnector(t *testing.T) {
+	var raw interface{}
+	raw = Communicator(nil)
+	if _, ok := raw.(packer.Reconnector); !ok {
+		t.Fatal("should be a Reconnector")
+	}
+}
+
+func TestCommunicator_ImplementsLayerer(t *testing.T) {
+	var raw interface{}
+	raw = Communicator(nil)
+	if _, ok := raw.(packer.Layerer); !ok {
+		t.Fatal("should be a Layerer")
+	}
+}
+
+func TestCommunicatorRPC_layersDisabled(t *testing.T) {
+	client, server := testClientServer(t)
+	defer client.Close()
+	defer server.Close()
+	server.RegisterCommunicator(new(packer.MockCommunicator))
+	remote := client.Communicator().(packer.Layerer)
+
+	// Communicators that aren't Layerers don't save layers
+	enabled, err := remote.LayersEnabled()
+	if err != nil || enabled {
+		t.Fatalf("bad: %t, %v", enabled, err)
+	}
+	resumed, err := remote.ResumeFromLayer("key")
+	if err != nil || resumed {
+		t.Fatalf("bad: %t, %v", resumed, err)
+	}
+	if err := remote.CommitLayer("key"); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+}
@@ -1,9 +1,14 @@
 package rpc
 
+import (
+	"github.com/hashicorp/packer/packer"
+)
+
 // This is a type that wraps error types so that they can be messaged
 // across RPC channels. Since "error" is an interface, we can't always
 // gob-encode the underlying structure. This is a valid error interface
-// implementer that we will push across.
+// implementer that we will push across. The secrets known to the process
+// are scrubbed from the message.
 type BasicError struct {
 	Message string
 }
@@ -13,7 +18,7 @@ func NewBasicError(err error) *BasicError {
 		return nil
 	}
 
-	return &BasicError{err.Error()}
+	return &BasicError{packer.LogSecretFilter.Scrub(err.Error())}
 }
 
 func (e *BasicError) Error() string {
@@ -7,7 +7,8 @@ import (
 )
 
 // An implementation of packer.Ui where the Ui is actually executed
-// over an RPC connection.
+// over an RPC connection. The secrets known to the process, like the
+// credentials of a plugin, are scrubbed before they are sent.
 type Ui struct {
 	commonClient
 	endpoint string
@@ -29,17 +30,22 @@ type UiMachineArgs struct {
 }
 
 func (u *Ui) Ask(query string) (result string, err error) {
+	query = packer.LogSecretFilter.Scrub(query)
 	err = u.client.Call("Ui.Ask", query, &result)
 	return
 }
 
 func (u *Ui) Error(message string) {
+	message = packer.LogSecretFilter.Scrub(message)
 	if err := u.client.Call("Ui.Error", message, new(interface{})); err != nil {
 		log.Printf("Error in Ui.Error RPC call: %s", err)
 	}
 }
 
 func (u *Ui) Machine(t string, args ...string) {
+	for i := range args {
+		args[i] = packer.LogSecretFilter.Scrub(args[i])
+	}
 	rpcArgs := &UiMachineArgs{
 		Category: t,
 		Args:     args,
@@ -51,12 +57,14 @@ func (u *Ui) Machine(t string, args ...string) {
 }
 
 func (u *Ui) Message(message string) {
+	message = packer.LogSecretFilter.Scrub(message)
 	if err := u.client.Call("Ui.Message", message, new(interface{})); err != nil {
 		log.Printf("Error in Ui.Message RPC call: %s", err)
 	}
 }
 
 func (u *Ui) Say(message string) {
+	message = packer.LogSecretFilter.Scrub(message)
 	if err := u.client.Call("Ui.Say", message, new(interface{})); err != nil {
 		log.Printf("Error in Ui.Say RPC call: %s", err)
 	}
@@ -1,13 +1,25 @@
 package packer
 
-import "github.com/hashicorp/hcl/v2"
+import (
+	"time"
+
+	"github.com/hashicorp/hcl/v2"
+)
 
 type GetBuildsOptions struct {
 	// Get builds except the ones that match with except and with only the ones
 	// that match with Only. When those are empty everything matches.
-	Except, Only []string
-	Debug, Force bool
-	OnError      string
+	Except, Only         []string
+	Debug, Force, Resume bool
+	OnError              string
+
+	// Validate prepares the builds only to validate their configuration, see
+	// Build.SetValidate.
+	Validate bool
+
+	// CleanupTimeout is how long the cleanup of each step of the builds is
+	// waited for, see Build.SetCleanupTimeout.
+	CleanupTimeout time.Duration
 }
 
 type BuildGetter interface {
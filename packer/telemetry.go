@@ -116,7 +116,7 @@ func (c *CheckpointTelemetry) Finalize(command string, errCode int, err error) e
 		Command:  command,
 	}
 	if err != nil {
-		extra.Error = err.Error()
+		extra.Error = LogSecretFilter.Scrub(err.Error())
 	}
 	params.Payload = extra
 	// b, _ := json.MarshalIndent(params, "", "    ")
@@ -145,7 +145,7 @@ func (s *TelemetrySpan) End(err error) {
 	s.EndTime = time.Now().UTC()
 	log.Printf("[INFO] (telemetry) ending %s", s.Name)
 	if err != nil {
-		s.Error = err.Error()
+		s.Error = LogSecretFilter.Scrub(err.Error())
 	}
 }
 
@@ -0,0 +1,326 @@
+package packer
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	packerVersion "github.com/hashicorp/packer/version"
+)
+
+// TraceExporter sends a trace per build to an OpenTelemetry collector, with
+// OTLP over HTTP encoded as JSON. The trace has a span for the build, and a
+// span per step, provisioner and post-processor of the build.
+type TraceExporter struct {
+	// Endpoint is the URL the traces are posted to, like
+	// "http://localhost:4318/v1/traces".
+	Endpoint string
+	// Headers are added to the requests, like for authentication.
+	Headers map[string]string
+	// ServiceName is the service of the spans, "packer" when empty.
+	ServiceName string
+	// Parent is the W3C traceparent of the span the builds are part of, like
+	// the span of a CI job. Each build has a trace of its own without it.
+	Parent string
+	// Client sends the requests, http.DefaultClient when nil.
+	Client *http.Client
+}
+
+// NewTraceExporter returns the TraceExporter configured by the environment
+// variables of OpenTelemetry, or nil when no OTLP endpoint is set, tracing
+// being opt-in. The parent of the builds is read from TRACEPARENT. When the
+// variables are invalid, like with an unsupported protocol, it returns a nil
+// TraceExporter with the error: the builds run without exporting traces.
+func NewTraceExporter() (*TraceExporter, error) {
+	if strings.EqualFold(os.Getenv("OTEL_SDK_DISABLED"), "true") {
+		return nil, nil
+	}
+
+	endpoint := os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT")
+	if endpoint == "" {
+		if base := os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"); base != "" {
+			endpoint = strings.TrimSuffix(base, "/") + "/v1/traces"
+		}
+	}
+	if endpoint == "" {
+		return nil, nil
+	}
+
+	protocol := os.Getenv("OTEL_EXPORTER_OTLP_TRACES_PROTOCOL")
+	if protocol == "" {
+		protocol = os.Getenv("OTEL_EXPORTER_OTLP_PROTOCOL")
+	}
+	if protocol != "" && protocol != "http/json" {
+		return nil, fmt.Errorf("only the http/json protocol of OTLP is supported, not %q", protocol)
+	}
+
+	headers := map[string]string{}
+	for _, env := range []string{"OTEL_EXPORTER_OTLP_HEADERS", "OTEL_EXPORTER_OTLP_TRACES_HEADERS"} {
+		if err := parseOTLPHeaders(os.Getenv(env), headers); err != nil {
+			return nil, fmt.Errorf("bad %s: %s", env, err)
+		}
+	}
+
+	parent := os.Getenv("TRACEPARENT")
+	if parent != "" {
+		if _, _, err := parseTraceParent(parent); err != nil {
+			return nil, fmt.Errorf("bad TRACEPARENT: %s", err)
+		}
+	}
+
+	return &TraceExporter{
+		Endpoint:    endpoint,
+		Headers:     headers,
+		ServiceName: os.Getenv("OTEL_SERVICE_NAME"),
+		Parent:      parent,
+	}, nil
+}
+
+// parseOTLPHeaders adds the headers of s, like "api-key=secret,team=images",
+// to headers. The values are URL-encoded.
+func parseOTLPHeaders(s string, headers map[string]string) error {
+	for _, pair := range strings.Split(s, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 {
+			return fmt.Errorf("expected key=value, got %q", pair)
+		}
+		value, err := url.QueryUnescape(strings.TrimSpace(parts[1]))
+		if err != nil {
+			return err
+		}
+		headers[strings.TrimSpace(parts[0])] = value
+	}
+	return nil
+}
+
+// parseTraceParent returns the trace and the span IDs of a W3C traceparent,
+// like "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01".
+func parseTraceParent(s string) (traceID, spanID string, err error) {
+	parts := strings.Split(s, "-")
+	if len(parts) < 4 || len(parts[1]) != 32 || len(parts[2]) != 16 {
+		return "", "", fmt.Errorf("expected version-traceid-spanid-flags, got %q", s)
+	}
+	for _, id := range parts[1:3] {
+		if _, err := hex.DecodeString(id); err != nil {
+			return "", "", fmt.Errorf("bad ID %q: %s", id, err)
+		}
+	}
+	return parts[1], parts[2], nil
+}
+
+// BuildTrace is what the trace of a run of a build tells.
+type BuildTrace struct {
+	Name        string
+	BuilderType string
+	// Profile tells when the steps, provisioners and post-processors of the
+	// build ran.
+	Profile   BuildProfile
+	End       time.Time
+	Artifacts []Artifact
+	// Err is the error the build failed with, if any.
+	Err error
+}
+
+// ExportBuild sends the trace of a run of a build.
+func (e *TraceExporter) ExportBuild(ctx context.Context, t BuildTrace) error {
+	body, err := json.Marshal(e.request(t))
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range e.Headers {
+		req.Header.Set(k, v)
+	}
+
+	client := e.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("%s: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+	return nil
+}
+
+func (e *TraceExporter) request(t BuildTrace) *otlpRequest {
+	traceID, parentID := randomID(16), ""
+	if e.Parent != "" {
+		traceID, parentID, _ = parseTraceParent(e.Parent)
+	}
+
+	ids := []otlpAnyValue{}
+	for _, a := range t.Artifacts {
+		if a != nil && a.Id() != "" {
+			ids = append(ids, otlpAnyValue{StringValue: a.Id()})
+		}
+	}
+	build := otlpSpan{
+		TraceID:      traceID,
+		SpanID:       randomID(8),
+		ParentSpanID: parentID,
+		Name:         t.Name,
+		Kind:         otlpSpanKindInternal,
+		Start:        otlpTime(t.Profile.Start),
+		End:          otlpTime(t.End),
+		Attributes: []otlpAttribute{
+			stringAttribute("packer.build.name", t.Name),
+			stringAttribute("packer.builder.type", t.BuilderType),
+			{Key: "packer.artifact.ids", Value: otlpAnyValue{ArrayValue: &otlpArrayValue{Values: ids}}},
+		},
+	}
+	if t.Err != nil {
+		build.Status = &otlpStatus{Code: otlpStatusCodeError, Message: t.Err.Error()}
+	}
+	spans := []otlpSpan{build}
+
+	child := func(parentID, kind string, e ProfileEntry) otlpSpan {
+		return otlpSpan{
+			TraceID:      traceID,
+			SpanID:       randomID(8),
+			ParentSpanID: parentID,
+			Name:         e.Name,
+			Kind:         otlpSpanKindInternal,
+			Start:        otlpTime(e.Start),
+			End:          otlpTime(e.Start.Add(e.Duration())),
+			Attributes:   []otlpAttribute{stringAttribute("packer."+kind+".name", e.Name)},
+		}
+	}
+	var steps []otlpSpan
+	for _, step := range t.Profile.Steps {
+		steps = append(steps, child(build.SpanID, "step", step))
+	}
+	spans = append(spans, steps...)
+	for _, p := range t.Profile.Provisioners {
+		// The provisioners run within a step, like StepProvision
+		parentID := build.SpanID
+		for i, step := range t.Profile.Steps {
+			if !p.Start.Before(step.Start) && !p.Start.After(step.Start.Add(step.Duration())) {
+				parentID = steps[i].SpanID
+			}
+		}
+		spans = append(spans, child(parentID, "provisioner", p))
+	}
+	for _, pp := range t.Profile.PostProcessors {
+		spans = append(spans, child(build.SpanID, "post-processor", pp))
+	}
+
+	serviceName := e.ServiceName
+	if serviceName == "" {
+		serviceName = "packer"
+	}
+	return &otlpRequest{
+		ResourceSpans: []otlpResourceSpans{{
+			Resource: otlpResource{Attributes: []otlpAttribute{
+				stringAttribute("service.name", serviceName),
+				stringAttribute("service.version", packerVersion.FormattedVersion()),
+			}},
+			ScopeSpans: []otlpScopeSpans{{
+				Scope: otlpScope{Name: "packer", Version: packerVersion.FormattedVersion()},
+				Spans: spans,
+			}},
+		}},
+	}
+}
+
+func randomID(n int) string {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
+
+func otlpTime(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
+
+func stringAttribute(key, value string) otlpAttribute {
+	return otlpAttribute{Key: key, Value: otlpAnyValue{StringValue: value}}
+}
+
+// The JSON encoding of the ExportTraceServiceRequest of OTLP, with the IDs
+// encoded in hexadecimal.
+
+const (
+	otlpSpanKindInternal = 1
+	otlpStatusCodeError  = 2
+)
+
+type otlpRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpAttribute `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type otlpSpan struct {
+	TraceID      string          `json:"traceId"`
+	SpanID       string          `json:"spanId"`
+	ParentSpanID string          `json:"parentSpanId,omitempty"`
+	Name         string          `json:"name"`
+	Kind         int             `json:"kind"`
+	Start        string          `json:"startTimeUnixNano"`
+	End          string          `json:"endTimeUnixNano"`
+	Attributes   []otlpAttribute `json:"attributes"`
+	Status       *otlpStatus     `json:"status,omitempty"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+type otlpAttribute struct {
+	Key   string       `json:"key"`
+	Value otlpAnyValue `json:"value"`
+}
+
+type otlpAnyValue struct {
+	StringValue string          `json:"stringValue,omitempty"`
+	ArrayValue  *otlpArrayValue `json:"arrayValue,omitempty"`
+}
+
+type otlpArrayValue struct {
+	Values []otlpAnyValue `json:"values"`
+}
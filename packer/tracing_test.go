@@ -0,0 +1,153 @@
+package packer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestNewTraceExporter(t *testing.T) {
+	for _, env := range []string{"OTEL_SDK_DISABLED", "OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "OTEL_EXPORTER_OTLP_ENDPOINT",
+		"OTEL_EXPORTER_OTLP_PROTOCOL", "OTEL_EXPORTER_OTLP_TRACES_PROTOCOL", "OTEL_EXPORTER_OTLP_HEADERS", "OTEL_SERVICE_NAME", "TRACEPARENT"} {
+		defer os.Setenv(env, os.Getenv(env))
+		os.Unsetenv(env)
+	}
+
+	e, err := NewTraceExporter()
+	if err != nil || e != nil {
+		t.Fatalf("tracing should be opt-in, got %#v, %v", e, err)
+	}
+
+	os.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "http://collector:4318/")
+	os.Setenv("OTEL_EXPORTER_OTLP_HEADERS", "api-key=a%20b,team=images")
+	e, err = NewTraceExporter()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if e.Endpoint != "http://collector:4318/v1/traces" {
+		t.Fatalf("bad endpoint: %s", e.Endpoint)
+	}
+	if e.Headers["api-key"] != "a b" || e.Headers["team"] != "images" {
+		t.Fatalf("bad headers: %#v", e.Headers)
+	}
+
+	for _, env := range []string{"OTEL_EXPORTER_OTLP_PROTOCOL", "OTEL_EXPORTER_OTLP_TRACES_PROTOCOL"} {
+		for _, protocol := range []string{"grpc", "http/protobuf"} {
+			os.Setenv(env, protocol)
+			if e, err := NewTraceExporter(); err == nil || e != nil {
+				t.Fatalf("%s=%s should disable the export with an error, got %#v, %v", env, protocol, e, err)
+			}
+		}
+		os.Unsetenv(env)
+	}
+
+	os.Setenv("TRACEPARENT", "00-nothex-b7ad6b7169203331-01")
+	if e, err := NewTraceExporter(); err == nil || e != nil {
+		t.Fatalf("a bad TRACEPARENT should disable the export with an error, got %#v, %v", e, err)
+	}
+
+	os.Setenv("OTEL_SDK_DISABLED", "true")
+	if e, _ := NewTraceExporter(); e != nil {
+		t.Fatal("OTEL_SDK_DISABLED should disable tracing")
+	}
+}
+
+func TestTraceExporter_ExportBuild(t *testing.T) {
+	var req otlpRequest
+	var header http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("bad request: %s", err)
+		}
+	}))
+	defer server.Close()
+
+	start := time.Now()
+	profile := BuildProfile{
+		Build: "vm",
+		Start: start,
+		Steps: []ProfileEntry{
+			{Name: "StepCreateVM", Seconds: 2, Start: start},
+			{Name: "StepProvision", Seconds: 5, Start: start.Add(2 * time.Second)},
+		},
+		Provisioners:   []ProfileEntry{{Name: "shell", Seconds: 4, Start: start.Add(3 * time.Second)}},
+		PostProcessors: []ProfileEntry{{Name: "manifest", Seconds: 1, Start: start.Add(7 * time.Second)}},
+	}
+	e := &TraceExporter{
+		Endpoint: server.URL,
+		Headers:  map[string]string{"api-key": "secret"},
+		Parent:   "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01",
+	}
+	err := e.ExportBuild(context.Background(), BuildTrace{
+		Name:        "hyperv-iso.vm",
+		BuilderType: "hyperv-iso",
+		Profile:     profile,
+		End:         start.Add(8 * time.Second),
+		Artifacts:   []Artifact{&MockArtifact{IdValue: "vm-1"}},
+		Err:         errors.New("post-processor failed"),
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if header.Get("api-key") != "secret" {
+		t.Fatalf("the headers should be sent, got %#v", header)
+	}
+
+	spans := req.ResourceSpans[0].ScopeSpans[0].Spans
+	if len(spans) != 5 {
+		t.Fatalf("expected a span for the build and each of its parts, got %#v", spans)
+	}
+	byName := map[string]otlpSpan{}
+	for _, s := range spans {
+		if s.TraceID != "0af7651916cd43dd8448eb211c80319c" {
+			t.Fatalf("the spans should be part of the trace of TRACEPARENT: %#v", s)
+		}
+		byName[s.Name] = s
+	}
+
+	build := byName["hyperv-iso.vm"]
+	if build.ParentSpanID != "b7ad6b7169203331" {
+		t.Fatalf("the build should be a child of TRACEPARENT: %#v", build)
+	}
+	if build.Status == nil || build.Status.Code != otlpStatusCodeError {
+		t.Fatalf("the build span should have failed: %#v", build.Status)
+	}
+	attrs := map[string]otlpAnyValue{}
+	for _, a := range build.Attributes {
+		attrs[a.Key] = a.Value
+	}
+	if attrs["packer.builder.type"].StringValue != "hyperv-iso" {
+		t.Fatalf("bad attributes: %#v", attrs)
+	}
+	if ids := attrs["packer.artifact.ids"].ArrayValue; ids == nil || ids.Values[0].StringValue != "vm-1" {
+		t.Fatalf("bad artifact ids: %#v", ids)
+	}
+
+	if byName["StepProvision"].ParentSpanID != build.SpanID || byName["manifest"].ParentSpanID != build.SpanID {
+		t.Fatal("the steps and post-processors should be children of the build")
+	}
+	if byName["shell"].ParentSpanID != byName["StepProvision"].SpanID {
+		t.Fatal("the provisioners should be children of the step they ran in")
+	}
+	if byName["shell"].Start != otlpTime(start.Add(3*time.Second)) {
+		t.Fatalf("bad start: %s", byName["shell"].Start)
+	}
+}
+
+func TestTraceExporter_ExportBuild_error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "bad token", http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	e := &TraceExporter{Endpoint: server.URL}
+	if err := e.ExportBuild(context.Background(), BuildTrace{Name: "vm"}); err == nil {
+		t.Fatal("a rejected export should be an error")
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"os"
 	"os/signal"
 	"runtime"
+	"strconv"
 	"strings"
 	"sync"
 	"syscall"
@@ -135,12 +136,25 @@ func (u *ColoredUi) supportsColors() bool {
 // the output to indicate a specific target. Specifically, all Say output
 // is prefixed with the target name. Message output is not prefixed but
 // is offset by the length of the target so that output is lined up properly
-// with Say output. Machine-readable output has the proper target set.
+// with Say output. Machine-readable output has the proper target set, and so
+// do the events of a JSONEventsUi and the messages of a DashboardBuild.
 type TargetedUI struct {
 	Target string
 	Ui     Ui
 }
 
+// targetMessageUi is implemented by the UIs keeping track of the target of
+// the messages of a TargetedUI themselves, instead of having them prefixed.
+type targetMessageUi interface {
+	targetMessage(target, level, message string)
+}
+
+// targetProgressUi is implemented by the UIs keeping track of the target of
+// the transfers of a TargetedUI themselves.
+type targetProgressUi interface {
+	targetTrackProgress(target, src string, currentSize, totalSize int64, stream io.ReadCloser) io.ReadCloser
+}
+
 var _ Ui = new(TargetedUI)
 
 func (u *TargetedUI) Ask(query string) (string, error) {
@@ -148,14 +162,26 @@ func (u *TargetedUI) Ask(query string) (string, error) {
 }
 
 func (u *TargetedUI) Say(message string) {
+	if ui, ok := u.Ui.(targetMessageUi); ok {
+		ui.targetMessage(u.Target, "say", message)
+		return
+	}
 	u.Ui.Say(u.prefixLines(true, message))
 }
 
 func (u *TargetedUI) Message(message string) {
+	if ui, ok := u.Ui.(targetMessageUi); ok {
+		ui.targetMessage(u.Target, "message", message)
+		return
+	}
 	u.Ui.Message(u.prefixLines(false, message))
 }
 
 func (u *TargetedUI) Error(message string) {
+	if ui, ok := u.Ui.(targetMessageUi); ok {
+		ui.targetMessage(u.Target, "error", message)
+		return
+	}
 	u.Ui.Error(u.prefixLines(true, message))
 }
 
@@ -180,6 +206,9 @@ func (u *TargetedUI) prefixLines(arrow bool, message string) string {
 }
 
 func (u *TargetedUI) TrackProgress(src string, currentSize, totalSize int64, stream io.ReadCloser) io.ReadCloser {
+	if ui, ok := u.Ui.(targetProgressUi); ok {
+		return ui.targetTrackProgress(u.Target, src, currentSize, totalSize, stream)
+	}
 	return u.Ui.TrackProgress(u.prefixLines(false, src), currentSize, totalSize, stream)
 }
 
@@ -213,6 +242,8 @@ func (rw *BasicUi) Ask(query string) (string, error) {
 	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
 	defer signal.Stop(sigCh)
 
+	// The question can show an error, like with -on-error=ask
+	query = LogSecretFilter.Scrub(query)
 	log.Printf("ui: ask: %s", query)
 	if query != "" {
 		if _, err := fmt.Fprint(rw.Writer, query+" "); err != nil {
@@ -250,11 +281,7 @@ func (rw *BasicUi) Say(message string) {
 	defer rw.l.Unlock()
 
 	// Use LogSecretFilter to scrub out sensitive variables
-	for s := range LogSecretFilter.s {
-		if s != "" {
-			message = strings.Replace(message, s, "<sensitive>", -1)
-		}
-	}
+	message = LogSecretFilter.Scrub(message)
 
 	log.Printf("ui: %s", message)
 	_, err := fmt.Fprint(rw.Writer, message+"\n")
@@ -268,11 +295,7 @@ func (rw *BasicUi) Message(message string) {
 	defer rw.l.Unlock()
 
 	// Use LogSecretFilter to scrub out sensitive variables
-	for s := range LogSecretFilter.s {
-		if s != "" {
-			message = strings.Replace(message, s, "<sensitive>", -1)
-		}
-	}
+	message = LogSecretFilter.Scrub(message)
 
 	log.Printf("ui: %s", message)
 	_, err := fmt.Fprint(rw.Writer, message+"\n")
@@ -291,11 +314,7 @@ func (rw *BasicUi) Error(message string) {
 	}
 
 	// Use LogSecretFilter to scrub out sensitive variables
-	for s := range LogSecretFilter.s {
-		if s != "" {
-			message = strings.Replace(message, s, "<sensitive>", -1)
-		}
-	}
+	message = LogSecretFilter.Scrub(message)
 
 	log.Printf("ui error: %s", message)
 	_, err := fmt.Fprint(writer, message+"\n")
@@ -316,7 +335,6 @@ func (rw *BasicUi) TrackProgress(src string, currentSize, totalSize int64, strea
 // to the given Writer.
 type MachineReadableUi struct {
 	Writer io.Writer
-	PB     NoopProgressTracker
 }
 
 var _ Ui = new(MachineReadableUi)
@@ -349,14 +367,10 @@ func (u *MachineReadableUi) Machine(category string, args ...string) {
 	}
 
 	// Prepare the args
-	for i, v := range args {
+	for i := range args {
 		// Use LogSecretFilter to scrub out sensitive variables
-		for s := range LogSecretFilter.s {
-			if s != "" {
-				args[i] = strings.Replace(args[i], s, "<sensitive>", -1)
-			}
-		}
-		args[i] = strings.Replace(v, ",", "%!(PACKER_COMMA)", -1)
+		args[i] = LogSecretFilter.Scrub(args[i])
+		args[i] = strings.Replace(args[i], ",", "%!(PACKER_COMMA)", -1)
 		args[i] = strings.Replace(args[i], "\r", "\\r", -1)
 		args[i] = strings.Replace(args[i], "\n", "\\n", -1)
 	}
@@ -375,7 +389,35 @@ func (u *MachineReadableUi) Machine(category string, args ...string) {
 }
 
 func (u *MachineReadableUi) TrackProgress(src string, currentSize, totalSize int64, stream io.ReadCloser) (body io.ReadCloser) {
-	return u.PB.TrackProgress(src, currentSize, totalSize, stream)
+	return u.targetTrackProgress("", src, currentSize, totalSize, stream)
+}
+
+// targetTrackProgress outputs a "progress" message every
+// progressReportInterval while stream is read, and a last one with "done"
+// once it's closed.
+func (u *MachineReadableUi) targetTrackProgress(target, src string, currentSize, totalSize int64, stream io.ReadCloser) io.ReadCloser {
+	p := NewTransferProgress(src, currentSize, totalSize)
+	return reportProgress(stream, p, progressReportInterval, func(p *TransferProgress, done bool) {
+		now := time.Now()
+		args := []string{
+			p.Src,
+			strconv.FormatInt(p.Current(), 10),
+			strconv.FormatInt(p.Total, 10),
+			strconv.FormatInt(int64(p.Rate(now)), 10),
+			"",
+		}
+		if left, ok := p.Remaining(now); ok {
+			args[4] = strconv.FormatInt(int64(left.Seconds()), 10)
+		}
+		if done {
+			args = append(args, "done")
+		}
+		category := "progress"
+		if target != "" {
+			category = target + "," + category
+		}
+		u.Machine(category, args...)
+	})
 }
 
 // TimestampedUi is a UI that wraps another UI implementation and
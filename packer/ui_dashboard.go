@@ -0,0 +1,462 @@
+package packer
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Dashboard shows the progress of concurrent builds on a terminal as a table,
+// with a row per build: its status, how long it has been running, its current
+// step or provisioner and its last message. The table is redrawn in place
+// instead of interleaving the output of the builds. Entering the number of a
+// build shows its whole output instead, and an empty line goes back to the
+// table. When a build asks a question, its output is shown with the question
+// and the next line entered is the answer.
+type Dashboard struct {
+	// Writer is the terminal the dashboard is drawn to.
+	Writer io.Writer
+	// Size returns the width and the height of the terminal, 80x24 is used
+	// when it's nil or fails.
+	Size func() (width, height int, err error)
+
+	l        sync.Mutex
+	builds   []*DashboardBuild
+	focus    int // the number of the build shown, 0 for the table
+	drawn    int // the number of lines drawn the last time
+	question *dashboardQuestion
+	stopped  bool
+	inputCh  chan string // the lines read, nil without input
+	stopCh   chan struct{}
+	doneCh   chan struct{}
+
+	// askL makes the builds ask their questions one at a time
+	askL sync.Mutex
+}
+
+// dashboardQuestion is a question a build is asking, the next line read is
+// sent to answer.
+type dashboardQuestion struct {
+	build  *DashboardBuild
+	query  string
+	answer chan string
+}
+
+const (
+	dashboardWaiting = "waiting"
+	dashboardRunning = "running"
+	dashboardDone    = "done"
+	dashboardFailed  = "failed"
+)
+
+// DashboardBuild is the Ui of a build shown by a Dashboard. Its messages are
+// kept as the output of the build, and its machine-readable messages tell the
+// step or the provisioner that runs.
+type DashboardBuild struct {
+	d    *Dashboard
+	name string
+
+	// Guarded by the lock of the dashboard
+	status     string
+	start, end time.Time
+	current    string
+	progress   *TransferProgress
+	lines      []string
+}
+
+var _ Ui = new(DashboardBuild)
+
+// Build adds a build to the dashboard, and returns its Ui. Builds are shown
+// in the order they are added.
+func (d *Dashboard) Build(name string) *DashboardBuild {
+	d.l.Lock()
+	defer d.l.Unlock()
+	b := &DashboardBuild{d: d, name: name, status: dashboardWaiting}
+	d.builds = append(d.builds, b)
+	return b
+}
+
+// Start starts drawing the dashboard every interval until Stop is called. The
+// lines read from input select the build to show, or answer the question of a
+// build.
+func (d *Dashboard) Start(input io.Reader, interval time.Duration) {
+	d.stopCh = make(chan struct{})
+	d.doneCh = make(chan struct{})
+
+	if input != nil {
+		d.inputCh = make(chan string)
+		go d.read(input)
+	}
+
+	go func() {
+		defer close(d.doneCh)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			d.draw()
+			select {
+			case <-ticker.C:
+			case line := <-d.inputCh:
+				d.input(line)
+			case <-d.stopCh:
+				return
+			}
+		}
+	}()
+}
+
+// read sends the lines of input to the drawing loop until Stop is called. A
+// read that is blocked can't be interrupted, so the line it returns after
+// Stop is dropped.
+func (d *Dashboard) read(input io.Reader) {
+	scanner := bufio.NewScanner(input)
+	for scanner.Scan() {
+		select {
+		case d.inputCh <- scanner.Text():
+		case <-d.stopCh:
+			return
+		}
+	}
+}
+
+// Stop stops drawing the dashboard, and draws the table a last time so that
+// it stays on the terminal.
+func (d *Dashboard) Stop() {
+	close(d.stopCh)
+	<-d.doneCh
+
+	d.l.Lock()
+	d.focus = 0
+	d.l.Unlock()
+	d.draw()
+
+	d.l.Lock()
+	d.stopped = true
+	d.l.Unlock()
+}
+
+// input answers the question that is asked with line, or selects the build to
+// show otherwise.
+func (d *Dashboard) input(line string) {
+	d.l.Lock()
+	q := d.question
+	if q != nil {
+		d.question = nil
+		// The line was echoed below the dashboard
+		d.drawn++
+	}
+	d.l.Unlock()
+
+	if q != nil {
+		q.answer <- line
+		return
+	}
+	d.selectBuild(strings.TrimSpace(line))
+}
+
+func (d *Dashboard) selectBuild(line string) {
+	d.l.Lock()
+	defer d.l.Unlock()
+
+	// The line was echoed below the dashboard
+	d.drawn++
+
+	if line == "" {
+		d.focus = 0
+		return
+	}
+	if n, err := strconv.Atoi(line); err == nil && n >= 1 && n <= len(d.builds) {
+		d.focus = n
+	}
+}
+
+func (d *Dashboard) draw() {
+	width, height := 80, 24
+	if d.Size != nil {
+		if w, h, err := d.Size(); err == nil && w > 0 && h > 0 {
+			width, height = w, h
+		}
+	}
+
+	d.l.Lock()
+	defer d.l.Unlock()
+	if d.stopped {
+		return
+	}
+
+	var out strings.Builder
+	// Move up to draw over the previous lines, clearing what remains of them
+	if d.drawn > 0 {
+		fmt.Fprintf(&out, "\033[%dA\r", d.drawn)
+	}
+	lines := d.render(width, height, time.Now())
+	for _, line := range lines {
+		out.WriteString(line + "\033[K\n")
+	}
+	out.WriteString("\033[J")
+	d.drawn = len(lines)
+	if _, err := io.WriteString(d.Writer, out.String()); err != nil {
+		log.Printf("[ERR] Failed to draw the dashboard: %s", err)
+	}
+}
+
+// render returns the lines of the table, or of the output of the selected
+// build, to fit a terminal of width and height.
+func (d *Dashboard) render(width, height int, now time.Time) []string {
+	if d.focus > 0 {
+		return d.renderBuild(d.builds[d.focus-1], width, height, now)
+	}
+
+	counts := map[string]int{}
+	nameWidth, stepWidth := len("BUILD"), len("STEP")
+	for _, b := range d.builds {
+		counts[b.status]++
+		nameWidth = maxInt(nameWidth, len(b.name))
+		stepWidth = maxInt(stepWidth, len(b.current))
+	}
+	nameWidth = minInt(nameWidth, 40)
+	stepWidth = minInt(stepWidth, 30)
+
+	lines := []string{
+		fmt.Sprintf("%d builds: %d running, %d done, %d failed. Enter the number of a build to show its output.",
+			len(d.builds), counts[dashboardRunning], counts[dashboardDone], counts[dashboardFailed]),
+		fmt.Sprintf("%3s  %-*s  %-7s  %8s  %-*s  %s", "#", nameWidth, "BUILD", "STATUS", "TIME", stepWidth, "STEP", "OUTPUT"),
+	}
+	for i, b := range d.builds {
+		// Keep the last line of the terminal for the input
+		if len(lines) == height-2 && i < len(d.builds)-1 {
+			lines = append(lines, fmt.Sprintf("... and %d more builds", len(d.builds)-i))
+			break
+		}
+		lines = append(lines, fmt.Sprintf("%3d  %-*s  %-7s  %8s  %-*s  %s", i+1,
+			nameWidth, truncate(b.name, nameWidth), b.status, b.elapsed(now),
+			stepWidth, truncate(b.current, stepWidth), b.lastLine(now)))
+	}
+	for i := range lines {
+		lines[i] = truncate(lines[i], width)
+	}
+	return lines
+}
+
+func (d *Dashboard) renderBuild(b *DashboardBuild, width, height int, now time.Time) []string {
+	header := fmt.Sprintf("%d %s: %s after %s", d.focus, b.name, b.status, b.elapsed(now))
+	if b.current != "" {
+		header += ", " + b.current
+	}
+	asking := d.question != nil && d.question.build == b
+	if asking {
+		header += ". Enter the answer to its question."
+	} else {
+		header += ". Enter an empty line to go back to the builds."
+	}
+
+	output := b.lines
+	if b.progress != nil {
+		output = append(output[:len(output):len(output)], b.progress.Format(now))
+	}
+	if asking {
+		output = append(output[:len(output):len(output)], strings.Split(strings.TrimRight(d.question.query, "\n"), "\n")...)
+	}
+	if rows := height - 2; len(output) > rows {
+		output = output[len(output)-rows:]
+	}
+
+	lines := []string{truncate(header, width)}
+	for _, line := range output {
+		lines = append(lines, truncate(line, width))
+	}
+	return lines
+}
+
+// truncate cuts s to width characters, ending with "…" when it's cut.
+func truncate(s string, width int) string {
+	runes := []rune(s)
+	if len(runes) <= width {
+		return s
+	}
+	if width < 1 {
+		return ""
+	}
+	return string(runes[:width-1]) + "…"
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// Start marks the build as running.
+func (b *DashboardBuild) Start() {
+	b.d.l.Lock()
+	defer b.d.l.Unlock()
+	b.status = dashboardRunning
+	b.start = time.Now()
+}
+
+// Done marks the build as done, or failed when err isn't nil.
+func (b *DashboardBuild) Done(err error) {
+	b.d.l.Lock()
+	defer b.d.l.Unlock()
+	b.status = dashboardDone
+	if err != nil {
+		b.status = dashboardFailed
+	}
+	b.current = ""
+	b.end = time.Now()
+}
+
+func (b *DashboardBuild) elapsed(now time.Time) string {
+	switch {
+	case b.start.IsZero():
+		return "-"
+	case b.end.IsZero():
+		return now.Sub(b.start).Round(time.Second).String()
+	default:
+		return b.end.Sub(b.start).Round(time.Second).String()
+	}
+}
+
+func (b *DashboardBuild) lastLine(now time.Time) string {
+	if b.progress != nil {
+		return b.progress.Format(now)
+	}
+	for i := len(b.lines) - 1; i >= 0; i-- {
+		if line := strings.TrimSpace(b.lines[i]); line != "" {
+			return line
+		}
+	}
+	return ""
+}
+
+// Ask shows the output of the build with query, and returns the next line
+// entered. The questions of the builds are asked one at a time.
+func (b *DashboardBuild) Ask(query string) (string, error) {
+	d := b.d
+	d.askL.Lock()
+	defer d.askL.Unlock()
+
+	q := &dashboardQuestion{build: b, query: LogSecretFilter.Scrub(query), answer: make(chan string, 1)}
+	d.l.Lock()
+	if d.inputCh == nil || d.stopped {
+		d.l.Unlock()
+		return "", errors.New("the dashboard doesn't read the input, it can't ask")
+	}
+	log.Printf("ui: %s: ask: %s", b.name, q.query)
+	focus := d.focus
+	d.question = q
+	for i := range d.builds {
+		if d.builds[i] == b {
+			d.focus = i + 1
+		}
+	}
+	d.l.Unlock()
+	d.draw()
+
+	select {
+	case answer := <-q.answer:
+		d.l.Lock()
+		d.focus = focus
+		d.l.Unlock()
+		return answer, nil
+	case <-d.stopCh:
+		d.l.Lock()
+		d.question = nil
+		d.l.Unlock()
+		return "", errors.New("the dashboard was stopped before the question was answered")
+	}
+}
+
+func (b *DashboardBuild) Say(message string) {
+	b.targetMessage(b.name, "say", message)
+}
+
+func (b *DashboardBuild) Message(message string) {
+	b.targetMessage(b.name, "message", message)
+}
+
+func (b *DashboardBuild) Error(message string) {
+	b.targetMessage(b.name, "error", message)
+}
+
+func (b *DashboardBuild) targetMessage(target, level, message string) {
+	message = LogSecretFilter.Scrub(message)
+	log.Printf("ui: %s: %s", target, message)
+
+	prefix := ""
+	if target != b.name {
+		// Like the messages of a post-processor, "build (type)"
+		prefix = target + ": "
+	}
+	if level == "error" {
+		prefix += "Error: "
+	}
+
+	b.d.l.Lock()
+	defer b.d.l.Unlock()
+	for _, line := range strings.Split(strings.TrimRight(message, "\n"), "\n") {
+		b.lines = append(b.lines, prefix+strings.TrimRight(line, "\r"))
+	}
+}
+
+func (b *DashboardBuild) Machine(category string, args ...string) {
+	log.Printf("machine readable: %s %#v", category, args)
+	if i := strings.Index(category, ","); i > -1 {
+		category = category[i+1:]
+	}
+	if len(args) == 0 {
+		return
+	}
+
+	b.d.l.Lock()
+	defer b.d.l.Unlock()
+	switch category {
+	case StepStartMachineType:
+		b.current = args[0]
+	case ProvisionerStartMachineType:
+		b.current = "provisioner " + args[0]
+	}
+}
+
+// TrackProgress shows the progress of a transfer of the build as its last
+// line.
+func (b *DashboardBuild) TrackProgress(src string, currentSize, totalSize int64, stream io.ReadCloser) io.ReadCloser {
+	return b.targetTrackProgress(b.name, src, currentSize, totalSize, stream)
+}
+
+func (b *DashboardBuild) targetTrackProgress(target, src string, currentSize, totalSize int64, stream io.ReadCloser) io.ReadCloser {
+	if target != b.name {
+		src = target + ": " + src
+	}
+	p := NewTransferProgress(src, currentSize, totalSize)
+
+	b.d.l.Lock()
+	b.progress = p
+	b.d.l.Unlock()
+
+	// The progress is drawn with the dashboard, it's only cleared here
+	return reportProgress(stream, p, time.Hour, func(p *TransferProgress, done bool) {
+		if !done {
+			return
+		}
+		b.d.l.Lock()
+		defer b.d.l.Unlock()
+		if b.progress == p {
+			b.progress = nil
+		}
+	})
+}
@@ -0,0 +1,137 @@
+package packer
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDashboard(t *testing.T) {
+	d := &Dashboard{Writer: new(bytes.Buffer)}
+	ubuntu := d.Build("amazon-ebs.ubuntu")
+	centos := d.Build("amazon-ebs.centos")
+	d.Build("amazon-ebs.debian")
+
+	ubuntu.Start()
+	ui := &TargetedUI{Target: "amazon-ebs.ubuntu", Ui: ubuntu}
+	ui.Machine(StepStartMachineType, "StepRunSourceInstance")
+	ui.Say("Launching a source AWS instance...")
+	ui.Message("Instance ID: i-1234\n")
+	centos.Start()
+	(&TargetedUI{Target: "amazon-ebs.centos (manifest)", Ui: centos}).Error("no space left")
+	centos.Done(errors.New("failed"))
+
+	now := ubuntu.start.Add(90 * time.Second)
+	lines := d.render(100, 24, now)
+	expected := []string{
+		"3 builds: 1 running, 0 done, 1 failed. Enter the number of a build to show its output.",
+		"  #  BUILD              STATUS       TIME  STEP                   OUTPUT",
+		"  1  amazon-ebs.ubuntu  running     1m30s  StepRunSourceInstance  Instance ID: i-1234",
+		"  2  amazon-ebs.centos  failed         0s                         amazon-ebs.centos (manifest): Err…",
+		"  3  amazon-ebs.debian  waiting         -                         ",
+	}
+	if !reflect.DeepEqual(lines, expected) {
+		t.Fatalf("bad: %s", strings.Join(lines, "\n"))
+	}
+
+	d.selectBuild("1")
+	lines = d.render(100, 24, now)
+	expected = []string{
+		"1 amazon-ebs.ubuntu: running after 1m30s, StepRunSourceInstance. Enter an empty line to go back to the builds.",
+		"Launching a source AWS instance...",
+		"Instance ID: i-1234",
+	}
+	expected[0] = truncate(expected[0], 100)
+	if !reflect.DeepEqual(lines, expected) {
+		t.Fatalf("bad: %s", strings.Join(lines, "\n"))
+	}
+
+	d.selectBuild("")
+	if lines := d.render(100, 4, now); len(lines) != 3 || lines[2] != "... and 3 more builds" {
+		t.Fatalf("bad: %s", strings.Join(lines, "\n"))
+	}
+}
+
+func TestDashboard_ask(t *testing.T) {
+	d := &Dashboard{Writer: new(bytes.Buffer)}
+	d.Build("qemu.ubuntu")
+	b := d.Build("qemu.centos")
+
+	if _, err := b.Ask("Continue?"); err == nil {
+		t.Fatal("should not ask without input")
+	}
+
+	r, w := io.Pipe()
+	defer w.Close()
+	d.Start(r, time.Hour)
+
+	answerCh := make(chan string)
+	go func() {
+		answer, err := b.Ask("Continue? [y/n]")
+		if err != nil {
+			t.Errorf("err: %s", err)
+		}
+		answerCh <- answer
+	}()
+
+	// Wait for the question to be shown
+	for {
+		d.l.Lock()
+		asking := d.question != nil
+		d.l.Unlock()
+		if asking {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	d.l.Lock()
+	lines := d.render(100, 24, time.Now())
+	d.l.Unlock()
+	expected := []string{
+		"2 qemu.centos: waiting after -. Enter the answer to its question.",
+		"Continue? [y/n]",
+	}
+	if !reflect.DeepEqual(lines, expected) {
+		t.Fatalf("bad: %s", strings.Join(lines, "\n"))
+	}
+
+	if _, err := io.WriteString(w, "y\n"); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if answer := <-answerCh; answer != "y" {
+		t.Fatalf("bad: %q", answer)
+	}
+	d.l.Lock()
+	focus := d.focus
+	d.l.Unlock()
+	if focus != 0 {
+		t.Fatalf("the table should be shown again: %d", focus)
+	}
+
+	d.Stop()
+	if _, err := b.Ask("Continue?"); err == nil {
+		t.Fatal("should not ask once stopped")
+	}
+}
+
+func TestDashboard_progress(t *testing.T) {
+	d := &Dashboard{Writer: new(bytes.Buffer)}
+	b := d.Build("qemu.ubuntu")
+
+	stream := b.TrackProgress("ubuntu.iso", 0, 4, ioutil.NopCloser(strings.NewReader("abc")))
+	if _, err := stream.Read(make([]byte, 2)); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if line := b.lastLine(time.Now()); !strings.HasPrefix(line, "ubuntu.iso: 2 B/4 B (50%), ") {
+		t.Fatalf("bad: %q", line)
+	}
+	stream.Close()
+	if line := b.lastLine(time.Now()); line != "" {
+		t.Fatalf("bad: %q", line)
+	}
+}
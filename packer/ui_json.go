@@ -0,0 +1,240 @@
+package packer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// JSONEvent is an event of a JSONEventsUi, written as a line of JSON.
+type JSONEvent struct {
+	Time time.Time `json:"time"`
+	// Type is "ui" for the messages of the Ui, or the type of the
+	// machine-readable message, like "step-start" or "artifact".
+	Type string `json:"type"`
+	// Build is the build the event comes from, like
+	// "virtualbox-iso.ubuntu", empty for the events of Packer itself.
+	Build string `json:"build,omitempty"`
+
+	// Level is "say", "message" or "error" for the messages of the Ui.
+	Level   string `json:"level,omitempty"`
+	Message string `json:"message,omitempty"`
+
+	// Step, Provisioner and Seconds are set for the start and the end of the
+	// steps and provisioners.
+	Step        string   `json:"step,omitempty"`
+	Provisioner string   `json:"provisioner,omitempty"`
+	Seconds     *float64 `json:"seconds,omitempty"`
+
+	Artifact *JSONArtifact `json:"artifact,omitempty"`
+
+	Progress *JSONProgress `json:"progress,omitempty"`
+
+	// Args are the arguments of the other machine-readable messages.
+	Args []string `json:"args,omitempty"`
+}
+
+// JSONArtifact is an artifact produced by a build.
+type JSONArtifact struct {
+	Index     int      `json:"index"`
+	BuilderId string   `json:"builder_id"`
+	Id        string   `json:"id"`
+	String    string   `json:"string"`
+	Files     []string `json:"files"`
+}
+
+// JSONProgress is the progress of a transfer of a build, like a download or
+// an upload.
+type JSONProgress struct {
+	Src            string `json:"src"`
+	Bytes          int64  `json:"bytes"`
+	TotalBytes     int64  `json:"total_bytes,omitempty"`
+	BytesPerSecond int64  `json:"bytes_per_second"`
+	// SecondsLeft is how long the rest of the transfer should take, nil when
+	// it can't be told.
+	SecondsLeft *float64 `json:"seconds_left,omitempty"`
+	Done        bool     `json:"done,omitempty"`
+}
+
+// JSONEventsUi is a UI that writes a line of JSON to Writer for each message
+// and machine-readable message, so that the progress of builds can be parsed.
+// The target of a TargetedUI wrapping it is the build of the events instead of
+// a prefix of the messages.
+type JSONEventsUi struct {
+	Writer io.Writer
+
+	l sync.Mutex
+	// artifacts are the artifacts being reported by "artifact" messages,
+	// by build and index, until their "end" message.
+	artifacts map[string]*JSONArtifact
+}
+
+var _ Ui = new(JSONEventsUi)
+
+func (u *JSONEventsUi) Ask(query string) (string, error) {
+	return "", errors.New("JSON events UI can't ask")
+}
+
+func (u *JSONEventsUi) Say(message string) {
+	u.targetMessage("", "say", message)
+}
+
+func (u *JSONEventsUi) Message(message string) {
+	u.targetMessage("", "message", message)
+}
+
+func (u *JSONEventsUi) Error(message string) {
+	u.targetMessage("", "error", message)
+}
+
+func (u *JSONEventsUi) targetMessage(target, level, message string) {
+	u.write(&JSONEvent{
+		Type:    "ui",
+		Build:   target,
+		Level:   level,
+		Message: LogSecretFilter.Scrub(message),
+	})
+}
+
+func (u *JSONEventsUi) Machine(category string, args ...string) {
+	target := ""
+	if i := strings.Index(category, ","); i > -1 {
+		target = category[:i]
+		category = category[i+1:]
+	}
+	for i := range args {
+		args[i] = LogSecretFilter.Scrub(args[i])
+	}
+
+	event := &JSONEvent{Type: category, Build: target}
+	switch {
+	case category == StepStartMachineType && len(args) == 1:
+		event.Step = args[0]
+	case category == StepEndMachineType && len(args) == 2:
+		event.Step = args[0]
+		event.Seconds = parseSeconds(args[1])
+	case category == ProvisionerStartMachineType && len(args) == 1:
+		event.Provisioner = args[0]
+	case category == ProvisionerEndMachineType && len(args) == 2:
+		event.Provisioner = args[0]
+		event.Seconds = parseSeconds(args[1])
+	case category == "error" && len(args) == 1:
+		event.Message = args[0]
+	case category == "artifact":
+		event.Artifact = u.artifact(target, args)
+		if event.Artifact == nil {
+			// The artifact isn't complete yet
+			return
+		}
+	default:
+		event.Args = args
+	}
+	u.write(event)
+}
+
+// artifact records an "artifact" message of a build, and returns the
+// artifact once its last message is recorded.
+func (u *JSONEventsUi) artifact(target string, args []string) *JSONArtifact {
+	if len(args) < 2 {
+		return nil
+	}
+	index, err := strconv.Atoi(args[0])
+	if err != nil {
+		return nil
+	}
+	key := target + "," + args[0]
+
+	u.l.Lock()
+	defer u.l.Unlock()
+	if u.artifacts == nil {
+		u.artifacts = map[string]*JSONArtifact{}
+	}
+	artifact, ok := u.artifacts[key]
+	if !ok {
+		artifact = &JSONArtifact{Index: index, Files: []string{}}
+		u.artifacts[key] = artifact
+	}
+	switch args[1] {
+	case "builder-id":
+		artifact.BuilderId = strings.Join(args[2:], ",")
+	case "id":
+		artifact.Id = strings.Join(args[2:], ",")
+	case "string":
+		artifact.String = strings.Join(args[2:], ",")
+	case "file":
+		if len(args) == 4 {
+			artifact.Files = append(artifact.Files, args[3])
+		}
+	case "end":
+		delete(u.artifacts, key)
+		return artifact
+	}
+	return nil
+}
+
+func parseSeconds(duration string) *float64 {
+	d, err := time.ParseDuration(duration)
+	if err != nil {
+		return nil
+	}
+	seconds := d.Seconds()
+	return &seconds
+}
+
+func (u *JSONEventsUi) write(event *JSONEvent) {
+	event.Time = time.Now().UTC()
+	b, err := json.Marshal(event)
+	if err != nil {
+		log.Printf("[ERR] Failed to encode event: %s", err)
+		return
+	}
+
+	u.l.Lock()
+	defer u.l.Unlock()
+	_, err = fmt.Fprintf(u.Writer, "%s\n", b)
+	if err != nil {
+		if err == syscall.EPIPE || strings.Contains(err.Error(), "broken pipe") {
+			// Ignore epipe errors because that just means that the file
+			// is probably closed or going to /dev/null or something.
+		} else {
+			panic(err)
+		}
+	}
+	log.Printf("event: %s", b)
+}
+
+func (u *JSONEventsUi) TrackProgress(src string, currentSize, totalSize int64, stream io.ReadCloser) (body io.ReadCloser) {
+	return u.targetTrackProgress("", src, currentSize, totalSize, stream)
+}
+
+// targetTrackProgress writes a "progress" event every progressReportInterval
+// while stream is read, and a last one once it's closed.
+func (u *JSONEventsUi) targetTrackProgress(target, src string, currentSize, totalSize int64, stream io.ReadCloser) io.ReadCloser {
+	p := NewTransferProgress(src, currentSize, totalSize)
+	return reportProgress(stream, p, progressReportInterval, func(p *TransferProgress, done bool) {
+		now := time.Now()
+		progress := &JSONProgress{
+			Src:            p.Src,
+			Bytes:          p.Current(),
+			TotalBytes:     p.Total,
+			BytesPerSecond: int64(p.Rate(now)),
+			Done:           done,
+		}
+		if left, ok := p.Remaining(now); ok {
+			seconds := left.Seconds()
+			progress.SecondsLeft = &seconds
+		}
+		u.write(&JSONEvent{
+			Type:     "progress",
+			Build:    target,
+			Progress: progress,
+		})
+	})
+}
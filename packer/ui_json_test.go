@@ -0,0 +1,126 @@
+package packer
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func readJSONEvents(t *testing.T, buf *bytes.Buffer) []JSONEvent {
+	var events []JSONEvent
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var event JSONEvent
+		if err := json.Unmarshal([]byte(line), &event); err != nil {
+			t.Fatalf("bad line %q: %s", line, err)
+		}
+		if event.Time.IsZero() {
+			t.Fatalf("the event should have a time: %q", line)
+		}
+		events = append(events, event)
+	}
+	buf.Reset()
+	return events
+}
+
+func TestJSONEventsUi(t *testing.T) {
+	buf := new(bytes.Buffer)
+	ui := &JSONEventsUi{Writer: buf}
+	targeted := &TargetedUI{Target: "null.basic", Ui: ui}
+
+	ui.Say("Starting builds")
+	targeted.Message("line 1\nline 2")
+	targeted.Error("failed")
+	targeted.Machine(StepStartMachineType, "StepCreateVM")
+	targeted.Machine(StepEndMachineType, "StepCreateVM", "1.5s")
+	targeted.Machine(ProvisionerEndMachineType, "shell", "2s")
+	targeted.Machine("error-count", "1")
+
+	events := readJSONEvents(t, buf)
+	if len(events) != 7 {
+		t.Fatalf("bad: %#v", events)
+	}
+	if e := events[0]; e.Type != "ui" || e.Level != "say" || e.Build != "" || e.Message != "Starting builds" {
+		t.Fatalf("bad: %#v", e)
+	}
+	if e := events[1]; e.Level != "message" || e.Build != "null.basic" || e.Message != "line 1\nline 2" {
+		t.Fatalf("the message should not be prefixed with the target: %#v", e)
+	}
+	if e := events[2]; e.Level != "error" || e.Message != "failed" {
+		t.Fatalf("bad: %#v", e)
+	}
+	if e := events[3]; e.Type != StepStartMachineType || e.Build != "null.basic" || e.Step != "StepCreateVM" {
+		t.Fatalf("bad: %#v", e)
+	}
+	if e := events[4]; e.Step != "StepCreateVM" || e.Seconds == nil || *e.Seconds != 1.5 {
+		t.Fatalf("bad: %#v", e)
+	}
+	if e := events[5]; e.Provisioner != "shell" || e.Seconds == nil || *e.Seconds != 2 {
+		t.Fatalf("bad: %#v", e)
+	}
+	if e := events[6]; e.Type != "error-count" || len(e.Args) != 1 || e.Args[0] != "1" {
+		t.Fatalf("bad: %#v", e)
+	}
+}
+
+func TestJSONEventsUi_artifact(t *testing.T) {
+	buf := new(bytes.Buffer)
+	ui := &TargetedUI{Target: "null.basic", Ui: &JSONEventsUi{Writer: buf}}
+
+	ui.Machine("artifact", "0", "builder-id", "packer.null")
+	ui.Machine("artifact", "0", "id", "Null")
+	ui.Machine("artifact", "0", "string", "Did not export anything, a, b")
+	ui.Machine("artifact", "0", "files-count", "1")
+	ui.Machine("artifact", "0", "file", "0", "output/disk.img")
+	if buf.Len() != 0 {
+		t.Fatalf("the artifact should be written once complete: %s", buf)
+	}
+	ui.Machine("artifact", "0", "end")
+
+	events := readJSONEvents(t, buf)
+	if len(events) != 1 || events[0].Artifact == nil {
+		t.Fatalf("bad: %#v", events)
+	}
+	artifact := events[0].Artifact
+	if events[0].Build != "null.basic" || artifact.BuilderId != "packer.null" || artifact.Id != "Null" {
+		t.Fatalf("bad: %#v", artifact)
+	}
+	if artifact.String != "Did not export anything, a, b" {
+		t.Fatalf("bad: %q", artifact.String)
+	}
+	if len(artifact.Files) != 1 || artifact.Files[0] != "output/disk.img" {
+		t.Fatalf("bad: %#v", artifact.Files)
+	}
+}
+
+func TestJSONEventsUi_progress(t *testing.T) {
+	buf := new(bytes.Buffer)
+	ui := &TargetedUI{Target: "qemu.ubuntu", Ui: &JSONEventsUi{Writer: buf}}
+
+	stream := ui.TrackProgress("ubuntu.iso", 0, 4, ioutil.NopCloser(strings.NewReader("abcd")))
+	if _, err := ioutil.ReadAll(stream); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	stream.Close()
+
+	events := readJSONEvents(t, buf)
+	if len(events) != 2 {
+		t.Fatalf("bad: %#v", events)
+	}
+	for _, e := range events {
+		if e.Type != "progress" || e.Build != "qemu.ubuntu" || e.Progress == nil || e.Progress.Src != "ubuntu.iso" {
+			t.Fatalf("bad: %#v", e)
+		}
+	}
+	if p := events[1].Progress; !p.Done || p.Bytes != 4 || p.TotalBytes != 4 {
+		t.Fatalf("bad: %#v", p)
+	}
+}
+
+func TestJSONEventsUi_ImplUi(t *testing.T) {
+	var raw interface{} = &JSONEventsUi{}
+	if _, ok := raw.(Ui); !ok {
+		t.Fatalf("JSONEventsUi must implement Ui")
+	}
+}
@@ -2,6 +2,7 @@ package packer
 
 import (
 	"bytes"
+	"io/ioutil"
 	"os"
 	"strings"
 	"testing"
@@ -255,6 +256,29 @@ func TestMachineReadableUi_ImplUi(t *testing.T) {
 	}
 }
 
+func TestMachineReadableUi_progress(t *testing.T) {
+	buf := new(bytes.Buffer)
+	ui := &TargetedUI{Target: "qemu.ubuntu", Ui: &MachineReadableUi{Writer: buf}}
+
+	stream := ui.TrackProgress("ubuntu.iso", 0, 4, ioutil.NopCloser(strings.NewReader("abcd")))
+	if _, err := ioutil.ReadAll(stream); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	stream.Close()
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("bad: %s", buf)
+	}
+	if !strings.HasPrefix(strings.SplitN(lines[0], ",", 2)[1], "qemu.ubuntu,progress,ubuntu.iso,") {
+		t.Fatalf("bad: %s", lines[0])
+	}
+	fields := strings.Split(lines[1], ",")
+	if len(fields) != 9 || fields[4] != "4" || fields[5] != "4" || fields[8] != "done" {
+		t.Fatalf("bad: %s", lines[1])
+	}
+}
+
 func TestMachineReadableUi(t *testing.T) {
 	var data, expected string
 
@@ -295,4 +319,14 @@ func TestMachineReadableUi(t *testing.T) {
 	if data != expected {
 		t.Fatalf("bad: %#v", data)
 	}
+
+	// Secrets
+	buf.Reset()
+	LogSecretFilter.Set("machine-readable-secret")
+	ui.Machine("foo", "the machine-readable-secret")
+	data = strings.SplitN(buf.String(), ",", 2)[1]
+	expected = ",foo,the <sensitive>\n"
+	if data != expected {
+		t.Fatalf("bad: %#v", data)
+	}
 }
@@ -0,0 +1,115 @@
+package amazonimport
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/url"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/hashicorp/packer/packer"
+)
+
+const (
+	bootModeLegacyBIOS = "legacy-bios"
+	bootModeUEFI       = "uefi"
+
+	// defaultRoleName is the service role VM Import uses when role_name
+	// isn't set.
+	defaultRoleName = "vmimport"
+)
+
+// withBootMode sets the boot mode of the image to import. The BootMode
+// parameter of ImportImage is more recent than the AWS SDK, so it is added
+// to the query once the request is built.
+func withBootMode(mode string) request.Option {
+	return func(r *request.Request) {
+		r.Handlers.Build.PushBack(func(r *request.Request) {
+			if r.Error != nil || r.Body == nil {
+				return
+			}
+			body, err := ioutil.ReadAll(r.Body)
+			if err != nil {
+				r.Error = err
+				return
+			}
+			r.SetBufferBody(append(body, "&BootMode="+url.QueryEscape(mode)...))
+		})
+	}
+}
+
+// importProgress displays the progress of an import task each time it
+// changes while waiting for it.
+func importProgress(ui packer.Ui) request.WaiterOption {
+	last := ""
+	return request.WithWaiterRequestOptions(func(r *request.Request) {
+		r.Handlers.Complete.PushBack(func(r *request.Request) {
+			out, ok := r.Data.(*ec2.DescribeImportImageTasksOutput)
+			if r.Error != nil || !ok || len(out.ImportImageTasks) == 0 {
+				return
+			}
+			task := out.ImportImageTasks[0]
+			if aws.StringValue(task.Status) != "active" {
+				return
+			}
+			status := aws.StringValue(task.StatusMessage)
+			if progress := aws.StringValue(task.Progress); progress != "" {
+				status = fmt.Sprintf("%s%%, %s", progress, status)
+			}
+			if status != last {
+				ui.Message(fmt.Sprintf("Import task %s: %s", aws.StringValue(task.ImportTaskId), status))
+				last = status
+			}
+		})
+	})
+}
+
+// roleGetter is the part of the IAM API reading roles.
+type roleGetter interface {
+	GetRole(*iam.GetRoleInput) (*iam.GetRoleOutput, error)
+}
+
+// checkImportRole verifies that the service role of VM Import exists and
+// can be assumed by it, as the import task only fails once the image is
+// uploaded otherwise. The role is not verified when the credentials aren't
+// allowed to read it.
+func checkImportRole(ui packer.Ui, conn roleGetter, name string) error {
+	resp, err := conn.GetRole(&iam.GetRoleInput{RoleName: aws.String(name)})
+	if err != nil {
+		if awsErr, ok := err.(awserr.Error); ok && awsErr.Code() == iam.ErrCodeNoSuchEntityException {
+			return fmt.Errorf("The import role %s doesn't exist. Create it as documented in "+
+				"https://docs.aws.amazon.com/vm-import/latest/userguide/vmie_prereqs.html#vmimport-role, "+
+				"or set role_name", name)
+		}
+		log.Printf("Unable to read the import role %s: %s", name, err)
+		ui.Message(fmt.Sprintf("Unable to verify the import role %s, continuing", name))
+		return nil
+	}
+
+	// The policy document is URL encoded
+	policy, err := url.QueryUnescape(aws.StringValue(resp.Role.AssumeRolePolicyDocument))
+	if err != nil {
+		policy = aws.StringValue(resp.Role.AssumeRolePolicyDocument)
+	}
+	if !strings.Contains(policy, "vmie.amazonaws.com") {
+		return fmt.Errorf("The import role %s can't be assumed by VM Import, its trust policy "+
+			"must allow the vmie.amazonaws.com service to assume it", name)
+	}
+	return nil
+}
+
+// checkENA verifies that the import found the ENA driver in the image,
+// which the instance types of the Nitro system need.
+func checkENA(image *ec2.Image) error {
+	if !aws.BoolValue(image.EnaSupport) {
+		return fmt.Errorf("The import didn't detect the ENA driver in %s, instances of the "+
+			"Nitro system instance types won't have network access. Install the ENA driver in "+
+			"the image, or unset ena_support", aws.StringValue(image.ImageId))
+	}
+	return nil
+}
@@ -11,7 +11,9 @@ import (
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/iam"
 	"github.com/aws/aws-sdk-go/service/s3"
 	"github.com/aws/aws-sdk-go/service/s3/s3manager"
 	"github.com/hashicorp/hcl/v2/hcldec"
@@ -46,6 +48,13 @@ type Config struct {
 	LicenseType     string            `mapstructure:"license_type"`
 	RoleName        string            `mapstructure:"role_name"`
 	Format          string            `mapstructure:"format"`
+	// The boot mode of the imported image: legacy-bios or uefi. Defaults to
+	// uefi for the generation 2 virtual machines of the Hyper-V builders,
+	// and to the boot mode detected by the import otherwise.
+	BootMode string `mapstructure:"boot_mode"`
+	// Fail when the import doesn't detect the ENA driver in the image, which
+	// the Nitro system instance types need.
+	EnaSupport bool `mapstructure:"ena_support"`
 
 	ctx interpolate.Context
 }
@@ -111,6 +120,13 @@ func (p *PostProcessor) Configure(raws ...interface{}) error {
 			errs, fmt.Errorf("invalid format '%s'. Only 'ova', 'raw', 'vhd', 'vhdx', or 'vmdk' are allowed", p.config.Format))
 	}
 
+	switch p.config.BootMode {
+	case "", bootModeLegacyBIOS, bootModeUEFI:
+	default:
+		errs = packer.MultiErrorAppend(
+			errs, fmt.Errorf("invalid boot_mode '%s'. Only '%s' and '%s' are allowed", p.config.BootMode, bootModeLegacyBIOS, bootModeUEFI))
+	}
+
 	if p.config.S3Encryption != "" && p.config.S3Encryption != "AES256" && p.config.S3Encryption != "aws:kms" {
 		errs = packer.MultiErrorAppend(
 			errs, fmt.Errorf("invalid s3 encryption format '%s'. Only 'AES256' and 'aws:kms' are allowed", p.config.S3Encryption))
@@ -168,6 +184,21 @@ func (p *PostProcessor) PostProcess(ctx context.Context, ui packer.Ui, artifact
 		return nil, false, false, fmt.Errorf("No %s image file found in artifact from builder", p.config.Format)
 	}
 
+	bootMode := p.config.BootMode
+	if generation, _ := artifact.State("generation").(uint); bootMode == "" && generation == 2 {
+		ui.Message("Importing the generation 2 virtual machine disk in uefi boot mode")
+		bootMode = bootModeUEFI
+	}
+
+	// Fail before uploading the image if VM Import can't use its role
+	roleName := p.config.RoleName
+	if roleName == "" {
+		roleName = defaultRoleName
+	}
+	if err := checkImportRole(ui, iam.New(session), roleName); err != nil {
+		return nil, false, false, err
+	}
+
 	if p.config.S3Encryption == "AES256" && p.config.S3EncryptionKey != "" {
 		ui.Message(fmt.Sprintf("Ignoring s3_encryption_key because s3_encryption is set to '%s'", p.config.S3Encryption))
 	}
@@ -237,12 +268,18 @@ func (p *PostProcessor) PostProcess(ctx context.Context, ui packer.Ui, artifact
 		params.LicenseType = &p.config.LicenseType
 	}
 
+	var importOpts []request.Option
+	if bootMode != "" {
+		ui.Message(fmt.Sprintf("Setting boot mode to '%s'", bootMode))
+		importOpts = append(importOpts, withBootMode(bootMode))
+	}
+
 	var import_start *ec2.ImportImageOutput
 	err = retry.Config{
 		Tries:      11,
 		RetryDelay: (&retry.Backoff{InitialBackoff: 200 * time.Millisecond, MaxBackoff: 30 * time.Second, Multiplier: 2}).Linear,
 	}.Run(ctx, func(ctx context.Context) error {
-		import_start, err = ec2conn.ImportImage(params)
+		import_start, err = ec2conn.ImportImageWithContext(ctx, params, importOpts...)
 		return err
 	})
 
@@ -254,7 +291,7 @@ func (p *PostProcessor) PostProcess(ctx context.Context, ui packer.Ui, artifact
 
 	// Wait for import process to complete, this takes a while
 	ui.Message(fmt.Sprintf("Waiting for task %s to complete (may take a while)", *import_start.ImportTaskId))
-	err = p.config.PollingConfig.WaitUntilImageImported(aws.BackgroundContext(), ec2conn, *import_start.ImportTaskId)
+	err = p.config.PollingConfig.WaitUntilImageImported(ctx, ec2conn, *import_start.ImportTaskId, importProgress(ui))
 	if err != nil {
 
 		// Retrieve the status message
@@ -333,6 +370,22 @@ func (p *PostProcessor) PostProcess(ctx context.Context, ui packer.Ui, artifact
 		createdami = *resp.ImageId
 	}
 
+	if p.config.EnaSupport {
+		imageResp, err := ec2conn.DescribeImages(&ec2.DescribeImagesInput{
+			ImageIds: []*string{&createdami},
+		})
+		if err != nil {
+			return nil, false, false, fmt.Errorf("Failed to retrieve details for AMI %s: %s", createdami, err)
+		}
+		if len(imageResp.Images) == 0 {
+			return nil, false, false, fmt.Errorf("AMI %s has no images", createdami)
+		}
+		if err := checkENA(imageResp.Images[0]); err != nil {
+			return nil, false, false, err
+		}
+		ui.Message(fmt.Sprintf("AMI %s supports ENA", createdami))
+	}
+
 	// If we have tags, then apply them now to both the AMI and snaps
 	// created by the import
 	if len(p.config.Tags) > 0 {
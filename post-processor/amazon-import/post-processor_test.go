@@ -0,0 +1,122 @@
+package amazonimport
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/hashicorp/packer/packer"
+)
+
+func testConfig() map[string]interface{} {
+	return map[string]interface{}{
+		"access_key":     "foo",
+		"secret_key":     "bar",
+		"region":         "us-east-1",
+		"s3_bucket_name": "images",
+	}
+}
+
+func TestPostProcessor_ConfigureBootMode(t *testing.T) {
+	for mode, valid := range map[string]bool{
+		"":            true,
+		"uefi":        true,
+		"legacy-bios": true,
+		"bios":        false,
+	} {
+		raw := testConfig()
+		raw["boot_mode"] = mode
+		p := new(PostProcessor)
+		err := p.Configure(raw)
+		if valid && err != nil {
+			t.Fatalf("boot_mode %q should be valid: %s", mode, err)
+		}
+		if !valid && err == nil {
+			t.Fatalf("boot_mode %q should be invalid", mode)
+		}
+	}
+}
+
+func TestWithBootMode(t *testing.T) {
+	var query url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		query, _ = url.ParseQuery(string(body))
+		fmt.Fprint(w, `<ImportImageResponse><importTaskId>import-ami-1234</importTaskId></ImportImageResponse>`)
+	}))
+	defer server.Close()
+
+	sess := session.Must(session.NewSession(&aws.Config{
+		Credentials: credentials.NewStaticCredentials("foo", "bar", ""),
+		Endpoint:    aws.String(server.URL),
+		Region:      aws.String("us-east-1"),
+	}))
+	out, err := ec2.New(sess).ImportImageWithContext(aws.BackgroundContext(), &ec2.ImportImageInput{
+		LicenseType: aws.String("BYOL"),
+	}, withBootMode(bootModeUEFI))
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if aws.StringValue(out.ImportTaskId) != "import-ami-1234" {
+		t.Fatalf("bad output: %s", out)
+	}
+	if query.Get("Action") != "ImportImage" || query.Get("LicenseType") != "BYOL" || query.Get("BootMode") != "uefi" {
+		t.Fatalf("bad query: %v", query)
+	}
+}
+
+type testRoleGetter struct {
+	policy string
+	err    error
+}
+
+func (g *testRoleGetter) GetRole(*iam.GetRoleInput) (*iam.GetRoleOutput, error) {
+	if g.err != nil {
+		return nil, g.err
+	}
+	return &iam.GetRoleOutput{Role: &iam.Role{
+		AssumeRolePolicyDocument: aws.String(url.QueryEscape(g.policy)),
+	}}, nil
+}
+
+func TestCheckImportRole(t *testing.T) {
+	ui := packer.TestUi(t)
+	trust := `{"Statement":[{"Effect":"Allow","Principal":{"Service":"vmie.amazonaws.com"},"Action":"sts:AssumeRole"}]}`
+	if err := checkImportRole(ui, &testRoleGetter{policy: trust}, "vmimport"); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	err := checkImportRole(ui, &testRoleGetter{policy: strings.Replace(trust, "vmie", "ec2", 1)}, "vmimport")
+	if err == nil || !strings.Contains(err.Error(), "trust policy") {
+		t.Fatalf("a role VM Import can't assume should be refused: %v", err)
+	}
+
+	err = checkImportRole(ui, &testRoleGetter{err: awserr.New(iam.ErrCodeNoSuchEntityException, "not found", nil)}, "custom")
+	if err == nil || !strings.Contains(err.Error(), "custom doesn't exist") {
+		t.Fatalf("a missing role should be refused: %v", err)
+	}
+
+	err = checkImportRole(ui, &testRoleGetter{err: awserr.New("AccessDenied", "denied", nil)}, "vmimport")
+	if err != nil {
+		t.Fatalf("the role should not be verified without access to it: %s", err)
+	}
+}
+
+func TestCheckENA(t *testing.T) {
+	if err := checkENA(&ec2.Image{ImageId: aws.String("ami-1"), EnaSupport: aws.Bool(true)}); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if err := checkENA(&ec2.Image{ImageId: aws.String("ami-1")}); err == nil {
+		t.Fatal("an image without ENA support should be refused")
+	}
+}
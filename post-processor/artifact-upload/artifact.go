@@ -0,0 +1,38 @@
+package artifactupload
+
+import (
+	"fmt"
+	"strings"
+)
+
+const BuilderId = "packer.post-processor.artifact-upload"
+
+// Artifact is the set of files uploaded to an artifact store.
+type Artifact struct {
+	Locations []string
+	StateData map[string]interface{}
+}
+
+func (*Artifact) BuilderId() string {
+	return BuilderId
+}
+
+func (*Artifact) Files() []string {
+	return nil
+}
+
+func (a *Artifact) Id() string {
+	return strings.Join(a.Locations, ",")
+}
+
+func (a *Artifact) String() string {
+	return fmt.Sprintf("Uploaded files: %s", strings.Join(a.Locations, ", "))
+}
+
+func (a *Artifact) State(name string) interface{} {
+	return a.StateData[name]
+}
+
+func (a *Artifact) Destroy() error {
+	return nil
+}
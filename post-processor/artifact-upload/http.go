@@ -0,0 +1,155 @@
+package artifactupload
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/packer/packer"
+)
+
+// httpUploader uploads files to an HTTP server, with a PUT request per file
+// or a multipart/form-data POST request.
+type httpUploader struct {
+	client   *http.Client
+	url      string
+	method   string
+	field    string
+	headers  map[string]string
+	username string
+	password string
+}
+
+func newHTTPUploader(c *Config, target string) *httpUploader {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if c.InsecureSkipTLSVerify {
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	}
+	return &httpUploader{
+		client:   &http.Client{Transport: transport},
+		url:      target,
+		method:   c.HTTPMethod,
+		field:    c.FormField,
+		headers:  c.Headers,
+		username: c.Username,
+		password: c.Password,
+	}
+}
+
+func (u *httpUploader) Upload(ctx context.Context, ui packer.Ui, f *os.File, size int64, sum []byte) (string, error) {
+	name := filepath.Base(f.Name())
+	location := u.url + url.PathEscape(name)
+	ui.Say(fmt.Sprintf("Uploading %s to %s...", f.Name(), location))
+
+	body := ui.TrackProgress(name, 0, size, f)
+	defer body.Close()
+
+	var req *http.Request
+	var err error
+	switch u.method {
+	case http.MethodPut:
+		req, err = http.NewRequestWithContext(ctx, u.method, location, body)
+		if err != nil {
+			return "", err
+		}
+		req.ContentLength = size
+		req.Header.Set("Content-Type", "application/octet-stream")
+	case http.MethodPost:
+		pr, pw := io.Pipe()
+		mw := multipart.NewWriter(pw)
+		go func() {
+			part, err := mw.CreateFormFile(u.field, name)
+			if err == nil {
+				_, err = io.Copy(part, body)
+			}
+			if err == nil {
+				err = mw.Close()
+			}
+			pw.CloseWithError(err)
+		}()
+		req, err = http.NewRequestWithContext(ctx, u.method, u.url, pr)
+		if err != nil {
+			pr.Close()
+			return "", err
+		}
+		req.Header.Set("Content-Type", mw.FormDataContentType())
+	}
+	// The checksum headers let the servers supporting them verify the
+	// upload.
+	req.Header.Set("Digest", "SHA-256="+base64.StdEncoding.EncodeToString(sum))
+	req.Header.Set("X-Checksum-Sha256", hex.EncodeToString(sum))
+	u.prepare(req)
+
+	resp, err := u.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("Error uploading %s: %s", name, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+		return "", fmt.Errorf("Error uploading %s: %s %s: %s\n%s", name, u.method, req.URL, resp.Status,
+			strings.TrimSpace(string(msg)))
+	}
+
+	if u.method == http.MethodPost {
+		// The location of the file is only known when the server tells it
+		if l, err := resp.Location(); err == nil {
+			return l.String(), nil
+		}
+		return u.url, nil
+	}
+	if err := u.verify(ctx, location, size, sum); err != nil {
+		return "", err
+	}
+	return location, nil
+}
+
+// verify checks the size and, when the server returns it, the checksum of
+// the uploaded file.
+func (u *httpUploader) verify(ctx context.Context, location string, size int64, sum []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, location, nil)
+	if err != nil {
+		return err
+	}
+	u.prepare(req)
+	resp, err := u.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("Error verifying %s: %s", location, err)
+	}
+	resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusMethodNotAllowed:
+		log.Printf("%s doesn't support HEAD requests, skipping verification", location)
+		return nil
+	case resp.StatusCode < 200 || resp.StatusCode >= 300:
+		return fmt.Errorf("Error verifying %s: %s", location, resp.Status)
+	}
+
+	if resp.ContentLength >= 0 && resp.ContentLength != size {
+		return fmt.Errorf("%s is %d bytes, expected %d", location, resp.ContentLength, size)
+	}
+	if remote := resp.Header.Get("X-Checksum-Sha256"); remote != "" && !strings.EqualFold(remote, hex.EncodeToString(sum)) {
+		return fmt.Errorf("%s has SHA256 checksum %s, expected %x", location, remote, sum)
+	}
+	return nil
+}
+
+func (u *httpUploader) prepare(req *http.Request) {
+	for k, v := range u.headers {
+		req.Header.Set(k, v)
+	}
+	if u.username != "" {
+		req.SetBasicAuth(u.username, u.password)
+	}
+}
@@ -0,0 +1,276 @@
+//go:generate struct-markdown
+//go:generate mapstructure-to-hcl2 -type Config
+
+package artifactupload
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2/hcldec"
+	"github.com/hashicorp/packer/packer"
+	"github.com/hashicorp/packer/packer-plugin-sdk/common"
+	"github.com/hashicorp/packer/packer-plugin-sdk/template/config"
+	"github.com/hashicorp/packer/packer-plugin-sdk/template/interpolate"
+)
+
+type Config struct {
+	common.PackerConfig `mapstructure:",squash"`
+
+	// The URL the files of the artifact are uploaded under, like
+	// `s3://bucket/images/{{.BuildName}}/` for S3-compatible stores or
+	// `https://artifacts.example.com/images/` for HTTP servers. Each file is
+	// uploaded with its name appended to the URL. This is treated as a
+	// [template engine](/docs/templates/engine), `BuildName` and
+	// `BuilderType` being the name and the type of the build.
+	URL string `mapstructure:"url" required:"true"`
+
+	// The HTTP method uploading the files, `PUT` to upload each file to its
+	// URL or `POST` to post each file to `url` as a multipart/form-data
+	// request. Defaults to `PUT`.
+	HTTPMethod string `mapstructure:"http_method"`
+	// The name of the form field of the files posted with the `POST` method.
+	// Defaults to `file`.
+	FormField string `mapstructure:"form_field"`
+	// Headers to add to the HTTP requests, like an `Authorization` header.
+	Headers map[string]string `mapstructure:"headers"`
+	// The username authenticating the HTTP requests, with basic
+	// authentication.
+	Username string `mapstructure:"username"`
+	// The password of `username`.
+	Password string `mapstructure:"password"`
+	// Skip the verification of the TLS certificate of the HTTP server.
+	// Defaults to `false`.
+	InsecureSkipTLSVerify bool `mapstructure:"insecure_skip_tls_verify"`
+
+	// The endpoint of the S3-compatible store, like
+	// `https://minio.example.com:9000`. Defaults to Amazon S3.
+	S3Endpoint string `mapstructure:"s3_endpoint"`
+	// The region of the bucket. Defaults to `us-east-1`.
+	S3Region string `mapstructure:"s3_region"`
+	// Use path-style URLs to access the bucket, which most S3-compatible
+	// stores require. Defaults to `false`.
+	S3ForcePathStyle bool `mapstructure:"s3_force_path_style"`
+	// The access key of the S3-compatible store. Defaults to the credentials
+	// of the environment, like the `AWS_ACCESS_KEY_ID` environment variable
+	// or the shared credentials file.
+	AccessKey string `mapstructure:"access_key"`
+	// The secret key of `access_key`.
+	SecretKey string `mapstructure:"secret_key"`
+	// The session token of `access_key`, if any.
+	Token string `mapstructure:"token"`
+	// The size of the parts of multipart uploads, in megabytes. Files larger
+	// than a part are uploaded in parts, and an interrupted upload is resumed
+	// by the next build uploading the same file. The part size grows for
+	// files too large to be uploaded in 10000 parts. Defaults to `64`.
+	PartSize int `mapstructure:"part_size"`
+	// The number of parts uploaded at once. Defaults to `4`.
+	UploadConcurrency int `mapstructure:"upload_concurrency"`
+	// Tags to set on the uploaded objects, like the ones matched by the
+	// lifecycle rules of the bucket expiring objects.
+	Tags map[string]string `mapstructure:"tags"`
+	// The number of versions to keep after the upload, the uploaded one
+	// included. The versions are the prefixes next to the prefix of `url`:
+	// when uploading to `s3://bucket/images/1.2.0/`, setting `keep_versions`
+	// to 3 deletes all the prefixes of `s3://bucket/images/` but the three
+	// most recent ones. Defaults to `0`, keeping all the versions.
+	KeepVersions int `mapstructure:"keep_versions"`
+
+	ctx interpolate.Context
+}
+
+// An uploader uploads the files of the artifact.
+type uploader interface {
+	// Upload uploads f of size bytes and SHA256 checksum sum, and returns
+	// its location.
+	Upload(ctx context.Context, ui packer.Ui, f *os.File, size int64, sum []byte) (string, error)
+}
+
+type PostProcessor struct {
+	config Config
+}
+
+func (p *PostProcessor) ConfigSpec() hcldec.ObjectSpec { return p.config.FlatMapstructure().HCL2Spec() }
+
+func (p *PostProcessor) Configure(raws ...interface{}) error {
+	err := config.Decode(&p.config, &config.DecodeOpts{
+		PluginType:         BuilderId,
+		Interpolate:        true,
+		InterpolateContext: &p.config.ctx,
+		InterpolateFilter: &interpolate.RenderFilter{
+			Exclude: []string{"url"},
+		},
+	}, raws...)
+	if err != nil {
+		return err
+	}
+
+	errs := new(packer.MultiError)
+
+	if p.config.HTTPMethod == "" {
+		p.config.HTTPMethod = http.MethodPut
+	}
+	p.config.HTTPMethod = strings.ToUpper(p.config.HTTPMethod)
+	if p.config.FormField == "" {
+		p.config.FormField = "file"
+	}
+	if p.config.S3Region == "" {
+		p.config.S3Region = "us-east-1"
+	}
+	if p.config.PartSize == 0 {
+		p.config.PartSize = 64
+	}
+	if p.config.UploadConcurrency == 0 {
+		p.config.UploadConcurrency = 4
+	}
+
+	if p.config.URL == "" {
+		errs = packer.MultiErrorAppend(errs, fmt.Errorf("url must be set"))
+	} else if err = interpolate.Validate(p.config.URL, &p.config.ctx); err != nil {
+		errs = packer.MultiErrorAppend(
+			errs, fmt.Errorf("Error parsing url template: %s", err))
+	}
+	s3 := strings.HasPrefix(p.config.URL, "s3://")
+	if !s3 && !strings.HasPrefix(p.config.URL, "http://") && !strings.HasPrefix(p.config.URL, "https://") {
+		errs = packer.MultiErrorAppend(errs,
+			fmt.Errorf("url must be an s3://, http:// or https:// URL"))
+	}
+
+	if p.config.HTTPMethod != http.MethodPut && p.config.HTTPMethod != http.MethodPost {
+		errs = packer.MultiErrorAppend(errs,
+			fmt.Errorf("http_method must be PUT or POST"))
+	}
+	if p.config.PartSize < 5 {
+		errs = packer.MultiErrorAppend(errs,
+			fmt.Errorf("part_size must be at least 5 megabytes"))
+	}
+	if p.config.UploadConcurrency < 1 {
+		errs = packer.MultiErrorAppend(errs,
+			fmt.Errorf("upload_concurrency must be positive"))
+	}
+	if p.config.KeepVersions < 0 {
+		errs = packer.MultiErrorAppend(errs,
+			fmt.Errorf("keep_versions must be positive"))
+	}
+	if !s3 && (len(p.config.Tags) > 0 || p.config.KeepVersions > 0) {
+		errs = packer.MultiErrorAppend(errs,
+			fmt.Errorf("tags and keep_versions are only supported with s3:// URLs"))
+	}
+	if p.config.SecretKey != "" && p.config.AccessKey == "" {
+		errs = packer.MultiErrorAppend(errs,
+			fmt.Errorf("secret_key requires access_key"))
+	}
+
+	if len(errs.Errors) > 0 {
+		return errs
+	}
+
+	packer.LogSecretFilter.Set(p.config.Password, p.config.SecretKey, p.config.Token)
+	return nil
+}
+
+func (p *PostProcessor) PostProcess(ctx context.Context, ui packer.Ui, artifact packer.Artifact) (packer.Artifact, bool, bool, error) {
+	var generatedData map[interface{}]interface{}
+	stateData := artifact.State("generated_data")
+	if stateData != nil {
+		// Make sure it's not a nil map so we can assign to it later.
+		generatedData = stateData.(map[interface{}]interface{})
+	}
+	// If stateData has a nil map generatedData will be nil
+	// and we need to make sure it's not
+	if generatedData == nil {
+		generatedData = make(map[interface{}]interface{})
+	}
+	generatedData["BuildName"] = p.config.PackerBuildName
+	generatedData["BuilderType"] = p.config.PackerBuilderType
+	p.config.ctx.Data = generatedData
+
+	target, err := interpolate.Render(p.config.URL, &p.config.ctx)
+	if err != nil {
+		return nil, false, false, fmt.Errorf("Error interpolating url: %s", err)
+	}
+	if !strings.HasSuffix(target, "/") {
+		target += "/"
+	}
+	u, err := url.Parse(target)
+	if err != nil {
+		return nil, false, false, fmt.Errorf("Error parsing url %s: %s", target, err)
+	}
+
+	var up uploader
+	var s3up *s3Uploader
+	switch u.Scheme {
+	case "s3":
+		s3up, err = newS3Uploader(&p.config, u.Host, strings.TrimPrefix(u.Path, "/"))
+		if err != nil {
+			return nil, false, false, err
+		}
+		up = s3up
+	default:
+		up = newHTTPUploader(&p.config, target)
+	}
+
+	var locations []string
+	for _, path := range artifact.Files() {
+		location, err := p.upload(ctx, ui, up, path)
+		if err != nil {
+			return nil, false, false, err
+		}
+		if location != "" {
+			locations = append(locations, location)
+		}
+	}
+	if len(locations) == 0 {
+		return nil, false, false, fmt.Errorf("No files to upload in artifact %s", artifact.BuilderId())
+	}
+
+	if s3up != nil && p.config.KeepVersions > 0 {
+		if err := s3up.Prune(ctx, ui, p.config.KeepVersions); err != nil {
+			return nil, false, false, err
+		}
+	}
+
+	return &Artifact{
+		Locations: locations,
+		StateData: map[string]interface{}{"generated_data": generatedData},
+	}, true, false, nil
+}
+
+// upload uploads the file at path, and returns its location. Directories are
+// skipped.
+func (p *PostProcessor) upload(ctx context.Context, ui packer.Ui, up uploader, path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return "", err
+	}
+	if info.IsDir() {
+		return "", nil
+	}
+
+	ui.Say(fmt.Sprintf("Computing the SHA256 checksum of %s...", path))
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", fmt.Errorf("Error reading %s: %s", path, err)
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+
+	location, err := up.Upload(ctx, ui, f, info.Size(), h.Sum(nil))
+	if err != nil {
+		return "", err
+	}
+	ui.Say(fmt.Sprintf("Uploaded %s to %s (sha256 %x)", path, location, h.Sum(nil)))
+	return location, nil
+}
@@ -0,0 +1,198 @@
+package artifactupload
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/hashicorp/packer/packer"
+)
+
+func testUi() *packer.BasicUi {
+	return &packer.BasicUi{
+		Reader: new(bytes.Buffer),
+		Writer: new(bytes.Buffer),
+		PB:     &packer.NoopProgressTracker{},
+	}
+}
+
+func testArtifact(t *testing.T, files map[string]string) *packer.MockArtifact {
+	dir, err := ioutil.TempDir("", "packer-artifact-upload")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	artifact := &packer.MockArtifact{BuilderIdValue: "packer.qemu"}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("err: %s", err)
+		}
+		artifact.FilesValue = append(artifact.FilesValue, path)
+	}
+	return artifact
+}
+
+func TestPostProcessor_Configure(t *testing.T) {
+	var p PostProcessor
+	if err := p.Configure(map[string]interface{}{"url": "s3://bucket/images"}); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if p.config.HTTPMethod != "PUT" || p.config.PartSize != 64 || p.config.UploadConcurrency != 4 || p.config.S3Region != "us-east-1" {
+		t.Fatalf("bad defaults: %#v", p.config)
+	}
+
+	for _, raw := range []map[string]interface{}{
+		{},
+		{"url": "ftp://example.com/images/"},
+		{"url": "https://example.com/images/", "http_method": "PATCH"},
+		{"url": "s3://bucket/images/", "part_size": 1},
+		{"url": "s3://bucket/images/", "upload_concurrency": -1},
+		{"url": "s3://bucket/images/", "keep_versions": -1},
+		{"url": "https://example.com/images/", "keep_versions": 3},
+		{"url": "https://example.com/images/", "tags": map[string]string{"retention": "short"}},
+	} {
+		p = PostProcessor{}
+		if err := p.Configure(raw); err == nil {
+			t.Fatalf("should have error with %#v", raw)
+		}
+	}
+}
+
+func TestPostProcessor_PostProcessHTTPPut(t *testing.T) {
+	content := "disk content"
+	sum := sha256.Sum256([]byte(content))
+
+	var lock sync.Mutex
+	stored := map[string][]byte{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		defer lock.Unlock()
+		if user, pass, _ := r.BasicAuth(); user != "user" || pass != "pass" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.Method {
+		case http.MethodPut:
+			if r.Header.Get("X-Checksum-Sha256") != hex.EncodeToString(sum[:]) {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			stored[r.URL.Path], _ = ioutil.ReadAll(r.Body)
+			w.WriteHeader(http.StatusCreated)
+		case http.MethodHead:
+			data, ok := stored[r.URL.Path]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			sum := sha256.Sum256(data)
+			w.Header().Set("X-Checksum-Sha256", hex.EncodeToString(sum[:]))
+			w.Header().Set("Content-Length", "12")
+		}
+	}))
+	defer server.Close()
+
+	artifact := testArtifact(t, map[string]string{"disk.qcow2": content})
+	defer os.RemoveAll(filepath.Dir(artifact.FilesValue[0]))
+
+	var p PostProcessor
+	err := p.Configure(map[string]interface{}{
+		"url":                 server.URL + "/images/{{.BuildName}}",
+		"username":            "user",
+		"password":            "pass",
+		"packer_build_name":   "web",
+		"packer_builder_type": "qemu",
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	result, keep, _, err := p.PostProcess(context.Background(), testUi(), artifact)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if !keep {
+		t.Fatal("should keep the input artifact")
+	}
+	if string(stored["/images/web/disk.qcow2"]) != content {
+		t.Fatalf("bad upload: %#v", stored)
+	}
+	if result.Id() != server.URL+"/images/web/disk.qcow2" {
+		t.Fatalf("bad artifact: %s", result.Id())
+	}
+}
+
+func TestPostProcessor_PostProcessHTTPPutMismatch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			// A truncated upload
+			w.Header().Set("Content-Length", "4")
+		}
+	}))
+	defer server.Close()
+
+	artifact := testArtifact(t, map[string]string{"disk.qcow2": "disk content"})
+	defer os.RemoveAll(filepath.Dir(artifact.FilesValue[0]))
+
+	var p PostProcessor
+	if err := p.Configure(map[string]interface{}{"url": server.URL}); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	_, _, _, err := p.PostProcess(context.Background(), testUi(), artifact)
+	if err == nil || !strings.Contains(err.Error(), "expected 12") {
+		t.Fatalf("the upload should fail verification: %v", err)
+	}
+}
+
+func TestPostProcessor_PostProcessHTTPPost(t *testing.T) {
+	var received, field string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		f, header, err := r.FormFile("image")
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		defer f.Close()
+		data, _ := ioutil.ReadAll(f)
+		received, field = string(data), header.Filename
+		w.Header().Set("Location", "https://example.com/images/42")
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	artifact := testArtifact(t, map[string]string{"disk.qcow2": "disk content"})
+	defer os.RemoveAll(filepath.Dir(artifact.FilesValue[0]))
+
+	var p PostProcessor
+	err := p.Configure(map[string]interface{}{
+		"url":         server.URL + "/upload",
+		"http_method": "post",
+		"form_field":  "image",
+		"headers":     map[string]string{"Authorization": "Bearer token"},
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	result, _, _, err := p.PostProcess(context.Background(), testUi(), artifact)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if received != "disk content" || field != "disk.qcow2" {
+		t.Fatalf("bad upload: %q %q", received, field)
+	}
+	if result.Id() != "https://example.com/images/42" {
+		t.Fatalf("bad artifact: %s", result.Id())
+	}
+}
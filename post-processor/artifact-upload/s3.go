@@ -0,0 +1,435 @@
+package artifactupload
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	"github.com/hashicorp/packer/packer"
+	"golang.org/x/sync/errgroup"
+)
+
+// S3 allows at most 10000 parts in a multipart upload.
+const maxParts = 10000
+
+// checksumMetadata is the metadata of the objects holding their SHA256
+// checksum.
+const checksumMetadata = "Sha256"
+
+// s3Uploader uploads files to a bucket of an S3-compatible store.
+type s3Uploader struct {
+	client      s3iface.S3API
+	bucket      string
+	prefix      string
+	partSize    int64
+	concurrency int
+	tags        map[string]string
+}
+
+func newS3Uploader(c *Config, bucket string, prefix string) (*s3Uploader, error) {
+	config := aws.NewConfig().WithRegion(c.S3Region)
+	if c.S3Endpoint != "" {
+		config = config.WithEndpoint(c.S3Endpoint)
+	}
+	if c.S3ForcePathStyle {
+		config = config.WithS3ForcePathStyle(true)
+	}
+	if c.AccessKey != "" {
+		config = config.WithCredentials(credentials.NewStaticCredentials(c.AccessKey, c.SecretKey, c.Token))
+	}
+	sess, err := session.NewSession(config)
+	if err != nil {
+		return nil, err
+	}
+	return &s3Uploader{
+		client:      s3.New(sess),
+		bucket:      bucket,
+		prefix:      prefix,
+		partSize:    int64(c.PartSize) * 1024 * 1024,
+		concurrency: c.UploadConcurrency,
+		tags:        c.Tags,
+	}, nil
+}
+
+func (u *s3Uploader) Upload(ctx context.Context, ui packer.Ui, f *os.File, size int64, sum []byte) (string, error) {
+	key := u.prefix + path.Base(f.Name())
+	location := fmt.Sprintf("s3://%s/%s", u.bucket, key)
+
+	var etag string
+	var err error
+	if size <= u.partSize {
+		ui.Say(fmt.Sprintf("Uploading %s to %s...", f.Name(), location))
+		etag, err = u.putObject(ctx, ui, f, key, size, sum)
+	} else {
+		etag, err = u.multipartUpload(ctx, ui, f, key, size, sum)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	ui.Say(fmt.Sprintf("Verifying %s...", location))
+	head, err := u.client.HeadObjectWithContext(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(u.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return "", fmt.Errorf("Error verifying %s: %s", location, err)
+	}
+	if aws.Int64Value(head.ContentLength) != size {
+		return "", fmt.Errorf("%s is %d bytes, expected %d", location, aws.Int64Value(head.ContentLength), size)
+	}
+	if remote := strings.Trim(aws.StringValue(head.ETag), `"`); !strings.EqualFold(remote, etag) {
+		return "", fmt.Errorf("%s has ETag %s, expected %s", location, remote, etag)
+	}
+	if remote := aws.StringValue(head.Metadata[checksumMetadata]); remote != hex.EncodeToString(sum) {
+		return "", fmt.Errorf("%s has SHA256 checksum %s, expected %x", location, remote, sum)
+	}
+	return location, nil
+}
+
+// putObject uploads f in a single request, and returns its expected ETag.
+func (u *s3Uploader) putObject(ctx context.Context, ui packer.Ui, f *os.File, key string, size int64, sum []byte) (string, error) {
+	data := make([]byte, size)
+	if _, err := io.ReadFull(f, data); err != nil {
+		return "", err
+	}
+	digest := md5.Sum(data)
+
+	progress := packer.NewProgressCounter(ui, path.Base(key), size)
+	defer progress.Close()
+	_, err := u.client.PutObjectWithContext(ctx, &s3.PutObjectInput{
+		Bucket:     aws.String(u.bucket),
+		Key:        aws.String(key),
+		Body:       bytes.NewReader(data),
+		ContentMD5: aws.String(base64.StdEncoding.EncodeToString(digest[:])),
+		Metadata:   map[string]*string{checksumMetadata: aws.String(hex.EncodeToString(sum))},
+		Tagging:    u.tagging(),
+	})
+	if err != nil {
+		return "", fmt.Errorf("Error uploading %s: %s", key, err)
+	}
+	progress.Add(size)
+	return hex.EncodeToString(digest[:]), nil
+}
+
+// multipartUpload uploads f in parts, resuming a previous upload of the key
+// when there is one, and returns its expected ETag. The parts already
+// uploaded are kept when their MD5 checksum matches the one of the file.
+//
+// An interrupted upload is not aborted so that it can be resumed by the next
+// build.
+func (u *s3Uploader) multipartUpload(ctx context.Context, ui packer.Ui, f *os.File, key string, size int64, sum []byte) (string, error) {
+	partSize := u.partSize
+	if min := (size + maxParts - 1) / maxParts; partSize < min {
+		// Round up to a megabyte
+		partSize = (min + 1024*1024 - 1) / (1024 * 1024) * (1024 * 1024)
+	}
+	count := int((size + partSize - 1) / partSize)
+
+	uploadID, uploaded, err := u.resumableUpload(ctx, key)
+	if err != nil {
+		return "", err
+	}
+	if uploadID != "" && !partsMatch(f, partSize, size, uploaded) {
+		log.Printf("Aborting upload %s of %s, its parts don't match the file", uploadID, key)
+		_, err := u.client.AbortMultipartUploadWithContext(ctx, &s3.AbortMultipartUploadInput{
+			Bucket:   aws.String(u.bucket),
+			Key:      aws.String(key),
+			UploadId: aws.String(uploadID),
+		})
+		if err != nil {
+			return "", fmt.Errorf("Error aborting the previous upload of %s: %s", key, err)
+		}
+		uploadID = ""
+	}
+	if uploadID == "" {
+		ui.Say(fmt.Sprintf("Uploading %s to s3://%s/%s in %d parts...", f.Name(), u.bucket, key, count))
+		out, err := u.client.CreateMultipartUploadWithContext(ctx, &s3.CreateMultipartUploadInput{
+			Bucket:   aws.String(u.bucket),
+			Key:      aws.String(key),
+			Metadata: map[string]*string{checksumMetadata: aws.String(hex.EncodeToString(sum))},
+			Tagging:  u.tagging(),
+		})
+		if err != nil {
+			return "", fmt.Errorf("Error starting the upload of %s: %s", key, err)
+		}
+		uploadID = aws.StringValue(out.UploadId)
+		uploaded = nil
+	} else {
+		ui.Say(fmt.Sprintf("Resuming the upload of %s to s3://%s/%s, %d parts of %d already uploaded...",
+			f.Name(), u.bucket, key, len(uploaded), count))
+	}
+
+	progress := packer.NewProgressCounter(ui, path.Base(key), size)
+	defer progress.Close()
+
+	digests := make([][]byte, count)
+	parts := make(chan int)
+	g, gctx := errgroup.WithContext(ctx)
+	for i := 0; i < u.concurrency; i++ {
+		g.Go(func() error {
+			buf := make([]byte, partSize)
+			for i := range parts {
+				number := int64(i + 1)
+				if part, ok := uploaded[number]; ok {
+					digests[i] = part.digest
+					progress.Add(part.size)
+					continue
+				}
+
+				n, err := f.ReadAt(buf, int64(i)*partSize)
+				if err != nil && err != io.EOF {
+					return err
+				}
+				data := buf[:n]
+				digest := md5.Sum(data)
+				digests[i] = digest[:]
+				_, err = u.client.UploadPartWithContext(gctx, &s3.UploadPartInput{
+					Bucket:     aws.String(u.bucket),
+					Key:        aws.String(key),
+					UploadId:   aws.String(uploadID),
+					PartNumber: aws.Int64(number),
+					Body:       bytes.NewReader(data),
+					ContentMD5: aws.String(base64.StdEncoding.EncodeToString(digest[:])),
+				})
+				if err != nil {
+					return fmt.Errorf("Error uploading part %d of %s: %s", number, key, err)
+				}
+				progress.Add(int64(n))
+			}
+			return nil
+		})
+	}
+	g.Go(func() error {
+		defer close(parts)
+		for i := 0; i < count; i++ {
+			select {
+			case parts <- i:
+			case <-gctx.Done():
+				return gctx.Err()
+			}
+		}
+		return nil
+	})
+	if err := g.Wait(); err != nil {
+		return "", err
+	}
+
+	completed := make([]*s3.CompletedPart, count)
+	all := make([]byte, 0, count*md5.Size)
+	for i, digest := range digests {
+		completed[i] = &s3.CompletedPart{
+			ETag:       aws.String(`"` + hex.EncodeToString(digest) + `"`),
+			PartNumber: aws.Int64(int64(i + 1)),
+		}
+		all = append(all, digest...)
+	}
+	_, err = u.client.CompleteMultipartUploadWithContext(ctx, &s3.CompleteMultipartUploadInput{
+		Bucket:          aws.String(u.bucket),
+		Key:             aws.String(key),
+		UploadId:        aws.String(uploadID),
+		MultipartUpload: &s3.CompletedMultipartUpload{Parts: completed},
+	})
+	if err != nil {
+		return "", fmt.Errorf("Error completing the upload of %s: %s", key, err)
+	}
+
+	// The ETag of a multipart object is the MD5 checksum of the MD5
+	// checksums of its parts, followed by the number of parts.
+	digest := md5.Sum(all)
+	return fmt.Sprintf("%s-%d", hex.EncodeToString(digest[:]), count), nil
+}
+
+// An uploadedPart is a part of a pending multipart upload.
+type uploadedPart struct {
+	size   int64
+	digest []byte
+}
+
+// resumableUpload returns the ID of the most recent pending multipart upload
+// of key, if any, and its parts by part number.
+func (u *s3Uploader) resumableUpload(ctx context.Context, key string) (string, map[int64]uploadedPart, error) {
+	out, err := u.client.ListMultipartUploadsWithContext(ctx, &s3.ListMultipartUploadsInput{
+		Bucket: aws.String(u.bucket),
+		Prefix: aws.String(key),
+	})
+	if err != nil {
+		return "", nil, fmt.Errorf("Error listing the pending uploads of %s: %s", key, err)
+	}
+	var latest *s3.MultipartUpload
+	for _, upload := range out.Uploads {
+		if aws.StringValue(upload.Key) != key {
+			continue
+		}
+		if latest == nil || aws.TimeValue(upload.Initiated).After(aws.TimeValue(latest.Initiated)) {
+			latest = upload
+		}
+	}
+	if latest == nil {
+		return "", nil, nil
+	}
+
+	uploaded := map[int64]uploadedPart{}
+	var parseErr error
+	err = u.client.ListPartsPagesWithContext(ctx, &s3.ListPartsInput{
+		Bucket:   aws.String(u.bucket),
+		Key:      aws.String(key),
+		UploadId: latest.UploadId,
+	}, func(page *s3.ListPartsOutput, lastPage bool) bool {
+		for _, part := range page.Parts {
+			digest, err := hex.DecodeString(strings.Trim(aws.StringValue(part.ETag), `"`))
+			if err != nil {
+				parseErr = fmt.Errorf("part %d has an unexpected ETag %s", aws.Int64Value(part.PartNumber), aws.StringValue(part.ETag))
+				return false
+			}
+			uploaded[aws.Int64Value(part.PartNumber)] = uploadedPart{
+				size:   aws.Int64Value(part.Size),
+				digest: digest,
+			}
+		}
+		return true
+	})
+	if err == nil {
+		err = parseErr
+	}
+	if err != nil {
+		return "", nil, fmt.Errorf("Error listing the uploaded parts of %s: %s", key, err)
+	}
+	return aws.StringValue(latest.UploadId), uploaded, nil
+}
+
+// partsMatch returns whether the parts of a pending upload are the parts of
+// the file, so that the upload can be resumed.
+func partsMatch(f *os.File, partSize int64, size int64, uploaded map[int64]uploadedPart) bool {
+	buf := make([]byte, partSize)
+	for number, part := range uploaded {
+		offset := (number - 1) * partSize
+		expected := size - offset
+		if expected > partSize {
+			expected = partSize
+		}
+		if number < 1 || expected <= 0 || part.size != expected {
+			return false
+		}
+		n, err := f.ReadAt(buf[:expected], offset)
+		if err != nil && err != io.EOF {
+			return false
+		}
+		digest := md5.Sum(buf[:n])
+		if !bytes.Equal(digest[:], part.digest) {
+			return false
+		}
+	}
+	return true
+}
+
+func (u *s3Uploader) tagging() *string {
+	if len(u.tags) == 0 {
+		return nil
+	}
+	values := url.Values{}
+	for k, v := range u.tags {
+		values.Set(k, v)
+	}
+	return aws.String(values.Encode())
+}
+
+// Prune deletes the versions next to the uploaded one but the keep most
+// recent ones. The versions are the prefixes sharing the parent of the
+// upload prefix, ordered by their most recent object.
+func (u *s3Uploader) Prune(ctx context.Context, ui packer.Ui, keep int) error {
+	parent := path.Dir(strings.TrimSuffix(u.prefix, "/")) + "/"
+	if parent == "./" {
+		parent = ""
+	}
+
+	type version struct {
+		prefix   string
+		modified time.Time
+		keys     []*s3.ObjectIdentifier
+	}
+	var versions []*version
+	err := u.client.ListObjectsV2PagesWithContext(ctx, &s3.ListObjectsV2Input{
+		Bucket:    aws.String(u.bucket),
+		Prefix:    aws.String(parent),
+		Delimiter: aws.String("/"),
+	}, func(page *s3.ListObjectsV2Output, lastPage bool) bool {
+		for _, p := range page.CommonPrefixes {
+			versions = append(versions, &version{prefix: aws.StringValue(p.Prefix)})
+		}
+		return true
+	})
+	if err != nil {
+		return fmt.Errorf("Error listing the versions in s3://%s/%s: %s", u.bucket, parent, err)
+	}
+
+	for _, v := range versions {
+		err := u.client.ListObjectsV2PagesWithContext(ctx, &s3.ListObjectsV2Input{
+			Bucket: aws.String(u.bucket),
+			Prefix: aws.String(v.prefix),
+		}, func(page *s3.ListObjectsV2Output, lastPage bool) bool {
+			for _, o := range page.Contents {
+				if t := aws.TimeValue(o.LastModified); t.After(v.modified) {
+					v.modified = t
+				}
+				v.keys = append(v.keys, &s3.ObjectIdentifier{Key: o.Key})
+			}
+			return true
+		})
+		if err != nil {
+			return fmt.Errorf("Error listing s3://%s/%s: %s", u.bucket, v.prefix, err)
+		}
+	}
+	sort.SliceStable(versions, func(i, j int) bool {
+		return versions[i].modified.After(versions[j].modified)
+	})
+
+	// The uploaded version is always kept
+	kept := 1
+	for _, v := range versions {
+		if v.prefix == u.prefix {
+			continue
+		}
+		if kept < keep {
+			kept++
+			continue
+		}
+		ui.Say(fmt.Sprintf("Deleting old version s3://%s/%s...", u.bucket, v.prefix))
+		for len(v.keys) > 0 {
+			// DeleteObjects deletes at most 1000 objects at once
+			n := len(v.keys)
+			if n > 1000 {
+				n = 1000
+			}
+			out, err := u.client.DeleteObjectsWithContext(ctx, &s3.DeleteObjectsInput{
+				Bucket: aws.String(u.bucket),
+				Delete: &s3.Delete{Objects: v.keys[:n], Quiet: aws.Bool(true)},
+			})
+			if err != nil {
+				return fmt.Errorf("Error deleting s3://%s/%s: %s", u.bucket, v.prefix, err)
+			}
+			if len(out.Errors) > 0 {
+				return fmt.Errorf("Error deleting s3://%s/%s: %s", u.bucket,
+					aws.StringValue(out.Errors[0].Key), aws.StringValue(out.Errors[0].Message))
+			}
+			v.keys = v.keys[n:]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,343 @@
+package artifactupload
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+)
+
+type memObject struct {
+	data     []byte
+	etag     string
+	metadata map[string]*string
+	modified time.Time
+}
+
+type memUpload struct {
+	key      string
+	metadata map[string]*string
+	parts    map[int64][]byte
+}
+
+// memS3 is a bucket in memory. The methods of s3iface.S3API it doesn't
+// implement panic.
+type memS3 struct {
+	s3iface.S3API
+
+	lock    sync.Mutex
+	objects map[string]*memObject
+	uploads map[string]*memUpload
+	// uploadedParts counts the parts uploaded
+	uploadedParts int
+	// failPart fails the upload of a part when it's set
+	failPart int64
+}
+
+func newMemS3() *memS3 {
+	return &memS3{
+		objects: map[string]*memObject{},
+		uploads: map[string]*memUpload{},
+	}
+}
+
+func (m *memS3) PutObjectWithContext(_ aws.Context, in *s3.PutObjectInput, _ ...request.Option) (*s3.PutObjectOutput, error) {
+	data, _ := ioutil.ReadAll(in.Body)
+	digest := md5.Sum(data)
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.objects[*in.Key] = &memObject{
+		data:     data,
+		etag:     hex.EncodeToString(digest[:]),
+		metadata: in.Metadata,
+		modified: time.Now(),
+	}
+	return &s3.PutObjectOutput{}, nil
+}
+
+func (m *memS3) HeadObjectWithContext(_ aws.Context, in *s3.HeadObjectInput, _ ...request.Option) (*s3.HeadObjectOutput, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	o, ok := m.objects[*in.Key]
+	if !ok {
+		return nil, fmt.Errorf("NotFound")
+	}
+	return &s3.HeadObjectOutput{
+		ContentLength: aws.Int64(int64(len(o.data))),
+		ETag:          aws.String(`"` + o.etag + `"`),
+		Metadata:      o.metadata,
+	}, nil
+}
+
+func (m *memS3) CreateMultipartUploadWithContext(_ aws.Context, in *s3.CreateMultipartUploadInput, _ ...request.Option) (*s3.CreateMultipartUploadOutput, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	id := fmt.Sprintf("upload-%d", len(m.uploads))
+	m.uploads[id] = &memUpload{key: *in.Key, metadata: in.Metadata, parts: map[int64][]byte{}}
+	return &s3.CreateMultipartUploadOutput{UploadId: aws.String(id)}, nil
+}
+
+func (m *memS3) UploadPartWithContext(_ aws.Context, in *s3.UploadPartInput, _ ...request.Option) (*s3.UploadPartOutput, error) {
+	data, _ := ioutil.ReadAll(in.Body)
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if *in.PartNumber == m.failPart {
+		return nil, fmt.Errorf("connection reset")
+	}
+	m.uploads[*in.UploadId].parts[*in.PartNumber] = data
+	m.uploadedParts++
+	return &s3.UploadPartOutput{}, nil
+}
+
+func (m *memS3) ListMultipartUploadsWithContext(_ aws.Context, in *s3.ListMultipartUploadsInput, _ ...request.Option) (*s3.ListMultipartUploadsOutput, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	out := &s3.ListMultipartUploadsOutput{}
+	for id, u := range m.uploads {
+		if strings.HasPrefix(u.key, *in.Prefix) {
+			out.Uploads = append(out.Uploads, &s3.MultipartUpload{
+				Key:       aws.String(u.key),
+				UploadId:  aws.String(id),
+				Initiated: aws.Time(time.Now()),
+			})
+		}
+	}
+	return out, nil
+}
+
+func (m *memS3) ListPartsPagesWithContext(_ aws.Context, in *s3.ListPartsInput, fn func(*s3.ListPartsOutput, bool) bool, _ ...request.Option) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	out := &s3.ListPartsOutput{}
+	for number, data := range m.uploads[*in.UploadId].parts {
+		digest := md5.Sum(data)
+		out.Parts = append(out.Parts, &s3.Part{
+			PartNumber: aws.Int64(number),
+			Size:       aws.Int64(int64(len(data))),
+			ETag:       aws.String(`"` + hex.EncodeToString(digest[:]) + `"`),
+		})
+	}
+	fn(out, true)
+	return nil
+}
+
+func (m *memS3) AbortMultipartUploadWithContext(_ aws.Context, in *s3.AbortMultipartUploadInput, _ ...request.Option) (*s3.AbortMultipartUploadOutput, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	delete(m.uploads, *in.UploadId)
+	return &s3.AbortMultipartUploadOutput{}, nil
+}
+
+func (m *memS3) CompleteMultipartUploadWithContext(_ aws.Context, in *s3.CompleteMultipartUploadInput, _ ...request.Option) (*s3.CompleteMultipartUploadOutput, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	u := m.uploads[*in.UploadId]
+	var data, digests []byte
+	for i, part := range in.MultipartUpload.Parts {
+		if *part.PartNumber != int64(i+1) {
+			return nil, fmt.Errorf("InvalidPartOrder")
+		}
+		p := u.parts[*part.PartNumber]
+		digest := md5.Sum(p)
+		if *part.ETag != `"`+hex.EncodeToString(digest[:])+`"` {
+			return nil, fmt.Errorf("InvalidPart %d", *part.PartNumber)
+		}
+		data = append(data, p...)
+		digests = append(digests, digest[:]...)
+	}
+	digest := md5.Sum(digests)
+	m.objects[u.key] = &memObject{
+		data:     data,
+		etag:     fmt.Sprintf("%s-%d", hex.EncodeToString(digest[:]), len(in.MultipartUpload.Parts)),
+		metadata: u.metadata,
+		modified: time.Now(),
+	}
+	delete(m.uploads, *in.UploadId)
+	return &s3.CompleteMultipartUploadOutput{}, nil
+}
+
+func (m *memS3) ListObjectsV2PagesWithContext(_ aws.Context, in *s3.ListObjectsV2Input, fn func(*s3.ListObjectsV2Output, bool) bool, _ ...request.Option) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	out := &s3.ListObjectsV2Output{}
+	prefixes := map[string]bool{}
+	var keys []string
+	for key := range m.objects {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if !strings.HasPrefix(key, *in.Prefix) {
+			continue
+		}
+		rest := strings.TrimPrefix(key, *in.Prefix)
+		if in.Delimiter != nil && strings.Contains(rest, *in.Delimiter) {
+			prefix := *in.Prefix + rest[:strings.Index(rest, *in.Delimiter)+1]
+			if !prefixes[prefix] {
+				prefixes[prefix] = true
+				out.CommonPrefixes = append(out.CommonPrefixes, &s3.CommonPrefix{Prefix: aws.String(prefix)})
+			}
+			continue
+		}
+		out.Contents = append(out.Contents, &s3.Object{
+			Key:          aws.String(key),
+			LastModified: aws.Time(m.objects[key].modified),
+		})
+	}
+	fn(out, true)
+	return nil
+}
+
+func (m *memS3) DeleteObjectsWithContext(_ aws.Context, in *s3.DeleteObjectsInput, _ ...request.Option) (*s3.DeleteObjectsOutput, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	for _, o := range in.Delete.Objects {
+		delete(m.objects, *o.Key)
+	}
+	return &s3.DeleteObjectsOutput{}, nil
+}
+
+func testFile(t *testing.T, size int) (*os.File, []byte) {
+	dir, err := ioutil.TempDir("", "packer-artifact-upload")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	data := make([]byte, size)
+	for i := range data {
+		data[i] = byte(i % 251)
+	}
+	path := filepath.Join(dir, "disk.raw")
+	if err := ioutil.WriteFile(path, data, 0644); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	sum := sha256.Sum256(data)
+	return f, sum[:]
+}
+
+func closeTestFile(f *os.File) {
+	f.Close()
+	os.RemoveAll(filepath.Dir(f.Name()))
+}
+
+func TestS3Uploader_Upload(t *testing.T) {
+	m := newMemS3()
+	u := &s3Uploader{client: m, bucket: "bucket", prefix: "images/1.0/", partSize: 1024, concurrency: 2}
+	f, sum := testFile(t, 100)
+	defer closeTestFile(f)
+
+	location, err := u.Upload(context.Background(), testUi(), f, 100, sum)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if location != "s3://bucket/images/1.0/disk.raw" {
+		t.Fatalf("bad location: %s", location)
+	}
+	if len(m.objects["images/1.0/disk.raw"].data) != 100 {
+		t.Fatal("the file should be uploaded in one request")
+	}
+}
+
+func TestS3Uploader_UploadMultipartResume(t *testing.T) {
+	m := newMemS3()
+	m.failPart = 3
+	u := &s3Uploader{client: m, bucket: "bucket", prefix: "images/1.0/", partSize: 1024, concurrency: 1}
+	f, sum := testFile(t, 4*1024+10)
+	defer closeTestFile(f)
+
+	if _, err := u.Upload(context.Background(), testUi(), f, 4*1024+10, sum); err == nil {
+		t.Fatal("the upload should fail")
+	}
+	if len(m.uploads) != 1 {
+		t.Fatal("the failed upload should be kept to be resumed")
+	}
+	uploaded := m.uploadedParts
+
+	m.failPart = 0
+	if _, err := u.Upload(context.Background(), testUi(), f, 4*1024+10, sum); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if m.uploadedParts-uploaded != 5-uploaded {
+		t.Fatalf("only the missing parts should be uploaded, %d were uploaded before", uploaded)
+	}
+	o := m.objects["images/1.0/disk.raw"]
+	if !strings.HasSuffix(o.etag, "-5") || len(o.data) != 4*1024+10 {
+		t.Fatalf("bad object: %s %d", o.etag, len(o.data))
+	}
+	if len(m.uploads) != 0 {
+		t.Fatal("the upload should be completed")
+	}
+}
+
+func TestS3Uploader_UploadMultipartStale(t *testing.T) {
+	m := newMemS3()
+	u := &s3Uploader{client: m, bucket: "bucket", prefix: "images/1.0/", partSize: 1024, concurrency: 2}
+	// A pending upload of another version of the file
+	m.uploads["stale"] = &memUpload{
+		key:   "images/1.0/disk.raw",
+		parts: map[int64][]byte{1: bytes.Repeat([]byte{1}, 1024)},
+	}
+	f, sum := testFile(t, 3*1024)
+	defer closeTestFile(f)
+
+	if _, err := u.Upload(context.Background(), testUi(), f, 3*1024, sum); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if _, ok := m.uploads["stale"]; ok {
+		t.Fatal("the stale upload should be aborted")
+	}
+	if m.uploadedParts != 3 {
+		t.Fatalf("all the parts should be uploaded, got %d", m.uploadedParts)
+	}
+}
+
+func TestS3Uploader_Prune(t *testing.T) {
+	m := newMemS3()
+	now := time.Now()
+	for i, version := range []string{"1.0", "1.1", "1.2", "1.3"} {
+		for _, name := range []string{"disk.raw", "disk.raw.sha256"} {
+			m.objects["images/"+version+"/"+name] = &memObject{modified: now.Add(time.Duration(i) * time.Hour)}
+		}
+	}
+	m.objects["other/disk.raw"] = &memObject{modified: now}
+
+	// The uploaded version is kept even though it's not the most recent
+	u := &s3Uploader{client: m, bucket: "bucket", prefix: "images/1.0/"}
+	if err := u.Prune(context.Background(), testUi(), 2); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	var keys []string
+	for key := range m.objects {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	expected := []string{
+		"images/1.0/disk.raw",
+		"images/1.0/disk.raw.sha256",
+		"images/1.3/disk.raw",
+		"images/1.3/disk.raw.sha256",
+		"other/disk.raw",
+	}
+	if strings.Join(keys, " ") != strings.Join(expected, " ") {
+		t.Fatalf("bad objects: %v", keys)
+	}
+}
@@ -0,0 +1,13 @@
+package version
+
+import (
+	"github.com/hashicorp/packer/packer-plugin-sdk/version"
+	packerVersion "github.com/hashicorp/packer/version"
+)
+
+var ArtifactUploadPluginVersion *version.PluginVersion
+
+func init() {
+	ArtifactUploadPluginVersion = version.InitializePluginVersion(
+		packerVersion.Version, packerVersion.VersionPrerelease)
+}
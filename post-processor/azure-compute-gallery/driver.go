@@ -0,0 +1,34 @@
+package azurecomputegallery
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"os/exec"
+	"strings"
+)
+
+// A Driver converts the disk of the artifact.
+type Driver interface {
+	// ConvertToRaw converts the disk at src to a raw disk at dst.
+	ConvertToRaw(src string, dst string) error
+}
+
+// QemuImgDriver is a Driver running qemu-img.
+type QemuImgDriver struct {
+	QemuImgPath string
+}
+
+func (d *QemuImgDriver) ConvertToRaw(src string, dst string) error {
+	var stderr bytes.Buffer
+
+	args := []string{"convert", "-O", "raw", src, dst}
+	log.Printf("Executing %s: %#v", d.QemuImgPath, args)
+	cmd := exec.Command(d.QemuImgPath, args...)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s convert failed: %s\nStderr: %s", d.QemuImgPath, err,
+			strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
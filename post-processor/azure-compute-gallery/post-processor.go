@@ -0,0 +1,416 @@
+//go:generate struct-markdown
+//go:generate mapstructure-to-hcl2 -type Config
+
+package azurecomputegallery
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2019-12-01/compute"
+	"github.com/Azure/go-autorest/autorest/to"
+	"github.com/hashicorp/hcl/v2/hcldec"
+	"github.com/hashicorp/packer/builder/azure/chroot"
+	azcommon "github.com/hashicorp/packer/builder/azure/common"
+	"github.com/hashicorp/packer/builder/azure/common/client"
+	"github.com/hashicorp/packer/packer"
+	"github.com/hashicorp/packer/packer-plugin-sdk/common"
+	"github.com/hashicorp/packer/packer-plugin-sdk/template/config"
+	"github.com/hashicorp/packer/packer-plugin-sdk/template/interpolate"
+)
+
+const BuilderId = "packer.post-processor.azure-compute-gallery"
+
+type Config struct {
+	common.PackerConfig `mapstructure:",squash"`
+
+	ClientConfig client.Config `mapstructure:",squash"`
+
+	// The resource group of the storage account the disk is uploaded to,
+	// where the managed image is created.
+	ResourceGroup string `mapstructure:"resource_group" required:"true"`
+	// The Azure region of the storage account and of the managed image, like
+	// `westeurope`.
+	Location string `mapstructure:"location" required:"true"`
+	// The storage account the disk is uploaded to.
+	StorageAccount string `mapstructure:"storage_account" required:"true"`
+	// The container of `storage_account` the disk is uploaded to. It is
+	// created if it doesn't exist. Defaults to `images`.
+	StorageContainer string `mapstructure:"storage_container"`
+	// The name of the blob the disk is uploaded to. This is treated as a
+	// [template engine](/docs/templates/engine). Defaults to
+	// `packer-{{timestamp}}.vhd`.
+	BlobName string `mapstructure:"blob_name"`
+	// The name of the managed image created from the disk.
+	ImageName string `mapstructure:"image_name" required:"true"`
+	// The OS of the disk, `Linux` or `Windows`. Defaults to `Linux`.
+	OSType string `mapstructure:"os_type"`
+	// The Hyper-V generation of the disk, `V1` or `V2`. Use `V2` for the
+	// disks of generation 2 Hyper-V machines, booting with UEFI. Defaults to
+	// `V1`.
+	HyperVGeneration string `mapstructure:"hyperv_generation"`
+	// The image version to publish in a Shared Image Gallery. The image
+	// definition must exist and match `os_type` and `hyperv_generation`.
+	// `target_regions` defaults to `location`.
+	SharedImageGalleryDestination chroot.SharedImageGalleryDestination `mapstructure:"shared_image_gallery_destination" required:"true"`
+	// The path to the qemu-img command converting VHDX and dynamic VHD disks
+	// to fixed VHD disks. Defaults to `qemu-img`.
+	QemuImgPath string `mapstructure:"qemu_img_path"`
+	// Keep the uploaded blob after the image version is published. Defaults
+	// to `false`.
+	SkipClean bool `mapstructure:"skip_clean"`
+
+	ctx interpolate.Context
+}
+
+type PostProcessor struct {
+	config Config
+	driver Driver
+	azcli  client.AzureClientSet
+	blob   pageBlob
+}
+
+func (p *PostProcessor) ConfigSpec() hcldec.ObjectSpec { return p.config.FlatMapstructure().HCL2Spec() }
+
+func (p *PostProcessor) Configure(raws ...interface{}) error {
+	err := config.Decode(&p.config, &config.DecodeOpts{
+		PluginType:         BuilderId,
+		Interpolate:        true,
+		InterpolateContext: &p.config.ctx,
+		InterpolateFilter: &interpolate.RenderFilter{
+			Exclude: []string{"blob_name"},
+		},
+	}, raws...)
+	if err != nil {
+		return err
+	}
+
+	errs := new(packer.MultiError)
+
+	if err := p.config.ClientConfig.SetDefaultValues(); err != nil {
+		return err
+	}
+	p.config.ClientConfig.Validate(errs)
+
+	if p.config.StorageContainer == "" {
+		p.config.StorageContainer = "images"
+	}
+	if p.config.BlobName == "" {
+		p.config.BlobName = "packer-{{timestamp}}.vhd"
+	}
+	if err = interpolate.Validate(p.config.BlobName, &p.config.ctx); err != nil {
+		errs = packer.MultiErrorAppend(
+			errs, fmt.Errorf("Error parsing blob_name template: %s", err))
+	}
+	if p.config.OSType == "" {
+		p.config.OSType = string(compute.Linux)
+	}
+	if p.config.HyperVGeneration == "" {
+		p.config.HyperVGeneration = string(compute.HyperVGenerationTypesV1)
+	}
+	if p.config.QemuImgPath == "" {
+		p.config.QemuImgPath = "qemu-img"
+	}
+
+	required := map[string]string{
+		"resource_group":  p.config.ResourceGroup,
+		"location":        p.config.Location,
+		"storage_account": p.config.StorageAccount,
+		"image_name":      p.config.ImageName,
+	}
+	for key, value := range required {
+		if value == "" {
+			errs = packer.MultiErrorAppend(
+				errs, fmt.Errorf("%s must be set", key))
+		}
+	}
+
+	if err := checkOSType(p.config.OSType); err != nil {
+		errs = packer.MultiErrorAppend(errs, fmt.Errorf("os_type: %v", err))
+	}
+	if err := checkHyperVGeneration(p.config.HyperVGeneration); err != nil {
+		errs = packer.MultiErrorAppend(errs, fmt.Errorf("hyperv_generation: %v", err))
+	}
+
+	sigd := &p.config.SharedImageGalleryDestination
+	if len(sigd.TargetRegions) == 0 && p.config.Location != "" {
+		sigd.TargetRegions = []chroot.TargetRegion{{Name: p.config.Location}}
+	}
+	e, warns := sigd.Validate("shared_image_gallery_destination")
+	errs = packer.MultiErrorAppend(errs, e...)
+	for _, w := range warns {
+		log.Printf("[WARN] %s", w)
+	}
+
+	if len(errs.Errors) > 0 {
+		return errs
+	}
+
+	packer.LogSecretFilter.Set(p.config.ClientConfig.ClientSecret, p.config.ClientConfig.ClientJWT)
+
+	if p.driver == nil {
+		p.driver = &QemuImgDriver{QemuImgPath: p.config.QemuImgPath}
+	}
+	return nil
+}
+
+func checkOSType(s string) error {
+	for _, v := range compute.PossibleOperatingSystemTypesValues() {
+		if compute.OperatingSystemTypes(s) == v {
+			return nil
+		}
+	}
+	return fmt.Errorf("%q is not a valid value %v",
+		s, compute.PossibleOperatingSystemTypesValues())
+}
+
+func checkHyperVGeneration(s string) error {
+	for _, v := range compute.PossibleHyperVGenerationTypesValues() {
+		if compute.HyperVGenerationTypes(s) == v {
+			return nil
+		}
+	}
+	return fmt.Errorf("%q is not a valid value %v",
+		s, compute.PossibleHyperVGenerationTypesValues())
+}
+
+func (p *PostProcessor) PostProcess(ctx context.Context, ui packer.Ui, artifact packer.Artifact) (packer.Artifact, bool, bool, error) {
+	source := artifactDisk(artifact)
+	if source == "" {
+		return nil, false, false, fmt.Errorf(
+			"No VHD or VHDX disk found in artifact %s", artifact.BuilderId())
+	}
+
+	generatedData := artifact.State("generated_data")
+	if generatedData == nil {
+		// Make sure it's not a nil map so we can assign to it later.
+		generatedData = make(map[string]interface{})
+	}
+	p.config.ctx.Data = generatedData
+	blobName, err := interpolate.Render(p.config.BlobName, &p.config.ctx)
+	if err != nil {
+		return nil, false, false, fmt.Errorf("Error rendering blob_name template: %s", err)
+	}
+
+	if p.azcli == nil {
+		if err := p.config.ClientConfig.FillParameters(); err != nil {
+			return nil, false, false, fmt.Errorf("error setting Azure client defaults: %v", err)
+		}
+		p.azcli, err = client.New(p.config.ClientConfig, ui.Say)
+		if err != nil {
+			return nil, false, false, fmt.Errorf("error creating Azure client: %v", err)
+		}
+	}
+	subscriptionID := p.azcli.SubscriptionID()
+	sigd := p.config.SharedImageGalleryDestination
+
+	galleryLocation, err := p.verifyGalleryImage(ctx, ui)
+	if err != nil {
+		return nil, false, false, err
+	}
+
+	disk, size, err := p.openDisk(ui, source)
+	if err != nil {
+		return nil, false, false, err
+	}
+	defer disk.Close()
+
+	if p.blob == nil {
+		p.blob, err = newStorageBlob(ctx, p.config.ClientConfig, ui.Say,
+			p.config.ResourceGroup, p.config.StorageAccount, p.config.StorageContainer, blobName)
+		if err != nil {
+			return nil, false, false, err
+		}
+	}
+	if !p.config.SkipClean {
+		defer func() {
+			ui.Say(fmt.Sprintf("Deleting blob %s...", p.blob.URL()))
+			if err := p.blob.Delete(); err != nil {
+				ui.Error(fmt.Sprintf("Error deleting blob %s: %s", p.blob.URL(), err))
+			}
+		}()
+	}
+
+	ui.Say(fmt.Sprintf("Uploading %s to %s...", source, p.blob.URL()))
+	if err := uploadDisk(ctx, ui, filepath.Base(source), disk, size, p.blob); err != nil {
+		return nil, false, false, err
+	}
+
+	imageID := fmt.Sprintf("/subscriptions/%s/resourceGroups/%s/providers/Microsoft.Compute/images/%s",
+		subscriptionID, p.config.ResourceGroup, p.config.ImageName)
+	ui.Say(fmt.Sprintf("Creating managed image %s...", imageID))
+	image := compute.Image{
+		Location: to.StringPtr(p.config.Location),
+		ImageProperties: &compute.ImageProperties{
+			StorageProfile: &compute.ImageStorageProfile{
+				OsDisk: &compute.ImageOSDisk{
+					OsType:  compute.OperatingSystemTypes(p.config.OSType),
+					OsState: compute.Generalized,
+					BlobURI: to.StringPtr(p.blob.URL()),
+					Caching: compute.CachingTypesReadWrite,
+				},
+			},
+			HyperVGeneration: compute.HyperVGenerationTypes(p.config.HyperVGeneration),
+		},
+	}
+	f, err := p.azcli.ImagesClient().CreateOrUpdate(ctx, p.config.ResourceGroup, p.config.ImageName, image)
+	if err == nil {
+		err = f.WaitForCompletionRef(ctx, p.azcli.PollClient())
+	}
+	if err != nil {
+		return nil, false, false, fmt.Errorf("error creating managed image '%s': %v", imageID, err)
+	}
+
+	versionID := sigd.ResourceID(subscriptionID)
+	var regions []string
+	var targetRegions []compute.TargetRegion
+	for _, tr := range sigd.TargetRegions {
+		regions = append(regions, tr.Name)
+		apiObject := compute.TargetRegion{
+			Name:               to.StringPtr(tr.Name),
+			StorageAccountType: compute.StorageAccountType(tr.StorageAccountType),
+		}
+		if tr.ReplicaCount != 0 {
+			apiObject.RegionalReplicaCount = to.Int32Ptr(tr.ReplicaCount)
+		}
+		targetRegions = append(targetRegions, apiObject)
+	}
+	ui.Say(fmt.Sprintf("Publishing image version %s, replicating to %s...",
+		versionID, strings.Join(regions, ", ")))
+	imageVersion := compute.GalleryImageVersion{
+		Location: to.StringPtr(galleryLocation),
+		GalleryImageVersionProperties: &compute.GalleryImageVersionProperties{
+			StorageProfile: &compute.GalleryImageVersionStorageProfile{
+				Source: &compute.GalleryArtifactVersionSource{ID: to.StringPtr(imageID)},
+			},
+			PublishingProfile: &compute.GalleryImageVersionPublishingProfile{
+				TargetRegions:     &targetRegions,
+				ExcludeFromLatest: to.BoolPtr(sigd.ExcludeFromLatest),
+			},
+		},
+	}
+	vf, err := p.azcli.GalleryImageVersionsClient().CreateOrUpdate(ctx,
+		sigd.ResourceGroup, sigd.GalleryName, sigd.ImageName, sigd.ImageVersion, imageVersion)
+	if err == nil {
+		pollClient := p.azcli.PollClient()
+		pollClient.PollingDelay = 10 * time.Second
+		ctx, cancel := context.WithTimeout(ctx, time.Hour*12)
+		defer cancel()
+		err = vf.WaitForCompletionRef(ctx, pollClient)
+	}
+	if err != nil {
+		return nil, false, false, fmt.Errorf("error creating shared image version '%s': %v", versionID, err)
+	}
+	ui.Say(fmt.Sprintf("Published image version %s", versionID))
+
+	return &azcommon.Artifact{
+		BuilderIdValue: BuilderId,
+		Resources:      []string{imageID, versionID},
+		AzureClientSet: p.azcli,
+		StateData:      map[string]interface{}{"generated_data": generatedData},
+	}, false, false, nil
+}
+
+// verifyGalleryImage checks that the image definition of the destination
+// exists and matches the disk, and returns the location of the gallery.
+func (p *PostProcessor) verifyGalleryImage(ctx context.Context, ui packer.Ui) (string, error) {
+	sigd := p.config.SharedImageGalleryDestination
+	ui.Say(fmt.Sprintf("Validating that shared image %s exists in gallery %s...",
+		sigd.ImageName, sigd.GalleryName))
+
+	image, err := p.azcli.GalleryImagesClient().Get(ctx, sigd.ResourceGroup, sigd.GalleryName, sigd.ImageName)
+	if err != nil {
+		return "", fmt.Errorf("Error retrieving shared image %q: %v", sigd.ImageName, err)
+	}
+	if image.GalleryImageProperties == nil {
+		return "", fmt.Errorf("Could not retrieve shared image properties for image %q", sigd.ImageName)
+	}
+	if !strings.EqualFold(string(image.OsType), p.config.OSType) {
+		return "", fmt.Errorf("The shared image %q is a %s image, not a %s one",
+			sigd.ImageName, image.OsType, p.config.OSType)
+	}
+	if image.HyperVGeneration != "" && !strings.EqualFold(string(image.HyperVGeneration), p.config.HyperVGeneration) {
+		return "", fmt.Errorf("The shared image %q is a %s generation image, not a %s one",
+			sigd.ImageName, image.HyperVGeneration, p.config.HyperVGeneration)
+	}
+	return to.String(image.Location), nil
+}
+
+// openDisk returns the raw content of the disk at path and its size. Fixed
+// VHD disks are read as is, other disks are converted to raw disks first.
+func (p *PostProcessor) openDisk(ui packer.Ui, path string) (io.ReadCloser, int64, error) {
+	if strings.EqualFold(filepath.Ext(path), ".vhd") {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, 0, err
+		}
+		fixed, _, err := readVHDFooter(f)
+		if err != nil {
+			f.Close()
+			return nil, 0, err
+		}
+		if fixed {
+			info, err := f.Stat()
+			if err != nil {
+				f.Close()
+				return nil, 0, err
+			}
+			size := info.Size() - vhdFooterSize
+			return readCloser{io.LimitReader(f, size), f}, size, nil
+		}
+		f.Close()
+	}
+
+	dir, err := ioutil.TempDir("", "packer-azure-compute-gallery")
+	if err != nil {
+		return nil, 0, err
+	}
+	raw := filepath.Join(dir, "disk.raw")
+	ui.Say(fmt.Sprintf("Converting %s to a fixed VHD...", path))
+	if err := p.driver.ConvertToRaw(path, raw); err != nil {
+		os.RemoveAll(dir)
+		return nil, 0, err
+	}
+	f, err := os.Open(raw)
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, 0, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		os.RemoveAll(dir)
+		return nil, 0, err
+	}
+	return readCloser{f, closerFunc(func() error {
+		f.Close()
+		return os.RemoveAll(dir)
+	})}, info.Size(), nil
+}
+
+// artifactDisk returns the first VHD or VHDX disk of the artifact.
+func artifactDisk(artifact packer.Artifact) string {
+	for _, path := range artifact.Files() {
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".vhd", ".vhdx":
+			return path
+		}
+	}
+	return ""
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+type closerFunc func() error
+
+func (f closerFunc) Close() error { return f() }
@@ -0,0 +1,252 @@
+package azurecomputegallery
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2019-12-01/compute"
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/hashicorp/packer/builder/azure/common/client"
+	"github.com/hashicorp/packer/packer"
+)
+
+// memBlob is a pageBlob in memory.
+type memBlob struct {
+	data    []byte
+	writes  int
+	deleted bool
+}
+
+func (b *memBlob) Create(size int64) error {
+	b.data = make([]byte, size)
+	return nil
+}
+
+func (b *memBlob) WriteRange(offset int64, data []byte) error {
+	b.writes++
+	copy(b.data[offset:], data)
+	return nil
+}
+
+func (b *memBlob) Delete() error {
+	b.deleted = true
+	return nil
+}
+
+func (b *memBlob) URL() string {
+	return "https://account.blob.core.windows.net/images/disk.vhd"
+}
+
+// rawDriver "converts" a disk by copying it.
+type rawDriver struct {
+	converted bool
+}
+
+func (d *rawDriver) ConvertToRaw(src string, dst string) error {
+	d.converted = true
+	data, err := ioutil.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(dst, data, 0644)
+}
+
+func testConfig() map[string]interface{} {
+	return map[string]interface{}{
+		"subscription_id": "00000000-0000-0000-0000-000000000000",
+		"client_id":       "client",
+		"client_secret":   "secret",
+		"resource_group":  "group",
+		"location":        "westeurope",
+		"storage_account": "account",
+		"image_name":      "web",
+		"shared_image_gallery_destination": map[string]interface{}{
+			"resource_group": "gallery-group",
+			"gallery_name":   "gallery",
+			"image_name":     "web",
+			"image_version":  "1.0.0",
+		},
+	}
+}
+
+func TestPostProcessor_Configure(t *testing.T) {
+	var p PostProcessor
+	if err := p.Configure(testConfig()); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if p.config.StorageContainer != "images" || p.config.OSType != "Linux" || p.config.HyperVGeneration != "V1" {
+		t.Fatalf("bad defaults: %#v", p.config)
+	}
+	regions := p.config.SharedImageGalleryDestination.TargetRegions
+	if len(regions) != 1 || regions[0].Name != "westeurope" {
+		t.Fatalf("the image version should be replicated to location: %#v", regions)
+	}
+
+	for key, value := range map[string]interface{}{
+		"storage_account":   "",
+		"os_type":           "Plan9",
+		"hyperv_generation": "V3",
+		"shared_image_gallery_destination": map[string]interface{}{
+			"gallery_name": "gallery",
+		},
+	} {
+		raw := testConfig()
+		raw[key] = value
+		p = PostProcessor{}
+		if err := p.Configure(raw); err == nil {
+			t.Fatalf("should have error with %s %#v", key, value)
+		}
+	}
+}
+
+func TestPostProcessor_PostProcess(t *testing.T) {
+	dir, err := ioutil.TempDir("", "packer-azure-compute-gallery")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	// A disk with data in its first and last ranges, and an empty one
+	// between them
+	disk := make([]byte, 9*1024*1024+4096)
+	copy(disk, "boot")
+	copy(disk[len(disk)-4:], "data")
+	source := filepath.Join(dir, "Virtual Hard Disks", "web.vhdx")
+	os.MkdirAll(filepath.Dir(source), 0755)
+	if err := ioutil.WriteFile(source, disk, 0644); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	artifact := &packer.MockArtifact{
+		BuilderIdValue: "MSOpenTech.hyperv",
+		FilesValue:     []string{filepath.Join(dir, "Virtual Machines", "web.vmcx"), source},
+	}
+
+	var imageBody, versionBody string
+	images := compute.NewImagesClient("subscription")
+	images.Sender = autorest.SenderFunc(func(r *http.Request) (*http.Response, error) {
+		b, _ := ioutil.ReadAll(r.Body)
+		imageBody = string(b)
+		return &http.Response{Request: r, StatusCode: 200}, nil
+	})
+	galleryImages := compute.NewGalleryImagesClient("subscription")
+	galleryImages.Sender = autorest.SenderFunc(func(r *http.Request) (*http.Response, error) {
+		return &http.Response{
+			Request:    r,
+			StatusCode: 200,
+			Body:       ioutil.NopCloser(strings.NewReader(`{"location": "northeurope", "properties": {"osType": "Linux"}}`)),
+		}, nil
+	})
+	versions := compute.NewGalleryImageVersionsClient("subscription")
+	versions.Sender = autorest.SenderFunc(func(r *http.Request) (*http.Response, error) {
+		b, _ := ioutil.ReadAll(r.Body)
+		versionBody = string(b)
+		return &http.Response{Request: r, StatusCode: 200}, nil
+	})
+
+	blob := &memBlob{}
+	driver := &rawDriver{}
+	p := PostProcessor{
+		driver: driver,
+		blob:   blob,
+		azcli: &client.AzureClientSetMock{
+			ImagesClientMock:               images,
+			GalleryImagesClientMock:        galleryImages,
+			GalleryImageVersionsClientMock: versions,
+			SubscriptionIDMock:             "subscription",
+		},
+	}
+	if err := p.Configure(testConfig()); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	ui := &packer.BasicUi{
+		Reader: new(bytes.Buffer),
+		Writer: new(bytes.Buffer),
+		PB:     &packer.NoopProgressTracker{},
+	}
+	result, keep, _, err := p.PostProcess(context.Background(), ui, artifact)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if keep {
+		t.Fatal("should not keep the input artifact")
+	}
+	if !driver.converted {
+		t.Fatal("the VHDX disk should be converted")
+	}
+
+	// The disk is padded to 10MB and followed by the footer
+	if len(blob.data) != 10*1024*1024+vhdFooterSize {
+		t.Fatalf("bad blob size: %d", len(blob.data))
+	}
+	if !bytes.Equal(blob.data[:len(disk)], disk) {
+		t.Fatal("bad blob content")
+	}
+	if blob.writes != 3 {
+		t.Fatalf("empty ranges should be skipped, got %d writes", blob.writes)
+	}
+	if !bytes.Equal(blob.data[10*1024*1024:][:8], vhdCookie) {
+		t.Fatal("the blob should end with a VHD footer")
+	}
+	if !blob.deleted {
+		t.Fatal("the blob should be deleted")
+	}
+
+	for _, s := range []string{
+		`"blobUri":"https://account.blob.core.windows.net/images/disk.vhd"`,
+		`"osState":"Generalized"`,
+		`"hyperVGeneration":"V1"`,
+	} {
+		if !strings.Contains(imageBody, s) {
+			t.Fatalf("the image should contain %s: %s", s, imageBody)
+		}
+	}
+	for _, s := range []string{
+		`"location":"northeurope"`,
+		`"source":{"id":"/subscriptions/subscription/resourceGroups/group/providers/Microsoft.Compute/images/web"}`,
+		`"targetRegions":[{"name":"westeurope"}]`,
+	} {
+		if !strings.Contains(versionBody, s) {
+			t.Fatalf("the image version should contain %s: %s", s, versionBody)
+		}
+	}
+
+	expected := "/subscriptions/subscription/resourceGroups/gallery-group/providers/Microsoft.Compute/galleries/gallery/images/web/versions/1.0.0"
+	if !strings.Contains(result.Id(), strings.ToLower(expected)) {
+		t.Fatalf("bad artifact: %s", result.Id())
+	}
+}
+
+func TestPostProcessor_PostProcessOSTypeMismatch(t *testing.T) {
+	galleryImages := compute.NewGalleryImagesClient("subscription")
+	galleryImages.Sender = autorest.SenderFunc(func(r *http.Request) (*http.Response, error) {
+		return &http.Response{
+			Request:    r,
+			StatusCode: 200,
+			Body:       ioutil.NopCloser(strings.NewReader(`{"properties": {"osType": "Windows"}}`)),
+		}, nil
+	})
+	blob := &memBlob{}
+	p := PostProcessor{
+		driver: &rawDriver{},
+		blob:   blob,
+		azcli:  &client.AzureClientSetMock{GalleryImagesClientMock: galleryImages},
+	}
+	if err := p.Configure(testConfig()); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	artifact := &packer.MockArtifact{FilesValue: []string{"web.vhdx"}}
+	_, _, _, err := p.PostProcess(context.Background(), packer.TestUi(t), artifact)
+	if err == nil || !strings.Contains(err.Error(), "Windows") {
+		t.Fatalf("should refuse the image definition: %v", err)
+	}
+	if blob.data != nil {
+		t.Fatal("nothing should be uploaded")
+	}
+}
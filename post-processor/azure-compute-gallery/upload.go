@@ -0,0 +1,148 @@
+package azurecomputegallery
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	armStorage "github.com/Azure/azure-sdk-for-go/services/storage/mgmt/2017-10-01/storage"
+	"github.com/Azure/azure-sdk-for-go/storage"
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/hashicorp/packer/builder/azure/common/client"
+	"github.com/hashicorp/packer/packer"
+	"github.com/hashicorp/packer/packer-plugin-sdk/useragent"
+	"github.com/hashicorp/packer/post-processor/azure-compute-gallery/version"
+)
+
+// maxPageWrite is the largest range written at once in a page blob.
+const maxPageWrite = 4 * 1024 * 1024
+
+// A pageBlob is the blob the disk is uploaded to.
+type pageBlob interface {
+	// Create creates the blob, of size bytes.
+	Create(size int64) error
+	// WriteRange writes data at offset in the blob.
+	WriteRange(offset int64, data []byte) error
+	// Delete deletes the blob, if it exists.
+	Delete() error
+	URL() string
+}
+
+// storageBlob is a pageBlob in a storage account.
+type storageBlob struct {
+	blob *storage.Blob
+}
+
+// newStorageBlob returns the blob name in container of the storage account,
+// creating the container when it doesn't exist.
+func newStorageBlob(ctx context.Context, c client.Config, say func(string), resourceGroup, account, container, name string) (*storageBlob, error) {
+	env := c.CloudEnvironment()
+	token, err := c.GetServicePrincipalToken(say, env.ResourceManagerEndpoint)
+	if err != nil {
+		return nil, err
+	}
+	accounts := armStorage.NewAccountsClientWithBaseURI(env.ResourceManagerEndpoint, c.SubscriptionID)
+	accounts.Authorizer = autorest.NewBearerAuthorizer(token)
+	accounts.AddToUserAgent(useragent.String(version.AzureComputeGalleryPluginVersion.FormattedVersion()))
+
+	keys, err := accounts.ListKeys(ctx, resourceGroup, account)
+	if err != nil {
+		return nil, fmt.Errorf("Error retrieving the keys of storage account %s: %s", account, err)
+	}
+	if keys.Keys == nil || len(*keys.Keys) == 0 {
+		return nil, fmt.Errorf("Storage account %s has no keys", account)
+	}
+	storageClient, err := storage.NewClient(
+		account,
+		*(*keys.Keys)[0].Value,
+		env.StorageEndpointSuffix,
+		storage.DefaultAPIVersion,
+		true /*useHttps*/)
+	if err != nil {
+		return nil, err
+	}
+
+	blobs := storageClient.GetBlobService()
+	cnt := blobs.GetContainerReference(container)
+	if _, err := cnt.CreateIfNotExists(&storage.CreateContainerOptions{Access: storage.ContainerAccessTypePrivate}); err != nil {
+		return nil, fmt.Errorf("Error creating container %s: %s", container, err)
+	}
+	return &storageBlob{blob: cnt.GetBlobReference(name)}, nil
+}
+
+func (b *storageBlob) Create(size int64) error {
+	b.blob.Properties.ContentLength = size
+	return b.blob.PutPageBlob(nil)
+}
+
+func (b *storageBlob) WriteRange(offset int64, data []byte) error {
+	r := storage.BlobRange{Start: uint64(offset), End: uint64(offset + int64(len(data)) - 1)}
+	return b.blob.WriteRange(r, bytes.NewReader(data), nil)
+}
+
+func (b *storageBlob) Delete() error {
+	_, err := b.blob.DeleteIfExists(nil)
+	return err
+}
+
+func (b *storageBlob) URL() string {
+	return b.blob.GetURL()
+}
+
+// uploadDisk uploads the size bytes of the raw disk r to blob as a fixed VHD.
+// The disk is padded to a whole number of megabytes, and its empty ranges are
+// skipped, page blobs being created zeroed.
+func uploadDisk(ctx context.Context, ui packer.Ui, name string, r io.ReadCloser, size int64, blob pageBlob) error {
+	aligned := (size + vhdAlignment - 1) / vhdAlignment * vhdAlignment
+	if err := blob.Create(aligned + vhdFooterSize); err != nil {
+		return fmt.Errorf("Error creating blob %s: %s", blob.URL(), err)
+	}
+
+	body := ui.TrackProgress(name, 0, size, r)
+	defer body.Close()
+
+	buf := make([]byte, maxPageWrite)
+	var offset int64
+	for offset < size {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		n, err := io.ReadFull(body, buf)
+		if err == io.EOF {
+			break
+		}
+		if err != nil && err != io.ErrUnexpectedEOF {
+			return fmt.Errorf("Error reading %s: %s", name, err)
+		}
+		// Ranges are written by whole pages of 512 bytes
+		chunk := buf[:(n+vhdFooterSize-1)/vhdFooterSize*vhdFooterSize]
+		for i := n; i < len(chunk); i++ {
+			chunk[i] = 0
+		}
+		if !isZero(chunk) {
+			if err := blob.WriteRange(offset, chunk); err != nil {
+				return fmt.Errorf("Error uploading %s at offset %d: %s", name, offset, err)
+			}
+		}
+		offset += int64(n)
+	}
+	if offset < size {
+		return fmt.Errorf("Error reading %s: expected %d bytes, read %d", name, size, offset)
+	}
+
+	if err := blob.WriteRange(aligned, vhdFooter(aligned, time.Now())); err != nil {
+		return fmt.Errorf("Error writing the VHD footer of %s: %s", name, err)
+	}
+	return nil
+}
+
+func isZero(b []byte) bool {
+	for _, c := range b {
+		if c != 0 {
+			return false
+		}
+	}
+	return true
+}
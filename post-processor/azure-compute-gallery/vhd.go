@@ -0,0 +1,118 @@
+package azurecomputegallery
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// vhdFooterSize is the size of the footer ending a VHD file. A fixed VHD is
+// the raw disk followed by the footer, which is the only format Azure
+// accepts.
+const vhdFooterSize = 512
+
+// The virtual size of the disks uploaded to Azure must be a whole number of
+// megabytes.
+const vhdAlignment = 1024 * 1024
+
+const (
+	vhdDiskTypeFixed = 2
+	// vhdMaxSectors is the size limit of the CHS geometry of the footer.
+	vhdMaxSectors = 65535 * 16 * 255
+)
+
+var vhdCookie = []byte("conectix")
+
+// vhdEpoch is the origin of the timestamps of VHD footers.
+var vhdEpoch = time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// readVHDFooter reads the footer of the VHD file f. It returns whether the
+// VHD is a fixed one, and its virtual size.
+func readVHDFooter(f *os.File) (bool, int64, error) {
+	info, err := f.Stat()
+	if err != nil {
+		return false, 0, err
+	}
+	if info.Size() < vhdFooterSize {
+		return false, 0, fmt.Errorf("%s is too small to be a VHD", f.Name())
+	}
+	footer := make([]byte, vhdFooterSize)
+	if _, err := f.ReadAt(footer, info.Size()-vhdFooterSize); err != nil && err != io.EOF {
+		return false, 0, err
+	}
+	if !bytes.Equal(footer[:8], vhdCookie) {
+		return false, 0, fmt.Errorf("%s has no VHD footer", f.Name())
+	}
+	size := int64(binary.BigEndian.Uint64(footer[48:56]))
+	fixed := binary.BigEndian.Uint32(footer[60:64]) == vhdDiskTypeFixed
+	return fixed, size, nil
+}
+
+// vhdFooter returns the footer of a fixed VHD of size bytes.
+func vhdFooter(size int64, now time.Time) []byte {
+	footer := make([]byte, vhdFooterSize)
+	copy(footer[0:8], vhdCookie)
+	// Features: reserved bit, always set
+	binary.BigEndian.PutUint32(footer[8:12], 2)
+	binary.BigEndian.PutUint32(footer[12:16], 0x00010000)
+	// Data offset: none for a fixed disk
+	binary.BigEndian.PutUint64(footer[16:24], 0xFFFFFFFFFFFFFFFF)
+	binary.BigEndian.PutUint32(footer[24:28], uint32(now.Sub(vhdEpoch)/time.Second))
+	copy(footer[28:32], "pckr")
+	binary.BigEndian.PutUint32(footer[32:36], 0x00010000)
+	copy(footer[36:40], "Wi2k")
+	binary.BigEndian.PutUint64(footer[40:48], uint64(size))
+	binary.BigEndian.PutUint64(footer[48:56], uint64(size))
+	cylinders, heads, sectors := vhdGeometry(size)
+	binary.BigEndian.PutUint16(footer[56:58], cylinders)
+	footer[58] = heads
+	footer[59] = sectors
+	binary.BigEndian.PutUint32(footer[60:64], vhdDiskTypeFixed)
+	// Unique ID
+	rand.Read(footer[68:84])
+
+	var checksum uint32
+	for _, b := range footer {
+		checksum += uint32(b)
+	}
+	binary.BigEndian.PutUint32(footer[64:68], ^checksum)
+	return footer
+}
+
+// vhdGeometry computes the CHS geometry of a disk of size bytes, as
+// described in the appendix of the VHD specification.
+func vhdGeometry(size int64) (uint16, uint8, uint8) {
+	totalSectors := size / 512
+	if totalSectors > vhdMaxSectors {
+		totalSectors = vhdMaxSectors
+	}
+
+	var sectorsPerTrack, heads, cylinderTimesHeads int64
+	if totalSectors >= 65535*16*63 {
+		sectorsPerTrack = 255
+		heads = 16
+		cylinderTimesHeads = totalSectors / sectorsPerTrack
+	} else {
+		sectorsPerTrack = 17
+		cylinderTimesHeads = totalSectors / sectorsPerTrack
+		heads = (cylinderTimesHeads + 1023) / 1024
+		if heads < 4 {
+			heads = 4
+		}
+		if cylinderTimesHeads >= heads*1024 || heads > 16 {
+			sectorsPerTrack = 31
+			heads = 16
+			cylinderTimesHeads = totalSectors / sectorsPerTrack
+		}
+		if cylinderTimesHeads >= heads*1024 {
+			sectorsPerTrack = 63
+			heads = 16
+			cylinderTimesHeads = totalSectors / sectorsPerTrack
+		}
+	}
+	return uint16(cylinderTimesHeads / heads), uint8(heads), uint8(sectorsPerTrack)
+}
@@ -0,0 +1,68 @@
+package azurecomputegallery
+
+import (
+	"encoding/binary"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestVHDFooter(t *testing.T) {
+	dir, err := ioutil.TempDir("", "packer-vhd")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	size := int64(30 * 1024 * 1024 * 1024)
+	footer := vhdFooter(size, time.Now())
+	if len(footer) != vhdFooterSize {
+		t.Fatalf("bad footer size: %d", len(footer))
+	}
+
+	var sum uint32
+	for i, b := range footer {
+		if i < 64 || i >= 68 {
+			sum += uint32(b)
+		}
+	}
+	if ^sum != binary.BigEndian.Uint32(footer[64:68]) {
+		t.Fatal("bad checksum")
+	}
+
+	// 30GB disks are 62415 cylinders of 16 heads and 63 sectors
+	if c := binary.BigEndian.Uint16(footer[56:58]); c != 62415 || footer[58] != 16 || footer[59] != 63 {
+		t.Fatalf("bad geometry: %d/%d/%d", c, footer[58], footer[59])
+	}
+
+	path := filepath.Join(dir, "disk.vhd")
+	if err := ioutil.WriteFile(path, append(make([]byte, 1024), footer...), 0644); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer f.Close()
+	fixed, virtualSize, err := readVHDFooter(f)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if !fixed || virtualSize != size {
+		t.Fatalf("bad: %t %d", fixed, virtualSize)
+	}
+
+	if err := ioutil.WriteFile(path, make([]byte, 1024), 0644); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	f, err = os.Open(path)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer f.Close()
+	if _, _, err := readVHDFooter(f); err == nil {
+		t.Fatal("should have error without footer")
+	}
+}
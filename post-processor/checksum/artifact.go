@@ -24,6 +24,15 @@ func (a *Artifact) Files() []string {
 	return a.files
 }
 
+func (a *Artifact) hasFile(path string) bool {
+	for _, f := range a.files {
+		if f == path {
+			return true
+		}
+	}
+	return false
+}
+
 func (a *Artifact) Id() string {
 	return ""
 }
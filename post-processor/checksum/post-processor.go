@@ -12,21 +12,29 @@ import (
 	"hash"
 	"io"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"strings"
 
 	"github.com/hashicorp/hcl/v2/hcldec"
 	"github.com/hashicorp/packer/packer"
 	"github.com/hashicorp/packer/packer-plugin-sdk/common"
 	"github.com/hashicorp/packer/packer-plugin-sdk/template/config"
 	"github.com/hashicorp/packer/packer-plugin-sdk/template/interpolate"
+	"golang.org/x/crypto/blake2b"
 )
 
 type Config struct {
 	common.PackerConfig `mapstructure:",squash"`
 
-	ChecksumTypes []string `mapstructure:"checksum_types"`
-	OutputPath    string   `mapstructure:"output"`
-	ctx           interpolate.Context
+	ChecksumTypes        []string `mapstructure:"checksum_types"`
+	OutputPath           string   `mapstructure:"output"`
+	Format               string   `mapstructure:"format"`
+	SigningKey           string   `mapstructure:"signing_key"`
+	SigningKeyID         string   `mapstructure:"signing_key_id"`
+	SigningKeyPassphrase string   `mapstructure:"signing_key_passphrase"`
+	SignatureArmor       bool     `mapstructure:"signature_armor"`
+	ctx                  interpolate.Context
 }
 
 type PostProcessor struct {
@@ -48,10 +56,36 @@ func getHash(t string) hash.Hash {
 		h = sha512.New384()
 	case "sha512":
 		h = sha512.New()
+	case "blake2b":
+		h, _ = blake2b.New512(nil)
 	}
 	return h
 }
 
+// bsdTags are the names of the checksum types in the checksum files of the
+// bsd format, as written by `shasum --tag` and `b2sum --tag`.
+var bsdTags = map[string]string{
+	"md5":     "MD5",
+	"sha1":    "SHA1",
+	"sha224":  "SHA224",
+	"sha256":  "SHA256",
+	"sha384":  "SHA384",
+	"sha512":  "SHA512",
+	"blake2b": "BLAKE2b",
+}
+
+// checksumLine returns the line of a checksum file for the file name.
+func checksumLine(format string, checksumType string, sum []byte, name string) string {
+	switch format {
+	case "gnu":
+		return fmt.Sprintf("%x  %s\n", sum, name)
+	case "bsd":
+		return fmt.Sprintf("%s (%s) = %x\n", bsdTags[checksumType], name, sum)
+	default:
+		return fmt.Sprintf("%x\t%s\n", sum, name)
+	}
+}
+
 func (p *PostProcessor) ConfigSpec() hcldec.ObjectSpec { return p.config.FlatMapstructure().HCL2Spec() }
 
 func (p *PostProcessor) Configure(raws ...interface{}) error {
@@ -79,6 +113,27 @@ func (p *PostProcessor) Configure(raws ...interface{}) error {
 		}
 	}
 
+	switch p.config.Format {
+	case "":
+		p.config.Format = "packer"
+	case "packer", "gnu", "bsd":
+	default:
+		errs = packer.MultiErrorAppend(errs,
+			fmt.Errorf("format must be one of packer, gnu or bsd"))
+	}
+
+	if p.config.SigningKey != "" {
+		if _, err := os.Stat(p.config.SigningKey); err != nil {
+			errs = packer.MultiErrorAppend(errs, fmt.Errorf("Error reading signing_key: %s", err))
+		}
+		if _, err := exec.LookPath("gpg"); err != nil {
+			errs = packer.MultiErrorAppend(errs, fmt.Errorf("signing_key requires gpg: %s", err))
+		}
+	} else if p.config.SigningKeyID != "" || p.config.SigningKeyPassphrase != "" {
+		errs = packer.MultiErrorAppend(errs,
+			fmt.Errorf("signing_key_id and signing_key_passphrase require signing_key"))
+	}
+
 	if p.config.OutputPath == "" {
 		p.config.OutputPath = "packer_{{.BuildName}}_{{.BuilderType}}_{{.ChecksumType}}.checksum"
 	}
@@ -92,12 +147,12 @@ func (p *PostProcessor) Configure(raws ...interface{}) error {
 		return errs
 	}
 
+	packer.LogSecretFilter.Set(p.config.SigningKeyPassphrase)
 	return nil
 }
 
 func (p *PostProcessor) PostProcess(ctx context.Context, ui packer.Ui, artifact packer.Artifact) (packer.Artifact, bool, bool, error) {
 	files := artifact.Files()
-	var h hash.Hash
 
 	var generatedData map[interface{}]interface{}
 	stateData := artifact.State("generated_data")
@@ -115,12 +170,33 @@ func (p *PostProcessor) PostProcess(ctx context.Context, ui packer.Ui, artifact
 
 	newartifact := NewArtifact(artifact.Files())
 
-	for _, ct := range p.config.ChecksumTypes {
-		h = getHash(ct)
-		generatedData["ChecksumType"] = ct
-		p.config.ctx.Data = generatedData
+	// The checksum files written, in order, to sign them
+	var checksumFiles []string
+	written := map[string]bool{}
+
+	for _, art := range files {
+		// Every checksum of the file is computed in a single pass
+		hashes := make([]hash.Hash, len(p.config.ChecksumTypes))
+		writers := make([]io.Writer, len(p.config.ChecksumTypes))
+		for i, ct := range p.config.ChecksumTypes {
+			hashes[i] = getHash(ct)
+			writers[i] = hashes[i]
+		}
+		fr, err := os.Open(art)
+		if err != nil {
+			return nil, false, true, fmt.Errorf("unable to open file %s: %s", art, err.Error())
+		}
+		_, err = io.Copy(io.MultiWriter(writers...), fr)
+		fr.Close()
+		if err != nil {
+			return nil, false, true, fmt.Errorf("unable to compute %s hashes for %s",
+				strings.Join(p.config.ChecksumTypes, ", "), art)
+		}
+
+		for i, ct := range p.config.ChecksumTypes {
+			generatedData["ChecksumType"] = ct
+			p.config.ctx.Data = generatedData
 
-		for _, art := range files {
 			checksumFile, err := interpolate.Render(p.config.OutputPath, &p.config.ctx)
 			if err != nil {
 				return nil, false, true, err
@@ -129,6 +205,10 @@ func (p *PostProcessor) PostProcess(ctx context.Context, ui packer.Ui, artifact
 			if _, err := os.Stat(checksumFile); err != nil {
 				newartifact.files = append(newartifact.files, checksumFile)
 			}
+			if !written[checksumFile] {
+				written[checksumFile] = true
+				checksumFiles = append(checksumFiles, checksumFile)
+			}
 			if err := os.MkdirAll(filepath.Dir(checksumFile), os.FileMode(0755)); err != nil {
 				return nil, false, true, fmt.Errorf("unable to create dir: %s", err.Error())
 			}
@@ -136,21 +216,30 @@ func (p *PostProcessor) PostProcess(ctx context.Context, ui packer.Ui, artifact
 			if err != nil {
 				return nil, false, true, fmt.Errorf("unable to create file %s: %s", checksumFile, err.Error())
 			}
-			fr, err := os.Open(art)
+			_, err = fw.WriteString(checksumLine(p.config.Format, ct, hashes[i].Sum(nil), filepath.Base(art)))
+			fw.Close()
 			if err != nil {
-				fw.Close()
-				return nil, false, true, fmt.Errorf("unable to open file %s: %s", art, err.Error())
+				return nil, false, true, fmt.Errorf("unable to write file %s: %s", checksumFile, err.Error())
 			}
+		}
+	}
+
+	if p.config.SigningKey != "" {
+		signer, err := newGPGSigner(p.config.SigningKey, p.config.SigningKeyID, p.config.SigningKeyPassphrase)
+		if err != nil {
+			return nil, false, true, err
+		}
+		defer signer.Close()
 
-			if _, err = io.Copy(h, fr); err != nil {
-				fr.Close()
-				fw.Close()
-				return nil, false, true, fmt.Errorf("unable to compute %s hash for %s", ct, art)
+		for _, checksumFile := range checksumFiles {
+			ui.Say(fmt.Sprintf("Signing %s with %s", checksumFile, p.config.SigningKey))
+			signature, err := signer.signFile(checksumFile, p.config.SignatureArmor)
+			if err != nil {
+				return nil, false, true, fmt.Errorf("unable to sign %s: %s", checksumFile, err)
+			}
+			if !newartifact.hasFile(signature) {
+				newartifact.files = append(newartifact.files, signature)
 			}
-			fr.Close()
-			fw.WriteString(fmt.Sprintf("%x\t%s\n", h.Sum(nil), filepath.Base(art)))
-			fw.Close()
-			h.Reset()
 		}
 	}
 
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"os/exec"
 	"strings"
 	"testing"
 
@@ -42,6 +43,134 @@ func TestChecksumSHA1(t *testing.T) {
 	defer f.Close()
 }
 
+func TestChecksumMultipleGNU(t *testing.T) {
+	const config = `
+	{
+	    "post-processors": [
+	        {
+	            "type": "checksum",
+	            "checksum_types": ["sha256", "sha512", "blake2b"],
+	            "format": "gnu",
+	            "output": "{{.ChecksumType | upper}}SUMS"
+	        }
+	    ]
+	}
+	`
+	artifact := testChecksum(t, config)
+	defer artifact.Destroy()
+
+	expected := map[string]string{
+		"SHA256SUMS":  "c0535e4be2b79ffd93291305436bf889314e4a3faec05ecffcbb7df31ad9e51a  package.txt\n",
+		"SHA512SUMS":  "f6cde2a0f819314cdde55fc227d8d7dae3d28cc556222a0a8ad66d91ccad4aad6094f517a2182360c9aacf6a3dc323162cb6fd8cdffedb0fe038f55e85ffb5b6  package.txt\n",
+		"BLAKE2BSUMS": "0389abc5ab1e8e170e95aff19d341ecbf88b83a12dd657291ec1254108ea97352c2ff5116902b9fe4021bfe5a6a4372b0f7c9fc2d7dd810c29f85511d1e04c59  package.txt\n",
+	}
+	for name, line := range expected {
+		buf, err := ioutil.ReadFile(name)
+		if err != nil {
+			t.Fatalf("Unable to read checksum file: %s", err)
+		}
+		if string(buf) != line {
+			t.Errorf("Bad %s: %s", name, buf)
+		}
+	}
+}
+
+func TestChecksumBSD(t *testing.T) {
+	const config = `
+	{
+	    "post-processors": [
+	        {
+	            "type": "checksum",
+	            "checksum_types": ["sha1"],
+	            "format": "bsd",
+	            "output": "sha1sums"
+	        }
+	    ]
+	}
+	`
+	artifact := testChecksum(t, config)
+	defer artifact.Destroy()
+
+	buf, err := ioutil.ReadFile("sha1sums")
+	if err != nil {
+		t.Fatalf("Unable to read checksum file: %s", err)
+	}
+	if string(buf) != "SHA1 (package.txt) = d3486ae9136e7856bc42212385ea797094475802\n" {
+		t.Errorf("Bad checksum file: %s", buf)
+	}
+}
+
+// gpg runs gpg on the keyring in home.
+func gpg(t *testing.T, home string, args ...string) []byte {
+	args = append([]string{"--batch", "--homedir", home, "--pinentry-mode", "loopback"}, args...)
+	out, err := exec.Command("gpg", args...).CombinedOutput()
+	if err != nil {
+		t.Fatalf("gpg %s: %s: %s", strings.Join(args, " "), err, out)
+	}
+	return out
+}
+
+func TestChecksumSigned(t *testing.T) {
+	if _, err := exec.LookPath("gpg"); err != nil {
+		t.Skip("gpg not found")
+	}
+	home, err := ioutil.TempDir("", "packer-checksum-test-gpg")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer func() {
+		exec.Command("gpgconf", "--homedir", home, "--kill", "gpg-agent").Run()
+		os.RemoveAll(home)
+	}()
+
+	gpg(t, home, "--passphrase", "secret", "--quick-gen-key", "Packer <packer@example.com>", "ed25519", "sign", "never")
+	keyFile := home + "/key.asc"
+	gpg(t, home, "--passphrase", "secret", "--armor", "--output", keyFile, "--export-secret-keys")
+
+	config := fmt.Sprintf(`
+	{
+	    "post-processors": [
+	        {
+	            "type": "checksum",
+	            "checksum_types": ["sha256"],
+	            "format": "gnu",
+	            "output": "SHA256SUMS",
+	            "signing_key": %q,
+	            "signing_key_id": "packer@example.com",
+	            "signing_key_passphrase": "secret",
+	            "signature_armor": true
+	        }
+	    ]
+	}
+	`, keyFile)
+	artifact := testChecksum(t, config)
+	defer artifact.Destroy()
+
+	found := false
+	for _, f := range artifact.Files() {
+		found = found || f == "SHA256SUMS.asc"
+	}
+	if !found {
+		t.Fatalf("The signature should be in the artifact: %v", artifact.Files())
+	}
+
+	gpg(t, home, "--verify", "SHA256SUMS.asc", "SHA256SUMS")
+}
+
+func TestChecksumConfigure(t *testing.T) {
+	for _, raw := range []map[string]interface{}{
+		{"checksum_types": []string{"crc32"}},
+		{"format": "sfv"},
+		{"signing_key": "/nonexistent/key.asc"},
+		{"signing_key_passphrase": "secret"},
+	} {
+		p := PostProcessor{}
+		if err := p.Configure(raw); err == nil {
+			t.Fatalf("should have error with %#v", raw)
+		}
+	}
+}
+
 // Test Helpers
 
 func setup(t *testing.T) (packer.Ui, packer.Artifact, error) {
@@ -0,0 +1,97 @@
+package checksum
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// gpgSigner signs the checksum files with gpg. The key of signing_key is
+// imported into a keyring of its own, so that the keyring of the user isn't
+// touched.
+type gpgSigner struct {
+	gpg        string
+	home       string
+	keyID      string
+	passphrase string
+}
+
+// newGPGSigner imports the OpenPGP private key at keyPath, ASCII armored or
+// binary, into a new temporary keyring. keyID selects the key to sign with
+// when there are several, the first one is used otherwise. Close removes the
+// keyring.
+func newGPGSigner(keyPath string, keyID string, passphrase string) (*gpgSigner, error) {
+	gpg, err := exec.LookPath("gpg")
+	if err != nil {
+		return nil, fmt.Errorf("signing_key requires gpg: %s", err)
+	}
+	home, err := ioutil.TempDir("", "packer-checksum-gpg")
+	if err != nil {
+		return nil, err
+	}
+	s := &gpgSigner{
+		gpg:        gpg,
+		home:       home,
+		keyID:      keyID,
+		passphrase: passphrase,
+	}
+	if err := s.run("--import", keyPath); err != nil {
+		s.Close()
+		return nil, fmt.Errorf("Error importing signing_key %s: %s", keyPath, err)
+	}
+	return s, nil
+}
+
+// signFile writes a detached signature of the file at path next to it, and
+// returns its path: path.asc for an ASCII armored signature and path.sig
+// otherwise.
+func (s *gpgSigner) signFile(path string, armor bool) (string, error) {
+	signature := path + ".sig"
+	args := []string{"--detach-sign"}
+	if armor {
+		signature = path + ".asc"
+		args = append(args, "--armor")
+	}
+	if s.keyID != "" {
+		args = append(args, "--local-user", s.keyID)
+	}
+	args = append(args, "--output", signature, path)
+
+	if err := s.run(args...); err != nil {
+		os.Remove(signature)
+		return "", err
+	}
+	return signature, nil
+}
+
+// run runs gpg on the keyring of the signer. The passphrase is written to its
+// standard input so that it never shows in the arguments.
+func (s *gpgSigner) run(args ...string) error {
+	args = append([]string{
+		"--batch", "--yes", "--no-tty",
+		"--homedir", s.home,
+		"--pinentry-mode", "loopback",
+		"--passphrase-fd", "0",
+	}, args...)
+
+	var stderr bytes.Buffer
+	cmd := exec.Command(s.gpg, args...)
+	cmd.Stdin = strings.NewReader(s.passphrase + "\n")
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("gpg failed: %s\nStderr: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// Close stops the gpg agent started for the keyring and removes it.
+func (s *gpgSigner) Close() error {
+	if gpgconf, err := exec.LookPath("gpgconf"); err == nil {
+		cmd := exec.Command(gpgconf, "--homedir", s.home, "--kill", "gpg-agent")
+		_ = cmd.Run()
+	}
+	return os.RemoveAll(s.home)
+}
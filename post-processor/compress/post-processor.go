@@ -5,13 +5,17 @@ package compress
 import (
 	"archive/tar"
 	"archive/zip"
+	"bytes"
 	"context"
 	"fmt"
 	"io"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"regexp"
 	"runtime"
+	"strconv"
+	"strings"
 
 	"github.com/biogo/hts/bgzf"
 	"github.com/hashicorp/hcl/v2/hcldec"
@@ -36,13 +40,19 @@ var (
 	filenamePattern = regexp.MustCompile(`(?:\.([a-z0-9]+))`)
 )
 
+// zstdBestCompression is the highest compression level of zstd, the levels
+// above 19 requiring its --ultra flag.
+const zstdBestCompression = 22
+
 type Config struct {
 	common.PackerConfig `mapstructure:",squash"`
 
 	// Fields from config file
-	OutputPath       string `mapstructure:"output"`
-	Format           string `mapstructure:"format"`
-	CompressionLevel int    `mapstructure:"compression_level"`
+	OutputPath         string `mapstructure:"output"`
+	Format             string `mapstructure:"format"`
+	CompressionLevel   int    `mapstructure:"compression_level"`
+	CompressionWorkers int    `mapstructure:"compression_workers"`
+	GzipBackend        string `mapstructure:"gzip_backend"`
 
 	// Derived fields
 	Archive   string
@@ -81,8 +91,33 @@ func (p *PostProcessor) Configure(raws ...interface{}) error {
 		p.config.OutputPath = "packer_{{.BuildName}}_{{.BuilderType}}"
 	}
 
-	if p.config.CompressionLevel > pgzip.BestCompression {
-		p.config.CompressionLevel = pgzip.BestCompression
+	if p.config.CompressionWorkers == 0 {
+		p.config.CompressionWorkers = runtime.GOMAXPROCS(-1)
+	}
+	if p.config.CompressionWorkers < 0 {
+		errs = packer.MultiErrorAppend(
+			errs, fmt.Errorf("compression_workers must be positive"))
+	}
+
+	p.config.detectFromFilename()
+
+	switch p.config.GzipBackend {
+	case "", "pgzip":
+	case "pigz":
+		if p.config.Algorithm == "pgzip" {
+			p.config.Algorithm = "pigz"
+		}
+	default:
+		errs = packer.MultiErrorAppend(
+			errs, fmt.Errorf("gzip_backend must be pgzip or pigz"))
+	}
+
+	maxLevel := pgzip.BestCompression
+	if p.config.Algorithm == "zstd" {
+		maxLevel = zstdBestCompression
+	}
+	if p.config.CompressionLevel > maxLevel {
+		p.config.CompressionLevel = maxLevel
 	}
 	// Technically 0 means "don't compress" but I don't know how to
 	// differentiate between "user entered zero" and "user entered nothing".
@@ -96,8 +131,6 @@ func (p *PostProcessor) Configure(raws ...interface{}) error {
 			errs, fmt.Errorf("Error parsing target template: %s", err))
 	}
 
-	p.config.detectFromFilename()
-
 	if len(errs.Errors) > 0 {
 		return errs
 	}
@@ -154,8 +187,8 @@ func (p *PostProcessor) PostProcess(
 	switch p.config.Algorithm {
 	case "bgzf":
 		ui.Say(fmt.Sprintf("Using bgzf compression with %d cores for %s",
-			runtime.GOMAXPROCS(-1), target))
-		output, err = makeBGZFWriter(outputFile, p.config.CompressionLevel)
+			p.config.CompressionWorkers, target))
+		output, err = makeBGZFWriter(outputFile, p.config.CompressionLevel, p.config.CompressionWorkers)
 		if err != nil {
 			return nil, false, false, fmt.Errorf(errTmpl, p.config.Algorithm, err)
 		}
@@ -178,13 +211,29 @@ func (p *PostProcessor) PostProcess(
 		defer output.Close()
 	case "pgzip":
 		ui.Say(fmt.Sprintf("Using pgzip compression with %d cores for %s",
-			runtime.GOMAXPROCS(-1), target))
-		output, err = makePgzipWriter(outputFile, p.config.CompressionLevel)
+			p.config.CompressionWorkers, target))
+		output, err = makePgzipWriter(outputFile, p.config.CompressionLevel, p.config.CompressionWorkers)
 		if err != nil {
 			return nil, false, false,
 				fmt.Errorf(errTmpl, p.config.Algorithm, err)
 		}
 		defer output.Close()
+	case "pigz":
+		ui.Say(fmt.Sprintf("Using pigz compression with %d cores for %s",
+			p.config.CompressionWorkers, target))
+		output, err = makePigzWriter(ctx, outputFile, p.config.CompressionLevel, p.config.CompressionWorkers)
+		if err != nil {
+			return nil, false, false, fmt.Errorf(errTmpl, p.config.Algorithm, err)
+		}
+		defer output.Close()
+	case "zstd":
+		ui.Say(fmt.Sprintf("Using zstd compression with %d cores for %s",
+			p.config.CompressionWorkers, target))
+		output, err = makeZstdWriter(ctx, outputFile, p.config.CompressionLevel, p.config.CompressionWorkers)
+		if err != nil {
+			return nil, false, false, fmt.Errorf(errTmpl, p.config.Algorithm, err)
+		}
+		defer output.Close()
 	default:
 		output = outputFile
 	}
@@ -233,6 +282,14 @@ func (p *PostProcessor) PostProcess(
 		}
 	}
 
+	// External compressors only report their errors once all their input
+	// is written.
+	if cmd, ok := output.(*commandWriter); ok {
+		if err := cmd.Close(); err != nil {
+			return nil, false, false, fmt.Errorf("Failed to compress %s: %s", target, err)
+		}
+	}
+
 	ui.Say(fmt.Sprintf("Archive %s completed", target))
 
 	return newArtifact, false, false, nil
@@ -248,6 +305,8 @@ func (config *Config) detectFromFilename() {
 		"lz4":  "lz4",
 		"bgzf": "bgzf",
 		"xz":   "xz",
+		"zst":  "zstd",
+		"zstd": "zstd",
 	}
 
 	if config.Format == "" {
@@ -296,8 +355,8 @@ func (config *Config) detectFromFilename() {
 	return
 }
 
-func makeBGZFWriter(output io.WriteCloser, compressionLevel int) (io.WriteCloser, error) {
-	bgzfWriter, err := bgzf.NewWriterLevel(output, compressionLevel, runtime.GOMAXPROCS(-1))
+func makeBGZFWriter(output io.WriteCloser, compressionLevel int, workers int) (io.WriteCloser, error) {
+	bgzfWriter, err := bgzf.NewWriterLevel(output, compressionLevel, workers)
 	if err != nil {
 		return nil, ErrInvalidCompressionLevel
 	}
@@ -320,15 +379,88 @@ func makeXZWriter(output io.WriteCloser) (io.WriteCloser, error) {
 	return xzwriter, nil
 }
 
-func makePgzipWriter(output io.WriteCloser, compressionLevel int) (io.WriteCloser, error) {
+func makePgzipWriter(output io.WriteCloser, compressionLevel int, workers int) (io.WriteCloser, error) {
 	gzipWriter, err := pgzip.NewWriterLevel(output, compressionLevel)
 	if err != nil {
 		return nil, ErrInvalidCompressionLevel
 	}
-	gzipWriter.SetConcurrency(500000, runtime.GOMAXPROCS(-1))
+	gzipWriter.SetConcurrency(500000, workers)
 	return gzipWriter, nil
 }
 
+func makePigzWriter(ctx context.Context, output io.WriteCloser, compressionLevel int, workers int) (io.WriteCloser, error) {
+	args := []string{"-c", "-p", strconv.Itoa(workers)}
+	if compressionLevel > 0 {
+		args = append(args, fmt.Sprintf("-%d", compressionLevel))
+	}
+	return makeCommandWriter(ctx, output, "pigz", args...)
+}
+
+func makeZstdWriter(ctx context.Context, output io.WriteCloser, compressionLevel int, workers int) (io.WriteCloser, error) {
+	args := []string{"-q", "-c", fmt.Sprintf("-T%d", workers)}
+	if compressionLevel > 0 {
+		if compressionLevel > 19 {
+			args = append(args, "--ultra")
+		}
+		args = append(args, fmt.Sprintf("-%d", compressionLevel))
+	}
+	return makeCommandWriter(ctx, output, "zstd", args...)
+}
+
+// commandWriter streams what is written to it to the standard input of a
+// compression command, which writes to the output. Nothing is written to a
+// temporary file.
+type commandWriter struct {
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stderr bytes.Buffer
+	closed bool
+	err    error
+}
+
+func makeCommandWriter(ctx context.Context, output io.Writer, name string, args ...string) (*commandWriter, error) {
+	path, err := exec.LookPath(name)
+	if err != nil {
+		return nil, fmt.Errorf("%s must be installed: %s", name, err)
+	}
+	w := &commandWriter{cmd: exec.CommandContext(ctx, path, args...)}
+	w.cmd.Stdout = output
+	w.cmd.Stderr = &w.stderr
+	w.stdin, err = w.cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := w.cmd.Start(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *commandWriter) Write(b []byte) (int, error) {
+	n, err := w.stdin.Write(b)
+	if err != nil {
+		// The command exited, its error tells why
+		if cerr := w.Close(); cerr != nil {
+			return n, cerr
+		}
+	}
+	return n, err
+}
+
+// Close waits for the command to compress all its input. It can be called
+// more than once.
+func (w *commandWriter) Close() error {
+	if w.closed {
+		return w.err
+	}
+	w.closed = true
+	w.stdin.Close()
+	if err := w.cmd.Wait(); err != nil {
+		w.err = fmt.Errorf("%s: %s", err, strings.TrimSpace(w.stderr.String()))
+	}
+	return w.err
+}
+
 func createTarArchive(files []string, output io.WriteCloser) error {
 	archive := tar.NewWriter(output)
 	defer archive.Close()
@@ -1,11 +1,13 @@
 package compress
 
 import (
+	"bytes"
 	"compress/gzip"
 	"context"
 	"fmt"
 	"io/ioutil"
 	"os"
+	"os/exec"
 	"strings"
 	"testing"
 
@@ -54,6 +56,115 @@ func TestDetectFilename(t *testing.T) {
 	if lotsOfDots.Algorithm != "lz4" {
 		t.Error("Expected to find lz4 algorithm setting")
 	}
+
+	// Test .tar.zst
+	zstdFilename := Config{OutputPath: "test.tar.zst"}
+	zstdFilename.detectFromFilename()
+	if zstdFilename.Archive != "tar" {
+		t.Error("Expected to find tar archive setting")
+	}
+	if zstdFilename.Algorithm != "zstd" {
+		t.Error("Expected to find zstd algorithm setting")
+	}
+}
+
+func TestConfigureCompression(t *testing.T) {
+	p := PostProcessor{}
+	err := p.Configure(map[string]interface{}{
+		"output":              "test.tar.gz",
+		"gzip_backend":        "pigz",
+		"compression_level":   19,
+		"compression_workers": 3,
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if p.config.Algorithm != "pigz" || p.config.CompressionLevel != 9 || p.config.CompressionWorkers != 3 {
+		t.Fatalf("bad config: %#v", p.config)
+	}
+
+	p = PostProcessor{}
+	err = p.Configure(map[string]interface{}{
+		"output":            "test.zst",
+		"compression_level": 19,
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if p.config.Algorithm != "zstd" || p.config.CompressionLevel != 19 || p.config.CompressionWorkers < 1 {
+		t.Fatalf("bad config: %#v", p.config)
+	}
+
+	for _, raw := range []map[string]interface{}{
+		{"gzip_backend": "gzip"},
+		{"compression_workers": -1},
+	} {
+		p = PostProcessor{}
+		if err := p.Configure(raw); err == nil {
+			t.Fatalf("should have error with %#v", raw)
+		}
+	}
+}
+
+func TestCommandWriter(t *testing.T) {
+	if _, err := exec.LookPath("gzip"); err != nil {
+		t.Skip("gzip must be installed")
+	}
+	var buf bytes.Buffer
+	w, err := makeCommandWriter(context.Background(), &buf, "gzip", "-c")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if _, err := w.Write([]byte(expectedFileContents)); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	gzipReader, err := gzip.NewReader(&buf)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	data, _ := ioutil.ReadAll(gzipReader)
+	if string(data) != expectedFileContents {
+		t.Errorf("Expected:\n%s\nFound:\n%s\n", expectedFileContents, data)
+	}
+
+	w, err = makeCommandWriter(context.Background(), ioutil.Discard, "gzip", "--no-such-flag")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	w.Write([]byte(expectedFileContents))
+	if err := w.Close(); err == nil {
+		t.Fatal("the failure of the command should be returned")
+	}
+}
+
+func TestZstdCompress(t *testing.T) {
+	if _, err := exec.LookPath("zstd"); err != nil {
+		t.Skip("zstd must be installed")
+	}
+	const config = `
+	{
+	    "post-processors": [
+	        {
+	            "type": "compress",
+	            "output": "package.txt.zst",
+	            "compression_level": 19
+	        }
+	    ]
+	}
+	`
+	artifact := testArchive(t, config)
+	defer artifact.Destroy()
+
+	data, err := exec.Command("zstd", "-d", "-c", "package.txt.zst").Output()
+	if err != nil {
+		t.Fatalf("Unable to decompress archive: %s", err)
+	}
+	if string(data) != expectedFileContents {
+		t.Errorf("Expected:\n%s\nFound:\n%s\n", expectedFileContents, data)
+	}
 }
 
 const expectedFileContents = "Hello world!"
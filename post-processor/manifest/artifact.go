@@ -1,6 +1,10 @@
 package manifest
 
-import "fmt"
+import (
+	"fmt"
+
+	"github.com/hashicorp/packer/packer"
+)
 
 const BuilderId = "packer.post-processor.manifest"
 
@@ -17,6 +21,10 @@ type Artifact struct {
 	ArtifactId    string            `json:"artifact_id"`
 	PackerRunUUID string            `json:"packer_run_uuid"`
 	CustomData    map[string]string `json:"custom_data"`
+	// Environment is what was captured about the environment Packer ran in.
+	Environment *Environment `json:"environment,omitempty"`
+	// Provenance is how the artifact was made, when Packer knows it.
+	Provenance *packer.Provenance `json:"provenance,omitempty"`
 }
 
 func (a *Artifact) BuilderId() string {
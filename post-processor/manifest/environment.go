@@ -0,0 +1,194 @@
+package manifest
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// Environment is what the manifest records about the environment Packer ran
+// in.
+type Environment struct {
+	Git       *GitMetadata      `json:"git,omitempty"`
+	CI        *CIMetadata       `json:"ci,omitempty"`
+	Variables map[string]string `json:"variables,omitempty"`
+}
+
+// GitMetadata is the state of the git repository of the working directory.
+type GitMetadata struct {
+	Commit string `json:"commit"`
+	Branch string `json:"branch,omitempty"`
+	// Dirty is whether the working tree had uncommitted changes.
+	Dirty bool `json:"dirty"`
+}
+
+// CIMetadata is the continuous integration job running Packer.
+type CIMetadata struct {
+	Provider string `json:"provider"`
+	JobID    string `json:"job_id,omitempty"`
+	JobURL   string `json:"job_url,omitempty"`
+}
+
+// ciProvider detects a continuous integration service from the variables it
+// sets in the environment of its jobs.
+type ciProvider struct {
+	name string
+	// detect is set in the environment of the jobs of the service.
+	detect string
+	jobID  string
+	jobURL func(getenv func(string) string) string
+	// branch is the branch being built, for when the repository is checked
+	// out in detached HEAD.
+	branch []string
+}
+
+func envURL(name string) func(func(string) string) string {
+	return func(getenv func(string) string) string { return getenv(name) }
+}
+
+var ciProviders = []ciProvider{
+	{
+		name:   "github-actions",
+		detect: "GITHUB_ACTIONS",
+		jobID:  "GITHUB_RUN_ID",
+		jobURL: func(getenv func(string) string) string {
+			if getenv("GITHUB_SERVER_URL") == "" || getenv("GITHUB_REPOSITORY") == "" {
+				return ""
+			}
+			return fmt.Sprintf("%s/%s/actions/runs/%s",
+				getenv("GITHUB_SERVER_URL"), getenv("GITHUB_REPOSITORY"), getenv("GITHUB_RUN_ID"))
+		},
+		branch: []string{"GITHUB_HEAD_REF", "GITHUB_REF_NAME"},
+	},
+	{
+		name:   "gitlab",
+		detect: "GITLAB_CI",
+		jobID:  "CI_JOB_ID",
+		jobURL: envURL("CI_JOB_URL"),
+		branch: []string{"CI_COMMIT_REF_NAME"},
+	},
+	{
+		name:   "jenkins",
+		detect: "JENKINS_URL",
+		jobID:  "BUILD_ID",
+		jobURL: envURL("BUILD_URL"),
+		branch: []string{"BRANCH_NAME", "GIT_BRANCH"},
+	},
+	{
+		name:   "circleci",
+		detect: "CIRCLECI",
+		jobID:  "CIRCLE_BUILD_NUM",
+		jobURL: envURL("CIRCLE_BUILD_URL"),
+		branch: []string{"CIRCLE_BRANCH"},
+	},
+	{
+		name:   "buildkite",
+		detect: "BUILDKITE",
+		jobID:  "BUILDKITE_BUILD_ID",
+		jobURL: envURL("BUILDKITE_BUILD_URL"),
+		branch: []string{"BUILDKITE_BRANCH"},
+	},
+	{
+		name:   "azure-pipelines",
+		detect: "TF_BUILD",
+		jobID:  "BUILD_BUILDID",
+		jobURL: func(getenv func(string) string) string {
+			if getenv("SYSTEM_COLLECTIONURI") == "" || getenv("SYSTEM_TEAMPROJECT") == "" {
+				return ""
+			}
+			return fmt.Sprintf("%s%s/_build/results?buildId=%s",
+				getenv("SYSTEM_COLLECTIONURI"), getenv("SYSTEM_TEAMPROJECT"), getenv("BUILD_BUILDID"))
+		},
+		branch: []string{"BUILD_SOURCEBRANCHNAME"},
+	},
+}
+
+// detectCI returns the continuous integration job running Packer, or nil
+// when Packer doesn't run in a known service, along with the provider.
+func detectCI(getenv func(string) string) (*CIMetadata, *ciProvider) {
+	for i, p := range ciProviders {
+		if getenv(p.detect) == "" {
+			continue
+		}
+		return &CIMetadata{
+			Provider: p.name,
+			JobID:    getenv(p.jobID),
+			JobURL:   p.jobURL(getenv),
+		}, &ciProviders[i]
+	}
+	return nil, nil
+}
+
+// gitMetadata returns the state of the git repository of the working
+// directory.
+func gitMetadata() (*GitMetadata, error) {
+	commit, err := git("rev-parse", "HEAD")
+	if err != nil {
+		return nil, err
+	}
+	branch, err := git("rev-parse", "--abbrev-ref", "HEAD")
+	if err != nil {
+		return nil, err
+	}
+	if branch == "HEAD" {
+		// Detached HEAD, as CI services usually check the repository out
+		branch = ""
+	}
+	status, err := git("status", "--porcelain", "--untracked-files=no")
+	if err != nil {
+		return nil, err
+	}
+	return &GitMetadata{
+		Commit: commit,
+		Branch: branch,
+		Dirty:  status != "",
+	}, nil
+}
+
+func git(args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command("git", args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("git %s: %s: %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// captureEnvironment returns what the configuration asks to record about the
+// environment, or nil when it asks for nothing. Failing to read the git
+// repository isn't fatal, the error is returned along with the rest.
+func captureEnvironment(c *Config, getenv func(string) string) (*Environment, error) {
+	if !c.CaptureGit && !c.CaptureCI && len(c.EnvironmentVariables) == 0 {
+		return nil, nil
+	}
+	env := &Environment{}
+
+	ci, provider := detectCI(getenv)
+	if c.CaptureCI {
+		env.CI = ci
+	}
+
+	var gitErr error
+	if c.CaptureGit {
+		env.Git, gitErr = gitMetadata()
+		if env.Git != nil && env.Git.Branch == "" && provider != nil {
+			for _, name := range provider.branch {
+				if branch := getenv(name); branch != "" {
+					env.Git.Branch = branch
+					break
+				}
+			}
+		}
+	}
+
+	for _, name := range c.EnvironmentVariables {
+		if env.Variables == nil {
+			env.Variables = make(map[string]string)
+		}
+		env.Variables[name] = getenv(name)
+	}
+	return env, gitErr
+}
@@ -0,0 +1,103 @@
+package manifest
+
+import (
+	"testing"
+)
+
+func testGetenv(env map[string]string) func(string) string {
+	return func(name string) string { return env[name] }
+}
+
+func TestDetectCI(t *testing.T) {
+	cases := []struct {
+		env      map[string]string
+		expected *CIMetadata
+	}{
+		{
+			env:      map[string]string{},
+			expected: nil,
+		},
+		{
+			env: map[string]string{
+				"GITHUB_ACTIONS":    "true",
+				"GITHUB_SERVER_URL": "https://github.com",
+				"GITHUB_REPOSITORY": "example/images",
+				"GITHUB_RUN_ID":     "1234",
+			},
+			expected: &CIMetadata{
+				Provider: "github-actions",
+				JobID:    "1234",
+				JobURL:   "https://github.com/example/images/actions/runs/1234",
+			},
+		},
+		{
+			env: map[string]string{
+				"GITLAB_CI":  "true",
+				"CI_JOB_ID":  "42",
+				"CI_JOB_URL": "https://gitlab.example.com/images/-/jobs/42",
+			},
+			expected: &CIMetadata{
+				Provider: "gitlab",
+				JobID:    "42",
+				JobURL:   "https://gitlab.example.com/images/-/jobs/42",
+			},
+		},
+		{
+			env: map[string]string{
+				"TF_BUILD":             "True",
+				"BUILD_BUILDID":        "7",
+				"SYSTEM_COLLECTIONURI": "https://dev.azure.com/example/",
+				"SYSTEM_TEAMPROJECT":   "images",
+			},
+			expected: &CIMetadata{
+				Provider: "azure-pipelines",
+				JobID:    "7",
+				JobURL:   "https://dev.azure.com/example/images/_build/results?buildId=7",
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		ci, _ := detectCI(testGetenv(tc.env))
+		if tc.expected == nil {
+			if ci != nil {
+				t.Fatalf("no CI should be detected in %#v: %#v", tc.env, ci)
+			}
+			continue
+		}
+		if ci == nil || *ci != *tc.expected {
+			t.Fatalf("bad CI for %#v: %#v", tc.env, ci)
+		}
+	}
+}
+
+func TestCaptureEnvironment(t *testing.T) {
+	env, err := captureEnvironment(&Config{}, testGetenv(nil))
+	if env != nil || err != nil {
+		t.Fatalf("nothing should be captured by default: %#v, %v", env, err)
+	}
+
+	config := &Config{
+		CaptureCI:            true,
+		EnvironmentVariables: []string{"IMAGE_CHANNEL", "UNSET"},
+	}
+	env, err = captureEnvironment(config, testGetenv(map[string]string{
+		"IMAGE_CHANNEL": "stable",
+		"JENKINS_URL":   "https://jenkins.example.com/",
+		"BUILD_URL":     "https://jenkins.example.com/job/images/3/",
+		"BUILD_ID":      "3",
+		"SECRET":        "hunter2",
+	}))
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if env.Git != nil {
+		t.Fatal("git should not be captured")
+	}
+	if env.CI == nil || env.CI.JobURL != "https://jenkins.example.com/job/images/3/" {
+		t.Fatalf("bad CI: %#v", env.CI)
+	}
+	if len(env.Variables) != 2 || env.Variables["IMAGE_CHANNEL"] != "stable" || env.Variables["UNSET"] != "" {
+		t.Fatalf("bad variables: %#v", env.Variables)
+	}
+}
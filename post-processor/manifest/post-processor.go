@@ -29,20 +29,47 @@ type Config struct {
 	// Write only filename without the path to the manifest file. This defaults
 	// to false.
 	StripPath bool `mapstructure:"strip_path"`
-	// Don't write the `build_time` field from the output.
+	// Don't write the `build_time` field, and the times of the `provenance`
+	// field, from the output.
 	StripTime bool `mapstructure:"strip_time"`
 	// Arbitrary data to add to the manifest. This is a [template
 	// engine](https://packer.io/docs/templates/engine.html). Therefore, you
-	// may use user variables and template functions in this field.
+	// may use user variables and template functions in this field. In HCL2
+	// templates, the values can be any expression, like variables, locals
+	// or function calls, which are resolved before being written.
 	CustomData map[string]string `mapstructure:"custom_data"`
-	ctx        interpolate.Context
+	// Record the commit and the branch of the git repository of the working
+	// directory, and whether its working tree has uncommitted changes, in
+	// the `environment.git` field. A warning is displayed when the working
+	// directory isn't in a git repository. This defaults to false.
+	CaptureGit bool `mapstructure:"capture_git"`
+	// Record the continuous integration job running Packer, with its URL,
+	// in the `environment.ci` field. GitHub Actions, GitLab CI, Jenkins,
+	// CircleCI, Buildkite and Azure Pipelines are detected from the
+	// variables they set in the environment of their jobs. This defaults to
+	// false.
+	CaptureCI bool `mapstructure:"capture_ci"`
+	// The names of the environment variables to record in the
+	// `environment.variables` field. Unset variables are recorded as empty
+	// strings. Don't list variables holding secrets, the manifest is written
+	// in clear text.
+	EnvironmentVariables []string `mapstructure:"environment_variables"`
+	ctx                  interpolate.Context
 }
 
 type PostProcessor struct {
 	config Config
 }
 
+// ManifestVersion is the version of the structure of the manifest file. It
+// is increased when a change could break the tools reading the manifest,
+// like removing or renaming a field, but not when fields are added.
+const ManifestVersion = 1
+
 type ManifestFile struct {
+	// Version is the ManifestVersion of the manifest, 0 for the manifests
+	// written before the version was recorded.
+	Version     int        `json:"version"`
 	Builds      []Artifact `json:"builds"`
 	LastRunUUID string     `json:"last_run_uuid"`
 }
@@ -81,17 +108,25 @@ func (p *PostProcessor) PostProcess(ctx context.Context, ui packer.Ui, source pa
 	}
 	p.config.ctx.Data = generatedData
 
+	var customData map[string]string
 	for key, data := range p.config.CustomData {
 		interpolatedData, err := createInterpolatedCustomData(&p.config, data)
 		if err != nil {
 			return nil, false, false, err
 		}
-		p.config.CustomData[key] = interpolatedData
+		if customData == nil {
+			customData = make(map[string]string, len(p.config.CustomData))
+		}
+		customData[key] = interpolatedData
+	}
+
+	environment, err := captureEnvironment(&p.config, os.Getenv)
+	if err != nil {
+		ui.Error(fmt.Sprintf("Warning: Unable to read the git repository of the working directory: %s", err))
 	}
 
 	artifact := &Artifact{}
 
-	var err error
 	var fi os.FileInfo
 
 	// Create the current artifact.
@@ -108,12 +143,18 @@ func (p *PostProcessor) PostProcess(ctx context.Context, ui packer.Ui, source pa
 		artifact.ArtifactFiles = append(artifact.ArtifactFiles, af)
 	}
 	artifact.ArtifactId = source.Id()
-	artifact.CustomData = p.config.CustomData
+	artifact.CustomData = customData
+	artifact.Environment = environment
 	artifact.BuilderType = p.config.PackerBuilderType
 	artifact.BuildName = p.config.PackerBuildName
 	artifact.BuildTime = time.Now().Unix()
+	artifact.Provenance = packer.ArtifactProvenance(source)
 	if p.config.StripTime {
 		artifact.BuildTime = 0
+		if artifact.Provenance != nil {
+			artifact.Provenance.StartTime = 0
+			artifact.Provenance.EndTime = 0
+		}
 	}
 	// Since each post-processor runs in a different process we need a way to
 	// coordinate between various post-processors in a single packer run. We do
@@ -150,6 +191,11 @@ func (p *PostProcessor) PostProcess(ctx context.Context, ui packer.Ui, source pa
 		if err = json.Unmarshal(contents, manifestFile); err != nil {
 			return source, true, true, fmt.Errorf("Unable to parse content from %s: %s", p.config.OutputPath, err)
 		}
+		if manifestFile.Version > ManifestVersion {
+			return source, true, true, fmt.Errorf("The manifest %s has version %d, which is newer than "+
+				"the version %d this version of Packer writes. Remove it or write to another file.",
+				p.config.OutputPath, manifestFile.Version, ManifestVersion)
+		}
 	}
 
 	// If -force is set and we are not on same run, truncate the file. Otherwise
@@ -159,6 +205,7 @@ func (p *PostProcessor) PostProcess(ctx context.Context, ui packer.Ui, source pa
 	}
 
 	// Add the current artifact to the manifest file
+	manifestFile.Version = ManifestVersion
 	manifestFile.Builds = append(manifestFile.Builds, *artifact)
 	manifestFile.LastRunUUID = os.Getenv("PACKER_RUN_UUID")
 
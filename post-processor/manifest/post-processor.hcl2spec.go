@@ -9,18 +9,23 @@ import (
 // FlatConfig is an auto-generated flat version of Config.
 // Where the contents of a field with a `mapstructure:,squash` tag are bubbled up.
 type FlatConfig struct {
-	PackerBuildName     *string           `mapstructure:"packer_build_name" cty:"packer_build_name" hcl:"packer_build_name"`
-	PackerBuilderType   *string           `mapstructure:"packer_builder_type" cty:"packer_builder_type" hcl:"packer_builder_type"`
-	PackerCoreVersion   *string           `mapstructure:"packer_core_version" cty:"packer_core_version" hcl:"packer_core_version"`
-	PackerDebug         *bool             `mapstructure:"packer_debug" cty:"packer_debug" hcl:"packer_debug"`
-	PackerForce         *bool             `mapstructure:"packer_force" cty:"packer_force" hcl:"packer_force"`
-	PackerOnError       *string           `mapstructure:"packer_on_error" cty:"packer_on_error" hcl:"packer_on_error"`
-	PackerUserVars      map[string]string `mapstructure:"packer_user_variables" cty:"packer_user_variables" hcl:"packer_user_variables"`
-	PackerSensitiveVars []string          `mapstructure:"packer_sensitive_variables" cty:"packer_sensitive_variables" hcl:"packer_sensitive_variables"`
-	OutputPath          *string           `mapstructure:"output" cty:"output" hcl:"output"`
-	StripPath           *bool             `mapstructure:"strip_path" cty:"strip_path" hcl:"strip_path"`
-	StripTime           *bool             `mapstructure:"strip_time" cty:"strip_time" hcl:"strip_time"`
-	CustomData          map[string]string `mapstructure:"custom_data" cty:"custom_data" hcl:"custom_data"`
+	PackerBuildName      *string           `mapstructure:"packer_build_name" cty:"packer_build_name" hcl:"packer_build_name"`
+	PackerBuilderType    *string           `mapstructure:"packer_builder_type" cty:"packer_builder_type" hcl:"packer_builder_type"`
+	PackerCoreVersion    *string           `mapstructure:"packer_core_version" cty:"packer_core_version" hcl:"packer_core_version"`
+	PackerDebug          *bool             `mapstructure:"packer_debug" cty:"packer_debug" hcl:"packer_debug"`
+	PackerForce          *bool             `mapstructure:"packer_force" cty:"packer_force" hcl:"packer_force"`
+	PackerOnError        *string           `mapstructure:"packer_on_error" cty:"packer_on_error" hcl:"packer_on_error"`
+	PackerResume         *bool             `mapstructure:"packer_resume" cty:"packer_resume" hcl:"packer_resume"`
+	PackerCleanupTimeout *string           `mapstructure:"packer_cleanup_timeout" cty:"packer_cleanup_timeout" hcl:"packer_cleanup_timeout"`
+	PackerUserVars       map[string]string `mapstructure:"packer_user_variables" cty:"packer_user_variables" hcl:"packer_user_variables"`
+	PackerSensitiveVars  []string          `mapstructure:"packer_sensitive_variables" cty:"packer_sensitive_variables" hcl:"packer_sensitive_variables"`
+	OutputPath           *string           `mapstructure:"output" cty:"output" hcl:"output"`
+	StripPath            *bool             `mapstructure:"strip_path" cty:"strip_path" hcl:"strip_path"`
+	StripTime            *bool             `mapstructure:"strip_time" cty:"strip_time" hcl:"strip_time"`
+	CustomData           map[string]string `mapstructure:"custom_data" cty:"custom_data" hcl:"custom_data"`
+	CaptureGit           *bool             `mapstructure:"capture_git" cty:"capture_git" hcl:"capture_git"`
+	CaptureCI            *bool             `mapstructure:"capture_ci" cty:"capture_ci" hcl:"capture_ci"`
+	EnvironmentVariables []string          `mapstructure:"environment_variables" cty:"environment_variables" hcl:"environment_variables"`
 }
 
 // FlatMapstructure returns a new FlatConfig.
@@ -41,12 +46,17 @@ func (*FlatConfig) HCL2Spec() map[string]hcldec.Spec {
 		"packer_debug":               &hcldec.AttrSpec{Name: "packer_debug", Type: cty.Bool, Required: false},
 		"packer_force":               &hcldec.AttrSpec{Name: "packer_force", Type: cty.Bool, Required: false},
 		"packer_on_error":            &hcldec.AttrSpec{Name: "packer_on_error", Type: cty.String, Required: false},
+		"packer_resume":              &hcldec.AttrSpec{Name: "packer_resume", Type: cty.Bool, Required: false},
+		"packer_cleanup_timeout":     &hcldec.AttrSpec{Name: "packer_cleanup_timeout", Type: cty.String, Required: false},
 		"packer_user_variables":      &hcldec.AttrSpec{Name: "packer_user_variables", Type: cty.Map(cty.String), Required: false},
 		"packer_sensitive_variables": &hcldec.AttrSpec{Name: "packer_sensitive_variables", Type: cty.List(cty.String), Required: false},
 		"output":                     &hcldec.AttrSpec{Name: "output", Type: cty.String, Required: false},
 		"strip_path":                 &hcldec.AttrSpec{Name: "strip_path", Type: cty.Bool, Required: false},
 		"strip_time":                 &hcldec.AttrSpec{Name: "strip_time", Type: cty.Bool, Required: false},
 		"custom_data":                &hcldec.AttrSpec{Name: "custom_data", Type: cty.Map(cty.String), Required: false},
+		"capture_git":                &hcldec.AttrSpec{Name: "capture_git", Type: cty.Bool, Required: false},
+		"capture_ci":                 &hcldec.AttrSpec{Name: "capture_ci", Type: cty.Bool, Required: false},
+		"environment_variables":      &hcldec.AttrSpec{Name: "environment_variables", Type: cty.List(cty.String), Required: false},
 	}
 	return s
 }
@@ -0,0 +1,82 @@
+package manifest
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/packer/packer"
+)
+
+func testManifest(t *testing.T, output string, raw map[string]interface{}) error {
+	raw["output"] = output
+	p := &PostProcessor{}
+	if err := p.Configure(raw); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	artifact := &packer.MockArtifact{IdValue: "image-1"}
+	_, _, _, err := p.PostProcess(context.Background(), packer.TestUi(t), artifact)
+	return err
+}
+
+func TestPostProcessor_PostProcess(t *testing.T) {
+	dir, err := ioutil.TempDir("", "packer")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer os.RemoveAll(dir)
+	output := filepath.Join(dir, "manifest.json")
+
+	err = testManifest(t, output, map[string]interface{}{
+		"custom_data": map[string]string{
+			"channel": "{{ upper `stable` }}",
+		},
+		"environment_variables": []string{"PACKER_TEST_MANIFEST"},
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	contents, err := ioutil.ReadFile(output)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	manifest := &ManifestFile{}
+	if err := json.Unmarshal(contents, manifest); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if manifest.Version != ManifestVersion {
+		t.Fatalf("bad version: %d", manifest.Version)
+	}
+	if len(manifest.Builds) != 1 {
+		t.Fatalf("bad builds: %#v", manifest.Builds)
+	}
+	build := manifest.Builds[0]
+	if build.CustomData["channel"] != "STABLE" {
+		t.Fatalf("bad custom data: %#v", build.CustomData)
+	}
+	if build.Environment == nil || build.Environment.Variables["PACKER_TEST_MANIFEST"] != "" {
+		t.Fatalf("bad environment: %#v", build.Environment)
+	}
+}
+
+func TestPostProcessor_PostProcessNewerVersion(t *testing.T) {
+	dir, err := ioutil.TempDir("", "packer")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer os.RemoveAll(dir)
+	output := filepath.Join(dir, "manifest.json")
+	if err := ioutil.WriteFile(output, []byte(`{"version": 99, "builds": []}`), 0644); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	err = testManifest(t, output, map[string]interface{}{})
+	if err == nil || !strings.Contains(err.Error(), "version 99") {
+		t.Fatalf("a manifest with a newer version should not be modified: %v", err)
+	}
+}
@@ -0,0 +1,58 @@
+package ova
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os/exec"
+	"strings"
+)
+
+// A Driver converts the disks of the artifact.
+type Driver interface {
+	// VirtualSize returns the size of the disk as seen by the machine, in
+	// bytes.
+	VirtualSize(path string) (int64, error)
+	// ConvertToVMDK converts the disk at src to a stream optimized VMDK
+	// disk at dst.
+	ConvertToVMDK(src string, dst string) error
+}
+
+// QemuImgDriver is a Driver running qemu-img.
+type QemuImgDriver struct {
+	QemuImgPath string
+}
+
+func (d *QemuImgDriver) VirtualSize(path string) (int64, error) {
+	out, err := d.run("info", "--output=json", path)
+	if err != nil {
+		return 0, err
+	}
+	var info struct {
+		VirtualSize int64 `json:"virtual-size"`
+	}
+	if err := json.Unmarshal(out, &info); err != nil {
+		return 0, fmt.Errorf("Error reading the information of %s: %s", path, err)
+	}
+	return info.VirtualSize, nil
+}
+
+func (d *QemuImgDriver) ConvertToVMDK(src string, dst string) error {
+	_, err := d.run("convert", "-O", "vmdk", "-o", "subformat=streamOptimized", src, dst)
+	return err
+}
+
+func (d *QemuImgDriver) run(args ...string) ([]byte, error) {
+	var stdout, stderr bytes.Buffer
+
+	log.Printf("Executing %s: %#v", d.QemuImgPath, args)
+	cmd := exec.Command(d.QemuImgPath, args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("%s %s failed: %s\nStderr: %s", d.QemuImgPath, args[0], err,
+			strings.TrimSpace(stderr.String()))
+	}
+	return stdout.Bytes(), nil
+}
@@ -0,0 +1,139 @@
+package ova
+
+import (
+	"bytes"
+	"text/template"
+)
+
+// The network adapters by network_adapter_type, as OVF resource subtypes.
+var networkAdapters = map[string]string{
+	"e1000":   "E1000",
+	"e1000e":  "E1000e",
+	"vmxnet3": "VmxNet3",
+}
+
+type ovfDisk struct {
+	File string
+	// The size of the file, in bytes.
+	Size int64
+	// The size of the disk, in bytes.
+	Capacity int64
+}
+
+// UnitNumber returns the address of the disk at index i on the SCSI
+// controller, which reserves unit 7 for itself.
+func (ovfDisk) UnitNumber(i int) int {
+	if i >= 7 {
+		return i + 1
+	}
+	return i
+}
+
+type ovfDescriptor struct {
+	Name            string
+	GuestOSType     string
+	HardwareVersion int
+	CPUs            int
+	Memory          int
+	Firmware        string
+	Network         string
+	NetworkAdapter  string
+	Disks           []ovfDisk
+}
+
+var ovfTemplate = template.Must(template.New("ovf").Funcs(template.FuncMap{
+	"xml": template.HTMLEscapeString,
+	"add": func(a, b int) int { return a + b },
+}).Parse(`<?xml version="1.0" encoding="UTF-8"?>
+<Envelope xmlns="http://schemas.dmtf.org/ovf/envelope/1" xmlns:cim="http://schemas.dmtf.org/wbem/wscim/1/common" xmlns:ovf="http://schemas.dmtf.org/ovf/envelope/1" xmlns:rasd="http://schemas.dmtf.org/wbem/wscim/1/cim-schema/2/CIM_ResourceAllocationSettingData" xmlns:vmw="http://www.vmware.com/schema/ovf" xmlns:vssd="http://schemas.dmtf.org/wbem/wscim/1/cim-schema/2/CIM_VirtualSystemSettingData" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance">
+  <References>
+{{- range $i, $d := .Disks}}
+    <File ovf:href="{{xml $d.File}}" ovf:id="file{{add $i 1}}" ovf:size="{{$d.Size}}"/>
+{{- end}}
+  </References>
+  <DiskSection>
+    <Info>Virtual disk information</Info>
+{{- range $i, $d := .Disks}}
+    <Disk ovf:capacity="{{$d.Capacity}}" ovf:capacityAllocationUnits="byte" ovf:diskId="vmdisk{{add $i 1}}" ovf:fileRef="file{{add $i 1}}" ovf:format="http://www.vmware.com/interfaces/specifications/vmdk.html#streamOptimized"/>
+{{- end}}
+  </DiskSection>
+  <NetworkSection>
+    <Info>The list of logical networks</Info>
+    <Network ovf:name="{{xml .Network}}">
+      <Description>The {{xml .Network}} network</Description>
+    </Network>
+  </NetworkSection>
+  <VirtualSystem ovf:id="{{xml .Name}}">
+    <Info>A virtual machine</Info>
+    <Name>{{xml .Name}}</Name>
+    <OperatingSystemSection ovf:id="1" vmw:osType="{{xml .GuestOSType}}">
+      <Info>The kind of installed guest operating system</Info>
+    </OperatingSystemSection>
+    <VirtualHardwareSection>
+      <Info>Virtual hardware requirements</Info>
+      <System>
+        <vssd:ElementName>Virtual Hardware Family</vssd:ElementName>
+        <vssd:InstanceID>0</vssd:InstanceID>
+        <vssd:VirtualSystemIdentifier>{{xml .Name}}</vssd:VirtualSystemIdentifier>
+        <vssd:VirtualSystemType>vmx-{{printf "%02d" .HardwareVersion}}</vssd:VirtualSystemType>
+      </System>
+      <Item>
+        <rasd:AllocationUnits>hertz * 10^6</rasd:AllocationUnits>
+        <rasd:Description>Number of Virtual CPUs</rasd:Description>
+        <rasd:ElementName>{{.CPUs}} virtual CPU(s)</rasd:ElementName>
+        <rasd:InstanceID>1</rasd:InstanceID>
+        <rasd:ResourceType>3</rasd:ResourceType>
+        <rasd:VirtualQuantity>{{.CPUs}}</rasd:VirtualQuantity>
+      </Item>
+      <Item>
+        <rasd:AllocationUnits>byte * 2^20</rasd:AllocationUnits>
+        <rasd:Description>Memory Size</rasd:Description>
+        <rasd:ElementName>{{.Memory}}MB of memory</rasd:ElementName>
+        <rasd:InstanceID>2</rasd:InstanceID>
+        <rasd:ResourceType>4</rasd:ResourceType>
+        <rasd:VirtualQuantity>{{.Memory}}</rasd:VirtualQuantity>
+      </Item>
+      <Item>
+        <rasd:Address>0</rasd:Address>
+        <rasd:Description>SCSI Controller</rasd:Description>
+        <rasd:ElementName>SCSI Controller 0</rasd:ElementName>
+        <rasd:InstanceID>3</rasd:InstanceID>
+        <rasd:ResourceSubType>lsilogic</rasd:ResourceSubType>
+        <rasd:ResourceType>6</rasd:ResourceType>
+      </Item>
+      <Item>
+        <rasd:AddressOnParent>7</rasd:AddressOnParent>
+        <rasd:AutomaticAllocation>true</rasd:AutomaticAllocation>
+        <rasd:Connection>{{xml .Network}}</rasd:Connection>
+        <rasd:Description>{{.NetworkAdapter}} ethernet adapter on &quot;{{xml .Network}}&quot;</rasd:Description>
+        <rasd:ElementName>Network adapter 1</rasd:ElementName>
+        <rasd:InstanceID>4</rasd:InstanceID>
+        <rasd:ResourceSubType>{{.NetworkAdapter}}</rasd:ResourceSubType>
+        <rasd:ResourceType>10</rasd:ResourceType>
+      </Item>
+{{- range $i, $d := .Disks}}
+      <Item>
+        <rasd:AddressOnParent>{{$d.UnitNumber $i}}</rasd:AddressOnParent>
+        <rasd:ElementName>Hard Disk {{add $i 1}}</rasd:ElementName>
+        <rasd:HostResource>ovf:/disk/vmdisk{{add $i 1}}</rasd:HostResource>
+        <rasd:InstanceID>{{add $i 10}}</rasd:InstanceID>
+        <rasd:Parent>3</rasd:Parent>
+        <rasd:ResourceType>17</rasd:ResourceType>
+      </Item>
+{{- end}}
+{{- if eq .Firmware "efi"}}
+      <vmw:Config ovf:required="false" vmw:key="firmware" vmw:value="efi"/>
+{{- end}}
+    </VirtualHardwareSection>
+  </VirtualSystem>
+</Envelope>
+`))
+
+// render returns the OVF descriptor of the machine.
+func (d *ovfDescriptor) render() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := ovfTemplate.Execute(&buf, d); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
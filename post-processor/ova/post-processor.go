@@ -0,0 +1,383 @@
+//go:generate mapstructure-to-hcl2 -type Config
+
+// ova implements the packer.PostProcessor interface and adds a
+// post-processor that converts the disks of Hyper-V and QEMU artifacts into
+// an OVA, an archive of an OVF machine.
+package ova
+
+import (
+	"archive/tar"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/hcl/v2/hcldec"
+	"github.com/hashicorp/packer/packer"
+	"github.com/hashicorp/packer/packer-plugin-sdk/common"
+	"github.com/hashicorp/packer/packer-plugin-sdk/template/config"
+	"github.com/hashicorp/packer/packer-plugin-sdk/template/interpolate"
+)
+
+// The extensions of the disk files of an artifact.
+var diskExtensions = []string{".vhdx", ".vhd", ".qcow2", ".raw", ".img", ".vmdk"}
+
+// maxDisks is the number of disks of the SCSI controller of the machine.
+const maxDisks = 15
+
+type Config struct {
+	common.PackerConfig `mapstructure:",squash"`
+
+	// The path of the OVA. Defaults to `packer_{{.BuildName}}.ova`.
+	OutputPath string `mapstructure:"output"`
+	// The name of the machine in the OVF descriptor. Defaults to the name of
+	// the build.
+	VMName string `mapstructure:"vm_name"`
+	// The number of CPUs of the machine. Defaults to 1.
+	CPUs int `mapstructure:"cpus"`
+	// The memory of the machine, in megabytes. Defaults to 1024.
+	Memory int `mapstructure:"memory"`
+	// The VMware guest OS identifier of the machine, like
+	// `ubuntu64Guest` or `windows9Server64Guest`. Defaults to
+	// `otherGuest64`.
+	GuestOSType string `mapstructure:"guest_os_type"`
+	// The VMware virtual hardware version of the machine. Defaults to 13,
+	// supported since ESXi 6.5.
+	HardwareVersion int `mapstructure:"hardware_version"`
+	// The firmware of the machine, `bios` or `efi`. Defaults to `bios`.
+	Firmware string `mapstructure:"firmware"`
+	// The network the network adapter of the machine is connected to.
+	// Defaults to `VM Network`.
+	Network string `mapstructure:"network"`
+	// The type of the network adapter, `e1000`, `e1000e` or `vmxnet3`.
+	// Defaults to `e1000`, which most guests have a driver for.
+	NetworkAdapterType string `mapstructure:"network_adapter_type"`
+	// The path to the qemu-img command converting the disks. Defaults to
+	// `qemu-img`.
+	QemuImgPath string `mapstructure:"qemu_img_path"`
+	// The path to a PEM encoded X.509 certificate to sign the OVA with.
+	// Requires `signing_key`.
+	SigningCertificate string `mapstructure:"signing_certificate"`
+	// The path to the PEM encoded RSA private key of `signing_certificate`.
+	SigningKey string `mapstructure:"signing_key"`
+
+	ctx interpolate.Context
+}
+
+type PostProcessor struct {
+	config Config
+	driver Driver
+	signer *signer
+}
+
+func (p *PostProcessor) ConfigSpec() hcldec.ObjectSpec { return p.config.FlatMapstructure().HCL2Spec() }
+
+func (p *PostProcessor) Configure(raws ...interface{}) error {
+	err := config.Decode(&p.config, &config.DecodeOpts{
+		PluginType:         "ova",
+		Interpolate:        true,
+		InterpolateContext: &p.config.ctx,
+		InterpolateFilter: &interpolate.RenderFilter{
+			Exclude: []string{"output"},
+		},
+	}, raws...)
+	if err != nil {
+		return err
+	}
+
+	errs := new(packer.MultiError)
+
+	if p.config.OutputPath == "" {
+		p.config.OutputPath = "packer_{{.BuildName}}.ova"
+	}
+	if err = interpolate.Validate(p.config.OutputPath, &p.config.ctx); err != nil {
+		errs = packer.MultiErrorAppend(
+			errs, fmt.Errorf("Error parsing target template: %s", err))
+	}
+
+	if p.config.VMName == "" {
+		p.config.VMName = p.config.PackerBuildName
+	}
+	if p.config.VMName == "" {
+		p.config.VMName = "packer"
+	}
+	if p.config.CPUs == 0 {
+		p.config.CPUs = 1
+	}
+	if p.config.Memory == 0 {
+		p.config.Memory = 1024
+	}
+	if p.config.GuestOSType == "" {
+		p.config.GuestOSType = "otherGuest64"
+	}
+	if p.config.HardwareVersion == 0 {
+		p.config.HardwareVersion = 13
+	}
+	if p.config.Firmware == "" {
+		p.config.Firmware = "bios"
+	}
+	if p.config.Network == "" {
+		p.config.Network = "VM Network"
+	}
+	if p.config.NetworkAdapterType == "" {
+		p.config.NetworkAdapterType = "e1000"
+	}
+	if p.config.QemuImgPath == "" {
+		p.config.QemuImgPath = "qemu-img"
+	}
+
+	if p.config.CPUs < 0 || p.config.Memory < 0 || p.config.HardwareVersion < 0 {
+		errs = packer.MultiErrorAppend(errs,
+			fmt.Errorf("cpus, memory and hardware_version must be positive"))
+	}
+	if p.config.Firmware != "bios" && p.config.Firmware != "efi" {
+		errs = packer.MultiErrorAppend(errs,
+			fmt.Errorf("firmware must be bios or efi"))
+	}
+	if _, ok := networkAdapters[p.config.NetworkAdapterType]; !ok {
+		errs = packer.MultiErrorAppend(errs,
+			fmt.Errorf("network_adapter_type must be e1000, e1000e or vmxnet3"))
+	}
+
+	switch {
+	case p.config.SigningCertificate == "" && p.config.SigningKey == "":
+	case p.config.SigningCertificate == "" || p.config.SigningKey == "":
+		errs = packer.MultiErrorAppend(errs,
+			fmt.Errorf("signing_certificate and signing_key must be specified together"))
+	default:
+		p.signer, err = loadSigner(p.config.SigningCertificate, p.config.SigningKey)
+		if err != nil {
+			errs = packer.MultiErrorAppend(errs, err)
+		}
+	}
+
+	if len(errs.Errors) > 0 {
+		return errs
+	}
+
+	if p.driver == nil {
+		p.driver = &QemuImgDriver{QemuImgPath: p.config.QemuImgPath}
+	}
+	return nil
+}
+
+func (p *PostProcessor) PostProcess(ctx context.Context, ui packer.Ui, artifact packer.Artifact) (packer.Artifact, bool, bool, error) {
+	disks := artifactDisks(artifact)
+	if len(disks) == 0 {
+		return nil, false, false, fmt.Errorf(
+			"No disks found in artifact %s. The ova post-processor converts the "+
+				"%s disks of artifacts, like Hyper-V and QEMU ones.",
+			artifact.BuilderId(), strings.Join(diskExtensions, ", "))
+	}
+	if len(disks) > maxDisks {
+		return nil, false, false, fmt.Errorf("An OVA can have at most %d disks, artifact has %d", maxDisks, len(disks))
+	}
+
+	var generatedData map[interface{}]interface{}
+	stateData := artifact.State("generated_data")
+	if stateData != nil {
+		// Make sure it's not a nil map so we can assign to it later.
+		generatedData = stateData.(map[interface{}]interface{})
+	}
+	// If stateData has a nil map generatedData will be nil
+	// and we need to make sure it's not
+	if generatedData == nil {
+		generatedData = make(map[interface{}]interface{})
+	}
+	generatedData["BuildName"] = p.config.PackerBuildName
+	generatedData["BuilderType"] = p.config.PackerBuilderType
+	p.config.ctx.Data = generatedData
+
+	target, err := interpolate.Render(p.config.OutputPath, &p.config.ctx)
+	if err != nil {
+		return nil, false, false, fmt.Errorf("Error interpolating output: %s", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return nil, false, false, fmt.Errorf("Unable to create dir for OVA %s: %s", target, err)
+	}
+
+	// The disks are converted next to the OVA, as they can be large
+	workDir, err := ioutil.TempDir(filepath.Dir(target), "packer-ova")
+	if err != nil {
+		return nil, false, false, err
+	}
+	defer os.RemoveAll(workDir)
+
+	ui.Say(fmt.Sprintf("Creating OVA %s", target))
+	descriptor := &ovfDescriptor{
+		Name:            p.config.VMName,
+		GuestOSType:     p.config.GuestOSType,
+		HardwareVersion: p.config.HardwareVersion,
+		CPUs:            p.config.CPUs,
+		Memory:          p.config.Memory,
+		Firmware:        p.config.Firmware,
+		Network:         p.config.Network,
+		NetworkAdapter:  networkAdapters[p.config.NetworkAdapterType],
+	}
+	for i, disk := range disks {
+		capacity, err := p.driver.VirtualSize(disk)
+		if err != nil {
+			return nil, false, false, err
+		}
+		name := fmt.Sprintf("%s-disk%d.vmdk", p.config.VMName, i+1)
+		ui.Message(fmt.Sprintf("Converting %s to %s", disk, name))
+		if err := p.driver.ConvertToVMDK(disk, filepath.Join(workDir, name)); err != nil {
+			return nil, false, false, err
+		}
+		info, err := os.Stat(filepath.Join(workDir, name))
+		if err != nil {
+			return nil, false, false, err
+		}
+		descriptor.Disks = append(descriptor.Disks, ovfDisk{File: name, Size: info.Size(), Capacity: capacity})
+	}
+
+	ovf, err := descriptor.render()
+	if err != nil {
+		return nil, false, false, fmt.Errorf("Error generating the OVF descriptor: %s", err)
+	}
+	ovfName := p.config.VMName + ".ovf"
+	if err := ioutil.WriteFile(filepath.Join(workDir, ovfName), ovf, 0644); err != nil {
+		return nil, false, false, err
+	}
+
+	// The manifest lists the SHA256 of the descriptor and of the disks
+	files := []string{ovfName}
+	for _, d := range descriptor.Disks {
+		files = append(files, d.File)
+	}
+	var manifest strings.Builder
+	for _, name := range files {
+		sum, err := fileSHA256(filepath.Join(workDir, name))
+		if err != nil {
+			return nil, false, false, err
+		}
+		fmt.Fprintf(&manifest, "SHA256(%s)= %x\n", name, sum)
+	}
+	mfName := p.config.VMName + ".mf"
+	if err := ioutil.WriteFile(filepath.Join(workDir, mfName), []byte(manifest.String()), 0644); err != nil {
+		return nil, false, false, err
+	}
+
+	// The descriptor comes first in the OVA, followed by the manifest and
+	// the certificate
+	entries := append([]string{ovfName, mfName}, files[1:]...)
+	if p.signer != nil {
+		ui.Message(fmt.Sprintf("Signing the manifest with %s", p.config.SigningCertificate))
+		cert, err := p.signer.certificate(mfName, []byte(manifest.String()))
+		if err != nil {
+			return nil, false, false, fmt.Errorf("Error signing the manifest: %s", err)
+		}
+		certName := p.config.VMName + ".cert"
+		if err := ioutil.WriteFile(filepath.Join(workDir, certName), cert, 0644); err != nil {
+			return nil, false, false, err
+		}
+		entries = append([]string{ovfName, mfName, certName}, files[1:]...)
+	}
+
+	if err := writeOVA(target, workDir, entries); err != nil {
+		os.Remove(target)
+		return nil, false, false, fmt.Errorf("Error creating OVA %s: %s", target, err)
+	}
+
+	return NewArtifact(target), false, false, nil
+}
+
+// artifactDisks returns the disks of the artifact, the ones the QEMU builder
+// reports or else its files with a disk extension.
+func artifactDisks(artifact packer.Artifact) []string {
+	switch paths := artifact.State("diskPaths").(type) {
+	case []string:
+		if len(paths) > 0 {
+			return paths
+		}
+	case []interface{}:
+		var disks []string
+		for _, path := range paths {
+			if path, ok := path.(string); ok {
+				disks = append(disks, path)
+			}
+		}
+		if len(disks) > 0 {
+			return disks
+		}
+	}
+
+	var disks []string
+	for _, f := range artifact.Files() {
+		ext := strings.ToLower(filepath.Ext(f))
+		for _, diskExt := range diskExtensions {
+			if ext == diskExt {
+				disks = append(disks, f)
+				break
+			}
+		}
+	}
+	return disks
+}
+
+func fileSHA256(path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
+
+// writeOVA writes the files of dir named entries to the tar archive target,
+// in order.
+func writeOVA(target string, dir string, entries []string) error {
+	out, err := os.Create(target)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	tw := tar.NewWriter(out)
+	for _, name := range entries {
+		if err := addTarFile(tw, filepath.Join(dir, name), name); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return out.Close()
+}
+
+func addTarFile(tw *tar.Writer, path string, name string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	// Only the fields of the ustar format are set, which OVA consumers
+	// expect, unless a disk is too large for it
+	header := &tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     name,
+		Mode:     0644,
+		Size:     info.Size(),
+		ModTime:  info.ModTime().Truncate(time.Second),
+	}
+	if err := tw.WriteHeader(header); err != nil {
+		return err
+	}
+	_, err = io.Copy(tw, f)
+	return err
+}
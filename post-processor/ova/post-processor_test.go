@@ -0,0 +1,259 @@
+package ova
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/pem"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/packer/packer"
+)
+
+// mockDriver "converts" a disk by copying it.
+type mockDriver struct {
+	converted []string
+}
+
+func (d *mockDriver) VirtualSize(path string) (int64, error) {
+	return 10 * 1024 * 1024 * 1024, nil
+}
+
+func (d *mockDriver) ConvertToVMDK(src string, dst string) error {
+	d.converted = append(d.converted, src)
+	data, err := ioutil.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(dst, data, 0644)
+}
+
+func testArtifact(t *testing.T, dir string) *packer.MockArtifact {
+	var files []string
+	for _, name := range []string{"Virtual Hard Disks/disk.vhdx", "Virtual Machines/vm.vmcx", "Virtual Hard Disks/data.vhdx"} {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("err: %s", err)
+		}
+		if err := ioutil.WriteFile(path, []byte(name), 0644); err != nil {
+			t.Fatalf("err: %s", err)
+		}
+		files = append(files, path)
+	}
+	return &packer.MockArtifact{BuilderIdValue: "MSOpenTech.hyperv", FilesValue: files}
+}
+
+func readOVA(t *testing.T, path string) ([]string, map[string][]byte) {
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer f.Close()
+
+	var names []string
+	contents := map[string][]byte{}
+	tr := tar.NewReader(f)
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("err: %s", err)
+		}
+		data, err := ioutil.ReadAll(tr)
+		if err != nil {
+			t.Fatalf("err: %s", err)
+		}
+		names = append(names, h.Name)
+		contents[h.Name] = data
+	}
+	return names, contents
+}
+
+func TestPostProcessor_Configure(t *testing.T) {
+	var p PostProcessor
+	if err := p.Configure(map[string]interface{}{}); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if p.config.VMName != "packer" || p.config.CPUs != 1 || p.config.Memory != 1024 || p.config.NetworkAdapterType != "e1000" {
+		t.Fatalf("bad defaults: %#v", p.config)
+	}
+
+	for _, raw := range []map[string]interface{}{
+		{"firmware": "uefi"},
+		{"network_adapter_type": "pcnet32"},
+		{"signing_key": "key.pem"},
+	} {
+		p = PostProcessor{}
+		if err := p.Configure(raw); err == nil {
+			t.Fatalf("should have error with %#v", raw)
+		}
+	}
+}
+
+func TestPostProcessor_PostProcess(t *testing.T) {
+	dir, err := ioutil.TempDir("", "packer-ova")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	driver := &mockDriver{}
+	p := PostProcessor{driver: driver}
+	err = p.Configure(map[string]interface{}{
+		"output":        filepath.Join(dir, "out", "{{.BuildName}}.ova"),
+		"vm_name":       "web",
+		"cpus":          2,
+		"firmware":      "efi",
+		"guest_os_type": "ubuntu64Guest",
+	}, map[string]interface{}{
+		"packer_build_name": "web-server",
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	artifact, keep, _, err := p.PostProcess(context.Background(), packer.TestUi(t), testArtifact(t, dir))
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if keep {
+		t.Fatal("should not keep the input artifact")
+	}
+	target := filepath.Join(dir, "out", "web-server.ova")
+	if !reflect.DeepEqual(artifact.Files(), []string{target}) {
+		t.Fatalf("bad: %#v", artifact.Files())
+	}
+	if len(driver.converted) != 2 || strings.HasSuffix(driver.converted[1], ".vmcx") {
+		t.Fatalf("only the disks should be converted: %#v", driver.converted)
+	}
+
+	names, contents := readOVA(t, target)
+	expected := []string{"web.ovf", "web.mf", "web-disk1.vmdk", "web-disk2.vmdk"}
+	if !reflect.DeepEqual(names, expected) {
+		t.Fatalf("bad: %#v", names)
+	}
+
+	if err := xml.Unmarshal(contents["web.ovf"], new(interface{})); err != nil {
+		t.Fatalf("the OVF descriptor should be valid XML: %s", err)
+	}
+	for _, s := range []string{
+		`ovf:href="web-disk2.vmdk" ovf:id="file2" ovf:size="28"`,
+		`ovf:capacity="10737418240"`,
+		`vmw:osType="ubuntu64Guest"`,
+		`<rasd:VirtualQuantity>2</rasd:VirtualQuantity>`,
+		`<vssd:VirtualSystemType>vmx-13</vssd:VirtualSystemType>`,
+		`vmw:key="firmware" vmw:value="efi"`,
+	} {
+		if !bytes.Contains(contents["web.ovf"], []byte(s)) {
+			t.Fatalf("the OVF descriptor should contain %s:\n%s", s, contents["web.ovf"])
+		}
+	}
+
+	sum := sha256.Sum256(contents["web-disk1.vmdk"])
+	if !strings.Contains(string(contents["web.mf"]), fmt.Sprintf("SHA256(web-disk1.vmdk)= %x\n", sum)) {
+		t.Fatalf("bad manifest: %s", contents["web.mf"])
+	}
+
+	// The work directory is removed
+	entries, _ := ioutil.ReadDir(filepath.Join(dir, "out"))
+	if len(entries) != 1 {
+		t.Fatalf("only the OVA should be left: %v", entries)
+	}
+}
+
+func TestPostProcessor_PostProcessNoDisks(t *testing.T) {
+	p := PostProcessor{driver: &mockDriver{}}
+	if err := p.Configure(map[string]interface{}{}); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	artifact := &packer.MockArtifact{FilesValue: []string{"vm.vmx"}}
+	if _, _, _, err := p.PostProcess(context.Background(), packer.TestUi(t), artifact); err == nil {
+		t.Fatal("should have error without disks")
+	}
+}
+
+func TestPostProcessor_PostProcessSigned(t *testing.T) {
+	dir, err := ioutil.TempDir("", "packer-ova")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "packer"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+	ioutil.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0644)
+	ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}), 0600)
+
+	p := PostProcessor{driver: &mockDriver{}}
+	err = p.Configure(map[string]interface{}{
+		"output":              filepath.Join(dir, "signed.ova"),
+		"signing_certificate": certFile,
+		"signing_key":         keyFile,
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if _, _, _, err := p.PostProcess(context.Background(), packer.TestUi(t), testArtifact(t, dir)); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	names, contents := readOVA(t, filepath.Join(dir, "signed.ova"))
+	if names[2] != "packer.cert" {
+		t.Fatalf("the certificate should follow the manifest: %#v", names)
+	}
+	lines := strings.SplitN(string(contents["packer.cert"]), "\n", 2)
+	prefix := "SHA256(packer.mf)= "
+	if !strings.HasPrefix(lines[0], prefix) || !strings.HasPrefix(lines[1], "-----BEGIN CERTIFICATE-----") {
+		t.Fatalf("bad certificate: %s", contents["packer.cert"])
+	}
+	sig, err := hex.DecodeString(strings.TrimPrefix(lines[0], prefix))
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	digest := sha256.Sum256(contents["packer.mf"])
+	if err := rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, digest[:], sig); err != nil {
+		t.Fatalf("bad signature: %s", err)
+	}
+
+	// A key not matching the certificate is refused
+	other, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(other)}), 0600)
+	p = PostProcessor{}
+	err = p.Configure(map[string]interface{}{"signing_certificate": certFile, "signing_key": keyFile})
+	if err == nil || !strings.Contains(err.Error(), "doesn't match") {
+		t.Fatalf("should refuse the key: %v", err)
+	}
+}
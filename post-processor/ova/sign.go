@@ -0,0 +1,84 @@
+package ova
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+)
+
+// A signer signs the manifest of the OVA with an X.509 certificate.
+type signer struct {
+	cert []byte
+	key  *rsa.PrivateKey
+}
+
+// loadSigner reads a PEM encoded certificate and the matching RSA private
+// key.
+func loadSigner(certFile string, keyFile string) (*signer, error) {
+	certPEM, err := ioutil.ReadFile(certFile)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(certPEM)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, fmt.Errorf("%s is not a PEM encoded certificate", certFile)
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("Error parsing %s: %s", certFile, err)
+	}
+
+	keyPEM, err := ioutil.ReadFile(keyFile)
+	if err != nil {
+		return nil, err
+	}
+	block, _ = pem.Decode(keyPEM)
+	if block == nil {
+		return nil, fmt.Errorf("%s is not a PEM encoded private key", keyFile)
+	}
+	var key interface{}
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	default:
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Error parsing %s: %s", keyFile, err)
+	}
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("%s must be an RSA private key", keyFile)
+	}
+
+	pub, ok := cert.PublicKey.(*rsa.PublicKey)
+	if !ok || pub.N.Cmp(rsaKey.N) != 0 || pub.E != rsaKey.E {
+		return nil, fmt.Errorf("the private key of %s doesn't match the certificate %s", keyFile, certFile)
+	}
+
+	return &signer{
+		cert: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw}),
+		key:  rsaKey,
+	}, nil
+}
+
+// certificate returns the content of the certificate file of the OVA, the
+// signature of the manifest named name followed by the certificate.
+func (s *signer) certificate(name string, manifest []byte) ([]byte, error) {
+	digest := sha256.Sum256(manifest)
+	sig, err := rsa.SignPKCS1v15(rand.Reader, s.key, crypto.SHA256, digest[:])
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "SHA256(%s)= %x\n", name, sig)
+	buf.Write(s.cert)
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,13 @@
+package version
+
+import (
+	"github.com/hashicorp/packer/packer-plugin-sdk/version"
+	packerVersion "github.com/hashicorp/packer/version"
+)
+
+var OVAPluginVersion *version.PluginVersion
+
+func init() {
+	OVAPluginVersion = version.InitializePluginVersion(
+		packerVersion.Version, packerVersion.VersionPrerelease)
+}
@@ -4,12 +4,18 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 
 	"github.com/hashicorp/packer/packer"
 )
 
-type HypervProvider struct{}
+type HypervProvider struct {
+	// ParentVHDX is the path of the parent disk of the differencing disks of
+	// the box on the machines using it. When set, the parent disks are left
+	// out of the box.
+	ParentVHDX string
+}
 
 func (p *HypervProvider) KeepInputArtifact() bool {
 	return false
@@ -19,49 +25,99 @@ func (p *HypervProvider) Process(ui packer.Ui, artifact packer.Artifact, dir str
 	// Create the metadata
 	metadata = map[string]interface{}{"provider": "hyperv"}
 
-	// ui.Message(fmt.Sprintf("artifacts all: %+v", artifact))
-	var outputDir string
+	// Vagrant requires specific dir structure for hyperv: the configuration
+	// of the virtual machine in 'Virtual Machines' and its disks in
+	// 'Virtual Hard Disks'. The checkpoints of 'Snapshots' are left out.
+	version, _ := artifact.State("configuration_version").(string)
+	config, err := hypervConfiguration(artifact.Files(), version)
+	if err != nil {
+		return
+	}
+	outputDir := filepath.Dir(filepath.Dir(config))
+	ui.Message(fmt.Sprintf("Using virtual machine configuration %s", config))
 
-	// Vargant requires specific dir structure for hyperv
-	// hyperv builder creates the structure in the output dir
-	// we have to keep the structure in a temp dir
-	// hack little bit but string in artifact usually have output dir
-	artifactString := artifact.String()
-	d := strings.Split(artifactString, ": ")
-	outputDir = d[1]
-	// ui.Message(fmt.Sprintf("artifact dir from string: %s", outputDir))
+	configID := strings.TrimSuffix(filepath.Base(config), filepath.Ext(config))
+	var files, disks []string
+	hasGuestState := false
+	for _, path := range artifact.Files() {
+		switch {
+		case isHypervDir(filepath.Dir(path), outputDir, "Virtual Machines"):
+			// The configuration and its state files, like the guest state
+			// holding the EFI variables of generation 2 machines
+			base := filepath.Base(path)
+			if strings.TrimSuffix(base, filepath.Ext(base)) != configID {
+				continue
+			}
+			if strings.EqualFold(filepath.Ext(base), ".vmgs") {
+				hasGuestState = true
+			}
+			files = append(files, path)
+		case isHypervDir(filepath.Dir(path), outputDir, "Virtual Hard Disks"):
+			disks = append(disks, path)
+		}
+	}
+	if generation, _ := artifact.State("generation").(uint); generation == 2 && strings.EqualFold(filepath.Ext(config), ".vmcx") && !hasGuestState {
+		err = fmt.Errorf("The guest state file (.vmgs) of %s is missing, generation 2 "+
+			"virtual machines need it to boot", config)
+		return
+	}
+	if len(disks) == 0 {
+		err = fmt.Errorf("No disk in %s", filepath.Join(outputDir, "Virtual Hard Disks"))
+		return
+	}
+
+	// Differencing disks are rewritten to reference their parent on the
+	// machines using the box, so they are copied instead of linked.
+	var differencing map[string]bool
+	if p.ParentVHDX != "" {
+		var parents []string
+		differencing, parents, err = hypervDifferencingDisks(disks)
+		if err != nil {
+			return
+		}
+		disks = excludeFiles(disks, parents)
+		for _, path := range parents {
+			ui.Message(fmt.Sprintf("Leaving out parent disk %s", path))
+		}
+	}
+	files = append(files, disks...)
 
 	// Copy all of the original contents into the temporary directory
-	for _, path := range artifact.Files() {
+	for _, path := range files {
 		ui.Message(fmt.Sprintf("Copying: %s", path))
 
 		var rel string
-
-		rel, err = filepath.Rel(outputDir, filepath.Dir(path))
-		// ui.Message(fmt.Sprintf("rel is: %s", rel))
-
+		rel, err = filepath.Rel(outputDir, path)
 		if err != nil {
-			ui.Message(fmt.Sprintf("err in: %s", rel))
+			return
+		}
+		dstPath := filepath.Join(dir, rel)
+		if err = os.MkdirAll(filepath.Dir(dstPath), 0755); err != nil {
 			return
 		}
 
-		dstDir := filepath.Join(dir, rel)
-		// ui.Message(fmt.Sprintf("dstdir is: %s", dstDir))
-		if _, err = os.Stat(dstDir); err != nil {
-			if err = os.MkdirAll(dstDir, 0755); err != nil {
-				ui.Message(fmt.Sprintf("err in creating: %s", dstDir))
+		if differencing[path] {
+			if err = CopyContents(dstPath, path); err != nil {
 				return
 			}
+			ui.Message(fmt.Sprintf("Setting the parent of %s to %s", rel, p.ParentVHDX))
+			var disk *vhdxDisk
+			if disk, err = openVHDX(dstPath, true); err != nil {
+				return
+			}
+			err = disk.SetParentPath(p.ParentVHDX)
+			disk.Close()
+			if err != nil {
+				return
+			}
+			continue
 		}
 
-		dstPath := filepath.Join(dstDir, filepath.Base(path))
-
 		// We prefer to link the files where possible because they are often very huge.
 		// Some filesystem configurations do not allow hardlinks. As the possibilities
 		// of mounting different devices in different paths are flexible, we just try to
 		// link the file and copy if the link fails, thereby automatically optimizing with a safe fallback.
 		if err = LinkFile(dstPath, path); err != nil {
-			// ui.Message(fmt.Sprintf("err in linking: %s to %s", path, dstPath))
 			if err = CopyContents(dstPath, path); err != nil {
 				ui.Message(fmt.Sprintf("err in copying: %s to %s", path, dstPath))
 				return
@@ -73,3 +129,100 @@ func (p *HypervProvider) Process(ui packer.Ui, artifact packer.Artifact, dir str
 
 	return
 }
+
+// hypervConfiguration returns the configuration file of the virtual machine
+// in the 'Virtual Machines' directory of the files. When the configuration
+// exists in both formats, the .xml one is used for the configuration
+// version 5.0 and older ones, which the hosts older than Windows Server 2016
+// and Windows 10 need, and the .vmcx one otherwise.
+func hypervConfiguration(files []string, version string) (string, error) {
+	var vmcx, xml []string
+	for _, path := range files {
+		if !strings.EqualFold(filepath.Base(filepath.Dir(path)), "Virtual Machines") {
+			continue
+		}
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".vmcx":
+			vmcx = append(vmcx, path)
+		case ".xml":
+			xml = append(xml, path)
+		}
+	}
+
+	candidates := vmcx
+	if v, err := strconv.ParseFloat(version, 64); len(xml) > 0 && (len(vmcx) == 0 || (err == nil && v < 6)) {
+		candidates = xml
+	}
+	switch len(candidates) {
+	case 0:
+		return "", fmt.Errorf("No virtual machine configuration in the artifact, " +
+			"Vagrant boxes can only be made of exported virtual machines")
+	case 1:
+		return candidates[0], nil
+	default:
+		return "", fmt.Errorf("Several virtual machine configurations in the artifact: %s",
+			strings.Join(candidates, ", "))
+	}
+}
+
+// hypervDifferencingDisks returns the differencing disks and their parents
+// among the disks. Only differencing disks with a parent among the disks
+// are supported.
+func hypervDifferencingDisks(disks []string) (map[string]bool, []string, error) {
+	byGUID := map[string]string{}
+	linkages := map[string]string{}
+	for _, path := range disks {
+		if !strings.EqualFold(filepath.Ext(path), ".vhdx") && !strings.EqualFold(filepath.Ext(path), ".avhdx") {
+			continue
+		}
+		disk, err := openVHDX(path, false)
+		if err != nil {
+			return nil, nil, err
+		}
+		disk.Close()
+		byGUID[strings.ToLower(disk.DataWriteGUID)] = path
+		if disk.HasParent() {
+			linkages[path] = strings.ToLower(disk.Parent["parent_linkage"])
+		}
+	}
+	if len(linkages) == 0 {
+		return nil, nil, fmt.Errorf("hyperv_parent_vhdx is set but the artifact has no differencing disk")
+	}
+
+	differencing := map[string]bool{}
+	var parents []string
+	for path, linkage := range linkages {
+		parent, ok := byGUID[linkage]
+		if !ok {
+			return nil, nil, fmt.Errorf("The parent of the differencing disk %s is not in the artifact", path)
+		}
+		if _, ok := linkages[parent]; ok {
+			return nil, nil, fmt.Errorf("The parent %s of %s is a differencing disk, only one level "+
+				"of differencing disks is supported", parent, path)
+		}
+		differencing[path] = true
+		parents = append(parents, parent)
+	}
+	return differencing, parents, nil
+}
+
+func isHypervDir(dir string, outputDir string, name string) bool {
+	return filepath.Dir(dir) == outputDir && strings.EqualFold(filepath.Base(dir), name)
+}
+
+func excludeFiles(files []string, excluded []string) []string {
+	var result []string
+	for _, f := range files {
+		keep := true
+		for _, e := range excluded {
+			if f == e {
+				keep = false
+				break
+			}
+		}
+		if keep {
+			result = append(result, f)
+		}
+	}
+	return result
+}
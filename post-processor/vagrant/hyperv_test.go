@@ -0,0 +1,247 @@
+package vagrant
+
+import (
+	"encoding/binary"
+	"hash/crc32"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/packer/packer"
+)
+
+func TestHypervProvider_impl(t *testing.T) {
+	var _ Provider = new(HypervProvider)
+}
+
+// writeTestVHDX writes the structures of a VHDX disk read by vhdxDisk, with
+// the given data write GUID and parent locator.
+func writeTestVHDX(t *testing.T, path string, dataWriteGUID string, parent map[string]string) {
+	b := make([]byte, 2*1024*1024)
+	copy(b, "vhdxfile")
+
+	header := make([]byte, vhdxHeaderSize)
+	copy(header, "head")
+	binary.LittleEndian.PutUint64(header[8:], 1)
+	copy(header[32:], parseGUID(dataWriteGUID))
+	binary.LittleEndian.PutUint32(header[4:], crc32.Checksum(header, crc32c))
+	copy(b[vhdxHeaderOffsets[0]:], header)
+
+	table := make([]byte, vhdxRegionTableSize)
+	copy(table, "regi")
+	binary.LittleEndian.PutUint32(table[8:], 1)
+	copy(table[16:], vhdxMetadataRegion)
+	binary.LittleEndian.PutUint64(table[32:], 1024*1024)
+	binary.LittleEndian.PutUint32(table[40:], 1024*1024)
+	binary.LittleEndian.PutUint32(table[4:], crc32.Checksum(table, crc32c))
+	for _, offset := range vhdxRegionTableOffsets {
+		copy(b[offset:], table)
+	}
+
+	metadata := b[1024*1024:]
+	copy(metadata, "metadata")
+	params := make([]byte, 8)
+	if parent != nil {
+		binary.LittleEndian.PutUint32(params[4:], vhdxHasParent)
+	}
+	items := [][]byte{params}
+	ids := [][]byte{vhdxFileParameters}
+	if parent != nil {
+		items = append(items, encodeParentLocator(parent))
+		ids = append(ids, vhdxParentLocator)
+	}
+	binary.LittleEndian.PutUint16(metadata[10:], uint16(len(items)))
+	offset := vhdxMetadataSize
+	for i, item := range items {
+		entry := metadata[32+i*32:]
+		copy(entry, ids[i])
+		binary.LittleEndian.PutUint32(entry[16:], uint32(offset))
+		binary.LittleEndian.PutUint32(entry[20:], uint32(len(item)))
+		copy(metadata[offset:], item)
+		offset += len(item)
+	}
+
+	if err := ioutil.WriteFile(path, b, 0644); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+}
+
+func TestVHDX_SetParentPath(t *testing.T) {
+	dir, err := ioutil.TempDir("", "packer")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "child.avhdx")
+	writeTestVHDX(t, path, "11111111-2222-3333-4444-555555555555", map[string]string{
+		"parent_linkage":      "{aaaaaaaa-bbbb-cccc-dddd-eeeeeeeeeeee}",
+		"relative_path":       `.\base.vhdx`,
+		"absolute_win32_path": `C:\a\b.vhdx`,
+	})
+
+	disk, err := openVHDX(path, true)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if disk.DataWriteGUID != "{11111111-2222-3333-4444-555555555555}" {
+		t.Fatalf("bad data write GUID: %s", disk.DataWriteGUID)
+	}
+	// The new locator is longer than the old one
+	parentPath := `D:\Vagrant\Parents\windows-server-2019-base.vhdx`
+	if err := disk.SetParentPath(parentPath); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	disk.Close()
+
+	disk, err = openVHDX(path, false)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer disk.Close()
+	if disk.headerIndex != 1 || binary.LittleEndian.Uint64(disk.header[8:]) != 2 {
+		t.Fatal("the second header should be the current one")
+	}
+	if disk.DataWriteGUID != "{11111111-2222-3333-4444-555555555555}" {
+		t.Fatal("the data write GUID should not change")
+	}
+	if disk.Parent["absolute_win32_path"] != parentPath ||
+		disk.Parent["parent_linkage"] != "{aaaaaaaa-bbbb-cccc-dddd-eeeeeeeeeeee}" {
+		t.Fatalf("bad parent locator: %#v", disk.Parent)
+	}
+	if _, ok := disk.Parent["relative_path"]; ok {
+		t.Fatal("the relative path should be removed")
+	}
+}
+
+func TestHypervProvider_Process(t *testing.T) {
+	output, err := ioutil.TempDir("", "packer")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer os.RemoveAll(output)
+	box, err := ioutil.TempDir("", "packer")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer os.RemoveAll(box)
+
+	var files []string
+	for _, name := range []string{
+		"Virtual Machines/A1B2.vmcx",
+		"Virtual Machines/A1B2.vmgs",
+		"Virtual Machines/A1B2.VMRS",
+		"Snapshots/C3D4.vmcx",
+		"packer.log",
+	} {
+		path := filepath.Join(output, filepath.FromSlash(name))
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := ioutil.WriteFile(path, []byte(name), 0644); err != nil {
+			t.Fatalf("err: %s", err)
+		}
+		files = append(files, path)
+	}
+	disks := filepath.Join(output, "Virtual Hard Disks")
+	os.MkdirAll(disks, 0755)
+	writeTestVHDX(t, filepath.Join(disks, "base.vhdx"), "aaaaaaaa-bbbb-cccc-dddd-eeeeeeeeeeee", nil)
+	writeTestVHDX(t, filepath.Join(disks, "child.avhdx"), "11111111-2222-3333-4444-555555555555", map[string]string{
+		"parent_linkage": "{aaaaaaaa-bbbb-cccc-dddd-eeeeeeeeeeee}",
+		"relative_path":  `.\base.vhdx`,
+	})
+	files = append(files, filepath.Join(disks, "base.vhdx"), filepath.Join(disks, "child.avhdx"))
+
+	artifact := &packer.MockArtifact{
+		FilesValue: files,
+		StateValues: map[string]interface{}{
+			"generation": uint(2),
+		},
+	}
+	p := &HypervProvider{ParentVHDX: `C:\Parents\base.vhdx`}
+	_, metadata, err := p.Process(testUi(), artifact, box)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if metadata["provider"] != "hyperv" {
+		t.Fatalf("bad metadata: %#v", metadata)
+	}
+
+	var packaged []string
+	filepath.Walk(box, func(path string, info os.FileInfo, err error) error {
+		if !info.IsDir() {
+			rel, _ := filepath.Rel(box, path)
+			packaged = append(packaged, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	sort.Strings(packaged)
+	expected := []string{
+		"Virtual Hard Disks/child.avhdx",
+		"Virtual Machines/A1B2.VMRS",
+		"Virtual Machines/A1B2.vmcx",
+		"Virtual Machines/A1B2.vmgs",
+	}
+	if strings.Join(packaged, ",") != strings.Join(expected, ",") {
+		t.Fatalf("bad box content: %v", packaged)
+	}
+
+	disk, err := openVHDX(filepath.Join(box, "Virtual Hard Disks", "child.avhdx"), false)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer disk.Close()
+	if disk.Parent["absolute_win32_path"] != `C:\Parents\base.vhdx` {
+		t.Fatalf("bad parent locator: %#v", disk.Parent)
+	}
+	original, err := openVHDX(filepath.Join(disks, "child.avhdx"), false)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer original.Close()
+	if original.Parent["relative_path"] != `.\base.vhdx` {
+		t.Fatal("the disk of the artifact should not be modified")
+	}
+}
+
+func TestHypervProvider_ProcessMissingGuestState(t *testing.T) {
+	output, err := ioutil.TempDir("", "packer")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer os.RemoveAll(output)
+
+	var files []string
+	for _, name := range []string{"Virtual Machines/A1B2.vmcx", "Virtual Hard Disks/disk.vhdx"} {
+		path := filepath.Join(output, filepath.FromSlash(name))
+		os.MkdirAll(filepath.Dir(path), 0755)
+		ioutil.WriteFile(path, []byte(name), 0644)
+		files = append(files, path)
+	}
+	artifact := &packer.MockArtifact{
+		FilesValue:  files,
+		StateValues: map[string]interface{}{"generation": uint(2)},
+	}
+	_, _, err = new(HypervProvider).Process(testUi(), artifact, output)
+	if err == nil || !strings.Contains(err.Error(), ".vmgs") {
+		t.Fatalf("a generation 2 machine without guest state should be refused: %v", err)
+	}
+}
+
+func TestHypervConfiguration(t *testing.T) {
+	files := []string{
+		filepath.Join("out", "Virtual Machines", "A1B2.vmcx"),
+		filepath.Join("out", "Virtual Machines", "A1B2.xml"),
+		filepath.Join("out", "Virtual Hard Disks", "disk.vhdx"),
+	}
+	if config, _ := hypervConfiguration(files, ""); filepath.Ext(config) != ".vmcx" {
+		t.Fatalf("the .vmcx configuration should be used by default: %s", config)
+	}
+	if config, _ := hypervConfiguration(files, "5.0"); filepath.Ext(config) != ".xml" {
+		t.Fatalf("the .xml configuration should be used for version 5.0: %s", config)
+	}
+	if _, err := hypervConfiguration(files[2:], ""); err == nil {
+		t.Fatal("an artifact without configuration should be refused")
+	}
+}
@@ -69,6 +69,7 @@ type Config struct {
 	VagrantfileTemplate          string `mapstructure:"vagrantfile_template"`
 	VagrantfileTemplateGenerated bool   `mapstructure:"vagrantfile_template_generated"`
 	ProviderOverride             string `mapstructure:"provider_override"`
+	HypervParentVHDX             string `mapstructure:"hyperv_parent_vhdx"`
 
 	ctx interpolate.Context
 }
@@ -155,6 +156,10 @@ func (p *PostProcessor) PostProcessProvider(name string, provider Provider, ui p
 		}
 	}
 
+	if hyperv, ok := provider.(*HypervProvider); ok {
+		hyperv.ParentVHDX = config.HypervParentVHDX
+	}
+
 	// Run the provider processing step
 	vagrantfile, metadata, err := provider.Process(ui, artifact, dir)
 	if err != nil {
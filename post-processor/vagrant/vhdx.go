@@ -0,0 +1,355 @@
+package vagrant
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"unicode/utf16"
+)
+
+// The structures of VHDX disks read and updated to package differencing
+// disks referencing a parent disk outside of the box. See the VHDX format
+// specification for their layout.
+const (
+	vhdxHeaderSize      = 4 * 1024
+	vhdxRegionTableSize = 64 * 1024
+	vhdxMetadataSize    = 64 * 1024
+
+	vhdxHasParent = 2
+)
+
+var (
+	vhdxHeaderOffsets      = []int64{64 * 1024, 128 * 1024}
+	vhdxRegionTableOffsets = []int64{192 * 1024, 256 * 1024}
+
+	vhdxMetadataRegion    = parseGUID("8B7CA206-4790-4B9A-B8FE-575F050F886E")
+	vhdxFileParameters    = parseGUID("CAA16737-FA36-4D43-B3B6-33F0AA44E76B")
+	vhdxParentLocator     = parseGUID("A8D35F2D-B30B-454D-ABF7-D3D84834AB0C")
+	vhdxParentLocatorType = parseGUID("B04AEFB7-D19E-4A81-B789-25B8E9445913")
+
+	crc32c = crc32.MakeTable(crc32.Castagnoli)
+)
+
+// vhdxDisk is a VHDX disk, and the location of its parent when it is a
+// differencing disk.
+type vhdxDisk struct {
+	f *os.File
+
+	header      []byte
+	headerIndex int
+
+	metadataOffset int64
+	metadataLength int64
+	metadataTable  []byte
+
+	// DataWriteGUID identifies the content of the disk, the differencing
+	// disks reference their parent by it.
+	DataWriteGUID string
+	// Parent holds the entries of the parent locator of differencing disks,
+	// like parent_linkage and absolute_win32_path.
+	Parent map[string]string
+}
+
+// HasParent returns whether the disk is a differencing disk.
+func (d *vhdxDisk) HasParent() bool {
+	return d.Parent != nil
+}
+
+// openVHDX opens the VHDX disk at path, read-only unless writable is set.
+func openVHDX(path string, writable bool) (*vhdxDisk, error) {
+	flag := os.O_RDONLY
+	if writable {
+		flag = os.O_RDWR
+	}
+	f, err := os.OpenFile(path, flag, 0)
+	if err != nil {
+		return nil, err
+	}
+	d := &vhdxDisk{f: f}
+	if err := d.read(); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("Error reading VHDX disk %s: %s", path, err)
+	}
+	return d, nil
+}
+
+func (d *vhdxDisk) Close() error {
+	return d.f.Close()
+}
+
+func (d *vhdxDisk) read() error {
+	signature := make([]byte, 8)
+	if _, err := d.f.ReadAt(signature, 0); err != nil {
+		return err
+	}
+	if string(signature) != "vhdxfile" {
+		return fmt.Errorf("not a VHDX disk")
+	}
+
+	// The current header is the valid one with the highest sequence number
+	d.headerIndex = -1
+	for i, offset := range vhdxHeaderOffsets {
+		header := make([]byte, vhdxHeaderSize)
+		if _, err := d.f.ReadAt(header, offset); err != nil {
+			return err
+		}
+		if string(header[:4]) != "head" || !vhdxChecksumValid(header) {
+			continue
+		}
+		if d.header == nil || binary.LittleEndian.Uint64(header[8:]) > binary.LittleEndian.Uint64(d.header[8:]) {
+			d.header = header
+			d.headerIndex = i
+		}
+	}
+	if d.header == nil {
+		return fmt.Errorf("no valid header")
+	}
+	d.DataWriteGUID = formatGUID(d.header[32:48])
+
+	var table []byte
+	for _, offset := range vhdxRegionTableOffsets {
+		t := make([]byte, vhdxRegionTableSize)
+		if _, err := d.f.ReadAt(t, offset); err != nil {
+			return err
+		}
+		if string(t[:4]) == "regi" && vhdxChecksumValid(t) {
+			table = t
+			break
+		}
+	}
+	if table == nil {
+		return fmt.Errorf("no valid region table")
+	}
+	count := int(binary.LittleEndian.Uint32(table[8:]))
+	for i := 0; i < count && 16+(i+1)*32 <= len(table); i++ {
+		entry := table[16+i*32:][:32]
+		if bytes.Equal(entry[:16], vhdxMetadataRegion) {
+			d.metadataOffset = int64(binary.LittleEndian.Uint64(entry[16:]))
+			d.metadataLength = int64(binary.LittleEndian.Uint32(entry[24:]))
+		}
+	}
+	if d.metadataOffset == 0 {
+		return fmt.Errorf("no metadata region")
+	}
+
+	d.metadataTable = make([]byte, vhdxMetadataSize)
+	if _, err := d.f.ReadAt(d.metadataTable, d.metadataOffset); err != nil {
+		return err
+	}
+	if string(d.metadataTable[:8]) != "metadata" {
+		return fmt.Errorf("no metadata table")
+	}
+
+	params, err := d.metadataItem(vhdxFileParameters)
+	if err != nil {
+		return err
+	}
+	if len(params) < 8 {
+		return fmt.Errorf("no file parameters")
+	}
+	if binary.LittleEndian.Uint32(params[4:])&vhdxHasParent == 0 {
+		return nil
+	}
+
+	locator, err := d.metadataItem(vhdxParentLocator)
+	if err != nil {
+		return err
+	}
+	if d.Parent, err = decodeParentLocator(locator); err != nil {
+		return err
+	}
+	return nil
+}
+
+// metadataEntry returns the entry of the metadata table of the item id.
+func (d *vhdxDisk) metadataEntry(id []byte) []byte {
+	count := int(binary.LittleEndian.Uint16(d.metadataTable[10:]))
+	for i := 0; i < count && 32+(i+1)*32 <= len(d.metadataTable); i++ {
+		entry := d.metadataTable[32+i*32:][:32]
+		if bytes.Equal(entry[:16], id) {
+			return entry
+		}
+	}
+	return nil
+}
+
+func (d *vhdxDisk) metadataItem(id []byte) ([]byte, error) {
+	entry := d.metadataEntry(id)
+	if entry == nil {
+		return nil, nil
+	}
+	offset := int64(binary.LittleEndian.Uint32(entry[16:]))
+	length := int64(binary.LittleEndian.Uint32(entry[20:]))
+	if offset+length > d.metadataLength {
+		return nil, fmt.Errorf("metadata item %s out of the metadata region", formatGUID(id))
+	}
+	item := make([]byte, length)
+	if _, err := d.f.ReadAt(item, d.metadataOffset+offset); err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+// SetParentPath makes the differencing disk reference its parent at the
+// absolute path, the parent still being identified by its parent_linkage.
+func (d *vhdxDisk) SetParentPath(path string) error {
+	if !d.HasParent() {
+		return fmt.Errorf("%s is not a differencing disk", d.f.Name())
+	}
+	if !bytes.Equal(d.header[48:64], make([]byte, 16)) {
+		return fmt.Errorf("%s has pending log entries, attach it once in Hyper-V to replay them", d.f.Name())
+	}
+
+	parent := map[string]string{"absolute_win32_path": path}
+	for _, key := range []string{"parent_linkage", "parent_linkage2"} {
+		if v, ok := d.Parent[key]; ok {
+			parent[key] = v
+		}
+	}
+	locator := encodeParentLocator(parent)
+
+	// The new locator replaces the old one when it fits, and is written
+	// after the last metadata item otherwise.
+	entry := d.metadataEntry(vhdxParentLocator)
+	offset := int64(binary.LittleEndian.Uint32(entry[16:]))
+	if int64(len(locator)) > int64(binary.LittleEndian.Uint32(entry[20:])) {
+		offset = vhdxMetadataSize
+		count := int(binary.LittleEndian.Uint16(d.metadataTable[10:]))
+		for i := 0; i < count; i++ {
+			e := d.metadataTable[32+i*32:][:32]
+			if end := int64(binary.LittleEndian.Uint32(e[16:])) + int64(binary.LittleEndian.Uint32(e[20:])); end > offset {
+				offset = end
+			}
+		}
+		offset = (offset + 7) / 8 * 8
+		if offset+int64(len(locator)) > d.metadataLength {
+			return fmt.Errorf("no room for the parent locator of %s", d.f.Name())
+		}
+	}
+	if _, err := d.f.WriteAt(locator, d.metadataOffset+offset); err != nil {
+		return err
+	}
+	binary.LittleEndian.PutUint32(entry[16:], uint32(offset))
+	binary.LittleEndian.PutUint32(entry[20:], uint32(len(locator)))
+	if _, err := d.f.WriteAt(d.metadataTable, d.metadataOffset); err != nil {
+		return err
+	}
+
+	// The file being modified, the header gets a new file write GUID and
+	// replaces the older one.
+	header := make([]byte, vhdxHeaderSize)
+	copy(header, d.header)
+	binary.LittleEndian.PutUint64(header[8:], binary.LittleEndian.Uint64(d.header[8:])+1)
+	if _, err := io.ReadFull(rand.Reader, header[16:32]); err != nil {
+		return err
+	}
+	binary.LittleEndian.PutUint32(header[4:], 0)
+	binary.LittleEndian.PutUint32(header[4:], crc32.Checksum(header, crc32c))
+	index := 1 - d.headerIndex
+	if _, err := d.f.WriteAt(header, vhdxHeaderOffsets[index]); err != nil {
+		return err
+	}
+	if err := d.f.Sync(); err != nil {
+		return err
+	}
+	d.header, d.headerIndex, d.Parent = header, index, parent
+	return nil
+}
+
+func decodeParentLocator(b []byte) (map[string]string, error) {
+	if len(b) < 20 || !bytes.Equal(b[:16], vhdxParentLocatorType) {
+		return nil, fmt.Errorf("unsupported parent locator")
+	}
+	count := int(binary.LittleEndian.Uint16(b[18:]))
+	parent := make(map[string]string, count)
+	for i := 0; i < count; i++ {
+		if 20+(i+1)*12 > len(b) {
+			return nil, fmt.Errorf("truncated parent locator")
+		}
+		entry := b[20+i*12:]
+		keyOffset := int(binary.LittleEndian.Uint32(entry[0:]))
+		valueOffset := int(binary.LittleEndian.Uint32(entry[4:]))
+		keyLength := int(binary.LittleEndian.Uint16(entry[8:]))
+		valueLength := int(binary.LittleEndian.Uint16(entry[10:]))
+		if keyOffset+keyLength > len(b) || valueOffset+valueLength > len(b) {
+			return nil, fmt.Errorf("truncated parent locator")
+		}
+		parent[decodeUTF16(b[keyOffset:][:keyLength])] = decodeUTF16(b[valueOffset:][:valueLength])
+	}
+	return parent, nil
+}
+
+func encodeParentLocator(parent map[string]string) []byte {
+	keys := make([]string, 0, len(parent))
+	for k := range parent {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	header := make([]byte, 20+12*len(keys))
+	copy(header, vhdxParentLocatorType)
+	binary.LittleEndian.PutUint16(header[18:], uint16(len(keys)))
+	var data []byte
+	for i, k := range keys {
+		key, value := encodeUTF16(k), encodeUTF16(parent[k])
+		entry := header[20+i*12:]
+		binary.LittleEndian.PutUint32(entry[0:], uint32(len(header)+len(data)))
+		binary.LittleEndian.PutUint16(entry[8:], uint16(len(key)))
+		data = append(data, key...)
+		binary.LittleEndian.PutUint32(entry[4:], uint32(len(header)+len(data)))
+		binary.LittleEndian.PutUint16(entry[10:], uint16(len(value)))
+		data = append(data, value...)
+	}
+	return append(header, data...)
+}
+
+func vhdxChecksumValid(b []byte) bool {
+	c := make([]byte, len(b))
+	copy(c, b)
+	binary.LittleEndian.PutUint32(c[4:], 0)
+	return crc32.Checksum(c, crc32c) == binary.LittleEndian.Uint32(b[4:])
+}
+
+func decodeUTF16(b []byte) string {
+	u := make([]uint16, len(b)/2)
+	for i := range u {
+		u[i] = binary.LittleEndian.Uint16(b[i*2:])
+	}
+	return string(utf16.Decode(u))
+}
+
+func encodeUTF16(s string) []byte {
+	u := utf16.Encode([]rune(s))
+	b := make([]byte, len(u)*2)
+	for i, c := range u {
+		binary.LittleEndian.PutUint16(b[i*2:], c)
+	}
+	return b
+}
+
+// parseGUID returns the mixed-endian binary form of a GUID, the first three
+// groups being little-endian.
+func parseGUID(s string) []byte {
+	b, err := hex.DecodeString(strings.Replace(strings.Trim(s, "{}"), "-", "", -1))
+	if err != nil || len(b) != 16 {
+		panic(fmt.Sprintf("bad GUID %s", s))
+	}
+	b[0], b[1], b[2], b[3] = b[3], b[2], b[1], b[0]
+	b[4], b[5] = b[5], b[4]
+	b[6], b[7] = b[7], b[6]
+	return b
+}
+
+// formatGUID formats a GUID the way the parent locators reference it, like
+// {8b7ca206-4790-4b9a-b8fe-575f050f886e}.
+func formatGUID(b []byte) string {
+	return fmt.Sprintf("{%02x%02x%02x%02x-%02x%02x-%02x%02x-%x-%x}",
+		b[3], b[2], b[1], b[0], b[5], b[4], b[7], b[6], b[8:10], b[10:16])
+}
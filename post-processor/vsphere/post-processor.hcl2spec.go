@@ -9,29 +9,31 @@ import (
 // FlatConfig is an auto-generated flat version of Config.
 // Where the contents of a field with a `mapstructure:,squash` tag are bubbled up.
 type FlatConfig struct {
-	PackerBuildName     *string           `mapstructure:"packer_build_name" cty:"packer_build_name" hcl:"packer_build_name"`
-	PackerBuilderType   *string           `mapstructure:"packer_builder_type" cty:"packer_builder_type" hcl:"packer_builder_type"`
-	PackerCoreVersion   *string           `mapstructure:"packer_core_version" cty:"packer_core_version" hcl:"packer_core_version"`
-	PackerDebug         *bool             `mapstructure:"packer_debug" cty:"packer_debug" hcl:"packer_debug"`
-	PackerForce         *bool             `mapstructure:"packer_force" cty:"packer_force" hcl:"packer_force"`
-	PackerOnError       *string           `mapstructure:"packer_on_error" cty:"packer_on_error" hcl:"packer_on_error"`
-	PackerUserVars      map[string]string `mapstructure:"packer_user_variables" cty:"packer_user_variables" hcl:"packer_user_variables"`
-	PackerSensitiveVars []string          `mapstructure:"packer_sensitive_variables" cty:"packer_sensitive_variables" hcl:"packer_sensitive_variables"`
-	Cluster             *string           `mapstructure:"cluster" cty:"cluster" hcl:"cluster"`
-	Datacenter          *string           `mapstructure:"datacenter" cty:"datacenter" hcl:"datacenter"`
-	Datastore           *string           `mapstructure:"datastore" cty:"datastore" hcl:"datastore"`
-	DiskMode            *string           `mapstructure:"disk_mode" cty:"disk_mode" hcl:"disk_mode"`
-	Host                *string           `mapstructure:"host" cty:"host" hcl:"host"`
-	ESXiHost            *string           `mapstructure:"esxi_host" cty:"esxi_host" hcl:"esxi_host"`
-	Insecure            *bool             `mapstructure:"insecure" cty:"insecure" hcl:"insecure"`
-	Options             []string          `mapstructure:"options" cty:"options" hcl:"options"`
-	Overwrite           *bool             `mapstructure:"overwrite" cty:"overwrite" hcl:"overwrite"`
-	Password            *string           `mapstructure:"password" cty:"password" hcl:"password"`
-	ResourcePool        *string           `mapstructure:"resource_pool" cty:"resource_pool" hcl:"resource_pool"`
-	Username            *string           `mapstructure:"username" cty:"username" hcl:"username"`
-	VMFolder            *string           `mapstructure:"vm_folder" cty:"vm_folder" hcl:"vm_folder"`
-	VMName              *string           `mapstructure:"vm_name" cty:"vm_name" hcl:"vm_name"`
-	VMNetwork           *string           `mapstructure:"vm_network" cty:"vm_network" hcl:"vm_network"`
+	PackerBuildName      *string           `mapstructure:"packer_build_name" cty:"packer_build_name" hcl:"packer_build_name"`
+	PackerBuilderType    *string           `mapstructure:"packer_builder_type" cty:"packer_builder_type" hcl:"packer_builder_type"`
+	PackerCoreVersion    *string           `mapstructure:"packer_core_version" cty:"packer_core_version" hcl:"packer_core_version"`
+	PackerDebug          *bool             `mapstructure:"packer_debug" cty:"packer_debug" hcl:"packer_debug"`
+	PackerForce          *bool             `mapstructure:"packer_force" cty:"packer_force" hcl:"packer_force"`
+	PackerOnError        *string           `mapstructure:"packer_on_error" cty:"packer_on_error" hcl:"packer_on_error"`
+	PackerResume         *bool             `mapstructure:"packer_resume" cty:"packer_resume" hcl:"packer_resume"`
+	PackerCleanupTimeout *string           `mapstructure:"packer_cleanup_timeout" cty:"packer_cleanup_timeout" hcl:"packer_cleanup_timeout"`
+	PackerUserVars       map[string]string `mapstructure:"packer_user_variables" cty:"packer_user_variables" hcl:"packer_user_variables"`
+	PackerSensitiveVars  []string          `mapstructure:"packer_sensitive_variables" cty:"packer_sensitive_variables" hcl:"packer_sensitive_variables"`
+	Cluster              *string           `mapstructure:"cluster" cty:"cluster" hcl:"cluster"`
+	Datacenter           *string           `mapstructure:"datacenter" cty:"datacenter" hcl:"datacenter"`
+	Datastore            *string           `mapstructure:"datastore" cty:"datastore" hcl:"datastore"`
+	DiskMode             *string           `mapstructure:"disk_mode" cty:"disk_mode" hcl:"disk_mode"`
+	Host                 *string           `mapstructure:"host" cty:"host" hcl:"host"`
+	ESXiHost             *string           `mapstructure:"esxi_host" cty:"esxi_host" hcl:"esxi_host"`
+	Insecure             *bool             `mapstructure:"insecure" cty:"insecure" hcl:"insecure"`
+	Options              []string          `mapstructure:"options" cty:"options" hcl:"options"`
+	Overwrite            *bool             `mapstructure:"overwrite" cty:"overwrite" hcl:"overwrite"`
+	Password             *string           `mapstructure:"password" cty:"password" hcl:"password"`
+	ResourcePool         *string           `mapstructure:"resource_pool" cty:"resource_pool" hcl:"resource_pool"`
+	Username             *string           `mapstructure:"username" cty:"username" hcl:"username"`
+	VMFolder             *string           `mapstructure:"vm_folder" cty:"vm_folder" hcl:"vm_folder"`
+	VMName               *string           `mapstructure:"vm_name" cty:"vm_name" hcl:"vm_name"`
+	VMNetwork            *string           `mapstructure:"vm_network" cty:"vm_network" hcl:"vm_network"`
 }
 
 // FlatMapstructure returns a new FlatConfig.
@@ -52,6 +54,8 @@ func (*FlatConfig) HCL2Spec() map[string]hcldec.Spec {
 		"packer_debug":               &hcldec.AttrSpec{Name: "packer_debug", Type: cty.Bool, Required: false},
 		"packer_force":               &hcldec.AttrSpec{Name: "packer_force", Type: cty.Bool, Required: false},
 		"packer_on_error":            &hcldec.AttrSpec{Name: "packer_on_error", Type: cty.String, Required: false},
+		"packer_resume":              &hcldec.AttrSpec{Name: "packer_resume", Type: cty.Bool, Required: false},
+		"packer_cleanup_timeout":     &hcldec.AttrSpec{Name: "packer_cleanup_timeout", Type: cty.String, Required: false},
 		"packer_user_variables":      &hcldec.AttrSpec{Name: "packer_user_variables", Type: cty.Map(cty.String), Required: false},
 		"packer_sensitive_variables": &hcldec.AttrSpec{Name: "packer_sensitive_variables", Type: cty.List(cty.String), Required: false},
 		"cluster":                    &hcldec.AttrSpec{Name: "cluster", Type: cty.String, Required: false},
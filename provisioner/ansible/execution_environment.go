@@ -0,0 +1,145 @@
+package ansible
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// An ExecutionEnvironment runs Ansible from a container image or a Python
+// virtualenv instead of from the Ansible installed on the build host.
+type ExecutionEnvironment struct {
+	// The container image to run Ansible in, like
+	// `quay.io/ansible/ansible-runner:stable-2.10-devel`. The container
+	// shares the network of the host, so that Ansible can reach the
+	// proxy adapter and the machine, and the files Ansible needs, like the
+	// playbook directory, the inventory and the private key, are mounted at
+	// the same path as on the host.
+	Image string `mapstructure:"image"`
+	// The command running the container image, either `docker` or `podman`.
+	// Defaults to `docker`.
+	ContainerEngine string `mapstructure:"container_engine"`
+	// Extra arguments of the `run` command of the container engine, like
+	// `["-v", "/home/user/.ansible:/root/.ansible"]`.
+	ContainerArguments []string `mapstructure:"container_arguments"`
+	// The path to a Python virtualenv where Ansible is installed. The
+	// Ansible commands are run from the virtualenv, as if it was activated.
+	Virtualenv string `mapstructure:"virtualenv"`
+}
+
+func (e *ExecutionEnvironment) Prepare() []error {
+	var errs []error
+	if e.Image != "" && e.Virtualenv != "" {
+		errs = append(errs, fmt.Errorf("execution_environment: only one of image or virtualenv can be specified"))
+	}
+	if e.Image != "" && e.ContainerEngine == "" {
+		e.ContainerEngine = "docker"
+	}
+	if e.Image == "" && (e.ContainerEngine != "" || len(e.ContainerArguments) > 0) {
+		errs = append(errs, fmt.Errorf("execution_environment: container_engine and container_arguments require an image"))
+	}
+	if e.Virtualenv != "" {
+		venv, err := filepath.Abs(e.Virtualenv)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("execution_environment: virtualenv: %s", err))
+		} else if info, err := os.Stat(venv); err != nil {
+			errs = append(errs, fmt.Errorf("execution_environment: virtualenv: %s is invalid: %s", e.Virtualenv, err))
+		} else if !info.IsDir() {
+			errs = append(errs, fmt.Errorf("execution_environment: virtualenv: %s must point to a directory", e.Virtualenv))
+		}
+		e.Virtualenv = venv
+	}
+	return errs
+}
+
+// command returns the command running name with args and the environment
+// variables env in the execution environment. In a container, mounts are
+// the host paths to mount in it.
+func (e *ExecutionEnvironment) command(name string, args []string, env []string, mounts []string) *exec.Cmd {
+	switch {
+	case e.Image != "":
+		return exec.Command(e.ContainerEngine, e.containerArgs(name, args, env, mounts)...)
+	case e.Virtualenv != "":
+		bin := filepath.Join(e.Virtualenv, "bin")
+		if runtime.GOOS == "windows" {
+			bin = filepath.Join(e.Virtualenv, "Scripts")
+		}
+		// Commands given as a path, and not just a name, are left alone
+		if !strings.ContainsRune(name, filepath.Separator) && !strings.ContainsRune(name, '/') {
+			name = filepath.Join(bin, name)
+		}
+		cmd := exec.Command(name, args...)
+		cmd.Env = append(os.Environ(),
+			"VIRTUAL_ENV="+e.Virtualenv,
+			"PATH="+bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+		cmd.Env = append(cmd.Env, env...)
+		return cmd
+	default:
+		cmd := exec.Command(name, args...)
+		cmd.Env = append(os.Environ(), env...)
+		return cmd
+	}
+}
+
+func (e *ExecutionEnvironment) containerArgs(name string, args []string, env []string, mounts []string) []string {
+	res := []string{"run", "--rm", "--network", "host"}
+	if wd, err := os.Getwd(); err == nil {
+		res = append(res, "-v", wd+":"+wd, "-w", wd)
+	}
+	for _, m := range mounts {
+		res = append(res, "-v", m+":"+m)
+	}
+	for _, kv := range env {
+		res = append(res, "-e", kv)
+	}
+	res = append(res, e.ContainerArguments...)
+	res = append(res, e.Image, name)
+	return append(res, args...)
+}
+
+// containerMounts returns the host paths Ansible needs to read or write,
+// besides the working directory, sorted and without duplicates.
+func (p *Provisioner) containerMounts(privKeyFile string) []string {
+	paths := []string{p.config.InventoryFile, privKeyFile}
+	if playbook, err := filepath.Abs(p.config.PlaybookFile); err == nil {
+		paths = append(paths, filepath.Dir(playbook))
+	}
+	for _, path := range []string{p.config.GalaxyFile, p.config.RolesPath, p.config.CollectionsPath, p.config.InventoryDirectory} {
+		if path == "" {
+			continue
+		}
+		path, err := filepath.Abs(path)
+		if err != nil {
+			continue
+		}
+		if !isDir(path) {
+			path = filepath.Dir(path)
+		}
+		paths = append(paths, path)
+	}
+
+	wd, _ := os.Getwd()
+	seen := map[string]bool{"": true, wd: true}
+	var mounts []string
+	for _, path := range paths {
+		if path != "" && !filepath.IsAbs(path) {
+			path, _ = filepath.Abs(path)
+		}
+		if seen[path] {
+			continue
+		}
+		seen[path] = true
+		mounts = append(mounts, path)
+	}
+	sort.Strings(mounts)
+	return mounts
+}
+
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
@@ -1,4 +1,4 @@
-//go:generate mapstructure-to-hcl2 -type Config
+//go:generate mapstructure-to-hcl2 -type Config,ExecutionEnvironment
 //go:generate struct-markdown
 
 package ansible
@@ -18,7 +18,6 @@ import (
 	"log"
 	"net"
 	"os"
-	"os/exec"
 	"os/user"
 	"path/filepath"
 	"regexp"
@@ -202,8 +201,19 @@ type Config struct {
 	// Currently, this defaults to `true` for all connection types. In the future,
 	// this option will be changed to default to `false` for SSH and WinRM
 	// connections where the provisioner has access to a host IP.
-	UseProxy     config.Trilean `mapstructure:"use_proxy"`
-	userWasEmpty bool
+	UseProxy config.Trilean `mapstructure:"use_proxy"`
+	// Run Ansible from a container image or a Python virtualenv, so that the
+	// build host doesn't need a matching version of Ansible installed. See
+	// [Execution Environments](#execution-environments).
+	ExecutionEnvironment ExecutionEnvironment `mapstructure:"execution_environment"`
+	// The Ansible connection plugin used to connect to the machine over WinRM
+	// when `use_proxy` is `false`, either `winrm` or `psrp`. Defaults to
+	// `winrm`. The inventory uses the connection settings of the WinRM
+	// communicator: HTTPS with `winrm_use_ssl`, no certificate validation
+	// with `winrm_insecure`, and NTLM or client certificate authentication
+	// with `winrm_use_ntlm` or `winrm_client_cert_file`.
+	WinRMConnection string `mapstructure:"winrm_connection"`
+	userWasEmpty    bool
 }
 
 type Provisioner struct {
@@ -250,6 +260,10 @@ func (p *Provisioner) Prepare(raws ...interface{}) error {
 		p.config.HostAlias = "default"
 	}
 
+	if p.config.WinRMConnection == "" {
+		p.config.WinRMConnection = "winrm"
+	}
+
 	var errs *packer.MultiError
 	err = validateFileConfig(p.config.PlaybookFile, "playbook_file", true)
 	if err != nil {
@@ -286,6 +300,10 @@ func (p *Provisioner) Prepare(raws ...interface{}) error {
 		p.config.AnsibleEnvVars = append(p.config.AnsibleEnvVars, "ANSIBLE_SCP_IF_SSH=True")
 	}
 
+	if p.config.WinRMConnection != "winrm" && p.config.WinRMConnection != "psrp" {
+		errs = packer.MultiErrorAppend(errs, fmt.Errorf("winrm_connection: %q must be winrm or psrp", p.config.WinRMConnection))
+	}
+
 	if p.config.LocalPort > 65535 {
 		errs = packer.MultiErrorAppend(errs, fmt.Errorf("local_port: %d must be a valid port", p.config.LocalPort))
 	}
@@ -298,6 +316,10 @@ func (p *Provisioner) Prepare(raws ...interface{}) error {
 		}
 	}
 
+	for _, err := range p.config.ExecutionEnvironment.Prepare() {
+		errs = packer.MultiErrorAppend(errs, err)
+	}
+
 	if !p.config.SkipVersionCheck {
 		err = p.getVersion()
 		if err != nil {
@@ -335,7 +357,7 @@ func (p *Provisioner) Prepare(raws ...interface{}) error {
 }
 
 func (p *Provisioner) getVersion() error {
-	out, err := exec.Command(p.config.Command, "--version").Output()
+	out, err := p.config.ExecutionEnvironment.command(p.config.Command, []string{"--version"}, nil, nil).Output()
 	if err != nil {
 		return fmt.Errorf(
 			"Error running \"%s --version\": %s", p.config.Command, err.Error())
@@ -445,6 +467,52 @@ const DefaultSSHInventoryFilev2 = "{{ .HostAlias }} ansible_host={{ .Host }} ans
 const DefaultSSHInventoryFilev1 = "{{ .HostAlias }} ansible_ssh_host={{ .Host }} ansible_ssh_user={{ .User }} ansible_ssh_port={{ .Port }}\n"
 const DefaultWinRMInventoryFilev2 = "{{ .HostAlias}} ansible_host={{ .Host }} ansible_connection=winrm ansible_winrm_transport=basic ansible_shell_type=powershell ansible_user={{ .User}} ansible_port={{ .Port }}\n"
 
+// winRMInventoryTemplate returns the inventory line connecting Ansible to the
+// WinRM listener of the machine with the settings of the communicator.
+func (p *Provisioner) winRMInventoryTemplate() string {
+	useSSL, _ := p.generatedData["WinRMUseSSL"].(bool)
+	insecure, _ := p.generatedData["WinRMInsecure"].(bool)
+	useNTLM, _ := p.generatedData["WinRMUseNTLM"].(bool)
+	certFile, _ := p.generatedData["WinRMClientCertFile"].(string)
+	keyFile, _ := p.generatedData["WinRMClientKeyFile"].(string)
+
+	auth := "basic"
+	if certFile != "" {
+		auth = "certificate"
+	} else if useNTLM {
+		auth = "ntlm"
+	}
+
+	if p.config.WinRMConnection == "psrp" {
+		protocol := "http"
+		if useSSL {
+			protocol = "https"
+		}
+		host := "{{ .HostAlias}} ansible_host={{ .Host }} ansible_connection=psrp ansible_psrp_protocol=" + protocol +
+			" ansible_psrp_auth=" + auth + " ansible_user={{ .User}} ansible_port={{ .Port }}"
+		if insecure {
+			host += " ansible_psrp_cert_validation=ignore"
+		}
+		if certFile != "" {
+			host += fmt.Sprintf(" ansible_psrp_certificate_pem=%s ansible_psrp_certificate_key_pem=%s", certFile, keyFile)
+		}
+		return host + "\n"
+	}
+
+	host := "{{ .HostAlias}} ansible_host={{ .Host }} ansible_connection=winrm ansible_winrm_transport=" + auth +
+		" ansible_shell_type=powershell ansible_user={{ .User}} ansible_port={{ .Port }}"
+	if useSSL {
+		host += " ansible_winrm_scheme=https"
+	}
+	if insecure {
+		host += " ansible_winrm_server_cert_validation=ignore"
+	}
+	if certFile != "" {
+		host += fmt.Sprintf(" ansible_winrm_cert_pem=%s ansible_winrm_cert_key_pem=%s", certFile, keyFile)
+	}
+	return host + "\n"
+}
+
 func (p *Provisioner) createInventoryFile() error {
 	log.Printf("Creating inventory file for Ansible run...")
 	tf, err := ioutil.TempFile(p.config.InventoryDirectory, "packer-provisioner-ansible")
@@ -461,7 +529,7 @@ func (p *Provisioner) createInventoryFile() error {
 			hostTemplate = DefaultSSHInventoryFilev1
 		}
 		if p.config.UseProxy.False() && p.generatedData["ConnType"] == "winrm" {
-			hostTemplate = DefaultWinRMInventoryFilev2
+			hostTemplate = p.winRMInventoryTemplate()
 		}
 	}
 
@@ -601,7 +669,10 @@ func (p *Provisioner) Provision(ctx context.Context, ui packer.Ui, comm packer.C
 			}
 		case "winrm":
 			ui.Message("Not using Proxy adapter for Ansible run:\n" +
-				"\tUsing WinRM Password from Packer communicator...")
+				"\tUsing WinRM connection settings from Packer communicator...")
+			if p.config.userWasEmpty {
+				p.config.User = generatedData["User"].(string)
+			}
 		}
 	}
 
@@ -678,7 +749,7 @@ func (p *Provisioner) executeGalaxy(ui packer.Ui, comm packer.Communicator) erro
 // Intended to be invoked from p.executeGalaxy depending on the Ansible Galaxy parameters passed to Packer
 func (p *Provisioner) invokeGalaxyCommand(args []string, ui packer.Ui, comm packer.Communicator) error {
 	ui.Message(fmt.Sprintf("Executing Ansible Galaxy"))
-	cmd := exec.Command(p.config.GalaxyCommand, args...)
+	cmd := p.config.ExecutionEnvironment.command(p.config.GalaxyCommand, args, nil, p.containerMounts(""))
 
 	stdout, err := cmd.StdoutPipe()
 	if err != nil {
@@ -760,7 +831,9 @@ func (p *Provisioner) createCmdArgs(httpAddr, inventory, playbook, privKeyFile s
 	args = append(args, p.config.ExtraArguments...)
 
 	// Add password to ansible call.
-	if !checkArg("ansible_password", args) && p.config.UseProxy.False() && p.generatedData["ConnType"] == "winrm" {
+	// Certificate authentication doesn't need a password.
+	winRMCertFile, _ := p.generatedData["WinRMClientCertFile"].(string)
+	if !checkArg("ansible_password", args) && p.config.UseProxy.False() && p.generatedData["ConnType"] == "winrm" && winRMCertFile == "" {
 		args = append(args, "-e", fmt.Sprintf("ansible_password=%s", p.generatedData["Password"]))
 	}
 
@@ -794,12 +867,7 @@ func (p *Provisioner) executeAnsible(ui packer.Ui, comm packer.Communicator, pri
 	}
 	args, envvars := p.createCmdArgs(httpAddr, inventory, playbook, privKeyFile)
 
-	cmd := exec.Command(p.config.Command, args...)
-
-	cmd.Env = os.Environ()
-	if len(envvars) > 0 {
-		cmd.Env = append(cmd.Env, envvars...)
-	}
+	cmd := p.config.ExecutionEnvironment.command(p.config.Command, args, envvars, p.containerMounts(privKeyFile))
 
 	stdout, err := cmd.StdoutPipe()
 	if err != nil {
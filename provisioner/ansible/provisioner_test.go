@@ -308,6 +308,89 @@ func TestProvisionerPrepare_InventoryDirectory(t *testing.T) {
 	}
 }
 
+func TestProvisionerPrepare_ExecutionEnvironment(t *testing.T) {
+	var p Provisioner
+	config := testConfig(t)
+	defer os.Remove(config["command"].(string))
+
+	playbook_file, err := ioutil.TempFile("", "playbook")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer os.Remove(playbook_file.Name())
+	config["playbook_file"] = playbook_file.Name()
+	config["skip_version_check"] = true
+
+	config["execution_environment"] = map[string]interface{}{
+		"image":      "ansible:2.10",
+		"virtualenv": "/opt/ansible",
+	}
+	if err := p.Prepare(config); err == nil {
+		t.Fatal("should error with both an image and a virtualenv")
+	}
+
+	p = Provisioner{}
+	config["execution_environment"] = map[string]interface{}{
+		"virtualenv": "doesnotexist",
+	}
+	if err := p.Prepare(config); err == nil {
+		t.Fatal("should error if the virtualenv does not exist")
+	}
+
+	p = Provisioner{}
+	config["execution_environment"] = map[string]interface{}{
+		"image": "ansible:2.10",
+	}
+	if err := p.Prepare(config); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if p.config.ExecutionEnvironment.ContainerEngine != "docker" {
+		t.Fatalf("unexpected container engine: %s", p.config.ExecutionEnvironment.ContainerEngine)
+	}
+}
+
+func TestExecutionEnvironment_container(t *testing.T) {
+	wd, _ := os.Getwd()
+	e := ExecutionEnvironment{
+		Image:              "ansible:2.10",
+		ContainerEngine:    "podman",
+		ContainerArguments: []string{"--pull", "always"},
+	}
+	cmd := e.command("ansible-playbook", []string{"-i", "/tmp/inventory", "play.yml"},
+		[]string{"ANSIBLE_FORCE_COLOR=1"}, []string{"/tmp/inventory"})
+
+	expected := []string{"podman", "run", "--rm", "--network", "host",
+		"-v", wd + ":" + wd, "-w", wd,
+		"-v", "/tmp/inventory:/tmp/inventory",
+		"-e", "ANSIBLE_FORCE_COLOR=1",
+		"--pull", "always",
+		"ansible:2.10", "ansible-playbook", "-i", "/tmp/inventory", "play.yml"}
+	assert.Equal(t, expected, cmd.Args)
+}
+
+func TestExecutionEnvironment_virtualenv(t *testing.T) {
+	e := ExecutionEnvironment{Virtualenv: "/opt/ansible"}
+	cmd := e.command("ansible-playbook", []string{"--version"}, []string{"ANSIBLE_FORCE_COLOR=1"}, nil)
+
+	assert.Equal(t, []string{"/opt/ansible/bin/ansible-playbook", "--version"}, cmd.Args)
+	assert.Contains(t, cmd.Env, "VIRTUAL_ENV=/opt/ansible")
+	assert.Contains(t, cmd.Env, "ANSIBLE_FORCE_COLOR=1")
+
+	cmd = e.command("/usr/local/bin/ansible-playbook", nil, nil, nil)
+	assert.Equal(t, "/usr/local/bin/ansible-playbook", cmd.Path)
+}
+
+func TestContainerMounts(t *testing.T) {
+	var p Provisioner
+	p.config.PlaybookFile = "/srv/ansible/site.yml"
+	p.config.InventoryFile = "/tmp/packer-provisioner-ansible123"
+	p.config.RolesPath = "/srv/ansible/roles"
+
+	mounts := p.containerMounts("/tmp/ansible-key456")
+	expected := []string{"/srv/ansible", "/tmp/ansible-key456", "/tmp/packer-provisioner-ansible123"}
+	assert.Equal(t, expected, mounts)
+}
+
 func TestAnsibleGetVersion(t *testing.T) {
 	if os.Getenv("PACKER_ACC") == "" {
 		t.Skip("This test is only run with PACKER_ACC=1 and it requires Ansible to be installed")
@@ -360,13 +443,14 @@ func TestAnsibleLongMessages(t *testing.T) {
 
 func TestCreateInventoryFile(t *testing.T) {
 	type inventoryFileTestCases struct {
-		AnsibleVersion uint
-		User           string
-		Groups         []string
-		EmptyGroups    []string
-		UseProxy       confighelper.Trilean
-		GeneratedData  map[string]interface{}
-		Expected       string
+		AnsibleVersion  uint
+		User            string
+		Groups          []string
+		EmptyGroups     []string
+		UseProxy        confighelper.Trilean
+		WinRMConnection string
+		GeneratedData   map[string]interface{}
+		Expected        string
 	}
 
 	TestCases := []inventoryFileTestCases{
@@ -433,6 +517,32 @@ default ansible_ssh_host=123.45.67.89 ansible_ssh_user=testuser ansible_ssh_port
 			}),
 			Expected: "default ansible_host=123.45.67.89 ansible_connection=winrm ansible_winrm_transport=basic ansible_shell_type=powershell ansible_user=testuser ansible_port=1234\n",
 		},
+		{
+			AnsibleVersion: 2,
+			User:           "testuser",
+			UseProxy:       confighelper.TriFalse,
+			GeneratedData: basicGenData(map[string]interface{}{
+				"ConnType":      "winrm",
+				"Password":      "12345",
+				"WinRMUseSSL":   true,
+				"WinRMInsecure": true,
+				"WinRMUseNTLM":  true,
+			}),
+			Expected: "default ansible_host=123.45.67.89 ansible_connection=winrm ansible_winrm_transport=ntlm ansible_shell_type=powershell ansible_user=testuser ansible_port=1234 ansible_winrm_scheme=https ansible_winrm_server_cert_validation=ignore\n",
+		},
+		{
+			AnsibleVersion:  2,
+			User:            "testuser",
+			UseProxy:        confighelper.TriFalse,
+			WinRMConnection: "psrp",
+			GeneratedData: basicGenData(map[string]interface{}{
+				"ConnType":            "winrm",
+				"WinRMUseSSL":         true,
+				"WinRMClientCertFile": "/certs/packer.pem",
+				"WinRMClientKeyFile":  "/certs/packer.key",
+			}),
+			Expected: "default ansible_host=123.45.67.89 ansible_connection=psrp ansible_psrp_protocol=https ansible_psrp_auth=certificate ansible_user=testuser ansible_port=1234 ansible_psrp_certificate_pem=/certs/packer.pem ansible_psrp_certificate_key_pem=/certs/packer.key\n",
+		},
 	}
 
 	for _, tc := range TestCases {
@@ -444,6 +554,9 @@ default ansible_ssh_host=123.45.67.89 ansible_ssh_user=testuser ansible_ssh_port
 		p.config.Groups = tc.Groups
 		p.config.EmptyGroups = tc.EmptyGroups
 		p.config.UseProxy = tc.UseProxy
+		if tc.WinRMConnection != "" {
+			p.config.WinRMConnection = tc.WinRMConnection
+		}
 		p.generatedData = tc.GeneratedData
 
 		err := p.createInventoryFile()
@@ -575,6 +688,20 @@ func TestCreateCmdArgs(t *testing.T) {
 			ExpectedArgs:    []string{"-e", "packer_builder_type=fakebuilder", "-e", "packer_http_addr=123.45.67.89", "-e", "ansible_password=ilovebananapancakes", "-i", "/var/inventory", "test-playbook.yml"},
 			ExpectedEnvVars: []string{"ENV_1=pancakes", "ENV_2=bananas"},
 		},
+		{
+			// No ansible_password with a WinRM client certificate.
+			TestName: "No ansible_password with a WinRM client certificate.",
+			UseProxy: confighelper.TriFalse,
+			generatedData: basicGenData(map[string]interface{}{
+				"ConnType":            "winrm",
+				"Password":            "",
+				"WinRMClientCertFile": "/certs/packer.pem",
+				"PackerHTTPAddr":      "123.45.67.89",
+			}),
+			callArgs:        []string{"123.45.67.89", "/var/inventory", "test-playbook.yml", ""},
+			ExpectedArgs:    []string{"-e", "packer_builder_type=fakebuilder", "-e", "packer_http_addr=123.45.67.89", "-i", "/var/inventory", "test-playbook.yml"},
+			ExpectedEnvVars: []string{},
+		},
 		{
 			// Neither special ssh stuff, nor special windows stuff. This is docker!
 			TestName:        "Neither special ssh stuff, nor special windows stuff. This is docker!",
@@ -5,6 +5,7 @@ package breakpoint
 import (
 	"context"
 	"fmt"
+	"net"
 
 	"golang.org/x/sync/errgroup"
 
@@ -21,6 +22,14 @@ type Config struct {
 	Note    string `mapstructure:"note"`
 	Disable bool   `mapstructure:"disable"`
 
+	// The path of a file on the host running Packer. Instead of waiting for
+	// enter, the build resumes once the file exists, and the file is removed.
+	ContinueFile string `mapstructure:"continue_file"`
+	// The address an HTTP server listens to, like `127.0.0.1:8930`. Instead
+	// of waiting for enter, the build resumes when `/continue` is POSTed to,
+	// and fails when `/abort` is.
+	HTTPAddress string `mapstructure:"http_address"`
+
 	ctx interpolate.Context
 }
 
@@ -43,6 +52,12 @@ func (p *Provisioner) Prepare(raws ...interface{}) error {
 		return err
 	}
 
+	if p.config.HTTPAddress != "" {
+		if _, _, err := net.SplitHostPort(p.config.HTTPAddress); err != nil {
+			return fmt.Errorf("http_address: %s", err)
+		}
+	}
+
 	return nil
 }
 
@@ -64,6 +79,10 @@ func (p *Provisioner) Provision(ctx context.Context, ui packer.Ui, comm packer.C
 		ui.Say("Pausing at breakpoint provisioner.")
 	}
 
+	if p.config.ContinueFile != "" || p.config.HTTPAddress != "" {
+		return p.waitForSignal(ctx, ui)
+	}
+
 	message := fmt.Sprintf(
 		"Press enter to continue.")
 
@@ -84,3 +103,31 @@ func (p *Provisioner) Provision(ctx context.Context, ui packer.Ui, comm packer.C
 	}
 	return nil
 }
+
+// waitForSignal waits for the continue file or for a request to the HTTP
+// server, whichever comes first.
+func (p *Provisioner) waitForSignal(ctx context.Context, ui packer.Ui) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	signals := make(chan error, 2)
+	if p.config.HTTPAddress != "" {
+		server, err := listenForSignal(p.config.HTTPAddress)
+		if err != nil {
+			return fmt.Errorf("Error starting the breakpoint server: %s", err)
+		}
+		ui.Say(fmt.Sprintf("POST to %s/continue to continue, or to %s/abort to abort the build.",
+			server.URL(), server.URL()))
+		go func() { signals <- server.wait(ctx) }()
+	}
+	if p.config.ContinueFile != "" {
+		ui.Say(fmt.Sprintf("Create %s to continue.", p.config.ContinueFile))
+		go func() { signals <- waitForFile(ctx, p.config.ContinueFile) }()
+	}
+
+	if err := <-signals; err != nil {
+		return err
+	}
+	ui.Say("Continuing...")
+	return nil
+}
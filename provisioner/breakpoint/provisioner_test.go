@@ -0,0 +1,134 @@
+package breakpoint
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/packer/packer"
+)
+
+func testUi() *packer.BasicUi {
+	return &packer.BasicUi{
+		Reader:      new(bytes.Buffer),
+		Writer:      new(bytes.Buffer),
+		ErrorWriter: new(bytes.Buffer),
+	}
+}
+
+func TestProvisionerPrepare_HTTPAddress(t *testing.T) {
+	var p Provisioner
+	if err := p.Prepare(map[string]interface{}{"http_address": "localhost"}); err == nil {
+		t.Fatal("should have error without a port")
+	}
+
+	p = Provisioner{}
+	if err := p.Prepare(map[string]interface{}{"http_address": "127.0.0.1:0"}); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+}
+
+func TestProvisionerProvision_ContinueFile(t *testing.T) {
+	continueFilePollInterval = 10 * time.Millisecond
+
+	dir, err := ioutil.TempDir("", "packer-breakpoint")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "continue")
+
+	var p Provisioner
+	if err := p.Prepare(map[string]interface{}{"continue_file": file}); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- p.Provision(context.Background(), testUi(), nil, nil) }()
+
+	select {
+	case err := <-done:
+		t.Fatalf("should wait for the continue file: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	if err := ioutil.WriteFile(file, nil, 0644); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if _, err := os.Stat(file); !os.IsNotExist(err) {
+		t.Fatalf("the continue file should be removed: %v", err)
+	}
+
+	// The build stops waiting when it is cancelled
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := p.Provision(ctx, testUi(), nil, nil); err != context.DeadlineExceeded {
+		t.Fatalf("bad: %v", err)
+	}
+}
+
+func TestProvisionerProvision_HTTPAddress(t *testing.T) {
+	for _, tc := range []struct {
+		endpoint string
+		err      error
+	}{
+		{"continue", nil},
+		{"abort", errAborted},
+	} {
+		var p Provisioner
+		if err := p.Prepare(map[string]interface{}{"http_address": "127.0.0.1:0"}); err != nil {
+			t.Fatalf("err: %s", err)
+		}
+
+		ui := &sayUi{BasicUi: testUi(), said: make(chan string, 10)}
+		done := make(chan error, 1)
+		go func() { done <- p.Provision(context.Background(), ui, nil, nil) }()
+
+		var url string
+		for url == "" {
+			url = regexp.MustCompile(`http://[^ ]+/continue`).FindString(<-ui.said)
+		}
+		url = url[:len(url)-len("continue")] + tc.endpoint
+
+		resp, err := http.Get(url)
+		if err != nil {
+			t.Fatalf("err: %s", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusMethodNotAllowed {
+			t.Fatalf("GET should not be allowed: %d", resp.StatusCode)
+		}
+
+		resp, err = http.Post(url, "text/plain", nil)
+		if err != nil {
+			t.Fatalf("err: %s", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("bad status: %d", resp.StatusCode)
+		}
+
+		if err := <-done; err != tc.err {
+			t.Fatalf("%s: bad: %v", tc.endpoint, err)
+		}
+	}
+}
+
+// sayUi sends what the provisioner says on a channel.
+type sayUi struct {
+	*packer.BasicUi
+	said chan string
+}
+
+func (u *sayUi) Say(message string) {
+	u.said <- message
+}
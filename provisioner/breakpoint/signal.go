@@ -0,0 +1,103 @@
+package breakpoint
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"time"
+)
+
+// errAborted is returned when the build is aborted at the breakpoint.
+var errAborted = errors.New("build aborted at breakpoint")
+
+// continueFilePollInterval is how often the continue file is looked for.
+var continueFilePollInterval = time.Second
+
+// waitForFile waits until the file at path exists, and removes it.
+func waitForFile(ctx context.Context, path string) error {
+	ticker := time.NewTicker(continueFilePollInterval)
+	defer ticker.Stop()
+	for {
+		if _, err := os.Stat(path); err == nil {
+			if err := os.Remove(path); err != nil {
+				log.Printf("[WARN] Removing continue file %s: %s", path, err)
+			}
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// signalServer resumes or aborts the build when its /continue or /abort
+// endpoints are POSTed to.
+type signalServer struct {
+	listener net.Listener
+	signal   chan error
+}
+
+func listenForSignal(address string) (*signalServer, error) {
+	l, err := net.Listen("tcp", address)
+	if err != nil {
+		return nil, err
+	}
+	return &signalServer{listener: l, signal: make(chan error, 1)}, nil
+}
+
+// URL returns the base URL of the server.
+func (s *signalServer) URL() string {
+	return fmt.Sprintf("http://%s", s.listener.Addr())
+}
+
+func (s *signalServer) handler(result error) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		select {
+		case s.signal <- result:
+			w.WriteHeader(http.StatusOK)
+		default:
+			http.Error(w, "the build was already signaled", http.StatusConflict)
+		}
+	}
+}
+
+// wait serves the signal endpoints until one of them is called, and returns
+// errAborted when the build is aborted.
+func (s *signalServer) wait(ctx context.Context) error {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/continue", s.handler(nil))
+	mux.HandleFunc("/abort", s.handler(errAborted))
+	server := &http.Server{Handler: mux}
+	go func() {
+		if err := server.Serve(s.listener); err != nil && err != http.ErrServerClosed {
+			log.Printf("[WARN] Breakpoint server: %s", err)
+		}
+	}()
+	defer func() {
+		// Let the handler answer the request that resumed the build, but
+		// don't wait for connections that never sent a request
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			server.Close()
+		}
+	}()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case err := <-s.signal:
+		return err
+	}
+}
@@ -0,0 +1,246 @@
+package file
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/packer/packer"
+)
+
+// statCommand prints the mode, modification time, size and path of its
+// arguments, with either GNU or BSD stat.
+const statCommand = `stat -c "%a %Y %s %n" "$@" 2>/dev/null || stat -f "%Lp %m %z %N" "$@"`
+
+// A remoteFile is a regular file of the machine.
+type remoteFile struct {
+	// The path of the file, relative to the directory it was listed from.
+	Path    string
+	Mode    os.FileMode
+	ModTime time.Time
+	Size    int64
+}
+
+func isGlob(src string) bool {
+	return strings.ContainsAny(src, "*?[")
+}
+
+// splitGlob splits src into the directory to list on the machine and the
+// pattern the listed paths have to match. Directories have an empty
+// pattern.
+func splitGlob(src string) (string, string) {
+	elems := strings.Split(strings.TrimSuffix(src, "/"), "/")
+	i := 0
+	for i < len(elems) && !isGlob(elems[i]) {
+		i++
+	}
+	root := strings.Join(elems[:i], "/")
+	switch {
+	case root == "" && i > 0:
+		root = "/"
+	case root == "":
+		root = "."
+	}
+	return root, strings.Trim(strings.Join(elems[i:], "/"), "/")
+}
+
+// matchPath reports whether the slash separated path name, or one of its
+// parent directories, matches pattern. A `**` element of pattern matches
+// any number of directories.
+func matchPath(pattern, name string) bool {
+	if pattern == "" {
+		return true
+	}
+	return matchElems(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+func matchElems(pattern, elems []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(elems); i++ {
+				if matchElems(pattern[1:], elems[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(elems) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], elems[0]); !ok {
+			return false
+		}
+		pattern, elems = pattern[1:], elems[1:]
+	}
+	return true
+}
+
+// excluded reports whether name matches one of the exclude patterns. A
+// pattern without a slash matches any element of name, other patterns match
+// name from its start.
+func excluded(excludes []string, name string) bool {
+	for _, pattern := range excludes {
+		if !strings.Contains(strings.TrimSuffix(pattern, "/"), "/") {
+			for _, elem := range strings.Split(name, "/") {
+				if ok, _ := path.Match(strings.TrimSuffix(pattern, "/"), elem); ok {
+					return true
+				}
+			}
+			continue
+		}
+		if matchPath(strings.Trim(pattern, "/"), name) {
+			return true
+		}
+	}
+	return false
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.Replace(s, "'", `'"'"'`, -1) + "'"
+}
+
+// parseStat parses the output of statCommand.
+func parseStat(out []byte) ([]remoteFile, error) {
+	var files []remoteFile
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			continue
+		}
+		fields := strings.SplitN(line, " ", 4)
+		if len(fields) != 4 {
+			return nil, fmt.Errorf("unexpected file listing %q", line)
+		}
+		mode, err := strconv.ParseUint(fields[0], 8, 32)
+		if err != nil {
+			return nil, fmt.Errorf("unexpected file mode in %q: %s", line, err)
+		}
+		mtime, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("unexpected modification time in %q: %s", line, err)
+		}
+		size, err := strconv.ParseInt(fields[2], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("unexpected file size in %q: %s", line, err)
+		}
+		files = append(files, remoteFile{
+			Path:    strings.TrimPrefix(fields[3], "./"),
+			Mode:    os.FileMode(mode) & os.ModePerm,
+			ModTime: time.Unix(mtime, 0),
+			Size:    size,
+		})
+	}
+	return files, scanner.Err()
+}
+
+// runStat runs a statCommand based command on the machine and parses its
+// output.
+func runStat(ctx context.Context, comm packer.Communicator, command string) ([]remoteFile, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := &packer.RemoteCmd{Command: command, Stdout: &stdout, Stderr: &stderr}
+	if err := comm.Start(ctx, cmd); err != nil {
+		return nil, err
+	}
+	if status := cmd.Wait(); status != 0 {
+		return nil, fmt.Errorf("exit status %d: %s", status, strings.TrimSpace(stderr.String()))
+	}
+	return parseStat(stdout.Bytes())
+}
+
+// listRemoteFiles lists the regular files under the root directory of the
+// machine, recursively.
+func listRemoteFiles(ctx context.Context, comm packer.Communicator, root string) ([]remoteFile, error) {
+	command := fmt.Sprintf("cd %s && find . -type f -exec sh -c %s sh {} +",
+		shellQuote(root), shellQuote(statCommand))
+	return runStat(ctx, comm, command)
+}
+
+func statRemoteFile(ctx context.Context, comm packer.Communicator, name string) (*remoteFile, error) {
+	files, err := runStat(ctx, comm, fmt.Sprintf("sh -c %s sh %s", shellQuote(statCommand), shellQuote(name)))
+	if err != nil {
+		return nil, err
+	}
+	if len(files) != 1 {
+		return nil, fmt.Errorf("unexpected listing of %s", name)
+	}
+	return &files[0], nil
+}
+
+// downloadDir downloads the files under a directory of the machine, or the
+// files matching a glob pattern, to the local directory dst.
+func (p *Provisioner) downloadDir(ctx context.Context, ui packer.Ui, comm packer.Communicator, src string, dst string) error {
+	root, pattern := splitGlob(src)
+	files, err := listRemoteFiles(ctx, comm, root)
+	if err != nil {
+		if len(p.config.Exclude) == 0 && !p.config.PreserveAttributes {
+			// The machine can't list its files, like a Windows one, so let
+			// the communicator download the directory
+			log.Printf("[WARN] Listing %s failed, downloading it with the communicator: %s", root, err)
+			return comm.DownloadDir(src, dst, nil)
+		}
+		return fmt.Errorf("Error listing %s: %s", root, err)
+	}
+
+	downloaded, unchanged := 0, 0
+	for _, f := range files {
+		if !matchPath(pattern, f.Path) || excluded(p.config.Exclude, f.Path) {
+			continue
+		}
+
+		local := filepath.Join(dst, filepath.FromSlash(f.Path))
+		if p.config.PreserveAttributes {
+			if info, err := os.Stat(local); err == nil && info.Size() == f.Size && info.ModTime().Unix() == f.ModTime.Unix() {
+				unchanged++
+				continue
+			}
+		}
+
+		log.Printf("[DEBUG] Downloading %s to %s", path.Join(root, f.Path), local)
+		if err := p.downloadFile(comm, path.Join(root, f.Path), local, &f); err != nil {
+			ui.Error(fmt.Sprintf("Download failed: %s", err))
+			return err
+		}
+		downloaded++
+	}
+
+	ui.Message(fmt.Sprintf("Downloaded %d files, %d unchanged", downloaded, unchanged))
+	return nil
+}
+
+// downloadFile downloads src to dst, and gives it the mode and modification
+// time of f with preserve_attributes.
+func (p *Provisioner) downloadFile(comm packer.Communicator, src string, dst string, f *remoteFile) error {
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	if err := comm.Download(src, out); err != nil {
+		out.Close()
+		os.Remove(dst)
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+
+	if !p.config.PreserveAttributes || f == nil {
+		return nil
+	}
+	if err := os.Chmod(dst, f.Mode); err != nil {
+		return err
+	}
+	return os.Chtimes(dst, f.ModTime, f.ModTime)
+}
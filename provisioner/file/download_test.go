@@ -0,0 +1,167 @@
+package file
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/packer/packer"
+)
+
+func TestSplitGlob(t *testing.T) {
+	tests := []struct {
+		src, root, pattern string
+	}{
+		{"/var/log/", "/var/log", ""},
+		{"/var/log/*.log", "/var/log", "*.log"},
+		{"/var/log/**/*.log", "/var/log", "**/*.log"},
+		{"/home/*/build/", "/home", "*/build"},
+		{"/*.log", "/", "*.log"},
+		{"*.log", ".", "*.log"},
+		{"logs/", "logs", ""},
+	}
+	for _, tt := range tests {
+		root, pattern := splitGlob(tt.src)
+		if root != tt.root || pattern != tt.pattern {
+			t.Errorf("splitGlob(%q) = %q, %q; want %q, %q", tt.src, root, pattern, tt.root, tt.pattern)
+		}
+	}
+}
+
+func TestMatchPath(t *testing.T) {
+	tests := []struct {
+		pattern, name string
+		match         bool
+	}{
+		{"", "a/b.log", true},
+		{"*.log", "b.log", true},
+		{"*.log", "a/b.log", false},
+		{"*.log", "b.txt", false},
+		{"a*", "app/b.txt", true},
+		{"**/*.log", "b.log", true},
+		{"**/*.log", "a/b/c.log", true},
+		{"a/**/c.log", "a/b/d/c.log", true},
+		{"a/**/c.log", "b/c.log", false},
+	}
+	for _, tt := range tests {
+		if match := matchPath(tt.pattern, tt.name); match != tt.match {
+			t.Errorf("matchPath(%q, %q) = %t; want %t", tt.pattern, tt.name, match, tt.match)
+		}
+	}
+}
+
+func TestExcluded(t *testing.T) {
+	excludes := []string{"*.tmp", "cache/", "build/out/**/*.o"}
+	tests := []struct {
+		name     string
+		excluded bool
+	}{
+		{"a.log", false},
+		{"a.tmp", true},
+		{"sub/a.tmp", true},
+		{"cache/a.log", true},
+		{"sub/cache/a.log", true},
+		{"build/out/x/y.o", true},
+		{"build/y.o", false},
+	}
+	for _, tt := range tests {
+		if ex := excluded(excludes, tt.name); ex != tt.excluded {
+			t.Errorf("excluded(%q) = %t; want %t", tt.name, ex, tt.excluded)
+		}
+	}
+}
+
+func TestParseStat(t *testing.T) {
+	files, err := parseStat([]byte("644 1600000000 12 ./app.log\n755 1600000001 0 ./bin/run me.sh\n"))
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if len(files) != 2 {
+		t.Fatalf("bad: %#v", files)
+	}
+	if f := files[1]; f.Path != "bin/run me.sh" || f.Mode != 0755 || f.ModTime.Unix() != 1600000001 || f.Size != 0 {
+		t.Fatalf("bad: %#v", f)
+	}
+
+	if _, err := parseStat([]byte("644 app.log\n")); err == nil {
+		t.Fatal("should have error")
+	}
+}
+
+func TestProvisionDownloadDir(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "packer-file")
+	if err != nil {
+		t.Fatalf("error tempdir: %s", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	var p Provisioner
+	config := map[string]interface{}{
+		"source":              "/var/log/app/",
+		"destination":         tmpDir,
+		"direction":           "download",
+		"exclude":             []string{"*.tmp"},
+		"preserve_attributes": true,
+	}
+	if err := p.Prepare(config); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	ui := &packer.BasicUi{
+		Writer: new(bytes.Buffer),
+		PB:     &packer.NoopProgressTracker{},
+	}
+	comm := &packer.MockCommunicator{
+		StartStdout:  "640 1600000000 5 ./app.log\n644 1600000000 5 ./old/app.log\n644 1600000000 5 ./app.tmp\n",
+		DownloadData: "hello",
+	}
+	if err := p.Provision(context.Background(), ui, comm, nil); err != nil {
+		t.Fatalf("should successfully provision: %s", err)
+	}
+
+	info, err := os.Stat(filepath.Join(tmpDir, "old", "app.log"))
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if info.Mode().Perm() != 0644 || !info.ModTime().Equal(time.Unix(1600000000, 0)) {
+		t.Fatalf("attributes should be preserved: %s %s", info.Mode(), info.ModTime())
+	}
+	if info, err := os.Stat(filepath.Join(tmpDir, "app.log")); err != nil || info.Mode().Perm() != 0640 {
+		t.Fatalf("bad: %v %v", info, err)
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, "app.tmp")); !os.IsNotExist(err) {
+		t.Fatalf("excluded file should not be downloaded: %v", err)
+	}
+
+	// Unchanged files are skipped
+	comm.DownloadCalled = false
+	if err := p.Provision(context.Background(), ui, comm, nil); err != nil {
+		t.Fatalf("should successfully provision: %s", err)
+	}
+	if comm.DownloadCalled {
+		t.Fatal("unchanged files should not be downloaded again")
+	}
+}
+
+func TestProvisionerPrepare_DownloadOptions(t *testing.T) {
+	var p Provisioner
+	config := map[string]interface{}{
+		"source":              "/var/log/",
+		"destination":         "logs",
+		"preserve_attributes": true,
+	}
+	if err := p.Prepare(config); err == nil {
+		t.Fatal("should require the download direction")
+	}
+
+	p = Provisioner{}
+	config["direction"] = "download"
+	config["exclude"] = []string{"[a-"}
+	if err := p.Prepare(config); err == nil {
+		t.Fatal("should have error with a bad exclude pattern")
+	}
+}
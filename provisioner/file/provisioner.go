@@ -7,8 +7,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"io"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
 
@@ -56,6 +56,17 @@ type Config struct {
 	// the Packer run, but realize that there are situations where this may be
 	// unavoidable.
 	Generated bool `mapstructure:"generated" required:"false"`
+	// Patterns of the files not to download when downloading a directory or
+	// a glob pattern, like `*.tmp` or `cache/`. A pattern without a slash
+	// matches the name of any file or directory, other patterns match the
+	// path relative to the downloaded directory, where `**` matches any
+	// number of directories. Only with the "download" direction.
+	Exclude []string `mapstructure:"exclude" required:"false"`
+	// Give the downloaded files the permissions and modification time they
+	// have in the machine, and skip the files of a directory that are
+	// already downloaded with the same size and modification time. Only
+	// with the "download" direction.
+	PreserveAttributes bool `mapstructure:"preserve_attributes" required:"false"`
 
 	ctx interpolate.Context
 }
@@ -89,6 +100,16 @@ func (p *Provisioner) Prepare(raws ...interface{}) error {
 		errs = packer.MultiErrorAppend(errs,
 			errors.New("Direction must be one of: download, upload."))
 	}
+	if p.config.Direction != "download" && (len(p.config.Exclude) > 0 || p.config.PreserveAttributes) {
+		errs = packer.MultiErrorAppend(errs,
+			errors.New("exclude and preserve_attributes can only be used with the download direction."))
+	}
+	for _, pattern := range p.config.Exclude {
+		if _, err := path.Match(pattern, ""); err != nil {
+			errs = packer.MultiErrorAppend(errs,
+				fmt.Errorf("Bad exclude pattern '%s': %s", pattern, err))
+		}
+	}
 	if p.config.Source != "" {
 		p.config.Sources = append(p.config.Sources, p.config.Source)
 	}
@@ -126,13 +147,13 @@ func (p *Provisioner) Provision(ctx context.Context, ui packer.Ui, comm packer.C
 	p.config.ctx.Data = generatedData
 
 	if p.config.Direction == "download" {
-		return p.ProvisionDownload(ui, comm)
+		return p.ProvisionDownload(ctx, ui, comm)
 	} else {
 		return p.ProvisionUpload(ui, comm)
 	}
 }
 
-func (p *Provisioner) ProvisionDownload(ui packer.Ui, comm packer.Communicator) error {
+func (p *Provisioner) ProvisionDownload(ctx context.Context, ui packer.Ui, comm packer.Communicator) error {
 	dst, err := interpolate.Render(p.config.Destination, &p.config.ctx)
 	if err != nil {
 		return fmt.Errorf("Error interpolating destination: %s", err)
@@ -144,36 +165,34 @@ func (p *Provisioner) ProvisionDownload(ui packer.Ui, comm packer.Communicator)
 		}
 
 		ui.Say(fmt.Sprintf("Downloading %s => %s", src, dst))
-		// ensure destination dir exists.  p.config.Destination may either be a file or a dir.
-		dir := dst
-		// if it doesn't end with a /, set dir as the parent dir
-		if !strings.HasSuffix(dst, "/") {
-			dir = filepath.Dir(dir)
-		} else if !strings.HasSuffix(src, "/") && !strings.HasSuffix(src, "*") {
-			dst = filepath.Join(dst, filepath.Base(src))
-		}
-		if dir != "" {
-			err := os.MkdirAll(dir, os.FileMode(0755))
-			if err != nil {
+
+		// if the src is a dir or a glob pattern, download the files under it
+		// to the dst dir
+		if strings.HasSuffix(src, "/") || isGlob(src) {
+			if err := os.MkdirAll(dst, os.FileMode(0755)); err != nil {
 				return err
 			}
-		}
-		// if the src was a dir, download the dir
-		if strings.HasSuffix(src, "/") || strings.ContainsAny(src, "*?[") {
-			return comm.DownloadDir(src, dst, nil)
+			if err := p.downloadDir(ctx, ui, comm, src, dst); err != nil {
+				return err
+			}
+			continue
 		}
 
-		f, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
-		if err != nil {
-			return err
+		filedst := dst
+		if strings.HasSuffix(dst, "/") {
+			filedst = filepath.Join(dst, filepath.Base(src))
 		}
-		defer f.Close()
 
-		// Create MultiWriter for the current progress
-		pf := io.MultiWriter(f)
+		var f *remoteFile
+		if p.config.PreserveAttributes {
+			f, err = statRemoteFile(ctx, comm, src)
+			if err != nil {
+				return fmt.Errorf("Error reading the attributes of %s: %s", src, err)
+			}
+		}
 
-		// Download the file
-		if err = comm.Download(src, pf); err != nil {
+		// Download the file, creating its parent dir
+		if err = p.downloadFile(comm, src, filedst, f); err != nil {
 			ui.Error(fmt.Sprintf("Download failed: %s", err))
 			return err
 		}
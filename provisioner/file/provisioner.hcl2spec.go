@@ -9,19 +9,23 @@ import (
 // FlatConfig is an auto-generated flat version of Config.
 // Where the contents of a field with a `mapstructure:,squash` tag are bubbled up.
 type FlatConfig struct {
-	PackerBuildName     *string           `mapstructure:"packer_build_name" cty:"packer_build_name" hcl:"packer_build_name"`
-	PackerBuilderType   *string           `mapstructure:"packer_builder_type" cty:"packer_builder_type" hcl:"packer_builder_type"`
-	PackerCoreVersion   *string           `mapstructure:"packer_core_version" cty:"packer_core_version" hcl:"packer_core_version"`
-	PackerDebug         *bool             `mapstructure:"packer_debug" cty:"packer_debug" hcl:"packer_debug"`
-	PackerForce         *bool             `mapstructure:"packer_force" cty:"packer_force" hcl:"packer_force"`
-	PackerOnError       *string           `mapstructure:"packer_on_error" cty:"packer_on_error" hcl:"packer_on_error"`
-	PackerUserVars      map[string]string `mapstructure:"packer_user_variables" cty:"packer_user_variables" hcl:"packer_user_variables"`
-	PackerSensitiveVars []string          `mapstructure:"packer_sensitive_variables" cty:"packer_sensitive_variables" hcl:"packer_sensitive_variables"`
-	Source              *string           `mapstructure:"source" required:"true" cty:"source" hcl:"source"`
-	Sources             []string          `mapstructure:"sources" required:"false" cty:"sources" hcl:"sources"`
-	Destination         *string           `mapstructure:"destination" required:"true" cty:"destination" hcl:"destination"`
-	Direction           *string           `mapstructure:"direction" required:"false" cty:"direction" hcl:"direction"`
-	Generated           *bool             `mapstructure:"generated" required:"false" cty:"generated" hcl:"generated"`
+	PackerBuildName      *string           `mapstructure:"packer_build_name" cty:"packer_build_name" hcl:"packer_build_name"`
+	PackerBuilderType    *string           `mapstructure:"packer_builder_type" cty:"packer_builder_type" hcl:"packer_builder_type"`
+	PackerCoreVersion    *string           `mapstructure:"packer_core_version" cty:"packer_core_version" hcl:"packer_core_version"`
+	PackerDebug          *bool             `mapstructure:"packer_debug" cty:"packer_debug" hcl:"packer_debug"`
+	PackerForce          *bool             `mapstructure:"packer_force" cty:"packer_force" hcl:"packer_force"`
+	PackerOnError        *string           `mapstructure:"packer_on_error" cty:"packer_on_error" hcl:"packer_on_error"`
+	PackerResume         *bool             `mapstructure:"packer_resume" cty:"packer_resume" hcl:"packer_resume"`
+	PackerCleanupTimeout *string           `mapstructure:"packer_cleanup_timeout" cty:"packer_cleanup_timeout" hcl:"packer_cleanup_timeout"`
+	PackerUserVars       map[string]string `mapstructure:"packer_user_variables" cty:"packer_user_variables" hcl:"packer_user_variables"`
+	PackerSensitiveVars  []string          `mapstructure:"packer_sensitive_variables" cty:"packer_sensitive_variables" hcl:"packer_sensitive_variables"`
+	Source               *string           `mapstructure:"source" required:"true" cty:"source" hcl:"source"`
+	Sources              []string          `mapstructure:"sources" required:"false" cty:"sources" hcl:"sources"`
+	Destination          *string           `mapstructure:"destination" required:"true" cty:"destination" hcl:"destination"`
+	Direction            *string           `mapstructure:"direction" required:"false" cty:"direction" hcl:"direction"`
+	Generated            *bool             `mapstructure:"generated" required:"false" cty:"generated" hcl:"generated"`
+	Exclude              []string          `mapstructure:"exclude" required:"false" cty:"exclude" hcl:"exclude"`
+	PreserveAttributes   *bool             `mapstructure:"preserve_attributes" required:"false" cty:"preserve_attributes" hcl:"preserve_attributes"`
 }
 
 // FlatMapstructure returns a new FlatConfig.
@@ -42,6 +46,8 @@ func (*FlatConfig) HCL2Spec() map[string]hcldec.Spec {
 		"packer_debug":               &hcldec.AttrSpec{Name: "packer_debug", Type: cty.Bool, Required: false},
 		"packer_force":               &hcldec.AttrSpec{Name: "packer_force", Type: cty.Bool, Required: false},
 		"packer_on_error":            &hcldec.AttrSpec{Name: "packer_on_error", Type: cty.String, Required: false},
+		"packer_resume":              &hcldec.AttrSpec{Name: "packer_resume", Type: cty.Bool, Required: false},
+		"packer_cleanup_timeout":     &hcldec.AttrSpec{Name: "packer_cleanup_timeout", Type: cty.String, Required: false},
 		"packer_user_variables":      &hcldec.AttrSpec{Name: "packer_user_variables", Type: cty.Map(cty.String), Required: false},
 		"packer_sensitive_variables": &hcldec.AttrSpec{Name: "packer_sensitive_variables", Type: cty.List(cty.String), Required: false},
 		"source":                     &hcldec.AttrSpec{Name: "source", Type: cty.String, Required: false},
@@ -49,6 +55,8 @@ func (*FlatConfig) HCL2Spec() map[string]hcldec.Spec {
 		"destination":                &hcldec.AttrSpec{Name: "destination", Type: cty.String, Required: false},
 		"direction":                  &hcldec.AttrSpec{Name: "direction", Type: cty.String, Required: false},
 		"generated":                  &hcldec.AttrSpec{Name: "generated", Type: cty.Bool, Required: false},
+		"exclude":                    &hcldec.AttrSpec{Name: "exclude", Type: cty.List(cty.String), Required: false},
+		"preserve_attributes":        &hcldec.AttrSpec{Name: "preserve_attributes", Type: cty.Bool, Required: false},
 	}
 	return s
 }
@@ -368,7 +368,7 @@ func TestProvisionDownloadMkdirAll(t *testing.T) {
 			PB:     &packer.NoopProgressTracker{},
 		}
 		comm := &packer.MockCommunicator{}
-		err = p.ProvisionDownload(ui, comm)
+		err = p.ProvisionDownload(context.Background(), ui, comm)
 		if err != nil {
 			t.Fatalf("should successfully provision: %s", err)
 		}
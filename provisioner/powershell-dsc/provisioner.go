@@ -0,0 +1,296 @@
+//go:generate mapstructure-to-hcl2 -type Config
+
+// This package implements a provisioner for Packer that applies a
+// PowerShell Desired State Configuration to a Windows machine.
+package dsc
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/hcl/v2/hcldec"
+	"github.com/hashicorp/packer/packer"
+	"github.com/hashicorp/packer/packer-plugin-sdk/common"
+	"github.com/hashicorp/packer/packer-plugin-sdk/guestexec"
+	"github.com/hashicorp/packer/packer-plugin-sdk/template/config"
+	"github.com/hashicorp/packer/packer-plugin-sdk/template/interpolate"
+	"github.com/hashicorp/packer/packer-plugin-sdk/uuid"
+	"github.com/masterzen/winrm"
+)
+
+// ModulesDir is where the DSC resource modules of module_paths are uploaded.
+const ModulesDir = "C:/Program Files/WindowsPowerShell/Modules"
+
+// exitRestartRequired is the exit code of the apply script when the
+// configuration requested a restart of the machine before it can converge.
+const exitRestartRequired = 101
+
+// maxRestarts bounds the restarts a configuration can request, so that a
+// configuration that never converges doesn't restart the machine forever.
+const maxRestarts = 10
+
+var reportRegexp = regexp.MustCompile(`^(Resource not in desired state|DSC error): .*`)
+
+type Config struct {
+	common.PackerConfig `mapstructure:",squash"`
+
+	// The path to a configuration script, compiled to a MOF document on the
+	// machine. Either this or mof_path must be specified.
+	ConfigurationFile string `mapstructure:"configuration_file"`
+
+	// The name of the configuration of configuration_file to compile.
+	// Defaults to the name of configuration_file without its extension.
+	ConfigurationName string `mapstructure:"configuration_name"`
+
+	// The path to a .psd1 file of configuration data, passed to the
+	// configuration as -ConfigurationData.
+	ConfigurationData string `mapstructure:"configuration_data"`
+
+	// The parameters of the configuration, by name.
+	ConfigurationParams map[string]string `mapstructure:"configuration_params"`
+
+	// The path to a compiled MOF document, or to a directory of them.
+	MOFPath string `mapstructure:"mof_path"`
+
+	// Local directories of DSC resource modules to upload to the PowerShell
+	// modules directory of the machine before applying the configuration.
+	ModulePaths []string `mapstructure:"module_paths"`
+
+	// How long to wait for the machine to restart when the configuration
+	// requests it. Defaults to 30m.
+	RestartTimeout time.Duration `mapstructure:"restart_timeout"`
+
+	ctx interpolate.Context
+}
+
+type Provisioner struct {
+	config Config
+}
+
+func (p *Provisioner) ConfigSpec() hcldec.ObjectSpec { return p.config.FlatMapstructure().HCL2Spec() }
+
+func (p *Provisioner) Prepare(raws ...interface{}) error {
+	err := config.Decode(&p.config, &config.DecodeOpts{
+		PluginType:         "powershell-dsc",
+		Interpolate:        true,
+		InterpolateContext: &p.config.ctx,
+	}, raws...)
+	if err != nil {
+		return err
+	}
+
+	if p.config.RestartTimeout == 0 {
+		p.config.RestartTimeout = 30 * time.Minute
+	}
+
+	if p.config.ConfigurationName == "" && p.config.ConfigurationFile != "" {
+		base := filepath.Base(p.config.ConfigurationFile)
+		p.config.ConfigurationName = strings.TrimSuffix(base, filepath.Ext(base))
+	}
+
+	var errs *packer.MultiError
+	if (p.config.ConfigurationFile == "") == (p.config.MOFPath == "") {
+		errs = packer.MultiErrorAppend(errs,
+			fmt.Errorf("Exactly one of configuration_file or mof_path must be specified."))
+	}
+	if p.config.MOFPath != "" && (p.config.ConfigurationData != "" || len(p.config.ConfigurationParams) > 0) {
+		errs = packer.MultiErrorAppend(errs,
+			fmt.Errorf("configuration_data and configuration_params can only be used with configuration_file."))
+	}
+	for _, path := range []string{p.config.ConfigurationFile, p.config.ConfigurationData, p.config.MOFPath} {
+		if path == "" {
+			continue
+		}
+		if _, err := os.Stat(path); err != nil {
+			errs = packer.MultiErrorAppend(errs, fmt.Errorf("Bad path '%s': %s", path, err))
+		}
+	}
+	for _, path := range p.config.ModulePaths {
+		if info, err := os.Stat(path); err != nil {
+			errs = packer.MultiErrorAppend(errs, fmt.Errorf("Bad module path '%s': %s", path, err))
+		} else if !info.IsDir() {
+			errs = packer.MultiErrorAppend(errs, fmt.Errorf("Module path '%s' must be a directory", path))
+		}
+	}
+	if errs != nil && len(errs.Errors) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+func (p *Provisioner) Provision(ctx context.Context, ui packer.Ui, comm packer.Communicator, _ map[string]interface{}) error {
+	workDir := fmt.Sprintf("C:/Windows/Temp/packer-dsc-%s", uuid.TimeOrderedUUID())
+	defer p.cleanup(ctx, comm, workDir)
+
+	opts, err := p.upload(ctx, ui, comm, workDir)
+	if err != nil {
+		return err
+	}
+
+	for restarts := 0; ; restarts++ {
+		status, report, err := p.apply(ctx, ui, comm, workDir, opts)
+		if err != nil {
+			return err
+		}
+
+		switch status {
+		case 0:
+			ui.Say("The DSC configuration converged.")
+			return nil
+		case exitRestartRequired, packer.CmdDisconnect:
+			if restarts == maxRestarts {
+				return fmt.Errorf("The DSC configuration didn't converge after %d restarts", maxRestarts)
+			}
+			if status == packer.CmdDisconnect {
+				ui.Say("The machine disconnected, waiting for it to come back...")
+				if err := packer.WaitForReconnect(ctx, comm); err != nil {
+					return err
+				}
+			} else {
+				ui.Say("Restarting the machine as requested by the DSC configuration...")
+				if err := guestexec.RestartWindows(ctx, comm, p.config.RestartTimeout); err != nil {
+					return err
+				}
+			}
+			opts.Resume = true
+		default:
+			if len(report) == 0 {
+				return fmt.Errorf("The DSC configuration failed to converge: exit status %d", status)
+			}
+			return fmt.Errorf("The DSC configuration failed to converge:\n%s", strings.Join(report, "\n"))
+		}
+	}
+}
+
+// upload uploads the resource modules and the configuration to apply, and
+// returns the options of the apply script.
+func (p *Provisioner) upload(ctx context.Context, ui packer.Ui, comm packer.Communicator, workDir string) (scriptOptions, error) {
+	opts := scriptOptions{
+		WorkDir: psQuote(workDir),
+		Params:  make(map[string]string),
+	}
+
+	mkdir := winrm.Powershell(fmt.Sprintf("New-Item -ItemType Directory -Force -Path %s | Out-Null",
+		psQuote(workDir+"/mof")))
+	cmd := &packer.RemoteCmd{Command: mkdir}
+	if err := cmd.RunWithUi(ctx, comm, ui); err != nil {
+		return opts, err
+	}
+	if status := cmd.ExitStatus(); status != 0 {
+		return opts, fmt.Errorf("Error creating %s: exit status %d", workDir, status)
+	}
+
+	for _, path := range p.config.ModulePaths {
+		ui.Say(fmt.Sprintf("Uploading DSC resource module %s...", path))
+		if err := comm.UploadDir(ModulesDir, filepath.Clean(path), nil); err != nil {
+			return opts, fmt.Errorf("Error uploading DSC resource module %s: %s", path, err)
+		}
+	}
+
+	if p.config.MOFPath != "" {
+		ui.Say(fmt.Sprintf("Uploading MOF documents from %s...", p.config.MOFPath))
+		info, err := os.Stat(p.config.MOFPath)
+		if err != nil {
+			return opts, err
+		}
+		if info.IsDir() {
+			// The trailing separator uploads the content of the directory
+			err = comm.UploadDir(workDir+"/mof", filepath.Clean(p.config.MOFPath)+string(filepath.Separator), nil)
+		} else {
+			// Start-DscConfiguration applies the MOF document named after the
+			// node, which is localhost from the machine itself.
+			err = uploadFile(comm, workDir+"/mof/localhost.mof", p.config.MOFPath)
+		}
+		if err != nil {
+			return opts, fmt.Errorf("Error uploading MOF documents: %s", err)
+		}
+		return opts, nil
+	}
+
+	remote := workDir + "/" + filepath.Base(p.config.ConfigurationFile)
+	if err := uploadFile(comm, remote, p.config.ConfigurationFile); err != nil {
+		return opts, fmt.Errorf("Error uploading the DSC configuration: %s", err)
+	}
+	opts.ConfigurationFile = psQuote(remote)
+	opts.ConfigurationName = psQuote(p.config.ConfigurationName)
+
+	if p.config.ConfigurationData != "" {
+		remote := workDir + "/" + filepath.Base(p.config.ConfigurationData)
+		if err := uploadFile(comm, remote, p.config.ConfigurationData); err != nil {
+			return opts, fmt.Errorf("Error uploading the DSC configuration data: %s", err)
+		}
+		opts.ConfigurationData = psQuote(remote)
+	}
+	for k, v := range p.config.ConfigurationParams {
+		opts.Params[psQuote(k)] = psQuote(v)
+	}
+	return opts, nil
+}
+
+func uploadFile(comm packer.Communicator, dst string, src string) error {
+	f, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	return comm.Upload(dst, f, &fi)
+}
+
+// apply runs the apply script once, streaming the progress of the Local
+// Configuration Manager, and returns its exit status and the resource-level
+// report of the failures.
+func (p *Provisioner) apply(ctx context.Context, ui packer.Ui, comm packer.Communicator, workDir string, opts scriptOptions) (int, []string, error) {
+	var script bytes.Buffer
+	if err := applyScript.Execute(&script, opts); err != nil {
+		return 0, nil, fmt.Errorf("Error generating the DSC apply script: %s", err)
+	}
+
+	path := workDir + "/apply.ps1"
+	if err := comm.Upload(path, &script, nil); err != nil {
+		return 0, nil, fmt.Errorf("Error uploading the DSC apply script: %s", err)
+	}
+
+	var stdout bytes.Buffer
+	cmd := &packer.RemoteCmd{
+		Command: fmt.Sprintf(`PowerShell -ExecutionPolicy Bypass -OutputFormat Text -File %s`, path),
+		Stdout:  &stdout,
+	}
+	if err := cmd.RunWithUi(ctx, comm, ui); err != nil {
+		return 0, nil, err
+	}
+	return cmd.ExitStatus(), parseReport(stdout.String()), nil
+}
+
+// parseReport returns the lines of the failure report of the apply script.
+func parseReport(output string) []string {
+	var report []string
+	for _, line := range strings.Split(output, "\n") {
+		if line = strings.TrimSpace(line); reportRegexp.MatchString(line) {
+			report = append(report, line)
+		}
+	}
+	return report
+}
+
+func (p *Provisioner) cleanup(ctx context.Context, comm packer.Communicator, workDir string) {
+	cmd := &packer.RemoteCmd{Command: winrm.Powershell(fmt.Sprintf(
+		"Remove-Item -Recurse -Force -Path %s -ErrorAction SilentlyContinue", psQuote(workDir)))}
+	if err := comm.Start(ctx, cmd); err != nil {
+		log.Printf("[WARN] Error removing %s: %s", workDir, err)
+		return
+	}
+	cmd.Wait()
+}
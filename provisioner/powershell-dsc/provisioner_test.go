@@ -0,0 +1,196 @@
+package dsc
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/packer/packer"
+)
+
+func testConfigFile(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "packer-dsc")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	path := filepath.Join(dir, "WebServer.ps1")
+	if err := ioutil.WriteFile(path, []byte("Configuration WebServer {}\n"), 0644); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	return path
+}
+
+func TestProvisioner_Impl(t *testing.T) {
+	var raw interface{}
+	raw = &Provisioner{}
+	if _, ok := raw.(packer.Provisioner); !ok {
+		t.Fatalf("must be a Provisioner")
+	}
+}
+
+func TestProvisionerPrepare_Defaults(t *testing.T) {
+	path := testConfigFile(t)
+	defer os.RemoveAll(filepath.Dir(path))
+
+	var p Provisioner
+	if err := p.Prepare(map[string]interface{}{"configuration_file": path}); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if p.config.ConfigurationName != "WebServer" {
+		t.Errorf("unexpected configuration name: %s", p.config.ConfigurationName)
+	}
+	if p.config.RestartTimeout.Minutes() != 30 {
+		t.Errorf("unexpected restart timeout: %s", p.config.RestartTimeout)
+	}
+}
+
+func TestProvisionerPrepare_Sources(t *testing.T) {
+	path := testConfigFile(t)
+	defer os.RemoveAll(filepath.Dir(path))
+
+	var p Provisioner
+	if err := p.Prepare(map[string]interface{}{}); err == nil {
+		t.Fatal("should have error without a configuration")
+	}
+
+	p = Provisioner{}
+	config := map[string]interface{}{
+		"configuration_file": path,
+		"mof_path":           path,
+	}
+	if err := p.Prepare(config); err == nil {
+		t.Fatal("should have error with both a configuration and a MOF")
+	}
+
+	p = Provisioner{}
+	config = map[string]interface{}{
+		"mof_path":             path,
+		"configuration_params": map[string]string{"Port": "80"},
+	}
+	if err := p.Prepare(config); err == nil {
+		t.Fatal("should have error with parameters for a MOF")
+	}
+
+	p = Provisioner{}
+	config = map[string]interface{}{
+		"mof_path":     path,
+		"module_paths": []string{path},
+	}
+	if err := p.Prepare(config); err == nil {
+		t.Fatal("should have error with a module that isn't a directory")
+	}
+}
+
+func TestApplyScript(t *testing.T) {
+	opts := scriptOptions{
+		WorkDir:           psQuote("C:/Windows/Temp/packer-dsc"),
+		ConfigurationFile: psQuote("C:/Windows/Temp/packer-dsc/WebServer.ps1"),
+		ConfigurationName: psQuote("WebServer"),
+		ConfigurationData: psQuote("C:/Windows/Temp/packer-dsc/data.psd1"),
+		Params:            map[string]string{psQuote("Port"): psQuote("80")},
+	}
+
+	var script bytes.Buffer
+	if err := applyScript.Execute(&script, opts); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	for _, expected := range []string{
+		"\n. 'C:/Windows/Temp/packer-dsc/WebServer.ps1'\n",
+		"\n$params.ConfigurationData = 'C:/Windows/Temp/packer-dsc/data.psd1'\n",
+		"\n$params['Port'] = '80'\n",
+		"\n& 'WebServer' @params | Out-Null\n",
+		"\nStart-DscConfiguration -Path $mofDir ",
+	} {
+		if !strings.Contains(script.String(), expected) {
+			t.Errorf("the script should contain %q:\n%s", expected, script.String())
+		}
+	}
+
+	opts.Resume = true
+	script.Reset()
+	if err := applyScript.Execute(&script, opts); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if !strings.Contains(script.String(), "Start-DscConfiguration -UseExisting") ||
+		strings.Contains(script.String(), "@params") {
+		t.Fatalf("the script should resume the configuration:\n%s", script.String())
+	}
+}
+
+func TestParseReport(t *testing.T) {
+	output := "Applying the DSC configuration...\r\n" +
+		"VERBOSE: [WIN]: LCM:  [ Start  Set      ]  [[WindowsFeature]IIS]\r\n" +
+		"Resource not in desired state: [WindowsFeature]IIS: The feature is unavailable\r\n" +
+		"DSC error: PowerShell DSC resource MSFT_RoleResource failed to execute Set-TargetResource\r\n"
+
+	report := parseReport(output)
+	expected := []string{
+		"Resource not in desired state: [WindowsFeature]IIS: The feature is unavailable",
+		"DSC error: PowerShell DSC resource MSFT_RoleResource failed to execute Set-TargetResource",
+	}
+	if !reflect.DeepEqual(report, expected) {
+		t.Fatalf("bad: %#v", report)
+	}
+}
+
+// applyFailComm fails the apply script, and only it.
+type applyFailComm struct {
+	packer.MockCommunicator
+}
+
+func (c *applyFailComm) Start(ctx context.Context, rc *packer.RemoteCmd) error {
+	c.StartExitStatus = 0
+	if strings.Contains(rc.Command, "apply.ps1") {
+		c.StartExitStatus = 1
+	}
+	return c.MockCommunicator.Start(ctx, rc)
+}
+
+func TestProvisionerProvision(t *testing.T) {
+	path := testConfigFile(t)
+	defer os.RemoveAll(filepath.Dir(path))
+
+	var p Provisioner
+	if err := p.Prepare(map[string]interface{}{"mof_path": path}); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	comm := new(packer.MockCommunicator)
+	if err := p.Provision(context.Background(), testUi(), comm, nil); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if !strings.Contains(comm.UploadData, "Start-DscConfiguration -Path $mofDir") {
+		t.Fatalf("the apply script should be uploaded last: %s", comm.UploadData)
+	}
+}
+
+func TestProvisionerProvision_failure(t *testing.T) {
+	path := testConfigFile(t)
+	defer os.RemoveAll(filepath.Dir(path))
+
+	var p Provisioner
+	if err := p.Prepare(map[string]interface{}{"configuration_file": path}); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	comm := &applyFailComm{packer.MockCommunicator{
+		StartStdout: "Resource not in desired state: [File]Config: Access denied\n",
+	}}
+	err := p.Provision(context.Background(), testUi(), comm, nil)
+	if err == nil || !strings.Contains(err.Error(), "[File]Config: Access denied") {
+		t.Fatalf("the error should report the failed resources: %v", err)
+	}
+}
+
+func testUi() *packer.BasicUi {
+	return &packer.BasicUi{
+		Reader:      new(bytes.Buffer),
+		Writer:      new(bytes.Buffer),
+		ErrorWriter: new(bytes.Buffer),
+	}
+}
@@ -0,0 +1,76 @@
+package dsc
+
+import (
+	"strings"
+	"text/template"
+)
+
+// scriptOptions are the options of the apply script, quoted as PowerShell
+// strings.
+type scriptOptions struct {
+	WorkDir           string
+	ConfigurationFile string
+	ConfigurationName string
+	ConfigurationData string
+	Params            map[string]string
+
+	// Resume applies the configuration the Local Configuration Manager
+	// already has, after the machine restarted.
+	Resume bool
+}
+
+// psQuote quotes s as a single quoted PowerShell string.
+func psQuote(s string) string {
+	return "'" + strings.Replace(s, "'", "''", -1) + "'"
+}
+
+// applyScript compiles the configuration if needed, applies it with the
+// Local Configuration Manager and reports the resources that didn't
+// converge. It exits with exitRestartRequired when the configuration needs
+// the machine to restart, and with 1 when it failed to converge.
+var applyScript = template.Must(template.New("ApplyDSC").Parse(`
+$ErrorActionPreference = 'Stop'
+$ProgressPreference = 'SilentlyContinue'
+
+$workDir = {{.WorkDir}}
+$mofDir = Join-Path $workDir 'mof'
+Set-Location $workDir
+
+{{if .Resume -}}
+Write-Output 'Resuming the DSC configuration...'
+Start-DscConfiguration -UseExisting -Wait -Verbose -Force -ErrorAction Continue -ErrorVariable dscErrors 4>&1 |
+    ForEach-Object { Write-Output "$_" }
+{{- else -}}
+{{if .ConfigurationFile -}}
+. {{.ConfigurationFile}}
+$params = @{ OutputPath = $mofDir }
+{{if .ConfigurationData}}$params.ConfigurationData = {{.ConfigurationData}}
+{{end}}
+{{- range $k, $v := .Params}}$params[{{$k}}] = {{$v}}
+{{end -}}
+Write-Output ('Compiling the DSC configuration ' + {{.ConfigurationName}} + '...')
+& {{.ConfigurationName}} @params | Out-Null
+{{end -}}
+Write-Output 'Applying the DSC configuration...'
+Start-DscConfiguration -Path $mofDir -Wait -Verbose -Force -ErrorAction Continue -ErrorVariable dscErrors 4>&1 |
+    ForEach-Object { Write-Output "$_" }
+{{- end}}
+
+$status = Get-DscConfigurationStatus
+if ($status.RebootRequested) {
+    Write-Output 'The DSC configuration requested a restart.'
+    exit 101
+}
+
+$failed = @($status.ResourcesNotInDesiredState)
+if ($status.Status -ne 'Success' -or $failed.Count -gt 0 -or $dscErrors.Count -gt 0) {
+    foreach ($resource in $failed) {
+        Write-Output "Resource not in desired state: $($resource.ResourceId): $($resource.Error)"
+    }
+    foreach ($e in $dscErrors) {
+        Write-Output "DSC error: $e"
+    }
+    exit 1
+}
+exit 0
+`))
@@ -277,6 +277,12 @@ func (p *Provisioner) Provision(ctx context.Context, ui packer.Ui, comm packer.C
 
 	// every provisioner run will only have one env var script file so lets add it first
 	uploadedScripts := []string{p.config.RemoteEnvVarPath}
+	outputUi, err := p.config.OutputUi(ui)
+	if err != nil {
+		return err
+	}
+	defer outputUi.Close()
+
 	for _, path := range scripts {
 		ui.Say(fmt.Sprintf("Provisioning with powershell script: %s", path))
 
@@ -314,7 +320,7 @@ func (p *Provisioner) Provision(ctx context.Context, ui packer.Ui, comm packer.C
 			}
 
 			cmd = &packer.RemoteCmd{Command: command}
-			return cmd.RunWithUi(ctx, comm, ui)
+			return cmd.RunWithUi(ctx, comm, outputUi)
 		})
 		if err != nil {
 			return err
@@ -336,7 +342,7 @@ func (p *Provisioner) Provision(ctx context.Context, ui packer.Ui, comm packer.C
 		return nil
 	}
 
-	err := retry.Config{StartTimeout: time.Minute, RetryDelay: func() time.Duration { return 10 * time.Second }}.Run(ctx, func(ctx context.Context) error {
+	err = retry.Config{StartTimeout: time.Minute, RetryDelay: func() time.Duration { return 10 * time.Second }}.Run(ctx, func(ctx context.Context) error {
 		command, err := p.createRemoteCleanUpCommand(uploadedScripts)
 		if err != nil {
 			log.Printf("failed to upload the remote cleanup script: %q", err)
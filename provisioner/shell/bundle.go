@@ -0,0 +1,284 @@
+package shell
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/packer/packer"
+	"github.com/hashicorp/packer/packer-plugin-sdk/retry"
+	"github.com/hashicorp/packer/packer-plugin-sdk/template/interpolate"
+)
+
+// bundleManifestName is the name of the manifest of a script bundle, in the
+// remote bundle directory. It uses the format of sha256sum so that the
+// remote machine can check the files without any packer tooling.
+const bundleManifestName = ".packer-manifest.sha256"
+
+// A bundleFile is a regular file of a script bundle.
+type bundleFile struct {
+	// Path is the slash separated path of the file, relative to the bundle.
+	Path string
+	// Local is the path of the file on this machine.
+	Local    string
+	Checksum string
+	Info     os.FileInfo
+}
+
+// readBundle lists the regular files of the bundle in dir, sorted by path,
+// with their sha256 checksum.
+func readBundle(dir string) ([]bundleFile, error) {
+	var files []bundleFile
+	err := filepath.Walk(dir, func(local string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.Mode()&os.ModeSymlink != 0 {
+			if info, err = os.Stat(local); err != nil {
+				return err
+			}
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, local)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if rel == bundleManifestName {
+			return nil
+		}
+		if strings.ContainsAny(rel, "\\\n") {
+			return fmt.Errorf("unsupported file name in script bundle: %q", rel)
+		}
+
+		checksum, err := fileChecksum(local)
+		if err != nil {
+			return err
+		}
+		files = append(files, bundleFile{
+			Path:     rel,
+			Local:    local,
+			Checksum: checksum,
+			Info:     info,
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+	return files, nil
+}
+
+func fileChecksum(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// bundleManifest returns the sha256sum manifest of files.
+func bundleManifest(files []bundleFile) []byte {
+	var buf bytes.Buffer
+	for _, f := range files {
+		fmt.Fprintf(&buf, "%s  %s\n", f.Checksum, f.Path)
+	}
+	return buf.Bytes()
+}
+
+// parseBundleCheck returns the files a checksum verification of the bundle
+// reported as unchanged. Anything else, like a missing file, is reported as
+// FAILED.
+func parseBundleCheck(output string) map[string]bool {
+	ok := make(map[string]bool)
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if strings.HasSuffix(line, ": OK") {
+			ok[strings.TrimSuffix(line, ": OK")] = true
+		}
+	}
+	return ok
+}
+
+// shellQuote quotes s for a POSIX shell.
+func shellQuote(s string) string {
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
+}
+
+// provisionBundle uploads bundle_dir and runs its entrypoint with
+// execute_command, from the remote bundle directory so that the entrypoint
+// can find the other scripts of the bundle with relative paths.
+func (p *Provisioner) provisionBundle(ctx context.Context, ui packer.Ui, comm packer.Communicator, outputUi packer.Ui, flattenedEnvVars string) error {
+	ui.Say(fmt.Sprintf("Provisioning with script bundle: %s", p.config.BundleDir))
+
+	files, err := readBundle(p.config.BundleDir)
+	if err != nil {
+		return fmt.Errorf("Error reading script bundle: %s", err)
+	}
+
+	p.generatedData["Vars"] = flattenedEnvVars
+	p.generatedData["EnvVarFile"] = p.config.envVarFile
+	p.generatedData["Path"] = path.Join(p.config.RemoteBundleDir, filepath.ToSlash(p.config.BundleEntrypoint))
+	p.config.ctx.Data = p.generatedData
+
+	command, err := interpolate.Render(p.config.ExecuteCommand, &p.config.ctx)
+	if err != nil {
+		return fmt.Errorf("Error processing command: %s", err)
+	}
+	command = fmt.Sprintf("cd %s || exit 1; %s", shellQuote(p.config.RemoteBundleDir), command)
+
+	if p.config.ExpectDisconnect {
+		if err := packer.ExpectDisconnect(comm); err != nil {
+			return err
+		}
+	}
+
+	// As with single scripts, upload and run in the same retryable function
+	// so that the bundle is still there if the machine restarted meanwhile.
+	var cmd *packer.RemoteCmd
+	err = retry.Config{StartTimeout: p.config.StartRetryTimeout}.Run(ctx, func(ctx context.Context) error {
+		if err := p.syncBundle(ctx, ui, comm, files); err != nil {
+			return err
+		}
+		cmd = &packer.RemoteCmd{Command: command}
+		return cmd.RunWithUi(ctx, comm, outputUi)
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := p.checkExitStatus(ctx, ui, comm, cmd); err != nil {
+		return err
+	}
+
+	if p.config.SkipClean {
+		return nil
+	}
+	return p.cleanupRemote(fmt.Sprintf("rm -rf %s", shellQuote(p.config.RemoteBundleDir)),
+		p.config.RemoteBundleDir, comm)
+}
+
+// syncBundle uploads the files of the bundle that are missing or changed on
+// the remote machine, and then verifies all of them against the manifest.
+// It is safe to retry: unchanged files are never uploaded twice.
+func (p *Provisioner) syncBundle(ctx context.Context, ui packer.Ui, comm packer.Communicator, files []bundleFile) error {
+	remoteDir := p.config.RemoteBundleDir
+	if err := p.runBundleCommand(ctx, comm, fmt.Sprintf("mkdir -p %s", shellQuote(remoteDir))); err != nil {
+		return err
+	}
+
+	manifest := bundleManifest(files)
+	if err := comm.Upload(path.Join(remoteDir, bundleManifestName), bytes.NewReader(manifest), nil); err != nil {
+		return fmt.Errorf("Error uploading script bundle manifest: %s", err)
+	}
+
+	unchanged, err := p.checkBundle(ctx, comm)
+	if err != nil {
+		return err
+	}
+
+	var changed []bundleFile
+	dirs := make(map[string]bool)
+	for _, f := range files {
+		if unchanged[f.Path] {
+			continue
+		}
+		changed = append(changed, f)
+		if dir := path.Dir(f.Path); dir != "." {
+			dirs[shellQuote(path.Join(remoteDir, dir))] = true
+		}
+	}
+	ui.Say(fmt.Sprintf("Uploading script bundle: %d of %d files changed", len(changed), len(files)))
+
+	if len(dirs) > 0 {
+		var quoted []string
+		for dir := range dirs {
+			quoted = append(quoted, dir)
+		}
+		sort.Strings(quoted)
+		if err := p.runBundleCommand(ctx, comm, "mkdir -p "+strings.Join(quoted, " ")); err != nil {
+			return err
+		}
+	}
+
+	for _, f := range changed {
+		if err := uploadBundleFile(comm, path.Join(remoteDir, f.Path), f); err != nil {
+			return fmt.Errorf("Error uploading %s: %s", f.Local, err)
+		}
+	}
+
+	unchanged, err = p.checkBundle(ctx, comm)
+	if err != nil {
+		return err
+	}
+	var mismatched []string
+	for _, f := range files {
+		if !unchanged[f.Path] {
+			mismatched = append(mismatched, f.Path)
+		}
+	}
+	if len(mismatched) > 0 {
+		return fmt.Errorf("Checksum mismatch in script bundle for: %s", strings.Join(mismatched, ", "))
+	}
+	return nil
+}
+
+func uploadBundleFile(comm packer.Communicator, dst string, f bundleFile) error {
+	r, err := os.Open(f.Local)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	log.Printf("[INFO] Uploading script bundle file %s to %s", f.Local, dst)
+	return comm.Upload(dst, r, &f.Info)
+}
+
+// checkBundle verifies the remote bundle against its manifest, with
+// sha256sum or, on systems without it like BSDs and macOS, shasum.
+func (p *Provisioner) checkBundle(ctx context.Context, comm packer.Communicator) (map[string]bool, error) {
+	command := fmt.Sprintf("cd %s && if command -v sha256sum >/dev/null 2>&1; "+
+		"then sha256sum -c %s; else shasum -a 256 -c %s; fi",
+		shellQuote(p.config.RemoteBundleDir), bundleManifestName, bundleManifestName)
+
+	var stdout bytes.Buffer
+	cmd := &packer.RemoteCmd{Command: command, Stdout: &stdout}
+	if err := comm.Start(ctx, cmd); err != nil {
+		return nil, fmt.Errorf("Error checking script bundle: %s", err)
+	}
+	// A failed check only means that some files have to be uploaded
+	if cmd.Wait() == packer.CmdDisconnect {
+		return nil, fmt.Errorf("Disconnect while checking script bundle.")
+	}
+	return parseBundleCheck(stdout.String()), nil
+}
+
+func (p *Provisioner) runBundleCommand(ctx context.Context, comm packer.Communicator, command string) error {
+	cmd := &packer.RemoteCmd{Command: command}
+	if err := comm.Start(ctx, cmd); err != nil {
+		return fmt.Errorf("Error running %q: %s", command, err)
+	}
+	if status := cmd.Wait(); status != 0 {
+		return fmt.Errorf("Error running %q: exit status %d", command, status)
+	}
+	return nil
+}
@@ -0,0 +1,158 @@
+package shell
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/packer/packer"
+)
+
+func testBundle(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "packer-bundle")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	for name, content := range map[string]string{
+		"main.sh":       "#!/bin/sh\n. ./lib/common.sh\n",
+		"lib/common.sh": "echo common\n",
+	} {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("err: %s", err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("err: %s", err)
+		}
+	}
+	return dir
+}
+
+func TestProvisionerPrepare_Bundle(t *testing.T) {
+	dir := testBundle(t)
+	defer os.RemoveAll(dir)
+
+	var p Provisioner
+	config := map[string]interface{}{"bundle_dir": dir}
+	if err := p.Prepare(config); err == nil {
+		t.Fatal("should have error without an entrypoint")
+	}
+
+	config["bundle_entrypoint"] = "missing.sh"
+	if err := p.Prepare(config); err == nil {
+		t.Fatal("should have error with a missing entrypoint")
+	}
+
+	config["bundle_entrypoint"] = "main.sh"
+	config["inline"] = []interface{}{"foo"}
+	if err := p.Prepare(config); err == nil {
+		t.Fatal("should have error with an inline script")
+	}
+
+	delete(config, "inline")
+	p = Provisioner{}
+	if err := p.Prepare(config); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	expected := "/tmp/packer-bundle-" + filepath.Base(dir)
+	if p.config.RemoteBundleDir != expected {
+		t.Fatalf("bad remote bundle dir: %s", p.config.RemoteBundleDir)
+	}
+}
+
+func TestBundleManifest(t *testing.T) {
+	dir := testBundle(t)
+	defer os.RemoveAll(dir)
+
+	files, err := readBundle(dir)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	expected := fmt.Sprintf("%x  lib/common.sh\n%x  main.sh\n",
+		sha256.Sum256([]byte("echo common\n")),
+		sha256.Sum256([]byte("#!/bin/sh\n. ./lib/common.sh\n")))
+	if manifest := string(bundleManifest(files)); manifest != expected {
+		t.Fatalf("bad manifest:\n%s", manifest)
+	}
+}
+
+func TestParseBundleCheck(t *testing.T) {
+	output := "lib/common.sh: OK\n" +
+		"main.sh: FAILED\n" +
+		"lib/other file.sh: FAILED open or read\n" +
+		"dir/with space.sh: OK\r\n"
+	ok := parseBundleCheck(output)
+	if len(ok) != 2 || !ok["lib/common.sh"] || !ok["dir/with space.sh"] {
+		t.Fatalf("bad: %#v", ok)
+	}
+}
+
+func TestProvisionerProvision_BundleUnchanged(t *testing.T) {
+	dir := testBundle(t)
+	defer os.RemoveAll(dir)
+
+	var p Provisioner
+	config := map[string]interface{}{
+		"bundle_dir":        dir,
+		"bundle_entrypoint": "main.sh",
+		"remote_bundle_dir": "/tmp/bundle",
+		"skip_clean":        true,
+	}
+	if err := p.Prepare(config); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	ui := &packer.BasicUi{
+		Reader:      new(bytes.Buffer),
+		Writer:      new(bytes.Buffer),
+		ErrorWriter: new(bytes.Buffer),
+	}
+	// Every file is reported as unchanged, so only the manifest is uploaded
+	comm := &packer.MockCommunicator{StartStdout: "lib/common.sh: OK\nmain.sh: OK\n"}
+	if err := p.Provision(context.Background(), ui, comm, nil); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if comm.UploadPath != "/tmp/bundle/"+bundleManifestName {
+		t.Fatalf("only the manifest should be uploaded: %s", comm.UploadPath)
+	}
+	if !strings.Contains(comm.StartCmd.Command, "cd '/tmp/bundle' || exit 1;") ||
+		!strings.Contains(comm.StartCmd.Command, "/tmp/bundle/main.sh") {
+		t.Fatalf("bad command: %s", comm.StartCmd.Command)
+	}
+}
+
+func TestProvisionerProvision_BundleMismatch(t *testing.T) {
+	dir := testBundle(t)
+	defer os.RemoveAll(dir)
+
+	var p Provisioner
+	config := map[string]interface{}{
+		"bundle_dir":          dir,
+		"bundle_entrypoint":   "main.sh",
+		"start_retry_timeout": "1ns",
+	}
+	if err := p.Prepare(config); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	ui := &packer.BasicUi{
+		Reader:      new(bytes.Buffer),
+		Writer:      new(bytes.Buffer),
+		ErrorWriter: new(bytes.Buffer),
+	}
+	// main.sh never verifies, even once uploaded
+	comm := &packer.MockCommunicator{StartStdout: "lib/common.sh: OK\nmain.sh: FAILED\n"}
+	err := p.Provision(context.Background(), ui, comm, nil)
+	if err == nil || !strings.Contains(err.Error(), "main.sh") {
+		t.Fatalf("should have a checksum error: %v", err)
+	}
+	if comm.UploadData != "#!/bin/sh\n. ./lib/common.sh\n" {
+		t.Fatalf("main.sh should be uploaded again: %q", comm.UploadData)
+	}
+}
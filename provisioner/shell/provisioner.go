@@ -13,6 +13,7 @@ import (
 	"log"
 	"math/rand"
 	"os"
+	"path/filepath"
 	"sort"
 	"strings"
 	"time"
@@ -60,6 +61,19 @@ type Config struct {
 
 	ExpectDisconnect bool `mapstructure:"expect_disconnect"`
 
+	// A local directory of scripts to upload as a whole, instead of
+	// uploading each script separately. Only the files that are missing or
+	// changed on the remote machine are uploaded, and all of them are
+	// verified against their sha256 checksum before bundle_entrypoint runs.
+	BundleDir string `mapstructure:"bundle_dir"`
+
+	// The script of bundle_dir to execute, relative to bundle_dir.
+	BundleEntrypoint string `mapstructure:"bundle_entrypoint"`
+
+	// The remote directory where bundle_dir is uploaded to. This defaults
+	// to remote_folder/packer-bundle-<name of bundle_dir>.
+	RemoteBundleDir string `mapstructure:"remote_bundle_dir"`
+
 	// name of the tmp environment variable file, if UseEnvVarFile is true
 	envVarFile string
 
@@ -146,7 +160,29 @@ func (p *Provisioner) Prepare(raws ...interface{}) error {
 		p.config.Scripts = []string{p.config.Script}
 	}
 
-	if len(p.config.Scripts) == 0 && p.config.Inline == nil {
+	if p.config.BundleDir != "" {
+		if len(p.config.Scripts) > 0 || p.config.Inline != nil {
+			errs = packer.MultiErrorAppend(errs,
+				errors.New("Only one of bundle_dir, a script file or an inline script can be specified."))
+		}
+		if info, err := os.Stat(p.config.BundleDir); err != nil {
+			errs = packer.MultiErrorAppend(errs,
+				fmt.Errorf("Bad bundle_dir '%s': %s", p.config.BundleDir, err))
+		} else if !info.IsDir() {
+			errs = packer.MultiErrorAppend(errs,
+				fmt.Errorf("bundle_dir '%s' must be a directory", p.config.BundleDir))
+		} else if p.config.BundleEntrypoint == "" {
+			errs = packer.MultiErrorAppend(errs,
+				errors.New("bundle_entrypoint must be specified with bundle_dir."))
+		} else if _, err := os.Stat(filepath.Join(p.config.BundleDir, p.config.BundleEntrypoint)); err != nil {
+			errs = packer.MultiErrorAppend(errs,
+				fmt.Errorf("Bad bundle_entrypoint '%s': %s", p.config.BundleEntrypoint, err))
+		}
+		if p.config.RemoteBundleDir == "" {
+			p.config.RemoteBundleDir = fmt.Sprintf("%s/packer-bundle-%s",
+				p.config.RemoteFolder, filepath.Base(filepath.Clean(p.config.BundleDir)))
+		}
+	} else if len(p.config.Scripts) == 0 && p.config.Inline == nil {
 		errs = packer.MultiErrorAppend(errs,
 			errors.New("Either a script file or inline script must be specified."))
 	} else if len(p.config.Scripts) > 0 && p.config.Inline != nil {
@@ -274,6 +310,18 @@ func (p *Provisioner) Provision(ctx context.Context, ui packer.Ui, comm packer.C
 	// Create environment variables to set before executing the command
 	flattenedEnvVars := p.createFlattenedEnvVars()
 
+	outputUi, err := p.config.OutputUi(ui)
+	if err != nil {
+		return err
+	}
+	defer outputUi.Close()
+
+	if p.config.BundleDir != "" {
+		if err := p.provisionBundle(ctx, ui, comm, outputUi, flattenedEnvVars); err != nil {
+			return err
+		}
+	}
+
 	for _, path := range scripts {
 		ui.Say(fmt.Sprintf("Provisioning with shell script: %s", path))
 
@@ -296,6 +344,14 @@ func (p *Provisioner) Provision(ctx context.Context, ui packer.Ui, comm packer.C
 			return fmt.Errorf("Error processing command: %s", err)
 		}
 
+		// Let the communicator accept a new identity of the machine, like
+		// a regenerated host key, if the script restarts it.
+		if p.config.ExpectDisconnect {
+			if err := packer.ExpectDisconnect(comm); err != nil {
+				return err
+			}
+		}
+
 		// Upload the file and run the command. Do this in the context of
 		// a single retryable function so that we don't end up with
 		// the case that the upload succeeded, a restart is initiated,
@@ -327,24 +383,14 @@ func (p *Provisioner) Provision(ctx context.Context, ui packer.Ui, comm packer.C
 			cmd.Wait()
 
 			cmd = &packer.RemoteCmd{Command: command}
-			return cmd.RunWithUi(ctx, comm, ui)
+			return cmd.RunWithUi(ctx, comm, outputUi)
 		})
 
 		if err != nil {
 			return err
 		}
 
-		// If the exit code indicates a remote disconnect, fail unless
-		// we were expecting it.
-		if cmd.ExitStatus() == packer.CmdDisconnect {
-			if !p.config.ExpectDisconnect {
-				return fmt.Errorf("Script disconnected unexpectedly. " +
-					"If you expected your script to disconnect, i.e. from a " +
-					"restart, you can try adding `\"expect_disconnect\": true` " +
-					"or `\"valid_exit_codes\": [0, 2300218]` to the shell " +
-					"provisioner parameters.")
-			}
-		} else if err := p.config.ValidExitCode(cmd.ExitStatus()); err != nil {
+		if err := p.checkExitStatus(ctx, ui, comm, cmd); err != nil {
 			return err
 		}
 
@@ -378,11 +424,33 @@ func (p *Provisioner) Provision(ctx context.Context, ui packer.Ui, comm packer.C
 	return nil
 }
 
+// checkExitStatus fails unless cmd exited with a valid exit code. If the
+// exit code indicates a remote disconnect, it fails unless we were expecting
+// it, and then waits for the machine to come back.
+func (p *Provisioner) checkExitStatus(ctx context.Context, ui packer.Ui, comm packer.Communicator, cmd *packer.RemoteCmd) error {
+	if cmd.ExitStatus() != packer.CmdDisconnect {
+		return p.config.ValidExitCode(cmd.ExitStatus())
+	}
+	if !p.config.ExpectDisconnect {
+		return fmt.Errorf("Script disconnected unexpectedly. " +
+			"If you expected your script to disconnect, i.e. from a " +
+			"restart, you can try adding `\"expect_disconnect\": true` " +
+			"or `\"valid_exit_codes\": [0, 2300218]` to the shell " +
+			"provisioner parameters.")
+	}
+	ui.Say("Waiting for the machine to come back...")
+	return packer.WaitForReconnect(ctx, comm)
+}
+
 func (p *Provisioner) cleanupRemoteFile(path string, comm packer.Communicator) error {
+	return p.cleanupRemote(fmt.Sprintf("rm -f %s", path), path, comm)
+}
+
+func (p *Provisioner) cleanupRemote(command string, path string, comm packer.Communicator) error {
 	ctx := context.TODO()
 	err := retry.Config{StartTimeout: p.config.StartRetryTimeout}.Run(ctx, func(ctx context.Context) error {
 		cmd := &packer.RemoteCmd{
-			Command: fmt.Sprintf("rm -f %s", path),
+			Command: command,
 		}
 		if err := comm.Start(ctx, cmd); err != nil {
 			return fmt.Errorf(
@@ -9,31 +9,38 @@ import (
 // FlatConfig is an auto-generated flat version of Config.
 // Where the contents of a field with a `mapstructure:,squash` tag are bubbled up.
 type FlatConfig struct {
-	PackerBuildName     *string           `mapstructure:"packer_build_name" cty:"packer_build_name" hcl:"packer_build_name"`
-	PackerBuilderType   *string           `mapstructure:"packer_builder_type" cty:"packer_builder_type" hcl:"packer_builder_type"`
-	PackerCoreVersion   *string           `mapstructure:"packer_core_version" cty:"packer_core_version" hcl:"packer_core_version"`
-	PackerDebug         *bool             `mapstructure:"packer_debug" cty:"packer_debug" hcl:"packer_debug"`
-	PackerForce         *bool             `mapstructure:"packer_force" cty:"packer_force" hcl:"packer_force"`
-	PackerOnError       *string           `mapstructure:"packer_on_error" cty:"packer_on_error" hcl:"packer_on_error"`
-	PackerUserVars      map[string]string `mapstructure:"packer_user_variables" cty:"packer_user_variables" hcl:"packer_user_variables"`
-	PackerSensitiveVars []string          `mapstructure:"packer_sensitive_variables" cty:"packer_sensitive_variables" hcl:"packer_sensitive_variables"`
-	Inline              []string          `cty:"inline" hcl:"inline"`
-	Script              *string           `cty:"script" hcl:"script"`
-	Scripts             []string          `cty:"scripts" hcl:"scripts"`
-	ValidExitCodes      []int             `mapstructure:"valid_exit_codes" cty:"valid_exit_codes" hcl:"valid_exit_codes"`
-	Vars                []string          `mapstructure:"environment_vars" cty:"environment_vars" hcl:"environment_vars"`
-	EnvVarFormat        *string           `mapstructure:"env_var_format" cty:"env_var_format" hcl:"env_var_format"`
-	Binary              *bool             `cty:"binary" hcl:"binary"`
-	RemotePath          *string           `mapstructure:"remote_path" cty:"remote_path" hcl:"remote_path"`
-	ExecuteCommand      *string           `mapstructure:"execute_command" cty:"execute_command" hcl:"execute_command"`
-	InlineShebang       *string           `mapstructure:"inline_shebang" cty:"inline_shebang" hcl:"inline_shebang"`
-	PauseAfter          *string           `mapstructure:"pause_after" cty:"pause_after" hcl:"pause_after"`
-	UseEnvVarFile       *bool             `mapstructure:"use_env_var_file" cty:"use_env_var_file" hcl:"use_env_var_file"`
-	RemoteFolder        *string           `mapstructure:"remote_folder" cty:"remote_folder" hcl:"remote_folder"`
-	RemoteFile          *string           `mapstructure:"remote_file" cty:"remote_file" hcl:"remote_file"`
-	StartRetryTimeout   *string           `mapstructure:"start_retry_timeout" cty:"start_retry_timeout" hcl:"start_retry_timeout"`
-	SkipClean           *bool             `mapstructure:"skip_clean" cty:"skip_clean" hcl:"skip_clean"`
-	ExpectDisconnect    *bool             `mapstructure:"expect_disconnect" cty:"expect_disconnect" hcl:"expect_disconnect"`
+	PackerBuildName      *string           `mapstructure:"packer_build_name" cty:"packer_build_name" hcl:"packer_build_name"`
+	PackerBuilderType    *string           `mapstructure:"packer_builder_type" cty:"packer_builder_type" hcl:"packer_builder_type"`
+	PackerCoreVersion    *string           `mapstructure:"packer_core_version" cty:"packer_core_version" hcl:"packer_core_version"`
+	PackerDebug          *bool             `mapstructure:"packer_debug" cty:"packer_debug" hcl:"packer_debug"`
+	PackerForce          *bool             `mapstructure:"packer_force" cty:"packer_force" hcl:"packer_force"`
+	PackerOnError        *string           `mapstructure:"packer_on_error" cty:"packer_on_error" hcl:"packer_on_error"`
+	PackerResume         *bool             `mapstructure:"packer_resume" cty:"packer_resume" hcl:"packer_resume"`
+	PackerCleanupTimeout *string           `mapstructure:"packer_cleanup_timeout" cty:"packer_cleanup_timeout" hcl:"packer_cleanup_timeout"`
+	PackerUserVars       map[string]string `mapstructure:"packer_user_variables" cty:"packer_user_variables" hcl:"packer_user_variables"`
+	PackerSensitiveVars  []string          `mapstructure:"packer_sensitive_variables" cty:"packer_sensitive_variables" hcl:"packer_sensitive_variables"`
+	Inline               []string          `cty:"inline" hcl:"inline"`
+	Script               *string           `cty:"script" hcl:"script"`
+	Scripts              []string          `cty:"scripts" hcl:"scripts"`
+	ValidExitCodes       []int             `mapstructure:"valid_exit_codes" cty:"valid_exit_codes" hcl:"valid_exit_codes"`
+	Vars                 []string          `mapstructure:"environment_vars" cty:"environment_vars" hcl:"environment_vars"`
+	EnvVarFormat         *string           `mapstructure:"env_var_format" cty:"env_var_format" hcl:"env_var_format"`
+	Binary               *bool             `cty:"binary" hcl:"binary"`
+	RemotePath           *string           `mapstructure:"remote_path" cty:"remote_path" hcl:"remote_path"`
+	ExecuteCommand       *string           `mapstructure:"execute_command" cty:"execute_command" hcl:"execute_command"`
+	OutputTimestamps     *bool             `mapstructure:"output_timestamps" cty:"output_timestamps" hcl:"output_timestamps"`
+	OutputFile           *string           `mapstructure:"output_file" cty:"output_file" hcl:"output_file"`
+	InlineShebang        *string           `mapstructure:"inline_shebang" cty:"inline_shebang" hcl:"inline_shebang"`
+	PauseAfter           *string           `mapstructure:"pause_after" cty:"pause_after" hcl:"pause_after"`
+	UseEnvVarFile        *bool             `mapstructure:"use_env_var_file" cty:"use_env_var_file" hcl:"use_env_var_file"`
+	RemoteFolder         *string           `mapstructure:"remote_folder" cty:"remote_folder" hcl:"remote_folder"`
+	RemoteFile           *string           `mapstructure:"remote_file" cty:"remote_file" hcl:"remote_file"`
+	StartRetryTimeout    *string           `mapstructure:"start_retry_timeout" cty:"start_retry_timeout" hcl:"start_retry_timeout"`
+	SkipClean            *bool             `mapstructure:"skip_clean" cty:"skip_clean" hcl:"skip_clean"`
+	ExpectDisconnect     *bool             `mapstructure:"expect_disconnect" cty:"expect_disconnect" hcl:"expect_disconnect"`
+	BundleDir            *string           `mapstructure:"bundle_dir" cty:"bundle_dir" hcl:"bundle_dir"`
+	BundleEntrypoint     *string           `mapstructure:"bundle_entrypoint" cty:"bundle_entrypoint" hcl:"bundle_entrypoint"`
+	RemoteBundleDir      *string           `mapstructure:"remote_bundle_dir" cty:"remote_bundle_dir" hcl:"remote_bundle_dir"`
 }
 
 // FlatMapstructure returns a new FlatConfig.
@@ -54,6 +61,8 @@ func (*FlatConfig) HCL2Spec() map[string]hcldec.Spec {
 		"packer_debug":               &hcldec.AttrSpec{Name: "packer_debug", Type: cty.Bool, Required: false},
 		"packer_force":               &hcldec.AttrSpec{Name: "packer_force", Type: cty.Bool, Required: false},
 		"packer_on_error":            &hcldec.AttrSpec{Name: "packer_on_error", Type: cty.String, Required: false},
+		"packer_resume":              &hcldec.AttrSpec{Name: "packer_resume", Type: cty.Bool, Required: false},
+		"packer_cleanup_timeout":     &hcldec.AttrSpec{Name: "packer_cleanup_timeout", Type: cty.String, Required: false},
 		"packer_user_variables":      &hcldec.AttrSpec{Name: "packer_user_variables", Type: cty.Map(cty.String), Required: false},
 		"packer_sensitive_variables": &hcldec.AttrSpec{Name: "packer_sensitive_variables", Type: cty.List(cty.String), Required: false},
 		"inline":                     &hcldec.AttrSpec{Name: "inline", Type: cty.List(cty.String), Required: false},
@@ -65,6 +74,8 @@ func (*FlatConfig) HCL2Spec() map[string]hcldec.Spec {
 		"binary":                     &hcldec.AttrSpec{Name: "binary", Type: cty.Bool, Required: false},
 		"remote_path":                &hcldec.AttrSpec{Name: "remote_path", Type: cty.String, Required: false},
 		"execute_command":            &hcldec.AttrSpec{Name: "execute_command", Type: cty.String, Required: false},
+		"output_timestamps":          &hcldec.AttrSpec{Name: "output_timestamps", Type: cty.Bool, Required: false},
+		"output_file":                &hcldec.AttrSpec{Name: "output_file", Type: cty.String, Required: false},
 		"inline_shebang":             &hcldec.AttrSpec{Name: "inline_shebang", Type: cty.String, Required: false},
 		"pause_after":                &hcldec.AttrSpec{Name: "pause_after", Type: cty.String, Required: false},
 		"use_env_var_file":           &hcldec.AttrSpec{Name: "use_env_var_file", Type: cty.Bool, Required: false},
@@ -73,6 +84,9 @@ func (*FlatConfig) HCL2Spec() map[string]hcldec.Spec {
 		"start_retry_timeout":        &hcldec.AttrSpec{Name: "start_retry_timeout", Type: cty.String, Required: false},
 		"skip_clean":                 &hcldec.AttrSpec{Name: "skip_clean", Type: cty.Bool, Required: false},
 		"expect_disconnect":          &hcldec.AttrSpec{Name: "expect_disconnect", Type: cty.Bool, Required: false},
+		"bundle_dir":                 &hcldec.AttrSpec{Name: "bundle_dir", Type: cty.String, Required: false},
+		"bundle_entrypoint":          &hcldec.AttrSpec{Name: "bundle_entrypoint", Type: cty.String, Required: false},
+		"remote_bundle_dir":          &hcldec.AttrSpec{Name: "remote_bundle_dir", Type: cty.String, Required: false},
 	}
 	return s
 }
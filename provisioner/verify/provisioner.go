@@ -0,0 +1,292 @@
+//go:generate mapstructure-to-hcl2 -type Config
+
+// This package implements a provisioner for Packer that verifies the
+// machine with a goss or InSpec profile run on the machine itself.
+package verify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2/hcldec"
+	"github.com/hashicorp/packer/packer"
+	"github.com/hashicorp/packer/packer-plugin-sdk/common"
+	"github.com/hashicorp/packer/packer-plugin-sdk/template/config"
+	"github.com/hashicorp/packer/packer-plugin-sdk/template/interpolate"
+	"github.com/hashicorp/packer/packer-plugin-sdk/uuid"
+)
+
+const (
+	ToolGoss   = "goss"
+	ToolInSpec = "inspec"
+)
+
+// The keys of the build metadata the provisioner reports.
+const (
+	SummaryMetadataKey = "verify.summary"
+	ResultsMetadataKey = "verify.results"
+)
+
+type Config struct {
+	common.PackerConfig `mapstructure:",squash"`
+
+	// The verification tool, either `goss` or `inspec`.
+	Tool string `mapstructure:"tool" required:"true"`
+
+	// The path to the profile to verify the machine with. For goss, a
+	// gossfile or a directory with a `goss.yaml` gossfile. For InSpec, a
+	// profile directory or a single `.rb` file of controls.
+	Profile string `mapstructure:"profile" required:"true"`
+
+	// The path to a binary of the tool to upload to the machine and run,
+	// like a static goss binary. By default the tool installed on the
+	// machine is run.
+	Binary string `mapstructure:"binary"`
+
+	// The command running the tool on the machine when binary isn't set.
+	// Defaults to the name of the tool.
+	Command string `mapstructure:"command"`
+
+	// Extra arguments of the tool, like `--vars vars.yaml` for goss or
+	// `--input-file inputs.yml` for InSpec.
+	ExtraArguments []string `mapstructure:"extra_arguments"`
+
+	// The command to use to run the tool. Defaults to
+	// `cd {{.Dir}} && {{.Command}}`, where `Dir` is the directory the
+	// profile is uploaded to and `Command` the tool with its arguments. Set
+	// it to `cd {{.Dir}} && sudo {{.Command}}` to verify as root.
+	ExecuteCommand string `mapstructure:"execute_command"`
+
+	// The folder where the profile is uploaded on the machine. Defaults to
+	// `/tmp`.
+	RemoteFolder string `mapstructure:"remote_folder"`
+
+	// Report the failed tests without failing the build.
+	IgnoreFailures bool `mapstructure:"ignore_failures"`
+
+	ctx interpolate.Context
+}
+
+type Provisioner struct {
+	config Config
+}
+
+func (p *Provisioner) ConfigSpec() hcldec.ObjectSpec { return p.config.FlatMapstructure().HCL2Spec() }
+
+func (p *Provisioner) Prepare(raws ...interface{}) error {
+	err := config.Decode(&p.config, &config.DecodeOpts{
+		PluginType:         "verify",
+		Interpolate:        true,
+		InterpolateContext: &p.config.ctx,
+		InterpolateFilter: &interpolate.RenderFilter{
+			Exclude: []string{
+				"execute_command",
+			},
+		},
+	}, raws...)
+	if err != nil {
+		return err
+	}
+
+	if p.config.Command == "" {
+		p.config.Command = p.config.Tool
+	}
+
+	if p.config.ExecuteCommand == "" {
+		p.config.ExecuteCommand = "cd {{.Dir}} && {{.Command}}"
+	}
+
+	if p.config.RemoteFolder == "" {
+		p.config.RemoteFolder = "/tmp"
+	}
+
+	var errs *packer.MultiError
+	if p.config.Tool != ToolGoss && p.config.Tool != ToolInSpec {
+		errs = packer.MultiErrorAppend(errs,
+			fmt.Errorf("tool must be %s or %s", ToolGoss, ToolInSpec))
+	}
+
+	if p.config.Profile == "" {
+		errs = packer.MultiErrorAppend(errs, errors.New("profile must be specified"))
+	} else if info, err := os.Stat(p.config.Profile); err != nil {
+		errs = packer.MultiErrorAppend(errs,
+			fmt.Errorf("Bad profile '%s': %s", p.config.Profile, err))
+	} else if info.IsDir() && p.config.Tool == ToolGoss {
+		if _, err := os.Stat(filepath.Join(p.config.Profile, "goss.yaml")); err != nil {
+			errs = packer.MultiErrorAppend(errs,
+				fmt.Errorf("Bad profile '%s': %s", p.config.Profile, err))
+		}
+	}
+
+	if p.config.Binary != "" {
+		if _, err := os.Stat(p.config.Binary); err != nil {
+			errs = packer.MultiErrorAppend(errs,
+				fmt.Errorf("Bad binary '%s': %s", p.config.Binary, err))
+		}
+	}
+
+	if errs != nil && len(errs.Errors) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+func (p *Provisioner) Provision(ctx context.Context, ui packer.Ui, comm packer.Communicator, generatedData map[string]interface{}) error {
+	if generatedData == nil {
+		generatedData = make(map[string]interface{})
+	}
+	ui.Say(fmt.Sprintf("Verifying the machine with %s profile %s...", p.config.Tool, p.config.Profile))
+
+	dir := path.Join(p.config.RemoteFolder, fmt.Sprintf("packer-verify-%s", uuid.TimeOrderedUUID()))
+	if err := p.runCommand(ctx, comm, fmt.Sprintf("mkdir -p %s", dir)); err != nil {
+		return err
+	}
+	defer func() {
+		if err := p.runCommand(ctx, comm, fmt.Sprintf("rm -rf %s", dir)); err != nil {
+			log.Printf("[WARN] %s", err)
+		}
+	}()
+
+	target, err := p.uploadProfile(comm, dir)
+	if err != nil {
+		return err
+	}
+
+	command := p.config.Command
+	if p.config.Binary != "" {
+		command = path.Join(dir, "bin", path.Base(filepath.ToSlash(p.config.Binary)))
+		if err := uploadFile(comm, command, p.config.Binary); err != nil {
+			return fmt.Errorf("Error uploading %s: %s", p.config.Binary, err)
+		}
+		if err := p.runCommand(ctx, comm, fmt.Sprintf("chmod 0755 %s", command)); err != nil {
+			return err
+		}
+	}
+	command = strings.Join(append([]string{command}, p.toolArgs(target)...), " ")
+
+	generatedData["Dir"] = dir
+	generatedData["Command"] = command
+	p.config.ctx.Data = generatedData
+	command, err = interpolate.Render(p.config.ExecuteCommand, &p.config.ctx)
+	if err != nil {
+		return fmt.Errorf("Error processing command: %s", err)
+	}
+
+	// The JSON report is written on stdout, so only stderr is shown
+	var stdout bytes.Buffer
+	cmd := &packer.RemoteCmd{Command: command, Stdout: &stdout}
+	if err := cmd.RunWithUi(ctx, comm, ui); err != nil {
+		return err
+	}
+
+	r, err := p.parseReport(stdout.Bytes())
+	if err != nil {
+		return fmt.Errorf("%s exited with status %d without a valid report: %s",
+			p.config.Tool, cmd.ExitStatus(), err)
+	}
+
+	summary := fmt.Sprintf("%d tests, %d failed", r.Tests, len(r.Failures))
+	var results bytes.Buffer
+	if err := json.Compact(&results, stdout.Bytes()); err != nil {
+		return err
+	}
+	ui.Machine(packer.MetadataMachineType, SummaryMetadataKey, summary)
+	ui.Machine(packer.MetadataMachineType, ResultsMetadataKey, results.String())
+
+	if len(r.Failures) == 0 {
+		ui.Say(fmt.Sprintf("Verification passed: %s", summary))
+		return nil
+	}
+	for _, failure := range r.Failures {
+		ui.Error(fmt.Sprintf("Failed: %s", failure))
+	}
+	if p.config.IgnoreFailures {
+		ui.Say(fmt.Sprintf("Verification failed, ignoring: %s", summary))
+		return nil
+	}
+	return fmt.Errorf("Verification failed: %s", summary)
+}
+
+// uploadProfile uploads the profile to dir, and returns the path of the
+// profile the tool has to run, relative to dir.
+func (p *Provisioner) uploadProfile(comm packer.Communicator, dir string) (string, error) {
+	info, err := os.Stat(p.config.Profile)
+	if err != nil {
+		return "", err
+	}
+
+	if info.IsDir() {
+		// The trailing separator uploads the content of the directory
+		src := filepath.Clean(p.config.Profile) + string(filepath.Separator)
+		if err := comm.UploadDir(path.Join(dir, "profile"), src, nil); err != nil {
+			return "", fmt.Errorf("Error uploading profile: %s", err)
+		}
+		if p.config.Tool == ToolGoss {
+			return "profile/goss.yaml", nil
+		}
+		return "profile", nil
+	}
+
+	name := path.Base(filepath.ToSlash(p.config.Profile))
+	if err := uploadFile(comm, path.Join(dir, name), p.config.Profile); err != nil {
+		return "", fmt.Errorf("Error uploading profile: %s", err)
+	}
+	return name, nil
+}
+
+// toolArgs returns the arguments running the tool on target with a JSON
+// report on stdout.
+func (p *Provisioner) toolArgs(target string) []string {
+	var args []string
+	switch p.config.Tool {
+	case ToolGoss:
+		args = []string{"--gossfile", target}
+		args = append(args, p.config.ExtraArguments...)
+		args = append(args, "validate", "--format", "json", "--no-color")
+	case ToolInSpec:
+		args = []string{"exec", target, "--reporter", "json", "--no-color", "--chef-license", "accept-silent"}
+		args = append(args, p.config.ExtraArguments...)
+	}
+	return args
+}
+
+func (p *Provisioner) parseReport(data []byte) (*report, error) {
+	if p.config.Tool == ToolGoss {
+		return parseGossReport(data)
+	}
+	return parseInSpecReport(data)
+}
+
+func uploadFile(comm packer.Communicator, dst string, src string) error {
+	f, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	return comm.Upload(dst, f, &fi)
+}
+
+func (p *Provisioner) runCommand(ctx context.Context, comm packer.Communicator, command string) error {
+	cmd := &packer.RemoteCmd{Command: command}
+	if err := comm.Start(ctx, cmd); err != nil {
+		return fmt.Errorf("Error running %q: %s", command, err)
+	}
+	if status := cmd.Wait(); status != 0 {
+		return fmt.Errorf("Error running %q: exit status %d", command, status)
+	}
+	return nil
+}
@@ -0,0 +1,175 @@
+package verify
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/packer/packer"
+)
+
+const testGossReport = `{
+  "results": [
+    {"successful": true, "skipped": false, "summary-line": "Service: nginx: running: matches expectation: [true]"},
+    {"successful": false, "skipped": false, "summary-line": "Port: tcp:80: listening: Expected\n    <bool>: false\nto equal\n    <bool>: true"},
+    {"successful": false, "skipped": true, "summary-line": "File: /etc/motd: exists: skipped"}
+  ],
+  "summary": {"failed-count": 1, "summary-line": "Count: 3, Failed: 1, Duration: 0.012s", "test-count": 3}
+}`
+
+const testInSpecReport = `{
+  "profiles": [{
+    "name": "base",
+    "controls": [
+      {"id": "sshd-01", "results": [{"status": "passed", "code_desc": "Service sshd is expected to be running"}]},
+      {"id": "sshd-02", "results": [
+        {"status": "failed", "code_desc": "sshd_config PermitRootLogin is expected to eq \"no\"", "message": "expected: \"no\"\n     got: \"yes\""},
+        {"status": "skipped", "code_desc": "sshd_config Protocol"}
+      ]}
+    ]
+  }],
+  "statistics": {"duration": 0.21}
+}`
+
+func testProfile(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "packer-verify")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "goss.yaml"), []byte("service:\n  nginx:\n    running: true\n"), 0644); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	return dir
+}
+
+func testUi() *packer.BasicUi {
+	return &packer.BasicUi{
+		Reader:      new(bytes.Buffer),
+		Writer:      new(bytes.Buffer),
+		ErrorWriter: new(bytes.Buffer),
+	}
+}
+
+func TestProvisioner_Impl(t *testing.T) {
+	var raw interface{}
+	raw = &Provisioner{}
+	if _, ok := raw.(packer.Provisioner); !ok {
+		t.Fatalf("must be a Provisioner")
+	}
+}
+
+func TestProvisionerPrepare(t *testing.T) {
+	dir := testProfile(t)
+	defer os.RemoveAll(dir)
+
+	var p Provisioner
+	if err := p.Prepare(map[string]interface{}{"profile": dir}); err == nil {
+		t.Fatal("should have error without a tool")
+	}
+
+	p = Provisioner{}
+	if err := p.Prepare(map[string]interface{}{"tool": "goss"}); err == nil {
+		t.Fatal("should have error without a profile")
+	}
+
+	p = Provisioner{}
+	if err := p.Prepare(map[string]interface{}{"tool": "goss", "profile": dir}); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if p.config.Command != "goss" || p.config.RemoteFolder != "/tmp" {
+		t.Fatalf("bad defaults: %#v", p.config)
+	}
+
+	os.Remove(filepath.Join(dir, "goss.yaml"))
+	p = Provisioner{}
+	if err := p.Prepare(map[string]interface{}{"tool": "goss", "profile": dir}); err == nil {
+		t.Fatal("should have error without a goss.yaml")
+	}
+}
+
+func TestParseGossReport(t *testing.T) {
+	r, err := parseGossReport([]byte(testGossReport))
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if r.Tests != 3 || len(r.Failures) != 1 || !strings.HasPrefix(r.Failures[0], "Port: tcp:80: listening") {
+		t.Fatalf("bad: %#v", r)
+	}
+
+	if _, err := parseGossReport([]byte(`{"results": []}`)); err == nil {
+		t.Fatal("should have error without a summary")
+	}
+}
+
+func TestParseInSpecReport(t *testing.T) {
+	r, err := parseInSpecReport([]byte(testInSpecReport))
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	expected := []string{"sshd-02: sshd_config PermitRootLogin is expected to eq \"no\": expected: \"no\"\n     got: \"yes\""}
+	if r.Tests != 3 || !reflect.DeepEqual(r.Failures, expected) {
+		t.Fatalf("bad: %#v", r)
+	}
+}
+
+func TestToolArgs(t *testing.T) {
+	p := Provisioner{config: Config{Tool: ToolGoss, ExtraArguments: []string{"--vars", "vars.yaml"}}}
+	expected := []string{"--gossfile", "profile/goss.yaml", "--vars", "vars.yaml", "validate", "--format", "json", "--no-color"}
+	if args := p.toolArgs("profile/goss.yaml"); !reflect.DeepEqual(args, expected) {
+		t.Fatalf("bad: %#v", args)
+	}
+
+	p = Provisioner{config: Config{Tool: ToolInSpec}}
+	expected = []string{"exec", "profile", "--reporter", "json", "--no-color", "--chef-license", "accept-silent"}
+	if args := p.toolArgs("profile"); !reflect.DeepEqual(args, expected) {
+		t.Fatalf("bad: %#v", args)
+	}
+}
+
+// metadataUi records the build metadata the provisioner reports.
+type metadataUi struct {
+	*packer.BasicUi
+	metadata map[string]string
+}
+
+func (u *metadataUi) Machine(t string, args ...string) {
+	if t == packer.MetadataMachineType && len(args) == 2 {
+		u.metadata[args[0]] = args[1]
+	}
+}
+
+func TestProvisionerProvision(t *testing.T) {
+	dir := testProfile(t)
+	defer os.RemoveAll(dir)
+
+	var p Provisioner
+	if err := p.Prepare(map[string]interface{}{"tool": "goss", "profile": dir}); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	ui := &metadataUi{BasicUi: testUi(), metadata: make(map[string]string)}
+	comm := &packer.MockCommunicator{StartStdout: testGossReport}
+	err := p.Provision(context.Background(), ui, comm, nil)
+	if err == nil || !strings.Contains(err.Error(), "3 tests, 1 failed") {
+		t.Fatalf("should fail the build: %v", err)
+	}
+	if !strings.HasSuffix(comm.UploadDirDst, "/profile") {
+		t.Fatalf("the profile should be uploaded: %s", comm.UploadDirDst)
+	}
+	if ui.metadata[SummaryMetadataKey] != "3 tests, 1 failed" {
+		t.Fatalf("bad summary: %#v", ui.metadata)
+	}
+	if !strings.HasPrefix(ui.metadata[ResultsMetadataKey], `{"results":[{"successful":true`) {
+		t.Fatalf("bad results: %s", ui.metadata[ResultsMetadataKey])
+	}
+
+	p.config.IgnoreFailures = true
+	if err := p.Provision(context.Background(), ui, comm, nil); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+}
@@ -0,0 +1,88 @@
+package verify
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// A report is the outcome of a verification run.
+type report struct {
+	Tests    int
+	Failures []string
+}
+
+// gossReport is the part of the json format of goss validate the
+// provisioner reads.
+type gossReport struct {
+	Results []struct {
+		Successful  bool   `json:"successful"`
+		Skipped     bool   `json:"skipped"`
+		SummaryLine string `json:"summary-line"`
+	} `json:"results"`
+	Summary *struct {
+		TestCount   int `json:"test-count"`
+		FailedCount int `json:"failed-count"`
+	} `json:"summary"`
+}
+
+func parseGossReport(data []byte) (*report, error) {
+	var gr gossReport
+	if err := json.Unmarshal(data, &gr); err != nil {
+		return nil, err
+	}
+	if gr.Summary == nil {
+		return nil, fmt.Errorf("missing summary")
+	}
+
+	r := &report{Tests: gr.Summary.TestCount}
+	for _, result := range gr.Results {
+		if !result.Successful && !result.Skipped {
+			r.Failures = append(r.Failures, result.SummaryLine)
+		}
+	}
+	return r, nil
+}
+
+// inspecReport is the part of the json reporter of inspec exec the
+// provisioner reads.
+type inspecReport struct {
+	Profiles []struct {
+		Controls []struct {
+			ID      string `json:"id"`
+			Results []struct {
+				Status   string `json:"status"`
+				CodeDesc string `json:"code_desc"`
+				Message  string `json:"message"`
+			} `json:"results"`
+		} `json:"controls"`
+	} `json:"profiles"`
+	Statistics *json.RawMessage `json:"statistics"`
+}
+
+func parseInSpecReport(data []byte) (*report, error) {
+	var ir inspecReport
+	if err := json.Unmarshal(data, &ir); err != nil {
+		return nil, err
+	}
+	if ir.Statistics == nil {
+		return nil, fmt.Errorf("missing statistics")
+	}
+
+	r := &report{}
+	for _, profile := range ir.Profiles {
+		for _, control := range profile.Controls {
+			for _, result := range control.Results {
+				r.Tests++
+				if result.Status != "failed" {
+					continue
+				}
+				failure := fmt.Sprintf("%s: %s", control.ID, result.CodeDesc)
+				if result.Message != "" {
+					failure += ": " + result.Message
+				}
+				r.Failures = append(r.Failures, failure)
+			}
+		}
+	}
+	return r, nil
+}
@@ -109,6 +109,10 @@ func (p *Provisioner) Provision(ctx context.Context, ui packer.Ui, comm packer.C
 	p.comm = comm
 	p.ui = ui
 
+	if err := packer.ExpectDisconnect(comm); err != nil {
+		return err
+	}
+
 	var cmd *packer.RemoteCmd
 	command := p.config.RestartCommand
 	err := retry.Config{StartTimeout: p.config.RestartTimeout}.Run(ctx, func(context.Context) error {
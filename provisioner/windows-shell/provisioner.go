@@ -175,6 +175,12 @@ func (p *Provisioner) Provision(ctx context.Context, ui packer.Ui, comm packer.C
 		defer os.Remove(temp)
 	}
 
+	outputUi, err := p.config.OutputUi(ui)
+	if err != nil {
+		return err
+	}
+	defer outputUi.Close()
+
 	for _, path := range scripts {
 		ui.Say(fmt.Sprintf("Provisioning with shell script: %s", path))
 
@@ -214,7 +220,7 @@ func (p *Provisioner) Provision(ctx context.Context, ui packer.Ui, comm packer.C
 			}
 
 			cmd = &packer.RemoteCmd{Command: command}
-			return cmd.RunWithUi(ctx, comm, ui)
+			return cmd.RunWithUi(ctx, comm, outputUi)
 		})
 		if err != nil {
 			return err
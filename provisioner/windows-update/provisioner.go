@@ -0,0 +1,224 @@
+//go:generate mapstructure-to-hcl2 -type Config
+
+// This package implements a provisioner for Packer that installs Windows
+// updates, restarting the machine as many times as they need.
+package update
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/hcl/v2/hcldec"
+	"github.com/hashicorp/packer/packer"
+	"github.com/hashicorp/packer/packer-plugin-sdk/common"
+	"github.com/hashicorp/packer/packer-plugin-sdk/guestexec"
+	"github.com/hashicorp/packer/packer-plugin-sdk/template/config"
+	"github.com/hashicorp/packer/packer-plugin-sdk/template/interpolate"
+	"github.com/hashicorp/packer/packer-plugin-sdk/uuid"
+)
+
+// DefaultSearchCriteria finds the updates that aren't installed yet.
+const DefaultSearchCriteria = "BrowseOnly=0 and IsInstalled=0"
+
+// InstalledMetadataKey is the key of the build metadata listing the KBs the
+// provisioner installed.
+const InstalledMetadataKey = "windows-update.installed_kbs"
+
+// The exit codes of the update script, besides 0 when there is nothing left
+// to install.
+const (
+	exitRestartRequired = 101
+	exitSearchAgain     = 102
+)
+
+var kbRegexp = regexp.MustCompile(`(?i)^(KB)?(\d+)$`)
+var installedRegexp = regexp.MustCompile(`^Installed update (KB\d+)`)
+
+type Config struct {
+	common.PackerConfig `mapstructure:",squash"`
+
+	// The Windows Update search criteria, as documented for
+	// IUpdateSearcher::Search. Defaults to
+	// "BrowseOnly=0 and IsInstalled=0".
+	SearchCriteria string `mapstructure:"search_criteria"`
+
+	// Only install the updates of one of these categories, like
+	// "Security Updates" or "Critical Updates". Defaults to all categories.
+	Categories []string `mapstructure:"categories"`
+
+	// Only install these updates, by KB number.
+	IncludeKBs []string `mapstructure:"include_kbs"`
+
+	// Never install these updates, by KB number.
+	ExcludeKBs []string `mapstructure:"exclude_kbs"`
+
+	// How many updates to install before restarting the machine. Defaults
+	// to 1000.
+	UpdateLimit int `mapstructure:"update_limit"`
+
+	// How long to wait for the machine to restart once the updates that
+	// need it are installed. Defaults to 1h.
+	RestartTimeout time.Duration `mapstructure:"restart_timeout"`
+
+	ctx interpolate.Context
+}
+
+type Provisioner struct {
+	config Config
+	comm   packer.Communicator
+}
+
+func (p *Provisioner) ConfigSpec() hcldec.ObjectSpec { return p.config.FlatMapstructure().HCL2Spec() }
+
+func (p *Provisioner) Prepare(raws ...interface{}) error {
+	err := config.Decode(&p.config, &config.DecodeOpts{
+		PluginType:         "windows-update",
+		Interpolate:        true,
+		InterpolateContext: &p.config.ctx,
+	}, raws...)
+	if err != nil {
+		return err
+	}
+
+	if p.config.SearchCriteria == "" {
+		p.config.SearchCriteria = DefaultSearchCriteria
+	}
+
+	if p.config.UpdateLimit == 0 {
+		p.config.UpdateLimit = 1000
+	}
+
+	if p.config.RestartTimeout == 0 {
+		p.config.RestartTimeout = time.Hour
+	}
+
+	var errs *packer.MultiError
+	if p.config.UpdateLimit < 0 {
+		errs = packer.MultiErrorAppend(errs, fmt.Errorf("update_limit must be positive"))
+	}
+	if p.config.IncludeKBs, err = normalizeKBs(p.config.IncludeKBs); err != nil {
+		errs = packer.MultiErrorAppend(errs, fmt.Errorf("include_kbs is invalid: %s", err))
+	}
+	if p.config.ExcludeKBs, err = normalizeKBs(p.config.ExcludeKBs); err != nil {
+		errs = packer.MultiErrorAppend(errs, fmt.Errorf("exclude_kbs is invalid: %s", err))
+	}
+	if errs != nil && len(errs.Errors) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+// normalizeKBs returns kbs as KB followed by their number, like KB4580325.
+func normalizeKBs(kbs []string) ([]string, error) {
+	res := make([]string, 0, len(kbs))
+	for _, kb := range kbs {
+		m := kbRegexp.FindStringSubmatch(strings.TrimSpace(kb))
+		if m == nil {
+			return nil, fmt.Errorf("%q is not a KB number", kb)
+		}
+		res = append(res, "KB"+m[2])
+	}
+	return res, nil
+}
+
+// Communicator, ElevatedUser and ElevatedPassword implement
+// guestexec.ElevatedProvisioner: the Windows Update API refuses to download
+// updates from a remote session, so the update script runs as a scheduled
+// task of the SYSTEM account.
+func (p *Provisioner) Communicator() packer.Communicator {
+	return p.comm
+}
+
+func (p *Provisioner) ElevatedUser() string {
+	return "SYSTEM"
+}
+
+func (p *Provisioner) ElevatedPassword() string {
+	return ""
+}
+
+func (p *Provisioner) Provision(ctx context.Context, ui packer.Ui, comm packer.Communicator, _ map[string]interface{}) error {
+	p.comm = comm
+
+	var installed []string
+	for {
+		status, kbs, err := p.update(ctx, ui)
+		if err != nil {
+			return err
+		}
+		for _, kb := range kbs {
+			ui.Machine(packer.MetadataMachineType, InstalledMetadataKey, kb)
+		}
+		installed = append(installed, kbs...)
+
+		switch status {
+		case 0:
+			if len(installed) > 0 {
+				ui.Say(fmt.Sprintf("Installed %d Windows updates: %s", len(installed), strings.Join(installed, ", ")))
+			}
+			return nil
+		case exitSearchAgain:
+			continue
+		case exitRestartRequired, packer.CmdDisconnect:
+			if err := p.restart(ctx, ui); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("Windows update script exited with non-zero exit status: %d", status)
+		}
+	}
+}
+
+// update runs the update script once, and returns its exit status and the
+// KBs it installed.
+func (p *Provisioner) update(ctx context.Context, ui packer.Ui) (int, []string, error) {
+	var script bytes.Buffer
+	if err := updateScript.Execute(&script, newScriptOptions(p.config)); err != nil {
+		return 0, nil, fmt.Errorf("Error generating the Windows update script: %s", err)
+	}
+
+	path := fmt.Sprintf(`C:/Windows/Temp/packer-windows-update-%s.ps1`, uuid.TimeOrderedUUID())
+	if err := p.comm.Upload(path, &script, nil); err != nil {
+		return 0, nil, fmt.Errorf("Error uploading the Windows update script: %s", err)
+	}
+
+	command, err := guestexec.GenerateElevatedRunner(
+		fmt.Sprintf(`PowerShell -ExecutionPolicy Bypass -OutputFormat Text -File %s`, path), p)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	var stdout bytes.Buffer
+	cmd := &packer.RemoteCmd{Command: command, Stdout: &stdout}
+	if err := cmd.RunWithUi(ctx, p.comm, ui); err != nil {
+		return 0, nil, err
+	}
+	return cmd.ExitStatus(), parseInstalled(stdout.String()), nil
+}
+
+// parseInstalled returns the KBs of the updates the update script says it
+// installed.
+func parseInstalled(output string) []string {
+	var kbs []string
+	for _, line := range strings.Split(output, "\n") {
+		if m := installedRegexp.FindStringSubmatch(strings.TrimSpace(line)); m != nil {
+			kbs = append(kbs, m[1])
+		}
+	}
+	return kbs
+}
+
+// restart restarts the machine and waits until it booted again.
+func (p *Provisioner) restart(ctx context.Context, ui packer.Ui) error {
+	ui.Say("Restarting the machine to finish installing the updates...")
+	if err := guestexec.RestartWindows(ctx, p.comm, p.config.RestartTimeout); err != nil {
+		return err
+	}
+	ui.Say("Machine restarted, searching for more updates...")
+	return nil
+}
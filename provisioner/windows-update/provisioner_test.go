@@ -0,0 +1,157 @@
+package update
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/packer/packer"
+)
+
+func testConfig() map[string]interface{} {
+	return map[string]interface{}{}
+}
+
+func TestProvisioner_Impl(t *testing.T) {
+	var raw interface{}
+	raw = &Provisioner{}
+	if _, ok := raw.(packer.Provisioner); !ok {
+		t.Fatalf("must be a Provisioner")
+	}
+}
+
+func TestProvisionerPrepare_Defaults(t *testing.T) {
+	var p Provisioner
+	config := testConfig()
+
+	err := p.Prepare(config)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if p.config.SearchCriteria != DefaultSearchCriteria {
+		t.Errorf("unexpected search criteria: %s", p.config.SearchCriteria)
+	}
+	if p.config.UpdateLimit != 1000 {
+		t.Errorf("unexpected update limit: %d", p.config.UpdateLimit)
+	}
+	if p.config.RestartTimeout != time.Hour {
+		t.Errorf("unexpected restart timeout: %s", p.config.RestartTimeout)
+	}
+}
+
+func TestProvisionerPrepare_KBs(t *testing.T) {
+	var p Provisioner
+	config := testConfig()
+	config["include_kbs"] = []string{"KB4580325", "4586793", "kb123"}
+
+	err := p.Prepare(config)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	expected := []string{"KB4580325", "KB4586793", "KB123"}
+	if !reflect.DeepEqual(p.config.IncludeKBs, expected) {
+		t.Fatalf("bad: %#v", p.config.IncludeKBs)
+	}
+
+	config["exclude_kbs"] = []string{"Windows Defender"}
+	if err := p.Prepare(config); err == nil {
+		t.Fatal("should have error")
+	}
+}
+
+func TestProvisionerPrepare_InvalidKey(t *testing.T) {
+	var p Provisioner
+	config := testConfig()
+
+	// Add a random key
+	config["i_should_not_be_valid"] = true
+	err := p.Prepare(config)
+	if err == nil {
+		t.Fatal("should have error")
+	}
+}
+
+func TestUpdateScript(t *testing.T) {
+	var p Provisioner
+	config := testConfig()
+	config["categories"] = []string{"Security Updates", "Critical Updates"}
+	config["exclude_kbs"] = []string{"KB4580325"}
+	config["search_criteria"] = "IsInstalled=0 and Type='Software'"
+	if err := p.Prepare(config); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	var script bytes.Buffer
+	if err := updateScript.Execute(&script, newScriptOptions(p.config)); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	for _, expected := range []string{
+		`$searchCriteria = 'IsInstalled=0 and Type=''Software'''`,
+		`$categories = @('Security Updates', 'Critical Updates')`,
+		`$includeKBs = @()`,
+		`$excludeKBs = @('KB4580325')`,
+		`$updateLimit = 1000`,
+	} {
+		if !strings.Contains(script.String(), expected) {
+			t.Errorf("the script should contain %s", expected)
+		}
+	}
+}
+
+func TestParseInstalled(t *testing.T) {
+	output := "Searching for Windows updates (BrowseOnly=0 and IsInstalled=0)...\r\n" +
+		"Installed update KB4580325: 2020-10 Security Update for Adobe Flash Player\r\n" +
+		"Installed update: Windows Defender Antivirus\r\n" +
+		"Failed to install update (result code 4): KB4586793\r\n" +
+		"Installed update KB890830: Windows Malicious Software Removal Tool\r\n"
+
+	kbs := parseInstalled(output)
+	expected := []string{"KB4580325", "KB890830"}
+	if !reflect.DeepEqual(kbs, expected) {
+		t.Fatalf("bad: %#v", kbs)
+	}
+}
+
+func TestProvisionerProvision_noUpdates(t *testing.T) {
+	var p Provisioner
+	if err := p.Prepare(testConfig()); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	ui := testUi()
+	comm := new(packer.MockCommunicator)
+	if err := p.Provision(context.Background(), ui, comm, nil); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	// The update script runs from an elevated wrapper, uploaded last
+	if !comm.UploadCalled || !strings.Contains(comm.UploadData, "C:/Windows/Temp/packer-windows-update-") {
+		t.Fatalf("the update script should run elevated: %s", comm.UploadData)
+	}
+	if !strings.Contains(comm.StartCmd.Command, comm.UploadPath) {
+		t.Fatalf("bad command: %s", comm.StartCmd.Command)
+	}
+}
+
+func TestProvisionerProvision_failure(t *testing.T) {
+	var p Provisioner
+	if err := p.Prepare(testConfig()); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	comm := &packer.MockCommunicator{StartExitStatus: 1}
+	if err := p.Provision(context.Background(), testUi(), comm, nil); err == nil {
+		t.Fatal("should have error")
+	}
+}
+
+func testUi() *packer.BasicUi {
+	return &packer.BasicUi{
+		Reader:      new(bytes.Buffer),
+		Writer:      new(bytes.Buffer),
+		ErrorWriter: new(bytes.Buffer),
+	}
+}
@@ -0,0 +1,129 @@
+package update
+
+import (
+	"strings"
+	"text/template"
+)
+
+type scriptOptions struct {
+	SearchCriteria string
+	Categories     []string
+	IncludeKBs     []string
+	ExcludeKBs     []string
+	UpdateLimit    int
+}
+
+func newScriptOptions(c Config) scriptOptions {
+	return scriptOptions{
+		SearchCriteria: psQuote(c.SearchCriteria),
+		Categories:     psQuoteAll(c.Categories),
+		IncludeKBs:     psQuoteAll(c.IncludeKBs),
+		ExcludeKBs:     psQuoteAll(c.ExcludeKBs),
+		UpdateLimit:    c.UpdateLimit,
+	}
+}
+
+// psQuote quotes s as a single quoted PowerShell string.
+func psQuote(s string) string {
+	return "'" + strings.Replace(s, "'", "''", -1) + "'"
+}
+
+func psQuoteAll(list []string) []string {
+	res := make([]string, len(list))
+	for i, s := range list {
+		res[i] = psQuote(s)
+	}
+	return res
+}
+
+// updateScript installs the updates that match the filters with the Windows
+// Update API. It exits with exitRestartRequired when the machine has to
+// restart, with exitSearchAgain when more updates may be installed right
+// away, and with 0 when there is nothing left to install. The provisioner
+// reads the KBs it installed from the "Installed update" lines.
+var updateScript = template.Must(template.New("WindowsUpdate").Parse(`
+$ErrorActionPreference = 'Stop'
+$ProgressPreference = 'SilentlyContinue'
+
+$searchCriteria = {{.SearchCriteria}}
+$categories = @({{range $i, $c := .Categories}}{{if $i}}, {{end}}{{$c}}{{end}})
+$includeKBs = @({{range $i, $kb := .IncludeKBs}}{{if $i}}, {{end}}{{$kb}}{{end}})
+$excludeKBs = @({{range $i, $kb := .ExcludeKBs}}{{if $i}}, {{end}}{{$kb}}{{end}})
+$updateLimit = {{.UpdateLimit}}
+
+if ((New-Object -ComObject 'Microsoft.Update.SystemInfo').RebootRequired) {
+    Write-Output 'A restart is pending.'
+    exit 101
+}
+
+$session = New-Object -ComObject 'Microsoft.Update.Session'
+$session.ClientApplicationID = 'packer-windows-update'
+Write-Output "Searching for Windows updates ($searchCriteria)..."
+$result = $session.CreateUpdateSearcher().Search($searchCriteria)
+
+$updates = New-Object -ComObject 'Microsoft.Update.UpdateColl'
+foreach ($update in $result.Updates) {
+    $kbs = @($update.KBArticleIDs | ForEach-Object { "KB$_" })
+    if (@($kbs | Where-Object { $excludeKBs -contains $_ }).Count -gt 0) {
+        Write-Output "Skipping excluded update: $($update.Title)"
+        continue
+    }
+    if ($includeKBs.Count -gt 0 -and @($kbs | Where-Object { $includeKBs -contains $_ }).Count -eq 0) {
+        continue
+    }
+    if ($categories.Count -gt 0 -and @($update.Categories | Where-Object { $categories -contains $_.Name }).Count -eq 0) {
+        continue
+    }
+    if ($updates.Count -ge $updateLimit) {
+        Write-Output "Reached the update limit of $updateLimit updates."
+        break
+    }
+    if (!$update.EulaAccepted) {
+        $update.AcceptEula() | Out-Null
+    }
+    Write-Output "Found update: $($update.Title)"
+    $updates.Add($update) | Out-Null
+}
+
+if ($updates.Count -eq 0) {
+    Write-Output 'No Windows updates to install.'
+    exit 0
+}
+
+Write-Output "Downloading $($updates.Count) updates..."
+$downloader = $session.CreateUpdateDownloader()
+$downloader.Updates = $updates
+$downloader.Download() | Out-Null
+
+Write-Output "Installing $($updates.Count) updates..."
+$installer = $session.CreateUpdateInstaller()
+$installer.Updates = $updates
+$installResult = $installer.Install()
+
+$installed = 0
+for ($i = 0; $i -lt $updates.Count; $i++) {
+    $update = $updates.Item($i)
+    # 2 is orcSucceeded and 3 orcSucceededWithErrors
+    $code = $installResult.GetUpdateResult($i).ResultCode
+    if ($code -eq 2 -or $code -eq 3) {
+        $installed++
+        $kbs = @($update.KBArticleIDs)
+        if ($kbs.Count -gt 0) {
+            Write-Output "Installed update KB$($kbs[0]): $($update.Title)"
+        } else {
+            Write-Output "Installed update: $($update.Title)"
+        }
+    } else {
+        Write-Output "Failed to install update (result code $code): $($update.Title)"
+    }
+}
+
+if ($installResult.RebootRequired) {
+    exit 101
+}
+if ($installed -eq 0) {
+    Write-Output 'None of the updates could be installed.'
+    exit 1
+}
+exit 102
+`))
@@ -43,12 +43,18 @@ func main() {
 		log.Fatalf("Failed to discover post processors: %s", err)
 	}
 
+	datasources, err := discoverDatasources()
+	if err != nil {
+		log.Fatalf("Failed to discover data sources: %s", err)
+	}
+
 	// Do some simple code generation and templating
 	output := source
-	output = strings.Replace(output, "IMPORTS", makeImports(builders, provisioners, postProcessors), 1)
+	output = strings.Replace(output, "IMPORTS", makeImports(builders, provisioners, postProcessors, datasources), 1)
 	output = strings.Replace(output, "BUILDERS", makeMap("Builders", "Builder", builders), 1)
 	output = strings.Replace(output, "PROVISIONERS", makeMap("Provisioners", "Provisioner", provisioners), 1)
 	output = strings.Replace(output, "POSTPROCESSORS", makeMap("PostProcessors", "PostProcessor", postProcessors), 1)
+	output = strings.Replace(output, "DATASOURCES", makeMap("Datasources", "Datasource", datasources), 1)
 
 	// TODO sort the lists of plugins so we are not subjected to random OS ordering of the plugin lists
 	// TODO format the file
@@ -105,7 +111,7 @@ func makeMap(varName, varType string, items []plugin) string {
 	return output
 }
 
-func makeImports(builders, provisioners, postProcessors []plugin) string {
+func makeImports(builders, provisioners, postProcessors, datasources []plugin) string {
 	plugins := []string{}
 
 	for _, builder := range builders {
@@ -120,6 +126,10 @@ func makeImports(builders, provisioners, postProcessors []plugin) string {
 		plugins = append(plugins, fmt.Sprintf("\t%s \"github.com/hashicorp/packer/%s\"\n", postProcessor.ImportName, filepath.ToSlash(postProcessor.Path)))
 	}
 
+	for _, datasource := range datasources {
+		plugins = append(plugins, fmt.Sprintf("\t%s \"github.com/hashicorp/packer/%s\"\n", datasource.ImportName, filepath.ToSlash(datasource.Path)))
+	}
+
 	// Make things pretty
 	sort.Strings(plugins)
 
@@ -241,6 +251,12 @@ func discoverPostProcessors() ([]plugin, error) {
 	return discoverTypesInPath(path, typeID)
 }
 
+func discoverDatasources() ([]plugin, error) {
+	path := "./datasource"
+	typeID := "Datasource"
+	return discoverTypesInPath(path, typeID)
+}
+
 const source = `//
 // This file is automatically generated by scripts/generate-plugins.go -- Do not edit!
 //
@@ -269,6 +285,8 @@ PROVISIONERS
 
 POSTPROCESSORS
 
+DATASOURCES
+
 var pluginRegexp = regexp.MustCompile("packer-(builder|post-processor|provisioner)-(.+)")
 
 func (c *PluginCommand) Run(args []string) int {
@@ -19,9 +19,13 @@ export default [
               'provisioner',
               'post-processor',
               'post-processors',
+              'output',
             ],
           },
+          'data',
+          'limit',
           'locals',
+          'module',
           'source',
           'variable',
           'packer',
@@ -63,6 +67,8 @@ export default [
               'join',
               'lower',
               'replace',
+              'regex',
+              'regexall',
               'regex_replace',
               'split',
               'strrev',
@@ -125,6 +131,7 @@ export default [
               'fileexists',
               'fileset',
               'pathexpand',
+              'templatefile',
             ],
           },
           {
@@ -170,7 +177,7 @@ export default [
   'terminology',
   {
     category: 'commands',
-    content: ['build', 'console', 'fix', 'fmt', 'inspect', 'validate', 'hcl2_upgrade'],
+    content: ['build', 'cache', 'console', 'destroy', 'fix', 'fmt', 'init', 'inspect', 'validate', 'hcl2_upgrade'],
   },
   {
     category: 'templates',
@@ -206,6 +213,7 @@ export default [
       'hyperone',
       { category: 'hyperv', content: ['iso', 'vmcx'] },
       'linode',
+      'linux-chroot',
       'lxc',
       'lxd',
       'ncloud',
@@ -252,13 +260,16 @@ export default [
       'file',
       'inspec',
       'powershell',
+      'powershell-dsc',
       'puppet-masterless',
       'puppet-server',
       'salt-masterless',
       'shell',
       'shell-local',
+      'verify',
       'windows-shell',
       'windows-restart',
+      'windows-update',
       'custom',
       'community-supported',
     ],
@@ -268,7 +279,9 @@ export default [
     content: [
       'alicloud-import',
       'amazon-import',
+      'artifact-upload',
       'artifice',
+      'azure-compute-gallery',
       'compress',
       'checksum',
       'digitalocean-import',
@@ -280,6 +293,7 @@ export default [
       'googlecompute-export',
       'googlecompute-import',
       'manifest',
+      'ova',
       'shell-local',
       'ucloud-import',
       'vagrant',
@@ -291,6 +305,10 @@ export default [
       'community-supported',
     ],
   },
+  {
+    category: 'datasources',
+    content: ['file', 'http', 'vault'],
+  },
   '----------',
   'install',
   '----------',